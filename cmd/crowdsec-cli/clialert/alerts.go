@@ -139,10 +139,13 @@ func (cli *cliAlerts) displayOneAlert(alert *models.Alert, withDetail bool) erro
  - Begin        : {{.StartAt}}
  - End          : {{.StopAt}}
  - UUID         : {{.UUID}}
+{{- if .Labels}}
+ - Labels       : {{join .Labels ", "}}
+{{- end}}
 
 `
 
-	tmpl, err := template.New("alert").Parse(alertTemplate)
+	tmpl, err := template.New("alert").Funcs(template.FuncMap{"join": strings.Join}).Parse(alertTemplate)
 	if err != nil {
 		return err
 	}
@@ -234,6 +237,7 @@ func (cli *cliAlerts) NewCommand() *cobra.Command {
 	cmd.AddCommand(cli.newInspectCmd())
 	cmd.AddCommand(cli.newFlushCmd())
 	cmd.AddCommand(cli.newDeleteCmd())
+	cmd.AddCommand(cli.newExportCmd())
 
 	return cmd
 }
@@ -392,7 +396,9 @@ func (cli *cliAlerts) newDeleteCmd() *cobra.Command {
 /!\ This command can be used only on the same machine than the local API.`,
 		Example: `cscli alerts delete --ip 1.2.3.4
 cscli alerts delete --range 1.2.3.0/24
-cscli alerts delete -s crowdsecurity/ssh-bf"`,
+cscli alerts delete -s crowdsecurity/ssh-bf"
+cscli alerts delete --filter-expression 'Alert.Source.Cn == "FR" && Alert.GetScenario() startsWith "crowdsecurity/http-"'
+cscli alerts delete --range 1.2.3.0/24 --filter-expression 'date(Alert.CreatedAt) < now() - duration("720h")'`,
 		DisableAutoGenTag: true,
 		Aliases:           []string{"remove"},
 		Args:              args.NoArgs,
@@ -402,7 +408,7 @@ cscli alerts delete -s crowdsecurity/ssh-bf"`,
 			}
 			if delFilter.ScopeEquals == "" && delFilter.ValueEquals == "" &&
 				delFilter.ScenarioEquals == "" && delFilter.IPEquals == "" &&
-				delFilter.RangeEquals == "" && delAlertByID == "" {
+				delFilter.RangeEquals == "" && delFilter.Expression == "" && delAlertByID == "" {
 				_ = cmd.Usage()
 				return errors.New("at least one filter or --all must be specified")
 			}
@@ -421,6 +427,7 @@ cscli alerts delete -s crowdsecurity/ssh-bf"`,
 	flags.StringVarP(&delFilter.ScenarioEquals, "scenario", "s", "", "the scenario (ie. crowdsecurity/ssh-bf)")
 	flags.StringVarP(&delFilter.IPEquals, "ip", "i", "", "Source ip (shorthand for --scope ip --value <IP>)")
 	flags.StringVarP(&delFilter.RangeEquals, "range", "r", "", "Range source ip (shorthand for --scope range --value <RANGE>)")
+	flags.StringVar(&delFilter.Expression, "filter-expression", "", "delete only the alerts for which this expression is true (over Alert, like the profile filters), evaluated by the local API")
 	flags.StringVar(&delAlertByID, "id", "", "alert ID")
 	flags.BoolVarP(&deleteAll, "all", "a", false, "delete all alerts")
 	flags.BoolVar(contained, "contained", false, "query decisions contained by range")
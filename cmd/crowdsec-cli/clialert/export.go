@@ -0,0 +1,541 @@
+package clialert
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/crowdsecurity/go-cs-lib/cstime"
+	"github.com/crowdsecurity/go-cs-lib/ptr"
+	"github.com/crowdsecurity/go-cs-lib/version"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
+	"github.com/crowdsecurity/crowdsec/pkg/apiclient"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+)
+
+const (
+	ecsVersion = "8.11.0"
+	// number of alerts requested at each poll of --follow
+	followBatchSize = 500
+)
+
+// alertRenderer writes an alert on a single line.
+type alertRenderer func(w io.Writer, alert *models.Alert) error
+
+var alertRenderers = map[string]alertRenderer{
+	"ecs": renderECS,
+	"cef": renderCEF,
+}
+
+// alertTime returns the time of the alert: when it was received by the local API, or when it stopped.
+func alertTime(alert *models.Alert) time.Time {
+	for _, s := range []string{alert.CreatedAt, ptr.OrEmpty(alert.StopAt), ptr.OrEmpty(alert.StartAt)} {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t.UTC()
+		}
+	}
+
+	return time.Time{}
+}
+
+func alertMeta(alert *models.Alert) map[string]string {
+	ret := map[string]string{}
+
+	for _, m := range alert.Meta {
+		if m != nil {
+			ret[m.Key] = m.Value
+		}
+	}
+
+	return ret
+}
+
+type ecsDocument struct {
+	Timestamp string         `json:"@timestamp"`
+	ECS       map[string]any `json:"ecs"`
+	Event     ecsEvent       `json:"event"`
+	Message   string         `json:"message,omitempty"`
+	Rule      ecsRule        `json:"rule"`
+	Source    *ecsSource     `json:"source,omitempty"`
+	Observer  ecsObserver    `json:"observer"`
+	Tags      []string       `json:"tags,omitempty"`
+	CrowdSec  ecsCrowdSec    `json:"crowdsec"`
+}
+
+type ecsEvent struct {
+	Kind     string   `json:"kind"`
+	Category []string `json:"category"`
+	Type     []string `json:"type"`
+	Module   string   `json:"module"`
+	Dataset  string   `json:"dataset"`
+	ID       string   `json:"id,omitempty"`
+	Created  string   `json:"created,omitempty"`
+	Start    string   `json:"start,omitempty"`
+	End      string   `json:"end,omitempty"`
+	Action   string   `json:"action,omitempty"`
+}
+
+type ecsRule struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Hash    string `json:"hash,omitempty"`
+}
+
+type ecsSource struct {
+	IP      string  `json:"ip,omitempty"`
+	Address string  `json:"address,omitempty"`
+	AS      *ecsAS  `json:"as,omitempty"`
+	Geo     *ecsGeo `json:"geo,omitempty"`
+}
+
+type ecsAS struct {
+	Number       int64          `json:"number,omitempty"`
+	Organization map[string]any `json:"organization,omitempty"`
+}
+
+type ecsGeo struct {
+	CountryISOCode string             `json:"country_iso_code,omitempty"`
+	Location       map[string]float32 `json:"location,omitempty"`
+}
+
+type ecsObserver struct {
+	Name    string `json:"name,omitempty"`
+	Type    string `json:"type"`
+	Vendor  string `json:"vendor"`
+	Product string `json:"product"`
+	Version string `json:"version"`
+}
+
+// ecsCrowdSec has the fields without an equivalent in ECS.
+type ecsCrowdSec struct {
+	AlertID     int64             `json:"alert_id,omitempty"`
+	Kind        string            `json:"kind,omitempty"`
+	EventsCount int32             `json:"events_count"`
+	Capacity    int32             `json:"capacity"`
+	Leakspeed   string            `json:"leakspeed,omitempty"`
+	Simulated   bool              `json:"simulated"`
+	Remediation bool              `json:"remediation"`
+	Scope       string            `json:"scope,omitempty"`
+	Value       string            `json:"value,omitempty"`
+	Range       string            `json:"range,omitempty"`
+	Decisions   []ecsDecision     `json:"decisions,omitempty"`
+	Meta        map[string]string `json:"meta,omitempty"`
+}
+
+type ecsDecision struct {
+	Type     string `json:"type"`
+	Scope    string `json:"scope"`
+	Value    string `json:"value"`
+	Duration string `json:"duration,omitempty"`
+	Origin   string `json:"origin,omitempty"`
+	Until    string `json:"until,omitempty"`
+}
+
+func newECSDocument(alert *models.Alert) ecsDocument {
+	doc := ecsDocument{
+		ECS: map[string]any{"version": ecsVersion},
+		Event: ecsEvent{
+			Kind:     "alert",
+			Category: []string{"intrusion_detection"},
+			Type:     []string{"info"},
+			Module:   "crowdsec",
+			Dataset:  "crowdsec.alerts",
+			ID:       alert.UUID,
+			Created:  alert.CreatedAt,
+			Start:    ptr.OrEmpty(alert.StartAt),
+			End:      ptr.OrEmpty(alert.StopAt),
+		},
+		Message: ptr.OrEmpty(alert.Message),
+		Rule: ecsRule{
+			Name:    ptr.OrEmpty(alert.Scenario),
+			Version: ptr.OrEmpty(alert.ScenarioVersion),
+			Hash:    ptr.OrEmpty(alert.ScenarioHash),
+		},
+		Observer: ecsObserver{
+			Name:    alert.MachineID,
+			Type:    "ids",
+			Vendor:  "CrowdSec",
+			Product: "CrowdSec",
+			Version: version.String(),
+		},
+		Tags: alert.Labels,
+		CrowdSec: ecsCrowdSec{
+			AlertID:     alert.ID,
+			Kind:        alert.Kind,
+			EventsCount: ptr.OrEmpty(alert.EventsCount),
+			Capacity:    ptr.OrEmpty(alert.Capacity),
+			Leakspeed:   ptr.OrEmpty(alert.Leakspeed),
+			Simulated:   ptr.OrEmpty(alert.Simulated),
+			Remediation: alert.Remediation,
+		},
+	}
+
+	if t := alertTime(alert); !t.IsZero() {
+		doc.Timestamp = t.Format(time.RFC3339Nano)
+	}
+
+	if meta := alertMeta(alert); len(meta) > 0 {
+		doc.CrowdSec.Meta = meta
+	}
+
+	for _, d := range alert.Decisions {
+		doc.CrowdSec.Decisions = append(doc.CrowdSec.Decisions, ecsDecision{
+			Type:     ptr.OrEmpty(d.Type),
+			Scope:    ptr.OrEmpty(d.Scope),
+			Value:    ptr.OrEmpty(d.Value),
+			Duration: ptr.OrEmpty(d.Duration),
+			Origin:   ptr.OrEmpty(d.Origin),
+			Until:    d.Until,
+		})
+	}
+
+	if len(alert.Decisions) > 0 {
+		doc.Event.Type = []string{"denied"}
+		doc.Event.Action = ptr.OrEmpty(alert.Decisions[0].Type)
+	}
+
+	src := alert.Source
+	if src == nil {
+		return doc
+	}
+
+	doc.CrowdSec.Scope = ptr.OrEmpty(src.Scope)
+	doc.CrowdSec.Value = ptr.OrEmpty(src.Value)
+	doc.CrowdSec.Range = src.Range
+
+	doc.Source = &ecsSource{
+		IP:      src.IP,
+		Address: cmp.Or(src.IP, ptr.OrEmpty(src.Value)),
+	}
+
+	asn, _ := strconv.ParseInt(src.AsNumber, 10, 64)
+	if asn != 0 || src.AsName != "" {
+		doc.Source.AS = &ecsAS{Number: asn}
+		if src.AsName != "" {
+			doc.Source.AS.Organization = map[string]any{"name": src.AsName}
+		}
+	}
+
+	if src.Cn != "" || src.Latitude != 0 || src.Longitude != 0 {
+		doc.Source.Geo = &ecsGeo{CountryISOCode: src.Cn}
+		if src.Latitude != 0 || src.Longitude != 0 {
+			doc.Source.Geo.Location = map[string]float32{"lat": src.Latitude, "lon": src.Longitude}
+		}
+	}
+
+	return doc
+}
+
+// renderECS writes the alert as an Elastic Common Schema document, on a single line.
+func renderECS(w io.Writer, alert *models.Alert) error {
+	return json.NewEncoder(w).Encode(newECSDocument(alert))
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`)
+)
+
+// renderCEF writes the alert as an ArcSight Common Event Format line.
+func renderCEF(w io.Writer, alert *models.Alert) error {
+	scenario := ptr.OrEmpty(alert.Scenario)
+
+	// the alerts with a remediation are more severe than the ones only reported
+	severity := 3
+	if len(alert.Decisions) > 0 {
+		severity = 7
+	}
+
+	ext := [][2]string{}
+
+	add := func(key string, value string) {
+		if value != "" {
+			ext = append(ext, [2]string{key, value})
+		}
+	}
+
+	millis := func(s string) string {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return ""
+		}
+
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+
+	if t := alertTime(alert); !t.IsZero() {
+		add("rt", strconv.FormatInt(t.UnixMilli(), 10))
+	}
+
+	add("start", millis(ptr.OrEmpty(alert.StartAt)))
+	add("end", millis(ptr.OrEmpty(alert.StopAt)))
+	add("msg", ptr.OrEmpty(alert.Message))
+	add("cnt", strconv.Itoa(int(ptr.OrEmpty(alert.EventsCount))))
+	add("dvchost", alert.MachineID)
+
+	if alert.ID != 0 {
+		add("externalId", strconv.FormatInt(alert.ID, 10))
+	}
+
+	if src := alert.Source; src != nil {
+		add("src", src.IP)
+		add("cs1Label", "scope")
+		add("cs1", ptr.OrEmpty(src.Scope))
+		add("cs2Label", "value")
+		add("cs2", ptr.OrEmpty(src.Value))
+
+		if src.Cn != "" {
+			add("cs3Label", "country")
+			add("cs3", src.Cn)
+		}
+
+		if src.AsNumber != "" {
+			add("cn1Label", "asn")
+			add("cn1", src.AsNumber)
+		}
+
+		if src.AsName != "" {
+			add("cs4Label", "as_name")
+			add("cs4", src.AsName)
+		}
+	}
+
+	if len(alert.Decisions) > 0 {
+		d := alert.Decisions[0]
+		add("act", ptr.OrEmpty(d.Type))
+		add("cs5Label", "decision_duration")
+		add("cs5", ptr.OrEmpty(d.Duration))
+		add("cs6Label", "decision_origin")
+		add("cs6", ptr.OrEmpty(d.Origin))
+	}
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "CEF:0|CrowdSec|CrowdSec|%s|%s|%s|%d|",
+		cefHeaderEscaper.Replace(version.String()),
+		cefHeaderEscaper.Replace(scenario),
+		cefHeaderEscaper.Replace(cmp.Or(ptr.OrEmpty(alert.Message), scenario)),
+		severity)
+
+	for i, kv := range ext {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+
+		sb.WriteString(kv[0] + "=" + cefExtensionEscaper.Replace(kv[1]))
+	}
+
+	sb.WriteByte('\n')
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// followAlerts polls the local API and writes the new alerts, in the order of their IDs.
+func (cli *cliAlerts) followAlerts(ctx context.Context, filter apiclient.AlertsListOpts, interval time.Duration, out io.Writer, render alertRenderer, startID int64) error {
+	// the IDs that were written: the alerts saved at the same time may not be visible in order
+	seen := map[int64]bool{}
+
+	filter.Since = cstime.DurationWithDays(0)
+	filter.Limit = new(followBatchSize)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		alerts, _, err := cli.client.Alerts.List(ctx, filter)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			log.Warningf("unable to list alerts: %s", err)
+
+			continue
+		}
+
+		if len(*alerts) == followBatchSize {
+			log.Warningf("%d alerts received since the last poll, some may be missing: reduce --interval", followBatchSize)
+		}
+
+		minID, err := writeNewAlerts(*alerts, out, render, startID, seen)
+		if err != nil {
+			return err
+		}
+
+		// the older alerts won't be returned anymore
+		maps.DeleteFunc(seen, func(id int64, _ bool) bool { return id < minID })
+	}
+}
+
+// writeNewAlerts writes the alerts that were not seen yet, and returns the lowest ID of the batch.
+func writeNewAlerts(alerts []*models.Alert, out io.Writer, render alertRenderer, startID int64, seen map[int64]bool) (int64, error) {
+	slices.SortFunc(alerts, func(a, b *models.Alert) int { return cmp.Compare(a.ID, b.ID) })
+
+	minID := int64(0)
+	if len(alerts) > 0 {
+		minID = alerts[0].ID
+	}
+
+	for _, alert := range alerts {
+		if alert.ID <= startID || seen[alert.ID] {
+			continue
+		}
+
+		if err := render(out, alert); err != nil {
+			return 0, fmt.Errorf("writing the export: %w", err)
+		}
+
+		seen[alert.ID] = true
+	}
+
+	return minID, nil
+}
+
+func (cli *cliAlerts) export(ctx context.Context, filter apiclient.AlertsListOpts, format string, output string, follow bool, interval time.Duration) error {
+	render, ok := alertRenderers[format]
+	if !ok {
+		return fmt.Errorf("invalid format '%s', expected one of %s", format, strings.Join(slices.Sorted(maps.Keys(alertRenderers)), ", "))
+	}
+
+	if follow && interval <= 0 {
+		return fmt.Errorf("invalid interval %s", interval)
+	}
+
+	var err error
+
+	filter.ScopeEquals, err = SanitizeScope(filter.ScopeEquals, filter.IPEquals, filter.RangeEquals)
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+
+	if output != "" && output != "-" {
+		// appended to, for the collectors reading the file
+		f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		out = f
+	}
+
+	// without --since, --follow only writes the new alerts
+	dump := !follow || filter.Since != cstime.DurationWithDays(0)
+
+	query := filter
+	if !dump {
+		query.Limit = new(1)
+	} else {
+		query.Limit = new(0)
+	}
+
+	alerts, _, err := cli.client.Alerts.List(ctx, query)
+	if err != nil {
+		return fmt.Errorf("unable to list alerts: %w", err)
+	}
+
+	startID := int64(0)
+
+	for _, alert := range *alerts {
+		startID = max(startID, alert.ID)
+	}
+
+	if dump {
+		if _, err := writeNewAlerts(*alerts, out, render, 0, map[int64]bool{}); err != nil {
+			return err
+		}
+	}
+
+	if follow {
+		return cli.followAlerts(ctx, filter, interval, out, render, startID)
+	}
+
+	if out != os.Stdout {
+		if err := out.Close(); err != nil {
+			return err
+		}
+
+		log.Infof("%d alert(s) exported to %s", len(*alerts), output)
+	}
+
+	return nil
+}
+
+func (cli *cliAlerts) newExportCmd() *cobra.Command {
+	filter := apiclient.AlertsListOpts{
+		IncludeCAPI: new(bool),
+	}
+
+	var (
+		format   string
+		output   string
+		follow   bool
+		interval time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export [options]",
+		Short: "Export the alerts for a SIEM",
+		Long: `Export the alerts, one per line, in a format that SIEMs ingest without a custom mapping:
+
+ecs : Elastic Common Schema JSON documents. The fields without an ECS equivalent are in "crowdsec".
+cef : ArcSight Common Event Format lines, with the scenario as the signature ID.
+
+With --follow, the new alerts are written as they are received by the local API. The existing
+alerts are written first only if --since is set.`,
+		Example: `cscli alerts export --format ecs --since 24h -o /var/log/crowdsec-alerts.json
+cscli alerts export --format cef --follow | logger -t crowdsec -n siem.example.com
+cscli alerts export --format ecs --follow --since 1h --scenario crowdsecurity/ssh-bf`,
+		Args:              args.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cli.export(cmd.Context(), filter, format, output, follow, interval)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.StringVarP(&format, "format", "f", "", "Output format: ecs, cef")
+	flags.StringVarP(&output, "output", "o", "", "Output file, appended to (default: standard output)")
+	flags.BoolVar(&follow, "follow", false, "Keep writing the new alerts")
+	flags.DurationVar(&interval, "interval", 10*time.Second, "How often to look for new alerts, with --follow")
+	flags.BoolVarP(filter.IncludeCAPI, "all", "a", false, "Include decisions from Central API")
+	flags.Var(&filter.Since, "since", "restrict to alerts newer than since (ie. 4h, 30d)")
+	flags.Var(&filter.Until, "until", "restrict to alerts older than until (ie. 4h, 30d)")
+	flags.StringVarP(&filter.IPEquals, "ip", "i", "", "restrict to alerts from this source ip (shorthand for --scope ip --value <IP>)")
+	flags.StringVarP(&filter.RangeEquals, "range", "r", "", "restrict to alerts from this range (shorthand for --scope range --value <RANGE/X>)")
+	flags.StringVarP(&filter.ScenarioEquals, "scenario", "s", "", "the scenario (ie. crowdsecurity/ssh-bf)")
+	flags.StringVar(&filter.TypeEquals, "type", "", "restrict to alerts with given decision type (ie. ban, captcha)")
+	flags.StringVar(&filter.ScopeEquals, "scope", "", "restrict to alerts of this scope (ie. ip,range)")
+	flags.StringVarP(&filter.ValueEquals, "value", "v", "", "the value to match for in the specified scope")
+	flags.StringVar(&filter.OriginEquals, "origin", "", fmt.Sprintf("the value to match for the specified origin (%s ...)", strings.Join(types.GetOrigins(), ",")))
+	flags.StringVar(&filter.Kind, "kind", "", fmt.Sprintf("the value to match for the specified kind (%s ...)", strings.Join(types.GetAlertKinds(), ",")))
+
+	_ = cmd.MarkFlagRequired("format")
+
+	return cmd
+}
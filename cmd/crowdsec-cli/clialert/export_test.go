@@ -0,0 +1,176 @@
+package clialert
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/version"
+
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+)
+
+func testAlert() *models.Alert {
+	return &models.Alert{
+		ID:              42,
+		UUID:            "1b6d2d5a-0c3c-4a6e-9d1c-2f5c0c1e7c11",
+		CreatedAt:       "2026-10-18T10:00:05Z",
+		MachineID:       "web1",
+		Scenario:        new("crowdsecurity/ssh-bf"),
+		ScenarioVersion: new("0.3"),
+		ScenarioHash:    new("abc"),
+		Message:         new("Ip 192.0.2.10 performed 'crowdsecurity/ssh-bf' (6 events over 2s)"),
+		StartAt:         new("2026-10-18T10:00:00Z"),
+		StopAt:          new("2026-10-18T10:00:02Z"),
+		EventsCount:     new(int32(6)),
+		Capacity:        new(int32(5)),
+		Leakspeed:       new("10s"),
+		Simulated:       new(false),
+		Remediation:     true,
+		Labels:          []string{"ssh"},
+		Meta:            models.Meta{{Key: "target_user", Value: "root"}},
+		Source: &models.Source{
+			Scope:     new("Ip"),
+			Value:     new("192.0.2.10"),
+			IP:        "192.0.2.10",
+			Range:     "192.0.2.0/24",
+			AsNumber:  "64496",
+			AsName:    "Example|Net",
+			Cn:        "FR",
+			Latitude:  48.85,
+			Longitude: 2.35,
+		},
+		Decisions: []*models.Decision{{
+			Type:     new("ban"),
+			Scope:    new("Ip"),
+			Value:    new("192.0.2.10"),
+			Duration: new("4h"),
+			Origin:   new("crowdsec"),
+		}},
+	}
+}
+
+func TestRenderECS(t *testing.T) {
+	buf := bytes.Buffer{}
+	require.NoError(t, renderECS(&buf, testAlert()))
+
+	out := buf.String()
+	assert.Equal(t, 1, strings.Count(out, "\n"))
+
+	doc := map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Equal(t, "2026-10-18T10:00:05Z", doc["@timestamp"])
+	assert.Equal(t, map[string]any{"version": ecsVersion}, doc["ecs"])
+	assert.Equal(t, map[string]any{
+		"kind":     "alert",
+		"category": []any{"intrusion_detection"},
+		"type":     []any{"denied"},
+		"module":   "crowdsec",
+		"dataset":  "crowdsec.alerts",
+		"id":       "1b6d2d5a-0c3c-4a6e-9d1c-2f5c0c1e7c11",
+		"created":  "2026-10-18T10:00:05Z",
+		"start":    "2026-10-18T10:00:00Z",
+		"end":      "2026-10-18T10:00:02Z",
+		"action":   "ban",
+	}, doc["event"])
+	assert.Equal(t, map[string]any{"name": "crowdsecurity/ssh-bf", "version": "0.3", "hash": "abc"}, doc["rule"])
+	assert.JSONEq(t, `{
+		"ip": "192.0.2.10",
+		"address": "192.0.2.10",
+		"as": {"number": 64496, "organization": {"name": "Example|Net"}},
+		"geo": {"country_iso_code": "FR", "location": {"lat": 48.85, "lon": 2.35}}
+	}`, mustJSON(t, doc["source"]))
+	assert.JSONEq(t, `{
+		"alert_id": 42,
+		"events_count": 6,
+		"capacity": 5,
+		"leakspeed": "10s",
+		"simulated": false,
+		"remediation": true,
+		"scope": "Ip",
+		"value": "192.0.2.10",
+		"range": "192.0.2.0/24",
+		"decisions": [{"type": "ban", "scope": "Ip", "value": "192.0.2.10", "duration": "4h", "origin": "crowdsec"}],
+		"meta": {"target_user": "root"}
+	}`, mustJSON(t, doc["crowdsec"]))
+
+	// an alert without decision or source
+	buf.Reset()
+	require.NoError(t, renderECS(&buf, &models.Alert{Scenario: new("manual")}))
+
+	doc = map[string]any{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.NotContains(t, doc, "source")
+	assert.Equal(t, []any{"info"}, doc["event"].(map[string]any)["type"])
+}
+
+func mustJSON(t *testing.T, v any) string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func TestRenderCEF(t *testing.T) {
+	alert := testAlert()
+	alert.Message = new("line1\nuser=root | sudo")
+
+	buf := bytes.Buffer{}
+	require.NoError(t, renderCEF(&buf, alert))
+
+	expected := "CEF:0|CrowdSec|CrowdSec|" + version.String() + `|crowdsecurity/ssh-bf|line1 user=root \| sudo|7|` +
+		`rt=1792317605000 start=1792317600000 end=1792317602000 msg=line1\nuser\=root | sudo cnt=6 dvchost=web1 externalId=42 ` +
+		`src=192.0.2.10 cs1Label=scope cs1=Ip cs2Label=value cs2=192.0.2.10 cs3Label=country cs3=FR cn1Label=asn cn1=64496 ` +
+		`cs4Label=as_name cs4=Example|Net act=ban cs5Label=decision_duration cs5=4h cs6Label=decision_origin cs6=crowdsec` + "\n"
+
+	assert.Equal(t, expected, buf.String())
+
+	buf.Reset()
+	require.NoError(t, renderCEF(&buf, &models.Alert{Scenario: new("manual")}))
+	assert.Equal(t, "CEF:0|CrowdSec|CrowdSec|"+version.String()+"|manual|manual|3|cnt=0\n", buf.String())
+}
+
+func TestWriteNewAlerts(t *testing.T) {
+	alerts := func(ids ...int64) []*models.Alert {
+		ret := []*models.Alert{}
+		for _, id := range ids {
+			ret = append(ret, &models.Alert{ID: id})
+		}
+
+		return ret
+	}
+
+	var written []int64
+
+	render := func(_ io.Writer, alert *models.Alert) error {
+		written = append(written, alert.ID)
+		return nil
+	}
+
+	seen := map[int64]bool{}
+
+	// the alerts before the start are skipped, the others written in order
+	minID, err := writeNewAlerts(alerts(12, 11, 10, 9), nil, render, 10, seen)
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), minID)
+	assert.Equal(t, []int64{11, 12}, written)
+
+	// 13 was committed after 14
+	written = nil
+	_, err = writeNewAlerts(alerts(14, 12, 11), nil, render, 10, seen)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{14}, written)
+
+	written = nil
+	_, err = writeNewAlerts(alerts(14, 13, 12), nil, render, 10, seen)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{13}, written)
+}
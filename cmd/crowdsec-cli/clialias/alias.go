@@ -0,0 +1,264 @@
+package clialias
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/kballard/go-shellquote"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+)
+
+var validName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+
+type cliAlias struct {
+	cfg csconfig.Getter
+	// path of the file containing the aliases
+	path string
+}
+
+func New(cfg csconfig.Getter, path string) *cliAlias {
+	return &cliAlias{
+		cfg:  cfg,
+		path: path,
+	}
+}
+
+func (cli *cliAlias) NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alias [command]",
+		Short: "Manage cscli aliases",
+		Long: `Manage user-defined shortcuts for cscli commands.
+
+An alias is expanded before the command line is parsed, and any additional
+argument is appended to its definition.`,
+		Example: `cscli alias add banlist 'decisions list -o json --origin cscli'
+cscli banlist --ip 1.2.3.4
+cscli alias list
+cscli alias delete banlist`,
+		DisableAutoGenTag: true,
+		Args:              args.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	cmd.AddCommand(cli.newAddCmd())
+	cmd.AddCommand(cli.newListCmd())
+	cmd.AddCommand(cli.newDeleteCmd())
+
+	return cmd
+}
+
+func (cli *cliAlias) add(root *cobra.Command, name string, definition string, force bool) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid alias name %q: must start with a letter and contain only letters, digits, '-' or '_'", name)
+	}
+
+	if isCommand(root, name) {
+		return fmt.Errorf("%q is a cscli command, it can't be used as an alias", name)
+	}
+
+	words, err := shellquote.Split(definition)
+	if err != nil {
+		return fmt.Errorf("invalid command %q: %w", definition, err)
+	}
+
+	if len(words) == 0 {
+		return errors.New("empty command")
+	}
+
+	target, _, err := root.Find(words)
+	if err != nil {
+		return fmt.Errorf("invalid command %q: %w", definition, err)
+	}
+
+	if target == root {
+		return fmt.Errorf("invalid command %q: unknown command %q", definition, words[0])
+	}
+
+	aliases, err := csconfig.LoadAliases(cli.path)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := aliases[name]; ok && !force {
+		return fmt.Errorf("alias %q already exists, use --force to replace it", name)
+	}
+
+	aliases[name] = definition
+
+	if err := csconfig.SaveAliases(cli.path, aliases); err != nil {
+		return err
+	}
+
+	log.Infof("alias '%s' added", name)
+
+	return nil
+}
+
+func (cli *cliAlias) newAddCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:               "add [name] [command]",
+		Short:             "Add an alias",
+		Example:           `cscli alias add banlist 'decisions list -o json --origin cscli'`,
+		Args:              args.ExactArgs(2),
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cli.add(cmd.Root(), args[0], args[1], force)
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "Replace the alias if it already exists")
+
+	return cmd
+}
+
+func (cli *cliAlias) list(out io.Writer) error {
+	aliases, err := csconfig.LoadAliases(cli.path)
+	if err != nil {
+		return err
+	}
+
+	names := slices.Sorted(maps.Keys(aliases))
+
+	switch cli.cfg().Cscli.Output {
+	case "human":
+		if len(aliases) == 0 {
+			fmt.Fprintln(out, "No alias defined.")
+			return nil
+		}
+
+		t := cstable.NewLight(out, cli.cfg().Cscli.Color).Writer
+		t.AppendHeader(table.Row{"Name", "Command"})
+
+		for _, name := range names {
+			t.AppendRow(table.Row{name, aliases[name]})
+		}
+
+		fmt.Fprintln(out, t.Render())
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(aliases); err != nil {
+			return fmt.Errorf("failed to serialize: %w", err)
+		}
+	case "raw":
+		csvwriter := csv.NewWriter(out)
+
+		if err := csvwriter.Write([]string{"name", "command"}); err != nil {
+			return fmt.Errorf("failed to write raw header: %w", err)
+		}
+
+		for _, name := range names {
+			if err := csvwriter.Write([]string{name, aliases[name]}); err != nil {
+				return fmt.Errorf("failed to write raw: %w", err)
+			}
+		}
+
+		csvwriter.Flush()
+	}
+
+	return nil
+}
+
+func (cli *cliAlias) newListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "list",
+		Short:             "List aliases",
+		Example:           `cscli alias list`,
+		Args:              args.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return cli.list(os.Stdout)
+		},
+	}
+
+	return cmd
+}
+
+func (cli *cliAlias) delete(names []string, ignoreMissing bool) error {
+	aliases, err := csconfig.LoadAliases(cli.path)
+	if err != nil {
+		return err
+	}
+
+	deleted := []string{}
+
+	for _, name := range names {
+		if _, ok := aliases[name]; !ok {
+			if ignoreMissing {
+				continue
+			}
+
+			return fmt.Errorf("alias %q not found", name)
+		}
+
+		delete(aliases, name)
+		deleted = append(deleted, name)
+	}
+
+	if err := csconfig.SaveAliases(cli.path, aliases); err != nil {
+		return err
+	}
+
+	for _, name := range deleted {
+		log.Infof("alias '%s' deleted", name)
+	}
+
+	return nil
+}
+
+func (cli *cliAlias) validAliasNames(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	aliases, err := csconfig.LoadAliases(cli.path)
+	if err != nil {
+		cobra.CompError("unable to load aliases: " + err.Error())
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	ret := []string{}
+
+	for _, name := range slices.Sorted(maps.Keys(aliases)) {
+		if !slices.Contains(args, name) {
+			ret = append(ret, name)
+		}
+	}
+
+	return ret, cobra.ShellCompDirectiveNoFileComp
+}
+
+func (cli *cliAlias) newDeleteCmd() *cobra.Command {
+	var ignoreMissing bool
+
+	cmd := &cobra.Command{
+		Use:               "delete [name]...",
+		Short:             "Delete one or more aliases",
+		Example:           `cscli alias delete banlist`,
+		Aliases:           []string{"remove"},
+		Args:              args.MinimumNArgs(1),
+		DisableAutoGenTag: true,
+		ValidArgsFunction: cli.validAliasNames,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return cli.delete(args, ignoreMissing)
+		},
+	}
+
+	cmd.Flags().BoolVar(&ignoreMissing, "ignore-missing", false, "Don't print errors if one or more aliases don't exist")
+
+	return cmd
+}
@@ -0,0 +1,124 @@
+package clialias
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/spf13/cobra"
+)
+
+// isCommand returns true if name is a sub-command (or the alias of a sub-command) of root.
+func isCommand(root *cobra.Command, name string) bool {
+	for _, c := range root.Commands() {
+		if c.Name() == name || c.HasAlias(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// flagTakesValue returns true if arg is a persistent flag of root that consumes the next argument.
+func flagTakesValue(root *cobra.Command, arg string) bool {
+	if strings.Contains(arg, "=") {
+		return false
+	}
+
+	flags := root.PersistentFlags()
+
+	var name string
+
+	switch {
+	case strings.HasPrefix(arg, "--"):
+		name = arg[2:]
+	case len(arg) == 2:
+		f := flags.ShorthandLookup(arg[1:])
+		return f != nil && f.NoOptDefVal == ""
+	default:
+		return false
+	}
+
+	f := flags.Lookup(name)
+
+	return f != nil && f.NoOptDefVal == ""
+}
+
+// commandIndex returns the position of the first argument which is not a flag, starting at start, or -1.
+func commandIndex(root *cobra.Command, args []string, start int) int {
+	for i := start; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "--":
+			return -1
+		case strings.HasPrefix(arg, "-"):
+			if flagTakesValue(root, arg) {
+				i++
+			}
+		default:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Expand replaces the alias in the command line with its definition, before cobra parses it.
+// Global flags that come before the alias (i.e. "-c config.yaml") are kept in place,
+// the arguments after it are appended to the expanded command. Built-in commands always
+// take precedence, and aliases are not expanded recursively.
+//
+// The hidden completion commands are handled too, so that the arguments of an alias
+// can be completed like those of the command it stands for.
+func Expand(root *cobra.Command, args []string, aliases map[string]string) ([]string, error) {
+	if len(aliases) == 0 {
+		return args, nil
+	}
+
+	idx := commandIndex(root, args, 0)
+	if idx == -1 {
+		return args, nil
+	}
+
+	if args[idx] == cobra.ShellCompRequestCmd || args[idx] == cobra.ShellCompNoDescRequestCmd {
+		idx = commandIndex(root, args, idx+1)
+		// the last argument is the word being completed, it can be a partial alias name
+		if idx == -1 || idx == len(args)-1 {
+			return args, nil
+		}
+	}
+
+	name := args[idx]
+
+	definition, ok := aliases[name]
+	if !ok || isCommand(root, name) {
+		return args, nil
+	}
+
+	words, err := shellquote.Split(definition)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alias %q: %w", name, err)
+	}
+
+	ret := make([]string, 0, len(args)+len(words))
+	ret = append(ret, args[:idx]...)
+	ret = append(ret, words...)
+	ret = append(ret, args[idx+1:]...)
+
+	return ret, nil
+}
+
+// Completions returns the alias names to be added to the valid arguments of the root command,
+// with their definition as description.
+func Completions(aliases map[string]string) []string {
+	ret := make([]string, 0, len(aliases))
+
+	for _, name := range slices.Sorted(maps.Keys(aliases)) {
+		ret = append(ret, name+"\t"+aliases[name])
+	}
+
+	return ret
+}
@@ -0,0 +1,119 @@
+package clialias
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func testRoot() *cobra.Command {
+	root := &cobra.Command{Use: "cscli"}
+	root.PersistentFlags().StringP("config", "c", "", "")
+	root.PersistentFlags().StringP("output", "o", "", "")
+	root.PersistentFlags().Bool("debug", false, "")
+
+	decisions := &cobra.Command{Use: "decisions", Aliases: []string{"decision"}}
+	decisions.AddCommand(&cobra.Command{Use: "list"})
+	root.AddCommand(decisions)
+
+	return root
+}
+
+func TestExpand(t *testing.T) {
+	aliases := map[string]string{
+		"banlist":   "decisions list -o json --origin cscli",
+		"decision":  "version",
+		"quoted":    `decisions list --scenario "crowdsecurity/ssh bf"`,
+		"malformed": `decisions list --scenario "foo`,
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "no args",
+			args: []string{},
+			want: []string{},
+		},
+		{
+			name: "not an alias",
+			args: []string{"decisions", "list"},
+			want: []string{"decisions", "list"},
+		},
+		{
+			name: "alias",
+			args: []string{"banlist"},
+			want: []string{"decisions", "list", "-o", "json", "--origin", "cscli"},
+		},
+		{
+			name: "alias with extra args",
+			args: []string{"banlist", "--ip", "1.2.3.4"},
+			want: []string{"decisions", "list", "-o", "json", "--origin", "cscli", "--ip", "1.2.3.4"},
+		},
+		{
+			name: "global flags before the alias",
+			args: []string{"-c", "/etc/crowdsec/config.yaml", "--debug", "--output=raw", "banlist"},
+			want: []string{"-c", "/etc/crowdsec/config.yaml", "--debug", "--output=raw", "decisions", "list", "-o", "json", "--origin", "cscli"},
+		},
+		{
+			name: "flag value with the same name as an alias",
+			args: []string{"-c", "banlist", "decisions"},
+			want: []string{"-c", "banlist", "decisions"},
+		},
+		{
+			name: "commands take precedence",
+			args: []string{"decision", "list"},
+			want: []string{"decision", "list"},
+		},
+		{
+			name: "only the command is expanded",
+			args: []string{"decisions", "banlist"},
+			want: []string{"decisions", "banlist"},
+		},
+		{
+			name: "quoted arguments",
+			args: []string{"quoted"},
+			want: []string{"decisions", "list", "--scenario", "crowdsecurity/ssh bf"},
+		},
+		{
+			name:    "malformed alias",
+			args:    []string{"malformed"},
+			wantErr: `invalid alias "malformed": Unterminated double-quoted string`,
+		},
+		{
+			name: "completion of the alias name",
+			args: []string{cobra.ShellCompRequestCmd, "banlist"},
+			want: []string{cobra.ShellCompRequestCmd, "banlist"},
+		},
+		{
+			name: "completion of the alias arguments",
+			args: []string{cobra.ShellCompNoDescRequestCmd, "banlist", "--"},
+			want: []string{cobra.ShellCompNoDescRequestCmd, "decisions", "list", "-o", "json", "--origin", "cscli", "--"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Expand(testRoot(), tc.args, aliases)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+
+			if tc.wantErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	got := Completions(map[string]string{"b": "version", "a": "decisions list"})
+	require.Equal(t, []string{"a\tdecisions list", "b\tversion"}, got)
+}
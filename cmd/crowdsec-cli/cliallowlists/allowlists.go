@@ -31,6 +31,12 @@ import (
 	"github.com/crowdsecurity/crowdsec/pkg/models"
 )
 
+// origins of the allowlists, for "list --origin"
+const (
+	originConsole = "console"
+	originLocal   = "local"
+)
+
 type cliAllowLists struct {
 	cfg csconfig.Getter
 }
@@ -295,12 +301,20 @@ func (*cliAllowLists) create(ctx context.Context, db *database.Client, name stri
 }
 
 func (cli *cliAllowLists) newListCmd() *cobra.Command {
+	var origin string
+
 	cmd := &cobra.Command{
-		Use:     "list",
-		Example: `cscli allowlists list`,
-		Short:   "List all allowlists",
-		Args:    args.NoArgs,
+		Use: "list",
+		Example: `cscli allowlists list
+# only the allowlists managed from the console
+cscli allowlists list --origin console`,
+		Short: "List all allowlists",
+		Args:  args.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			if origin != "" && origin != originConsole && origin != originLocal {
+				return fmt.Errorf("invalid origin %q, must be one of: %s, %s", origin, originConsole, originLocal)
+			}
+
 			cfg := cli.cfg()
 			if err := cfg.LoadAPIClient(); err != nil {
 				return fmt.Errorf("loading api client: %w", err)
@@ -318,20 +332,41 @@ func (cli *cliAllowLists) newListCmd() *cobra.Command {
 				VersionPrefix: "v1",
 			})
 
-			return cli.list(cmd.Context(), client, color.Output)
+			return cli.list(cmd.Context(), client, origin, color.Output)
 		},
 	}
 
+	cmd.Flags().StringVar(&origin, "origin", "", "only list the allowlists of this origin ("+originConsole+" or "+originLocal+")")
+
 	return cmd
 }
 
-func (cli *cliAllowLists) list(ctx context.Context, client *apiclient.ApiClient, out io.Writer) error {
+// filterByOrigin keeps the allowlists managed from the console, or the ones created locally.
+func filterByOrigin(allowlists *models.GetAllowlistsResponse, origin string) *models.GetAllowlistsResponse {
+	if origin == "" {
+		return allowlists
+	}
+
+	ret := models.GetAllowlistsResponse{}
+
+	for _, allowlist := range *allowlists {
+		if allowlist.ConsoleManaged == (origin == originConsole) {
+			ret = append(ret, allowlist)
+		}
+	}
+
+	return &ret
+}
+
+func (cli *cliAllowLists) list(ctx context.Context, client *apiclient.ApiClient, origin string, out io.Writer) error {
 	// not db?
 	allowlists, _, err := client.Allowlists.List(ctx, apiclient.AllowlistListOpts{WithContent: true})
 	if err != nil {
 		return err
 	}
 
+	allowlists = filterByOrigin(allowlists, origin)
+
 	switch cli.cfg().Cscli.Output {
 	case "human":
 		return cli.listHuman(out, allowlists)
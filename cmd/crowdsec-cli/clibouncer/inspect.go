@@ -15,8 +15,52 @@ import (
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/bouncer"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/schema"
 )
 
+// bouncerInspectInfo adds the pull and version history to the bouncer details, for JSON output
+type bouncerInspectInfo struct {
+	bouncerInfo
+	PullHistory    []schema.BouncerPull    `json:"pull_history"`
+	VersionHistory []schema.BouncerVersion `json:"version_history"`
+}
+
+func (cli *cliBouncers) inspectPullsHuman(out io.Writer, bouncer *ent.Bouncer) {
+	if len(bouncer.PullHistory) == 0 {
+		fmt.Fprintln(out, "No pulls recorded for this bouncer")
+		return
+	}
+
+	t := cstable.New(out, cli.cfg().Cscli.Color).Writer
+	t.SetTitle("Last Pulls")
+	t.AppendHeader(table.Row{"Time", "Endpoint", "Startup", "Filters", "New", "Deleted"})
+
+	// most recent first
+	for i := len(bouncer.PullHistory) - 1; i >= 0; i-- {
+		pull := bouncer.PullHistory[i]
+		t.AppendRow(table.Row{pull.Time, pull.Endpoint, pull.Startup, pull.Filters, pull.New, pull.Deleted})
+	}
+
+	fmt.Fprintln(out, t.Render())
+}
+
+func (cli *cliBouncers) inspectVersionsHuman(out io.Writer, bouncer *ent.Bouncer) {
+	if len(bouncer.VersionHistory) == 0 {
+		return
+	}
+
+	t := cstable.New(out, cli.cfg().Cscli.Color).Writer
+	t.SetTitle("Version History")
+	t.AppendHeader(table.Row{"First Seen", "Type", "Version"})
+
+	for i := len(bouncer.VersionHistory) - 1; i >= 0; i-- {
+		v := bouncer.VersionHistory[i]
+		t.AppendRow(table.Row{v.FirstSeen, v.Type, v.Version})
+	}
+
+	fmt.Fprintln(out, t.Render())
+}
+
 func (cli *cliBouncers) inspectHuman(out io.Writer, bouncer *ent.Bouncer) {
 	t := cstable.NewLight(out, cli.cfg().Cscli.Color).Writer
 
@@ -48,7 +92,7 @@ func (cli *cliBouncers) inspectHuman(out io.Writer, bouncer *ent.Bouncer) {
 		t.AppendRow(table.Row{"Feature Flags", ff})
 	}
 
-	fmt.Fprint(out, t.Render())
+	fmt.Fprintln(out, t.Render())
 }
 
 func (cli *cliBouncers) inspect(bouncer *ent.Bouncer) error {
@@ -58,11 +102,19 @@ func (cli *cliBouncers) inspect(bouncer *ent.Bouncer) error {
 	switch outputFormat {
 	case "human":
 		cli.inspectHuman(out, bouncer)
+		cli.inspectPullsHuman(out, bouncer)
+		cli.inspectVersionsHuman(out, bouncer)
 	case "json":
 		enc := json.NewEncoder(out)
 		enc.SetIndent("", "  ")
 
-		if err := enc.Encode(newBouncerInfo(bouncer)); err != nil {
+		info := bouncerInspectInfo{
+			bouncerInfo:    newBouncerInfo(bouncer),
+			PullHistory:    bouncer.PullHistory,
+			VersionHistory: bouncer.VersionHistory,
+		}
+
+		if err := enc.Encode(info); err != nil {
 			return errors.New("failed to serialize")
 		}
 
@@ -0,0 +1,269 @@
+package clibuckets
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/leakybucket"
+)
+
+var (
+	ErrMissingConfig   = errors.New("prometheus section missing, can't inspect buckets")
+	ErrMetricsDisabled = errors.New("prometheus is not enabled, can't inspect buckets")
+)
+
+const bucketsPath = "/debug/buckets"
+
+type cliBuckets struct {
+	cfg csconfig.Getter
+}
+
+func New(cfg csconfig.Getter) *cliBuckets {
+	return &cliBuckets{
+		cfg: cfg,
+	}
+}
+
+func (cli *cliBuckets) NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "buckets [command]",
+		Short: "Inspect the live buckets of the log processor",
+		Long: `Inspect the buckets currently alive in the log processor.
+
+The buckets are read from the prometheus endpoint of crowdsec, which must be enabled.`,
+		DisableAutoGenTag: true,
+		Args:              args.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	cmd.AddCommand(cli.newListCmd())
+
+	return cmd
+}
+
+// bucketsURL returns the introspection endpoint, on the same host as the metrics.
+func bucketsURL(metricsURL string, filter leakybucket.InspectFilter) (string, error) {
+	u, err := url.Parse(metricsURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid prometheus url %q: %w", metricsURL, err)
+	}
+
+	u.Path = bucketsPath
+
+	q := url.Values{}
+
+	if filter.Scenario != "" {
+		q.Set("scenario", filter.Scenario)
+	}
+
+	if filter.GroupBy != "" {
+		q.Set("groupby", filter.GroupBy)
+	}
+
+	if filter.MinFill > 0 {
+		q.Set("min_fill", strconv.FormatFloat(filter.MinFill, 'f', -1, 64))
+	}
+
+	if filter.Limit > 0 {
+		q.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+func fetchBuckets(ctx context.Context, bucketsURL string) ([]leakybucket.BucketInfo, error) {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, bucketsURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching buckets: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("fetching buckets: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var buckets []leakybucket.BucketInfo
+
+	if err := json.NewDecoder(resp.Body).Decode(&buckets); err != nil {
+		return nil, fmt.Errorf("parsing buckets: %w", err)
+	}
+
+	return buckets, nil
+}
+
+func formatSeconds(s float64) string {
+	return time.Duration(s * float64(time.Second)).Round(time.Second).String()
+}
+
+func formatFill(b leakybucket.BucketInfo) string {
+	if b.Capacity <= 0 {
+		return strconv.FormatFloat(b.Fill, 'f', 0, 64)
+	}
+
+	return fmt.Sprintf("%.1f/%d (%.0f%%)", b.Fill, b.Capacity, b.FillRatio*100)
+}
+
+func (cli *cliBuckets) listHuman(out io.Writer, buckets []leakybucket.BucketInfo) {
+	t := cstable.NewLight(out, cli.cfg().Cscli.Color).Writer
+	t.AppendHeader(table.Row{"Scenario", "GroupBy", "Fill", "Events", "Age", "TTL"})
+
+	for _, b := range buckets {
+		t.AppendRow(table.Row{b.Scenario, b.GroupBy, formatFill(b), b.Events, formatSeconds(b.Age), formatSeconds(b.TTL)})
+	}
+
+	fmt.Fprintln(out, t.Render())
+}
+
+func listCSV(out io.Writer, buckets []leakybucket.BucketInfo) error {
+	csvwriter := csv.NewWriter(out)
+
+	header := []string{"scenario", "type", "groupby", "mapkey", "capacity", "fill", "fill_ratio", "events", "first_event", "last_event", "age_seconds", "ttl_seconds"}
+	if err := csvwriter.Write(header); err != nil {
+		return fmt.Errorf("failed to write raw header: %w", err)
+	}
+
+	for _, b := range buckets {
+		row := []string{
+			b.Scenario,
+			b.Type,
+			b.GroupBy,
+			b.Mapkey,
+			strconv.Itoa(b.Capacity),
+			strconv.FormatFloat(b.Fill, 'f', 2, 64),
+			strconv.FormatFloat(b.FillRatio, 'f', 2, 64),
+			strconv.Itoa(b.Events),
+			b.FirstEvent.Format(time.RFC3339),
+			b.LastEvent.Format(time.RFC3339),
+			strconv.FormatFloat(b.Age, 'f', 0, 64),
+			strconv.FormatFloat(b.TTL, 'f', 0, 64),
+		}
+
+		if err := csvwriter.Write(row); err != nil {
+			return fmt.Errorf("failed to write raw: %w", err)
+		}
+	}
+
+	csvwriter.Flush()
+
+	return nil
+}
+
+func (cli *cliBuckets) list(ctx context.Context, out io.Writer, metricsURL string, filter leakybucket.InspectFilter) error {
+	cfg := cli.cfg()
+
+	if metricsURL == "" {
+		if cfg.Prometheus == nil {
+			return ErrMissingConfig
+		}
+
+		if !cfg.Prometheus.Enabled {
+			return ErrMetricsDisabled
+		}
+
+		metricsURL = cfg.Cscli.PrometheusUrl
+	}
+
+	if filter.MinFill < 0 || filter.MinFill > 1 {
+		return errors.New("--min-fill must be between 0 and 1")
+	}
+
+	u, err := bucketsURL(metricsURL, filter)
+	if err != nil {
+		return err
+	}
+
+	buckets, err := fetchBuckets(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	switch cfg.Cscli.Output {
+	case "human":
+		if len(buckets) == 0 {
+			fmt.Fprintln(out, "No live bucket.")
+			return nil
+		}
+
+		cli.listHuman(out, buckets)
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(buckets); err != nil {
+			return fmt.Errorf("failed to serialize: %w", err)
+		}
+	case "raw":
+		return listCSV(out, buckets)
+	}
+
+	return nil
+}
+
+func (cli *cliBuckets) newListCmd() *cobra.Command {
+	var (
+		metricsURL string
+		filter     leakybucket.InspectFilter
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the live buckets, the fullest first",
+		Long: `List the live buckets, the fullest first.
+
+The fill level of a leaky bucket is the number of events it holds after leaking,
+compared to its capacity: the bucket overflows when it's full. Counters have no
+capacity, their fill level is the number of events they hold.
+The TTL is the time left before the bucket expires if it receives no more events.`,
+		Example: `cscli buckets list
+# buckets that are at least 80% full
+cscli buckets list --min-fill 0.8
+cscli buckets list --scenario 'crowdsecurity/ssh-*' --limit 10
+cscli buckets list --groupby 192.168.1.1 -o json
+# connect to a different url
+cscli buckets list --url http://lapi.local:6060/metrics`,
+		Args:              args.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cli.list(cmd.Context(), color.Output, metricsURL, filter)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&metricsURL, "url", "u", "", "Metrics url (http://<ip>:<port>/metrics)")
+	flags.StringVarP(&filter.Scenario, "scenario", "s", "", "only show the buckets of a scenario (accepts glob patterns)")
+	flags.StringVar(&filter.GroupBy, "groupby", "", "only show the buckets with this groupby value (ie. an IP)")
+	flags.Float64Var(&filter.MinFill, "min-fill", 0, "only show the buckets filled at least to this ratio (0 to 1)")
+	flags.IntVarP(&filter.Limit, "limit", "l", 0, "maximum number of buckets to show (0 for no limit)")
+
+	return cmd
+}
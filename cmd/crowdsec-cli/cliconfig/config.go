@@ -22,7 +22,7 @@ func New(cfg csconfig.Getter) *cliConfig {
 func (cli *cliConfig) NewCommand(mergedConfigGetter mergedConfigGetter) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:               "config [command]",
-		Short:             "Allows to view current config",
+		Short:             "Allows to view and change current config",
 		DisableAutoGenTag: true,
 		Args:              args.NoArgs,
 		RunE: func(cmd *cobra.Command, _ []string) error {
@@ -32,6 +32,8 @@ func (cli *cliConfig) NewCommand(mergedConfigGetter mergedConfigGetter) *cobra.C
 
 	cmd.AddCommand(cli.newShowCmd())
 	cmd.AddCommand(cli.newShowYAMLCmd(mergedConfigGetter))
+	cmd.AddCommand(cli.newGetCmd(mergedConfigGetter))
+	cmd.AddCommand(cli.newSetCmd())
 	cmd.AddCommand(cli.newBackupCmd())
 	cmd.AddCommand(cli.newRestoreCmd())
 	cmd.AddCommand(cli.newFeatureFlagsCmd())
@@ -0,0 +1,440 @@
+package cliconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/reload"
+)
+
+// settableKey is a configuration key that can be changed with "cscli config set".
+type settableKey struct {
+	// the YAML tag of the value: !!str, !!bool or !!int
+	tag      string
+	validate func(string) error
+}
+
+func oneOf(values ...string) func(string) error {
+	return func(value string) error {
+		if !slices.Contains(values, value) {
+			return fmt.Errorf("must be one of: %s", strings.Join(values, ", "))
+		}
+
+		return nil
+	}
+}
+
+func validateBool(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return errors.New("must be true or false")
+	}
+
+	return nil
+}
+
+func validatePort(value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return errors.New("must be a port number between 1 and 65535")
+	}
+
+	return nil
+}
+
+var hostnameRe = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?$`)
+
+func validateHost(value string) error {
+	if net.ParseIP(value) == nil && !hostnameRe.MatchString(value) {
+		return errors.New("must be an IP address or a hostname")
+	}
+
+	return nil
+}
+
+func validateListenURI(value string) error {
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return errors.New("must be in the form host:port")
+	}
+
+	if host != "" {
+		if err := validateHost(host); err != nil {
+			return err
+		}
+	}
+
+	return validatePort(port)
+}
+
+func validateLogLevel(value string) error {
+	if _, err := log.ParseLevel(value); err != nil {
+		return errors.New("must be one of: trace, debug, info, warning, error, fatal")
+	}
+
+	return nil
+}
+
+var settableKeys = map[string]settableKey{
+	"common.log_level":       {tag: "!!str", validate: validateLogLevel},
+	"common.log_media":       {tag: "!!str", validate: oneOf("file", "stdout", "syslog")},
+	"api.server.enable":      {tag: "!!bool", validate: validateBool},
+	"api.server.listen_uri":  {tag: "!!str", validate: validateListenURI},
+	"prometheus.enabled":     {tag: "!!bool", validate: validateBool},
+	"prometheus.level":       {tag: "!!str", validate: oneOf("full", "aggregated", "none")},
+	"prometheus.listen_addr": {tag: "!!str", validate: validateHost},
+	"prometheus.listen_port": {tag: "!!int", validate: validatePort},
+	"cscli.output":           {tag: "!!str", validate: oneOf("human", "json", "raw")},
+	"cscli.color":            {tag: "!!str", validate: oneOf("yes", "no", "auto")},
+}
+
+func settableKeyNames() []string {
+	names := make([]string, 0, len(settableKeys))
+	for name := range settableKeys {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+func getSettableKey(key string) (settableKey, error) {
+	sk, ok := settableKeys[key]
+	if !ok {
+		return sk, fmt.Errorf("unsupported key %q, must be one of: %s", key, strings.Join(settableKeyNames(), ", "))
+	}
+
+	return sk, nil
+}
+
+// lookupNode returns the value of a dotted path in a YAML document, or nil if it is not set.
+func lookupNode(doc *yaml.Node, path []string) *yaml.Node {
+	node := doc
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+
+		node = node.Content[0]
+	}
+
+	for _, name := range path {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		var next *yaml.Node
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				next = node.Content[i+1]
+			}
+		}
+
+		if next == nil {
+			return nil
+		}
+
+		node = next
+	}
+
+	return node
+}
+
+// appendToMapping adds a key at the end of a mapping, in block style.
+func appendToMapping(mapping *yaml.Node, name string, value *yaml.Node) {
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+
+	// a comment after the last key (like a commented out setting) stays before the new one
+	if n := len(mapping.Content); n >= 2 {
+		for _, prev := range mapping.Content[n-2:] {
+			if prev.FootComment != "" {
+				key.HeadComment = prev.FootComment
+				prev.FootComment = ""
+			}
+		}
+	}
+
+	mapping.Style &^= yaml.FlowStyle
+	mapping.Content = append(mapping.Content, key, value)
+}
+
+// setNode sets the value of a dotted path in a YAML document, creating the missing mappings.
+// The other nodes, and their comments, are left untouched.
+func setNode(doc *yaml.Node, path []string, value *yaml.Node) error {
+	if doc.Kind != yaml.DocumentNode {
+		return errors.New("not a YAML document")
+	}
+
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+
+	node := doc.Content[0]
+
+	for i, name := range path {
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s is not a mapping", strings.Join(path[:i], "."))
+		}
+
+		var next *yaml.Node
+
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if node.Content[j].Value == name {
+				next = node.Content[j+1]
+			}
+		}
+
+		last := i == len(path)-1
+
+		switch {
+		case next != nil && last:
+			next.Kind = value.Kind
+			next.Tag = value.Tag
+			next.Value = value.Value
+			next.Style = value.Style
+			next.Content = nil
+		case next != nil && next.Kind == yaml.ScalarNode && next.Tag == "!!null":
+			// "key:" with no value, make it a mapping
+			next.Kind = yaml.MappingNode
+			next.Tag = "!!map"
+			next.Value = ""
+		case next == nil && last:
+			next = value
+			appendToMapping(node, name, next)
+		case next == nil:
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			appendToMapping(node, name, next)
+		}
+
+		node = next
+	}
+
+	return nil
+}
+
+// setYAMLKey changes the value of a key in a YAML configuration, keeping the formatting and comments of the rest of the file.
+func setYAMLKey(content []byte, key string, sk settableKey, value string) ([]byte, error) {
+	doc := yaml.Node{}
+
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+	}
+
+	if err := setNode(&doc, strings.Split(key, "."), &yaml.Node{Kind: yaml.ScalarNode, Tag: sk.tag, Value: value}); err != nil {
+		return nil, err
+	}
+
+	buf := bytes.Buffer{}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeFileAtomic replaces the content of a file, keeping its permissions.
+func writeFileAtomic(path string, content []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// configFileForKey returns the file to edit: config.yaml.local if it already sets the key, config.yaml otherwise.
+func configFileForKey(configFile string, key string) (string, error) {
+	localFile := configFile + ".local"
+
+	content, err := os.ReadFile(localFile)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return configFile, nil
+	case err != nil:
+		return "", err
+	}
+
+	doc := yaml.Node{}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return "", fmt.Errorf("%s: %w", localFile, err)
+	}
+
+	if lookupNode(&doc, strings.Split(key, ".")) != nil {
+		return localFile, nil
+	}
+
+	return configFile, nil
+}
+
+func (cli *cliConfig) set(out io.Writer, key string, value string) error {
+	sk, err := getSettableKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := sk.validate(value); err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", value, key, err)
+	}
+
+	if sk.tag == "!!bool" {
+		b, _ := strconv.ParseBool(value)
+		value = strconv.FormatBool(b)
+	}
+
+	cfg := cli.cfg()
+
+	if cfg.FilePath == "" {
+		return errors.New("no configuration file to edit")
+	}
+
+	configFile, err := configFileForKey(cfg.FilePath, key)
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+
+	newContent, err := setYAMLKey(content, key, sk, value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", configFile, err)
+	}
+
+	if err := writeFileAtomic(configFile, newContent); err != nil {
+		return fmt.Errorf("while writing %s: %w", configFile, err)
+	}
+
+	fmt.Fprintf(out, "%s set to %s in %s\n", key, value, configFile)
+
+	if msg := reload.UserMessage(); msg != "" {
+		fmt.Fprintln(out, "\n"+msg)
+	}
+
+	return nil
+}
+
+func (*cliConfig) get(out io.Writer, mergedConfig string, key string) error {
+	if _, err := getSettableKey(key); err != nil {
+		return err
+	}
+
+	doc := yaml.Node{}
+	if err := yaml.Unmarshal([]byte(mergedConfig), &doc); err != nil {
+		return err
+	}
+
+	node := lookupNode(&doc, strings.Split(key, "."))
+	if node == nil {
+		return fmt.Errorf("%s is not set in the configuration, the default value is used", key)
+	}
+
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("%s is not a scalar value", key)
+	}
+
+	fmt.Fprintln(out, node.Value)
+
+	return nil
+}
+
+func (cli *cliConfig) newSetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Change a configuration setting",
+		Long: `Change a configuration setting in config.yaml, or in config.yaml.local if it is set there.
+The value is validated, and the rest of the file (including comments) is preserved.
+
+Supported keys: ` + strings.Join(settableKeyNames(), ", "),
+		Example: `cscli config set common.log_level debug
+cscli config set api.server.listen_uri 0.0.0.0:8080
+cscli config set prometheus.listen_port 6061`,
+		Args:              args.ExactArgs(2),
+		DisableAutoGenTag: true,
+		ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return settableKeyNames(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			return cli.set(os.Stdout, args[0], args[1])
+		},
+	}
+
+	return cmd
+}
+
+func (cli *cliConfig) newGetCmd(mergedConfigGetter mergedConfigGetter) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Display a configuration setting",
+		Long: `Display a configuration setting, as set in config.yaml and config.yaml.local.
+
+Supported keys: ` + strings.Join(settableKeyNames(), ", "),
+		Example:           `cscli config get common.log_level`,
+		Args:              args.ExactArgs(1),
+		DisableAutoGenTag: true,
+		ValidArgsFunction: func(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+
+			return settableKeyNames(), cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			return cli.get(os.Stdout, mergedConfigGetter(), args[0])
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,213 @@
+package cliconfig
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+)
+
+const testConfig = `common:
+  daemonize: true
+  # comment on log_media
+  log_media: file
+  log_level: info # trailing comment
+api:
+  server:
+    listen_uri: 127.0.0.1:8080
+    #enable: false
+`
+
+func TestSetYAMLKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		key      string
+		value    string
+		expected string
+	}{
+		{
+			name:    "existing key",
+			content: testConfig,
+			key:     "common.log_level",
+			value:   "debug",
+			expected: `common:
+  daemonize: true
+  # comment on log_media
+  log_media: file
+  log_level: debug # trailing comment
+api:
+  server:
+    listen_uri: 127.0.0.1:8080
+    #enable: false
+`,
+		},
+		{
+			name:    "new key in existing mapping",
+			content: testConfig,
+			key:     "api.server.enable",
+			value:   "false",
+			expected: `common:
+  daemonize: true
+  # comment on log_media
+  log_media: file
+  log_level: info # trailing comment
+api:
+  server:
+    listen_uri: 127.0.0.1:8080
+    #enable: false
+    enable: false
+`,
+		},
+		{
+			name:    "new mapping",
+			content: testConfig,
+			key:     "prometheus.listen_port",
+			value:   "6061",
+			expected: `common:
+  daemonize: true
+  # comment on log_media
+  log_media: file
+  log_level: info # trailing comment
+api:
+  server:
+    listen_uri: 127.0.0.1:8080
+    #enable: false
+prometheus:
+  listen_port: 6061
+`,
+		},
+		{
+			name:     "empty file",
+			content:  "",
+			key:      "cscli.output",
+			value:    "json",
+			expected: "cscli:\n  output: json\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sk, err := getSettableKey(tc.key)
+			require.NoError(t, err)
+
+			out, err := setYAMLKey([]byte(tc.content), tc.key, sk, tc.value)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, string(out))
+		})
+	}
+}
+
+func TestConfigSet(t *testing.T) {
+	tests := []struct {
+		name        string
+		key         string
+		value       string
+		local       string
+		expectedErr string
+		expected    string
+		file        string
+	}{
+		{
+			name:        "unsupported key",
+			key:         "db_config.type",
+			value:       "mysql",
+			expectedErr: `unsupported key "db_config.type", must be one of: api.server.enable, api.server.listen_uri, common.log_level, common.log_media, cscli.color, cscli.output, prometheus.enabled, prometheus.level, prometheus.listen_addr, prometheus.listen_port`,
+		},
+		{
+			name:        "invalid log level",
+			key:         "common.log_level",
+			value:       "verbose",
+			expectedErr: `invalid value "verbose" for common.log_level: must be one of: trace, debug, info, warning, error, fatal`,
+		},
+		{
+			name:        "invalid listen_uri",
+			key:         "api.server.listen_uri",
+			value:       "127.0.0.1",
+			expectedErr: `invalid value "127.0.0.1" for api.server.listen_uri: must be in the form host:port`,
+		},
+		{
+			name:        "invalid port",
+			key:         "prometheus.listen_port",
+			value:       "70000",
+			expectedErr: `invalid value "70000" for prometheus.listen_port: must be a port number between 1 and 65535`,
+		},
+		{
+			name:     "bool is normalized",
+			key:      "prometheus.enabled",
+			value:    "1",
+			expected: "prometheus:\n  enabled: true\n",
+			file:     "config.yaml",
+		},
+		{
+			name:     "key set in the local file",
+			key:      "common.log_level",
+			value:    "warning",
+			local:    "common:\n  log_level: debug\n",
+			expected: "common:\n  log_level: warning\n",
+			file:     "config.yaml.local",
+		},
+		{
+			name:     "key not set in the local file",
+			key:      "cscli.color",
+			value:    "no",
+			local:    "common:\n  log_level: debug\n",
+			expected: "cscli:\n  color: no\n",
+			file:     "config.yaml",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			configFile := filepath.Join(dir, "config.yaml")
+
+			require.NoError(t, os.WriteFile(configFile, []byte("{}\n"), 0o600))
+
+			if tc.local != "" {
+				require.NoError(t, os.WriteFile(configFile+".local", []byte(tc.local), 0o600))
+			}
+
+			cli := New(func() *csconfig.Config { return &csconfig.Config{FilePath: configFile} })
+
+			out := bytes.Buffer{}
+
+			err := cli.set(&out, tc.key, tc.value)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			content, err := os.ReadFile(filepath.Join(dir, tc.file))
+			require.NoError(t, err)
+			assert.Contains(t, string(content), tc.expected)
+			assert.Contains(t, out.String(), tc.key+" set to ")
+
+			info, err := os.Stat(filepath.Join(dir, tc.file))
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+		})
+	}
+}
+
+func TestConfigGet(t *testing.T) {
+	cli := New(func() *csconfig.Config { return &csconfig.Config{} })
+
+	out := bytes.Buffer{}
+	require.NoError(t, cli.get(&out, testConfig, "api.server.listen_uri"))
+	assert.Equal(t, "127.0.0.1:8080\n", out.String())
+
+	err := cli.get(&out, testConfig, "prometheus.level")
+	cstest.RequireErrorContains(t, err, "prometheus.level is not set in the configuration, the default value is used")
+
+	err = cli.get(&out, testConfig, "common")
+	cstest.RequireErrorContains(t, err, `unsupported key "common"`)
+}
@@ -69,6 +69,7 @@ func (*cliConfig) template() string {
    - Hub Folder             : {{.ConfigPaths.HubDir}}
    - Notification Folder    : {{.ConfigPaths.NotificationDir}}
    - Simulation File        : {{.ConfigPaths.SimulationFilePath}}
+   - Scenario Overrides     : {{.ConfigPaths.ScenarioOverridesPath}}
 {{- end }}
 
 {{- if .Common }}
@@ -81,6 +82,7 @@ func (*cliConfig) template() string {
 Crowdsec{{if and .Crowdsec.Enable (not (ValueBool .Crowdsec.Enable))}} (disabled){{end}}:
   - Acquisition File        : {{.Crowdsec.AcquisitionFilePath}}
   - Parsers routines        : {{.Crowdsec.ParserRoutinesCount}}
+  - Enricher routines       : {{.Crowdsec.EnricherRoutinesCount}}
 {{- if .Crowdsec.AcquisitionDirPath }}
   - Acquisition Folder      : {{.Crowdsec.AcquisitionDirPath}}
 {{- end }}
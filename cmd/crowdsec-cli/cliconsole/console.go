@@ -345,13 +345,25 @@ Disable given information push to the central API.`,
 }
 
 func (cli *cliConsole) newStatusCmd() *cobra.Command {
+	remote := false
+
 	cmd := &cobra.Command{
-		Use:               "status",
-		Short:             "Shows status of the console options",
-		Example:           `sudo cscli console status`,
+		Use:   "status",
+		Short: "Shows status of the console options",
+		Long: `Shows status of the console options.
+
+With --remote, the Central API is asked for the console view of the instance: enrollment,
+plan (from the Polling API) and attached blocklists. It's compared with the local
+configuration, and with the enrollment state crowdsec started with.`,
+		Example: `sudo cscli console status
+sudo cscli console status --remote`,
 		Args:              args.NoArgs,
 		DisableAutoGenTag: true,
-		RunE: func(_ *cobra.Command, _ []string) error {
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if remote {
+				return cli.remoteStatus(cmd.Context())
+			}
+
 			cfg := cli.cfg()
 			consoleCfg := cfg.API.Server.ConsoleConfig
 
@@ -402,6 +414,8 @@ func (cli *cliConsole) newStatusCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVar(&remote, "remote", false, "Compare with the console view of the instance")
+
 	return cmd
 }
 
@@ -1,6 +1,7 @@
 package cliconsole
 
 import (
+	"cmp"
 	"io"
 
 	"github.com/jedib0t/go-pretty/v6/text"
@@ -8,6 +9,7 @@ import (
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
 	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/emoji"
+	"github.com/crowdsecurity/crowdsec/pkg/modelscapi"
 )
 
 func cmdConsoleStatusTable(out io.Writer, wantColor string, consoleCfg csconfig.ConsoleConfig) {
@@ -48,3 +50,26 @@ func cmdConsoleStatusTable(out io.Writer, wantColor string, consoleCfg csconfig.
 
 	t.Render()
 }
+
+func consoleRemoteBlocklistsTable(out io.Writer, wantColor string, blocklists []*modelscapi.BlocklistLink, lastPulls map[string]string) {
+	t := cstable.New(out, wantColor)
+	t.SetRowLines(false)
+
+	t.SetHeaders("Blocklist", "Remediation", "Scope", "Duration", "Last Pull")
+	t.SetHeaderAlignment(text.AlignLeft, text.AlignLeft, text.AlignLeft, text.AlignLeft, text.AlignLeft)
+
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+
+		return *s
+	}
+
+	for _, blocklist := range blocklists {
+		name := blocklistName(blocklist)
+		t.AddRow(name, deref(blocklist.Remediation), deref(blocklist.Scope), deref(blocklist.Duration), cmp.Or(lastPulls[name], "never"))
+	}
+
+	t.Render()
+}
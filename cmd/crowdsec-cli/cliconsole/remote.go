@@ -0,0 +1,330 @@
+package cliconsole
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/go-openapi/strfmt"
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/require"
+	"github.com/crowdsecurity/crowdsec/pkg/apiclient"
+	"github.com/crowdsecurity/crowdsec/pkg/apiserver"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/database"
+	"github.com/crowdsecurity/crowdsec/pkg/modelscapi"
+)
+
+// localToken is what the local API knows of the enrollment, from the token of its last login to
+// the Central API. The console features are only enabled if the instance was enrolled then.
+type localToken struct {
+	found    bool
+	enrolled bool
+	plan     string
+}
+
+func parseLocalToken(raw string) localToken {
+	if raw == "" {
+		return localToken{}
+	}
+
+	tok, _, err := new(jwt.Parser).ParseUnverified(raw, jwt.MapClaims{})
+	if err != nil {
+		return localToken{}
+	}
+
+	claims, ok := tok.Claims.(jwt.MapClaims)
+	if !ok {
+		return localToken{}
+	}
+
+	ret := localToken{found: true}
+	_, ret.enrolled = claims["organization_id"]
+	ret.plan, _ = claims["subscription_type"].(string)
+
+	return ret
+}
+
+type consoleDifference struct {
+	Item    string `json:"item"`
+	Local   string `json:"local"`
+	Console string `json:"console"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// remoteConsoleStatus is what the Central API tells of the instance: the enrollment from the token
+// of a new login, the plan from the Polling API, and the blocklists from the decisions stream.
+type remoteConsoleStatus struct {
+	Enrolled   bool     `json:"enrolled"`
+	Plan       string   `json:"plan"`
+	Categories []string `json:"categories"`
+	// PAPIError is why the Polling API couldn't be queried, the plan is unknown then.
+	PAPIError  string                      `json:"papi_error,omitempty"`
+	Blocklists []*modelscapi.BlocklistLink `json:"blocklists"`
+	// LastPulls is the time of the last pull of the attached blocklists, empty if they were never pulled.
+	LastPulls   map[string]string   `json:"blocklists_last_pull"`
+	Differences []consoleDifference `json:"differences"`
+}
+
+func enabledString(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+
+	return "disabled"
+}
+
+// diffConsole compares the console view of the instance with the local configuration, and with the
+// enrollment state the local API started with.
+func diffConsole(cfg *csconfig.Config, status *remoteConsoleStatus, token localToken) []consoleDifference {
+	ret := []consoleDifference{}
+
+	switch {
+	case status.Enrolled && !token.found:
+		ret = append(ret, consoleDifference{
+			Item:    "enrollment",
+			Local:   "not logged in",
+			Console: "enrolled",
+			Hint:    "crowdsec has not logged in to the Central API yet, start it to enable the console features",
+		})
+	case status.Enrolled && !token.enrolled:
+		ret = append(ret, consoleDifference{
+			Item:    "enrollment",
+			Local:   "not enrolled",
+			Console: "enrolled",
+			Hint:    "the enrollment was accepted after crowdsec logged in to the Central API, restart it to enable the console features",
+		})
+	case !status.Enrolled && token.enrolled:
+		ret = append(ret, consoleDifference{
+			Item:    "enrollment",
+			Local:   "enrolled",
+			Console: "not enrolled",
+			Hint:    "the instance was removed from its organization, restart crowdsec",
+		})
+	case status.Enrolled && status.Plan != "" && token.plan != status.Plan:
+		ret = append(ret, consoleDifference{
+			Item:    "plan",
+			Local:   token.plan,
+			Console: status.Plan,
+			Hint:    "the plan changed after crowdsec logged in to the Central API, restart it to apply the new one",
+		})
+	}
+
+	pullBlocklists := cfg.API.Server.OnlineClient.PullConfig.Blocklists
+
+	if len(status.Blocklists) > 0 && (pullBlocklists == nil || !*pullBlocklists) {
+		ret = append(ret, consoleDifference{
+			Item:    "blocklists",
+			Local:   "pull disabled",
+			Console: fmt.Sprintf("%d attached", len(status.Blocklists)),
+			Hint:    "set api.server.online_client.pull.blocklists to true",
+		})
+	} else if status.Enrolled && token.enrolled {
+		for _, blocklist := range status.Blocklists {
+			name := blocklistName(blocklist)
+			if status.LastPulls[name] != "" {
+				continue
+			}
+
+			ret = append(ret, consoleDifference{
+				Item:    "blocklist " + name,
+				Local:   "never pulled",
+				Console: "attached",
+				Hint:    "the blocklists are pulled along with the community blocklist, check the logs of crowdsec if it was more than 2 hours ago",
+			})
+		}
+	}
+
+	return ret
+}
+
+func blocklistName(blocklist *modelscapi.BlocklistLink) string {
+	if blocklist.Name == nil {
+		return ""
+	}
+
+	return *blocklist.Name
+}
+
+// lastPulls returns the time of the last pull of each blocklist, as recorded by the local API.
+func lastPulls(ctx context.Context, db *database.Client, blocklists []*modelscapi.BlocklistLink) (map[string]string, error) {
+	ret := make(map[string]string, len(blocklists))
+
+	for _, blocklist := range blocklists {
+		name := blocklistName(blocklist)
+
+		value, err := db.GetConfigItem(ctx, fmt.Sprintf("blocklist:%s:last_pull", name))
+		if err != nil {
+			return nil, err
+		}
+
+		ret[name] = value
+	}
+
+	return ret, nil
+}
+
+func (cli *cliConsole) queryRemoteStatus(ctx context.Context) (*remoteConsoleStatus, error) {
+	cfg := cli.cfg()
+	creds := cfg.API.Server.OnlineClient.Credentials
+
+	apiURL, err := url.Parse(creds.URL)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse CAPI URL: %w", err)
+	}
+
+	papiURL, err := url.Parse(creds.PapiURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse PAPI URL: %w", err)
+	}
+
+	hub, err := require.Hub(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := require.DBClient(ctx, cfg.DbConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// the token is not saved: it would replace the one of the local API, which tells how it started
+	c := apiclient.NewClient(&apiclient.Config{
+		MachineID:     creds.Login,
+		Password:      strfmt.Password(creds.Password),
+		URL:           apiURL,
+		PapiURL:       papiURL,
+		VersionPrefix: "v3",
+		UpdateScenario: func(_ context.Context) ([]string, error) {
+			return hub.GetInstalledListForAPI(), nil
+		},
+	})
+
+	// only the links of the blocklists are needed, not the community blocklist
+	stream, _, err := c.Decisions.GetStreamV3(ctx, apiclient.DecisionsStreamOpts{Startup: true, CommunityPull: false, AdditionalPull: true})
+	if err != nil {
+		return nil, fmt.Errorf("could not get the blocklists: %w", err)
+	}
+
+	status := &remoteConsoleStatus{
+		Categories: []string{},
+		Blocklists: []*modelscapi.BlocklistLink{},
+	}
+
+	if stream.Links != nil {
+		status.Blocklists = stream.Links.Blocklists
+	}
+
+	// the token of the login, done by the stream request, has the current enrollment
+	if transport, ok := c.GetClient().Transport.(*apiclient.JWTTransport); ok {
+		status.Enrolled = parseLocalToken(transport.Token).enrolled
+	}
+
+	if status.Enrolled {
+		perms, err := apiserver.GetPAPIPermissions(ctx, c, papiURL.String())
+		if err != nil {
+			status.PAPIError = err.Error()
+		} else {
+			status.Plan = perms.Plan
+			status.Categories = perms.Categories
+		}
+	}
+
+	raw, err := db.GetConfigItem(ctx, database.APICTokenKey)
+	if err != nil {
+		return nil, err
+	}
+
+	status.LastPulls, err = lastPulls(ctx, db, status.Blocklists)
+	if err != nil {
+		return nil, err
+	}
+
+	status.Differences = diffConsole(cfg, status, parseLocalToken(raw))
+
+	return status, nil
+}
+
+func printRemoteStatusHuman(out io.Writer, wantColor string, st *remoteConsoleStatus) {
+	switch {
+	case !st.Enrolled:
+		fmt.Fprintln(out, "The instance is not enrolled in the console, see 'cscli console enroll'.")
+	case st.PAPIError != "":
+		fmt.Fprintln(out, "The instance is enrolled in the console.")
+		fmt.Fprintf(out, "Plan: unknown, the Polling API can't be queried: %s\n", st.PAPIError)
+	default:
+		fmt.Fprintln(out, "The instance is enrolled in the console.")
+		fmt.Fprintf(out, "Plan: %s\n", st.Plan)
+
+		if len(st.Categories) > 0 {
+			fmt.Fprintf(out, "Console orders: %s\n", strings.Join(st.Categories, ", "))
+		}
+	}
+
+	if len(st.Blocklists) > 0 {
+		fmt.Fprintln(out)
+		consoleRemoteBlocklistsTable(out, wantColor, st.Blocklists, st.LastPulls)
+	}
+
+	fmt.Fprintln(out)
+
+	if len(st.Differences) == 0 {
+		fmt.Fprintln(out, "The local configuration matches the console.")
+		return
+	}
+
+	fmt.Fprintln(out, "The local configuration differs from the console:")
+
+	for _, d := range st.Differences {
+		fmt.Fprintf(out, "  - %s: %s locally, %s in the console (%s)\n", d.Item, d.Local, d.Console, d.Hint)
+	}
+}
+
+func (cli *cliConsole) remoteStatus(ctx context.Context) error {
+	cfg := cli.cfg()
+
+	st, err := cli.queryRemoteStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch cfg.Cscli.Output {
+	case "human":
+		printRemoteStatusHuman(color.Output, cfg.Cscli.Color, st)
+	case "json":
+		data, err := json.MarshalIndent(st, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to serialize console status: %w", err)
+		}
+
+		fmt.Fprintln(os.Stdout, string(data))
+	case "raw":
+		csvwriter := csv.NewWriter(os.Stdout)
+
+		if err := csvwriter.Write([]string{"item", "local", "console", "hint"}); err != nil {
+			return err
+		}
+
+		if err := csvwriter.Write([]string{"enrolled", "", strconv.FormatBool(st.Enrolled), ""}); err != nil {
+			return err
+		}
+
+		for _, d := range st.Differences {
+			if err := csvwriter.Write([]string{d.Item, d.Local, d.Console, d.Hint}); err != nil {
+				return err
+			}
+		}
+
+		csvwriter.Flush()
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package cliconsole
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/modelscapi"
+)
+
+func signedToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	raw, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	return raw
+}
+
+func TestParseLocalToken(t *testing.T) {
+	assert.Equal(t, localToken{}, parseLocalToken(""))
+	assert.Equal(t, localToken{}, parseLocalToken("not a token"))
+	assert.Equal(t, localToken{found: true}, parseLocalToken(signedToken(t, jwt.MapClaims{"id": "x"})))
+	assert.Equal(t, localToken{found: true, enrolled: true, plan: "enterprise"},
+		parseLocalToken(signedToken(t, jwt.MapClaims{"organization_id": "1234", "subscription_type": "enterprise"})))
+}
+
+func testConsoleConfig(management bool, pullBlocklists bool) *csconfig.Config {
+	return &csconfig.Config{
+		API: &csconfig.APICfg{
+			Server: &csconfig.LocalApiServerCfg{
+				ConsoleConfig: &csconfig.ConsoleConfig{
+					ShareManualDecisions:  new(false),
+					ShareCustomScenarios:  new(true),
+					ShareTaintedScenarios: new(true),
+					ShareContext:          new(false),
+					ConsoleManagement:     new(management),
+				},
+				OnlineClient: &csconfig.OnlineApiClientCfg{
+					PullConfig: csconfig.CapiPullConfig{
+						Community:  new(true),
+						Blocklists: new(pullBlocklists),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDiffConsole(t *testing.T) {
+	enrolled := localToken{found: true, enrolled: true, plan: "free"}
+
+	blocklists := func(names ...string) []*modelscapi.BlocklistLink {
+		ret := []*modelscapi.BlocklistLink{}
+		for _, name := range names {
+			ret = append(ret, &modelscapi.BlocklistLink{Name: new(name)})
+		}
+
+		return ret
+	}
+
+	tests := []struct {
+		name     string
+		cfg      *csconfig.Config
+		status   *remoteConsoleStatus
+		token    localToken
+		expected []string
+	}{
+		{
+			name:     "not enrolled",
+			cfg:      testConsoleConfig(false, true),
+			status:   &remoteConsoleStatus{},
+			token:    localToken{found: true},
+			expected: []string{},
+		},
+		{
+			name:     "in sync",
+			cfg:      testConsoleConfig(true, true),
+			status:   &remoteConsoleStatus{Enrolled: true, Plan: "free"},
+			token:    enrolled,
+			expected: []string{},
+		},
+		{
+			name:     "accepted after the login",
+			cfg:      testConsoleConfig(true, true),
+			status:   &remoteConsoleStatus{Enrolled: true, Plan: "free"},
+			token:    localToken{found: true},
+			expected: []string{"enrollment: not enrolled/enrolled"},
+		},
+		{
+			name:     "never logged in",
+			cfg:      testConsoleConfig(true, true),
+			status:   &remoteConsoleStatus{Enrolled: true},
+			expected: []string{"enrollment: not logged in/enrolled"},
+		},
+		{
+			name:     "removed from the organization",
+			cfg:      testConsoleConfig(true, true),
+			status:   &remoteConsoleStatus{},
+			token:    enrolled,
+			expected: []string{"enrollment: enrolled/not enrolled"},
+		},
+		{
+			name:     "plan changed",
+			cfg:      testConsoleConfig(true, true),
+			status:   &remoteConsoleStatus{Enrolled: true, Plan: "enterprise"},
+			token:    enrolled,
+			expected: []string{"plan: free/enterprise"},
+		},
+		{
+			name:     "plan unknown",
+			cfg:      testConsoleConfig(true, true),
+			status:   &remoteConsoleStatus{Enrolled: true, PAPIError: "unable to query PAPI : forbidden (403)"},
+			token:    enrolled,
+			expected: []string{},
+		},
+		{
+			name:     "blocklists not pulled",
+			cfg:      testConsoleConfig(true, false),
+			status:   &remoteConsoleStatus{Enrolled: true, Plan: "free", Blocklists: blocklists("tor")},
+			token:    enrolled,
+			expected: []string{"blocklists: pull disabled/1 attached"},
+		},
+		{
+			name: "blocklist never pulled",
+			cfg:  testConsoleConfig(true, true),
+			status: &remoteConsoleStatus{
+				Enrolled:   true,
+				Plan:       "free",
+				Blocklists: blocklists("tor", "proxies"),
+				LastPulls:  map[string]string{"tor": "Sat, 17 Oct 2026 10:00:00 GMT"},
+			},
+			token:    enrolled,
+			expected: []string{"blocklist proxies: never pulled/attached"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := []string{}
+
+			for _, d := range diffConsole(tc.cfg, tc.status, tc.token) {
+				assert.NotEmpty(t, d.Hint)
+				actual = append(actual, d.Item+": "+d.Local+"/"+d.Console)
+			}
+
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -1,11 +1,13 @@
 package clidecision
 
 import (
+	"cmp"
 	"context"
 	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/netip"
 	"net/url"
 	"os"
 	"strconv"
@@ -133,7 +135,7 @@ func (cli *cliDecisions) NewCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "decisions [action]",
 		Short:   "Manage decisions",
-		Long:    `Add/List/Delete/Import decisions from LAPI`,
+		Long:    `Add/List/Delete/Import/Export decisions from LAPI`,
 		Example: `cscli decisions [action] [filter]`,
 		Aliases: []string{"decision"},
 		// TBD example
@@ -167,6 +169,7 @@ func (cli *cliDecisions) NewCommand() *cobra.Command {
 	cmd.AddCommand(cli.newAddCmd())
 	cmd.AddCommand(cli.newDeleteCmd())
 	cmd.AddCommand(cli.newImportCmd())
+	cmd.AddCommand(cli.newExportCmd())
 
 	return cmd
 }
@@ -201,6 +204,10 @@ func (cli *cliDecisions) list(ctx context.Context, filter apiclient.AlertsListOp
 		return fmt.Errorf("unable to retrieve decisions: %w", err)
 	}
 
+	if target := cmp.Or(filter.IPEquals, filter.RangeEquals); target != "" {
+		filterDecisionsByIP(alerts, target, contained != nil && *contained)
+	}
+
 	err = cli.decisionsToTable(alerts, printMachine)
 	if err != nil {
 		return fmt.Errorf("unable to print decisions: %w", err)
@@ -209,6 +216,73 @@ func (cli *cliDecisions) list(ctx context.Context, filter apiclient.AlertsListOp
 	return nil
 }
 
+// parsePrefix returns the network of an ip (as /32 or /128) or range, invalid if the value is neither.
+func parsePrefix(value string) netip.Prefix {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}
+		}
+
+		return prefix.Masked()
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}
+	}
+
+	return netip.PrefixFrom(addr, addr.BitLen())
+}
+
+// filterDecisionsByIP keeps only the decisions that match the ip or range looked up: the alerts
+// returned by the API have at least one of them, but can have others on unrelated values.
+// Alerts left without decisions are removed.
+func filterDecisionsByIP(alerts *models.GetAlertsResponse, target string, contained bool) {
+	targetPrefix := parsePrefix(target)
+	if !targetPrefix.IsValid() {
+		return
+	}
+
+	matches := func(d *models.Decision) bool {
+		if d.Value == nil {
+			return false
+		}
+
+		p := parsePrefix(*d.Value)
+		if !p.IsValid() {
+			return false
+		}
+
+		if contained {
+			return p.Bits() >= targetPrefix.Bits() && targetPrefix.Contains(p.Addr())
+		}
+
+		return p.Bits() <= targetPrefix.Bits() && p.Contains(targetPrefix.Addr())
+	}
+
+	ret := make(models.GetAlertsResponse, 0, len(*alerts))
+
+	for _, alertItem := range *alerts {
+		decisions := make([]*models.Decision, 0, len(alertItem.Decisions))
+
+		for _, d := range alertItem.Decisions {
+			if matches(d) {
+				decisions = append(decisions, d)
+			}
+		}
+
+		if len(decisions) == 0 {
+			continue
+		}
+
+		alertItem.Decisions = decisions
+		ret = append(ret, alertItem)
+	}
+
+	*alerts = ret
+}
+
 func (cli *cliDecisions) newListCmd() *cobra.Command {
 	filter := apiclient.AlertsListOpts{
 		ValueEquals:    "",
@@ -232,8 +306,13 @@ func (cli *cliDecisions) newListCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list [options]",
 		Short: "List decisions from LAPI",
+		Long: `List decisions from LAPI.
+
+With --ip or --range, the decisions on a range that contains the ip (or range) are listed too.
+With --contained, the decisions on the ips and ranges that are inside the range are listed instead.`,
 		Example: `cscli decisions list -i 1.2.3.4
 cscli decisions list -r 1.2.3.0/24
+cscli decisions list -r 1.2.3.0/24 --contained
 cscli decisions list -s crowdsecurity/ssh-bf
 cscli decisions list --origin lists --scenario list_name
 `,
@@ -260,7 +339,7 @@ cscli decisions list --origin lists --scenario list_name
 	flags.IntVarP(filter.Limit, "limit", "l", 100, "number of alerts to get (use 0 to remove the limit)")
 	flags.BoolVar(noSimu, "no-simu", false, "exclude decisions in simulation mode")
 	flags.BoolVarP(&printMachine, "machine", "m", false, "print machines that triggered decisions")
-	flags.BoolVar(contained, "contained", false, "query decisions contained by range")
+	flags.BoolVar(contained, "contained", false, "query decisions contained by the ip or range, instead of the ones containing it")
 
 	return cmd
 }
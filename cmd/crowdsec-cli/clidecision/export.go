@@ -0,0 +1,314 @@
+package clidecision
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/netip"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
+	"github.com/crowdsecurity/crowdsec/pkg/apiclient"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+)
+
+// ipset can't store a longer timeout, in seconds
+const maxIpsetTimeout = 2147483
+
+var exportNameRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+
+// exportEntry is an ip or range to block, and how long.
+type exportEntry struct {
+	prefix netip.Prefix
+	ttl    time.Duration
+}
+
+// exportString returns the entry without the prefix length if it's a single ip.
+func (e exportEntry) exportString() string {
+	if e.prefix.IsSingleIP() {
+		return e.prefix.Addr().String()
+	}
+
+	return e.prefix.String()
+}
+
+type exportRenderer func(w io.Writer, entries []exportEntry, name string) error
+
+var exportRenderers = map[string]exportRenderer{
+	"ipset":    renderIpset,
+	"nftables": renderNftables,
+	"nginx":    renderNginx,
+	"waf-json": renderWAFJSON,
+}
+
+// exportEntries returns the ips and ranges of the decisions, sorted and without duplicates: the longest
+// remaining duration is kept. The number of decisions that can't be exported (other scopes) is returned too.
+func exportEntries(alerts *models.GetAlertsResponse) ([]exportEntry, int) {
+	byPrefix := map[netip.Prefix]time.Duration{}
+	skipped := 0
+
+	for _, alertItem := range *alerts {
+		for _, d := range alertItem.Decisions {
+			if d.Value == nil || d.Duration == nil {
+				skipped++
+				continue
+			}
+
+			prefix := parsePrefix(*d.Value)
+			if !prefix.IsValid() {
+				skipped++
+				continue
+			}
+
+			ttl, err := time.ParseDuration(*d.Duration)
+			if err != nil || ttl <= 0 {
+				// expired since the query
+				continue
+			}
+
+			byPrefix[prefix] = max(byPrefix[prefix], ttl)
+		}
+	}
+
+	ret := make([]exportEntry, 0, len(byPrefix))
+
+	for _, prefix := range slices.SortedFunc(maps.Keys(byPrefix), netip.Prefix.Compare) {
+		ret = append(ret, exportEntry{prefix: prefix, ttl: byPrefix[prefix]})
+	}
+
+	return ret, skipped
+}
+
+// splitFamilies returns the IPv4 and IPv6 entries.
+func splitFamilies(entries []exportEntry) ([]exportEntry, []exportEntry) {
+	var v4, v6 []exportEntry
+
+	for _, e := range entries {
+		if e.prefix.Addr().Is4() {
+			v4 = append(v4, e)
+		} else {
+			v6 = append(v6, e)
+		}
+	}
+
+	return v4, v6
+}
+
+// renderIpset writes a file for "ipset restore", with the sets used by the firewall bouncer.
+func renderIpset(w io.Writer, entries []exportEntry, name string) error {
+	v4, v6 := splitFamilies(entries)
+
+	for _, set := range []struct {
+		name    string
+		family  string
+		entries []exportEntry
+	}{
+		{name + "-blacklists", "inet", v4},
+		{name + "6-blacklists", "inet6", v6},
+	} {
+		if _, err := fmt.Fprintf(w, "create %s hash:net family %s timeout 0 -exist\n", set.name, set.family); err != nil {
+			return err
+		}
+
+		for _, e := range set.entries {
+			timeout := min(int64(e.ttl.Seconds()), maxIpsetTimeout)
+			if _, err := fmt.Fprintf(w, "add %s %s timeout %d -exist\n", set.name, e.exportString(), timeout); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// renderNftables writes a script for "nft -f", with a set by address family in the table name.
+func renderNftables(w io.Writer, entries []exportEntry, name string) error {
+	v4, v6 := splitFamilies(entries)
+
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "table inet %s {\n", name)
+
+	for _, set := range []struct {
+		name    string
+		typ     string
+		entries []exportEntry
+	}{
+		{"blacklists", "ipv4_addr", v4},
+		{"blacklists6", "ipv6_addr", v6},
+	} {
+		fmt.Fprintf(&sb, "\tset %s {\n", set.name)
+		fmt.Fprintf(&sb, "\t\ttype %s\n", set.typ)
+		sb.WriteString("\t\tflags interval, timeout\n")
+		sb.WriteString("\t\tauto-merge\n")
+
+		if len(set.entries) > 0 {
+			elements := make([]string, 0, len(set.entries))
+			for _, e := range set.entries {
+				elements = append(elements, fmt.Sprintf("%s timeout %ds", e.exportString(), int64(e.ttl.Seconds())))
+			}
+
+			fmt.Fprintf(&sb, "\t\telements = { %s }\n", strings.Join(elements, ", "))
+		}
+
+		sb.WriteString("\t}\n")
+	}
+
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// renderNginx writes a geo block, to be included in the http context. The variable is 1 for the blocked clients.
+func renderNginx(w io.Writer, entries []exportEntry, name string) error {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "geo $%s_banned {\n", name)
+	sb.WriteString("\tdefault 0;\n")
+
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "\t%s 1;\n", e.exportString())
+	}
+
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// renderWAFJSON writes the entries as CIDRs, by address family, the way cloud WAFs expect IP sets.
+func renderWAFJSON(w io.Writer, entries []exportEntry, _ string) error {
+	v4, v6 := splitFamilies(entries)
+
+	cidrs := func(entries []exportEntry) []string {
+		ret := make([]string, 0, len(entries))
+		for _, e := range entries {
+			ret = append(ret, e.prefix.String())
+		}
+
+		return ret
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(map[string][]string{
+		"ipv4": cidrs(v4),
+		"ipv6": cidrs(v6),
+	})
+}
+
+func (cli *cliDecisions) export(ctx context.Context, filter apiclient.AlertsListOpts, format string, name string, output string) error {
+	render, ok := exportRenderers[format]
+	if !ok {
+		return fmt.Errorf("invalid format '%s', expected one of %s", format, strings.Join(slices.Sorted(maps.Keys(exportRenderers)), ", "))
+	}
+
+	if !exportNameRe.MatchString(name) {
+		return fmt.Errorf("invalid name '%s': only letters, digits and underscores are allowed", name)
+	}
+
+	filter.ActiveDecisionEquals = new(true)
+	// the decisions in simulation mode are not enforced
+	filter.IncludeSimulated = new(false)
+	filter.Limit = new(0)
+
+	alerts, _, err := cli.client.Alerts.List(ctx, filter)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve decisions: %w", err)
+	}
+
+	entries, skipped := exportEntries(alerts)
+	if skipped > 0 {
+		log.Warningf("%d decision(s) skipped: only the ip and range scopes can be exported", skipped)
+	}
+
+	out := os.Stdout
+
+	if output != "" && output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		out = f
+	}
+
+	if err := render(out, entries, name); err != nil {
+		return fmt.Errorf("writing the export: %w", err)
+	}
+
+	if out != os.Stdout {
+		if err := out.Close(); err != nil {
+			return err
+		}
+
+		log.Infof("%d ip(s) and range(s) exported to %s", len(entries), output)
+	}
+
+	return nil
+}
+
+func (cli *cliDecisions) newExportCmd() *cobra.Command {
+	filter := apiclient.AlertsListOpts{
+		IncludeCAPI: new(bool),
+	}
+
+	var (
+		format string
+		name   string
+		output string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export [options]",
+		Short: "Export the active decisions for a firewall or a web server",
+		Long: `Export the active decisions (bans by default) on ips and ranges, in a format that can be used
+without a remediation component:
+
+ipset    : a file for "ipset restore", with the <name>-blacklists and <name>6-blacklists sets.
+           They can be used by iptables with "-m set --match-set".
+nftables : a script for "nft -f", with the blacklists and blacklists6 sets in the inet <name> table.
+nginx    : a geo block that sets $<name>_banned to 1 for the blocked clients, for the http context.
+waf-json : the CIDRs by address family, to update the IP sets of a cloud WAF.
+
+The entries expire with the decisions, except for nginx and waf-json.`,
+		Example: `cscli decisions export --format ipset -o /tmp/crowdsec.ipset && ipset restore < /tmp/crowdsec.ipset
+cscli decisions export --format nftables | nft -f -
+cscli decisions export --format nginx -o /etc/nginx/conf.d/crowdsec-geo.conf
+cscli decisions export --format waf-json --origin lists`,
+		Args:              args.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cli.export(cmd.Context(), filter, format, name, output)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.SortFlags = false
+	flags.StringVarP(&format, "format", "f", "", "Output format: ipset, nftables, nginx, waf-json")
+	flags.StringVarP(&output, "output", "o", "", "Output file (default: standard output)")
+	flags.StringVar(&name, "name", "crowdsec", "Name of the sets, table or variable")
+	flags.BoolVarP(filter.IncludeCAPI, "all", "a", false, "Include decisions from Central API")
+	flags.StringVarP(&filter.TypeEquals, "type", "t", "ban", "restrict to this decision type (ie. ban,captcha)")
+	flags.StringVar(&filter.OriginEquals, "origin", "", "restrict to this origin (ie. crowdsec,cscli,lists)")
+	flags.StringVarP(&filter.ScenarioEquals, "scenario", "s", "", "restrict to this scenario (ie. crowdsecurity/ssh-bf)")
+
+	_ = cmd.MarkFlagRequired("format")
+
+	return cmd
+}
@@ -0,0 +1,128 @@
+package clidecision
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+)
+
+func testAlerts() *models.GetAlertsResponse {
+	decision := func(scope string, value string, duration string) *models.Decision {
+		return &models.Decision{Scope: &scope, Value: &value, Duration: &duration}
+	}
+
+	return &models.GetAlertsResponse{
+		{Decisions: []*models.Decision{
+			decision("Ip", "192.0.2.10", "1h"),
+			decision("Range", "198.51.100.0/24", "30m"),
+			decision("Ip", "2001:db8::1", "4h"),
+		}},
+		{Decisions: []*models.Decision{
+			// the longest duration is kept
+			decision("Ip", "192.0.2.10", "3h59m59.5s"),
+			decision("Username", "admin", "1h"),
+			decision("Ip", "192.0.2.11", "-1s"),
+		}},
+	}
+}
+
+func TestExportEntries(t *testing.T) {
+	entries, skipped := exportEntries(testAlerts())
+	assert.Equal(t, 1, skipped)
+
+	got := make([]string, 0, len(entries))
+	for _, e := range entries {
+		got = append(got, e.exportString()+" "+e.ttl.String())
+	}
+
+	assert.Equal(t, []string{
+		"192.0.2.10 3h59m59.5s",
+		"198.51.100.0/24 30m0s",
+		"2001:db8::1 4h0m0s",
+	}, got)
+}
+
+func TestExportRenderers(t *testing.T) {
+	entries, _ := exportEntries(testAlerts())
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: "ipset",
+			expected: `create crowdsec-blacklists hash:net family inet timeout 0 -exist
+add crowdsec-blacklists 192.0.2.10 timeout 14399 -exist
+add crowdsec-blacklists 198.51.100.0/24 timeout 1800 -exist
+create crowdsec6-blacklists hash:net family inet6 timeout 0 -exist
+add crowdsec6-blacklists 2001:db8::1 timeout 14400 -exist
+`,
+		},
+		{
+			format: "nftables",
+			expected: `table inet crowdsec {
+	set blacklists {
+		type ipv4_addr
+		flags interval, timeout
+		auto-merge
+		elements = { 192.0.2.10 timeout 14399s, 198.51.100.0/24 timeout 1800s }
+	}
+	set blacklists6 {
+		type ipv6_addr
+		flags interval, timeout
+		auto-merge
+		elements = { 2001:db8::1 timeout 14400s }
+	}
+}
+`,
+		},
+		{
+			format: "nginx",
+			expected: `geo $crowdsec_banned {
+	default 0;
+	192.0.2.10 1;
+	198.51.100.0/24 1;
+	2001:db8::1 1;
+}
+`,
+		},
+		{
+			format: "waf-json",
+			expected: `{
+  "ipv4": [
+    "192.0.2.10/32",
+    "198.51.100.0/24"
+  ],
+  "ipv6": [
+    "2001:db8::1/128"
+  ]
+}
+`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.format, func(t *testing.T) {
+			var sb strings.Builder
+
+			require.NoError(t, exportRenderers[tc.format](&sb, entries, "crowdsec"))
+			assert.Equal(t, tc.expected, sb.String())
+		})
+	}
+}
+
+func TestExportRenderersEmpty(t *testing.T) {
+	var sb strings.Builder
+
+	require.NoError(t, renderNftables(&sb, nil, "crowdsec"))
+	assert.NotContains(t, sb.String(), "elements")
+
+	sb.Reset()
+
+	require.NoError(t, renderWAFJSON(&sb, nil, "crowdsec"))
+	assert.JSONEq(t, `{"ipv4": [], "ipv6": []}`, sb.String())
+}
@@ -25,7 +25,7 @@ func (cli *cliDecisions) decisionsTable(out io.Writer, alerts *models.GetAlertsR
 
 	for _, alertItem := range *alerts {
 		for _, decisionItem := range alertItem.Decisions {
-			if *alertItem.Simulated {
+			if *alertItem.Simulated || (decisionItem.Simulated != nil && *decisionItem.Simulated) {
 				*decisionItem.Type = "(simul)" + *decisionItem.Type
 			}
 
@@ -0,0 +1,149 @@
+package clidetectconfig
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultSyslogPort = 514
+
+// acquisEntry has the fields of a datasource configuration that tell which logs it reads.
+// The datasources are not configured: the log files may not exist yet.
+type acquisEntry struct {
+	File             string            `yaml:"-"`
+	Source           string            `yaml:"source"`
+	Filename         string            `yaml:"filename"`
+	Filenames        []string          `yaml:"filenames"`
+	JournalctlFilter []string          `yaml:"journalctl_filter"`
+	ListenPort       int               `yaml:"listen_port"`
+	Labels           map[string]string `yaml:"labels"`
+}
+
+func loadAcquisition(files []string) ([]acquisEntry, error) {
+	ret := []acquisEntry{}
+
+	for _, file := range files {
+		fd, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+
+		dec := yaml.NewDecoder(fd)
+
+		for {
+			entry := acquisEntry{File: file}
+
+			err := dec.Decode(&entry)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			if err != nil {
+				fd.Close()
+				return nil, fmt.Errorf("while parsing %s: %w", file, err)
+			}
+
+			if entry.Source == "" && (entry.Filename != "" || len(entry.Filenames) > 0) {
+				entry.Source = "file"
+			}
+
+			ret = append(ret, entry)
+		}
+
+		fd.Close()
+	}
+
+	return ret, nil
+}
+
+func (e *acquisEntry) readsFile(path string) bool {
+	if e.Source != "file" {
+		return false
+	}
+
+	for _, pattern := range append([]string{e.Filename}, e.Filenames...) {
+		if pattern == "" {
+			continue
+		}
+
+		if matched, err := filepath.Match(pattern, path); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readsJournal returns true if one of the journalctl filters selects the unit or identifier.
+func (e *acquisEntry) readsJournal(names []string) bool {
+	if e.Source != "journalctl" {
+		return false
+	}
+
+	for i, filter := range e.JournalctlFilter {
+		value := filter
+
+		switch {
+		case (filter == "-u" || filter == "--unit") && i+1 < len(e.JournalctlFilter):
+			value = e.JournalctlFilter[i+1]
+		default:
+			for _, prefix := range []string{"_SYSTEMD_UNIT=", "SYSLOG_IDENTIFIER=", "_COMM=", "--unit=", "-u"} {
+				if v, ok := strings.CutPrefix(filter, prefix); ok {
+					value = v
+					break
+				}
+			}
+		}
+
+		for _, name := range names {
+			if value == name || value == name+".service" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (e *acquisEntry) listensOn(port int) bool {
+	return e.Source == "syslog" && cmp.Or(e.ListenPort, defaultSyslogPort) == port
+}
+
+// findAcquisition returns the datasource that reads the destination, or nil.
+func findAcquisition(d destination, acquis []acquisEntry) *acquisEntry {
+	for i := range acquis {
+		e := &acquis[i]
+
+		switch d.Kind {
+		case kindFile:
+			if e.readsFile(d.Target) {
+				return e
+			}
+		case kindJournald:
+			if e.readsJournal(strings.Split(d.Target, ",")) {
+				return e
+			}
+		case kindSyslog:
+			_, portStr, err := net.SplitHostPort(d.Target)
+			if err != nil {
+				portStr = strconv.Itoa(defaultSyslogPort)
+			}
+
+			port, err := strconv.Atoi(portStr)
+			if err == nil && e.listensOn(port) {
+				return e
+			}
+		}
+	}
+
+	return nil
+}
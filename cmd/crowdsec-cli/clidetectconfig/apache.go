@@ -0,0 +1,217 @@
+package clidetectconfig
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	apacheVarRegexp    = regexp.MustCompile(`\$\{(\w+)\}`)
+	apacheExportRegexp = regexp.MustCompile(`^\s*export\s+(\w+)=(.*)$`)
+)
+
+type apacheParser struct {
+	serverRoot string
+	vars       map[string]string
+	seen       map[string]bool
+	dests      []destination
+}
+
+// apacheDestinations returns the files of the CustomLog, TransferLog and ErrorLog directives,
+// in the configuration and the included files. The variables are read from the envvars file
+// of Debian, next to the configuration.
+func apacheDestinations(configPath string) ([]destination, error) {
+	p := apacheParser{
+		serverRoot: filepath.Dir(configPath),
+		vars:       map[string]string{},
+		seen:       map[string]bool{},
+	}
+
+	if err := p.loadEnvvars(filepath.Join(p.serverRoot, "envvars")); err != nil {
+		return nil, err
+	}
+
+	if err := p.parseFile(configPath); err != nil {
+		return nil, err
+	}
+
+	return dedupDestinations(p.dests), nil
+}
+
+func (p *apacheParser) expand(s string) string {
+	return apacheVarRegexp.ReplaceAllStringFunc(s, func(m string) string {
+		return p.vars[m[2:len(m)-1]]
+	})
+}
+
+func (p *apacheParser) loadEnvvars(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for line := range strings.Lines(string(data)) {
+		m := apacheExportRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+
+		value := strings.Trim(m[2], `"'`)
+		// shell variables, like $SUFFIX for the multiple instances
+		p.vars[m[1]] = os.Expand(value, func(name string) string { return p.vars[name] })
+	}
+
+	return nil
+}
+
+// apacheFields splits a directive into its arguments, which can be quoted.
+func apacheFields(line string) []string {
+	var (
+		ret    []string
+		word   strings.Builder
+		quoted bool
+	)
+
+	for _, c := range line {
+		switch {
+		case c == '"':
+			if quoted {
+				ret = append(ret, word.String())
+				word.Reset()
+			}
+
+			quoted = !quoted
+		case !quoted && (c == ' ' || c == '\t'):
+			if word.Len() > 0 {
+				ret = append(ret, word.String())
+				word.Reset()
+			}
+		default:
+			word.WriteRune(c)
+		}
+	}
+
+	if word.Len() > 0 {
+		ret = append(ret, word.String())
+	}
+
+	return ret
+}
+
+func (p *apacheParser) parseFile(path string) error {
+	if p.seen[path] {
+		return nil
+	}
+
+	p.seen[path] = true
+
+	fd, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+	lineNumber := 0
+	directive := ""
+	start := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if directive == "" {
+			start = lineNumber
+		}
+
+		if cont, ok := strings.CutSuffix(line, "\\"); ok {
+			directive += cont + " "
+			continue
+		}
+
+		directive += line
+
+		if err := p.directive(fmt.Sprintf("%s:%d", path, start), directive); err != nil {
+			return err
+		}
+
+		directive = ""
+	}
+
+	return scanner.Err()
+}
+
+func (p *apacheParser) path(s string) string {
+	if filepath.IsAbs(s) {
+		return s
+	}
+
+	return filepath.Join(p.serverRoot, s)
+}
+
+func (p *apacheParser) directive(origin string, line string) error {
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "<") {
+		return nil
+	}
+
+	fields := apacheFields(p.expand(line))
+	if len(fields) < 2 {
+		return nil
+	}
+
+	arg := fields[1]
+
+	switch strings.ToLower(fields[0]) {
+	case "serverroot":
+		p.serverRoot = arg
+	case "define":
+		if len(fields) > 2 {
+			p.vars[arg] = fields[2]
+		}
+	case "include", "includeoptional":
+		pattern := p.path(arg)
+
+		if info, err := os.Stat(pattern); err == nil && info.IsDir() {
+			pattern = filepath.Join(pattern, "*")
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("%s: %w", origin, err)
+		}
+
+		if len(matches) == 0 && strings.EqualFold(fields[0], "include") && !strings.ContainsAny(pattern, "*?[") {
+			return fmt.Errorf("%s: included file %s not found", origin, pattern)
+		}
+
+		for _, m := range matches {
+			if err := p.parseFile(m); err != nil {
+				return err
+			}
+		}
+	case "customlog", "transferlog", "errorlog":
+		p.dests = append(p.dests, p.logDestination(arg, origin))
+	}
+
+	return nil
+}
+
+func (p *apacheParser) logDestination(target string, origin string) destination {
+	switch {
+	case strings.HasPrefix(target, "|"):
+		return destination{Kind: kindFile, Target: target, Origin: origin, Unchecked: "piped to a program"}
+	case target == "syslog" || strings.HasPrefix(target, "syslog:"):
+		return destination{Kind: kindSyslog, Target: target, Origin: origin, Unchecked: "sent to the local syslog daemon"}
+	}
+
+	return fileDestination(p.path(target), origin)
+}
@@ -0,0 +1,278 @@
+package clidetectconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/require"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/emoji"
+)
+
+const (
+	kindFile     = "file"
+	kindSyslog   = "syslog"
+	kindJournald = "journald"
+
+	statusAcquired  = "acquired"
+	statusElsewhere = "acquired elsewhere"
+	statusMissing   = "not acquired"
+	statusWrongType = "wrong type"
+	statusUnchecked = "unchecked"
+)
+
+// destination is where a service writes its logs, according to its configuration.
+type destination struct {
+	Kind   string `json:"kind"`
+	Target string `json:"target"`
+	// file and line of the directive
+	Origin string `json:"origin,omitempty"`
+	// the destinations of a group receive the same logs, acquiring one of them is enough
+	Group string `json:"group,omitempty"`
+	// why the destination can't be checked
+	Unchecked string `json:"-"`
+}
+
+// service reads the log destinations from the configuration of a service.
+type service struct {
+	// default paths of the configuration, the first one that exists is used
+	configPaths []string
+	// labels.type of the acquisition, for the parsers of the service
+	logType      string
+	destinations func(configPath string) ([]destination, error)
+}
+
+var services = map[string]service{
+	"nginx": {
+		configPaths:  []string{"/etc/nginx/nginx.conf", "/usr/local/etc/nginx/nginx.conf"},
+		logType:      "nginx",
+		destinations: nginxDestinations,
+	},
+	"apache2": {
+		configPaths:  []string{"/etc/apache2/apache2.conf", "/etc/httpd/conf/httpd.conf", "/usr/local/etc/apache24/httpd.conf"},
+		logType:      "apache2",
+		destinations: apacheDestinations,
+	},
+	"sshd": {
+		configPaths: []string{"/etc/ssh/sshd_config"},
+		logType:     "syslog",
+		destinations: func(configPath string) ([]destination, error) {
+			return sshdDestinations(configPath, rsyslogConfigPath)
+		},
+	},
+}
+
+func serviceNames() []string {
+	return slices.Sorted(maps.Keys(services))
+}
+
+type finding struct {
+	destination
+
+	Status      string `json:"status"`
+	Acquisition string `json:"acquisition,omitempty"`
+	Detail      string `json:"detail,omitempty"`
+}
+
+type report struct {
+	Service  string    `json:"service"`
+	Config   string    `json:"config"`
+	Findings []finding `json:"destinations"`
+}
+
+// failures returns the number of destinations that are not acquired as expected.
+func (r *report) failures() int {
+	n := 0
+
+	for _, f := range r.Findings {
+		if f.Status == statusMissing || f.Status == statusWrongType {
+			n++
+		}
+	}
+
+	return n
+}
+
+// check compares the log destinations of a service with the acquisition.
+func check(svc service, dests []destination, acquis []acquisEntry) []finding {
+	findings := make([]finding, 0, len(dests))
+	coveredGroups := map[string]bool{}
+
+	for _, d := range dests {
+		f := finding{destination: d}
+
+		switch {
+		case d.Unchecked != "":
+			f.Status = statusUnchecked
+			f.Detail = d.Unchecked
+		default:
+			entry := findAcquisition(d, acquis)
+
+			// the syslog parser comes first for the messages from syslog and the journal
+			logType := svc.logType
+			if d.Kind != kindFile {
+				logType = "syslog"
+			}
+
+			switch {
+			case entry == nil:
+				f.Status = statusMissing
+			case entry.Labels["type"] != logType:
+				f.Status = statusWrongType
+				f.Acquisition = entry.File
+				f.Detail = fmt.Sprintf("labels.type is '%s', expected '%s'", entry.Labels["type"], logType)
+			default:
+				f.Status = statusAcquired
+				f.Acquisition = entry.File
+			}
+		}
+
+		if d.Group != "" && f.Status == statusAcquired {
+			coveredGroups[d.Group] = true
+		}
+
+		findings = append(findings, f)
+	}
+
+	for i := range findings {
+		if findings[i].Group != "" && findings[i].Status == statusMissing && coveredGroups[findings[i].Group] {
+			findings[i].Status = statusElsewhere
+		}
+	}
+
+	return findings
+}
+
+type cliDetectConfig struct {
+	cfg csconfig.Getter
+}
+
+func New(cfg csconfig.Getter) *cliDetectConfig {
+	return &cliDetectConfig{
+		cfg: cfg,
+	}
+}
+
+func (cli *cliDetectConfig) NewCommand() *cobra.Command {
+	var serviceConfig string
+
+	cmd := &cobra.Command{
+		Use:   "detect-config [service]",
+		Short: "Check that the acquisition covers the logs of a service",
+		Long: `Read the configuration of a service to find where it writes its logs, and check
+that they are acquired by crowdsec with the expected labels.type.
+
+The supported services are: ` + strings.Join(serviceNames(), ", ") + `.
+
+The command fails if a log destination is not acquired.`,
+		Example: `cscli detect-config nginx
+cscli detect-config apache2 --service-config /etc/httpd/conf/httpd.conf
+cscli detect-config sshd -o json`,
+		ValidArgs:         serviceNames(),
+		Args:              cobra.MatchAll(args.ExactArgs(1), cobra.OnlyValidArgs),
+		DisableAutoGenTag: true,
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			return require.Agent(cli.cfg())
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			return cli.detect(color.Output, args[0], serviceConfig)
+		},
+	}
+
+	cmd.Flags().StringVar(&serviceConfig, "service-config", "", "path of the configuration of the service (default: the usual locations)")
+
+	return cmd
+}
+
+func (cli *cliDetectConfig) detect(out io.Writer, name string, configPath string) error {
+	cfg := cli.cfg()
+	svc := services[name]
+
+	if configPath == "" {
+		for _, p := range svc.configPaths {
+			if _, err := os.Stat(p); err == nil {
+				configPath = p
+				break
+			}
+		}
+
+		if configPath == "" {
+			return fmt.Errorf("configuration of %s not found in %s: use --service-config", name, strings.Join(svc.configPaths, ", "))
+		}
+	}
+
+	dests, err := svc.destinations(configPath)
+	if err != nil {
+		return fmt.Errorf("while reading the configuration of %s: %w", name, err)
+	}
+
+	acquis, err := loadAcquisition(cfg.Crowdsec.AcquisitionFiles)
+	if err != nil {
+		return err
+	}
+
+	r := report{
+		Service:  name,
+		Config:   configPath,
+		Findings: check(svc, dests, acquis),
+	}
+
+	switch cfg.Cscli.Output {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(r); err != nil {
+			return errors.New("failed to serialize")
+		}
+	default:
+		cli.showHuman(out, &r)
+	}
+
+	if n := r.failures(); n > 0 {
+		return fmt.Errorf("%d log destination(s) of %s are not acquired as expected", n, name)
+	}
+
+	return nil
+}
+
+func (cli *cliDetectConfig) showHuman(out io.Writer, r *report) {
+	fmt.Fprintf(out, "%s configuration: %s\n", r.Service, r.Config)
+
+	if len(r.Findings) == 0 {
+		fmt.Fprintln(out, "No log destination found.")
+		return
+	}
+
+	t := cstable.NewLight(out, cli.cfg().Cscli.Color).Writer
+	t.AppendHeader(table.Row{"Kind", "Destination", "Defined in", "Status", "Acquisition", "Detail"})
+
+	for _, f := range r.Findings {
+		status := f.Status
+
+		switch f.Status {
+		case statusAcquired, statusElsewhere:
+			status = emoji.CheckMark + " " + status
+		case statusMissing, statusWrongType:
+			status = emoji.CrossMark + " " + status
+		case statusUnchecked:
+			status = emoji.QuestionMark + " " + status
+		}
+
+		t.AppendRow(table.Row{f.Kind, f.Target, f.Origin, status, f.Acquisition, f.Detail})
+	}
+
+	fmt.Fprintln(out, t.Render())
+}
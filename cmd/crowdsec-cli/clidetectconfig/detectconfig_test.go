@@ -0,0 +1,153 @@
+package clidetectconfig
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type expectedFinding struct {
+	kind   string
+	target string
+	origin string
+	status string
+}
+
+func requireFindings(t *testing.T, expected []expectedFinding, findings []finding) {
+	t.Helper()
+
+	require.Len(t, findings, len(expected))
+
+	for i, e := range expected {
+		assert.Equal(t, e.kind, findings[i].Kind, "finding %d", i)
+		assert.Equal(t, e.target, findings[i].Target, "finding %d", i)
+		assert.Equal(t, e.origin, findings[i].Origin, "finding %d", i)
+		assert.Equal(t, e.status, findings[i].Status, "finding %d", i)
+	}
+}
+
+func TestDetectConfig(t *testing.T) {
+	acquis, err := loadAcquisition([]string{"testdata/acquis.yaml"})
+	require.NoError(t, err)
+	require.Len(t, acquis, 4)
+	assert.Equal(t, "file", acquis[0].Source)
+
+	tests := []struct {
+		name     string
+		service  string
+		config   string
+		expected []expectedFinding
+	}{
+		{
+			name:    "nginx",
+			service: "nginx",
+			config:  "testdata/nginx/nginx.conf",
+			expected: []expectedFinding{
+				{kindFile, "/var/log/nginx/error.log", "testdata/nginx/nginx.conf:4", statusAcquired},
+				{kindFile, "/var/log/nginx/access.log", "testdata/nginx/nginx.conf:9", statusAcquired},
+				{kindFile, "/srv/app/logs/access.log", "testdata/nginx/conf.d/app.conf:4", statusMissing},
+				{kindSyslog, "10.0.0.1:5140", "testdata/nginx/conf.d/app.conf:5", statusAcquired},
+				{kindFile, "/var/log/nginx/$host.log", "testdata/nginx/conf.d/app.conf:12", statusUnchecked},
+			},
+		},
+		{
+			name:    "apache2",
+			service: "apache2",
+			config:  "testdata/apache/apache2.conf",
+			expected: []expectedFinding{
+				{kindFile, "/var/log/apache2/error.log", "testdata/apache/apache2.conf:2", statusMissing},
+				{kindFile, "/var/log/apache2/access.log", "testdata/apache/sites-enabled/000-default.conf:3", statusWrongType},
+				{kindFile, "|/usr/bin/rotatelogs /var/log/apache2/rotated.%Y 86400", "testdata/apache/sites-enabled/000-default.conf:5", statusUnchecked},
+				{kindFile, filepath.Join("testdata", "apache", "logs", "transfer.log"), "testdata/apache/sites-enabled/000-default.conf:6", statusMissing},
+				{kindSyslog, "syslog:local1", "testdata/apache/sites-enabled/000-default.conf:7", statusUnchecked},
+			},
+		},
+		{
+			name:    "sshd",
+			service: "sshd",
+			config:  "testdata/sshd/sshd_config",
+			expected: []expectedFinding{
+				{kindFile, "/var/log/auth.log", "testdata/sshd/rsyslog.d/10-auth.conf:1", statusElsewhere},
+				{kindJournald, "ssh,sshd", "", statusAcquired},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := services[tc.service]
+
+			var (
+				dests []destination
+				err   error
+			)
+
+			if tc.service == "sshd" {
+				dests, err = sshdDestinations(tc.config, "testdata/sshd/rsyslog.conf")
+			} else {
+				dests, err = svc.destinations(tc.config)
+			}
+
+			require.NoError(t, err)
+
+			requireFindings(t, tc.expected, check(svc, dests, acquis))
+		})
+	}
+}
+
+func TestSSHDWithoutRsyslog(t *testing.T) {
+	dests, err := sshdDestinations("testdata/sshd/sshd_config", "testdata/sshd/missing.conf")
+	require.NoError(t, err)
+
+	findings := check(services["sshd"], dests, nil)
+	requireFindings(t, []expectedFinding{{kindJournald, "ssh,sshd", "", statusMissing}}, findings)
+	assert.Equal(t, "sshd (authpriv)", findings[0].Group)
+
+	r := report{Findings: findings}
+	assert.Equal(t, 1, r.failures())
+}
+
+func TestRsyslogSelects(t *testing.T) {
+	tests := []struct {
+		selector string
+		facility string
+		expected bool
+	}{
+		{"auth,authpriv.*", "auth", true},
+		{"authpriv.*", "auth", false},
+		{"*.*;auth,authpriv.none", "auth", false},
+		{"*.*;auth,authpriv.none", "local0", true},
+		{"auth.info", "auth", true},
+		{"auth.notice", "auth", false},
+		{"auth.=info", "auth", true},
+		{"auth.!notice", "auth", true},
+		{"*.emerg", "auth", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.selector+" "+tc.facility, func(t *testing.T) {
+			assert.Equal(t, tc.expected, rsyslogSelects(tc.selector, tc.facility))
+		})
+	}
+}
+
+func TestReadsJournal(t *testing.T) {
+	tests := []struct {
+		filters  []string
+		expected bool
+	}{
+		{[]string{"_SYSTEMD_UNIT=ssh.service"}, true},
+		{[]string{"_SYSTEMD_UNIT=sshd.service"}, true},
+		{[]string{"-u", "ssh"}, true},
+		{[]string{"--unit=sshd.service"}, true},
+		{[]string{"SYSLOG_IDENTIFIER=sshd"}, true},
+		{[]string{"_SYSTEMD_UNIT=nginx.service"}, false},
+	}
+
+	for _, tc := range tests {
+		e := acquisEntry{Source: "journalctl", JournalctlFilter: tc.filters}
+		assert.Equal(t, tc.expected, e.readsJournal([]string{"ssh", "sshd"}), tc.filters)
+	}
+}
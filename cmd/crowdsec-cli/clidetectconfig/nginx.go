@@ -0,0 +1,242 @@
+package clidetectconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type nginxToken struct {
+	value  string
+	line   int
+	quoted bool
+}
+
+// nginxTokens splits a configuration file into words, and the ";", "{" and "}" separators.
+func nginxTokens(data string) []nginxToken {
+	var (
+		ret   []nginxToken
+		word  strings.Builder
+		quote rune
+	)
+
+	line := 1
+	start := 1
+
+	flush := func(quoted bool) {
+		if word.Len() > 0 || quoted {
+			ret = append(ret, nginxToken{value: word.String(), line: start, quoted: quoted})
+		}
+
+		word.Reset()
+	}
+
+	runes := []rune(data)
+
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+
+		if c == '\n' {
+			line++
+		}
+
+		if quote != 0 {
+			switch c {
+			case '\\':
+				if i+1 < len(runes) {
+					i++
+					word.WriteRune(runes[i])
+				}
+			case quote:
+				flush(true)
+
+				quote = 0
+			default:
+				word.WriteRune(c)
+			}
+
+			continue
+		}
+
+		switch c {
+		case '#':
+			flush(false)
+
+			for i < len(runes)-1 && runes[i+1] != '\n' {
+				i++
+			}
+		case '"', '\'':
+			flush(false)
+
+			quote = c
+			start = line
+		case ';', '{', '}':
+			flush(false)
+
+			ret = append(ret, nginxToken{value: string(c), line: line})
+		case ' ', '\t', '\r', '\n':
+			flush(false)
+		default:
+			if word.Len() == 0 {
+				start = line
+			}
+
+			word.WriteRune(c)
+		}
+	}
+
+	flush(false)
+
+	return ret
+}
+
+type nginxParser struct {
+	root  string
+	seen  map[string]bool
+	dests []destination
+}
+
+// nginxDestinations returns the files of the access_log and error_log directives, in the
+// configuration and the included files.
+func nginxDestinations(configPath string) ([]destination, error) {
+	p := nginxParser{
+		root: filepath.Dir(configPath),
+		seen: map[string]bool{},
+	}
+
+	if err := p.parseFile(configPath); err != nil {
+		return nil, err
+	}
+
+	return dedupDestinations(p.dests), nil
+}
+
+func (p *nginxParser) parseFile(path string) error {
+	if p.seen[path] {
+		return nil
+	}
+
+	p.seen[path] = true
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var words []nginxToken
+
+	for _, tok := range nginxTokens(string(data)) {
+		if tok.quoted {
+			words = append(words, tok)
+			continue
+		}
+
+		switch tok.value {
+		case ";":
+			if err := p.directive(path, words); err != nil {
+				return err
+			}
+
+			words = nil
+		case "{", "}":
+			words = nil
+		default:
+			words = append(words, tok)
+		}
+	}
+
+	return nil
+}
+
+func (p *nginxParser) directive(file string, words []nginxToken) error {
+	if len(words) < 2 {
+		return nil
+	}
+
+	origin := fmt.Sprintf("%s:%d", file, words[0].line)
+	arg := words[1].value
+
+	switch words[0].value {
+	case "include":
+		pattern := arg
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(p.root, pattern)
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("%s: %w", origin, err)
+		}
+
+		for _, m := range matches {
+			if err := p.parseFile(m); err != nil {
+				return err
+			}
+		}
+	case "access_log", "error_log":
+		if arg == "off" {
+			return nil
+		}
+
+		p.dests = append(p.dests, p.logDestination(arg, origin))
+	}
+
+	return nil
+}
+
+func (p *nginxParser) logDestination(target string, origin string) destination {
+	if params, ok := strings.CutPrefix(target, "syslog:"); ok {
+		d := destination{Kind: kindSyslog, Target: "unix:/dev/log", Origin: origin}
+
+		for param := range strings.SplitSeq(params, ",") {
+			if server, ok := strings.CutPrefix(param, "server="); ok {
+				d.Target = server
+			}
+		}
+
+		if strings.HasPrefix(d.Target, "unix:") {
+			d.Unchecked = "sent to the local syslog daemon"
+		}
+
+		return d
+	}
+
+	if !filepath.IsAbs(target) && target != "stderr" {
+		target = filepath.Join(p.root, target)
+	}
+
+	return fileDestination(target, origin)
+}
+
+func fileDestination(path string, origin string) destination {
+	d := destination{Kind: kindFile, Target: path, Origin: origin}
+
+	switch {
+	case path == "stderr" || path == "/dev/stderr" || path == "/dev/stdout":
+		d.Unchecked = "written to the standard output of the service"
+	case strings.Contains(path, "$"):
+		d.Unchecked = "the path has variables"
+	}
+
+	return d
+}
+
+// dedupDestinations removes the destinations that are defined several times, keeping the first one.
+func dedupDestinations(dests []destination) []destination {
+	ret := make([]destination, 0, len(dests))
+	seen := map[string]bool{}
+
+	for _, d := range dests {
+		key := d.Kind + " " + d.Target
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+
+		ret = append(ret, d)
+	}
+
+	return ret
+}
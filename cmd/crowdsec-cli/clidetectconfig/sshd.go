@@ -0,0 +1,266 @@
+package clidetectconfig
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const rsyslogConfigPath = "/etc/rsyslog.conf"
+
+// sshd logs at the info level, by default
+const sshdPriority = 6
+
+var syslogPriorities = map[string]int{
+	"emerg":   0,
+	"panic":   0,
+	"alert":   1,
+	"crit":    2,
+	"err":     3,
+	"error":   3,
+	"warning": 4,
+	"warn":    4,
+	"notice":  5,
+	"info":    6,
+	"debug":   7,
+}
+
+// sshdFacility returns the value of SyslogFacility, in the configuration or the included files.
+// The first value is used, as sshd does.
+func sshdFacility(path string, seen map[string]bool) (string, error) {
+	if seen[path] {
+		return "", nil
+	}
+
+	seen[path] = true
+
+	fd, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		switch strings.ToLower(fields[0]) {
+		case "syslogfacility":
+			return strings.ToLower(fields[1]), nil
+		case "include":
+			for _, pattern := range fields[1:] {
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(filepath.Dir(path), pattern)
+				}
+
+				matches, err := filepath.Glob(pattern)
+				if err != nil {
+					return "", err
+				}
+
+				for _, m := range matches {
+					facility, err := sshdFacility(m, seen)
+					if err != nil {
+						return "", err
+					}
+
+					if facility != "" {
+						return facility, nil
+					}
+				}
+			}
+		}
+	}
+
+	return "", scanner.Err()
+}
+
+// sshdDestinations returns where the logs of sshd go: the files of the rsyslog rules that
+// select its facility, and the journal. Any of them can be acquired.
+func sshdDestinations(configPath string, rsyslogPath string) ([]destination, error) {
+	facility, err := sshdFacility(configPath, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+
+	if facility == "" {
+		facility = "auth"
+	}
+
+	dests := []destination{}
+
+	if _, err := os.Stat(rsyslogPath); err == nil {
+		r := rsyslogParser{facility: facility, seen: map[string]bool{}}
+		if err := r.parseFile(rsyslogPath); err != nil {
+			return nil, err
+		}
+
+		dests = append(dests, r.dests...)
+	}
+
+	dests = append(dests, destination{Kind: kindJournald, Target: "ssh,sshd"})
+
+	for i := range dests {
+		dests[i].Group = "sshd (" + facility + ")"
+	}
+
+	return dedupDestinations(dests), nil
+}
+
+// rsyslogParser finds the files where the messages of a facility are written. Only the
+// traditional selector lines, and the includes, are understood.
+type rsyslogParser struct {
+	facility string
+	seen     map[string]bool
+	stopped  bool
+	dests    []destination
+}
+
+// rsyslogSelects returns true if a selector, like "*.*;auth,authpriv.none", matches the messages
+// of sshd. The last part that names the facility wins.
+func rsyslogSelects(selector string, facility string) bool {
+	selected := false
+
+	for part := range strings.SplitSeq(selector, ";") {
+		facilities, priority, ok := strings.Cut(part, ".")
+		if !ok {
+			continue
+		}
+
+		match := false
+
+		for f := range strings.SplitSeq(facilities, ",") {
+			if f == "*" || f == facility {
+				match = true
+			}
+		}
+
+		if match {
+			selected = rsyslogPriority(priority)
+		}
+	}
+
+	return selected
+}
+
+func rsyslogPriority(priority string) bool {
+	switch {
+	case priority == "none":
+		return false
+	case priority == "*":
+		return true
+	case strings.HasPrefix(priority, "!"):
+		return !rsyslogPriority(priority[1:])
+	case strings.HasPrefix(priority, "="):
+		return syslogPriorities[priority[1:]] == sshdPriority
+	}
+
+	level, ok := syslogPriorities[priority]
+
+	return ok && level >= sshdPriority
+}
+
+func (r *rsyslogParser) include(from string, pattern string) error {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(from), pattern)
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range matches {
+		if err := r.parseFile(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *rsyslogParser) parseFile(path string) error {
+	if r.seen[path] {
+		return nil
+	}
+
+	r.seen[path] = true
+
+	fd, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+	lineNumber := 0
+	// the previous rule selected the facility, for "& stop"
+	previous := false
+
+	for scanner.Scan() && !r.stopped {
+		lineNumber++
+
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if pattern, ok := strings.CutPrefix(line, "$IncludeConfig "); ok {
+			if err := r.include(path, strings.TrimSpace(pattern)); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if strings.HasPrefix(line, "include(") {
+			if _, rest, ok := strings.Cut(line, `file="`); ok {
+				if pattern, _, ok := strings.Cut(rest, `"`); ok {
+					if err := r.include(path, pattern); err != nil {
+						return err
+					}
+				}
+			}
+
+			continue
+		}
+
+		if action, ok := strings.CutPrefix(line, "&"); ok {
+			action = strings.TrimSpace(action)
+			if previous && (action == "stop" || action == "~") {
+				r.stopped = true
+			}
+
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(line, "$") || !strings.Contains(fields[0], ".") {
+			previous = false
+			continue
+		}
+
+		previous = rsyslogSelects(fields[0], r.facility)
+		if !previous {
+			continue
+		}
+
+		action := strings.TrimPrefix(fields[1], "-")
+
+		switch {
+		case filepath.IsAbs(action):
+			r.dests = append(r.dests, fileDestination(action, fmt.Sprintf("%s:%d", path, lineNumber)))
+		case action == "stop" || action == "~":
+			r.stopped = true
+		}
+	}
+
+	return scanner.Err()
+}
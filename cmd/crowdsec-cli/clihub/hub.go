@@ -53,12 +53,14 @@ cscli hub upgrade`,
 	cmd.AddCommand(cli.newListCmd())
 	cmd.AddCommand(cli.newUpdateCmd())
 	cmd.AddCommand(cli.newUpgradeCmd())
+	cmd.AddCommand(cli.newRollbackCmd())
 	cmd.AddCommand(cli.newTypesCmd())
 
 	return cmd
 }
 
-func (cli *cliHub) List(out io.Writer, hub *cwhub.Hub, all bool) error {
+// List shows the items of the hub, with their counters in the running crowdsec if itemMetrics is not nil.
+func (cli *cliHub) List(out io.Writer, hub *cwhub.Hub, all bool, itemMetrics ItemMetrics) error {
 	cfg := cli.cfg()
 
 	for _, v := range hub.Warnings {
@@ -80,7 +82,7 @@ func (cli *cliHub) List(out io.Writer, hub *cwhub.Hub, all bool) error {
 		}
 	}
 
-	err = ListItems(out, cfg.Cscli.Color, cwhub.ItemTypes, items, true, cfg.Cscli.Output)
+	err = ListItems(out, cfg.Cscli.Color, cwhub.ItemTypes, items, true, cfg.Cscli.Output, itemMetrics)
 	if err != nil {
 		return err
 	}
@@ -115,25 +117,57 @@ func (cli *cliHub) newBranchCmd() *cobra.Command {
 }
 
 func (cli *cliHub) newListCmd() *cobra.Command {
-	var all bool
+	var (
+		all         bool
+		withMetrics bool
+		url         string
+	)
 
 	cmd := &cobra.Command{
-		Use:               "list [-a]",
-		Short:             "List all installed configurations",
+		Use:   "list [-a]",
+		Short: "List all installed configurations",
+		Long: `List all installed configurations.
+
+With --with-metrics, the installed parsers, postoverflows, scenarios and appsec rules also show
+how many times they matched in the running crowdsec (events parsed, poured in a bucket, or matched
+by an appsec rule), and the -o json output has their detailed counters. The counters are reset
+when crowdsec restarts.`,
+		Example: `cscli hub list
+cscli hub list -a
+cscli hub list --with-metrics
+# installed scenarios that never matched
+cscli hub list -o json --with-metrics | jq -r '.scenarios[] | select(.matches == 0) | .name'`,
 		Args:              args.NoArgs,
 		DisableAutoGenTag: true,
-		RunE: func(_ *cobra.Command, _ []string) error {
-			hub, err := require.Hub(cli.cfg(), log.StandardLogger())
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := cli.cfg()
+
+			hub, err := require.Hub(cfg, log.StandardLogger())
 			if err != nil {
 				return err
 			}
 
-			return cli.List(color.Output, hub, all)
+			var itemMetrics ItemMetrics
+
+			if withMetrics {
+				if url == "" {
+					url = cfg.Cscli.PrometheusUrl
+				}
+
+				itemMetrics, err = FetchItemMetrics(cmd.Context(), url)
+				if err != nil {
+					return err
+				}
+			}
+
+			return cli.List(color.Output, hub, all, itemMetrics)
 		},
 	}
 
 	flags := cmd.Flags()
 	flags.BoolVarP(&all, "all", "a", false, "List all available items, including those not installed")
+	flags.BoolVar(&withMetrics, "with-metrics", false, "Show the matches of the installed items in the running crowdsec")
+	flags.StringVarP(&url, "url", "u", "", "Prometheus url (with --with-metrics)")
 
 	return cmd
 }
@@ -201,7 +235,42 @@ cscli hub update --with-content`,
 	return cmd
 }
 
-func (cli *cliHub) upgrade(ctx context.Context, interactive bool, dryRun bool, force bool) error {
+// upgradePlan returns the action plan to upgrade all the installed items of a hub.
+func upgradePlan(hub *cwhub.Hub, contentProvider cwhub.ContentProvider, force bool) (*hubops.ActionPlan, error) {
+	plan := hubops.NewActionPlan(hub)
+
+	for _, itemType := range cwhub.ItemTypes {
+		for _, item := range hub.GetInstalledByType(itemType, true) {
+			if err := plan.AddCommand(hubops.NewDownloadCommand(item, contentProvider, force)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := plan.AddCommand(hubops.NewDataRefreshCommand(force)); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+func printPlan(plan *hubops.ActionPlan) {
+	fmt.Fprintln(os.Stdout, "Upgrade plan:\n"+plan.Description(true))
+
+	if dataFiles := plan.DataFiles(); len(dataFiles) > 0 {
+		fmt.Fprintln(os.Stdout, "Data files:")
+
+		for _, d := range dataFiles {
+			fmt.Fprintf(os.Stdout, " %s (%s)\n", d.DestPath, d.SourceURL)
+		}
+
+		fmt.Fprintln(os.Stdout)
+	}
+
+	fmt.Fprintln(os.Stdout, "No action taken.")
+}
+
+func (cli *cliHub) upgrade(ctx context.Context, interactive bool, dryRun bool, planOnly bool, force bool) error {
 	cfg := cli.cfg()
 
 	hub, err := require.Hub(cfg, log.StandardLogger())
@@ -209,25 +278,42 @@ func (cli *cliHub) upgrade(ctx context.Context, interactive bool, dryRun bool, f
 		return err
 	}
 
-	plan := hubops.NewActionPlan(hub)
-
 	contentProvider, err := require.HubDownloader(ctx, cfg)
 	if err != nil {
 		return err
 	}
 
-	for _, itemType := range cwhub.ItemTypes {
-		for _, item := range hub.GetInstalledByType(itemType, true) {
-			if err := plan.AddCommand(hubops.NewDownloadCommand(item, contentProvider, force)); err != nil {
-				return err
+	var tx *hubops.Transaction
+
+	// the upgrade is applied to a copy of the hub, which replaces the live one only if it succeeds
+	if !dryRun && !planOnly {
+		tx, err = hubops.NewTransaction(hub, cfg.Hub)
+		if err != nil {
+			return fmt.Errorf("while preparing the staging directory: %w", err)
+		}
+
+		defer func() {
+			if err := tx.Abort(); err != nil {
+				log.Warningf("while removing the staging directory: %s", err)
 			}
+		}()
+
+		hub, err = tx.Hub(log.StandardLogger())
+		if err != nil {
+			return err
 		}
 	}
 
-	if err := plan.AddCommand(hubops.NewDataRefreshCommand(force)); err != nil {
+	plan, err := upgradePlan(hub, contentProvider, force)
+	if err != nil {
 		return err
 	}
 
+	if planOnly {
+		printPlan(plan)
+		return nil
+	}
+
 	showPlan := (log.StandardLogger().Level >= log.InfoLevel)
 	verbosePlan := (cfg.Cscli.Output == "raw")
 
@@ -240,7 +326,20 @@ func (cli *cliHub) upgrade(ctx context.Context, interactive bool, dryRun bool, f
 		fmt.Fprintln(os.Stdout, err.Error())
 	}
 
-	if msg := reload.UserMessage(); msg != "" && plan.ReloadNeeded {
+	// nothing changed: keep the previous state for rollback
+	if tx == nil || !plan.ReloadNeeded {
+		return nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("while replacing the hub with the upgraded one: %w", err)
+	}
+
+	if log.StandardLogger().Level >= log.InfoLevel {
+		fmt.Fprintln(os.Stdout, "\nThe previous state of the hub was saved, run 'cscli hub rollback' to restore it.")
+	}
+
+	if msg := reload.UserMessage(); msg != "" {
 		fmt.Fprintln(os.Stdout, "\n"+msg)
 	}
 
@@ -251,6 +350,7 @@ func (cli *cliHub) newUpgradeCmd() *cobra.Command {
 	var (
 		interactive bool
 		dryRun      bool
+		planOnly    bool
 		force       bool
 	)
 
@@ -259,6 +359,9 @@ func (cli *cliHub) newUpgradeCmd() *cobra.Command {
 		Short: "Upgrade all configurations to their latest version",
 		Long: `
 Upgrade all configs installed from Crowdsec Hub. Run 'sudo cscli hub update' if you want the latest versions available.
+
+The upgrade is applied to a copy of the hub (items, links and data files), which replaces the current one only if
+all the downloads succeed. The replaced state is kept and can be restored with 'cscli hub rollback'.
 `,
 		Example: `# Upgrade all the collections, scenarios etc. to the latest version in the downloaded index. Update data files too.
 cscli hub upgrade
@@ -266,21 +369,64 @@ cscli hub upgrade
 # Upgrade tainted items as well; force re-download of data files.
 cscli hub upgrade --force
 
+# Show the items that would be upgraded, with their versions, and the data files to check; don't change anything.
+cscli hub upgrade --plan
+
 # Prompt for confirmation if running in an interactive terminal; otherwise, the option is ignored.
 cscli hub upgrade --interactive
 cscli hub upgrade -i`,
 		Args:              args.NoArgs,
 		DisableAutoGenTag: true,
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return cli.upgrade(cmd.Context(), interactive, dryRun, force)
+			return cli.upgrade(cmd.Context(), interactive, dryRun, planOnly, force)
 		},
 	}
 
 	flags := cmd.Flags()
 	flags.BoolVarP(&interactive, "interactive", "i", false, "Ask for confirmation before proceeding")
 	flags.BoolVar(&dryRun, "dry-run", false, "Don't install or remove anything; print the execution plan")
+	flags.BoolVar(&planOnly, "plan", false, "Don't install or remove anything; print the detailed plan, including data files")
 	flags.BoolVar(&force, "force", false, "Force upgrade: overwrite tainted and outdated items; always update data files")
-	cmd.MarkFlagsMutuallyExclusive("interactive", "dry-run")
+	cmd.MarkFlagsMutuallyExclusive("interactive", "dry-run", "plan")
+
+	return cmd
+}
+
+func (cli *cliHub) rollback() error {
+	local := cli.cfg().Hub
+
+	if local == nil {
+		return errors.New("you must configure cli before interacting with hub")
+	}
+
+	if err := hubops.Rollback(local); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stdout, "The hub was restored to its state before the last upgrade.")
+
+	if msg := reload.UserMessage(); msg != "" {
+		fmt.Fprintln(os.Stdout, "\n"+msg)
+	}
+
+	return nil
+}
+
+func (cli *cliHub) newRollbackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Restore the hub to its state before the last upgrade",
+		Long: `
+Restore the items, links and data files replaced by the last 'cscli hub upgrade', for example if the new parsers don't work as expected.
+The upgraded state is kept in turn, so running 'cscli hub rollback' again applies the upgrade back.
+`,
+		Example:           `cscli hub rollback`,
+		Args:              args.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return cli.rollback()
+		},
+	}
 
 	return cmd
 }
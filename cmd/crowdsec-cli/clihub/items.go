@@ -54,7 +54,9 @@ func SelectItems(hub *cwhub.Hub, itemType string, args []string, installedOnly b
 	return wantedItems, nil
 }
 
-func ListItems(out io.Writer, wantColor string, itemTypes []string, items map[string][]*cwhub.Item, omitIfEmpty bool, output string) error {
+// ListItems shows the items in the requested output format. With itemMetrics, the installed items
+// also have their counters and matches in the running crowdsec.
+func ListItems(out io.Writer, wantColor string, itemTypes []string, items map[string][]*cwhub.Item, omitIfEmpty bool, output string, itemMetrics ItemMetrics) error {
 	switch output {
 	case "human":
 		nothingToDisplay := true
@@ -64,7 +66,7 @@ func ListItems(out io.Writer, wantColor string, itemTypes []string, items map[st
 				continue
 			}
 
-			listHubItemTable(out, wantColor, strings.ToUpper(itemType), items[itemType])
+			listHubItemTable(out, wantColor, strings.ToUpper(itemType), items[itemType], itemMetrics)
 
 			nothingToDisplay = false
 		}
@@ -80,6 +82,9 @@ func ListItems(out io.Writer, wantColor string, itemTypes []string, items map[st
 			Description  string `json:"description"`
 			UTF8Status   string `json:"utf8_status"`
 			Status       string `json:"status"`
+			// only with the metrics, for the installed items
+			Metrics map[string]int `json:"metrics,omitempty"`
+			Matches *int           `json:"matches,omitempty"`
 		}
 
 		hubStatus := make(map[string][]itemHubStatus)
@@ -98,6 +103,13 @@ func ListItems(out io.Writer, wantColor string, itemTypes []string, items map[st
 					Status:       status,
 					UTF8Status:   fmt.Sprintf("%v  %s", statusEmo, status),
 				}
+
+				if itemMetrics != nil {
+					if counters, matches, ok := itemMetrics.counters(item); ok {
+						hubStatus[itemType][i].Metrics = counters
+						hubStatus[itemType][i].Matches = &matches
+					}
+				}
 			}
 		}
 
@@ -115,6 +127,10 @@ func ListItems(out io.Writer, wantColor string, itemTypes []string, items map[st
 			header = append(header, "type")
 		}
 
+		if itemMetrics != nil {
+			header = append(header, "matches")
+		}
+
 		if err := csvwriter.Write(header); err != nil {
 			return fmt.Errorf("failed to write header: %w", err)
 		}
@@ -131,6 +147,10 @@ func ListItems(out io.Writer, wantColor string, itemTypes []string, items map[st
 					row = append(row, itemType)
 				}
 
+				if itemMetrics != nil {
+					row = append(row, matchesText(itemMetrics, item))
+				}
+
 				if err := csvwriter.Write(row); err != nil {
 					return fmt.Errorf("failed to write raw output: %w", err)
 				}
@@ -0,0 +1,129 @@
+package clihub
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/climetrics"
+	"github.com/crowdsecurity/crowdsec/pkg/cwhub"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+// ItemMetrics are the counters of the hub items in the running crowdsec, by item name,
+// summed over the sources. They are reset when crowdsec restarts.
+type ItemMetrics map[string]map[string]int
+
+// FetchItemMetrics reads the counters of the parsers, postoverflows, scenarios and appsec rules.
+func FetchItemMetrics(ctx context.Context, url string) (ItemMetrics, error) {
+	points, err := climetrics.ScrapeMetrics(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("can't read the metrics from %s, is crowdsec running? %w", url, err)
+	}
+
+	return newItemMetrics(points), nil
+}
+
+func newItemMetrics(points []climetrics.MetricPoint) ItemMetrics {
+	ret := ItemMetrics{}
+
+	for _, p := range points {
+		if !strings.HasPrefix(p.Name, "cs_") {
+			continue
+		}
+
+		name, ok := p.Labels["name"]
+		if !ok {
+			continue
+		}
+
+		var counter string
+
+		switch p.Name {
+		case metrics.NodesHitsMetricName:
+			counter = "hits"
+		case metrics.NodesHitsOkMetricName:
+			counter = "parsed"
+		case metrics.NodesHitsKoMetricName:
+			counter = "unparsed"
+		case metrics.BucketsInstantiationMetricName:
+			counter = "instantiation"
+		case metrics.BucketsCurrentCountMetricName:
+			counter = "curr_count"
+		case metrics.BucketsOverflowMetricName:
+			counter = "overflow"
+		case metrics.BucketPouredMetricName:
+			counter = "pour"
+		case metrics.BucketsUnderflowMetricName:
+			counter = "underflow"
+		case metrics.AppsecRuleHitsMetricName:
+			switch p.Labels["type"] {
+			case "inband":
+				counter = "inband_hits"
+			case "outband":
+				counter = "outband_hits"
+			default:
+				continue
+			}
+		default:
+			continue
+		}
+
+		if _, ok := ret[name]; !ok {
+			ret[name] = map[string]int{}
+		}
+
+		ret[name][counter] += int(p.Value)
+	}
+
+	return ret
+}
+
+// counters returns the counters of an installed item, and how many times it matched: the events
+// parsed by a parser or postoverflow, poured in a scenario, or matched by an appsec rule.
+// ok is false for the item types without metrics.
+func (m ItemMetrics) counters(item *cwhub.Item) (counters map[string]int, matches int, ok bool) {
+	var keys []string
+
+	switch item.Type {
+	case cwhub.PARSERS, cwhub.POSTOVERFLOWS:
+		keys = []string{"hits", "parsed", "unparsed"}
+	case cwhub.SCENARIOS:
+		keys = []string{"instantiation", "curr_count", "overflow", "pour", "underflow"}
+	case cwhub.APPSEC_RULES:
+		keys = []string{"inband_hits", "outband_hits"}
+	default:
+		return nil, 0, false
+	}
+
+	if !item.State.IsInstalled() {
+		return nil, 0, false
+	}
+
+	counters = make(map[string]int, len(keys))
+	for _, k := range keys {
+		counters[k] = m[item.Name][k]
+	}
+
+	switch item.Type {
+	case cwhub.SCENARIOS:
+		matches = counters["pour"]
+	case cwhub.APPSEC_RULES:
+		matches = counters["inband_hits"] + counters["outband_hits"]
+	default:
+		matches = counters["parsed"]
+	}
+
+	return counters, matches, true
+}
+
+// matchesText is the number of matches of an item, empty if it has no metrics.
+func matchesText(m ItemMetrics, item *cwhub.Item) string {
+	_, matches, ok := m.counters(item)
+	if !ok {
+		return ""
+	}
+
+	return strconv.Itoa(matches)
+}
@@ -0,0 +1,60 @@
+package clihub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/climetrics"
+	"github.com/crowdsecurity/crowdsec/pkg/cwhub"
+)
+
+func TestItemMetrics(t *testing.T) {
+	points := []climetrics.MetricPoint{
+		{Name: "cs_node_hits_total", Value: 10, Labels: map[string]string{"name": "crowdsecurity/nginx-logs", "source": "/var/log/nginx/access.log"}},
+		{Name: "cs_node_hits_total", Value: 5, Labels: map[string]string{"name": "crowdsecurity/nginx-logs", "source": "/var/log/nginx/error.log"}},
+		{Name: "cs_node_hits_ok_total", Value: 12, Labels: map[string]string{"name": "crowdsecurity/nginx-logs"}},
+		{Name: "cs_node_hits_ko_total", Value: 3, Labels: map[string]string{"name": "crowdsecurity/nginx-logs"}},
+		{Name: "cs_bucket_poured_total", Value: 7, Labels: map[string]string{"name": "crowdsecurity/http-probing"}},
+		{Name: "cs_bucket_overflowed_total", Value: 1, Labels: map[string]string{"name": "crowdsecurity/http-probing"}},
+		{Name: "cs_appsec_rule_hits", Value: 2, Labels: map[string]string{"name": "crowdsecurity/vpatch-env-access", "type": "inband"}},
+		{Name: "cs_appsec_rule_hits", Value: 1, Labels: map[string]string{"name": "crowdsecurity/vpatch-env-access", "type": "outband"}},
+		// not a hub item
+		{Name: "cs_parser_hits_total", Value: 100, Labels: map[string]string{"source": "/var/log/syslog"}},
+		{Name: "go_goroutines", Value: 42, Labels: map[string]string{"name": "crowdsecurity/nginx-logs"}},
+	}
+
+	m := newItemMetrics(points)
+
+	installed := func(itemType string, name string) *cwhub.Item {
+		return &cwhub.Item{Type: itemType, Name: name, State: cwhub.ItemState{LocalPath: "/etc/crowdsec/" + itemType + "/" + name + ".yaml"}}
+	}
+
+	counters, matches, ok := m.counters(installed(cwhub.PARSERS, "crowdsecurity/nginx-logs"))
+	assert.True(t, ok)
+	assert.Equal(t, map[string]int{"hits": 15, "parsed": 12, "unparsed": 3}, counters)
+	assert.Equal(t, 12, matches)
+
+	counters, matches, ok = m.counters(installed(cwhub.SCENARIOS, "crowdsecurity/http-probing"))
+	assert.True(t, ok)
+	assert.Equal(t, map[string]int{"instantiation": 0, "curr_count": 0, "overflow": 1, "pour": 7, "underflow": 0}, counters)
+	assert.Equal(t, 7, matches)
+
+	_, matches, ok = m.counters(installed(cwhub.APPSEC_RULES, "crowdsecurity/vpatch-env-access"))
+	assert.True(t, ok)
+	assert.Equal(t, 3, matches)
+
+	// installed, never matched
+	_, matches, ok = m.counters(installed(cwhub.SCENARIOS, "crowdsecurity/ssh-bf"))
+	assert.True(t, ok)
+	assert.Equal(t, 0, matches)
+	assert.Equal(t, "0", matchesText(m, installed(cwhub.SCENARIOS, "crowdsecurity/ssh-bf")))
+
+	// no metrics for the collections and the items that are not installed
+	_, _, ok = m.counters(installed(cwhub.COLLECTIONS, "crowdsecurity/nginx"))
+	assert.False(t, ok)
+
+	_, _, ok = m.counters(&cwhub.Item{Type: cwhub.SCENARIOS, Name: "crowdsecurity/http-probing"})
+	assert.False(t, ok)
+	assert.Empty(t, matchesText(m, &cwhub.Item{Type: cwhub.SCENARIOS, Name: "crowdsecurity/http-probing"}))
+}
@@ -11,13 +11,25 @@ import (
 	"github.com/crowdsecurity/crowdsec/pkg/emoji"
 )
 
-func listHubItemTable(out io.Writer, wantColor string, title string, items []*cwhub.Item) {
+func listHubItemTable(out io.Writer, wantColor string, title string, items []*cwhub.Item, itemMetrics ItemMetrics) {
 	t := cstable.NewLight(out, wantColor).Writer
-	t.AppendHeader(table.Row{"Name", fmt.Sprintf("%v Status", emoji.Package), "Version", "Local Path"})
+
+	header := table.Row{"Name", fmt.Sprintf("%v Status", emoji.Package), "Version", "Local Path"}
+	if itemMetrics != nil {
+		header = append(header, "Matches")
+	}
+
+	t.AppendHeader(header)
 
 	for _, item := range items {
 		status := fmt.Sprintf("%v  %s", item.State.Emoji(), item.State.Text())
-		t.AppendRow(table.Row{item.Name, status, item.State.LocalVersion, item.State.LocalPath})
+		row := table.Row{item.Name, status, item.State.LocalVersion, item.State.LocalPath}
+
+		if itemMetrics != nil {
+			row = append(row, matchesText(itemMetrics, item))
+		}
+
+		t.AppendRow(row)
 	}
 
 	t.SetTitle(title)
@@ -19,7 +19,7 @@ import (
 	"github.com/crowdsecurity/crowdsec/pkg/hubtest"
 )
 
-func (cli *cliHubTest) run(ctx context.Context, all bool, nucleiTargetHost string, appSecHost string, args []string, maxJobs uint) error {
+func (cli *cliHubTest) run(ctx context.Context, all bool, nucleiTargetHost string, appSecHost string, args []string, parallel uint, shard string) error {
 	cfg := cli.cfg()
 
 	if !all && len(args) == 0 {
@@ -42,17 +42,30 @@ func (cli *cliHubTest) run(ctx context.Context, all bool, nucleiTargetHost strin
 		}
 	}
 
+	if shard != "" {
+		index, total, err := hubtest.ParseShard(shard)
+		if err != nil {
+			return err
+		}
+
+		hubPtr.Shard(index, total)
+
+		if cfg.Cscli.Output == "human" {
+			fmt.Fprintf(os.Stdout, "Running shard %d/%d (%d tests)\n", index, total, len(hubPtr.Tests))
+		}
+	}
+
 	patternDir := cfg.ConfigPaths.PatternDir
 
 	eg, gctx := errgroup.WithContext(ctx)
 
 	if isAppsecTest {
-		fmt.Fprintln(os.Stdout, "Appsec tests can not run in parallel: setting max_jobs=1")
+		fmt.Fprintln(os.Stdout, "Appsec tests can not run in parallel: setting parallel=1")
 
-		maxJobs = 1
+		parallel = 1
 	}
 
-	eg.SetLimit(int(maxJobs))
+	eg.SetLimit(int(max(parallel, 1)))
 
 	for _, test := range hubPtr.Tests {
 		if cfg.Cscli.Output == "human" {
@@ -217,20 +230,26 @@ func (cli *cliHubTest) newRunCmd() *cobra.Command {
 		forceClean       bool
 		nucleiTargetHost string
 		appSecHost       string
+		shard            string
 	)
 
-	maxJobs := uint(runtime.NumCPU())
+	parallel := uint(runtime.NumCPU())
 
 	cmd := &cobra.Command{
-		Use:               "run",
-		Short:             "run [test_name]",
+		Use:   "run",
+		Short: "run [test_name]",
+		Example: `cscli hubtest run my-test
+cscli hubtest run --all --parallel 8
+
+# split the tests across 4 CI jobs, this is the second one
+cscli hubtest run --all --shard 2/4`,
 		DisableAutoGenTag: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if all {
-				fmt.Fprintf(os.Stdout, "Running all tests (max_jobs: %d)\n", maxJobs)
+				fmt.Fprintf(os.Stdout, "Running all tests (parallel: %d)\n", parallel)
 			}
 
-			if err := cli.run(cmd.Context(), all, nucleiTargetHost, appSecHost, args, maxJobs); err != nil {
+			if err := cli.run(cmd.Context(), all, nucleiTargetHost, appSecHost, args, parallel, shard); err != nil {
 				return err
 			}
 
@@ -244,7 +263,10 @@ func (cli *cliHubTest) newRunCmd() *cobra.Command {
 	cmd.Flags().StringVar(&appSecHost, "host", hubtest.DefaultAppsecHost, "Address to expose AppSec for hubtest")
 	cmd.Flags().BoolVar(&all, "all", false, "Run all tests")
 	cmd.Flags().BoolVar(&reportSuccess, "report-success", false, "Report successful tests too (implied with json output)")
-	cmd.Flags().UintVar(&maxJobs, "max-jobs", maxJobs, "Max number of concurrent tests (does not apply to appsec)")
+	cmd.Flags().UintVarP(&parallel, "parallel", "j", parallel, "Max number of concurrent tests (does not apply to appsec)")
+	cmd.Flags().UintVar(&parallel, "max-jobs", parallel, "Max number of concurrent tests (does not apply to appsec)")
+	_ = cmd.Flags().MarkDeprecated("max-jobs", "use --parallel instead")
+	cmd.Flags().StringVar(&shard, "shard", "", "Only run the i-th of n groups of tests (i/n), to split them across CI jobs")
 
 	return cmd
 }
@@ -0,0 +1,243 @@
+package cliitem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/crowdsecurity/go-cs-lib/cstime"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/require"
+	"github.com/crowdsecurity/crowdsec/pkg/database"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/leakybucket"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+)
+
+// learnSamples builds a sample from the events of each alert. The source of an alert is malicious if
+// it received a decision from anything else than the given scenarios: another scenario, a blocklist or cscli.
+func learnSamples(ctx context.Context, db *database.Client, alerts []*ent.Alert, scenarios []string) ([]leakybucket.BayesianSample, int, error) {
+	samples := []leakybucket.BayesianSample{}
+	labels := map[string]bool{}
+	skipped := 0
+
+	for _, alert := range alerts {
+		if alert.SourceScope != types.Ip && alert.SourceScope != types.Range {
+			skipped++
+			continue
+		}
+
+		malicious, ok := labels[alert.SourceValue]
+		if !ok {
+			var err error
+
+			malicious, err = db.HasDecisionsFromOtherScenarios(ctx, alert.SourceValue, scenarios)
+			if err != nil {
+				return nil, 0, fmt.Errorf("alert %d: %w", alert.ID, err)
+			}
+
+			labels[alert.SourceValue] = malicious
+		}
+
+		events, err := alertEvents([]*ent.Alert{alert})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		samples = append(samples, leakybucket.BayesianSample{Events: events, Malicious: malicious})
+	}
+
+	return samples, skipped, nil
+}
+
+func (cli *cliItem) learn(ctx context.Context, scenarioFile string, fromScenarios []string, since cstime.DurationWithDays, limit int, writeFile string) error {
+	cfg := cli.cfg()
+
+	if limit < 0 {
+		return errors.New("--limit must be a positive number, or 0 for all alerts")
+	}
+
+	content, err := os.ReadFile(scenarioFile)
+	if err != nil {
+		return err
+	}
+
+	holders, _, err := loadScenarioFile(cfg, scenarioFile)
+	if err != nil {
+		return err
+	}
+
+	bayesian := []*leakybucket.BucketFactory{}
+	names := []string{}
+
+	for idx := range holders {
+		if holders[idx].Spec.Type == "bayesian" {
+			bayesian = append(bayesian, &holders[idx])
+			names = append(names, holders[idx].Spec.Name)
+		}
+	}
+
+	if len(bayesian) == 0 {
+		return fmt.Errorf("no bayesian scenario found in %s", scenarioFile)
+	}
+
+	// the alerts of the bayesian scenario(s), unless other names are provided
+	if len(fromScenarios) == 0 {
+		fromScenarios = names
+	}
+
+	db, err := require.DBClient(ctx, cfg.DbConfig)
+	if err != nil {
+		return err
+	}
+
+	alerts := []*ent.Alert{}
+
+	for _, name := range fromScenarios {
+		filter := map[string][]string{
+			"scenario":       {name},
+			"limit":          {strconv.Itoa(limit)},
+			"with_decisions": {"false"},
+		}
+
+		if since != 0 {
+			filter["since"] = []string{time.Duration(since).String()}
+		}
+
+		found, err := db.QueryAlertWithFilter(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("unable to fetch alerts of %s: %w", name, err)
+		}
+
+		alerts = append(alerts, found...)
+	}
+
+	// the decisions of the scenarios we learn from, or of the one we tune, don't tell anything
+	samples, skipped, err := learnSamples(ctx, db, alerts, slices.Concat(fromScenarios, names))
+	if err != nil {
+		return err
+	}
+
+	if skipped > 0 {
+		log.Warningf("%d alert(s) skipped: only the ip and range scopes can be labeled", skipped)
+	}
+
+	estimates := []*leakybucket.BayesianEstimate{}
+
+	for _, f := range bayesian {
+		est, err := leakybucket.LearnBayesian(f, samples)
+		if err != nil {
+			return err
+		}
+
+		estimates = append(estimates, est)
+	}
+
+	if writeFile != "" {
+		tuned, err := leakybucket.TuneBayesianScenario(content, estimates)
+		if err != nil {
+			return fmt.Errorf("while tuning %s: %w", scenarioFile, err)
+		}
+
+		if err := os.WriteFile(writeFile, tuned, 0o644); err != nil {
+			return err
+		}
+
+		log.Infof("Tuned scenario written to %s", writeFile)
+	}
+
+	return printLearnReport(os.Stdout, estimates, cfg.Cscli.Output, cfg.Cscli.Color)
+}
+
+func printLearnReport(out io.Writer, estimates []*leakybucket.BayesianEstimate, output string, wantColor string) error {
+	switch output {
+	case "human", "raw":
+		for _, est := range estimates {
+			fmt.Fprintf(out, "%s: %d malicious and %d benign sample(s), %d without matching events, prior %.4g\n",
+				est.Scenario, est.Malicious, est.Benign, est.Skipped, est.Prior)
+
+			t := cstable.New(out, wantColor).Writer
+			t.AppendHeader(table.Row{"Condition", "Malicious", "Benign", "Prob Given Evil", "Prob Given Benign"})
+
+			for _, c := range est.Conditions {
+				t.AppendRow(table.Row{
+					c.Condition,
+					fmt.Sprintf("%d/%d", c.TrueMalicious, est.Malicious),
+					fmt.Sprintf("%d/%d", c.TrueBenign, est.Benign),
+					fmt.Sprintf("%.4g", c.ProbGivenEvil),
+					fmt.Sprintf("%.4g", c.ProbGivenBenign),
+				})
+			}
+
+			t.SetTitle("(Learned likelihoods) " + est.Scenario)
+			fmt.Fprintln(out, t.Render())
+		}
+	case "json":
+		x, err := json.MarshalIndent(estimates, "", " ")
+		if err != nil {
+			return fmt.Errorf("failed to serialize learning report: %w", err)
+		}
+
+		fmt.Fprintln(out, string(x))
+	default:
+		return fmt.Errorf("unknown output format '%s'", output)
+	}
+
+	return nil
+}
+
+func (cli *cliItem) newLearnCmd() *cobra.Command {
+	var (
+		fromScenarios []string
+		since         = cstime.DurationWithDays(0)
+		limit         int
+		writeFile     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "learn <scenario file>",
+		Short: "Learn the prior and likelihoods of a bayesian scenario from stored alerts",
+		Long: `Estimate bayesian_prior, prob_given_evil and prob_given_benign of the bayesian scenarios in a file
+from the events of the alerts stored in the database, instead of setting them by hand.
+
+Each alert is a sample. Its source is labeled as malicious if it received a decision from anything else
+than the scenarios that are learned from or tuned (another scenario, a blocklist, cscli), and benign
+otherwise. The bayesian scenario can run in simulation mode, or the alerts of a scenario with the same
+filter and groupby that raises an alert for every source can be used to get enough benign samples.
+
+The conditions are evaluated as the events are poured, and a condition is counted for a sample if it was
+true at any point. Only the meta of the events is stored: conditions on evt.Parsed or evt.Enriched
+won't match. The probabilities are smoothed so that they are never 0 or 1, and bayesian_threshold is not
+changed.`,
+		Example: `# Learn from the alerts of the scenario itself, and write the tuned file
+cscli scenarios learn /etc/crowdsec/scenarios/http-bayesian.yaml --write /tmp/http-bayesian.yaml
+
+# Learn from the alerts of a collecting scenario in the last 30 days
+cscli scenarios learn http-bayesian.yaml --from-scenario custom/http-collect --since 30d`,
+		Args:              args.ExactArgs(1),
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cli.learn(cmd.Context(), args[0], fromScenarios, since, limit, writeFile)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&fromScenarios, "from-scenario", nil, "Learn from the alerts of these scenarios (default: the bayesian scenarios in the file)")
+	flags.Var(&since, "since", "Only learn from the alerts newer than since (ie. 4h, 30d)")
+	flags.IntVar(&limit, "limit", 1000, "Maximum number of alerts to learn from, per scenario (0 for all)")
+	flags.StringVar(&writeFile, "write", "", "Write the tuned scenario to this file (can be the same as the scenario file)")
+
+	return cmd
+}
@@ -0,0 +1,324 @@
+package cliitem
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/crowdsecurity/go-cs-lib/cstime"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/require"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/cwhub"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+	"github.com/crowdsecurity/crowdsec/pkg/leakybucket"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// how long to wait for the buckets to emit their overflows once all the events have been poured
+const simulateSettle = 2 * time.Second
+
+type simulatedOverflow struct {
+	Scope       string `json:"scope"`
+	Value       string `json:"value"`
+	EventsCount int32  `json:"events_count"`
+	StartAt     string `json:"start_at"`
+	StopAt      string `json:"stop_at"`
+}
+
+type simulationResult struct {
+	Scenario     string              `json:"scenario"`
+	StoredAlerts int                 `json:"stored_alerts"`
+	Overflows    []simulatedOverflow `json:"overflows"`
+}
+
+type simulationReport struct {
+	Alerts    int                `json:"alerts"`
+	Events    int                `json:"events"`
+	Scenarios []simulationResult `json:"scenarios"`
+}
+
+// alertEvents rebuilds the events of stored alerts, as they were when they were poured to the scenario.
+// Only the meta of the events is kept in the database, the parsed and enriched fields are not available.
+func alertEvents(alerts []*ent.Alert) ([]pipeline.Event, error) {
+	events := []pipeline.Event{}
+
+	for _, alert := range alerts {
+		for _, stored := range alert.Edges.Events {
+			metas := models.Meta{}
+			if err := json.Unmarshal([]byte(stored.Serialized), &metas); err != nil {
+				return nil, fmt.Errorf("alert %d: unable to parse event meta: %w", alert.ID, err)
+			}
+
+			evt := pipeline.MakeEvent(true, pipeline.LOG, true)
+			evt.Time = stored.Time
+			evt.MarshaledTime = stored.Time.Format(time.RFC3339Nano)
+
+			for _, meta := range metas {
+				evt.Meta[meta.Key] = meta.Value
+			}
+
+			events = append(events, evt)
+		}
+	}
+
+	return events, nil
+}
+
+// loadScenarioFile loads the buckets of a scenario file that is not necessarily installed.
+func loadScenarioFile(cfg *csconfig.Config, scenarioFile string) ([]leakybucket.BucketFactory, chan pipeline.Event, error) {
+	scenarioPath, err := filepath.Abs(scenarioFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err = os.Stat(scenarioPath); err != nil {
+		return nil, nil, err
+	}
+
+	hub, err := require.Hub(cfg, log.StandardLogger())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = exprhelpers.Init(nil); err != nil {
+		return nil, nil, err
+	}
+
+	crowdsecCfg := cfg.Crowdsec
+	if crowdsecCfg == nil {
+		crowdsecCfg = &csconfig.CrowdsecServiceCfg{}
+	}
+
+	item := &cwhub.Item{
+		Name: filepath.Base(scenarioPath),
+		State: cwhub.ItemState{
+			LocalPath: scenarioPath,
+		},
+	}
+
+	holders, response, err := leakybucket.LoadBuckets(crowdsecCfg, hub, []*cwhub.Item{item}, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("while loading %s: %w", scenarioFile, err)
+	}
+
+	if len(holders) == 0 {
+		return nil, nil, fmt.Errorf("no scenario found in %s", scenarioFile)
+	}
+
+	return holders, response, nil
+}
+
+func (cli *cliItem) simulate(ctx context.Context, scenarioFile string, fromAlerts bool, scenario string, since cstime.DurationWithDays, limit int) error {
+	cfg := cli.cfg()
+
+	if !fromAlerts {
+		return errors.New("a source of events is required: use --from-alerts to replay the events of the alerts stored in the database")
+	}
+
+	if limit < 0 {
+		return errors.New("--limit must be a positive number, or 0 for all alerts")
+	}
+
+	holders, response, err := loadScenarioFile(cfg, scenarioFile)
+	if err != nil {
+		return err
+	}
+
+	db, err := require.DBClient(ctx, cfg.DbConfig)
+	if err != nil {
+		return err
+	}
+
+	// the alerts of the scenario(s) being modified, unless another name is provided
+	names := []string{}
+
+	if scenario != "" {
+		names = append(names, scenario)
+	} else {
+		for _, holder := range holders {
+			if !slices.Contains(names, holder.Spec.Name) {
+				names = append(names, holder.Spec.Name)
+			}
+		}
+	}
+
+	storedAlerts := map[string]int{}
+	alerts := []*ent.Alert{}
+
+	for _, name := range names {
+		filter := map[string][]string{
+			"scenario":       {name},
+			"limit":          {strconv.Itoa(limit)},
+			"with_decisions": {"false"},
+		}
+
+		if since != 0 {
+			filter["since"] = []string{time.Duration(since).String()}
+		}
+
+		found, err := db.QueryAlertWithFilter(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("unable to fetch alerts of %s: %w", name, err)
+		}
+
+		storedAlerts[name] = len(found)
+		alerts = append(alerts, found...)
+	}
+
+	events, err := alertEvents(alerts)
+	if err != nil {
+		return err
+	}
+
+	overflows, err := leakybucket.Replay(ctx, holders, response, events, simulateSettle)
+	if err != nil {
+		return fmt.Errorf("while replaying events: %w", err)
+	}
+
+	report := simulationReport{
+		Alerts: len(alerts),
+		Events: len(events),
+	}
+
+	for _, holder := range holders {
+		result := simulationResult{
+			Scenario:     holder.Spec.Name,
+			StoredAlerts: storedAlerts[cmp.Or(scenario, holder.Spec.Name)],
+			Overflows:    []simulatedOverflow{},
+		}
+
+		for _, ovflw := range overflows {
+			if ovflw.Alert.Scenario == nil || *ovflw.Alert.Scenario != holder.Spec.Name {
+				continue
+			}
+
+			o := simulatedOverflow{}
+
+			if ovflw.Alert.StartAt != nil {
+				o.StartAt = *ovflw.Alert.StartAt
+			}
+
+			if ovflw.Alert.StopAt != nil {
+				o.StopAt = *ovflw.Alert.StopAt
+			}
+
+			if ovflw.Alert.EventsCount != nil {
+				o.EventsCount = *ovflw.Alert.EventsCount
+			}
+
+			if ovflw.Alert.Source != nil {
+				o.Scope = *ovflw.Alert.Source.Scope
+				o.Value = *ovflw.Alert.Source.Value
+			}
+
+			result.Overflows = append(result.Overflows, o)
+		}
+
+		report.Scenarios = append(report.Scenarios, result)
+	}
+
+	return printSimulationReport(os.Stdout, report, cfg.Cscli.Output, cfg.Cscli.Color)
+}
+
+func printSimulationReport(out io.Writer, report simulationReport, output string, wantColor string) error {
+	switch output {
+	case "human", "raw":
+		fmt.Fprintf(out, "Replayed %d events from %d alerts\n", report.Events, report.Alerts)
+
+		t := cstable.New(out, wantColor).Writer
+		t.AppendHeader(table.Row{"Scenario", "Stored Alerts", "Simulated Overflows"})
+
+		for _, result := range report.Scenarios {
+			t.AppendRow(table.Row{result.Scenario, strconv.Itoa(result.StoredAlerts), strconv.Itoa(len(result.Overflows))})
+		}
+
+		fmt.Fprintln(out, t.Render())
+
+		for _, result := range report.Scenarios {
+			if len(result.Overflows) == 0 {
+				continue
+			}
+
+			t := cstable.New(out, wantColor).Writer
+			t.AppendHeader(table.Row{"Scope:Value", "Events", "First Event", "Last Event"})
+
+			for _, o := range result.Overflows {
+				t.AppendRow(table.Row{o.Scope + ":" + o.Value, strconv.Itoa(int(o.EventsCount)), o.StartAt, o.StopAt})
+			}
+
+			t.SetTitle("(Simulated overflows) " + result.Scenario)
+			fmt.Fprintln(out, t.Render())
+		}
+	case "json":
+		x, err := json.MarshalIndent(report, "", " ")
+		if err != nil {
+			return fmt.Errorf("failed to serialize simulation report: %w", err)
+		}
+
+		fmt.Fprintln(out, string(x))
+	default:
+		return fmt.Errorf("unknown output format '%s'", output)
+	}
+
+	return nil
+}
+
+func (cli *cliItem) newSimulateCmd() *cobra.Command {
+	var (
+		fromAlerts bool
+		scenario   string
+		since      = cstime.DurationWithDays(0)
+		limit      int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "simulate <scenario file> --from-alerts",
+		Short: "Replay the events of stored alerts against a scenario",
+		Long: `Replay the events of the alerts stored in the database against a scenario file (for example,
+a copy of an installed scenario with a different capacity or leakspeed), and report how many
+overflows it would have raised compared to the alerts that were stored.
+
+Only the events that were part of an alert are stored, with their meta: the simulation can tell
+if a scenario would have fired less often, or on more events, but not catch traffic that never
+triggered an alert, and filters or groupby on evt.Parsed or evt.Enriched won't match.`,
+		Example: `# Replay the alerts of crowdsecurity/ssh-bf against a modified copy of the scenario
+cp /etc/crowdsec/scenarios/ssh-bf.yaml /tmp/ssh-bf.yaml
+cscli scenarios simulate /tmp/ssh-bf.yaml --from-alerts
+
+# Only replay the alerts of the last 7 days
+cscli scenarios simulate /tmp/ssh-bf.yaml --from-alerts --since 7d
+
+# Replay the alerts of another scenario
+cscli scenarios simulate /tmp/ssh-slow-bf.yaml --from-alerts --scenario crowdsecurity/ssh-bf`,
+		Args:              args.ExactArgs(1),
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cli.simulate(cmd.Context(), args[0], fromAlerts, scenario, since, limit)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&fromAlerts, "from-alerts", false, "Replay the events of the alerts stored in the database")
+	flags.StringVar(&scenario, "scenario", "", "Replay the alerts of this scenario (default: the name of the scenario in the file)")
+	flags.Var(&since, "since", "Only replay the alerts newer than since (ie. 4h, 30d)")
+	flags.IntVar(&limit, "limit", 1000, "Maximum number of alerts to replay per scenario (0 for all)")
+
+	return cmd
+}
@@ -0,0 +1,335 @@
+package cliitem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/require"
+	appsecacquisition "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/appsec"
+	"github.com/crowdsecurity/crowdsec/pkg/appsec"
+	"github.com/crowdsecurity/crowdsec/pkg/cwhub"
+	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+	"github.com/crowdsecurity/crowdsec/pkg/logging"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+type appsecTestRule struct {
+	Phase   string   `json:"phase"` // inband or outofband
+	ID      int      `json:"id"`
+	Name    string   `json:"name"`
+	Message string   `json:"message"`
+	Zones   []string `json:"matched_zones"`
+}
+
+type appsecTestResult struct {
+	File     string           `json:"file"`
+	Index    int              `json:"index"` // position of the request in the file, from 1
+	Method   string           `json:"method"`
+	URI      string           `json:"uri"`
+	Action   string           `json:"action"`    // remediation of the in-band rules
+	HTTPCode int              `json:"http_code"` // returned to the client
+	Rules    []appsecTestRule `json:"matched_rules"`
+	Alerts   []string         `json:"alerts"` // scenarios of the alerts
+	Events   int              `json:"events"`
+}
+
+// addTestedRules makes the rules available to the appsec config, and returns their names. A rule
+// is either a file or the name of a hub item.
+func addTestedRules(hub *cwhub.Hub, rules []string) ([]string, error) {
+	names := []string{}
+
+	for _, rule := range rules {
+		if _, err := os.Stat(rule); err == nil {
+			path, err := filepath.Abs(rule)
+			if err != nil {
+				return nil, err
+			}
+
+			name, err := appsec.AddAppsecRuleFile(path, "", "")
+			if err != nil {
+				return nil, err
+			}
+
+			names = append(names, name)
+
+			continue
+		}
+
+		item := hub.GetItem(cwhub.APPSEC_RULES, rule)
+		if item == nil {
+			return nil, fmt.Errorf("%s is neither a file nor an appsec-rule of the hub", rule)
+		}
+
+		if item.State.LocalPath == "" {
+			return nil, fmt.Errorf("appsec-rule %s is not downloaded", rule)
+		}
+
+		name, err := appsec.AddAppsecRuleFile(item.State.LocalPath, item.State.LocalHash, item.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// testResult summarizes the evaluation of a request.
+func testResult(response appsec.AppsecTempResponse, events []pipeline.Event) appsecTestResult {
+	result := appsecTestResult{
+		Action:   response.Action,
+		HTTPCode: response.UserHTTPResponseCode,
+		Rules:    []appsecTestRule{},
+		Alerts:   []string{},
+	}
+
+	for _, evt := range events {
+		if evt.Overflow.Alert != nil {
+			if evt.Overflow.Alert.Scenario != nil {
+				result.Alerts = append(result.Alerts, *evt.Overflow.Alert.Scenario)
+			}
+
+			continue
+		}
+
+		result.Events++
+
+		for _, matched := range evt.Appsec.MatchedRules {
+			rule := appsecTestRule{}
+			rule.ID, _ = matched["id"].(int)
+			rule.Phase, _ = matched["rule_type"].(string)
+			rule.Name, _ = matched["name"].(string)
+			rule.Message, _ = matched["msg"].(string)
+			zones, _ := matched["matched_zones"].([]string)
+			// a zone is listed for each of its matches
+			rule.Zones = slices.Compact(slices.Sorted(slices.Values(zones)))
+
+			if slices.ContainsFunc(result.Rules, func(r appsecTestRule) bool { return r.ID == rule.ID && r.Phase == rule.Phase }) {
+				continue
+			}
+
+			result.Rules = append(result.Rules, rule)
+		}
+	}
+
+	return result
+}
+
+func (cli *cliItem) testRules(rules []string, requestFiles []string, appsecConfig string, outOfBand bool, clientIP string) error {
+	cfg := cli.cfg()
+
+	if len(rules) == 0 && appsecConfig == "" {
+		return errors.New("nothing to test: provide appsec-rules, or an appsec-config with --appsec-config")
+	}
+
+	if len(requestFiles) == 0 {
+		return errors.New("at least one --request-file is required")
+	}
+
+	if net.ParseIP(clientIP) == nil {
+		return fmt.Errorf("invalid --ip '%s'", clientIP)
+	}
+
+	hub, err := require.Hub(cfg, log.StandardLogger())
+	if err != nil {
+		return err
+	}
+
+	if err = exprhelpers.Init(nil); err != nil {
+		return err
+	}
+
+	// the engine is verbose at info level
+	level := log.GetLevel()
+	if level == log.InfoLevel {
+		level = log.WarnLevel
+	}
+
+	logger := logging.SubLogger(log.StandardLogger(), "appsec", level)
+
+	appsecCfg := appsec.AppsecConfig{Name: "cscli-test", Logger: logger}
+
+	if appsecConfig != "" {
+		// the rules the config refers to
+		for _, item := range hub.GetInstalledByType(cwhub.APPSEC_RULES, false) {
+			if _, err := appsec.AddAppsecRuleFile(item.State.LocalPath, item.State.LocalHash, item.Version); err != nil {
+				logger.Warning(err)
+			}
+		}
+
+		if err := appsecCfg.Load(appsecConfig, hub); err != nil {
+			return err
+		}
+	}
+
+	if len(rules) > 0 {
+		names, err := addTestedRules(hub, rules)
+		if err != nil {
+			return err
+		}
+
+		// only the tested rules are loaded, in the phase they're tested in
+		appsecCfg.InBandRules = names
+		appsecCfg.OutOfBandRules = nil
+
+		if outOfBand {
+			appsecCfg.InBandRules, appsecCfg.OutOfBandRules = nil, names
+		}
+
+		if appsecCfg.InBand != nil {
+			appsecCfg.InBand.Rules = nil
+		}
+
+		if appsecCfg.OutOfBand != nil {
+			appsecCfg.OutOfBand.Rules = nil
+		}
+	}
+
+	appsecCfg.SetUpLogger()
+
+	runtime, err := appsecCfg.Build(hub)
+	if err != nil {
+		return fmt.Errorf("unable to build appsec config: %w", err)
+	}
+
+	if err = runtime.ProcessOnLoadRules(); err != nil {
+		return fmt.Errorf("unable to process on_load hooks: %w", err)
+	}
+
+	runner, err := appsecacquisition.NewEvaluationRunner(runtime, hub.GetDataDir(), map[string]string{"type": "appsec"}, logger)
+	if err != nil {
+		return fmt.Errorf("unable to load the rules: %w", err)
+	}
+
+	results := []appsecTestResult{}
+
+	for _, requestFile := range requestFiles {
+		requests, err := appsec.ReadRequestFile(requestFile)
+		if err != nil {
+			return err
+		}
+
+		for idx, req := range requests {
+			parsed, err := appsec.NewParsedRequestFromClientRequest(req, clientIP, logger, runtime.BodySettings)
+			if err != nil {
+				return fmt.Errorf("%s: request %d: %w", requestFile, idx+1, err)
+			}
+
+			parsed.AppsecEngine = appsecCfg.Name
+
+			result := testResult(runner.Evaluate(&parsed))
+			result.File = requestFile
+			result.Index = idx + 1
+			result.Method = parsed.Method
+			result.URI = parsed.URI
+
+			results = append(results, result)
+		}
+	}
+
+	return printTestResults(os.Stdout, results, cfg.Cscli.Output, cfg.Cscli.Color)
+}
+
+func printTestResults(out io.Writer, results []appsecTestResult, output string, wantColor string) error {
+	switch output {
+	case "human", "raw":
+		for _, result := range results {
+			fmt.Fprintf(out, "%s #%d: %s %s\n", result.File, result.Index, result.Method, result.URI)
+			fmt.Fprintf(out, "  in-band: %s (%d)\n", result.Action, result.HTTPCode)
+
+			if len(result.Rules) == 0 {
+				fmt.Fprintln(out, "  no rule matched")
+			} else {
+				t := cstable.New(out, wantColor).Writer
+				t.AppendHeader(table.Row{"Phase", "ID", "Name", "Message", "Matched Zones"})
+
+				for _, rule := range result.Rules {
+					t.AppendRow(table.Row{rule.Phase, strconv.Itoa(rule.ID), rule.Name, rule.Message, strings.Join(rule.Zones, ", ")})
+				}
+
+				fmt.Fprintln(out, t.Render())
+			}
+
+			alerts := "none"
+			if len(result.Alerts) > 0 {
+				alerts = strings.Join(result.Alerts, ", ")
+			}
+
+			fmt.Fprintf(out, "  events: %d, alerts: %s\n\n", result.Events, alerts)
+		}
+	case "json":
+		x, err := json.MarshalIndent(results, "", " ")
+		if err != nil {
+			return fmt.Errorf("failed to serialize test results: %w", err)
+		}
+
+		fmt.Fprintln(out, string(x))
+	default:
+		return fmt.Errorf("unknown output format '%s'", output)
+	}
+
+	return nil
+}
+
+func (cli *cliItem) newTestCmd() *cobra.Command {
+	var (
+		requestFiles []string
+		appsecConfig string
+		outOfBand    bool
+		clientIP     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "test [appsec-rule or file]... --request-file <file>",
+		Short: "Evaluate appsec rules against recorded HTTP requests",
+		Long: `Evaluate appsec rules against recorded HTTP requests, and show the rules that matched, the response
+of the in-band rules and the events and alerts that would be sent. Nothing is sent to the Local API.
+
+The rules are installed hub items, or files being written. They are evaluated in-band, or out-of-band
+with --outofband. With --appsec-config, the hooks and remediations of the appsec-config are used, and
+its own rules are evaluated if no rule is given.
+
+The request files are HAR archives (as saved from the network tab of a browser), or raw HTTP/1.x
+requests, one after the other.`,
+		Example: `# Test a rule being written against a request
+cat > /tmp/request.txt <<EOF
+GET /wp-admin/admin-ajax.php?action=../../../../etc/passwd HTTP/1.1
+Host: example.com
+User-Agent: curl/8.0
+
+EOF
+cscli appsec-rules test ./my-rule.yaml --request-file /tmp/request.txt
+
+# Test an installed rule, out-of-band, against the requests of a HAR archive
+cscli appsec-rules test crowdsecurity/vpatch-CVE-2023-40044 --outofband --request-file session.har
+
+# Test the rules and hooks of an appsec-config
+cscli appsec-rules test --appsec-config crowdsecurity/virtual-patching --request-file session.har -o json`,
+		DisableAutoGenTag: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return cli.testRules(args, requestFiles, appsecConfig, outOfBand, clientIP)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&requestFiles, "request-file", nil, "HAR archive or raw HTTP requests to evaluate (can be repeated)")
+	flags.StringVar(&appsecConfig, "appsec-config", "", "Use the hooks, remediations and rules of this appsec-config")
+	flags.BoolVar(&outOfBand, "outofband", false, "Evaluate the rules out-of-band instead of in-band")
+	flags.StringVar(&clientIP, "ip", "127.0.0.1", "IP address the requests are seen from")
+
+	return cmd
+}
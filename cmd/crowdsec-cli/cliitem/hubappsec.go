@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/spf13/cobra"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 	"gopkg.in/yaml.v3"
@@ -254,5 +255,8 @@ cscli waf-rules list -a
 # List specific waf-rules (installed or not).
 cscli waf-rules list crowdsecurity/crs crowdsecurity/vpatch-git-config`,
 		},
+		extraCommands: []func(cli *cliItem) *cobra.Command{
+			(*cliItem).newTestCmd,
+		},
 	}
 }
@@ -1,11 +1,95 @@
 package cliitem
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
 	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/cwhub"
 )
 
+// scenarioNames returns the names of the scenarios defined in the file of an item.
+func scenarioNames(item *cwhub.Item) ([]string, error) {
+	if item.State.LocalPath == "" {
+		return []string{item.Name}, nil
+	}
+
+	f, err := os.Open(item.State.LocalPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ret := []string{}
+	dec := yaml.NewDecoder(f)
+
+	for {
+		spec := struct {
+			Name string `yaml:"name"`
+		}{}
+
+		err := dec.Decode(&spec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("while parsing %s: %w", item.State.LocalPath, err)
+		}
+
+		if spec.Name != "" {
+			ret = append(ret, spec.Name)
+		}
+	}
+
+	return ret, nil
+}
+
 func NewScenario(cfg csconfig.Getter) *cliItem {
+	inspectDetail := func(item *cwhub.Item) error {
+		// Only show the local thresholds in human mode
+		if cfg().Cscli.Output != "human" {
+			return nil
+		}
+
+		path := cfg().ConfigPaths.ScenarioOverridesPath
+
+		overrides, err := csconfig.LoadScenarioOverrides(path)
+		if err != nil {
+			return err
+		}
+
+		if len(overrides) == 0 {
+			return nil
+		}
+
+		names, err := scenarioNames(item)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			o, ok := overrides[name]
+			if !ok {
+				continue
+			}
+
+			out, err := yaml.Marshal(map[string]*csconfig.ScenarioOverride{name: o})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(os.Stdout, "\nLocal thresholds (from %s):\n%s", path, out)
+		}
+
+		return nil
+	}
+
 	return &cliItem{
 		cfg:       cfg,
 		name:      cwhub.SCENARIOS,
@@ -88,7 +172,10 @@ cscli scenarios inspect crowdsecurity/ssh-bf --no-metrics
 cscli scenarios inspect crowdsecurity/ssh-bf --diff
 
 # Reverse the above diff
-cscli scenarios inspect crowdsecurity/ssh-bf --diff --rev`,
+cscli scenarios inspect crowdsecurity/ssh-bf --diff --rev
+
+# The thresholds overridden in scenario_overrides.yaml are shown as well.
+cscli scenarios inspect crowdsecurity/ssh-bf --no-metrics`,
 		},
 		listHelp: cliHelp{
 			example: `# List enabled (installed) scenarios.
@@ -100,5 +187,10 @@ cscli scenarios list -a
 # List specific scenarios (installed or not).
 cscli scenarios list crowdsecurity/ssh-bf crowdsecurity/http-probing`,
 		},
+		inspectDetail: inspectDetail,
+		extraCommands: []func(cli *cliItem) *cobra.Command{
+			(*cliItem).newSimulateCmd,
+			(*cliItem).newLearnCmd,
+		},
 	}
 }
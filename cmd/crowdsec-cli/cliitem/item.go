@@ -38,6 +38,8 @@ type cliItem struct {
 	inspectHelp   cliHelp
 	inspectDetail func(item *cwhub.Item) error
 	listHelp      cliHelp
+	// additional commands for a specific item type
+	extraCommands []func(cli *cliItem) *cobra.Command
 }
 
 func (cli *cliItem) NewCommand() *cobra.Command {
@@ -68,6 +70,10 @@ func (cli *cliItem) NewCommand() *cobra.Command {
 	cmd.AddCommand(cli.newInspectCmd())
 	cmd.AddCommand(cli.newListCmd())
 
+	for _, newCmd := range cli.extraCommands {
+		cmd.AddCommand(newCmd(cli))
+	}
+
 	return cmd
 }
 
@@ -86,7 +92,7 @@ func (cli *cliItem) list(args []string, all bool) error {
 		return err
 	}
 
-	return clihub.ListItems(color.Output, cfg.Cscli.Color, []string{cli.name}, items, false, cfg.Cscli.Output)
+	return clihub.ListItems(color.Output, cfg.Cscli.Color, []string{cli.name}, items, false, cfg.Cscli.Output, nil)
 }
 
 func (cli *cliItem) newListCmd() *cobra.Command {
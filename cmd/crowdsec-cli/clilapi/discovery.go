@@ -0,0 +1,232 @@
+package clilapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lapiURLTag is the instance tag (or metadata attribute on GCP) holding the URL of the Local API.
+const lapiURLTag = "crowdsec-lapi-url"
+
+// the SRV records are looked up in this order, the first one found is used
+var lapiSRVServices = []struct {
+	service string
+	scheme  string
+}{
+	{"crowdsec-lapis", "https"},
+	{"crowdsec-lapi", "http"},
+}
+
+type srvResolver interface {
+	LookupSRV(ctx context.Context, service string, proto string, name string) (string, []*net.SRV, error)
+}
+
+// lapiDiscovery finds the URL of the Local API, to register the machines of a fleet
+// without configuring them one by one.
+type lapiDiscovery struct {
+	resolver srvResolver
+	hostname func() (string, error)
+	http     *http.Client
+	awsURL   string
+	gcpURL   string
+	azureURL string
+}
+
+func newLAPIDiscovery() *lapiDiscovery {
+	return &lapiDiscovery{
+		resolver: net.DefaultResolver,
+		hostname: os.Hostname,
+		// the metadata services answer quickly, or not at all outside of their cloud
+		http:     &http.Client{Timeout: 5 * time.Second},
+		awsURL:   "http://169.254.169.254",
+		gcpURL:   "http://metadata.google.internal",
+		azureURL: "http://169.254.169.254",
+	}
+}
+
+// discover returns the URL of the Local API found with a discovery method:
+// dns-srv[:domain], aws, gcp or azure.
+func (d *lapiDiscovery) discover(ctx context.Context, method string) (string, error) {
+	method, arg, _ := strings.Cut(method, ":")
+
+	var (
+		apiURL string
+		err    error
+	)
+
+	switch method {
+	case "dns-srv":
+		apiURL, err = d.fromSRV(ctx, arg)
+	case "aws":
+		apiURL, err = d.fromAWS(ctx)
+	case "gcp":
+		apiURL, err = d.fromGCP(ctx)
+	case "azure":
+		apiURL, err = d.fromAzure(ctx)
+	default:
+		return "", fmt.Errorf("unknown discovery method '%s': must be one of dns-srv[:domain], aws, gcp, azure", method)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("%s discovery: %w", method, err)
+	}
+
+	apiURL = strings.TrimSpace(apiURL)
+	if apiURL == "" {
+		return "", fmt.Errorf("%s discovery: empty URL", method)
+	}
+
+	return apiURL, nil
+}
+
+// fromSRV looks up the _crowdsec-lapis._tcp (https) and _crowdsec-lapi._tcp (http) records of the
+// domain, which is the one of the host if not provided.
+func (d *lapiDiscovery) fromSRV(ctx context.Context, domain string) (string, error) {
+	if domain == "" {
+		hostname, err := d.hostname()
+		if err != nil {
+			return "", err
+		}
+
+		_, domain, _ = strings.Cut(hostname, ".")
+		if domain == "" {
+			return "", fmt.Errorf("unable to find the domain of host '%s', use dns-srv:<domain>", hostname)
+		}
+	}
+
+	for _, srv := range lapiSRVServices {
+		_, addrs, err := d.resolver.LookupSRV(ctx, srv.service, "tcp", domain)
+
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			continue
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		// the records are sorted by priority and weight; a target "." means the service is not available
+		if len(addrs) == 0 || addrs[0].Target == "." {
+			continue
+		}
+
+		host := strings.TrimSuffix(addrs[0].Target, ".")
+
+		return srv.scheme + "://" + net.JoinHostPort(host, strconv.Itoa(int(addrs[0].Port))), nil
+	}
+
+	return "", fmt.Errorf("no _%s._tcp or _%s._tcp record in %s", lapiSRVServices[0].service, lapiSRVServices[1].service, domain)
+}
+
+// get sends a request to a metadata service and returns the body of the response.
+func (d *lapiDiscovery) get(ctx context.Context, method string, url string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = header
+
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: %s", method, req.URL.Path, resp.Status)
+	}
+
+	return body, nil
+}
+
+// fromAWS reads the instance tag with IMDSv2. The access to the tags in the instance metadata must be enabled.
+func (d *lapiDiscovery) fromAWS(ctx context.Context) (string, error) {
+	token, err := d.get(ctx, http.MethodPut, d.awsURL+"/latest/api/token", http.Header{
+		"X-Aws-Ec2-Metadata-Token-Ttl-Seconds": {"60"},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if token == nil {
+		return "", errors.New("no instance metadata token")
+	}
+
+	value, err := d.get(ctx, http.MethodGet, d.awsURL+"/latest/meta-data/tags/instance/"+lapiURLTag, http.Header{
+		"X-Aws-Ec2-Metadata-Token": {string(token)},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if value == nil {
+		return "", fmt.Errorf("no instance tag %s (is the access to tags in the instance metadata allowed?)", lapiURLTag)
+	}
+
+	return string(value), nil
+}
+
+// fromGCP reads the custom metadata attribute of the instance (the labels are not in the metadata server).
+func (d *lapiDiscovery) fromGCP(ctx context.Context) (string, error) {
+	value, err := d.get(ctx, http.MethodGet, d.gcpURL+"/computeMetadata/v1/instance/attributes/"+lapiURLTag, http.Header{
+		"Metadata-Flavor": {"Google"},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if value == nil {
+		return "", fmt.Errorf("no instance metadata attribute %s", lapiURLTag)
+	}
+
+	return string(value), nil
+}
+
+// fromAzure reads the tag of the virtual machine.
+func (d *lapiDiscovery) fromAzure(ctx context.Context) (string, error) {
+	body, err := d.get(ctx, http.MethodGet, d.azureURL+"/metadata/instance/compute/tagsList?api-version=2021-02-01", http.Header{
+		"Metadata": {"true"},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var tags []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	if body != nil {
+		if err := json.Unmarshal(body, &tags); err != nil {
+			return "", fmt.Errorf("decoding tags: %w", err)
+		}
+	}
+
+	for _, tag := range tags {
+		if tag.Name == lapiURLTag {
+			return tag.Value, nil
+		}
+	}
+
+	return "", fmt.Errorf("no tag %s on the virtual machine", lapiURLTag)
+}
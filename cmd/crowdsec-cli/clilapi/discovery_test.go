@@ -0,0 +1,145 @@
+package clilapi
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+// fakeResolver answers the SRV lookups from a map of "_service._proto.name" to records.
+type fakeResolver map[string][]*net.SRV
+
+func (f fakeResolver) LookupSRV(_ context.Context, service string, proto string, name string) (string, []*net.SRV, error) {
+	target := "_" + service + "._" + proto + "." + name
+
+	addrs, ok := f[target]
+	if !ok {
+		return "", nil, &net.DNSError{Err: "no such host", Name: target, IsNotFound: true}
+	}
+
+	return target, addrs, nil
+}
+
+func TestDiscoverSRV(t *testing.T) {
+	resolver := fakeResolver{
+		"_crowdsec-lapis._tcp.secure.example.com": {{Target: "lapi.secure.example.com.", Port: 8443}},
+		"_crowdsec-lapi._tcp.secure.example.com":  {{Target: "lapi.secure.example.com.", Port: 8080}},
+		"_crowdsec-lapi._tcp.example.com":         {{Target: "lapi.example.com.", Port: 8080}},
+		"_crowdsec-lapis._tcp.down.example.com":   {{Target: ".", Port: 0}},
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		hostname string
+		want     string
+		wantErr  string
+	}{
+		{"https first", "dns-srv:secure.example.com", "", "https://lapi.secure.example.com:8443", ""},
+		{"http", "dns-srv:example.com", "", "http://lapi.example.com:8080", ""},
+		{"domain of the host", "dns-srv", "web-1.example.com", "http://lapi.example.com:8080", ""},
+		{"no domain", "dns-srv", "web-1", "", "dns-srv discovery: unable to find the domain of host 'web-1', use dns-srv:<domain>"},
+		{"no record", "dns-srv:other.example.com", "", "", "dns-srv discovery: no _crowdsec-lapis._tcp or _crowdsec-lapi._tcp record in other.example.com"},
+		{"service not available", "dns-srv:down.example.com", "", "", "dns-srv discovery: no _crowdsec-lapis._tcp or _crowdsec-lapi._tcp record in down.example.com"},
+		{"unknown method", "consul", "", "", "unknown discovery method 'consul': must be one of dns-srv[:domain], aws, gcp, azure"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := newLAPIDiscovery()
+			d.resolver = resolver
+			d.hostname = func() (string, error) { return tc.hostname, nil }
+
+			got, err := d.discover(t.Context(), tc.method)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDiscoverCloud(t *testing.T) {
+	tags := map[string]bool{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Aws-Ec2-Metadata-Token-Ttl-Seconds") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		_, _ = io.WriteString(w, "imds-token")
+	})
+	mux.HandleFunc("GET /latest/meta-data/tags/instance/crowdsec-lapi-url", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("X-Aws-Ec2-Metadata-Token") != "imds-token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case !tags["aws"]:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			_, _ = io.WriteString(w, "https://lapi.aws.internal:8080")
+		}
+	})
+	mux.HandleFunc("GET /computeMetadata/v1/instance/attributes/crowdsec-lapi-url", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("Metadata-Flavor") != "Google":
+			w.WriteHeader(http.StatusForbidden)
+		case !tags["gcp"]:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			_, _ = io.WriteString(w, "http://lapi.gcp.internal:8080\n")
+		}
+	})
+	mux.HandleFunc("GET /metadata/instance/compute/tagsList", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("Metadata") != "true" || r.URL.Query().Get("api-version") == "":
+			w.WriteHeader(http.StatusBadRequest)
+		case !tags["azure"]:
+			_, _ = io.WriteString(w, `[{"name": "env", "value": "prod"}]`)
+		default:
+			_, _ = io.WriteString(w, `[{"name": "env", "value": "prod"}, {"name": "crowdsec-lapi-url", "value": "https://lapi.azure.internal"}]`)
+		}
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	d := newLAPIDiscovery()
+	d.awsURL = srv.URL
+	d.gcpURL = srv.URL
+	d.azureURL = srv.URL
+
+	_, err := d.discover(t.Context(), "aws")
+	cstest.RequireErrorContains(t, err, "aws discovery: no instance tag crowdsec-lapi-url (is the access to tags in the instance metadata allowed?)")
+
+	_, err = d.discover(t.Context(), "gcp")
+	cstest.RequireErrorContains(t, err, "gcp discovery: no instance metadata attribute crowdsec-lapi-url")
+
+	_, err = d.discover(t.Context(), "azure")
+	cstest.RequireErrorContains(t, err, "azure discovery: no tag crowdsec-lapi-url on the virtual machine")
+
+	tags["aws"], tags["gcp"], tags["azure"] = true, true, true
+
+	for method, want := range map[string]string{
+		"aws":   "https://lapi.aws.internal:8080",
+		"gcp":   "http://lapi.gcp.internal:8080",
+		"azure": "https://lapi.azure.internal",
+	} {
+		got, err := d.discover(t.Context(), method)
+		require.NoError(t, err, method)
+		assert.Equal(t, want, got, method)
+	}
+
+	// not in a cloud
+	srv.Close()
+
+	_, err = d.discover(t.Context(), "aws")
+	cstest.RequireErrorContains(t, err, "aws discovery: Put \""+srv.URL+"/latest/api/token\"")
+}
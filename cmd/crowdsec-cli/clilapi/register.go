@@ -16,12 +16,21 @@ import (
 	"github.com/crowdsecurity/crowdsec/pkg/apiclient"
 )
 
-func (cli *cliLapi) register(ctx context.Context, apiURL string, outputFile string, machine string, token string) error {
+func (cli *cliLapi) register(ctx context.Context, apiURL string, urlDiscovery string, outputFile string, machine string, token string) error {
 	var err error
 
 	lapiUser := machine
 	cfg := cli.cfg()
 
+	if urlDiscovery != "" {
+		apiURL, err = newLAPIDiscovery().discover(ctx, urlDiscovery)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("Discovered Local API URL: %s", apiURL)
+	}
+
 	if lapiUser == "" {
 		lapiUser, err = idgen.GenerateMachineID("")
 		if err != nil {
@@ -97,29 +106,41 @@ func (cli *cliLapi) register(ctx context.Context, apiURL string, outputFile stri
 
 func (cli *cliLapi) newRegisterCmd() *cobra.Command {
 	var (
-		apiURL     string
-		outputFile string
-		machine    string
-		token      string
+		apiURL       string
+		urlDiscovery string
+		outputFile   string
+		machine      string
+		token        string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "register",
 		Short: "Register a machine to Local API (LAPI)",
 		Long: `Register your machine to the Local API (LAPI).
-Keep in mind the machine needs to be validated by an administrator on LAPI side to be effective.`,
+Keep in mind the machine needs to be validated by an administrator on LAPI side to be effective.
+
+With --url-discovery, the URL of the Local API is found with:
+ - dns-srv[:domain]: the _crowdsec-lapis._tcp (https) or _crowdsec-lapi._tcp (http) SRV record of the domain, the one of the host by default
+ - aws, azure: the crowdsec-lapi-url tag of the instance (on AWS, the access to tags in the instance metadata must be allowed)
+ - gcp: the crowdsec-lapi-url custom metadata of the instance`,
+		Example: `cscli lapi register --url-discovery dns-srv --token <token>
+cscli lapi register --url-discovery dns-srv:example.com
+cscli lapi register --url-discovery aws --machine $(hostname)`,
 		Args:              args.NoArgs,
 		DisableAutoGenTag: true,
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return cli.register(cmd.Context(), apiURL, outputFile, machine, token)
+			return cli.register(cmd.Context(), apiURL, urlDiscovery, outputFile, machine, token)
 		},
 	}
 
 	flags := cmd.Flags()
 	flags.StringVarP(&apiURL, "url", "u", "", "URL of the API (ie. http://127.0.0.1)")
+	flags.StringVar(&urlDiscovery, "url-discovery", "", "find the URL of the API with dns-srv[:domain], aws, gcp or azure")
 	flags.StringVarP(&outputFile, "file", "f", "", "output file destination")
 	flags.StringVar(&machine, "machine", "", "Name of the machine to register with")
 	flags.StringVar(&token, "token", "", "Auto registration token to use")
 
+	cmd.MarkFlagsMutuallyExclusive("url", "url-discovery")
+
 	return cmd
 }
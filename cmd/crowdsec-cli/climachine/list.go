@@ -16,6 +16,7 @@ import (
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/clientinfo"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/database"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
 	"github.com/crowdsecurity/crowdsec/pkg/emoji"
@@ -23,7 +24,7 @@ import (
 
 // getLastHeartbeat returns the last heartbeat timestamp of a machine
 // and a boolean indicating if the machine is considered active or not.
-func getLastHeartbeat(m *ent.Machine) (string, bool) {
+func getLastHeartbeat(m *ent.Machine, maxDelay time.Duration) (string, bool) {
 	if m.LastHeartbeat == nil {
 		return "-", false
 	}
@@ -31,7 +32,7 @@ func getLastHeartbeat(m *ent.Machine) (string, bool) {
 	elapsed := time.Now().UTC().Sub(*m.LastHeartbeat)
 
 	hb := elapsed.Truncate(time.Second).String()
-	if elapsed > 2*time.Minute {
+	if elapsed > maxDelay {
 		return hb, false
 	}
 
@@ -48,7 +49,7 @@ func (cli *cliMachines) listHuman(out io.Writer, machines ent.Machines) {
 			validated = emoji.CheckMark
 		}
 
-		hb, active := getLastHeartbeat(m)
+		hb, active := getLastHeartbeat(m, cli.heartbeatSLA().MaxDelayFor(m.MachineId))
 		if !active {
 			hb = emoji.Warning + " " + hb
 		}
@@ -88,10 +89,34 @@ func (*cliMachines) listCSV(out io.Writer, machines ent.Machines) error {
 	return nil
 }
 
+// heartbeatSLA returns the heartbeat settings of the local API, or nil for the defaults.
+func (cli *cliMachines) heartbeatSLA() *csconfig.HeartbeatSLACfg {
+	cfg := cli.cfg()
+	if cfg.API == nil || cfg.API.Server == nil {
+		return nil
+	}
+
+	return cfg.API.Server.HeartbeatSLA
+}
+
 func (cli *cliMachines) List(ctx context.Context, out io.Writer, db *database.Client) error {
+	return cli.list(ctx, out, db, false)
+}
+
+func (cli *cliMachines) list(ctx context.Context, out io.Writer, db *database.Client, stale bool) error {
 	// XXX: must use the provided db object, the one in the struct might be nil
 	// (calling List directly skips the PersistentPreRunE)
-	machines, err := db.ListMachines(ctx)
+	var (
+		machines ent.Machines
+		err      error
+	)
+
+	if stale {
+		machines, err = db.ListStaleMachines(ctx, cli.heartbeatSLA())
+	} else {
+		machines, err = db.ListMachines(ctx)
+	}
+
 	if err != nil {
 		return fmt.Errorf("unable to list machines: %w", err)
 	}
@@ -121,17 +146,28 @@ func (cli *cliMachines) List(ctx context.Context, out io.Writer, db *database.Cl
 }
 
 func (cli *cliMachines) newListCmd() *cobra.Command {
+	var stale bool
+
 	cmd := &cobra.Command{
-		Use:               "list",
-		Short:             "list all machines in the database",
-		Long:              `list all machines in the database with their status and last heartbeat`,
-		Example:           `cscli machines list`,
+		Use:   "list",
+		Short: "list all machines in the database",
+		Long: `list all machines in the database with their status and last heartbeat.
+
+A validated machine is stale when it did not send a heartbeat for longer than the
+max_delay of the api.server.heartbeat_sla configuration (default: 2 minutes).`,
+		Example: `cscli machines list
+
+# only the machines that stopped sending heartbeats
+cscli machines list --stale`,
 		Args:              args.NoArgs,
 		DisableAutoGenTag: true,
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return cli.List(cmd.Context(), color.Output, cli.db)
+			return cli.list(cmd.Context(), color.Output, cli.db, stale)
 		},
 	}
 
+	flags := cmd.Flags()
+	flags.BoolVar(&stale, "stale", false, "only list the machines that did not send a heartbeat within their max delay")
+
 	return cmd
 }
@@ -60,6 +60,7 @@ Note: This command requires database direct access, so is intended to be run on
 	cmd.AddCommand(cli.newValidateCmd())
 	cmd.AddCommand(cli.newPruneCmd())
 	cmd.AddCommand(cli.newInspectCmd())
+	cmd.AddCommand(cli.newTokenCmd())
 
 	return cmd
 }
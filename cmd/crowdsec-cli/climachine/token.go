@@ -0,0 +1,254 @@
+package climachine
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/crowdsecurity/go-cs-lib/cstime"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
+	middlewares "github.com/crowdsecurity/crowdsec/pkg/apiserver/middlewares/v1"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+)
+
+const (
+	defaultTokenTTL = time.Hour
+	tokenLength     = 32
+)
+
+// tokenInfo is the json representation of a registration token. The token itself is only known at creation.
+type tokenInfo struct {
+	ID          int        `json:"id"`
+	Token       string     `json:"token,omitempty"`
+	Description string     `json:"description,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	UsedAt      *time.Time `json:"used_at,omitempty"`
+	UsedBy      string     `json:"used_by,omitempty"`
+}
+
+func newTokenInfo(t *ent.RegistrationToken) tokenInfo {
+	return tokenInfo{
+		ID:          t.ID,
+		Description: t.Description,
+		CreatedAt:   t.CreatedAt,
+		ExpiresAt:   t.ExpiresAt,
+		UsedAt:      t.UsedAt,
+		UsedBy:      t.UsedBy,
+	}
+}
+
+func tokenStatus(t *ent.RegistrationToken) string {
+	switch {
+	case t.UsedAt != nil:
+		return "used"
+	case time.Now().UTC().After(t.ExpiresAt):
+		return "expired"
+	default:
+		return "valid"
+	}
+}
+
+func (cli *cliMachines) newTokenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token [action]",
+		Short: "Manage one-time registration tokens",
+		Long: `Manage one-time registration tokens.
+
+A log processor can use a registration token once, before it expires, to register
+to the local API and be validated at the same time, with "cscli lapi register --token".
+The tokens don't depend on the auto_registration settings.`,
+		DisableAutoGenTag: true,
+		Args:              args.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	cmd.AddCommand(cli.newTokenCreateCmd())
+	cmd.AddCommand(cli.newTokenListCmd())
+	cmd.AddCommand(cli.newTokenDeleteCmd())
+
+	return cmd
+}
+
+func (cli *cliMachines) tokenCreate(ctx context.Context, out io.Writer, ttl time.Duration, description string) error {
+	if ttl <= 0 {
+		return errors.New("the token duration must be positive")
+	}
+
+	token, err := middlewares.GenerateAPIKey(tokenLength)
+	if err != nil {
+		return fmt.Errorf("unable to generate token: %w", err)
+	}
+
+	created, err := cli.db.CreateRegistrationToken(ctx, middlewares.HashSHA512(token), description, time.Now().UTC().Add(ttl))
+	if err != nil {
+		return err
+	}
+
+	switch cli.cfg().Cscli.Output {
+	case "human":
+		fmt.Fprintln(out, token)
+		fmt.Fprintf(os.Stderr, "\nThis token can be used once, until %s.\n", created.ExpiresAt.Format(time.RFC3339))
+		fmt.Fprintln(os.Stderr, "Please keep it: it won't be displayed again.")
+		fmt.Fprintln(os.Stderr, "On the log processor, run: cscli lapi register --url <local API URL> --token <token>")
+	case "json":
+		info := newTokenInfo(created)
+		info.Token = token
+
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(info); err != nil {
+			return errors.New("failed to serialize")
+		}
+	case "raw":
+		fmt.Fprintln(out, token)
+	}
+
+	return nil
+}
+
+func (cli *cliMachines) newTokenCreateCmd() *cobra.Command {
+	var description string
+
+	ttl := cstime.DurationWithDays(defaultTokenTTL)
+
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "create a one-time registration token",
+		Example: `cscli machines token create
+cscli machines token create --ttl 1d --description "web servers"`,
+		Args:              args.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cli.tokenCreate(cmd.Context(), color.Output, time.Duration(ttl), description)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.Var(&ttl, "ttl", "how long the token can be used")
+	flags.StringVar(&description, "description", "", "description of the token")
+
+	return cmd
+}
+
+func (cli *cliMachines) tokenListHuman(out io.Writer, tokens []*ent.RegistrationToken) {
+	t := cstable.NewLight(out, cli.cfg().Cscli.Color).Writer
+	t.AppendHeader(table.Row{"ID", "Description", "Created At", "Expires At", "Status", "Used By"})
+
+	for _, tok := range tokens {
+		t.AppendRow(table.Row{tok.ID, tok.Description, tok.CreatedAt.Format(time.RFC3339), tok.ExpiresAt.Format(time.RFC3339), tokenStatus(tok), tok.UsedBy})
+	}
+
+	fmt.Fprintln(out, t.Render())
+}
+
+func (*cliMachines) tokenListCSV(out io.Writer, tokens []*ent.RegistrationToken) error {
+	csvwriter := csv.NewWriter(out)
+
+	if err := csvwriter.Write([]string{"id", "description", "created_at", "expires_at", "status", "used_by"}); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, tok := range tokens {
+		if err := csvwriter.Write([]string{strconv.Itoa(tok.ID), tok.Description, tok.CreatedAt.Format(time.RFC3339), tok.ExpiresAt.Format(time.RFC3339), tokenStatus(tok), tok.UsedBy}); err != nil {
+			return fmt.Errorf("failed to write raw output: %w", err)
+		}
+	}
+
+	csvwriter.Flush()
+
+	return nil
+}
+
+func (cli *cliMachines) tokenList(ctx context.Context, out io.Writer) error {
+	tokens, err := cli.db.ListRegistrationTokens(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to list registration tokens: %w", err)
+	}
+
+	switch cli.cfg().Cscli.Output {
+	case "human":
+		cli.tokenListHuman(out, tokens)
+	case "json":
+		info := make([]tokenInfo, 0, len(tokens))
+		for _, tok := range tokens {
+			info = append(info, newTokenInfo(tok))
+		}
+
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(info); err != nil {
+			return errors.New("failed to serialize")
+		}
+	case "raw":
+		return cli.tokenListCSV(out, tokens)
+	}
+
+	return nil
+}
+
+func (cli *cliMachines) newTokenListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "list",
+		Short:             "list the registration tokens",
+		Long:              `list the registration tokens. Expired tokens are removed by the local API after some time.`,
+		Example:           `cscli machines token list`,
+		Args:              args.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cli.tokenList(cmd.Context(), color.Output)
+		},
+	}
+
+	return cmd
+}
+
+func (cli *cliMachines) tokenDelete(ctx context.Context, ids []string) error {
+	for _, arg := range ids {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("invalid token id '%s'", arg)
+		}
+
+		if err := cli.db.DeleteRegistrationToken(ctx, id); err != nil {
+			return fmt.Errorf("unable to delete token: %w", err)
+		}
+
+		log.Infof("registration token %d deleted successfully", id)
+	}
+
+	return nil
+}
+
+func (cli *cliMachines) newTokenDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "delete [id]...",
+		Short:             "delete registration token(s) by id",
+		Example:           `cscli machines token delete 1 2`,
+		Args:              args.MinimumNArgs(1),
+		Aliases:           []string{"remove"},
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cli.tokenDelete(cmd.Context(), args)
+		},
+	}
+
+	return cmd
+}
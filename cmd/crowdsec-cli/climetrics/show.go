@@ -64,7 +64,7 @@ func expandAlias(args []string) []string {
 	for _, section := range args {
 		switch section {
 		case "engine":
-			ret = append(ret, "acquisition", "parsers", "scenarios", "stash", "whitelists")
+			ret = append(ret, "acquisition", "parsers", "quarantine", "scenarios", "stash", "whitelists")
 		case "lapi":
 			ret = append(ret, "alerts", "decisions", "lapi", "lapi-bouncer", "lapi-decisions", "lapi-machine")
 		case "appsec":
@@ -17,6 +17,7 @@ func (statAcquis) Description() (string, string) {
 		`Measures the lines read, parsed, and unparsed per datasource. ` +
 			`Zero read lines indicate a misconfigured or inactive datasource. ` +
 			`Zero parsed lines means the parser(s) failed. ` +
+			`Dropped lines are discarded by the transform expression of the datasource, before parsing. ` +
 			`Non-zero parsed lines are fine as crowdsec selects relevant lines.`
 }
 
@@ -30,9 +31,9 @@ func (s statAcquis) Process(source, metric string, val int) {
 
 func (s statAcquis) Table(out io.Writer, wantColor string, noUnit bool, showEmpty bool) {
 	t := cstable.New(out, wantColor).Writer
-	t.AppendHeader(table.Row{"Source", "Lines read", "Lines parsed", "Lines unparsed", "Lines poured to bucket", "Lines whitelisted"})
+	t.AppendHeader(table.Row{"Source", "Lines read", "Lines parsed", "Lines unparsed", "Lines poured to bucket", "Lines whitelisted", "Lines dropped"})
 
-	keys := []string{"reads", "parsed", "unparsed", "pour", "whitelisted"}
+	keys := []string{"reads", "parsed", "unparsed", "pour", "whitelisted", "dropped"}
 
 	if numRows, err := metricsToTable(t, s, keys, noUnit); err != nil {
 		log.Warningf("while collecting acquis stats: %s", err)
@@ -29,40 +29,70 @@ type bouncerMetricItem struct {
 	bouncerName string
 	ipType      string
 	origin      string
+	scenario    string
 	name        string
 	unit        string
 	value       float64
 }
 
 // aggregationOverTime is the first level of aggregation: we aggregate
-// over time, then over ip type, then over origin. we only sum values
+// over time, then over ip type and scenario, then over origin. we only sum values
 // for non-gauge metrics, and take the last value for gauge metrics.
-type aggregationOverTime map[string]map[string]map[string]map[string]map[string]int64
+type aggregationOverTime map[string]map[string]map[string]map[string]map[string]map[string]int64
 
-func (a aggregationOverTime) add(bouncerName, origin, name, unit, ipType string, value float64, isGauge bool) {
+func (a aggregationOverTime) add(bouncerName, origin, scenario, name, unit, ipType string, value float64, isGauge bool) {
 	if _, ok := a[bouncerName]; !ok {
-		a[bouncerName] = make(map[string]map[string]map[string]map[string]int64)
+		a[bouncerName] = make(map[string]map[string]map[string]map[string]map[string]int64)
 	}
 
 	if _, ok := a[bouncerName][origin]; !ok {
-		a[bouncerName][origin] = make(map[string]map[string]map[string]int64)
+		a[bouncerName][origin] = make(map[string]map[string]map[string]map[string]int64)
 	}
 
-	if _, ok := a[bouncerName][origin][name]; !ok {
-		a[bouncerName][origin][name] = make(map[string]map[string]int64)
+	if _, ok := a[bouncerName][origin][scenario]; !ok {
+		a[bouncerName][origin][scenario] = make(map[string]map[string]map[string]int64)
 	}
 
-	if _, ok := a[bouncerName][origin][name][unit]; !ok {
-		a[bouncerName][origin][name][unit] = make(map[string]int64)
+	if _, ok := a[bouncerName][origin][scenario][name]; !ok {
+		a[bouncerName][origin][scenario][name] = make(map[string]map[string]int64)
+	}
+
+	if _, ok := a[bouncerName][origin][scenario][name][unit]; !ok {
+		a[bouncerName][origin][scenario][name][unit] = make(map[string]int64)
 	}
 
 	if isGauge {
-		a[bouncerName][origin][name][unit][ipType] = int64(value)
+		a[bouncerName][origin][scenario][name][unit][ipType] = int64(value)
 	} else {
-		a[bouncerName][origin][name][unit][ipType] += int64(value)
+		a[bouncerName][origin][scenario][name][unit][ipType] += int64(value)
 	}
 }
 
+// aggregationOverScenario is used to display, below each origin, the metrics
+// reported for each scenario (if the bouncer provides the "scenario" label).
+// Like aggregationOverIPType, data is summed regardless of the metrics type.
+type aggregationOverScenario map[string]map[string]map[string]map[string]map[string]int64
+
+func (a aggregationOverScenario) add(bouncerName, origin, scenario, name, unit string, value int64) {
+	if _, ok := a[bouncerName]; !ok {
+		a[bouncerName] = make(map[string]map[string]map[string]map[string]int64)
+	}
+
+	if _, ok := a[bouncerName][origin]; !ok {
+		a[bouncerName][origin] = make(map[string]map[string]map[string]int64)
+	}
+
+	if _, ok := a[bouncerName][origin][scenario]; !ok {
+		a[bouncerName][origin][scenario] = make(map[string]map[string]int64)
+	}
+
+	if _, ok := a[bouncerName][origin][scenario][name]; !ok {
+		a[bouncerName][origin][scenario][name] = make(map[string]int64)
+	}
+
+	a[bouncerName][origin][scenario][name][unit] += value
+}
+
 // aggregationOverIPType is the second level of aggregation: data is summed
 // regardless of the metrics type (gauge or not). This is used to display
 // table rows, they won't differentiate ipv4 and ipv6
@@ -110,12 +140,16 @@ type statBouncer struct {
 	// aggregate over origin: always sum
 	// [bouncer][name][unit]value
 	aggOverOrigin aggregationOverOrigin
+	// aggregate over ip type, keeping the scenario: always sum
+	// [bouncer][origin][scenario][name][unit]value
+	aggOverScenario aggregationOverScenario
 }
 
 var knownPlurals = map[string]string{
-	"byte":   "bytes",
-	"packet": "packets",
-	"ip":     "IPs",
+	"byte":    "bytes",
+	"packet":  "packets",
+	"ip":      "IPs",
+	"request": "requests",
 }
 
 func (s *statBouncer) MarshalJSON() ([]byte, error) {
@@ -202,6 +236,7 @@ func (*statBouncer) extractRawMetrics(metrics []*ent.Metric) ([]bouncerMetricIte
 					bouncerName: bouncerName,
 					ipType:      item.Labels["ip_type"],
 					origin:      item.Labels["origin"],
+					scenario:    item.Labels["scenario"],
 					name:        *item.Name,
 					unit:        *item.Unit,
 					value:       *item.Value,
@@ -246,7 +281,8 @@ func (s *statBouncer) Fetch(ctx context.Context, db *database.Client) error {
 
 	s.oldestTS = oldestTS
 	aggOverTime := s.newAggregationOverTime(rawMetrics)
-	s.aggOverIPType = s.newAggregationOverIPType(aggOverTime)
+	s.aggOverScenario = s.newAggregationOverScenario(aggOverTime)
+	s.aggOverIPType = s.newAggregationOverIPType(s.aggOverScenario)
 	s.aggOverOrigin = s.newAggregationOverOrigin(s.aggOverIPType)
 
 	return nil
@@ -281,21 +317,42 @@ func (s *statBouncer) newAggregationOverTime(rawMetrics []bouncerMetricItem) agg
 	ret := aggregationOverTime{}
 
 	for _, raw := range rawMetrics {
-		ret.add(raw.bouncerName, raw.origin, raw.name, raw.unit, raw.ipType, raw.value, s.isGauge(raw.name))
+		ret.add(raw.bouncerName, raw.origin, raw.scenario, raw.name, raw.unit, raw.ipType, raw.value, s.isGauge(raw.name))
 	}
 
 	return ret
 }
 
-func (*statBouncer) newAggregationOverIPType(aggMetrics aggregationOverTime) aggregationOverIPType {
+func (*statBouncer) newAggregationOverScenario(aggMetrics aggregationOverTime) aggregationOverScenario {
+	ret := aggregationOverScenario{}
+
+	for bouncerName := range aggMetrics {
+		for origin := range aggMetrics[bouncerName] {
+			for scenario := range aggMetrics[bouncerName][origin] {
+				for name := range aggMetrics[bouncerName][origin][scenario] {
+					for unit := range aggMetrics[bouncerName][origin][scenario][name] {
+						for ipType := range aggMetrics[bouncerName][origin][scenario][name][unit] {
+							value := aggMetrics[bouncerName][origin][scenario][name][unit][ipType]
+							ret.add(bouncerName, origin, scenario, name, unit, value)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return ret
+}
+
+func (*statBouncer) newAggregationOverIPType(aggMetrics aggregationOverScenario) aggregationOverIPType {
 	ret := aggregationOverIPType{}
 
 	for bouncerName := range aggMetrics {
 		for origin := range aggMetrics[bouncerName] {
-			for name := range aggMetrics[bouncerName][origin] {
-				for unit := range aggMetrics[bouncerName][origin][name] {
-					for ipType := range aggMetrics[bouncerName][origin][name][unit] {
-						value := aggMetrics[bouncerName][origin][name][unit][ipType]
+			for scenario := range aggMetrics[bouncerName][origin] {
+				for name := range aggMetrics[bouncerName][origin][scenario] {
+					for unit := range aggMetrics[bouncerName][origin][scenario][name] {
+						value := aggMetrics[bouncerName][origin][scenario][name][unit]
 						ret.add(bouncerName, origin, name, unit, value)
 					}
 				}
@@ -323,6 +380,25 @@ func (*statBouncer) newAggregationOverOrigin(aggMetrics aggregationOverIPType) a
 	return ret
 }
 
+// metricsRow returns a table row with the value of each column, or "-" if there is none
+func (*statBouncer) metricsRow(label string, metrics map[string]map[string]int64, columns map[string]map[string]struct{}, noUnit bool) table.Row {
+	row := table.Row{label}
+
+	for _, name := range maptools.SortedKeys(columns) {
+		for _, unit := range maptools.SortedKeys(columns[name]) {
+			valStr := "-"
+
+			if val, ok := metrics[name][unit]; ok {
+				valStr = formatNumber(val, !noUnit)
+			}
+
+			row = append(row, valStr)
+		}
+	}
+
+	return row
+}
+
 // bouncerTable displays a table of metrics for a single bouncer
 func (s *statBouncer) bouncerTable(out io.Writer, bouncerName string, wantColor string, noUnit bool) {
 	columns := make(map[string]map[string]struct{})
@@ -397,25 +473,20 @@ func (s *statBouncer) bouncerTable(out io.Writer, bouncerName string, wantColor
 			continue
 		}
 
-		metrics := s.aggOverIPType[bouncerName][origin]
-
-		row := table.Row{s.formatMetricOrigin(origin)}
-
-		for _, name := range maptools.SortedKeys(columns) {
-			for _, unit := range maptools.SortedKeys(columns[name]) {
-				valStr := "-"
+		t.AppendRow(s.metricsRow(s.formatMetricOrigin(origin), s.aggOverIPType[bouncerName][origin], columns, noUnit))
 
-				if val, ok := metrics[name][unit]; ok {
-					valStr = formatNumber(val, !noUnit)
-				}
+		numRows += 1
 
-				row = append(row, valStr)
+		// detail the origin by scenario, when the bouncer reports them
+		for _, scenario := range maptools.SortedKeys(s.aggOverScenario[bouncerName][origin]) {
+			if scenario == "" {
+				continue
 			}
-		}
 
-		t.AppendRow(row)
+			t.AppendRow(s.metricsRow("  "+scenario, s.aggOverScenario[bouncerName][origin][scenario], columns, noUnit))
 
-		numRows += 1
+			numRows += 1
+		}
 	}
 
 	totals := s.aggOverOrigin[bouncerName]
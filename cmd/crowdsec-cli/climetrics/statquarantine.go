@@ -0,0 +1,67 @@
+package climetrics
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+
+	"github.com/crowdsecurity/go-cs-lib/maptools"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
+)
+
+type quarantineStat struct {
+	Quarantined bool `json:"quarantined"`
+	Skipped     int  `json:"skipped_lines"`
+}
+
+type statQuarantine map[string]*quarantineStat
+
+func (statQuarantine) Description() (string, string) {
+	return "Parser Quarantine Metrics",
+		`Datasources that went over the parsing error budget. The lines of a quarantined source are read, but not parsed.`
+}
+
+func (s statQuarantine) Process(source, metric string, val int) {
+	if _, ok := s[source]; !ok {
+		s[source] = &quarantineStat{}
+	}
+
+	switch metric {
+	case "quarantined":
+		s[source].Quarantined = val > 0
+	case "skipped":
+		s[source].Skipped += val
+	}
+}
+
+func (s statQuarantine) Table(out io.Writer, wantColor string, noUnit bool, showEmpty bool) {
+	t := cstable.New(out, wantColor).Writer
+	t.AppendHeader(table.Row{"Source", "Quarantined", "Skipped lines"})
+
+	numRows := 0
+
+	for _, source := range maptools.SortedKeys(s) {
+		stat := s[source]
+
+		quarantined := "no"
+		if stat.Quarantined {
+			quarantined = "yes"
+		}
+
+		t.AppendRow(table.Row{
+			source,
+			quarantined,
+			formatNumber(int64(stat.Skipped), !noUnit),
+		})
+
+		numRows++
+	}
+
+	if numRows > 0 || showEmpty {
+		title, _ := s.Description()
+		t.SetTitle(title)
+		fmt.Fprintln(out, t.Render())
+	}
+}
@@ -35,6 +35,7 @@ func NewMetricStore() metricStore {
 		"lapi-decisions": statLapiDecision{},
 		"lapi-machine":   statLapiMachine{},
 		"parsers":        statParser{},
+		"quarantine":     statQuarantine{},
 		"scenarios":      statBucket{},
 		"stash":          statStash{},
 		"whitelists":     statWhitelist{},
@@ -68,6 +69,7 @@ func (ms metricStore) processPrometheusMetrics(result []MetricPoint) {
 	mLapiDecision := ms["lapi-decisions"].(statLapiDecision)
 	mLapiMachine := ms["lapi-machine"].(statLapiMachine)
 	mParser := ms["parsers"].(statParser)
+	mQuarantine := ms["quarantine"].(statQuarantine)
 	mBucket := ms["scenarios"].(statBucket)
 	mStash := ms["stash"].(statStash)
 	mWhitelist := ms["whitelists"].(statWhitelist)
@@ -132,12 +134,18 @@ func (ms metricStore) processPrometheusMetrics(result []MetricPoint) {
 			mAcquis.Process(source, "parsed", ival)
 		case metrics.GlobalParserHitsKoMetricName:
 			mAcquis.Process(source, "unparsed", ival)
+		case metrics.TransformDroppedLinesMetricName, metrics.StructuredDroppedLinesMetricName:
+			mAcquis.Process(source, "dropped", ival)
 		case metrics.NodesHitsMetricName:
 			mParser.Process(name, "hits", ival)
 		case metrics.NodesHitsOkMetricName:
 			mParser.Process(name, "parsed", ival)
 		case metrics.NodesHitsKoMetricName:
 			mParser.Process(name, "unparsed", ival)
+		case metrics.GlobalParserQuarantinedSourcesMetricName:
+			mQuarantine.Process(source, "quarantined", ival)
+		case metrics.GlobalParserQuarantinedLinesMetricName:
+			mQuarantine.Process(source, "skipped", ival)
 		//
 		// whitelists
 		//
@@ -20,7 +20,6 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"gopkg.in/tomb.v2"
-	"gopkg.in/yaml.v3"
 
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/require"
@@ -32,7 +31,6 @@ import (
 	"github.com/crowdsecurity/crowdsec/pkg/database"
 	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
 	"github.com/crowdsecurity/crowdsec/pkg/models"
-	"github.com/crowdsecurity/crowdsec/pkg/types"
 )
 
 type NotificationsCfg struct {
@@ -160,15 +158,31 @@ func (cli *cliNotifications) getProfilesConfigs() (map[string]NotificationsCfg,
 }
 
 func (cli *cliNotifications) newListCmd() *cobra.Command {
+	var (
+		testAll       bool
+		alertOverride string
+	)
+
 	cmd := &cobra.Command{
-		Use:               "list",
-		Short:             "list notifications plugins",
-		Long:              `list notifications plugins and their status (active or not)`,
-		Example:           `cscli notifications list`,
+		Use:   "list",
+		Short: "list notifications plugins",
+		Long: `list notifications plugins and their status (active or not).
+
+With --test-all, a test alert is sent to every configured plugin, even if it is not active in profiles,
+and the result and latency of each delivery are reported with the delivery counters of the running crowdsec.`,
+		Example: `cscli notifications list
+
+# check that every plugin can deliver a notification
+cscli notifications list --test-all`,
 		Args:              args.NoArgs,
 		DisableAutoGenTag: true,
-		RunE: func(_ *cobra.Command, _ []string) error {
+		RunE: func(cmd *cobra.Command, _ []string) error {
 			cfg := cli.cfg()
+
+			if testAll {
+				return cli.testAll(cmd.Context(), color.Output, alertOverride)
+			}
+
 			ncfgs, err := cli.getProfilesConfigs()
 			if err != nil {
 				return fmt.Errorf("can't build profiles configuration: %w", err)
@@ -205,6 +219,12 @@ func (cli *cliNotifications) newListCmd() *cobra.Command {
 		},
 	}
 
+	flags := cmd.Flags()
+	flags.BoolVar(&testAll, "test-all", false, "send a test alert to all the notification plugins and report the results")
+	flags.StringVarP(&alertOverride, "alert", "a", "",
+		"with --test-all, JSON string used to override alert fields in the generic alert "+
+			"(see crowdsec/pkg/models/alert.go in the source tree for the full definition of the object)")
+
 	return cmd
 }
 
@@ -297,12 +317,7 @@ func (cli *cliNotifications) newTestCmd() *cobra.Command {
 				return fmt.Errorf("plugin name: '%s' does not exist", args[0])
 			}
 
-			if cfg.API.CTI != nil && cfg.API.CTI.Enabled != nil && *cfg.API.CTI.Enabled {
-				log.Infof("Crowdsec CTI helper enabled")
-				if err := ctiexpr.InitCrowdsecCTI(cfg.API.CTI.Key, cfg.API.CTI.CacheTimeout, cfg.API.CTI.CacheSize, cfg.API.CTI.LogLevel); err != nil {
-					log.Errorf("failed to init crowdsec cti: %s", err)
-				}
-			}
+			cli.initCTI()
 
 			// Create a single profile with plugin name as notification name
 			return pluginBroker.Init(ctx, cfg.PluginConfig, []*csconfig.ProfileCfg{
@@ -314,44 +329,15 @@ func (cli *cliNotifications) newTestCmd() *cobra.Command {
 			}, cfg.ConfigPaths)
 		},
 		RunE: func(_ *cobra.Command, _ []string) error {
+			alert, err := newTestAlert(alertOverride)
+			if err != nil {
+				return err
+			}
+
 			pluginTomb.Go(func() error {
 				pluginBroker.Run(&pluginTomb)
 				return nil
 			})
-			alert := &models.Alert{
-				Capacity: new(int32(0)),
-				Decisions: []*models.Decision{{
-					Duration: new("4h"),
-					Scope:    new("Ip"),
-					Value:    new("10.10.10.10"),
-					Type:     new("ban"),
-					Scenario: new("test alert"),
-					Origin:   new(types.CscliOrigin),
-				}},
-				Events:          []*models.Event{},
-				EventsCount:     new(int32(1)),
-				Leakspeed:       new("0"),
-				Message:         new("test alert"),
-				ScenarioHash:    new(""),
-				Scenario:        new("test alert"),
-				ScenarioVersion: new(""),
-				Simulated:       new(false),
-				Source: &models.Source{
-					AsName:   "",
-					AsNumber: "",
-					Cn:       "",
-					IP:       "10.10.10.10",
-					Range:    "",
-					Scope:    new("Ip"),
-					Value:    new("10.10.10.10"),
-				},
-				StartAt:   new(time.Now().UTC().Format(time.RFC3339)),
-				StopAt:    new(time.Now().UTC().Format(time.RFC3339)),
-				CreatedAt: time.Now().UTC().Format(time.RFC3339),
-			}
-			if err := yaml.Unmarshal([]byte(alertOverride), alert); err != nil {
-				return fmt.Errorf("failed to parse alert override: %w", err)
-			}
 
 			pluginBroker.PluginChannel <- models.ProfileAlert{
 				ProfileID: uint(0),
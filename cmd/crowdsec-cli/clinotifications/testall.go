@@ -0,0 +1,265 @@
+package clinotifications
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/climetrics"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/csplugin"
+	"github.com/crowdsecurity/crowdsec/pkg/cticlient/ctiexpr"
+	"github.com/crowdsecurity/crowdsec/pkg/emoji"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+)
+
+// deliveryResult is the outcome of a test notification, with the delivery counters of the running crowdsec.
+type deliveryResult struct {
+	Name      string        `json:"name"`
+	Type      string        `json:"type"`
+	Success   bool          `json:"success"`
+	Latency   time.Duration `json:"latency_ns"`
+	Error     string        `json:"error,omitempty"`
+	Delivered *int          `json:"delivered,omitempty"`
+	Failed    *int          `json:"failed,omitempty"`
+}
+
+// newTestAlert returns the generic alert sent by the test commands, with the fields of override (JSON or YAML).
+func newTestAlert(override string) (*models.Alert, error) {
+	alert := &models.Alert{
+		Capacity: new(int32(0)),
+		Decisions: []*models.Decision{{
+			Duration: new("4h"),
+			Scope:    new("Ip"),
+			Value:    new("10.10.10.10"),
+			Type:     new("ban"),
+			Scenario: new("test alert"),
+			Origin:   new(types.CscliOrigin),
+		}},
+		Events:          []*models.Event{},
+		EventsCount:     new(int32(1)),
+		Leakspeed:       new("0"),
+		Message:         new("test alert"),
+		ScenarioHash:    new(""),
+		Scenario:        new("test alert"),
+		ScenarioVersion: new(""),
+		Simulated:       new(false),
+		Source: &models.Source{
+			AsName:   "",
+			AsNumber: "",
+			Cn:       "",
+			IP:       "10.10.10.10",
+			Range:    "",
+			Scope:    new("Ip"),
+			Value:    new("10.10.10.10"),
+		},
+		StartAt:   new(time.Now().UTC().Format(time.RFC3339)),
+		StopAt:    new(time.Now().UTC().Format(time.RFC3339)),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if err := yaml.Unmarshal([]byte(override), alert); err != nil {
+		return nil, fmt.Errorf("failed to parse alert override: %w", err)
+	}
+
+	return alert, nil
+}
+
+func (cli *cliNotifications) initCTI() {
+	cfg := cli.cfg()
+
+	if cfg.API.CTI != nil && cfg.API.CTI.Enabled != nil && *cfg.API.CTI.Enabled {
+		log.Infof("Crowdsec CTI helper enabled")
+
+		if err := ctiexpr.InitCrowdsecCTI(cfg.API.CTI.Key, cfg.API.CTI.CacheTimeout, cfg.API.CTI.CacheSize, cfg.API.CTI.LogLevel); err != nil {
+			log.Errorf("failed to init crowdsec cti: %s", err)
+		}
+	}
+}
+
+// testPlugin starts a plugin, sends it the alert and waits for the delivery.
+func (cli *cliNotifications) testPlugin(ctx context.Context, pcfg csplugin.PluginConfig, alert *models.Alert) deliveryResult {
+	var pluginBroker csplugin.PluginBroker
+
+	cfg := cli.cfg()
+	result := deliveryResult{
+		Name: pcfg.Name,
+		Type: pcfg.Type,
+	}
+
+	// a profile with only this plugin, so that the other ones are not started
+	err := pluginBroker.Init(ctx, cfg.PluginConfig, []*csconfig.ProfileCfg{{Notifications: []string{pcfg.Name}}}, cfg.ConfigPaths)
+	defer pluginBroker.Kill()
+
+	if err == nil {
+		start := time.Now()
+		err = pluginBroker.Deliver(ctx, pcfg.Name, []*models.Alert{alert})
+		result.Latency = time.Since(start)
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Success = true
+
+	return result
+}
+
+// addDeliveryCounters fills the results with the delivery counters of the running crowdsec.
+func addDeliveryCounters(ctx context.Context, prometheusURL string, results []deliveryResult) error {
+	points, err := climetrics.ScrapeMetrics(ctx, prometheusURL)
+	if err != nil {
+		return err
+	}
+
+	for i := range results {
+		delivered, failed := 0, 0
+
+		for _, p := range points {
+			if p.Name != metrics.NotificationDeliveriesMetricName || p.Labels["plugin"] != results[i].Name {
+				continue
+			}
+
+			switch p.Labels["status"] {
+			case "success":
+				delivered += int(p.Value)
+			case "failure":
+				failed += int(p.Value)
+			}
+		}
+
+		results[i].Delivered = &delivered
+		results[i].Failed = &failed
+	}
+
+	return nil
+}
+
+func (cli *cliNotifications) testAll(ctx context.Context, out io.Writer, alertOverride string) error {
+	cfg := cli.cfg()
+
+	pconfigs, err := cli.getPluginConfigs()
+	if err != nil {
+		return fmt.Errorf("can't build profiles configuration: %w", err)
+	}
+
+	if len(pconfigs) == 0 {
+		return fmt.Errorf("no notification plugin configured in %s", cfg.ConfigPaths.NotificationDir)
+	}
+
+	alert, err := newTestAlert(alertOverride)
+	if err != nil {
+		return err
+	}
+
+	cli.initCTI()
+
+	names := make([]string, 0, len(pconfigs))
+	for name := range pconfigs {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	results := make([]deliveryResult, len(names))
+
+	var wg sync.WaitGroup
+
+	for i, name := range names {
+		wg.Go(func() {
+			results[i] = cli.testPlugin(ctx, pconfigs[name], alert)
+		})
+	}
+
+	wg.Wait()
+
+	if err := addDeliveryCounters(ctx, cfg.Cscli.PrometheusUrl, results); err != nil {
+		log.Warningf("can't read the delivery counters from %s, is crowdsec running? %s", cfg.Cscli.PrometheusUrl, err)
+	}
+
+	if err := printDeliveryResults(out, results, cfg.Cscli.Output, cfg.Cscli.Color); err != nil {
+		return err
+	}
+
+	failed := 0
+
+	for _, r := range results {
+		if !r.Success {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d notification plugins failed the test", failed, len(results))
+	}
+
+	return nil
+}
+
+func formatCounter(c *int) string {
+	if c == nil {
+		return "-"
+	}
+
+	return strconv.Itoa(*c)
+}
+
+func printDeliveryResults(out io.Writer, results []deliveryResult, output string, wantColor string) error {
+	switch output {
+	case "human":
+		t := cstable.New(out, wantColor).Writer
+		t.AppendHeader(table.Row{"Name", "Type", "Test", "Latency", "Delivered", "Failed", "Error"})
+
+		for _, r := range results {
+			status := emoji.CheckMark
+			if !r.Success {
+				status = emoji.Prohibited
+			}
+
+			t.AppendRow(table.Row{r.Name, r.Type, status, r.Latency.Round(time.Millisecond).String(), formatCounter(r.Delivered), formatCounter(r.Failed), r.Error})
+		}
+
+		fmt.Fprintln(out, t.Render())
+	case "json":
+		x, err := json.MarshalIndent(results, "", " ")
+		if err != nil {
+			return fmt.Errorf("failed to serialize test results: %w", err)
+		}
+
+		fmt.Fprintln(out, string(x))
+	case "raw":
+		csvwriter := csv.NewWriter(out)
+
+		if err := csvwriter.Write([]string{"name", "type", "success", "latency", "delivered", "failed", "error"}); err != nil {
+			return fmt.Errorf("failed to write raw header: %w", err)
+		}
+
+		for _, r := range results {
+			row := []string{r.Name, r.Type, strconv.FormatBool(r.Success), r.Latency.String(), formatCounter(r.Delivered), formatCounter(r.Failed), r.Error}
+			if err := csvwriter.Write(row); err != nil {
+				return fmt.Errorf("failed to write raw content: %w", err)
+			}
+		}
+
+		csvwriter.Flush()
+	default:
+		return fmt.Errorf("unknown output format '%s'", output)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package clipapi
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"time"
@@ -52,22 +53,62 @@ func (cli *cliPapi) NewCommand() *cobra.Command {
 
 	cmd.AddCommand(cli.newStatusCmd())
 	cmd.AddCommand(cli.newSyncCmd())
+	cmd.AddCommand(cli.newReplayCmd())
 
 	return cmd
 }
 
-func (cli *cliPapi) Status(ctx context.Context, out io.Writer, db *database.Client) error {
+// papiStatus is what cscli papi status shows.
+type papiStatus struct {
+	URL               string     `json:"url"`
+	ConsoleManagement bool       `json:"console_management"`
+	Subscription      string     `json:"subscription"`
+	Plan              string     `json:"plan"`
+	Categories        []string   `json:"categories"`
+	LastPull          *time.Time `json:"last_pull"`
+}
+
+func (cli *cliPapi) newPAPI(ctx context.Context, db *database.Client) (*apiserver.Papi, error) {
 	cfg := cli.cfg()
 
 	apic, err := apiserver.NewAPIC(ctx, cfg.API.Server.OnlineClient, db, cfg.API.Server.ConsoleConfig, cfg.API.Server.CapiWhitelists)
 	if err != nil {
-		return fmt.Errorf("unable to initialize API client: %w", err)
+		return nil, fmt.Errorf("unable to initialize API client: %w", err)
 	}
 
 	papiLogger := cfg.API.Server.NewPAPILogger()
+
 	papi, err := apiserver.NewPAPI(apic, db, cfg.API.Server.ConsoleConfig, papiLogger)
 	if err != nil {
-		return fmt.Errorf("unable to initialize PAPI client: %w", err)
+		return nil, fmt.Errorf("unable to initialize PAPI client: %w", err)
+	}
+
+	papi.ManagedProfilesDir = cfg.API.Server.ManagedProfilesDir
+
+	return papi, nil
+}
+
+// lastPull returns the time of the last order received by the local API, nil if there was none.
+func lastPull(ctx context.Context, db *database.Client) *time.Time {
+	raw, err := db.GetConfigItem(ctx, apiserver.PapiPullKey)
+	if err != nil || raw == "" {
+		return nil
+	}
+
+	last := time.Time{}
+	if err := last.UnmarshalText([]byte(raw)); err != nil || last.IsZero() {
+		return nil
+	}
+
+	return &last
+}
+
+func (cli *cliPapi) Status(ctx context.Context, out io.Writer, db *database.Client) error {
+	cfg := cli.cfg()
+
+	papi, err := cli.newPAPI(ctx, db)
+	if err != nil {
+		return err
 	}
 
 	perms, err := papi.GetPermissions(ctx)
@@ -75,28 +116,60 @@ func (cli *cliPapi) Status(ctx context.Context, out io.Writer, db *database.Clie
 		return fmt.Errorf("unable to get PAPI permissions: %w", err)
 	}
 
-	lastTimestampStr, err := db.GetConfigItem(ctx, apiserver.PapiPullKey)
-	if err != nil {
-		lastTimestampStr = "never"
+	consoleCfg := cfg.API.Server.ConsoleConfig
+
+	st := papiStatus{
+		URL:               papi.URL,
+		ConsoleManagement: consoleCfg != nil && consoleCfg.ConsoleManagement != nil && *consoleCfg.ConsoleManagement,
+		Subscription:      papi.SubscriptionType(),
+		Plan:              perms.Plan,
+		Categories:        perms.Categories,
+		LastPull:          lastPull(ctx, db),
+	}
+
+	if cfg.Cscli.Output == "json" {
+		data, err := json.MarshalIndent(st, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to serialize PAPI status: %w", err)
+		}
+
+		fmt.Fprintln(out, string(data))
+
+		return nil
 	}
 
-	// both can and did happen
-	if lastTimestampStr == "" || lastTimestampStr == "0001-01-01T00:00:00Z" {
-		lastTimestampStr = "never"
+	lastTimestampStr := "never"
+	if st.LastPull != nil {
+		lastTimestampStr = fmt.Sprintf("%s (%s ago)", st.LastPull.Format(time.RFC3339), time.Since(*st.LastPull).Truncate(time.Second))
 	}
 
 	fmt.Fprint(out, "You can successfully interact with Polling API (PAPI)\n")
-	fmt.Fprintf(out, "Console plan: %s\n", perms.Plan)
+	fmt.Fprintf(out, "PAPI URL: %s\n", st.URL)
+	fmt.Fprintf(out, "Console plan: %s\n", st.Plan)
+	fmt.Fprintf(out, "Subscription: %s\n", st.Subscription)
+	fmt.Fprintf(out, "Console management: %s\n", enabledString(st.ConsoleManagement))
 	fmt.Fprintf(out, "Last order received: %s\n", lastTimestampStr)
 	fmt.Fprint(out, "PAPI subscriptions:\n")
 
-	for _, sub := range perms.Categories {
+	for _, sub := range st.Categories {
 		fmt.Fprintf(out, " - %s\n", sub)
 	}
 
+	if !st.ConsoleManagement {
+		fmt.Fprint(out, "\nThe local API does not apply the orders of the console, enable them with 'cscli console enable console_management'\n")
+	}
+
 	return nil
 }
 
+func enabledString(b bool) string {
+	if b {
+		return "enabled"
+	}
+
+	return "disabled"
+}
+
 func (cli *cliPapi) newStatusCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:               "status",
@@ -119,7 +192,9 @@ func (cli *cliPapi) newStatusCmd() *cobra.Command {
 	return cmd
 }
 
-func (cli *cliPapi) sync(ctx context.Context, db *database.Client) error {
+// pull applies the orders sent since the given time, all the non-expired ones if it's zero. The
+// management orders are ignored.
+func (cli *cliPapi) pull(ctx context.Context, db *database.Client, since time.Time) error {
 	cfg := cli.cfg()
 
 	apic, err := apiserver.NewAPIC(ctx, cfg.API.Server.OnlineClient, db, cfg.API.Server.ConsoleConfig, cfg.API.Server.CapiWhitelists)
@@ -138,7 +213,7 @@ func (cli *cliPapi) sync(ctx context.Context, db *database.Client) error {
 
 	g.Go(func() error { return papi.SyncDecisions(ctx) })
 
-	err = papi.PullOnce(ctx, time.Time{}, true)
+	err = papi.PullOnce(ctx, since, true)
 	if err != nil {
 		return fmt.Errorf("unable to sync decisions: %w", err)
 	}
@@ -168,7 +243,7 @@ func (cli *cliPapi) newSyncCmd() *cobra.Command {
 				return err
 			}
 
-			return cli.sync(ctx, db)
+			return cli.pull(ctx, db, time.Time{})
 		},
 	}
 
@@ -0,0 +1,139 @@
+package clipapi
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/spf13/cobra"
+
+	"github.com/crowdsecurity/go-cs-lib/cstime"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/require"
+	"github.com/crowdsecurity/crowdsec/pkg/apiserver"
+)
+
+func ordersTable(out io.Writer, wantColor string, orders []apiserver.Order) {
+	t := cstable.New(out, wantColor)
+	t.SetRowLines(false)
+
+	t.SetHeaders("Time", "Type", "Command", "User", "UUID", "Error")
+	t.SetHeaderAlignment(text.AlignLeft, text.AlignLeft, text.AlignLeft, text.AlignLeft, text.AlignLeft, text.AlignLeft)
+
+	for _, o := range orders {
+		t.AddRow(o.Timestamp.Format(time.RFC3339), o.Type, o.Cmd, o.User, o.UUID, o.Error)
+	}
+
+	t.Render()
+}
+
+func (cli *cliPapi) listOrders(ctx context.Context, since time.Time) error {
+	cfg := cli.cfg()
+
+	db, err := require.DBClient(ctx, cfg.DbConfig)
+	if err != nil {
+		return err
+	}
+
+	papi, err := cli.newPAPI(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	orders, err := papi.ListOrders(ctx, since)
+	if err != nil {
+		return fmt.Errorf("unable to pull PAPI orders: %w", err)
+	}
+
+	switch cfg.Cscli.Output {
+	case "human":
+		if len(orders) == 0 {
+			fmt.Fprintf(color.Output, "No order since %s\n", since.Format(time.RFC3339))
+			return nil
+		}
+
+		ordersTable(color.Output, cfg.Cscli.Color, orders)
+		fmt.Fprintf(color.Output, "%d order(s) would be applied, run without --dry-run to apply them\n", len(orders))
+	case "json":
+		data, err := json.MarshalIndent(orders, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to serialize orders: %w", err)
+		}
+
+		fmt.Fprintln(color.Output, string(data))
+	case "raw":
+		csvwriter := csv.NewWriter(color.Output)
+
+		if err := csvwriter.Write([]string{"timestamp", "type", "cmd", "user", "uuid", "error"}); err != nil {
+			return err
+		}
+
+		for _, o := range orders {
+			if err := csvwriter.Write([]string{o.Timestamp.Format(time.RFC3339), o.Type, o.Cmd, o.User, o.UUID, o.Error}); err != nil {
+				return err
+			}
+		}
+
+		csvwriter.Flush()
+	}
+
+	return nil
+}
+
+func (cli *cliPapi) newReplayCmd() *cobra.Command {
+	var (
+		since  cstime.DurationWithDays
+		dryRun bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Apply again the orders received from the Polling API in a period",
+		Long: `Pull the orders sent by the console since the given time and apply them again, for example
+after restoring the database or when the local API was down for longer than the retention of the
+orders. The management orders (enrollment, options) are ignored.
+
+The last order received by the local API is shown by 'cscli papi status'.`,
+		Example: `# list the orders of the last 6 hours, without applying them
+cscli papi replay --since 6h --dry-run
+
+cscli papi replay --since 2d`,
+		Args:              args.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if since <= 0 {
+				return errors.New("--since must be positive")
+			}
+
+			ctx := cmd.Context()
+			from := time.Now().UTC().Add(-time.Duration(since))
+
+			if dryRun {
+				return cli.listOrders(ctx, from)
+			}
+
+			db, err := require.DBClient(ctx, cli.cfg().DbConfig)
+			if err != nil {
+				return err
+			}
+
+			return cli.pull(ctx, db, from)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.Var(&since, "since", "Replay the orders newer than since (ie. 4h, 2d)")
+	flags.BoolVar(&dryRun, "dry-run", false, "List the orders without applying them")
+
+	_ = cmd.MarkFlagRequired("since")
+
+	return cmd
+}
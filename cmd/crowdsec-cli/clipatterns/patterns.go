@@ -0,0 +1,131 @@
+package clipatterns
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/parser"
+)
+
+// longer patterns are truncated in the human output
+const maxExpressionLen = 80
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+
+	return s[:n-3] + "..."
+}
+
+type cliPatterns struct {
+	cfg csconfig.Getter
+}
+
+func New(cfg csconfig.Getter) *cliPatterns {
+	return &cliPatterns{
+		cfg: cfg,
+	}
+}
+
+func (cli *cliPatterns) NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "patterns [command]",
+		Short: "Inspect the grok pattern library",
+		Long: `Inspect the grok patterns used by the parsers.
+
+Patterns are loaded from pattern_dir, then from pattern_override_dir: a pattern defined
+in the override directory replaces the one with the same name, and is not affected by upgrades.`,
+		DisableAutoGenTag: true,
+		Args:              args.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	cmd.AddCommand(cli.newListCmd())
+
+	return cmd
+}
+
+func (cli *cliPatterns) list(out io.Writer, showOrigin bool) error {
+	cfg := cli.cfg()
+
+	patterns, err := parser.ListPatterns(cfg.ConfigPaths.PatternDir, cfg.ConfigPaths.PatternOverrideDir)
+	if err != nil {
+		return fmt.Errorf("unable to load patterns: %w", err)
+	}
+
+	switch cfg.Cscli.Output {
+	case "human":
+		t := cstable.NewLight(out, cfg.Cscli.Color).Writer
+
+		if showOrigin {
+			t.AppendHeader(table.Row{"Name", "Origin", "Overrides"})
+		} else {
+			t.AppendHeader(table.Row{"Name", "Pattern"})
+		}
+
+		for _, p := range patterns {
+			if showOrigin {
+				t.AppendRow(table.Row{p.Name, p.Origin, p.Overrides})
+			} else {
+				t.AppendRow(table.Row{p.Name, truncate(p.Expression, maxExpressionLen)})
+			}
+		}
+
+		fmt.Fprintln(out, t.Render())
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(patterns); err != nil {
+			return fmt.Errorf("failed to serialize: %w", err)
+		}
+	case "raw":
+		csvwriter := csv.NewWriter(out)
+
+		if err := csvwriter.Write([]string{"name", "origin", "overrides", "pattern"}); err != nil {
+			return fmt.Errorf("failed to write raw header: %w", err)
+		}
+
+		for _, p := range patterns {
+			if err := csvwriter.Write([]string{p.Name, p.Origin, p.Overrides, p.Expression}); err != nil {
+				return fmt.Errorf("failed to write raw: %w", err)
+			}
+		}
+
+		csvwriter.Flush()
+	}
+
+	return nil
+}
+
+func (cli *cliPatterns) newListCmd() *cobra.Command {
+	var showOrigin bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List grok patterns",
+		Example: `cscli patterns list
+cscli patterns list --origin`,
+		Args:              args.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return cli.list(os.Stdout, showOrigin)
+		},
+	}
+
+	cmd.Flags().BoolVar(&showOrigin, "origin", false, "Show which file provides each pattern")
+
+	return cmd
+}
@@ -0,0 +1,42 @@
+package cliprofiles
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/require"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+)
+
+type cliProfiles struct {
+	cfg csconfig.Getter
+}
+
+func New(cfg csconfig.Getter) *cliProfiles {
+	return &cliProfiles{
+		cfg: cfg,
+	}
+}
+
+func (cli *cliProfiles) NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profiles [command]",
+		Short: "Test the profiles of the local API",
+		Long: `Test the profiles of the local API.
+
+The profiles decide which decisions and notifications are produced for the alerts
+received by the local API.`,
+		DisableAutoGenTag: true,
+		Args:              args.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cmd.Usage()
+		},
+		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+			return require.LAPI(cli.cfg())
+		},
+	}
+
+	cmd.AddCommand(cli.newTestCmd())
+
+	return cmd
+}
@@ -0,0 +1,381 @@
+package cliprofiles
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/strfmt"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
+	"github.com/crowdsecurity/crowdsec/pkg/apiclient"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/csprofiles"
+	"github.com/crowdsecurity/crowdsec/pkg/cticlient/ctiexpr"
+	"github.com/crowdsecurity/crowdsec/pkg/database"
+	"github.com/crowdsecurity/crowdsec/pkg/emoji"
+	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+)
+
+// alertResult is the outcome of the profiles for one alert.
+type alertResult struct {
+	Alert    *models.Alert              `json:"alert"`
+	Profiles []csprofiles.ProfileResult `json:"profiles"`
+}
+
+// readAlerts decodes a JSON alert, as shown by "cscli alerts inspect -o json", or a list of alerts.
+func readAlerts(r io.Reader) ([]*models.Alert, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	data = bytes.TrimSpace(data)
+
+	if bytes.HasPrefix(data, []byte("[")) {
+		alerts := []*models.Alert{}
+		if err := json.Unmarshal(data, &alerts); err != nil {
+			return nil, fmt.Errorf("can't parse the alerts: %w", err)
+		}
+
+		return alerts, nil
+	}
+
+	alert := models.Alert{}
+	if err := json.Unmarshal(data, &alert); err != nil {
+		return nil, fmt.Errorf("can't parse the alert: %w", err)
+	}
+
+	return []*models.Alert{&alert}, nil
+}
+
+func readAlertFile(path string) ([]*models.Alert, error) {
+	if path == "-" {
+		return readAlerts(os.Stdin)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	alerts, err := readAlerts(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return alerts, nil
+}
+
+func (cli *cliProfiles) fetchAlerts(ctx context.Context, ids []int) ([]*models.Alert, error) {
+	cfg := cli.cfg()
+
+	if err := cfg.LoadAPIClient(); err != nil {
+		return nil, fmt.Errorf("loading api client: %w", err)
+	}
+
+	apiURL, err := url.Parse(cfg.API.Client.Credentials.URL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing the URL of the API: %w", err)
+	}
+
+	client := apiclient.NewClient(&apiclient.Config{
+		MachineID:     cfg.API.Client.Credentials.Login,
+		Password:      strfmt.Password(cfg.API.Client.Credentials.Password),
+		URL:           apiURL,
+		VersionPrefix: "v1",
+	})
+
+	alerts := make([]*models.Alert, 0, len(ids))
+
+	for _, id := range ids {
+		alert, _, err := client.Alerts.GetByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("can't find alert with id %d: %w", id, err)
+		}
+
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
+
+// loadProfiles returns the profiles of the local API, or the ones of another file to test it before deployment.
+func (cli *cliProfiles) loadProfiles(path string) ([]*csprofiles.Runtime, error) {
+	cfg := cli.cfg()
+
+	profilesCfg := cfg.API.Server.Profiles
+
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		profilesCfg, err = csconfig.DecodeProfiles(f, cfg.API.Server.ProfilesDryRun)
+		if err != nil {
+			return nil, fmt.Errorf("while decoding %s: %w", path, err)
+		}
+	}
+
+	if len(profilesCfg) == 0 {
+		return nil, errors.New("no profiles to test")
+	}
+
+	profiles, err := csprofiles.NewProfile(profilesCfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot extract profiles from configuration: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// initExprHelpers makes the helpers depending on the database or on the CTI available to the filters, when configured.
+func (cli *cliProfiles) initExprHelpers(ctx context.Context) {
+	cfg := cli.cfg()
+
+	var dbClient *database.Client
+
+	if cfg.API.Server.DbConfig != nil {
+		var err error
+
+		dbCfg := cfg.API.Server.DbConfig
+
+		dbClient, err = database.NewClient(ctx, dbCfg, dbCfg.NewLogger())
+		if err != nil {
+			log.Warningf("failed to get database client, the helpers using the database will not be available: %s", err)
+		}
+	}
+
+	if err := exprhelpers.Init(dbClient); err != nil {
+		log.Errorf("failed to init expr helpers: %s", err)
+	}
+
+	if cfg.API.CTI != nil && cfg.API.CTI.Enabled != nil && *cfg.API.CTI.Enabled {
+		if err := ctiexpr.InitCrowdsecCTI(cfg.API.CTI.Key, cfg.API.CTI.CacheTimeout, cfg.API.CTI.CacheSize, cfg.API.CTI.LogLevel); err != nil {
+			log.Errorf("failed to init crowdsec cti: %s", err)
+		}
+	}
+}
+
+func alertTitle(alert *models.Alert) string {
+	title := alert.GetScenario()
+	if title == "" {
+		title = "(no scenario)"
+	}
+
+	if alert.Source != nil {
+		title += fmt.Sprintf(" (%s:%s)", alert.GetScope(), alert.GetValue())
+	}
+
+	return title
+}
+
+func formatDecision(d *models.Decision) string {
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+
+		return *s
+	}
+
+	ret := fmt.Sprintf("%s %s:%s for %s", deref(d.Type), deref(d.Scope), deref(d.Value), deref(d.Duration))
+
+	if d.Simulated != nil && *d.Simulated {
+		ret += " (simulated)"
+	}
+
+	return ret
+}
+
+func printHuman(out io.Writer, results []alertResult) {
+	for idx, res := range results {
+		if idx > 0 {
+			fmt.Fprintln(out)
+		}
+
+		title := alertTitle(res.Alert)
+		if res.Alert.ID != 0 {
+			title = fmt.Sprintf("#%d %s", res.Alert.ID, title)
+		}
+
+		fmt.Fprintf(out, "Alert %d/%d: %s\n", idx+1, len(results), title)
+
+		for _, p := range res.Profiles {
+			fmt.Fprintln(out)
+
+			status := emoji.CrossMark
+			if p.Matched {
+				status = emoji.CheckMark
+			}
+
+			fmt.Fprintf(out, "%s %s: %s\n", status, p.Name, p.Reason)
+
+			for _, f := range p.Filters {
+				mark := emoji.CrossMark
+
+				switch {
+				case f.Error != "":
+					mark = emoji.Warning
+				case f.Matched:
+					mark = emoji.CheckMark
+				}
+
+				fmt.Fprintf(out, "    %s %s\n", mark, f.Filter)
+
+				if f.Error != "" {
+					fmt.Fprintf(out, "      %s\n", f.Error)
+				}
+			}
+
+			for _, d := range p.Decisions {
+				fmt.Fprintf(out, "    decision: %s\n", formatDecision(d))
+			}
+
+			if len(p.Notifications) > 0 {
+				fmt.Fprintf(out, "    notifications: %s\n", strings.Join(p.Notifications, ", "))
+			}
+		}
+	}
+}
+
+func printRaw(out io.Writer, results []alertResult) error {
+	csvwriter := csv.NewWriter(out)
+
+	if err := csvwriter.Write([]string{"alert", "profile", "evaluated", "matched", "decisions", "notifications", "reason"}); err != nil {
+		return fmt.Errorf("failed to write raw header: %w", err)
+	}
+
+	for idx, res := range results {
+		for _, p := range res.Profiles {
+			decisions := make([]string, 0, len(p.Decisions))
+			for _, d := range p.Decisions {
+				decisions = append(decisions, formatDecision(d))
+			}
+
+			row := []string{
+				strconv.Itoa(idx + 1),
+				p.Name,
+				strconv.FormatBool(p.Evaluated),
+				strconv.FormatBool(p.Matched),
+				strings.Join(decisions, ";"),
+				strings.Join(p.Notifications, ";"),
+				p.Reason,
+			}
+
+			if err := csvwriter.Write(row); err != nil {
+				return fmt.Errorf("failed to write raw: %w", err)
+			}
+		}
+	}
+
+	csvwriter.Flush()
+
+	return csvwriter.Error()
+}
+
+func (cli *cliProfiles) test(ctx context.Context, out io.Writer, alertFiles []string, alertIDs []int, profilesPath string) error {
+	cfg := cli.cfg()
+
+	alerts := []*models.Alert{}
+
+	for _, path := range alertFiles {
+		fileAlerts, err := readAlertFile(path)
+		if err != nil {
+			return err
+		}
+
+		alerts = append(alerts, fileAlerts...)
+	}
+
+	if len(alertIDs) > 0 {
+		stored, err := cli.fetchAlerts(ctx, alertIDs)
+		if err != nil {
+			return err
+		}
+
+		alerts = append(alerts, stored...)
+	}
+
+	if len(alerts) == 0 {
+		return errors.New("no alert to test: use --alert-file or --alert-id")
+	}
+
+	cli.initExprHelpers(ctx)
+
+	profiles, err := cli.loadProfiles(profilesPath)
+	if err != nil {
+		return err
+	}
+
+	results := make([]alertResult, 0, len(alerts))
+
+	for _, alert := range alerts {
+		results = append(results, alertResult{Alert: alert, Profiles: csprofiles.Explain(profiles, alert)})
+	}
+
+	switch cfg.Cscli.Output {
+	case "human":
+		printHuman(out, results)
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(results); err != nil {
+			return fmt.Errorf("failed to serialize: %w", err)
+		}
+	case "raw":
+		return printRaw(out, results)
+	}
+
+	return nil
+}
+
+func (cli *cliProfiles) newTestCmd() *cobra.Command {
+	var (
+		alertFiles   []string
+		alertIDs     []int
+		profilesPath string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Run alerts through the profiles",
+		Long: `Run alerts through the profiles, the way the local API does when it receives them, and show
+which profiles match, the decisions and notifications they would produce, and why the other
+profiles don't match. Nothing is written to the database and no notification is sent.
+
+The alerts are read from JSON files (an alert or a list of alerts, as shown by
+"cscli alerts inspect -o json") or fetched from the local API by id.`,
+		Example: `cscli profiles test --alert-file alert.json
+cscli alerts inspect 42 -o json | cscli profiles test --alert-file -
+cscli profiles test --alert-id 42 --alert-id 43
+cscli profiles test --alert-file alerts.json --profiles /tmp/profiles.yaml`,
+		Args:              args.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cli.test(cmd.Context(), os.Stdout, alertFiles, alertIDs, profilesPath)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&alertFiles, "alert-file", nil, "JSON file with an alert or a list of alerts (- for stdin)")
+	flags.IntSliceVar(&alertIDs, "alert-id", nil, "ID of an alert stored in the local API")
+	flags.StringVar(&profilesPath, "profiles", "", "profiles file to test instead of the ones of the local API")
+
+	return cmd
+}
@@ -0,0 +1,112 @@
+package cliprofiles
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+)
+
+const testProfiles = `name: default_ip_remediation
+filters:
+ - Alert.Remediation == true && Alert.GetScope() == "Ip"
+decisions:
+ - type: ban
+   duration: 4h
+notifications:
+ - slack_default
+on_success: break
+---
+name: default_range_remediation
+filters:
+ - Alert.Remediation == true && Alert.GetScope() == "Range"
+decisions:
+ - type: ban
+   duration: 4h
+on_success: break
+`
+
+func TestReadAlerts(t *testing.T) {
+	alerts, err := readAlerts(strings.NewReader(`{"scenario": "crowdsecurity/ssh-bf", "remediation": true}`))
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "crowdsecurity/ssh-bf", alerts[0].GetScenario())
+
+	alerts, err = readAlerts(strings.NewReader(` [{"scenario": "a"}, {"scenario": "b"}]`))
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, "b", alerts[1].GetScenario())
+
+	_, err = readAlerts(strings.NewReader(`scenario: a`))
+	cstest.RequireErrorContains(t, err, "can't parse the alert")
+}
+
+func TestProfilesTest(t *testing.T) {
+	dir := t.TempDir()
+
+	profilesPath := filepath.Join(dir, "profiles.yaml")
+	require.NoError(t, os.WriteFile(profilesPath, []byte(testProfiles), 0o644))
+
+	alertPath := filepath.Join(dir, "alerts.json")
+	require.NoError(t, os.WriteFile(alertPath, []byte(`[
+		{"scenario": "crowdsecurity/ssh-bf", "remediation": true, "source": {"scope": "Ip", "value": "192.0.2.10"}},
+		{"scenario": "crowdsecurity/http-probing", "remediation": true, "source": {"scope": "Country", "value": "FR"}}
+	]`), 0o644))
+
+	cfg := &csconfig.Config{
+		Cscli: &csconfig.CscliCfg{Output: "json"},
+		API:   &csconfig.APICfg{Server: &csconfig.LocalApiServerCfg{}},
+	}
+
+	cli := New(func() *csconfig.Config { return cfg })
+
+	err := cli.test(t.Context(), &bytes.Buffer{}, []string{alertPath}, nil, "")
+	cstest.RequireErrorContains(t, err, "no profiles to test")
+
+	out := &bytes.Buffer{}
+	err = cli.test(t.Context(), out, []string{alertPath}, nil, profilesPath)
+	require.NoError(t, err)
+
+	results := []alertResult{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &results))
+	require.Len(t, results, 2)
+
+	ip := results[0].Profiles
+	require.Len(t, ip, 2)
+	assert.True(t, ip[0].Matched)
+	assert.Equal(t, []string{"slack_default"}, ip[0].Notifications)
+	require.Len(t, ip[0].Decisions, 1)
+	assert.Equal(t, "192.0.2.10", *ip[0].Decisions[0].Value)
+	assert.False(t, ip[1].Evaluated)
+
+	country := results[1].Profiles
+	assert.False(t, country[0].Matched)
+	assert.Equal(t, "no filter matched", country[0].Reason)
+	assert.False(t, country[1].Matched)
+
+	cfg.Cscli.Output = "human"
+	out.Reset()
+	err = cli.test(t.Context(), out, []string{alertPath}, nil, profilesPath)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Alert 1/2: crowdsecurity/ssh-bf (Ip:192.0.2.10)")
+	assert.Contains(t, out.String(), "decision: ban Ip:192.0.2.10 for 4h")
+	assert.Contains(t, out.String(), "default_range_remediation: not evaluated, profile default_ip_remediation matched with on_success: break")
+
+	cfg.Cscli.Output = "raw"
+	out.Reset()
+	err = cli.test(t.Context(), out, []string{alertPath}, nil, profilesPath)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "1,default_ip_remediation,true,true,ban Ip:192.0.2.10 for 4h,slack_default,matched\n")
+
+	err = cli.test(t.Context(), out, nil, nil, profilesPath)
+	cstest.RequireErrorContains(t, err, "no alert to test: use --alert-file or --alert-id")
+}
@@ -228,7 +228,7 @@ func (cli *cliSupport) dumpHubItems(zw *zip.Writer, hub *cwhub.Hub) error {
 	out := new(bytes.Buffer)
 	ch := clihub.New(cli.cfg)
 
-	if err := ch.List(out, hub, false); err != nil {
+	if err := ch.List(out, hub, false, nil); err != nil {
 		return err
 	}
 
@@ -0,0 +1,351 @@
+package clitrace
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/cstable"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+var (
+	ErrMissingConfig   = errors.New("prometheus section missing, can't read the traces")
+	ErrMetricsDisabled = errors.New("prometheus is not enabled, can't read the traces")
+)
+
+const tracesPath = "/debug/traces"
+
+// maximum length of a line in the list of traces
+const maxLineLength = 80
+
+type cliTrace struct {
+	cfg csconfig.Getter
+}
+
+func New(cfg csconfig.Getter) *cliTrace {
+	return &cliTrace{
+		cfg: cfg,
+	}
+}
+
+func (cli *cliTrace) NewCommand() *cobra.Command {
+	var metricsURL string
+
+	cmd := &cobra.Command{
+		Use:   "trace [trace ID]",
+		Short: "Show how the log processor handled a line",
+		Long: `Show the decisions taken by the log processor on a line: the parser nodes that were
+tried, the result of their filters and grok patterns, the whitelists and the buckets
+the event was poured in. The trace starts when the datasource reads the line, so the
+lines dropped by a transform expression or the structured decoder are traced too.
+
+Tracing must be enabled in the crowdsec_service.trace section of the configuration.
+The last traces are kept in memory, and read from the prometheus endpoint of crowdsec,
+which must be enabled. Use "cscli trace list" to find the ID of a trace.`,
+		Example: `cscli trace list --contains 192.168.1.1
+cscli trace 5a4d7f09c3b1e2a8
+cscli trace 5a4d7f09c3b1e2a8 -o json`,
+		DisableAutoGenTag: true,
+		Args:              args.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return cmd.Usage()
+			}
+
+			return cli.show(cmd.Context(), color.Output, metricsURL, args[0])
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&metricsURL, "url", "u", "", "Metrics url (http://<ip>:<port>/metrics)")
+
+	cmd.AddCommand(cli.newListCmd(&metricsURL))
+
+	return cmd
+}
+
+// tracesURL returns the tracing endpoint, on the same host as the metrics.
+func tracesURL(metricsURL string, id string, filter pipeline.TraceFilter) (string, error) {
+	u, err := url.Parse(metricsURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid prometheus url %q: %w", metricsURL, err)
+	}
+
+	u.Path = tracesPath
+
+	if id != "" {
+		u.Path += "/" + url.PathEscape(id)
+	}
+
+	q := url.Values{}
+
+	if filter.Type != "" {
+		q.Set("type", filter.Type)
+	}
+
+	if filter.Outcome != "" {
+		q.Set("outcome", filter.Outcome)
+	}
+
+	if filter.Contains != "" {
+		q.Set("contains", filter.Contains)
+	}
+
+	if filter.Limit > 0 {
+		q.Set("limit", strconv.Itoa(filter.Limit))
+	}
+
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+func fetch(ctx context.Context, u string, out any) error {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching traces: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("fetching traces: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("parsing traces: %w", err)
+	}
+
+	return nil
+}
+
+func (cli *cliTrace) resolveMetricsURL(metricsURL string) (string, error) {
+	if metricsURL != "" {
+		return metricsURL, nil
+	}
+
+	cfg := cli.cfg()
+
+	if cfg.Prometheus == nil {
+		return "", ErrMissingConfig
+	}
+
+	if !cfg.Prometheus.Enabled {
+		return "", ErrMetricsDisabled
+	}
+
+	return cfg.Cscli.PrometheusUrl, nil
+}
+
+func truncate(s string, n int) string {
+	s = strings.TrimSpace(s)
+
+	if len(s) <= n {
+		return s
+	}
+
+	return s[:n-3] + "..."
+}
+
+func (cli *cliTrace) showHuman(out io.Writer, evtTrace pipeline.Trace) {
+	fmt.Fprintf(out, "Trace:   %s\n", evtTrace.ID)
+	fmt.Fprintf(out, "Time:    %s\n", evtTrace.Time.Format(time.RFC3339Nano))
+	fmt.Fprintf(out, "Source:  %s (%s)\n", evtTrace.Source, evtTrace.Type)
+	fmt.Fprintf(out, "Line:    %s\n", strings.TrimSpace(evtTrace.Line))
+	fmt.Fprintf(out, "Outcome: %s\n", evtTrace.Outcome)
+
+	if dropped := evtTrace.StepCount - len(evtTrace.Steps); dropped > 0 {
+		fmt.Fprintf(out, "%d steps were not kept, see max_steps in the trace configuration\n", dropped)
+	}
+
+	fmt.Fprintln(out)
+
+	t := cstable.NewLight(out, cli.cfg().Cscli.Color).Writer
+	t.AppendHeader(table.Row{"Elapsed", "Stage", "Kind", "Name", "Result", "Detail"})
+
+	for _, step := range evtTrace.Steps {
+		t.AppendRow(table.Row{
+			step.Time.Sub(evtTrace.Time).String(),
+			step.Stage,
+			step.Kind,
+			step.Name,
+			step.Result,
+			truncate(step.Detail, maxLineLength),
+		})
+	}
+
+	fmt.Fprintln(out, t.Render())
+}
+
+func showCSV(out io.Writer, evtTrace pipeline.Trace) error {
+	csvwriter := csv.NewWriter(out)
+
+	if err := csvwriter.Write([]string{"time", "stage", "kind", "name", "result", "detail"}); err != nil {
+		return fmt.Errorf("failed to write raw header: %w", err)
+	}
+
+	for _, step := range evtTrace.Steps {
+		row := []string{step.Time.Format(time.RFC3339Nano), step.Stage, step.Kind, step.Name, step.Result, step.Detail}
+
+		if err := csvwriter.Write(row); err != nil {
+			return fmt.Errorf("failed to write raw: %w", err)
+		}
+	}
+
+	csvwriter.Flush()
+
+	return nil
+}
+
+func (cli *cliTrace) show(ctx context.Context, out io.Writer, metricsURL string, id string) error {
+	metricsURL, err := cli.resolveMetricsURL(metricsURL)
+	if err != nil {
+		return err
+	}
+
+	u, err := tracesURL(metricsURL, id, pipeline.TraceFilter{})
+	if err != nil {
+		return err
+	}
+
+	var evtTrace pipeline.Trace
+
+	if err := fetch(ctx, u, &evtTrace); err != nil {
+		return err
+	}
+
+	switch cli.cfg().Cscli.Output {
+	case "human":
+		cli.showHuman(out, evtTrace)
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(evtTrace); err != nil {
+			return fmt.Errorf("failed to serialize: %w", err)
+		}
+	case "raw":
+		return showCSV(out, evtTrace)
+	}
+
+	return nil
+}
+
+func (cli *cliTrace) listHuman(out io.Writer, traces []pipeline.Trace) {
+	t := cstable.NewLight(out, cli.cfg().Cscli.Color).Writer
+	t.AppendHeader(table.Row{"ID", "Time", "Type", "Source", "Outcome", "Steps", "Line"})
+
+	for _, tr := range traces {
+		t.AppendRow(table.Row{tr.ID, tr.Time.Format(time.RFC3339), tr.Type, tr.Source, tr.Outcome, tr.StepCount, truncate(tr.Line, maxLineLength)})
+	}
+
+	fmt.Fprintln(out, t.Render())
+}
+
+func listCSV(out io.Writer, traces []pipeline.Trace) error {
+	csvwriter := csv.NewWriter(out)
+
+	if err := csvwriter.Write([]string{"id", "time", "type", "source", "module", "outcome", "steps", "line"}); err != nil {
+		return fmt.Errorf("failed to write raw header: %w", err)
+	}
+
+	for _, tr := range traces {
+		row := []string{tr.ID, tr.Time.Format(time.RFC3339Nano), tr.Type, tr.Source, tr.Module, tr.Outcome, strconv.Itoa(tr.StepCount), tr.Line}
+
+		if err := csvwriter.Write(row); err != nil {
+			return fmt.Errorf("failed to write raw: %w", err)
+		}
+	}
+
+	csvwriter.Flush()
+
+	return nil
+}
+
+func (cli *cliTrace) list(ctx context.Context, out io.Writer, metricsURL string, filter pipeline.TraceFilter) error {
+	metricsURL, err := cli.resolveMetricsURL(metricsURL)
+	if err != nil {
+		return err
+	}
+
+	u, err := tracesURL(metricsURL, "", filter)
+	if err != nil {
+		return err
+	}
+
+	var traces []pipeline.Trace
+
+	if err := fetch(ctx, u, &traces); err != nil {
+		return err
+	}
+
+	switch cli.cfg().Cscli.Output {
+	case "human":
+		if len(traces) == 0 {
+			fmt.Fprintln(out, "No trace.")
+			return nil
+		}
+
+		cli.listHuman(out, traces)
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(traces); err != nil {
+			return fmt.Errorf("failed to serialize: %w", err)
+		}
+	case "raw":
+		return listCSV(out, traces)
+	}
+
+	return nil
+}
+
+func (cli *cliTrace) newListCmd(metricsURL *string) *cobra.Command {
+	filter := pipeline.TraceFilter{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the last traced lines, the most recent first",
+		Example: `cscli trace list
+# the lines that were not parsed
+cscli trace list --outcome unparsed
+cscli trace list --type journalctl --contains sshd --limit 10`,
+		Args:              args.NoArgs,
+		DisableAutoGenTag: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return cli.list(cmd.Context(), color.Output, *metricsURL, filter)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&filter.Type, "type", "", "only show the lines of a datasource type (ie. file, journalctl)")
+	flags.StringVar(&filter.Outcome, "outcome", "", "only show the lines with this outcome (dropped, unparsed, whitelisted, parsed, poured, not poured)")
+	flags.StringVar(&filter.Contains, "contains", "", "only show the lines that contain this text")
+	flags.IntVarP(&filter.Limit, "limit", "l", 50, "maximum number of traces to show (0 for no limit)")
+
+	return cmd
+}
@@ -18,12 +18,15 @@ import (
 	"github.com/crowdsecurity/go-cs-lib/trace"
 
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clialert"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clialias"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/cliallowlists"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clibouncer"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clibuckets"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clicapi"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/cliconfig"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/cliconsole"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clidecision"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clidetectconfig"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/cliexplain"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clihub"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clihubtest"
@@ -33,8 +36,11 @@ import (
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/climetrics"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clinotifications"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clipapi"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clipatterns"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/cliprofiles"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clisimulation"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clisupport"
+	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/clitrace"
 	"github.com/crowdsecurity/crowdsec/cmd/crowdsec-cli/core/args"
 	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/fflag"
@@ -78,6 +84,8 @@ type cliRoot struct {
 	outputFormat string
 	// flagBranch overrides the value in csConfig.Cscli.HubBranch
 	flagBranch string
+	// args is the command line, after alias expansion
+	args []string
 }
 
 func newCliRoot() *cliRoot {
@@ -94,6 +102,7 @@ func (*cliRoot) cfg() *csconfig.Config {
 // If the sub-command does not need it, it returns a default configuration.
 func loadConfigFor(command string) (*csconfig.Config, string, error) {
 	noNeedConfig := []string{
+		"alias",
 		"doc",
 		"help",
 		"completion",
@@ -132,7 +141,7 @@ func (cli *cliRoot) initialize() error {
 		DisableLevelTruncation: true,
 	})
 
-	csConfig, mergedConfig, err = loadConfigFor(os.Args[1])
+	csConfig, mergedConfig, err = loadConfigFor(cli.args[0])
 	if err != nil {
 		return err
 	}
@@ -218,10 +227,10 @@ func (cli *cliRoot) NewCommand() (*cobra.Command, error) {
 
 	// list of valid subcommands for the shell completion
 	validArgs := []string{
-		"alerts", "appsec-configs", "waf-configs", "appsec-rules", "waf-rules", "bouncers", "capi", "collections",
+		"alerts", "alias", "appsec-configs", "waf-configs", "appsec-rules", "waf-rules", "bouncers", "buckets", "capi", "collections",
 		"completion", "config", "console", "contexts", "dashboard", "decisions", "explain",
 		"hub", "hubtest", "lapi", "machines", "metrics", "notifications", "parsers",
-		"postoverflows", "scenarios", "simulation", "support", "version",
+		"patterns", "postoverflows", "scenarios", "simulation", "support", "version",
 	}
 
 	cmd := &cobra.Command{
@@ -286,6 +295,8 @@ It is meant to allow you to manage bans, parsers/scenarios/etc, api and generall
 	cmd.AddCommand(clialert.New(cli.cfg).NewCommand())
 	cmd.AddCommand(clisimulation.New(cli.cfg).NewCommand())
 	cmd.AddCommand(clibouncer.New(cli.cfg).NewCommand())
+	cmd.AddCommand(clibuckets.New(cli.cfg).NewCommand())
+	cmd.AddCommand(clitrace.New(cli.cfg).NewCommand())
 	cmd.AddCommand(climachine.New(cli.cfg).NewCommand())
 	cmd.AddCommand(clicapi.New(cli.cfg).NewCommand())
 	cmd.AddCommand(clilapi.New(cli.cfg).NewCommand())
@@ -294,20 +305,38 @@ It is meant to allow you to manage bans, parsers/scenarios/etc, api and generall
 	cmd.AddCommand(cliexplain.New(ConfigFilePath).NewCommand())
 	cmd.AddCommand(clihubtest.New(cli.cfg).NewCommand())
 	cmd.AddCommand(clinotifications.New(cli.cfg).NewCommand())
+	cmd.AddCommand(cliprofiles.New(cli.cfg).NewCommand())
 	cmd.AddCommand(clisupport.New(cli.cfg).NewCommand())
 	cmd.AddCommand(clipapi.New(cli.cfg).NewCommand())
 	cmd.AddCommand(cliitem.NewCollection(cli.cfg).NewCommand())
 	cmd.AddCommand(cliitem.NewParser(cli.cfg).NewCommand())
+	cmd.AddCommand(clipatterns.New(cli.cfg).NewCommand())
 	cmd.AddCommand(cliitem.NewScenario(cli.cfg).NewCommand())
 	cmd.AddCommand(cliitem.NewPostOverflow(cli.cfg).NewCommand())
 	cmd.AddCommand(cliitem.NewContext(cli.cfg).NewCommand())
 	cmd.AddCommand(cliitem.NewAppsecConfig(cli.cfg).NewCommand())
 	cmd.AddCommand(cliitem.NewAppsecRule(cli.cfg).NewCommand())
 	cmd.AddCommand(cliallowlists.New(cli.cfg).NewCommand())
+	cmd.AddCommand(clidetectconfig.New(cli.cfg).NewCommand())
+	cmd.AddCommand(clialias.New(cli.cfg, csconfig.GetAliasesFilePath(ConfigFilePath)).NewCommand())
 
 	cli.addSetup(cmd)
 
-	if len(os.Args) > 1 {
+	aliases, err := csconfig.LoadAliases(csconfig.GetAliasesFilePath(ConfigFilePath))
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.ValidArgs = append(cmd.ValidArgs, clialias.Completions(aliases)...)
+
+	cli.args, err = clialias.Expand(cmd, os.Args[1:], aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd.SetArgs(cli.args)
+
+	if len(cli.args) > 0 {
 		cobra.OnInitialize(
 			func() {
 				if err := cli.initialize(); err != nil {
@@ -329,7 +358,9 @@ func mainWrap() error {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	cmd, err := newCliRoot().NewCommand()
+	cli := newCliRoot()
+
+	cmd, err := cli.NewCommand()
 	if err != nil {
 		return err
 	}
@@ -338,7 +369,7 @@ func mainWrap() error {
 	if err != nil {
 		cmdName := cmd.Name()
 
-		subCmd, _, subErr := cmd.Find(os.Args[1:])
+		subCmd, _, subErr := cmd.Find(cli.args)
 		if subErr == nil {
 			cmdName = subCmd.CommandPath()
 		}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/leakybucket"
+)
+
+// bucketsStateConfig returns the configuration of the bucket persistence, or nil if it's
+// disabled. The buckets of a replay (-dsn) are never persisted.
+func bucketsStateConfig(cConfig *csconfig.Config) *csconfig.BucketsStateCfg {
+	cfg := cConfig.Crowdsec.BucketsState
+
+	if cfg == nil || !*cfg.Enabled || flags.haveTimeMachine() {
+		return nil
+	}
+
+	return cfg
+}
+
+// restoreBuckets starts again the buckets saved by the previous run, before any event is poured.
+func restoreBuckets(ctx context.Context, cfg *csconfig.BucketsStateCfg, bucketStore *leakybucket.BucketStore) {
+	restored, dropped, err := leakybucket.RestoreBucketsState(ctx, cfg.Path, holders, bucketStore, time.Now().UTC())
+	if err != nil {
+		log.Warningf("unable to restore the buckets: %s", err)
+		return
+	}
+
+	if restored+dropped > 0 {
+		log.Infof("Restored %d buckets from %s (%d expired or obsolete)", restored, cfg.Path, dropped)
+	}
+}
+
+func saveBuckets(cfg *csconfig.BucketsStateCfg, bucketStore *leakybucket.BucketStore) {
+	count, err := leakybucket.DumpBucketsState(cfg.Path, bucketStore)
+	if err != nil {
+		log.Errorf("unable to save the buckets: %s", err)
+		return
+	}
+
+	log.Debugf("Saved %d buckets to %s", count, cfg.Path)
+}
+
+// runBucketsStateDump saves the buckets periodically, so they survive a crash. The last dump
+// happens during the shutdown, before the buckets are killed.
+func runBucketsStateDump(ctx context.Context, cfg *csconfig.BucketsStateCfg, bucketStore *leakybucket.BucketStore) {
+	if *cfg.DumpInterval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(*cfg.DumpInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			saveBuckets(cfg, bucketStore)
+		}
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/cwhub"
 	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+	"github.com/crowdsecurity/crowdsec/pkg/geoipupdate"
 	"github.com/crowdsecurity/crowdsec/pkg/leakybucket"
 	"github.com/crowdsecurity/crowdsec/pkg/metrics"
 	"github.com/crowdsecurity/crowdsec/pkg/parser"
@@ -60,6 +61,12 @@ func initCrowdsec(ctx context.Context, cConfig *csconfig.Config, hub *cwhub.Hub,
 		if err != nil {
 			return nil, nil, fmt.Errorf("while initializing LAPIClient: %w", err)
 		}
+
+		if cConfig.Crowdsec.ManagedAcquisitionDir != "" {
+			if err = syncManagedAcquisition(ctx, cConfig); err != nil {
+				log.Errorf("unable to update the managed acquisition: %s", err)
+			}
+		}
 	}
 
 	datasources, err := LoadAcquisition(ctx, cConfig, hub)
@@ -75,7 +82,7 @@ func startParserRoutines(ctx context.Context, g *errgroup.Group, cConfig *csconf
 		log.WithField("idx", idx).Info("Starting parser routine")
 		g.Go(func() error {
 			defer trace.ReportPanic()
-			runParse(ctx, logLines, inEvents, *parsers.Ctx, parsers.Nodes, stageCollector)
+			runParse(ctx, logLines, inEvents, *parsers.Ctx, parsers.Nodes, stageCollector, parsers.Unparsed, parsers.ErrorBudget)
 			return nil
 		})
 	}
@@ -152,6 +159,26 @@ func runCrowdsec(
 	startParserRoutines(ctx, g, cConfig, parsers, sd.StageParse)
 	startBucketRoutines(ctx, g, cConfig, sd.Pour, bucketStore)
 
+	if stateCfg := bucketsStateConfig(cConfig); stateCfg != nil {
+		restoreBuckets(ctx, stateCfg, bucketStore)
+
+		g.Go(func() error {
+			defer trace.ReportPanic()
+			runBucketsStateDump(ctx, stateCfg, bucketStore)
+			return nil
+		})
+	}
+
+	if updateCfg := cConfig.Crowdsec.GeoIPUpdate; updateCfg != nil {
+		updater := geoipupdate.New(updateCfg, hub.GetDataDir(), log.WithField("service", "geoip_update"))
+
+		g.Go(func() error {
+			defer trace.ReportPanic()
+			updater.Run(ctx)
+			return nil
+		})
+	}
+
 	apiClient, err := apiclient.GetLAPIClient()
 	if err != nil {
 		return err
@@ -189,6 +216,14 @@ func serveCrowdsec(
 	var g errgroup.Group
 
 	bucketStore := leakybucket.NewBucketStore()
+	liveBuckets.Store(bucketStore)
+
+	if traceCfg := cConfig.Crowdsec.Trace; traceCfg != nil {
+		log.Infof("Tracing the events, keeping the last %d traces", *traceCfg.BufferSize)
+		pipeline.SetTracer(pipeline.NewTracer(*traceCfg.BufferSize, *traceCfg.MaxSteps, traceCfg.Sources, *traceCfg.SampleRate))
+	} else {
+		pipeline.SetTracer(nil)
+	}
 
 	crowdsecTomb.Go(func() error {
 		defer trace.ReportPanic()
@@ -212,10 +247,20 @@ func serveCrowdsec(
 		waitOnTomb()
 		log.Debugf("Shutting down crowdsec routines")
 
-		if err := ShutdownCrowdsecRoutines(cancel, &g, datasources); err != nil {
+		var saveState func()
+
+		if stateCfg := bucketsStateConfig(cConfig); stateCfg != nil {
+			saveState = func() { saveBuckets(stateCfg, bucketStore) }
+		}
+
+		if err := ShutdownCrowdsecRoutines(cancel, &g, datasources, saveState); err != nil {
 			return fmt.Errorf("unable to shutdown crowdsec routines: %w", err)
 		}
 
+		if err := parsers.Unparsed.Close(); err != nil {
+			log.Warningf("while closing the unparsed lines sink: %s", err)
+		}
+
 		log.Debugf("everything is dead, return crowdsecTomb")
 		log.Debugf("sd.DumpDir == %s", sd.DumpDir)
 
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/apiclient"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/managedconfig"
+)
+
+// syncManagedAcquisition fetches the acquisition pushed by the console to the machine, and writes it
+// in the managed directory before the acquisition is loaded. If the local API can't be reached, the
+// files of the previous synchronization are used.
+func syncManagedAcquisition(ctx context.Context, cConfig *csconfig.Config) error {
+	client, err := apiclient.GetLAPIClient()
+	if err != nil {
+		return err
+	}
+
+	ctxTime, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	snippets, resp, err := client.ManagedConfig.List(ctxTime)
+
+	switch {
+	case err != nil && resp != nil && resp.Response.StatusCode == http.StatusNotFound:
+		log.Warning("managed acquisition endpoint not found, older LAPI?")
+		return nil
+	case err != nil:
+		return fmt.Errorf("while fetching the managed acquisition: %w", err)
+	}
+
+	logger := log.WithField("dir", cConfig.Crowdsec.ManagedAcquisitionDir)
+
+	if err := managedconfig.Sync(cConfig.Crowdsec.ManagedAcquisitionDir, snippets, logger); err != nil {
+		return err
+	}
+
+	// with the managed directory, there is always an acquisition source
+	acquisitionFiles, err := cConfig.Crowdsec.CollectAcquisitionFiles()
+	if err != nil {
+		return err
+	}
+
+	cConfig.Crowdsec.AcquisitionFiles = acquisitionFiles
+
+	logger.Debugf("%d managed acquisition files", len(snippets))
+
+	return nil
+}
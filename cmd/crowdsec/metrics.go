@@ -1,9 +1,14 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"net"
 	"net/http"
+	"path"
 	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -15,9 +20,15 @@ import (
 	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/database"
 	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+	"github.com/crowdsecurity/crowdsec/pkg/leakybucket"
 	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
 )
 
+// liveBuckets holds the buckets of the running log processor, for the introspection
+// endpoint. It's replaced at each reload, and nil if the log processor is disabled.
+var liveBuckets atomic.Pointer[leakybucket.BucketStore]
+
 func computeDynamicMetrics(next http.Handler, dbClient *database.Client) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer trace.ReportPanic()
@@ -71,6 +82,131 @@ func computeDynamicMetrics(next http.Handler, dbClient *database.Client) http.Ha
 	})
 }
 
+func parseInspectFilter(r *http.Request) (leakybucket.InspectFilter, error) {
+	q := r.URL.Query()
+
+	filter := leakybucket.InspectFilter{
+		Scenario: q.Get("scenario"),
+		GroupBy:  q.Get("groupby"),
+	}
+
+	if _, err := path.Match(filter.Scenario, ""); err != nil {
+		return filter, fmt.Errorf("invalid scenario pattern %q: %w", filter.Scenario, err)
+	}
+
+	if v := q.Get("min_fill"); v != "" {
+		minFill, err := strconv.ParseFloat(v, 64)
+		if err != nil || minFill < 0 || minFill > 1 {
+			return filter, fmt.Errorf("invalid min_fill %q: must be between 0 and 1", v)
+		}
+
+		filter.MinFill = minFill
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return filter, fmt.Errorf("invalid limit %q", v)
+		}
+
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// serveBuckets returns the state of the live buckets, filtered by the query parameters
+// scenario, groupby, min_fill and limit.
+func serveBuckets(w http.ResponseWriter, r *http.Request) {
+	defer trace.ReportPanic()
+
+	bucketStore := liveBuckets.Load()
+	if bucketStore == nil {
+		http.Error(w, "the log processor is not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	filter, err := parseInspectFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(bucketStore.Inspect(time.Now().UTC(), filter)); err != nil {
+		log.WithError(err).Error("serving buckets")
+	}
+}
+
+func parseTraceFilter(r *http.Request) (pipeline.TraceFilter, error) {
+	q := r.URL.Query()
+
+	filter := pipeline.TraceFilter{
+		Type:     q.Get("type"),
+		Outcome:  q.Get("outcome"),
+		Contains: q.Get("contains"),
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return filter, fmt.Errorf("invalid limit %q", v)
+		}
+
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// serveTraces returns the traces of the last events, without their steps, filtered by the query
+// parameters type, outcome, contains and limit.
+func serveTraces(w http.ResponseWriter, r *http.Request) {
+	defer trace.ReportPanic()
+
+	tracer := pipeline.GetTracer()
+	if tracer == nil {
+		http.Error(w, "tracing is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	filter, err := parseTraceFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(tracer.List(filter)); err != nil {
+		log.WithError(err).Error("serving traces")
+	}
+}
+
+// serveTrace returns a trace with its steps.
+func serveTrace(w http.ResponseWriter, r *http.Request) {
+	defer trace.ReportPanic()
+
+	tracer := pipeline.GetTracer()
+	if tracer == nil {
+		http.Error(w, "tracing is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	evtTrace, ok := tracer.Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "trace not found, it may have been dropped from the buffer", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(evtTrace); err != nil {
+		log.WithError(err).Error("serving trace")
+	}
+}
+
 func registerPrometheus(config *csconfig.PrometheusCfg) {
 	if !config.Enabled {
 		return
@@ -90,6 +226,9 @@ func servePrometheus(config *csconfig.PrometheusCfg, dbClient *database.Client,
 	}
 
 	http.Handle("/metrics", computeDynamicMetrics(promhttp.Handler(), dbClient))
+	http.HandleFunc("/debug/buckets", serveBuckets)
+	http.HandleFunc("/debug/traces", serveTraces)
+	http.HandleFunc("/debug/traces/{id}", serveTrace)
 
 	if err := http.ListenAndServe(net.JoinHostPort(config.ListenAddr, strconv.Itoa(config.ListenPort)), nil); err != nil {
 		// in time machine, we most likely have the LAPI using the port
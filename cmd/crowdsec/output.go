@@ -152,7 +152,7 @@ func runOutput(
 
 			if ov.Reprocess {
 				select {
-				case input <- event:
+				case input <- leaky.ReinjectEvent(event):
 					log.Debug("Reprocessing overflow event")
 				case <-ctx.Done():
 					log.Debug("Reprocessing overflow event: parsing is dead, skipping")
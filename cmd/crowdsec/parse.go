@@ -17,6 +17,8 @@ func parseEvent(
 	parserCTX parser.UnixParserCtx,
 	nodes []parser.Node,
 	stageCollector *parser.StageParseCollector,
+	unparsed *parser.UnparsedSink,
+	budget *parser.ErrorBudget,
 ) *pipeline.Event {
 	if !event.Process {
 		return nil
@@ -32,6 +34,11 @@ func parseEvent(
 		log.Errorf("empty event.Line.Module field, the acquisition module must set it ! : %+v", event.Line)
 		return nil
 	}
+	if budget.Quarantined(event) {
+		pipeline.RecordTraceStep(&event, "error budget", "", "quarantined", "")
+		pipeline.SetTraceOutcome(&event, pipeline.TraceDropped)
+		return nil
+	}
 	metrics.GlobalParserHits.With(prometheus.Labels{"source": event.Line.Src, "type": event.Line.Module}).Inc()
 
 	startParsing := time.Now()
@@ -42,28 +49,38 @@ func parseEvent(
 	}
 	elapsed := time.Since(startParsing)
 	metrics.GlobalParsingHistogram.With(prometheus.Labels{"source": event.Line.Src, "type": event.Line.Module}).Observe(elapsed.Seconds())
+	if report := budget.Record(event, err != nil || !parsed.Process); report != nil {
+		outEvents <- report.Event()
+	}
 	if !parsed.Process {
 		metrics.GlobalParserHitsKo.With(prometheus.Labels{"source": event.Line.Src, "type": event.Line.Module, "acquis_type": event.Line.Labels["type"]}).Inc()
 		log.Debugf("Discarding line %+v", parsed)
+		pipeline.SetTraceOutcome(&parsed, pipeline.TraceUnparsed)
+		if err := unparsed.Write(parsed); err != nil {
+			log.Warning(err)
+		}
 		return nil
 	}
 	metrics.GlobalParserHitsOk.With(prometheus.Labels{"source": event.Line.Src, "type": event.Line.Module, "acquis_type": event.Line.Labels["type"]}).Inc()
 	if parsed.Whitelisted {
 		log.Debugf("event whitelisted, discard")
+		pipeline.SetTraceOutcome(&parsed, pipeline.TraceWhitelisted)
 		return nil
 	}
 
+	pipeline.SetTraceOutcome(&parsed, pipeline.TraceParsed)
+
 	return &parsed
 }
 
-func runParse(ctx context.Context, input chan pipeline.Event, output chan pipeline.Event, parserCTX parser.UnixParserCtx, nodes []parser.Node, stageCollector *parser.StageParseCollector) {
+func runParse(ctx context.Context, input chan pipeline.Event, output chan pipeline.Event, parserCTX parser.UnixParserCtx, nodes []parser.Node, stageCollector *parser.StageParseCollector, unparsed *parser.UnparsedSink, budget *parser.ErrorBudget) {
 	for {
 		select {
 		case <-ctx.Done():
 			log.Infof("Killing parser routines")
 			return
 		case event := <-input:
-			parsed := parseEvent(event, parserCTX, nodes, stageCollector)
+			parsed := parseEvent(event, parserCTX, nodes, stageCollector, unparsed, budget)
 			if parsed == nil {
 				continue
 			}
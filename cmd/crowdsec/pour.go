@@ -63,8 +63,10 @@ func runPour(ctx context.Context, input chan pipeline.Event, holders []leaky.Buc
 
 			if poured {
 				metrics.GlobalBucketPourOk.Inc()
+				pipeline.SetTraceOutcome(&parsed, pipeline.TracePoured)
 			} else {
 				metrics.GlobalBucketPourKo.Inc()
+				pipeline.SetTraceOutcome(&parsed, pipeline.TraceNotPoured)
 			}
 		}
 	}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"runtime/pprof"
+	"strings"
 	"syscall"
 	"time"
 
@@ -100,7 +101,9 @@ func waitErrGroup(g *errgroup.Group, timeout time.Duration) error {
 	}
 }
 
-func ShutdownCrowdsecRoutines(cancel context.CancelFunc, g *errgroup.Group, datasources []acquisitionTypes.DataSource) error {
+// ShutdownCrowdsecRoutines stops the log processor. saveState, if not nil, is called once the
+// pipeline is drained, before the buckets are killed.
+func ShutdownCrowdsecRoutines(cancel context.CancelFunc, g *errgroup.Group, datasources []acquisitionTypes.DataSource, saveState func()) error {
 	var reterr error
 
 	log.Debugf("Shutting down crowdsec sub-routines")
@@ -141,6 +144,10 @@ func ShutdownCrowdsecRoutines(cancel context.CancelFunc, g *errgroup.Group, data
 		log.Warningf("Outputs didn't finish in time, some events may have not been flushed")
 	}
 
+	if saveState != nil {
+		saveState()
+	}
+
 	cancel()
 
 	if err := waitErrGroup(g, 3*time.Second); err != nil {
@@ -344,6 +351,27 @@ func Serve(
 		}
 	}
 
+	var httpHelperCfg *csconfig.HTTPHelperCfg
+
+	if cConfig.Crowdsec != nil && cConfig.Crowdsec.HTTPHelper != nil {
+		httpHelperCfg = cConfig.Crowdsec.HTTPHelper
+		log.Infof("HttpGet helper enabled for %s", strings.Join(httpHelperCfg.AllowedHosts, ", "))
+	}
+
+	if err := exprhelpers.InitHttpGet(httpHelperCfg); err != nil {
+		return fmt.Errorf("failed to init http helper: %w", err)
+	}
+
+	var mlModelsCfg []*csconfig.MLModelCfg
+
+	if cConfig.Crowdsec != nil {
+		mlModelsCfg = cConfig.Crowdsec.MLModels
+	}
+
+	if err := exprhelpers.InitMachineLearningModels(mlModelsCfg); err != nil {
+		return fmt.Errorf("failed to init ml models: %w", err)
+	}
+
 	if !cConfig.DisableAPI {
 		if cConfig.API.Server.OnlineClient == nil || cConfig.API.Server.OnlineClient.Credentials == nil {
 			log.Warningf("Communication with CrowdSec Central API disabled from configuration file")
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"maps"
 	"os"
 	"slices"
 	"strconv"
@@ -14,7 +13,6 @@ import (
 	"time"
 
 	"github.com/cenkalti/backoff/v5"
-	"github.com/expr-lang/expr"
 	"github.com/expr-lang/expr/vm"
 	"github.com/goccy/go-yaml"
 	"github.com/google/uuid"
@@ -28,10 +26,10 @@ import (
 
 	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
 	"github.com/crowdsecurity/crowdsec/pkg/acquisition/registry"
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/structured"
 	"github.com/crowdsecurity/crowdsec/pkg/acquisition/types"
 	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/cwhub"
-	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
 	"github.com/crowdsecurity/crowdsec/pkg/logging"
 	"github.com/crowdsecurity/crowdsec/pkg/metrics"
 	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
@@ -50,7 +48,22 @@ func (e *DataSourceUnavailableError) Unwrap() error {
 	return e.Err
 }
 
-var transformRuntimes = map[string]*vm.Program{}
+// sourcesWithoutLabels can be configured without labels: docker takes them from the containers,
+// the log file presets have a default type.
+var sourcesWithoutLabels = []string{"docker", "suricata", "zeek"}
+
+// hasPreset returns true if the datasource uses a vendor preset (ie. syslog), which sets a default type.
+func hasPreset(yamlDoc []byte) bool {
+	var cfg struct {
+		Preset string `yaml:"preset"`
+	}
+
+	if err := yaml.Unmarshal(yamlDoc, &cfg); err != nil {
+		return false
+	}
+
+	return cfg.Preset != ""
+}
 
 // DataSourceConfigure creates and returns a DataSource object from a configuration,
 // if the configuration is not valid it returns an error.
@@ -124,13 +137,13 @@ func LoadAcquisitionFromDSN(
 	dataSrc := factory()
 	uniqueID := uuid.NewString()
 
+	var transformRuntime *vm.Program
+
 	if transformExpr != "" {
-		vm, err := expr.Compile(transformExpr, exprhelpers.GetExprOptions(map[string]any{"evt": &pipeline.Event{}})...)
+		transformRuntime, err = compileTransform(transformExpr)
 		if err != nil {
 			return nil, fmt.Errorf("while compiling transform expression '%s': %w", transformExpr, err)
 		}
-
-		transformRuntimes[uniqueID] = vm
 	}
 
 	if hubAware, ok := dataSrc.(types.HubAware); ok {
@@ -153,6 +166,10 @@ func LoadAcquisitionFromDSN(
 		return nil, fmt.Errorf("datasource for %q: %w", dsn, err)
 	}
 
+	if transformRuntime != nil {
+		transformRuntimes[dataSrc] = transformRuntime
+	}
+
 	return dataSrc, nil
 }
 
@@ -210,6 +227,7 @@ type ParsedSourceConfig struct {
 	Common           configuration.DataSourceCommonCfg
 	Source           types.DataSource
 	Transform        *vm.Program
+	Structured       *structured.Decoder
 	SourceMissing    bool   // the "source" field was missing, and detected
 	SourceOverridden string // the "source" field was not missing, but didn't match the detected one
 }
@@ -225,6 +243,7 @@ var ErrEmptyYAMLDocument = errors.New("empty yaml document")
 // - validate common fields
 // - delegate per-source config validation to the appropriate module
 // - compile transform expression
+// - load the decoder of the structured events
 func ParseSourceConfig(ctx context.Context, yamlDoc []byte, metricsLevel metrics.AcquisitionMetricsLevel, hub *cwhub.Hub) (*ParsedSourceConfig, error) {
 	detectedType, err := detectType(bytes.NewReader(yamlDoc))
 	if err != nil {
@@ -279,8 +298,7 @@ func ParseSourceConfig(ctx context.Context, yamlDoc []byte, metricsLevel metrics
 
 	// check for labels now, an error for missing labels has lower priority
 	// than missing or unknown source type
-	if len(sub.Labels) == 0 && sub.Source != "docker" {
-		// docker is the only source that does not require labels
+	if len(sub.Labels) == 0 && !slices.Contains(sourcesWithoutLabels, sub.Source) && !hasPreset(yamlDoc) {
 		return nil, errors.New("missing labels")
 	}
 
@@ -294,12 +312,17 @@ func ParseSourceConfig(ctx context.Context, yamlDoc []byte, metricsLevel metrics
 	parsed.Source = src
 
 	if sub.TransformExpr != "" {
-		vm, err := expr.Compile(sub.TransformExpr, exprhelpers.GetExprOptions(map[string]any{"evt": &pipeline.Event{}})...)
+		parsed.Transform, err = compileTransform(sub.TransformExpr)
 		if err != nil {
 			return nil, fmt.Errorf("while compiling transform expression '%s' for datasource %s: %w", sub.TransformExpr, sub.Source, err)
 		}
+	}
 
-		parsed.Transform = vm
+	if sub.Structured != nil {
+		parsed.Structured, err = structured.New(*sub.Structured)
+		if err != nil {
+			return nil, fmt.Errorf("structured events of datasource %s: %w", sub.Source, err)
+		}
 	}
 
 	return parsed, nil
@@ -380,7 +403,11 @@ func sourcesFromFile(
 		}
 
 		if parsed.Transform != nil {
-			transformRuntimes[parsed.Common.UniqueId] = parsed.Transform
+			transformRuntimes[parsed.Source] = parsed.Transform
+		}
+
+		if parsed.Structured != nil {
+			structuredDecoders[parsed.Source] = parsed.Structured
 		}
 
 		sources = append(sources, parsed.Source)
@@ -442,81 +469,6 @@ func GetMetrics(sources []types.DataSource, aggregated bool) error {
 	return nil
 }
 
-// There's no need for an actual deep copy
-// The event is almost empty, we are mostly interested in allocating new maps for Parsed/Meta/...
-func copyEvent(evt pipeline.Event, line string) pipeline.Event {
-	evtCopy := pipeline.MakeEvent(evt.ExpectMode == pipeline.TIMEMACHINE, evt.Type, evt.Process)
-	evtCopy.Line = evt.Line
-	evtCopy.Line.Raw = line
-	evtCopy.Line.Labels = make(map[string]string)
-
-	maps.Copy(evtCopy.Line.Labels, evt.Line.Labels)
-
-	return evtCopy
-}
-
-func transform(
-	transformChan chan pipeline.Event,
-	output chan pipeline.Event,
-	acquisTomb *tomb.Tomb,
-	transformRuntime *vm.Program,
-	logger *log.Entry,
-) {
-	logger.Info("transformer started")
-
-	for {
-		select {
-		case <-acquisTomb.Dying():
-			logger.Debugf("transformer is dying")
-			return
-		case evt := <-transformChan:
-			logger.Tracef("Received event %s", evt.Line.Raw)
-
-			out, err := expr.Run(transformRuntime, map[string]any{"evt": &evt})
-			if err != nil {
-				logger.Errorf("while running transform expression: %s, sending event as-is", err)
-				output <- evt
-				continue
-			}
-
-			if out == nil {
-				logger.Errorf("transform expression returned nil, sending event as-is")
-				output <- evt
-				continue
-			}
-
-			switch v := out.(type) {
-			case string:
-				logger.Tracef("transform expression returned %s", v)
-				output <- copyEvent(evt, v)
-			case []any:
-				logger.Tracef("transform expression returned %v", v) // We actually want to log the slice content
-
-				for _, line := range v {
-					l, ok := line.(string)
-					if !ok {
-						logger.Errorf("transform expression returned []interface{}, but cannot assert an element to string")
-						output <- evt
-
-						continue
-					}
-
-					output <- copyEvent(evt, l)
-				}
-			case []string:
-				logger.Tracef("transform expression returned %v", v)
-
-				for _, line := range v {
-					output <- copyEvent(evt, line)
-				}
-			default:
-				logger.Errorf("transform expression returned an invalid type %T, sending event as-is", out)
-				output <- evt
-			}
-		}
-	}
-}
-
 func runBatchFetcher(ctx context.Context, bf types.BatchFetcher, output chan pipeline.Event, acquisTomb *tomb.Tomb) error {
 	// wrap tomb logic with context
 	ctx, cancel := context.WithCancel(ctx)
@@ -633,10 +585,30 @@ func StartAcquisition(
 
 			log.Debugf("datasource %s UUID: %s", subsrc.GetName(), subsrc.GetUuid())
 
-			if transformRuntime, ok := transformRuntimes[subsrc.GetUuid()]; ok {
+			// the events are decoded after the transform expression, which works on the raw lines
+			if decoder, ok := structuredDecoders[subsrc]; ok {
+				log.Infof("structured events for datasource %s", subsrc.GetName())
+
+				decodeChan := make(chan pipeline.Event)
+				decodeLogger := log.WithFields(log.Fields{
+					"component":  "structured",
+					"datasource": subsrc.GetName(),
+				})
+
+				acquisTomb.Go(func() error {
+					defer trace.ReportPanic()
+					decodeStructured(decodeChan, output, acquisTomb, decoder, decodeLogger)
+					return nil
+				})
+
+				outChan = decodeChan
+			}
+
+			if transformRuntime, ok := transformRuntimes[subsrc]; ok {
 				log.Infof("transform expression found for datasource %s", subsrc.GetName())
 
 				transformChan := make(chan pipeline.Event)
+				transformOutput := outChan
 				outChan = transformChan
 				transformLogger := log.WithFields(log.Fields{
 					"component":  "transform",
@@ -645,16 +617,45 @@ func StartAcquisition(
 
 				acquisTomb.Go(func() error {
 					defer trace.ReportPanic()
-					transform(outChan, output, acquisTomb, transformRuntime, transformLogger)
+					transform(transformChan, transformOutput, acquisTomb, transformRuntime, transformLogger)
+
+					// let the decoder finish too
+					if transformOutput != output {
+						close(transformOutput)
+					}
+
+					return nil
+				})
+			}
+
+			if pipeline.GetTracer() != nil {
+				traceChan := make(chan pipeline.Event)
+				traceOutput := outChan
+				outChan = traceChan
+
+				acquisTomb.Go(func() error {
+					defer trace.ReportPanic()
+					startTraces(traceChan, traceOutput, acquisTomb)
+
+					// let the transformer and the decoder finish too
+					if traceOutput != output {
+						close(traceOutput)
+					}
+
 					return nil
 				})
 			}
 
-			if err := acquireSource(ctx, subsrc, subsrc.GetName(), output, acquisTomb); err != nil {
+			if err := acquireSource(ctx, subsrc, subsrc.GetName(), outChan, acquisTomb); err != nil {
 				// if one of the acquisitions returns an error, we kill the others to properly shutdown
 				acquisTomb.Kill(err)
 			}
 
+			// in cat mode, the source is done sending: let the transformer finish too
+			if outChan != output && subsrc.GetMode() == configuration.CAT_MODE {
+				close(outChan)
+			}
+
 			return nil
 		})
 	}
@@ -131,6 +131,10 @@ func TestParseSourceConfig(t *testing.T) {
 						return
 					}
 
+					if runtime.GOOS != "windows" && strings.Contains(path, "/etw/") {
+						return
+					}
+
 					wantErr, hasWant := wantErrFromYAML(t, fileContent)
 
 					wantSchemaErr, hasWantSchemaErr := wantSchemaErrFromYAML(t, fileContent)
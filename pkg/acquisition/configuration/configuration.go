@@ -13,6 +13,7 @@ type DataSourceCommonCfg struct {
 	UseTimeMachine bool              `yaml:"use_time_machine,omitempty"`
 	UniqueId       string            `yaml:"unique_id,omitempty"`
 	TransformExpr  string            `yaml:"transform,omitempty"`
+	Structured     *StructuredCfg    `yaml:"structured,omitempty"`
 }
 
 const (
@@ -20,3 +21,23 @@ const (
 	CAT_MODE    = "cat"
 	SERVER_MODE = "server" // No difference with tail, just a bit more verbose
 )
+
+// StructuredCfg describes the events of a datasource that are structured at origin (ECS JSON
+// documents or protobuf messages). They are decoded before the parsers, and skip the parsing
+// stages that would run grok on them.
+type StructuredCfg struct {
+	Format string `yaml:"format"` // ecs or protobuf
+	// protobuf: a FileDescriptorSet (protoc --include_imports --descriptor_set_out) and the full name of the message
+	DescriptorSet string `yaml:"descriptor_set,omitempty"`
+	Message       string `yaml:"message,omitempty"`
+	// fields that must be present in each event, with dotted paths (i.e. source.ip)
+	RequiredFields []string `yaml:"required_fields,omitempty"`
+	// field with the time of the event, @timestamp for ecs
+	TimestampField string `yaml:"timestamp_field,omitempty"`
+	// meta of the event, from the fields. They are added to the ones of the format
+	Meta map[string]string `yaml:"meta,omitempty"`
+	// first stage of the parsers for the events, s02-enrich by default
+	Stage string `yaml:"stage,omitempty"`
+	// what to do with the events that can't be decoded or validated: drop (default), or send them to the parsers as-is
+	OnError string `yaml:"on_error,omitempty"`
+}
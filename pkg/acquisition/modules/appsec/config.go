@@ -47,10 +47,18 @@ type Configuration struct {
 	AuthCacheDuration *time.Duration `yaml:"auth_cache_duration"`
 	// BodyReadTimeout bounds how long we wait for the bouncer to finish sending the request body.
 	// Set to 0 to disable. Defaults to DefaultBodyReadTimeout.
-	BodyReadTimeout                   *time.Duration `yaml:"body_read_timeout"`
+	BodyReadTimeout *time.Duration `yaml:"body_read_timeout"`
+	// SPOE and ExtProc are the listeners of the proxies that call the engine inline, without a
+	// remediation component: HAProxy with the SPOE filter, Envoy with the ext_proc filter.
+	SPOE                              *ProxyListenerCfg `yaml:"spoe"`
+	ExtProc                           *ProxyListenerCfg `yaml:"ext_proc"`
 	configuration.DataSourceCommonCfg `yaml:",inline"`
 }
 
+type ProxyListenerCfg struct {
+	ListenAddr string `yaml:"listen_addr"`
+}
+
 func (w *Source) UnmarshalConfig(yamlConfig []byte) error {
 	err := yaml.UnmarshalWithOptions(yamlConfig, &w.config, yaml.Strict())
 	if err != nil {
@@ -86,6 +94,14 @@ func (w *Source) UnmarshalConfig(yamlConfig []byte) error {
 		return errors.New("appsec_config and appsec_config_path are mutually exclusive with appsec_configs")
 	}
 
+	if w.config.SPOE != nil && w.config.SPOE.ListenAddr == "" {
+		return errors.New("spoe.listen_addr is required")
+	}
+
+	if w.config.ExtProc != nil && w.config.ExtProc.ListenAddr == "" {
+		return errors.New("ext_proc.listen_addr is required")
+	}
+
 	if w.config.Name == "" {
 		if w.config.ListenSocket != "" && w.config.ListenAddr == "" {
 			w.config.Name = w.config.ListenSocket
@@ -157,9 +173,10 @@ func (w *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Ent
 	w.mux = http.NewServeMux()
 
 	w.server = &http.Server{
-		Addr:      w.config.ListenAddr,
-		Handler:   w.mux,
-		Protocols: &http.Protocols{},
+		Addr:        w.config.ListenAddr,
+		Handler:     w.mux,
+		Protocols:   &http.Protocols{},
+		ConnContext: appsec.ConnContext,
 	}
 
 	w.server.Protocols.SetHTTP1(true)
@@ -371,6 +388,24 @@ func (w *Source) appsecHandler(rw http.ResponseWriter, r *http.Request) {
 		"client_ip":    parsedRequest.ClientIP,
 	})
 
+	statusCode, appsecResponse := w.processRequest(parsedRequest, logger)
+
+	rw.WriteHeader(statusCode)
+
+	body, err := json.Marshal(appsecResponse)
+	if err != nil {
+		logger.Errorf("unable to serialize response: %s", err)
+		rw.WriteHeader(http.StatusInternalServerError)
+	} else {
+		if _, err := rw.Write(body); err != nil {
+			logger.Errorf("unable to write response: %s", err)
+		}
+	}
+}
+
+// processRequest sends a request to the runners, and returns the status code for the remediation
+// component and the response of the in-band rules.
+func (w *Source) processRequest(parsedRequest appsec.ParsedRequest, logger *log.Entry) (int, appsec.BodyResponse) {
 	metrics.AppsecReqCounter.With(prometheus.Labels{"source": parsedRequest.RemoteAddrNormalized, "appsec_engine": parsedRequest.AppsecEngine}).Inc()
 
 	w.InChan <- parsedRequest
@@ -388,15 +423,5 @@ func (w *Source) appsecHandler(rw http.ResponseWriter, r *http.Request) {
 	statusCode, appsecResponse := w.AppsecRuntime.GenerateResponse(response, logger)
 	logger.Debugf("Response: %+v", appsecResponse)
 
-	rw.WriteHeader(statusCode)
-
-	body, err := json.Marshal(appsecResponse)
-	if err != nil {
-		logger.Errorf("unable to serialize response: %s", err)
-		rw.WriteHeader(http.StatusInternalServerError)
-	} else {
-		if _, err := rw.Write(body); err != nil {
-			logger.Errorf("unable to write response: %s", err)
-		}
-	}
+	return statusCode, appsecResponse
 }
@@ -0,0 +1,62 @@
+package appsecacquisition
+
+import (
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/appsec"
+	"github.com/crowdsecurity/crowdsec/pkg/appsec/allowlists"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// NewEvaluationRunner returns a runner that is not attached to a datasource, to evaluate requests
+// on demand (i.e. to test rules). No ip is allowlisted.
+func NewEvaluationRunner(runtime *appsec.AppsecRuntimeConfig, datadir string, labels map[string]string, logger *log.Entry) (*AppsecRunner, error) {
+	runner := &AppsecRunner{
+		UUID:                   uuid.NewString(),
+		AppsecRuntime:          runtime,
+		Labels:                 labels,
+		logger:                 logger,
+		appsecAllowlistsClient: allowlists.NewAppsecAllowlist(logger),
+	}
+
+	if err := runner.Init(datadir); err != nil {
+		return nil, err
+	}
+
+	return runner, nil
+}
+
+// Evaluate processes a request like the datasource does, and returns the response of the in-band
+// rules and the events that are sent to the pipeline.
+func (r *AppsecRunner) Evaluate(request *appsec.ParsedRequest) (appsec.AppsecTempResponse, []pipeline.Event) {
+	out := make(chan pipeline.Event)
+	done := make(chan struct{})
+	events := []pipeline.Event{}
+
+	go func() {
+		defer close(done)
+
+		for evt := range out {
+			events = append(events, evt)
+		}
+	}()
+
+	r.outChan = out
+	request.ResponseChannel = make(chan appsec.AppsecTempResponse, 1)
+
+	r.handleRequest(request)
+
+	close(out)
+	<-done
+
+	// there is no response if the in-band rules could not be processed
+	response := appsec.AppsecTempResponse{}
+
+	select {
+	case response = <-request.ResponseChannel:
+	default:
+	}
+
+	return response, events
+}
@@ -0,0 +1,63 @@
+package appsecacquisition
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/appsec"
+	"github.com/crowdsecurity/crowdsec/pkg/appsec/appsec_rule"
+	"github.com/crowdsecurity/crowdsec/pkg/cwhub"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func TestEvaluationRunner(t *testing.T) {
+	logger := log.WithField("test", t.Name())
+
+	rule := appsec_rule.CustomRule{
+		Name:      "rule1",
+		Zones:     []string{"ARGS"},
+		Variables: []string{"foo"},
+		Match:     appsec_rule.Match{Type: "regex", Value: "^toto"},
+	}
+
+	strRule, _, err := rule.Convert(appsec_rule.ModsecurityRuleType, rule.Name, "test-rule")
+	require.NoError(t, err)
+
+	appsecCfg := appsec.AppsecConfig{Name: "test", Logger: logger}
+
+	runtime, err := appsecCfg.Build(&cwhub.Hub{})
+	require.NoError(t, err)
+
+	runtime.InBandRules = []appsec.AppsecCollection{{Rules: []string{strRule}}}
+
+	runner, err := NewEvaluationRunner(runtime, t.TempDir(), map[string]string{"type": "appsec"}, logger)
+	require.NoError(t, err)
+
+	evaluate := func(uri string) (appsec.AppsecTempResponse, []pipeline.Event) {
+		req := httptest.NewRequest("GET", uri, nil)
+
+		parsed, err := appsec.NewParsedRequestFromClientRequest(req, "1.2.3.4", logger, runtime.BodySettings)
+		require.NoError(t, err)
+
+		return runner.Evaluate(&parsed)
+	}
+
+	response, events := evaluate("/?foo=totolol")
+	assert.True(t, response.InBandInterrupt)
+	assert.Equal(t, appsec.BanRemediation, response.Action)
+	require.Len(t, events, 2)
+	assert.Equal(t, pipeline.APPSEC, events[0].Type)
+	assert.Equal(t, "1.2.3.4", events[0].Overflow.Alert.Source.IP)
+	assert.Equal(t, pipeline.LOG, events[1].Type)
+	assert.Equal(t, "test-rule", events[1].Appsec.MatchedRules[0]["msg"])
+
+	// the runner can be used again
+	response, events = evaluate("/?foo=bar")
+	assert.False(t, response.InBandInterrupt)
+	assert.Equal(t, appsec.AllowRemediation, response.Action)
+	assert.Empty(t, events)
+}
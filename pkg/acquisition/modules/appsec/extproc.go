@@ -0,0 +1,265 @@
+package appsecacquisition
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	filterv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_proc/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/crowdsecurity/crowdsec/pkg/appsec"
+)
+
+// Envoy external processing: the ext_proc HTTP filter sends the headers of the requests, and the
+// bodies if the processing mode is BUFFERED (or STREAMED). A request is inspected when it is
+// complete, and refused with an immediate response if the action is not "allow":
+//
+//	http_filters:
+//	- name: envoy.filters.http.ext_proc
+//	  typed_config:
+//	    "@type": type.googleapis.com/envoy.extensions.filters.http.ext_proc.v3.ExternalProcessor
+//	    grpc_service:
+//	      envoy_grpc:
+//	        cluster_name: crowdsec
+//	    processing_mode:
+//	      request_body_mode: BUFFERED
+//	      response_header_mode: SKIP
+//	    request_attributes: [source.address, request.protocol]
+
+// extProcAttributes are the attributes of the ext_proc filter in a ProcessingRequest.
+const extProcAttributes = "envoy.filters.http.ext_proc"
+
+// extProcServer is an external processor of Envoy.
+type extProcServer struct {
+	extprocv3.UnimplementedExternalProcessorServer
+
+	inspect     inspectFunc
+	maxBodySize int64
+	logger      *log.Entry
+}
+
+func newExtProcServer(inspect inspectFunc, maxBodySize int64, logger *log.Entry) *extProcServer {
+	return &extProcServer{
+		inspect:     inspect,
+		maxBodySize: maxBodySize,
+		logger:      logger,
+	}
+}
+
+// extProcAttribute returns an attribute of the request, if Envoy sends it (request_attributes).
+func extProcAttribute(req *extprocv3.ProcessingRequest, name string) string {
+	attrs, ok := req.GetAttributes()[extProcAttributes]
+	if !ok {
+		return ""
+	}
+
+	return attrs.GetFields()[name].GetStringValue()
+}
+
+// extProcClientIP returns the IP of the client: the source.address attribute, or the header set
+// by Envoy for the external requests.
+func extProcClientIP(req *extprocv3.ProcessingRequest, headers http.Header) string {
+	if addr := extProcAttribute(req, "source.address"); addr != "" {
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			return host
+		}
+
+		return addr
+	}
+
+	if ip := headers.Get("X-Envoy-External-Address"); ip != "" {
+		return ip
+	}
+
+	// the last hop, appended by Envoy
+	if xff := headers.Values("X-Forwarded-For"); len(xff) > 0 {
+		hops := strings.Split(xff[len(xff)-1], ",")
+		return strings.TrimSpace(hops[len(hops)-1])
+	}
+
+	return ""
+}
+
+// extProcHTTPVersion converts the request.protocol attribute ("HTTP/1.1", "HTTP/2") to the format
+// of the remediation components ("11", "20").
+func extProcHTTPVersion(protocol string) string {
+	version, ok := strings.CutPrefix(protocol, "HTTP/")
+	if !ok {
+		return ""
+	}
+
+	version = strings.ReplaceAll(version, ".", "")
+	if len(version) == 1 {
+		version += "0"
+	}
+
+	return version
+}
+
+// extProcRequest builds the request of the headers sent by Envoy.
+func extProcRequest(ctx context.Context, req *extprocv3.ProcessingRequest, headerMap *corev3.HeaderMap) proxyRequest {
+	ret := proxyRequest{
+		proxy:       "envoy-ext-proc",
+		headers:     http.Header{},
+		httpVersion: extProcHTTPVersion(extProcAttribute(req, "request.protocol")),
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		ret.remoteAddr = p.Addr.String()
+	}
+
+	for _, h := range headerMap.GetHeaders() {
+		value := h.GetValue()
+		if raw := h.GetRawValue(); len(raw) > 0 {
+			value = string(raw)
+		}
+
+		switch h.GetKey() {
+		case ":method":
+			ret.method = value
+		case ":path":
+			ret.uri = value
+		case ":authority":
+			ret.host = value
+		default:
+			if !strings.HasPrefix(h.GetKey(), ":") {
+				ret.headers.Add(h.GetKey(), value)
+			}
+		}
+	}
+
+	ret.clientIP = extProcClientIP(req, ret.headers)
+	ret.transactionID = ret.headers.Get("X-Request-Id")
+
+	return ret
+}
+
+// decide inspects a complete request, and returns the response for Envoy: continue, or refuse the
+// request. On error, the request is let through.
+func (s *extProcServer) decide(ctx context.Context, req proxyRequest, cont *extprocv3.ProcessingResponse) *extprocv3.ProcessingResponse {
+	resp, err := s.inspect(ctx, req)
+	if err != nil {
+		s.logger.Errorf("while inspecting request from %s: %s", req.remoteAddr, err)
+		return cont
+	}
+
+	if resp.Action == appsec.AllowRemediation {
+		return cont
+	}
+
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_ImmediateResponse{
+			ImmediateResponse: &extprocv3.ImmediateResponse{
+				Status:  &typev3.HttpStatus{Code: typev3.StatusCode(resp.HTTPStatus)},
+				Details: "crowdsec_appsec_" + resp.Action,
+			},
+		},
+	}
+}
+
+func continueRequestHeaders() *extprocv3.ProcessingResponse {
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestHeaders{RequestHeaders: &extprocv3.HeadersResponse{}},
+	}
+}
+
+func continueRequestBody() *extprocv3.ProcessingResponse {
+	return &extprocv3.ProcessingResponse{
+		Response: &extprocv3.ProcessingResponse_RequestBody{RequestBody: &extprocv3.BodyResponse{}},
+	}
+}
+
+// Process handles the messages of a stream, one stream for each HTTP request.
+func (s *extProcServer) Process(stream extprocv3.ExternalProcessor_ProcessServer) error {
+	ctx := stream.Context()
+
+	// the request, until its body is received
+	var pending *proxyRequest
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		var resp *extprocv3.ProcessingResponse
+
+		switch r := req.GetRequest().(type) {
+		case *extprocv3.ProcessingRequest_RequestHeaders:
+			request := extProcRequest(ctx, req, r.RequestHeaders.GetHeaders())
+
+			if r.RequestHeaders.GetEndOfStream() {
+				resp = s.decide(ctx, request, continueRequestHeaders())
+				break
+			}
+
+			// ask for the body, Envoy ignores it if allow_mode_override is not set
+			pending = &request
+			resp = continueRequestHeaders()
+			resp.ModeOverride = &filterv3.ProcessingMode{RequestBodyMode: filterv3.ProcessingMode_BUFFERED}
+		case *extprocv3.ProcessingRequest_RequestBody:
+			resp = continueRequestBody()
+
+			if pending == nil {
+				break
+			}
+
+			if room := s.maxBodySize - int64(len(pending.body)); room > 0 {
+				body := r.RequestBody.GetBody()
+				pending.body = append(pending.body, body[:min(int64(len(body)), room)]...)
+			}
+
+			if r.RequestBody.GetEndOfStream() {
+				resp = s.decide(ctx, *pending, resp)
+				pending = nil
+			}
+		case *extprocv3.ProcessingRequest_RequestTrailers:
+			resp = &extprocv3.ProcessingResponse{
+				Response: &extprocv3.ProcessingResponse_RequestTrailers{RequestTrailers: &extprocv3.TrailersResponse{}},
+			}
+
+			if pending != nil {
+				resp = s.decide(ctx, *pending, resp)
+				pending = nil
+			}
+		case *extprocv3.ProcessingRequest_ResponseHeaders:
+			resp = &extprocv3.ProcessingResponse{
+				Response: &extprocv3.ProcessingResponse_ResponseHeaders{ResponseHeaders: &extprocv3.HeadersResponse{}},
+			}
+
+			// the body was not sent: the request is inspected late, the response can still be replaced
+			if pending != nil {
+				resp = s.decide(ctx, *pending, resp)
+				pending = nil
+			}
+		case *extprocv3.ProcessingRequest_ResponseBody:
+			resp = &extprocv3.ProcessingResponse{
+				Response: &extprocv3.ProcessingResponse_ResponseBody{ResponseBody: &extprocv3.BodyResponse{}},
+			}
+		case *extprocv3.ProcessingRequest_ResponseTrailers:
+			resp = &extprocv3.ProcessingResponse{
+				Response: &extprocv3.ProcessingResponse_ResponseTrailers{ResponseTrailers: &extprocv3.TrailersResponse{}},
+			}
+		default:
+			return status.Errorf(codes.InvalidArgument, "unexpected message %T", r)
+		}
+
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package appsecacquisition
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/crowdsecurity/crowdsec/pkg/appsec"
+)
+
+func startExtProc(t *testing.T, inspect inspectFunc) extprocv3.ExternalProcessorClient {
+	t.Helper()
+
+	listener, err := (&net.ListenConfig{}).Listen(t.Context(), "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	extprocv3.RegisterExternalProcessorServer(server, newExtProcServer(inspect, 16, log.NewEntry(log.StandardLogger())))
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { conn.Close() })
+
+	return extprocv3.NewExternalProcessorClient(conn)
+}
+
+func envoyHeaders(method string, path string, endOfStream bool) *extprocv3.ProcessingRequest {
+	attrs, _ := structpb.NewStruct(map[string]any{
+		"source.address":   "192.0.2.1:51234",
+		"request.protocol": "HTTP/2",
+	})
+
+	return &extprocv3.ProcessingRequest{
+		Request: &extprocv3.ProcessingRequest_RequestHeaders{
+			RequestHeaders: &extprocv3.HttpHeaders{
+				Headers: &corev3.HeaderMap{
+					Headers: []*corev3.HeaderValue{
+						{Key: ":method", RawValue: []byte(method)},
+						{Key: ":path", RawValue: []byte(path)},
+						{Key: ":authority", RawValue: []byte("www.example.com")},
+						{Key: "user-agent", RawValue: []byte("curl/8.5.0")},
+						{Key: "x-request-id", RawValue: []byte("c3d4")},
+					},
+				},
+				EndOfStream: endOfStream,
+			},
+		},
+		Attributes: map[string]*structpb.Struct{extProcAttributes: attrs},
+	}
+}
+
+func TestExtProc(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []proxyRequest
+	)
+
+	client := startExtProc(t, func(_ context.Context, req proxyRequest) (appsec.BodyResponse, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		received = append(received, req)
+
+		if req.uri == "/.env" {
+			return appsec.BodyResponse{Action: "ban", HTTPStatus: http.StatusForbidden}, nil
+		}
+
+		return appsec.BodyResponse{Action: appsec.AllowRemediation, HTTPStatus: http.StatusOK}, nil
+	})
+
+	// a request without body, refused
+	stream, err := client.Process(t.Context())
+	require.NoError(t, err)
+
+	require.NoError(t, stream.Send(envoyHeaders("GET", "/.env", true)))
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetImmediateResponse())
+	assert.EqualValues(t, http.StatusForbidden, resp.GetImmediateResponse().GetStatus().GetCode())
+	assert.Equal(t, "crowdsec_appsec_ban", resp.GetImmediateResponse().GetDetails())
+
+	require.NoError(t, stream.CloseSend())
+
+	mu.Lock()
+	require.Len(t, received, 1)
+	assert.Equal(t, proxyRequest{
+		proxy:         "envoy-ext-proc",
+		remoteAddr:    received[0].remoteAddr,
+		clientIP:      "192.0.2.1",
+		method:        "GET",
+		uri:           "/.env",
+		host:          "www.example.com",
+		httpVersion:   "20",
+		transactionID: "c3d4",
+		headers:       http.Header{"User-Agent": {"curl/8.5.0"}, "X-Request-Id": {"c3d4"}},
+	}, received[0])
+	mu.Unlock()
+
+	// a request with a body, inspected when the body is received, and allowed
+	stream, err = client.Process(t.Context())
+	require.NoError(t, err)
+
+	require.NoError(t, stream.Send(envoyHeaders("POST", "/login", false)))
+
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetRequestHeaders())
+	assert.NotNil(t, resp.GetModeOverride())
+
+	for _, chunk := range []string{"user=admin&", "password=secret"} {
+		require.NoError(t, stream.Send(&extprocv3.ProcessingRequest{
+			Request: &extprocv3.ProcessingRequest_RequestBody{
+				RequestBody: &extprocv3.HttpBody{Body: []byte(chunk), EndOfStream: chunk == "password=secret"},
+			},
+		}))
+
+		resp, err = stream.Recv()
+		require.NoError(t, err)
+		require.NotNil(t, resp.GetRequestBody())
+	}
+
+	require.NoError(t, stream.CloseSend())
+
+	mu.Lock()
+	require.Len(t, received, 2)
+	// the body is truncated to the max size
+	assert.Equal(t, "user=admin&passw", string(received[1].body))
+	mu.Unlock()
+}
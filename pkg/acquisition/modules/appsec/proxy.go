@@ -0,0 +1,120 @@
+package appsecacquisition
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/appsec"
+)
+
+// proxyRequest is a request received from a proxy that calls the engine inline (HAProxy SPOE,
+// Envoy ext_proc) instead of a remediation component.
+type proxyRequest struct {
+	proxy         string // name of the integration, the user agent of the "remediation component"
+	remoteAddr    string // address of the proxy
+	clientIP      string
+	method        string
+	uri           string // path and query
+	host          string
+	httpVersion   string // "11", "20"...
+	transactionID string
+	headers       http.Header
+	body          []byte
+}
+
+// inspectFunc processes a request of a proxy, and returns the response of the in-band rules.
+type inspectFunc func(ctx context.Context, req proxyRequest) (appsec.BodyResponse, error)
+
+// httpRequest builds the request that the engine expects from a remediation component, with the
+// details of the original request in the X-Crowdsec-Appsec-* headers.
+func (p proxyRequest) httpRequest(ctx context.Context) (*http.Request, error) {
+	if p.clientIP == "" {
+		return nil, errors.New("missing client IP")
+	}
+
+	if p.method == "" {
+		return nil, errors.New("missing method")
+	}
+
+	if p.uri == "" {
+		return nil, errors.New("missing path")
+	}
+
+	var body io.Reader = http.NoBody
+	if len(p.body) > 0 {
+		body = bytes.NewReader(p.body)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.RemoteAddr = p.remoteAddr
+
+	if p.headers != nil {
+		r.Header = p.headers.Clone()
+	}
+
+	if ua := r.Header.Get("User-Agent"); ua != "" {
+		r.Header.Set(appsec.UserAgentHeaderName, ua)
+	}
+
+	r.Header.Set("User-Agent", p.proxy)
+	r.Header.Set(appsec.IPHeaderName, p.clientIP)
+	r.Header.Set(appsec.URIHeaderName, p.uri)
+	r.Header.Set(appsec.VerbHeaderName, p.method)
+	r.Header.Set(appsec.HostHeaderName, p.host)
+
+	if p.httpVersion != "" {
+		r.Header.Set(appsec.HTTPVersionHeaderName, p.httpVersion)
+	}
+
+	if p.transactionID != "" {
+		r.Header.Set(appsec.TransactionIDHeaderName, p.transactionID)
+	}
+
+	return r, nil
+}
+
+// inspect processes a request of a proxy like the requests of the remediation components. There
+// is no API key: the proxies are trusted, the listeners must not be exposed.
+func (w *Source) inspect(ctx context.Context, req proxyRequest) (appsec.BodyResponse, error) {
+	r, err := req.httpRequest(ctx)
+	if err != nil {
+		return appsec.BodyResponse{}, err
+	}
+
+	parsedRequest, err := appsec.NewParsedRequestFromRequest(r, w.logger, w.AppsecRuntime.BodySettings)
+	if err != nil {
+		return appsec.BodyResponse{}, err
+	}
+
+	parsedRequest.AppsecEngine = w.config.Name
+
+	logger := w.logger.WithFields(log.Fields{
+		"request_uuid": parsedRequest.UUID,
+		"client_ip":    parsedRequest.ClientIP,
+		"proxy":        req.proxy,
+	})
+
+	_, response := w.processRequest(parsedRequest, logger)
+
+	return response, nil
+}
+
+// maxBodySize is the size of the bodies kept by the proxy integrations. One more byte is kept, for
+// the engine to apply the action of the body settings when the limit is exceeded.
+func (w *Source) maxBodySize() int64 {
+	size := w.AppsecRuntime.BodySettings.MaxSize
+	if size <= 0 {
+		size = appsec.DefaultMaxBodySize
+	}
+
+	return size + 1
+}
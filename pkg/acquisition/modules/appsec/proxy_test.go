@@ -0,0 +1,91 @@
+package appsecacquisition
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/tomb.v2"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/appsec"
+	"github.com/crowdsecurity/crowdsec/pkg/appsec/allowlists"
+	"github.com/crowdsecurity/crowdsec/pkg/appsec/appsec_rule"
+	"github.com/crowdsecurity/crowdsec/pkg/cwhub"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func TestInspect(t *testing.T) {
+	logger := log.WithField("test", t.Name())
+
+	appsecCfg := appsec.AppsecConfig{Logger: logger}
+
+	runtime, err := appsecCfg.Build(&cwhub.Hub{})
+	require.NoError(t, err)
+
+	rule := appsec_rule.CustomRule{
+		Name:  "rule1",
+		Zones: []string{"URI"},
+		Match: appsec_rule.Match{Type: "equals", Value: "/.env"},
+	}
+
+	strRule, _, err := rule.Convert(appsec_rule.ModsecurityRuleType, rule.Name, "test-rule")
+	require.NoError(t, err)
+
+	runtime.InBandRules = []appsec.AppsecCollection{{Rules: []string{strRule}}}
+
+	w := &Source{
+		config:        Configuration{DataSourceCommonCfg: configuration.DataSourceCommonCfg{Name: "proxies"}},
+		logger:        logger,
+		AppsecRuntime: runtime,
+		InChan:        make(chan appsec.ParsedRequest),
+	}
+
+	runner := AppsecRunner{
+		inChan:                 w.InChan,
+		UUID:                   uuid.NewString(),
+		logger:                 logger,
+		AppsecRuntime:          runtime,
+		outChan:                make(chan pipeline.Event, 10),
+		appsecAllowlistsClient: allowlists.NewAppsecAllowlist(logger),
+	}
+
+	require.NoError(t, runner.Init(t.TempDir()))
+
+	tb := tomb.Tomb{}
+	tb.Go(func() error { return runner.Run(&tb) })
+
+	t.Cleanup(func() {
+		tb.Kill(nil)
+		_ = tb.Wait()
+	})
+
+	req := proxyRequest{
+		proxy:      "haproxy-spoe",
+		remoteAddr: "127.0.0.1:40000",
+		clientIP:   "192.0.2.1",
+		method:     "GET",
+		uri:        "/.env",
+		host:       "www.example.com",
+		headers:    http.Header{"User-Agent": {"curl/8.5.0"}},
+	}
+
+	resp, err := w.inspect(t.Context(), req)
+	require.NoError(t, err)
+	assert.Equal(t, appsec.BanRemediation, resp.Action)
+	assert.Equal(t, http.StatusForbidden, resp.HTTPStatus)
+
+	req.uri = "/index.html"
+
+	resp, err = w.inspect(t.Context(), req)
+	require.NoError(t, err)
+	assert.Equal(t, appsec.AllowRemediation, resp.Action)
+
+	req.clientIP = ""
+
+	_, err = w.inspect(t.Context(), req)
+	require.EqualError(t, err, "missing client IP")
+}
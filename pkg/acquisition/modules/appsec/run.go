@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"os"
 
+	extprocv3 "github.com/envoyproxy/go-control-plane/envoy/service/ext_proc/v3"
+	"google.golang.org/grpc"
 	"gopkg.in/tomb.v2"
 
 	"github.com/crowdsecurity/go-cs-lib/trace"
@@ -22,7 +24,7 @@ import (
 func (w *Source) listenAndServe(ctx context.Context, t *tomb.Tomb) error {
 	w.logger.Infof("%d appsec runner to start", len(w.AppsecRunners))
 
-	serverError := make(chan error, 2)
+	serverError := make(chan error, 4)
 
 	startServer := func(listener net.Listener, canTLS bool) {
 		var err error
@@ -93,11 +95,30 @@ func (w *Source) listenAndServe(ctx context.Context, t *tomb.Tomb) error {
 		startServer(listener, true)
 	}(w.config.ListenAddr)
 
+	spoe, spoeListener, err := w.startSPOE(ctx, listenConfig, serverError)
+	if err != nil {
+		return err
+	}
+
+	extProc, err := w.startExtProc(ctx, listenConfig, serverError)
+	if err != nil {
+		return err
+	}
+
 	select {
 	case err := <-serverError:
 		return err
 	case <-t.Dying():
 		w.logger.Info("Shutting down Appsec server")
+
+		if spoe != nil {
+			spoeListener.Close()
+			spoe.close()
+		}
+
+		if extProc != nil {
+			extProc.Stop()
+		}
 		// xx let's clean up the appsec runners :)
 		appsec.AppsecRulesDetails = make(map[int]appsec.RulesDetails)
 
@@ -117,6 +138,55 @@ func (w *Source) listenAndServe(ctx context.Context, t *tomb.Tomb) error {
 	return nil
 }
 
+// startSPOE starts the listener of the SPOE agent, if configured.
+func (w *Source) startSPOE(ctx context.Context, listenConfig *net.ListenConfig, serverError chan<- error) (*spoeServer, net.Listener, error) {
+	if w.config.SPOE == nil {
+		return nil, nil, nil
+	}
+
+	listener, err := listenConfig.Listen(ctx, "tcp", w.config.SPOE.ListenAddr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("listening on %s: %w", w.config.SPOE.ListenAddr, err)
+	}
+
+	server := newSpoeServer(w.inspect, w.logger.WithField("listener", "spoe"))
+
+	w.logger.Infof("Appsec listening for HAProxy SPOE on %s", w.config.SPOE.ListenAddr)
+
+	go func() {
+		if err := server.serve(ctx, listener); err != nil {
+			serverError <- fmt.Errorf("spoe: %w", err)
+		}
+	}()
+
+	return server, listener, nil
+}
+
+// startExtProc starts the gRPC server of the Envoy external processor, if configured.
+func (w *Source) startExtProc(ctx context.Context, listenConfig *net.ListenConfig, serverError chan<- error) (*grpc.Server, error) {
+	if w.config.ExtProc == nil {
+		return nil, nil
+	}
+
+	listener, err := listenConfig.Listen(ctx, "tcp", w.config.ExtProc.ListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", w.config.ExtProc.ListenAddr, err)
+	}
+
+	server := grpc.NewServer()
+	extprocv3.RegisterExternalProcessorServer(server, newExtProcServer(w.inspect, w.maxBodySize(), w.logger.WithField("listener", "ext_proc")))
+
+	w.logger.Infof("Appsec listening for Envoy ext_proc on %s", w.config.ExtProc.ListenAddr)
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			serverError <- fmt.Errorf("ext_proc: %w", err)
+		}
+	}()
+
+	return server, nil
+}
+
 func (w *Source) StreamingAcquisition(ctx context.Context, out chan pipeline.Event, t *tomb.Tomb) error {
 	lapiClient, err := apiclient.GetLAPIClient()
 	if err != nil {
@@ -0,0 +1,669 @@
+package appsecacquisition
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// HAProxy Stream Processing Offload Protocol, version 2.0. The frames, the data types and the
+// varints are described in https://www.haproxy.org/download/3.0/doc/SPOE.txt
+//
+// HAProxy sends the request in a message, i.e.:
+//
+//	spoe-message crowdsec-http
+//	    args id=unique-id ip=src method=method path=path query=query version=req.ver headers=req.hdrs body=req.body
+//	    event on-frontend-http-request
+//
+// and the agent sets the variables "action" and "http_status" in the transaction scope, with the
+// prefix of the spoe-agent (txn.crowdsec.action).
+
+const (
+	spoeVersion = "2.0"
+
+	// the frames can be as large as the buffers of HAProxy (tune.bufsize)
+	spoeMaxFrameSize = 1 << 20
+
+	spoeFrameHaproxyHello      = 1
+	spoeFrameHaproxyDisconnect = 2
+	spoeFrameNotify            = 3
+	spoeFrameAgentHello        = 101
+	spoeFrameAgentDisconnect   = 102
+	spoeFrameAck               = 103
+
+	spoeFlagFin = 0x01
+
+	spoeTypeNull   = 0
+	spoeTypeBool   = 1
+	spoeTypeInt32  = 2
+	spoeTypeUint32 = 3
+	spoeTypeInt64  = 4
+	spoeTypeUint64 = 5
+	spoeTypeIPv4   = 6
+	spoeTypeIPv6   = 7
+	spoeTypeString = 8
+	spoeTypeBinary = 9
+
+	spoeActionSetVar = 1
+	spoeScopeTxn     = 2
+
+	// status codes of the disconnect frames
+	spoeStatusNormal             = 0
+	spoeStatusInvalidFrame       = 4
+	spoeStatusUnsupportedVersion = 8
+	spoeStatusNoFragmentation    = 10
+)
+
+var errSpoeShortBuffer = errors.New("truncated data")
+
+// spoeFrame is a frame, without the fragmentation: the agent does not announce the capability.
+type spoeFrame struct {
+	typ      byte
+	flags    uint32
+	streamID uint64
+	frameID  uint64
+	payload  []byte
+}
+
+// spoeMessage is a message of a NOTIFY frame, with its arguments.
+type spoeMessage struct {
+	name string
+	args map[string]any
+}
+
+// appendVarint encodes an integer as a varint of SPOP: 4 bits in the first byte after 240, then 7
+// bits by byte.
+func appendVarint(b []byte, i uint64) []byte {
+	if i < 240 {
+		return append(b, byte(i))
+	}
+
+	b = append(b, byte(i)|240)
+	i = (i - 240) >> 4
+
+	for i >= 128 {
+		b = append(b, byte(i)|128)
+		i = (i - 128) >> 7
+	}
+
+	return append(b, byte(i))
+}
+
+// readVarint decodes a varint, and returns the number of bytes read.
+func readVarint(b []byte) (uint64, int, error) {
+	if len(b) == 0 {
+		return 0, 0, errSpoeShortBuffer
+	}
+
+	i := uint64(b[0])
+	if i < 240 {
+		return i, 1, nil
+	}
+
+	shift := 4
+
+	for n := 1; n < len(b) && n <= 10; n++ {
+		i += uint64(b[n]) << shift
+		shift += 7
+
+		if b[n] < 128 {
+			return i, n + 1, nil
+		}
+	}
+
+	return 0, 0, errSpoeShortBuffer
+}
+
+func appendSpoeString(b []byte, s string) []byte {
+	b = appendVarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func readSpoeString(b []byte) (string, int, error) {
+	size, n, err := readVarint(b)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if uint64(len(b)-n) < size {
+		return "", 0, errSpoeShortBuffer
+	}
+
+	end := n + int(size)
+
+	return string(b[n:end]), end, nil
+}
+
+// appendTyped encodes a string, a boolean or an integer as typed data.
+func appendTyped(b []byte, v any) []byte {
+	switch v := v.(type) {
+	case string:
+		return appendSpoeString(append(b, spoeTypeString), v)
+	case bool:
+		if v {
+			return append(b, spoeTypeBool|0x10)
+		}
+
+		return append(b, spoeTypeBool)
+	case int:
+		return appendVarint(append(b, spoeTypeInt32), uint64(int64(v)))
+	case uint32:
+		return appendVarint(append(b, spoeTypeUint32), uint64(v))
+	default:
+		return append(b, spoeTypeNull)
+	}
+}
+
+// readTyped decodes typed data: nil, bool, int64, uint64, net.IP, string or []byte.
+func readTyped(b []byte) (any, int, error) {
+	if len(b) == 0 {
+		return nil, 0, errSpoeShortBuffer
+	}
+
+	typ, flags := b[0]&0x0f, b[0]&0xf0
+	b = b[1:]
+
+	switch typ {
+	case spoeTypeNull:
+		return nil, 1, nil
+	case spoeTypeBool:
+		return flags&0x10 != 0, 1, nil
+	case spoeTypeInt32, spoeTypeInt64:
+		i, n, err := readVarint(b)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if typ == spoeTypeInt32 {
+			return int64(int32(i)), n + 1, nil
+		}
+
+		return int64(i), n + 1, nil
+	case spoeTypeUint32, spoeTypeUint64:
+		i, n, err := readVarint(b)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return i, n + 1, nil
+	case spoeTypeIPv4, spoeTypeIPv6:
+		size := net.IPv4len
+		if typ == spoeTypeIPv6 {
+			size = net.IPv6len
+		}
+
+		if len(b) < size {
+			return nil, 0, errSpoeShortBuffer
+		}
+
+		return net.IP(slices.Clone(b[:size])), size + 1, nil
+	case spoeTypeString:
+		s, n, err := readSpoeString(b)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return s, n + 1, nil
+	case spoeTypeBinary:
+		s, n, err := readSpoeString(b)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return []byte(s), n + 1, nil
+	default:
+		return nil, 0, fmt.Errorf("unknown data type %d", typ)
+	}
+}
+
+// readKVList decodes the key-values of the HELLO and DISCONNECT frames.
+func readKVList(b []byte) (map[string]any, error) {
+	ret := map[string]any{}
+
+	for len(b) > 0 {
+		key, n, err := readSpoeString(b)
+		if err != nil {
+			return nil, err
+		}
+
+		b = b[n:]
+
+		value, n, err := readTyped(b)
+		if err != nil {
+			return nil, fmt.Errorf("value of %s: %w", key, err)
+		}
+
+		b = b[n:]
+		ret[key] = value
+	}
+
+	return ret, nil
+}
+
+// readMessages decodes the messages of a NOTIFY frame.
+func readMessages(b []byte) ([]spoeMessage, error) {
+	var ret []spoeMessage
+
+	for len(b) > 0 {
+		name, n, err := readSpoeString(b)
+		if err != nil {
+			return nil, err
+		}
+
+		b = b[n:]
+
+		if len(b) == 0 {
+			return nil, errSpoeShortBuffer
+		}
+
+		nbArgs := int(b[0])
+		b = b[1:]
+
+		msg := spoeMessage{name: name, args: make(map[string]any, nbArgs)}
+
+		for range nbArgs {
+			key, n, err := readSpoeString(b)
+			if err != nil {
+				return nil, fmt.Errorf("message %s: %w", name, err)
+			}
+
+			b = b[n:]
+
+			value, n, err := readTyped(b)
+			if err != nil {
+				return nil, fmt.Errorf("message %s, argument %s: %w", name, key, err)
+			}
+
+			b = b[n:]
+			msg.args[key] = value
+		}
+
+		ret = append(ret, msg)
+	}
+
+	return ret, nil
+}
+
+func readSpoeFrame(r io.Reader, maxSize uint32) (spoeFrame, error) {
+	var f spoeFrame
+
+	var header [4]byte
+
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return f, err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxSize {
+		return f, fmt.Errorf("frame too big (%d bytes)", size)
+	}
+
+	data := make([]byte, size)
+
+	if _, err := io.ReadFull(r, data); err != nil {
+		return f, err
+	}
+
+	if len(data) < 5 {
+		return f, errSpoeShortBuffer
+	}
+
+	f.typ = data[0]
+	f.flags = binary.BigEndian.Uint32(data[1:5])
+	data = data[5:]
+
+	streamID, n, err := readVarint(data)
+	if err != nil {
+		return f, err
+	}
+
+	data = data[n:]
+
+	frameID, n, err := readVarint(data)
+	if err != nil {
+		return f, err
+	}
+
+	f.streamID = streamID
+	f.frameID = frameID
+	f.payload = data[n:]
+
+	return f, nil
+}
+
+func (f spoeFrame) marshal() []byte {
+	b := make([]byte, 4, 32+len(f.payload))
+	b = append(b, f.typ)
+	b = binary.BigEndian.AppendUint32(b, f.flags)
+	b = appendVarint(b, f.streamID)
+	b = appendVarint(b, f.frameID)
+	b = append(b, f.payload...)
+
+	binary.BigEndian.PutUint32(b, uint32(len(b)-4))
+
+	return b
+}
+
+// spoeString returns the value of an argument as a string. The IP addresses and the integers are
+// formatted.
+func spoeString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case net.IP:
+		return v.String()
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	default:
+		return ""
+	}
+}
+
+// parseRawHeaders parses a block of headers, as returned by req.hdrs.
+func parseRawHeaders(raw string) (http.Header, error) {
+	raw = strings.TrimRight(raw, "\r\n")
+	if raw == "" {
+		return http.Header{}, nil
+	}
+
+	headers, err := textproto.NewReader(bufio.NewReader(strings.NewReader(raw + "\r\n\r\n"))).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	return http.Header(headers), nil
+}
+
+// spoeRequest builds the request of the arguments of a message.
+func spoeRequest(msg spoeMessage, remoteAddr string) (proxyRequest, error) {
+	req := proxyRequest{
+		proxy:         "haproxy-spoe",
+		remoteAddr:    remoteAddr,
+		clientIP:      spoeString(msg.args["ip"]),
+		method:        spoeString(msg.args["method"]),
+		uri:           spoeString(msg.args["path"]),
+		transactionID: spoeString(msg.args["id"]),
+	}
+
+	if query := spoeString(msg.args["query"]); query != "" {
+		req.uri += "?" + query
+	}
+
+	// req.ver is "1.1", "2.0"
+	req.httpVersion = strings.ReplaceAll(spoeString(msg.args["version"]), ".", "")
+
+	headers, err := parseRawHeaders(spoeString(msg.args["headers"]))
+	if err != nil {
+		return req, fmt.Errorf("invalid headers: %w", err)
+	}
+
+	req.headers = headers
+	req.host = headers.Get("Host")
+
+	if body, ok := msg.args["body"]; ok {
+		req.body = []byte(spoeString(body))
+	}
+
+	return req, nil
+}
+
+// spoeServer is an agent of the SPOE filter of HAProxy.
+type spoeServer struct {
+	inspect inspectFunc
+	logger  *log.Entry
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+func newSpoeServer(inspect inspectFunc, logger *log.Entry) *spoeServer {
+	return &spoeServer{
+		inspect: inspect,
+		logger:  logger,
+		conns:   map[net.Conn]struct{}{},
+	}
+}
+
+// serve accepts the connections of HAProxy until the listener is closed.
+func (s *spoeServer) serve(ctx context.Context, listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+
+			s.handleConn(ctx, conn)
+
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// close closes the connections and waits for their requests, the listener must be closed first.
+func (s *spoeServer) close() {
+	s.mu.Lock()
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// spoeConn is a connection with HAProxy. The frames can be pipelined: the ACK frames are written
+// as soon as the requests are processed.
+type spoeConn struct {
+	conn         net.Conn
+	logger       *log.Entry
+	maxFrameSize uint32
+
+	writeMu sync.Mutex
+}
+
+func (c *spoeConn) write(f spoeFrame) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, err := c.conn.Write(f.marshal())
+
+	return err
+}
+
+func (c *spoeConn) disconnect(status uint32, message string) {
+	var payload []byte
+
+	payload = appendSpoeString(payload, "status-code")
+	payload = appendTyped(payload, status)
+	payload = appendSpoeString(payload, "message")
+	payload = appendTyped(payload, message)
+
+	if err := c.write(spoeFrame{typ: spoeFrameAgentDisconnect, flags: spoeFlagFin, payload: payload}); err != nil {
+		c.logger.Debugf("while disconnecting: %s", err)
+	}
+}
+
+// hello negotiates the version, the frame size and the capabilities. It returns false if the
+// connection must be closed (error or health check).
+func (c *spoeConn) hello(r io.Reader) bool {
+	f, err := readSpoeFrame(r, spoeMaxFrameSize)
+	if err != nil {
+		c.logger.Errorf("reading HAPROXY-HELLO: %s", err)
+		return false
+	}
+
+	if f.typ != spoeFrameHaproxyHello {
+		c.disconnect(spoeStatusInvalidFrame, "expected HAPROXY-HELLO")
+		return false
+	}
+
+	kv, err := readKVList(f.payload)
+	if err != nil {
+		c.disconnect(spoeStatusInvalidFrame, err.Error())
+		return false
+	}
+
+	versions := strings.Split(spoeString(kv["supported-versions"]), ",")
+	if !slices.ContainsFunc(versions, func(v string) bool { return strings.TrimSpace(v) == spoeVersion }) {
+		c.disconnect(spoeStatusUnsupportedVersion, "unsupported version")
+		return false
+	}
+
+	c.maxFrameSize = spoeMaxFrameSize
+
+	if size, ok := kv["max-frame-size"].(uint64); ok && size < spoeMaxFrameSize {
+		c.maxFrameSize = uint32(size)
+	}
+
+	capabilities := ""
+
+	for capability := range strings.SplitSeq(spoeString(kv["capabilities"]), ",") {
+		if strings.TrimSpace(capability) == "pipelining" {
+			capabilities = "pipelining"
+		}
+	}
+
+	var payload []byte
+
+	payload = appendSpoeString(payload, "version")
+	payload = appendTyped(payload, spoeVersion)
+	payload = appendSpoeString(payload, "max-frame-size")
+	payload = appendTyped(payload, c.maxFrameSize)
+	payload = appendSpoeString(payload, "capabilities")
+	payload = appendTyped(payload, capabilities)
+
+	if err := c.write(spoeFrame{typ: spoeFrameAgentHello, flags: spoeFlagFin, payload: payload}); err != nil {
+		c.logger.Errorf("writing AGENT-HELLO: %s", err)
+		return false
+	}
+
+	if healthcheck, _ := kv["healthcheck"].(bool); healthcheck {
+		return false
+	}
+
+	return true
+}
+
+func (s *spoeServer) handleConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	c := &spoeConn{
+		conn:   conn,
+		logger: s.logger.WithField("haproxy", conn.RemoteAddr().String()),
+	}
+
+	r := bufio.NewReader(conn)
+
+	if !c.hello(r) {
+		return
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		f, err := readSpoeFrame(r, c.maxFrameSize)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				c.logger.Errorf("reading frame: %s", err)
+			}
+
+			return
+		}
+
+		switch f.typ {
+		case spoeFrameNotify:
+			if f.flags&spoeFlagFin == 0 {
+				c.disconnect(spoeStatusNoFragmentation, "fragmentation is not supported")
+				return
+			}
+
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				if err := c.write(s.notify(ctx, c, f)); err != nil {
+					c.logger.Errorf("writing ACK: %s", err)
+				}
+			}()
+		case spoeFrameHaproxyDisconnect:
+			c.disconnect(spoeStatusNormal, "")
+			return
+		default:
+			c.disconnect(spoeStatusInvalidFrame, fmt.Sprintf("unexpected frame type %d", f.typ))
+			return
+		}
+	}
+}
+
+// notify processes the requests of a NOTIFY frame, and returns the ACK frame. On error, no variable
+// is set and HAProxy lets the request through.
+func (s *spoeServer) notify(ctx context.Context, c *spoeConn, f spoeFrame) spoeFrame {
+	ack := spoeFrame{typ: spoeFrameAck, flags: spoeFlagFin, streamID: f.streamID, frameID: f.frameID}
+
+	messages, err := readMessages(f.payload)
+	if err != nil {
+		c.logger.Errorf("invalid NOTIFY frame: %s", err)
+		return ack
+	}
+
+	for _, msg := range messages {
+		req, err := spoeRequest(msg, c.conn.RemoteAddr().String())
+		if err != nil {
+			c.logger.Errorf("message %s: %s", msg.name, err)
+			continue
+		}
+
+		resp, err := s.inspect(ctx, req)
+		if err != nil {
+			c.logger.Errorf("message %s: %s", msg.name, err)
+			continue
+		}
+
+		ack.payload = appendSetVar(ack.payload, "action", resp.Action)
+		ack.payload = appendSetVar(ack.payload, "http_status", resp.HTTPStatus)
+	}
+
+	return ack
+}
+
+func appendSetVar(b []byte, name string, value any) []byte {
+	b = append(b, spoeActionSetVar, 3, spoeScopeTxn)
+	b = appendSpoeString(b, name)
+
+	return appendTyped(b, value)
+}
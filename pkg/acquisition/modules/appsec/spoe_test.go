@@ -0,0 +1,231 @@
+package appsecacquisition
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/appsec"
+)
+
+func TestSpoeVarint(t *testing.T) {
+	tests := []struct {
+		value   uint64
+		encoded []byte
+	}{
+		{0, []byte{0x00}},
+		{239, []byte{0xef}},
+		{240, []byte{0xf0, 0x00}},
+		{2287, []byte{0xff, 0x7f}},
+		{2288, []byte{0xf0, 0x80, 0x00}},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.encoded, appendVarint(nil, tc.value), tc.value)
+
+		value, n, err := readVarint(tc.encoded)
+		require.NoError(t, err)
+		assert.Equal(t, tc.value, value)
+		assert.Equal(t, len(tc.encoded), n)
+	}
+
+	for _, value := range []uint64{264431, 264432, 1 << 32, 1<<64 - 1} {
+		encoded := appendVarint(nil, value)
+
+		decoded, n, err := readVarint(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, value, decoded)
+		assert.Equal(t, len(encoded), n)
+	}
+
+	_, _, err := readVarint([]byte{0xf0, 0x80})
+	require.ErrorIs(t, err, errSpoeShortBuffer)
+}
+
+func TestSpoeRequest(t *testing.T) {
+	msg := spoeMessage{
+		name: "crowdsec-http",
+		args: map[string]any{
+			"id":      "a1b2",
+			"ip":      net.IPv4(192, 0, 2, 1).To4(),
+			"method":  "POST",
+			"path":    "/login",
+			"query":   "user=admin",
+			"version": "1.1",
+			"headers": "host: www.example.com\r\nuser-agent: curl/8.5.0\r\ncontent-type: application/x-www-form-urlencoded\r\n\r\n",
+			"body":    []byte("password=secret"),
+		},
+	}
+
+	req, err := spoeRequest(msg, "127.0.0.1:40000")
+	require.NoError(t, err)
+
+	r, err := req.httpRequest(t.Context())
+	require.NoError(t, err)
+
+	parsed, err := appsec.NewParsedRequestFromRequest(r, log.NewEntry(log.StandardLogger()), appsec.BodySettings{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "192.0.2.1", parsed.ClientIP)
+	assert.Equal(t, "POST", parsed.Method)
+	assert.Equal(t, "/login?user=admin", parsed.URI)
+	assert.Equal(t, "www.example.com", parsed.Host)
+	assert.Equal(t, "HTTP/1.1", parsed.Proto)
+	assert.Equal(t, "a1b2", parsed.UUID)
+	assert.Equal(t, []byte("password=secret"), parsed.Body)
+	assert.Equal(t, "curl/8.5.0", parsed.Headers.Get("User-Agent"))
+	assert.Equal(t, "application/x-www-form-urlencoded", parsed.Headers.Get("Content-Type"))
+	assert.Empty(t, parsed.Headers.Get(appsec.IPHeaderName))
+	assert.Equal(t, "haproxy-spoe", parsed.RemediationComponent.Name)
+	assert.Equal(t, "127.0.0.1", parsed.RemoteAddrNormalized)
+
+	// the client IP is required
+	delete(msg.args, "ip")
+
+	req, err = spoeRequest(msg, "127.0.0.1:40000")
+	require.NoError(t, err)
+
+	_, err = req.httpRequest(t.Context())
+	require.EqualError(t, err, "missing client IP")
+}
+
+// haproxyHello returns the HAPROXY-HELLO frame.
+func haproxyHello(versions string, healthcheck bool) spoeFrame {
+	var payload []byte
+
+	payload = appendSpoeString(payload, "supported-versions")
+	payload = appendTyped(payload, versions)
+	payload = appendSpoeString(payload, "max-frame-size")
+	payload = appendTyped(payload, uint32(16380))
+	payload = appendSpoeString(payload, "capabilities")
+	payload = appendTyped(payload, "pipelining,async")
+
+	if healthcheck {
+		payload = appendSpoeString(payload, "healthcheck")
+		payload = appendTyped(payload, true)
+	}
+
+	return spoeFrame{typ: spoeFrameHaproxyHello, flags: spoeFlagFin, payload: payload}
+}
+
+func startSpoeConn(t *testing.T, inspect inspectFunc) net.Conn {
+	t.Helper()
+
+	client, conn := net.Pipe()
+	server := newSpoeServer(inspect, log.NewEntry(log.StandardLogger()))
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		server.handleConn(t.Context(), conn)
+	}()
+
+	t.Cleanup(func() {
+		client.Close()
+		<-done
+	})
+
+	return client
+}
+
+func TestSpoeConversation(t *testing.T) {
+	var received []proxyRequest
+
+	client := startSpoeConn(t, func(_ context.Context, req proxyRequest) (appsec.BodyResponse, error) {
+		received = append(received, req)
+		return appsec.BodyResponse{Action: "ban", HTTPStatus: http.StatusForbidden}, nil
+	})
+
+	_, err := client.Write(haproxyHello("1.0, 2.0", false).marshal())
+	require.NoError(t, err)
+
+	hello, err := readSpoeFrame(client, spoeMaxFrameSize)
+	require.NoError(t, err)
+	assert.Equal(t, byte(spoeFrameAgentHello), hello.typ)
+
+	kv, err := readKVList(hello.payload)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"version": "2.0", "max-frame-size": uint64(16380), "capabilities": "pipelining"}, kv)
+
+	// a message with the request
+	payload := appendSpoeString(nil, "crowdsec-http")
+	payload = append(payload, 4)
+	payload = appendSpoeString(payload, "ip")
+	payload = append(payload, spoeTypeIPv4, 192, 0, 2, 1)
+	payload = appendSpoeString(payload, "method")
+	payload = appendTyped(payload, "GET")
+	payload = appendSpoeString(payload, "path")
+	payload = appendTyped(payload, "/.env")
+	payload = appendSpoeString(payload, "headers")
+	payload = appendTyped(payload, "host: www.example.com\r\n\r\n")
+
+	_, err = client.Write(spoeFrame{typ: spoeFrameNotify, flags: spoeFlagFin, streamID: 7, frameID: 1, payload: payload}.marshal())
+	require.NoError(t, err)
+
+	ack, err := readSpoeFrame(client, spoeMaxFrameSize)
+	require.NoError(t, err)
+	assert.Equal(t, byte(spoeFrameAck), ack.typ)
+	assert.Equal(t, uint64(7), ack.streamID)
+	assert.Equal(t, uint64(1), ack.frameID)
+
+	expected := appendSetVar(nil, "action", "ban")
+	expected = appendSetVar(expected, "http_status", http.StatusForbidden)
+	assert.Equal(t, expected, ack.payload)
+
+	require.Len(t, received, 1)
+	assert.Equal(t, "192.0.2.1", received[0].clientIP)
+	assert.Equal(t, "/.env", received[0].uri)
+	assert.Equal(t, "www.example.com", received[0].host)
+
+	// fragmented frames are refused
+	_, err = client.Write(spoeFrame{typ: spoeFrameNotify, streamID: 8, frameID: 1, payload: payload}.marshal())
+	require.NoError(t, err)
+
+	disconnect, err := readSpoeFrame(client, spoeMaxFrameSize)
+	require.NoError(t, err)
+	assert.Equal(t, byte(spoeFrameAgentDisconnect), disconnect.typ)
+
+	kv, err = readKVList(disconnect.payload)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(spoeStatusNoFragmentation), kv["status-code"])
+}
+
+func TestSpoeHello(t *testing.T) {
+	inspect := func(context.Context, proxyRequest) (appsec.BodyResponse, error) {
+		return appsec.BodyResponse{}, nil
+	}
+
+	// health check: the connection is closed after the hello
+	client := startSpoeConn(t, inspect)
+
+	_, err := client.Write(haproxyHello("2.0", true).marshal())
+	require.NoError(t, err)
+
+	hello, err := readSpoeFrame(client, spoeMaxFrameSize)
+	require.NoError(t, err)
+	assert.Equal(t, byte(spoeFrameAgentHello), hello.typ)
+
+	_, err = readSpoeFrame(client, spoeMaxFrameSize)
+	require.ErrorIs(t, err, io.EOF)
+
+	// unsupported version
+	client = startSpoeConn(t, inspect)
+
+	_, err = client.Write(haproxyHello("1.0", false).marshal())
+	require.NoError(t, err)
+
+	disconnect, err := readSpoeFrame(client, spoeMaxFrameSize)
+	require.NoError(t, err)
+	assert.Equal(t, byte(spoeFrameAgentDisconnect), disconnect.typ)
+
+	kv, err := readKVList(disconnect.payload)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"status-code": uint64(spoeStatusUnsupportedVersion), "message": "unsupported version"}, kv)
+}
@@ -0,0 +1,5 @@
+//go:build !no_datasource_auth0
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/auth0" // register the datasource
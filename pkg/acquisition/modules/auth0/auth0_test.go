@@ -0,0 +1,260 @@
+package auth0acquisition
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/sourcetest"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const domain = "example.eu.auth0.com"
+
+// fakeManagementAPI answers the token requests and serves the log events. The log ids are
+// "log-<index>", the events are one minute apart.
+type fakeManagementAPI struct {
+	mu          sync.Mutex
+	events      []string
+	dates       []time.Time
+	queries     []string
+	tokens      int
+	revokeFirst bool // reject the first token after its first use
+	used        map[string]bool
+}
+
+func (f *fakeManagementAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.URL.Path == "/oauth/token" {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["client_secret"] != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"error": "access_denied", "error_description": "Unauthorized"}`)
+
+			return
+		}
+
+		if body["audience"] != "https://"+domain+"/api/v2/" || body["grant_type"] != "client_credentials" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		f.tokens++
+		_, _ = fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 86400, "token_type": "Bearer"}`, f.tokens)
+
+		return
+	}
+
+	if r.URL.Path != logsPath {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if f.revokeFirst && token == "token-1" && f.used[token] {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"statusCode": 401, "error": "Unauthorized", "message": "Invalid token", "errorCode": "invalid_token"}`)
+
+		return
+	}
+
+	f.used[token] = true
+
+	query := r.URL.Query()
+	f.queries = append(f.queries, query.Encode())
+
+	start := 0
+	size := 0
+
+	switch {
+	case query.Has("from"):
+		idx, _ := strconv.Atoi(strings.TrimPrefix(query.Get("from"), "log-"))
+		start = idx + 1
+		size, _ = strconv.Atoi(query.Get("take"))
+	case query.Has("q"):
+		// date:[2025-01-02T03:04:05.000Z TO *]
+		since, err := time.Parse("2006-01-02T15:04:05.000Z", strings.TrimSuffix(strings.TrimPrefix(query.Get("q"), "date:["), " TO *]"))
+		if err != nil || query.Get("sort") != "date:1" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"statusCode": 400, "error": "Bad Request", "message": "Invalid query"}`)
+
+			return
+		}
+
+		for start < len(f.dates) && f.dates[start].Before(since) {
+			start++
+		}
+
+		size, _ = strconv.Atoi(query.Get("per_page"))
+	}
+
+	start = min(start, len(f.events))
+	end := min(start+size, len(f.events))
+
+	_, _ = io.WriteString(w, "["+strings.Join(f.events[start:end], ",")+"]")
+}
+
+func (f *fakeManagementAPI) addEvents(first time.Time, n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range n {
+		date := first.Add(time.Duration(i) * time.Minute).UTC()
+		f.dates = append(f.dates, date)
+		f.events = append(f.events, fmt.Sprintf(`{
+  "log_id": "log-%d",
+  "date": %q,
+  "type": "fp",
+  "description": "Wrong email or password.",
+  "ip": "192.0.2.%d",
+  "user_name": "alice@example.com"
+}`, len(f.events), date.Format("2006-01-02T15:04:05.000Z"), len(f.events)))
+	}
+}
+
+func newFakeAPI(t *testing.T) (*fakeManagementAPI, string) {
+	t.Helper()
+
+	fake := &fakeManagementAPI{used: map[string]bool{}}
+
+	return fake, sourcetest.NewServer(t, fake)
+}
+
+func newTestSource(t *testing.T, apiURL string, extra string) *Source {
+	t.Helper()
+
+	s := &Source{}
+	sourcetest.Configure(t, s, `source: auth0
+labels:
+  type: auth0
+domain: `+domain+`
+client_id: m2m
+client_secret: secret
+api_url: `+apiURL, extra)
+
+	return s
+}
+
+func TestConfigure(t *testing.T) {
+	base := "source: auth0\ndomain: " + domain + "\nclient_id: m2m\nclient_secret: secret\n"
+
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "unknown field", extra: "foobar: 42", wantErr: `unknown field "foobar"`},
+		{name: "no domain", extra: "domain: ''", wantErr: "domain is required"},
+		{name: "domain url", extra: "domain: https://" + domain, wantErr: "invalid domain 'https://" + domain + "': must be a host name, use api_url for a custom url"},
+		{name: "no client_id", extra: "client_id: ''", wantErr: "client_id is required"},
+		{name: "no client_secret", extra: "client_secret: ''", wantErr: "client_secret is required"},
+		{name: "api_url scheme", extra: "api_url: ftp://" + domain, wantErr: "invalid api_url scheme 'ftp': must be http or https"},
+		{name: "page_size", extra: "page_size: 500", wantErr: "page_size must be between 1 and 100"},
+		{name: "poll_interval", extra: "poll_interval: -1s", wantErr: "poll_interval must be positive"},
+		{name: "since", extra: "since: 1000h", wantErr: "since must be positive and at most"},
+		{name: "mode", extra: "mode: foo", wantErr: "unsupported mode foo for auth0 datasource"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Source{}
+			cfg := sourcetest.MergeConfig(t, base, tc.extra)
+			err := s.Configure(t.Context(), []byte(cfg), log.WithField("type", ModuleName), metrics.AcquisitionMetricsLevelNone)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestOneShot(t *testing.T) {
+	fake, apiURL := newFakeAPI(t)
+	// the first 2 events are too old
+	fake.addEvents(time.Now().Add(-3*time.Hour), 2)
+	fake.addEvents(time.Now().Add(-time.Hour), 5)
+
+	s := newTestSource(t, apiURL, "mode: cat\nsince: 2h\npage_size: 2\n")
+
+	events := sourcetest.OneShot(t, s)
+	require.Len(t, events, 5)
+
+	assert.Equal(t, ModuleName, events[0].Line.Module)
+	assert.Equal(t, domain, events[0].Line.Src)
+	assert.Equal(t, fake.dates[2].Truncate(time.Millisecond), events[0].Line.Time)
+	assert.NotContains(t, events[0].Line.Raw, "\n")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(events[4].Line.Raw), &record))
+	assert.Equal(t, "log-6", record["log_id"])
+	assert.Equal(t, "fp", record["type"])
+
+	// a search, then the events after the last one
+	require.Len(t, fake.queries, 3)
+	assert.Contains(t, fake.queries[0], "sort=date%3A1")
+	assert.Equal(t, "from=log-3&take=2", fake.queries[1])
+	assert.Equal(t, "from=log-5&take=2", fake.queries[2])
+}
+
+func TestErrors(t *testing.T) {
+	_, apiURL := newFakeAPI(t)
+
+	s := newTestSource(t, apiURL, "client_secret: wrong\n")
+	err := s.Stream(t.Context(), make(chan pipeline.Event))
+	cstest.RequireErrorContains(t, err, "requesting access token: POST /oauth/token: 401 Unauthorized: Unauthorized")
+}
+
+func TestTokenRefresh(t *testing.T) {
+	fake, apiURL := newFakeAPI(t)
+	fake.revokeFirst = true
+	fake.addEvents(time.Now().Add(-time.Hour), 3)
+
+	s := newTestSource(t, apiURL, "mode: cat\npage_size: 2\n")
+
+	assert.Len(t, sourcetest.OneShot(t, s), 3)
+
+	// the first token was rejected after its first use, a new one was requested
+	assert.Equal(t, 2, fake.tokens)
+}
+
+func TestStreamCursor(t *testing.T) {
+	fake, apiURL := newFakeAPI(t)
+	cursorFile := filepath.Join(t.TempDir(), "auth0.cursor")
+
+	// without cursor, the events older than since are skipped
+	fake.addEvents(time.Now().Add(-2*time.Hour), 2)
+	fake.addEvents(time.Now().Add(-time.Hour), 1)
+
+	s := newTestSource(t, apiURL, "poll_interval: 50ms\nsince: 90m\ncursor_file: "+cursorFile+"\n")
+	events := sourcetest.Stream(t, s, 1)
+	assert.Contains(t, events[0].Line.Raw, `"log_id":"log-2"`)
+
+	saved, err := os.ReadFile(cursorFile)
+	require.NoError(t, err)
+	assert.Equal(t, "log-2\n", string(saved))
+
+	// the events created while stopped are read after a restart, and only them
+	fake.addEvents(time.Now(), 2)
+
+	s = newTestSource(t, apiURL, "poll_interval: 50ms\ncursor_file: "+cursorFile+"\n")
+	events = sourcetest.Stream(t, s, 2)
+	assert.Contains(t, events[0].Line.Raw, `"log_id":"log-3"`)
+	assert.Contains(t, events[1].Line.Raw, `"log_id":"log-4"`)
+}
@@ -0,0 +1,150 @@
+package auth0acquisition
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/apipoll"
+)
+
+const logsPath = "/api/v2/logs"
+
+// logClient is a minimal client for the logs of the Auth0 Management API, authenticated with the
+// client credentials of a machine to machine application.
+type logClient struct {
+	tokenURL     string
+	logsURL      string
+	clientID     string
+	clientSecret string
+	audience     string
+	api          *apipoll.Client
+}
+
+// apiErrorReason returns the reason of a failed request given by the API,
+// i.e. {"statusCode": 403, "error": "Forbidden", "message": "Insufficient scope, expected any of: read:logs", "errorCode": "insufficient_scope"},
+// or by the token endpoint, i.e. {"error": "access_denied", "error_description": "Unauthorized"}
+func apiErrorReason(body []byte) string {
+	var apiErr struct {
+		Message     string `json:"message"`
+		ErrorCode   string `json:"errorCode"`
+		Description string `json:"error_description"`
+	}
+
+	if err := json.Unmarshal(body, &apiErr); err != nil {
+		return ""
+	}
+
+	switch {
+	case apiErr.Message != "" && apiErr.ErrorCode != "":
+		return fmt.Sprintf("%s (%s)", apiErr.Message, apiErr.ErrorCode)
+	case apiErr.Message != "":
+		return apiErr.Message
+	default:
+		return apiErr.Description
+	}
+}
+
+func newLogClient(cfg *Configuration) *logClient {
+	c := &logClient{
+		tokenURL:     cfg.APIURL + "/oauth/token",
+		logsURL:      cfg.APIURL + logsPath,
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		audience:     cfg.Audience,
+		api: &apipoll.Client{
+			HTTP: &http.Client{
+				Timeout: 60 * time.Second,
+			},
+			Header:         http.Header{"Accept": {"application/json"}},
+			RateLimitReset: "X-RateLimit-Reset",
+			Reason:         apiErrorReason,
+		},
+	}
+
+	c.api.Token = &apipoll.TokenSource{Request: c.requestToken}
+
+	return c
+}
+
+// requestToken requests an access token with the client credentials.
+func (c *logClient) requestToken(ctx context.Context) (string, time.Duration, error) {
+	body, err := json.Marshal(map[string]string{
+		"grant_type":    "client_credentials",
+		"client_id":     c.clientID,
+		"client_secret": c.clientSecret,
+		"audience":      c.audience,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL, bytes.NewReader(body))
+	if err != nil {
+		return "", 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.api.HTTP.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("requesting access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("requesting access token: %w", c.api.ResponseError(http.MethodPost, "/oauth/token", resp))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", 0, fmt.Errorf("requesting access token: decoding response: %w", err)
+	}
+
+	return token.AccessToken, time.Duration(token.ExpiresIn) * time.Second, nil
+}
+
+// logs returns the log events of a query.
+func (c *logClient) logs(ctx context.Context, query url.Values) ([]json.RawMessage, error) {
+	resp, err := c.api.Get(ctx, c.logsURL+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var events []json.RawMessage
+
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		return nil, fmt.Errorf("GET %s: decoding response: %w", logsPath, err)
+	}
+
+	return events, nil
+}
+
+// since returns the first page of the log events of a time and after, in ascending order. The
+// search is limited to 1000 results: the next ones are read from the last one, with after.
+func (c *logClient) since(ctx context.Context, t time.Time, pageSize int) ([]json.RawMessage, error) {
+	return c.logs(ctx, url.Values{
+		"q":        {"date:[" + t.UTC().Format("2006-01-02T15:04:05.000Z") + " TO *]"},
+		"sort":     {"date:1"},
+		"page":     {"0"},
+		"per_page": {strconv.Itoa(pageSize)},
+	})
+}
+
+// after returns the log events that follow a log event (checkpoint pagination), in ascending order.
+func (c *logClient) after(ctx context.Context, logID string, pageSize int) ([]json.RawMessage, error) {
+	return c.logs(ctx, url.Values{
+		"from": {logID},
+		"take": {strconv.Itoa(pageSize)},
+	})
+}
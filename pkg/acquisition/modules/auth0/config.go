@@ -0,0 +1,154 @@
+package auth0acquisition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+const (
+	defaultPollInterval = time.Minute
+	defaultPageSize     = 100
+
+	// the longest retention of the logs, depending on the plan
+	maxSince = 30 * 24 * time.Hour
+)
+
+type Configuration struct {
+	configuration.DataSourceCommonCfg `yaml:",inline"`
+
+	Domain string `yaml:"domain"` // of the tenant, i.e. example.eu.auth0.com
+	// machine to machine application, with the read:logs scope on the Management API
+	ClientID     string        `yaml:"client_id"`
+	ClientSecret string        `yaml:"client_secret"`
+	Audience     string        `yaml:"audience"` // https://<domain>/api/v2/ by default
+	APIURL       string        `yaml:"api_url"`  // https://<domain> by default
+	PageSize     int           `yaml:"page_size"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	Since        time.Duration `yaml:"since"` // only used in cat mode, and in tail mode without cursor
+	// the id of the last log event read is saved in this file, to resume after a restart
+	CursorFile string `yaml:"cursor_file"`
+}
+
+func ConfigurationFromYAML(y []byte) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.UnmarshalWithOptions(y, &cfg, yaml.Strict()); err != nil {
+		return cfg, fmt.Errorf("cannot parse: %s", yaml.FormatError(err, false, false))
+	}
+
+	cfg.SetDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Configuration) SetDefaults() {
+	if c.Mode == "" {
+		c.Mode = configuration.TAIL_MODE
+	}
+
+	if c.Audience == "" && c.Domain != "" {
+		c.Audience = "https://" + c.Domain + "/api/v2/"
+	}
+
+	if c.APIURL == "" && c.Domain != "" {
+		c.APIURL = "https://" + c.Domain
+	}
+
+	if c.PageSize == 0 {
+		c.PageSize = defaultPageSize
+	}
+
+	if c.PollInterval == 0 {
+		c.PollInterval = defaultPollInterval
+	}
+
+	if c.Since == 0 && c.Mode == configuration.CAT_MODE {
+		c.Since = 24 * time.Hour
+	}
+
+	c.APIURL = strings.TrimSuffix(c.APIURL, "/")
+}
+
+func (c *Configuration) Validate() error {
+	if c.Domain == "" {
+		return errors.New("domain is required")
+	}
+
+	if strings.Contains(c.Domain, "/") {
+		return fmt.Errorf("invalid domain '%s': must be a host name, use api_url for a custom url", c.Domain)
+	}
+
+	if c.ClientID == "" {
+		return errors.New("client_id is required")
+	}
+
+	if c.ClientSecret == "" {
+		return errors.New("client_secret is required")
+	}
+
+	u, err := url.Parse(c.APIURL)
+	if err != nil {
+		return fmt.Errorf("invalid api_url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid api_url scheme '%s': must be http or https", u.Scheme)
+	}
+
+	if c.PageSize < 0 || c.PageSize > defaultPageSize {
+		return fmt.Errorf("page_size must be between 1 and %d", defaultPageSize)
+	}
+
+	if c.PollInterval < 0 {
+		return errors.New("poll_interval must be positive")
+	}
+
+	if c.Since < 0 || c.Since > maxSince {
+		return fmt.Errorf("since must be positive and at most %s", maxSince)
+	}
+
+	switch c.Mode {
+	case configuration.TAIL_MODE, configuration.CAT_MODE:
+	default:
+		return fmt.Errorf("unsupported mode %s for auth0 datasource", c.Mode)
+	}
+
+	return nil
+}
+
+func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
+	cfg, err := ConfigurationFromYAML(yamlConfig)
+	if err != nil {
+		return err
+	}
+
+	s.config = cfg
+	s.src = s.config.Domain
+
+	return nil
+}
+
+func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
+	if err := s.UnmarshalConfig(yamlConfig); err != nil {
+		return err
+	}
+
+	s.logger = logger.WithField("domain", s.src)
+	s.metricsLevel = metricsLevel
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package auth0acquisition
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/apipoll"
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/cursor"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// sendEvent sends a log event, and returns its id.
+func (s *Source) sendEvent(event json.RawMessage, out chan pipeline.Event) string {
+	var meta struct {
+		LogID string `json:"log_id"`
+		ID    string `json:"_id"`
+		Date  string `json:"date"`
+	}
+
+	if err := json.Unmarshal(event, &meta); err != nil {
+		s.logger.Errorf("unable to read log event: %s", err)
+		return ""
+	}
+
+	if meta.LogID == "" {
+		meta.LogID = meta.ID
+	}
+
+	evtTime, err := time.Parse(time.RFC3339Nano, meta.Date)
+	if err != nil {
+		s.logger.Warningf("log event %s: invalid date %q", meta.LogID, meta.Date)
+		evtTime = time.Now()
+	}
+
+	// one event per line
+	var raw bytes.Buffer
+
+	if err := json.Compact(&raw, event); err != nil {
+		s.logger.Errorf("unable to read log event %s: %s", meta.LogID, err)
+		return meta.LogID
+	}
+
+	if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+		metrics.Auth0DataSourceEventsRead.With(prometheus.Labels{
+			"source":          s.src,
+			"datasource_type": ModuleName,
+			"acquis_type":     s.config.Labels["type"],
+		}).Inc()
+	}
+
+	line := pipeline.Line{
+		Raw:     raw.String(),
+		Src:     s.src,
+		Time:    evtTime.UTC(),
+		Labels:  s.config.Labels,
+		Process: true,
+		Module:  s.GetName(),
+	}
+
+	evt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+	evt.Line = line
+
+	out <- evt
+
+	return meta.LogID
+}
+
+// fetch returns the log events after a log event or, without one, since a given time. It waits
+// for the reset of the rate limit if needed.
+func (s *Source) fetch(ctx context.Context, client *logClient, logID string, since time.Time) ([]json.RawMessage, error) {
+	var events []json.RawMessage
+
+	err := apipoll.WaitRateLimit(ctx, s.logger, func() error {
+		var err error
+
+		if logID != "" {
+			events, err = client.after(ctx, logID, s.config.PageSize)
+		} else {
+			events, err = client.since(ctx, since, s.config.PageSize)
+		}
+
+		return err
+	})
+
+	return events, err
+}
+
+// readLogs reads the log events after logID or, if it's empty, since a given time. If follow is
+// true, it keeps polling for new events until ctx is canceled.
+func (s *Source) readLogs(ctx context.Context, logID string, since time.Time, follow bool, cur *cursor.File, out chan pipeline.Event) error {
+	client := newLogClient(&s.config)
+
+	return apipoll.Poll(ctx, s.logger, s.config.PollInterval, follow, func(ctx context.Context) (bool, error) {
+		events, err := s.fetch(ctx, client, logID, since)
+		if err != nil {
+			return false, err
+		}
+
+		for _, event := range events {
+			if id := s.sendEvent(event, out); id != "" {
+				logID = id
+			}
+		}
+
+		if len(events) > 0 {
+			if err := cur.Save(logID); err != nil {
+				s.logger.Error(err)
+			}
+		}
+
+		// a full page: there are probably more events already
+		return len(events) >= s.config.PageSize, nil
+	})
+}
+
+func (s *Source) OneShot(ctx context.Context, out chan pipeline.Event) error {
+	since := time.Now().Add(-s.config.Since)
+
+	s.logger.Infof("Reading the logs since %s", since.UTC())
+
+	err := s.readLogs(ctx, "", since, false, nil, out)
+	s.logger.Debug("Oneshot acquisition is done")
+
+	return err
+}
+
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	cur := cursor.New(s.config.CursorFile)
+
+	logID, err := cur.Load()
+	if err != nil {
+		return err
+	}
+
+	since := time.Now().Add(-s.config.Since)
+
+	if logID != "" {
+		s.logger.Infof("Resuming the logs after %s", logID)
+	} else {
+		s.logger.Infof("Reading the logs since %s", since.UTC())
+	}
+
+	return s.readLogs(ctx, logID, since, true, cur, out)
+}
@@ -0,0 +1,5 @@
+//go:build !no_datasource_dockerevents
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/dockerevents" // register the datasource
@@ -0,0 +1,144 @@
+package dockereventsacquisition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+const (
+	RuntimeDocker     = "docker"
+	RuntimeContainerd = "containerd"
+
+	defaultContainerdAddress = "/run/containerd/containerd.sock"
+	defaultCtrPath           = "ctr"
+)
+
+type Configuration struct {
+	configuration.DataSourceCommonCfg `yaml:",inline"`
+
+	Runtime string `yaml:"runtime"` // docker or containerd
+	// docker
+	DockerHost string `yaml:"docker_host"` // from the environment if empty, like the docker CLI
+	// read the privileges and mounts of the containers, on create, start and exec
+	Inspect *bool `yaml:"inspect"`
+	// containerd, with the "ctr events" command
+	ContainerdAddress string `yaml:"containerd_address"`
+	CtrPath           string `yaml:"ctr_path"`
+	// type of the objects, all of them if empty
+	EventTypes []string `yaml:"event_types"`
+	// like "create", "start" or "exec_start", all of them if empty
+	Actions []string `yaml:"actions"`
+}
+
+func ConfigurationFromYAML(y []byte) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.UnmarshalWithOptions(y, &cfg, yaml.Strict()); err != nil {
+		return cfg, fmt.Errorf("cannot parse: %s", yaml.FormatError(err, false, false))
+	}
+
+	cfg.SetDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Configuration) SetDefaults() {
+	if c.Mode == "" {
+		c.Mode = configuration.TAIL_MODE
+	}
+
+	if c.Runtime == "" {
+		c.Runtime = RuntimeDocker
+	}
+
+	if c.Inspect == nil {
+		c.Inspect = new(true)
+	}
+
+	if c.Runtime == RuntimeContainerd {
+		if c.ContainerdAddress == "" {
+			c.ContainerdAddress = defaultContainerdAddress
+		}
+
+		if c.CtrPath == "" {
+			c.CtrPath = defaultCtrPath
+		}
+	}
+}
+
+func (c *Configuration) Validate() error {
+	switch c.Runtime {
+	case RuntimeDocker:
+		if c.ContainerdAddress != "" || c.CtrPath != "" {
+			return errors.New("containerd_address and ctr_path can only be used with the containerd runtime")
+		}
+	case RuntimeContainerd:
+		if c.DockerHost != "" {
+			return errors.New("docker_host can only be used with the docker runtime")
+		}
+	default:
+		return fmt.Errorf("unknown runtime %q, must be docker or containerd", c.Runtime)
+	}
+
+	for _, t := range c.EventTypes {
+		if t == "" {
+			return errors.New("event_types: empty type")
+		}
+	}
+
+	for _, a := range c.Actions {
+		if a == "" {
+			return errors.New("actions: empty action")
+		}
+	}
+
+	// the past events are not kept by containerd, and only for a while by docker
+	if c.Mode != configuration.TAIL_MODE {
+		return fmt.Errorf("unsupported mode %s for docker-events datasource", c.Mode)
+	}
+
+	return nil
+}
+
+func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
+	cfg, err := ConfigurationFromYAML(yamlConfig)
+	if err != nil {
+		return err
+	}
+
+	s.config = cfg
+
+	switch cfg.Runtime {
+	case RuntimeContainerd:
+		s.src = cfg.ContainerdAddress
+	default:
+		s.src = cfg.DockerHost
+		if s.src == "" {
+			s.src = RuntimeDocker
+		}
+	}
+
+	return nil
+}
+
+func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
+	if err := s.UnmarshalConfig(yamlConfig); err != nil {
+		return err
+	}
+
+	s.logger = logger.WithField("src", s.src)
+	s.metricsLevel = metricsLevel
+
+	return nil
+}
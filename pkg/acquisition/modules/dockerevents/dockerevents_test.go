@@ -0,0 +1,391 @@
+package dockereventsacquisition
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/events"
+	"github.com/moby/moby/client"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+type fakeDocker struct {
+	messages   chan events.Message
+	errs       chan error
+	containers map[string]container.InspectResponse
+	inspects   int
+	since      string
+}
+
+func newFakeDocker() *fakeDocker {
+	return &fakeDocker{
+		messages:   make(chan events.Message),
+		errs:       make(chan error, 1),
+		containers: map[string]container.InspectResponse{},
+	}
+}
+
+func (f *fakeDocker) Events(_ context.Context, options client.EventsListOptions) client.EventsResult {
+	f.since = options.Since
+	return client.EventsResult{Messages: f.messages, Err: f.errs}
+}
+
+func (f *fakeDocker) ContainerInspect(_ context.Context, id string, _ client.ContainerInspectOptions) (client.ContainerInspectResult, error) {
+	f.inspects++
+
+	c, ok := f.containers[id]
+	if !ok {
+		return client.ContainerInspectResult{}, errors.New("no such container")
+	}
+
+	return client.ContainerInspectResult{Container: c}, nil
+}
+
+func newSource(t *testing.T, cfg string) *Source {
+	t.Helper()
+
+	s := &Source{}
+	err := s.Configure(t.Context(), []byte(cfg), log.WithField("type", ModuleName), metrics.AcquisitionMetricsLevelNone)
+	require.NoError(t, err)
+
+	return s
+}
+
+func readEvent(t *testing.T, out chan pipeline.Event) containerEvent {
+	t.Helper()
+
+	select {
+	case evt := <-out:
+		assert.Equal(t, ModuleName, evt.Line.Module)
+		assert.Equal(t, "docker-events", evt.Line.Labels["type"])
+
+		var ret containerEvent
+		require.NoError(t, json.Unmarshal([]byte(evt.Line.Raw), &ret))
+
+		return ret
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for an event")
+	}
+
+	return containerEvent{}
+}
+
+func TestConfigure(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		expectedErr string
+	}{
+		{
+			name:   "defaults",
+			config: "source: docker-events\nlabels:\n  type: docker-events",
+		},
+		{
+			name:   "containerd",
+			config: "source: docker-events\nruntime: containerd\nevent_types: [container]",
+		},
+		{
+			name:        "unknown runtime",
+			config:      "source: docker-events\nruntime: podman",
+			expectedErr: `unknown runtime "podman", must be docker or containerd`,
+		},
+		{
+			name:        "docker with ctr",
+			config:      "source: docker-events\nctr_path: /usr/bin/ctr",
+			expectedErr: "containerd_address and ctr_path can only be used with the containerd runtime",
+		},
+		{
+			name:        "containerd with docker_host",
+			config:      "source: docker-events\nruntime: containerd\ndocker_host: unix:///var/run/docker.sock",
+			expectedErr: "docker_host can only be used with the docker runtime",
+		},
+		{
+			name:        "empty action",
+			config:      "source: docker-events\nactions: ['']",
+			expectedErr: "actions: empty action",
+		},
+		{
+			name:        "cat mode",
+			config:      "source: docker-events\nmode: cat",
+			expectedErr: "unsupported mode cat for docker-events datasource",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Source{}
+			err := s.Configure(t.Context(), []byte(tc.config), log.WithField("type", ModuleName), metrics.AcquisitionMetricsLevelNone)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+
+	s := newSource(t, "source: docker-events\nruntime: containerd")
+	assert.Equal(t, "/run/containerd/containerd.sock", s.src)
+	assert.Equal(t, "ctr", s.config.CtrPath)
+	assert.True(t, *s.config.Inspect)
+}
+
+func TestNewDockerEvent(t *testing.T) {
+	evt := newDockerEvent(events.Message{
+		Type:   events.ContainerEventType,
+		Action: "exec_start: sh -c id",
+		Actor: events.Actor{
+			ID:         "c0ffee",
+			Attributes: map[string]string{"name": "web", "image": "nginx:1.27", "execID": "e1"},
+		},
+		TimeNano: time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC).UnixNano(),
+	})
+
+	assert.Equal(t, "exec_start", evt.Action)
+	assert.Equal(t, "sh -c id", evt.Command)
+	assert.Equal(t, "c0ffee", evt.ContainerID)
+	assert.Equal(t, "web", evt.ContainerName)
+	assert.Equal(t, "nginx:1.27", evt.Image)
+	assert.Equal(t, "e1", evt.ExecID)
+	assert.Equal(t, time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC), evt.Time)
+	assert.True(t, evt.inspected())
+
+	evt = newDockerEvent(events.Message{
+		Type:   events.ContainerEventType,
+		Action: events.ActionHealthStatusUnhealthy,
+		Actor:  events.Actor{ID: "c0ffee"},
+		Time:   1700000000,
+	})
+
+	assert.Equal(t, "health_status", evt.Action)
+	assert.Equal(t, "unhealthy", evt.Status)
+	assert.Empty(t, evt.Command)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), evt.Time)
+	assert.False(t, evt.inspected())
+
+	evt = newDockerEvent(events.Message{
+		Type:   events.VolumeEventType,
+		Action: events.ActionMount,
+		Actor:  events.Actor{ID: "data", Attributes: map[string]string{"container": "c0ffee", "destination": "/host"}},
+	})
+
+	assert.Equal(t, "volume", evt.Type)
+	assert.Equal(t, "c0ffee", evt.ContainerID)
+	assert.Equal(t, "/host", evt.Attributes["destination"])
+}
+
+func TestParseCtrLine(t *testing.T) {
+	tests := []struct {
+		name        string
+		line        string
+		expected    containerEvent
+		expectedErr string
+	}{
+		{
+			name: "exec added",
+			line: `2026-01-02 03:04:05.123456789 +0000 UTC k8s.io /tasks/exec-added {"container_id":"c0ffee","exec_id":"e1"}`,
+			expected: containerEvent{
+				Runtime:     "containerd",
+				Time:        time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.UTC),
+				Namespace:   "k8s.io",
+				Type:        "container",
+				Action:      "exec_create",
+				ID:          "c0ffee",
+				ContainerID: "c0ffee",
+				ExecID:      "e1",
+			},
+		},
+		{
+			name: "container create",
+			line: `2026-01-02 03:04:05 +0100 CET default /containers/create {"id":"c0ffee","image":"docker.io/library/nginx:latest","runtime":{"name":"io.containerd.runc.v2"}}`,
+			expected: containerEvent{
+				Runtime:     "containerd",
+				Time:        time.Date(2026, 1, 2, 2, 4, 5, 0, time.UTC),
+				Namespace:   "default",
+				Type:        "container",
+				Action:      "create",
+				ID:          "c0ffee",
+				ContainerID: "c0ffee",
+				Image:       "docker.io/library/nginx:latest",
+			},
+		},
+		{
+			name: "task exit",
+			line: `2026-01-02 03:04:05 +0000 UTC default /tasks/exit {"container_id":"c0ffee","id":"c0ffee","pid":42,"exit_status":137}`,
+			expected: containerEvent{
+				Runtime:     "containerd",
+				Time:        time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+				Namespace:   "default",
+				Type:        "container",
+				Action:      "die",
+				ID:          "c0ffee",
+				ContainerID: "c0ffee",
+				ExitCode:    "137",
+			},
+		},
+		{
+			name: "image",
+			line: `2026-01-02 03:04:05 +0000 UTC default /images/delete {"name":"docker.io/library/nginx:latest"}`,
+			expected: containerEvent{
+				Runtime:   "containerd",
+				Time:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+				Namespace: "default",
+				Type:      "image",
+				Action:    "delete",
+				ID:        "docker.io/library/nginx:latest",
+			},
+		},
+		{
+			name:        "short",
+			line:        `2026-01-02 03:04:05 +0000 UTC`,
+			expectedErr: "not enough fields",
+		},
+		{
+			name:        "bad time",
+			line:        `yesterday at noon or so default /tasks/start {}`,
+			expectedErr: "bad time",
+		},
+		{
+			name:        "bad event",
+			line:        `2026-01-02 03:04:05 +0000 UTC default /tasks/start {`,
+			expectedErr: "bad event",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			evt, err := parseCtrLine(tc.line)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, evt)
+		})
+	}
+}
+
+func TestStreamDocker(t *testing.T) {
+	s := newSource(t, `
+source: docker-events
+event_types: [container]
+labels:
+  type: docker-events`)
+
+	docker := newFakeDocker()
+	docker.containers["c0ffee"] = container.InspectResponse{
+		Name:   "/web",
+		Config: &container.Config{User: "root"},
+		HostConfig: &container.HostConfig{
+			Privileged:  true,
+			NetworkMode: "host",
+			CapAdd:      []string{"SYS_ADMIN"},
+		},
+		Mounts: []container.MountPoint{{Type: "bind", Source: "/", Destination: "/host", RW: true}},
+	}
+
+	out := make(chan pipeline.Event)
+	done := make(chan error)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	go func() {
+		done <- s.streamDocker(ctx, docker, out)
+	}()
+
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	docker.messages <- events.Message{Type: events.ContainerEventType, Action: events.ActionCreate, Actor: events.Actor{ID: "c0ffee"}, TimeNano: ts.UnixNano()}
+
+	evt := readEvent(t, out)
+	assert.Equal(t, "create", evt.Action)
+	assert.Equal(t, "web", evt.ContainerName)
+	assert.True(t, evt.Privileged)
+	assert.Equal(t, "host", evt.NetworkMode)
+	assert.Equal(t, []string{"SYS_ADMIN"}, evt.CapAdd)
+	assert.Equal(t, []mountPoint{{Type: "bind", Source: "/", Destination: "/host", RW: true}}, evt.Mounts)
+
+	// not selected
+	docker.messages <- events.Message{Type: events.NetworkEventType, Action: events.ActionConnect, Actor: events.Actor{ID: "bridge"}, TimeNano: ts.UnixNano()}
+
+	// the inspection is cached
+	docker.messages <- events.Message{Type: events.ContainerEventType, Action: "exec_start: bash", Actor: events.Actor{ID: "c0ffee"}, TimeNano: ts.UnixNano()}
+
+	evt = readEvent(t, out)
+	assert.Equal(t, "exec_start", evt.Action)
+	assert.Equal(t, "bash", evt.Command)
+	assert.Equal(t, "root", evt.User)
+	assert.True(t, evt.Privileged)
+	assert.Equal(t, 1, docker.inspects)
+
+	// a container that can't be inspected
+	docker.messages <- events.Message{Type: events.ContainerEventType, Action: events.ActionStart, Actor: events.Actor{ID: "gone"}, TimeNano: ts.UnixNano()}
+
+	evt = readEvent(t, out)
+	assert.Equal(t, "start", evt.Action)
+	assert.False(t, evt.Privileged)
+
+	docker.errs <- io.EOF
+
+	cstest.RequireErrorContains(t, <-done, "the docker event stream was closed")
+
+	// restarted after the last event
+	go func() {
+		done <- s.streamDocker(ctx, docker, out)
+	}()
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, "1767323045.000000001", docker.since)
+}
+
+func TestStreamContainerd(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no shell script on windows")
+	}
+
+	dir := t.TempDir()
+	ctr := filepath.Join(dir, "ctr")
+
+	script := `#!/bin/sh
+echo '2026-01-02 03:04:05 +0000 UTC default /tasks/exec-added {"container_id":"c0ffee","exec_id":"e1"}'
+echo 'garbage'
+echo '2026-01-02 03:04:06 +0000 UTC default /snapshot/prepare {"key":"k","parent":"p"}'
+echo '2026-01-02 03:04:07 +0000 UTC default /tasks/exit {"container_id":"c0ffee","exit_status":1}'
+`
+	require.NoError(t, os.WriteFile(ctr, []byte(script), 0o755))
+
+	s := newSource(t, `
+source: docker-events
+runtime: containerd
+ctr_path: `+ctr+`
+event_types: [container]
+labels:
+  type: docker-events`)
+
+	out := make(chan pipeline.Event, 10)
+
+	err := s.Stream(t.Context(), out)
+	cstest.RequireErrorContains(t, err, "ctr exited")
+	require.Len(t, out, 2)
+
+	evt := readEvent(t, out)
+	assert.Equal(t, "exec_create", evt.Action)
+	assert.Equal(t, "e1", evt.ExecID)
+
+	evt = readEvent(t, out)
+	assert.Equal(t, "die", evt.Action)
+	assert.Equal(t, "1", evt.ExitCode)
+}
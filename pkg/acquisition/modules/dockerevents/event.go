@@ -0,0 +1,233 @@
+package dockereventsacquisition
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/events"
+)
+
+// containerEvent is the JSON document sent to the parsers.
+type containerEvent struct {
+	Runtime       string            `json:"runtime"`
+	Time          time.Time         `json:"time"`
+	Namespace     string            `json:"namespace,omitempty"` // containerd
+	Type          string            `json:"type"`                // container, image, volume, network...
+	Action        string            `json:"action"`
+	ID            string            `json:"id,omitempty"` // of the object
+	ContainerID   string            `json:"container_id,omitempty"`
+	ContainerName string            `json:"container_name,omitempty"`
+	Image         string            `json:"image,omitempty"`
+	ExecID        string            `json:"exec_id,omitempty"`
+	Command       string            `json:"command,omitempty"` // of an exec
+	Status        string            `json:"status,omitempty"`  // of a health check
+	ExitCode      string            `json:"exit_code,omitempty"`
+	Privileged    bool              `json:"privileged,omitempty"`
+	User          string            `json:"user,omitempty"`
+	NetworkMode   string            `json:"network_mode,omitempty"`
+	PidMode       string            `json:"pid_mode,omitempty"`
+	CapAdd        []string          `json:"cap_add,omitempty"`
+	Mounts        []mountPoint      `json:"mounts,omitempty"`
+	Attributes    map[string]string `json:"attributes,omitempty"`
+}
+
+type mountPoint struct {
+	Type        string `json:"type,omitempty"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	RW          bool   `json:"rw"`
+}
+
+// newDockerEvent converts a message of the docker event stream. The details of an action, like the
+// command of an exec, are after a colon: "exec_start: sh -c id".
+func newDockerEvent(msg events.Message) containerEvent {
+	action, detail, _ := strings.Cut(string(msg.Action), ": ")
+
+	evt := containerEvent{
+		Runtime:    RuntimeDocker,
+		Time:       time.Now().UTC(),
+		Type:       string(msg.Type),
+		Action:     action,
+		ID:         msg.Actor.ID,
+		Attributes: msg.Actor.Attributes,
+		ExecID:     msg.Actor.Attributes["execID"],
+		ExitCode:   msg.Actor.Attributes["exitCode"],
+	}
+
+	switch {
+	case msg.TimeNano != 0:
+		evt.Time = time.Unix(0, msg.TimeNano).UTC()
+	case msg.Time != 0:
+		evt.Time = time.Unix(msg.Time, 0).UTC()
+	}
+
+	switch {
+	case strings.HasPrefix(action, "exec_"):
+		evt.Command = detail
+	case detail != "":
+		evt.Status = detail
+	}
+
+	switch msg.Type {
+	case events.ContainerEventType:
+		evt.ContainerID = msg.Actor.ID
+		evt.ContainerName = msg.Actor.Attributes["name"]
+		evt.Image = msg.Actor.Attributes["image"]
+	case events.VolumeEventType, events.NetworkEventType:
+		// mount, connect...
+		evt.ContainerID = msg.Actor.Attributes["container"]
+	}
+
+	return evt
+}
+
+// inspected returns true if the privileges of the container are read for the action.
+func (e *containerEvent) inspected() bool {
+	if e.Type != string(events.ContainerEventType) {
+		return false
+	}
+
+	return e.Action == "create" || e.Action == "start" || strings.HasPrefix(e.Action, "exec_")
+}
+
+// addInspect adds the privileges and the mounts of the container.
+func (e *containerEvent) addInspect(c *container.InspectResponse) {
+	if e.ContainerName == "" {
+		e.ContainerName = strings.TrimPrefix(c.Name, "/")
+	}
+
+	if c.Config != nil {
+		e.User = c.Config.User
+
+		if e.Image == "" {
+			e.Image = c.Config.Image
+		}
+	}
+
+	if c.HostConfig != nil {
+		e.Privileged = c.HostConfig.Privileged
+		e.NetworkMode = string(c.HostConfig.NetworkMode)
+		e.PidMode = string(c.HostConfig.PidMode)
+		e.CapAdd = c.HostConfig.CapAdd
+	}
+
+	for _, m := range c.Mounts {
+		e.Mounts = append(e.Mounts, mountPoint{
+			Type:        string(m.Type),
+			Source:      m.Source,
+			Destination: m.Destination,
+			RW:          m.RW,
+		})
+	}
+}
+
+// containerd topics, and the docker actions they match
+var containerdActions = map[string]string{
+	"/containers/create":  "create",
+	"/containers/update":  "update",
+	"/containers/delete":  "destroy",
+	"/tasks/create":       "task_create",
+	"/tasks/start":        "start",
+	"/tasks/exit":         "die",
+	"/tasks/delete":       "task_delete",
+	"/tasks/oom":          "oom",
+	"/tasks/paused":       "pause",
+	"/tasks/resumed":      "unpause",
+	"/tasks/exec-added":   "exec_create",
+	"/tasks/exec-started": "exec_start",
+}
+
+type containerdPayload struct {
+	ID          string `json:"id"`
+	ContainerID string `json:"container_id"`
+	ExecID      string `json:"exec_id"`
+	Image       string `json:"image"`
+	Name        string `json:"name"`
+	ExitStatus  *int   `json:"exit_status"`
+}
+
+// ctr prints the time with time.Time.String()
+const ctrTimeLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+// parseCtrLine parses a line of "ctr events": the time, the namespace, the topic and the event.
+// There is no command, nor privileges, in the events of containerd.
+func parseCtrLine(line string) (containerEvent, error) {
+	fields := strings.SplitN(strings.TrimSpace(line), " ", 7)
+	if len(fields) < 6 {
+		return containerEvent{}, errors.New("not enough fields")
+	}
+
+	ts, err := time.Parse(ctrTimeLayout, strings.Join(fields[:4], " "))
+	if err != nil {
+		return containerEvent{}, fmt.Errorf("bad time: %w", err)
+	}
+
+	topic := fields[5]
+
+	evt := containerEvent{
+		Runtime:   RuntimeContainerd,
+		Time:      ts.UTC(),
+		Namespace: fields[4],
+		Action:    containerdActions[topic],
+	}
+
+	objects, action, _ := strings.Cut(strings.TrimPrefix(topic, "/"), "/")
+
+	switch objects {
+	case "containers", "tasks":
+		evt.Type = string(events.ContainerEventType)
+	default:
+		evt.Type = strings.TrimSuffix(objects, "s")
+	}
+
+	if evt.Action == "" {
+		evt.Action = action
+	}
+
+	if len(fields) < 7 {
+		return evt, nil
+	}
+
+	var payload containerdPayload
+	if err := json.Unmarshal([]byte(fields[6]), &payload); err != nil {
+		return containerEvent{}, fmt.Errorf("bad event: %w", err)
+	}
+
+	evt.ExecID = payload.ExecID
+	evt.Image = payload.Image
+
+	if payload.ExitStatus != nil {
+		evt.ExitCode = fmt.Sprint(*payload.ExitStatus)
+	}
+
+	switch evt.Type {
+	case string(events.ContainerEventType):
+		evt.ContainerID = payload.ContainerID
+		if evt.ContainerID == "" {
+			evt.ContainerID = payload.ID
+		}
+
+		evt.ID = evt.ContainerID
+	default:
+		evt.ID = payload.ID
+		if evt.ID == "" {
+			evt.ID = payload.Name
+		}
+	}
+
+	return evt, nil
+}
+
+// selected returns true if the event is of the configured types and actions.
+func (c *Configuration) selected(e containerEvent) bool {
+	if len(c.EventTypes) > 0 && !slices.Contains(c.EventTypes, e.Type) {
+		return false
+	}
+
+	return len(c.Actions) == 0 || slices.Contains(c.Actions, e.Action)
+}
@@ -0,0 +1,238 @@
+package dockereventsacquisition
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/moby/moby/api/types/container"
+	"github.com/moby/moby/api/types/events"
+	"github.com/moby/moby/client"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// dockerAPI is the part of the docker client used by the datasource.
+type dockerAPI interface {
+	Events(ctx context.Context, options client.EventsListOptions) client.EventsResult
+	ContainerInspect(ctx context.Context, containerID string, options client.ContainerInspectOptions) (client.ContainerInspectResult, error)
+}
+
+// Stream reads the event stream of the runtime. The errors are returned, for the acquisition to
+// restart the datasource: with docker, the stream resumes after the last event that was read.
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	if s.config.Runtime == RuntimeContainerd {
+		return s.streamContainerd(ctx, out)
+	}
+
+	opts := []client.Opt{client.FromEnv}
+
+	if s.config.DockerHost != "" {
+		opts = append(opts, client.WithHost(s.config.DockerHost))
+	}
+
+	cli, err := client.New(opts...)
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	return s.streamDocker(ctx, cli, out)
+}
+
+func (s *Source) streamDocker(ctx context.Context, cli dockerAPI, out chan pipeline.Event) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	options := client.EventsListOptions{}
+
+	if !s.lastEvent.IsZero() {
+		since := s.lastEvent.Add(time.Nanosecond)
+		options.Since = fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond())
+	}
+
+	result := cli.Events(ctx, options)
+
+	s.logger.Info("Reading the docker events")
+
+	// the containers that were inspected, until they are destroyed
+	inspected := map[string]*container.InspectResponse{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Datasource stopping")
+			return nil
+		case err := <-result.Err:
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if errors.Is(err, io.EOF) {
+				return errors.New("the docker event stream was closed")
+			}
+
+			return fmt.Errorf("while reading the docker events: %w", err)
+		case msg := <-result.Messages:
+			evt := newDockerEvent(msg)
+			s.lastEvent = evt.Time
+
+			if *s.config.Inspect && evt.inspected() {
+				s.inspect(ctx, cli, &evt, inspected)
+			}
+
+			if msg.Type == events.ContainerEventType && msg.Action == events.ActionDestroy {
+				delete(inspected, msg.Actor.ID)
+			}
+
+			if !s.config.selected(evt) {
+				continue
+			}
+
+			if !s.sendEvent(ctx, evt, out) {
+				return nil
+			}
+		}
+	}
+}
+
+func (s *Source) inspect(ctx context.Context, cli dockerAPI, evt *containerEvent, cache map[string]*container.InspectResponse) {
+	c, ok := cache[evt.ContainerID]
+
+	// the configuration can't change after the start, but the create event comes first
+	if !ok || evt.Action == "start" {
+		res, err := cli.ContainerInspect(ctx, evt.ContainerID, client.ContainerInspectOptions{})
+		if err != nil {
+			// the container may be gone already
+			s.logger.Debugf("unable to inspect container %s: %s", evt.ContainerID, err)
+			return
+		}
+
+		c = &res.Container
+		cache[evt.ContainerID] = c
+	}
+
+	evt.addInspect(c)
+}
+
+// streamContainerd runs "ctr events", which reads the events of all the namespaces.
+func (s *Source) streamContainerd(ctx context.Context, out chan pipeline.Event) error {
+	// to stop the command if we stop reading its output
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, s.config.CtrPath, "--address", s.config.ContainerdAddress, "events")
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("could not get stdout: %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("could not get stderr: %w", err)
+	}
+
+	s.logger.WithField("command", cmd.String()).Info("Reading the containerd events")
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not start command: %w", err)
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Go(func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			s.logger.Warnf("ctr: %s", scanner.Text())
+		}
+	})
+
+	readErr := s.readCtrEvents(ctx, stdout, out)
+	if readErr != nil {
+		// nobody will read the output anymore
+		cancel()
+	}
+
+	wg.Wait()
+
+	cmdErr := cmd.Wait()
+
+	if readErr != nil {
+		return fmt.Errorf("while reading the containerd events: %w", readErr)
+	}
+
+	// if the context was canceled, the error is likely "signal: killed" and we ignore that
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	if cmdErr != nil {
+		return fmt.Errorf("ctr exited with error: %w", cmdErr)
+	}
+
+	return errors.New("ctr exited")
+}
+
+func (s *Source) readCtrEvents(ctx context.Context, r io.Reader, out chan pipeline.Event) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		evt, err := parseCtrLine(scanner.Text())
+		if err != nil {
+			s.logger.Warnf("unable to parse containerd event %q: %s", scanner.Text(), err)
+			continue
+		}
+
+		if !s.config.selected(evt) {
+			continue
+		}
+
+		if !s.sendEvent(ctx, evt, out) {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
+
+// sendEvent returns false if the datasource is stopping.
+func (s *Source) sendEvent(ctx context.Context, evt containerEvent, out chan pipeline.Event) bool {
+	raw, err := json.Marshal(evt)
+	if err != nil {
+		s.logger.Errorf("unable to serialize event: %s", err)
+		return true
+	}
+
+	if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+		metrics.DockerEventsDataSourceEventsRead.With(prometheus.Labels{"source": s.src, "datasource_type": ModuleName, "acquis_type": s.config.Labels["type"]}).Inc()
+	}
+
+	line := pipeline.Line{
+		Raw:     string(raw),
+		Src:     s.src,
+		Time:    evt.Time,
+		Labels:  s.config.Labels,
+		Process: true,
+		Module:  s.GetName(),
+	}
+
+	pevt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+	pevt.Line = line
+
+	select {
+	case <-ctx.Done():
+		return false
+	case out <- pevt:
+		return true
+	}
+}
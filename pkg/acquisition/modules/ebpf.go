@@ -0,0 +1,5 @@
+//go:build !no_datasource_ebpf
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/ebpf" // register the datasource
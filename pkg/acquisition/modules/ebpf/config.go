@@ -0,0 +1,106 @@
+package ebpfacquisition
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+const (
+	eventExec    = "exec"    // a process executed a program
+	eventConnect = "connect" // a process opened an outbound TCP connection
+
+	// in bytes, shared by all the events
+	defaultBufferSize = 256 * 1024
+	minBufferSize     = 4096
+)
+
+var eventTypes = []string{eventExec, eventConnect}
+
+type Configuration struct {
+	configuration.DataSourceCommonCfg `yaml:",inline"`
+
+	Events []string `yaml:"events"`
+	// size of the ring buffer between the kernel and crowdsec, a power of 2. The events are lost when it's full.
+	BufferSize int `yaml:"buffer_size"`
+	// mount point of tracefs, /sys/kernel/tracing or /sys/kernel/debug/tracing by default
+	TracefsPath string `yaml:"tracefs_path"`
+}
+
+func ConfigurationFromYAML(y []byte) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.UnmarshalWithOptions(y, &cfg, yaml.Strict()); err != nil {
+		return cfg, fmt.Errorf("cannot parse: %s", yaml.FormatError(err, false, false))
+	}
+
+	cfg.SetDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Configuration) SetDefaults() {
+	if c.Mode == "" {
+		c.Mode = configuration.TAIL_MODE
+	}
+
+	if len(c.Events) == 0 {
+		c.Events = slices.Clone(eventTypes)
+	}
+
+	if c.BufferSize == 0 {
+		c.BufferSize = defaultBufferSize
+	}
+}
+
+func (c *Configuration) Validate() error {
+	if c.Mode != configuration.TAIL_MODE {
+		return fmt.Errorf("unsupported mode %s for ebpf datasource", c.Mode)
+	}
+
+	for _, e := range c.Events {
+		if !slices.Contains(eventTypes, e) {
+			return fmt.Errorf("invalid event '%s': must be one of %s", e, strings.Join(eventTypes, ", "))
+		}
+	}
+
+	if c.BufferSize < minBufferSize || c.BufferSize&(c.BufferSize-1) != 0 {
+		return fmt.Errorf("buffer_size must be a power of 2, at least %d", minBufferSize)
+	}
+
+	return nil
+}
+
+func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
+	cfg, err := ConfigurationFromYAML(yamlConfig)
+	if err != nil {
+		return err
+	}
+
+	s.config = cfg
+
+	return nil
+}
+
+func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
+	if err := s.UnmarshalConfig(yamlConfig); err != nil {
+		return err
+	}
+
+	s.logger = logger
+	s.metricsLevel = metricsLevel
+	s.procPath = "/proc"
+
+	return nil
+}
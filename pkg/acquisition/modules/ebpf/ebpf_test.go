@@ -0,0 +1,251 @@
+package ebpfacquisition
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+const execFormat = `name: sched_process_exec
+ID: 312
+format:
+	field:unsigned short common_type;	offset:0;	size:2;	signed:0;
+	field:unsigned char common_flags;	offset:2;	size:1;	signed:0;
+	field:unsigned char common_preempt_count;	offset:3;	size:1;	signed:0;
+	field:int common_pid;	offset:4;	size:4;	signed:1;
+
+	field:__data_loc char[] filename;	offset:8;	size:4;	signed:0;
+	field:pid_t pid;	offset:12;	size:4;	signed:1;
+	field:pid_t old_pid;	offset:16;	size:4;	signed:1;
+
+print fmt: "filename=%s pid=%d old_pid=%d", __get_str(filename), REC->pid, REC->old_pid
+`
+
+const connectFormat = `name: inet_sock_set_state
+ID: 1523
+format:
+	field:unsigned short common_type;	offset:0;	size:2;	signed:0;
+	field:unsigned char common_flags;	offset:2;	size:1;	signed:0;
+	field:unsigned char common_preempt_count;	offset:3;	size:1;	signed:0;
+	field:int common_pid;	offset:4;	size:4;	signed:1;
+
+	field:const void * skaddr;	offset:8;	size:8;	signed:0;
+	field:int oldstate;	offset:16;	size:4;	signed:1;
+	field:int newstate;	offset:20;	size:4;	signed:1;
+	field:__u16 sport;	offset:24;	size:2;	signed:0;
+	field:__u16 dport;	offset:26;	size:2;	signed:0;
+	field:__u16 family;	offset:28;	size:2;	signed:0;
+	field:__u16 protocol;	offset:30;	size:2;	signed:0;
+	field:__u8 saddr[4];	offset:32;	size:4;	signed:0;
+	field:__u8 daddr[4];	offset:36;	size:4;	signed:0;
+	field:__u8 saddr_v6[16];	offset:40;	size:16;	signed:0;
+	field:__u8 daddr_v6[16];	offset:56;	size:16;	signed:0;
+
+print fmt: "family=%s protocol=%s sport=%hu dport=%hu"
+`
+
+func TestConfiguration(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		expectedErr string
+	}{
+		{
+			name:   "defaults",
+			config: "source: ebpf",
+		},
+		{
+			name:   "exec only",
+			config: "events: [exec]\nbuffer_size: 1048576",
+		},
+		{
+			name:        "bad event",
+			config:      "events: [exec, open]",
+			expectedErr: "invalid event 'open': must be one of exec, connect",
+		},
+		{
+			name:        "buffer too small",
+			config:      "buffer_size: 1024",
+			expectedErr: "buffer_size must be a power of 2, at least 4096",
+		},
+		{
+			name:        "buffer not a power of 2",
+			config:      "buffer_size: 100000",
+			expectedErr: "buffer_size must be a power of 2, at least 4096",
+		},
+		{
+			name:        "bad mode",
+			config:      "mode: cat",
+			expectedErr: "unsupported mode cat for ebpf datasource",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ConfigurationFromYAML([]byte(tc.config))
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	cfg, err := ConfigurationFromYAML([]byte("source: ebpf"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "tail", cfg.Mode)
+	assert.Equal(t, []string{"exec", "connect"}, cfg.Events)
+	assert.Equal(t, defaultBufferSize, cfg.BufferSize)
+}
+
+func TestPrograms(t *testing.T) {
+	format, err := parseFormat(strings.NewReader(execFormat))
+	require.NoError(t, err)
+	assert.Equal(t, field{offset: 8, size: 4}, format["filename"])
+	assert.Equal(t, field{offset: 4, size: 4}, format["common_pid"])
+
+	_, err = execProgram(format, 3)
+	require.NoError(t, err)
+
+	_, err = connectProgram(format, 3)
+	cstest.RequireErrorContains(t, err, "no field")
+
+	format, err = parseFormat(strings.NewReader(connectFormat))
+	require.NoError(t, err)
+	assert.Equal(t, field{offset: 40, size: 16}, format["saddr_v6"])
+
+	insns, err := connectProgram(format, 3)
+	require.NoError(t, err)
+
+	// the jumps to the labels are resolved
+	err = insns.Marshal(&strings.Builder{}, binary.LittleEndian)
+	require.NoError(t, err)
+
+	format["protocol"] = field{offset: 30, size: 4}
+
+	_, err = connectProgram(format, 3)
+	cstest.RequireErrorContains(t, err, "unexpected size 4 or offset 30 of field protocol")
+}
+
+func TestDecodeRecord(t *testing.T) {
+	order := binary.NativeEndian
+
+	header := func(recordType uint32, length int) []byte {
+		b := make([]byte, length)
+		order.PutUint32(b[recType:], recordType)
+		order.PutUint32(b[recPID:], 1234)
+		order.PutUint32(b[recUID:], 1000)
+		order.PutUint32(b[recGID:], 100)
+		order.PutUint64(b[recCgroupID:], 42)
+		copy(b[recComm:], "bash")
+
+		return b
+	}
+
+	b := header(recordExec, execRecordLen)
+	copy(b[recFilename:], "/usr/bin/wget")
+
+	r, err := decodeRecord(b)
+	require.NoError(t, err)
+	assert.Equal(t, record{Type: "exec", PID: 1234, UID: 1000, GID: 100, CgroupID: 42, Comm: "bash", Exe: "/usr/bin/wget"}, r)
+
+	b = header(recordConnect, connectRecordLen)
+	order.PutUint16(b[recFamily:], afInet)
+	order.PutUint16(b[recSourcePort:], 43210)
+	order.PutUint16(b[recDestPort:], 443)
+	copy(b[recSourceAddr:], []byte{10, 0, 0, 2})
+	copy(b[recDestAddr:], []byte{192, 0, 2, 1})
+
+	r, err = decodeRecord(b)
+	require.NoError(t, err)
+	assert.Equal(t, "connect", r.Type)
+	assert.Equal(t, "ipv4", r.Family)
+	assert.Equal(t, "10.0.0.2", r.SourceIP)
+	assert.Equal(t, uint16(43210), r.SourcePort)
+	assert.Equal(t, "192.0.2.1", r.DestIP)
+	assert.Equal(t, uint16(443), r.DestPort)
+
+	order.PutUint16(b[recFamily:], afInet6)
+	copy(b[recDestAddr:], []byte{0x20, 0x01, 0x0d, 0xb8, 15: 1})
+
+	r, err = decodeRecord(b)
+	require.NoError(t, err)
+	assert.Equal(t, "ipv6", r.Family)
+	assert.Equal(t, "2001:db8::1", r.DestIP)
+
+	_, err = decodeRecord(b[:connectRecordLen-1])
+	cstest.RequireErrorContains(t, err, "short connect record (79 bytes)")
+
+	_, err = decodeRecord(header(7, execRecordLen))
+	cstest.RequireErrorContains(t, err, "unknown record type 7")
+}
+
+func TestEnrich(t *testing.T) {
+	procPath := t.TempDir()
+	dir := filepath.Join(procPath, "1234")
+	id := strings.Repeat("0123456789abcdef", 4)
+
+	require.NoError(t, os.Mkdir(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stat"), []byte("1234 (my (odd) cmd) S 1200 1234 1200 0 -1 4194560"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cmdline"), []byte("wget\x00-q\x00http://example.com/x.sh\x00"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cgroup"), []byte("0::/system.slice/docker-"+id+".scope\n"), 0o644))
+	require.NoError(t, os.Symlink("/usr/bin/wget", filepath.Join(dir, "exe")))
+
+	s := &Source{procPath: procPath, metricsLevel: metrics.AcquisitionMetricsLevelNone}
+	require.NoError(t, s.UnmarshalConfig([]byte("labels:\n  type: ebpf")))
+
+	r := record{Type: "connect", PID: 1234, Comm: "wget", Family: "ipv4", DestIP: "192.0.2.1", DestPort: 80}
+	s.enrich(&r)
+
+	assert.Equal(t, uint32(1200), r.PPID)
+	assert.Equal(t, []string{"wget", "-q", "http://example.com/x.sh"}, r.Args)
+	assert.Equal(t, "/usr/bin/wget", r.Exe)
+	assert.Equal(t, "/system.slice/docker-"+id+".scope", r.Cgroup)
+	assert.Equal(t, id, r.ContainerID)
+
+	// the process is gone
+	gone := record{Type: "exec", PID: 1, Exe: "/bin/true"}
+	s.enrich(&gone)
+	assert.Equal(t, record{Type: "exec", PID: 1, Exe: "/bin/true"}, gone)
+
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	r.Timestamp = ts
+
+	evt, err := s.toEvent(r)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ebpf:connect", evt.Line.Src)
+	assert.Equal(t, ModuleName, evt.Line.Module)
+	assert.Equal(t, "ebpf", evt.Line.Labels["type"])
+	assert.Equal(t, ts, evt.Line.Time)
+
+	assert.Equal(t, "connect", evt.Parsed["type"])
+	assert.Equal(t, "1234", evt.Parsed["pid"])
+	assert.Equal(t, "192.0.2.1", evt.Parsed["dest_ip"])
+	assert.Equal(t, "80", evt.Parsed["dest_port"])
+	assert.Equal(t, id, evt.Parsed["container_id"])
+	assert.Equal(t, "wget -q http://example.com/x.sh", evt.Parsed["cmdline"])
+	assert.NotContains(t, evt.Parsed, "source_port")
+
+	var decoded record
+
+	require.NoError(t, json.Unmarshal([]byte(evt.Line.Raw), &decoded))
+	assert.Equal(t, r, decoded)
+}
+
+func TestCgroupV1(t *testing.T) {
+	content := "12:pids:/docker/" + strings.Repeat("a", 64) + "\n11:memory:/docker/" + strings.Repeat("a", 64) + "\n"
+
+	assert.Equal(t, "/docker/"+strings.Repeat("a", 64), cgroupPath([]byte(content)))
+	assert.Empty(t, containerID("/user.slice/user-1000.slice/session-2.scope"))
+}
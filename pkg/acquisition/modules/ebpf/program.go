@@ -0,0 +1,300 @@
+package ebpfacquisition
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cilium/ebpf/asm"
+)
+
+// The programs are generated here instead of being compiled from C, so there is no build
+// dependency on clang, and the offsets of the tracepoint fields are read from the running kernel.
+
+// tracepoint is a tracepoint the datasource attaches a program to.
+type tracepoint struct {
+	group string
+	name  string
+}
+
+var tracepoints = map[string]tracepoint{
+	eventExec:    {"sched", "sched_process_exec"},
+	eventConnect: {"sock", "inet_sock_set_state"},
+}
+
+// layout of the records sent by the programs, in host byte order
+const (
+	recType     = 0  // u32: recordExec or recordConnect
+	recPID      = 4  // u32: thread group ID
+	recUID      = 8  // u32
+	recGID      = 12 // u32
+	recCgroupID = 16 // u64: cgroup v2 ID
+	recComm     = 24 // [16]byte
+	recPayload  = 40
+
+	commLen = 16
+
+	// exec: the path of the program
+	recFilename   = recPayload
+	filenameLen   = 256
+	execRecordLen = recFilename + filenameLen
+
+	// connect: the addresses are IPv4 in the first 4 bytes, or IPv6
+	recFamily        = recPayload      // u16
+	recSourcePort    = recPayload + 2  // u16
+	recDestPort      = recPayload + 4  // u16
+	recSourceAddr    = recPayload + 8  // [16]byte
+	recDestAddr      = recPayload + 24 // [16]byte
+	connectRecordLen = recPayload + 40
+)
+
+const (
+	recordExec    = 1
+	recordConnect = 2
+
+	afInet      = 2
+	afInet6     = 10
+	ipprotoTCP  = 6
+	tcpSynSent  = 2
+	tcpClose    = 7
+	dataLocMask = 0xffff // the low 16 bits of a __data_loc field are the offset of the data
+)
+
+// field is a field of a tracepoint record, as described by its format file.
+type field struct {
+	offset int
+	size   int
+}
+
+var formatFieldRe = regexp.MustCompile(`^\s*field:(.+?);\s*offset:(\d+);\s*size:(\d+);`)
+
+// parseFormat reads the fields of a tracepoint format file (events/<group>/<name>/format in tracefs).
+func parseFormat(r io.Reader) (map[string]field, error) {
+	fields := map[string]field{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := formatFieldRe.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+
+		// "unsigned short common_type", "__data_loc char[] filename", "__u8 saddr[4]"
+		decl := strings.Fields(m[1])
+		name, _, _ := strings.Cut(decl[len(decl)-1], "[")
+
+		offset, _ := strconv.Atoi(m[2])
+		size, _ := strconv.Atoi(m[3])
+
+		fields[name] = field{offset: offset, size: size}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+// lookupFields returns the fields of a tracepoint needed by a program, checking their size.
+func lookupFields(fields map[string]field, want map[string][]int) (map[string]field, error) {
+	ret := make(map[string]field, len(want))
+
+	for name, sizes := range want {
+		f, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("no field %s in the tracepoint", name)
+		}
+
+		valid := false
+
+		for _, size := range sizes {
+			if f.size == size && f.offset%min(size, 4) == 0 {
+				valid = true
+			}
+		}
+
+		if !valid {
+			return nil, fmt.Errorf("unexpected size %d or offset %d of field %s", f.size, f.offset, name)
+		}
+
+		ret[name] = f
+	}
+
+	return ret, nil
+}
+
+func sizeOf(f field) asm.Size {
+	switch f.size {
+	case 1:
+		return asm.Byte
+	case 2:
+		return asm.Half
+	case 8:
+		return asm.DWord
+	default:
+		return asm.Word
+	}
+}
+
+// recordHeader fills the header of a record on the stack, at offset base from the frame pointer.
+// The record is zeroed first: the verifier rejects the output of uninitialized stack.
+func recordHeader(base int16, length int, recordType int64) asm.Instructions {
+	// there is no store of a 64 bits immediate
+	insns := asm.Instructions{
+		asm.Mov.Imm(asm.R1, 0),
+	}
+
+	for off := 0; off < length; off += 8 {
+		insns = append(insns, asm.StoreMem(asm.R10, base+int16(off), asm.R1, asm.DWord))
+	}
+
+	return append(insns,
+		asm.StoreImm(asm.R10, base+recType, recordType, asm.Word),
+
+		asm.FnGetCurrentPidTgid.Call(),
+		asm.RSh.Imm(asm.R0, 32),
+		asm.StoreMem(asm.R10, base+recPID, asm.R0, asm.Word),
+
+		asm.FnGetCurrentUidGid.Call(),
+		asm.StoreMem(asm.R10, base+recUID, asm.R0, asm.Word),
+		asm.RSh.Imm(asm.R0, 32),
+		asm.StoreMem(asm.R10, base+recGID, asm.R0, asm.Word),
+
+		asm.FnGetCurrentCgroupId.Call(),
+		asm.StoreMem(asm.R10, base+recCgroupID, asm.R0, asm.DWord),
+
+		asm.Mov.Reg(asm.R1, asm.R10),
+		asm.Add.Imm(asm.R1, int32(base+recComm)),
+		asm.Mov.Imm(asm.R2, commLen),
+		asm.FnGetCurrentComm.Call(),
+	)
+}
+
+// recordOutput sends the record to the ring buffer and returns.
+func recordOutput(mapFD int, base int16, length int) asm.Instructions {
+	return asm.Instructions{
+		asm.LoadMapPtr(asm.R1, mapFD),
+		asm.Mov.Reg(asm.R2, asm.R10),
+		asm.Add.Imm(asm.R2, int32(base)),
+		asm.Mov.Imm(asm.R3, int32(length)),
+		asm.Mov.Imm(asm.R4, 0),
+		asm.FnRingbufOutput.Call(),
+		asm.Mov.Imm(asm.R0, 0).WithSymbol("exit"),
+		asm.Return(),
+	}
+}
+
+// execProgram sends a record for each execution of a program, from sched/sched_process_exec.
+func execProgram(format map[string]field, mapFD int) (asm.Instructions, error) {
+	fields, err := lookupFields(format, map[string][]int{"filename": {4}})
+	if err != nil {
+		return nil, err
+	}
+
+	base := int16(-execRecordLen)
+
+	insns := asm.Instructions{
+		// the context is in R1, which is clobbered by the calls
+		asm.Mov.Reg(asm.R6, asm.R1),
+	}
+
+	insns = append(insns, recordHeader(base, execRecordLen, recordExec)...)
+
+	insns = append(insns,
+		// the filename is a __data_loc field: its data is in the record, at the offset of the low 16 bits
+		asm.LoadMem(asm.R3, asm.R6, int16(fields["filename"].offset), asm.Word),
+		asm.And.Imm(asm.R3, dataLocMask),
+		asm.Add.Reg(asm.R3, asm.R6),
+		asm.Mov.Reg(asm.R1, asm.R10),
+		asm.Add.Imm(asm.R1, int32(base+recFilename)),
+		asm.Mov.Imm(asm.R2, filenameLen),
+		asm.FnProbeReadKernelStr.Call(),
+	)
+
+	return append(insns, recordOutput(mapFD, base, execRecordLen)...), nil
+}
+
+// connectProgram sends a record for each outbound TCP connection, from sock/inet_sock_set_state:
+// the connect() call moves the socket from TCP_CLOSE to TCP_SYN_SENT, in the context of the process.
+func connectProgram(format map[string]field, mapFD int) (asm.Instructions, error) {
+	fields, err := lookupFields(format, map[string][]int{
+		"oldstate": {4},
+		"newstate": {4},
+		"protocol": {1, 2},
+		"family":   {2},
+		"sport":    {2},
+		"dport":    {2},
+		"saddr":    {4},
+		"daddr":    {4},
+		"saddr_v6": {16},
+		"daddr_v6": {16},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	load := func(dst asm.Register, name string, extra int) asm.Instruction {
+		f := fields[name]
+		size := sizeOf(f)
+
+		if f.size == 16 {
+			size = asm.Word
+		}
+
+		return asm.LoadMem(dst, asm.R6, int16(f.offset+extra), size)
+	}
+
+	base := int16(-connectRecordLen)
+
+	insns := asm.Instructions{
+		asm.Mov.Reg(asm.R6, asm.R1),
+		load(asm.R2, "newstate", 0),
+		asm.JNE.Imm(asm.R2, tcpSynSent, "exit"),
+		load(asm.R2, "oldstate", 0),
+		asm.JNE.Imm(asm.R2, tcpClose, "exit"),
+		load(asm.R2, "protocol", 0),
+		asm.JNE.Imm(asm.R2, ipprotoTCP, "exit"),
+	}
+
+	insns = append(insns, recordHeader(base, connectRecordLen, recordConnect)...)
+
+	insns = append(insns,
+		// the ports are in host byte order
+		load(asm.R2, "sport", 0),
+		asm.StoreMem(asm.R10, base+recSourcePort, asm.R2, asm.Half),
+		load(asm.R2, "dport", 0),
+		asm.StoreMem(asm.R10, base+recDestPort, asm.R2, asm.Half),
+		load(asm.R2, "family", 0),
+		asm.StoreMem(asm.R10, base+recFamily, asm.R2, asm.Half),
+		asm.JEq.Imm(asm.R2, afInet6, "ipv6"),
+
+		load(asm.R2, "saddr", 0),
+		asm.StoreMem(asm.R10, base+recSourceAddr, asm.R2, asm.Word),
+		load(asm.R2, "daddr", 0),
+		asm.StoreMem(asm.R10, base+recDestAddr, asm.R2, asm.Word),
+		asm.Ja.Label("output"),
+	)
+
+	for i := range 4 {
+		ins := load(asm.R2, "saddr_v6", 4*i)
+		if i == 0 {
+			ins = ins.WithSymbol("ipv6")
+		}
+
+		insns = append(insns,
+			ins,
+			asm.StoreMem(asm.R10, base+recSourceAddr+int16(4*i), asm.R2, asm.Word),
+			load(asm.R2, "daddr_v6", 4*i),
+			asm.StoreMem(asm.R10, base+recDestAddr+int16(4*i), asm.R2, asm.Word),
+		)
+	}
+
+	output := recordOutput(mapFD, base, connectRecordLen)
+	output[0] = output[0].WithSymbol("output")
+
+	return append(insns, output...), nil
+}
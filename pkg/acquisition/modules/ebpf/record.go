@@ -0,0 +1,254 @@
+package ebpfacquisition
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/filepreset"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// the command line is truncated to this size
+const maxCmdline = 4096
+
+// container runtimes name the cgroups after the container ID: docker-<id>.scope, /docker/<id>,
+// cri-containerd-<id>.scope, crio-<id>.scope, libpod-<id>.scope...
+var containerIDRe = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// record is an event sent by a program, with the details of the process read from procfs.
+// It's sent as JSON in the Raw field of the line.
+type record struct {
+	Type        string    `json:"type"`
+	Timestamp   time.Time `json:"timestamp"`
+	PID         uint32    `json:"pid"`
+	PPID        uint32    `json:"ppid,omitempty"`
+	UID         uint32    `json:"uid"`
+	GID         uint32    `json:"gid"`
+	Comm        string    `json:"comm"`
+	Exe         string    `json:"exe,omitempty"`
+	Args        []string  `json:"args,omitempty"`
+	CgroupID    uint64    `json:"cgroup_id"`
+	Cgroup      string    `json:"cgroup,omitempty"`
+	ContainerID string    `json:"container_id,omitempty"`
+
+	// connect
+	Family     string `json:"family,omitempty"`
+	SourceIP   string `json:"source_ip,omitempty"`
+	SourcePort uint16 `json:"source_port,omitempty"`
+	DestIP     string `json:"dest_ip,omitempty"`
+	DestPort   uint16 `json:"dest_port,omitempty"`
+}
+
+// cString returns the string of a NUL terminated buffer.
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+
+	return string(b)
+}
+
+// decodeRecord decodes a record of the ring buffer.
+func decodeRecord(raw []byte) (record, error) {
+	var r record
+
+	if len(raw) < recPayload {
+		return r, fmt.Errorf("short record (%d bytes)", len(raw))
+	}
+
+	order := binary.NativeEndian
+
+	r.PID = order.Uint32(raw[recPID:])
+	r.UID = order.Uint32(raw[recUID:])
+	r.GID = order.Uint32(raw[recGID:])
+	r.CgroupID = order.Uint64(raw[recCgroupID:])
+	r.Comm = cString(raw[recComm : recComm+commLen])
+
+	switch order.Uint32(raw[recType:]) {
+	case recordExec:
+		if len(raw) < execRecordLen {
+			return r, fmt.Errorf("short exec record (%d bytes)", len(raw))
+		}
+
+		r.Type = eventExec
+		r.Exe = cString(raw[recFilename : recFilename+filenameLen])
+	case recordConnect:
+		if len(raw) < connectRecordLen {
+			return r, fmt.Errorf("short connect record (%d bytes)", len(raw))
+		}
+
+		r.Type = eventConnect
+		r.SourcePort = order.Uint16(raw[recSourcePort:])
+		r.DestPort = order.Uint16(raw[recDestPort:])
+
+		src := raw[recSourceAddr : recSourceAddr+16]
+		dst := raw[recDestAddr : recDestAddr+16]
+
+		switch order.Uint16(raw[recFamily:]) {
+		case afInet:
+			r.Family = "ipv4"
+			r.SourceIP = netip.AddrFrom4([4]byte(src[:4])).String()
+			r.DestIP = netip.AddrFrom4([4]byte(dst[:4])).String()
+		case afInet6:
+			r.Family = "ipv6"
+			r.SourceIP = netip.AddrFrom16([16]byte(src)).String()
+			r.DestIP = netip.AddrFrom16([16]byte(dst)).String()
+		default:
+			return r, fmt.Errorf("unexpected address family %d", order.Uint16(raw[recFamily:]))
+		}
+	default:
+		return r, fmt.Errorf("unknown record type %d", order.Uint32(raw[recType:]))
+	}
+
+	return r, nil
+}
+
+// readProcFile reads at most limit bytes of a file of a process.
+func readProcFile(path string, limit int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(io.LimitReader(f, limit))
+}
+
+// parentPID reads the parent of a process in /proc/<pid>/stat. The command name can contain
+// spaces and parentheses, the fields are after the last parenthesis.
+func parentPID(stat []byte) (uint32, error) {
+	i := bytes.LastIndexByte(stat, ')')
+	if i < 0 {
+		return 0, errors.New("invalid stat")
+	}
+
+	fields := strings.Fields(string(stat[i+1:]))
+	if len(fields) < 2 {
+		return 0, errors.New("invalid stat")
+	}
+
+	ppid, err := strconv.ParseUint(fields[1], 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid stat: %w", err)
+	}
+
+	return uint32(ppid), nil
+}
+
+// cgroupPath returns the cgroup of a process from /proc/<pid>/cgroup: the unified hierarchy
+// ("0::/system.slice/docker-<id>.scope"), or the first hierarchy with cgroup v1.
+func cgroupPath(content []byte) string {
+	first := ""
+
+	for line := range strings.Lines(string(content)) {
+		parts := strings.SplitN(strings.TrimSpace(line), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+
+		if parts[0] == "0" && parts[1] == "" {
+			return parts[2]
+		}
+
+		if first == "" {
+			first = parts[2]
+		}
+	}
+
+	return first
+}
+
+// containerID returns the ID of the container of a cgroup, if any.
+func containerID(cgroup string) string {
+	ids := containerIDRe.FindAllString(cgroup, -1)
+	if len(ids) == 0 {
+		return ""
+	}
+
+	// the last one is the most specific (a container in a pod)
+	return ids[len(ids)-1]
+}
+
+// enrich adds the details of the process that are not in the record. The process may be gone
+// already: the details are best effort.
+func (s *Source) enrich(r *record) {
+	dir := filepath.Join(s.procPath, strconv.FormatUint(uint64(r.PID), 10))
+
+	if stat, err := readProcFile(filepath.Join(dir, "stat"), 1024); err == nil {
+		if ppid, err := parentPID(stat); err == nil {
+			r.PPID = ppid
+		}
+	}
+
+	if cmdline, err := readProcFile(filepath.Join(dir, "cmdline"), maxCmdline); err == nil && len(cmdline) > 0 {
+		r.Args = strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+	}
+
+	if r.Exe == "" {
+		if exe, err := os.Readlink(filepath.Join(dir, "exe")); err == nil {
+			r.Exe = exe
+		}
+	}
+
+	if cgroup, err := readProcFile(filepath.Join(dir, "cgroup"), 4096); err == nil {
+		r.Cgroup = cgroupPath(cgroup)
+		r.ContainerID = containerID(r.Cgroup)
+	}
+}
+
+// toEvent builds the pipeline event of a record. Parsed has the fields of the record, with the
+// arguments joined in cmdline.
+func (s *Source) toEvent(r record) (pipeline.Event, error) {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return pipeline.Event{}, fmt.Errorf("serializing %s event of %d: %w", r.Type, r.PID, err)
+	}
+
+	src := ModuleName + ":" + r.Type
+
+	evt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+
+	evt.Line = pipeline.Line{
+		Raw:     string(raw),
+		Src:     src,
+		Time:    r.Timestamp,
+		Labels:  s.config.Labels,
+		Module:  ModuleName,
+		Process: true,
+	}
+
+	var fields map[string]any
+
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return pipeline.Event{}, fmt.Errorf("serializing %s event of %d: %w", r.Type, r.PID, err)
+	}
+
+	delete(fields, "args")
+	filepreset.Flatten("", fields, evt.Parsed)
+
+	if len(r.Args) > 0 {
+		evt.Parsed["cmdline"] = strings.Join(r.Args, " ")
+	}
+
+	evt.StrTime = r.Timestamp.Format(time.RFC3339Nano)
+
+	if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+		metrics.EBPFDataSourceEventsRead.With(prometheus.Labels{"source": src, "datasource_type": ModuleName, "acquis_type": s.config.Labels["type"]}).Inc()
+	}
+
+	return evt, nil
+}
@@ -0,0 +1,161 @@
+//go:build linux
+
+package ebpfacquisition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/ringbuf"
+	"github.com/cilium/ebpf/rlimit"
+
+	"github.com/crowdsecurity/go-cs-lib/trace"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+var defaultTracefsPaths = []string{"/sys/kernel/tracing", "/sys/kernel/debug/tracing"}
+
+// tracefsPath returns the mount point of tracefs, where the formats of the tracepoints are.
+func (s *Source) tracefsPath() (string, error) {
+	paths := defaultTracefsPaths
+	if s.config.TracefsPath != "" {
+		paths = []string{s.config.TracefsPath}
+	}
+
+	for _, p := range paths {
+		if _, err := os.Stat(filepath.Join(p, "events")); err == nil {
+			return p, nil
+		}
+	}
+
+	return "", fmt.Errorf("tracefs not found in %v (is it mounted?)", paths)
+}
+
+// attach loads the program of an event and attaches it to its tracepoint.
+func attach(tracefs string, event string, events *ebpf.Map) (*ebpf.Program, link.Link, error) {
+	tp := tracepoints[event]
+
+	f, err := os.Open(filepath.Join(tracefs, "events", tp.group, tp.name, "format"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading the format of %s/%s: %w", tp.group, tp.name, err)
+	}
+	defer f.Close()
+
+	format, err := parseFormat(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading the format of %s/%s: %w", tp.group, tp.name, err)
+	}
+
+	build := execProgram
+	if event == eventConnect {
+		build = connectProgram
+	}
+
+	insns, err := build(format, events.FD())
+	if err != nil {
+		return nil, nil, fmt.Errorf("building %s program: %w", event, err)
+	}
+
+	prog, err := ebpf.NewProgram(&ebpf.ProgramSpec{
+		Name:         "crowdsec_" + event,
+		Type:         ebpf.TracePoint,
+		License:      "GPL",
+		Instructions: insns,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading %s program: %w", event, err)
+	}
+
+	l, err := link.Tracepoint(tp.group, tp.name, prog, nil)
+	if err != nil {
+		prog.Close()
+		return nil, nil, fmt.Errorf("attaching %s program to %s/%s: %w", event, tp.group, tp.name, err)
+	}
+
+	return prog, l, nil
+}
+
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	// needed before kernel 5.11, where the memory of the maps is charged to the memlock limit
+	if err := rlimit.RemoveMemlock(); err != nil {
+		return fmt.Errorf("removing the memlock limit: %w", err)
+	}
+
+	tracefs, err := s.tracefsPath()
+	if err != nil {
+		return err
+	}
+
+	events, err := ebpf.NewMap(&ebpf.MapSpec{
+		Name:       "crowdsec_events",
+		Type:       ebpf.RingBuf,
+		MaxEntries: uint32(s.config.BufferSize),
+	})
+	if err != nil {
+		return fmt.Errorf("creating the ring buffer: %w", err)
+	}
+	defer events.Close()
+
+	for _, event := range s.config.Events {
+		prog, l, err := attach(tracefs, event, events)
+		if err != nil {
+			return err
+		}
+
+		defer prog.Close()
+		defer l.Close()
+
+		s.logger.Infof("capturing %s events", event)
+	}
+
+	reader, err := ringbuf.NewReader(events)
+	if err != nil {
+		return fmt.Errorf("reading the ring buffer: %w", err)
+	}
+	defer reader.Close()
+
+	go func() {
+		defer trace.ReportPanic()
+		<-ctx.Done()
+		reader.Close()
+	}()
+
+	for {
+		sample, err := reader.Read()
+		if errors.Is(err, ringbuf.ErrClosed) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("reading the ring buffer: %w", err)
+		}
+
+		r, err := decodeRecord(sample.RawSample)
+		if err != nil {
+			s.logger.Errorf("decoding event: %s", err)
+			continue
+		}
+
+		r.Timestamp = time.Now().UTC()
+		s.enrich(&r)
+
+		evt, err := s.toEvent(r)
+		if err != nil {
+			s.logger.Error(err)
+			continue
+		}
+
+		select {
+		case out <- evt:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
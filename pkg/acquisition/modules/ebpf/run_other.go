@@ -0,0 +1,14 @@
+//go:build !linux
+
+package ebpfacquisition
+
+import (
+	"context"
+	"errors"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func (*Source) Stream(_ context.Context, _ chan pipeline.Event) error {
+	return errors.New("eBPF acquisition is only supported on Linux")
+}
@@ -0,0 +1,41 @@
+package ebpfacquisition
+
+import (
+	"errors"
+	"runtime"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+type Source struct {
+	metricsLevel metrics.AcquisitionMetricsLevel
+	config       Configuration
+	logger       *log.Entry
+	procPath     string // mount point of procfs, to read the details of the processes
+}
+
+func (s *Source) GetUuid() string {
+	return s.config.UniqueId
+}
+
+func (s *Source) GetMode() string {
+	return s.config.Mode
+}
+
+func (*Source) GetName() string {
+	return ModuleName
+}
+
+func (*Source) CanRun() error {
+	if runtime.GOOS != "linux" {
+		return errors.New("eBPF acquisition is only supported on Linux")
+	}
+
+	return nil
+}
+
+func (s *Source) Dump() any {
+	return s
+}
@@ -0,0 +1,5 @@
+//go:build !no_datasource_etw
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/etw" // register the datasource
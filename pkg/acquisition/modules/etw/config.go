@@ -0,0 +1,189 @@
+package etwacquisition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+const (
+	defaultSessionName = "crowdsec"
+	// in KB, per buffer
+	defaultBufferSize = 64
+
+	// TRACE_LEVEL_VERBOSE: analytic events are mostly informational or verbose
+	defaultLevel = 5
+)
+
+// preset is a provider known to the datasource, with the fields worth normalizing.
+type preset struct {
+	provider string
+	// property name -> Parsed key
+	fields map[string]string
+}
+
+var presets = map[string]preset{
+	// DNS Server analytic events: queries received (256), responses (257, 258), recursion (260, 261)...
+	"dns_server": {
+		provider: "Microsoft-Windows-DNSServer",
+		fields: map[string]string{
+			"Source":      "source_ip",
+			"InterfaceIP": "dest_ip",
+			"QNAME":       "dns_qname",
+			"QTYPE":       "dns_qtype",
+			"RCODE":       "dns_rcode",
+			"Zone":        "dns_zone",
+		},
+	},
+	// DHCP Server operational and audit events
+	"dhcp_server": {
+		provider: "Microsoft-Windows-DHCP-Server",
+		fields:   map[string]string{},
+	},
+}
+
+type ProviderCfg struct {
+	// Preset is dns_server or dhcp_server. Name and fields mapping come from the preset.
+	Preset string `yaml:"preset"`
+	// Name of a registered provider (Microsoft-Windows-DNSServer), or GUID if it's not registered
+	Name string `yaml:"name"`
+	GUID string `yaml:"guid"`
+	// 1 (critical) to 5 (verbose)
+	Level    *uint8 `yaml:"level"`
+	Keywords uint64 `yaml:"keywords"`
+	EventIDs []int  `yaml:"event_ids"`
+}
+
+type Configuration struct {
+	configuration.DataSourceCommonCfg `yaml:",inline"`
+
+	Providers []ProviderCfg `yaml:"providers"`
+	// the ETW session is stopped and started again if it already exists, each datasource needs its own name
+	SessionName string `yaml:"session_name"`
+	BufferSize  uint32 `yaml:"buffer_size"`
+}
+
+func ConfigurationFromYAML(y []byte) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.UnmarshalWithOptions(y, &cfg, yaml.Strict()); err != nil {
+		return cfg, fmt.Errorf("cannot parse: %s", yaml.FormatError(err, false, false))
+	}
+
+	cfg.SetDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Configuration) SetDefaults() {
+	if c.Mode == "" {
+		c.Mode = configuration.TAIL_MODE
+	}
+
+	if c.SessionName == "" {
+		c.SessionName = defaultSessionName
+	}
+
+	if c.BufferSize == 0 {
+		c.BufferSize = defaultBufferSize
+	}
+
+	for i := range c.Providers {
+		p := &c.Providers[i]
+
+		if p.Name == "" && p.Preset != "" {
+			p.Name = presets[p.Preset].provider
+		}
+
+		if p.Level == nil {
+			level := uint8(defaultLevel)
+			p.Level = &level
+		}
+	}
+}
+
+func (c *Configuration) Validate() error {
+	if c.Mode != configuration.TAIL_MODE {
+		return fmt.Errorf("unsupported mode %s for etw datasource", c.Mode)
+	}
+
+	if len(c.Providers) == 0 {
+		return errors.New("at least one provider is required")
+	}
+
+	seen := make(map[string]struct{}, len(c.Providers))
+
+	for i, p := range c.Providers {
+		if p.Preset != "" {
+			if _, ok := presets[p.Preset]; !ok {
+				return fmt.Errorf("providers[%d]: unknown preset %q", i, p.Preset)
+			}
+		}
+
+		if p.Name == "" && p.GUID == "" {
+			return fmt.Errorf("providers[%d]: preset, name or guid is required", i)
+		}
+
+		if p.GUID != "" {
+			if _, err := parseGUID(p.GUID); err != nil {
+				return fmt.Errorf("providers[%d]: %w", i, err)
+			}
+		}
+
+		if *p.Level < 1 || *p.Level > 5 {
+			return fmt.Errorf("providers[%d]: level must be between 1 (critical) and 5 (verbose)", i)
+		}
+
+		for _, id := range p.EventIDs {
+			if id < 0 || id > 0xffff {
+				return fmt.Errorf("providers[%d]: invalid event id %d", i, id)
+			}
+		}
+
+		key := strings.ToLower(p.Name + "/" + p.GUID)
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("providers[%d]: duplicate provider %s", i, p.Name+p.GUID)
+		}
+
+		seen[key] = struct{}{}
+	}
+
+	if len(c.SessionName) > 1023 {
+		return errors.New("session_name is too long")
+	}
+
+	return nil
+}
+
+func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
+	cfg, err := ConfigurationFromYAML(yamlConfig)
+	if err != nil {
+		return err
+	}
+
+	s.config = cfg
+
+	return nil
+}
+
+func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
+	if err := s.UnmarshalConfig(yamlConfig); err != nil {
+		return err
+	}
+
+	s.logger = logger
+	s.metricsLevel = metricsLevel
+
+	return nil
+}
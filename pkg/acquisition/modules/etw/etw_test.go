@@ -0,0 +1,151 @@
+package etwacquisition
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+func TestConfiguration(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		expectedErr string
+	}{
+		{
+			name:   "preset",
+			config: "providers:\n  - preset: dns_server",
+		},
+		{
+			name:   "guid only",
+			config: "providers:\n  - guid: EB79061A-A566-4698-9119-3ED2807060E7",
+		},
+		{
+			name:        "no provider",
+			config:      "session_name: foo",
+			expectedErr: "at least one provider is required",
+		},
+		{
+			name:        "unknown preset",
+			config:      "providers:\n  - preset: dns",
+			expectedErr: `providers[0]: unknown preset "dns"`,
+		},
+		{
+			name:        "empty provider",
+			config:      "providers:\n  - level: 4",
+			expectedErr: "providers[0]: preset, name or guid is required",
+		},
+		{
+			name:        "bad guid",
+			config:      "providers:\n  - guid: EB79061A",
+			expectedErr: `providers[0]: invalid guid "EB79061A"`,
+		},
+		{
+			name:        "bad level",
+			config:      "providers:\n  - preset: dns_server\n    level: 0",
+			expectedErr: "providers[0]: level must be between 1 (critical) and 5 (verbose)",
+		},
+		{
+			name:        "bad event id",
+			config:      "providers:\n  - preset: dns_server\n    event_ids: [70000]",
+			expectedErr: "providers[0]: invalid event id 70000",
+		},
+		{
+			name:        "duplicate",
+			config:      "providers:\n  - preset: dns_server\n  - name: microsoft-windows-dnsserver",
+			expectedErr: "providers[1]: duplicate provider microsoft-windows-dnsserver",
+		},
+		{
+			name:        "bad mode",
+			config:      "mode: cat\nproviders:\n  - preset: dns_server",
+			expectedErr: "unsupported mode cat for etw datasource",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ConfigurationFromYAML([]byte(tc.config))
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	cfg, err := ConfigurationFromYAML([]byte("providers:\n  - preset: dns_server"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "tail", cfg.Mode)
+	assert.Equal(t, defaultSessionName, cfg.SessionName)
+	assert.Equal(t, uint32(defaultBufferSize), cfg.BufferSize)
+	assert.Equal(t, "Microsoft-Windows-DNSServer", cfg.Providers[0].Name)
+	assert.Equal(t, uint8(defaultLevel), *cfg.Providers[0].Level)
+}
+
+func TestGUID(t *testing.T) {
+	for _, s := range []string{"{EB79061A-A566-4698-9119-3ED2807060E7}", "eb79061a-a566-4698-9119-3ed2807060e7"} {
+		g, err := parseGUID(s)
+		require.NoError(t, err)
+		assert.Equal(t, uint32(0xEB79061A), g.Data1)
+		assert.Equal(t, [8]byte{0x91, 0x19, 0x3E, 0xD2, 0x80, 0x70, 0x60, 0xE7}, g.Data4)
+		assert.Equal(t, "{EB79061A-A566-4698-9119-3ED2807060E7}", g.String())
+	}
+
+	_, err := parseGUID("EB79061A-A566-4698-9119-3ED2807060EZ")
+	require.Error(t, err)
+}
+
+func TestToEvent(t *testing.T) {
+	s := &Source{metricsLevel: metrics.AcquisitionMetricsLevelNone}
+	require.NoError(t, s.UnmarshalConfig([]byte("labels:\n  type: windows-dns\nproviders:\n  - preset: dns_server\n    event_ids: [256]")))
+
+	g, err := parseGUID("EB79061A-A566-4698-9119-3ED2807060E7")
+	require.NoError(t, err)
+
+	p := newProvider(s.config.Providers[0], g)
+	assert.True(t, p.wants(256))
+	assert.False(t, p.wants(257))
+
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	r := record{
+		Provider:     "Microsoft-Windows-DNSServer",
+		ProviderGUID: g.String(),
+		EventID:      256,
+		Level:        4,
+		Timestamp:    ts,
+		Data: map[string]any{
+			"Source":      "192.168.1.10",
+			"InterfaceIP": "10.0.0.1",
+			"QNAME":       "example.com.",
+			"QTYPE":       "1",
+		},
+	}
+
+	evt, err := s.toEvent(p, r)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Microsoft-Windows-DNSServer", evt.Line.Src)
+	assert.Equal(t, ModuleName, evt.Line.Module)
+	assert.Equal(t, "windows-dns", evt.Line.Labels["type"])
+	assert.Equal(t, ts, evt.Line.Time)
+
+	assert.Equal(t, "256", evt.Parsed["event_id"])
+	assert.Equal(t, "example.com.", evt.Parsed["data.QNAME"])
+	assert.Equal(t, "192.168.1.10", evt.Parsed["source_ip"])
+	assert.Equal(t, "10.0.0.1", evt.Parsed["dest_ip"])
+	assert.Equal(t, "example.com.", evt.Parsed["dns_qname"])
+	assert.Equal(t, "1", evt.Parsed["dns_qtype"])
+	assert.NotContains(t, evt.Parsed, "dns_rcode")
+
+	var decoded record
+
+	require.NoError(t, json.Unmarshal([]byte(evt.Line.Raw), &decoded))
+	assert.Equal(t, r.Data, decoded.Data)
+}
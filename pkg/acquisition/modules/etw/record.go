@@ -0,0 +1,149 @@
+package etwacquisition
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/filepreset"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// guid has the memory layout of the windows GUID structure.
+type guid struct {
+	Data1 uint32
+	Data2 uint16
+	Data3 uint16
+	Data4 [8]byte
+}
+
+// parseGUID accepts the registry format, with or without braces: {EB79061A-A566-4698-9119-3ED2807060E7}
+func parseGUID(s string) (guid, error) {
+	var g guid
+
+	raw := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(s), "{"), "}")
+
+	parts := strings.Split(raw, "-")
+	if len(parts) != 5 || len(parts[0]) != 8 || len(parts[1]) != 4 || len(parts[2]) != 4 || len(parts[3]) != 4 || len(parts[4]) != 12 {
+		return g, fmt.Errorf("invalid guid %q", s)
+	}
+
+	b, err := hex.DecodeString(strings.Join(parts, ""))
+	if err != nil {
+		return g, fmt.Errorf("invalid guid %q", s)
+	}
+
+	g.Data1 = uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+	g.Data2 = uint16(b[4])<<8 | uint16(b[5])
+	g.Data3 = uint16(b[6])<<8 | uint16(b[7])
+	copy(g.Data4[:], b[8:])
+
+	return g, nil
+}
+
+func (g guid) String() string {
+	return fmt.Sprintf("{%08X-%04X-%04X-%02X%02X-%02X%02X%02X%02X%02X%02X}",
+		g.Data1, g.Data2, g.Data3,
+		g.Data4[0], g.Data4[1], g.Data4[2], g.Data4[3], g.Data4[4], g.Data4[5], g.Data4[6], g.Data4[7])
+}
+
+// record is a decoded ETW event. It's sent as JSON in the Raw field of the line.
+type record struct {
+	Provider     string         `json:"provider"`
+	ProviderGUID string         `json:"provider_guid"`
+	EventID      uint16         `json:"event_id"`
+	Version      uint8          `json:"version"`
+	Level        uint8          `json:"level"`
+	Task         uint16         `json:"task"`
+	Opcode       uint8          `json:"opcode"`
+	Keywords     uint64         `json:"keywords"`
+	Timestamp    time.Time      `json:"timestamp"`
+	ProcessID    uint32         `json:"process_id"`
+	ThreadID     uint32         `json:"thread_id"`
+	Data         map[string]any `json:"data"`
+}
+
+// provider is a provider enabled in the session, with its configuration.
+type provider struct {
+	guid     guid
+	name     string
+	level    uint8
+	keywords uint64
+	eventIDs []uint16
+	fields   map[string]string
+}
+
+func newProvider(cfg ProviderCfg, g guid) provider {
+	p := provider{
+		guid:     g,
+		name:     cfg.Name,
+		level:    *cfg.Level,
+		keywords: cfg.Keywords,
+		fields:   presets[cfg.Preset].fields,
+	}
+
+	for _, id := range cfg.EventIDs {
+		p.eventIDs = append(p.eventIDs, uint16(id))
+	}
+
+	return p
+}
+
+func (p provider) wants(eventID uint16) bool {
+	return len(p.eventIDs) == 0 || slices.Contains(p.eventIDs, eventID)
+}
+
+// toEvent builds the pipeline event of a record. Parsed has the flattened record (provider,
+// event_id, data.QNAME...), and the fields normalized by the preset (source_ip...).
+func (s *Source) toEvent(p provider, r record) (pipeline.Event, error) {
+	raw, err := json.Marshal(r)
+	if err != nil {
+		return pipeline.Event{}, fmt.Errorf("serializing event %d of %s: %w", r.EventID, r.Provider, err)
+	}
+
+	evt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+
+	evt.Line = pipeline.Line{
+		Raw:     string(raw),
+		Src:     r.Provider,
+		Time:    r.Timestamp,
+		Labels:  s.config.Labels,
+		Module:  ModuleName,
+		Process: true,
+	}
+
+	filepreset.Flatten("", map[string]any{
+		"provider":      r.Provider,
+		"provider_guid": r.ProviderGUID,
+		"event_id":      r.EventID,
+		"version":       r.Version,
+		"level":         r.Level,
+		"task":          r.Task,
+		"opcode":        r.Opcode,
+		"keywords":      r.Keywords,
+		"timestamp":     r.Timestamp.Format(time.RFC3339Nano),
+		"process_id":    r.ProcessID,
+		"thread_id":     r.ThreadID,
+		"data":          r.Data,
+	}, evt.Parsed)
+
+	for property, key := range p.fields {
+		if v, ok := evt.Parsed["data."+property]; ok {
+			evt.Parsed[key] = v
+		}
+	}
+
+	evt.StrTime = r.Timestamp.Format(time.RFC3339Nano)
+
+	if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+		metrics.ETWDataSourceLinesRead.With(prometheus.Labels{"source": r.Provider, "datasource_type": ModuleName, "acquis_type": s.config.Labels["type"]}).Inc()
+	}
+
+	return evt, nil
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package etwacquisition
+
+import (
+	"context"
+	"errors"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func (*Source) Stream(_ context.Context, _ chan pipeline.Event) error {
+	return errors.New("ETW acquisition is only supported on Windows")
+}
@@ -0,0 +1,180 @@
+package etwacquisition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows"
+
+	"github.com/crowdsecurity/go-cs-lib/trace"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// The callbacks created by windows.NewCallback are never released, so there is a single one
+// for the process: it finds the consumer of an event with the context given to OpenTrace.
+var (
+	callbackOnce sync.Once
+	callback     uintptr
+	consumers    sync.Map
+	lastConsumer atomic.Uintptr
+)
+
+type consumer struct {
+	ctx       context.Context
+	source    *Source
+	providers map[guid]provider
+	out       chan pipeline.Event
+	logger    *log.Entry
+}
+
+func eventRecordCallback(rec *eventRecord) uintptr {
+	if c, ok := consumers.Load(rec.UserContext); ok {
+		c.(*consumer).handle(rec)
+	}
+
+	return 0
+}
+
+func (c *consumer) handle(rec *eventRecord) {
+	p, ok := c.providers[rec.EventHeader.ProviderID]
+	if !ok || !p.wants(rec.EventHeader.EventDescriptor.ID) {
+		return
+	}
+
+	r, err := decodeRecord(rec)
+	if err != nil {
+		c.logger.Debugf("event %d of %s: %s", r.EventID, p.name, err)
+	}
+
+	if r.Provider == "" {
+		r.Provider = p.name
+	}
+
+	evt, err := c.source.toEvent(p, r)
+	if err != nil {
+		c.logger.Error(err)
+		return
+	}
+
+	select {
+	case c.out <- evt:
+	case <-c.ctx.Done():
+	}
+}
+
+// resolveProviders looks up the GUID of the providers configured by name.
+func (s *Source) resolveProviders() (map[guid]provider, error) {
+	var registered map[string]guid
+
+	ret := make(map[guid]provider, len(s.config.Providers))
+
+	for _, cfg := range s.config.Providers {
+		if cfg.GUID != "" {
+			g, err := parseGUID(cfg.GUID)
+			if err != nil {
+				return nil, err
+			}
+
+			ret[g] = newProvider(cfg, g)
+
+			continue
+		}
+
+		if registered == nil {
+			var err error
+
+			registered, err = registeredProviders()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		g, ok := registered[strings.ToLower(cfg.Name)]
+		if !ok {
+			return nil, fmt.Errorf("provider %s is not registered (is the server role installed?)", cfg.Name)
+		}
+
+		ret[g] = newProvider(cfg, g)
+	}
+
+	return ret, nil
+}
+
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	if err := checkLayout(); err != nil {
+		return err
+	}
+
+	providers, err := s.resolveProviders()
+	if err != nil {
+		return err
+	}
+
+	session, err := startSession(s.config.SessionName, s.config.BufferSize)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := stopSession(s.config.SessionName); err != nil {
+			s.logger.Errorf("stopping ETW session %s: %s", s.config.SessionName, err)
+		}
+	}()
+
+	for _, p := range providers {
+		if err := enableProvider(session, p); err != nil {
+			return err
+		}
+
+		s.logger.Infof("capturing events of %s %s", p.name, p.guid)
+	}
+
+	callbackOnce.Do(func() {
+		callback = windows.NewCallback(eventRecordCallback)
+	})
+
+	id := lastConsumer.Add(1)
+
+	consumers.Store(id, &consumer{
+		ctx:       ctx,
+		source:    s,
+		providers: providers,
+		out:       out,
+		logger:    s.logger,
+	})
+	defer consumers.Delete(id)
+
+	handle, err := openTrace(s.config.SessionName, callback, id)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		defer trace.ReportPanic()
+		done <- processTrace(handle)
+	}()
+
+	select {
+	case <-ctx.Done():
+		closeTrace(handle)
+		<-done
+
+		return nil
+	case err := <-done:
+		closeTrace(handle)
+
+		if err == nil {
+			err = errors.New("ETW session stopped")
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,364 @@
+package etwacquisition
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	advapi32 = windows.NewLazySystemDLL("advapi32.dll")
+	tdh      = windows.NewLazySystemDLL("tdh.dll")
+
+	procStartTraceW    = advapi32.NewProc("StartTraceW")
+	procControlTraceW  = advapi32.NewProc("ControlTraceW")
+	procEnableTraceEx2 = advapi32.NewProc("EnableTraceEx2")
+	procOpenTraceW     = advapi32.NewProc("OpenTraceW")
+	procProcessTrace   = advapi32.NewProc("ProcessTrace")
+	procCloseTrace     = advapi32.NewProc("CloseTrace")
+
+	procTdhEnumerateProviders     = tdh.NewProc("TdhEnumerateProviders")
+	procTdhGetEventInformation    = tdh.NewProc("TdhGetEventInformation")
+	procTdhGetEventMapInformation = tdh.NewProc("TdhGetEventMapInformation")
+	procTdhFormatProperty         = tdh.NewProc("TdhFormatProperty")
+)
+
+const (
+	wnodeFlagTracedGUID            = 0x00020000
+	eventTraceRealTimeMode         = 0x00000100
+	eventTraceControlStop          = 1
+	eventControlCodeEnableProvider = 1
+	processTraceModeRealTime       = 0x00000100
+	processTraceModeEventRecord    = 0x10000000
+	// the timestamps of the events are FILETIME
+	clientContextSystemTime = 2
+
+	invalidProcessTraceHandle = ^uint64(0)
+)
+
+// The structures below have the layout of their C counterpart on 64 bit windows.
+
+type wnodeHeader struct {
+	BufferSize        uint32
+	ProviderID        uint32
+	HistoricalContext uint64
+	TimeStamp         int64
+	GUID              guid
+	ClientContext     uint32
+	Flags             uint32
+}
+
+type eventTraceProperties struct {
+	Wnode               wnodeHeader
+	BufferSize          uint32
+	MinimumBuffers      uint32
+	MaximumBuffers      uint32
+	MaximumFileSize     uint32
+	LogFileMode         uint32
+	FlushTimer          uint32
+	EnableFlags         uint32
+	AgeLimit            int32
+	NumberOfBuffers     uint32
+	FreeBuffers         uint32
+	EventsLost          uint32
+	BuffersWritten      uint32
+	LogBuffersLost      uint32
+	RealTimeBuffersLost uint32
+	LoggerThreadID      uintptr
+	LogFileNameOffset   uint32
+	LoggerNameOffset    uint32
+}
+
+// sessionProperties has room for the names that are written after EVENT_TRACE_PROPERTIES.
+type sessionProperties struct {
+	eventTraceProperties
+	loggerName  [1024]uint16
+	logFileName [1024]uint16
+}
+
+type eventTraceHeader struct {
+	Size           uint16
+	FieldTypeFlags uint16
+	Version        uint32
+	ThreadID       uint32
+	ProcessID      uint32
+	TimeStamp      int64
+	GUID           guid
+	ProcessorTime  uint64
+}
+
+type eventTrace struct {
+	Header           eventTraceHeader
+	InstanceID       uint32
+	ParentInstanceID uint32
+	ParentGUID       guid
+	MofData          uintptr
+	MofLength        uint32
+	ClientContext    uint32
+}
+
+type traceLogfileHeader struct {
+	BufferSize         uint32
+	Version            uint32
+	ProviderVersion    uint32
+	NumberOfProcessors uint32
+	EndTime            int64
+	TimerResolution    uint32
+	MaximumFileSize    uint32
+	LogFileMode        uint32
+	BuffersWritten     uint32
+	LogInstanceGUID    guid
+	LoggerName         *uint16
+	LogFileName        *uint16
+	TimeZone           windows.Timezoneinformation
+	BootTime           int64
+	PerfFreq           int64
+	StartTime          int64
+	ReservedFlags      uint32
+	BuffersLost        uint32
+}
+
+type eventTraceLogfile struct {
+	LogFileName         *uint16
+	LoggerName          *uint16
+	CurrentTime         int64
+	BuffersRead         uint32
+	ProcessTraceMode    uint32
+	CurrentEvent        eventTrace
+	LogfileHeader       traceLogfileHeader
+	BufferCallback      uintptr
+	BufferSize          uint32
+	Filled              uint32
+	EventsLost          uint32
+	EventRecordCallback uintptr
+	IsKernelTrace       uint32
+	Context             uintptr
+}
+
+type eventDescriptor struct {
+	ID      uint16
+	Version uint8
+	Channel uint8
+	Level   uint8
+	Opcode  uint8
+	Task    uint16
+	Keyword uint64
+}
+
+type eventHeader struct {
+	Size            uint16
+	HeaderType      uint16
+	Flags           uint16
+	EventProperty   uint16
+	ThreadID        uint32
+	ProcessID       uint32
+	TimeStamp       int64
+	ProviderID      guid
+	EventDescriptor eventDescriptor
+	ProcessorTime   uint64
+	ActivityID      guid
+}
+
+type eventRecord struct {
+	EventHeader       eventHeader
+	ProcessorIndex    uint16
+	LoggerID          uint16
+	ExtendedDataCount uint16
+	UserDataLength    uint16
+	ExtendedData      uintptr
+	UserData          unsafe.Pointer
+	UserContext       uintptr
+}
+
+// checkLayout makes sure the structures match the ones of the API (64 bit only).
+func checkLayout() error {
+	if unsafe.Sizeof(eventTraceProperties{}) != 120 ||
+		unsafe.Sizeof(eventTraceLogfile{}) != 448 ||
+		unsafe.Sizeof(eventRecord{}) != 112 ||
+		unsafe.Sizeof(traceEventInfo{}) != 112 ||
+		unsafe.Sizeof(eventPropertyInfo{}) != 24 {
+		return errors.New("ETW acquisition is only supported on 64 bit windows")
+	}
+
+	return nil
+}
+
+func newSessionProperties(bufferSize uint32) *sessionProperties {
+	p := &sessionProperties{}
+	p.Wnode.BufferSize = uint32(unsafe.Sizeof(*p))
+	p.Wnode.Flags = wnodeFlagTracedGUID
+	p.Wnode.ClientContext = clientContextSystemTime
+	p.BufferSize = bufferSize
+	p.LogFileMode = eventTraceRealTimeMode
+	p.LoggerNameOffset = uint32(unsafe.Offsetof(p.loggerName))
+
+	return p
+}
+
+// startSession creates a real time session. A session with the same name, left by a previous run, is stopped first.
+func startSession(name string, bufferSize uint32) (uint64, error) {
+	namePtr, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+
+	var handle uint64
+
+	for range 2 {
+		props := newSessionProperties(bufferSize)
+
+		r, _, _ := procStartTraceW.Call(
+			uintptr(unsafe.Pointer(&handle)),
+			uintptr(unsafe.Pointer(namePtr)),
+			uintptr(unsafe.Pointer(props)))
+
+		switch windows.Errno(r) {
+		case windows.ERROR_SUCCESS:
+			return handle, nil
+		case windows.ERROR_ALREADY_EXISTS:
+			if err := stopSession(name); err != nil {
+				return 0, fmt.Errorf("stopping existing session %s: %w", name, err)
+			}
+		case windows.ERROR_ACCESS_DENIED:
+			return 0, fmt.Errorf("starting ETW session %s: %w (crowdsec must run as administrator)", name, windows.Errno(r))
+		default:
+			return 0, fmt.Errorf("starting ETW session %s: %w", name, windows.Errno(r))
+		}
+	}
+
+	return 0, fmt.Errorf("starting ETW session %s: %w", name, windows.ERROR_ALREADY_EXISTS)
+}
+
+func stopSession(name string) error {
+	namePtr, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+
+	props := newSessionProperties(0)
+	props.LogFileNameOffset = uint32(unsafe.Offsetof(props.logFileName))
+
+	r, _, _ := procControlTraceW.Call(
+		0,
+		uintptr(unsafe.Pointer(namePtr)),
+		uintptr(unsafe.Pointer(props)),
+		eventTraceControlStop)
+	if r != 0 && windows.Errno(r) != windows.ERROR_WMI_INSTANCE_NOT_FOUND {
+		return windows.Errno(r)
+	}
+
+	return nil
+}
+
+func enableProvider(session uint64, p provider) error {
+	r, _, _ := procEnableTraceEx2.Call(
+		uintptr(session),
+		uintptr(unsafe.Pointer(&p.guid)),
+		eventControlCodeEnableProvider,
+		uintptr(p.level),
+		uintptr(p.keywords),
+		0, // MatchAllKeyword
+		0, // Timeout: asynchronous
+		0) // EnableParameters
+	if r != 0 {
+		return fmt.Errorf("enabling provider %s %s: %w", p.name, p.guid, windows.Errno(r))
+	}
+
+	return nil
+}
+
+// openTrace opens a consumer for the session. The events are passed to callback, with context as UserContext.
+func openTrace(name string, callback uintptr, context uintptr) (uint64, error) {
+	namePtr, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+
+	logfile := eventTraceLogfile{
+		LoggerName:          namePtr,
+		ProcessTraceMode:    processTraceModeRealTime | processTraceModeEventRecord,
+		EventRecordCallback: callback,
+		Context:             context,
+	}
+
+	r, _, err := procOpenTraceW.Call(uintptr(unsafe.Pointer(&logfile)))
+	if uint64(r) == invalidProcessTraceHandle {
+		return 0, fmt.Errorf("opening ETW session %s: %w", name, err)
+	}
+
+	return uint64(r), nil
+}
+
+// processTrace blocks until the trace is closed or the session is stopped.
+func processTrace(handle uint64) error {
+	r, _, _ := procProcessTrace.Call(uintptr(unsafe.Pointer(&handle)), 1, 0, 0)
+
+	switch windows.Errno(r) {
+	case windows.ERROR_SUCCESS, windows.ERROR_CANCELLED:
+		return nil
+	default:
+		return fmt.Errorf("processing ETW events: %w", windows.Errno(r))
+	}
+}
+
+func closeTrace(handle uint64) {
+	_, _, _ = procCloseTrace.Call(uintptr(handle))
+}
+
+type providerEnumerationInfo struct {
+	NumberOfProviders uint32
+	Reserved          uint32
+}
+
+type traceProviderInfo struct {
+	ProviderGUID       guid
+	SchemaSource       uint32
+	ProviderNameOffset uint32
+}
+
+// registeredProviders returns the GUID of the providers registered on the system, by lowercase name.
+func registeredProviders() (map[string]guid, error) {
+	size := uint32(64 * 1024)
+
+	var buf []byte
+
+	for {
+		buf = make([]byte, size)
+
+		r, _, _ := procTdhEnumerateProviders.Call(uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&size)))
+		if windows.Errno(r) == windows.ERROR_INSUFFICIENT_BUFFER {
+			continue
+		}
+
+		if r != 0 {
+			return nil, fmt.Errorf("listing ETW providers: %w", windows.Errno(r))
+		}
+
+		break
+	}
+
+	info := (*providerEnumerationInfo)(unsafe.Pointer(&buf[0]))
+	providers := unsafe.Slice((*traceProviderInfo)(unsafe.Add(unsafe.Pointer(&buf[0]), unsafe.Sizeof(*info))), info.NumberOfProviders)
+
+	ret := make(map[string]guid, len(providers))
+
+	for _, p := range providers {
+		ret[strings.ToLower(utf16At(buf, p.ProviderNameOffset))] = p.ProviderGUID
+	}
+
+	return ret, nil
+}
+
+// utf16At reads the null-terminated string at offset in buf.
+func utf16At(buf []byte, offset uint32) string {
+	if offset == 0 || int(offset) >= len(buf) {
+		return ""
+	}
+
+	s := unsafe.Slice((*uint16)(unsafe.Pointer(&buf[offset])), (len(buf)-int(offset))/2)
+
+	return windows.UTF16ToString(s)
+}
@@ -0,0 +1,283 @@
+package etwacquisition
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	propertyStruct      = 0x1
+	propertyParamLength = 0x2
+	propertyParamCount  = 0x4
+
+	tdhInTypeInt8   = 3
+	tdhInTypeUint8  = 4
+	tdhInTypeInt16  = 5
+	tdhInTypeUint16 = 6
+	tdhInTypeInt32  = 7
+	tdhInTypeUint32 = 8
+	tdhInTypeBinary = 14
+
+	tdhOutTypeIPv6 = 24
+
+	eventHeaderFlag32BitHeader = 0x0020
+)
+
+type traceEventInfo struct {
+	ProviderGUID          guid
+	EventGUID             guid
+	EventDescriptor       eventDescriptor
+	DecodingSource        uint32
+	ProviderNameOffset    uint32
+	LevelNameOffset       uint32
+	ChannelNameOffset     uint32
+	KeywordsNameOffset    uint32
+	TaskNameOffset        uint32
+	OpcodeNameOffset      uint32
+	EventMessageOffset    uint32
+	ProviderMessageOffset uint32
+	BinaryXMLOffset       uint32
+	BinaryXMLSize         uint32
+	EventNameOffset       uint32
+	EventAttributesOffset uint32
+	PropertyCount         uint32
+	TopLevelPropertyCount uint32
+	Flags                 uint32
+}
+
+type eventPropertyInfo struct {
+	Flags      uint32
+	NameOffset uint32
+	// StructStartIndex and NumOfStructMembers for structures
+	InType        uint16
+	OutType       uint16
+	MapNameOffset uint32
+	Count         uint16
+	Length        uint16
+	Reserved      uint32
+}
+
+// eventInformation returns the TRACE_EVENT_INFO of the event, from the manifest of the provider.
+func eventInformation(rec *eventRecord) ([]byte, error) {
+	size := uint32(4096)
+
+	for {
+		buf := make([]byte, size)
+
+		r, _, _ := procTdhGetEventInformation.Call(
+			uintptr(unsafe.Pointer(rec)),
+			0, 0,
+			uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(unsafe.Pointer(&size)))
+
+		switch windows.Errno(r) {
+		case windows.ERROR_SUCCESS:
+			return buf, nil
+		case windows.ERROR_INSUFFICIENT_BUFFER:
+			continue
+		default:
+			return nil, fmt.Errorf("getting event information: %w", windows.Errno(r))
+		}
+	}
+}
+
+// mapInformation returns the EVENT_MAP_INFO used to format a value map or bitmap, nil if there is none.
+func mapInformation(rec *eventRecord, info []byte, offset uint32) []byte {
+	if offset == 0 {
+		return nil
+	}
+
+	name := unsafe.Pointer(&info[offset])
+	size := uint32(1024)
+
+	for {
+		buf := make([]byte, size)
+
+		r, _, _ := procTdhGetEventMapInformation.Call(
+			uintptr(unsafe.Pointer(rec)),
+			uintptr(name),
+			uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(unsafe.Pointer(&size)))
+
+		switch windows.Errno(r) {
+		case windows.ERROR_SUCCESS:
+			return buf
+		case windows.ERROR_INSUFFICIENT_BUFFER:
+			continue
+		default:
+			// the raw value is formatted instead
+			return nil
+		}
+	}
+}
+
+// formatProperty formats one value at the start of data, and returns the number of bytes it used.
+func formatProperty(info []byte, mapInfo []byte, pointerSize uint32, inType uint16, outType uint16, length uint16, data []byte) (string, uint16, error) {
+	var mapPtr, dataPtr uintptr
+
+	if mapInfo != nil {
+		mapPtr = uintptr(unsafe.Pointer(&mapInfo[0]))
+	}
+
+	if len(data) > 0 {
+		dataPtr = uintptr(unsafe.Pointer(&data[0]))
+	}
+
+	out := make([]uint16, 256)
+
+	for {
+		size := uint32(len(out) * 2)
+
+		var consumed uint16
+
+		r, _, _ := procTdhFormatProperty.Call(
+			uintptr(unsafe.Pointer(&info[0])),
+			mapPtr,
+			uintptr(pointerSize),
+			uintptr(inType),
+			uintptr(outType),
+			uintptr(length),
+			uintptr(len(data)),
+			dataPtr,
+			uintptr(unsafe.Pointer(&size)),
+			uintptr(unsafe.Pointer(&out[0])),
+			uintptr(unsafe.Pointer(&consumed)))
+
+		switch windows.Errno(r) {
+		case windows.ERROR_SUCCESS:
+			return windows.UTF16ToString(out), consumed, nil
+		case windows.ERROR_INSUFFICIENT_BUFFER:
+			out = make([]uint16, size/2+1)
+		default:
+			return "", 0, windows.Errno(r)
+		}
+	}
+}
+
+// integerValue reads the value of an integer property, used as length or count of the following properties.
+func integerValue(inType uint16, data []byte) (uint32, bool) {
+	switch inType {
+	case tdhInTypeInt8, tdhInTypeUint8:
+		if len(data) >= 1 {
+			return uint32(data[0]), true
+		}
+	case tdhInTypeInt16, tdhInTypeUint16:
+		if len(data) >= 2 {
+			return uint32(binary.LittleEndian.Uint16(data)), true
+		}
+	case tdhInTypeInt32, tdhInTypeUint32:
+		if len(data) >= 4 {
+			return binary.LittleEndian.Uint32(data), true
+		}
+	}
+
+	return 0, false
+}
+
+// decodeRecord formats the top level properties of the event. Structures are not supported: decoding stops
+// at the first one, and the record has the properties read until then.
+func decodeRecord(rec *eventRecord) (record, error) {
+	h := rec.EventHeader
+
+	ft := windows.Filetime{
+		LowDateTime:  uint32(h.TimeStamp),
+		HighDateTime: uint32(h.TimeStamp >> 32),
+	}
+
+	r := record{
+		ProviderGUID: h.ProviderID.String(),
+		EventID:      h.EventDescriptor.ID,
+		Version:      h.EventDescriptor.Version,
+		Level:        h.EventDescriptor.Level,
+		Task:         h.EventDescriptor.Task,
+		Opcode:       h.EventDescriptor.Opcode,
+		Keywords:     h.EventDescriptor.Keyword,
+		Timestamp:    time.Unix(0, ft.Nanoseconds()).UTC(),
+		ProcessID:    h.ProcessID,
+		ThreadID:     h.ThreadID,
+		Data:         map[string]any{},
+	}
+
+	info, err := eventInformation(rec)
+	if err != nil {
+		return r, err
+	}
+
+	tei := (*traceEventInfo)(unsafe.Pointer(&info[0]))
+	r.Provider = utf16At(info, tei.ProviderNameOffset)
+
+	properties := unsafe.Slice((*eventPropertyInfo)(unsafe.Add(unsafe.Pointer(&info[0]), unsafe.Sizeof(*tei))), tei.PropertyCount)
+
+	pointerSize := uint32(8)
+	if h.Flags&eventHeaderFlag32BitHeader != 0 {
+		pointerSize = 4
+	}
+
+	var data []byte
+	if rec.UserDataLength > 0 {
+		data = unsafe.Slice((*byte)(rec.UserData), rec.UserDataLength)
+	}
+
+	// values of the integer properties, by index
+	integers := make(map[uint16]uint32)
+
+	for i := range uint16(min(tei.TopLevelPropertyCount, tei.PropertyCount)) {
+		p := properties[i]
+		name := utf16At(info, p.NameOffset)
+
+		if p.Flags&propertyStruct != 0 {
+			return r, fmt.Errorf("property %s: structures are not supported", name)
+		}
+
+		count := p.Count
+		if p.Flags&propertyParamCount != 0 {
+			count = uint16(integers[p.Count])
+		}
+
+		length := p.Length
+		if p.Flags&propertyParamLength != 0 {
+			length = uint16(integers[p.Length])
+		}
+
+		if p.InType == tdhInTypeBinary && p.OutType == tdhOutTypeIPv6 && length == 0 {
+			length = 16
+		}
+
+		if v, ok := integerValue(p.InType, data); ok {
+			integers[i] = v
+		}
+
+		mapInfo := mapInformation(rec, info, p.MapNameOffset)
+
+		values := make([]any, 0, count)
+
+		for range count {
+			if len(data) == 0 {
+				break
+			}
+
+			value, consumed, err := formatProperty(info, mapInfo, pointerSize, p.InType, p.OutType, length, data)
+			if err != nil {
+				return r, fmt.Errorf("property %s: %w", name, err)
+			}
+
+			values = append(values, value)
+			data = data[consumed:]
+		}
+
+		switch {
+		case len(values) == 0:
+			r.Data[name] = ""
+		case count == 1 && p.Flags&propertyParamCount == 0:
+			r.Data[name] = values[0]
+		default:
+			r.Data[name] = values
+		}
+	}
+
+	return r, nil
+}
@@ -0,0 +1,5 @@
+//go:build !no_datasource_exec
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/exec" // register the datasource
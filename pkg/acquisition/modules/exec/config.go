@@ -0,0 +1,180 @@
+package execacquisition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+// restart policies, applied when the command exits in tail mode
+const (
+	RestartAlways    = "always"
+	RestartOnFailure = "on-failure"
+	RestartNever     = "never"
+)
+
+type Configuration struct {
+	configuration.DataSourceCommonCfg `yaml:",inline"`
+
+	Command         string        `yaml:"command"`
+	Args            []string      `yaml:"args"`
+	Restart         string        `yaml:"restart"`           // always, on-failure or never
+	RestartDelay    time.Duration `yaml:"restart_delay"`     // delay before the first restart, doubled after each failed run
+	MaxRestartDelay time.Duration `yaml:"max_restart_delay"` // upper bound of the restart delay
+}
+
+func ConfigurationFromYAML(y []byte) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.UnmarshalWithOptions(y, &cfg, yaml.Strict()); err != nil {
+		return cfg, fmt.Errorf("cannot parse: %s", yaml.FormatError(err, false, false))
+	}
+
+	cfg.SetDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Configuration) SetDefaults() {
+	if c.Mode == "" {
+		c.Mode = configuration.TAIL_MODE
+	}
+
+	if c.Restart == "" {
+		c.Restart = RestartAlways
+	}
+
+	if c.RestartDelay == 0 {
+		c.RestartDelay = time.Second
+	}
+
+	if c.MaxRestartDelay == 0 {
+		c.MaxRestartDelay = max(time.Minute, c.RestartDelay)
+	}
+}
+
+func (c *Configuration) Validate() error {
+	if c.Command == "" {
+		return errors.New("command is required")
+	}
+
+	switch c.Restart {
+	case RestartAlways, RestartOnFailure, RestartNever:
+	default:
+		return fmt.Errorf("invalid restart policy %q, must be one of: %s, %s, %s", c.Restart, RestartAlways, RestartOnFailure, RestartNever)
+	}
+
+	if c.RestartDelay < 0 {
+		return errors.New("restart_delay can't be negative")
+	}
+
+	if c.MaxRestartDelay < c.RestartDelay {
+		return errors.New("max_restart_delay can't be lower than restart_delay")
+	}
+
+	return nil
+}
+
+func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
+	cfg, err := ConfigurationFromYAML(yamlConfig)
+	if err != nil {
+		return err
+	}
+
+	s.config = cfg
+
+	s.setSrc()
+
+	return nil
+}
+
+func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
+	if err := s.UnmarshalConfig(yamlConfig); err != nil {
+		return err
+	}
+
+	if _, err := exec.LookPath(s.config.Command); err != nil {
+		return fmt.Errorf("command %q: %w", s.config.Command, err)
+	}
+
+	s.setLogger(logger, 0)
+
+	s.metricsLevel = metricsLevel
+
+	return nil
+}
+
+func (s *Source) ConfigureByDSN(_ context.Context, dsn string, labels map[string]string, logger *log.Entry, uuid string) error {
+	var (
+		args     []string
+		logLevel log.Level
+	)
+
+	// format for the DSN is : exec://COMMAND?args=ARG1&args=ARG2
+	if !strings.HasPrefix(dsn, "exec://") {
+		return fmt.Errorf("invalid DSN %s for exec source, must start with exec://", dsn)
+	}
+
+	command, qs, _ := strings.Cut(strings.TrimPrefix(dsn, "exec://"), "?")
+	if command == "" {
+		return errors.New("empty exec:// DSN")
+	}
+
+	params, err := url.ParseQuery(qs)
+	if err != nil {
+		return fmt.Errorf("could not parse exec DSN: %w", err)
+	}
+
+	for key, value := range params {
+		switch key {
+		case "args":
+			args = append(args, value...)
+		case "log_level":
+			if len(value) != 1 {
+				return errors.New("expected exactly one value for 'log_level'")
+			}
+
+			lvl, err := log.ParseLevel(value[0])
+			if err != nil {
+				return err
+			}
+
+			logLevel = lvl
+		default:
+			return fmt.Errorf("unsupported key %s in exec DSN", key)
+		}
+	}
+
+	if _, err := exec.LookPath(command); err != nil {
+		return fmt.Errorf("command %q: %w", command, err)
+	}
+
+	s.config = Configuration{
+		DataSourceCommonCfg: configuration.DataSourceCommonCfg{
+			Mode:     configuration.CAT_MODE,
+			Labels:   labels,
+			UniqueId: uuid,
+		},
+		Command: command,
+		Args:    args,
+	}
+
+	s.setSrc()
+	s.setLogger(logger, logLevel)
+
+	return nil
+}
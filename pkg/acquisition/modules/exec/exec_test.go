@@ -0,0 +1,277 @@
+package execacquisition
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func TestConfigure(t *testing.T) {
+	cstest.SkipOnWindows(t)
+
+	ctx := t.Context()
+
+	tests := []struct {
+		config  string
+		wantErr string
+	}{
+		{
+			config:  "source: exec",
+			wantErr: "command is required",
+		},
+		{
+			config: `
+source: exec
+command: sh
+foobar: 42`,
+			wantErr: `[4:1] unknown field "foobar"`,
+		},
+		{
+			config: `
+source: exec
+command: sh
+restart: sometimes`,
+			wantErr: `invalid restart policy "sometimes", must be one of: always, on-failure, never`,
+		},
+		{
+			config: `
+source: exec
+command: sh
+restart_delay: 2m
+max_restart_delay: 1m`,
+			wantErr: "max_restart_delay can't be lower than restart_delay",
+		},
+		{
+			config: `
+source: exec
+command: /does/not/exist`,
+			wantErr: `command "/does/not/exist": exec: "/does/not/exist": stat /does/not/exist: no such file or directory`,
+		},
+		{
+			config: `
+source: exec
+command: sh
+args: ["-c", "echo foo"]
+restart: on-failure
+restart_delay: 5s`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.config, func(t *testing.T) {
+			f := Source{}
+			logger, _ := logtest.NewNullLogger()
+			err := f.Configure(ctx, []byte(tc.config), logrus.NewEntry(logger), metrics.AcquisitionMetricsLevelNone)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestConfigureDSN(t *testing.T) {
+	cstest.SkipOnWindows(t)
+
+	ctx := t.Context()
+
+	tests := []struct {
+		dsn      string
+		wantErr  string
+		wantArgs []string
+	}{
+		{
+			dsn:     "asd://",
+			wantErr: "invalid DSN asd:// for exec source, must start with exec://",
+		},
+		{
+			dsn:     "exec://",
+			wantErr: "empty exec:// DSN",
+		},
+		{
+			dsn:     "exec://sh?foobar=42",
+			wantErr: "unsupported key foobar in exec DSN",
+		},
+		{
+			dsn:     "exec://sh?args=%ZZ",
+			wantErr: "could not parse exec DSN: invalid URL escape \"%ZZ\"",
+		},
+		{
+			dsn:     "exec://sh?log_level=foobar",
+			wantErr: `not a valid logrus Level: "foobar"`,
+		},
+		{
+			dsn:      "exec://sh?args=-c&args=echo+foo&log_level=warn",
+			wantArgs: []string{"-c", "echo foo"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.dsn, func(t *testing.T) {
+			f := Source{}
+			logger, _ := logtest.NewNullLogger()
+			err := f.ConfigureByDSN(ctx, tc.dsn, map[string]string{"type": "testtype"}, logrus.NewEntry(logger), "")
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+
+			if tc.wantErr != "" {
+				return
+			}
+
+			assert.Equal(t, "sh", f.config.Command)
+			assert.Equal(t, tc.wantArgs, f.config.Args)
+		})
+	}
+}
+
+func TestOneShot(t *testing.T) {
+	cstest.SkipOnWindows(t)
+
+	ctx := t.Context()
+
+	tests := []struct {
+		name      string
+		script    string
+		wantErr   string
+		wantLines []string
+	}{
+		{
+			name:      "clean exit",
+			script:    "echo line1; echo line2 >&2; echo line3",
+			wantLines: []string{"line1", "line3"},
+		},
+		{
+			name:      "failure",
+			script:    "echo line1; exit 3",
+			wantLines: []string{"line1"},
+			wantErr:   "command exited with error: exit status 3",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := Source{}
+			logger, _ := logtest.NewNullLogger()
+			err := f.ConfigureByDSN(ctx, "exec://sh?args=-c&args="+url.QueryEscape(tc.script), map[string]string{"type": "testtype"}, logrus.NewEntry(logger), "")
+			require.NoError(t, err)
+
+			out := make(chan pipeline.Event, 10)
+
+			err = f.OneShot(ctx, out)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+
+			close(out)
+
+			lines := []string{}
+			for evt := range out {
+				lines = append(lines, evt.Line.Raw)
+				assert.Equal(t, "testtype", evt.Line.Labels["type"])
+				assert.Equal(t, ModuleName, evt.Line.Module)
+			}
+
+			assert.Equal(t, tc.wantLines, lines)
+		})
+	}
+}
+
+// streamLines runs the datasource in tail mode and returns the lines read until the timeout.
+func streamLines(t *testing.T, config string, timeout time.Duration) []string {
+	t.Helper()
+
+	f := Source{}
+	logger, _ := logtest.NewNullLogger()
+	err := f.Configure(t.Context(), []byte(config), logrus.NewEntry(logger), metrics.AcquisitionMetricsLevelFull)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), timeout)
+	defer cancel()
+
+	out := make(chan pipeline.Event)
+	done := make(chan error)
+
+	go func() {
+		done <- f.Stream(ctx, out)
+	}()
+
+	lines := []string{}
+
+	for {
+		select {
+		case evt := <-out:
+			lines = append(lines, evt.Line.Raw)
+		case err := <-done:
+			require.NoError(t, err)
+			return lines
+		}
+	}
+}
+
+func TestStreamRestart(t *testing.T) {
+	cstest.SkipOnWindows(t)
+
+	tests := []struct {
+		name      string
+		restart   string
+		exitCode  string
+		wantLines int // minimum number of lines
+		maxLines  int
+	}{
+		{name: "never", restart: "never", exitCode: "1", wantLines: 1, maxLines: 1},
+		{name: "on-failure, clean exit", restart: "on-failure", exitCode: "0", wantLines: 1, maxLines: 1},
+		{name: "on-failure, failure", restart: "on-failure", exitCode: "1", wantLines: 3},
+		{name: "always", restart: "always", exitCode: "0", wantLines: 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := `
+source: exec
+command: sh
+args: ["-c", "echo ` + tc.restart + `; exit ` + tc.exitCode + `"]
+restart: ` + tc.restart + `
+restart_delay: 10ms
+max_restart_delay: 20ms
+labels:
+  type: testtype`
+
+			src := "exec-sh -c echo " + tc.restart + "; exit " + tc.exitCode
+			exitsBefore := testutil.ToFloat64(metrics.ExecDataSourceExits.With(prometheus.Labels{"source": src, "exit_code": tc.exitCode}))
+
+			lines := streamLines(t, config, 500*time.Millisecond)
+
+			assert.GreaterOrEqual(t, len(lines), tc.wantLines)
+
+			if tc.maxLines > 0 {
+				assert.LessOrEqual(t, len(lines), tc.maxLines)
+			}
+
+			for _, line := range lines {
+				assert.Equal(t, tc.restart, line)
+			}
+
+			exits := testutil.ToFloat64(metrics.ExecDataSourceExits.With(prometheus.Labels{"source": src, "exit_code": tc.exitCode}))
+			assert.InDelta(t, float64(len(lines)), exits-exitsBefore, 1)
+		})
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	cstest.SkipOnWindows(t)
+
+	// the command never exits, it is stopped with the datasource
+	lines := streamLines(t, `
+source: exec
+command: sh
+args: ["-c", "echo started; exec sleep 60"]`, 500*time.Millisecond)
+
+	assert.Equal(t, []string{"started"}, lines)
+}
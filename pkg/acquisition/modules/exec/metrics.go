@@ -0,0 +1,21 @@
+package execacquisition
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+func (*Source) GetMetrics() []prometheus.Collector {
+	return []prometheus.Collector{
+		metrics.ExecDataSourceLinesRead,
+		metrics.ExecDataSourceExits,
+	}
+}
+
+func (*Source) GetAggregMetrics() []prometheus.Collector {
+	return []prometheus.Collector{
+		metrics.ExecDataSourceLinesRead,
+		metrics.ExecDataSourceExits,
+	}
+}
@@ -0,0 +1,186 @@
+package execacquisition
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func (s *Source) OneShot(ctx context.Context, out chan pipeline.Event) error {
+	err := s.runCommand(ctx, out)
+	s.logger.Debug("Oneshot acquisition is done")
+
+	return err
+}
+
+// Stream runs the command and restarts it according to the restart policy.
+// Unlike other streamers, the datasource supervises its own process: the restart
+// policy and delay are part of the configuration, and a command that is not to be
+// restarted must not be restarted by the acquisition either.
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = s.config.RestartDelay
+	bo.MaxInterval = s.config.MaxRestartDelay
+	bo.Reset()
+
+	for {
+		started := time.Now()
+		err := s.runCommand(ctx, out)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if !s.shouldRestart(err) {
+			s.logger.Infof("Command exited (%v), not restarting it (restart: %s)", err, s.config.Restart)
+			<-ctx.Done()
+
+			return nil
+		}
+
+		// a command that ran for a while is not failing in a loop
+		if time.Since(started) > s.config.MaxRestartDelay {
+			bo.Reset()
+		}
+
+		delay := bo.NextBackOff()
+
+		if err != nil {
+			s.logger.Errorf("Command failed: %s, restarting in %s", err, delay)
+		} else {
+			s.logger.Infof("Command exited, restarting in %s", delay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (s *Source) shouldRestart(runErr error) bool {
+	switch s.config.Restart {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return runErr != nil
+	default:
+		return false
+	}
+}
+
+// runCommand runs the command once, and sends a line event for each line of its standard output.
+func (s *Source) runCommand(ctx context.Context, out chan pipeline.Event) error {
+	// to stop the command if we stop reading its output
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, s.config.Command, s.config.Args...)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("could not get stdout: %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("could not get stderr: %w", err)
+	}
+
+	s.logger.WithField("command", cmd.String()).Info("Spawning process")
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not start command: %w", err)
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Go(func() {
+		stderrScanner := bufio.NewScanner(stderr)
+		for stderrScanner.Scan() {
+			s.logger.Warnf("Got stderr: %s", stderrScanner.Text())
+		}
+	})
+
+	readErr := s.readLines(ctx, stdout, out)
+	if readErr != nil {
+		// nobody will read the output anymore
+		cancel()
+	}
+
+	wg.Wait()
+
+	cmdErr := cmd.Wait()
+
+	s.countExit(cmd)
+
+	if readErr != nil {
+		return fmt.Errorf("while reading command output: %w", readErr)
+	}
+
+	// if the context was canceled, the error is likely "signal: killed" and we ignore that
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	if cmdErr != nil {
+		return fmt.Errorf("command exited with error: %w", cmdErr)
+	}
+
+	return nil
+}
+
+func (s *Source) readLines(ctx context.Context, stdout io.Reader, out chan pipeline.Event) error {
+	scanner := bufio.NewScanner(stdout)
+
+	for scanner.Scan() {
+		line := pipeline.Line{
+			Raw:     scanner.Text(),
+			Src:     s.src,
+			Time:    time.Now().UTC(),
+			Labels:  s.config.Labels,
+			Process: true,
+			Module:  s.GetName(),
+		}
+
+		s.logger.Debugf("getting one line: %s", line.Raw)
+
+		if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+			metrics.ExecDataSourceLinesRead.With(prometheus.Labels{"source": s.src, "datasource_type": ModuleName, "acquis_type": line.Labels["type"]}).Inc()
+		}
+
+		evt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+		evt.Line = line
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case out <- evt:
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (s *Source) countExit(cmd *exec.Cmd) {
+	if s.metricsLevel == metrics.AcquisitionMetricsLevelNone || cmd.ProcessState == nil {
+		return
+	}
+
+	// -1 when the process was killed by a signal
+	exitCode := strconv.Itoa(cmd.ProcessState.ExitCode())
+
+	metrics.ExecDataSourceExits.With(prometheus.Labels{"source": s.src, "exit_code": exitCode}).Inc()
+}
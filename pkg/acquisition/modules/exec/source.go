@@ -0,0 +1,47 @@
+package execacquisition
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+type Source struct {
+	metricsLevel metrics.AcquisitionMetricsLevel
+	config       Configuration
+	logger       *log.Entry
+	src          string // specific source name (i.e. exec-<command line>)
+}
+
+func (s *Source) GetUuid() string {
+	return s.config.UniqueId
+}
+
+func (s *Source) GetMode() string {
+	return s.config.Mode
+}
+
+func (*Source) GetName() string {
+	return ModuleName
+}
+
+func (*Source) CanRun() error {
+	return nil
+}
+
+func (s *Source) Dump() any {
+	return s
+}
+
+func (s *Source) setSrc() {
+	s.src = "exec-" + strings.Join(append([]string{s.config.Command}, s.config.Args...), " ")
+}
+
+func (s *Source) setLogger(logger *log.Entry, level log.Level) {
+	s.logger = logger.WithField("src", s.src)
+	if level != 0 {
+		s.logger.Logger.SetLevel(level)
+	}
+}
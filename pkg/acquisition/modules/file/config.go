@@ -29,6 +29,8 @@ type Configuration struct {
 	PollWithoutInotify                *bool         `yaml:"poll_without_inotify"`
 	DiscoveryPollEnable               bool          `yaml:"discovery_poll_enable"`
 	DiscoveryPollInterval             time.Duration `yaml:"discovery_poll_interval"`
+	Format                            string        `yaml:"format"` // lines (default), csv or tsv
+	CSV                               *CSVConfig    `yaml:"csv"`
 	configuration.DataSourceCommonCfg `yaml:",inline"`
 }
 
@@ -60,6 +62,10 @@ func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
 		return fmt.Errorf("unsupported mode %s for file source", s.config.Mode)
 	}
 
+	if err := s.config.validateFormat(); err != nil {
+		return err
+	}
+
 	for _, exclude := range s.config.ExcludeRegexps {
 		re, err := regexp.Compile(exclude)
 		if err != nil {
@@ -72,6 +78,34 @@ func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
 	return nil
 }
 
+func (c *Configuration) validateFormat() error {
+	switch c.Format {
+	case "":
+		c.Format = FormatLines
+	case FormatLines, FormatCSV, FormatTSV:
+	default:
+		return fmt.Errorf("unknown format '%s': must be %s, %s or %s", c.Format, FormatLines, FormatCSV, FormatTSV)
+	}
+
+	if c.Format == FormatLines {
+		if c.CSV != nil {
+			return fmt.Errorf("csv options require the %s or %s format", FormatCSV, FormatTSV)
+		}
+
+		return nil
+	}
+
+	if c.CSV == nil {
+		c.CSV = &CSVConfig{}
+	}
+
+	if err := c.CSV.validate(c.Format); err != nil {
+		return fmt.Errorf("csv: %w", err)
+	}
+
+	return nil
+}
+
 func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
 	s.logger = logger
 	s.metricsLevel = metricsLevel
@@ -113,6 +147,12 @@ func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Ent
 			return fmt.Errorf("glob failure: %w", err)
 		}
 
+		// watch the directories that can hold matching files even if there are none yet,
+		// so the new ones are tailed as soon as they are created
+		if isGlob(pattern) && s.config.Mode == configuration.TAIL_MODE {
+			s.watchPatternDirectories(pattern)
+		}
+
 		if len(files) == 0 {
 			s.logger.Warnf("No matching files for pattern %s", pattern)
 			continue
@@ -123,23 +163,6 @@ func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Ent
 				continue
 			}
 
-			if files[0] != pattern && s.config.Mode == configuration.TAIL_MODE { // we have a glob pattern
-				directory := filepath.Dir(file)
-				s.logger.Debugf("Will add watch to directory: %s", directory)
-
-				if !s.watchedDirectories[directory] {
-					err = s.watcher.Add(directory)
-					if err != nil {
-						s.logger.Errorf("Could not create watch on directory %s : %s", directory, err)
-						continue
-					}
-
-					s.watchedDirectories[directory] = true
-				} else {
-					s.logger.Debugf("Watch for directory %s already exists", directory)
-				}
-			}
-
 			s.logger.Infof("Adding file %s to datasources", file)
 			s.files = append(s.files, file)
 		}
@@ -194,12 +217,22 @@ func (s *Source) ConfigureByDSN(_ context.Context, dsn string, labels map[string
 				}
 
 				s.config.MaxBufferSize = maxBufferSize
+			case "format":
+				if len(value) != 1 {
+					return errors.New("expected zero or one value for 'format'")
+				}
+
+				s.config.Format = value[0]
 			default:
 				return fmt.Errorf("unknown parameter %s", key)
 			}
 		}
 	}
 
+	if err := s.config.validateFormat(); err != nil {
+		return err
+	}
+
 	s.config.Labels = labels
 	s.config.Mode = configuration.CAT_MODE
 	s.config.UniqueId = uuid
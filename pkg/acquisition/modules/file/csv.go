@@ -0,0 +1,304 @@
+package fileacquisition
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const (
+	FormatLines = "lines"
+	FormatCSV   = "csv"
+	FormatTSV   = "tsv"
+
+	defaultCSVStage = "s02-enrich"
+
+	// how many lines are read from the start of a file to find its header, when tailing it
+	maxHeaderLines = 100
+)
+
+// CSVConfig configures the csv and tsv formats. The rows are decoded into the Parsed fields
+// with the names of the columns, from the header row of each file, and skip the parsing stages
+// that would run grok on them. A row can't span several lines.
+type CSVConfig struct {
+	Delimiter string `yaml:"delimiter"` // "," for csv, tab for tsv
+	// names of the columns, if the files have no header row. A "#Fields:" line (W3C extended
+	// log format, like IIS) is used as the header as well.
+	Columns []string `yaml:"columns"`
+	// type of the columns that are checked and converted in evt.Unmarshaled.csv: int, float,
+	// bool, ip or time. The other columns are strings.
+	Types map[string]string `yaml:"types"`
+	// layout of the time columns (Go reference time), RFC 3339 by default
+	TimeLayout string `yaml:"time_layout"`
+	// column with the time of the event
+	TimestampField string `yaml:"timestamp_field"`
+	// meta of the event, from the columns
+	Meta map[string]string `yaml:"meta"`
+	// first stage of the parsers for the events, s02-enrich by default
+	Stage string `yaml:"stage"`
+}
+
+var csvTypes = []string{"int", "float", "bool", "ip", "time"}
+
+func (c *CSVConfig) validate(format string) error {
+	if c.Delimiter == "" {
+		c.Delimiter = ","
+		if format == FormatTSV {
+			c.Delimiter = "\t"
+		}
+	}
+
+	if utf8.RuneCountInString(c.Delimiter) != 1 || strings.ContainsAny(c.Delimiter, "\"\r\n") {
+		return fmt.Errorf("invalid delimiter %q: must be a single character", c.Delimiter)
+	}
+
+	for _, column := range c.Columns {
+		if strings.TrimSpace(column) == "" {
+			return errors.New("columns: empty column name")
+		}
+	}
+
+	for column, typ := range c.Types {
+		if !slices.Contains(csvTypes, typ) {
+			return fmt.Errorf("types: unknown type '%s' for column %s: must be one of %s", typ, column, strings.Join(csvTypes, ", "))
+		}
+	}
+
+	if c.TimeLayout == "" {
+		c.TimeLayout = time.RFC3339
+	}
+
+	if c.Stage == "" {
+		c.Stage = defaultCSVStage
+	}
+
+	return nil
+}
+
+// csvDecoder decodes the rows of a file. The header can change when the file is rotated.
+type csvDecoder struct {
+	cfg        *CSVConfig
+	delimiter  rune
+	header     []string
+	headerLine string
+}
+
+func newCSVDecoder(cfg *CSVConfig) *csvDecoder {
+	delimiter, _ := utf8.DecodeRuneInString(cfg.Delimiter)
+
+	return &csvDecoder{
+		cfg:       cfg,
+		delimiter: delimiter,
+		header:    cfg.Columns,
+	}
+}
+
+func (d *csvDecoder) record(line string) ([]string, error) {
+	r := csv.NewReader(strings.NewReader(line))
+	r.Comma = d.delimiter
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+
+	return r.Read()
+}
+
+// consume returns true if the line is not a row: a comment, or the header of the file.
+func (d *csvDecoder) consume(line string) (bool, error) {
+	if comment, ok := strings.CutPrefix(line, "#"); ok {
+		if fields, ok := strings.CutPrefix(strings.TrimSpace(comment), "Fields:"); ok && len(d.cfg.Columns) == 0 {
+			d.header = strings.Fields(fields)
+			d.headerLine = ""
+		}
+
+		return true, nil
+	}
+
+	if line == d.headerLine {
+		// the file was rotated
+		return true, nil
+	}
+
+	if d.header != nil {
+		return false, nil
+	}
+
+	header, err := d.record(strings.TrimPrefix(line, "\ufeff"))
+	if err != nil {
+		return true, fmt.Errorf("invalid header: %w", err)
+	}
+
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	d.header = header
+	d.headerLine = line
+
+	return true, nil
+}
+
+// readHeader reads the header from the start of a file, before it's tailed from the end.
+func (d *csvDecoder) readHeader(filename string) error {
+	if d.header != nil {
+		return nil
+	}
+
+	fd, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	scanner := bufio.NewScanner(fd)
+
+	for range maxHeaderLines {
+		if !scanner.Scan() {
+			break
+		}
+
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		if _, err := d.consume(line); err != nil {
+			return err
+		}
+
+		if d.header != nil {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
+
+func convertCSV(typ string, value string, layout string) (any, error) {
+	switch typ {
+	case "int":
+		return strconv.ParseInt(value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "bool":
+		return strconv.ParseBool(value)
+	case "ip":
+		if net.ParseIP(value) == nil {
+			return nil, errors.New("not an IP address")
+		}
+
+		return value, nil
+	case "time":
+		return time.Parse(layout, value)
+	}
+
+	return value, nil
+}
+
+// decode fills the event from a row. It returns false if the line is not a row.
+func (d *csvDecoder) decode(evt *pipeline.Event) (bool, error) {
+	skip, err := d.consume(evt.Line.Raw)
+	if skip || err != nil {
+		return false, err
+	}
+
+	record, err := d.record(evt.Line.Raw)
+	if err != nil {
+		return false, err
+	}
+
+	if len(record) != len(d.header) {
+		return false, fmt.Errorf("expected %d fields, got %d", len(d.header), len(record))
+	}
+
+	parsed := make(map[string]string, len(record))
+	row := make(map[string]any, len(record))
+
+	for i, value := range record {
+		column := d.header[i]
+		parsed[column] = value
+		row[column] = value
+
+		typ, ok := d.cfg.Types[column]
+		if !ok || value == "" {
+			continue
+		}
+
+		v, err := convertCSV(typ, value, d.cfg.TimeLayout)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s '%s': not a %s", column, value, typ)
+		}
+
+		row[column] = v
+	}
+
+	if evt.Parsed == nil {
+		evt.Parsed = make(map[string]string)
+	}
+
+	if evt.Meta == nil {
+		evt.Meta = make(map[string]string)
+	}
+
+	if evt.Unmarshaled == nil {
+		evt.Unmarshaled = make(map[string]any)
+	}
+
+	maps.Copy(evt.Parsed, parsed)
+
+	for key, column := range d.cfg.Meta {
+		if v, ok := parsed[column]; ok {
+			evt.Meta[key] = v
+		}
+	}
+
+	evt.Unmarshaled[FormatCSV] = row
+
+	if d.cfg.TimestampField != "" {
+		switch ts := row[d.cfg.TimestampField].(type) {
+		case time.Time:
+			evt.StrTime = ts.Format(time.RFC3339Nano)
+		case string:
+			evt.StrTime = ts
+		}
+	}
+
+	evt.Stage = d.cfg.Stage
+
+	return true, nil
+}
+
+// newDecoder returns a decoder for the rows of a file, or nil with the lines format.
+func (s *Source) newDecoder() *csvDecoder {
+	if s.config.CSV == nil {
+		return nil
+	}
+
+	return newCSVDecoder(s.config.CSV)
+}
+
+// decodeRow fills the event from a row. It returns false if the event must not be sent: the
+// line is the header or a comment, or it's not a valid row.
+func (s *Source) decodeRow(dec *csvDecoder, evt *pipeline.Event, logger *log.Entry) bool {
+	ok, err := dec.decode(evt)
+	if err != nil {
+		logger.Debugf("dropping line: %s", err)
+		metrics.StructuredDroppedLines.With(prometheus.Labels{"source": evt.Line.Src, "type": evt.Line.Module}).Inc()
+	}
+
+	return ok
+}
@@ -0,0 +1,207 @@
+package fileacquisition_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/tomb.v2"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	fileacquisition "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/file"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func TestCSVConfig(t *testing.T) {
+	ctx := t.Context()
+
+	tests := []struct {
+		name        string
+		config      string
+		expectedErr string
+	}{
+		{
+			name: "tsv",
+			config: `
+filename: testdata/test.csv
+format: tsv`,
+		},
+		{
+			name: "unknown format",
+			config: `
+filename: testdata/test.csv
+format: xml`,
+			expectedErr: "unknown format 'xml': must be lines, csv or tsv",
+		},
+		{
+			name: "csv options without format",
+			config: `
+filename: testdata/test.csv
+csv:
+  delimiter: ";"`,
+			expectedErr: "csv options require the csv or tsv format",
+		},
+		{
+			name: "bad delimiter",
+			config: `
+filename: testdata/test.csv
+format: csv
+csv:
+  delimiter: "::"`,
+			expectedErr: `csv: invalid delimiter "::": must be a single character`,
+		},
+		{
+			name: "bad type",
+			config: `
+filename: testdata/test.csv
+format: csv
+csv:
+  types:
+    status: integer`,
+			expectedErr: "csv: types: unknown type 'integer' for column status: must be one of int, float, bool, ip, time",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := fileacquisition.Source{}
+			err := f.Configure(ctx, []byte(tc.config), log.WithField("type", fileacquisition.ModuleName), metrics.AcquisitionMetricsLevelNone)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestCSVOneShot(t *testing.T) {
+	ctx := t.Context()
+
+	config := `
+mode: cat
+filename: testdata/test.csv
+format: csv
+csv:
+  types:
+    time: time
+    client_ip: ip
+    status: int
+    bytes: int
+  timestamp_field: time
+  meta:
+    source_ip: client_ip`
+
+	f := fileacquisition.Source{}
+	err := f.Configure(ctx, []byte(config), log.WithField("type", fileacquisition.ModuleName), metrics.AcquisitionMetricsLevelNone)
+	require.NoError(t, err)
+
+	out := make(chan pipeline.Event, 10)
+
+	err = f.OneShot(ctx, out)
+	require.NoError(t, err)
+
+	// the header, the short row and the invalid ip are not sent
+	require.Len(t, out, 3)
+
+	evt := <-out
+	assert.Equal(t, map[string]string{
+		"time": "2026-10-01T12:00:00Z", "client_ip": "192.0.2.1", "method": "GET",
+		"path": "/index.html", "status": "200", "bytes": "512",
+	}, evt.Parsed)
+	assert.Equal(t, map[string]string{"source_ip": "192.0.2.1"}, evt.Meta)
+	assert.Equal(t, map[string]any{
+		"time": time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), "client_ip": "192.0.2.1", "method": "GET",
+		"path": "/index.html", "status": int64(200), "bytes": int64(512),
+	}, evt.Unmarshaled["csv"])
+	assert.Equal(t, "2026-10-01T12:00:00Z", evt.StrTime)
+	assert.Equal(t, "s02-enrich", evt.Stage)
+	assert.Equal(t, pipeline.TIMEMACHINE, evt.ExpectMode)
+
+	evt = <-out
+	assert.Equal(t, "/login,admin", evt.Parsed["path"])
+	assert.Equal(t, "", evt.Unmarshaled["csv"].(map[string]any)["bytes"])
+
+	evt = <-out
+	assert.Equal(t, "192.0.2.4", evt.Meta["source_ip"])
+}
+
+func TestCSVW3C(t *testing.T) {
+	ctx := t.Context()
+
+	config := `
+mode: cat
+filename: testdata/iis.log
+format: csv
+csv:
+  delimiter: " "
+  types:
+    sc-status: int
+  meta:
+    source_ip: c-ip
+  stage: s01-parse`
+
+	f := fileacquisition.Source{}
+	err := f.Configure(ctx, []byte(config), log.WithField("type", fileacquisition.ModuleName), metrics.AcquisitionMetricsLevelNone)
+	require.NoError(t, err)
+
+	out := make(chan pipeline.Event, 10)
+
+	err = f.OneShot(ctx, out)
+	require.NoError(t, err)
+	require.Len(t, out, 2)
+
+	<-out
+	evt := <-out
+	assert.Equal(t, "/admin", evt.Parsed["cs-uri-stem"])
+	assert.Equal(t, int64(403), evt.Unmarshaled["csv"].(map[string]any)["sc-status"])
+	assert.Equal(t, "192.0.2.2", evt.Meta["source_ip"])
+	assert.Equal(t, "s01-parse", evt.Stage)
+}
+
+func TestCSVLiveAcquisition(t *testing.T) {
+	ctx := t.Context()
+	tmpDir := t.TempDir()
+	filename := filepath.Join(tmpDir, "export.tsv")
+
+	require.NoError(t, os.WriteFile(filename, []byte("user\taction\nalice\tlogin\n"), 0o600))
+
+	config := `
+mode: tail
+filename: ` + filename + `
+format: tsv`
+
+	f := fileacquisition.Source{}
+	err := f.Configure(ctx, []byte(config), log.WithField("type", fileacquisition.ModuleName), metrics.AcquisitionMetricsLevelNone)
+	require.NoError(t, err)
+
+	out := make(chan pipeline.Event, 10)
+	tb := tomb.Tomb{}
+
+	err = f.StreamingAcquisition(ctx, out, &tb)
+	require.NoError(t, err)
+
+	// the tail starts at the end, the header is read from the start of the file
+	require.Eventually(t, func() bool { return f.IsTailing(filename) }, 5*time.Second, 50*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+
+	fd, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+
+	_, err = fd.WriteString("bob\tlogout\n")
+	require.NoError(t, err)
+	require.NoError(t, fd.Close())
+
+	select {
+	case evt := <-out:
+		assert.Equal(t, map[string]string{"user": "bob", "action": "logout"}, evt.Parsed)
+		assert.Equal(t, pipeline.LIVE, evt.ExpectMode)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+	}
+
+	tb.Kill(nil)
+	require.NoError(t, tb.Wait())
+}
@@ -556,3 +556,51 @@ mode: tail
 	tomb.Kill(nil)
 	require.NoError(t, tomb.Wait())
 }
+
+func TestDiscoveryInotify(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+
+	// no file matches yet, the directories must be watched anyway
+	yamlConfig := fmt.Sprintf(`
+filenames:
+ - '%s'
+ - '%s'
+mode: tail
+`, filepath.Join(dir, "*.log"), filepath.Join(dir, "sites", "*", "access.log"))
+
+	f := &fileacquisition.Source{}
+	err := f.Configure(ctx, []byte(yamlConfig), log.NewEntry(log.New()), metrics.AcquisitionMetricsLevelNone)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sites"), 0o755))
+
+	eventChan := make(chan pipeline.Event)
+	tomb := tomb.Tomb{}
+
+	err = f.StreamingAcquisition(ctx, eventChan, &tomb)
+	require.NoError(t, err)
+
+	testFile := filepath.Join(dir, "test.log")
+	require.NoError(t, os.WriteFile(testFile, []byte("test line\n"), 0o644))
+
+	require.Eventually(t, func() bool { return f.IsTailing(testFile) }, 3*time.Second, 50*time.Millisecond,
+		"new file should be tailed")
+
+	// a new site directory, and its log file
+	siteFile := filepath.Join(dir, "sites", "example.com", "access.log")
+	require.NoError(t, os.Mkdir(filepath.Dir(siteFile), 0o755))
+	require.NoError(t, os.WriteFile(siteFile, []byte("test line\n"), 0o644))
+
+	require.Eventually(t, func() bool { return f.IsTailing(siteFile) }, 3*time.Second, 50*time.Millisecond,
+		"file in new directory should be tailed")
+
+	otherFile := filepath.Join(dir, "sites", "example.com", "error.log")
+	require.NoError(t, os.WriteFile(otherFile, []byte("test line\n"), 0o644))
+
+	time.Sleep(200 * time.Millisecond)
+	require.False(t, f.IsTailing(otherFile), "file not matching the pattern should not be tailed")
+
+	tomb.Kill(nil)
+	require.NoError(t, tomb.Wait())
+}
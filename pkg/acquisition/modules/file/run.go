@@ -9,6 +9,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"time"
 
@@ -154,11 +155,21 @@ func (s *Source) monitorNewFiles(out chan pipeline.Event, t *tomb.Tomb) error {
 				continue
 			}
 
+			if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() {
+				s.discoverDirectory(event.Name, logger, out, t)
+				continue
+			}
+
 			_ = s.checkAndTailFile(event.Name, logger, out, t)
 
 		case <-tickerChan: // Will never trigger if tickerChan is nil
 			// Poll for all configured patterns
 			for _, pattern := range s.config.Filenames {
+				if isGlob(pattern) {
+					// pick up the directories that were not seen by inotify
+					s.watchPatternDirectories(pattern)
+				}
+
 				files, err := filepath.Glob(pattern)
 				if err != nil {
 					logger.Errorf("Error globbing pattern %s during poll: %s", pattern, err)
@@ -188,6 +199,101 @@ func (s *Source) monitorNewFiles(out chan pipeline.Event, t *tomb.Tomb) error {
 	}
 }
 
+// isGlob returns true if the pattern has special characters, it can match several files.
+func isGlob(pattern string) bool {
+	magicChars := `*?[`
+	if runtime.GOOS != "windows" {
+		magicChars = `*?[\`
+	}
+
+	return strings.ContainsAny(pattern, magicChars)
+}
+
+// watchDirectory adds an inotify watch on a directory, if it's not already watched.
+func (s *Source) watchDirectory(directory string) bool {
+	if s.watchedDirectories[directory] {
+		s.logger.Debugf("Watch for directory %s already exists", directory)
+		return false
+	}
+
+	s.logger.Debugf("Will add watch to directory: %s", directory)
+
+	if err := s.watcher.Add(directory); err != nil {
+		s.logger.Errorf("Could not create watch on directory %s : %s", directory, err)
+		return false
+	}
+
+	s.watchedDirectories[directory] = true
+
+	return true
+}
+
+// watchPatternDirectories watches the existing directories that can hold files matching a glob pattern.
+// If the directory part is a glob too (ie. /var/log/sites/*/access.log), the parent directories are
+// watched as well, to detect the new sub-directories.
+func (s *Source) watchPatternDirectories(pattern string) {
+	dirPattern := filepath.Dir(pattern)
+
+	patterns := []string{dirPattern}
+	if isGlob(dirPattern) {
+		patterns = append(patterns, filepath.Dir(dirPattern))
+	}
+
+	for _, p := range patterns {
+		dirs, err := filepath.Glob(p)
+		if err != nil {
+			s.logger.Errorf("Could not glob directories %s : %s", p, err)
+			continue
+		}
+
+		for _, dir := range dirs {
+			if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+				continue
+			}
+
+			s.watchDirectory(dir)
+		}
+	}
+}
+
+// discoverDirectory handles a new directory: if it can hold files matching one of the patterns,
+// it is watched, and the files created before the watch are tailed.
+func (s *Source) discoverDirectory(directory string, logger *log.Entry, out chan pipeline.Event, t *tomb.Tomb) {
+	for _, pattern := range s.config.Filenames {
+		if !isGlob(pattern) {
+			continue
+		}
+
+		dirPattern := filepath.Dir(pattern)
+
+		matched, err := filepath.Match(dirPattern, directory)
+		if err != nil || !matched {
+			// it could be the parent of the directories to watch
+			if parentMatched, err := filepath.Match(filepath.Dir(dirPattern), directory); err == nil && parentMatched {
+				s.watchPatternDirectories(pattern)
+			}
+
+			continue
+		}
+
+		if s.watchDirectory(directory) {
+			logger.Infof("Watching new directory %s", directory)
+		}
+
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			logger.Errorf("Error globbing pattern %s: %s", pattern, err)
+			continue
+		}
+
+		for _, file := range files {
+			if filepath.Dir(file) == directory {
+				_ = s.checkAndTailFile(file, logger, out, t)
+			}
+		}
+	}
+}
+
 func (s *Source) setupTailForFile(file string, out chan pipeline.Event, seekEnd bool, t *tomb.Tomb) error {
 	logger := s.logger.WithField("file", file)
 
@@ -295,6 +401,13 @@ func (s *Source) tailFile(out chan pipeline.Event, t *tomb.Tomb, tail *tail.Tail
 	logger := s.logger.WithField("tail", tail.Filename)
 	logger.Debug("-> start tailing")
 
+	dec := s.newDecoder()
+	if dec != nil {
+		if err := dec.readHeader(tail.Filename); err != nil {
+			logger.Warningf("unable to read the header: %s", err)
+		}
+	}
+
 	for {
 		select {
 		case <-t.Dying():
@@ -361,6 +474,10 @@ func (s *Source) tailFile(out chan pipeline.Event, t *tomb.Tomb, tail *tail.Tail
 			evt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
 			evt.Line = l
 
+			if dec != nil && !s.decodeRow(dec, &evt, logger) {
+				continue
+			}
+
 			out <- evt
 		}
 	}
@@ -399,6 +516,8 @@ func (s *Source) readFile(ctx context.Context, filename string, out chan pipelin
 		scanner.Buffer(buf, s.config.MaxBufferSize)
 	}
 
+	dec := s.newDecoder()
+
 	for scanner.Scan() {
 		select {
 		case <-ctx.Done():
@@ -421,7 +540,13 @@ func (s *Source) readFile(ctx context.Context, filename string, out chan pipelin
 			metrics.FileDatasourceLinesRead.With(prometheus.Labels{"source": filename, "datasource_type": ModuleName, "acquis_type": l.Labels["type"]}).Inc()
 
 			// we're reading logs at once, it must be time-machine buckets
-			out <- pipeline.Event{Line: l, Process: true, Type: pipeline.LOG, ExpectMode: pipeline.TIMEMACHINE, Unmarshaled: make(map[string]any)}
+			evt := pipeline.Event{Line: l, Process: true, Type: pipeline.LOG, ExpectMode: pipeline.TIMEMACHINE, Unmarshaled: make(map[string]any)}
+
+			if dec != nil && !s.decodeRow(dec, &evt, logger) {
+				continue
+			}
+
+			out <- evt
 		}
 	}
 
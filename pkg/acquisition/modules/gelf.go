@@ -0,0 +1,5 @@
+//go:build !no_datasource_gelf
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/gelf" // register the datasource
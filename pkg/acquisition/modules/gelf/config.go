@@ -0,0 +1,114 @@
+package gelfacquisition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+type Configuration struct {
+	configuration.DataSourceCommonCfg `yaml:",inline"`
+
+	Proto         string        `yaml:"protocol,omitempty"` // udp or tcp
+	Addr          string        `yaml:"listen_addr,omitempty"`
+	Port          int           `yaml:"listen_port,omitempty"`
+	MaxMessageLen int           `yaml:"max_message_len,omitempty"` // maximum size of a message, after reassembly and decompression
+	ChunkTimeout  time.Duration `yaml:"chunk_timeout,omitempty"`   // incomplete chunked messages are dropped after this delay
+}
+
+func ConfigurationFromYAML(y []byte) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.UnmarshalWithOptions(y, &cfg, yaml.Strict()); err != nil {
+		return cfg, fmt.Errorf("cannot parse: %s", yaml.FormatError(err, false, false))
+	}
+
+	cfg.SetDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Configuration) SetDefaults() {
+	if c.Mode == "" {
+		c.Mode = configuration.TAIL_MODE
+	}
+
+	if c.Proto == "" {
+		c.Proto = "udp"
+	}
+
+	if c.Addr == "" {
+		c.Addr = "127.0.0.1"
+	}
+
+	if c.Port == 0 {
+		c.Port = 12201
+	}
+
+	if c.MaxMessageLen == 0 {
+		c.MaxMessageLen = 1024 * 1024
+	}
+
+	if c.ChunkTimeout == 0 {
+		// per the GELF specification
+		c.ChunkTimeout = 5 * time.Second
+	}
+}
+
+func (c *Configuration) Validate() error {
+	if c.Proto != "udp" && c.Proto != "tcp" {
+		return fmt.Errorf("invalid protocol %q, must be udp or tcp", c.Proto)
+	}
+
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("invalid port %d", c.Port)
+	}
+
+	if net.ParseIP(c.Addr) == nil {
+		return fmt.Errorf("invalid listen IP %s", c.Addr)
+	}
+
+	if c.MaxMessageLen < 0 {
+		return errors.New("max_message_len can't be negative")
+	}
+
+	if c.ChunkTimeout < 0 {
+		return errors.New("chunk_timeout can't be negative")
+	}
+
+	return nil
+}
+
+func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
+	cfg, err := ConfigurationFromYAML(yamlConfig)
+	if err != nil {
+		return err
+	}
+
+	s.config = cfg
+
+	return nil
+}
+
+func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
+	if err := s.UnmarshalConfig(yamlConfig); err != nil {
+		return err
+	}
+
+	s.logger = logger
+	s.metricsLevel = metricsLevel
+
+	return nil
+}
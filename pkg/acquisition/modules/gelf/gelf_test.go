@@ -0,0 +1,357 @@
+package gelfacquisition
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func TestConfigure(t *testing.T) {
+	ctx := t.Context()
+
+	tests := []struct {
+		config  string
+		wantErr string
+	}{
+		{
+			config: "source: gelf",
+		},
+		{
+			config: `
+source: gelf
+foobar: 42`,
+			wantErr: `[3:1] unknown field "foobar"`,
+		},
+		{
+			config: `
+source: gelf
+protocol: http`,
+			wantErr: `invalid protocol "http", must be udp or tcp`,
+		},
+		{
+			config: `
+source: gelf
+listen_port: 123456`,
+			wantErr: "invalid port 123456",
+		},
+		{
+			config: `
+source: gelf
+listen_addr: localhost`,
+			wantErr: "invalid listen IP localhost",
+		},
+		{
+			config: `
+source: gelf
+protocol: tcp
+listen_addr: 0.0.0.0
+chunk_timeout: 10s`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.config, func(t *testing.T) {
+			s := Source{}
+			logger, _ := logtest.NewNullLogger()
+			err := s.Configure(ctx, []byte(tc.config), logrus.NewEntry(logger), metrics.AcquisitionMetricsLevelNone)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+// chunks splits a message in GELF chunks of at most size bytes.
+func chunks(id string, msg []byte, size int) [][]byte {
+	var ret [][]byte
+
+	count := (len(msg) + size - 1) / size
+
+	for i := range count {
+		chunk := append([]byte{0x1e, 0x0f}, []byte(id)...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, msg[i*size:min(len(msg), (i+1)*size)]...)
+		ret = append(ret, chunk)
+	}
+
+	return ret
+}
+
+func TestChunkAssembler(t *testing.T) {
+	now := time.Now()
+	msg := []byte(`{"version":"1.1","host":"example.org","short_message":"a message split in chunks"}`)
+
+	a := newChunkAssembler(5*time.Second, 1024)
+
+	parts := chunks("abcdefgh", msg, 10)
+	require.Greater(t, len(parts), 2)
+
+	// out of order, with a duplicate
+	for _, i := range []int{1, 0, 1} {
+		ret, err := a.add("1.2.3.4", parts[i], now)
+		require.NoError(t, err)
+		assert.Nil(t, ret)
+	}
+
+	// same ID from another client is another message
+	ret, err := a.add("1.2.3.5", parts[0], now)
+	require.NoError(t, err)
+	assert.Nil(t, ret)
+
+	for _, part := range parts[2:] {
+		ret, err = a.add("1.2.3.4", part, now)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, msg, ret)
+
+	assert.Empty(t, a.expire(now.Add(time.Second)))
+	assert.Equal(t, []string{"1.2.3.5"}, a.expire(now.Add(6*time.Second)))
+	assert.Empty(t, a.messages)
+
+	_, err = a.add("1.2.3.4", []byte{0x1e, 0x0f, 1, 2}, now)
+	cstest.RequireErrorContains(t, err, "truncated chunk header")
+
+	_, err = a.add("1.2.3.4", append([]byte{0x1e, 0x0f}, []byte("abcdefgh\x05\x02")...), now)
+	cstest.RequireErrorContains(t, err, "chunk sequence 5 out of range (count: 2)")
+
+	_, err = a.add("1.2.3.4", append([]byte{0x1e, 0x0f}, []byte("abcdefgh\x00\xff")...), now)
+	cstest.RequireErrorContains(t, err, "invalid chunk count 255")
+
+	small := newChunkAssembler(5*time.Second, 15)
+
+	for _, part := range parts[:2] {
+		_, err = small.add("1.2.3.4", part, now)
+	}
+
+	require.ErrorIs(t, err, errTooLarge)
+}
+
+func TestDecompress(t *testing.T) {
+	msg := []byte(`{"version":"1.1","host":"example.org","short_message":"hello"}`)
+
+	var zbuf, gbuf bytes.Buffer
+
+	zw := zlib.NewWriter(&zbuf)
+	_, err := zw.Write(msg)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	gw := gzip.NewWriter(&gbuf)
+	_, err = gw.Write(msg)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	for name, payload := range map[string][]byte{"plain": msg, "zlib": zbuf.Bytes(), "gzip": gbuf.Bytes()} {
+		t.Run(name, func(t *testing.T) {
+			ret, err := decompress(payload, 1024)
+			require.NoError(t, err)
+			assert.Equal(t, msg, ret)
+
+			_, err = decompress(payload, 10)
+			require.ErrorIs(t, err, errTooLarge)
+		})
+	}
+
+	_, err = decompress([]byte{0x1f, 0x8b, 0x00}, 1024)
+	cstest.RequireErrorContains(t, err, "decompressing: unexpected EOF")
+}
+
+func TestParseMessage(t *testing.T) {
+	s := Source{config: Configuration{}}
+	s.config.Labels = map[string]string{"type": "nginx"}
+
+	evt, err := s.parseMessage([]byte(`{
+		"version": "1.1",
+		"host": "example.org",
+		"short_message": "GET / HTTP/1.1",
+		"full_message": "GET / HTTP/1.1\nmore details",
+		"timestamp": 1385053862.3072,
+		"level": 1,
+		"_container_name": "web",
+		"_http_status": 200,
+		"_empty": null
+	}`), "1.2.3.4")
+	require.NoError(t, err)
+
+	assert.Equal(t, "GET / HTTP/1.1", evt.Line.Raw)
+	assert.Equal(t, "1.2.3.4", evt.Line.Src)
+	assert.Equal(t, ModuleName, evt.Line.Module)
+	assert.Equal(t, "nginx", evt.Line.Labels["type"])
+	assert.Equal(t, time.Date(2013, 11, 21, 17, 11, 2, 307_000_000, time.UTC), evt.Line.Time.Round(time.Millisecond))
+	assert.Equal(t, map[string]string{
+		"version":        "1.1",
+		"host":           "example.org",
+		"short_message":  "GET / HTTP/1.1",
+		"full_message":   "GET / HTTP/1.1\nmore details",
+		"timestamp":      "1385053862.3072",
+		"level":          "1",
+		"container_name": "web",
+		"http_status":    "200",
+	}, evt.Parsed)
+
+	_, err = s.parseMessage([]byte(`{"version": "1.1", "host": "example.org"}`), "1.2.3.4")
+	cstest.RequireErrorContains(t, err, "invalid GELF message: missing short_message")
+
+	_, err = s.parseMessage([]byte(`not json`), "1.2.3.4")
+	cstest.RequireErrorContains(t, err, "invalid GELF message: invalid character")
+}
+
+// freePort returns a port that is not in use for the given protocol.
+func freePort(t *testing.T, proto string) int {
+	t.Helper()
+
+	var addr net.Addr
+
+	switch proto {
+	case "udp":
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		addr = conn.LocalAddr()
+		conn.Close()
+	case "tcp":
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		addr = l.Addr()
+		l.Close()
+	}
+
+	_, port, err := net.SplitHostPort(addr.String())
+	require.NoError(t, err)
+
+	ret, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	return ret
+}
+
+func startSource(t *testing.T, proto string) (int, chan pipeline.Event) {
+	t.Helper()
+
+	ctx := t.Context()
+	port := freePort(t, proto)
+
+	s := Source{}
+	logger, _ := logtest.NewNullLogger()
+	err := s.Configure(ctx, fmt.Appendf(nil, "source: gelf\nprotocol: %s\nlisten_port: %d\nlabels:\n  type: test", proto, port),
+		logrus.NewEntry(logger), metrics.AcquisitionMetricsLevelNone)
+	require.NoError(t, err)
+
+	out := make(chan pipeline.Event, 10)
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- s.Stream(ctx, out)
+	}()
+
+	t.Cleanup(func() {
+		// the context of the test is canceled before the cleanup functions are called
+		require.NoError(t, <-errCh)
+	})
+
+	return port, out
+}
+
+func dial(t *testing.T, proto string, port int) net.Conn {
+	t.Helper()
+
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	// wait for the server to listen
+	require.Eventually(t, func() bool {
+		conn, err = net.Dial(proto, net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func receive(t *testing.T, out chan pipeline.Event) pipeline.Event {
+	t.Helper()
+
+	select {
+	case evt := <-out:
+		return evt
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timeout waiting for an event")
+	}
+
+	return pipeline.Event{}
+}
+
+func TestStreamUDP(t *testing.T) {
+	port, out := startSource(t, "udp")
+	conn := dial(t, "udp", port)
+
+	// the datagrams sent before the server listens are lost (or refused)
+	require.Eventually(t, func() bool {
+		_, _ = conn.Write([]byte(`{"version":"1.1","host":"example.org","short_message":"ping"}`))
+
+		select {
+		case <-out:
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	var buf bytes.Buffer
+
+	zw := zlib.NewWriter(&buf)
+	_, err := zw.Write([]byte(`{"version":"1.1","host":"example.org","short_message":"compressed and chunked","_app":"test"}`))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	// invalid messages are dropped, the source keeps running
+	_, err = conn.Write([]byte("not json"))
+	require.NoError(t, err)
+
+	for _, chunk := range chunks("abcdefgh", buf.Bytes(), 16) {
+		_, err = conn.Write(chunk)
+		require.NoError(t, err)
+	}
+
+	evt := receive(t, out)
+	// skip the pings that were in flight
+	for evt.Line.Raw == "ping" {
+		evt = receive(t, out)
+	}
+
+	assert.Equal(t, "compressed and chunked", evt.Line.Raw)
+	assert.Equal(t, "127.0.0.1", evt.Line.Src)
+	assert.Equal(t, "test", evt.Parsed["app"])
+	assert.Equal(t, "test", evt.Line.Labels["type"])
+}
+
+func TestStreamTCP(t *testing.T) {
+	port, out := startSource(t, "tcp")
+	conn := dial(t, "tcp", port)
+
+	_, err := conn.Write([]byte(`{"version":"1.1","host":"example.org","short_message":"first"}` + "\x00" +
+		"not json\x00" +
+		`{"version":"1.1","host":"example.org","short_message":"second"}` + "\x00"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "first", receive(t, out).Line.Raw)
+	assert.Equal(t, "second", receive(t, out).Line.Raw)
+}
@@ -0,0 +1,223 @@
+package gelfacquisition
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const (
+	// a chunk starts with the magic bytes, a message ID (8 bytes), a sequence number and a sequence count
+	chunkHeaderLen = 12
+	maxChunks      = 128
+)
+
+var (
+	chunkMagic = []byte{0x1e, 0x0f}
+	gzipMagic  = []byte{0x1f, 0x8b}
+
+	errTooLarge = errors.New("message too large")
+)
+
+// chunkedMessage is a message sent in several UDP datagrams, being reassembled.
+type chunkedMessage struct {
+	client   string
+	chunks   [][]byte
+	received int
+	size     int
+	first    time.Time
+}
+
+// chunkAssembler reassembles the chunked messages, identified by client and message ID.
+// It is not safe for concurrent use.
+type chunkAssembler struct {
+	timeout  time.Duration
+	maxLen   int
+	messages map[string]*chunkedMessage
+}
+
+func newChunkAssembler(timeout time.Duration, maxLen int) *chunkAssembler {
+	return &chunkAssembler{
+		timeout:  timeout,
+		maxLen:   maxLen,
+		messages: make(map[string]*chunkedMessage),
+	}
+}
+
+func isChunk(datagram []byte) bool {
+	return bytes.HasPrefix(datagram, chunkMagic)
+}
+
+// add stores a chunk, and returns the reassembled message when all its chunks were received.
+func (a *chunkAssembler) add(client string, datagram []byte, now time.Time) ([]byte, error) {
+	if len(datagram) < chunkHeaderLen {
+		return nil, errors.New("truncated chunk header")
+	}
+
+	id := client + "/" + string(datagram[2:10])
+	seq := int(datagram[10])
+	count := int(datagram[11])
+
+	if count == 0 || count > maxChunks {
+		return nil, fmt.Errorf("invalid chunk count %d", count)
+	}
+
+	if seq >= count {
+		return nil, fmt.Errorf("chunk sequence %d out of range (count: %d)", seq, count)
+	}
+
+	msg, ok := a.messages[id]
+	if !ok {
+		msg = &chunkedMessage{client: client, chunks: make([][]byte, count), first: now}
+		a.messages[id] = msg
+	}
+
+	if len(msg.chunks) != count {
+		delete(a.messages, id)
+		return nil, errors.New("inconsistent chunk count")
+	}
+
+	if msg.chunks[seq] != nil {
+		// duplicate
+		return nil, nil
+	}
+
+	payload := datagram[chunkHeaderLen:]
+
+	msg.size += len(payload)
+	if msg.size > a.maxLen {
+		delete(a.messages, id)
+		return nil, errTooLarge
+	}
+
+	msg.chunks[seq] = bytes.Clone(payload)
+	msg.received++
+
+	if msg.received < count {
+		return nil, nil
+	}
+
+	delete(a.messages, id)
+
+	return bytes.Join(msg.chunks, nil), nil
+}
+
+// expire drops the messages that were not completed in time, and returns their clients.
+func (a *chunkAssembler) expire(now time.Time) []string {
+	var dropped []string
+
+	for id, msg := range a.messages {
+		if now.Sub(msg.first) > a.timeout {
+			delete(a.messages, id)
+			dropped = append(dropped, msg.client)
+		}
+	}
+
+	return dropped
+}
+
+// decompress returns the payload, decompressed if it starts with a zlib or gzip header.
+func decompress(payload []byte, maxLen int) ([]byte, error) {
+	var (
+		r   io.ReadCloser
+		err error
+	)
+
+	switch {
+	case bytes.HasPrefix(payload, gzipMagic):
+		r, err = gzip.NewReader(bytes.NewReader(payload))
+	case len(payload) >= 2 && payload[0] == 0x78 && (uint16(payload[0])<<8|uint16(payload[1]))%31 == 0:
+		r, err = zlib.NewReader(bytes.NewReader(payload))
+	default:
+		if len(payload) > maxLen {
+			return nil, errTooLarge
+		}
+
+		return payload, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("decompressing: %w", err)
+	}
+	defer r.Close()
+
+	// read one more byte to detect the messages that are too large
+	ret, err := io.ReadAll(io.LimitReader(r, int64(maxLen)+1))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing: %w", err)
+	}
+
+	if len(ret) > maxLen {
+		return nil, errTooLarge
+	}
+
+	return ret, nil
+}
+
+// parseMessage converts a GELF message to an event. The short message is the line to parse, and
+// all the fields are in Parsed, with the leading underscore removed from the additional fields:
+// host, level, short_message, full_message, timestamp, container_name...
+func (s *Source) parseMessage(payload []byte, client string) (pipeline.Event, error) {
+	evt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+
+	var record map[string]any
+
+	if err := dec.Decode(&record); err != nil {
+		return evt, fmt.Errorf("invalid GELF message: %w", err)
+	}
+
+	shortMessage, ok := record["short_message"].(string)
+	if !ok {
+		return evt, errors.New("invalid GELF message: missing short_message")
+	}
+
+	for key, value := range record {
+		key = strings.TrimPrefix(key, "_")
+
+		switch v := value.(type) {
+		case nil:
+		case string:
+			evt.Parsed[key] = v
+		case json.Number, bool:
+			evt.Parsed[key] = fmt.Sprint(v)
+		default:
+			// not allowed by the specification, but keep them anyway
+			raw, err := json.Marshal(v)
+			if err == nil {
+				evt.Parsed[key] = string(raw)
+			}
+		}
+	}
+
+	evt.Line = pipeline.Line{
+		Raw:     shortMessage,
+		Src:     client,
+		Time:    time.Now().UTC(),
+		Labels:  s.config.Labels,
+		Module:  s.GetName(),
+		Process: true,
+	}
+
+	// seconds since the epoch, with optional decimal places for milliseconds
+	if ts, ok := record["timestamp"].(json.Number); ok {
+		if f, err := ts.Float64(); err == nil {
+			sec, frac := math.Modf(f)
+			evt.Line.Time = time.Unix(int64(sec), int64(frac*1e9)).UTC()
+			evt.StrTime = evt.Line.Time.Format(time.RFC3339Nano)
+		}
+	}
+
+	return evt, nil
+}
@@ -0,0 +1,21 @@
+package gelfacquisition
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+func (*Source) GetMetrics() []prometheus.Collector {
+	return []prometheus.Collector{
+		metrics.GelfDataSourceMessagesReceived,
+		metrics.GelfDataSourceMessagesDropped,
+	}
+}
+
+func (*Source) GetAggregMetrics() []prometheus.Collector {
+	return []prometheus.Collector{
+		metrics.GelfDataSourceMessagesReceived,
+		metrics.GelfDataSourceMessagesDropped,
+	}
+}
@@ -0,0 +1,262 @@
+package gelfacquisition
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// maximum size of a UDP datagram
+const maxDatagramLen = 65535
+
+type datagram struct {
+	payload []byte
+	client  string
+}
+
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	addr := net.JoinHostPort(s.config.Addr, strconv.Itoa(s.config.Port))
+
+	if s.config.Proto == "tcp" {
+		return s.serveTCP(ctx, addr, out)
+	}
+
+	return s.serveUDP(ctx, addr, out)
+}
+
+func (s *Source) serveUDP(ctx context.Context, addr string, out chan pipeline.Event) error {
+	lc := net.ListenConfig{}
+
+	conn, err := lc.ListenPacket(ctx, "udp", addr)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s/udp: %w", addr, err)
+	}
+
+	s.logger.Infof("listening on %s/udp", addr)
+
+	datagrams := make(chan datagram)
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		defer close(datagrams)
+
+		context.AfterFunc(ctx, func() {
+			// closing the socket unblocks ReadFrom()
+			conn.Close()
+		})
+
+		buf := make([]byte, maxDatagramLen)
+
+		for {
+			n, raddr, err := conn.ReadFrom(buf)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil //nolint:nilerr  // context cancelation is not a failure
+				}
+
+				return fmt.Errorf("reading from socket: %w", err)
+			}
+
+			client, _, _ := net.SplitHostPort(raddr.String())
+
+			select {
+			case datagrams <- datagram{payload: bytes.Clone(buf[:n]), client: client}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	})
+
+	g.Go(func() error {
+		// the chunks are only reassembled here, no need for locking
+		assembler := newChunkAssembler(s.config.ChunkTimeout, s.config.MaxMessageLen)
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case now := <-ticker.C:
+				for _, client := range assembler.expire(now) {
+					s.logger.WithField("client", client).Debug("dropping incomplete chunked message")
+					s.countDropped(client, "incomplete", 1)
+				}
+			case d, ok := <-datagrams:
+				if !ok {
+					return nil
+				}
+
+				payload := d.payload
+
+				if isChunk(payload) {
+					var err error
+
+					payload, err = assembler.add(d.client, payload, time.Now())
+					if err != nil {
+						s.logger.WithField("client", d.client).Debugf("dropping chunk: %s", err)
+						s.countDropped(d.client, dropReason(err), 1)
+
+						continue
+					}
+
+					if payload == nil {
+						// waiting for more chunks
+						continue
+					}
+				}
+
+				s.handleMessage(ctx, payload, d.client, out)
+			}
+		}
+	})
+
+	return g.Wait()
+}
+
+func (s *Source) serveTCP(ctx context.Context, addr string, out chan pipeline.Event) error {
+	lc := net.ListenConfig{}
+
+	listener, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s/tcp: %w", addr, err)
+	}
+
+	s.logger.Infof("listening on %s/tcp", addr)
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	context.AfterFunc(ctx, func() {
+		listener.Close()
+	})
+
+	g.Go(func() error {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil //nolint:nilerr  // context cancelation is not a failure
+				}
+
+				return fmt.Errorf("accepting connection: %w", err)
+			}
+
+			g.Go(func() error {
+				return s.handleConn(ctx, conn, out)
+			})
+		}
+	})
+
+	return g.Wait()
+}
+
+// handleConn reads the messages of a TCP connection, each terminated by a null byte.
+func (s *Source) handleConn(ctx context.Context, conn net.Conn, out chan pipeline.Event) error {
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	client, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	logger := s.logger.WithField("client", client)
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), s.config.MaxMessageLen+1)
+	scanner.Split(splitNull)
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		s.handleMessage(ctx, scanner.Bytes(), client, out)
+	}
+
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		// a broken connection is not a reason to stop the datasource
+		logger.Warningf("closing connection: %s", err)
+
+		if errors.Is(err, bufio.ErrTooLong) {
+			s.countDropped(client, "too_large", 1)
+		}
+	}
+
+	logger.Debug("connection closed")
+
+	return nil
+}
+
+// splitNull is a bufio.SplitFunc for the null-terminated messages of GELF over TCP.
+func splitNull(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+// handleMessage decompresses and parses a complete message, and sends it to the parsers.
+// Invalid messages are dropped.
+func (s *Source) handleMessage(ctx context.Context, payload []byte, client string, out chan pipeline.Event) {
+	logger := s.logger.WithField("client", client)
+
+	payload, err := decompress(payload, s.config.MaxMessageLen)
+	if err != nil {
+		logger.Debugf("dropping message: %s", err)
+		s.countDropped(client, dropReason(err), 1)
+
+		return
+	}
+
+	evt, err := s.parseMessage(payload, client)
+	if err != nil {
+		logger.Debugf("dropping message: %s", err)
+		s.countDropped(client, "invalid", 1)
+
+		return
+	}
+
+	if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+		metrics.GelfDataSourceMessagesReceived.With(prometheus.Labels{"source": client, "datasource_type": ModuleName, "acquis_type": s.config.Labels["type"]}).Inc()
+	}
+
+	select {
+	case out <- evt:
+	case <-ctx.Done():
+	}
+}
+
+func dropReason(err error) string {
+	if errors.Is(err, errTooLarge) {
+		return "too_large"
+	}
+
+	return "invalid"
+}
+
+func (s *Source) countDropped(client string, reason string, n int) {
+	if s.metricsLevel == metrics.AcquisitionMetricsLevelNone {
+		return
+	}
+
+	metrics.GelfDataSourceMessagesDropped.With(prometheus.Labels{"source": client, "reason": reason}).Add(float64(n))
+}
@@ -0,0 +1,168 @@
+// Package apipoll has the parts shared by the datasources that poll the logs of a
+// remote API (okta, auth0, salesforce...): the requests, with a token renewed when
+// the API rejects it, the errors and rate limits of the API, and the polling loop.
+package apipoll
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// maxRateLimitWait is the longest wait for the reset of a rate limit, before trying again anyway.
+const maxRateLimitWait = time.Minute
+
+// RateLimitError is returned when the rate limit of the API is exceeded.
+type RateLimitError struct {
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return "rate limit exceeded until " + e.Reset.Format(time.RFC3339)
+}
+
+// Wait returns how long to wait for the reset of the rate limit.
+func (e *RateLimitError) Wait() time.Duration {
+	return min(max(time.Until(e.Reset), time.Second), maxRateLimitWait)
+}
+
+// Client sends GET requests to an API.
+type Client struct {
+	HTTP *http.Client
+	// Header is added to each request, i.e. Accept or a static Authorization.
+	Header http.Header
+	// Token is the bearer token of the requests, if any.
+	Token *TokenSource
+	// RateLimitReset is the header with the reset time of the rate limit (unix time), on a 429 response.
+	RateLimitReset string
+	// Reason returns the reason of a failure given in the body of a response, or an empty string.
+	Reason func(body []byte) string
+}
+
+// ResponseError returns the error of a failed request, with the reason given by the API if any.
+// A 429 response is a *RateLimitError.
+func (c *Client) ResponseError(method string, path string, resp *http.Response) error {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		reset := time.Now().Add(maxRateLimitWait)
+		if epoch, err := strconv.ParseInt(resp.Header.Get(c.RateLimitReset), 10, 64); err == nil {
+			reset = time.Unix(epoch, 0)
+		}
+
+		return &RateLimitError{Reset: reset}
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	if c.Reason != nil {
+		if reason := c.Reason(body); reason != "" {
+			return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, reason)
+		}
+	}
+
+	return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+}
+
+func (c *Client) send(ctx context.Context, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	maps.Copy(req.Header, c.Header)
+
+	if c.Token != nil {
+		token, err := c.Token.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", u.Path, err)
+	}
+
+	return resp, nil
+}
+
+// Get sends a GET request, and returns the response if its status is 200 OK. With a token, the
+// request is sent again with a new one if the current one was rejected.
+func (c *Client) Get(ctx context.Context, rawURL string) (*http.Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(ctx, u)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && c.Token != nil {
+		// the token may have been revoked, or have expired early
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		c.Token.Reset()
+
+		resp, err = c.send(ctx, u)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, c.ResponseError(http.MethodGet, u.Path, resp)
+	}
+
+	return resp, nil
+}
+
+// TokenSource keeps the bearer token of an API, and requests a new one when there is none, when
+// it expires soon, or after it was rejected.
+type TokenSource struct {
+	// Request returns a new token, and how long it's valid (0 if it doesn't expire).
+	Request func(ctx context.Context) (string, time.Duration, error)
+
+	token  string
+	expiry time.Time
+}
+
+// the token is renewed when it expires in less than tokenRenewal
+const tokenRenewal = 5 * time.Minute
+
+// Token returns a valid token, requesting a new one if needed.
+func (t *TokenSource) Token(ctx context.Context) (string, error) {
+	if t.token != "" && (t.expiry.IsZero() || time.Until(t.expiry) > tokenRenewal) {
+		return t.token, nil
+	}
+
+	token, lifetime, err := t.Request(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if token == "" {
+		return "", errors.New("requesting access token: empty token")
+	}
+
+	t.token = token
+	t.expiry = time.Time{}
+
+	if lifetime > 0 {
+		t.expiry = time.Now().Add(lifetime)
+	}
+
+	return t.token, nil
+}
+
+// Reset drops the token, the next request gets a new one.
+func (t *TokenSource) Reset() {
+	t.token = ""
+}
@@ -0,0 +1,154 @@
+package apipoll
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestGetTokenRenewal(t *testing.T) {
+	ctx := t.Context()
+
+	tokens := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "json", r.Header.Get("Accept"))
+
+		if r.Header.Get("Authorization") != "Bearer token-"+strconv.Itoa(tokens) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := &Client{
+		HTTP:   srv.Client(),
+		Header: http.Header{"Accept": {"json"}},
+		Token: &TokenSource{Request: func(context.Context) (string, time.Duration, error) {
+			tokens++
+			return "token-" + strconv.Itoa(tokens), 0, nil
+		}},
+	}
+
+	resp, err := c.Get(ctx, srv.URL+"/logs")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 1, tokens)
+
+	// the token is kept until it's rejected
+	resp, err = c.Get(ctx, srv.URL+"/logs")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 1, tokens)
+
+	// revoked: the request is sent again with a new token
+	tokens++
+	resp, err = c.Get(ctx, srv.URL+"/logs")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 3, tokens)
+}
+
+func TestTokenSource(t *testing.T) {
+	ctx := t.Context()
+
+	requests := 0
+	lifetime := time.Hour
+
+	ts := &TokenSource{Request: func(context.Context) (string, time.Duration, error) {
+		requests++
+		return "token", lifetime, nil
+	}}
+
+	_, err := ts.Token(ctx)
+	require.NoError(t, err)
+	_, err = ts.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	// renewed before it expires
+	lifetime = time.Minute
+	ts.Reset()
+	_, err = ts.Token(ctx)
+	require.NoError(t, err)
+	_, err = ts.Token(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, requests)
+
+	ts = &TokenSource{Request: func(context.Context) (string, time.Duration, error) {
+		return "", 0, nil
+	}}
+
+	_, err = ts.Token(ctx)
+	cstest.RequireErrorMessage(t, err, "requesting access token: empty token")
+}
+
+func TestResponseError(t *testing.T) {
+	ctx := t.Context()
+
+	reset := time.Now().Add(30 * time.Second).Truncate(time.Second)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/limited":
+			w.Header().Set("X-Reset", strconv.FormatInt(reset.Unix(), 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/denied":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte("no access"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{
+		HTTP:           srv.Client(),
+		RateLimitReset: "X-Reset",
+		Reason: func(body []byte) string {
+			return string(body)
+		},
+	}
+
+	_, err := c.Get(ctx, srv.URL+"/limited")
+
+	var rlErr *RateLimitError
+	require.ErrorAs(t, err, &rlErr)
+	assert.Equal(t, reset, rlErr.Reset)
+	assert.LessOrEqual(t, rlErr.Wait(), 30*time.Second)
+
+	_, err = c.Get(ctx, srv.URL+"/denied?q=1")
+	cstest.RequireErrorMessage(t, err, "GET /denied: 403 Forbidden: no access")
+
+	_, err = c.Get(ctx, srv.URL+"/missing")
+	cstest.RequireErrorMessage(t, err, "GET /missing: 404 Not Found")
+}
+
+func TestWaitRateLimit(t *testing.T) {
+	ctx := t.Context()
+
+	calls := 0
+
+	err := WaitRateLimit(ctx, log.NewEntry(log.StandardLogger()), func() error {
+		calls++
+		if calls == 1 {
+			return &RateLimitError{Reset: time.Now()}
+		}
+
+		return errors.New("boom")
+	})
+	cstest.RequireErrorMessage(t, err, "boom")
+	assert.Equal(t, 2, calls)
+}
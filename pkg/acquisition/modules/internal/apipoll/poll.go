@@ -0,0 +1,65 @@
+package apipoll
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// WaitRateLimit calls fn until it doesn't fail with a *RateLimitError, waiting for the reset of
+// the rate limit in between.
+func WaitRateLimit(ctx context.Context, logger *log.Entry, fn func() error) error {
+	for {
+		err := fn()
+
+		var rlErr *RateLimitError
+		if !errors.As(err, &rlErr) {
+			return err
+		}
+
+		wait := rlErr.Wait()
+		logger.Warningf("%s, waiting %s", rlErr, wait)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Poll calls fetch right away as long as it returns more (i.e. after a full page, there are
+// probably more events already), then every interval. Without follow, it returns once there is
+// nothing more. The cancelation of ctx is not an error.
+func Poll(ctx context.Context, logger *log.Entry, interval time.Duration, follow bool, fetch func(ctx context.Context) (bool, error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		more, err := fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		if more {
+			continue
+		}
+
+		if !follow {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Datasource stopping")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package cursor keeps the reading position of the datasources that poll a
+// remote API (okta, auth0, salesforce...) in a file, to resume where they
+// stopped after a restart instead of missing or reading again the events.
+package cursor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File is the cursor of a datasource. The zero value, or a File with an empty
+// path, doesn't persist anything.
+type File struct {
+	path string
+}
+
+func New(path string) *File {
+	return &File{path: path}
+}
+
+// Load returns the saved cursor, or an empty string if there is none.
+func (f *File) Load() (string, error) {
+	if f == nil || f.path == "" {
+		return "", nil
+	}
+
+	content, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("reading cursor: %w", err)
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
+
+// Save replaces the saved cursor. The file is written next to the previous
+// one and renamed, to never leave a partial cursor behind.
+func (f *File) Save(value string) error {
+	if f == nil || f.path == "" {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("saving cursor: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(value + "\n"); err != nil {
+		tmp.Close()
+		return fmt.Errorf("saving cursor: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("saving cursor: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("saving cursor: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package cursor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "okta.cursor")
+
+	f := New(path)
+
+	value, err := f.Load()
+	require.NoError(t, err)
+	assert.Empty(t, value)
+
+	require.NoError(t, f.Save("abc"))
+	require.NoError(t, f.Save("def"))
+
+	value, err = New(path).Load()
+	require.NoError(t, err)
+	assert.Equal(t, "def", value)
+
+	// no temporary file left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	err = New(filepath.Join(dir, "missing", "okta.cursor")).Save("abc")
+	cstest.RequireErrorContains(t, err, "saving cursor: open "+filepath.Join(dir, "missing"))
+}
+
+func TestNoFile(t *testing.T) {
+	var f *File
+
+	require.NoError(t, f.Save("abc"))
+
+	value, err := New("").Load()
+	require.NoError(t, err)
+	assert.Empty(t, value)
+}
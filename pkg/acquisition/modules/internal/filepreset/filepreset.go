@@ -0,0 +1,129 @@
+// Package filepreset runs a file datasource on behalf of the datasources that
+// read well-known log files (suricata, zeek...), with their own defaults, and
+// converts its events before they are sent to the parsers.
+package filepreset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/tomb.v2"
+
+	fileacquisition "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/file"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// ConvertFunc is called for each line read by the file datasource, with an empty Parsed map.
+// It can modify the event, and returns false if the event must be dropped.
+type ConvertFunc func(evt *pipeline.Event) bool
+
+// NewFileSource configures the underlying file datasource.
+func NewFileSource(ctx context.Context, cfg fileacquisition.Configuration, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) (*fileacquisition.Source, error) {
+	// the file datasource can only be configured from yaml
+	fileConfig, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("while building file configuration: %w", err)
+	}
+
+	src := &fileacquisition.Source{}
+
+	if err := src.Configure(ctx, fileConfig, logger, metricsLevel); err != nil {
+		return nil, err
+	}
+
+	return src, nil
+}
+
+// OneShot reads the files at once, and sends the converted events.
+func OneShot(ctx context.Context, src *fileacquisition.Source, out chan pipeline.Event, convert ConvertFunc) error {
+	in := make(chan pipeline.Event)
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- src.OneShot(ctx, in)
+		close(in)
+	}()
+
+	for evt := range in {
+		if evt.Parsed == nil {
+			evt.Parsed = make(map[string]string)
+		}
+
+		if convert(&evt) {
+			out <- evt
+		}
+	}
+
+	return <-errChan
+}
+
+// Stream tails the files until the context is canceled, and sends the converted events.
+func Stream(ctx context.Context, src *fileacquisition.Source, out chan pipeline.Event, convert ConvertFunc) error {
+	in := make(chan pipeline.Event)
+	t := &tomb.Tomb{}
+
+	if err := src.StreamingAcquisition(ctx, in, t); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.Kill(nil)
+			// the tailers might be blocked sending an event
+			for {
+				select {
+				case <-in:
+				case <-t.Dead():
+					return nil
+				}
+			}
+		case <-t.Dead():
+			return t.Err()
+		case evt := <-in:
+			if evt.Parsed == nil {
+				evt.Parsed = make(map[string]string)
+			}
+
+			if !convert(&evt) {
+				continue
+			}
+
+			select {
+			case out <- evt:
+			case <-ctx.Done():
+			}
+		}
+	}
+}
+
+// Flatten adds the scalar values of a decoded JSON document to parsed, with
+// dotted keys for nested objects (i.e. "alert.signature"). Arrays are kept as JSON.
+// The document must have been decoded with UseNumber(), to keep the integers intact.
+func Flatten(prefix string, value any, parsed map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, sub := range v {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+
+			Flatten(key, sub, parsed)
+		}
+	case []any:
+		raw, err := json.Marshal(v)
+		if err == nil {
+			parsed[prefix] = string(raw)
+		}
+	case nil:
+	case string:
+		parsed[prefix] = v
+	default:
+		parsed[prefix] = fmt.Sprint(v)
+	}
+}
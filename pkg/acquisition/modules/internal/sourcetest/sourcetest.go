@@ -0,0 +1,133 @@
+// Package sourcetest has the helpers shared by the tests of the datasources that poll a remote
+// API: a fake server, the configuration of a datasource, and the reading of its events.
+package sourcetest
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/types"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// NewServer starts a fake API, stopped at the end of the test, and returns its URL.
+func NewServer(t *testing.T, handler http.Handler) string {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return srv.URL
+}
+
+// MergeConfig returns the configuration base, with the keys of extra added or replaced.
+func MergeConfig(t *testing.T, base string, extra string) string {
+	t.Helper()
+
+	cfg := map[string]any{}
+	require.NoError(t, yaml.Unmarshal([]byte(base), &cfg))
+
+	override := map[string]any{}
+	require.NoError(t, yaml.Unmarshal([]byte(extra), &override))
+
+	maps.Copy(cfg, override)
+
+	out, err := yaml.Marshal(cfg)
+	require.NoError(t, err)
+
+	return string(out)
+}
+
+// Configure configures s with the configuration base, with the keys of extra added or replaced.
+func Configure(t *testing.T, s types.DataSource, base string, extra string) {
+	t.Helper()
+
+	cfg := MergeConfig(t, base, extra)
+
+	var source struct {
+		Source string `yaml:"source"`
+	}
+
+	require.NoError(t, yaml.Unmarshal([]byte(cfg), &source))
+
+	err := s.Configure(t.Context(), []byte(cfg), log.WithField("type", source.Source), metrics.AcquisitionMetricsLevelNone)
+	require.NoError(t, err)
+}
+
+// OneShot reads all the events of s, which must not fail.
+func OneShot(t *testing.T, s types.BatchFetcher) []pipeline.Event {
+	t.Helper()
+
+	out := make(chan pipeline.Event)
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- s.OneShot(t.Context(), out)
+		close(out)
+	}()
+
+	var events []pipeline.Event
+
+	for evt := range out {
+		events = append(events, evt)
+	}
+
+	require.NoError(t, <-errChan)
+
+	return events
+}
+
+// Stream reads n events, then stops the datasource. A few more polls happen before, to check
+// that the events already read are not read again.
+func Stream(t *testing.T, s types.RestartableStreamer, n int) []pipeline.Event {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	out := make(chan pipeline.Event, 10)
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- s.Stream(ctx, out)
+	}()
+
+	events := make([]pipeline.Event, 0, n)
+
+	for range n {
+		select {
+		case evt := <-out:
+			events = append(events, evt)
+		case err := <-errChan:
+			require.NoError(t, err)
+			t.Fatal("datasource stopped before sending all the events")
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for events")
+		}
+	}
+
+	time.Sleep(200 * time.Millisecond)
+
+	cancel()
+
+	select {
+	case err := <-errChan:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("datasource did not stop")
+	}
+
+	assert.Empty(t, out)
+
+	return events
+}
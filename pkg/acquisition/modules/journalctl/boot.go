@@ -0,0 +1,124 @@
+package journalctlacquisition
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var bootIDRegexp = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// bootSelector is the value of the "boot" option: a boot id, an offset as in "journalctl -b"
+// (0 is the current boot, -1 the previous one), a range of offsets ("-3..0") or "all".
+type bootSelector struct {
+	id   string
+	from int
+	to   int
+	all  bool
+}
+
+func parseBootSelector(s string) (bootSelector, error) {
+	s = strings.TrimSpace(s)
+
+	if s == "all" {
+		return bootSelector{all: true}, nil
+	}
+
+	if bootIDRegexp.MatchString(s) {
+		return bootSelector{id: s}, nil
+	}
+
+	fromStr, toStr, isRange := strings.Cut(s, "..")
+	if !isRange {
+		toStr = fromStr
+	}
+
+	from, err := strconv.Atoi(fromStr)
+	if err != nil {
+		return bootSelector{}, fmt.Errorf("invalid boot %q: must be a boot id, an offset, a range of offsets or 'all'", s)
+	}
+
+	to, err := strconv.Atoi(toStr)
+	if err != nil {
+		return bootSelector{}, fmt.Errorf("invalid boot %q: must be a boot id, an offset, a range of offsets or 'all'", s)
+	}
+
+	if from > 0 || to > 0 {
+		return bootSelector{}, fmt.Errorf("invalid boot %q: the offsets must be 0 (current boot) or negative", s)
+	}
+
+	if from > to {
+		return bootSelector{}, fmt.Errorf("invalid boot %q: the range must go from the oldest to the newest boot", s)
+	}
+
+	return bootSelector{from: from, to: to}, nil
+}
+
+func (b bootSelector) match(offset int, id string) bool {
+	switch {
+	case b.all:
+		return true
+	case b.id != "":
+		return b.id == id
+	default:
+		return offset >= b.from && offset <= b.to
+	}
+}
+
+// parseBootList reads the output of "journalctl --list-boots", from the oldest to the current boot:
+//
+//	IDX BOOT ID                          FIRST ENTRY                 LAST ENTRY
+//	 -1 8d21b1f3fc9a4a8fa0f5aa42b7fc4a8b Sun 2026-10-11 08:00:01 UTC Sun 2026-10-18 07:58:12 UTC
+//	  0 3c0f9e6a2f2c4f0f9b1d7e0a5c6b8d9e Sun 2026-10-18 08:00:03 UTC Sun 2026-10-18 10:12:45 UTC
+func parseBootList(data []byte, sel bootSelector) []string {
+	ret := []string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		// the header, or a line without offset
+		offset, err := strconv.Atoi(fields[0])
+		if err != nil || !bootIDRegexp.MatchString(fields[1]) {
+			continue
+		}
+
+		if sel.match(offset, fields[1]) {
+			ret = append(ret, fields[1])
+		}
+	}
+
+	return ret
+}
+
+// selectBoots returns the ids of the boots to read, from the oldest.
+func (s *Source) selectBoots(ctx context.Context) ([]string, error) {
+	sel, err := parseBootSelector(s.config.Boot)
+	if err != nil {
+		return nil, err
+	}
+
+	if sel.id != "" {
+		return []string{sel.id}, nil
+	}
+
+	output, err := exec.CommandContext(ctx, journalctlCmd, "--list-boots", "--no-pager").Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not list the boots: %w", err)
+	}
+
+	boots := parseBootList(output, sel)
+	if len(boots) == 0 {
+		return nil, fmt.Errorf("no boot matching %q in the journal", s.config.Boot)
+	}
+
+	return boots, nil
+}
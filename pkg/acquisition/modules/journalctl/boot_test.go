@@ -0,0 +1,62 @@
+package journalctlacquisition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestParseBootSelector(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bootSelector
+		wantErr  string
+	}{
+		{input: "0", expected: bootSelector{}},
+		{input: "-1", expected: bootSelector{from: -1, to: -1}},
+		{input: "-3..0", expected: bootSelector{from: -3, to: 0}},
+		{input: "all", expected: bootSelector{all: true}},
+		{input: "8d21b1f3fc9a4a8fa0f5aa42b7fc4a8b", expected: bootSelector{id: "8d21b1f3fc9a4a8fa0f5aa42b7fc4a8b"}},
+		{input: "1", wantErr: `invalid boot "1": the offsets must be 0 (current boot) or negative`},
+		{input: "0..-2", wantErr: `invalid boot "0..-2": the range must go from the oldest to the newest boot`},
+		{input: "yesterday", wantErr: `invalid boot "yesterday": must be a boot id, an offset, a range of offsets or 'all'`},
+		{input: "-1..", wantErr: `invalid boot "-1..": must be a boot id, an offset, a range of offsets or 'all'`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			sel, err := parseBootSelector(tc.input)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+
+			if tc.wantErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, sel)
+		})
+	}
+}
+
+func TestParseBootList(t *testing.T) {
+	output := []byte(`IDX BOOT ID                          FIRST ENTRY                 LAST ENTRY
+ -2 0f5a3c1e2b7d4e8f9a6b5c4d3e2f1a0b Fri 2020-11-20 08:00:01 CET Sat 2020-11-21 23:59:58 CET
+ -1 8d21b1f3fc9a4a8fa0f5aa42b7fc4a8b Sun 2020-11-22 08:00:03 CET Sun 2020-11-22 11:30:12 CET
+  0 3c0f9e6a2f2c4f0f9b1d7e0a5c6b8d9e Sun 2020-11-22 11:31:40 CET Mon 2020-11-23 09:17:34 CET
+`)
+
+	assert.Equal(t, []string{
+		"0f5a3c1e2b7d4e8f9a6b5c4d3e2f1a0b",
+		"8d21b1f3fc9a4a8fa0f5aa42b7fc4a8b",
+		"3c0f9e6a2f2c4f0f9b1d7e0a5c6b8d9e",
+	}, parseBootList(output, bootSelector{all: true}))
+
+	assert.Equal(t, []string{
+		"8d21b1f3fc9a4a8fa0f5aa42b7fc4a8b",
+		"3c0f9e6a2f2c4f0f9b1d7e0a5c6b8d9e",
+	}, parseBootList(output, bootSelector{from: -1, to: 0}))
+
+	assert.Equal(t, []string{"0f5a3c1e2b7d4e8f9a6b5c4d3e2f1a0b"}, parseBootList(output, bootSelector{from: -2, to: -2}))
+	assert.Empty(t, parseBootList(output, bootSelector{from: -5, to: -3}))
+}
@@ -18,6 +18,7 @@ type Configuration struct {
 	configuration.DataSourceCommonCfg `yaml:",inline"`
 
 	Filters []string `yaml:"journalctl_filter"`
+	Boot    string   `yaml:"boot"` // cat mode only: boot id, offset (-1), range of offsets (-3..0) or "all"
 	since   string // set only by DSN
 }
 
@@ -48,6 +49,16 @@ func (c *Configuration) Validate() error {
 		return errors.New("journalctl_filter is required")
 	}
 
+	if c.Boot != "" {
+		if c.Mode != configuration.CAT_MODE {
+			return errors.New("boot can only be used in cat mode")
+		}
+
+		if _, err := parseBootSelector(c.Boot); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -80,6 +91,7 @@ func (s *Source) ConfigureByDSN(_ context.Context, dsn string, labels map[string
 	var (
 		filters  []string
 		since    string
+		boot     string
 		logLevel log.Level
 	)
 
@@ -119,6 +131,16 @@ func (s *Source) ConfigureByDSN(_ context.Context, dsn string, labels map[string
 			}
 
 			since = value[0]
+		case "boot":
+			if len(value) != 1 {
+				return errors.New("expected exactly one value for 'boot'")
+			}
+
+			if _, err := parseBootSelector(value[0]); err != nil {
+				return err
+			}
+
+			boot = value[0]
 		default:
 			return fmt.Errorf("unsupported key %s in journalctl DSN", key)
 		}
@@ -131,6 +153,7 @@ func (s *Source) ConfigureByDSN(_ context.Context, dsn string, labels map[string
 			UniqueId: uuid,
 		},
 		Filters: filters,
+		Boot:    boot,
 		since:   since,
 	}
 
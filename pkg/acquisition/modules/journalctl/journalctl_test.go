@@ -1,7 +1,9 @@
 package journalctlacquisition
 
 import (
+	"cmp"
 	"context"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -56,6 +58,13 @@ func TestConfigureDSN(t *testing.T) {
 		{
 			dsn: "journalctl://filters=_UID=1000&log_level=warn&since=yesterday",
 		},
+		{
+			dsn: "journalctl://filters=_UID=1000&boot=-2..0",
+		},
+		{
+			dsn:     "journalctl://filters=_UID=1000&boot=last",
+			wantErr: `invalid boot "last": must be a boot id, an offset, a range of offsets or 'all'`,
+		},
 	}
 
 	for _, tc := range tests {
@@ -126,6 +135,99 @@ journalctl_filter:
 	}
 }
 
+func TestOneShotBoots(t *testing.T) {
+	cstest.SkipOnWindows(t)
+
+	ctx := t.Context()
+
+	tests := []struct {
+		name          string
+		boot          string
+		mode          string
+		wantConfigErr string
+		wantErr       string
+		wantBoots     []string
+	}{
+		{
+			name:      "previous boot",
+			boot:      "-1",
+			wantBoots: []string{"8d21b1f3fc9a4a8fa0f5aa42b7fc4a8b"},
+		},
+		{
+			name:      "range",
+			boot:      "-2..-1",
+			wantBoots: []string{"0f5a3c1e2b7d4e8f9a6b5c4d3e2f1a0b", "8d21b1f3fc9a4a8fa0f5aa42b7fc4a8b"},
+		},
+		{
+			name:      "all",
+			boot:      "all",
+			wantBoots: []string{"0f5a3c1e2b7d4e8f9a6b5c4d3e2f1a0b", "8d21b1f3fc9a4a8fa0f5aa42b7fc4a8b", "3c0f9e6a2f2c4f0f9b1d7e0a5c6b8d9e"},
+		},
+		{
+			name:      "boot id",
+			boot:      "3c0f9e6a2f2c4f0f9b1d7e0a5c6b8d9e",
+			wantBoots: []string{"3c0f9e6a2f2c4f0f9b1d7e0a5c6b8d9e"},
+		},
+		{
+			name:    "unknown boot id",
+			boot:    "00000000000000000000000000000000",
+			wantErr: "journalctl exited with error: exit status 1",
+		},
+		{
+			name:    "no such boot",
+			boot:    "-9..-5",
+			wantErr: `no boot matching "-9..-5" in the journal`,
+		},
+		{
+			name:          "tail mode",
+			boot:          "-1",
+			mode:          "tail",
+			wantConfigErr: "boot can only be used in cat mode",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := fmt.Sprintf(`
+source: journalctl
+mode: %s
+boot: %q
+labels:
+  type: syslog
+journalctl_filter:
+ - _SYSTEMD_UNIT=ssh.service`, cmp.Or(tc.mode, "cat"), tc.boot)
+
+			out := make(chan pipeline.Event, 100)
+			j := Source{}
+
+			logger, _ := logtest.NewNullLogger()
+
+			err := j.Configure(ctx, []byte(config), logrus.NewEntry(logger), metrics.AcquisitionMetricsLevelNone)
+			cstest.RequireErrorContains(t, err, tc.wantConfigErr)
+
+			if tc.wantConfigErr != "" {
+				return
+			}
+
+			err = j.OneShot(ctx, out)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+
+			if tc.wantErr != "" {
+				return
+			}
+
+			require.Len(t, out, 14*len(tc.wantBoots))
+
+			for _, bootID := range tc.wantBoots {
+				for range 14 {
+					evt := <-out
+					assert.Equal(t, map[string]string{"type": "syslog", "boot_id": bootID}, evt.Line.Labels)
+				}
+			}
+		})
+	}
+}
+
 func TestStreaming(t *testing.T) {
 	cstest.SkipOnWindows(t)
 
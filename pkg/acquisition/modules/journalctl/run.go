@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"maps"
 	"os/exec"
 	"time"
 	"golang.org/x/sync/errgroup"
@@ -18,23 +19,53 @@ import (
 const journalctlCmd = "journalctl"
 
 func (s *Source) OneShot(ctx context.Context, out chan pipeline.Event) error {
-	err := s.runJournalCtl(ctx, out)
+	err := s.run(ctx, out)
 	s.logger.Debug("Oneshot acquisition is done")
 
 	return err
 }
 
 func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
-	return s.runJournalCtl(ctx, out)
+	return s.run(ctx, out)
 }
 
-func (s *Source) getCommandArgs() []string {
+// run reads the journal, one boot after the other if a boot selection is configured.
+func (s *Source) run(ctx context.Context, out chan pipeline.Event) error {
+	if s.config.Boot == "" {
+		return s.runJournalCtl(ctx, out, "")
+	}
+
+	boots, err := s.selectBoots(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, bootID := range boots {
+		s.logger.Infof("Reading boot %s", bootID)
+
+		if err := s.runJournalCtl(ctx, out, bootID); err != nil {
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (s *Source) getCommandArgs(bootID string) []string {
 	args := []string{}
 
 	if s.config.Mode == configuration.TAIL_MODE {
 		args = []string{"--follow", "-n", "0"}
 	}
 
+	if bootID != "" {
+		args = append(args, "--boot", bootID)
+	}
+
 	if s.config.since != "" {
 		args = append(args, "--since", s.config.since)
 	}
@@ -42,11 +73,23 @@ func (s *Source) getCommandArgs() []string {
 	return append(args, s.config.Filters...)
 }
 
-func (s *Source) runJournalCtl(ctx context.Context, out chan pipeline.Event) error {
+func (s *Source) runJournalCtl(ctx context.Context, out chan pipeline.Event, bootID string) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, journalctlCmd, s.getCommandArgs()...)
+	labels := s.config.Labels
+
+	// the boot id tells apart the events of each boot, for a replay covering a crash
+	if bootID != "" {
+		labels = maps.Clone(labels)
+		if labels == nil {
+			labels = map[string]string{}
+		}
+
+		labels["boot_id"] = bootID
+	}
+
+	cmd := exec.CommandContext(ctx, journalctlCmd, s.getCommandArgs(bootID)...)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -145,7 +188,7 @@ func (s *Source) runJournalCtl(ctx context.Context, out chan pipeline.Event) err
 				Raw: stdoutLine,
 				Src: s.src,
 				Time: time.Now().UTC(),
-				Labels: s.config.Labels,
+				Labels: labels,
 				Process: true,
 				Module: s.GetName(),
 			}
@@ -0,0 +1,5 @@
+//go:build !no_datasource_mailbox
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/mailbox" // register the datasource
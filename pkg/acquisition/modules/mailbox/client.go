@@ -0,0 +1,486 @@
+package mailboxacquisition
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	dialTimeout    = 30 * time.Second
+	commandTimeout = time.Minute
+	// larger messages are refused, the abuse reports and DMARC reports are much smaller
+	maxMessageSize = 32 * 1024 * 1024
+)
+
+// session is a connection to the mailbox, for the duration of a poll. The messages are identified
+// by their UID with IMAP, and by their unique-id (UIDL) with POP3: both are stable across sessions.
+type session interface {
+	// list returns the messages to read: all of them, or only the unseen ones if the protocol supports it
+	list(all bool) ([]string, error)
+	fetch(id string) ([]byte, error)
+	markSeen(id string) error
+	remove(id string) error
+	// close ends the session. The removed messages are only deleted if it succeeds.
+	close() error
+}
+
+// dial connects to the server and starts TLS if required. The connection is closed if ctx is canceled.
+func dial(ctx context.Context, cfg *Configuration) (net.Conn, func() *tls.Config, error) {
+	host, _, err := net.SplitHostPort(cfg.Address)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tlsConfig := func() *tls.Config {
+		return &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec // opt-in, for self-signed certificates
+			MinVersion:         tls.VersionTLS12,
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: dialTimeout}
+
+	var conn net.Conn
+
+	if cfg.TLS == tlsImplicit {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig()}).DialContext(ctx, "tcp", cfg.Address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", cfg.Address)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return conn, tlsConfig, nil
+}
+
+// openSession connects to the server, authenticates and opens the mailbox.
+func openSession(ctx context.Context, cfg *Configuration, readOnly bool) (session, error) {
+	conn, tlsConfig, err := dial(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+
+	var sess session
+
+	switch cfg.Protocol {
+	case protocolPOP3:
+		sess, err = newPOP3Session(conn, cfg, tlsConfig, stop)
+	default:
+		sess, err = newIMAPSession(conn, cfg, tlsConfig, readOnly, stop)
+	}
+
+	if err != nil {
+		stop()
+		conn.Close()
+
+		return nil, err
+	}
+
+	return sess, nil
+}
+
+// quoteIMAP returns s as an IMAP quoted string.
+func quoteIMAP(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// errMessageTooLarge is returned by fetch for a message larger than maxMessageSize. The session can still be used.
+var errMessageTooLarge = errors.New("message too large")
+
+var literalRe = regexp.MustCompile(`\{(\d+)\}$`)
+
+// imapLine is a response line, with the literals it contains.
+type imapLine struct {
+	text     string
+	literals [][]byte
+}
+
+// imapSession is a minimal IMAP4rev1 client (RFC 3501), limited to reading and flagging messages.
+type imapSession struct {
+	conn     net.Conn
+	r        *bufio.Reader
+	tag      int
+	expunge  bool
+	stopFunc func() bool
+}
+
+func newIMAPSession(conn net.Conn, cfg *Configuration, tlsConfig func() *tls.Config, readOnly bool, stop func() bool) (*imapSession, error) {
+	s := &imapSession{conn: conn, r: bufio.NewReader(conn), stopFunc: stop}
+
+	_ = conn.SetDeadline(time.Now().Add(commandTimeout))
+
+	greeting, err := s.readLine()
+	if err != nil {
+		return nil, fmt.Errorf("reading greeting: %w", err)
+	}
+
+	if !strings.HasPrefix(greeting.text, "* OK") && !strings.HasPrefix(greeting.text, "* PREAUTH") {
+		return nil, fmt.Errorf("unexpected greeting: %s", greeting.text)
+	}
+
+	if cfg.TLS == tlsStartTLS {
+		if _, err := s.command("STARTTLS"); err != nil {
+			return nil, err
+		}
+
+		tlsConn := tls.Client(conn, tlsConfig())
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, fmt.Errorf("TLS handshake: %w", err)
+		}
+
+		s.conn = tlsConn
+		s.r = bufio.NewReader(tlsConn)
+	}
+
+	if _, err := s.command("LOGIN %s %s", quoteIMAP(cfg.Username), quoteIMAP(cfg.Password)); err != nil {
+		return nil, err
+	}
+
+	// EXAMINE opens the mailbox in read-only mode: the messages are not flagged as seen
+	selectCmd := "SELECT"
+	if readOnly {
+		selectCmd = "EXAMINE"
+	}
+
+	if _, err := s.command("%s %s", selectCmd, quoteIMAP(cfg.Mailbox)); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *imapSession) readLine() (imapLine, error) {
+	var ret imapLine
+
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			return ret, err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		ret.text += line
+
+		m := literalRe.FindStringSubmatch(line)
+		if m == nil {
+			return ret, nil
+		}
+
+		size, err := strconv.Atoi(m[1])
+		if err != nil {
+			return ret, fmt.Errorf("invalid literal size: %s", m[1])
+		}
+
+		// skip it, but keep reading the response
+		if size > maxMessageSize {
+			if _, err := io.CopyN(io.Discard, s.r, int64(size)); err != nil {
+				return ret, err
+			}
+
+			ret.literals = append(ret.literals, nil)
+
+			continue
+		}
+
+		literal := make([]byte, size)
+		if _, err := io.ReadFull(s.r, literal); err != nil {
+			return ret, err
+		}
+
+		ret.literals = append(ret.literals, literal)
+	}
+}
+
+// commandName returns the name of a command, without its arguments.
+func commandName(cmd string) string {
+	fields := strings.Fields(cmd)
+
+	switch {
+	case len(fields) == 0:
+		return ""
+	case fields[0] == "UID" && len(fields) > 1:
+		return fields[0] + " " + fields[1]
+	default:
+		return fields[0]
+	}
+}
+
+// command sends a command and returns the untagged responses. The errors only contain the name
+// of the command, not its arguments: they include the password for LOGIN.
+func (s *imapSession) command(format string, args ...any) ([]imapLine, error) {
+	s.tag++
+	tag := "a" + strconv.Itoa(s.tag)
+	cmd := fmt.Sprintf(format, args...)
+	name := commandName(cmd)
+
+	_ = s.conn.SetDeadline(time.Now().Add(commandTimeout))
+
+	if _, err := fmt.Fprintf(s.conn, "%s %s\r\n", tag, cmd); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	var untagged []imapLine
+
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		status, found := strings.CutPrefix(line.text, tag+" ")
+		if !found {
+			untagged = append(untagged, line)
+			continue
+		}
+
+		if !strings.HasPrefix(status, "OK") {
+			return nil, fmt.Errorf("%s: %s", name, status)
+		}
+
+		return untagged, nil
+	}
+}
+
+func (s *imapSession) list(all bool) ([]string, error) {
+	criteria := "UNSEEN"
+	if all {
+		criteria = "ALL"
+	}
+
+	lines, err := s.command("UID SEARCH %s", criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []string
+
+	for _, line := range lines {
+		if ids, ok := strings.CutPrefix(line.text, "* SEARCH"); ok {
+			ret = append(ret, strings.Fields(ids)...)
+		}
+	}
+
+	return ret, nil
+}
+
+func (s *imapSession) fetch(id string) ([]byte, error) {
+	// PEEK doesn't set the \Seen flag, it's set once the message is processed
+	lines, err := s.command("UID FETCH %s BODY.PEEK[]", id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		if strings.Contains(line.text, "FETCH") && len(line.literals) > 0 {
+			if line.literals[0] == nil {
+				return nil, errMessageTooLarge
+			}
+
+			return line.literals[0], nil
+		}
+	}
+
+	return nil, fmt.Errorf("message %s not found", id)
+}
+
+func (s *imapSession) markSeen(id string) error {
+	_, err := s.command(`UID STORE %s +FLAGS.SILENT (\Seen)`, id)
+	return err
+}
+
+func (s *imapSession) remove(id string) error {
+	if _, err := s.command(`UID STORE %s +FLAGS.SILENT (\Seen \Deleted)`, id); err != nil {
+		return err
+	}
+
+	s.expunge = true
+
+	return nil
+}
+
+func (s *imapSession) close() error {
+	defer s.stopFunc()
+	defer s.conn.Close()
+
+	// without UIDPLUS, EXPUNGE also removes the messages that were already flagged as deleted by another client
+	if s.expunge {
+		if _, err := s.command("EXPUNGE"); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.command("LOGOUT")
+
+	return err
+}
+
+// pop3Session is a minimal POP3 client (RFC 1939). POP3 has no flags: the source remembers which
+// messages were read, and the deletions are applied when the session is closed.
+type pop3Session struct {
+	conn     net.Conn
+	tp       *textproto.Conn
+	numbers  map[string]string // message number by unique-id, for this session
+	stopFunc func() bool
+}
+
+func newPOP3Session(conn net.Conn, cfg *Configuration, tlsConfig func() *tls.Config, stop func() bool) (*pop3Session, error) {
+	s := &pop3Session{conn: conn, tp: textproto.NewConn(conn), stopFunc: stop}
+
+	_ = conn.SetDeadline(time.Now().Add(commandTimeout))
+
+	greeting, err := s.tp.ReadLine()
+	if err != nil {
+		return nil, fmt.Errorf("reading greeting: %w", err)
+	}
+
+	if !strings.HasPrefix(greeting, "+OK") {
+		return nil, fmt.Errorf("unexpected greeting: %s", greeting)
+	}
+
+	if cfg.TLS == tlsStartTLS {
+		if _, err := s.command("STLS"); err != nil {
+			return nil, err
+		}
+
+		tlsConn := tls.Client(conn, tlsConfig())
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, fmt.Errorf("TLS handshake: %w", err)
+		}
+
+		s.conn = tlsConn
+		s.tp = textproto.NewConn(tlsConn)
+	}
+
+	if _, err := s.command("USER %s", cfg.Username); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.command("PASS %s", cfg.Password); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// command sends a command and returns the text of the +OK response. As with IMAP, only the name
+// of the command is in the errors.
+func (s *pop3Session) command(format string, args ...any) (string, error) {
+	name := commandName(format)
+
+	_ = s.conn.SetDeadline(time.Now().Add(commandTimeout))
+
+	if err := s.tp.PrintfLine(format, args...); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	line, err := s.tp.ReadLine()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+
+	if rest, ok := strings.CutPrefix(line, "+OK"); ok {
+		return strings.TrimSpace(rest), nil
+	}
+
+	return "", fmt.Errorf("%s: %s", name, line)
+}
+
+func (s *pop3Session) list(_ bool) ([]string, error) {
+	if _, err := s.command("UIDL"); err != nil {
+		return nil, err
+	}
+
+	lines, err := s.tp.ReadDotLines()
+	if err != nil {
+		return nil, fmt.Errorf("UIDL: %w", err)
+	}
+
+	s.numbers = make(map[string]string, len(lines))
+	ret := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		number, uid, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("UIDL: invalid line: %s", line)
+		}
+
+		s.numbers[uid] = number
+		ret = append(ret, uid)
+	}
+
+	return ret, nil
+}
+
+func (s *pop3Session) number(id string) (string, error) {
+	number, ok := s.numbers[id]
+	if !ok {
+		return "", fmt.Errorf("message %s not found", id)
+	}
+
+	return number, nil
+}
+
+func (s *pop3Session) fetch(id string) ([]byte, error) {
+	number, err := s.number(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.command("RETR %s", number); err != nil {
+		return nil, err
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(s.tp.DotReader(), maxMessageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("RETR: %w", err)
+	}
+
+	if len(raw) > maxMessageSize {
+		if _, err := io.Copy(io.Discard, s.tp.DotReader()); err != nil {
+			return nil, fmt.Errorf("RETR: %w", err)
+		}
+
+		return nil, errMessageTooLarge
+	}
+
+	return raw, nil
+}
+
+func (*pop3Session) markSeen(_ string) error {
+	return nil
+}
+
+func (s *pop3Session) remove(id string) error {
+	number, err := s.number(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.command("DELE %s", number)
+
+	return err
+}
+
+func (s *pop3Session) close() error {
+	defer s.stopFunc()
+	defer s.conn.Close()
+
+	_, err := s.command("QUIT")
+
+	return err
+}
@@ -0,0 +1,179 @@
+package mailboxacquisition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+const (
+	protocolIMAP = "imap"
+	protocolPOP3 = "pop3"
+
+	tlsImplicit = "implicit" // TLS from the start, usually on ports 993 and 995
+	tlsStartTLS = "starttls" // upgrade with STARTTLS (IMAP) or STLS (POP3), usually on ports 143 and 110
+	tlsNone     = "none"
+
+	defaultMailbox      = "INBOX"
+	defaultPollInterval = time.Minute
+	defaultMaxBodySize  = 64 * 1024
+)
+
+type Configuration struct {
+	configuration.DataSourceCommonCfg `yaml:",inline"`
+
+	Protocol           string        `yaml:"protocol"` // imap or pop3
+	Address            string        `yaml:"address"`  // host:port of the server
+	TLS                string        `yaml:"tls"`      // implicit, starttls or none
+	InsecureSkipVerify bool          `yaml:"insecure_skip_verify"`
+	Username           string        `yaml:"username"`
+	Password           string        `yaml:"password"`
+	Mailbox            string        `yaml:"mailbox"` // imap only
+	PollInterval       time.Duration `yaml:"poll_interval"`
+	Delete             bool          `yaml:"delete"`        // delete the messages once read, instead of marking them as seen
+	MaxBodySize        int           `yaml:"max_body_size"` // the text body is truncated beyond this size, in bytes
+}
+
+func ConfigurationFromYAML(y []byte) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.UnmarshalWithOptions(y, &cfg, yaml.Strict()); err != nil {
+		return cfg, fmt.Errorf("cannot parse: %s", yaml.FormatError(err, false, false))
+	}
+
+	cfg.SetDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Configuration) SetDefaults() {
+	if c.Mode == "" {
+		c.Mode = configuration.TAIL_MODE
+	}
+
+	if c.Protocol == "" {
+		c.Protocol = protocolIMAP
+	}
+
+	if c.TLS == "" {
+		c.TLS = tlsImplicit
+	}
+
+	if c.Mailbox == "" && c.Protocol == protocolIMAP {
+		c.Mailbox = defaultMailbox
+	}
+
+	if c.PollInterval == 0 {
+		c.PollInterval = defaultPollInterval
+	}
+
+	if c.MaxBodySize == 0 {
+		c.MaxBodySize = defaultMaxBodySize
+	}
+}
+
+func (c *Configuration) Validate() error {
+	switch c.Protocol {
+	case protocolIMAP:
+	case protocolPOP3:
+		if c.Mailbox != "" {
+			return errors.New("mailbox is only supported with the imap protocol")
+		}
+	default:
+		return fmt.Errorf("invalid protocol '%s': must be %s or %s", c.Protocol, protocolIMAP, protocolPOP3)
+	}
+
+	if c.Address == "" {
+		return errors.New("address is required")
+	}
+
+	if _, _, err := net.SplitHostPort(c.Address); err != nil {
+		return fmt.Errorf("invalid address '%s': %w", c.Address, err)
+	}
+
+	switch c.TLS {
+	case tlsImplicit, tlsStartTLS, tlsNone:
+	default:
+		return fmt.Errorf("invalid tls '%s': must be %s, %s or %s", c.TLS, tlsImplicit, tlsStartTLS, tlsNone)
+	}
+
+	if c.Username == "" {
+		return errors.New("username is required")
+	}
+
+	if c.Password == "" {
+		return errors.New("password is required")
+	}
+
+	// they are sent as is, in commands
+	for _, field := range []struct{ name, value string }{
+		{"username", c.Username},
+		{"password", c.Password},
+		{"mailbox", c.Mailbox},
+	} {
+		if strings.ContainsAny(field.value, "\r\n") {
+			return fmt.Errorf("%s must not contain line breaks", field.name)
+		}
+	}
+
+	if c.PollInterval < 0 {
+		return errors.New("poll_interval must be positive")
+	}
+
+	if c.MaxBodySize < 0 {
+		return errors.New("max_body_size must be positive")
+	}
+
+	switch c.Mode {
+	case configuration.TAIL_MODE:
+	case configuration.CAT_MODE:
+		// reading the mailbox for a replay must not change it
+		if c.Delete {
+			return errors.New("delete is not supported in cat mode")
+		}
+	default:
+		return fmt.Errorf("unsupported mode %s for mailbox datasource", c.Mode)
+	}
+
+	return nil
+}
+
+func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
+	cfg, err := ConfigurationFromYAML(yamlConfig)
+	if err != nil {
+		return err
+	}
+
+	s.config = cfg
+
+	s.src = fmt.Sprintf("%s://%s@%s", s.config.Protocol, s.config.Username, s.config.Address)
+	if s.config.Mailbox != "" {
+		s.src += "/" + s.config.Mailbox
+	}
+
+	return nil
+}
+
+func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
+	if err := s.UnmarshalConfig(yamlConfig); err != nil {
+		return err
+	}
+
+	s.logger = logger.WithField("src", s.src)
+	s.metricsLevel = metricsLevel
+
+	return nil
+}
@@ -0,0 +1,457 @@
+package mailboxacquisition
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/textproto"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const arfReport = "From: Abuse Desk <Abuse@Example.net>\r\n" +
+	"To: abuse@example.com\r\n" +
+	"Date: Thu, 8 Mar 2026 14:00:00 +0100\r\n" +
+	"Message-ID: <report-1@example.net>\r\n" +
+	"Subject: =?UTF-8?Q?Abuse_report_=E2=80=93_spam?=\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/report; report-type=feedback-report; boundary=\"part\"\r\n" +
+	"\r\n" +
+	"--part\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"This is an abuse report for a message received from 192.0.2.1 =\r\n" +
+	"on Thu, 8 Mar 2026.\r\n" +
+	"--part\r\n" +
+	"Content-Type: message/feedback-report\r\n" +
+	"\r\n" +
+	"Feedback-Type: abuse\r\n" +
+	"User-Agent: SomeGenerator/1.0\r\n" +
+	"Version: 1\r\n" +
+	"Source-IP: 192.0.2.1\r\n" +
+	"Reported-Domain: example.com\r\n" +
+	"--part\r\n" +
+	"Content-Type: text/rfc822-headers\r\n" +
+	"\r\n" +
+	"From: <someone@example.com>\r\n" +
+	"--part\r\n" +
+	"Content-Type: application/zip; name=\"example.net!example.com!1!2.zip\"\r\n" +
+	"Content-Disposition: attachment\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"aGVsbG8g\r\n" +
+	"d29ybGQ=\r\n" +
+	"--part--\r\n"
+
+func TestParseMessage(t *testing.T) {
+	evt, err := parseMessage("42", []byte(arfReport), 1024)
+	require.NoError(t, err)
+
+	assert.Equal(t, "42", evt.ID)
+	assert.Equal(t, "report-1@example.net", evt.MessageID)
+	assert.Equal(t, []string{"abuse@example.net"}, evt.From)
+	assert.Equal(t, []string{"abuse@example.com"}, evt.To)
+	assert.Equal(t, "Abuse report – spam", evt.Subject)
+	assert.Equal(t, time.Date(2026, 3, 8, 13, 0, 0, 0, time.UTC), *evt.Date)
+	assert.Equal(t, "multipart/report", evt.ContentType)
+	assert.Equal(t, "This is an abuse report for a message received from 192.0.2.1 on Thu, 8 Mar 2026.", evt.Body)
+	assert.False(t, evt.BodyTruncated)
+
+	assert.Equal(t, map[string]string{
+		"feedback_type":   "abuse",
+		"user_agent":      "SomeGenerator/1.0",
+		"version":         "1",
+		"source_ip":       "192.0.2.1",
+		"reported_domain": "example.com",
+	}, evt.FeedbackReport)
+
+	assert.Equal(t, []attachment{
+		{
+			ContentType: "text/rfc822-headers",
+			Size:        27,
+			SHA256:      "798ed888fe34b84a2fbccd3d817d64d429040a3ea1044e30fe8bd602067648a4",
+		},
+		{
+			Filename:    "example.net!example.com!1!2.zip",
+			ContentType: "application/zip",
+			Size:        11,
+			SHA256:      "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		},
+	}, evt.Attachments)
+
+	evt, err = parseMessage("43", []byte("Subject: plain\r\n\r\n0123456789"), 4)
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain", evt.ContentType)
+	assert.Equal(t, "0123", evt.Body)
+	assert.True(t, evt.BodyTruncated)
+	assert.Nil(t, evt.Date)
+}
+
+func testMessage(n int) string {
+	return fmt.Sprintf("From: sender%d@example.net\r\nSubject: message %d\r\n\r\nbody %d\r\n", n, n, n)
+}
+
+// fakeIMAP serves a single mailbox over plain text, with what the datasource uses of IMAP.
+type fakeIMAP struct {
+	mu       sync.Mutex
+	uids     []int
+	messages map[int]string
+	flags    map[int][]string
+	commands []string
+}
+
+func (f *fakeIMAP) add(uid int, seen bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.uids = append(f.uids, uid)
+	f.messages[uid] = testMessage(uid)
+
+	if seen {
+		f.flags[uid] = []string{`\Seen`}
+	}
+}
+
+func (f *fakeIMAP) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	fmt.Fprint(conn, "* OK fake IMAP ready\r\n")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		tag, cmd, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+
+		f.mu.Lock()
+		f.commands = append(f.commands, cmd)
+		resp := f.handle(cmd)
+		f.mu.Unlock()
+
+		if strings.HasPrefix(resp, "NO") {
+			fmt.Fprintf(conn, "%s %s", tag, resp)
+		} else {
+			fmt.Fprintf(conn, "%s%s OK done\r\n", resp, tag)
+		}
+
+		if cmd == "LOGOUT" {
+			return
+		}
+	}
+}
+
+// handle returns the untagged responses, or a NO response, of a command.
+func (f *fakeIMAP) handle(cmd string) string {
+	fields := strings.Fields(cmd)
+
+	switch {
+	case fields[0] == "LOGIN":
+		if cmd != `LOGIN "crowdsec" "pass\"word"` {
+			return "NO [AUTHENTICATIONFAILED] invalid credentials\r\n"
+		}
+	case fields[0] == "SELECT" || fields[0] == "EXAMINE":
+		return fmt.Sprintf("* %d EXISTS\r\n", len(f.uids))
+	case cmd == "UID SEARCH UNSEEN" || cmd == "UID SEARCH ALL":
+		ids := []string{}
+
+		for _, uid := range f.uids {
+			if cmd == "UID SEARCH ALL" || !slices.Contains(f.flags[uid], `\Seen`) {
+				ids = append(ids, fmt.Sprint(uid))
+			}
+		}
+
+		return "* SEARCH " + strings.Join(ids, " ") + "\r\n"
+	case fields[0] == "UID" && fields[1] == "FETCH":
+		var uid int
+		fmt.Sscan(fields[2], &uid)
+
+		msg := f.messages[uid]
+
+		return fmt.Sprintf("* %d FETCH (UID %d BODY[] {%d}\r\n%s)\r\n", slices.Index(f.uids, uid)+1, uid, len(msg), msg)
+	case fields[0] == "UID" && fields[1] == "STORE":
+		var uid int
+		fmt.Sscan(fields[2], &uid)
+
+		flags := strings.Trim(strings.Join(fields[4:], " "), "()")
+		f.flags[uid] = append(f.flags[uid], strings.Fields(flags)...)
+	case cmd == "EXPUNGE":
+		f.uids = slices.DeleteFunc(f.uids, func(uid int) bool {
+			return slices.Contains(f.flags[uid], `\Deleted`)
+		})
+	}
+
+	return ""
+}
+
+// fakePOP3 serves a mailbox over plain text, with what the datasource uses of POP3.
+type fakePOP3 struct {
+	mu       sync.Mutex
+	uids     []string
+	commands []string
+}
+
+func (f *fakePOP3) serve(conn net.Conn) {
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+
+	_ = tp.PrintfLine("+OK fake POP3 ready")
+
+	deleted := map[string]bool{}
+
+	for {
+		cmd, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		f.mu.Lock()
+		f.commands = append(f.commands, cmd)
+		uids := slices.Clone(f.uids)
+		f.mu.Unlock()
+
+		fields := strings.Fields(cmd)
+
+		switch fields[0] {
+		case "USER":
+			_ = tp.PrintfLine("+OK")
+		case "PASS":
+			_ = tp.PrintfLine("+OK logged in")
+		case "UIDL":
+			_ = tp.PrintfLine("+OK")
+			w := tp.DotWriter()
+
+			for i, uid := range uids {
+				fmt.Fprintf(w, "%d %s\n", i+1, uid)
+			}
+
+			w.Close()
+		case "RETR":
+			var n int
+			fmt.Sscan(fields[1], &n)
+
+			_ = tp.PrintfLine("+OK")
+			w := tp.DotWriter()
+			fmt.Fprint(w, testMessage(n))
+			w.Close()
+		case "DELE":
+			var n int
+			fmt.Sscan(fields[1], &n)
+
+			deleted[uids[n-1]] = true
+
+			_ = tp.PrintfLine("+OK deleted")
+		case "QUIT":
+			f.mu.Lock()
+			f.uids = slices.DeleteFunc(f.uids, func(uid string) bool { return deleted[uid] })
+			f.mu.Unlock()
+
+			_ = tp.PrintfLine("+OK bye")
+
+			return
+		default:
+			_ = tp.PrintfLine("-ERR unknown command")
+		}
+	}
+}
+
+func listen(t *testing.T, serve func(net.Conn)) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go serve(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func newTestSource(t *testing.T, cfg string) *Source {
+	t.Helper()
+
+	s := Source{}
+	err := s.Configure(t.Context(), []byte(cfg), log.WithField("type", ModuleName), metrics.AcquisitionMetricsLevelNone)
+	require.NoError(t, err)
+
+	return &s
+}
+
+// drain returns the subjects of the events sent so far.
+func drain(t *testing.T, out chan pipeline.Event) []string {
+	t.Helper()
+
+	var ret []string
+
+	for {
+		select {
+		case evt := <-out:
+			var msg mailEvent
+			require.NoError(t, json.Unmarshal([]byte(evt.Line.Raw), &msg))
+			assert.Equal(t, ModuleName, evt.Line.Module)
+			ret = append(ret, msg.Subject)
+		default:
+			return ret
+		}
+	}
+}
+
+func TestIMAP(t *testing.T) {
+	fake := &fakeIMAP{messages: map[int]string{}, flags: map[int][]string{}}
+	fake.add(1, true)
+	fake.add(2, false)
+	fake.add(3, false)
+
+	addr := listen(t, fake.serve)
+	out := make(chan pipeline.Event, 10)
+
+	cfg := `source: mailbox
+labels:
+  type: mailbox
+tls: none
+username: crowdsec
+password: pass"word
+address: ` + addr
+
+	// cat mode reads everything and doesn't change the flags
+	s := newTestSource(t, cfg+"\nmode: cat")
+	require.NoError(t, s.OneShot(t.Context(), out))
+	assert.Equal(t, []string{"message 1", "message 2", "message 3"}, drain(t, out))
+	assert.Contains(t, fake.commands, `EXAMINE "INBOX"`)
+	assert.Equal(t, []string{`\Seen`}, fake.flags[1])
+	assert.Empty(t, fake.flags[2])
+
+	s = newTestSource(t, cfg)
+	assert.Equal(t, "imap://crowdsec@"+addr+"/INBOX", s.src)
+
+	require.NoError(t, s.poll(t.Context(), out, false))
+	assert.Equal(t, []string{"message 2", "message 3"}, drain(t, out))
+	assert.Equal(t, []string{`\Seen`}, fake.flags[3])
+
+	fake.add(4, false)
+	require.NoError(t, s.poll(t.Context(), out, false))
+	assert.Equal(t, []string{"message 4"}, drain(t, out))
+
+	s = newTestSource(t, cfg+"\ndelete: true")
+	fake.add(5, false)
+	require.NoError(t, s.poll(t.Context(), out, false))
+	assert.Equal(t, []string{"message 5"}, drain(t, out))
+	assert.Equal(t, []int{1, 2, 3, 4}, fake.uids)
+
+	// the password is not in the error
+	s = newTestSource(t, strings.Replace(cfg, `pass"word`, "wrong", 1))
+	err := s.poll(t.Context(), out, false)
+	cstest.RequireErrorContains(t, err, "unable to open imap://crowdsec@"+addr+"/INBOX: LOGIN: NO [AUTHENTICATIONFAILED] invalid credentials")
+}
+
+func TestPOP3(t *testing.T) {
+	fake := &fakePOP3{uids: []string{"u1", "u2"}}
+	addr := listen(t, fake.serve)
+	out := make(chan pipeline.Event, 10)
+
+	cfg := `source: mailbox
+labels:
+  type: mailbox
+protocol: pop3
+tls: none
+username: crowdsec
+password: secret
+address: ` + addr
+
+	s := newTestSource(t, cfg)
+	assert.Equal(t, "pop3://crowdsec@"+addr, s.src)
+
+	// the messages already there are skipped
+	require.NoError(t, s.poll(t.Context(), out, false))
+	assert.Empty(t, drain(t, out))
+
+	fake.mu.Lock()
+	fake.uids = append(fake.uids, "u3")
+	fake.mu.Unlock()
+
+	require.NoError(t, s.poll(t.Context(), out, false))
+	assert.Equal(t, []string{"message 3"}, drain(t, out))
+
+	require.NoError(t, s.poll(t.Context(), out, false))
+	assert.Empty(t, drain(t, out))
+
+	// with delete, everything is read and removed
+	s = newTestSource(t, cfg+"\ndelete: true")
+	require.NoError(t, s.poll(t.Context(), out, false))
+	assert.Equal(t, []string{"message 1", "message 2", "message 3"}, drain(t, out))
+	assert.Empty(t, fake.uids)
+	assert.Contains(t, fake.commands, "PASS secret")
+}
+
+func TestStream(t *testing.T) {
+	fake := &fakeIMAP{messages: map[int]string{}, flags: map[int][]string{}}
+	fake.add(1, false)
+
+	s := newTestSource(t, `source: mailbox
+labels:
+  type: mailbox
+tls: none
+username: crowdsec
+password: pass"word
+poll_interval: 50ms
+address: `+listen(t, fake.serve))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	out := make(chan pipeline.Event, 10)
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- s.Stream(ctx, out)
+	}()
+
+	select {
+	case <-out:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for events")
+	}
+
+	fake.add(2, false)
+
+	select {
+	case <-out:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for events")
+	}
+
+	cancel()
+
+	select {
+	case err := <-errChan:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("datasource did not stop")
+	}
+}
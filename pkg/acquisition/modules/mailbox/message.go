@@ -0,0 +1,253 @@
+package mailboxacquisition
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// nested multiparts deeper than this are not parsed
+const maxPartDepth = 10
+
+type attachment struct {
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"` // decoded
+	SHA256      string `json:"sha256"`
+}
+
+// mailEvent is the JSON document sent to the parsers.
+type mailEvent struct {
+	ID            string              `json:"id"` // UID (IMAP) or unique-id (POP3)
+	MessageID     string              `json:"message_id,omitempty"`
+	Date          *time.Time          `json:"date,omitempty"`
+	From          []string            `json:"from,omitempty"`
+	To            []string            `json:"to,omitempty"`
+	Cc            []string            `json:"cc,omitempty"`
+	ReplyTo       []string            `json:"reply_to,omitempty"`
+	ReturnPath    string              `json:"return_path,omitempty"`
+	Subject       string              `json:"subject,omitempty"`
+	Headers       map[string][]string `json:"headers"` // raw values, by canonical name (i.e. Received, X-Originating-Ip)
+	ContentType   string              `json:"content_type"`
+	Body          string              `json:"body,omitempty"` // text/plain part, or text/html if there is none
+	BodyTruncated bool                `json:"body_truncated,omitempty"`
+	Attachments   []attachment        `json:"attachments,omitempty"`
+	// fields of an ARF abuse report (RFC 5965), with lower-case names and underscores: feedback_type, source_ip...
+	FeedbackReport map[string]string `json:"feedback_report,omitempty"`
+
+	bodyType string // media type of the part the body comes from
+}
+
+var wordDecoder = &mime.WordDecoder{}
+
+// decodeHeader decodes the encoded words (RFC 2047) of a header value, or returns it as is.
+func decodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+
+	return decoded
+}
+
+func addressList(header mail.Header, key string) []string {
+	addrs, err := header.AddressList(key)
+	if err != nil {
+		return nil
+	}
+
+	ret := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		ret = append(ret, strings.ToLower(addr.Address))
+	}
+
+	return ret
+}
+
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		// base64 bodies are split in lines
+		return base64.NewDecoder(base64.StdEncoding, &newlineSkipper{r: r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// newlineSkipper removes the line breaks, and the spaces some clients add, from a base64 body.
+type newlineSkipper struct {
+	r io.Reader
+}
+
+func (n *newlineSkipper) Read(p []byte) (int, error) {
+	for {
+		count, err := n.r.Read(p)
+
+		kept := 0
+
+		for _, b := range p[:count] {
+			if b != '\r' && b != '\n' && b != ' ' && b != '\t' {
+				p[kept] = b
+				kept++
+			}
+		}
+
+		if kept > 0 || err != nil {
+			return kept, err
+		}
+	}
+}
+
+// parseFeedbackReport returns the fields of a message/feedback-report part. They have the same
+// syntax as the headers of a message.
+func parseFeedbackReport(r io.Reader) (map[string]string, error) {
+	tp := textproto.NewReader(bufio.NewReader(io.MultiReader(io.LimitReader(r, 64*1024), strings.NewReader("\r\n\r\n"))))
+
+	fields, err := tp.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]string, len(fields))
+
+	for key, values := range fields {
+		ret[strings.ReplaceAll(strings.ToLower(key), "-", "_")] = strings.Join(values, ", ")
+	}
+
+	return ret, nil
+}
+
+// walkPart reads a part of the message, and the parts it contains.
+func walkPart(header textproto.MIMEHeader, body io.Reader, evt *mailEvent, maxBodySize int, depth int) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if depth == 0 {
+		evt.ContentType = mediaType
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" && depth < maxPartDepth {
+		mr := multipart.NewReader(body, params["boundary"])
+
+		for {
+			part, err := mr.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			if err != nil {
+				return fmt.Errorf("reading %s part: %w", mediaType, err)
+			}
+
+			if err := walkPart(part.Header, part, evt, maxBodySize, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	disposition, dispParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+
+	filename := dispParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+
+	filename = decodeHeader(filename)
+	isAttachment := disposition == "attachment" || filename != ""
+	decoded := decodeTransfer(header.Get("Content-Transfer-Encoding"), body)
+
+	switch {
+	case mediaType == "message/feedback-report" && !isAttachment && evt.FeedbackReport == nil:
+		report, err := parseFeedbackReport(decoded)
+		if err != nil {
+			return fmt.Errorf("reading feedback report: %w", err)
+		}
+
+		evt.FeedbackReport = report
+
+		return nil
+	case (mediaType == "text/plain" || mediaType == "text/html") && !isAttachment:
+		// the plain text version is preferred over html
+		if evt.bodyType != "" && !(mediaType == "text/plain" && evt.bodyType == "text/html") {
+			return nil
+		}
+
+		text, err := io.ReadAll(io.LimitReader(decoded, int64(maxBodySize)+1))
+		if err != nil {
+			return fmt.Errorf("reading %s part: %w", mediaType, err)
+		}
+
+		evt.BodyTruncated = len(text) > maxBodySize
+		if evt.BodyTruncated {
+			text = text[:maxBodySize]
+		}
+
+		evt.Body = string(text)
+		evt.bodyType = mediaType
+
+		return nil
+	}
+
+	h := sha256.New()
+
+	size, err := io.Copy(h, decoded)
+	if err != nil {
+		return fmt.Errorf("reading attachment %s: %w", filename, err)
+	}
+
+	evt.Attachments = append(evt.Attachments, attachment{
+		Filename:    filename,
+		ContentType: mediaType,
+		Size:        size,
+		SHA256:      hex.EncodeToString(h.Sum(nil)),
+	})
+
+	return nil
+}
+
+// parseMessage returns the event of a raw message (RFC 5322).
+func parseMessage(id string, raw []byte, maxBodySize int) (*mailEvent, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	evt := &mailEvent{
+		ID:         id,
+		MessageID:  strings.Trim(strings.TrimSpace(msg.Header.Get("Message-Id")), "<>"),
+		From:       addressList(msg.Header, "From"),
+		To:         addressList(msg.Header, "To"),
+		Cc:         addressList(msg.Header, "Cc"),
+		ReplyTo:    addressList(msg.Header, "Reply-To"),
+		ReturnPath: strings.ToLower(strings.Trim(strings.TrimSpace(msg.Header.Get("Return-Path")), "<>")),
+		Subject:    decodeHeader(msg.Header.Get("Subject")),
+		Headers:    msg.Header,
+	}
+
+	if date, err := msg.Header.Date(); err == nil {
+		date = date.UTC()
+		evt.Date = &date
+	}
+
+	if err := walkPart(textproto.MIMEHeader(msg.Header), msg.Body, evt, maxBodySize, 0); err != nil {
+		return nil, err
+	}
+
+	return evt, nil
+}
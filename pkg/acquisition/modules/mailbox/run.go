@@ -0,0 +1,164 @@
+package mailboxacquisition
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func (s *Source) sendEvent(evt *mailEvent, out chan pipeline.Event) {
+	raw, err := json.Marshal(evt)
+	if err != nil {
+		s.logger.Errorf("unable to serialize message %s: %s", evt.ID, err)
+		return
+	}
+
+	if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+		metrics.MailboxDataSourceEventsRead.With(prometheus.Labels{"source": s.src, "datasource_type": ModuleName, "acquis_type": s.config.Labels["type"]}).Inc()
+	}
+
+	ts := time.Now().UTC()
+	if evt.Date != nil {
+		ts = *evt.Date
+	}
+
+	line := pipeline.Line{
+		Raw:     string(raw),
+		Src:     s.src,
+		Time:    ts,
+		Labels:  s.config.Labels,
+		Process: true,
+		Module:  s.GetName(),
+	}
+
+	pevt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+	pevt.Line = line
+
+	out <- pevt
+}
+
+// readMessage fetches and sends a message. It returns an error if the session can't be used anymore:
+// the messages that can't be parsed are only logged, so that they are not read again and again.
+func (s *Source) readMessage(sess session, id string, out chan pipeline.Event) error {
+	raw, err := sess.fetch(id)
+
+	switch {
+	case errors.Is(err, errMessageTooLarge):
+		s.logger.Warningf("message %s skipped: larger than %d bytes", id, maxMessageSize)
+		return nil
+	case err != nil:
+		return err
+	}
+
+	evt, err := parseMessage(id, raw, s.config.MaxBodySize)
+	if err != nil {
+		s.logger.Warningf("message %s skipped: %s", id, err)
+		return nil
+	}
+
+	s.sendEvent(evt, out)
+
+	return nil
+}
+
+// poll reads the new messages of the mailbox. With all, it reads all the messages without
+// changing the mailbox.
+func (s *Source) poll(ctx context.Context, out chan pipeline.Event, all bool) error {
+	sess, err := openSession(ctx, &s.config, all)
+	if err != nil {
+		return fmt.Errorf("unable to open %s: %w", s.src, err)
+	}
+
+	ids, err := sess.list(all)
+	if err != nil {
+		sess.close()
+		return err
+	}
+
+	// POP3 has no seen flag: the messages already in the mailbox are the reference
+	trackSeen := !all && !s.config.Delete && s.config.Protocol == protocolPOP3
+	if trackSeen && s.seen == nil {
+		s.seen = make(map[string]bool, len(ids))
+		for _, id := range ids {
+			s.seen[id] = true
+		}
+
+		s.logger.Infof("%d message(s) already in the mailbox, only the new ones will be read", len(ids))
+
+		return sess.close()
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if trackSeen && s.seen[id] {
+			continue
+		}
+
+		if err := s.readMessage(sess, id, out); err != nil {
+			sess.close()
+			return err
+		}
+
+		switch {
+		case all:
+		case s.config.Delete:
+			err = sess.remove(id)
+		case trackSeen:
+			s.seen[id] = true
+		default:
+			err = sess.markSeen(id)
+		}
+
+		if err != nil {
+			sess.close()
+			return err
+		}
+	}
+
+	return sess.close()
+}
+
+func (s *Source) OneShot(ctx context.Context, out chan pipeline.Event) error {
+	err := s.poll(ctx, out, true)
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	s.logger.Debug("Oneshot acquisition is done")
+
+	return err
+}
+
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	s.logger.Infof("Polling the mailbox every %s", s.config.PollInterval)
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.poll(ctx, out, false); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Datasource stopping")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,5 @@
+//go:build !no_datasource_netflow
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/netflow" // register the datasource
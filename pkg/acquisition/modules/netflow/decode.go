@@ -0,0 +1,459 @@
+package netflowacquisition
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+const (
+	netflowV5 = 5
+	netflowV9 = 9
+	ipfix     = 10
+
+	v5HeaderLen    = 24
+	v5RecordLen    = 48
+	v9HeaderLen    = 20
+	ipfixHeaderLen = 16
+	setHeaderLen   = 4
+
+	// the IDs of the sets below 256 are reserved for the templates, the others carry data
+	v9TemplateSetID    = 0
+	v9OptionsSetID     = 1
+	ipfixTemplateSetID = 2
+	ipfixOptionsSetID  = 3
+	minDataSetID       = 256
+
+	fieldSpecifierLen        = 4
+	optionsTemplateHeaderLen = 6
+	// IPFIX only: vendor specific fields, followed by an enterprise number
+	enterpriseBit       = 0x8000
+	enterpriseNumberLen = 4
+	// IPFIX only: the length is sent before the value, on 1 byte or on 3 bytes starting with 255
+	variableLength     = 0xffff
+	variableLengthLong = 255
+)
+
+// the information elements used by the events, the IDs are the same for NetFlow v9 and IPFIX
+const (
+	fieldInBytes               = 1
+	fieldInPkts                = 2
+	fieldProtocol              = 4
+	fieldTCPFlags              = 6
+	fieldSrcPort               = 7
+	fieldSrcIPv4               = 8
+	fieldDstPort               = 11
+	fieldDstIPv4               = 12
+	fieldSrcAS                 = 16
+	fieldDstAS                 = 17
+	fieldLastSwitched          = 21
+	fieldFirstSwitched         = 22
+	fieldSrcIPv6               = 27
+	fieldDstIPv6               = 28
+	fieldTotalBytes            = 85
+	fieldTotalPkts             = 86
+	fieldFlowStartSeconds      = 150
+	fieldFlowEndSeconds        = 151
+	fieldFlowStartMilliseconds = 152
+	fieldFlowEndMilliseconds   = 153
+)
+
+var (
+	errInvalid            = errors.New("invalid packet")
+	errNoTemplate         = errors.New("unknown template")
+	errUnsupportedVersion = errors.New("unsupported version")
+)
+
+// packetHeader holds what the records of a NetFlow v9 or IPFIX packet need from its header.
+type packetHeader struct {
+	version    uint16
+	exporter   string
+	domain     uint32 // source ID for NetFlow v9, observation domain ID for IPFIX
+	exportTime time.Time
+	uptime     uint32 // milliseconds since the boot of the exporter, NetFlow v9 only
+}
+
+type templateField struct {
+	id         uint16
+	length     uint16
+	enterprise bool
+}
+
+type template struct {
+	fields []templateField
+	minLen int
+	// the options data describe the exporter, not flows
+	options bool
+}
+
+// templates are scoped by exporter and domain, several exporters can use the same IDs
+type templateKey struct {
+	exporter string
+	domain   uint32
+	id       uint16
+}
+
+// decoder decodes the packets of the exporters, and keeps the templates they send for the
+// NetFlow v9 and IPFIX data. It is not safe for concurrent use.
+type decoder struct {
+	templates map[templateKey]*template
+}
+
+func newDecoder() *decoder {
+	return &decoder{
+		templates: make(map[templateKey]*template),
+	}
+}
+
+// decode returns the flow records of a packet. With NetFlow v9 and IPFIX, the records of the
+// sets that can be decoded are returned along with the error of the others, usually because
+// their template was not received yet.
+func (d *decoder) decode(packet []byte, exporter string) ([]flowRecord, error) {
+	if len(packet) < 2 {
+		return nil, fmt.Errorf("%w: truncated header", errInvalid)
+	}
+
+	switch version := binary.BigEndian.Uint16(packet); version {
+	case netflowV5:
+		return decodeV5(packet)
+	case netflowV9:
+		return d.decodeV9(packet, exporter)
+	case ipfix:
+		return d.decodeIPFIX(packet, exporter)
+	default:
+		return nil, fmt.Errorf("%w %d", errUnsupportedVersion, version)
+	}
+}
+
+// uptimeToTime converts a timestamp relative to the boot of the exporter to an absolute time.
+func uptimeToTime(exportTime time.Time, uptime uint32, ts uint32) time.Time {
+	// the subtraction wraps around like the counters, after 49 days of uptime
+	return exportTime.Add(-time.Duration(uptime-ts) * time.Millisecond)
+}
+
+func decodeV5(packet []byte) ([]flowRecord, error) {
+	if len(packet) < v5HeaderLen {
+		return nil, fmt.Errorf("%w: truncated header", errInvalid)
+	}
+
+	count := int(binary.BigEndian.Uint16(packet[2:]))
+	uptime := binary.BigEndian.Uint32(packet[4:])
+	exportTime := time.Unix(int64(binary.BigEndian.Uint32(packet[8:])), int64(binary.BigEndian.Uint32(packet[12:]))).UTC()
+
+	if len(packet) < v5HeaderLen+count*v5RecordLen {
+		return nil, fmt.Errorf("%w: %d records announced in %d bytes", errInvalid, count, len(packet))
+	}
+
+	ret := make([]flowRecord, 0, count)
+
+	for i := range count {
+		rec := packet[v5HeaderLen+i*v5RecordLen:][:v5RecordLen]
+
+		ret = append(ret, flowRecord{
+			version:    netflowV5,
+			exportTime: exportTime,
+			srcIP:      netip.AddrFrom4([4]byte(rec[0:4])),
+			dstIP:      netip.AddrFrom4([4]byte(rec[4:8])),
+			packets:    uint64(binary.BigEndian.Uint32(rec[16:])),
+			bytes:      uint64(binary.BigEndian.Uint32(rec[20:])),
+			start:      uptimeToTime(exportTime, uptime, binary.BigEndian.Uint32(rec[24:])),
+			end:        uptimeToTime(exportTime, uptime, binary.BigEndian.Uint32(rec[28:])),
+			srcPort:    binary.BigEndian.Uint16(rec[32:]),
+			dstPort:    binary.BigEndian.Uint16(rec[34:]),
+			tcpFlags:   rec[37],
+			protocol:   rec[38],
+			srcAS:      uint32(binary.BigEndian.Uint16(rec[40:])),
+			dstAS:      uint32(binary.BigEndian.Uint16(rec[42:])),
+		})
+	}
+
+	return ret, nil
+}
+
+func (d *decoder) decodeV9(packet []byte, exporter string) ([]flowRecord, error) {
+	if len(packet) < v9HeaderLen {
+		return nil, fmt.Errorf("%w: truncated header", errInvalid)
+	}
+
+	hdr := packetHeader{
+		version:    netflowV9,
+		exporter:   exporter,
+		domain:     binary.BigEndian.Uint32(packet[16:]),
+		exportTime: time.Unix(int64(binary.BigEndian.Uint32(packet[8:])), 0).UTC(),
+		uptime:     binary.BigEndian.Uint32(packet[4:]),
+	}
+
+	return d.decodeSets(packet[v9HeaderLen:], hdr)
+}
+
+func (d *decoder) decodeIPFIX(packet []byte, exporter string) ([]flowRecord, error) {
+	if len(packet) < ipfixHeaderLen {
+		return nil, fmt.Errorf("%w: truncated header", errInvalid)
+	}
+
+	length := int(binary.BigEndian.Uint16(packet[2:]))
+	if length < ipfixHeaderLen || length > len(packet) {
+		return nil, fmt.Errorf("%w: message length %d in %d bytes", errInvalid, length, len(packet))
+	}
+
+	hdr := packetHeader{
+		version:    ipfix,
+		exporter:   exporter,
+		domain:     binary.BigEndian.Uint32(packet[12:]),
+		exportTime: time.Unix(int64(binary.BigEndian.Uint32(packet[4:])), 0).UTC(),
+	}
+
+	return d.decodeSets(packet[ipfixHeaderLen:length], hdr)
+}
+
+// decodeSets reads the flowsets (NetFlow v9) or sets (IPFIX) of a packet, which have the same layout.
+func (d *decoder) decodeSets(body []byte, hdr packetHeader) ([]flowRecord, error) {
+	var (
+		ret  []flowRecord
+		errs []error
+	)
+
+	for len(body) > 0 {
+		if len(body) < setHeaderLen {
+			return ret, fmt.Errorf("%w: truncated set header", errInvalid)
+		}
+
+		id := binary.BigEndian.Uint16(body)
+		length := int(binary.BigEndian.Uint16(body[2:]))
+
+		if length < setHeaderLen || length > len(body) {
+			return ret, fmt.Errorf("%w: set length %d in %d bytes", errInvalid, length, len(body))
+		}
+
+		set := body[setHeaderLen:length]
+		body = body[length:]
+
+		var err error
+
+		switch {
+		case hdr.version == netflowV9 && id == v9TemplateSetID, hdr.version == ipfix && id == ipfixTemplateSetID:
+			err = d.addTemplates(set, hdr, false)
+		case hdr.version == netflowV9 && id == v9OptionsSetID, hdr.version == ipfix && id == ipfixOptionsSetID:
+			err = d.addTemplates(set, hdr, true)
+		case id >= minDataSetID:
+			var records []flowRecord
+
+			records, err = d.decodeData(set, id, hdr)
+			ret = append(ret, records...)
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return ret, errors.Join(errs...)
+}
+
+// addTemplates stores the templates of a template set, to decode the data sets that refer to them.
+func (d *decoder) addTemplates(set []byte, hdr packetHeader, options bool) error {
+	// the sets may end with padding, shorter than a template header
+	for len(set) >= setHeaderLen {
+		id := binary.BigEndian.Uint16(set)
+		if id < minDataSetID {
+			// padding
+			return nil
+		}
+
+		var count int
+
+		switch {
+		case options && len(set) < optionsTemplateHeaderLen:
+			return fmt.Errorf("%w: truncated options template %d", errInvalid, id)
+		case options && hdr.version == netflowV9:
+			// the lengths in bytes of the scope fields and of the option fields
+			count = (int(binary.BigEndian.Uint16(set[2:])) + int(binary.BigEndian.Uint16(set[4:]))) / fieldSpecifierLen
+			set = set[optionsTemplateHeaderLen:]
+		case options:
+			// the field count includes the scope fields
+			count = int(binary.BigEndian.Uint16(set[2:]))
+			set = set[optionsTemplateHeaderLen:]
+		default:
+			count = int(binary.BigEndian.Uint16(set[2:]))
+			set = set[setHeaderLen:]
+		}
+
+		key := templateKey{exporter: hdr.exporter, domain: hdr.domain, id: id}
+
+		if count == 0 {
+			// template withdrawal
+			delete(d.templates, key)
+			continue
+		}
+
+		tmpl := &template{fields: make([]templateField, 0, count), options: options}
+
+		for range count {
+			if len(set) < fieldSpecifierLen {
+				return fmt.Errorf("%w: truncated template %d", errInvalid, id)
+			}
+
+			field := templateField{id: binary.BigEndian.Uint16(set), length: binary.BigEndian.Uint16(set[2:])}
+			set = set[fieldSpecifierLen:]
+
+			if hdr.version == ipfix && field.id&enterpriseBit != 0 {
+				// the enterprise number follows, the meaning of the field is vendor specific
+				if len(set) < enterpriseNumberLen {
+					return fmt.Errorf("%w: truncated template %d", errInvalid, id)
+				}
+
+				field.id &^= enterpriseBit
+				field.enterprise = true
+				set = set[enterpriseNumberLen:]
+			}
+
+			switch {
+			case hdr.version == ipfix && field.length == variableLength:
+				// at least the length prefix
+				tmpl.minLen++
+			default:
+				tmpl.minLen += int(field.length)
+			}
+
+			tmpl.fields = append(tmpl.fields, field)
+		}
+
+		if tmpl.minLen == 0 {
+			return fmt.Errorf("%w: empty records in template %d", errInvalid, id)
+		}
+
+		d.templates[key] = tmpl
+	}
+
+	return nil
+}
+
+// variableFieldLen reads the length prefix of a variable-length IPFIX field.
+func variableFieldLen(data []byte) (int, []byte, error) {
+	if len(data) < 1 {
+		return 0, nil, fmt.Errorf("%w: truncated record", errInvalid)
+	}
+
+	if data[0] < variableLengthLong {
+		return int(data[0]), data[1:], nil
+	}
+
+	if len(data) < 3 {
+		return 0, nil, fmt.Errorf("%w: truncated record", errInvalid)
+	}
+
+	return int(binary.BigEndian.Uint16(data[1:])), data[3:], nil
+}
+
+// decodeData returns the flow records of a data set. The records without addresses are ignored.
+func (d *decoder) decodeData(set []byte, id uint16, hdr packetHeader) ([]flowRecord, error) {
+	tmpl, ok := d.templates[templateKey{exporter: hdr.exporter, domain: hdr.domain, id: id}]
+	if !ok {
+		return nil, fmt.Errorf("%w %d", errNoTemplate, id)
+	}
+
+	if tmpl.options {
+		return nil, nil
+	}
+
+	var ret []flowRecord
+
+	// what's left after the last record is padding
+	for len(set) >= tmpl.minLen {
+		rec := flowRecord{version: hdr.version, exportTime: hdr.exportTime}
+
+		for _, field := range tmpl.fields {
+			length := int(field.length)
+
+			if hdr.version == ipfix && field.length == variableLength {
+				var err error
+
+				length, set, err = variableFieldLen(set)
+				if err != nil {
+					return ret, err
+				}
+			}
+
+			if len(set) < length {
+				return ret, fmt.Errorf("%w: truncated record", errInvalid)
+			}
+
+			if !field.enterprise {
+				rec.setField(field.id, set[:length], hdr)
+			}
+
+			set = set[length:]
+		}
+
+		if rec.srcIP.IsValid() || rec.dstIP.IsValid() {
+			ret = append(ret, rec)
+		}
+	}
+
+	return ret, nil
+}
+
+// readUint decodes an unsigned integer, which can be sent with fewer bytes than its type
+// (reduced-size encoding).
+func readUint(value []byte) uint64 {
+	if len(value) > 8 {
+		return 0
+	}
+
+	var ret uint64
+
+	for _, b := range value {
+		ret = ret<<8 | uint64(b)
+	}
+
+	return ret
+}
+
+func (r *flowRecord) setField(id uint16, value []byte, hdr packetHeader) {
+	switch id {
+	case fieldInBytes, fieldTotalBytes:
+		r.bytes = readUint(value)
+	case fieldInPkts, fieldTotalPkts:
+		r.packets = readUint(value)
+	case fieldProtocol:
+		r.protocol = uint8(readUint(value))
+	case fieldTCPFlags:
+		// tcpControlBits is 16 bits wide in IPFIX, the usual flags are in the low byte
+		r.tcpFlags = uint8(readUint(value))
+	case fieldSrcPort:
+		r.srcPort = uint16(readUint(value))
+	case fieldDstPort:
+		r.dstPort = uint16(readUint(value))
+	case fieldSrcIPv4, fieldSrcIPv6:
+		if addr, ok := netip.AddrFromSlice(value); ok {
+			r.srcIP = addr
+		}
+	case fieldDstIPv4, fieldDstIPv6:
+		if addr, ok := netip.AddrFromSlice(value); ok {
+			r.dstIP = addr
+		}
+	case fieldSrcAS:
+		r.srcAS = uint32(readUint(value))
+	case fieldDstAS:
+		r.dstAS = uint32(readUint(value))
+	case fieldFirstSwitched:
+		// IPFIX exporters would need to send their boot time as well, only NetFlow v9 has it in the header
+		if hdr.version == netflowV9 {
+			r.start = uptimeToTime(hdr.exportTime, hdr.uptime, uint32(readUint(value)))
+		}
+	case fieldLastSwitched:
+		if hdr.version == netflowV9 {
+			r.end = uptimeToTime(hdr.exportTime, hdr.uptime, uint32(readUint(value)))
+		}
+	case fieldFlowStartSeconds:
+		r.start = time.Unix(int64(readUint(value)), 0).UTC()
+	case fieldFlowEndSeconds:
+		r.end = time.Unix(int64(readUint(value)), 0).UTC()
+	case fieldFlowStartMilliseconds:
+		r.start = time.UnixMilli(int64(readUint(value))).UTC()
+	case fieldFlowEndMilliseconds:
+		r.end = time.UnixMilli(int64(readUint(value))).UTC()
+	}
+}
@@ -0,0 +1,149 @@
+package netflowacquisition
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// flowRecord is a flow, as reported by an exporter. The fields that were not in the record are zero.
+type flowRecord struct {
+	version    uint16
+	exportTime time.Time
+	srcIP      netip.Addr
+	dstIP      netip.Addr
+	srcPort    uint16
+	dstPort    uint16
+	protocol   uint8
+	bytes      uint64
+	packets    uint64
+	tcpFlags   uint8
+	start      time.Time
+	end        time.Time
+	srcAS      uint32
+	dstAS      uint32
+}
+
+var versionNames = map[uint16]string{
+	netflowV5: "netflow_v5",
+	netflowV9: "netflow_v9",
+	ipfix:     "ipfix",
+}
+
+var protocolNames = map[uint8]string{
+	1:   "icmp",
+	6:   "tcp",
+	17:  "udp",
+	47:  "gre",
+	50:  "esp",
+	58:  "icmpv6",
+	132: "sctp",
+}
+
+// in the order of the bits, from the lowest
+var tcpFlagNames = []string{"FIN", "SYN", "RST", "PSH", "ACK", "URG", "ECE", "CWR"}
+
+func protocolName(proto uint8) string {
+	if name, ok := protocolNames[proto]; ok {
+		return name
+	}
+
+	return strconv.Itoa(int(proto))
+}
+
+// tcpFlags returns the names of the flags seen during the flow, like "SYN,ACK".
+func tcpFlags(flags uint8) string {
+	names := []string{}
+
+	for bit, name := range tcpFlagNames {
+		if flags&(1<<bit) != 0 {
+			names = append(names, name)
+		}
+	}
+
+	return strings.Join(names, ",")
+}
+
+func formatEndpoint(addr netip.Addr, port uint16) string {
+	if !addr.IsValid() {
+		return "-"
+	}
+
+	return netip.AddrPortFrom(addr, port).String()
+}
+
+// line is the raw line of the event, for the logs and the parsers that prefer a grok pattern.
+func (r *flowRecord) line() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s %s -> %s %s bytes=%d packets=%d", versionNames[r.version],
+		formatEndpoint(r.srcIP, r.srcPort), formatEndpoint(r.dstIP, r.dstPort), protocolName(r.protocol), r.bytes, r.packets)
+
+	if r.protocol == 6 {
+		fmt.Fprintf(&sb, " flags=%s", tcpFlags(r.tcpFlags))
+	}
+
+	return sb.String()
+}
+
+// makeEvent converts a flow to an event. The fields are in Parsed, and the time of the event
+// is the end of the flow, or the export time of the packet if the exporter didn't send it.
+func (s *Source) makeEvent(r *flowRecord, exporter string) pipeline.Event {
+	evt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+
+	evt.Parsed["flow_version"] = versionNames[r.version]
+	evt.Parsed["exporter"] = exporter
+	evt.Parsed["protocol"] = protocolName(r.protocol)
+	evt.Parsed["bytes"] = strconv.FormatUint(r.bytes, 10)
+	evt.Parsed["packets"] = strconv.FormatUint(r.packets, 10)
+	evt.Parsed["src_port"] = strconv.Itoa(int(r.srcPort))
+	evt.Parsed["dst_port"] = strconv.Itoa(int(r.dstPort))
+
+	if r.srcIP.IsValid() {
+		evt.Parsed["src_ip"] = r.srcIP.Unmap().String()
+	}
+
+	if r.dstIP.IsValid() {
+		evt.Parsed["dst_ip"] = r.dstIP.Unmap().String()
+	}
+
+	if r.protocol == 6 {
+		evt.Parsed["tcp_flags"] = tcpFlags(r.tcpFlags)
+	}
+
+	if r.srcAS != 0 {
+		evt.Parsed["src_as"] = strconv.FormatUint(uint64(r.srcAS), 10)
+	}
+
+	if r.dstAS != 0 {
+		evt.Parsed["dst_as"] = strconv.FormatUint(uint64(r.dstAS), 10)
+	}
+
+	if !r.start.IsZero() {
+		evt.Parsed["start"] = r.start.Format(time.RFC3339Nano)
+	}
+
+	evtTime := r.exportTime
+
+	if !r.end.IsZero() {
+		evt.Parsed["end"] = r.end.Format(time.RFC3339Nano)
+		evtTime = r.end
+	}
+
+	evt.Line = pipeline.Line{
+		Raw:     r.line(),
+		Src:     exporter,
+		Time:    evtTime,
+		Labels:  s.config.Labels,
+		Module:  s.GetName(),
+		Process: true,
+	}
+
+	evt.StrTime = evtTime.Format(time.RFC3339Nano)
+
+	return evt
+}
@@ -0,0 +1,21 @@
+package netflowacquisition
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+func (*Source) GetMetrics() []prometheus.Collector {
+	return []prometheus.Collector{
+		metrics.NetflowDataSourceFlowsReceived,
+		metrics.NetflowDataSourcePacketsDropped,
+	}
+}
+
+func (*Source) GetAggregMetrics() []prometheus.Collector {
+	return []prometheus.Collector{
+		metrics.NetflowDataSourceFlowsReceived,
+		metrics.NetflowDataSourcePacketsDropped,
+	}
+}
@@ -0,0 +1,468 @@
+package netflowacquisition
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func TestConfigure(t *testing.T) {
+	ctx := t.Context()
+
+	tests := []struct {
+		config  string
+		wantErr string
+	}{
+		{
+			config: "source: netflow",
+		},
+		{
+			config: `
+source: netflow
+foobar: 42`,
+			wantErr: `[3:1] unknown field "foobar"`,
+		},
+		{
+			config: `
+source: netflow
+listen_port: 123456`,
+			wantErr: "invalid port 123456",
+		},
+		{
+			config: `
+source: netflow
+listen_addr: localhost`,
+			wantErr: "invalid listen IP localhost",
+		},
+		{
+			config: `
+source: netflow
+listen_addr: 0.0.0.0
+listen_port: 4739`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.config, func(t *testing.T) {
+			s := Source{}
+			logger, _ := logtest.NewNullLogger()
+			err := s.Configure(ctx, []byte(tc.config), logrus.NewEntry(logger), metrics.AcquisitionMetricsLevelNone)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+var (
+	exportTime = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	be         = binary.BigEndian
+)
+
+// v5Packet builds a NetFlow v5 packet with one TCP flow, exported 10s after the boot of the exporter.
+func v5Packet() []byte {
+	pkt := be.AppendUint16(nil, netflowV5)
+	pkt = be.AppendUint16(pkt, 1)     // count
+	pkt = be.AppendUint32(pkt, 10000) // sysUptime
+	pkt = be.AppendUint32(pkt, uint32(exportTime.Unix()))
+	pkt = be.AppendUint32(pkt, 0)      // nsecs
+	pkt = be.AppendUint32(pkt, 42)     // flow sequence
+	pkt = append(pkt, 0, 0, 0, 0)      // engine type and id, sampling
+	pkt = append(pkt, 192, 0, 2, 1)    // src
+	pkt = append(pkt, 198, 51, 100, 2) // dst
+	pkt = append(pkt, 0, 0, 0, 0)      // next hop
+	pkt = be.AppendUint16(pkt, 1)      // input interface
+	pkt = be.AppendUint16(pkt, 2)      // output interface
+	pkt = be.AppendUint32(pkt, 3)      // packets
+	pkt = be.AppendUint32(pkt, 180)    // bytes
+	pkt = be.AppendUint32(pkt, 4000)   // first
+	pkt = be.AppendUint32(pkt, 9000)   // last
+	pkt = be.AppendUint16(pkt, 51234)  // src port
+	pkt = be.AppendUint16(pkt, 22)     // dst port
+	pkt = append(pkt, 0, 0x02, 6, 0)   // pad, SYN, tcp, tos
+	pkt = be.AppendUint16(pkt, 64496)  // src as
+	pkt = be.AppendUint16(pkt, 64497)  // dst as
+	pkt = append(pkt, 24, 24, 0, 0)    // masks, pad
+
+	return pkt
+}
+
+// appendSet appends a set (or flowset) with its header.
+func appendSet(pkt []byte, id uint16, content []byte) []byte {
+	pkt = be.AppendUint16(pkt, id)
+	pkt = be.AppendUint16(pkt, uint16(setHeaderLen+len(content)))
+
+	return append(pkt, content...)
+}
+
+func v9Packet(sets ...[]byte) []byte {
+	pkt := be.AppendUint16(nil, netflowV9)
+	pkt = be.AppendUint16(pkt, uint16(len(sets)))
+	pkt = be.AppendUint32(pkt, 10000) // sysUptime
+	pkt = be.AppendUint32(pkt, uint32(exportTime.Unix()))
+	pkt = be.AppendUint32(pkt, 1) // sequence
+	pkt = be.AppendUint32(pkt, 7) // source ID
+
+	for _, set := range sets {
+		pkt = append(pkt, set...)
+	}
+
+	return pkt
+}
+
+func ipfixPacket(sets ...[]byte) []byte {
+	pkt := be.AppendUint16(nil, ipfix)
+	pkt = be.AppendUint16(pkt, 0) // length, set below
+	pkt = be.AppendUint32(pkt, uint32(exportTime.Unix()))
+	pkt = be.AppendUint32(pkt, 1) // sequence
+	pkt = be.AppendUint32(pkt, 7) // observation domain
+
+	for _, set := range sets {
+		pkt = append(pkt, set...)
+	}
+
+	be.PutUint16(pkt[2:], uint16(len(pkt)))
+
+	return pkt
+}
+
+// v9Template describes UDP flows: src, dst, ports, protocol, bytes (on 4 bytes), packets (on 2 bytes), first, last.
+func v9Template() []byte {
+	tmpl := be.AppendUint16(nil, 256)
+	tmpl = be.AppendUint16(tmpl, 9)
+
+	for _, f := range [][2]uint16{
+		{fieldSrcIPv4, 4}, {fieldDstIPv4, 4}, {fieldSrcPort, 2}, {fieldDstPort, 2}, {fieldProtocol, 1},
+		{fieldInBytes, 4}, {fieldInPkts, 2}, {fieldFirstSwitched, 4}, {fieldLastSwitched, 4},
+	} {
+		tmpl = be.AppendUint16(tmpl, f[0])
+		tmpl = be.AppendUint16(tmpl, f[1])
+	}
+
+	return appendSet(nil, v9TemplateSetID, tmpl)
+}
+
+func v9Data() []byte {
+	var data []byte
+
+	for _, src := range []byte{1, 2} {
+		data = append(data, 203, 0, 113, src, 192, 0, 2, 53)
+		data = be.AppendUint16(data, 5353)
+		data = be.AppendUint16(data, 53)
+		data = append(data, 17)
+		data = be.AppendUint32(data, 1500)
+		data = be.AppendUint16(data, 2)
+		data = be.AppendUint32(data, 8000)
+		data = be.AppendUint32(data, 9500)
+	}
+
+	// padding to a multiple of 4 bytes
+	data = append(data, 0, 0)
+
+	return appendSet(nil, 256, data)
+}
+
+func TestDecodeV5(t *testing.T) {
+	records, err := newDecoder().decode(v5Packet(), "10.0.0.1")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	assert.Equal(t, flowRecord{
+		version:    netflowV5,
+		exportTime: exportTime,
+		srcIP:      netip.MustParseAddr("192.0.2.1"),
+		dstIP:      netip.MustParseAddr("198.51.100.2"),
+		srcPort:    51234,
+		dstPort:    22,
+		protocol:   6,
+		bytes:      180,
+		packets:    3,
+		tcpFlags:   0x02,
+		start:      exportTime.Add(-6 * time.Second),
+		end:        exportTime.Add(-time.Second),
+		srcAS:      64496,
+		dstAS:      64497,
+	}, records[0])
+
+	_, err = newDecoder().decode(v5Packet()[:50], "10.0.0.1")
+	cstest.RequireErrorContains(t, err, "invalid packet: 1 records announced in 50 bytes")
+}
+
+func TestDecodeV9(t *testing.T) {
+	dec := newDecoder()
+
+	// the data can't be decoded before the template is received
+	records, err := dec.decode(v9Packet(v9Data()), "10.0.0.1")
+	require.ErrorIs(t, err, errNoTemplate)
+	assert.Empty(t, records)
+
+	records, err = dec.decode(v9Packet(v9Template(), v9Data()), "10.0.0.1")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, flowRecord{
+		version:    netflowV9,
+		exportTime: exportTime,
+		srcIP:      netip.MustParseAddr("203.0.113.2"),
+		dstIP:      netip.MustParseAddr("192.0.2.53"),
+		srcPort:    5353,
+		dstPort:    53,
+		protocol:   17,
+		bytes:      1500,
+		packets:    2,
+		start:      exportTime.Add(-2 * time.Second),
+		end:        exportTime.Add(-500 * time.Millisecond),
+	}, records[1])
+
+	// the template is kept for the next packets of the exporter
+	records, err = dec.decode(v9Packet(v9Data()), "10.0.0.1")
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	// but not shared with the other exporters
+	_, err = dec.decode(v9Packet(v9Data()), "10.0.0.2")
+	require.ErrorIs(t, err, errNoTemplate)
+
+	// options templates and their data are ignored
+	opts := be.AppendUint16(nil, 257)
+	opts = be.AppendUint16(opts, 4) // scope length
+	opts = be.AppendUint16(opts, 4) // options length
+	opts = be.AppendUint16(opts, 1) // scope: system
+	opts = be.AppendUint16(opts, 4)
+	opts = be.AppendUint16(opts, 34) // sampling interval
+	opts = be.AppendUint16(opts, 4)
+	opts = append(opts, 0, 0) // padding
+
+	records, err = dec.decode(v9Packet(appendSet(nil, v9OptionsSetID, opts), appendSet(nil, 257, make([]byte, 8))), "10.0.0.1")
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestDecodeIPFIX(t *testing.T) {
+	// IPv6 TCP flows, with a vendor specific field and a variable-length field
+	tmpl := be.AppendUint16(nil, 300)
+	tmpl = be.AppendUint16(tmpl, 9)
+
+	for _, f := range [][2]uint16{
+		{fieldSrcIPv6, 16}, {fieldDstIPv6, 16}, {fieldSrcPort, 2}, {fieldDstPort, 2}, {fieldProtocol, 1},
+		{fieldTCPFlags, 2}, {fieldTotalBytes, 8}, {fieldFlowEndMilliseconds, 8},
+	} {
+		tmpl = be.AppendUint16(tmpl, f[0])
+		tmpl = be.AppendUint16(tmpl, f[1])
+	}
+
+	tmpl = be.AppendUint16(tmpl, fieldSrcIPv4|enterpriseBit)
+	tmpl = be.AppendUint16(tmpl, variableLength)
+	tmpl = be.AppendUint32(tmpl, 29305) // enterprise number
+
+	data := netip.MustParseAddr("2001:db8::1").AsSlice()
+	data = append(data, netip.MustParseAddr("2001:db8::2").AsSlice()...)
+	data = be.AppendUint16(data, 40000)
+	data = be.AppendUint16(data, 443)
+	data = append(data, 6)
+	data = be.AppendUint16(data, 0x12) // SYN,ACK
+	data = be.AppendUint64(data, 1<<33)
+	data = be.AppendUint64(data, uint64(exportTime.UnixMilli()))
+	// the vendor field has the same ID as an IPv4 address, it must not be used as such
+	data = append(data, 4, 10, 0, 0, 1)
+
+	records, err := newDecoder().decode(ipfixPacket(appendSet(nil, ipfixTemplateSetID, tmpl), appendSet(nil, 300, data)), "10.0.0.1")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	assert.Equal(t, flowRecord{
+		version:    ipfix,
+		exportTime: exportTime,
+		srcIP:      netip.MustParseAddr("2001:db8::1"),
+		dstIP:      netip.MustParseAddr("2001:db8::2"),
+		srcPort:    40000,
+		dstPort:    443,
+		protocol:   6,
+		tcpFlags:   0x12,
+		bytes:      1 << 33,
+		end:        exportTime,
+	}, records[0])
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		packet  []byte
+		wantErr string
+	}{
+		{
+			name:    "empty",
+			packet:  []byte{0},
+			wantErr: "invalid packet: truncated header",
+		},
+		{
+			name:    "sflow",
+			packet:  []byte{0, 0, 0, 5, 0, 0, 0, 1},
+			wantErr: "unsupported version 0",
+		},
+		{
+			name:    "v9 truncated header",
+			packet:  v9Packet()[:12],
+			wantErr: "invalid packet: truncated header",
+		},
+		{
+			name:    "v9 set too long",
+			packet:  v9Packet(v9Template())[:30],
+			wantErr: "invalid packet: set length 44 in 10 bytes",
+		},
+		{
+			name:    "ipfix wrong length",
+			packet:  ipfixPacket()[:12],
+			wantErr: "invalid packet: truncated header",
+		},
+		{
+			name: "ipfix truncated record",
+			packet: ipfixPacket(
+				appendSet(nil, ipfixTemplateSetID, []byte{1, 44, 0, 1, 0, fieldSrcIPv4, 0xff, 0xff}),
+				appendSet(nil, 300, []byte{10, 192, 0})),
+			wantErr: "invalid packet: truncated record",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newDecoder().decode(tc.packet, "10.0.0.1")
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestMakeEvent(t *testing.T) {
+	s := Source{config: Configuration{}}
+	s.config.Labels = map[string]string{"type": "netflow"}
+
+	records, err := decodeV5(v5Packet())
+	require.NoError(t, err)
+
+	evt := s.makeEvent(&records[0], "10.0.0.1")
+
+	assert.Equal(t, "netflow_v5 192.0.2.1:51234 -> 198.51.100.2:22 tcp bytes=180 packets=3 flags=SYN", evt.Line.Raw)
+	assert.Equal(t, "10.0.0.1", evt.Line.Src)
+	assert.Equal(t, ModuleName, evt.Line.Module)
+	assert.Equal(t, exportTime.Add(-time.Second), evt.Line.Time)
+	assert.Equal(t, map[string]string{
+		"flow_version": "netflow_v5",
+		"exporter":     "10.0.0.1",
+		"src_ip":       "192.0.2.1",
+		"dst_ip":       "198.51.100.2",
+		"src_port":     "51234",
+		"dst_port":     "22",
+		"protocol":     "tcp",
+		"bytes":        "180",
+		"packets":      "3",
+		"tcp_flags":    "SYN",
+		"src_as":       "64496",
+		"dst_as":       "64497",
+		"start":        "2026-01-02T03:03:59Z",
+		"end":          "2026-01-02T03:04:04Z",
+	}, evt.Parsed)
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	addr := conn.LocalAddr()
+	conn.Close()
+
+	_, port, err := net.SplitHostPort(addr.String())
+	require.NoError(t, err)
+
+	ret, err := strconv.Atoi(port)
+	require.NoError(t, err)
+
+	return ret
+}
+
+func receive(t *testing.T, out chan pipeline.Event) pipeline.Event {
+	t.Helper()
+
+	select {
+	case evt := <-out:
+		return evt
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timeout waiting for an event")
+	}
+
+	return pipeline.Event{}
+}
+
+func TestStream(t *testing.T) {
+	ctx := t.Context()
+	port := freePort(t)
+
+	s := Source{}
+	logger, _ := logtest.NewNullLogger()
+	err := s.Configure(ctx, fmt.Appendf(nil, "source: netflow\nlisten_port: %d\nlabels:\n  type: test", port),
+		logrus.NewEntry(logger), metrics.AcquisitionMetricsLevelNone)
+	require.NoError(t, err)
+
+	out := make(chan pipeline.Event, 10)
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- s.Stream(ctx, out)
+	}()
+
+	t.Cleanup(func() {
+		// the context of the test is canceled before the cleanup functions are called
+		require.NoError(t, <-errCh)
+	})
+
+	conn, err := net.Dial("udp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { conn.Close() })
+
+	// the datagrams sent before the server listens are lost (or refused)
+	require.Eventually(t, func() bool {
+		_, _ = conn.Write(v5Packet())
+
+		select {
+		case <-out:
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// invalid packets are dropped, the source keeps running
+	_, err = conn.Write([]byte("not a flow"))
+	require.NoError(t, err)
+
+	_, err = conn.Write(v9Packet(v9Template(), v9Data()))
+	require.NoError(t, err)
+
+	evt := receive(t, out)
+	// skip the v5 packets that were in flight
+	for evt.Parsed["flow_version"] == "netflow_v5" {
+		evt = receive(t, out)
+	}
+
+	assert.Equal(t, "netflow_v9", evt.Parsed["flow_version"])
+	assert.Equal(t, "203.0.113.1", evt.Parsed["src_ip"])
+	assert.Equal(t, "udp", evt.Parsed["protocol"])
+	assert.Equal(t, "127.0.0.1", evt.Line.Src)
+	assert.Equal(t, "test", evt.Line.Labels["type"])
+
+	assert.Equal(t, "203.0.113.2", receive(t, out).Parsed["src_ip"])
+}
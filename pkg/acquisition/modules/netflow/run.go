@@ -0,0 +1,131 @@
+package netflowacquisition
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// maximum size of a UDP datagram
+const maxDatagramLen = 65535
+
+type datagram struct {
+	payload  []byte
+	exporter string
+}
+
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	addr := net.JoinHostPort(s.config.Addr, strconv.Itoa(s.config.Port))
+
+	lc := net.ListenConfig{}
+
+	conn, err := lc.ListenPacket(ctx, "udp", addr)
+	if err != nil {
+		return fmt.Errorf("could not listen on %s/udp: %w", addr, err)
+	}
+
+	s.logger.Infof("listening on %s/udp", addr)
+
+	datagrams := make(chan datagram)
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		defer close(datagrams)
+
+		context.AfterFunc(ctx, func() {
+			// closing the socket unblocks ReadFrom()
+			conn.Close()
+		})
+
+		buf := make([]byte, maxDatagramLen)
+
+		for {
+			n, raddr, err := conn.ReadFrom(buf)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil //nolint:nilerr  // context cancelation is not a failure
+				}
+
+				return fmt.Errorf("reading from socket: %w", err)
+			}
+
+			exporter, _, _ := net.SplitHostPort(raddr.String())
+
+			select {
+			case datagrams <- datagram{payload: bytes.Clone(buf[:n]), exporter: exporter}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	})
+
+	g.Go(func() error {
+		// the templates are only used here, no need for locking
+		dec := newDecoder()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case d, ok := <-datagrams:
+				if !ok {
+					return nil
+				}
+
+				s.handlePacket(ctx, dec, d, out)
+			}
+		}
+	})
+
+	return g.Wait()
+}
+
+// handlePacket sends the flows of a packet to the parsers. The packets, or the sets, that can't be decoded are dropped.
+func (s *Source) handlePacket(ctx context.Context, dec *decoder, d datagram, out chan pipeline.Event) {
+	records, err := dec.decode(d.payload, d.exporter)
+	if err != nil {
+		s.logger.WithField("exporter", d.exporter).Debugf("dropping flows: %s", err)
+		s.countDropped(d.exporter, dropReason(err))
+	}
+
+	if len(records) > 0 && s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+		metrics.NetflowDataSourceFlowsReceived.With(prometheus.Labels{"source": d.exporter, "datasource_type": ModuleName, "acquis_type": s.config.Labels["type"]}).Add(float64(len(records)))
+	}
+
+	for i := range records {
+		select {
+		case out <- s.makeEvent(&records[i], d.exporter):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func dropReason(err error) string {
+	switch {
+	case errors.Is(err, errNoTemplate):
+		return "no_template"
+	case errors.Is(err, errUnsupportedVersion):
+		return "unsupported_version"
+	default:
+		return "invalid"
+	}
+}
+
+func (s *Source) countDropped(exporter string, reason string) {
+	if s.metricsLevel == metrics.AcquisitionMetricsLevelNone {
+		return
+	}
+
+	metrics.NetflowDataSourcePacketsDropped.With(prometheus.Labels{"source": exporter, "reason": reason}).Inc()
+}
@@ -0,0 +1,5 @@
+//go:build !no_datasource_office365
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/office365" // register the datasource
@@ -0,0 +1,290 @@
+package office365acquisition
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// timeFormat is the format of the times of the API: UTC, without a time zone
+const timeFormat = "2006-01-02T15:04:05"
+
+// the token is renewed when it expires in less than tokenRenewal
+const tokenRenewal = 5 * time.Minute
+
+// maxWindow is the longest time range of a content listing
+const maxWindow = 24 * time.Hour
+
+// activityClient is a minimal client for the Office 365 Management Activity API,
+// authenticated with the client credentials of an application.
+type activityClient struct {
+	tokenURL     string
+	scope        string
+	clientID     string
+	clientSecret string
+	feedURL      string
+	apiHost      string
+	publisherID  string
+	http         *http.Client
+
+	token       string
+	tokenExpiry time.Time
+}
+
+// contentBlob is an entry of a content listing, the audit records are in the blob at ContentURI.
+type contentBlob struct {
+	ContentID         string `json:"contentId"`
+	ContentType       string `json:"contentType"`
+	ContentURI        string `json:"contentUri"`
+	ContentCreated    string `json:"contentCreated"`
+	ContentExpiration string `json:"contentExpiration"`
+}
+
+// apiError is the error returned by the API, i.e. {"error": {"code": "AF20022", "message": "No subscription found for the specified content type"}},
+// or by the token endpoint, i.e. {"error": "invalid_client", "error_description": "AADSTS7000215: Invalid client secret provided..."}
+type apiError struct {
+	Error       json.RawMessage `json:"error"`
+	Description string          `json:"error_description"`
+}
+
+func newActivityClient(cfg *Configuration) *activityClient {
+	apiURL, _ := url.Parse(cfg.APIURL)
+
+	return &activityClient{
+		tokenURL:     cfg.LoginURL + "/" + url.PathEscape(cfg.TenantID) + "/oauth2/v2.0/token",
+		scope:        cfg.APIURL + "/.default",
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		feedURL:      cfg.APIURL + "/api/v1.0/" + url.PathEscape(cfg.TenantID) + "/activity/feed",
+		apiHost:      apiURL.Host,
+		publisherID:  cfg.PublisherID,
+		http: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+// responseError returns the error of a failed request, with the reason given by the API if any.
+func responseError(method string, path string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	var apiErr apiError
+
+	if err := json.Unmarshal(body, &apiErr); err == nil {
+		var detail struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+
+		if apiErr.Description != "" {
+			// the first line is the useful one, the others are trace and correlation IDs
+			desc, _, _ := strings.Cut(apiErr.Description, "\r\n")
+			return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, desc)
+		}
+
+		if err := json.Unmarshal(apiErr.Error, &detail); err == nil && detail.Message != "" {
+			return fmt.Errorf("%s %s: %s: %s (%s)", method, path, resp.Status, detail.Message, detail.Code)
+		}
+	}
+
+	return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+}
+
+// accessToken returns a valid access token, requesting a new one if needed.
+func (c *activityClient) accessToken(ctx context.Context) (string, error) {
+	if c.token != "" && time.Until(c.tokenExpiry) > tokenRenewal {
+		return c.token, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.clientID},
+		"client_secret": {c.clientSecret},
+		"scope":         {c.scope},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("requesting access token: %w", responseError(http.MethodPost, "token", resp))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("requesting access token: decoding response: %w", err)
+	}
+
+	if token.AccessToken == "" {
+		return "", errors.New("requesting access token: empty token")
+	}
+
+	c.token = token.AccessToken
+	c.tokenExpiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+
+	return c.token, nil
+}
+
+// send sends an authenticated request to the API.
+func (c *activityClient) send(ctx context.Context, method string, rawURL string, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	return c.http.Do(req)
+}
+
+// do sends a request to the API and decodes the response in ret, if it's not nil. The request is
+// sent again with a new token if the current one was rejected. The headers of the response are
+// returned for the paging of the content listings.
+func (c *activityClient) do(ctx context.Context, method string, rawURL string, ret any) (http.Header, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// the content and next page urls come from the responses, the token must not leave the API
+	if u.Host != c.apiHost {
+		return nil, fmt.Errorf("%s %s: unexpected host %s", method, u.Path, u.Host)
+	}
+
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(ctx, method, rawURL, token)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		// the token may have been revoked, or have expired early
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		c.token = ""
+
+		if token, err = c.accessToken(ctx); err != nil {
+			return nil, err
+		}
+
+		resp, err = c.send(ctx, method, rawURL, token)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, u.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(method, u.Path, resp)
+	}
+
+	if ret != nil {
+		if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
+			return nil, fmt.Errorf("%s %s: decoding response: %w", method, u.Path, err)
+		}
+	}
+
+	return resp.Header, nil
+}
+
+// feed returns the url of an operation of the activity feed.
+func (c *activityClient) feed(operation string, query url.Values) string {
+	query.Set("PublisherIdentifier", c.publisherID)
+
+	return c.feedURL + "/" + operation + "?" + query.Encode()
+}
+
+// subscriptions returns the status of the subscriptions of the tenant, by content type.
+func (c *activityClient) subscriptions(ctx context.Context) (map[string]string, error) {
+	var ret []struct {
+		ContentType string `json:"contentType"`
+		Status      string `json:"status"`
+	}
+
+	if _, err := c.do(ctx, http.MethodGet, c.feed("subscriptions/list", url.Values{}), &ret); err != nil {
+		return nil, err
+	}
+
+	status := make(map[string]string, len(ret))
+	for _, s := range ret {
+		status[s.ContentType] = s.Status
+	}
+
+	return status, nil
+}
+
+func (c *activityClient) startSubscription(ctx context.Context, contentType string) error {
+	_, err := c.do(ctx, http.MethodPost, c.feed("subscriptions/start", url.Values{"contentType": {contentType}}), nil)
+	return err
+}
+
+// listContent returns the content blobs created between start and end, at most 24 hours apart,
+// following the pages of the listing.
+func (c *activityClient) listContent(ctx context.Context, contentType string, start time.Time, end time.Time) ([]contentBlob, error) {
+	next := c.feed("subscriptions/content", url.Values{
+		"contentType": {contentType},
+		"startTime":   {start.UTC().Format(timeFormat)},
+		"endTime":     {end.UTC().Format(timeFormat)},
+	})
+
+	var blobs []contentBlob
+
+	for next != "" {
+		var page []contentBlob
+
+		header, err := c.do(ctx, http.MethodGet, next, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		blobs = append(blobs, page...)
+		next = header.Get("NextPageUri")
+	}
+
+	return blobs, nil
+}
+
+// fetchContent returns the audit records of a content blob.
+func (c *activityClient) fetchContent(ctx context.Context, contentURI string) ([]json.RawMessage, error) {
+	u, err := url.Parse(contentURI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content uri: %w", err)
+	}
+
+	query := u.Query()
+	if !query.Has("PublisherIdentifier") {
+		query.Set("PublisherIdentifier", c.publisherID)
+		u.RawQuery = query.Encode()
+	}
+
+	var records []json.RawMessage
+
+	if _, err := c.do(ctx, http.MethodGet, u.String(), &records); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
@@ -0,0 +1,186 @@
+package office365acquisition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+const (
+	defaultLoginURL     = "https://login.microsoftonline.com"
+	defaultAPIURL       = "https://manage.office.com"
+	defaultPollInterval = time.Minute
+
+	// the content blobs are kept 7 days by the API
+	maxSince = 7 * 24 * time.Hour
+)
+
+// content types of the Management Activity API
+const (
+	contentAzureAD    = "Audit.AzureActiveDirectory" // sign-ins, user and application management
+	contentExchange   = "Audit.Exchange"             // mailbox access and administration
+	contentSharePoint = "Audit.SharePoint"           // SharePoint and OneDrive
+	contentGeneral    = "Audit.General"              // the other workloads (Teams, Power BI...)
+	contentDLP        = "DLP.All"                    // data loss prevention events
+)
+
+var contentTypes = []string{contentAzureAD, contentExchange, contentSharePoint, contentGeneral, contentDLP}
+
+type Configuration struct {
+	configuration.DataSourceCommonCfg `yaml:",inline"`
+
+	TenantID     string `yaml:"tenant_id"`
+	ClientID     string `yaml:"client_id"` // application registered in Entra ID, with the ActivityFeed.Read permission
+	ClientSecret string `yaml:"client_secret"`
+	// the publisher identifier counts the requests against the quota of a tenant, the tenant ID by default
+	PublisherID       string        `yaml:"publisher_id"`
+	ContentTypes      []string      `yaml:"content_types"`
+	StartSubscription *bool         `yaml:"start_subscription"` // start the subscriptions that are not enabled, true by default
+	PollInterval      time.Duration `yaml:"poll_interval"`
+	Since             time.Duration `yaml:"since"`     // only used in cat mode
+	LoginURL          string        `yaml:"login_url"` // for the national clouds, i.e. https://login.microsoftonline.us
+	APIURL            string        `yaml:"api_url"`   // for the national clouds, i.e. https://manage.office365.us
+}
+
+func ConfigurationFromYAML(y []byte) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.UnmarshalWithOptions(y, &cfg, yaml.Strict()); err != nil {
+		return cfg, fmt.Errorf("cannot parse: %s", yaml.FormatError(err, false, false))
+	}
+
+	cfg.SetDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Configuration) SetDefaults() {
+	if c.Mode == "" {
+		c.Mode = configuration.TAIL_MODE
+	}
+
+	if c.PublisherID == "" {
+		c.PublisherID = c.TenantID
+	}
+
+	if len(c.ContentTypes) == 0 {
+		c.ContentTypes = []string{contentAzureAD, contentExchange}
+	}
+
+	if c.StartSubscription == nil {
+		c.StartSubscription = new(true)
+	}
+
+	if c.PollInterval == 0 {
+		c.PollInterval = defaultPollInterval
+	}
+
+	if c.Since == 0 {
+		c.Since = 24 * time.Hour
+	}
+
+	if c.LoginURL == "" {
+		c.LoginURL = defaultLoginURL
+	}
+
+	if c.APIURL == "" {
+		c.APIURL = defaultAPIURL
+	}
+
+	c.LoginURL = strings.TrimSuffix(c.LoginURL, "/")
+	c.APIURL = strings.TrimSuffix(c.APIURL, "/")
+}
+
+func validateURL(name string, value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid %s scheme '%s': must be http or https", name, u.Scheme)
+	}
+
+	return nil
+}
+
+func (c *Configuration) Validate() error {
+	if c.TenantID == "" {
+		return errors.New("tenant_id is required")
+	}
+
+	if c.ClientID == "" {
+		return errors.New("client_id is required")
+	}
+
+	if c.ClientSecret == "" {
+		return errors.New("client_secret is required")
+	}
+
+	for _, ct := range c.ContentTypes {
+		if !slices.Contains(contentTypes, ct) {
+			return fmt.Errorf("invalid content type '%s': must be one of %s", ct, strings.Join(contentTypes, ", "))
+		}
+	}
+
+	if c.PollInterval < 0 {
+		return errors.New("poll_interval must be positive")
+	}
+
+	if c.Since < 0 || c.Since > maxSince {
+		return fmt.Errorf("since must be positive and at most %s", maxSince)
+	}
+
+	if err := validateURL("login_url", c.LoginURL); err != nil {
+		return err
+	}
+
+	if err := validateURL("api_url", c.APIURL); err != nil {
+		return err
+	}
+
+	switch c.Mode {
+	case configuration.TAIL_MODE, configuration.CAT_MODE:
+	default:
+		return fmt.Errorf("unsupported mode %s for office365 datasource", c.Mode)
+	}
+
+	return nil
+}
+
+func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
+	cfg, err := ConfigurationFromYAML(yamlConfig)
+	if err != nil {
+		return err
+	}
+
+	s.config = cfg
+	s.src = s.config.TenantID
+
+	return nil
+}
+
+func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
+	if err := s.UnmarshalConfig(yamlConfig); err != nil {
+		return err
+	}
+
+	s.logger = logger.WithField("tenant", s.src)
+	s.metricsLevel = metricsLevel
+
+	return nil
+}
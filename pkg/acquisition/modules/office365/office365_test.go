@@ -0,0 +1,292 @@
+package office365acquisition
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/sourcetest"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const tenantID = "8c4a2f3e-6b1d-4e7a-9c5f-0d2e8a1b3c4d"
+
+const signInRecord = `{
+  "Id": "5b0c0c5a-0a1b-4a2c-9d3e-4f5a6b7c8d9e",
+  "CreationTime": "2025-01-02T03:04:05",
+  "Operation": "UserLoginFailed",
+  "Workload": "AzureActiveDirectory",
+  "UserId": "alice@contoso.com",
+  "ClientIP": "192.0.2.10",
+  "ResultStatus": "Failed",
+  "LogonError": "InvalidPasswordAttemptsExceeded"
+}`
+
+const mailboxRecord = `{"Id": "6c1d1d6b-1b2c-4b3d-8e4f-5a6b7c8d9e0f", "CreationTime": "2025-01-02T03:05:06", "Operation": "MailItemsAccessed", "Workload": "Exchange", "UserId": "bob@contoso.com", "ClientIPAddress": "198.51.100.7"}`
+
+// fakeActivityAPI answers the token requests and the calls of the Management Activity API.
+type fakeActivityAPI struct {
+	url         string
+	mu          sync.Mutex
+	calls       []string
+	tokens      int  // number of tokens issued
+	revokeFirst bool // reject the first token after its first use
+	used        map[string]bool
+	enabled     map[string]bool
+}
+
+func (f *fakeActivityAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.URL.Path == "/"+tenantID+"/oauth2/v2.0/token" {
+		f.calls = append(f.calls, "token")
+
+		if r.FormValue("client_secret") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = io.WriteString(w, `{"error": "invalid_client", "error_description": "AADSTS7000215: Invalid client secret provided.\r\nTrace ID: 1234"}`)
+
+			return
+		}
+
+		if r.FormValue("scope") != f.url+"/.default" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		f.tokens++
+		_, _ = fmt.Fprintf(w, `{"token_type": "Bearer", "expires_in": 3599, "access_token": "token-%d"}`, f.tokens)
+
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if f.revokeFirst && token == "token-1" && f.used[token] {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	f.used[token] = true
+
+	feed := "/api/v1.0/" + tenantID + "/activity/feed/"
+	operation, ok := strings.CutPrefix(r.URL.Path, feed)
+
+	if !ok || r.URL.Query().Get("PublisherIdentifier") != tenantID {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	f.calls = append(f.calls, operation)
+	contentType := r.URL.Query().Get("contentType")
+	// the blobs are created at the end of the listing window
+	created := r.URL.Query().Get("endTime") + ".000Z"
+
+	switch operation {
+	case "subscriptions/list":
+		_, _ = io.WriteString(w, `[{"contentType": "Audit.AzureActiveDirectory", "status": "enabled", "webhook": null}]`)
+	case "subscriptions/start":
+		f.enabled[contentType] = true
+		_, _ = fmt.Fprintf(w, `{"contentType": %q, "status": "enabled", "webhook": null}`, contentType)
+	case "subscriptions/content":
+		if contentType == "Audit.Exchange" && !f.enabled[contentType] {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"error": {"code": "AF20022", "message": "No subscription found for the specified content type"}}`)
+
+			return
+		}
+
+		if r.URL.Query().Get("nextPage") == "" && contentType == "Audit.AzureActiveDirectory" {
+			w.Header().Set("NextPageUri", f.url+"/api/v1.0/"+tenantID+"/activity/feed/subscriptions/content?contentType=Audit.AzureActiveDirectory&PublisherIdentifier="+tenantID+"&endTime="+r.URL.Query().Get("endTime")+"&nextPage=2")
+			_, _ = fmt.Fprintf(w, `[{"contentId": "aad-1", "contentType": "Audit.AzureActiveDirectory", "contentUri": "%s/api/v1.0/%s/activity/feed/audit/aad-1", "contentCreated": %q}]`, f.url, tenantID, created)
+
+			return
+		}
+
+		id := "aad-2"
+		if contentType == "Audit.Exchange" {
+			id = "exo-1"
+		}
+
+		_, _ = fmt.Fprintf(w, `[{"contentId": %q, "contentType": %q, "contentUri": "%s/api/v1.0/%s/activity/feed/audit/%s", "contentCreated": %q}]`, id, contentType, f.url, tenantID, id, created)
+	case "audit/aad-1":
+		_, _ = io.WriteString(w, "["+signInRecord+"]")
+	case "audit/aad-2":
+		_, _ = io.WriteString(w, `[]`)
+	case "audit/exo-1":
+		_, _ = io.WriteString(w, "["+mailboxRecord+"]")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newFakeAPI(t *testing.T) *fakeActivityAPI {
+	t.Helper()
+
+	fake := &fakeActivityAPI{used: map[string]bool{}, enabled: map[string]bool{}}
+	fake.url = sourcetest.NewServer(t, fake)
+
+	return fake
+}
+
+func newTestSource(t *testing.T, url string, extra string) *Source {
+	t.Helper()
+
+	s := &Source{}
+	sourcetest.Configure(t, s, `source: office365
+labels:
+  type: office365
+tenant_id: `+tenantID+`
+client_id: 0f1e2d3c-4b5a-6978-8a9b-0c1d2e3f4a5b
+client_secret: secret
+login_url: `+url+`
+api_url: `+url, extra)
+
+	return s
+}
+
+func TestConfigure(t *testing.T) {
+	base := "source: office365\ntenant_id: " + tenantID + "\nclient_id: 0f1e2d3c-4b5a-6978-8a9b-0c1d2e3f4a5b\nclient_secret: secret\n"
+
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "unknown field", extra: "foobar: 42", wantErr: `unknown field "foobar"`},
+		{name: "no tenant_id", extra: "tenant_id: ''", wantErr: "tenant_id is required"},
+		{name: "no client_id", extra: "client_id: ''", wantErr: "client_id is required"},
+		{name: "no client_secret", extra: "client_secret: ''", wantErr: "client_secret is required"},
+		{name: "content type", extra: "content_types: [Audit.Teams]", wantErr: "invalid content type 'Audit.Teams': must be one of"},
+		{name: "poll_interval", extra: "poll_interval: -1s", wantErr: "poll_interval must be positive"},
+		{name: "since", extra: "since: 200h", wantErr: "since must be positive and at most"},
+		{name: "login_url scheme", extra: "login_url: ftp://login.example.com", wantErr: "invalid login_url scheme 'ftp': must be http or https"},
+		{name: "api_url scheme", extra: "api_url: ftp://manage.example.com", wantErr: "invalid api_url scheme 'ftp': must be http or https"},
+		{name: "mode", extra: "mode: foo", wantErr: "unsupported mode foo for office365 datasource"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Source{}
+			cfg := sourcetest.MergeConfig(t, base, tc.extra)
+			err := s.Configure(t.Context(), []byte(cfg), log.WithField("type", ModuleName), metrics.AcquisitionMetricsLevelNone)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestOneShot(t *testing.T) {
+	fake := newFakeAPI(t)
+	s := newTestSource(t, fake.url, "mode: cat\nsince: 36h\n")
+
+	events := sourcetest.OneShot(t, s)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, ModuleName, events[0].Line.Module)
+	assert.Equal(t, tenantID, events[0].Line.Src)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), events[0].Line.Time)
+	assert.NotContains(t, events[0].Line.Raw, "\n")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(events[0].Line.Raw), &record))
+	assert.Equal(t, "UserLoginFailed", record["Operation"])
+	assert.Equal(t, "192.0.2.10", record["ClientIP"])
+
+	assert.Contains(t, events[1].Line.Raw, `"Operation":"MailItemsAccessed"`)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 5, 6, 0, time.UTC), events[1].Line.Time)
+
+	// the Exchange subscription was started, the 36 hours are read in 2 windows,
+	// the blobs listed in both are read once
+	assert.Equal(t, []string{
+		"token",
+		"subscriptions/list",
+		"subscriptions/start",
+		"subscriptions/content",
+		"subscriptions/content",
+		"audit/aad-1",
+		"audit/aad-2",
+		"subscriptions/content",
+		"subscriptions/content",
+		"subscriptions/content",
+		"audit/exo-1",
+		"subscriptions/content",
+	}, fake.calls)
+}
+
+func TestSubscriptionNotStarted(t *testing.T) {
+	fake := newFakeAPI(t)
+	s := newTestSource(t, fake.url, "mode: cat\nstart_subscription: false\n")
+
+	err := s.OneShot(t.Context(), make(chan pipeline.Event, 10))
+	cstest.RequireErrorContains(t, err, "the subscription to Audit.Exchange is not enabled, start it or set start_subscription to true")
+}
+
+func TestErrors(t *testing.T) {
+	fake := newFakeAPI(t)
+
+	s := newTestSource(t, fake.url, "client_secret: wrong\n")
+	err := s.Stream(t.Context(), make(chan pipeline.Event))
+	cstest.RequireErrorContains(t, err, "unable to list the subscriptions of "+tenantID+": requesting access token: POST token: 401 Unauthorized: AADSTS7000215: Invalid client secret provided.")
+
+	// the subscription exists but is not started
+	fake.enabled["Audit.Exchange"] = false
+	s = newTestSource(t, fake.url, "mode: cat\ncontent_types: [Audit.Exchange]\n")
+	client := newActivityClient(&s.config)
+
+	_, err = client.listContent(t.Context(), "Audit.Exchange", time.Now().Add(-time.Hour), time.Now())
+	cstest.RequireErrorContains(t, err, "/activity/feed/subscriptions/content: 400 Bad Request: No subscription found for the specified content type (AF20022)")
+
+	// the token is only sent to the API
+	_, err = client.fetchContent(t.Context(), "https://attacker.example.com/api/v1.0/"+tenantID+"/activity/feed/audit/aad-1")
+	cstest.RequireErrorContains(t, err, "unexpected host attacker.example.com")
+}
+
+func TestTokenRefresh(t *testing.T) {
+	fake := newFakeAPI(t)
+	fake.revokeFirst = true
+
+	s := newTestSource(t, fake.url, "mode: cat\ncontent_types: [Audit.AzureActiveDirectory]\n")
+
+	assert.Len(t, sourcetest.OneShot(t, s), 1)
+
+	// the first token was rejected after its first use, a new one was requested
+	assert.Equal(t, 2, fake.tokens)
+	assert.Equal(t, []string{"token", "subscriptions/list", "token", "subscriptions/content"}, fake.calls[:4])
+}
+
+func TestStream(t *testing.T) {
+	fake := newFakeAPI(t)
+	s := newTestSource(t, fake.url, "poll_interval: 50ms\n")
+
+	// the blobs already read must not be read again
+	sourcetest.Stream(t, s, 2)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	for _, blob := range []string{"audit/aad-1", "audit/exo-1"} {
+		count := 0
+
+		for _, call := range fake.calls {
+			if call == blob {
+				count++
+			}
+		}
+
+		assert.Equal(t, 1, count, blob)
+	}
+}
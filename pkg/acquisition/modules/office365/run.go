@@ -0,0 +1,229 @@
+package office365acquisition
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// a blob can appear in a listing a while after its creation time: the listings overlap, and the
+// blobs that were already read are skipped
+const listingOverlap = 15 * time.Minute
+
+// feedState is the reading position in the content of a content type.
+type feedState struct {
+	contentType string
+	start       time.Time            // start of the next listing
+	seen        map[string]time.Time // creation time of the blobs already read, by content ID
+}
+
+// parseTime reads a time of the API, with or without fractional seconds and time zone.
+func parseTime(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, timeFormat, "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func (s *Source) sendRecord(contentType string, record json.RawMessage, out chan pipeline.Event) {
+	var meta struct {
+		ID           string `json:"Id"`
+		CreationTime string `json:"CreationTime"`
+	}
+
+	if err := json.Unmarshal(record, &meta); err != nil {
+		s.logger.Errorf("unable to read audit record: %s", err)
+		return
+	}
+
+	evtTime, ok := parseTime(meta.CreationTime)
+	if !ok {
+		s.logger.Warningf("audit record %s: invalid creation time %q", meta.ID, meta.CreationTime)
+		evtTime = time.Now().UTC()
+	}
+
+	// one record per line
+	var raw bytes.Buffer
+
+	if err := json.Compact(&raw, record); err != nil {
+		s.logger.Errorf("unable to read audit record %s: %s", meta.ID, err)
+		return
+	}
+
+	if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+		metrics.Office365DataSourceEventsRead.With(prometheus.Labels{
+			"source":          s.src,
+			"content_type":    contentType,
+			"datasource_type": ModuleName,
+			"acquis_type":     s.config.Labels["type"],
+		}).Inc()
+	}
+
+	line := pipeline.Line{
+		Raw:     raw.String(),
+		Src:     s.src,
+		Time:    evtTime,
+		Labels:  s.config.Labels,
+		Process: true,
+		Module:  s.GetName(),
+	}
+
+	evt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+	evt.Line = line
+
+	out <- evt
+}
+
+// ensureSubscriptions checks that the content types are subscribed to, and starts the subscriptions if allowed.
+func (s *Source) ensureSubscriptions(ctx context.Context, client *activityClient) error {
+	status, err := client.subscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to list the subscriptions of %s: %w", s.src, err)
+	}
+
+	for _, contentType := range s.config.ContentTypes {
+		if strings.EqualFold(status[contentType], "enabled") {
+			continue
+		}
+
+		if !*s.config.StartSubscription {
+			return fmt.Errorf("the subscription to %s is not enabled, start it or set start_subscription to true", contentType)
+		}
+
+		s.logger.Infof("Starting the subscription to %s", contentType)
+
+		if err := client.startSubscription(ctx, contentType); err != nil {
+			return fmt.Errorf("unable to start the subscription to %s: %w", contentType, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Source) OneShot(ctx context.Context, out chan pipeline.Event) error {
+	now := time.Now().UTC()
+	err := s.readFeeds(ctx, now.Add(-s.config.Since), now, out)
+	s.logger.Debug("Oneshot acquisition is done")
+
+	return err
+}
+
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	return s.readFeeds(ctx, time.Now().UTC(), time.Time{}, out)
+}
+
+// readFeeds reads the audit records created between since and until. If until is zero, it keeps
+// polling for new records until ctx is canceled.
+func (s *Source) readFeeds(ctx context.Context, since time.Time, until time.Time, out chan pipeline.Event) error {
+	client := newActivityClient(&s.config)
+
+	if err := s.ensureSubscriptions(ctx, client); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return err
+	}
+
+	feeds := make([]*feedState, 0, len(s.config.ContentTypes))
+	for _, contentType := range s.config.ContentTypes {
+		feeds = append(feeds, &feedState{contentType: contentType, start: since, seen: map[string]time.Time{}})
+	}
+
+	s.logger.Infof("Reading %s since %s", strings.Join(s.config.ContentTypes, ", "), since)
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		end := until
+		if end.IsZero() {
+			end = time.Now().UTC()
+		}
+
+		for _, feed := range feeds {
+			if err := s.pollFeed(ctx, client, feed, end, out); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+
+				return err
+			}
+		}
+
+		if !until.IsZero() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Datasource stopping")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollFeed reads the blobs of a content type created until end, by windows of at most 24 hours.
+func (s *Source) pollFeed(ctx context.Context, client *activityClient, feed *feedState, end time.Time, out chan pipeline.Event) error {
+	for start := feed.start; start.Before(end); start = start.Add(maxWindow) {
+		windowEnd := start.Add(maxWindow)
+		if windowEnd.After(end) {
+			windowEnd = end
+		}
+
+		blobs, err := client.listContent(ctx, feed.contentType, start, windowEnd)
+		if err != nil {
+			return fmt.Errorf("unable to list the content of %s: %w", feed.contentType, err)
+		}
+
+		for _, blob := range blobs {
+			if _, ok := feed.seen[blob.ContentID]; ok {
+				continue
+			}
+
+			records, err := client.fetchContent(ctx, blob.ContentURI)
+			if err != nil {
+				return fmt.Errorf("unable to read the content %s: %w", blob.ContentID, err)
+			}
+
+			s.logger.Debugf("read %d records from %s", len(records), blob.ContentID)
+
+			for _, record := range records {
+				s.sendRecord(feed.contentType, record, out)
+			}
+
+			created, ok := parseTime(blob.ContentCreated)
+			if !ok {
+				created = windowEnd
+			}
+
+			feed.seen[blob.ContentID] = created
+		}
+	}
+
+	if next := end.Add(-listingOverlap); next.After(feed.start) {
+		feed.start = next
+	}
+
+	// the blobs created before the next listing won't be listed again (the times of the
+	// listings are in seconds)
+	for id, created := range feed.seen {
+		if created.Before(feed.start.Truncate(time.Second)) {
+			delete(feed.seen, id)
+		}
+	}
+
+	return nil
+}
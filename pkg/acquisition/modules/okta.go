@@ -0,0 +1,5 @@
+//go:build !no_datasource_okta
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/okta" // register the datasource
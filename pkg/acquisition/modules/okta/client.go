@@ -0,0 +1,97 @@
+package oktaacquisition
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/apipoll"
+)
+
+const logsPath = "/api/v1/logs"
+
+// logClient is a minimal client for the Okta System Log API, authenticated with an API token.
+type logClient struct {
+	logsURL string
+	api     *apipoll.Client
+}
+
+// apiErrorReason returns the reason of a failed request given by the API,
+// i.e. {"errorCode": "E0000011", "errorSummary": "Invalid token provided", ...}
+func apiErrorReason(body []byte) string {
+	var apiErr struct {
+		Code    string `json:"errorCode"`
+		Summary string `json:"errorSummary"`
+	}
+
+	if err := json.Unmarshal(body, &apiErr); err != nil || apiErr.Summary == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s (%s)", apiErr.Summary, apiErr.Code)
+}
+
+func newLogClient(cfg *Configuration) *logClient {
+	return &logClient{
+		logsURL: cfg.OrgURL + logsPath,
+		api: &apipoll.Client{
+			HTTP: &http.Client{
+				Timeout: 60 * time.Second,
+			},
+			Header: http.Header{
+				"Authorization": {"SSWS " + cfg.APIToken},
+				"Accept":        {"application/json"},
+			},
+			RateLimitReset: "X-Rate-Limit-Reset",
+			Reason:         apiErrorReason,
+		},
+	}
+}
+
+// nextQuery returns the query of the next page, from the Link headers of a response.
+func nextQuery(header http.Header) (url.Values, bool) {
+	for _, value := range header.Values("Link") {
+		for link := range strings.SplitSeq(value, ",") {
+			target, params, _ := strings.Cut(link, ";")
+			if !strings.Contains(params, `rel="next"`) {
+				continue
+			}
+
+			u, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+			if err != nil {
+				return nil, false
+			}
+
+			return u.Query(), true
+		}
+	}
+
+	return nil, false
+}
+
+// logs returns a page of events, and the query of the next one. The next page is only built on
+// the query of the link: the token is never sent to the host of the link.
+func (c *logClient) logs(ctx context.Context, query url.Values) ([]json.RawMessage, url.Values, error) {
+	resp, err := c.api.Get(ctx, c.logsURL+"?"+query.Encode())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	var events []json.RawMessage
+
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		return nil, nil, fmt.Errorf("GET %s: decoding response: %w", logsPath, err)
+	}
+
+	next, ok := nextQuery(resp.Header)
+	if !ok {
+		return events, nil, nil
+	}
+
+	return events, next, nil
+}
@@ -0,0 +1,138 @@
+package oktaacquisition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+const (
+	defaultPollInterval = time.Minute
+	defaultPageSize     = 1000
+
+	// the events are kept 90 days by Okta
+	maxSince = 90 * 24 * time.Hour
+)
+
+type Configuration struct {
+	configuration.DataSourceCommonCfg `yaml:",inline"`
+
+	OrgURL   string `yaml:"org_url"`   // i.e. https://example.okta.com
+	APIToken string `yaml:"api_token"` // token of an administrator that can read the System Log
+	// SCIM expression selecting the events, i.e. eventType sw "user.authentication"
+	Filter       string        `yaml:"filter"`
+	PageSize     int           `yaml:"page_size"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	Since        time.Duration `yaml:"since"` // only used in cat mode, and in tail mode without cursor
+	// the position in the System Log is saved in this file, to resume after a restart
+	CursorFile string `yaml:"cursor_file"`
+}
+
+func ConfigurationFromYAML(y []byte) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.UnmarshalWithOptions(y, &cfg, yaml.Strict()); err != nil {
+		return cfg, fmt.Errorf("cannot parse: %s", yaml.FormatError(err, false, false))
+	}
+
+	cfg.SetDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Configuration) SetDefaults() {
+	if c.Mode == "" {
+		c.Mode = configuration.TAIL_MODE
+	}
+
+	if c.PageSize == 0 {
+		c.PageSize = defaultPageSize
+	}
+
+	if c.PollInterval == 0 {
+		c.PollInterval = defaultPollInterval
+	}
+
+	if c.Since == 0 && c.Mode == configuration.CAT_MODE {
+		c.Since = 24 * time.Hour
+	}
+
+	c.OrgURL = strings.TrimSuffix(c.OrgURL, "/")
+}
+
+func (c *Configuration) Validate() error {
+	if c.OrgURL == "" {
+		return errors.New("org_url is required")
+	}
+
+	u, err := url.Parse(c.OrgURL)
+	if err != nil {
+		return fmt.Errorf("invalid org_url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid org_url scheme '%s': must be http or https", u.Scheme)
+	}
+
+	if c.APIToken == "" {
+		return errors.New("api_token is required")
+	}
+
+	if c.PageSize < 0 || c.PageSize > defaultPageSize {
+		return fmt.Errorf("page_size must be between 1 and %d", defaultPageSize)
+	}
+
+	if c.PollInterval < 0 {
+		return errors.New("poll_interval must be positive")
+	}
+
+	if c.Since < 0 || c.Since > maxSince {
+		return fmt.Errorf("since must be positive and at most %s", maxSince)
+	}
+
+	switch c.Mode {
+	case configuration.TAIL_MODE, configuration.CAT_MODE:
+	default:
+		return fmt.Errorf("unsupported mode %s for okta datasource", c.Mode)
+	}
+
+	return nil
+}
+
+func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
+	cfg, err := ConfigurationFromYAML(yamlConfig)
+	if err != nil {
+		return err
+	}
+
+	s.config = cfg
+
+	u, _ := url.Parse(s.config.OrgURL)
+	s.src = u.Host
+
+	return nil
+}
+
+func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
+	if err := s.UnmarshalConfig(yamlConfig); err != nil {
+		return err
+	}
+
+	s.logger = logger.WithField("org", s.src)
+	s.metricsLevel = metricsLevel
+
+	return nil
+}
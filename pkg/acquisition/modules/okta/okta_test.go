@@ -0,0 +1,229 @@
+package oktaacquisition
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/cursor"
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/sourcetest"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func oktaEvent(i int) string {
+	return fmt.Sprintf(`{
+  "uuid": "event-%d",
+  "published": "2025-01-02T03:04:%02d.123Z",
+  "eventType": "user.session.start",
+  "outcome": {"result": "FAILURE", "reason": "INVALID_CREDENTIALS"},
+  "actor": {"alternateId": "alice@example.com"},
+  "client": {"ipAddress": "192.0.2.%d"}
+}`, i, i, i)
+}
+
+// fakeSystemLog serves the events of the System Log. The "after" cursor is the index of the next event.
+type fakeSystemLog struct {
+	url         string
+	mu          sync.Mutex
+	events      []string
+	queries     []url.Values
+	rateLimited int // number of requests to refuse with 429
+}
+
+func (f *fakeSystemLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.URL.Path != logsPath {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "SSWS token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"errorCode": "E0000011", "errorSummary": "Invalid token provided", "errorLink": "E0000011", "errorId": "oae1", "errorCauses": []}`)
+
+		return
+	}
+
+	query := r.URL.Query()
+	f.queries = append(f.queries, query)
+
+	if f.rateLimited > 0 {
+		f.rateLimited--
+		w.Header().Set("X-Rate-Limit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+		w.WriteHeader(http.StatusTooManyRequests)
+
+		return
+	}
+
+	start, _ := strconv.Atoi(query.Get("after"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	end := min(start+limit, len(f.events))
+
+	// the next link is always present when polling, and while there are events otherwise
+	if !query.Has("until") || end < len(f.events) {
+		next := url.Values{}
+		for k, v := range query {
+			next[k] = v
+		}
+
+		next.Del("since")
+		next.Set("after", strconv.Itoa(end))
+		w.Header().Add("Link", fmt.Sprintf(`<%s%s?%s>; rel="self"`, f.url, logsPath, query.Encode()))
+		w.Header().Add("Link", fmt.Sprintf(`<https://attacker.example.com%s?%s>; rel="next"`, logsPath, next.Encode()))
+	}
+
+	_, _ = io.WriteString(w, "["+strings.Join(f.events[start:end], ",")+"]")
+}
+
+func (f *fakeSystemLog) addEvents(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for range n {
+		f.events = append(f.events, oktaEvent(len(f.events)))
+	}
+}
+
+func newFakeSystemLog(t *testing.T, n int) *fakeSystemLog {
+	t.Helper()
+
+	fake := &fakeSystemLog{}
+	fake.addEvents(n)
+	fake.url = sourcetest.NewServer(t, fake)
+
+	return fake
+}
+
+func newTestSource(t *testing.T, url string, extra string) *Source {
+	t.Helper()
+
+	s := &Source{}
+	sourcetest.Configure(t, s, `source: okta
+labels:
+  type: okta
+org_url: `+url+`
+api_token: token`, extra)
+
+	return s
+}
+
+func TestConfigure(t *testing.T) {
+	base := "source: okta\norg_url: https://example.okta.com\napi_token: token\n"
+
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "unknown field", extra: "foobar: 42", wantErr: `unknown field "foobar"`},
+		{name: "no org_url", extra: "org_url: ''", wantErr: "org_url is required"},
+		{name: "org_url scheme", extra: "org_url: ftp://example.okta.com", wantErr: "invalid org_url scheme 'ftp': must be http or https"},
+		{name: "no api_token", extra: "api_token: ''", wantErr: "api_token is required"},
+		{name: "page_size", extra: "page_size: 5000", wantErr: "page_size must be between 1 and 1000"},
+		{name: "poll_interval", extra: "poll_interval: -1s", wantErr: "poll_interval must be positive"},
+		{name: "since", extra: "since: 2400h", wantErr: "since must be positive and at most"},
+		{name: "mode", extra: "mode: foo", wantErr: "unsupported mode foo for okta datasource"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Source{}
+			cfg := sourcetest.MergeConfig(t, base, tc.extra)
+			err := s.Configure(t.Context(), []byte(cfg), log.WithField("type", ModuleName), metrics.AcquisitionMetricsLevelNone)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestOneShot(t *testing.T) {
+	fake := newFakeSystemLog(t, 5)
+	s := newTestSource(t, fake.url, "mode: cat\npage_size: 2\nfilter: eventType eq \"user.session.start\"\n")
+
+	events := sourcetest.OneShot(t, s)
+	require.Len(t, events, 5)
+
+	u, _ := url.Parse(fake.url)
+	assert.Equal(t, ModuleName, events[0].Line.Module)
+	assert.Equal(t, u.Host, events[0].Line.Src)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 0, 123000000, time.UTC), events[0].Line.Time)
+	assert.NotContains(t, events[0].Line.Raw, "\n")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(events[4].Line.Raw), &record))
+	assert.Equal(t, "event-4", record["uuid"])
+
+	// 3 pages, the query of the next pages comes from the links
+	require.Len(t, fake.queries, 3)
+	assert.Equal(t, "ASCENDING", fake.queries[0].Get("sortOrder"))
+	assert.Equal(t, `eventType eq "user.session.start"`, fake.queries[0].Get("filter"))
+	assert.True(t, fake.queries[0].Has("since"))
+	assert.True(t, fake.queries[0].Has("until"))
+	assert.Equal(t, "2", fake.queries[1].Get("after"))
+	assert.Equal(t, "4", fake.queries[2].Get("after"))
+}
+
+func TestErrors(t *testing.T) {
+	fake := newFakeSystemLog(t, 1)
+
+	s := newTestSource(t, fake.url, "api_token: wrong\n")
+	err := s.Stream(t.Context(), make(chan pipeline.Event))
+	cstest.RequireErrorContains(t, err, "GET /api/v1/logs: 401 Unauthorized: Invalid token provided (E0000011)")
+}
+
+func TestRateLimit(t *testing.T) {
+	fake := newFakeSystemLog(t, 1)
+	fake.rateLimited = 1
+
+	s := newTestSource(t, fake.url, "mode: cat\n")
+
+	assert.Len(t, sourcetest.OneShot(t, s), 1)
+	assert.Len(t, fake.queries, 2)
+}
+
+func TestStreamCursor(t *testing.T) {
+	fake := newFakeSystemLog(t, 3)
+	cursorFile := filepath.Join(t.TempDir(), "okta.cursor")
+
+	s := newTestSource(t, fake.url, "poll_interval: 50ms\npage_size: 2\nsince: 1h\ncursor_file: "+cursorFile+"\n")
+	events := sourcetest.Stream(t, s, 3)
+	assert.Contains(t, events[2].Line.Raw, `"uuid":"event-2"`)
+
+	saved, err := os.ReadFile(cursorFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(saved), "after=3")
+
+	// the events published while stopped are read after a restart, and only them
+	fake.addEvents(2)
+
+	s = newTestSource(t, fake.url, "poll_interval: 50ms\npage_size: 2\ncursor_file: "+cursorFile+"\n")
+	events = sourcetest.Stream(t, s, 2)
+	assert.Contains(t, events[0].Line.Raw, `"uuid":"event-3"`)
+	assert.Contains(t, events[1].Line.Raw, `"uuid":"event-4"`)
+
+	// a new filter starts over
+	s = newTestSource(t, fake.url, "poll_interval: 50ms\npage_size: 2\nfilter: eventType eq \"x\"\ncursor_file: "+cursorFile+"\n")
+	query, err := s.resumeQuery(cursor.New(cursorFile))
+	require.NoError(t, err)
+	assert.Nil(t, query)
+}
@@ -0,0 +1,184 @@
+package oktaacquisition
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/apipoll"
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/cursor"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func (s *Source) sendEvent(event json.RawMessage, out chan pipeline.Event) {
+	var meta struct {
+		UUID      string `json:"uuid"`
+		Published string `json:"published"`
+	}
+
+	if err := json.Unmarshal(event, &meta); err != nil {
+		s.logger.Errorf("unable to read event: %s", err)
+		return
+	}
+
+	evtTime, err := time.Parse(time.RFC3339Nano, meta.Published)
+	if err != nil {
+		s.logger.Warningf("event %s: invalid published time %q", meta.UUID, meta.Published)
+		evtTime = time.Now()
+	}
+
+	// one event per line
+	var raw bytes.Buffer
+
+	if err := json.Compact(&raw, event); err != nil {
+		s.logger.Errorf("unable to read event %s: %s", meta.UUID, err)
+		return
+	}
+
+	if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+		metrics.OktaDataSourceEventsRead.With(prometheus.Labels{
+			"source":          s.src,
+			"datasource_type": ModuleName,
+			"acquis_type":     s.config.Labels["type"],
+		}).Inc()
+	}
+
+	line := pipeline.Line{
+		Raw:     raw.String(),
+		Src:     s.src,
+		Time:    evtTime.UTC(),
+		Labels:  s.config.Labels,
+		Process: true,
+		Module:  s.GetName(),
+	}
+
+	evt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+	evt.Line = line
+
+	out <- evt
+}
+
+// firstQuery returns the query of the events published since a given time, in ascending order.
+func (s *Source) firstQuery(since time.Time) url.Values {
+	query := url.Values{
+		"since":     {since.UTC().Format(time.RFC3339)},
+		"sortOrder": {"ASCENDING"},
+		"limit":     {strconv.Itoa(s.config.PageSize)},
+	}
+
+	if s.config.Filter != "" {
+		query.Set("filter", s.config.Filter)
+	}
+
+	return query
+}
+
+// fetch returns a page of events, waiting for the reset of the rate limit if needed.
+func (s *Source) fetch(ctx context.Context, client *logClient, query url.Values) ([]json.RawMessage, url.Values, error) {
+	var (
+		events []json.RawMessage
+		next   url.Values
+	)
+
+	err := apipoll.WaitRateLimit(ctx, s.logger, func() error {
+		var err error
+
+		events, next, err = client.logs(ctx, query)
+
+		return err
+	})
+
+	return events, next, err
+}
+
+// readLogs reads the events from a query, following the links to the next pages. If follow is
+// true, it keeps polling for new events until ctx is canceled.
+func (s *Source) readLogs(ctx context.Context, query url.Values, follow bool, cur *cursor.File, out chan pipeline.Event) error {
+	client := newLogClient(&s.config)
+
+	return apipoll.Poll(ctx, s.logger, s.config.PollInterval, follow, func(ctx context.Context) (bool, error) {
+		events, next, err := s.fetch(ctx, client, query)
+		if err != nil {
+			return false, err
+		}
+
+		for _, event := range events {
+			s.sendEvent(event, out)
+		}
+
+		if next == nil {
+			return false, nil
+		}
+
+		query = next
+
+		if err := cur.Save(query.Encode()); err != nil {
+			s.logger.Error(err)
+		}
+
+		// with an end time, the last page has no next link: all of them are read. Without, there
+		// is always one, it's followed right away after a full page
+		return len(events) >= s.config.PageSize || (!follow && len(events) > 0), nil
+	})
+}
+
+func (s *Source) OneShot(ctx context.Context, out chan pipeline.Event) error {
+	now := time.Now().UTC()
+	query := s.firstQuery(now.Add(-s.config.Since))
+	query.Set("until", now.Format(time.RFC3339))
+
+	s.logger.Infof("Reading the System Log since %s", now.Add(-s.config.Since))
+
+	err := s.readLogs(ctx, query, false, nil, out)
+	s.logger.Debug("Oneshot acquisition is done")
+
+	return err
+}
+
+// resumeQuery returns the query saved in the cursor, if it's still valid.
+func (s *Source) resumeQuery(cur *cursor.File) (url.Values, error) {
+	saved, err := cur.Load()
+	if err != nil || saved == "" {
+		return nil, err
+	}
+
+	query, err := url.ParseQuery(saved)
+	if err != nil || !query.Has("after") {
+		s.logger.Warningf("ignoring invalid cursor %q", saved)
+		return nil, nil
+	}
+
+	if query.Get("filter") != s.config.Filter {
+		s.logger.Info("the filter has changed, ignoring the cursor")
+		return nil, nil
+	}
+
+	return query, nil
+}
+
+// Stream polls the System Log. Okta always returns a link to the next page when polling without
+// end time, it's followed to read the events published since the previous page.
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	cur := cursor.New(s.config.CursorFile)
+
+	query, err := s.resumeQuery(cur)
+	if err != nil {
+		return err
+	}
+
+	if query != nil {
+		s.logger.Info("Resuming the System Log from the cursor")
+	} else {
+		since := time.Now().Add(-s.config.Since)
+		query = s.firstQuery(since)
+		s.logger.Infof("Reading the System Log since %s", since.UTC())
+	}
+
+	return s.readLogs(ctx, query, true, cur, out)
+}
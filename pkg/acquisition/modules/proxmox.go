@@ -0,0 +1,5 @@
+//go:build !no_datasource_proxmox
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/proxmox" // register the datasource
@@ -0,0 +1,162 @@
+package proxmoxacquisition
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pveClient is a minimal client for the Proxmox VE REST API,
+// limited to what is needed to read the task log and the node journals.
+type pveClient struct {
+	url           string
+	authorization string
+	http          *http.Client
+}
+
+// pveTask is an entry of /nodes/{node}/tasks.
+type pveTask struct {
+	UPID      string `json:"upid"`
+	Node      string `json:"node"`
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	User      string `json:"user"`
+	Status    string `json:"status"` // empty while the task is running
+	StartTime int64  `json:"starttime"`
+	EndTime   int64  `json:"endtime"`
+}
+
+func newPVEClient(baseURL string, tokenID string, tokenSecret string, insecureSkipVerify bool) *pveClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: insecureSkipVerify, //nolint:gosec // user-provided option for self-signed Proxmox certificates
+	}
+
+	return &pveClient{
+		url:           strings.TrimSuffix(baseURL, "/") + "/api2/json",
+		authorization: "PVEAPIToken=" + tokenID + "=" + tokenSecret,
+		http: &http.Client{
+			Transport: transport,
+			Timeout:   60 * time.Second,
+		},
+	}
+}
+
+// get sends a GET request to the given API path and decodes the data field of the response in ret.
+func (c *pveClient) get(ctx context.Context, path string, query url.Values, ret any) error {
+	u := c.url + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", c.authorization)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("GET %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// the reason is in the status line, i.e. "401 authentication failure" or "403 Permission check failed (/nodes/pve1, Sys.Audit)"
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("GET %s: %s", path, resp.Status)
+	}
+
+	envelope := struct {
+		Data any `json:"data"`
+	}{Data: ret}
+
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("GET %s: decoding response: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *pveClient) nodes(ctx context.Context) ([]string, error) {
+	var ret []struct {
+		Node string `json:"node"`
+	}
+
+	if err := c.get(ctx, "/nodes", nil, &ret); err != nil {
+		return nil, err
+	}
+
+	nodes := make([]string, 0, len(ret))
+	for _, n := range ret {
+		nodes = append(nodes, n.Node)
+	}
+
+	return nodes, nil
+}
+
+// tasks returns the tasks of a node started since the given time (and before until, if not zero), running or finished.
+func (c *pveClient) tasks(ctx context.Context, node string, since time.Time, until time.Time) ([]pveTask, error) {
+	query := url.Values{
+		"source": {"all"},
+		"since":  {strconv.FormatInt(since.Unix(), 10)},
+		"limit":  {"1000"},
+	}
+
+	if !until.IsZero() {
+		query.Set("until", strconv.FormatInt(until.Unix(), 10))
+	}
+
+	var ret []pveTask
+
+	if err := c.get(ctx, "/nodes/"+url.PathEscape(node)+"/tasks", query, &ret); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// journal returns the journal lines of a node, either after the cursor or, if it's empty, between since and until.
+// The first and last lines of the response are the start and end cursors of the returned lines, they are
+// removed from the lines and the end cursor is returned to read the next ones.
+func (c *pveClient) journal(ctx context.Context, node string, cursor string, since time.Time, until time.Time) ([]string, string, error) {
+	query := url.Values{}
+
+	if cursor != "" {
+		query.Set("startcursor", cursor)
+	} else {
+		query.Set("since", strconv.FormatInt(since.Unix(), 10))
+
+		if !until.IsZero() {
+			query.Set("until", strconv.FormatInt(until.Unix(), 10))
+		}
+	}
+
+	var ret []string
+
+	if err := c.get(ctx, "/nodes/"+url.PathEscape(node)+"/journal", query, &ret); err != nil {
+		return nil, cursor, err
+	}
+
+	if len(ret) < 2 {
+		return nil, cursor, nil
+	}
+
+	lines := ret[1 : len(ret)-1]
+
+	// the entry at the start cursor is included, it was already returned by the previous call
+	if cursor != "" && len(lines) > 0 {
+		lines = lines[1:]
+	}
+
+	return lines, ret[len(ret)-1], nil
+}
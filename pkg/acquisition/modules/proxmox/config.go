@@ -0,0 +1,147 @@
+package proxmoxacquisition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+const (
+	defaultPollInterval = 10 * time.Second
+
+	collectTasks = "tasks" // task log of the nodes: VM/CT creation, deletion, migration, console access...
+	collectAuth  = "auth"  // authentication results logged by pvedaemon in the node journals
+)
+
+type Configuration struct {
+	configuration.DataSourceCommonCfg `yaml:",inline"`
+
+	URL                string        `yaml:"url"`      // API endpoint, i.e. https://pve.example.com:8006
+	TokenID            string        `yaml:"token_id"` // user@realm!tokenid, the token needs Sys.Audit on /nodes
+	TokenSecret        string        `yaml:"token_secret"`
+	InsecureSkipVerify bool          `yaml:"insecure_skip_verify"`
+	Nodes              []string      `yaml:"nodes"` // if empty, all the nodes of the cluster
+	PollInterval       time.Duration `yaml:"poll_interval"`
+	Since              time.Duration `yaml:"since"`      // only used in cat mode
+	Collect            []string      `yaml:"collect"`    // tasks, auth
+	TaskTypes          []string      `yaml:"task_types"` // if set, only forward these task types
+}
+
+func ConfigurationFromYAML(y []byte) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.UnmarshalWithOptions(y, &cfg, yaml.Strict()); err != nil {
+		return cfg, fmt.Errorf("cannot parse: %s", yaml.FormatError(err, false, false))
+	}
+
+	cfg.SetDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Configuration) SetDefaults() {
+	if c.Mode == "" {
+		c.Mode = configuration.TAIL_MODE
+	}
+
+	if c.PollInterval == 0 {
+		c.PollInterval = defaultPollInterval
+	}
+
+	if c.Since == 0 {
+		c.Since = 24 * time.Hour
+	}
+
+	if len(c.Collect) == 0 {
+		c.Collect = []string{collectTasks, collectAuth}
+	}
+}
+
+func (c *Configuration) Validate() error {
+	if c.URL == "" {
+		return errors.New("url is required")
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid url scheme '%s': must be http or https", u.Scheme)
+	}
+
+	if c.TokenID == "" {
+		return errors.New("token_id is required")
+	}
+
+	user, tokenName, found := strings.Cut(c.TokenID, "!")
+	if !found || tokenName == "" || !strings.Contains(user, "@") {
+		return fmt.Errorf("invalid token_id '%s': must be in the form user@realm!tokenid", c.TokenID)
+	}
+
+	if c.TokenSecret == "" {
+		return errors.New("token_secret is required")
+	}
+
+	if c.PollInterval < 0 {
+		return errors.New("poll_interval must be positive")
+	}
+
+	for _, collect := range c.Collect {
+		if collect != collectTasks && collect != collectAuth {
+			return fmt.Errorf("invalid collect value '%s': must be %s or %s", collect, collectTasks, collectAuth)
+		}
+	}
+
+	switch c.Mode {
+	case configuration.TAIL_MODE, configuration.CAT_MODE:
+	default:
+		return fmt.Errorf("unsupported mode %s for proxmox datasource", c.Mode)
+	}
+
+	return nil
+}
+
+func (c *Configuration) collects(what string) bool {
+	return slices.Contains(c.Collect, what)
+}
+
+func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
+	cfg, err := ConfigurationFromYAML(yamlConfig)
+	if err != nil {
+		return err
+	}
+
+	s.config = cfg
+
+	u, _ := url.Parse(s.config.URL)
+	s.src = u.Host
+
+	return nil
+}
+
+func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
+	if err := s.UnmarshalConfig(yamlConfig); err != nil {
+		return err
+	}
+
+	s.logger = logger.WithField("src", s.src)
+	s.metricsLevel = metricsLevel
+
+	return nil
+}
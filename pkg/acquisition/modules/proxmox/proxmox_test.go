@@ -0,0 +1,201 @@
+package proxmoxacquisition
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/sourcetest"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const taskList = `{"data": [
+{"upid": "UPID:pve1:00001F40:0001E240:677602C7:vncproxy:100:root@pam:", "node": "pve1", "type": "vncproxy", "id": "100", "user": "root@pam", "status": "OK", "starttime": 1735787207, "endtime": 1735787300},
+{"upid": "UPID:pve1:00001F41:0001E241:677602C6:qmdestroy:101:admin@pve!ci:", "node": "pve1", "type": "qmdestroy", "id": "101", "user": "admin@pve!ci", "status": "OK", "starttime": 1735787206, "endtime": 1735787210}
+]}`
+
+const journal = `{"data": [
+"s=start;i=1",
+"Jan 02 03:04:05 pve1 pvedaemon[1234]: authentication failure; rhost=::ffff:192.0.2.10 user=root@pam msg=Authentication failure",
+"Jan 02 03:04:06 pve1 pvedaemon[1234]: <root@pam> successful auth for user 'root@pam'",
+"Jan 02 03:04:07 pve1 systemd[1]: Started session-42.scope.",
+"s=end;i=4"
+]}`
+
+// fakeProxmox answers the API calls made by the datasource, returning taskList and journal once.
+type fakeProxmox struct {
+	mu          sync.Mutex
+	calls       []string
+	tasksSent   bool
+	journalSent bool
+	token       string
+}
+
+func (f *fakeProxmox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, r.URL.Path)
+
+	if r.Header.Get("Authorization") != "PVEAPIToken="+f.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.URL.Path {
+	case "/api2/json/nodes":
+		_, _ = io.WriteString(w, `{"data": [{"node": "pve1", "status": "online"}]}`)
+	case "/api2/json/nodes/pve1/tasks":
+		if f.tasksSent {
+			// the last task is returned again, its start time is the next "since"
+			_, _ = io.WriteString(w, `{"data": [{"upid": "UPID:pve1:00001F40:0001E240:677602C7:vncproxy:100:root@pam:", "node": "pve1", "type": "vncproxy", "id": "100", "user": "root@pam", "status": "OK", "starttime": 1735787207}]}`)
+			return
+		}
+
+		f.tasksSent = true
+
+		_, _ = io.WriteString(w, taskList)
+	case "/api2/json/nodes/pve1/journal":
+		if f.journalSent {
+			if r.URL.Query().Get("startcursor") != "s=end;i=4" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			_, _ = io.WriteString(w, `{"data": ["s=end;i=4", "Jan 02 03:04:07 pve1 systemd[1]: Started session-42.scope.", "s=end;i=4"]}`)
+
+			return
+		}
+
+		f.journalSent = true
+
+		_, _ = io.WriteString(w, journal)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestSource(t *testing.T, url string, extra string) *Source {
+	t.Helper()
+
+	s := &Source{}
+	sourcetest.Configure(t, s, `source: proxmox
+labels:
+  type: proxmox
+url: `+url+`
+token_id: crowdsec@pve!monitoring
+token_secret: secret`, extra)
+
+	return s
+}
+
+func TestConfigure(t *testing.T) {
+	base := "source: proxmox\nurl: https://pve.example.com:8006\ntoken_id: crowdsec@pve!monitoring\ntoken_secret: secret\n"
+
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "unknown field", extra: "foobar: 42", wantErr: `unknown field "foobar"`},
+		{name: "no url", extra: "url: ''", wantErr: "url is required"},
+		{name: "url scheme", extra: "url: ftp://pve.example.com", wantErr: "invalid url scheme 'ftp': must be http or https"},
+		{name: "no token_id", extra: "token_id: ''", wantErr: "token_id is required"},
+		{name: "token_id", extra: "token_id: crowdsec", wantErr: "invalid token_id 'crowdsec': must be in the form user@realm!tokenid"},
+		{name: "no token_secret", extra: "token_secret: ''", wantErr: "token_secret is required"},
+		{name: "poll_interval", extra: "poll_interval: -1s", wantErr: "poll_interval must be positive"},
+		{name: "collect", extra: "collect: [syslog]", wantErr: "invalid collect value 'syslog': must be tasks or auth"},
+		{name: "mode", extra: "mode: foo", wantErr: "unsupported mode foo for proxmox datasource"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Source{}
+			cfg := sourcetest.MergeConfig(t, base, tc.extra)
+			err := s.Configure(t.Context(), []byte(cfg), log.WithField("type", ModuleName), metrics.AcquisitionMetricsLevelNone)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestOneShot(t *testing.T) {
+	fake := &fakeProxmox{token: "crowdsec@pve!monitoring=secret"}
+	s := newTestSource(t, sourcetest.NewServer(t, fake), "mode: cat\n")
+
+	var events []proxmoxEvent
+
+	for _, evt := range sourcetest.OneShot(t, s) {
+		var e proxmoxEvent
+		require.NoError(t, json.Unmarshal([]byte(evt.Line.Raw), &e))
+		assert.Equal(t, ModuleName, evt.Line.Module)
+		assert.Equal(t, e.Time, evt.Line.Time)
+		events = append(events, e)
+	}
+
+	require.Len(t, events, 4)
+
+	// tasks are sent by start time
+	assert.Equal(t, "tasks", events[0].Kind)
+	assert.Equal(t, "qmdestroy", events[0].TaskType)
+	assert.Equal(t, "admin_operation", events[0].Category)
+	assert.Equal(t, "admin@pve!ci", events[0].User)
+	assert.Equal(t, "101", events[0].TaskID)
+	assert.Equal(t, "OK", events[0].Status)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 6, 46, 0, time.UTC), events[0].Time)
+
+	assert.Equal(t, "vncproxy", events[1].TaskType)
+	assert.Equal(t, "console", events[1].Category)
+
+	assert.Equal(t, "auth", events[2].Kind)
+	assert.Equal(t, "auth_failure", events[2].Category)
+	assert.Equal(t, "pve1", events[2].Node)
+	assert.Equal(t, "root@pam", events[2].User)
+	assert.Equal(t, "192.0.2.10", events[2].IPAddress)
+	assert.Equal(t, "Authentication failure", events[2].Message)
+
+	assert.Equal(t, "auth_success", events[3].Category)
+	assert.Equal(t, "root@pam", events[3].User)
+
+	assert.Equal(t, []string{"/api2/json/nodes", "/api2/json/nodes/pve1/tasks", "/api2/json/nodes/pve1/journal"}, fake.calls)
+}
+
+func TestCollectAndTaskTypes(t *testing.T) {
+	fake := &fakeProxmox{token: "crowdsec@pve!monitoring=secret"}
+	s := newTestSource(t, sourcetest.NewServer(t, fake), "mode: cat\nnodes: [pve1]\ncollect: [tasks]\ntask_types: [qmdestroy]\n")
+
+	events := sourcetest.OneShot(t, s)
+	require.Len(t, events, 1)
+	assert.Contains(t, events[0].Line.Raw, `"task_type":"qmdestroy"`)
+
+	assert.Equal(t, []string{"/api2/json/nodes/pve1/tasks"}, fake.calls)
+}
+
+func TestAuthenticationFailure(t *testing.T) {
+	url := sourcetest.NewServer(t, &fakeProxmox{token: "crowdsec@pve!monitoring=other"})
+	s := newTestSource(t, url, "")
+
+	err := s.Stream(t.Context(), make(chan pipeline.Event))
+	cstest.RequireErrorContains(t, err, "unable to list the nodes of "+strings.TrimPrefix(url, "http://")+": GET /nodes: 401 Unauthorized")
+}
+
+func TestStream(t *testing.T) {
+	fake := &fakeProxmox{token: "crowdsec@pve!monitoring=secret"}
+	s := newTestSource(t, sourcetest.NewServer(t, fake), "poll_interval: 50ms\n")
+
+	// the tasks and journal lines already sent must not be sent again
+	sourcetest.Stream(t, s, 4)
+}
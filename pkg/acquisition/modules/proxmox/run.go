@@ -0,0 +1,251 @@
+package proxmoxacquisition
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// taskCategories maps the Proxmox VE task types to the categories used by the hub's virtualization scenarios.
+var taskCategories = map[string]string{
+	"qmcreate":    "admin_operation",
+	"qmdestroy":   "admin_operation",
+	"qmrestore":   "admin_operation",
+	"qmclone":     "admin_operation",
+	"qmigrate":    "admin_operation",
+	"qmtemplate":  "admin_operation",
+	"vzcreate":    "admin_operation",
+	"vzdestroy":   "admin_operation",
+	"vzrestore":   "admin_operation",
+	"vzclone":     "admin_operation",
+	"vzmigrate":   "admin_operation",
+	"vzdump":      "admin_operation",
+	"aptupdate":   "admin_operation",
+	"srvstop":     "admin_operation",
+	"cephdestroy": "admin_operation",
+	"vncproxy":    "console",
+	"vncshell":    "console",
+	"termproxy":   "console",
+	"spiceproxy":  "console",
+	"spiceshell":  "console",
+}
+
+var (
+	// Jan 02 03:04:05 pve1 pvedaemon[1234]: authentication failure; rhost=::ffff:192.0.2.10 user=root@pam msg=Authentication failure
+	authFailureRe = regexp.MustCompile(`pvedaemon\[\d+\]: authentication failure; rhost=(\S+) user=(\S+) msg=(.*)$`)
+	// Jan 02 03:04:05 pve1 pvedaemon[1234]: <root@pam> successful auth for user 'root@pam'
+	authSuccessRe = regexp.MustCompile(`pvedaemon\[\d+\]: <\S+> successful auth for user '([^']+)'`)
+)
+
+// proxmoxEvent is the JSON document sent to the parsers.
+type proxmoxEvent struct {
+	Kind      string    `json:"kind"` // task or auth
+	Category  string    `json:"category,omitempty"`
+	Node      string    `json:"node"`
+	Time      time.Time `json:"time"`
+	User      string    `json:"user,omitempty"`
+	IPAddress string    `json:"ip_address,omitempty"`
+	TaskType  string    `json:"task_type,omitempty"`
+	TaskID    string    `json:"task_id,omitempty"` // usually the VM or container ID
+	UPID      string    `json:"upid,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	Message   string    `json:"message,omitempty"`
+}
+
+func newTaskEvent(t pveTask) proxmoxEvent {
+	return proxmoxEvent{
+		Kind:     collectTasks,
+		Category: taskCategories[t.Type],
+		Node:     t.Node,
+		Time:     time.Unix(t.StartTime, 0).UTC(),
+		User:     t.User,
+		TaskType: t.Type,
+		TaskID:   t.ID,
+		UPID:     t.UPID,
+		Status:   t.Status,
+	}
+}
+
+// newAuthEvent returns the event of a journal line, or false if it's not an authentication result.
+// The journal lines have a syslog-like timestamp in the time zone of the node, without a year:
+// the time of the event is the time it was read.
+func newAuthEvent(node string, line string, now time.Time) (proxmoxEvent, bool) {
+	if m := authFailureRe.FindStringSubmatch(line); m != nil {
+		return proxmoxEvent{
+			Kind:      collectAuth,
+			Category:  "auth_failure",
+			Node:      node,
+			Time:      now,
+			User:      m[2],
+			IPAddress: strings.TrimPrefix(m[1], "::ffff:"),
+			Message:   m[3],
+		}, true
+	}
+
+	if m := authSuccessRe.FindStringSubmatch(line); m != nil {
+		return proxmoxEvent{
+			Kind:     collectAuth,
+			Category: "auth_success",
+			Node:     node,
+			Time:     now,
+			User:     m[1],
+		}, true
+	}
+
+	return proxmoxEvent{}, false
+}
+
+func (s *Source) sendEvent(evt proxmoxEvent, out chan pipeline.Event) {
+	if evt.Kind == collectTasks && len(s.config.TaskTypes) > 0 && !slices.Contains(s.config.TaskTypes, evt.TaskType) {
+		s.logger.Tracef("skipping task %s of type %s", evt.UPID, evt.TaskType)
+		return
+	}
+
+	raw, err := json.Marshal(evt)
+	if err != nil {
+		s.logger.Errorf("unable to serialize event: %s", err)
+		return
+	}
+
+	if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+		metrics.ProxmoxDataSourceEventsRead.With(prometheus.Labels{"source": s.src, "datasource_type": ModuleName, "acquis_type": s.config.Labels["type"]}).Inc()
+	}
+
+	line := pipeline.Line{
+		Raw:     string(raw),
+		Src:     s.src,
+		Time:    evt.Time,
+		Labels:  s.config.Labels,
+		Process: true,
+		Module:  s.GetName(),
+	}
+
+	pevt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+	pevt.Line = line
+
+	out <- pevt
+}
+
+// nodeState is the reading position in the task log and the journal of a node.
+type nodeState struct {
+	name          string
+	taskSince     time.Time
+	seenTasks     map[string]bool // tasks started at taskSince that were already sent
+	journalCursor string
+}
+
+func (s *Source) OneShot(ctx context.Context, out chan pipeline.Event) error {
+	now := time.Now().UTC()
+	err := s.readEvents(ctx, now.Add(-s.config.Since), now, out)
+	s.logger.Debug("Oneshot acquisition is done")
+
+	return err
+}
+
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	return s.readEvents(ctx, time.Now().UTC(), time.Time{}, out)
+}
+
+// readEvents reads the events between since and until. If until is zero, it keeps polling for new events until ctx is canceled.
+func (s *Source) readEvents(ctx context.Context, since time.Time, until time.Time, out chan pipeline.Event) error {
+	client := newPVEClient(s.config.URL, s.config.TokenID, s.config.TokenSecret, s.config.InsecureSkipVerify)
+
+	names := s.config.Nodes
+	if len(names) == 0 {
+		var err error
+
+		names, err = client.nodes(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to list the nodes of %s: %w", s.src, err)
+		}
+	}
+
+	nodes := make([]*nodeState, 0, len(names))
+	for _, name := range names {
+		nodes = append(nodes, &nodeState{name: name, taskSince: since, seenTasks: map[string]bool{}})
+	}
+
+	s.logger.Infof("Reading events of %d node(s) since %s", len(nodes), since)
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, node := range nodes {
+			if err := s.pollNode(ctx, client, node, since, until, out); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+
+				return err
+			}
+		}
+
+		if !until.IsZero() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Datasource stopping")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Source) pollNode(ctx context.Context, client *pveClient, node *nodeState, since time.Time, until time.Time, out chan pipeline.Event) error {
+	if s.config.collects(collectTasks) {
+		tasks, err := client.tasks(ctx, node.name, node.taskSince, until)
+		if err != nil {
+			return err
+		}
+
+		slices.SortStableFunc(tasks, func(a, b pveTask) int {
+			return int(a.StartTime - b.StartTime)
+		})
+
+		for _, t := range tasks {
+			if node.seenTasks[t.UPID] {
+				continue
+			}
+
+			// the next poll starts at the last start time, remember which tasks it will return again
+			if start := time.Unix(t.StartTime, 0); start.After(node.taskSince) {
+				node.taskSince = start
+				clear(node.seenTasks)
+			}
+
+			node.seenTasks[t.UPID] = true
+
+			s.sendEvent(newTaskEvent(t), out)
+		}
+	}
+
+	if s.config.collects(collectAuth) {
+		lines, cursor, err := client.journal(ctx, node.name, node.journalCursor, since, until)
+		if err != nil {
+			return err
+		}
+
+		node.journalCursor = cursor
+		now := time.Now().UTC()
+
+		for _, line := range lines {
+			if evt, ok := newAuthEvent(node.name, line, now); ok {
+				s.sendEvent(evt, out)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,5 @@
+//go:build !no_datasource_salesforce
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/salesforce" // register the datasource
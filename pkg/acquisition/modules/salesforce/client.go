@@ -0,0 +1,188 @@
+package salesforceacquisition
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/apipoll"
+)
+
+// timeFormat is the format of the times of the API, i.e. 2025-01-02T03:04:05.000+0000
+const timeFormat = "2006-01-02T15:04:05.000-0700"
+
+// eventLogFile is a record of the EventLogFile object: the events of a type, during an hour or a day.
+type eventLogFile struct {
+	ID          string `json:"Id"`
+	EventType   string `json:"EventType"`
+	LogDate     string `json:"LogDate"`
+	CreatedDate string `json:"CreatedDate"`
+}
+
+// restClient is a minimal client for the REST API of an instance, authenticated with the client
+// credentials of a connected app.
+type restClient struct {
+	instanceURL  string
+	dataPath     string
+	clientID     string
+	clientSecret string
+	api          *apipoll.Client
+}
+
+func newRESTClient(cfg *Configuration) *restClient {
+	c := &restClient{
+		instanceURL:  cfg.InstanceURL,
+		dataPath:     "/services/data/v" + cfg.APIVersion,
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		api: &apipoll.Client{
+			HTTP: &http.Client{
+				Timeout: 5 * time.Minute, // the log files can be large
+			},
+			Reason: apiErrorReason,
+		},
+	}
+
+	c.api.Token = &apipoll.TokenSource{Request: c.requestToken}
+
+	return c
+}
+
+// apiErrorReason returns the reason of a failed request given by the API,
+// i.e. [{"message": "Session expired or invalid", "errorCode": "INVALID_SESSION_ID"}],
+// or by the token endpoint, i.e. {"error": "invalid_client", "error_description": "invalid client credentials"}
+func apiErrorReason(body []byte) string {
+	var apiErrs []struct {
+		Message   string `json:"message"`
+		ErrorCode string `json:"errorCode"`
+	}
+
+	if err := json.Unmarshal(body, &apiErrs); err == nil && len(apiErrs) > 0 {
+		return fmt.Sprintf("%s (%s)", apiErrs[0].Message, apiErrs[0].ErrorCode)
+	}
+
+	var tokenErr struct {
+		Description string `json:"error_description"`
+	}
+
+	if err := json.Unmarshal(body, &tokenErr); err == nil {
+		return tokenErr.Description
+	}
+
+	return ""
+}
+
+// requestToken requests an access token with the client credentials. The tokens have no
+// expiration time: a new one is requested when the current one is rejected.
+func (c *restClient) requestToken(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {c.clientID},
+		"client_secret": {c.clientSecret},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.instanceURL+"/services/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.api.HTTP.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("requesting access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("requesting access token: %w", c.api.ResponseError(http.MethodPost, "/services/oauth2/token", resp))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", 0, fmt.Errorf("requesting access token: decoding response: %w", err)
+	}
+
+	return token.AccessToken, 0, nil
+}
+
+// get sends a request to the API, and passes the body of the response to read.
+func (c *restClient) get(ctx context.Context, path string, read func(io.Reader) error) error {
+	// the paths of the next pages come from the responses, the token must not leave the instance
+	if !strings.HasPrefix(path, "/services/data/") {
+		return fmt.Errorf("GET %s: unexpected path", path)
+	}
+
+	resp, err := c.api.Get(ctx, c.instanceURL+path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := read(resp.Body); err != nil {
+		displayPath, _, _ := strings.Cut(path, "?")
+		return fmt.Errorf("GET %s: %w", displayPath, err)
+	}
+
+	return nil
+}
+
+// logFiles returns the event log files created after a given time, oldest first, following the
+// pages of the query.
+func (c *restClient) logFiles(ctx context.Context, after time.Time, interval string, eventTypes []string) ([]eventLogFile, error) {
+	// the event types and the interval are validated, they can't be used to inject in the query
+	soql := "SELECT Id, EventType, LogDate, CreatedDate FROM EventLogFile" +
+		" WHERE Interval = '" + interval + "'" +
+		" AND CreatedDate > " + after.UTC().Format(time.RFC3339)
+
+	if len(eventTypes) > 0 {
+		soql += " AND EventType IN ('" + strings.Join(eventTypes, "', '") + "')"
+	}
+
+	soql += " ORDER BY CreatedDate, Id"
+
+	next := c.dataPath + "/query?" + url.Values{"q": {soql}}.Encode()
+
+	var files []eventLogFile
+
+	for next != "" {
+		var page struct {
+			Records        []eventLogFile `json:"records"`
+			Done           bool           `json:"done"`
+			NextRecordsURL string         `json:"nextRecordsUrl"`
+		}
+
+		err := c.get(ctx, next, func(r io.Reader) error {
+			if err := json.NewDecoder(r).Decode(&page); err != nil {
+				return fmt.Errorf("decoding response: %w", err)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, page.Records...)
+
+		next = ""
+		if !page.Done {
+			next = page.NextRecordsURL
+		}
+	}
+
+	return files, nil
+}
+
+// logFile passes the content of an event log file (CSV) to read.
+func (c *restClient) logFile(ctx context.Context, id string, read func(io.Reader) error) error {
+	return c.get(ctx, c.dataPath+"/sobjects/EventLogFile/"+url.PathEscape(id)+"/LogFile", read)
+}
@@ -0,0 +1,171 @@
+package salesforceacquisition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+const (
+	defaultAPIVersion   = "62.0"
+	defaultPollInterval = 5 * time.Minute
+
+	// the event log files are kept 30 days with Event Monitoring
+	maxSince = 30 * 24 * time.Hour
+)
+
+// intervals of the event log files
+const (
+	intervalHourly = "Hourly"
+	intervalDaily  = "Daily"
+)
+
+var (
+	apiVersionRe = regexp.MustCompile(`^\d+\.\d+$`)
+	eventTypeRe  = regexp.MustCompile(`^[A-Za-z]+$`)
+)
+
+type Configuration struct {
+	configuration.DataSourceCommonCfg `yaml:",inline"`
+
+	InstanceURL string `yaml:"instance_url"` // i.e. https://example.my.salesforce.com
+	// connected app with the client credentials flow, its run-as user needs the View Event Log Files permission
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	APIVersion   string `yaml:"api_version"`
+	// types of the event log files (Login, LoginAs, API, ReportExport...), all by default
+	EventTypes   []string      `yaml:"event_types"`
+	Interval     string        `yaml:"interval"` // Hourly (default) or Daily files
+	PollInterval time.Duration `yaml:"poll_interval"`
+	Since        time.Duration `yaml:"since"` // only used in cat mode, and in tail mode without cursor
+	// the creation time of the last file read is saved in this file, to resume after a restart
+	CursorFile string `yaml:"cursor_file"`
+}
+
+func ConfigurationFromYAML(y []byte) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.UnmarshalWithOptions(y, &cfg, yaml.Strict()); err != nil {
+		return cfg, fmt.Errorf("cannot parse: %s", yaml.FormatError(err, false, false))
+	}
+
+	cfg.SetDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Configuration) SetDefaults() {
+	if c.Mode == "" {
+		c.Mode = configuration.TAIL_MODE
+	}
+
+	if c.APIVersion == "" {
+		c.APIVersion = defaultAPIVersion
+	}
+
+	if c.Interval == "" {
+		c.Interval = intervalHourly
+	}
+
+	if c.PollInterval == 0 {
+		c.PollInterval = defaultPollInterval
+	}
+
+	if c.Since == 0 && c.Mode == configuration.CAT_MODE {
+		c.Since = 24 * time.Hour
+	}
+
+	c.InstanceURL = strings.TrimSuffix(c.InstanceURL, "/")
+}
+
+func (c *Configuration) Validate() error {
+	if c.InstanceURL == "" {
+		return errors.New("instance_url is required")
+	}
+
+	u, err := url.Parse(c.InstanceURL)
+	if err != nil {
+		return fmt.Errorf("invalid instance_url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid instance_url scheme '%s': must be http or https", u.Scheme)
+	}
+
+	if c.ClientID == "" {
+		return errors.New("client_id is required")
+	}
+
+	if c.ClientSecret == "" {
+		return errors.New("client_secret is required")
+	}
+
+	if !apiVersionRe.MatchString(c.APIVersion) {
+		return fmt.Errorf("invalid api_version '%s': must be like %s", c.APIVersion, defaultAPIVersion)
+	}
+
+	for _, eventType := range c.EventTypes {
+		if !eventTypeRe.MatchString(eventType) {
+			return fmt.Errorf("invalid event type '%s'", eventType)
+		}
+	}
+
+	if c.Interval != intervalHourly && c.Interval != intervalDaily {
+		return fmt.Errorf("invalid interval '%s': must be %s or %s", c.Interval, intervalHourly, intervalDaily)
+	}
+
+	if c.PollInterval < 0 {
+		return errors.New("poll_interval must be positive")
+	}
+
+	if c.Since < 0 || c.Since > maxSince {
+		return fmt.Errorf("since must be positive and at most %s", maxSince)
+	}
+
+	switch c.Mode {
+	case configuration.TAIL_MODE, configuration.CAT_MODE:
+	default:
+		return fmt.Errorf("unsupported mode %s for salesforce datasource", c.Mode)
+	}
+
+	return nil
+}
+
+func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
+	cfg, err := ConfigurationFromYAML(yamlConfig)
+	if err != nil {
+		return err
+	}
+
+	s.config = cfg
+
+	u, _ := url.Parse(s.config.InstanceURL)
+	s.src = u.Host
+
+	return nil
+}
+
+func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
+	if err := s.UnmarshalConfig(yamlConfig); err != nil {
+		return err
+	}
+
+	s.logger = logger.WithField("instance", s.src)
+	s.metricsLevel = metricsLevel
+
+	return nil
+}
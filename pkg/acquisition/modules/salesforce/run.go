@@ -0,0 +1,184 @@
+package salesforceacquisition
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/apipoll"
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/cursor"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// rowTime returns the time of an event: TIMESTAMP_DERIVED (2025-01-02T03:04:05.123Z), or
+// TIMESTAMP (20250102030405.123) in the older files.
+func rowTime(row map[string]string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, row["TIMESTAMP_DERIVED"]); err == nil {
+		return t.UTC(), true
+	}
+
+	if t, err := time.Parse("20060102150405.000", row["TIMESTAMP"]); err == nil {
+		return t.UTC(), true
+	}
+
+	return time.Time{}, false
+}
+
+// sendRow sends an event of a log file, as a JSON object with the columns of the file.
+func (s *Source) sendRow(file eventLogFile, row map[string]string, out chan pipeline.Event) {
+	evtTime, ok := rowTime(row)
+	if !ok {
+		var err error
+
+		if evtTime, err = time.Parse(timeFormat, file.LogDate); err != nil {
+			evtTime = time.Now()
+		}
+
+		s.logger.Warningf("log file %s: event without timestamp, using %s", file.ID, evtTime)
+	}
+
+	raw, err := json.Marshal(row)
+	if err != nil {
+		s.logger.Errorf("unable to encode event of log file %s: %s", file.ID, err)
+		return
+	}
+
+	if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+		metrics.SalesforceDataSourceEventsRead.With(prometheus.Labels{
+			"source":          s.src,
+			"event_type":      file.EventType,
+			"datasource_type": ModuleName,
+			"acquis_type":     s.config.Labels["type"],
+		}).Inc()
+	}
+
+	line := pipeline.Line{
+		Raw:     string(raw),
+		Src:     s.src,
+		Time:    evtTime.UTC(),
+		Labels:  s.config.Labels,
+		Process: true,
+		Module:  s.GetName(),
+	}
+
+	evt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+	evt.Line = line
+
+	out <- evt
+}
+
+// readLogFile sends the events of a log file, one for each row of the CSV.
+func (s *Source) readLogFile(ctx context.Context, client *restClient, file eventLogFile, out chan pipeline.Event) error {
+	return client.logFile(ctx, file.ID, func(r io.Reader) error {
+		reader := csv.NewReader(r)
+		reader.ReuseRecord = true
+
+		header, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("reading header: %w", err)
+		}
+
+		header = append([]string(nil), header...)
+
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			row := make(map[string]string, len(header))
+			for i, name := range header {
+				if i < len(record) && record[i] != "" {
+					row[name] = record[i]
+				}
+			}
+
+			s.sendRow(file, row, out)
+		}
+	})
+}
+
+// readLogFiles reads the log files created after a given time. If follow is true, it keeps
+// polling for new files until ctx is canceled.
+func (s *Source) readLogFiles(ctx context.Context, after time.Time, follow bool, cur *cursor.File, out chan pipeline.Event) error {
+	client := newRESTClient(&s.config)
+
+	return apipoll.Poll(ctx, s.logger, s.config.PollInterval, follow, func(ctx context.Context) (bool, error) {
+		files, err := client.logFiles(ctx, after, s.config.Interval, s.config.EventTypes)
+		if err != nil {
+			return false, fmt.Errorf("unable to list the event log files: %w", err)
+		}
+
+		for _, file := range files {
+			if err := s.readLogFile(ctx, client, file, out); err != nil {
+				return false, fmt.Errorf("unable to read the event log file %s (%s): %w", file.ID, file.EventType, err)
+			}
+
+			s.logger.Debugf("read the %s events of %s (%s)", file.EventType, file.LogDate, file.ID)
+
+			created, err := time.Parse(timeFormat, file.CreatedDate)
+			if err != nil {
+				s.logger.Warningf("log file %s: invalid creation date %q", file.ID, file.CreatedDate)
+				continue
+			}
+
+			after = created
+
+			if err := cur.Save(after.UTC().Format(time.RFC3339)); err != nil {
+				s.logger.Error(err)
+			}
+		}
+
+		// all the files created since the previous poll were listed
+		return false, nil
+	})
+}
+
+func (s *Source) OneShot(ctx context.Context, out chan pipeline.Event) error {
+	after := time.Now().Add(-s.config.Since)
+
+	s.logger.Infof("Reading the event log files created since %s", after.UTC())
+
+	err := s.readLogFiles(ctx, after, false, nil, out)
+	s.logger.Debug("Oneshot acquisition is done")
+
+	return err
+}
+
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	cur := cursor.New(s.config.CursorFile)
+
+	saved, err := cur.Load()
+	if err != nil {
+		return err
+	}
+
+	after := time.Now().Add(-s.config.Since)
+
+	if saved != "" {
+		if after, err = time.Parse(time.RFC3339, saved); err != nil {
+			return fmt.Errorf("invalid cursor %q: %w", saved, err)
+		}
+
+		s.logger.Infof("Resuming the event log files created after %s", after)
+	} else {
+		s.logger.Infof("Reading the event log files created since %s", after.UTC())
+	}
+
+	return s.readLogFiles(ctx, after, true, cur, out)
+}
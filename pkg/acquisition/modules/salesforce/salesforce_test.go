@@ -0,0 +1,296 @@
+package salesforceacquisition
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/sourcetest"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const loginCSV = `"EVENT_TYPE","TIMESTAMP","REQUEST_ID","USER_ID","LOGIN_STATUS","SOURCE_IP","USER_NAME","TIMESTAMP_DERIVED"
+"Login","20250102030405.123","4exLFFQZ1234","0055g000004abcd","LOGIN_ERROR_INVALID_PASSWORD","192.0.2.10","alice@example.com","2025-01-02T03:04:05.123Z"
+"Login","20250102030506.000","4exLFFQZ5678","0055g000004abcd","LOGIN_NO_ERROR","192.0.2.10","alice@example.com",""
+`
+
+const apiCSV = `"EVENT_TYPE","TIMESTAMP","REQUEST_ID","USER_ID","METHOD","CLIENT_IP","TIMESTAMP_DERIVED"
+"API","20250102040000.000","4exLFFQZ9999","0055g000004abcd","query","198.51.100.7","2025-01-02T04:00:00.000Z"
+`
+
+var soqlAfterRe = regexp.MustCompile(`CreatedDate > (\S+)`)
+
+type fakeFile struct {
+	eventLogFile
+
+	content string
+}
+
+// fakeRESTAPI answers the token requests, the queries of the event log files and serves their content.
+type fakeRESTAPI struct {
+	mu          sync.Mutex
+	files       []fakeFile
+	queries     []string
+	tokens      int
+	revokeFirst bool // reject the first token after its first use
+	used        map[string]bool
+}
+
+func (f *fakeRESTAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/services/oauth2/token" {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.FormValue("client_secret") != "secret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"error": "invalid_client", "error_description": "invalid client credentials"}`)
+
+			return
+		}
+
+		f.tokens++
+		_, _ = fmt.Fprintf(w, `{"access_token": "token-%d", "instance_url": "https://example.my.salesforce.com", "token_type": "Bearer"}`, f.tokens)
+
+		return
+	}
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if f.revokeFirst && token == "token-1" && f.used[token] {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `[{"message": "Session expired or invalid", "errorCode": "INVALID_SESSION_ID"}]`)
+
+		return
+	}
+
+	f.used[token] = true
+
+	switch {
+	case r.URL.Path == "/services/data/v62.0/query":
+		soql := r.URL.Query().Get("q")
+		f.queries = append(f.queries, soql)
+
+		m := soqlAfterRe.FindStringSubmatch(soql)
+		if m == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `[{"message": "unexpected token", "errorCode": "MALFORMED_QUERY"}]`)
+
+			return
+		}
+
+		after, _ := time.Parse(time.RFC3339, m[1])
+
+		records := []eventLogFile{}
+
+		for _, file := range f.files {
+			created, _ := time.Parse(timeFormat, file.CreatedDate)
+			if created.After(after) && strings.Contains(soql, "'"+file.EventType+"'") {
+				records = append(records, file.eventLogFile)
+			}
+		}
+
+		// one record per page
+		page := map[string]any{"totalSize": len(records), "done": len(records) <= 1, "records": records[:min(1, len(records))]}
+		if len(records) > 1 {
+			page["nextRecordsUrl"] = "/services/data/v62.0/query/01gD0000002HU6KIAW-2000"
+		}
+
+		_ = json.NewEncoder(w).Encode(page)
+	case r.URL.Path == "/services/data/v62.0/query/01gD0000002HU6KIAW-2000":
+		// the rest of the files of the last query
+		soql := f.queries[len(f.queries)-1]
+		after, _ := time.Parse(time.RFC3339, soqlAfterRe.FindStringSubmatch(soql)[1])
+
+		records := []eventLogFile{}
+
+		for _, file := range f.files {
+			created, _ := time.Parse(timeFormat, file.CreatedDate)
+			if created.After(after) && strings.Contains(soql, "'"+file.EventType+"'") {
+				records = append(records, file.eventLogFile)
+			}
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"totalSize": len(records), "done": true, "records": records[1:]})
+	case strings.HasPrefix(r.URL.Path, "/services/data/v62.0/sobjects/EventLogFile/"):
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/services/data/v62.0/sobjects/EventLogFile/"), "/LogFile")
+
+		for _, file := range f.files {
+			if file.ID == id {
+				w.Header().Set("Content-Type", "text/csv")
+				_, _ = io.WriteString(w, file.content)
+
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeRESTAPI) addFile(id string, eventType string, created time.Time, content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.files = append(f.files, fakeFile{
+		eventLogFile: eventLogFile{
+			ID:          id,
+			EventType:   eventType,
+			LogDate:     created.Truncate(time.Hour).Add(-time.Hour).Format(timeFormat),
+			CreatedDate: created.Truncate(time.Second).Format(timeFormat),
+		},
+		content: content,
+	})
+}
+
+func newFakeAPI(t *testing.T) (*fakeRESTAPI, string) {
+	t.Helper()
+
+	fake := &fakeRESTAPI{used: map[string]bool{}}
+
+	return fake, sourcetest.NewServer(t, fake)
+}
+
+func newTestSource(t *testing.T, instanceURL string, extra string) *Source {
+	t.Helper()
+
+	s := &Source{}
+	sourcetest.Configure(t, s, `source: salesforce
+labels:
+  type: salesforce
+instance_url: `+instanceURL+`
+client_id: 3MVG9connectedapp
+client_secret: secret
+event_types: [Login, API]`, extra)
+
+	return s
+}
+
+func TestConfigure(t *testing.T) {
+	base := "source: salesforce\ninstance_url: https://example.my.salesforce.com\nclient_id: 3MVG9connectedapp\nclient_secret: secret\n"
+
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "unknown field", extra: "foobar: 42", wantErr: `unknown field "foobar"`},
+		{name: "no instance_url", extra: "instance_url: ''", wantErr: "instance_url is required"},
+		{name: "instance_url scheme", extra: "instance_url: ftp://example.my.salesforce.com", wantErr: "invalid instance_url scheme 'ftp': must be http or https"},
+		{name: "no client_id", extra: "client_id: ''", wantErr: "client_id is required"},
+		{name: "no client_secret", extra: "client_secret: ''", wantErr: "client_secret is required"},
+		{name: "api_version", extra: "api_version: v62", wantErr: "invalid api_version 'v62': must be like 62.0"},
+		{name: "event type", extra: `event_types: ["Login') OR ('1'='1"]`, wantErr: "invalid event type 'Login') OR ('1'='1'"},
+		{name: "interval", extra: "interval: Weekly", wantErr: "invalid interval 'Weekly': must be Hourly or Daily"},
+		{name: "poll_interval", extra: "poll_interval: -1s", wantErr: "poll_interval must be positive"},
+		{name: "since", extra: "since: 1000h", wantErr: "since must be positive and at most"},
+		{name: "mode", extra: "mode: foo", wantErr: "unsupported mode foo for salesforce datasource"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Source{}
+			cfg := sourcetest.MergeConfig(t, base, tc.extra)
+			err := s.Configure(t.Context(), []byte(cfg), log.WithField("type", ModuleName), metrics.AcquisitionMetricsLevelNone)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestOneShot(t *testing.T) {
+	fake, instanceURL := newFakeAPI(t)
+	fake.revokeFirst = true
+	fake.addFile("0AT000000000001", "Login", time.Now().Add(-2*time.Hour), loginCSV)
+	fake.addFile("0AT000000000002", "API", time.Now().Add(-time.Hour), apiCSV)
+	// not selected
+	fake.addFile("0AT000000000003", "ReportExport", time.Now().Add(-time.Hour), apiCSV)
+
+	s := newTestSource(t, instanceURL, "mode: cat\n")
+
+	events := sourcetest.OneShot(t, s)
+	require.Len(t, events, 3)
+
+	u, _ := url.Parse(instanceURL)
+	assert.Equal(t, ModuleName, events[0].Line.Module)
+	assert.Equal(t, u.Host, events[0].Line.Src)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 123000000, time.UTC), events[0].Line.Time)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 5, 6, 0, time.UTC), events[1].Line.Time)
+
+	var row map[string]string
+	require.NoError(t, json.Unmarshal([]byte(events[0].Line.Raw), &row))
+	assert.Equal(t, "LOGIN_ERROR_INVALID_PASSWORD", row["LOGIN_STATUS"])
+	assert.Equal(t, "192.0.2.10", row["SOURCE_IP"])
+
+	// the empty columns are left out
+	assert.NotContains(t, events[1].Line.Raw, "TIMESTAMP_DERIVED")
+
+	assert.Contains(t, events[2].Line.Raw, `"METHOD":"query"`)
+
+	require.Len(t, fake.queries, 1)
+	assert.Contains(t, fake.queries[0], "WHERE Interval = 'Hourly' AND CreatedDate > ")
+	assert.Contains(t, fake.queries[0], "AND EventType IN ('Login', 'API') ORDER BY CreatedDate, Id")
+
+	// the first token was rejected after its first use, a new one was requested
+	assert.Equal(t, 2, fake.tokens)
+}
+
+func TestErrors(t *testing.T) {
+	_, instanceURL := newFakeAPI(t)
+
+	s := newTestSource(t, instanceURL, "client_secret: wrong\n")
+	err := s.Stream(t.Context(), make(chan pipeline.Event))
+	cstest.RequireErrorContains(t, err, "unable to list the event log files: requesting access token: POST /services/oauth2/token: 400 Bad Request: invalid client credentials")
+
+	// the token is only sent to the instance
+	s = newTestSource(t, instanceURL, "")
+	client := newRESTClient(&s.config)
+	err = client.get(t.Context(), "https://attacker.example.com/services/data/v62.0/query", func(io.Reader) error { return nil })
+	cstest.RequireErrorContains(t, err, "unexpected path")
+
+	err = client.logFile(t.Context(), "0AT000000000404", func(io.Reader) error { return nil })
+	cstest.RequireErrorContains(t, err, "GET /services/data/v62.0/sobjects/EventLogFile/0AT000000000404/LogFile: 404 Not Found")
+}
+
+func TestStreamCursor(t *testing.T) {
+	fake, instanceURL := newFakeAPI(t)
+	cursorFile := filepath.Join(t.TempDir(), "salesforce.cursor")
+
+	// without cursor, the files older than since are skipped
+	fake.addFile("0AT000000000001", "Login", time.Now().Add(-3*time.Hour), loginCSV)
+	fake.addFile("0AT000000000002", "API", time.Now().Add(-time.Hour), apiCSV)
+
+	s := newTestSource(t, instanceURL, "poll_interval: 50ms\nsince: 2h\ncursor_file: "+cursorFile+"\n")
+	events := sourcetest.Stream(t, s, 1)
+	assert.Contains(t, events[0].Line.Raw, `"EVENT_TYPE":"API"`)
+
+	saved, err := os.ReadFile(cursorFile)
+	require.NoError(t, err)
+	assert.Equal(t, time.Now().Add(-time.Hour).UTC().Truncate(time.Second).Format(time.RFC3339)[:13], string(saved)[:13])
+
+	// the files created while stopped are read after a restart, and only them
+	fake.addFile("0AT000000000004", "Login", time.Now().Add(-time.Minute), loginCSV)
+
+	s = newTestSource(t, instanceURL, "poll_interval: 50ms\ncursor_file: "+cursorFile+"\n")
+	events = sourcetest.Stream(t, s, 2)
+	assert.Contains(t, events[0].Line.Raw, `"EVENT_TYPE":"Login"`)
+	assert.Contains(t, events[1].Line.Raw, `"EVENT_TYPE":"Login"`)
+}
@@ -0,0 +1,5 @@
+//go:build !no_datasource_suricata
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/suricata" // register the datasource
@@ -0,0 +1,108 @@
+package suricataacquisition
+
+import (
+	"context"
+	"fmt"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	fileacquisition "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/file"
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/filepreset"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+const (
+	defaultFilename = "/var/log/suricata/eve.json"
+	// label expected by the suricata parsers of the hub
+	defaultType = "suricata-evelogs"
+)
+
+type Configuration struct {
+	configuration.DataSourceCommonCfg `yaml:",inline"`
+
+	Filenames          []string `yaml:"filenames"`
+	EventTypes         []string `yaml:"event_types"` // if set, only forward these event types (alert, flow...)
+	PollWithoutInotify *bool    `yaml:"poll_without_inotify"`
+}
+
+func ConfigurationFromYAML(y []byte) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.UnmarshalWithOptions(y, &cfg, yaml.Strict()); err != nil {
+		return cfg, fmt.Errorf("cannot parse: %s", yaml.FormatError(err, false, false))
+	}
+
+	cfg.SetDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Configuration) SetDefaults() {
+	if c.Mode == "" {
+		c.Mode = configuration.TAIL_MODE
+	}
+
+	if len(c.Filenames) == 0 {
+		c.Filenames = []string{defaultFilename}
+	}
+
+	if c.Labels == nil {
+		c.Labels = map[string]string{}
+	}
+
+	if c.Labels["type"] == "" {
+		c.Labels["type"] = defaultType
+	}
+}
+
+func (c *Configuration) Validate() error {
+	switch c.Mode {
+	case configuration.TAIL_MODE, configuration.CAT_MODE:
+	default:
+		return fmt.Errorf("unsupported mode %s for suricata datasource", c.Mode)
+	}
+
+	return nil
+}
+
+func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
+	cfg, err := ConfigurationFromYAML(yamlConfig)
+	if err != nil {
+		return err
+	}
+
+	s.config = cfg
+
+	return nil
+}
+
+func (s *Source) Configure(ctx context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
+	if err := s.UnmarshalConfig(yamlConfig); err != nil {
+		return err
+	}
+
+	s.logger = logger
+
+	fileConfig := fileacquisition.Configuration{
+		Filenames: s.config.Filenames,
+		// watch the directory even if eve.json does not exist yet, or has been rotated
+		ForceInotify:        true,
+		PollWithoutInotify:  s.config.PollWithoutInotify,
+		DataSourceCommonCfg: s.config.DataSourceCommonCfg,
+	}
+
+	file, err := filepreset.NewFileSource(ctx, fileConfig, logger, metricsLevel)
+	if err != nil {
+		return err
+	}
+
+	s.file = file
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package suricataacquisition
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// the lines are counted by the underlying file datasource
+
+func (s *Source) GetMetrics() []prometheus.Collector {
+	return s.file.GetMetrics()
+}
+
+func (s *Source) GetAggregMetrics() []prometheus.Collector {
+	return s.file.GetAggregMetrics()
+}
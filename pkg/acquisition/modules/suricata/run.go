@@ -0,0 +1,54 @@
+package suricataacquisition
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/filepreset"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// format of the "timestamp" field of EVE records
+const eveTimeLayout = "2006-01-02T15:04:05.999999-0700"
+
+// convert fills the Parsed fields of the event from the EVE record:
+// src_ip, dest_ip, event_type, alert.signature, flow.bytes_toserver...
+func (s *Source) convert(evt *pipeline.Event) bool {
+	dec := json.NewDecoder(strings.NewReader(evt.Line.Raw))
+	dec.UseNumber()
+
+	var record map[string]any
+
+	if err := dec.Decode(&record); err != nil {
+		s.logger.Warningf("%s: invalid EVE record: %s", evt.Line.Src, err)
+		return false
+	}
+
+	eventType, _ := record["event_type"].(string)
+
+	if len(s.config.EventTypes) > 0 && !slices.Contains(s.config.EventTypes, eventType) {
+		return false
+	}
+
+	filepreset.Flatten("", record, evt.Parsed)
+
+	if ts, err := time.Parse(eveTimeLayout, evt.Parsed["timestamp"]); err == nil {
+		evt.Line.Time = ts
+		evt.StrTime = ts.Format(time.RFC3339Nano)
+	}
+
+	evt.Line.Module = ModuleName
+
+	return true
+}
+
+func (s *Source) OneShot(ctx context.Context, out chan pipeline.Event) error {
+	return filepreset.OneShot(ctx, s.file, out, s.convert)
+}
+
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	return filepreset.Stream(ctx, s.file, out, s.convert)
+}
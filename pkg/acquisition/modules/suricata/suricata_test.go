@@ -0,0 +1,124 @@
+package suricataacquisition
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const eveLog = `{"timestamp":"2024-03-01T10:00:00.123456+0100","flow_id":1234567890123456,"event_type":"alert","src_ip":"192.168.1.10","src_port":51234,"dest_ip":"10.0.0.1","dest_port":22,"proto":"TCP","alert":{"action":"allowed","signature_id":2001219,"signature":"ET SCAN Potential SSH Scan","severity":2,"metadata":{"tag":["scan"]}}}
+{"timestamp":"2024-03-01T10:00:01.000000+0100","event_type":"flow","src_ip":"192.168.1.10","dest_ip":"10.0.0.1","flow":{"bytes_toserver":1200,"bytes_toclient":300}}
+{"timestamp":"2024-03-01T10:00:02.000000+0100","event_type":"stats","stats":{"uptime":12}}
+not json
+`
+
+func newSource(t *testing.T, cfg string) *Source {
+	t.Helper()
+
+	s := &Source{}
+	err := s.Configure(t.Context(), []byte(cfg), log.WithField("type", ModuleName), metrics.AcquisitionMetricsLevelNone)
+	require.NoError(t, err)
+
+	return s
+}
+
+func TestOneShot(t *testing.T) {
+	ctx := t.Context()
+
+	filename := filepath.Join(t.TempDir(), "eve.json")
+	require.NoError(t, os.WriteFile(filename, []byte(eveLog), 0o644))
+
+	s := newSource(t, fmt.Sprintf(`
+source: suricata
+mode: cat
+filenames: [%s]
+event_types: [alert, flow]
+`, filename))
+
+	out := make(chan pipeline.Event, 10)
+	require.NoError(t, s.OneShot(ctx, out))
+	close(out)
+
+	events := []pipeline.Event{}
+	for evt := range out {
+		events = append(events, evt)
+	}
+
+	require.Len(t, events, 2)
+
+	alert := events[0]
+	assert.Equal(t, "suricata-evelogs", alert.Line.Labels["type"])
+	assert.Equal(t, ModuleName, alert.Line.Module)
+	assert.Equal(t, "alert", alert.Parsed["event_type"])
+	assert.Equal(t, "192.168.1.10", alert.Parsed["src_ip"])
+	assert.Equal(t, "22", alert.Parsed["dest_port"])
+	assert.Equal(t, "1234567890123456", alert.Parsed["flow_id"])
+	assert.Equal(t, "ET SCAN Potential SSH Scan", alert.Parsed["alert.signature"])
+	assert.Equal(t, "2001219", alert.Parsed["alert.signature_id"])
+	assert.JSONEq(t, `["scan"]`, alert.Parsed["alert.metadata.tag"])
+	assert.True(t, alert.Line.Time.Equal(time.Date(2024, 3, 1, 9, 0, 0, 123456000, time.UTC)))
+
+	flow := events[1]
+	assert.Equal(t, "flow", flow.Parsed["event_type"])
+	assert.Equal(t, "1200", flow.Parsed["flow.bytes_toserver"])
+}
+
+func TestStream(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	filename := filepath.Join(t.TempDir(), "eve.json")
+	require.NoError(t, os.WriteFile(filename, nil, 0o644))
+
+	s := newSource(t, fmt.Sprintf(`
+source: suricata
+filenames: [%s]
+poll_without_inotify: true
+`, filename))
+
+	out := make(chan pipeline.Event, 10)
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- s.Stream(ctx, out)
+	}()
+
+	// give the tailer some time to start
+	time.Sleep(500 * time.Millisecond)
+
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(eveLog)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	for _, want := range []string{"alert", "flow", "stats"} {
+		select {
+		case evt := <-out:
+			assert.Equal(t, want, evt.Parsed["event_type"])
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for %s event", want)
+		}
+	}
+
+	cancel()
+
+	select {
+	case err := <-errChan:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the datasource to stop")
+	}
+
+	assert.Empty(t, out)
+}
@@ -3,7 +3,10 @@ package syslogacquisition
 import (
 	"context"
 	"fmt"
+	"maps"
 	"net"
+	"slices"
+	"strings"
 
 	yaml "github.com/goccy/go-yaml"
 	log "github.com/sirupsen/logrus"
@@ -18,6 +21,7 @@ type Configuration struct {
 	Addr                              string `yaml:"listen_addr,omitempty"`
 	MaxMessageLen                     int    `yaml:"max_message_len,omitempty"`
 	DisableRFCParser                  bool   `yaml:"disable_rfc_parser,omitempty"` // if true, we don't try to be smart and just remove the PRI
+	Preset                            string `yaml:"preset,omitempty"`             // vendor format to parse into Parsed (fortinet, paloalto, checkpoint)
 	configuration.DataSourceCommonCfg `yaml:",inline"`
 }
 
@@ -53,6 +57,16 @@ func (c *Configuration) SetDefaults() {
 	if c.MaxMessageLen == 0 {
 		c.MaxMessageLen = 2048
 	}
+
+	if c.Preset != "" {
+		if c.Labels == nil {
+			c.Labels = map[string]string{}
+		}
+
+		if c.Labels["type"] == "" {
+			c.Labels["type"] = c.Preset
+		}
+	}
 }
 
 func (c *Configuration) Validate() error {
@@ -64,6 +78,12 @@ func (c *Configuration) Validate() error {
 		return fmt.Errorf("invalid listen IP %s", c.Addr)
 	}
 
+	if c.Preset != "" {
+		if _, ok := presets[c.Preset]; !ok {
+			return fmt.Errorf("unknown preset %q, must be one of: %s", c.Preset, strings.Join(slices.Sorted(maps.Keys(presets)), ", "))
+		}
+	}
+
 	return nil
 }
 
@@ -74,6 +94,7 @@ func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
 	}
 
 	s.config = cfg
+	s.preset = presets[cfg.Preset]
 
 	return nil
 }
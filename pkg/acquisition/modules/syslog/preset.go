@@ -0,0 +1,276 @@
+package syslogacquisition
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// preset is a vendor format that is parsed during acquisition. It's cheaper than grok patterns.
+type preset struct {
+	// locate finds the vendor payload in the message (without PRI): the appliances don't always
+	// send a syslog header, and the RFC parsers can mistake the beginning of the payload for a tag.
+	locate func(msg string) (string, bool)
+	// parse splits the payload into the Parsed fields of the event
+	parse func(payload string, parsed map[string]string) error
+}
+
+// presets are indexed by name, which is also the default type label of the datasource.
+var presets = map[string]*preset{
+	"fortinet":   {locate: locateFortinet, parse: parseFortinet},
+	"paloalto":   {locate: locatePaloAlto, parse: parsePaloAlto},
+	"checkpoint": {locate: locateCheckpoint, parse: parseCheckpoint},
+}
+
+// apply fills the Parsed fields of an event from a syslog message without PRI.
+func (p *preset) apply(msg string, header *syslogHeader, parsed map[string]string) error {
+	if header != nil {
+		header.fillParsed(parsed)
+	}
+
+	payload, ok := p.locate(msg)
+	if !ok {
+		return errors.New("vendor payload not found")
+	}
+
+	parsed["message"] = payload
+
+	return p.parse(payload, parsed)
+}
+
+// syslogHeader holds the fields of the syslog header, when the message is RFC3164 or RFC5424.
+type syslogHeader struct {
+	timestamp time.Time
+	hostname  string
+}
+
+// fillParsed sets the fields of the header like the syslog-logs parser of the hub.
+func (h *syslogHeader) fillParsed(parsed map[string]string) {
+	if !h.timestamp.IsZero() {
+		parsed["timestamp"] = h.timestamp.Format(time.RFC3339)
+	}
+
+	if h.hostname != "" {
+		parsed["logsource"] = h.hostname
+	}
+}
+
+// splitKeyValues parses a list of key<kvSep>value pairs separated by pairSep (and spaces).
+// Values can be double-quoted, in which case they can contain separators and escaped quotes.
+func splitKeyValues(msg string, pairSep byte, kvSep byte, parsed map[string]string) error {
+	i := 0
+	n := len(msg)
+	found := false
+
+	for i < n {
+		// skip separators
+		for i < n && (msg[i] == pairSep || msg[i] == ' ') {
+			i++
+		}
+
+		if i == n {
+			break
+		}
+
+		start := i
+		for i < n && msg[i] != kvSep && msg[i] != pairSep && msg[i] != ' ' {
+			i++
+		}
+
+		key := msg[start:i]
+
+		if i == n || msg[i] != kvSep {
+			return fmt.Errorf("missing value for %q", key)
+		}
+
+		i++ // kvSep
+
+		var value string
+
+		if i < n && msg[i] == '"' {
+			i++
+
+			var sb strings.Builder
+
+			closed := false
+
+			for i < n {
+				c := msg[i]
+				if c == '\\' && i+1 < n && (msg[i+1] == '"' || msg[i+1] == '\\') {
+					sb.WriteByte(msg[i+1])
+					i += 2
+
+					continue
+				}
+
+				if c == '"' {
+					closed = true
+					i++
+
+					break
+				}
+
+				sb.WriteByte(c)
+				i++
+			}
+
+			if !closed {
+				return fmt.Errorf("unterminated quote for %q", key)
+			}
+
+			value = sb.String()
+		} else {
+			start = i
+			for i < n && msg[i] != pairSep && msg[i] != ' ' {
+				i++
+			}
+
+			value = msg[start:i]
+		}
+
+		parsed[key] = value
+		found = true
+	}
+
+	if !found {
+		return errors.New("no key/value pair found")
+	}
+
+	return nil
+}
+
+var fortinetStart = regexp.MustCompile(`(?:^|\s)[a-z_]+=`)
+
+func locateFortinet(msg string) (string, bool) {
+	loc := fortinetStart.FindStringIndex(msg)
+	if loc == nil {
+		return "", false
+	}
+
+	return strings.TrimSpace(msg[loc[0]:]), true
+}
+
+// parseFortinet handles the FortiGate/FortiOS format:
+//
+//	date=2024-01-02 time=10:00:00 devname="fw01" logid="0000000013" type="traffic" srcip=10.0.0.1 ...
+func parseFortinet(msg string, parsed map[string]string) error {
+	return splitKeyValues(msg, ' ', '=', parsed)
+}
+
+func locateCheckpoint(msg string) (string, bool) {
+	start := strings.Index(msg, "[")
+	end := strings.LastIndex(msg, "]")
+
+	if start == -1 || end < start {
+		return "", false
+	}
+
+	return msg[start : end+1], true
+}
+
+// parseCheckpoint handles the syslog format of the Check Point log exporter:
+//
+//	[action:"Accept"; flags:"411904"; ifdir:"inbound"; src:"10.0.0.1"; dst:"10.0.0.2"; ...]
+func parseCheckpoint(msg string, parsed map[string]string) error {
+	msg = strings.TrimSpace(msg)
+	msg = strings.TrimPrefix(msg, "[")
+	msg = strings.TrimSuffix(msg, "]")
+
+	return splitKeyValues(msg, ';', ':', parsed)
+}
+
+// paloAltoCommonFields are the first fields of all the PAN-OS log types. Unused fields are empty.
+var paloAltoCommonFields = []string{
+	"", "receive_time", "serial", "type", "subtype", "", "time_generated",
+}
+
+// paloAltoSessionFields are the fields of the TRAFFIC and THREAT logs, after the common ones.
+var paloAltoSessionFields = []string{
+	"src", "dst", "natsrc", "natdst", "rule", "srcuser", "dstuser", "app", "vsys",
+	"from", "to", "inbound_if", "outbound_if", "log_action", "", "sessionid", "repeatcnt",
+	"sport", "dport", "natsport", "natdport", "flags", "proto", "action",
+}
+
+var paloAltoFields = map[string][]string{
+	"TRAFFIC": concatFields(paloAltoCommonFields, paloAltoSessionFields, []string{
+		"bytes", "bytes_sent", "bytes_received", "packets", "start", "elapsed", "category", "",
+		"seqno", "actionflags", "srcloc", "dstloc", "", "pkts_sent", "pkts_received", "session_end_reason",
+	}),
+	"THREAT": concatFields(paloAltoCommonFields, paloAltoSessionFields, []string{
+		"misc", "threatid", "category", "severity", "direction", "seqno", "actionflags",
+		"srcloc", "dstloc", "", "contenttype", "pcap_id", "filedigest", "cloud", "url_idx",
+		"user_agent", "filetype", "xff", "referer", "sender", "subject", "recipient", "reportid",
+	}),
+	"SYSTEM": concatFields(paloAltoCommonFields, []string{
+		"vsys", "eventid", "object", "", "", "module", "severity", "opaque",
+	}),
+	"CONFIG": concatFields(paloAltoCommonFields, []string{
+		"host", "vsys", "cmd", "admin", "client", "result", "path",
+	}),
+}
+
+func concatFields(lists ...[]string) []string {
+	var ret []string
+
+	for _, l := range lists {
+		ret = append(ret, l...)
+	}
+
+	return ret
+}
+
+// the first fields are an unused integer and the receive time
+var paloAltoStart = regexp.MustCompile(`(?:^|\s)\d+,\d{4}/\d{2}/\d{2} `)
+
+func locatePaloAlto(msg string) (string, bool) {
+	loc := paloAltoStart.FindStringIndex(msg)
+	if loc == nil {
+		return "", false
+	}
+
+	return strings.TrimSpace(msg[loc[0]:]), true
+}
+
+// parsePaloAlto handles the CSV format of PAN-OS. The fields are named after the PAN-OS
+// documentation, according to the log type (TRAFFIC, THREAT, SYSTEM, CONFIG).
+//
+//	1,2024/01/02 10:00:00,012801000001,TRAFFIC,end,2561,2024/01/02 10:00:00,10.0.0.1,10.0.0.2,...
+func parsePaloAlto(msg string, parsed map[string]string) error {
+	r := csv.NewReader(strings.NewReader(strings.TrimSpace(msg)))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	record, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("invalid CSV: %w", err)
+	}
+
+	if len(record) < len(paloAltoCommonFields) {
+		return fmt.Errorf("too few fields (%d)", len(record))
+	}
+
+	logType := record[3]
+
+	fields, ok := paloAltoFields[logType]
+	if !ok {
+		// only the common fields are known
+		fields = paloAltoCommonFields
+	}
+
+	for i, value := range record {
+		if i >= len(fields) {
+			break
+		}
+
+		if fields[i] == "" {
+			continue
+		}
+
+		parsed[fields[i]] = value
+	}
+
+	return nil
+}
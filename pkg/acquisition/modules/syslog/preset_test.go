@@ -0,0 +1,282 @@
+package syslogacquisition
+
+import (
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	syslogserver "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/syslog/internal/server"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+func TestParseFortinet(t *testing.T) {
+	tests := []struct {
+		name        string
+		msg         string
+		expected    map[string]string
+		expectedErr string
+	}{
+		{
+			name: "traffic",
+			msg:  `date=2024-01-02 time=10:00:00 devname="fw01" logid="0000000013" type="traffic" subtype="forward" srcip=10.0.0.1 srcport=51234 dstip=192.0.2.10 dstport=443 action="deny" msg="quoted \"value\", with spaces"`,
+			expected: map[string]string{
+				"date":    "2024-01-02",
+				"time":    "10:00:00",
+				"devname": "fw01",
+				"logid":   "0000000013",
+				"type":    "traffic",
+				"subtype": "forward",
+				"srcip":   "10.0.0.1",
+				"srcport": "51234",
+				"dstip":   "192.0.2.10",
+				"dstport": "443",
+				"action":  "deny",
+				"msg":     `quoted "value", with spaces`,
+			},
+		},
+		{
+			name:     "empty value",
+			msg:      `user="" srcip=10.0.0.1`,
+			expected: map[string]string{"user": "", "srcip": "10.0.0.1"},
+		},
+		{
+			name:        "not key=value",
+			msg:         "session closed for user root",
+			expectedErr: `missing value for "session"`,
+		},
+		{
+			name:        "unterminated quote",
+			msg:         `devname="fw01`,
+			expectedErr: `unterminated quote for "devname"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed := map[string]string{}
+			err := parseFortinet(tc.msg, parsed)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, parsed)
+		})
+	}
+}
+
+func TestParseCheckpoint(t *testing.T) {
+	tests := []struct {
+		name        string
+		msg         string
+		expected    map[string]string
+		expectedErr string
+	}{
+		{
+			name: "accept",
+			msg:  `[action:"Accept"; flags:"411904"; ifdir:"inbound"; origin:"10.1.1.1"; src:"10.0.0.1"; dst:"192.0.2.10"; proto:"6"; s_port:"51234"; service:"443"; rule_name:"allow; web"]`,
+			expected: map[string]string{
+				"action":    "Accept",
+				"flags":     "411904",
+				"ifdir":     "inbound",
+				"origin":    "10.1.1.1",
+				"src":       "10.0.0.1",
+				"dst":       "192.0.2.10",
+				"proto":     "6",
+				"s_port":    "51234",
+				"service":   "443",
+				"rule_name": "allow; web",
+			},
+		},
+		{
+			name:        "empty",
+			msg:         "[]",
+			expectedErr: "no key/value pair found",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed := map[string]string{}
+			err := parseCheckpoint(tc.msg, parsed)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, parsed)
+		})
+	}
+}
+
+func TestParsePaloAlto(t *testing.T) {
+	tests := []struct {
+		name        string
+		msg         string
+		expected    map[string]string
+		expectedErr string
+	}{
+		{
+			name: "threat",
+			msg: `1,2024/01/02 10:00:00,012801000001,THREAT,url,2561,2024/01/02 10:00:00,10.0.0.1,192.0.2.10,0.0.0.0,0.0.0.0,` +
+				`allow-web,,,web-browsing,vsys1,trust,untrust,ethernet1/1,ethernet1/2,default,,12345,1,51234,80,0,0,0x8000,tcp,alert,` +
+				`"example.com/login,php",(9999),phishing,informational,client-to-server`,
+			expected: map[string]string{
+				"receive_time":   "2024/01/02 10:00:00",
+				"serial":         "012801000001",
+				"type":           "THREAT",
+				"subtype":        "url",
+				"time_generated": "2024/01/02 10:00:00",
+				"src":            "10.0.0.1",
+				"dst":            "192.0.2.10",
+				"natsrc":         "0.0.0.0",
+				"natdst":         "0.0.0.0",
+				"rule":           "allow-web",
+				"srcuser":        "",
+				"dstuser":        "",
+				"app":            "web-browsing",
+				"vsys":           "vsys1",
+				"from":           "trust",
+				"to":             "untrust",
+				"inbound_if":     "ethernet1/1",
+				"outbound_if":    "ethernet1/2",
+				"log_action":     "default",
+				"sessionid":      "12345",
+				"repeatcnt":      "1",
+				"sport":          "51234",
+				"dport":          "80",
+				"natsport":       "0",
+				"natdport":       "0",
+				"flags":          "0x8000",
+				"proto":          "tcp",
+				"action":         "alert",
+				"misc":           "example.com/login,php",
+				"threatid":       "(9999)",
+				"category":       "phishing",
+				"severity":       "informational",
+				"direction":      "client-to-server",
+			},
+		},
+		{
+			name: "unknown type",
+			msg:  `1,2024/01/02 10:00:00,012801000001,GLOBALPROTECT,0,2561,2024/01/02 10:00:00,vsys1`,
+			expected: map[string]string{
+				"receive_time":   "2024/01/02 10:00:00",
+				"serial":         "012801000001",
+				"type":           "GLOBALPROTECT",
+				"subtype":        "0",
+				"time_generated": "2024/01/02 10:00:00",
+			},
+		},
+		{
+			name:        "too short",
+			msg:         "1,2,3",
+			expectedErr: "too few fields (3)",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed := map[string]string{}
+			err := parsePaloAlto(tc.msg, parsed)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, parsed)
+		})
+	}
+}
+
+func TestPresetEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		preset   string
+		msg      string
+		expected map[string]string
+	}{
+		{
+			name:   "fortinet without header",
+			preset: "fortinet",
+			msg:    `<189>date=2024-01-02 time=10:00:00 devname="fw01" type="traffic" srcip=10.0.0.1 action="deny"`,
+			expected: map[string]string{
+				"message": `date=2024-01-02 time=10:00:00 devname="fw01" type="traffic" srcip=10.0.0.1 action="deny"`,
+				"date":    "2024-01-02",
+				"time":    "10:00:00",
+				"devname": "fw01",
+				"type":    "traffic",
+				"srcip":   "10.0.0.1",
+				"action":  "deny",
+			},
+		},
+		{
+			name:   "fortinet with header",
+			preset: "fortinet",
+			msg:    `<189>Jan  2 10:00:00 fw01 devname="fw01" srcip=10.0.0.1 action="deny"`,
+			expected: map[string]string{
+				"logsource": "fw01",
+				"message":   `devname="fw01" srcip=10.0.0.1 action="deny"`,
+				"devname":   "fw01",
+				"srcip":     "10.0.0.1",
+				"action":    "deny",
+			},
+		},
+		{
+			name:   "paloalto",
+			preset: "paloalto",
+			msg:    `<14>Jan  2 10:00:00 PA-VM 1,2024/01/02 10:00:00,012801000001,SYSTEM,auth,2561,2024/01/02 10:00:00,,auth-fail,admin,0,0,general,medium,"failed authentication for user 'admin'"`,
+			expected: map[string]string{
+				"logsource":      "PA-VM",
+				"message":        `1,2024/01/02 10:00:00,012801000001,SYSTEM,auth,2561,2024/01/02 10:00:00,,auth-fail,admin,0,0,general,medium,"failed authentication for user 'admin'"`,
+				"receive_time":   "2024/01/02 10:00:00",
+				"serial":         "012801000001",
+				"type":           "SYSTEM",
+				"subtype":        "auth",
+				"time_generated": "2024/01/02 10:00:00",
+				"vsys":           "",
+				"eventid":        "auth-fail",
+				"object":         "admin",
+				"module":         "general",
+				"severity":       "medium",
+				"opaque":         "failed authentication for user 'admin'",
+			},
+		},
+		{
+			name:   "checkpoint",
+			preset: "checkpoint",
+			msg:    `<134>1 2024-01-02T10:00:00Z gw01 CheckPoint 12345 - [action:"Drop"; src:"10.0.0.1"; dst:"192.0.2.10"; service:"22"]`,
+			expected: map[string]string{
+				"message": `[action:"Drop"; src:"10.0.0.1"; dst:"192.0.2.10"; service:"22"]`,
+				"action":  "Drop",
+				"src":     "10.0.0.1",
+				"dst":     "192.0.2.10",
+				"service": "22",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &Source{}
+			require.NoError(t, s.Configure(t.Context(), []byte("source: syslog\npreset: "+tc.preset),
+				log.WithField("test", tc.name), metrics.AcquisitionMetricsLevelNone))
+
+			evt, err := s.msgToEvent(syslogserver.SyslogMessage{Client: "10.1.1.1", Message: []byte(tc.msg)})
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.preset, evt.Line.Labels["type"])
+
+			// the timestamp depends on the current year for RFC3164
+			delete(evt.Parsed, "timestamp")
+			assert.Equal(t, tc.expected, evt.Parsed)
+		})
+	}
+}
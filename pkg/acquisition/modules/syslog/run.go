@@ -79,7 +79,7 @@ func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
 }
 
 func (s *Source) msgToEvent(msg syslogserver.SyslogMessage) (*pipeline.Event, error) {
-	line, err := s.parseLine(msg)
+	line, header, err := s.parseLine(msg)
 	if err != nil {
 		return nil, err
 	}
@@ -94,6 +94,18 @@ func (s *Source) msgToEvent(msg syslogserver.SyslogMessage) (*pipeline.Event, er
 		Process: true,
 	}
 
+	if s.preset != nil {
+		rest, err := stripPRI(msg.Message)
+		if err != nil {
+			rest = []byte(line)
+		}
+
+		if err := s.preset.apply(strings.TrimSuffix(string(rest), "\n"), header, evt.Parsed); err != nil {
+			// the parsers can still handle the line
+			s.logger.WithField("client", msg.Client).Debugf("%s preset: %s", s.config.Preset, err)
+		}
+	}
+
 	return &evt, nil
 }
 
@@ -193,8 +205,12 @@ func stripPRI(msg []byte) (rest []byte, err error) {
 	return msg[end+1:], nil
 }
 
-func (s *Source) parseLine(syslogLine syslogserver.SyslogMessage) (string, error) {
-	var line string
+// parseLine returns the line to send to the parsers, and the syslog header if the RFC parser is enabled.
+func (s *Source) parseLine(syslogLine syslogserver.SyslogMessage) (string, *syslogHeader, error) {
+	var (
+		line   string
+		header *syslogHeader
+	)
 
 	logger := s.logger.WithField("client", syslogLine.Client)
 	logger.Tracef("raw: %s", syslogLine)
@@ -206,10 +222,10 @@ func (s *Source) parseLine(syslogLine syslogserver.SyslogMessage) (string, error
 	if s.config.DisableRFCParser {
 		rest, err := stripPRI(syslogLine.Message)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
 
-		return strings.TrimSuffix(string(rest), "\n"), nil
+		return strings.TrimSuffix(string(rest), "\n"), nil, nil
 	}
 
 	var err3164, err5424 error
@@ -222,8 +238,18 @@ func (s *Source) parseLine(syslogLine syslogserver.SyslogMessage) (string, error
 		p2 := rfc5424.NewRFC5424Parser()
 
 		err = p2.Parse(syslogLine.Message)
+		if err != nil && s.preset != nil {
+			// appliances often send the payload without a syslog header
+			rest, err := stripPRI(syslogLine.Message)
+			if err != nil {
+				return "", nil, err
+			}
+
+			return strings.TrimSuffix(string(rest), "\n"), nil, nil
+		}
+
 		if err != nil {
-			return "", &ParseError{
+			return "", nil, &ParseError{
 				Reason:     ErrUnrecognized,
 				RawMessage: syslogLine.Message,
 				RFC3164:    err3164,
@@ -232,15 +258,17 @@ func (s *Source) parseLine(syslogLine syslogserver.SyslogMessage) (string, error
 		}
 
 		line = s.buildLogFromSyslog(p2.Timestamp, p2.Hostname, p2.Tag, p2.PID, p2.Message)
+		header = &syslogHeader{timestamp: p2.Timestamp, hostname: p2.Hostname}
 		if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
 			metrics.SyslogDataSourceLinesParsed.With(prometheus.Labels{"source": syslogLine.Client, "type": "rfc5424", "datasource_type": ModuleName, "acquis_type": s.config.Labels["type"]}).Inc()
 		}
 	} else {
 		line = s.buildLogFromSyslog(p.Timestamp, p.Hostname, p.Tag, p.PID, p.Message)
+		header = &syslogHeader{timestamp: p.Timestamp, hostname: p.Hostname}
 		if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
 			metrics.SyslogDataSourceLinesParsed.With(prometheus.Labels{"source": syslogLine.Client, "type": "rfc3164", "datasource_type": ModuleName, "acquis_type": s.config.Labels["type"]}).Inc()
 		}
 	}
 
-	return strings.TrimSuffix(line, "\n"), nil
+	return strings.TrimSuffix(line, "\n"), header, nil
 }
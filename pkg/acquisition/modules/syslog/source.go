@@ -10,6 +10,7 @@ type Source struct {
 	metricsLevel metrics.AcquisitionMetricsLevel
 	config       Configuration
 	logger       *log.Entry
+	preset       *preset // nil if there is no vendor preset
 }
 
 func (s *Source) GetUuid() string {
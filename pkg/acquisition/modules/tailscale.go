@@ -0,0 +1,5 @@
+//go:build !no_datasource_tailscale
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/tailscale" // register the datasource
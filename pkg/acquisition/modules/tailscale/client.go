@@ -0,0 +1,107 @@
+package tailscaleacquisition
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// tailscaled only accepts this host name on its local API, to prevent DNS rebinding attacks
+const localAPIHost = "local-tailscaled.sock"
+
+// localClient is a minimal client for the local API of tailscaled,
+// limited to what is needed to read the state of the node and its peers.
+type localClient struct {
+	http *http.Client
+}
+
+// tsStatus is the response of /localapi/v0/status.
+type tsStatus struct {
+	BackendState   string                   `json:"BackendState"` // NeedsLogin, NeedsMachineAuth, Stopped, Starting, Running
+	Self           *tsPeer                  `json:"Self"`
+	Peer           map[string]*tsPeer       `json:"Peer"` // by node key
+	User           map[string]tsUserProfile `json:"User"` // by user ID
+	CurrentTailnet *struct {
+		Name string `json:"Name"`
+	} `json:"CurrentTailnet"`
+}
+
+type tsPeer struct {
+	ID           string     `json:"ID"`
+	HostName     string     `json:"HostName"`
+	DNSName      string     `json:"DNSName"`
+	OS           string     `json:"OS"`
+	UserID       int64      `json:"UserID"`
+	Tags         []string   `json:"Tags"`
+	TailscaleIPs []string   `json:"TailscaleIPs"`
+	CurAddr      string     `json:"CurAddr"` // ip:port of a direct connection, empty if relayed
+	Relay        string     `json:"Relay"`   // DERP region
+	Online       bool       `json:"Online"`
+	Expired      bool       `json:"Expired"`
+	KeyExpiry    *time.Time `json:"KeyExpiry"`
+}
+
+type tsUserProfile struct {
+	LoginName string `json:"LoginName"`
+}
+
+func newLocalClient(socket string) *localClient {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}
+
+	return &localClient{
+		http: &http.Client{
+			Transport: transport,
+			Timeout:   30 * time.Second,
+		},
+	}
+}
+
+func (c *localClient) status(ctx context.Context) (*tsStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+localAPIHost+"/localapi/v0/status", http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET /localapi/v0/status: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// the reason is in the body, i.e. "tailscaled: access denied"
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("GET /localapi/v0/status: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	ret := &tsStatus{}
+
+	if err := json.NewDecoder(resp.Body).Decode(ret); err != nil {
+		return nil, fmt.Errorf("GET /localapi/v0/status: decoding response: %w", err)
+	}
+
+	return ret, nil
+}
+
+// loginName returns the login name of the owner of a node, or an empty string for tagged nodes.
+func (st *tsStatus) loginName(p *tsPeer) string {
+	return st.User[fmt.Sprint(p.UserID)].LoginName
+}
+
+func (st *tsStatus) tailnet() string {
+	if st.CurrentTailnet == nil {
+		return ""
+	}
+
+	return st.CurrentTailnet.Name
+}
@@ -0,0 +1,228 @@
+package tailscaleacquisition
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const (
+	kindAuth = "auth" // state of this node: login, logout, key expiry
+	kindNode = "node" // membership and connectivity of the peers
+)
+
+// tailscaleEvent is the JSON document sent to the parsers.
+type tailscaleEvent struct {
+	Kind             string    `json:"kind"` // auth or node
+	Action           string    `json:"action"`
+	Time             time.Time `json:"time"`
+	Tailnet          string    `json:"tailnet,omitempty"`
+	NodeID           string    `json:"node_id,omitempty"`
+	HostName         string    `json:"hostname,omitempty"`
+	DNSName          string    `json:"dns_name,omitempty"`
+	OS               string    `json:"os,omitempty"`
+	User             string    `json:"user,omitempty"` // login name of the owner, empty for tagged nodes
+	PreviousUser     string    `json:"previous_user,omitempty"`
+	Tags             []string  `json:"tags,omitempty"`
+	TailscaleIPs     []string  `json:"tailscale_ips,omitempty"`
+	IPAddress        string    `json:"ip_address,omitempty"` // public IP of a direct connection
+	Endpoint         string    `json:"endpoint,omitempty"`
+	PreviousEndpoint string    `json:"previous_endpoint,omitempty"`
+	Relay            string    `json:"relay,omitempty"`
+	State            string    `json:"state,omitempty"`
+	PreviousState    string    `json:"previous_state,omitempty"`
+}
+
+func newNodeEvent(st *tsStatus, p *tsPeer, action string, now time.Time) tailscaleEvent {
+	evt := tailscaleEvent{
+		Kind:         kindNode,
+		Action:       action,
+		Time:         now,
+		Tailnet:      st.tailnet(),
+		NodeID:       p.ID,
+		HostName:     p.HostName,
+		DNSName:      p.DNSName,
+		OS:           p.OS,
+		User:         st.loginName(p),
+		Tags:         p.Tags,
+		TailscaleIPs: p.TailscaleIPs,
+		Endpoint:     p.CurAddr,
+		Relay:        p.Relay,
+	}
+
+	if host, _, err := net.SplitHostPort(p.CurAddr); err == nil {
+		evt.IPAddress = host
+	}
+
+	return evt
+}
+
+// peersByID indexes the peers by stable node ID: the node key changes when a node re-authenticates.
+func peersByID(st *tsStatus) map[string]*tsPeer {
+	ret := make(map[string]*tsPeer, len(st.Peer))
+
+	for key, p := range st.Peer {
+		id := p.ID
+		if id == "" {
+			id = key
+		}
+
+		ret[id] = p
+	}
+
+	return ret
+}
+
+// diffStatus returns the events between two states of the local API.
+func diffStatus(prev *tsStatus, cur *tsStatus, now time.Time) []tailscaleEvent {
+	var ret []tailscaleEvent
+
+	if cur.BackendState != prev.BackendState {
+		ret = append(ret, tailscaleEvent{
+			Kind:          kindAuth,
+			Action:        "state_changed",
+			Time:          now,
+			Tailnet:       cur.tailnet(),
+			State:         cur.BackendState,
+			PreviousState: prev.BackendState,
+		})
+	}
+
+	if cur.Self != nil && prev.Self != nil {
+		if user, prevUser := cur.loginName(cur.Self), prev.loginName(prev.Self); user != prevUser && user != "" {
+			evt := newNodeEvent(cur, cur.Self, "user_changed", now)
+			evt.Kind = kindAuth
+			evt.PreviousUser = prevUser
+			ret = append(ret, evt)
+		}
+
+		if cur.Self.Expired && !prev.Self.Expired {
+			evt := newNodeEvent(cur, cur.Self, "expired", now)
+			evt.Kind = kindAuth
+			ret = append(ret, evt)
+		}
+	}
+
+	prevPeers := peersByID(prev)
+	curPeers := peersByID(cur)
+
+	var nodeEvents []tailscaleEvent
+
+	for id, p := range curPeers {
+		old, ok := prevPeers[id]
+		if !ok {
+			nodeEvents = append(nodeEvents, newNodeEvent(cur, p, "added", now))
+			continue
+		}
+
+		switch {
+		case p.Online && !old.Online:
+			nodeEvents = append(nodeEvents, newNodeEvent(cur, p, "online", now))
+		case !p.Online && old.Online:
+			nodeEvents = append(nodeEvents, newNodeEvent(cur, p, "offline", now))
+		}
+
+		// a relayed connection has no endpoint, only report the new direct ones
+		if p.CurAddr != "" && p.CurAddr != old.CurAddr {
+			evt := newNodeEvent(cur, p, "endpoint_changed", now)
+			evt.PreviousEndpoint = old.CurAddr
+			nodeEvents = append(nodeEvents, evt)
+		}
+
+		if p.Expired && !old.Expired {
+			nodeEvents = append(nodeEvents, newNodeEvent(cur, p, "expired", now))
+		}
+	}
+
+	for id, p := range prevPeers {
+		if _, ok := curPeers[id]; !ok {
+			nodeEvents = append(nodeEvents, newNodeEvent(prev, p, "removed", now))
+		}
+	}
+
+	// the maps are not ordered
+	slices.SortStableFunc(nodeEvents, func(a, b tailscaleEvent) int {
+		return cmp.Compare(a.NodeID, b.NodeID)
+	})
+
+	return append(ret, nodeEvents...)
+}
+
+func (s *Source) sendEvent(evt tailscaleEvent, out chan pipeline.Event) {
+	raw, err := json.Marshal(evt)
+	if err != nil {
+		s.logger.Errorf("unable to serialize event: %s", err)
+		return
+	}
+
+	if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+		metrics.TailscaleDataSourceEventsRead.With(prometheus.Labels{"source": s.src, "datasource_type": ModuleName, "acquis_type": s.config.Labels["type"]}).Inc()
+	}
+
+	line := pipeline.Line{
+		Raw:     string(raw),
+		Src:     s.src,
+		Time:    evt.Time,
+		Labels:  s.config.Labels,
+		Process: true,
+		Module:  s.GetName(),
+	}
+
+	pevt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+	pevt.Line = line
+
+	out <- pevt
+}
+
+// Stream polls the local API and sends the changes between two polls. The first poll is the
+// reference state: the nodes already in the tailnet when the datasource starts are not reported.
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	client := newLocalClient(s.config.Socket)
+
+	prev, err := client.status(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return fmt.Errorf("unable to read the status of tailscaled: %w", err)
+	}
+
+	s.logger.Infof("Watching %d peer(s), backend state: %s", len(prev.Peer), prev.BackendState)
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Datasource stopping")
+			return nil
+		case <-ticker.C:
+		}
+
+		cur, err := client.status(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return fmt.Errorf("unable to read the status of tailscaled: %w", err)
+		}
+
+		for _, evt := range diffStatus(prev, cur, time.Now().UTC()) {
+			s.sendEvent(evt, out)
+		}
+
+		prev = cur
+	}
+}
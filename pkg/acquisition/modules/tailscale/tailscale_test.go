@@ -0,0 +1,221 @@
+package tailscaleacquisition
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const statusBefore = `{
+  "BackendState": "Running",
+  "CurrentTailnet": {"Name": "example.com"},
+  "Self": {"ID": "nSELF", "HostName": "gw", "UserID": 1, "Online": true},
+  "Peer": {
+    "nodekey:aaa": {"ID": "nA", "HostName": "laptop", "OS": "linux", "UserID": 1, "TailscaleIPs": ["100.64.0.2"], "Relay": "fra", "Online": true},
+    "nodekey:bbb": {"ID": "nB", "HostName": "phone", "OS": "android", "UserID": 2, "Online": true, "CurAddr": "198.51.100.7:41641"}
+  },
+  "User": {
+    "1": {"LoginName": "alice@example.com"},
+    "2": {"LoginName": "bob@example.com"}
+  }
+}`
+
+// the laptop went offline, the phone roamed, a tagged server joined, and this node was logged out
+const statusAfter = `{
+  "BackendState": "NeedsLogin",
+  "CurrentTailnet": {"Name": "example.com"},
+  "Self": {"ID": "nSELF", "HostName": "gw", "UserID": 1, "Online": true},
+  "Peer": {
+    "nodekey:aaa": {"ID": "nA", "HostName": "laptop", "OS": "linux", "UserID": 1, "TailscaleIPs": ["100.64.0.2"], "Relay": "fra", "Online": false},
+    "nodekey:bbc": {"ID": "nB", "HostName": "phone", "OS": "android", "UserID": 2, "Online": true, "CurAddr": "203.0.113.9:41641"},
+    "nodekey:ccc": {"ID": "nC", "HostName": "server", "OS": "linux", "UserID": 3, "Tags": ["tag:prod"], "TailscaleIPs": ["100.64.0.4"], "Online": true}
+  },
+  "User": {
+    "1": {"LoginName": "alice@example.com"},
+    "2": {"LoginName": "bob@example.com"}
+  }
+}`
+
+func parseStatus(t *testing.T, s string) *tsStatus {
+	t.Helper()
+
+	ret := &tsStatus{}
+	require.NoError(t, json.Unmarshal([]byte(s), ret))
+
+	return ret
+}
+
+func TestDiffStatus(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	assert.Empty(t, diffStatus(parseStatus(t, statusBefore), parseStatus(t, statusBefore), now))
+
+	events := diffStatus(parseStatus(t, statusBefore), parseStatus(t, statusAfter), now)
+	require.Len(t, events, 4)
+
+	assert.Equal(t, tailscaleEvent{
+		Kind:          "auth",
+		Action:        "state_changed",
+		Time:          now,
+		Tailnet:       "example.com",
+		State:         "NeedsLogin",
+		PreviousState: "Running",
+	}, events[0])
+
+	assert.Equal(t, "offline", events[1].Action)
+	assert.Equal(t, "nA", events[1].NodeID)
+	assert.Equal(t, "alice@example.com", events[1].User)
+	assert.Equal(t, "fra", events[1].Relay)
+
+	// the node key changed, it's the same node
+	assert.Equal(t, "endpoint_changed", events[2].Action)
+	assert.Equal(t, "nB", events[2].NodeID)
+	assert.Equal(t, "203.0.113.9", events[2].IPAddress)
+	assert.Equal(t, "203.0.113.9:41641", events[2].Endpoint)
+	assert.Equal(t, "198.51.100.7:41641", events[2].PreviousEndpoint)
+
+	assert.Equal(t, "added", events[3].Action)
+	assert.Equal(t, "node", events[3].Kind)
+	assert.Equal(t, "nC", events[3].NodeID)
+	assert.Empty(t, events[3].User)
+	assert.Equal(t, []string{"tag:prod"}, events[3].Tags)
+
+	events = diffStatus(parseStatus(t, statusAfter), parseStatus(t, statusBefore), now)
+	require.Len(t, events, 4)
+	assert.Equal(t, "Running", events[0].State)
+	assert.Equal(t, "online", events[1].Action)
+	assert.Equal(t, "endpoint_changed", events[2].Action)
+	assert.Equal(t, "removed", events[3].Action)
+	assert.Equal(t, "server", events[3].HostName)
+}
+
+// fakeTailscaled serves the status of the local API, from a list of responses.
+type fakeTailscaled struct {
+	mu        sync.Mutex
+	responses []string
+	calls     int
+}
+
+func (f *fakeTailscaled) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Host != localAPIHost || r.URL.Path != "/localapi/v0/status" {
+		http.Error(w, "invalid request", http.StatusForbidden)
+		return
+	}
+
+	resp := f.responses[min(f.calls, len(f.responses)-1)]
+	f.calls++
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = io.WriteString(w, resp)
+}
+
+// startTailscaled serves the fake local API on a unix socket and returns its path.
+func startTailscaled(t *testing.T, handler http.Handler) string {
+	t.Helper()
+
+	// t.TempDir() can exceed the maximum length of a socket path
+	dir, err := os.MkdirTemp("", "tailscale")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "tailscaled.sock")
+
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	srv := httptest.NewUnstartedServer(handler)
+	srv.Listener = listener
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	return socket
+}
+
+func newTestSource(t *testing.T, socket string) *Source {
+	t.Helper()
+
+	cfg := `source: tailscale
+labels:
+  type: tailscale
+poll_interval: 50ms
+socket: ` + socket
+
+	s := Source{}
+	err := s.Configure(t.Context(), []byte(cfg), log.WithField("type", ModuleName), metrics.AcquisitionMetricsLevelNone)
+	require.NoError(t, err)
+
+	return &s
+}
+
+func TestStream(t *testing.T) {
+	fake := &fakeTailscaled{responses: []string{statusBefore, statusBefore, statusAfter}}
+	s := newTestSource(t, startTailscaled(t, fake))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	out := make(chan pipeline.Event, 10)
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- s.Stream(ctx, out)
+	}()
+
+	for range 4 {
+		select {
+		case evt := <-out:
+			assert.Equal(t, ModuleName, evt.Line.Module)
+			assert.Equal(t, s.config.Socket, evt.Line.Src)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for events")
+		}
+	}
+
+	// the state doesn't change anymore, nothing else is sent
+	time.Sleep(200 * time.Millisecond)
+
+	cancel()
+
+	select {
+	case err := <-errChan:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("datasource did not stop")
+	}
+
+	assert.Empty(t, out)
+}
+
+func TestStreamError(t *testing.T) {
+	denied := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "tailscaled: access denied", http.StatusForbidden)
+	})
+
+	s := newTestSource(t, startTailscaled(t, denied))
+
+	err := s.Stream(t.Context(), make(chan pipeline.Event))
+	cstest.RequireErrorContains(t, err, "unable to read the status of tailscaled: GET /localapi/v0/status: 403 Forbidden: tailscaled: access denied")
+
+	s = newTestSource(t, filepath.Join(t.TempDir(), "missing.sock"))
+
+	err = s.Stream(t.Context(), make(chan pipeline.Event))
+	cstest.RequireErrorContains(t, err, "connect: no such file or directory")
+}
@@ -0,0 +1,5 @@
+//go:build !no_datasource_vcenter
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/vcenter" // register the datasource
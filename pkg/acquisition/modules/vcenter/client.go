@@ -0,0 +1,213 @@
+package vcenteracquisition
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"time"
+)
+
+// vimClient is a minimal client for the vSphere Web Services (SOAP) API,
+// limited to what is needed to read the event history.
+type vimClient struct {
+	url        string
+	apiVersion string
+	http       *http.Client
+
+	sessionManager string
+	eventManager   string
+}
+
+type moRef struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type soapFault struct {
+	Code   string `xml:"faultcode"`
+	String string `xml:"faultstring"`
+}
+
+// vimEvent holds the fields shared by the vSphere events we care about.
+type vimEvent struct {
+	Type                 string    `xml:"http://www.w3.org/2001/XMLSchema-instance type,attr"`
+	Key                  int64     `xml:"key"`
+	ChainID              int64     `xml:"chainId"`
+	CreatedTime          time.Time `xml:"createdTime"`
+	UserName             string    `xml:"userName"`
+	Datacenter           string    `xml:"datacenter>name"`
+	ComputeResource      string    `xml:"computeResource>name"`
+	Host                 string    `xml:"host>name"`
+	VM                   string    `xml:"vm>name"`
+	FullFormattedMessage string    `xml:"fullFormattedMessage"`
+	IPAddress            string    `xml:"ipAddress"`
+	UserAgent            string    `xml:"userAgent"`
+	EventTypeID          string    `xml:"eventTypeId"`
+	Arguments            []struct {
+		Key   string `xml:"key"`
+		Value string `xml:"value"`
+	} `xml:"arguments"`
+}
+
+func newVimClient(url string, apiVersion string, insecureSkipVerify bool) (*vimClient, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: insecureSkipVerify, //nolint:gosec // user-provided option for self-signed vCenter certificates
+	}
+
+	return &vimClient{
+		url:        url,
+		apiVersion: apiVersion,
+		http: &http.Client{
+			Jar:       jar,
+			Transport: transport,
+			Timeout:   60 * time.Second,
+		},
+	}, nil
+}
+
+// call sends a SOAP request whose body is the given method element and decodes the response body in ret.
+func (c *vimClient) call(ctx context.Context, method string, args string, ret any) error {
+	body := `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+		`<soapenv:Body><` + method + ` xmlns="urn:vim25">` + args + `</` + method + `></soapenv:Body></soapenv:Envelope>`
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	req.Header.Set("SOAPAction", "urn:vim25/"+c.apiVersion)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: reading response: %w", method, err)
+	}
+
+	var envelope struct {
+		Fault *soapFault `xml:"Body>Fault"`
+	}
+
+	if err := xml.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("%s: invalid response (status %d): %w", method, resp.StatusCode, err)
+	}
+
+	if envelope.Fault != nil {
+		return fmt.Errorf("%s: %s", method, envelope.Fault.String)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status %d", method, resp.StatusCode)
+	}
+
+	if ret == nil {
+		return nil
+	}
+
+	if err := xml.Unmarshal(data, ret); err != nil {
+		return fmt.Errorf("%s: decoding response: %w", method, err)
+	}
+
+	return nil
+}
+
+func escape(s string) string {
+	var b bytes.Buffer
+
+	_ = xml.EscapeText(&b, []byte(s))
+
+	return b.String()
+}
+
+func (r moRef) xml(name string) string {
+	return fmt.Sprintf(`<%s type="%s">%s</%s>`, name, escape(r.Type), escape(r.Value), name)
+}
+
+func (c *vimClient) login(ctx context.Context, username string, password string) error {
+	var content struct {
+		SessionManager moRef `xml:"Body>RetrieveServiceContentResponse>returnval>sessionManager"`
+		EventManager   moRef `xml:"Body>RetrieveServiceContentResponse>returnval>eventManager"`
+	}
+
+	si := moRef{Type: "ServiceInstance", Value: "ServiceInstance"}
+
+	if err := c.call(ctx, "RetrieveServiceContent", si.xml("_this"), &content); err != nil {
+		return err
+	}
+
+	if content.EventManager.Value == "" {
+		return errors.New("the endpoint does not expose an event manager")
+	}
+
+	c.sessionManager = content.SessionManager.Value
+	c.eventManager = content.EventManager.Value
+
+	sm := moRef{Type: "SessionManager", Value: c.sessionManager}
+	args := sm.xml("_this") + "<userName>" + escape(username) + "</userName><password>" + escape(password) + "</password>"
+
+	return c.call(ctx, "Login", args, nil)
+}
+
+func (c *vimClient) logout(ctx context.Context) error {
+	sm := moRef{Type: "SessionManager", Value: c.sessionManager}
+	return c.call(ctx, "Logout", sm.xml("_this"), nil)
+}
+
+// createCollector returns an event history collector for the events created after since (and before until, if not zero).
+func (c *vimClient) createCollector(ctx context.Context, since time.Time, until time.Time) (moRef, error) {
+	var ret struct {
+		Collector moRef `xml:"Body>CreateCollectorForEventsResponse>returnval"`
+	}
+
+	filter := "<time><beginTime>" + since.UTC().Format(time.RFC3339Nano) + "</beginTime>"
+	if !until.IsZero() {
+		filter += "<endTime>" + until.UTC().Format(time.RFC3339Nano) + "</endTime>"
+	}
+
+	filter += "</time>"
+
+	em := moRef{Type: "EventManager", Value: c.eventManager}
+
+	if err := c.call(ctx, "CreateCollectorForEvents", em.xml("_this")+"<filter>"+filter+"</filter>", &ret); err != nil {
+		return moRef{}, err
+	}
+
+	return ret.Collector, nil
+}
+
+func (c *vimClient) readNextEvents(ctx context.Context, collector moRef, maxCount int) ([]vimEvent, error) {
+	var ret struct {
+		Events []vimEvent `xml:"Body>ReadNextEventsResponse>returnval"`
+	}
+
+	args := collector.xml("_this") + fmt.Sprintf("<maxCount>%d</maxCount>", maxCount)
+
+	if err := c.call(ctx, "ReadNextEvents", args, &ret); err != nil {
+		return nil, err
+	}
+
+	return ret.Events, nil
+}
+
+func (c *vimClient) destroyCollector(ctx context.Context, collector moRef) error {
+	return c.call(ctx, "DestroyCollector", collector.xml("_this"), nil)
+}
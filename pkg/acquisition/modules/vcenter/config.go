@@ -0,0 +1,137 @@
+package vcenteracquisition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+const (
+	defaultPollInterval = 10 * time.Second
+	defaultBatchSize    = 100
+	defaultAPIVersion   = "8.0"
+)
+
+type Configuration struct {
+	configuration.DataSourceCommonCfg `yaml:",inline"`
+
+	URL                string        `yaml:"url"` // SDK endpoint, i.e. https://vcenter.example.com/sdk
+	Username           string        `yaml:"username"`
+	Password           string        `yaml:"password"`
+	InsecureSkipVerify bool          `yaml:"insecure_skip_verify"`
+	APIVersion         string        `yaml:"api_version"`
+	PollInterval       time.Duration `yaml:"poll_interval"`
+	BatchSize          int           `yaml:"batch_size"`
+	Since              time.Duration `yaml:"since"`       // only used in cat mode
+	EventTypes         []string      `yaml:"event_types"` // if set, only forward these event types
+}
+
+func ConfigurationFromYAML(y []byte) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.UnmarshalWithOptions(y, &cfg, yaml.Strict()); err != nil {
+		return cfg, fmt.Errorf("cannot parse: %s", yaml.FormatError(err, false, false))
+	}
+
+	cfg.SetDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Configuration) SetDefaults() {
+	if c.Mode == "" {
+		c.Mode = configuration.TAIL_MODE
+	}
+
+	if c.APIVersion == "" {
+		c.APIVersion = defaultAPIVersion
+	}
+
+	if c.PollInterval == 0 {
+		c.PollInterval = defaultPollInterval
+	}
+
+	if c.BatchSize == 0 {
+		c.BatchSize = defaultBatchSize
+	}
+
+	if c.Since == 0 {
+		c.Since = 24 * time.Hour
+	}
+}
+
+func (c *Configuration) Validate() error {
+	if c.URL == "" {
+		return errors.New("url is required")
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid url scheme '%s': must be http or https", u.Scheme)
+	}
+
+	if c.Username == "" {
+		return errors.New("username is required")
+	}
+
+	if c.Password == "" {
+		return errors.New("password is required")
+	}
+
+	if c.PollInterval < 0 {
+		return errors.New("poll_interval must be positive")
+	}
+
+	if c.BatchSize < 0 || c.BatchSize > 1000 {
+		return fmt.Errorf("invalid batch_size %d: must be between 1 and 1000", c.BatchSize)
+	}
+
+	switch c.Mode {
+	case configuration.TAIL_MODE, configuration.CAT_MODE:
+	default:
+		return fmt.Errorf("unsupported mode %s for vcenter datasource", c.Mode)
+	}
+
+	return nil
+}
+
+func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
+	cfg, err := ConfigurationFromYAML(yamlConfig)
+	if err != nil {
+		return err
+	}
+
+	s.config = cfg
+
+	u, _ := url.Parse(s.config.URL)
+	s.src = u.Host
+
+	return nil
+}
+
+func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
+	if err := s.UnmarshalConfig(yamlConfig); err != nil {
+		return err
+	}
+
+	s.logger = logger.WithField("src", s.src)
+	s.metricsLevel = metricsLevel
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package vcenteracquisition
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// eventCategories maps the vSphere event types to the categories used by the hub's virtualization scenarios.
+var eventCategories = map[string]string{
+	"BadUsernameSessionEvent":                      "auth_failure",
+	"NoAccessUserEvent":                            "auth_failure",
+	"com.vmware.sso.LoginFailure":                  "auth_failure",
+	"esx.audit.account.locked":                     "auth_failure",
+	"UserLoginSessionEvent":                        "auth_success",
+	"com.vmware.sso.LoginSuccess":                  "auth_success",
+	"UserLogoutSessionEvent":                       "logout",
+	"AccountCreatedEvent":                          "admin_operation",
+	"AccountRemovedEvent":                          "admin_operation",
+	"AccountUpdatedEvent":                          "admin_operation",
+	"PermissionAddedEvent":                         "admin_operation",
+	"PermissionRemovedEvent":                       "admin_operation",
+	"PermissionUpdatedEvent":                       "admin_operation",
+	"RoleAddedEvent":                               "admin_operation",
+	"RoleRemovedEvent":                             "admin_operation",
+	"RoleUpdatedEvent":                             "admin_operation",
+	"GlobalMessageChangedEvent":                    "admin_operation",
+	"LicenseEvent":                                 "admin_operation",
+	"HostAddedEvent":                               "admin_operation",
+	"HostRemovedEvent":                             "admin_operation",
+	"VmCreatedEvent":                               "admin_operation",
+	"VmRemovedEvent":                               "admin_operation",
+	"VmReconfiguredEvent":                          "admin_operation",
+	"esx.audit.ssh.enabled":                        "admin_operation",
+	"esx.audit.shell.enabled":                      "admin_operation",
+	"esx.audit.lockdownmode.disabled":              "admin_operation",
+	"com.vmware.vc.guestOperations.GuestOperation": "admin_operation",
+}
+
+// vcenterEvent is the JSON document sent to the parsers.
+type vcenterEvent struct {
+	Type            string            `json:"type"`
+	Category        string            `json:"category,omitempty"`
+	Key             int64             `json:"key"`
+	ChainID         int64             `json:"chain_id,omitempty"`
+	CreatedTime     time.Time         `json:"created_time"`
+	User            string            `json:"user,omitempty"`
+	IPAddress       string            `json:"ip_address,omitempty"`
+	UserAgent       string            `json:"user_agent,omitempty"`
+	Datacenter      string            `json:"datacenter,omitempty"`
+	ComputeResource string            `json:"compute_resource,omitempty"`
+	Host            string            `json:"host,omitempty"`
+	VM              string            `json:"vm,omitempty"`
+	Message         string            `json:"message,omitempty"`
+	Arguments       map[string]string `json:"arguments,omitempty"`
+}
+
+func newVCenterEvent(e vimEvent) vcenterEvent {
+	// extended events (EventEx, ExtendedEvent) carry their actual type in eventTypeId
+	evtType := e.EventTypeID
+	if evtType == "" {
+		_, evtType, _ = strings.Cut(e.Type, ":")
+		if evtType == "" {
+			evtType = e.Type
+		}
+	}
+
+	ret := vcenterEvent{
+		Type:            evtType,
+		Category:        eventCategories[evtType],
+		Key:             e.Key,
+		ChainID:         e.ChainID,
+		CreatedTime:     e.CreatedTime,
+		User:            e.UserName,
+		IPAddress:       e.IPAddress,
+		UserAgent:       e.UserAgent,
+		Datacenter:      e.Datacenter,
+		ComputeResource: e.ComputeResource,
+		Host:            e.Host,
+		VM:              e.VM,
+		Message:         strings.TrimSpace(e.FullFormattedMessage),
+	}
+
+	if len(e.Arguments) > 0 {
+		ret.Arguments = make(map[string]string, len(e.Arguments))
+		for _, arg := range e.Arguments {
+			ret.Arguments[arg.Key] = arg.Value
+		}
+	}
+
+	// SSO login events have the client address in their arguments
+	if ret.IPAddress == "" && ret.Arguments != nil {
+		ret.IPAddress = ret.Arguments["ipAddress"]
+	}
+
+	return ret
+}
+
+func (s *Source) sendEvent(e vimEvent, out chan pipeline.Event) {
+	evt := newVCenterEvent(e)
+
+	if len(s.config.EventTypes) > 0 && !slices.Contains(s.config.EventTypes, evt.Type) {
+		s.logger.Tracef("skipping event %d of type %s", evt.Key, evt.Type)
+		return
+	}
+
+	raw, err := json.Marshal(evt)
+	if err != nil {
+		s.logger.Errorf("unable to serialize event %d: %s", evt.Key, err)
+		return
+	}
+
+	if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+		metrics.VCenterDataSourceEventsRead.With(prometheus.Labels{"source": s.src, "datasource_type": ModuleName, "acquis_type": s.config.Labels["type"]}).Inc()
+	}
+
+	line := pipeline.Line{
+		Raw:     string(raw),
+		Src:     s.src,
+		Time:    evt.CreatedTime,
+		Labels:  s.config.Labels,
+		Process: true,
+		Module:  s.GetName(),
+	}
+
+	pevt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+	pevt.Line = line
+
+	out <- pevt
+}
+
+func (s *Source) OneShot(ctx context.Context, out chan pipeline.Event) error {
+	now := time.Now().UTC()
+	err := s.readEvents(ctx, now.Add(-s.config.Since), now, out)
+	s.logger.Debug("Oneshot acquisition is done")
+
+	return err
+}
+
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	return s.readEvents(ctx, time.Now().UTC(), time.Time{}, out)
+}
+
+// readEvents reads the events between since and until. If until is zero, it keeps polling for new events until ctx is canceled.
+func (s *Source) readEvents(ctx context.Context, since time.Time, until time.Time, out chan pipeline.Event) error {
+	client, err := newVimClient(s.config.URL, s.config.APIVersion, s.config.InsecureSkipVerify)
+	if err != nil {
+		return err
+	}
+
+	if err := client.login(ctx, s.config.Username, s.config.Password); err != nil {
+		return fmt.Errorf("unable to login to %s: %w", s.src, err)
+	}
+
+	collector, err := client.createCollector(ctx, since, until)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// use a fresh context, ctx is likely canceled at this point
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := client.destroyCollector(cleanupCtx, collector); err != nil {
+			s.logger.Debugf("unable to destroy event collector: %s", err)
+		}
+
+		if err := client.logout(cleanupCtx); err != nil {
+			s.logger.Debugf("unable to logout: %s", err)
+		}
+	}()
+
+	s.logger.Infof("Reading events since %s", since)
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		events, err := client.readNextEvents(ctx, collector, s.config.BatchSize)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		for _, e := range events {
+			s.sendEvent(e, out)
+		}
+
+		if len(events) == s.config.BatchSize {
+			// there might be more, don't wait
+			continue
+		}
+
+		if !until.IsZero() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Datasource stopping")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package vcenteracquisition
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/sourcetest"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const soapEnvelope = `<?xml version="1.0" encoding="UTF-8"?>
+<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+<soapenv:Body>%s</soapenv:Body></soapenv:Envelope>`
+
+const serviceContent = `<RetrieveServiceContentResponse xmlns="urn:vim25"><returnval>
+<sessionManager type="SessionManager">SessionManager</sessionManager>
+<eventManager type="EventManager">EventManager</eventManager>
+</returnval></RetrieveServiceContentResponse>`
+
+const eventBatch = `<ReadNextEventsResponse xmlns="urn:vim25">
+<returnval xsi:type="BadUsernameSessionEvent">
+  <key>101</key><chainId>101</chainId><createdTime>2025-01-02T03:04:05.123Z</createdTime>
+  <userName>root</userName><fullFormattedMessage>Cannot login root@10.0.0.1</fullFormattedMessage>
+  <ipAddress>10.0.0.1</ipAddress>
+</returnval>
+<returnval xsi:type="EventEx">
+  <key>102</key><chainId>102</chainId><createdTime>2025-01-02T03:04:06Z</createdTime>
+  <userName>VSPHERE.LOCAL\admin</userName>
+  <datacenter><datacenter type="Datacenter">datacenter-1</datacenter><name>DC1</name></datacenter>
+  <eventTypeId>com.vmware.sso.LoginFailure</eventTypeId>
+  <arguments><key>ipAddress</key><value xsi:type="xsd:string">10.0.0.2</value></arguments>
+</returnval>
+<returnval xsi:type="VmRemovedEvent">
+  <key>103</key><chainId>103</chainId><createdTime>2025-01-02T03:04:07Z</createdTime>
+  <userName>VSPHERE.LOCAL\admin</userName>
+  <host><host type="HostSystem">host-1</host><name>esx1</name></host>
+  <vm><vm type="VirtualMachine">vm-1</vm><name>web01</name></vm>
+</returnval>
+</ReadNextEventsResponse>`
+
+// fakeVCenter answers the SOAP calls made by the datasource, returning eventBatch once.
+type fakeVCenter struct {
+	mu       sync.Mutex
+	calls    []string
+	sent     bool
+	password string
+}
+
+func (f *fakeVCenter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	b := string(body)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reply := func(s string) {
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = io.WriteString(w, strings.Replace(soapEnvelope, "%s", s, 1))
+	}
+
+	switch {
+	case strings.Contains(b, "<RetrieveServiceContent "):
+		f.calls = append(f.calls, "RetrieveServiceContent")
+		reply(serviceContent)
+	case strings.Contains(b, "<Login "):
+		f.calls = append(f.calls, "Login")
+
+		if !strings.Contains(b, "<password>"+f.password+"</password>") {
+			w.WriteHeader(http.StatusInternalServerError)
+			reply(`<soapenv:Fault><faultcode>ServerFaultCode</faultcode><faultstring>Cannot complete login due to an incorrect user name or password.</faultstring></soapenv:Fault>`)
+
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{Name: "vmware_soap_session", Value: "abc"})
+		reply(`<LoginResponse xmlns="urn:vim25"><returnval><key>abc</key></returnval></LoginResponse>`)
+	case strings.Contains(b, "<CreateCollectorForEvents "):
+		f.calls = append(f.calls, "CreateCollectorForEvents")
+		reply(`<CreateCollectorForEventsResponse xmlns="urn:vim25"><returnval type="EventHistoryCollector">session[1]1</returnval></CreateCollectorForEventsResponse>`)
+	case strings.Contains(b, "<ReadNextEvents "):
+		f.calls = append(f.calls, "ReadNextEvents")
+
+		if f.sent {
+			reply(`<ReadNextEventsResponse xmlns="urn:vim25"></ReadNextEventsResponse>`)
+			return
+		}
+
+		f.sent = true
+
+		reply(eventBatch)
+	default:
+		f.calls = append(f.calls, "other")
+		reply("")
+	}
+}
+
+func newTestSource(t *testing.T, url string, extra string) *Source {
+	t.Helper()
+
+	s := &Source{}
+	sourcetest.Configure(t, s, `source: vcenter
+labels:
+  type: vcenter
+url: `+url+`
+username: admin
+password: secret`, extra)
+
+	return s
+}
+
+func TestConfigure(t *testing.T) {
+	base := "source: vcenter\nurl: https://vcenter.example.com/sdk\nusername: admin\npassword: secret\n"
+
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "unknown field", extra: "foobar: 42", wantErr: `unknown field "foobar"`},
+		{name: "no url", extra: "url: ''", wantErr: "url is required"},
+		{name: "url scheme", extra: "url: ftp://vcenter.example.com", wantErr: "invalid url scheme 'ftp': must be http or https"},
+		{name: "no username", extra: "username: ''", wantErr: "username is required"},
+		{name: "no password", extra: "password: ''", wantErr: "password is required"},
+		{name: "poll_interval", extra: "poll_interval: -1s", wantErr: "poll_interval must be positive"},
+		{name: "batch_size", extra: "batch_size: 5000", wantErr: "invalid batch_size 5000: must be between 1 and 1000"},
+		{name: "mode", extra: "mode: foo", wantErr: "unsupported mode foo for vcenter datasource"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Source{}
+			cfg := sourcetest.MergeConfig(t, base, tc.extra)
+			err := s.Configure(t.Context(), []byte(cfg), log.WithField("type", ModuleName), metrics.AcquisitionMetricsLevelNone)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestOneShot(t *testing.T) {
+	fake := &fakeVCenter{password: "secret"}
+	s := newTestSource(t, sourcetest.NewServer(t, fake)+"/sdk", "mode: cat\n")
+
+	var events []vcenterEvent
+
+	for _, evt := range sourcetest.OneShot(t, s) {
+		var e vcenterEvent
+		require.NoError(t, json.Unmarshal([]byte(evt.Line.Raw), &e))
+		assert.Equal(t, ModuleName, evt.Line.Module)
+		assert.Equal(t, e.CreatedTime, evt.Line.Time)
+		events = append(events, e)
+	}
+
+	require.Len(t, events, 3)
+
+	assert.Equal(t, "BadUsernameSessionEvent", events[0].Type)
+	assert.Equal(t, "auth_failure", events[0].Category)
+	assert.Equal(t, "root", events[0].User)
+	assert.Equal(t, "10.0.0.1", events[0].IPAddress)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 123000000, time.UTC), events[0].CreatedTime)
+
+	assert.Equal(t, "com.vmware.sso.LoginFailure", events[1].Type)
+	assert.Equal(t, "auth_failure", events[1].Category)
+	assert.Equal(t, "10.0.0.2", events[1].IPAddress)
+	assert.Equal(t, "DC1", events[1].Datacenter)
+
+	assert.Equal(t, "VmRemovedEvent", events[2].Type)
+	assert.Equal(t, "admin_operation", events[2].Category)
+	assert.Equal(t, "esx1", events[2].Host)
+	assert.Equal(t, "web01", events[2].VM)
+
+	assert.Equal(t, []string{"RetrieveServiceContent", "Login", "CreateCollectorForEvents", "ReadNextEvents", "other", "other"}, fake.calls)
+}
+
+func TestEventTypesFilter(t *testing.T) {
+	s := newTestSource(t, sourcetest.NewServer(t, &fakeVCenter{password: "secret"})+"/sdk", "mode: cat\nevent_types: [VmRemovedEvent]\n")
+
+	events := sourcetest.OneShot(t, s)
+	require.Len(t, events, 1)
+	assert.Contains(t, events[0].Line.Raw, `"type":"VmRemovedEvent"`)
+}
+
+func TestLoginFailure(t *testing.T) {
+	url := sourcetest.NewServer(t, &fakeVCenter{password: "other"})
+	s := newTestSource(t, url+"/sdk", "")
+
+	err := s.Stream(t.Context(), make(chan pipeline.Event))
+	cstest.RequireErrorContains(t, err, "unable to login to "+strings.TrimPrefix(url, "http://")+": Login: Cannot complete login due to an incorrect user name or password.")
+}
+
+func TestStream(t *testing.T) {
+	s := newTestSource(t, sourcetest.NewServer(t, &fakeVCenter{password: "secret"})+"/sdk", "poll_interval: 50ms\n")
+
+	events := sourcetest.Stream(t, s, 3)
+	assert.Contains(t, events[2].Line.Raw, `"type":"VmRemovedEvent"`)
+}
@@ -0,0 +1,5 @@
+//go:build !no_datasource_webstatus
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/webstatus" // register the datasource
@@ -0,0 +1,223 @@
+package webstatusacquisition
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// the status pages are a few hundred bytes, the scoreboard of mod_status grows with the workers
+const maxStatusSize = 1 << 20
+
+// snapshot is the state of the server, as read on its status page.
+type snapshot struct {
+	requests uint64 // since the start of the server
+	kBytes   uint64 // since the start of the server, only with the ExtendedStatus of apache
+	hasBytes bool
+	active   int // open connections, or busy workers for the apache MPMs without ConnsTotal
+
+	nginx  *nginxConnections
+	apache *apacheWorkers
+}
+
+type nginxConnections struct {
+	Reading int `json:"reading"`
+	Writing int `json:"writing"`
+	Waiting int `json:"waiting"`
+}
+
+type apacheWorkers struct {
+	Busy int `json:"busy"`
+	Idle int `json:"idle"`
+}
+
+type statusClient struct {
+	http     *http.Client
+	url      string
+	server   string
+	username string
+	password string
+}
+
+func newStatusClient(cfg *Configuration) *statusClient {
+	statusURL := cfg.URL
+
+	// the machine readable version of the page
+	if cfg.Server == serverApache {
+		if u, err := url.Parse(cfg.URL); err == nil && !u.Query().Has("auto") {
+			q := u.Query()
+			q.Set("auto", "")
+			u.RawQuery = q.Encode()
+			statusURL = u.String()
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	}
+
+	return &statusClient{
+		http: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.Timeout,
+		},
+		url:      statusURL,
+		server:   cfg.Server,
+		username: cfg.Username,
+		password: cfg.Password,
+	}
+}
+
+func (c *statusClient) scrape(ctx context.Context) (*snapshot, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", req.URL.Redacted(), resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxStatusSize))
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %w", req.URL.Redacted(), err)
+	}
+
+	if c.server == serverApache {
+		return parseApacheStatus(body)
+	}
+
+	return parseNginxStatus(body)
+}
+
+// parseNginxStatus reads the page of stub_status:
+//
+//	Active connections: 291
+//	server accepts handled requests
+//	 16630948 16630948 31070465
+//	Reading: 6 Writing: 179 Waiting: 106
+func parseNginxStatus(body []byte) (*snapshot, error) {
+	f := strings.Fields(string(body))
+
+	if len(f) != 16 || f[0] != "Active" || f[1] != "connections:" || f[3] != "server" ||
+		f[10] != "Reading:" || f[12] != "Writing:" || f[14] != "Waiting:" {
+		return nil, errors.New("unexpected content, is it the page of stub_status?")
+	}
+
+	ints := make([]uint64, 0, 6)
+
+	for _, idx := range []int{2, 9, 11, 13, 15} {
+		v, err := strconv.ParseUint(f[idx], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", f[idx])
+		}
+
+		ints = append(ints, v)
+	}
+
+	return &snapshot{
+		requests: ints[1],
+		active:   int(ints[0]),
+		nginx: &nginxConnections{
+			Reading: int(ints[2]),
+			Writing: int(ints[3]),
+			Waiting: int(ints[4]),
+		},
+	}, nil
+}
+
+// parseApacheStatus reads the page of mod_status, with ?auto: one "Key: value" per line.
+func parseApacheStatus(body []byte) (*snapshot, error) {
+	values := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 4096), maxStatusSize)
+
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	number := func(key string) (uint64, bool, error) {
+		raw, ok := values[key]
+		if !ok {
+			return 0, false, nil
+		}
+
+		v, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid %s '%s'", key, raw)
+		}
+
+		return v, true, nil
+	}
+
+	ret := &snapshot{apache: &apacheWorkers{}}
+
+	// only with ExtendedStatus, the default since apache 2.3.6
+	requests, ok, err := number("Total Accesses")
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, errors.New("no Total Accesses, is it the page of mod_status with ExtendedStatus on?")
+	}
+
+	ret.requests = requests
+
+	if ret.kBytes, ret.hasBytes, err = number("Total kBytes"); err != nil {
+		return nil, err
+	}
+
+	busy, _, err := number("BusyWorkers")
+	if err != nil {
+		return nil, err
+	}
+
+	idle, _, err := number("IdleWorkers")
+	if err != nil {
+		return nil, err
+	}
+
+	ret.apache.Busy = int(busy)
+	ret.apache.Idle = int(idle)
+	ret.active = int(busy)
+
+	// event MPM
+	conns, ok, err := number("ConnsTotal")
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		ret.active = int(conns)
+	}
+
+	return ret, nil
+}
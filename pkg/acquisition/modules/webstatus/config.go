@@ -0,0 +1,134 @@
+package webstatusacquisition
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	yaml "github.com/goccy/go-yaml"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+)
+
+const (
+	serverNginx  = "nginx"  // stub_status
+	serverApache = "apache" // mod_status
+
+	defaultPollInterval = 10 * time.Second
+	defaultTimeout      = 5 * time.Second
+)
+
+type Configuration struct {
+	configuration.DataSourceCommonCfg `yaml:",inline"`
+
+	Server string `yaml:"server"` // nginx or apache
+	// e.g. http://127.0.0.1/nginx_status, or http://127.0.0.1/server-status (?auto is added)
+	URL string `yaml:"url"`
+	// basic authentication, if the status page is protected
+	Username           string        `yaml:"username"`
+	Password           string        `yaml:"password"`
+	InsecureSkipVerify bool          `yaml:"insecure_skip_verify"`
+	PollInterval       time.Duration `yaml:"poll_interval"`
+	Timeout            time.Duration `yaml:"timeout"`
+}
+
+func ConfigurationFromYAML(y []byte) (Configuration, error) {
+	var cfg Configuration
+
+	if err := yaml.UnmarshalWithOptions(y, &cfg, yaml.Strict()); err != nil {
+		return cfg, fmt.Errorf("cannot parse: %s", yaml.FormatError(err, false, false))
+	}
+
+	cfg.SetDefaults()
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Configuration) SetDefaults() {
+	if c.Mode == "" {
+		c.Mode = configuration.TAIL_MODE
+	}
+
+	if c.PollInterval == 0 {
+		c.PollInterval = defaultPollInterval
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = min(defaultTimeout, c.PollInterval)
+	}
+}
+
+func (c *Configuration) Validate() error {
+	switch c.Server {
+	case serverNginx, serverApache:
+	case "":
+		return errors.New("server is required")
+	default:
+		return fmt.Errorf("unsupported server '%s': must be nginx or apache", c.Server)
+	}
+
+	if c.URL == "" {
+		return errors.New("url is required")
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid url scheme '%s': must be http or https", u.Scheme)
+	}
+
+	if c.Password != "" && c.Username == "" {
+		return errors.New("username is required with password")
+	}
+
+	if c.PollInterval < 0 {
+		return errors.New("poll_interval must be positive")
+	}
+
+	if c.Timeout < 0 || c.Timeout > c.PollInterval {
+		return errors.New("timeout must be positive and at most poll_interval")
+	}
+
+	// the status pages only give the current counters, the rates are computed between two polls
+	if c.Mode != configuration.TAIL_MODE {
+		return fmt.Errorf("unsupported mode %s for webstatus datasource", c.Mode)
+	}
+
+	return nil
+}
+
+func (s *Source) UnmarshalConfig(yamlConfig []byte) error {
+	cfg, err := ConfigurationFromYAML(yamlConfig)
+	if err != nil {
+		return err
+	}
+
+	s.config = cfg
+
+	u, _ := url.Parse(s.config.URL)
+	s.src = u.Host
+
+	return nil
+}
+
+func (s *Source) Configure(_ context.Context, yamlConfig []byte, logger *log.Entry, metricsLevel metrics.AcquisitionMetricsLevel) error {
+	if err := s.UnmarshalConfig(yamlConfig); err != nil {
+		return err
+	}
+
+	s.logger = logger.WithFields(log.Fields{"server": s.config.Server, "src": s.src})
+	s.metricsLevel = metricsLevel
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package webstatusacquisition
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// statusEvent is the JSON document sent to the parsers: the activity of the whole server between
+// two polls, to compare the rate of a single IP with.
+type statusEvent struct {
+	Server            string            `json:"server"`   // nginx or apache
+	Instance          string            `json:"instance"` // host of the status page
+	Time              time.Time         `json:"time"`
+	Interval          float64           `json:"interval"` // seconds since the previous poll
+	Requests          uint64            `json:"requests"` // since the previous poll
+	RequestRate       float64           `json:"request_rate"`
+	ByteRate          *float64          `json:"byte_rate,omitempty"`
+	ActiveConnections int               `json:"active_connections"`
+	Nginx             *nginxConnections `json:"nginx,omitempty"`
+	Apache            *apacheWorkers    `json:"apache,omitempty"`
+}
+
+type scrape struct {
+	snap *snapshot
+	time time.Time
+}
+
+// newStatusEvent returns the activity between two polls, or false if the counters went backwards:
+// the server was restarted or reloaded and cur is the new reference.
+func (s *Source) newStatusEvent(prev scrape, cur scrape) (statusEvent, bool) {
+	if cur.snap.requests < prev.snap.requests || cur.snap.kBytes < prev.snap.kBytes {
+		return statusEvent{}, false
+	}
+
+	interval := cur.time.Sub(prev.time).Seconds()
+	if interval <= 0 {
+		return statusEvent{}, false
+	}
+
+	evt := statusEvent{
+		Server:            s.config.Server,
+		Instance:          s.src,
+		Time:              cur.time,
+		Interval:          interval,
+		Requests:          cur.snap.requests - prev.snap.requests,
+		ActiveConnections: cur.snap.active,
+		Nginx:             cur.snap.nginx,
+		Apache:            cur.snap.apache,
+	}
+
+	evt.RequestRate = float64(evt.Requests) / interval
+
+	if cur.snap.hasBytes && prev.snap.hasBytes {
+		evt.ByteRate = new(float64(cur.snap.kBytes-prev.snap.kBytes) * 1024 / interval)
+	}
+
+	return evt, true
+}
+
+func (s *Source) sendEvent(evt statusEvent, out chan pipeline.Event) {
+	raw, err := json.Marshal(evt)
+	if err != nil {
+		s.logger.Errorf("unable to serialize event: %s", err)
+		return
+	}
+
+	if s.metricsLevel != metrics.AcquisitionMetricsLevelNone {
+		metrics.WebStatusDataSourceEventsRead.With(prometheus.Labels{"source": s.src, "datasource_type": ModuleName, "acquis_type": s.config.Labels["type"]}).Inc()
+	}
+
+	line := pipeline.Line{
+		Raw:     string(raw),
+		Src:     s.src,
+		Time:    evt.Time,
+		Labels:  s.config.Labels,
+		Process: true,
+		Module:  s.GetName(),
+	}
+
+	pevt := pipeline.MakeEvent(s.config.UseTimeMachine, pipeline.LOG, true)
+	pevt.Line = line
+
+	out <- pevt
+}
+
+// Stream polls the status page and sends the activity between two polls. The first poll is the
+// reference: the counters only make sense as a difference.
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	client := newStatusClient(&s.config)
+
+	poll := func() (scrape, error) {
+		snap, err := client.scrape(ctx)
+		if err != nil {
+			return scrape{}, fmt.Errorf("unable to read the status page of %s: %w", s.config.Server, err)
+		}
+
+		return scrape{snap: snap, time: time.Now().UTC()}, nil
+	}
+
+	prev, err := poll()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return err
+	}
+
+	s.logger.Infof("Polling the status page every %s", s.config.PollInterval)
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Datasource stopping")
+			return nil
+		case <-ticker.C:
+		}
+
+		cur, err := poll()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		if evt, ok := s.newStatusEvent(prev, cur); ok {
+			s.sendEvent(evt, out)
+		} else {
+			s.logger.Info("The counters were reset, the server was restarted")
+		}
+
+		prev = cur
+	}
+}
@@ -0,0 +1,237 @@
+package webstatusacquisition
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/sourcetest"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const nginxStatus = `Active connections: 291
+server accepts handled requests
+ 16630948 16630948 31070465
+Reading: 6 Writing: 179 Waiting: 106
+`
+
+const apacheStatus = `localhost
+ServerVersion: Apache/2.4.62 (Unix)
+ServerMPM: event
+Total Accesses: 1520
+Total kBytes: 4096
+Uptime: 3600
+BusyWorkers: 3
+IdleWorkers: 72
+ConnsTotal: 12
+Scoreboard: __W_K___R_______
+`
+
+func TestParseNginxStatus(t *testing.T) {
+	snap, err := parseNginxStatus([]byte(nginxStatus))
+	require.NoError(t, err)
+
+	assert.Equal(t, &snapshot{
+		requests: 31070465,
+		active:   291,
+		nginx:    &nginxConnections{Reading: 6, Writing: 179, Waiting: 106},
+	}, snap)
+
+	_, err = parseNginxStatus([]byte("<html>Welcome to nginx!</html>"))
+	cstest.RequireErrorContains(t, err, "unexpected content, is it the page of stub_status?")
+
+	_, err = parseNginxStatus([]byte("Active connections: 1\nserver accepts handled requests\n 1 1 x\nReading: 0 Writing: 1 Waiting: 0\n"))
+	cstest.RequireErrorContains(t, err, "invalid number 'x'")
+}
+
+func TestParseApacheStatus(t *testing.T) {
+	snap, err := parseApacheStatus([]byte(apacheStatus))
+	require.NoError(t, err)
+
+	assert.Equal(t, &snapshot{
+		requests: 1520,
+		kBytes:   4096,
+		hasBytes: true,
+		active:   12,
+		apache:   &apacheWorkers{Busy: 3, Idle: 72},
+	}, snap)
+
+	// prefork, without ConnsTotal
+	snap, err = parseApacheStatus([]byte("Total Accesses: 10\nBusyWorkers: 4\nIdleWorkers: 6\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, snap.active)
+	assert.False(t, snap.hasBytes)
+
+	_, err = parseApacheStatus([]byte("BusyWorkers: 4\nIdleWorkers: 6\n"))
+	cstest.RequireErrorContains(t, err, "no Total Accesses, is it the page of mod_status with ExtendedStatus on?")
+
+	_, err = parseApacheStatus([]byte("Total Accesses: -1\n"))
+	cstest.RequireErrorContains(t, err, "invalid Total Accesses '-1'")
+}
+
+func TestNewStatusEvent(t *testing.T) {
+	s := &Source{config: Configuration{Server: serverApache}, src: "127.0.0.1"}
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	prev := scrape{snap: &snapshot{requests: 100, kBytes: 10, hasBytes: true}, time: now}
+	cur := scrape{
+		snap: &snapshot{requests: 300, kBytes: 30, hasBytes: true, active: 5, apache: &apacheWorkers{Busy: 5, Idle: 10}},
+		time: now.Add(10 * time.Second),
+	}
+
+	evt, ok := s.newStatusEvent(prev, cur)
+	require.True(t, ok)
+
+	assert.Equal(t, statusEvent{
+		Server:            "apache",
+		Instance:          "127.0.0.1",
+		Time:              cur.time,
+		Interval:          10,
+		Requests:          200,
+		RequestRate:       20,
+		ByteRate:          new(2048.0),
+		ActiveConnections: 5,
+		Apache:            &apacheWorkers{Busy: 5, Idle: 10},
+	}, evt)
+
+	// the server was restarted
+	_, ok = s.newStatusEvent(cur, prev)
+	assert.False(t, ok)
+}
+
+// fakeStatus serves a status page of nginx, with the requests counter growing by 50 on each call.
+type fakeStatus struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeStatus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if user, pass, ok := r.BasicAuth(); !ok || user != "crowdsec" || pass != "secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	f.calls++
+
+	fmt.Fprintf(w, "Active connections: 2\nserver accepts handled requests\n %d %d %d\nReading: 0 Writing: 1 Waiting: 1\n",
+		f.calls, f.calls, f.calls*50)
+}
+
+func newTestSource(t *testing.T, url string, password string) *Source {
+	t.Helper()
+
+	s := &Source{}
+	sourcetest.Configure(t, s, `source: webstatus
+labels:
+  type: webstatus
+server: nginx
+poll_interval: 50ms
+username: crowdsec
+url: `+url, "password: "+password)
+
+	return s
+}
+
+func TestConfigure(t *testing.T) {
+	base := "source: webstatus\nserver: nginx\nurl: http://127.0.0.1/nginx_status\n"
+
+	tests := []struct {
+		name    string
+		extra   string
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "apache", extra: "server: apache\nurl: http://127.0.0.1/server-status"},
+		{name: "unknown field", extra: "foobar: 42", wantErr: `unknown field "foobar"`},
+		{name: "no server", extra: "server: ''", wantErr: "server is required"},
+		{name: "server", extra: "server: iis", wantErr: "unsupported server 'iis': must be nginx or apache"},
+		{name: "no url", extra: "url: ''", wantErr: "url is required"},
+		{name: "url scheme", extra: "url: ftp://127.0.0.1/nginx_status", wantErr: "invalid url scheme 'ftp': must be http or https"},
+		{name: "password without username", extra: "password: secret", wantErr: "username is required with password"},
+		{name: "poll_interval", extra: "poll_interval: -1s", wantErr: "poll_interval must be positive"},
+		{name: "timeout", extra: "poll_interval: 10s\ntimeout: 20s", wantErr: "timeout must be positive and at most poll_interval"},
+		{name: "mode", extra: "mode: cat", wantErr: "unsupported mode cat for webstatus datasource"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Source{}
+			cfg := sourcetest.MergeConfig(t, base, tc.extra)
+			err := s.Configure(t.Context(), []byte(cfg), log.WithField("type", ModuleName), metrics.AcquisitionMetricsLevelNone)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	s := newTestSource(t, sourcetest.NewServer(t, &fakeStatus{})+"/nginx_status", "secret")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	out := make(chan pipeline.Event, 10)
+	errChan := make(chan error, 1)
+
+	go func() {
+		errChan <- s.Stream(ctx, out)
+	}()
+
+	for range 2 {
+		select {
+		case evt := <-out:
+			assert.Equal(t, ModuleName, evt.Line.Module)
+			assert.Equal(t, s.src, evt.Line.Src)
+
+			status := statusEvent{}
+			require.NoError(t, json.Unmarshal([]byte(evt.Line.Raw), &status))
+			assert.Equal(t, "nginx", status.Server)
+			assert.Equal(t, uint64(50), status.Requests)
+			assert.Positive(t, status.RequestRate)
+			assert.Nil(t, status.ByteRate)
+			assert.Equal(t, 2, status.ActiveConnections)
+			assert.Equal(t, &nginxConnections{Reading: 0, Writing: 1, Waiting: 1}, status.Nginx)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for events")
+		}
+	}
+
+	cancel()
+
+	select {
+	case err := <-errChan:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("datasource did not stop")
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	url := sourcetest.NewServer(t, &fakeStatus{}) + "/nginx_status"
+	s := newTestSource(t, url, "wrong")
+
+	err := s.Stream(t.Context(), make(chan pipeline.Event))
+	cstest.RequireErrorContains(t, err, "unable to read the status page of nginx: GET "+url+": 401 Unauthorized")
+}
+
+func TestApacheURL(t *testing.T) {
+	c := newStatusClient(&Configuration{Server: serverApache, URL: "http://127.0.0.1/server-status"})
+	assert.Equal(t, "http://127.0.0.1/server-status?auto=", c.url)
+
+	c = newStatusClient(&Configuration{Server: serverApache, URL: "http://127.0.0.1/server-status?auto"})
+	assert.Equal(t, "http://127.0.0.1/server-status?auto", c.url)
+
+	c = newStatusClient(&Configuration{Server: serverNginx, URL: "http://127.0.0.1/nginx_status"})
+	assert.Equal(t, "http://127.0.0.1/nginx_status", c.url)
+}
@@ -0,0 +1,5 @@
+//go:build !no_datasource_zeek
+
+package modules
+
+import _ "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/zeek" // register the datasource
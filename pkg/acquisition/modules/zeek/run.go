@@ -0,0 +1,218 @@
+package zeekacquisition
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/internal/filepreset"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// tsvHeader holds the directives found at the beginning of a TSV log.
+type tsvHeader struct {
+	separator  string
+	emptyField string
+	unsetField string
+	path       string
+	fields     []string
+}
+
+func newTSVHeader() *tsvHeader {
+	return &tsvHeader{
+		separator:  "\t",
+		emptyField: "(empty)",
+		unsetField: "-",
+	}
+}
+
+// unescape decodes the \xNN sequences used in the header values.
+func unescape(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) && s[i+1] == 'x' {
+			if c, err := strconv.ParseUint(s[i+2:i+4], 16, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+
+				continue
+			}
+		}
+
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
+
+// parseDirective updates the header from a line starting with '#'.
+func (h *tsvHeader) parseDirective(line string) {
+	// the separator directive is always separated by a space, since the separator is not known yet
+	if value, ok := strings.CutPrefix(line, "#separator "); ok {
+		h.separator = unescape(value)
+		return
+	}
+
+	name, value, _ := strings.Cut(line[1:], h.separator)
+
+	switch name {
+	case "empty_field":
+		h.emptyField = value
+	case "unset_field":
+		h.unsetField = value
+	case "path":
+		h.path = value
+	case "fields":
+		h.fields = strings.Split(value, h.separator)
+	}
+}
+
+// logPath returns the log type (conn, notice...) from the file name, when it's not in the record.
+func logPath(src string) string {
+	name := filepath.Base(src)
+	name, _, _ = strings.Cut(name, ".")
+
+	return name
+}
+
+// parseTimestamp accepts both epoch (default) and ISO8601 (JSON::TS_ISO8601) timestamps.
+func parseTimestamp(ts string) (time.Time, bool) {
+	if epoch, err := strconv.ParseFloat(ts, 64); err == nil {
+		sec := int64(epoch)
+		return time.Unix(sec, int64((epoch-float64(sec))*1e9)).UTC().Round(time.Microsecond), true
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+		return t, true
+	}
+
+	return time.Time{}, false
+}
+
+// convertJSON fills the Parsed fields from a JSON record (json-logs policy).
+func (s *Source) convertJSON(evt *pipeline.Event) bool {
+	dec := json.NewDecoder(strings.NewReader(evt.Line.Raw))
+	dec.UseNumber()
+
+	var record map[string]any
+
+	if err := dec.Decode(&record); err != nil {
+		s.logger.Warningf("%s: invalid JSON record: %s", evt.Line.Src, err)
+		return false
+	}
+
+	filepreset.Flatten("", record, evt.Parsed)
+
+	path := evt.Parsed["_path"]
+	if path == "" {
+		path = logPath(evt.Line.Src)
+	}
+
+	evt.Parsed["zeek_log"] = path
+
+	return true
+}
+
+// convertTSV fills the Parsed fields from a TSV record, using the header of its file.
+func (s *Source) convertTSV(evt *pipeline.Event) bool {
+	line := evt.Line.Raw
+
+	if strings.HasPrefix(line, "#") {
+		header, ok := s.headers[evt.Line.Src]
+		// a new header means the file has been rotated or zeek restarted
+		if !ok || strings.HasPrefix(line, "#separator") {
+			header = newTSVHeader()
+			s.headers[evt.Line.Src] = header
+		}
+
+		header.parseDirective(line)
+
+		return false
+	}
+
+	header, ok := s.headers[evt.Line.Src]
+	if !ok || len(header.fields) == 0 {
+		s.logger.Warningf("%s: no #fields header, ignoring line", evt.Line.Src)
+		return false
+	}
+
+	values := strings.Split(line, header.separator)
+	if len(values) != len(header.fields) {
+		s.logger.Warningf("%s: expected %d fields, got %d", evt.Line.Src, len(header.fields), len(values))
+		return false
+	}
+
+	for i, name := range header.fields {
+		switch values[i] {
+		case header.unsetField:
+			continue
+		case header.emptyField:
+			evt.Parsed[name] = ""
+		default:
+			evt.Parsed[name] = values[i]
+		}
+	}
+
+	path := header.path
+	if path == "" {
+		path = logPath(evt.Line.Src)
+	}
+
+	evt.Parsed["zeek_log"] = path
+
+	return true
+}
+
+// connection fields, with the same name as in the suricata records
+var connectionFields = map[string]string{
+	"id.orig_h": "src_ip",
+	"id.orig_p": "src_port",
+	"id.resp_h": "dest_ip",
+	"id.resp_p": "dest_port",
+}
+
+func (s *Source) convert(evt *pipeline.Event) bool {
+	var ok bool
+
+	if strings.HasPrefix(evt.Line.Raw, "{") {
+		ok = s.convertJSON(evt)
+	} else {
+		ok = s.convertTSV(evt)
+	}
+
+	if !ok {
+		return false
+	}
+
+	if len(s.config.LogTypes) > 0 && !slices.Contains(s.config.LogTypes, evt.Parsed["zeek_log"]) {
+		return false
+	}
+
+	for from, to := range connectionFields {
+		if v, ok := evt.Parsed[from]; ok {
+			evt.Parsed[to] = v
+		}
+	}
+
+	if ts, ok := parseTimestamp(evt.Parsed["ts"]); ok {
+		evt.Line.Time = ts
+		evt.StrTime = ts.Format(time.RFC3339Nano)
+	}
+
+	evt.Line.Module = ModuleName
+
+	return true
+}
+
+func (s *Source) OneShot(ctx context.Context, out chan pipeline.Event) error {
+	return filepreset.OneShot(ctx, s.file, out, s.convert)
+}
+
+func (s *Source) Stream(ctx context.Context, out chan pipeline.Event) error {
+	return filepreset.Stream(ctx, s.file, out, s.convert)
+}
@@ -0,0 +1,35 @@
+package zeekacquisition
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	fileacquisition "github.com/crowdsecurity/crowdsec/pkg/acquisition/modules/file"
+)
+
+type Source struct {
+	config Configuration
+	logger *log.Entry
+	file   *fileacquisition.Source
+	// header of the TSV logs, by file
+	headers map[string]*tsvHeader
+}
+
+func (s *Source) GetUuid() string {
+	return s.config.UniqueId
+}
+
+func (s *Source) GetMode() string {
+	return s.config.Mode
+}
+
+func (*Source) GetName() string {
+	return ModuleName
+}
+
+func (*Source) CanRun() error {
+	return nil
+}
+
+func (s *Source) Dump() any {
+	return s
+}
@@ -0,0 +1,136 @@
+package zeekacquisition
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const connTSV = `#separator \x09
+#set_separator	,
+#empty_field	(empty)
+#unset_field	-
+#path	conn
+#fields	ts	uid	id.orig_h	id.orig_p	id.resp_h	id.resp_p	proto	service
+#types	time	string	addr	port	addr	port	enum	string
+1700000000.123456	CHhAvVGS1DHFjwGM9	192.168.1.10	51234	10.0.0.1	22	tcp	-
+1700000001.000000	C4J4Th3PJpwUYZZ6gc	192.168.1.11	51235	10.0.0.1	80	tcp	(empty)
+#close	2023-11-14-22-13-21
+`
+
+const noticeJSON = `{"ts":"2023-11-14T22:13:20.123456Z","_path":"notice","id.orig_h":"192.168.1.12","note":"SSH::Password_Guessing","actions":["Notice::ACTION_LOG"]}
+{"ts":1700000002.5,"id.orig_h":"192.168.1.13","uid":"CxyZ"}
+`
+
+func runOneShot(t *testing.T, cfg string) []pipeline.Event {
+	t.Helper()
+
+	ctx := t.Context()
+
+	s := &Source{}
+	err := s.Configure(ctx, []byte(cfg), log.WithField("type", ModuleName), metrics.AcquisitionMetricsLevelNone)
+	require.NoError(t, err)
+
+	out := make(chan pipeline.Event, 10)
+	require.NoError(t, s.OneShot(ctx, out))
+	close(out)
+
+	events := []pipeline.Event{}
+	for evt := range out {
+		events = append(events, evt)
+	}
+
+	return events
+}
+
+func writeLogs(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "conn.log"), []byte(connTSV), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notice.log"), []byte(noticeJSON), 0o644))
+
+	return dir
+}
+
+func TestOneShot(t *testing.T) {
+	dir := writeLogs(t)
+
+	events := runOneShot(t, fmt.Sprintf(`
+source: zeek
+mode: cat
+filenames:
+  - %s/*.log
+`, dir))
+
+	require.Len(t, events, 4)
+
+	byUID := map[string]pipeline.Event{}
+	for _, evt := range events {
+		assert.Equal(t, "zeek", evt.Line.Labels["type"])
+		assert.Equal(t, ModuleName, evt.Line.Module)
+		byUID[evt.Parsed["uid"]] = evt
+	}
+
+	conn := byUID["CHhAvVGS1DHFjwGM9"]
+	assert.Equal(t, "conn", conn.Parsed["zeek_log"])
+	assert.Equal(t, "192.168.1.10", conn.Parsed["src_ip"])
+	assert.Equal(t, "51234", conn.Parsed["src_port"])
+	assert.Equal(t, "10.0.0.1", conn.Parsed["dest_ip"])
+	assert.Equal(t, "22", conn.Parsed["dest_port"])
+	assert.NotContains(t, conn.Parsed, "service")
+	assert.Equal(t, time.Date(2023, 11, 14, 22, 13, 20, 123456000, time.UTC), conn.Line.Time)
+
+	conn = byUID["C4J4Th3PJpwUYZZ6gc"]
+	assert.Contains(t, conn.Parsed, "service")
+	assert.Empty(t, conn.Parsed["service"])
+
+	notice := byUID[""]
+	assert.Equal(t, "notice", notice.Parsed["zeek_log"])
+	assert.Equal(t, "SSH::Password_Guessing", notice.Parsed["note"])
+	assert.Equal(t, "192.168.1.12", notice.Parsed["src_ip"])
+	assert.JSONEq(t, `["Notice::ACTION_LOG"]`, notice.Parsed["actions"])
+	assert.Equal(t, time.Date(2023, 11, 14, 22, 13, 20, 123456000, time.UTC), notice.Line.Time)
+
+	// no _path in the record: the log type comes from the file name
+	other := byUID["CxyZ"]
+	assert.Equal(t, "notice", other.Parsed["zeek_log"])
+	assert.Equal(t, time.Date(2023, 11, 14, 22, 13, 22, 500000000, time.UTC), other.Line.Time)
+}
+
+func TestOneShotLogTypes(t *testing.T) {
+	dir := writeLogs(t)
+
+	events := runOneShot(t, fmt.Sprintf(`
+source: zeek
+mode: cat
+filenames:
+  - %s/*.log
+log_types: [conn]
+labels:
+  type: myzeek
+`, dir))
+
+	require.Len(t, events, 2)
+
+	for _, evt := range events {
+		assert.Equal(t, "conn", evt.Parsed["zeek_log"])
+		assert.Equal(t, "myzeek", evt.Line.Labels["type"])
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	assert.Equal(t, "\t", unescape(`\x09`))
+	assert.Equal(t, ",", unescape(`\x2c`))
+	assert.Equal(t, `\xZZ|`, unescape(`\xZZ\x7c`))
+	assert.Equal(t, `\x0`, unescape(`\x0`))
+}
@@ -0,0 +1,59 @@
+package acquisition
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	tomb "gopkg.in/tomb.v2"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/structured"
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/types"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// structuredDecoders holds the decoder of the datasources that have structured events.
+var structuredDecoders = map[types.DataSource]*structured.Decoder{}
+
+// decodeStructured decodes the events of a datasource before they are sent to the parsers.
+func decodeStructured(
+	decodeChan chan pipeline.Event,
+	output chan pipeline.Event,
+	acquisTomb *tomb.Tomb,
+	decoder *structured.Decoder,
+	logger *log.Entry,
+) {
+	logger.Info("structured decoder started")
+
+	for {
+		select {
+		case <-acquisTomb.Dying():
+			logger.Debugf("structured decoder is dying")
+			return
+		case evt, ok := <-decodeChan:
+			if !ok {
+				logger.Debugf("structured decoder is done")
+				return
+			}
+
+			if err := decoder.Decode(&evt); err != nil {
+				if decoder.DropOnError() {
+					logger.Debugf("dropping event: %s", err)
+					metrics.StructuredDroppedLines.With(prometheus.Labels{"source": evt.Line.Src, "type": evt.Line.Module}).Inc()
+					pipeline.RecordTraceStep(&evt, "structured", "", "dropped", err.Error())
+					pipeline.SetTraceOutcome(&evt, pipeline.TraceDropped)
+
+					continue
+				}
+
+				logger.Debugf("sending event to the parsers as-is: %s", err)
+				pipeline.RecordTraceStep(&evt, "structured", "", "error", err.Error())
+			}
+
+			select {
+			case output <- evt:
+			case <-acquisTomb.Dying():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Package structured decodes the events of the datasources that are structured at origin
+// (ECS JSON documents or protobuf messages) into the Parsed, Unmarshaled and Meta fields,
+// so they can skip the parsing stages that would run grok on them.
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const (
+	FormatECS      = "ecs"
+	FormatProtobuf = "protobuf"
+
+	OnErrorDrop  = "drop"
+	OnErrorParse = "parse"
+
+	defaultStage        = "s02-enrich"
+	defaultECSTimestamp = "@timestamp"
+)
+
+// ecsMeta are the meta of the ECS documents, from the first of their fields that is set.
+var ecsMeta = map[string][]string{
+	"source_ip":       {"source.ip", "client.ip"},
+	"http_path":       {"url.path", "url.original"},
+	"http_verb":       {"http.request.method"},
+	"http_status":     {"http.response.status_code"},
+	"http_user_agent": {"user_agent.original"},
+	"target_fqdn":     {"url.domain", "destination.domain"},
+	"user":            {"user.name"},
+}
+
+// types of the ECS fields that are checked, when they are present
+var (
+	ecsIPFields      = []string{"source.ip", "destination.ip", "client.ip", "server.ip"}
+	ecsIntegerFields = []string{"source.port", "destination.port", "client.port", "server.port", "http.response.status_code"}
+)
+
+// Decoder decodes the events of a datasource.
+type Decoder struct {
+	cfg     configuration.StructuredCfg
+	message protoreflect.MessageDescriptor
+}
+
+// New checks the configuration, sets its defaults and loads the protobuf descriptors.
+func New(cfg configuration.StructuredCfg) (*Decoder, error) {
+	d := &Decoder{cfg: cfg}
+
+	if d.cfg.Stage == "" {
+		d.cfg.Stage = defaultStage
+	}
+
+	switch d.cfg.OnError {
+	case "":
+		d.cfg.OnError = OnErrorDrop
+	case OnErrorDrop, OnErrorParse:
+	default:
+		return nil, fmt.Errorf("on_error must be %s or %s", OnErrorDrop, OnErrorParse)
+	}
+
+	switch d.cfg.Format {
+	case FormatECS:
+		if d.cfg.TimestampField == "" {
+			d.cfg.TimestampField = defaultECSTimestamp
+		}
+	case FormatProtobuf:
+		if d.cfg.DescriptorSet == "" {
+			return nil, errors.New("descriptor_set is required with the protobuf format")
+		}
+
+		if d.cfg.Message == "" {
+			return nil, errors.New("message is required with the protobuf format")
+		}
+
+		message, err := loadMessage(d.cfg.DescriptorSet, d.cfg.Message)
+		if err != nil {
+			return nil, err
+		}
+
+		d.message = message
+	case "":
+		return nil, errors.New("format is required")
+	default:
+		return nil, fmt.Errorf("unknown format '%s': must be %s or %s", d.cfg.Format, FormatECS, FormatProtobuf)
+	}
+
+	return d, nil
+}
+
+// loadMessage finds a message in a FileDescriptorSet.
+func loadMessage(path string, name string) (protoreflect.MessageDescriptor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading descriptor_set: %w", err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("reading descriptor_set %s: %w", path, err)
+	}
+
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("reading descriptor_set %s: %w", path, err)
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("message %s not found in %s", name, path)
+	}
+
+	message, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message in %s", name, path)
+	}
+
+	return message, nil
+}
+
+// DropOnError returns true if the events that can't be decoded must be dropped.
+func (d *Decoder) DropOnError() bool {
+	return d.cfg.OnError == OnErrorDrop
+}
+
+// document decodes the raw line of an event.
+func (d *Decoder) document(raw string) (map[string]any, error) {
+	data := []byte(raw)
+
+	if d.cfg.Format == FormatProtobuf {
+		msg := dynamicpb.NewMessage(d.message)
+
+		if err := proto.Unmarshal(data, msg); err != nil {
+			return nil, fmt.Errorf("invalid %s message: %w", d.message.FullName(), err)
+		}
+
+		var err error
+
+		// with the field names of the .proto file, and the 64 bits integers as strings
+		data, err = protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s message: %w", d.message.FullName(), err)
+		}
+	}
+
+	var doc map[string]any
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+
+	if doc == nil {
+		return nil, errors.New("invalid JSON document: not an object")
+	}
+
+	return doc, nil
+}
+
+// flatten adds the scalar values of a document to parsed, with dotted keys for the nested
+// objects. ECS documents can have dotted keys already: both forms give the same key.
+// Arrays are kept as JSON.
+func flatten(prefix string, value any, parsed map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, sub := range v {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+
+			flatten(key, sub, parsed)
+		}
+	case []any:
+		if raw, err := json.Marshal(v); err == nil {
+			parsed[prefix] = string(raw)
+		}
+	case nil:
+		parsed[prefix] = ""
+	case string:
+		parsed[prefix] = v
+	default:
+		parsed[prefix] = fmt.Sprint(v)
+	}
+}
+
+// validateECS checks the type of the well-known fields of an ECS document.
+func validateECS(parsed map[string]string, timestampField string) error {
+	ts, ok := parsed[timestampField]
+	if !ok {
+		return fmt.Errorf("missing field %s", timestampField)
+	}
+
+	if _, err := time.Parse(time.RFC3339Nano, ts); err != nil {
+		return fmt.Errorf("invalid %s '%s': not a RFC 3339 date", timestampField, ts)
+	}
+
+	for _, field := range ecsIPFields {
+		if v, ok := parsed[field]; ok && net.ParseIP(v) == nil {
+			return fmt.Errorf("invalid %s '%s': not an IP address", field, v)
+		}
+	}
+
+	for _, field := range ecsIntegerFields {
+		if v, ok := parsed[field]; ok {
+			if _, err := strconv.ParseInt(v, 10, 64); err != nil {
+				return fmt.Errorf("invalid %s '%s': not an integer", field, v)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Decode fills Parsed, Unmarshaled and Meta from the raw line of an event, and moves the event
+// to the configured stage. The event is not modified if an error is returned.
+func (d *Decoder) Decode(evt *pipeline.Event) error {
+	doc, err := d.document(evt.Line.Raw)
+	if err != nil {
+		return err
+	}
+
+	parsed := make(map[string]string)
+	flatten("", doc, parsed)
+
+	if d.cfg.Format == FormatECS {
+		if err := validateECS(parsed, d.cfg.TimestampField); err != nil {
+			return err
+		}
+	}
+
+	for _, field := range d.cfg.RequiredFields {
+		if _, ok := parsed[field]; !ok {
+			return fmt.Errorf("missing field %s", field)
+		}
+	}
+
+	meta := make(map[string]string)
+
+	if d.cfg.Format == FormatECS {
+		for key, fields := range ecsMeta {
+			for _, field := range fields {
+				if v := parsed[field]; v != "" {
+					meta[key] = v
+					break
+				}
+			}
+		}
+	}
+
+	for key, field := range d.cfg.Meta {
+		if v, ok := parsed[field]; ok {
+			meta[key] = v
+		}
+	}
+
+	if evt.Parsed == nil {
+		evt.Parsed = make(map[string]string)
+	}
+
+	if evt.Meta == nil {
+		evt.Meta = make(map[string]string)
+	}
+
+	if evt.Unmarshaled == nil {
+		evt.Unmarshaled = make(map[string]any)
+	}
+
+	maps.Copy(evt.Parsed, parsed)
+	maps.Copy(evt.Meta, meta)
+
+	evt.Unmarshaled[d.cfg.Format] = doc
+
+	if ts := parsed[d.cfg.TimestampField]; d.cfg.TimestampField != "" && ts != "" {
+		evt.StrTime = ts
+	}
+
+	evt.Stage = d.cfg.Stage
+
+	return nil
+}
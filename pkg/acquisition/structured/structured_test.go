@@ -0,0 +1,208 @@
+package structured
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/configuration"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         configuration.StructuredCfg
+		expectedErr string
+	}{
+		{
+			name: "ecs",
+			cfg:  configuration.StructuredCfg{Format: "ecs"},
+		},
+		{
+			name:        "no format",
+			cfg:         configuration.StructuredCfg{},
+			expectedErr: "format is required",
+		},
+		{
+			name:        "unknown format",
+			cfg:         configuration.StructuredCfg{Format: "avro"},
+			expectedErr: "unknown format 'avro': must be ecs or protobuf",
+		},
+		{
+			name:        "bad on_error",
+			cfg:         configuration.StructuredCfg{Format: "ecs", OnError: "ignore"},
+			expectedErr: "on_error must be drop or parse",
+		},
+		{
+			name:        "protobuf without descriptors",
+			cfg:         configuration.StructuredCfg{Format: "protobuf", Message: "test.Event"},
+			expectedErr: "descriptor_set is required with the protobuf format",
+		},
+		{
+			name:        "protobuf without message",
+			cfg:         configuration.StructuredCfg{Format: "protobuf", DescriptorSet: "events.binpb"},
+			expectedErr: "message is required with the protobuf format",
+		},
+		{
+			name:        "missing descriptors",
+			cfg:         configuration.StructuredCfg{Format: "protobuf", DescriptorSet: "/does/not/exist.binpb", Message: "test.Event"},
+			expectedErr: "reading descriptor_set: open /does/not/exist.binpb: " + cstest.FileNotFoundMessage,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(tc.cfg)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestDecodeECS(t *testing.T) {
+	d, err := New(configuration.StructuredCfg{
+		Format:         "ecs",
+		RequiredFields: []string{"event.action"},
+		Meta:           map[string]string{"log_type": "event.dataset"},
+	})
+	require.NoError(t, err)
+
+	evt := pipeline.MakeEvent(false, pipeline.LOG, true)
+	evt.Line.Raw = `{"@timestamp": "2024-05-01T12:00:00.123Z", "event": {"action": "login", "dataset": "sshd.auth"},
+		"source.ip": "192.0.2.1", "source": {"port": 52044}, "user": {"name": "root"}, "tags": ["a", "b"]}`
+
+	require.NoError(t, d.Decode(&evt))
+
+	assert.Equal(t, "s02-enrich", evt.Stage)
+	assert.Equal(t, "2024-05-01T12:00:00.123Z", evt.StrTime)
+	assert.Equal(t, "login", evt.Parsed["event.action"])
+	assert.Equal(t, "52044", evt.Parsed["source.port"])
+	assert.Equal(t, `["a","b"]`, evt.Parsed["tags"])
+	assert.Equal(t, map[string]string{"source_ip": "192.0.2.1", "user": "root", "log_type": "sshd.auth"}, evt.Meta)
+
+	ecs, ok := evt.Unmarshaled["ecs"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "192.0.2.1", ecs["source.ip"])
+
+	for raw, expectedErr := range map[string]string{
+		`not json`:                       "invalid JSON document",
+		`[1, 2]`:                         "invalid JSON document",
+		`{"event": {"action": "login"}}`: "missing field @timestamp",
+		`{"@timestamp": "yesterday", "event": {"action": "login"}}`:                           "invalid @timestamp 'yesterday': not a RFC 3339 date",
+		`{"@timestamp": "2024-05-01T12:00:00Z", "source": {"ip": "example.com"}}`:             "invalid source.ip 'example.com': not an IP address",
+		`{"@timestamp": "2024-05-01T12:00:00Z", "http": {"response": {"status_code": "OK"}}}`: "invalid http.response.status_code 'OK': not an integer",
+		`{"@timestamp": "2024-05-01T12:00:00Z"}`:                                              "missing field event.action",
+	} {
+		evt := pipeline.MakeEvent(false, pipeline.LOG, true)
+		evt.Line.Raw = raw
+		cstest.RequireErrorContains(t, d.Decode(&evt), expectedErr)
+		assert.Empty(t, evt.Stage, raw)
+		assert.Empty(t, evt.Parsed, raw)
+	}
+}
+
+// writeDescriptorSet writes the descriptors of a test.Event message, and returns the path of the file.
+func writeDescriptorSet(t *testing.T) (string, *descriptorpb.FileDescriptorProto) {
+	file := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("test/event.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Http"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("path"), JsonName: proto.String("path"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+					{Name: proto.String("status_code"), JsonName: proto.String("statusCode"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+				},
+			},
+			{
+				Name: proto.String("Event"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{Name: proto.String("client_ip"), JsonName: proto.String("clientIp"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+					{Name: proto.String("time"), JsonName: proto.String("time"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+					{Name: proto.String("http"), JsonName: proto.String("http"), Number: proto.Int32(3), Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(), TypeName: proto.String(".test.Http"), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+				},
+			},
+		},
+	}
+
+	data, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{file}})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "events.binpb")
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+
+	return path, file
+}
+
+func TestDecodeProtobuf(t *testing.T) {
+	path, file := writeDescriptorSet(t)
+
+	_, err := New(configuration.StructuredCfg{Format: "protobuf", DescriptorSet: path, Message: "test.Missing"})
+	cstest.RequireErrorContains(t, err, "message test.Missing not found in "+path)
+
+	d, err := New(configuration.StructuredCfg{
+		Format:         "protobuf",
+		DescriptorSet:  path,
+		Message:        "test.Event",
+		TimestampField: "time",
+		Stage:          "s01-parse",
+		OnError:        "parse",
+		RequiredFields: []string{"client_ip"},
+		Meta:           map[string]string{"source_ip": "client_ip", "http_status": "http.status_code"},
+	})
+	require.NoError(t, err)
+	assert.False(t, d.DropOnError())
+
+	fd, err := protodesc.NewFile(file, nil)
+	require.NoError(t, err)
+
+	desc := fd.Messages().ByName("Event")
+	httpDesc := fd.Messages().ByName("Http")
+
+	httpMsg := dynamicpb.NewMessage(httpDesc)
+	httpMsg.Set(httpDesc.Fields().ByName("path"), protoreflect.ValueOfString("/wp-login.php"))
+	httpMsg.Set(httpDesc.Fields().ByName("status_code"), protoreflect.ValueOfInt32(404))
+
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().ByName("client_ip"), protoreflect.ValueOfString("192.0.2.1"))
+	msg.Set(desc.Fields().ByName("time"), protoreflect.ValueOfString("2024-05-01T12:00:00Z"))
+	msg.Set(desc.Fields().ByName("http"), protoreflect.ValueOfMessage(httpMsg))
+
+	raw, err := proto.Marshal(msg)
+	require.NoError(t, err)
+
+	evt := pipeline.MakeEvent(false, pipeline.LOG, true)
+	evt.Line.Raw = string(raw)
+
+	require.NoError(t, d.Decode(&evt))
+
+	assert.Equal(t, "s01-parse", evt.Stage)
+	assert.Equal(t, "2024-05-01T12:00:00Z", evt.StrTime)
+	assert.Equal(t, "/wp-login.php", evt.Parsed["http.path"])
+	assert.Equal(t, map[string]string{"source_ip": "192.0.2.1", "http_status": "404"}, evt.Meta)
+	assert.Contains(t, evt.Unmarshaled, "protobuf")
+
+	// no client_ip
+	msg.Clear(desc.Fields().ByName("client_ip"))
+
+	raw, err = proto.Marshal(msg)
+	require.NoError(t, err)
+
+	evt = pipeline.MakeEvent(false, pipeline.LOG, true)
+	evt.Line.Raw = string(raw)
+	cstest.RequireErrorContains(t, d.Decode(&evt), "missing field client_ip")
+
+	evt.Line.Raw = "\xff\xff\xff"
+	cstest.RequireErrorContains(t, d.Decode(&evt), "invalid test.Event message")
+}
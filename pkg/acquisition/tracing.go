@@ -0,0 +1,30 @@
+package acquisition
+
+import (
+	tomb "gopkg.in/tomb.v2"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// startTraces starts the trace of the lines of a datasource as they are read, before the transform
+// expression and the structured decoder, which can drop them.
+func startTraces(traceChan chan pipeline.Event, output chan pipeline.Event, acquisTomb *tomb.Tomb) {
+	for {
+		select {
+		case <-acquisTomb.Dying():
+			return
+		case evt, ok := <-traceChan:
+			if !ok {
+				return
+			}
+
+			pipeline.StartTrace(&evt)
+
+			select {
+			case output <- evt:
+			case <-acquisTomb.Dying():
+				return
+			}
+		}
+	}
+}
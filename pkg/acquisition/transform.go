@@ -0,0 +1,170 @@
+package acquisition
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"strconv"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	tomb "gopkg.in/tomb.v2"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/types"
+	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// transformRuntimes holds the compiled transform expression of the datasources that have one.
+var transformRuntimes = map[types.DataSource]*vm.Program{}
+
+// compileTransform compiles the transform expression of a datasource. The expression is run
+// on each event before the parsers, and must return:
+//
+//   - a string, to replace the raw line (an empty string drops it)
+//   - a list of strings, to replace the line with several ones (an empty list drops it)
+//   - a boolean, to keep (true) or drop (false) the line as-is
+func compileTransform(transformExpr string) (*vm.Program, error) {
+	program, err := expr.Compile(transformExpr, exprhelpers.GetExprOptions(map[string]any{"evt": &pipeline.Event{}})...)
+	if err != nil {
+		return nil, err
+	}
+
+	// the type is not known if the expression uses untyped values (ie. Parsed, Meta...)
+	if t := program.Node().Type(); t != nil {
+		switch t.Kind() {
+		case reflect.String, reflect.Bool, reflect.Interface:
+		case reflect.Slice:
+			if k := t.Elem().Kind(); k != reflect.String && k != reflect.Interface {
+				return nil, fmt.Errorf("must return a string, a list of strings or a boolean, not %s", t)
+			}
+		default:
+			return nil, fmt.Errorf("must return a string, a list of strings or a boolean, not %s", t)
+		}
+	}
+
+	return program, nil
+}
+
+// There's no need for an actual deep copy
+// The event is almost empty, we are mostly interested in allocating new maps for Parsed/Meta/...
+func copyEvent(evt pipeline.Event, line string) pipeline.Event {
+	evtCopy := pipeline.MakeEvent(evt.ExpectMode == pipeline.TIMEMACHINE, evt.Type, evt.Process)
+	evtCopy.Line = evt.Line
+	evtCopy.Line.Raw = line
+	evtCopy.Line.Labels = make(map[string]string)
+	evtCopy.TraceID = evt.TraceID
+
+	maps.Copy(evtCopy.Line.Labels, evt.Line.Labels)
+
+	return evtCopy
+}
+
+// transformEvent runs the expression on an event, and returns the events to send to the parsers.
+func transformEvent(evt pipeline.Event, transformRuntime *vm.Program, logger *log.Entry) []pipeline.Event {
+	out, err := expr.Run(transformRuntime, map[string]any{"evt": &evt})
+	if err != nil {
+		logger.Errorf("while running transform expression: %s, sending event as-is", err)
+		return []pipeline.Event{evt}
+	}
+
+	switch v := out.(type) {
+	case nil:
+		logger.Errorf("transform expression returned nil, sending event as-is")
+		return []pipeline.Event{evt}
+	case bool:
+		logger.Tracef("transform expression returned %t", v)
+
+		if !v {
+			return nil
+		}
+
+		return []pipeline.Event{evt}
+	case string:
+		logger.Tracef("transform expression returned %s", v)
+
+		if v == "" {
+			return nil
+		}
+
+		return []pipeline.Event{copyEvent(evt, v)}
+	case []any:
+		logger.Tracef("transform expression returned %v", v) // We actually want to log the slice content
+
+		ret := make([]pipeline.Event, 0, len(v))
+
+		for _, line := range v {
+			l, ok := line.(string)
+			if !ok {
+				logger.Errorf("transform expression returned []interface{}, but cannot assert an element to string")
+				ret = append(ret, evt)
+
+				continue
+			}
+
+			ret = append(ret, copyEvent(evt, l))
+		}
+
+		return ret
+	case []string:
+		logger.Tracef("transform expression returned %v", v)
+
+		ret := make([]pipeline.Event, 0, len(v))
+
+		for _, line := range v {
+			ret = append(ret, copyEvent(evt, line))
+		}
+
+		return ret
+	default:
+		logger.Errorf("transform expression returned an invalid type %T, sending event as-is", out)
+		return []pipeline.Event{evt}
+	}
+}
+
+func transform(
+	transformChan chan pipeline.Event,
+	output chan pipeline.Event,
+	acquisTomb *tomb.Tomb,
+	transformRuntime *vm.Program,
+	logger *log.Entry,
+) {
+	logger.Info("transformer started")
+
+	for {
+		select {
+		case <-acquisTomb.Dying():
+			logger.Debugf("transformer is dying")
+			return
+		case evt, ok := <-transformChan:
+			if !ok {
+				logger.Debugf("transformer is done")
+				return
+			}
+
+			logger.Tracef("Received event %s", evt.Line.Raw)
+
+			events := transformEvent(evt, transformRuntime, logger)
+			if len(events) == 0 {
+				metrics.TransformDroppedLines.With(prometheus.Labels{"source": evt.Line.Src, "type": evt.Line.Module}).Inc()
+				pipeline.RecordTraceStep(&evt, "transform", "", "dropped", "")
+				pipeline.SetTraceOutcome(&evt, pipeline.TraceDropped)
+
+				continue
+			}
+
+			pipeline.RecordTraceStep(&evt, "transform", "", "ok", strconv.Itoa(len(events))+" line(s)")
+
+			for _, e := range events {
+				select {
+				case output <- e:
+				case <-acquisTomb.Dying():
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package acquisition
+
+import (
+	"context"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tomb "gopkg.in/tomb.v2"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/acquisition/types"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func TestCompileTransform(t *testing.T) {
+	tests := []struct {
+		expr        string
+		expectedErr string
+	}{
+		{expr: `evt.Line.Raw + "!"`},
+		{expr: `evt.Line.Raw contains "debug"`},
+		{expr: `split(evt.Line.Raw, ",")`},
+		{expr: `evt.Parsed.foo`},
+		{expr: `1 + 1`, expectedErr: "must return a string, a list of strings or a boolean, not int"},
+		{expr: `{"a": 1}`, expectedErr: "must return a string, a list of strings or a boolean, not map[string]interface {}"},
+		{expr: `evt.Line.Foo`, expectedErr: "type pipeline.Line has no field Foo"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := compileTransform(tc.expr)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestTransformEvent(t *testing.T) {
+	tests := []struct {
+		expr     string
+		raw      string
+		expected []string
+	}{
+		{expr: `evt.Line.Raw + "!"`, raw: "foo", expected: []string{"foo!"}},
+		{expr: `evt.Line.Raw == "foo" ? "" : evt.Line.Raw`, raw: "foo", expected: []string{}},
+		{expr: `!(evt.Line.Raw startsWith "#")`, raw: "# comment", expected: []string{}},
+		{expr: `!(evt.Line.Raw startsWith "#")`, raw: "foo", expected: []string{"foo"}},
+		{expr: `split(evt.Line.Raw, ",")`, raw: "a,b", expected: []string{"a", "b"}},
+		{expr: `filter(split(evt.Line.Raw, ","), # != "")`, raw: ",", expected: []string{}},
+		{expr: `nil`, raw: "foo", expected: []string{"foo"}},
+	}
+
+	logger := log.WithField("test", "transform")
+
+	for _, tc := range tests {
+		t.Run(tc.expr+"/"+tc.raw, func(t *testing.T) {
+			program, err := compileTransform(tc.expr)
+			require.NoError(t, err)
+
+			evt := pipeline.MakeEvent(false, pipeline.LOG, true)
+			evt.Line.Raw = tc.raw
+			evt.Line.Labels = map[string]string{"type": "test"}
+
+			lines := []string{}
+			for _, e := range transformEvent(evt, program, logger) {
+				lines = append(lines, e.Line.Raw)
+				assert.Equal(t, "test", e.Line.Labels["type"])
+			}
+
+			assert.Equal(t, tc.expected, lines)
+		})
+	}
+}
+
+func TestStartAcquisitionTransform(t *testing.T) {
+	ctx := t.Context()
+	source := &MockCat{}
+
+	program, err := compileTransform(`evt.Line.Src == "test" ? ["a", "b"] : []`)
+	require.NoError(t, err)
+
+	transformRuntimes[source] = program
+	t.Cleanup(func() { delete(transformRuntimes, source) })
+
+	out := make(chan pipeline.Event)
+	acquisTomb := tomb.Tomb{}
+	done := make(chan error, 1)
+
+	go func() {
+		done <- StartAcquisition(ctx, []types.DataSource{source}, out, &acquisTomb)
+	}()
+
+	lines := []string{}
+
+	for {
+		select {
+		case evt := <-out:
+			lines = append(lines, evt.Line.Raw)
+			continue
+		case err := <-done:
+			// the transformer stops when the source is done
+			require.NoError(t, err)
+		case <-ctx.Done():
+			require.FailNow(t, context.Cause(ctx).Error())
+		}
+
+		break
+	}
+
+	assert.Len(t, lines, 20)
+	assert.Equal(t, []string{"a", "b"}, lines[:2])
+}
+
+func TestStartAcquisitionTrace(t *testing.T) {
+	ctx := t.Context()
+	source := &MockCat{}
+
+	// the lines of the source are empty
+	program, err := compileTransform(`evt.Line.Raw == "" ? false : evt.Line.Raw`)
+	require.NoError(t, err)
+
+	transformRuntimes[source] = program
+	t.Cleanup(func() { delete(transformRuntimes, source) })
+
+	tracer := pipeline.NewTracer(100, 10, nil, 1)
+	pipeline.SetTracer(tracer)
+	t.Cleanup(func() { pipeline.SetTracer(nil) })
+
+	out := make(chan pipeline.Event, 10)
+	acquisTomb := tomb.Tomb{}
+
+	require.NoError(t, StartAcquisition(ctx, []types.DataSource{source}, out, &acquisTomb))
+	assert.Empty(t, out)
+
+	// the lines are traced when they are read, the transform expression dropped them
+	traces := tracer.List(pipeline.TraceFilter{})
+	require.Len(t, traces, 10)
+
+	trace, ok := tracer.Get(traces[0].ID)
+	require.True(t, ok)
+	assert.Equal(t, "test", trace.Source)
+	assert.Equal(t, pipeline.TraceDropped, trace.Outcome)
+	require.Len(t, trace.Steps, 2)
+	assert.Equal(t, "acquisition", trace.Steps[0].Kind)
+	assert.Equal(t, "transform", trace.Steps[1].Kind)
+	assert.Equal(t, "dropped", trace.Steps[1].Result)
+}
@@ -46,6 +46,7 @@ type AlertsDeleteOpts struct {
 	SourceEquals         string                  `url:"alert_source,omitempty"`
 	Contains             *bool                   `url:"contains,omitempty"`
 	Limit                *int                    `url:"limit,omitempty"`
+	Expression           string                  `url:"expression,omitempty"`
 	ListOpts
 }
 
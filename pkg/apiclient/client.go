@@ -48,6 +48,7 @@ type ApiClient struct {
 	Signal         *SignalService
 	HeartBeat      *HeartBeatService
 	UsageMetrics   *UsageMetricsService
+	ManagedConfig  *ManagedConfigService
 }
 
 func (c *ApiClient) GetClient() *http.Client {
@@ -207,6 +208,7 @@ func NewClient(config *Config) *ApiClient {
 	c.DecisionDelete = (*DecisionDeleteService)(&c.common)
 	c.HeartBeat = (*HeartBeatService)(&c.common)
 	c.UsageMetrics = (*UsageMetricsService)(&c.common)
+	c.ManagedConfig = (*ManagedConfigService)(&c.common)
 
 	return c
 }
@@ -250,6 +252,7 @@ func NewDefaultClient(url *url.URL, prefix string, userAgent string, client *htt
 	c.DecisionDelete = (*DecisionDeleteService)(&c.common)
 	c.HeartBeat = (*HeartBeatService)(&c.common)
 	c.UsageMetrics = (*UsageMetricsService)(&c.common)
+	c.ManagedConfig = (*ManagedConfigService)(&c.common)
 
 	return c, nil
 }
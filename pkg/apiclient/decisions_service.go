@@ -35,6 +35,8 @@ type DecisionsStreamOpts struct {
 	ScenariosContaining    string `url:"scenarios_containing,omitempty"`
 	ScenariosNotContaining string `url:"scenarios_not_containing,omitempty"`
 	Origins                string `url:"origins,omitempty"`
+	PageSize               int    `url:"page_size,omitempty"`
+	Cursor                 string `url:"cursor,omitempty"`
 }
 
 func (o *DecisionsStreamOpts) addQueryParamsToURL(url string) (string, error) {
@@ -446,6 +446,8 @@ func TestDecisionsStreamOpts_addQueryParamsToURL(t *testing.T) {
 		ScenariosNotContaining string
 		CommunityPull          bool
 		AdditionalPull         bool
+		PageSize               int
+		Cursor                 string
 	}
 
 	tests := []struct {
@@ -491,6 +493,17 @@ func TestDecisionsStreamOpts_addQueryParamsToURL(t *testing.T) {
 			},
 			expected: baseURLString + "?additional_pull=false&community_pull=false",
 		},
+		{
+			name: "pagination",
+			fields: fields{
+				Startup:        true,
+				CommunityPull:  true,
+				AdditionalPull: true,
+				PageSize:       1000,
+				Cursor:         "abc",
+			},
+			expected: baseURLString + "?cursor=abc&page_size=1000&startup=true",
+		},
 	}
 
 	for _, tt := range tests {
@@ -502,6 +515,8 @@ func TestDecisionsStreamOpts_addQueryParamsToURL(t *testing.T) {
 				ScenariosNotContaining: tt.fields.ScenariosNotContaining,
 				CommunityPull:          tt.fields.CommunityPull,
 				AdditionalPull:         tt.fields.AdditionalPull,
+				PageSize:               tt.fields.PageSize,
+				Cursor:                 tt.fields.Cursor,
 			}
 
 			got, err := o.addQueryParamsToURL(baseURLString)
@@ -0,0 +1,30 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/crowdsecurity/crowdsec/pkg/managedconfig"
+)
+
+type ManagedConfigService service
+
+// List returns the acquisition pushed by the console to the machine.
+func (s *ManagedConfigService) List(ctx context.Context) ([]managedconfig.Snippet, *Response, error) {
+	u := fmt.Sprintf("%s/watchers/self/managed_config", s.client.URLPrefix)
+
+	req, err := s.client.PrepareRequest(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	snippets := []managedconfig.Snippet{}
+
+	resp, err := s.client.Do(ctx, req, &snippets)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return snippets, resp, nil
+}
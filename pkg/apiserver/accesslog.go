@@ -0,0 +1,123 @@
+package apiserver
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	v1 "github.com/crowdsecurity/crowdsec/pkg/apiserver/middlewares/v1"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+)
+
+// sampled returns true if a request with the given status must be logged.
+func sampled(cfg *csconfig.AccessLogCfg, status int) bool {
+	rate := *cfg.SampleRate
+	if status >= http.StatusBadRequest {
+		rate = *cfg.ErrorSampleRate
+	}
+
+	switch {
+	case rate >= 1:
+		return true
+	case rate <= 0:
+		return false
+	default:
+		return rand.Float64() < rate
+	}
+}
+
+func textAccessLog(cfg *csconfig.AccessLogCfg) gin.HandlerFunc {
+	return gin.LoggerWithConfig(gin.LoggerConfig{
+		Formatter: func(param gin.LogFormatterParams) string {
+			return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s %q %s\"\n",
+				param.ClientIP,
+				param.TimeStamp.Format(time.RFC1123),
+				param.Method,
+				param.Path,
+				param.Request.Proto,
+				param.StatusCode,
+				param.Latency,
+				param.Request.UserAgent(),
+				param.ErrorMessage,
+			)
+		},
+		Skip: func(c *gin.Context) bool {
+			return !sampled(cfg, c.Writer.Status())
+		},
+	})
+}
+
+// jsonAccessLog logs one structured entry per request, with the identity of the
+// machine or bouncer when the request is authenticated.
+func jsonAccessLog(cfg *csconfig.AccessLogCfg, logger *log.Entry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if !sampled(cfg, status) {
+			return
+		}
+
+		fields := log.Fields{
+			"client_ip":  c.ClientIP(),
+			"method":     c.Request.Method,
+			"path":       path,
+			"route":      c.FullPath(),
+			"proto":      c.Request.Proto,
+			"status":     status,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"size":       c.Writer.Size(),
+			"user_agent": c.Request.UserAgent(),
+		}
+
+		if machineID, ok := jwt.ExtractClaims(c)[v1.MachineIDKey].(string); ok {
+			fields["machine_id"] = machineID
+		}
+
+		if bouncer, ok := c.Get(v1.BouncerContextKey); ok {
+			if b, ok := bouncer.(*ent.Bouncer); ok {
+				fields["bouncer"] = b.Name
+			}
+		}
+
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate).String(); errs != "" {
+			fields["error"] = errs
+		}
+
+		entry := logger.WithFields(fields)
+
+		switch {
+		case status >= http.StatusInternalServerError:
+			entry.Error("request")
+		case status >= http.StatusBadRequest:
+			entry.Warn("request")
+		default:
+			entry.Info("request")
+		}
+	}
+}
+
+// accessLogMiddleware returns the middleware that logs the requests to the API,
+// according to the access_log configuration.
+func accessLogMiddleware(cfg *csconfig.AccessLogCfg, logger *log.Entry) gin.HandlerFunc {
+	if cfg == nil {
+		cfg = &csconfig.AccessLogCfg{}
+		// the defaults can't fail
+		_ = cfg.Load()
+	}
+
+	if cfg.Format == csconfig.AccessLogFormatJSON {
+		return jsonAccessLog(cfg, logger)
+	}
+
+	return textAccessLog(cfg)
+}
@@ -0,0 +1,103 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/crowdsecurity/crowdsec/pkg/apiserver/middlewares/v1"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+)
+
+func newAccessLogRouter(t *testing.T, cfg *csconfig.AccessLogCfg) (*gin.Engine, *bytes.Buffer) {
+	t.Helper()
+
+	require.NoError(t, cfg.Load())
+
+	buf := &bytes.Buffer{}
+	logger := log.New()
+	logger.SetOutput(buf)
+	logger.SetFormatter(&log.JSONFormatter{})
+
+	router := gin.New()
+	router.Use(accessLogMiddleware(cfg, logger.WithFields(nil)))
+	router.GET("/v1/decisions/:id", func(c *gin.Context) {
+		c.Set(v1.BouncerContextKey, &ent.Bouncer{Name: "fw"})
+		c.String(http.StatusOK, "ok")
+	})
+	router.GET("/v1/fail", func(c *gin.Context) {
+		_ = c.Error(http.ErrBodyNotAllowed)
+		c.String(http.StatusInternalServerError, "ko")
+	})
+
+	return router, buf
+}
+
+func accessLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	ret := []map[string]any{}
+
+	for line := range strings.Lines(buf.String()) {
+		entry := map[string]any{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		ret = append(ret, entry)
+	}
+
+	return ret
+}
+
+func TestJSONAccessLog(t *testing.T) {
+	router, buf := newAccessLogRouter(t, &csconfig.AccessLogCfg{Format: csconfig.AccessLogFormatJSON})
+
+	for _, path := range []string{"/v1/decisions/42", "/v1/fail"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, http.NoBody)
+		req.Header.Set("User-Agent", "test/1.0")
+		router.ServeHTTP(w, req)
+	}
+
+	lines := accessLogLines(t, buf)
+	require.Len(t, lines, 2)
+
+	assert.Equal(t, "info", lines[0]["level"])
+	assert.Equal(t, "GET", lines[0]["method"])
+	assert.Equal(t, "/v1/decisions/42", lines[0]["path"])
+	assert.Equal(t, "/v1/decisions/:id", lines[0]["route"])
+	assert.InDelta(t, 200, lines[0]["status"], 0)
+	assert.Equal(t, "fw", lines[0]["bouncer"])
+	assert.Equal(t, "test/1.0", lines[0]["user_agent"])
+	assert.Contains(t, lines[0], "latency_ms")
+	assert.NotContains(t, lines[0], "machine_id")
+
+	assert.Equal(t, "error", lines[1]["level"])
+	assert.InDelta(t, 500, lines[1]["status"], 0)
+	assert.Contains(t, lines[1]["error"], http.ErrBodyNotAllowed.Error())
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	router, buf := newAccessLogRouter(t, &csconfig.AccessLogCfg{
+		Format:          csconfig.AccessLogFormatJSON,
+		SampleRate:      new(0.0),
+		ErrorSampleRate: new(1.0),
+	})
+
+	for _, path := range []string{"/v1/decisions/42", "/v1/fail", "/v1/decisions/43"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequestWithContext(t.Context(), http.MethodGet, path, http.NoBody)
+		router.ServeHTTP(w, req)
+	}
+
+	lines := accessLogLines(t, buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "/v1/fail", lines[0]["path"])
+}
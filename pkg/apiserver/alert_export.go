@@ -0,0 +1,403 @@
+package apiserver
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/go-cs-lib/trace"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/database"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+)
+
+const (
+	// config item with the ID of the last exported alert
+	alertExportLastIDKey = "alert_export:last_id"
+	// the alerts are exported once their transaction is surely committed: the IDs of the alerts saved
+	// concurrently are not committed in order
+	alertExportSettleDelay = time.Minute
+)
+
+// alertRow is a line of the alerts table. The timestamps are in milliseconds, the precision
+// supported by both Athena and BigQuery.
+type alertRow struct {
+	ID              int64             `parquet:"id"`
+	UUID            string            `parquet:"uuid"`
+	CreatedAt       time.Time         `parquet:"created_at,timestamp(millisecond)"`
+	Machine         string            `parquet:"machine"`
+	Kind            string            `parquet:"kind"`
+	Scenario        string            `parquet:"scenario"`
+	ScenarioVersion string            `parquet:"scenario_version"`
+	ScenarioHash    string            `parquet:"scenario_hash"`
+	Message         string            `parquet:"message"`
+	StartedAt       time.Time         `parquet:"started_at,timestamp(millisecond)"`
+	StoppedAt       time.Time         `parquet:"stopped_at,timestamp(millisecond)"`
+	EventsCount     int32             `parquet:"events_count"`
+	Capacity        int32             `parquet:"capacity"`
+	LeakSpeed       string            `parquet:"leak_speed"`
+	Simulated       bool              `parquet:"simulated"`
+	Remediation     bool              `parquet:"remediation"`
+	SourceScope     string            `parquet:"source_scope"`
+	SourceValue     string            `parquet:"source_value"`
+	SourceIP        string            `parquet:"source_ip"`
+	SourceRange     string            `parquet:"source_range"`
+	SourceASNumber  string            `parquet:"source_as_number"`
+	SourceASName    string            `parquet:"source_as_name"`
+	SourceCountry   string            `parquet:"source_country"`
+	SourceLatitude  float32           `parquet:"source_latitude"`
+	SourceLongitude float32           `parquet:"source_longitude"`
+	Labels          []string          `parquet:"labels,list"`
+	Decisions       int32             `parquet:"decisions"`
+	DecisionTypes   []string          `parquet:"decision_types,list"`
+	Meta            map[string]string `parquet:"meta"`
+}
+
+// eventRow is a line of the events table, joined to the alerts on alert_id.
+type eventRow struct {
+	AlertID  int64             `parquet:"alert_id"`
+	Scenario string            `parquet:"scenario"`
+	Time     time.Time         `parquet:"time,timestamp(millisecond)"`
+	Meta     map[string]string `parquet:"meta"`
+}
+
+func newAlertRow(a *ent.Alert) alertRow {
+	row := alertRow{
+		ID:              int64(a.ID),
+		UUID:            a.UUID,
+		CreatedAt:       a.CreatedAt.UTC(),
+		Kind:            a.Kind,
+		Scenario:        a.Scenario,
+		ScenarioVersion: a.ScenarioVersion,
+		ScenarioHash:    a.ScenarioHash,
+		Message:         a.Message,
+		StartedAt:       a.StartedAt.UTC(),
+		StoppedAt:       a.StoppedAt.UTC(),
+		EventsCount:     a.EventsCount,
+		Capacity:        a.Capacity,
+		LeakSpeed:       a.LeakSpeed,
+		Simulated:       a.Simulated,
+		Remediation:     a.Remediation,
+		SourceScope:     a.SourceScope,
+		SourceValue:     a.SourceValue,
+		SourceIP:        a.SourceIp,
+		SourceRange:     a.SourceRange,
+		SourceASNumber:  a.SourceAsNumber,
+		SourceASName:    a.SourceAsName,
+		SourceCountry:   a.SourceCountry,
+		SourceLatitude:  a.SourceLatitude,
+		SourceLongitude: a.SourceLongitude,
+		Labels:          a.Labels,
+		Decisions:       int32(len(a.Edges.Decisions)),
+		Meta:            make(map[string]string, len(a.Edges.Metas)),
+	}
+
+	if a.Edges.Owner != nil {
+		row.Machine = a.Edges.Owner.MachineId
+	}
+
+	for _, d := range a.Edges.Decisions {
+		if !slices.Contains(row.DecisionTypes, d.Type) {
+			row.DecisionTypes = append(row.DecisionTypes, d.Type)
+		}
+	}
+
+	for _, m := range a.Edges.Metas {
+		row.Meta[m.Key] = m.Value
+	}
+
+	return row
+}
+
+func newEventRows(a *ent.Alert, logger *log.Entry) []eventRow {
+	ret := make([]eventRow, 0, len(a.Edges.Events))
+
+	for _, evt := range a.Edges.Events {
+		row := eventRow{
+			AlertID:  int64(a.ID),
+			Scenario: a.Scenario,
+			Time:     evt.Time.UTC(),
+			Meta:     map[string]string{},
+		}
+
+		var meta models.Meta
+		if err := json.Unmarshal([]byte(evt.Serialized), &meta); err != nil {
+			logger.Warningf("alert %d: unable to read the meta of event %d: %s", a.ID, evt.ID, err)
+		}
+
+		for _, m := range meta {
+			row.Meta[m.Key] = m.Value
+		}
+
+		ret = append(ret, row)
+	}
+
+	return ret
+}
+
+// alertPartition is the hive-style path of the files of an alert: day and scenario are columns in
+// Athena and BigQuery, and the queries restricted to a period don't read the rest.
+func alertPartition(a *ent.Alert) string {
+	return fmt.Sprintf("day=%s/scenario=%s", a.CreatedAt.UTC().Format(time.DateOnly), url.PathEscape(a.Scenario))
+}
+
+func compressionCodec(name string) compress.Codec {
+	switch name {
+	case csconfig.AlertExportCompressionZstd:
+		return &parquet.Zstd
+	case csconfig.AlertExportCompressionGzip:
+		return &parquet.Gzip
+	case csconfig.AlertExportCompressionNone:
+		return &parquet.Uncompressed
+	default:
+		return &parquet.Snappy
+	}
+}
+
+func writeParquet[T any](rows []T, codec compress.Codec) ([]byte, error) {
+	buf := bytes.Buffer{}
+
+	w := parquet.NewGenericWriter[T](&buf, parquet.Compression(codec))
+
+	if _, err := w.Write(rows); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// objectStore is where the files are written.
+type objectStore interface {
+	Put(ctx context.Context, key string, body []byte) error
+}
+
+type s3Store struct {
+	client *s3.Client
+	bucket string
+}
+
+func newS3Store(ctx context.Context, cfg *csconfig.AlertExportCfg) (*s3Store, error) {
+	loadOpts := []func(*config.LoadOptions) error{
+		config.WithRegion(cmp.Or(cfg.Region, "us-east-1")),
+	}
+
+	if cfg.AccessKeyID != "" {
+		loadOpts = append(loadOpts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aws config: %w", err)
+	}
+
+	var clientOpts []func(*s3.Options)
+	if cfg.Endpoint != "" {
+		clientOpts = append(clientOpts, func(o *s3.Options) {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+		})
+	}
+
+	return &s3Store{client: s3.NewFromConfig(awsCfg, clientOpts...), bucket: cfg.Bucket}, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, key string, body []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/vnd.apache.parquet"),
+	})
+	if err != nil {
+		return fmt.Errorf("while writing s3://%s/%s: %w", s.bucket, key, err)
+	}
+
+	return nil
+}
+
+type alertExporter struct {
+	cfg      *csconfig.AlertExportCfg
+	dbClient *database.Client
+	store    objectStore
+	logger   *log.Entry
+	// only the alerts older than that are exported
+	settleDelay time.Duration
+}
+
+// exportBatch writes the next batch of alerts, one file per partition, and returns how many were
+// exported. The watermark is only moved once all the files are written: after a failure, the same
+// files are written again, with the same names.
+func (e *alertExporter) exportBatch(ctx context.Context, before time.Time) (int, error) {
+	raw, err := e.dbClient.GetConfigItem(ctx, alertExportLastIDKey)
+	if err != nil {
+		return 0, err
+	}
+
+	lastID := 0
+
+	if raw != "" {
+		if lastID, err = strconv.Atoi(raw); err != nil {
+			return 0, fmt.Errorf("invalid %s: %w", alertExportLastIDKey, err)
+		}
+	}
+
+	alerts, err := e.dbClient.ListAlertsToExport(ctx, lastID, before, e.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(alerts) == 0 {
+		return 0, nil
+	}
+
+	alertRows := map[string][]alertRow{}
+	eventRows := map[string][]eventRow{}
+	partitions := []string{}
+
+	for _, a := range alerts {
+		partition := alertPartition(a)
+
+		if _, ok := alertRows[partition]; !ok {
+			partitions = append(partitions, partition)
+		}
+
+		alertRows[partition] = append(alertRows[partition], newAlertRow(a))
+
+		if e.cfg.IncludeEvents {
+			eventRows[partition] = append(eventRows[partition], newEventRows(a, e.logger)...)
+		}
+	}
+
+	first, last := alerts[0].ID, alerts[len(alerts)-1].ID
+	fileName := fmt.Sprintf("%d-%d.parquet", first, last)
+	codec := compressionCodec(e.cfg.Compression)
+
+	for _, partition := range partitions {
+		body, err := writeParquet(alertRows[partition], codec)
+		if err != nil {
+			return 0, fmt.Errorf("while writing the alerts of %s: %w", partition, err)
+		}
+
+		if err := e.store.Put(ctx, path.Join(e.cfg.Prefix, "alerts", partition, fileName), body); err != nil {
+			return 0, err
+		}
+
+		if len(eventRows[partition]) == 0 {
+			continue
+		}
+
+		body, err = writeParquet(eventRows[partition], codec)
+		if err != nil {
+			return 0, fmt.Errorf("while writing the events of %s: %w", partition, err)
+		}
+
+		if err := e.store.Put(ctx, path.Join(e.cfg.Prefix, "events", partition, fileName), body); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := e.dbClient.SetConfigItem(ctx, alertExportLastIDKey, strconv.Itoa(last)); err != nil {
+		return 0, err
+	}
+
+	e.logger.Debugf("exported alerts %d to %d in %d partition(s)", first, last, len(partitions))
+
+	return len(alerts), nil
+}
+
+// export writes all the alerts created since the previous export.
+func (e *alertExporter) export(ctx context.Context) error {
+	if err := e.dbClient.AcquireAlertExportLock(ctx); err != nil {
+		if e.dbClient.IsLocked(err) {
+			e.logger.Info("the alerts are being exported by another local API, skipping")
+			return nil
+		}
+
+		return err
+	}
+
+	defer func() {
+		if err := e.dbClient.ReleaseAlertExportLock(ctx); err != nil {
+			e.logger.Errorf("unable to release the lock: %s", err)
+		}
+	}()
+
+	before := time.Now().UTC().Add(-e.settleDelay)
+	total := 0
+
+	for {
+		n, err := e.exportBatch(ctx, before)
+		if err != nil {
+			return err
+		}
+
+		total += n
+
+		if n < e.cfg.BatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		e.logger.Infof("exported %d alert(s) to %s", total, e.cfg.URL)
+	}
+
+	return nil
+}
+
+func (s *APIServer) runAlertExport(ctx context.Context) error {
+	defer trace.ReportPanic()
+
+	store, err := newS3Store(ctx, s.cfg.AlertExport)
+	if err != nil {
+		return fmt.Errorf("alert export: %w", err)
+	}
+
+	exporter := &alertExporter{
+		cfg:         s.cfg.AlertExport,
+		dbClient:    s.dbClient,
+		store:       store,
+		logger:      log.WithField("component", "alert_export"),
+		settleDelay: alertExportSettleDelay,
+	}
+
+	ticker := time.NewTicker(*s.cfg.AlertExport.Interval)
+	defer ticker.Stop()
+
+	for {
+		// the backlog is exported at startup
+		if err := exporter.export(ctx); err != nil {
+			exporter.logger.Errorf("while exporting alerts: %s", err)
+		}
+
+		select {
+		case <-s.alertExportTomb.Dying():
+			return nil
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+)
+
+type memoryStore struct {
+	objects map[string][]byte
+	fail    bool
+}
+
+func (m *memoryStore) Put(_ context.Context, key string, body []byte) error {
+	if m.fail {
+		return errors.New("access denied")
+	}
+
+	m.objects[key] = body
+
+	return nil
+}
+
+func (m *memoryStore) keys() []string {
+	ret := []string{}
+	for key := range m.objects {
+		ret = append(ret, key)
+	}
+
+	slices.Sort(ret)
+
+	return ret
+}
+
+func readParquet[T any](t *testing.T, body []byte) []T {
+	t.Helper()
+
+	rows, err := parquet.Read[T](bytes.NewReader(body), int64(len(body)))
+	require.NoError(t, err)
+
+	return rows
+}
+
+func exportTestAlert(scenario string, ip string) *models.Alert {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	return &models.Alert{
+		Scenario:        new(scenario),
+		ScenarioHash:    new("hash"),
+		ScenarioVersion: new("0.1"),
+		Message:         new("ban " + ip),
+		EventsCount:     new(int32(1)),
+		StartAt:         new(now),
+		StopAt:          new(now),
+		Capacity:        new(int32(5)),
+		Leakspeed:       new("10s"),
+		Simulated:       new(false),
+		Source: &models.Source{
+			Scope: new("Ip"),
+			Value: new(ip),
+			IP:    ip,
+			Cn:    "FR",
+		},
+		Events: []*models.Event{
+			{
+				Timestamp: new(now),
+				Meta:      models.Meta{{Key: "target_user", Value: "root"}},
+			},
+		},
+		Meta: models.Meta{{Key: "service", Value: "ssh"}},
+		Decisions: []*models.Decision{
+			{
+				Duration: new("4h"),
+				Origin:   new("crowdsec"),
+				Scenario: new(scenario),
+				Scope:    new("Ip"),
+				Type:     new("ban"),
+				Value:    new(ip),
+			},
+		},
+	}
+}
+
+func TestAlertExport(t *testing.T) {
+	ctx := t.Context()
+	apiServer, _ := NewAPIServer(t, ctx)
+	db := apiServer.dbClient
+
+	cfg := &csconfig.AlertExportCfg{URL: "s3://analytics/crowdsec", BatchSize: 2, IncludeEvents: true}
+	require.NoError(t, cfg.Load())
+
+	store := &memoryStore{objects: map[string][]byte{}}
+	exporter := &alertExporter{
+		cfg:         cfg,
+		dbClient:    db,
+		store:       store,
+		logger:      log.WithField("test", "alert_export"),
+		settleDelay: alertExportSettleDelay,
+	}
+
+	_, err := db.CreateAlert(ctx, "", []*models.Alert{
+		exportTestAlert("crowdsecurity/ssh-bf", "1.2.3.4"),
+		exportTestAlert("crowdsecurity/ssh-bf", "1.2.3.5"),
+		exportTestAlert("crowdsecurity/http-probing", "1.2.3.6"),
+	})
+	require.NoError(t, err)
+
+	// too recent, their transaction might not be committed
+	require.NoError(t, exporter.export(ctx))
+	assert.Empty(t, store.objects)
+
+	exporter.settleDelay = -time.Second
+
+	require.NoError(t, exporter.export(ctx))
+
+	day := time.Now().UTC().Format(time.DateOnly)
+
+	// two batches, the first one with the two ssh-bf alerts
+	assert.Equal(t, []string{
+		"crowdsec/alerts/day=" + day + "/scenario=crowdsecurity%2Fhttp-probing/3-3.parquet",
+		"crowdsec/alerts/day=" + day + "/scenario=crowdsecurity%2Fssh-bf/1-2.parquet",
+		"crowdsec/events/day=" + day + "/scenario=crowdsecurity%2Fhttp-probing/3-3.parquet",
+		"crowdsec/events/day=" + day + "/scenario=crowdsecurity%2Fssh-bf/1-2.parquet",
+	}, store.keys())
+
+	alerts := readParquet[alertRow](t, store.objects["crowdsec/alerts/day="+day+"/scenario=crowdsecurity%2Fssh-bf/1-2.parquet"])
+	require.Len(t, alerts, 2)
+	assert.Equal(t, int64(1), alerts[0].ID)
+	assert.Equal(t, "crowdsecurity/ssh-bf", alerts[0].Scenario)
+	assert.Equal(t, "1.2.3.4", alerts[0].SourceValue)
+	assert.Equal(t, "FR", alerts[0].SourceCountry)
+	assert.Equal(t, int32(1), alerts[0].Decisions)
+	assert.Equal(t, []string{"ban"}, alerts[0].DecisionTypes)
+	assert.Equal(t, map[string]string{"service": "ssh"}, alerts[0].Meta)
+	assert.Equal(t, "1.2.3.5", alerts[1].SourceValue)
+
+	events := readParquet[eventRow](t, store.objects["crowdsec/events/day="+day+"/scenario=crowdsecurity%2Fhttp-probing/3-3.parquet"])
+	require.Len(t, events, 1)
+	assert.Equal(t, int64(3), events[0].AlertID)
+	assert.Equal(t, map[string]string{"target_user": "root"}, events[0].Meta)
+
+	lastID, err := db.GetConfigItem(ctx, alertExportLastIDKey)
+	require.NoError(t, err)
+	assert.Equal(t, "3", lastID)
+
+	// nothing new
+	store.objects = map[string][]byte{}
+
+	require.NoError(t, exporter.export(ctx))
+	assert.Empty(t, store.objects)
+
+	// the watermark doesn't move when the files can't be written
+	_, err = db.CreateAlert(ctx, "", []*models.Alert{exportTestAlert("crowdsecurity/ssh-bf", "1.2.3.7")})
+	require.NoError(t, err)
+
+	store.fail = true
+
+	err = exporter.export(ctx)
+	cstest.RequireErrorContains(t, err, "access denied")
+
+	lastID, err = db.GetConfigItem(ctx, alertExportLastIDKey)
+	require.NoError(t, err)
+	assert.Equal(t, "3", lastID)
+
+	store.fail = false
+
+	require.NoError(t, exporter.export(ctx))
+	assert.Len(t, store.objects, 2)
+
+	// a single local API exports the alerts
+	require.NoError(t, db.AcquireAlertExportLock(ctx))
+
+	store.objects = map[string][]byte{}
+
+	require.NoError(t, exporter.export(ctx))
+	assert.Empty(t, store.objects)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"sync"
 	"testing"
@@ -477,6 +478,48 @@ func TestDeleteAlert(t *testing.T) {
 	assert.JSONEq(t, `{"nbDeleted":"1"}`, w.Body.String())
 }
 
+func TestDeleteAlertExpression(t *testing.T) {
+	ctx := t.Context()
+	lapi := SetupLAPITest(t, ctx)
+	lapi.InsertAlertFromFile(t, ctx, "./tests/alert_sample.json")
+	lapi.InsertAlertFromFile(t, ctx, "./tests/alert_ssh-bf.json")
+
+	deleteAlerts := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, "/v1/alerts?"+query, strings.NewReader(""))
+		require.NoError(t, err)
+		AddAuthHeaders(req, lapi.loginResp)
+		req.RemoteAddr = "127.0.0.1:4242"
+		lapi.router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	// Invalid expression
+	w := deleteAlerts("expression=" + url.QueryEscape("Alert.Nope =="))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid expression")
+
+	// Not a boolean
+	w = deleteAlerts("expression=" + url.QueryEscape("Alert.Source.Cn"))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "must return a boolean")
+
+	// No match with the other filters
+	w = deleteAlerts("scenario=crowdsecurity/test&expression=" + url.QueryEscape(`Alert.Source.Cn == "FR"`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"nbDeleted":"0"}`, w.Body.String())
+
+	w = deleteAlerts("expression=" + url.QueryEscape(`Alert.Source.Cn == "FR" && Alert.GetScenario() startsWith "crowdsecurity/ssh"`))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"nbDeleted":"1"}`, w.Body.String())
+
+	w = lapi.RecordResponse(t, ctx, "GET", "/v1/alerts", emptyBody, "password")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "crowdsecurity/test")
+	assert.NotContains(t, w.Body.String(), "crowdsecurity/ssh-bf")
+}
+
 func TestDeleteAlertByID(t *testing.T) {
 	ctx := t.Context()
 	lapi := SetupLAPITest(t, ctx)
@@ -71,6 +71,8 @@ type apic struct {
 	pullCommunity  bool
 	shareSignals   bool
 
+	pushBatch pushBatchSizer
+
 	TokenSave apiclient.TokenSave
 }
 
@@ -389,41 +391,6 @@ func shouldShareAlert(alert *models.Alert, consoleConfig *csconfig.ConsoleConfig
 	return true
 }
 
-func (a *apic) sendBatch(ctx context.Context, signals []*modelscapi.AddSignalsRequestItem) error {
-	ctxBatch, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	_, _, err := a.apiClient.Signal.Add(ctxBatch, (*modelscapi.AddSignalsRequest)(&signals))
-
-	return err
-}
-
-func (a *apic) Send(ctx context.Context, cacheOrig *modelscapi.AddSignalsRequest) {
-	/*we do have a problem with this :
-	The apic.Push background routine reads from alertToPush chan.
-	This chan is filled by Controller.CreateAlert
-
-	If the chan apic.Send hangs, the alertToPush chan will become full,
-	with means that Controller.CreateAlert is going to hang, blocking API worker(s).
-
-	So instead, we prefer to cancel write.
-
-	I don't know enough about gin to tell how much of an issue it can be.
-	*/
-	var cache []*modelscapi.AddSignalsRequestItem = *cacheOrig
-
-	batchSize := 50
-
-	for start := 0; start < len(cache); start += batchSize {
-		end := min(start+batchSize, len(cache))
-
-		if err := a.sendBatch(ctx, cache[start:end]); err != nil {
-			log.Errorf("sending signal to central API: %s", err)
-			return
-		}
-	}
-}
-
 func (a *apic) CAPIPullIsOld(ctx context.Context) (bool, error) {
 	/*only pull community blocklist if it's older than 1h30 */
 	alerts := a.dbClient.Ent.Alert.Query()
@@ -754,35 +721,40 @@ func (a *apic) updateOneAllowlist(ctx context.Context, client *apiclient.ApiClie
 		items = append(items, j)
 	}
 
-	list, err := a.dbClient.GetAllowListByID(ctx, *link.ID, false)
+	return a.saveAllowlist(ctx, *link.ID, *link.Name, description, items)
+}
+
+// saveAllowlist creates or replaces the content of an allowlist managed by the console.
+func (a *apic) saveAllowlist(ctx context.Context, id string, name string, description string, items []*models.AllowlistItem) error {
+	list, err := a.dbClient.GetAllowListByID(ctx, id, false)
 	if err != nil {
 		if !ent.IsNotFound(err) {
-			return fmt.Errorf("while getting allowlist %s: %s", *link.Name, err)
+			return fmt.Errorf("while getting allowlist %s: %s", name, err)
 		}
 	}
 
 	if list == nil {
-		list, err = a.dbClient.CreateAllowList(ctx, *link.Name, description, *link.ID, true)
+		list, err = a.dbClient.CreateAllowList(ctx, name, description, id, true)
 		if err != nil {
-			return fmt.Errorf("while creating allowlist %s: %s", *link.Name, err)
+			return fmt.Errorf("while creating allowlist %s: %s", name, err)
 		}
 	}
 
 	added, err := a.dbClient.ReplaceAllowlist(ctx, list, items, true)
 	if err != nil {
-		return fmt.Errorf("while replacing allowlist %s: %s", *link.Name, err)
+		return fmt.Errorf("while replacing allowlist %s: %s", name, err)
 	}
 
 	log.Infof("added %d values to allowlist %s", added, list.Name)
 
-	if list.Name != *link.Name || list.Description != description {
-		err = a.dbClient.UpdateAllowlistMeta(ctx, *link.ID, *link.Name, description)
+	if list.Name != name || list.Description != description {
+		err = a.dbClient.UpdateAllowlistMeta(ctx, id, name, description)
 		if err != nil {
-			return fmt.Errorf("while updating allowlist meta %s: %s", *link.Name, err)
+			return fmt.Errorf("while updating allowlist meta %s: %s", name, err)
 		}
 	}
 
-	log.Infof("Allowlist %s updated", *link.Name)
+	log.Infof("Allowlist %s updated", name)
 
 	return nil
 }
@@ -815,9 +787,18 @@ func (a *apic) whitelistedBy(decision *models.Decision, additionalIPs []netip.Ad
 
 	ipval, err := netip.ParseAddr(*decision.Value)
 	if err != nil {
+		// range decisions: allowlisted if they contain an allowlisted value
+		if prefix, err := netip.ParsePrefix(*decision.Value); err == nil {
+			return rangeAllowlistedBy(prefix.Masked(), additionalIPs, additionalRanges)
+		}
+
 		return ""
 	}
 
+	if a.whitelists == nil {
+		return allowlistedBy(ipval, additionalIPs, additionalRanges)
+	}
+
 	for _, cidr := range a.whitelists.Cidrs {
 		if cidr.Contains(ipval) {
 			return cidr.String()
@@ -830,13 +811,17 @@ func (a *apic) whitelistedBy(decision *models.Decision, additionalIPs []netip.Ad
 		}
 	}
 
-	for _, ip := range additionalIPs {
+	return allowlistedBy(ipval, additionalIPs, additionalRanges)
+}
+
+func allowlistedBy(ipval netip.Addr, ips []netip.Addr, ranges []netip.Prefix) string {
+	for _, ip := range ips {
 		if ip == ipval {
 			return ip.String()
 		}
 	}
 
-	for _, cidr := range additionalRanges {
+	for _, cidr := range ranges {
 		if cidr.Contains(ipval) {
 			return cidr.String()
 		}
@@ -845,6 +830,22 @@ func (a *apic) whitelistedBy(decision *models.Decision, additionalIPs []netip.Ad
 	return ""
 }
 
+func rangeAllowlistedBy(prefix netip.Prefix, ips []netip.Addr, ranges []netip.Prefix) string {
+	for _, ip := range ips {
+		if prefix.Contains(ip) {
+			return ip.String()
+		}
+	}
+
+	for _, cidr := range ranges {
+		if cidr.Overlaps(prefix) {
+			return cidr.String()
+		}
+	}
+
+	return ""
+}
+
 func (a *apic) ApplyApicWhitelists(ctx context.Context, decisions []*models.Decision) []*models.Decision {
 	allowlisted_ips, allowlisted_cidrs, err := a.dbClient.GetAllowlistsContentForAPIC(ctx)
 	if err != nil {
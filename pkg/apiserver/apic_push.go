@@ -0,0 +1,186 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/modelscapi"
+)
+
+const (
+	// number of signals per batch: it starts at the default, shrinks when CAPI rejects
+	// a payload as too large and grows back slowly after successful pushes
+	pushBatchSizeDefault = 50
+	pushBatchSizeMin     = 1
+	pushBatchSizeMax     = 500
+	pushBatchSizeStep    = 10
+	// a batch is closed before its JSON payload exceeds this size, whatever the number of signals.
+	// The payload is compressed with gzip by the API client.
+	pushBatchMaxBytes = 512 * 1024
+	// attempts to send a batch on network errors, rate limiting or server errors
+	pushBatchAttempts = 3
+	pushRetryDelay    = 2 * time.Second
+)
+
+// pushBatchSizer holds the number of signals to send in a batch, shared by the concurrent pushes.
+// The zero value uses the default size.
+type pushBatchSizer struct {
+	size atomic.Int64
+}
+
+func (s *pushBatchSizer) get() int {
+	if size := s.size.Load(); size > 0 {
+		return int(size)
+	}
+
+	return pushBatchSizeDefault
+}
+
+func (s *pushBatchSizer) grow() {
+	s.size.Store(int64(min(s.get()+pushBatchSizeStep, pushBatchSizeMax)))
+}
+
+func (s *pushBatchSizer) shrink() {
+	s.size.Store(int64(max(s.get()/2, pushBatchSizeMin)))
+}
+
+// splitSignals cuts the signals in batches of at most maxSignals signals and maxBytes of JSON.
+// A signal larger than maxBytes is sent alone.
+func splitSignals(signals []*modelscapi.AddSignalsRequestItem, maxSignals int, maxBytes int) [][]*modelscapi.AddSignalsRequestItem {
+	var (
+		batches   [][]*modelscapi.AddSignalsRequestItem
+		start     int
+		batchSize = 2 // []
+	)
+
+	for i, signal := range signals {
+		size := 1 // ,
+		if b, err := json.Marshal(signal); err == nil {
+			size += len(b)
+		}
+
+		if i > start && (i-start >= maxSignals || batchSize+size > maxBytes) {
+			batches = append(batches, signals[start:i])
+			start = i
+			batchSize = 2
+		}
+
+		batchSize += size
+	}
+
+	if start < len(signals) {
+		batches = append(batches, signals[start:])
+	}
+
+	return batches
+}
+
+// sendBatch pushes a batch of signals, and returns the HTTP status code of the response if there is one.
+func (a *apic) sendBatch(ctx context.Context, signals []*modelscapi.AddSignalsRequestItem) (int, error) {
+	ctxBatch, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, resp, err := a.apiClient.Signal.Add(ctxBatch, (*modelscapi.AddSignalsRequest)(&signals))
+
+	status := 0
+	if resp != nil && resp.Response != nil {
+		status = resp.Response.StatusCode
+	}
+
+	return status, err
+}
+
+func isRetryablePushError(status int, err error) bool {
+	switch {
+	case status == 0:
+		// network error or timeout
+		return !errors.Is(err, context.Canceled)
+	case status == http.StatusTooManyRequests, status >= 500:
+		return true
+	default:
+		return false
+	}
+}
+
+// sendBatchWithRetry pushes a batch of signals. A batch rejected as too large is split in two,
+// transient errors are retried a few times before giving up on the batch.
+// It returns the number of signals that could not be sent.
+func (a *apic) sendBatchWithRetry(ctx context.Context, signals []*modelscapi.AddSignalsRequestItem) (int, error) {
+	for attempt := 1; ; attempt++ {
+		status, err := a.sendBatch(ctx, signals)
+		if err == nil {
+			a.pushBatch.grow()
+			return 0, nil
+		}
+
+		if status == http.StatusRequestEntityTooLarge && len(signals) > 1 {
+			a.pushBatch.shrink()
+
+			half := len(signals) / 2
+			log.Warnf("signal push: batch of %d signals is too large, splitting it", len(signals))
+
+			failedFirst, errFirst := a.sendBatchWithRetry(ctx, signals[:half])
+			failedSecond, errSecond := a.sendBatchWithRetry(ctx, signals[half:])
+
+			return failedFirst + failedSecond, errors.Join(errFirst, errSecond)
+		}
+
+		if status == 0 && !errors.Is(err, context.Canceled) {
+			// a timeout can be caused by a payload too large for the link
+			a.pushBatch.shrink()
+		}
+
+		if attempt >= pushBatchAttempts || !isRetryablePushError(status, err) {
+			return len(signals), err
+		}
+
+		log.Warnf("signal push: sending %d signals (attempt %d/%d): %s", len(signals), attempt, pushBatchAttempts, err)
+
+		select {
+		case <-ctx.Done():
+			return len(signals), ctx.Err()
+		case <-time.After(pushRetryDelay * time.Duration(attempt)):
+		}
+	}
+}
+
+func (a *apic) Send(ctx context.Context, cacheOrig *modelscapi.AddSignalsRequest) {
+	/*we do have a problem with this :
+	The apic.Push background routine reads from alertToPush chan.
+	This chan is filled by Controller.CreateAlert
+
+	If the chan apic.Send hangs, the alertToPush chan will become full,
+	with means that Controller.CreateAlert is going to hang, blocking API worker(s).
+
+	So instead, we prefer to cancel write.
+
+	I don't know enough about gin to tell how much of an issue it can be.
+	*/
+	var cache []*modelscapi.AddSignalsRequestItem = *cacheOrig
+
+	batches := splitSignals(cache, a.pushBatch.get(), pushBatchMaxBytes)
+	failed := 0
+
+	for _, batch := range batches {
+		n, err := a.sendBatchWithRetry(ctx, batch)
+		if err != nil {
+			log.Errorf("sending signal to central API: %s", err)
+		}
+
+		failed += n
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if failed > 0 {
+		log.Errorf("signal push: %d/%d signals could not be sent", failed, len(cache))
+	}
+}
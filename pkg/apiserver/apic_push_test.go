@@ -0,0 +1,224 @@
+package apiserver
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/apiclient"
+	"github.com/crowdsecurity/crowdsec/pkg/modelscapi"
+)
+
+func makeSignals(n int, messageSize int) modelscapi.AddSignalsRequest {
+	signals := make(modelscapi.AddSignalsRequest, n)
+	for i := range signals {
+		signals[i] = &modelscapi.AddSignalsRequestItem{
+			Message:  new(strings.Repeat("x", messageSize)),
+			Scenario: new("crowdsec/test"),
+		}
+	}
+
+	return signals
+}
+
+func TestSplitSignals(t *testing.T) {
+	tests := []struct {
+		name        string
+		signals     modelscapi.AddSignalsRequest
+		maxSignals  int
+		maxBytes    int
+		expectedLen []int
+	}{
+		{
+			name:        "no signal",
+			maxSignals:  50,
+			maxBytes:    pushBatchMaxBytes,
+			expectedLen: nil,
+		},
+		{
+			name:        "by number of signals",
+			signals:     makeSignals(120, 10),
+			maxSignals:  50,
+			maxBytes:    pushBatchMaxBytes,
+			expectedLen: []int{50, 50, 20},
+		},
+		{
+			name:        "by payload size",
+			signals:     makeSignals(10, 1000),
+			maxSignals:  50,
+			maxBytes:    4000,
+			expectedLen: []int{3, 3, 3, 1},
+		},
+		{
+			name:        "signal larger than the payload size",
+			signals:     makeSignals(2, 1000),
+			maxSignals:  50,
+			maxBytes:    100,
+			expectedLen: []int{1, 1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			batches := splitSignals(tc.signals, tc.maxSignals, tc.maxBytes)
+
+			var lens []int
+
+			total := 0
+
+			for _, batch := range batches {
+				lens = append(lens, len(batch))
+				total += len(batch)
+
+				if len(batch) > 1 {
+					b, err := json.Marshal(batch)
+					require.NoError(t, err)
+					assert.LessOrEqual(t, len(b), tc.maxBytes)
+				}
+			}
+
+			assert.Equal(t, tc.expectedLen, lens)
+			assert.Len(t, tc.signals, total)
+		})
+	}
+}
+
+func TestPushBatchSizer(t *testing.T) {
+	var s pushBatchSizer
+
+	assert.Equal(t, pushBatchSizeDefault, s.get())
+
+	s.shrink()
+	assert.Equal(t, pushBatchSizeDefault/2, s.get())
+
+	s.grow()
+	assert.Equal(t, pushBatchSizeDefault/2+pushBatchSizeStep, s.get())
+
+	for range 10 {
+		s.shrink()
+	}
+
+	assert.Equal(t, pushBatchSizeMin, s.get())
+
+	for range 100 {
+		s.grow()
+	}
+
+	assert.Equal(t, pushBatchSizeMax, s.get())
+}
+
+// signalsResponder answers the signal pushes with the status returned by respond,
+// given the number of signals in the request.
+func signalsResponder(t *testing.T, received *int, respond func(n int) int) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		var body io.Reader = req.Body
+
+		if req.Header.Get("Content-Encoding") == "gzip" {
+			gz, err := gzip.NewReader(req.Body)
+			require.NoError(t, err)
+
+			body = gz
+		}
+
+		signals := modelscapi.AddSignalsRequest{}
+		require.NoError(t, json.NewDecoder(body).Decode(&signals))
+
+		status := respond(len(signals))
+		if status == http.StatusOK {
+			*received += len(signals)
+		}
+
+		return httpmock.NewStringResponse(status, `{"message": "test"}`), nil
+	}
+}
+
+func TestAPICSend(t *testing.T) {
+	ctx := t.Context()
+
+	tests := []struct {
+		name             string
+		signals          int
+		respond          func(attempt int, n int) int
+		expectedCalls    int
+		expectedReceived int
+	}{
+		{
+			name:             "small batches are sent as is",
+			signals:          120,
+			respond:          func(int, int) int { return http.StatusOK },
+			expectedCalls:    3,
+			expectedReceived: 120,
+		},
+		{
+			name:    "too large batches are split",
+			signals: 40,
+			respond: func(_ int, n int) int {
+				if n > 10 {
+					return http.StatusRequestEntityTooLarge
+				}
+
+				return http.StatusOK
+			},
+			// 40 -> 2x20 -> 4x10
+			expectedCalls:    7,
+			expectedReceived: 40,
+		},
+		{
+			name:    "server errors are retried",
+			signals: 10,
+			respond: func(attempt int, _ int) int {
+				if attempt == 1 {
+					return http.StatusServiceUnavailable
+				}
+
+				return http.StatusOK
+			},
+			expectedCalls:    2,
+			expectedReceived: 10,
+		},
+		{
+			name:             "client errors are not retried",
+			signals:          10,
+			respond:          func(int, int) int { return http.StatusBadRequest },
+			expectedCalls:    1,
+			expectedReceived: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			api := getAPIC(t, ctx)
+
+			u, err := url.ParseRequestURI("http://api.crowdsec.net/")
+			require.NoError(t, err)
+
+			httpmock.Activate()
+			defer httpmock.DeactivateAndReset()
+
+			api.apiClient, err = apiclient.NewDefaultClient(u, "/api", "", nil)
+			require.NoError(t, err)
+
+			attempt := 0
+			received := 0
+
+			httpmock.RegisterResponder("POST", "http://api.crowdsec.net/api/signals", signalsResponder(t, &received, func(n int) int {
+				attempt++
+				return tc.respond(attempt, n)
+			}))
+
+			signals := makeSignals(tc.signals, 200)
+			api.Send(ctx, &signals)
+
+			assert.Equal(t, tc.expectedCalls, httpmock.GetCallCountInfo()["POST http://api.crowdsec.net/api/signals"])
+			assert.Equal(t, tc.expectedReceived, received)
+		})
+	}
+}
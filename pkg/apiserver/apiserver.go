@@ -22,6 +22,7 @@ import (
 	"github.com/crowdsecurity/go-cs-lib/trace"
 
 	"github.com/crowdsecurity/crowdsec/pkg/apiserver/controllers"
+	"github.com/crowdsecurity/crowdsec/pkg/apiserver/eventbus"
 	v1 "github.com/crowdsecurity/crowdsec/pkg/apiserver/middlewares/v1"
 	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/csnet"
@@ -32,6 +33,9 @@ import (
 
 const keyLength = 32
 
+// how often the end of the maintenance windows is checked
+const maintenanceFlushInterval = 30 * time.Second
+
 type APIServer struct {
 	cfg            *csconfig.LocalApiServerCfg
 	dbClient       *database.Client
@@ -42,6 +46,15 @@ type APIServer struct {
 	apic           *apic
 	papi           *Papi
 	httpServerTomb tomb.Tomb
+	heartbeatTomb  tomb.Tomb
+	eventBus       *eventbus.Bus
+	eventBusTomb   tomb.Tomb
+	// flushes what was held during the maintenance windows
+	maintenanceTomb tomb.Tomb
+	alertExportTomb tomb.Tomb
+	// checks the database connection, and keeps the snapshot served while it's down
+	dbSupervisor     *database.Supervisor
+	dbSupervisorTomb tomb.Tomb
 }
 
 func isBrokenConnection(maybeError any) bool {
@@ -127,6 +140,25 @@ func NewServer(ctx context.Context, config *csconfig.LocalApiServerCfg, accessLo
 		return nil, fmt.Errorf("unable to init database client: %w", err)
 	}
 
+	dbClient.DecisionDurations = config.DecisionDurations
+	dbClient.DecisionConflicts = config.DecisionConflicts
+	dbClient.EventAnonymization = config.EventAnonymization
+	dbClient.DecisionBudgets = config.DecisionBudgets
+
+	capped, err := dbClient.CapDecisionDurations(ctx, time.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("unable to apply decision_durations: %w", err)
+	}
+
+	if capped > 0 {
+		log.Infof("decision_durations: %d decisions shortened to the max_duration of their origin", capped)
+	}
+
+	eventBus, err := eventbus.New(config.EventBus, dbClient, log.WithField("component", "event_bus"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to init event bus: %w", err)
+	}
+
 	if config.DbConfig.Flush != nil {
 		flushScheduler, err = dbClient.StartFlushScheduler(ctx, config.DbConfig.Flush)
 		if err != nil {
@@ -160,19 +192,16 @@ func NewServer(ctx context.Context, config *csconfig.LocalApiServerCfg, accessLo
 	gin.DefaultErrorWriter = accessLogger.WriterLevel(log.ErrorLevel)
 	gin.DefaultWriter = accessLogger.Writer()
 
-	router.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s %q %s\"\n",
-			param.ClientIP,
-			param.TimeStamp.Format(time.RFC1123),
-			param.Method,
-			param.Path,
-			param.Request.Proto,
-			param.StatusCode,
-			param.Latency,
-			param.Request.UserAgent(),
-			param.ErrorMessage,
-		)
-	}))
+	router.Use(accessLogMiddleware(config.AccessLog, accessLogger))
+
+	trustedIPs, err := config.GetTrustedIPs()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.AutoBan != nil {
+		router.Use(newAutoBan(config.AutoBan, trustedIPs, dbClient, eventBus).middleware())
+	}
 
 	router.NoRoute(func(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"message": "Page or Method not found"})
@@ -187,6 +216,15 @@ func NewServer(ctx context.Context, config *csconfig.LocalApiServerCfg, accessLo
 		ConsoleConfig:                 config.ConsoleConfig,
 		DisableRemoteLapiRegistration: config.DisableRemoteLapiRegistration,
 		AutoRegisterCfg:               config.AutoRegister,
+		EventBus:                      eventBus,
+		MaintenanceWindows:            config.MaintenanceWindows,
+	}
+
+	var dbSupervisor *database.Supervisor
+
+	if config.DBSupervision != nil {
+		dbSupervisor = database.NewSupervisor(dbClient, config.DBSupervision, log.WithField("component", "db_supervision"))
+		controller.DBSupervisor = dbSupervisor
 	}
 
 	var (
@@ -219,17 +257,14 @@ func NewServer(ctx context.Context, config *csconfig.LocalApiServerCfg, accessLo
 				return nil, err
 			}
 
+			papiClient.ManagedProfilesDir = config.ManagedProfilesDir
+
 			controller.DecisionDeleteChan = papiClient.Channels.DeleteDecisionChannel
 		} else {
 			log.Error("Machine is not enrolled in the console, can't synchronize with the console")
 		}
 	}
 
-	trustedIPs, err := config.GetTrustedIPs()
-	if err != nil {
-		return nil, err
-	}
-
 	controller.TrustedIPs = trustedIPs
 
 	return &APIServer{
@@ -241,6 +276,8 @@ func NewServer(ctx context.Context, config *csconfig.LocalApiServerCfg, accessLo
 		apic:           apiClient,
 		papi:           papiClient,
 		httpServerTomb: tomb.Tomb{},
+		eventBus:       eventBus,
+		dbSupervisor:   dbSupervisor,
 	}, nil
 }
 
@@ -350,10 +387,49 @@ func (s *APIServer) Run(ctx context.Context, apiReady chan bool) error {
 		s.initAPIC(ctx)
 	}
 
+	if s.cfg.HeartbeatSLA != nil && s.cfg.HeartbeatSLA.Alert {
+		s.heartbeatTomb.Go(func() error {
+			return s.runHeartbeatSLA(ctx)
+		})
+	}
+
+	if s.cfg.AlertExport != nil {
+		s.alertExportTomb.Go(func() error {
+			return s.runAlertExport(s.alertExportTomb.Context(ctx))
+		})
+	}
+
+	if len(s.cfg.MaintenanceWindows) > 0 {
+		s.maintenanceTomb.Go(func() error {
+			defer trace.ReportPanic()
+			return s.controller.HandlerV1.RunMaintenance(s.maintenanceTomb.Context(ctx), maintenanceFlushInterval)
+		})
+	}
+
+	if s.dbSupervisor != nil {
+		s.dbSupervisorTomb.Go(func() error {
+			defer trace.ReportPanic()
+			return s.dbSupervisor.Run(s.dbSupervisorTomb.Context(ctx))
+		})
+	}
+
+	if s.eventBus != nil {
+		s.eventBusTomb.Go(func() error {
+			return s.eventBus.Run(s.eventBusTomb.Context(ctx))
+		})
+	}
+
 	s.httpServerTomb.Go(func() error {
 		return s.listenAndServeLAPI(ctx, apiReady)
 	})
 
+	// after the http server: a tomb can't start goroutines once they have all returned
+	if s.cfg.GRPC != nil {
+		s.httpServerTomb.Go(func() error {
+			return s.listenAndServeGRPC(ctx)
+		})
+	}
+
 	if err := s.httpServerTomb.Wait(); err != nil {
 		return fmt.Errorf("local API server stopped with error: %w", err)
 	}
@@ -470,6 +546,43 @@ func (s *APIServer) Close() {
 		s.papi.Shutdown() // papi also uses the dbClient
 	}
 
+	s.heartbeatTomb.Kill(nil)
+
+	if s.cfg.AlertExport != nil {
+		s.alertExportTomb.Kill(nil)
+
+		if err := s.alertExportTomb.Wait(); err != nil {
+			log.Errorf("alert export: %s", err)
+		}
+	}
+
+	if len(s.cfg.MaintenanceWindows) > 0 {
+		s.maintenanceTomb.Kill(nil)
+
+		if err := s.maintenanceTomb.Wait(); err != nil {
+			log.Errorf("maintenance windows: %s", err)
+		}
+	}
+
+	if s.eventBus != nil {
+		// the bus looks for decision changes in the database
+		s.eventBusTomb.Kill(nil)
+
+		if err := s.eventBusTomb.Wait(); err != nil {
+			log.Errorf("event bus: %s", err)
+		}
+
+		s.eventBus.Close()
+	}
+
+	if s.dbSupervisor != nil {
+		s.dbSupervisorTomb.Kill(nil)
+
+		if err := s.dbSupervisorTomb.Wait(); err != nil {
+			log.Errorf("db supervision: %s", err)
+		}
+	}
+
 	s.dbClient.Close()
 
 	if s.flushScheduler != nil {
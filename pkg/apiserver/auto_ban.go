@@ -0,0 +1,216 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/crowdsecurity/crowdsec/pkg/apiserver/eventbus"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/database"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+)
+
+// AutoBanScenario is the scenario of the alerts raised when a client is banned for abusing the local API.
+const AutoBanScenario = "crowdsecurity/lapi-abuse"
+
+// autoBanFailure returns true if a response means the client sent invalid credentials or a malformed payload.
+func autoBanFailure(status int) bool {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusUnprocessableEntity:
+		return true
+	default:
+		return false
+	}
+}
+
+// autoBan counts the failed requests of each client IP in a token bucket, and bans the IP when
+// the bucket is empty. The ban is stored as a decision, for the bouncers, and the local API
+// refuses the requests of the IP until it expires.
+type autoBan struct {
+	cfg        *csconfig.AutoBanCfg
+	trustedIPs []net.IPNet
+	dbClient   *database.Client
+	eventBus   *eventbus.Bus
+
+	mu          sync.Mutex
+	limiters    map[string]*rate.Limiter
+	banned      map[string]time.Time // end of the ban, by IP
+	lastCleanup time.Time
+}
+
+func newAutoBan(cfg *csconfig.AutoBanCfg, trustedIPs []net.IPNet, dbClient *database.Client, eventBus *eventbus.Bus) *autoBan {
+	return &autoBan{
+		cfg:        cfg,
+		trustedIPs: trustedIPs,
+		dbClient:   dbClient,
+		eventBus:   eventBus,
+		limiters:   map[string]*rate.Limiter{},
+		banned:     map[string]time.Time{},
+	}
+}
+
+// trusted returns true for the IPs that are never banned: the local ones and the trusted_ips.
+// The requests from the unix socket have 127.0.0.1 as remote address.
+func (a *autoBan) trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return true
+	}
+
+	if parsed.IsLoopback() {
+		return true
+	}
+
+	for _, network := range a.trustedIPs {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isBanned returns true if the IP is banned at the given time.
+func (a *autoBan) isBanned(ip string, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	end, ok := a.banned[ip]
+	if !ok {
+		return false
+	}
+
+	if now.Before(end) {
+		return true
+	}
+
+	delete(a.banned, ip)
+
+	return false
+}
+
+// fail records a failed request, and returns true if the IP must be banned.
+func (a *autoBan) fail(ip string, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	capacity := *a.cfg.Capacity
+
+	// a bucket is full again after capacity*leak_speed without failure, and can be forgotten
+	if refill := time.Duration(capacity) * *a.cfg.LeakSpeed; now.Sub(a.lastCleanup) > refill {
+		for key, lim := range a.limiters {
+			if lim.TokensAt(now) >= float64(capacity) {
+				delete(a.limiters, key)
+			}
+		}
+
+		a.lastCleanup = now
+	}
+
+	lim, ok := a.limiters[ip]
+	if !ok {
+		lim = rate.NewLimiter(rate.Every(*a.cfg.LeakSpeed), capacity)
+		a.limiters[ip] = lim
+	}
+
+	if lim.AllowN(now, 1) {
+		return false
+	}
+
+	delete(a.limiters, ip)
+	a.banned[ip] = now.Add(*a.cfg.Duration)
+
+	return true
+}
+
+func (a *autoBan) newAlert(ip string, c *gin.Context, now time.Time) *models.Alert {
+	capacity := int32(*a.cfg.Capacity)
+	duration := a.cfg.Duration.String()
+	message := fmt.Sprintf("%s banned by the local API after %d failed requests (last: %s %s, status %d)",
+		ip, capacity+1, c.Request.Method, c.Request.URL.Path, c.Writer.Status())
+
+	return &models.Alert{
+		Source: &models.Source{
+			Scope: new(types.Ip),
+			Value: new(ip),
+			IP:    ip,
+		},
+		Scenario:        new(AutoBanScenario),
+		Kind:            types.LAPIAlertKind.String(),
+		Message:         new(message),
+		StartAt:         new(now.UTC().Format(time.RFC3339)),
+		StopAt:          new(now.UTC().Format(time.RFC3339)),
+		Capacity:        new(capacity),
+		Simulated:       new(false),
+		EventsCount:     new(capacity + 1),
+		Leakspeed:       new(a.cfg.LeakSpeed.String()),
+		ScenarioHash:    new(""),
+		ScenarioVersion: new(""),
+		Remediation:     true,
+		Decisions: []*models.Decision{
+			{
+				Duration: new(duration),
+				Origin:   new(types.LAPIOrigin),
+				Scenario: new(AutoBanScenario),
+				Scope:    new(types.Ip),
+				Type:     new(a.cfg.Type),
+				Value:    new(ip),
+			},
+		},
+		Meta: models.Meta{
+			{Key: "method", Value: c.Request.Method},
+			{Key: "path", Value: c.Request.URL.Path},
+			{Key: "status", Value: strconv.Itoa(c.Writer.Status())},
+			{Key: "user_agent", Value: c.Request.UserAgent()},
+		},
+	}
+}
+
+// ban stores the decision against an IP.
+func (a *autoBan) ban(ctx context.Context, alert *models.Alert) {
+	log.Warning(*alert.Message)
+
+	if _, err := a.dbClient.CreateAlert(ctx, "", []*models.Alert{alert}); err != nil {
+		log.Errorf("while saving auto-ban alert for %s: %s", *alert.Source.Value, err)
+		return
+	}
+
+	a.eventBus.PublishAlerts([]*models.Alert{alert})
+}
+
+func (a *autoBan) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		if a.trusted(ip) {
+			return
+		}
+
+		if a.isBanned(ip, time.Now()) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": fmt.Sprintf("access forbidden from this IP (%s)", ip)})
+			return
+		}
+
+		c.Next()
+
+		if !autoBanFailure(c.Writer.Status()) {
+			return
+		}
+
+		now := time.Now()
+
+		if a.fail(ip, now) {
+			// the request is over, the alert must be saved anyway
+			a.ban(context.WithoutCancel(c.Request.Context()), a.newAlert(ip, c, now))
+		}
+	}
+}
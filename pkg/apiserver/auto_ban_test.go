@@ -0,0 +1,111 @@
+package apiserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+)
+
+func TestAutoBanBucket(t *testing.T) {
+	cfg := &csconfig.AutoBanCfg{Capacity: new(3), LeakSpeed: new(10 * time.Second)}
+	require.NoError(t, cfg.Load())
+
+	a := newAutoBan(cfg, nil, nil, nil)
+	now := time.Now()
+
+	for range 3 {
+		assert.False(t, a.fail("192.0.2.1", now))
+	}
+
+	// a token came back
+	now = now.Add(10 * time.Second)
+	assert.False(t, a.fail("192.0.2.1", now))
+
+	// another IP has its own bucket
+	assert.False(t, a.fail("192.0.2.2", now))
+
+	assert.True(t, a.fail("192.0.2.1", now))
+	assert.True(t, a.isBanned("192.0.2.1", now))
+	assert.False(t, a.isBanned("192.0.2.2", now))
+	assert.False(t, a.isBanned("192.0.2.1", now.Add(4*time.Hour)))
+
+	// the full buckets are forgotten
+	now = now.Add(time.Minute)
+	assert.False(t, a.fail("192.0.2.3", now))
+	assert.NotContains(t, a.limiters, "192.0.2.2")
+	assert.Contains(t, a.limiters, "192.0.2.3")
+
+	assert.True(t, a.trusted("127.0.0.1"))
+	assert.True(t, a.trusted("::1"))
+	assert.False(t, a.trusted("192.0.2.1"))
+}
+
+func TestAutoBanMiddleware(t *testing.T) {
+	ctx := t.Context()
+	config := LoadTestConfig(t)
+
+	config.API.Server.AutoBan = &csconfig.AutoBanCfg{Capacity: new(3)}
+	require.NoError(t, config.API.Server.AutoBan.Load())
+
+	os.Remove("./ent")
+
+	logger, _ := logtest.NewNullLogger()
+	apiServer, err := NewServer(ctx, config.API.Server, logger.WithFields(nil))
+	require.NoError(t, err)
+	require.NoError(t, apiServer.InitController())
+
+	log.Info("Creating new API server")
+	gin.SetMode(gin.TestMode)
+
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/v1/decisions", emptyBody)
+		req.Header.Add("User-Agent", UserAgent)
+		req.Header.Add("X-Api-Key", "not-a-key")
+		req.RemoteAddr = remoteAddr
+		apiServer.router.ServeHTTP(w, req)
+
+		return w
+	}
+
+	for range 4 {
+		w := request("203.0.113.5:4242")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.JSONEq(t, `{"message":"access forbidden"}`, w.Body.String())
+	}
+
+	// banned: the request doesn't reach the authentication anymore
+	w := request("203.0.113.5:4243")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"message":"access forbidden from this IP (203.0.113.5)"}`, w.Body.String())
+
+	// the local clients are never banned
+	for range 5 {
+		w = request("127.0.0.1:4242")
+		assert.JSONEq(t, `{"message":"access forbidden"}`, w.Body.String())
+	}
+
+	alerts, err := apiServer.dbClient.QueryAlertWithFilter(ctx, map[string][]string{"scenario": {AutoBanScenario}})
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "203.0.113.5", alerts[0].SourceValue)
+	assert.Equal(t, types.LAPIAlertKind.String(), alerts[0].Kind)
+	assert.Equal(t, "203.0.113.5 banned by the local API after 4 failed requests (last: GET /v1/decisions, status 403)", alerts[0].Message)
+
+	decisions := alerts[0].Edges.Decisions
+	require.Len(t, decisions, 1)
+	assert.Equal(t, types.LAPIOrigin, decisions[0].Origin)
+	assert.Equal(t, "ban", decisions[0].Type)
+	assert.Equal(t, "203.0.113.5", decisions[0].Value)
+}
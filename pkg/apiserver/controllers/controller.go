@@ -10,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	v1 "github.com/crowdsecurity/crowdsec/pkg/apiserver/controllers/v1"
+	"github.com/crowdsecurity/crowdsec/pkg/apiserver/eventbus"
 	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/database"
 	"github.com/crowdsecurity/crowdsec/pkg/logging"
@@ -29,6 +30,9 @@ type Controller struct {
 	HandlerV1                     *v1.Controller
 	AutoRegisterCfg               *csconfig.LocalAPIAutoRegisterCfg
 	DisableRemoteLapiRegistration bool
+	EventBus                      *eventbus.Bus
+	MaintenanceWindows            csconfig.MaintenanceWindowsCfg
+	DBSupervisor                  *database.Supervisor
 }
 
 func (c *Controller) Init() error {
@@ -88,6 +92,9 @@ func (c *Controller) NewV1() error {
 		ConsoleConfig:      *c.ConsoleConfig,
 		TrustedIPs:         c.TrustedIPs,
 		AutoRegisterCfg:    c.AutoRegisterCfg,
+		EventBus:           c.EventBus,
+		MaintenanceWindows: c.MaintenanceWindows,
+		DBSupervisor:       c.DBSupervisor,
 	}
 
 	c.HandlerV1, err = v1.New(&v1Config)
@@ -134,6 +141,7 @@ func (c *Controller) NewV1() error {
 		jwtAuth.HEAD("/allowlists/check/:ip_or_range", c.HandlerV1.CheckInAllowlist)
 		jwtAuth.POST("/allowlists/check", c.HandlerV1.CheckInAllowlistBulk)
 		jwtAuth.DELETE("/watchers/self", c.HandlerV1.DeleteMachine)
+		jwtAuth.GET("/watchers/self/managed_config", c.HandlerV1.GetManagedConfig)
 	}
 
 	apiKeyAuth := groupV1.Group("")
@@ -3,18 +3,24 @@ package v1
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"net"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"time"
 
+	"github.com/expr-lang/expr"
 	"github.com/gin-gonic/gin"
 	"github.com/go-openapi/strfmt"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
 	"github.com/crowdsecurity/crowdsec/pkg/models"
 	"github.com/crowdsecurity/crowdsec/pkg/types"
 )
@@ -55,7 +61,8 @@ func FormatOneAlert(alert *ent.Alert) *models.Alert {
 			Latitude:  alert.SourceLatitude,
 			Longitude: alert.SourceLongitude,
 		},
-		Kind: alert.Kind,
+		Kind:   alert.Kind,
+		Labels: alert.Labels,
 	}
 
 	for _, eventItem := range alert.Edges.Events {
@@ -108,11 +115,25 @@ func FormatAlerts(result []*ent.Alert) models.AddAlertsRequest {
 }
 
 func (c *Controller) sendAlertToPluginChannel(alert *models.Alert, profileID uint) {
+	if c.PluginChannel == nil {
+		return
+	}
+
+	profileAlert := models.ProfileAlert{ProfileID: profileID, Alert: alert}
+
+	if c.Maintenance.holdNotification(profileAlert, time.Now()) {
+		return
+	}
+
+	c.sendToPluginChannel(profileAlert)
+}
+
+func (c *Controller) sendToPluginChannel(profileAlert models.ProfileAlert) {
 	if c.PluginChannel != nil {
 	RETRY:
 		for try := range 3 {
 			select {
-			case c.PluginChannel <- models.ProfileAlert{ProfileID: profileID, Alert: alert}:
+			case c.PluginChannel <- profileAlert:
 				log.Debugf("alert sent to Plugin channel")
 
 				break RETRY
@@ -124,6 +145,29 @@ func (c *Controller) sendAlertToPluginChannel(alert *models.Alert, profileID uin
 	}
 }
 
+// NotifyAlert sends an alert to the notification plugins of the profiles it matches.
+// The decisions of the profiles are not applied.
+func (c *Controller) NotifyAlert(alert *models.Alert) {
+	for pIdx, profile := range c.Profiles {
+		_, matched, err := profile.EvaluateProfile(alert)
+		if err != nil {
+			profile.Logger.Warningf("error while evaluating profile %s : %v", profile.Cfg.Name, err)
+
+			continue
+		}
+
+		if !matched {
+			continue
+		}
+
+		c.sendAlertToPluginChannel(alert, uint(pIdx))
+
+		if profile.Cfg.OnSuccess == "break" {
+			break
+		}
+	}
+}
+
 func (c *Controller) isAllowListed(ctx context.Context, alert *models.Alert) (bool, string) {
 	// If we have decisions, it comes from cscli that already checked the allowlist
 	if len(alert.Decisions) > 0 {
@@ -193,24 +237,7 @@ func (c *Controller) CreateAlert(gctx *gin.Context) {
 				decision.UUID = uuid.NewString()
 			}
 
-			for pIdx, profile := range c.Profiles {
-				_, matched, err := profile.EvaluateProfile(alert)
-				if err != nil {
-					profile.Logger.Warningf("error while evaluating profile %s : %v", profile.Cfg.Name, err)
-
-					continue
-				}
-
-				if !matched {
-					continue
-				}
-
-				c.sendAlertToPluginChannel(alert, uint(pIdx))
-
-				if profile.Cfg.OnSuccess == "break" {
-					break
-				}
-			}
+			c.NotifyAlert(alert)
 
 			decision := alert.Decisions[0]
 			if decision.Origin != nil && *decision.Origin == types.CscliImportOrigin {
@@ -280,9 +307,11 @@ func (c *Controller) CreateAlert(gctx *gin.Context) {
 		return
 	}
 
+	c.EventBus.PublishAlerts(alertsToSave)
+
 	if c.AlertsAddChan != nil {
 		select {
-		case c.AlertsAddChan <- alertsToSave:
+		case c.AlertsAddChan <- c.Maintenance.holdAlerts(alertsToSave, time.Now()):
 			log.Debug("alert sent to CAPI channel")
 		default:
 			log.Warning("Cannot send alert to Central API channel")
@@ -383,7 +412,23 @@ func (c *Controller) DeleteAlerts(gctx *gin.Context) {
 		return
 	}
 
-	nbDeleted, err := c.DBClient.DeleteAlertWithFilter(ctx, gctx.Request.URL.Query())
+	filter := gctx.Request.URL.Query()
+
+	var (
+		nbDeleted int
+		err       error
+	)
+
+	if filter.Has("expression") {
+		nbDeleted, err = c.deleteAlertsWithExpression(ctx, filter)
+		if errors.Is(err, errInvalidExpression) {
+			gctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+	} else {
+		nbDeleted, err = c.DBClient.DeleteAlertWithFilter(ctx, filter)
+	}
+
 	if err != nil {
 		c.HandleDBErrors(gctx, err)
 		return
@@ -396,6 +441,62 @@ func (c *Controller) DeleteAlerts(gctx *gin.Context) {
 	gctx.JSON(http.StatusOK, deleteAlertsResp)
 }
 
+// deleteAlertsBatchSize is the number of alerts deleted in a query, when they are selected by an
+// expression.
+const deleteAlertsBatchSize = 1000
+
+var errInvalidExpression = errors.New("invalid expression")
+
+// deleteAlertsWithExpression deletes the alerts that match the other filters and for which the
+// expression is true. The expression is evaluated like the filters of the profiles, over Alert.
+// Nothing is deleted if it fails for any alert.
+func (c *Controller) deleteAlertsWithExpression(ctx context.Context, filter url.Values) (int, error) {
+	program, err := expr.Compile(filter.Get("expression"), exprhelpers.GetExprOptions(map[string]any{"Alert": &models.Alert{}})...)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", errInvalidExpression, err)
+	}
+
+	filter = maps.Clone(filter)
+	filter.Del("expression")
+	filter.Set("limit", "0")
+
+	alerts, err := c.DBClient.QueryAlertWithFilter(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	toDelete := make([]*ent.Alert, 0, len(alerts))
+
+	for _, alertItem := range alerts {
+		output, err := expr.Run(program, map[string]any{"Alert": FormatOneAlert(alertItem)})
+		if err != nil {
+			return 0, fmt.Errorf("%w: alert %d: %w", errInvalidExpression, alertItem.ID, err)
+		}
+
+		match, ok := output.(bool)
+		if !ok {
+			return 0, fmt.Errorf("%w: must return a boolean, got %T", errInvalidExpression, output)
+		}
+
+		if match {
+			toDelete = append(toDelete, alertItem)
+		}
+	}
+
+	nbDeleted := 0
+
+	for batch := range slices.Chunk(toDelete, deleteAlertsBatchSize) {
+		deleted, err := c.DBClient.DeleteAlertGraphBatch(ctx, batch)
+		nbDeleted += deleted
+
+		if err != nil {
+			return nbDeleted, err
+		}
+	}
+
+	return nbDeleted, nil
+}
+
 func networksContainIP(networks []net.IPNet, ip string) bool {
 	parsedIP := net.ParseIP(ip)
 	for _, network := range networks {
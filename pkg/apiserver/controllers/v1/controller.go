@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 
+	"github.com/crowdsecurity/crowdsec/pkg/apiserver/eventbus"
 	middlewares "github.com/crowdsecurity/crowdsec/pkg/apiserver/middlewares/v1"
 	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/csprofiles"
@@ -24,6 +25,9 @@ type Controller struct {
 	ConsoleConfig   csconfig.ConsoleConfig
 	TrustedIPs      []net.IPNet
 	AutoRegisterCfg *csconfig.LocalAPIAutoRegisterCfg
+	EventBus        *eventbus.Bus
+	Maintenance     *Maintenance
+	DBSupervisor    *database.Supervisor
 }
 
 type ControllerV1Config struct {
@@ -33,10 +37,13 @@ type ControllerV1Config struct {
 	AlertsAddChan      chan []*models.Alert
 	DecisionDeleteChan chan []*models.Decision
 
-	PluginChannel   chan models.ProfileAlert
-	ConsoleConfig   csconfig.ConsoleConfig
-	TrustedIPs      []net.IPNet
-	AutoRegisterCfg *csconfig.LocalAPIAutoRegisterCfg
+	PluginChannel      chan models.ProfileAlert
+	ConsoleConfig      csconfig.ConsoleConfig
+	TrustedIPs         []net.IPNet
+	AutoRegisterCfg    *csconfig.LocalAPIAutoRegisterCfg
+	EventBus           *eventbus.Bus
+	MaintenanceWindows csconfig.MaintenanceWindowsCfg
+	DBSupervisor       *database.Supervisor
 }
 
 func New(cfg *ControllerV1Config) (*Controller, error) {
@@ -57,6 +64,9 @@ func New(cfg *ControllerV1Config) (*Controller, error) {
 		ConsoleConfig:      cfg.ConsoleConfig,
 		TrustedIPs:         cfg.TrustedIPs,
 		AutoRegisterCfg:    cfg.AutoRegisterCfg,
+		EventBus:           cfg.EventBus,
+		Maintenance:        NewMaintenance(cfg.MaintenanceWindows),
+		DBSupervisor:       cfg.DBSupervisor,
 	}
 
 	v1.Middlewares, err = middlewares.NewMiddlewares(cfg.DbClient)
@@ -64,5 +74,7 @@ func New(cfg *ControllerV1Config) (*Controller, error) {
 		return v1, err
 	}
 
+	v1.Middlewares.APIKey.Supervisor = cfg.DBSupervisor
+
 	return v1, nil
 }
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"maps"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/schema"
 	"github.com/crowdsecurity/crowdsec/pkg/models"
 )
 
@@ -67,7 +70,16 @@ func (c *Controller) GetDecision(gctx *gin.Context) {
 		return
 	}
 
-	data, err = c.DBClient.QueryDecisionWithFilter(ctx, gctx.Request.URL.Query())
+	filters := gctx.Request.URL.Query()
+
+	fields, err := parseDecisionFields(filters)
+	if err != nil {
+		gctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+
+		return
+	}
+
+	data, err = c.DBClient.QueryDecisionWithFilter(ctx, filters)
 	if err != nil {
 		c.HandleDBErrors(gctx, err)
 
@@ -90,11 +102,32 @@ func (c *Controller) GetDecision(gctx *gin.Context) {
 	}
 
 	if bouncerInfo.LastPull == nil || time.Now().UTC().Sub(*bouncerInfo.LastPull) >= time.Minute {
-		if err := c.DBClient.UpdateBouncerLastPull(ctx, time.Now().UTC(), bouncerInfo.ID); err != nil {
+		now := time.Now().UTC()
+		pull := schema.BouncerPull{
+			Time:     now,
+			Endpoint: "decisions",
+			Filters:  pullFilters(gctx.Request.URL.Query()),
+			New:      len(results),
+		}
+
+		if err := c.DBClient.UpdateBouncerPull(ctx, bouncerInfo, &now, pull); err != nil {
 			log.Errorf("failed to update bouncer last pull: %v", err)
 		}
 	}
 
+	if fields != nil {
+		compact, err := c.formatCompactDecisions(ctx, data, fields)
+		if err != nil {
+			c.HandleDBErrors(gctx, err)
+
+			return
+		}
+
+		gctx.JSON(http.StatusOK, compact)
+
+		return
+	}
+
 	gctx.JSON(http.StatusOK, results)
 }
 
@@ -155,9 +188,10 @@ func (c *Controller) DeleteDecisions(gctx *gin.Context) {
 	gctx.JSON(http.StatusOK, deleteDecisionResp)
 }
 
-func writeStartupDecisions(gctx *gin.Context, now time.Time, filters map[string][]string, dbFunc func(context.Context, time.Time, map[string][]string) ([]*ent.Decision, error)) error {
+func writeStartupDecisions(gctx *gin.Context, now time.Time, filters map[string][]string, dbFunc func(context.Context, time.Time, map[string][]string) ([]*ent.Decision, error)) (int, error) {
 	limit := 30000 // FIXME : make it configurable
 	needComma := false
+	sent := 0
 	lastId := 0
 
 	ctx := gctx.Request.Context()
@@ -179,7 +213,7 @@ func writeStartupDecisions(gctx *gin.Context, now time.Time, filters map[string]
 
 		data, err := dbFunc(ctx, now, filters)
 		if err != nil {
-			return err
+			return sent, err
 		}
 
 		for _, d := range data {
@@ -193,7 +227,7 @@ func writeStartupDecisions(gctx *gin.Context, now time.Time, filters map[string]
 			if err := enc.Encode(formatOneDecision(d)); err != nil {
 				gctx.Writer.Flush()
 
-				return err
+				return sent, err
 			}
 			// Encode() appends a trailing newline; strip it to keep the wire format compact.
 			b := buf.Bytes()
@@ -203,8 +237,10 @@ func writeStartupDecisions(gctx *gin.Context, now time.Time, filters map[string]
 			if _, err := gctx.Writer.Write(b); err != nil {
 				gctx.Writer.Flush()
 
-				return err
+				return sent, err
 			}
+
+			sent++
 		}
 
 		if len(data) > 0 {
@@ -220,12 +256,13 @@ func writeStartupDecisions(gctx *gin.Context, now time.Time, filters map[string]
 		}
 	}
 
-	return nil
+	return sent, nil
 }
 
-func writeDeltaDecisions(gctx *gin.Context, now time.Time, filters map[string][]string, lastPull *time.Time, dbFunc func(context.Context, time.Time, *time.Time, map[string][]string) ([]*ent.Decision, error)) error {
+func writeDeltaDecisions(gctx *gin.Context, now time.Time, filters map[string][]string, lastPull *time.Time, dbFunc func(context.Context, time.Time, *time.Time, map[string][]string) ([]*ent.Decision, error)) (int, error) {
 	limit := 30000 // FIXME : make it configurable
 	needComma := false
+	sent := 0
 	lastId := 0
 
 	ctx := gctx.Request.Context()
@@ -247,7 +284,7 @@ func writeDeltaDecisions(gctx *gin.Context, now time.Time, filters map[string][]
 
 		data, err := dbFunc(ctx, now, lastPull, filters)
 		if err != nil {
-			return err
+			return sent, err
 		}
 
 		for _, d := range data {
@@ -261,7 +298,7 @@ func writeDeltaDecisions(gctx *gin.Context, now time.Time, filters map[string][]
 			if err := enc.Encode(formatOneDecision(d)); err != nil {
 				gctx.Writer.Flush()
 
-				return err
+				return sent, err
 			}
 			// Encode() appends a trailing newline; strip it to keep the wire format compact.
 			b := buf.Bytes()
@@ -271,8 +308,10 @@ func writeDeltaDecisions(gctx *gin.Context, now time.Time, filters map[string][]
 			if _, err := gctx.Writer.Write(b); err != nil {
 				gctx.Writer.Flush()
 
-				return err
+				return sent, err
 			}
+
+			sent++
 		}
 
 		if len(data) > 0 {
@@ -288,11 +327,14 @@ func writeDeltaDecisions(gctx *gin.Context, now time.Time, filters map[string][]
 		}
 	}
 
-	return nil
+	return sent, nil
 }
 
-func (c *Controller) streamDecisions(gctx *gin.Context, bouncerInfo *ent.Bouncer, now time.Time, filters map[string][]string) error {
-	var err error
+func (c *Controller) streamDecisions(gctx *gin.Context, src decisionSource, lastPull *time.Time, now time.Time, filters map[string][]string) (pullStats, error) {
+	var (
+		stats pullStats
+		err   error
+	)
 
 	gctx.Writer.Header().Set("Content-Type", "application/json")
 	gctx.Writer.Header().Set("Transfer-Encoding", "chunked")
@@ -302,61 +344,61 @@ func (c *Controller) streamDecisions(gctx *gin.Context, bouncerInfo *ent.Bouncer
 	// if the blocker just started, return all decisions
 	if val, ok := gctx.Request.URL.Query()["startup"]; ok && val[0] == "true" {
 		// Active decisions
-		err := writeStartupDecisions(gctx, now, filters, c.DBClient.QueryAllDecisionsWithFilters)
+		stats.new, err = writeStartupDecisions(gctx, now, filters, src.QueryAllDecisionsWithFilters)
 		if err != nil {
 			log.Errorf("failed sending new decisions for startup: %v", err)
 			gctx.Writer.WriteString(`], "deleted": []}`)
 			gctx.Writer.Flush()
 
-			return err
+			return stats, err
 		}
 
 		gctx.Writer.WriteString(`], "deleted": [`)
 		// Expired decisions
-		err = writeStartupDecisions(gctx, now, filters, c.DBClient.QueryExpiredDecisionsWithFilters)
+		stats.deleted, err = writeStartupDecisions(gctx, now, filters, src.QueryExpiredDecisionsWithFilters)
 		if err != nil {
 			log.Errorf("failed sending expired decisions for startup: %v", err)
 			gctx.Writer.WriteString(`]}`)
 			gctx.Writer.Flush()
 
-			return err
+			return stats, err
 		}
 
 		gctx.Writer.WriteString(`]}`)
 		gctx.Writer.Flush()
 	} else {
-		err = writeDeltaDecisions(gctx, now, filters, bouncerInfo.LastPull, c.DBClient.QueryNewDecisionsSinceWithFilters)
+		stats.new, err = writeDeltaDecisions(gctx, now, filters, lastPull, src.QueryNewDecisionsSinceWithFilters)
 		if err != nil {
 			log.Errorf("failed sending new decisions for delta: %v", err)
 			gctx.Writer.WriteString(`], "deleted": []}`)
 			gctx.Writer.Flush()
 
-			return err
+			return stats, err
 		}
 
 		gctx.Writer.WriteString(`], "deleted": [`)
 
 		// Use a 2-second overlap to avoid missing decisions that expired around the last pull time
 		var expiredSince *time.Time
-		if bouncerInfo.LastPull != nil {
-			since := bouncerInfo.LastPull.Add(-2 * time.Second)
+		if lastPull != nil {
+			since := lastPull.Add(-2 * time.Second)
 			expiredSince = &since
 		}
 
-		err = writeDeltaDecisions(gctx, now, filters, expiredSince, c.DBClient.QueryExpiredDecisionsSinceWithFilters)
+		stats.deleted, err = writeDeltaDecisions(gctx, now, filters, expiredSince, src.QueryExpiredDecisionsSinceWithFilters)
 		if err != nil {
 			log.Errorf("failed sending expired decisions for delta: %v", err)
 			gctx.Writer.WriteString("]}")
 			gctx.Writer.Flush()
 
-			return err
+			return stats, err
 		}
 
 		gctx.Writer.WriteString("]}")
 		gctx.Writer.Flush()
 	}
 
-	return nil
+	return stats, nil
 }
 
 func (c *Controller) StreamDecision(gctx *gin.Context) {
@@ -378,17 +420,71 @@ func (c *Controller) StreamDecision(gctx *gin.Context) {
 	}
 
 	filters := gctx.Request.URL.Query()
+	requestedFilters := pullFilters(filters)
+
 	if _, ok := filters["scopes"]; !ok {
 		filters["scopes"] = []string{"ip,range"}
 	}
 
-	err = c.streamDecisions(gctx, bouncerInfo, streamStartTime, filters)
+	startup := gctx.Query("startup") == "true"
+
+	page, err := parseStreamPage(filters, startup, streamStartTime)
+	if err != nil {
+		gctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+
+		return
+	}
+
+	src, lastPull := c.decisionSource(bouncerInfo)
+
+	pull := schema.BouncerPull{
+		Time:     streamStartTime,
+		Endpoint: "stream",
+		Startup:  startup,
+		Filters:  requestedFilters,
+	}
+
+	if page != nil {
+		done, stats, err := c.streamStartupPage(gctx, src, page, filters)
+		if err != nil {
+			return
+		}
+
+		pull.New, pull.Deleted = stats.new, stats.deleted
+
+		// The snapshot is complete: the next delta starts from the time the first page was requested
+		var lastPull *time.Time
+		if done {
+			lastPull = &page.cursor.Snapshot
+		}
+
+		c.updateBouncerPull(bouncerInfo, lastPull, pull)
+
+		return
+	}
+
+	stats, err := c.streamDecisions(gctx, src, lastPull, streamStartTime, filters)
 
 	if err == nil {
+		pull.New, pull.Deleted = stats.new, stats.deleted
+
 		// Only update the last pull time if no error occurred when sending the decisions to avoid missing decisions
-		// Do not reuse the context provided by gin because we already have sent the response to the client, so there's a chance for it to already be canceled
-		if err := c.DBClient.UpdateBouncerLastPull(context.Background(), streamStartTime, bouncerInfo.ID); err != nil {
-			log.Errorf("unable to update bouncer '%s' pull: %v", bouncerInfo.Name, err)
-		}
+		c.updateBouncerPull(bouncerInfo, &streamStartTime, pull)
 	}
 }
+
+// pullStats counts the decisions sent to a bouncer in a pull.
+type pullStats struct {
+	new     int
+	deleted int
+}
+
+// pullFilters returns the filters requested by a bouncer, without the pagination and format parameters.
+func pullFilters(query url.Values) string {
+	filters := maps.Clone(query)
+	for _, key := range []string{"startup", "cursor", "page_size", "fields"} {
+		delete(filters, key)
+	}
+
+	return filters.Encode()
+}
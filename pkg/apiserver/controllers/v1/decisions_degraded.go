@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/database"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/schema"
+)
+
+// decisionSource has the queries of the decision stream. It's the database or, while it's
+// unavailable, its last snapshot (database.Snapshot), with the same deduplication and pagination.
+type decisionSource interface {
+	QueryAllDecisionsWithFilters(ctx context.Context, now time.Time, filter map[string][]string) ([]*ent.Decision, error)
+	QueryExpiredDecisionsWithFilters(ctx context.Context, now time.Time, filter map[string][]string) ([]*ent.Decision, error)
+	QueryNewDecisionsSinceWithFilters(ctx context.Context, now time.Time, since *time.Time, filter map[string][]string) ([]*ent.Decision, error)
+	QueryExpiredDecisionsSinceWithFilters(ctx context.Context, now time.Time, since *time.Time, filter map[string][]string) ([]*ent.Decision, error)
+}
+
+var (
+	_ decisionSource = (*database.Client)(nil)
+	_ decisionSource = (*database.Snapshot)(nil)
+)
+
+// decisionSource returns where to read the decisions sent to a bouncer, and its last pull.
+func (c *Controller) decisionSource(bouncerInfo *ent.Bouncer) (decisionSource, *time.Time) {
+	if !c.DBSupervisor.Degraded() {
+		return c.DBClient, bouncerInfo.LastPull
+	}
+
+	snapshot := c.DBSupervisor.Snapshot()
+
+	return &snapshot, c.DBSupervisor.LastPull(bouncerInfo)
+}
+
+// updateBouncerPull saves a pull, and the new last pull of the bouncer if not nil. In degraded
+// mode, the last pull is kept in memory and the pull is not saved: once the database is back, the
+// next delta covers the outage.
+func (c *Controller) updateBouncerPull(bouncerInfo *ent.Bouncer, lastPull *time.Time, pull schema.BouncerPull) {
+	if c.DBSupervisor.Degraded() {
+		if lastPull != nil {
+			c.DBSupervisor.SetLastPull(bouncerInfo.Name, *lastPull)
+		}
+
+		return
+	}
+
+	// Do not reuse the context provided by gin because we already have sent the response to the client, so there's a chance for it to already be canceled
+	if err := c.DBClient.UpdateBouncerPull(context.Background(), bouncerInfo, lastPull, pull); err != nil {
+		log.Errorf("unable to update bouncer '%s' pull: %v", bouncerInfo.Name, err)
+	}
+}
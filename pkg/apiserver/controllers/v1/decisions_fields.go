@@ -0,0 +1,112 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+)
+
+// decisionFields are the extra fields a bouncer can request with the fields parameter of /v1/decisions,
+// i.e. to show the reason of a ban on a remediation page.
+var decisionFields = []string{"scenario", "origin", "created_at", "confidence"}
+
+// compactDecision is a decision in the response of /v1/decisions when the fields parameter is given:
+// the fields needed to apply the decision, and the requested ones.
+type compactDecision struct {
+	ID         int64   `json:"id"`
+	Type       string  `json:"type"`
+	Scope      string  `json:"scope"`
+	Value      string  `json:"value"`
+	Duration   string  `json:"duration"`
+	Scenario   *string `json:"scenario,omitempty"`
+	Origin     *string `json:"origin,omitempty"`
+	CreatedAt  *string `json:"created_at,omitempty"`
+	Confidence *string `json:"confidence,omitempty"`
+}
+
+// parseDecisionFields extracts the fields query parameter, a comma separated list of decisionFields,
+// and removes it from the filters. It returns nil if the bouncer did not ask for the compact schema.
+func parseDecisionFields(filters map[string][]string) ([]string, error) {
+	val, ok := filters["fields"]
+
+	delete(filters, "fields")
+
+	if !ok {
+		return nil, nil
+	}
+
+	fields := []string{}
+
+	for field := range strings.SplitSeq(val[0], ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		if !slices.Contains(decisionFields, field) {
+			return nil, fmt.Errorf("invalid field '%s': must be one of %s", field, strings.Join(decisionFields, ", "))
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// labelValue returns the value of a key:value alert label, and an empty string if there is none.
+func labelValue(labels []string, key string) string {
+	for _, label := range labels {
+		if v, ok := strings.CutPrefix(label, key+":"); ok {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// formatCompactDecisions formats the decisions with the requested fields.
+func (c *Controller) formatCompactDecisions(ctx context.Context, decisions []*ent.Decision, fields []string) ([]compactDecision, error) {
+	var labels map[int][]string
+
+	if slices.Contains(fields, "confidence") {
+		var err error
+
+		labels, err = c.DBClient.AlertLabelsOfDecisions(ctx, decisions)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]compactDecision, 0, len(decisions))
+
+	for _, dbDecision := range decisions {
+		decision := compactDecision{
+			ID:       int64(dbDecision.ID),
+			Type:     dbDecision.Type,
+			Scope:    dbDecision.Scope,
+			Value:    dbDecision.Value,
+			Duration: dbDecision.Until.Sub(time.Now().UTC()).Round(time.Second).String(),
+		}
+
+		for _, field := range fields {
+			switch field {
+			case "scenario":
+				decision.Scenario = &dbDecision.Scenario
+			case "origin":
+				decision.Origin = &dbDecision.Origin
+			case "created_at":
+				decision.CreatedAt = new(dbDecision.CreatedAt.UTC().Format(time.RFC3339))
+			case "confidence":
+				decision.Confidence = new(labelValue(labels[dbDecision.AlertDecisions], "confidence"))
+			}
+		}
+
+		results = append(results, decision)
+	}
+
+	return results, nil
+}
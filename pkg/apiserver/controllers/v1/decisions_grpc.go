@@ -0,0 +1,432 @@
+package v1
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	middlewares "github.com/crowdsecurity/crowdsec/pkg/apiserver/middlewares/v1"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/schema"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/protobufs"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+)
+
+const (
+	// decisions per message, to stay well below the 4MB limit of the clients
+	grpcBatchSize = 1000
+	// changes kept for a stream that is slow to read them, before it's closed
+	grpcStreamQueueSize = 64
+	// interval of the updates of the last pull of the bouncers with an open stream
+	grpcPullUpdateInterval = time.Minute
+)
+
+// decisionChanges are the decisions created and expired between two polls of the database.
+type decisionChanges struct {
+	new     []*ent.Decision
+	deleted []*ent.Decision
+}
+
+type grpcSubscriber struct {
+	changes chan *decisionChanges
+	lagging chan struct{} // closed if the queue was full
+}
+
+// DecisionStream is the gRPC variant of /v1/decisions/stream. The database is polled once for all
+// the open streams, and the changes are filtered for each bouncer: the cost doesn't grow with the
+// number of bouncers, unlike the HTTP polling.
+type DecisionStream struct {
+	protobufs.UnimplementedDecisionsServer
+
+	c            *Controller
+	pollInterval time.Duration
+
+	mu          sync.Mutex
+	subscribers map[*grpcSubscriber]struct{}
+}
+
+func (c *Controller) NewDecisionStream(pollInterval time.Duration) *DecisionStream {
+	return &DecisionStream{
+		c:            c,
+		pollInterval: pollInterval,
+		subscribers:  map[*grpcSubscriber]struct{}{},
+	}
+}
+
+func (d *DecisionStream) subscribe() *grpcSubscriber {
+	sub := &grpcSubscriber{
+		changes: make(chan *decisionChanges, grpcStreamQueueSize),
+		lagging: make(chan struct{}),
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.subscribers[sub] = struct{}{}
+
+	return sub
+}
+
+func (d *DecisionStream) unsubscribe(sub *grpcSubscriber) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.subscribers, sub)
+}
+
+func (d *DecisionStream) hasSubscribers() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.subscribers) > 0
+}
+
+// broadcast queues the changes for all the streams. It never blocks: a stream whose queue is full
+// is closed, its bouncer reconnects and asks for the startup decisions again.
+func (d *DecisionStream) broadcast(changes *decisionChanges) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for sub := range d.subscribers {
+		select {
+		case sub.changes <- changes:
+		default:
+			close(sub.lagging)
+			delete(d.subscribers, sub)
+		}
+	}
+}
+
+// poll returns the decisions created and expired since the previous poll, with the same queries
+// as /v1/decisions/stream.
+func (d *DecisionStream) poll(ctx context.Context, since time.Time, now time.Time) (*decisionChanges, error) {
+	newDecisions, err := d.c.DBClient.QueryNewDecisionsSinceWithFilters(ctx, now, &since, map[string][]string{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Use a 2-second overlap to avoid missing decisions that expired around the last poll
+	expiredSince := since.Add(-2 * time.Second)
+
+	deleted, err := d.c.DBClient.QueryExpiredDecisionsSinceWithFilters(ctx, now, &expiredSince, map[string][]string{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &decisionChanges{new: newDecisions, deleted: deleted}, nil
+}
+
+// Run polls the database for the changes, and sends them to the open streams, until the context
+// is canceled.
+func (d *DecisionStream) Run(ctx context.Context) error {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	since := time.Now().UTC()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			now := time.Now().UTC()
+
+			// nobody to send the changes to: don't query the database
+			if !d.hasSubscribers() {
+				since = now
+				continue
+			}
+
+			changes, err := d.poll(ctx, since, now)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+
+				// try again on the next tick, from the same time
+				log.Errorf("gRPC decision stream: %s", err)
+
+				continue
+			}
+
+			since = now
+
+			if len(changes.new) > 0 || len(changes.deleted) > 0 {
+				d.broadcast(changes)
+			}
+		}
+	}
+}
+
+// grpcFilter has the filters of a stream.
+type grpcFilter struct {
+	scopes        []string
+	origins       []string
+	containing    []string // lower case
+	notContaining []string // lower case
+}
+
+func newGRPCFilter(req *protobufs.StreamRequest) grpcFilter {
+	f := grpcFilter{
+		scopes:  slices.Clone(req.GetScopes()),
+		origins: req.GetOrigins(),
+	}
+
+	if len(f.scopes) == 0 {
+		f.scopes = []string{"ip", "range"}
+	}
+
+	// same names as in applyDecisionFilter
+	for i, scope := range f.scopes {
+		switch strings.ToLower(scope) {
+		case "ip":
+			f.scopes[i] = types.Ip
+		case "range":
+			f.scopes[i] = types.Range
+		case "country":
+			f.scopes[i] = types.Country
+		case "as":
+			f.scopes[i] = types.AS
+		}
+	}
+
+	for _, s := range req.GetScenariosContaining() {
+		f.containing = append(f.containing, strings.ToLower(s))
+	}
+
+	for _, s := range req.GetScenariosNotContaining() {
+		f.notContaining = append(f.notContaining, strings.ToLower(s))
+	}
+
+	return f
+}
+
+// query returns the filters for the database queries of the startup decisions.
+func (f grpcFilter) query() map[string][]string {
+	filters := map[string][]string{
+		"scopes": {strings.Join(f.scopes, ",")},
+	}
+
+	if len(f.origins) > 0 {
+		filters["origins"] = []string{strings.Join(f.origins, ",")}
+	}
+
+	if len(f.containing) > 0 {
+		filters["scenarios_containing"] = []string{strings.Join(f.containing, ",")}
+	}
+
+	if len(f.notContaining) > 0 {
+		filters["scenarios_not_containing"] = []string{strings.Join(f.notContaining, ",")}
+	}
+
+	return filters
+}
+
+func containsAny(s string, words []string) bool {
+	s = strings.ToLower(s)
+
+	for _, word := range words {
+		if strings.Contains(s, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// match applies the filters of the stream to a decision, like the database queries do.
+func (f grpcFilter) match(d *ent.Decision) bool {
+	if !slices.ContainsFunc(f.scopes, func(scope string) bool { return strings.EqualFold(scope, d.Scope) }) {
+		return false
+	}
+
+	if len(f.origins) > 0 && !slices.Contains(f.origins, d.Origin) {
+		return false
+	}
+
+	if len(f.containing) > 0 && !containsAny(d.Scenario, f.containing) {
+		return false
+	}
+
+	if len(f.notContaining) > 0 && containsAny(d.Scenario, f.notContaining) {
+		return false
+	}
+
+	return true
+}
+
+func protoDecision(d *models.Decision) *protobufs.Decision {
+	return &protobufs.Decision{
+		Id:       d.ID,
+		Uuid:     d.UUID,
+		Origin:   *d.Origin,
+		Type:     *d.Type,
+		Scope:    *d.Scope,
+		Value:    *d.Value,
+		Scenario: *d.Scenario,
+		Duration: *d.Duration,
+	}
+}
+
+// formatGRPCDecisions formats the decisions that match the filters of a stream.
+func formatGRPCDecisions(decisions []*ent.Decision, filter grpcFilter) []*protobufs.Decision {
+	ret := make([]*protobufs.Decision, 0, len(decisions))
+
+	for _, d := range decisions {
+		if !filter.match(d) {
+			continue
+		}
+
+		ret = append(ret, protoDecision(formatOneDecision(d)))
+	}
+
+	return ret
+}
+
+// sendChanges sends the decisions to add and to remove, in messages of grpcBatchSize decisions.
+func sendChanges(stream grpc.ServerStreamingServer[protobufs.DecisionChanges], newDecisions []*protobufs.Decision, deleted []*protobufs.Decision) error {
+	for batch := range slices.Chunk(newDecisions, grpcBatchSize) {
+		if err := stream.Send(&protobufs.DecisionChanges{New: batch}); err != nil {
+			return err
+		}
+	}
+
+	for batch := range slices.Chunk(deleted, grpcBatchSize) {
+		if err := stream.Send(&protobufs.DecisionChanges{Deleted: batch}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// authenticate returns the bouncer of the API key in the metadata of the stream. Unlike the HTTP
+// API, no bouncer is created for a key shared by several bouncers: the first one is used.
+func (d *DecisionStream) authenticate(ctx context.Context) (*ent.Bouncer, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	keys := md.Get(strings.ToLower(middlewares.APIKeyHeader))
+	if len(keys) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing API key")
+	}
+
+	bouncers, err := d.c.DBClient.SelectBouncers(ctx, middlewares.HashSHA512(keys[0]), types.ApiKeyAuthType)
+	if err != nil {
+		log.Errorf("while fetching bouncer info: %s", err)
+		return nil, status.Error(codes.Internal, "unable to authenticate")
+	}
+
+	if len(bouncers) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "access forbidden")
+	}
+
+	return bouncers[0], nil
+}
+
+// sendStartup sends the active decisions, read by pages like /v1/decisions/stream does.
+func (d *DecisionStream) sendStartup(ctx context.Context, stream grpc.ServerStreamingServer[protobufs.DecisionChanges], filter grpcFilter, now time.Time) (int, error) {
+	limit := 30000
+	sent := 0
+	lastID := 0
+
+	for {
+		filters := filter.query()
+		filters["limit"] = []string{strconv.Itoa(limit)}
+
+		if lastID > 0 {
+			filters["id_gt"] = []string{strconv.Itoa(lastID)}
+		}
+
+		data, err := d.c.DBClient.QueryAllDecisionsWithFilters(ctx, now, filters)
+		if err != nil {
+			return sent, err
+		}
+
+		decisions := formatGRPCDecisions(data, filter)
+		if err := sendChanges(stream, decisions, nil); err != nil {
+			return sent, err
+		}
+
+		sent += len(decisions)
+
+		if len(data) < limit {
+			return sent, nil
+		}
+
+		lastID = data[len(data)-1].ID
+	}
+}
+
+// Stream sends the active decisions if the bouncer asks for them, then the changes until it
+// disconnects or the local API stops.
+func (d *DecisionStream) Stream(req *protobufs.StreamRequest, stream grpc.ServerStreamingServer[protobufs.DecisionChanges]) error {
+	ctx := stream.Context()
+
+	bouncer, err := d.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+
+	logger := log.WithField("bouncer", bouncer.Name)
+	filter := newGRPCFilter(req)
+
+	// subscribe first, the changes made while the startup decisions are sent are not missed
+	sub := d.subscribe()
+	defer d.unsubscribe(sub)
+
+	now := time.Now().UTC()
+
+	pull := schema.BouncerPull{
+		Time:     now,
+		Endpoint: "grpc",
+		Startup:  req.GetStartup(),
+		Filters:  pullFilters(filter.query()),
+	}
+
+	if req.GetStartup() {
+		pull.New, err = d.sendStartup(ctx, stream, filter, now)
+		if err != nil {
+			logger.Errorf("failed sending startup decisions: %s", err)
+			return status.Error(codes.Internal, "unable to send the startup decisions")
+		}
+	}
+
+	if err := d.c.DBClient.UpdateBouncerPull(ctx, bouncer, &now, pull); err != nil {
+		logger.Errorf("unable to update bouncer '%s' pull: %v", bouncer.Name, err)
+	}
+
+	// the stream is recorded once in the pull history, then only the last pull is updated, for
+	// the bouncer not to look inactive
+	ticker := time.NewTicker(grpcPullUpdateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sub.lagging:
+			return status.Error(codes.ResourceExhausted, "too many pending changes, reconnect with startup")
+		case t := <-ticker.C:
+			if err := d.c.DBClient.UpdateBouncerLastPull(ctx, bouncer.ID, t.UTC()); err != nil {
+				logger.Errorf("unable to update bouncer '%s' last pull: %v", bouncer.Name, err)
+			}
+		case changes := <-sub.changes:
+			newDecisions := formatGRPCDecisions(changes.new, filter)
+			deleted := formatGRPCDecisions(changes.deleted, filter)
+
+			if err := sendChanges(stream, newDecisions, deleted); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/protobufs"
+)
+
+func TestGRPCFilter(t *testing.T) {
+	ip := &ent.Decision{Scope: "Ip", Origin: "crowdsec", Scenario: "crowdsecurity/ssh-bf"}
+	rng := &ent.Decision{Scope: "Range", Origin: "CAPI", Scenario: "crowdsecurity/http-probing"}
+	country := &ent.Decision{Scope: "Country", Origin: "cscli", Scenario: "manual 'ban' from 'localhost'"}
+
+	tests := []struct {
+		name     string
+		req      *protobufs.StreamRequest
+		expected []*ent.Decision
+		query    map[string][]string
+	}{
+		{
+			name:     "default scopes",
+			req:      &protobufs.StreamRequest{},
+			expected: []*ent.Decision{ip, rng},
+			query:    map[string][]string{"scopes": {"Ip,Range"}},
+		},
+		{
+			name:     "scopes",
+			req:      &protobufs.StreamRequest{Scopes: []string{"country", "RANGE"}},
+			expected: []*ent.Decision{rng, country},
+			query:    map[string][]string{"scopes": {"Country,Range"}},
+		},
+		{
+			name:     "origins",
+			req:      &protobufs.StreamRequest{Origins: []string{"crowdsec", "cscli"}},
+			expected: []*ent.Decision{ip},
+			query:    map[string][]string{"scopes": {"Ip,Range"}, "origins": {"crowdsec,cscli"}},
+		},
+		{
+			name:     "scenarios containing",
+			req:      &protobufs.StreamRequest{ScenariosContaining: []string{"SSH", "manual"}, Scopes: []string{"ip", "country"}},
+			expected: []*ent.Decision{ip, country},
+			query:    map[string][]string{"scopes": {"Ip,Country"}, "scenarios_containing": {"ssh,manual"}},
+		},
+		{
+			name:     "scenarios not containing",
+			req:      &protobufs.StreamRequest{ScenariosNotContaining: []string{"http"}},
+			expected: []*ent.Decision{ip},
+			query:    map[string][]string{"scopes": {"Ip,Range"}, "scenarios_not_containing": {"http"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newGRPCFilter(tc.req)
+
+			var matched []*ent.Decision
+
+			for _, d := range []*ent.Decision{ip, rng, country} {
+				if f.match(d) {
+					matched = append(matched, d)
+				}
+			}
+
+			assert.Equal(t, tc.expected, matched)
+			assert.Equal(t, tc.query, f.query())
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+)
+
+// maxStreamPageSize is the largest page a bouncer can request on a paginated startup pull.
+// It matches the chunk size used when the whole snapshot is sent in a single response.
+const maxStreamPageSize = 30000
+
+// streamCursor is the position of a bouncer in a paginated startup snapshot.
+// It is sent back to the bouncer as an opaque string and must be provided as-is to get the next page.
+type streamCursor struct {
+	// Snapshot is the time of the first page, reused for every page so that
+	// the whole snapshot is consistent and the last pull time can be set accordingly.
+	Snapshot time.Time `json:"t"`
+	// Deleted is true once all the active decisions have been sent.
+	Deleted bool `json:"d,omitempty"`
+	// LastID is the ID of the last decision sent in the current phase.
+	LastID int `json:"id,omitempty"`
+}
+
+func (c streamCursor) encode() string {
+	b, _ := json.Marshal(c) // cannot fail, the struct only has plain fields
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeStreamCursor(s string) (streamCursor, error) {
+	var c streamCursor
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, errors.New("invalid cursor")
+	}
+
+	if err := json.Unmarshal(b, &c); err != nil || c.Snapshot.IsZero() || c.LastID < 0 {
+		return c, errors.New("invalid cursor")
+	}
+
+	return c, nil
+}
+
+// streamPage holds the pagination parameters of a startup pull.
+type streamPage struct {
+	size   int
+	cursor streamCursor
+}
+
+// parseStreamPage extracts the page_size and cursor query parameters and removes them
+// from the filters. It returns nil if the bouncer did not ask for a paginated response.
+func parseStreamPage(filters map[string][]string, startup bool, now time.Time) (*streamPage, error) {
+	sizeVal, hasSize := filters["page_size"]
+	cursorVal, hasCursor := filters["cursor"]
+
+	delete(filters, "page_size")
+	delete(filters, "cursor")
+
+	if !hasSize && !hasCursor {
+		return nil, nil
+	}
+
+	if !startup {
+		return nil, errors.New("page_size and cursor can only be used with startup=true")
+	}
+
+	page := streamPage{
+		size:   maxStreamPageSize,
+		cursor: streamCursor{Snapshot: now},
+	}
+
+	if hasSize {
+		size, err := strconv.Atoi(sizeVal[0])
+		if err != nil || size < 1 || size > maxStreamPageSize {
+			return nil, fmt.Errorf("page_size must be an integer between 1 and %d", maxStreamPageSize)
+		}
+
+		page.size = size
+	}
+
+	if hasCursor && cursorVal[0] != "" {
+		cursor, err := decodeStreamCursor(cursorVal[0])
+		if err != nil {
+			return nil, err
+		}
+
+		page.cursor = cursor
+	}
+
+	return &page, nil
+}
+
+// writeDecisionsPage sends at most limit decisions with an ID greater than lastID, in a single query.
+// It returns the number of decisions read from the database and the ID of the last one.
+func writeDecisionsPage(gctx *gin.Context, now time.Time, filters map[string][]string, limit int, lastID int, dbFunc func(context.Context, time.Time, map[string][]string) ([]*ent.Decision, error)) (int, int, error) {
+	filters["limit"] = []string{strconv.Itoa(limit)}
+	delete(filters, "id_gt")
+
+	if lastID > 0 {
+		filters["id_gt"] = []string{strconv.Itoa(lastID)}
+	}
+
+	data, err := dbFunc(gctx.Request.Context(), now, filters)
+	if err != nil {
+		return 0, lastID, err
+	}
+
+	// We write to a buffer instead of directly to the writer to avoid the \n added by enc.Encode()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	needComma := false
+
+	for _, d := range data {
+		if needComma {
+			gctx.Writer.WriteString(",")
+		} else {
+			needComma = true
+		}
+
+		buf.Reset()
+		if err := enc.Encode(formatOneDecision(d)); err != nil {
+			return 0, lastID, err
+		}
+
+		if _, err := gctx.Writer.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))); err != nil {
+			return 0, lastID, err
+		}
+	}
+
+	if len(data) > 0 {
+		lastID = data[len(data)-1].ID
+	}
+
+	return len(data), lastID, nil
+}
+
+// streamStartupPage sends one page of the startup snapshot: the active decisions first, then the
+// expired ones, up to page.size decisions in total. The response has a next_cursor field as long
+// as there are decisions left to send. It returns true when the snapshot is complete, and the
+// number of decisions sent.
+func (c *Controller) streamStartupPage(gctx *gin.Context, src decisionSource, page *streamPage, filters map[string][]string) (bool, pullStats, error) {
+	var stats pullStats
+
+	cursor := page.cursor
+	budget := page.size
+	done := false
+
+	gctx.Writer.Header().Set("Content-Type", "application/json")
+	gctx.Writer.Header().Set("Transfer-Encoding", "chunked")
+	gctx.Writer.WriteHeader(http.StatusOK)
+	gctx.Writer.WriteString(`{"new": [`)
+
+	if !cursor.Deleted {
+		n, lastID, err := writeDecisionsPage(gctx, cursor.Snapshot, filters, budget, cursor.LastID, src.QueryAllDecisionsWithFilters)
+		if err != nil {
+			log.Errorf("failed sending new decisions for startup page: %v", err)
+			gctx.Writer.WriteString(`], "deleted": []}`)
+			gctx.Writer.Flush()
+
+			return false, stats, err
+		}
+
+		stats.new = n
+
+		log.Debugf("startup page: %d new decisions returned (limit: %d, lastid: %d)", n, budget, lastID)
+
+		cursor.LastID = lastID
+
+		if n < budget {
+			// no more active decisions, fill the rest of the page with the expired ones
+			budget -= n
+			cursor = streamCursor{Snapshot: cursor.Snapshot, Deleted: true}
+		} else {
+			budget = 0
+		}
+	}
+
+	gctx.Writer.WriteString(`], "deleted": [`)
+
+	if cursor.Deleted && budget > 0 {
+		n, lastID, err := writeDecisionsPage(gctx, cursor.Snapshot, filters, budget, cursor.LastID, src.QueryExpiredDecisionsWithFilters)
+		if err != nil {
+			log.Errorf("failed sending expired decisions for startup page: %v", err)
+			gctx.Writer.WriteString(`]}`)
+			gctx.Writer.Flush()
+
+			return false, stats, err
+		}
+
+		stats.deleted = n
+
+		log.Debugf("startup page: %d deleted decisions returned (limit: %d, lastid: %d)", n, budget, lastID)
+
+		cursor.LastID = lastID
+		done = n < budget
+	}
+
+	gctx.Writer.WriteString(`]`)
+
+	if !done {
+		gctx.Writer.WriteString(`, "next_cursor": "` + cursor.encode() + `"`)
+	}
+
+	gctx.Writer.WriteString(`}`)
+	gctx.Writer.Flush()
+
+	return done, stats, nil
+}
@@ -9,10 +9,13 @@ import (
 	"github.com/go-openapi/strfmt"
 	log "github.com/sirupsen/logrus"
 
+	middlewares "github.com/crowdsecurity/crowdsec/pkg/apiserver/middlewares/v1"
 	"github.com/crowdsecurity/crowdsec/pkg/models"
 	"github.com/crowdsecurity/crowdsec/pkg/types"
 )
 
+var errInvalidRegistrationToken = errors.New("invalid token for auto registration")
+
 func (c *Controller) shouldAutoRegister(token string, gctx *gin.Context) (bool, error) {
 	if c.AutoRegisterCfg == nil || c.AutoRegisterCfg.Enable == nil || !*c.AutoRegisterCfg.Enable {
 		return false, nil
@@ -32,7 +35,7 @@ func (c *Controller) shouldAutoRegister(token string, gctx *gin.Context) (bool,
 
 	// Check the token
 	if token != c.AutoRegisterCfg.Token {
-		return false, errors.New("invalid token for auto registration")
+		return false, errInvalidRegistrationToken
 	}
 
 	// Check the source IP
@@ -61,6 +64,35 @@ func (c *Controller) CreateMachine(gctx *gin.Context) {
 	}
 
 	autoRegister, err := c.shouldAutoRegister(input.RegistrationToken, gctx)
+
+	oneTimeToken := ""
+
+	// not the token from the configuration: it can be a one-time token created with "cscli machines token create"
+	if !autoRegister && input.RegistrationToken != "" && (err == nil || errors.Is(err, errInvalidRegistrationToken)) {
+		tokenHash := middlewares.HashSHA512(input.RegistrationToken)
+
+		used, useErr := c.DBClient.UseRegistrationToken(ctx, tokenHash, *input.MachineID)
+		if useErr != nil {
+			c.HandleDBErrors(gctx, useErr)
+			return
+		}
+
+		if used {
+			autoRegister, err = true, nil
+			oneTimeToken = tokenHash
+		} else {
+			exists, existErr := c.DBClient.RegistrationTokenExists(ctx, tokenHash)
+			if existErr != nil {
+				c.HandleDBErrors(gctx, existErr)
+				return
+			}
+
+			if exists {
+				err = errors.New("registration token expired or already used")
+			}
+		}
+	}
+
 	if err != nil {
 		log.WithFields(log.Fields{"ip": gctx.ClientIP(), "machine_id": *input.MachineID}).Errorf("Auto-register failed: %s", err)
 		gctx.JSON(http.StatusUnauthorized, gin.H{"message": err.Error()})
@@ -69,7 +101,15 @@ func (c *Controller) CreateMachine(gctx *gin.Context) {
 	}
 
 	if _, err := c.DBClient.CreateMachine(ctx, input.MachineID, input.Password, gctx.ClientIP(), autoRegister, false, types.PasswordAuthType); err != nil {
+		if oneTimeToken != "" {
+			// the token can be used again, i.e. with another machine name
+			if err := c.DBClient.ReleaseRegistrationToken(ctx, oneTimeToken); err != nil {
+				log.Errorf("unable to release registration token: %s", err)
+			}
+		}
+
 		c.HandleDBErrors(gctx, err)
+
 		return
 	}
 
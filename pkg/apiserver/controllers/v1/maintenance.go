@@ -0,0 +1,205 @@
+package v1
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+)
+
+// maxHeld is the number of notifications, and of alerts for the Central API, kept during the
+// maintenance windows. Beyond it, the oldest ones are dropped.
+const maxHeld = 10000
+
+type heldNotification struct {
+	window string
+	alert  models.ProfileAlert
+}
+
+type heldAlert struct {
+	window string
+	alert  *models.Alert
+}
+
+// Maintenance holds the notifications and the signals of the alerts raised during the maintenance
+// windows, until the windows are over. They are kept in memory: the ones held when the local API
+// stops are lost, the alerts are in the database anyway.
+type Maintenance struct {
+	windows csconfig.MaintenanceWindowsCfg
+
+	mu            sync.Mutex
+	notifications []heldNotification
+	alerts        []heldAlert
+}
+
+func NewMaintenance(windows csconfig.MaintenanceWindowsCfg) *Maintenance {
+	if len(windows) == 0 {
+		return nil
+	}
+
+	return &Maintenance{windows: windows}
+}
+
+// window returns the name of the window an alert is raised in, if any.
+func (m *Maintenance) window(alert *models.Alert, now time.Time) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+
+	scenario := ""
+	if alert.Scenario != nil {
+		scenario = *alert.Scenario
+	}
+
+	w, ok := m.windows.Lookup(now, scenario, alert.MachineID)
+	if !ok {
+		return "", false
+	}
+
+	return w.Name, true
+}
+
+// holdNotification keeps the notification of an alert if it's raised during a window.
+func (m *Maintenance) holdNotification(alert models.ProfileAlert, now time.Time) bool {
+	name, ok := m.window(alert.Alert, now)
+	if !ok {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.notifications) >= maxHeld {
+		log.Warningf("maintenance window %s: too many held notifications, dropping the oldest one", name)
+		m.notifications = m.notifications[1:]
+	}
+
+	m.notifications = append(m.notifications, heldNotification{window: name, alert: alert})
+
+	log.Debugf("maintenance window %s: holding the notification of %s", name, alert.Alert.GetScenario())
+
+	return true
+}
+
+// holdAlerts keeps the alerts raised during a window, and returns the ones to send to the Central
+// API now.
+func (m *Maintenance) holdAlerts(alerts []*models.Alert, now time.Time) []*models.Alert {
+	if m == nil {
+		return alerts
+	}
+
+	ret := make([]*models.Alert, 0, len(alerts))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, alert := range alerts {
+		name, ok := m.window(alert, now)
+		if !ok {
+			ret = append(ret, alert)
+			continue
+		}
+
+		if len(m.alerts) >= maxHeld {
+			log.Warningf("maintenance window %s: too many held alerts, dropping the oldest one", name)
+			m.alerts = m.alerts[1:]
+		}
+
+		m.alerts = append(m.alerts, heldAlert{window: name, alert: alert})
+	}
+
+	return ret
+}
+
+// release returns the notifications and the alerts of the windows that are over.
+func (m *Maintenance) release(now time.Time) ([]models.ProfileAlert, []*models.Alert) {
+	active := map[string]bool{}
+
+	for i := range m.windows {
+		active[m.windows[i].Name] = m.windows[i].Active(now)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		notifications []models.ProfileAlert
+		alerts        []*models.Alert
+	)
+
+	keptNotifications := m.notifications[:0]
+
+	for _, n := range m.notifications {
+		if active[n.window] {
+			keptNotifications = append(keptNotifications, n)
+			continue
+		}
+
+		notifications = append(notifications, n.alert)
+	}
+
+	m.notifications = keptNotifications
+
+	keptAlerts := m.alerts[:0]
+
+	for _, a := range m.alerts {
+		if active[a.window] {
+			keptAlerts = append(keptAlerts, a)
+			continue
+		}
+
+		alerts = append(alerts, a.alert)
+	}
+
+	m.alerts = keptAlerts
+
+	return notifications, alerts
+}
+
+// flushMaintenance sends the notifications and the alerts of the windows that are over.
+func (c *Controller) flushMaintenance(ctx context.Context, now time.Time) {
+	notifications, alerts := c.Maintenance.release(now)
+
+	if len(notifications) == 0 && len(alerts) == 0 {
+		return
+	}
+
+	log.Infof("maintenance window over: sending %d held notifications and %d held alerts", len(notifications), len(alerts))
+
+	for _, n := range notifications {
+		c.sendToPluginChannel(n)
+	}
+
+	if len(alerts) == 0 || c.AlertsAddChan == nil {
+		return
+	}
+
+	select {
+	case c.AlertsAddChan <- alerts:
+	case <-ctx.Done():
+	}
+}
+
+// RunMaintenance sends what was held during the maintenance windows when they are over, until the
+// context is canceled.
+func (c *Controller) RunMaintenance(ctx context.Context, interval time.Duration) error {
+	if c.Maintenance == nil {
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			c.flushMaintenance(ctx, now)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+)
+
+func TestMaintenance(t *testing.T) {
+	windows := csconfig.MaintenanceWindowsCfg{
+		{Name: "chaos", From: "10:00", To: "11:00", Timezone: "UTC", Scenarios: []string{"crowdsecurity/http-*"}},
+	}
+
+	require.NoError(t, windows.Validate())
+
+	m := NewMaintenance(windows)
+	require.NotNil(t, m)
+
+	during := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)
+	after := time.Date(2026, 3, 4, 11, 0, 0, 0, time.UTC)
+
+	probing := &models.Alert{Scenario: new("crowdsecurity/http-probing"), MachineID: "web-1"}
+	sshBF := &models.Alert{Scenario: new("crowdsecurity/ssh-bf"), MachineID: "web-1"}
+
+	assert.True(t, m.holdNotification(models.ProfileAlert{ProfileID: 1, Alert: probing}, during))
+	assert.False(t, m.holdNotification(models.ProfileAlert{ProfileID: 1, Alert: sshBF}, during))
+	assert.False(t, m.holdNotification(models.ProfileAlert{ProfileID: 1, Alert: probing}, after))
+
+	assert.Equal(t, []*models.Alert{sshBF}, m.holdAlerts([]*models.Alert{probing, sshBF}, during))
+	assert.Equal(t, []*models.Alert{probing}, m.holdAlerts([]*models.Alert{probing}, after))
+
+	// the window is still open
+	notifications, alerts := m.release(during)
+	assert.Empty(t, notifications)
+	assert.Empty(t, alerts)
+
+	notifications, alerts = m.release(after)
+	require.Len(t, notifications, 1)
+	assert.Equal(t, probing, notifications[0].Alert)
+	assert.Equal(t, []*models.Alert{probing}, alerts)
+
+	// everything was released
+	notifications, alerts = m.release(after)
+	assert.Empty(t, notifications)
+	assert.Empty(t, alerts)
+}
+
+func TestMaintenanceNoWindow(t *testing.T) {
+	m := NewMaintenance(nil)
+	require.Nil(t, m)
+
+	alert := &models.Alert{Scenario: new("crowdsecurity/ssh-bf")}
+
+	assert.False(t, m.holdNotification(models.ProfileAlert{Alert: alert}, time.Now()))
+	assert.Equal(t, []*models.Alert{alert}, m.holdAlerts([]*models.Alert{alert}, time.Now()))
+}
+
+func TestFlushMaintenance(t *testing.T) {
+	windows := csconfig.MaintenanceWindowsCfg{
+		{Name: "chaos", From: "10:00", To: "11:00", Timezone: "UTC"},
+	}
+
+	require.NoError(t, windows.Validate())
+
+	c := &Controller{
+		Maintenance:   NewMaintenance(windows),
+		PluginChannel: make(chan models.ProfileAlert, 1),
+		AlertsAddChan: make(chan []*models.Alert, 1),
+	}
+
+	alert := &models.Alert{Scenario: new("crowdsecurity/ssh-bf")}
+	during := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)
+
+	require.True(t, c.Maintenance.holdNotification(models.ProfileAlert{Alert: alert}, during))
+	require.Empty(t, c.Maintenance.holdAlerts([]*models.Alert{alert}, during))
+
+	c.flushMaintenance(t.Context(), during.Add(time.Hour))
+
+	assert.Equal(t, alert, (<-c.PluginChannel).Alert)
+	assert.Equal(t, []*models.Alert{alert}, <-c.AlertsAddChan)
+}
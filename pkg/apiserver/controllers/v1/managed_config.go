@@ -0,0 +1,38 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/crowdsecurity/crowdsec/pkg/managedconfig"
+)
+
+// GetManagedConfig returns the acquisition pushed by the console to the machine.
+func (c *Controller) GetManagedConfig(gctx *gin.Context) {
+	machineID, err := getMachineIDFromContext(gctx)
+	if err != nil {
+		gctx.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+
+	snippets, err := c.DBClient.GetManagedConfig(gctx.Request.Context())
+	if err != nil {
+		c.HandleDBErrors(gctx, err)
+		return
+	}
+
+	ret := []managedconfig.Snippet{}
+
+	for _, s := range snippets {
+		if s.Kind != managedconfig.KindAcquisition || !s.ForMachine(machineID) {
+			continue
+		}
+
+		// the other machines don't need to be known
+		s.Machines = nil
+		ret = append(ret, s)
+	}
+
+	gctx.JSON(http.StatusOK, ret)
+}
@@ -1,9 +1,20 @@
 package apiserver
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/gin-gonic/gin"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstime"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
 )
 
 const (
@@ -190,6 +201,56 @@ func TestGetDecision(t *testing.T) {
 	assert.Len(t, decisions, 3)
 }
 
+func TestGetDecisionFields(t *testing.T) {
+	ctx := t.Context()
+	lapi := SetupLAPITest(t, ctx)
+
+	lapi.InsertAlertFromFile(t, ctx, "./tests/alert_labels.json")
+	lapi.InsertAlertFromFile(t, ctx, "./tests/alert_sample.json")
+
+	w := lapi.RecordResponse(t, ctx, "GET", "/v1/decisions?ip=192.0.2.1&fields=scenario,confidence,created_at", emptyBody, APIKEY)
+	require.Equal(t, 200, w.Code)
+
+	var decisions []map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &decisions))
+	require.Len(t, decisions, 1)
+
+	assert.Equal(t, "crowdsecurity/ssh-bf", decisions[0]["scenario"])
+	assert.Equal(t, "high", decisions[0]["confidence"])
+	assert.Equal(t, "192.0.2.1", decisions[0]["value"])
+	assert.Equal(t, "ban", decisions[0]["type"])
+	assert.Contains(t, decisions[0], "duration")
+	assert.NotContains(t, decisions[0], "origin")
+
+	createdAt, err := time.Parse(time.RFC3339, decisions[0]["created_at"].(string))
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), createdAt, time.Minute)
+
+	// the scenario of the other alert has no confidence label
+	w = lapi.RecordResponse(t, ctx, "GET", "/v1/decisions?ip=127.0.0.1&fields=origin,confidence", emptyBody, APIKEY)
+	require.Equal(t, 200, w.Code)
+
+	var others []map[string]any
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &others))
+	require.Len(t, others, 3)
+
+	assert.Equal(t, "test", others[0]["origin"])
+	assert.Empty(t, others[0]["confidence"])
+	assert.Contains(t, others[0], "confidence")
+	assert.NotContains(t, others[0], "scenario")
+
+	// without fields, the response is unchanged
+	w = lapi.RecordResponse(t, ctx, "GET", "/v1/decisions?ip=192.0.2.1", emptyBody, APIKEY)
+	full, code := readDecisionsGetResp(t, w)
+	require.Equal(t, 200, code)
+	require.Len(t, full, 1)
+	assert.Equal(t, "crowdsec", *full[0].Origin)
+
+	w = lapi.RecordResponse(t, ctx, "GET", "/v1/decisions?fields=scenario,reason", emptyBody, APIKEY)
+	assert.Equal(t, 400, w.Code)
+	assert.JSONEq(t, `{"message": "invalid field 'reason': must be one of scenario, origin, created_at, confidence"}`, w.Body.String())
+}
+
 func TestDeleteDecisionByID(t *testing.T) {
 	ctx := t.Context()
 	lapi := SetupLAPITest(t, ctx)
@@ -319,6 +380,40 @@ func TestStreamStartDecisionDedup(t *testing.T) {
 	assert.Empty(t, decisions["new"])
 }
 
+func TestStreamPullHistory(t *testing.T) {
+	ctx := t.Context()
+	lapi := SetupLAPITest(t, ctx)
+
+	lapi.InsertAlertFromFile(t, ctx, "./tests/alert_sample.json")
+
+	w := lapi.RecordResponse(t, ctx, "GET", "/v1/decisions/stream?startup=true&scenarios_containing=test", emptyBody, APIKEY)
+	require.Equal(t, 200, w.Code)
+
+	w = lapi.RecordResponse(t, ctx, "DELETE", "/v1/decisions", emptyBody, PASSWORD)
+	require.Equal(t, 200, w.Code)
+
+	w = lapi.RecordResponse(t, ctx, "GET", "/v1/decisions/stream", emptyBody, APIKEY)
+	require.Equal(t, 200, w.Code)
+
+	bouncer, err := lapi.DBClient.SelectBouncerByName(ctx, "test")
+	require.NoError(t, err)
+	require.NotNil(t, bouncer.LastPull)
+	require.Len(t, bouncer.PullHistory, 2)
+
+	startup := bouncer.PullHistory[0]
+	assert.Equal(t, "stream", startup.Endpoint)
+	assert.True(t, startup.Startup)
+	assert.Equal(t, "scenarios_containing=test", startup.Filters)
+	assert.Equal(t, 1, startup.New)
+	assert.Equal(t, 0, startup.Deleted)
+
+	delta := bouncer.PullHistory[1]
+	assert.False(t, delta.Startup)
+	assert.Empty(t, delta.Filters)
+	assert.Equal(t, 0, delta.New)
+	assert.Equal(t, 3, delta.Deleted)
+}
+
 type DecisionCheck struct {
 	ID       int64
 	Origin   string
@@ -340,3 +435,261 @@ type DecisionTest struct {
 	DelChecks     []DecisionCheck
 	AuthType      string
 }
+
+func TestStreamStartupPagination(t *testing.T) {
+	ctx := t.Context()
+	lapi := SetupLAPITest(t, ctx)
+
+	lapi.InsertAlertFromFile(t, ctx, "./tests/alert_minibulk.json")
+	lapi.InsertAlertFromFile(t, ctx, "./tests/alert_sample.json")
+
+	// have some deleted decisions as well
+	w := lapi.RecordResponse(t, ctx, "DELETE", "/v1/decisions/1", emptyBody, PASSWORD)
+	require.Equal(t, 200, w.Code)
+
+	w = lapi.RecordResponse(t, ctx, "GET", "/v1/decisions/stream?startup=true", emptyBody, APIKEY)
+	full, code := readDecisionsStreamResp(t, w)
+	require.Equal(t, 200, code)
+	require.NotEmpty(t, full["new"])
+	require.NotEmpty(t, full["deleted"])
+
+	idsOf := func(decisions []*models.Decision) []int64 {
+		ids := []int64{}
+		for _, d := range decisions {
+			ids = append(ids, d.ID)
+		}
+
+		return ids
+	}
+
+	var newIDs, deletedIDs []int64
+
+	cursor := ""
+	pages := 0
+
+	for {
+		w = lapi.RecordResponse(t, ctx, "GET", "/v1/decisions/stream?startup=true&page_size=1&cursor="+cursor, emptyBody, APIKEY)
+		require.Equal(t, 200, w.Code)
+
+		page := models.DecisionsStreamResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		assert.LessOrEqual(t, len(page.New)+len(page.Deleted), 1)
+
+		newIDs = append(newIDs, idsOf(page.New)...)
+		deletedIDs = append(deletedIDs, idsOf(page.Deleted)...)
+		pages++
+
+		if page.NextCursor == "" {
+			break
+		}
+
+		require.Less(t, pages, 100, "pagination does not end")
+
+		cursor = page.NextCursor
+	}
+
+	assert.Equal(t, idsOf(full["new"]), newIDs)
+	assert.Equal(t, idsOf(full["deleted"]), deletedIDs)
+	assert.GreaterOrEqual(t, pages, len(newIDs)+len(deletedIDs))
+}
+
+func TestStreamStartupPaginationErrors(t *testing.T) {
+	ctx := t.Context()
+	lapi := SetupLAPITest(t, ctx)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "page_size without startup",
+			query:    "page_size=10",
+			expected: "page_size and cursor can only be used with startup=true",
+		},
+		{
+			name:     "page_size not a number",
+			query:    "startup=true&page_size=ten",
+			expected: "page_size must be an integer between 1 and 30000",
+		},
+		{
+			name:     "page_size too large",
+			query:    "startup=true&page_size=30001",
+			expected: "page_size must be an integer between 1 and 30000",
+		},
+		{
+			name:     "invalid cursor",
+			query:    "startup=true&cursor=not-a-cursor",
+			expected: "invalid cursor",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := lapi.RecordResponse(t, ctx, "GET", "/v1/decisions/stream?"+tc.query, emptyBody, APIKEY)
+			assert.Equal(t, 400, w.Code)
+			assert.JSONEq(t, `{"message":"`+tc.expected+`"}`, w.Body.String())
+		})
+	}
+}
+
+// withServerConfig returns lapi served by a new server, on the same database and for the same
+// bouncer, with the changes of configure to the configuration: like a restart of the local API.
+func (l LAPI) withServerConfig(t *testing.T, ctx context.Context, configure func(*csconfig.LocalApiServerCfg)) LAPI {
+	t.Helper()
+
+	config := LoadTestConfig(t)
+	config.API.Server.DbConfig = l.DBConfig
+	configure(config.API.Server)
+
+	logger, _ := logtest.NewNullLogger()
+	apiServer, err := NewServer(ctx, config.API.Server, logger.WithFields(nil))
+	require.NoError(t, err)
+
+	require.NoError(t, apiServer.InitController())
+
+	gin.SetMode(gin.TestMode)
+
+	l.router, err = apiServer.Router()
+	require.NoError(t, err)
+
+	return l
+}
+
+func setupLAPIConflictsTest(t *testing.T, ctx context.Context, conflicts *csconfig.DecisionConflictsCfg) LAPI {
+	t.Helper()
+
+	require.NoError(t, conflicts.Validate())
+
+	lapi := SetupLAPITest(t, ctx)
+
+	return lapi.withServerConfig(t, ctx, func(c *csconfig.LocalApiServerCfg) {
+		c.DecisionConflicts = conflicts
+	})
+}
+
+func TestStreamDecisionConflictsExpiry(t *testing.T) {
+	ctx := t.Context()
+	now := time.Now().UTC()
+
+	createDecision := func(t *testing.T, lapi LAPI, typ string, origin string, duration time.Duration) int64 {
+		t.Helper()
+
+		d, err := lapi.DBClient.Ent.Decision.Create().
+			SetUntil(now.Add(duration)).
+			SetScenario("test").
+			SetType(typ).
+			SetScope("Ip").
+			SetValue("1.2.3.4").
+			SetOrigin(origin).
+			Save(ctx)
+		require.NoError(t, err)
+
+		return int64(d.ID)
+	}
+
+	pull := func(t *testing.T, lapi LAPI, query string) ([]int64, []int64) {
+		t.Helper()
+
+		w := lapi.RecordResponse(t, ctx, "GET", "/v1/decisions/stream"+query, emptyBody, APIKEY)
+		decisions, code := readDecisionsStreamResp(t, w)
+		require.Equal(t, 200, code)
+
+		ids := func(decisions []*models.Decision) []int64 {
+			ret := []int64{}
+			for _, d := range decisions {
+				ret = append(ret, d.ID)
+			}
+
+			return ret
+		}
+
+		return ids(decisions["new"]), ids(decisions["deleted"])
+	}
+
+	expire := func(t *testing.T, lapi LAPI, id int64) {
+		t.Helper()
+
+		_, _, err := lapi.DBClient.ExpireDecisionByID(ctx, int(id))
+		require.NoError(t, err)
+	}
+
+	t.Run("trust", func(t *testing.T) {
+		lapi := setupLAPIConflictsTest(t, ctx, &csconfig.DecisionConflictsCfg{Policy: "trust"})
+
+		manualBan := createDecision(t, lapi, "ban", "cscli", time.Hour)
+		capiBan := createDecision(t, lapi, "ban", "CAPI", 24*time.Hour)
+
+		newIDs, deletedIDs := pull(t, lapi, "?startup=true")
+		assert.Equal(t, []int64{manualBan}, newIDs)
+		assert.Empty(t, deletedIDs)
+
+		// the manual ban was the one sent: its expiry is, and the CAPI ban takes over
+		expire(t, lapi, manualBan)
+
+		newIDs, deletedIDs = pull(t, lapi, "")
+		assert.Equal(t, []int64{capiBan}, newIDs)
+		assert.Equal(t, []int64{manualBan}, deletedIDs)
+	})
+
+	t.Run("strictest", func(t *testing.T) {
+		lapi := setupLAPIConflictsTest(t, ctx, &csconfig.DecisionConflictsCfg{Policy: "strictest"})
+
+		ban := createDecision(t, lapi, "ban", "CAPI", time.Hour)
+		captcha := createDecision(t, lapi, "captcha", "cscli", 24*time.Hour)
+
+		newIDs, deletedIDs := pull(t, lapi, "?startup=true")
+		assert.Equal(t, []int64{ban}, newIDs)
+		assert.Empty(t, deletedIDs)
+
+		// the captcha was hidden by the ban, which still applies: nothing to delete
+		expire(t, lapi, captcha)
+
+		newIDs, deletedIDs = pull(t, lapi, "")
+		assert.Empty(t, newIDs)
+		assert.Empty(t, deletedIDs)
+
+		// the captcha may be sent again: the expired decisions overlap the previous pull
+		expire(t, lapi, ban)
+
+		newIDs, deletedIDs = pull(t, lapi, "")
+		assert.Empty(t, newIDs)
+		assert.Contains(t, deletedIDs, ban)
+	})
+}
+
+func TestStreamDecisionDurationsCap(t *testing.T) {
+	ctx := t.Context()
+	lapi := SetupLAPITest(t, ctx)
+	now := time.Now().UTC()
+
+	d, err := lapi.DBClient.Ent.Decision.Create().
+		SetCreatedAt(now.Add(-48 * time.Hour)).
+		SetUntil(now.Add(time.Hour)).
+		SetScenario("test").
+		SetType("ban").
+		SetScope("Ip").
+		SetValue("1.2.3.4").
+		SetOrigin("CAPI").
+		Save(ctx)
+	require.NoError(t, err)
+
+	w := lapi.RecordResponse(t, ctx, "GET", "/v1/decisions/stream?startup=true", emptyBody, APIKEY)
+	decisions, code := readDecisionsStreamResp(t, w)
+	require.Equal(t, 200, code)
+	require.Len(t, decisions["new"], 1)
+
+	// restarted with a max_duration the decision is already past: it's deleted with the next delta
+	lapi = lapi.withServerConfig(t, ctx, func(c *csconfig.LocalApiServerCfg) {
+		c.DecisionDurations = csconfig.DecisionDurationsCfg{
+			{Origin: "CAPI", MaxDuration: cstime.DurationWithDays(24 * time.Hour)},
+		}
+	})
+
+	w = lapi.RecordResponse(t, ctx, "GET", "/v1/decisions/stream", emptyBody, APIKEY)
+	decisions, code = readDecisionsStreamResp(t, w)
+	require.Equal(t, 200, code)
+	assert.Empty(t, decisions["new"])
+	require.Len(t, decisions["deleted"], 1)
+	assert.Equal(t, int64(d.ID), decisions["deleted"][0].ID)
+}
@@ -0,0 +1,272 @@
+// Package eventbus sends the lifecycle events of the alerts and decisions of the local API
+// (alert created, decision applied, decision expired) to external systems, so that they
+// don't have to poll the API for changes.
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/go-cs-lib/trace"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/database"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+)
+
+// a failed event is sent again after 1s, then 2s
+const sendAttempts = 3
+
+// Decision is a decision, as sent in the events.
+type Decision struct {
+	ID        int       `json:"id"`
+	UUID      string    `json:"uuid"`
+	Origin    string    `json:"origin"`
+	Scenario  string    `json:"scenario"`
+	Scope     string    `json:"scope"`
+	Value     string    `json:"value"`
+	Type      string    `json:"type"`
+	Until     time.Time `json:"until"`
+	Simulated bool      `json:"simulated"`
+}
+
+func newDecision(d *ent.Decision) *Decision {
+	return &Decision{
+		ID:        d.ID,
+		UUID:      d.UUID,
+		Origin:    d.Origin,
+		Scenario:  d.Scenario,
+		Scope:     d.Scope,
+		Value:     d.Value,
+		Type:      d.Type,
+		Until:     d.Until.UTC(),
+		Simulated: d.Simulated,
+	}
+}
+
+// Event is the JSON document sent to the sinks.
+type Event struct {
+	Type     string        `json:"type"` // csconfig.EventAlertCreated...
+	Time     time.Time     `json:"time"`
+	Alert    *models.Alert `json:"alert,omitempty"`
+	Decision *Decision     `json:"decision,omitempty"`
+}
+
+// key groups the events about the same ip, range...
+func (e *Event) key() string {
+	switch {
+	case e.Decision != nil:
+		return e.Decision.Value
+	case e.Alert != nil && e.Alert.Source != nil && e.Alert.Source.Value != nil:
+		return *e.Alert.Source.Value
+	default:
+		return ""
+	}
+}
+
+// Sink is an external system the events are sent to.
+type Sink interface {
+	Send(ctx context.Context, evt *Event, payload []byte) error
+	Close() error
+}
+
+type worker struct {
+	cfg     *csconfig.EventSinkCfg
+	sink    Sink
+	queue   chan *Event
+	dropped atomic.Int64
+	logger  *log.Entry
+}
+
+func (w *worker) send(ctx context.Context, evt *Event) {
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		w.logger.Errorf("unable to serialize %s event: %s", evt.Type, err)
+		return
+	}
+
+	backoff := time.Second
+
+	for attempt := 1; ; attempt++ {
+		sendCtx, cancel := context.WithTimeout(ctx, *w.cfg.Timeout)
+		err = w.sink.Send(sendCtx, evt, payload)
+
+		cancel()
+
+		if err == nil {
+			return
+		}
+
+		if attempt == sendAttempts || ctx.Err() != nil {
+			break
+		}
+
+		w.logger.Debugf("unable to send %s event (attempt %d): %s", evt.Type, attempt, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+
+	w.logger.Errorf("unable to send %s event: %s", evt.Type, err)
+}
+
+func (w *worker) run(ctx context.Context) {
+	defer trace.ReportPanic()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if n := len(w.queue); n > 0 {
+				w.logger.Warningf("%d event(s) not sent", n)
+			}
+
+			return
+		case evt := <-w.queue:
+			w.send(ctx, evt)
+
+			if n := w.dropped.Swap(0); n > 0 {
+				w.logger.Warningf("%d event(s) dropped, the queue was full", n)
+			}
+		}
+	}
+}
+
+// Bus dispatches the events to the sinks, each one with its own queue.
+type Bus struct {
+	workers      []*worker
+	dbClient     *database.Client
+	pollInterval time.Duration
+	logger       *log.Entry
+}
+
+// New returns a bus for the sinks of the configuration, or nil if there is none.
+func New(cfg *csconfig.EventBusCfg, dbClient *database.Client, logger *log.Entry) (*Bus, error) {
+	if cfg == nil || len(cfg.Sinks) == 0 {
+		return nil, nil
+	}
+
+	b := &Bus{
+		dbClient:     dbClient,
+		pollInterval: *cfg.PollInterval,
+		logger:       logger,
+	}
+
+	for _, sinkCfg := range cfg.Sinks {
+		sink, err := newSink(sinkCfg)
+		if err != nil {
+			b.Close()
+			return nil, fmt.Errorf("sink %s: %w", sinkCfg.Name, err)
+		}
+
+		b.workers = append(b.workers, &worker{
+			cfg:    sinkCfg,
+			sink:   sink,
+			queue:  make(chan *Event, sinkCfg.QueueSize),
+			logger: logger.WithField("sink", sinkCfg.Name),
+		})
+	}
+
+	return b, nil
+}
+
+func newSink(cfg *csconfig.EventSinkCfg) (Sink, error) {
+	switch cfg.Type {
+	case csconfig.EventSinkWebhook:
+		return newWebhookSink(cfg), nil
+	case csconfig.EventSinkKafka:
+		return newKafkaSink(cfg), nil
+	case csconfig.EventSinkNATS:
+		return newNATSSink(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown type %s", cfg.Type)
+	}
+}
+
+// Publish queues an event for the sinks that want it. It never blocks: the event is dropped for
+// the sinks whose queue is full. It does nothing on a nil bus.
+func (b *Bus) Publish(evt *Event) {
+	if b == nil {
+		return
+	}
+
+	if evt.Time.IsZero() {
+		evt.Time = time.Now().UTC()
+	}
+
+	for _, w := range b.workers {
+		if !w.cfg.Wants(evt.Type) {
+			continue
+		}
+
+		select {
+		case w.queue <- evt:
+		default:
+			w.dropped.Add(1)
+		}
+	}
+}
+
+// PublishAlerts publishes the alert.created event of stored alerts.
+func (b *Bus) PublishAlerts(alerts []*models.Alert) {
+	if b == nil {
+		return
+	}
+
+	for _, alert := range alerts {
+		b.Publish(&Event{Type: csconfig.EventAlertCreated, Alert: alert})
+	}
+}
+
+// wants tells if a sink wants the event.
+func (b *Bus) wants(event string) bool {
+	for _, w := range b.workers {
+		if w.cfg.Wants(event) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Run sends the events, and looks for the decisions that are applied or expired, until the
+// context is canceled.
+func (b *Bus) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+
+	for _, w := range b.workers {
+		wg.Go(func() {
+			w.run(ctx)
+		})
+	}
+
+	err := b.pollDecisions(ctx)
+
+	wg.Wait()
+
+	return err
+}
+
+// Close releases the connections of the sinks, once Run has returned.
+func (b *Bus) Close() {
+	if b == nil {
+		return
+	}
+
+	for _, w := range b.workers {
+		if err := w.sink.Close(); err != nil {
+			w.logger.Warningf("while closing: %s", err)
+		}
+	}
+}
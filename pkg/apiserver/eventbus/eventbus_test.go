@@ -0,0 +1,353 @@
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/database"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+)
+
+func sinkCfg(t *testing.T, cfg *csconfig.EventSinkCfg) *csconfig.EventSinkCfg {
+	t.Helper()
+
+	bus := csconfig.EventBusCfg{Sinks: []*csconfig.EventSinkCfg{cfg}}
+	require.NoError(t, bus.Load())
+
+	return cfg
+}
+
+func TestWebhookSink(t *testing.T) {
+	var (
+		header http.Header
+		body   []byte
+		status = http.StatusNoContent
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := newWebhookSink(sinkCfg(t, &csconfig.EventSinkCfg{
+		Type:    csconfig.EventSinkWebhook,
+		URL:     server.URL + "/hook",
+		Headers: map[string]string{"Authorization": "Bearer abc"},
+		Secret:  "s3cret",
+	}))
+	defer sink.Close()
+
+	evt := &Event{Type: csconfig.EventDecisionApplied, Decision: &Decision{ID: 1, Value: "1.2.3.4"}}
+	payload, err := json.Marshal(evt)
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Send(t.Context(), evt, payload))
+
+	assert.Equal(t, payload, body)
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "Bearer abc", header.Get("Authorization"))
+	assert.Equal(t, "decision.applied", header.Get("X-Crowdsec-Event"))
+	assert.Equal(t, sign("s3cret", payload), header.Get("X-Crowdsec-Signature"))
+	assert.True(t, strings.HasPrefix(header.Get("X-Crowdsec-Signature"), "sha256="))
+
+	status = http.StatusBadGateway
+
+	err = sink.Send(t.Context(), evt, payload)
+	require.EqualError(t, err, "unexpected status: 502 Bad Gateway")
+}
+
+// fakeNATS is a NATS server that accepts the messages of one client at a time. It closes the first
+// connection right after the handshake if dropFirst is set.
+type fakeNATS struct {
+	listener  net.Listener
+	dropFirst bool
+
+	mu       sync.Mutex
+	connects []string
+	messages map[string]string // by subject
+}
+
+func newFakeNATS(t *testing.T, dropFirst bool) *fakeNATS {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	f := &fakeNATS{listener: listener, dropFirst: dropFirst, messages: map[string]string{}}
+
+	go f.serve()
+
+	t.Cleanup(func() { listener.Close() })
+
+	return f
+}
+
+func (f *fakeNATS) serve() {
+	for n := 0; ; n++ {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		f.handle(conn, f.dropFirst && n == 0)
+	}
+}
+
+func (f *fakeNATS) handle(conn net.Conn, drop bool) {
+	defer conn.Close()
+
+	fmt.Fprint(conn, `INFO {"server_id":"fake","max_payload":1024}`+"\r\n")
+
+	r := bufio.NewReader(conn)
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case strings.HasPrefix(line, "CONNECT "):
+			f.mu.Lock()
+			f.connects = append(f.connects, strings.TrimPrefix(line, "CONNECT "))
+			f.mu.Unlock()
+		case line == "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+
+			if drop {
+				return
+			}
+		case strings.HasPrefix(line, "PUB "):
+			fields := strings.Fields(line)
+			size, _ := strconv.Atoi(fields[2])
+			payload := make([]byte, size+2)
+
+			if _, err := io.ReadFull(r, payload); err != nil {
+				return
+			}
+
+			if strings.Contains(fields[1], "forbidden") {
+				fmt.Fprint(conn, "-ERR 'Permissions Violation for Publish'\r\n")
+				continue
+			}
+
+			f.mu.Lock()
+			f.messages[fields[1]] = string(payload[:size])
+			f.mu.Unlock()
+		}
+	}
+}
+
+func TestNATSSink(t *testing.T) {
+	server := newFakeNATS(t, true)
+
+	sink := newNATSSink(sinkCfg(t, &csconfig.EventSinkCfg{
+		Type:  csconfig.EventSinkNATS,
+		URL:   "nats://" + server.listener.Addr().String(),
+		Token: "t0ken",
+	}))
+	defer sink.Close()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+	defer cancel()
+
+	evt := &Event{Type: csconfig.EventAlertCreated, Alert: &models.Alert{UUID: "abc"}}
+	payload, err := json.Marshal(evt)
+	require.NoError(t, err)
+
+	// the first connection is closed by the server: the event is sent again by the bus, once the
+	// client has reconnected
+	require.Eventually(t, func() bool {
+		return sink.Send(ctx, evt, payload) == nil
+	}, 5*time.Second, 100*time.Millisecond)
+
+	server.mu.Lock()
+	assert.Equal(t, map[string]string{"crowdsec.alert.created": string(payload)}, server.messages)
+	require.Len(t, server.connects, 2)
+	assert.Contains(t, server.connects[1], `"auth_token":"t0ken"`)
+	server.mu.Unlock()
+
+	err = sink.Send(ctx, evt, make([]byte, 2048))
+	require.ErrorIs(t, err, nats.ErrMaxPayload)
+
+	sink.cfg.Subject = "forbidden"
+
+	err = sink.Send(ctx, evt, payload)
+	require.ErrorIs(t, err, nats.ErrPermissionViolation)
+
+	// the error of the previous event is not returned again
+	sink.cfg.Subject = "crowdsec"
+
+	require.NoError(t, sink.Send(ctx, evt, payload))
+}
+
+// recordSink keeps the events it's sent.
+type recordSink struct {
+	mu     sync.Mutex
+	events []*Event
+}
+
+func (s *recordSink) Send(_ context.Context, evt *Event, _ []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events = append(s.events, evt)
+
+	return nil
+}
+
+func (*recordSink) Close() error {
+	return nil
+}
+
+func newTestBus(t *testing.T, dbClient *database.Client, sinks ...*csconfig.EventSinkCfg) (*Bus, []*recordSink) {
+	t.Helper()
+
+	cfg := csconfig.EventBusCfg{Sinks: sinks}
+	require.NoError(t, cfg.Load())
+
+	b := &Bus{dbClient: dbClient, pollInterval: *cfg.PollInterval, logger: log.WithField("test", t.Name())}
+	records := []*recordSink{}
+
+	for _, sc := range cfg.Sinks {
+		rec := &recordSink{}
+		records = append(records, rec)
+		b.workers = append(b.workers, &worker{cfg: sc, sink: rec, queue: make(chan *Event, sc.QueueSize), logger: b.logger})
+	}
+
+	return b, records
+}
+
+// queued returns the types of the events in the queue of a worker.
+func queued(w *worker) []string {
+	ret := []string{}
+
+	for len(w.queue) > 0 {
+		evt := <-w.queue
+		ret = append(ret, evt.Type)
+	}
+
+	return ret
+}
+
+func TestPublish(t *testing.T) {
+	b, _ := newTestBus(t, nil,
+		&csconfig.EventSinkCfg{Type: csconfig.EventSinkWebhook, URL: "http://soc/hook", QueueSize: 2},
+		&csconfig.EventSinkCfg{Type: csconfig.EventSinkWebhook, URL: "http://soc/hook", Events: []string{csconfig.EventDecisionExpired}},
+	)
+
+	b.PublishAlerts([]*models.Alert{{UUID: "a"}, {UUID: "b"}, {UUID: "c"}})
+	b.Publish(&Event{Type: csconfig.EventDecisionExpired})
+
+	// the queue of the first sink is full
+	assert.Equal(t, []string{"alert.created", "alert.created"}, queued(b.workers[0]))
+	assert.Equal(t, int64(2), b.workers[0].dropped.Load())
+	assert.Equal(t, []string{"decision.expired"}, queued(b.workers[1]))
+
+	var nilBus *Bus
+
+	nilBus.Publish(&Event{Type: csconfig.EventAlertCreated})
+	nilBus.PublishAlerts([]*models.Alert{{UUID: "a"}})
+}
+
+func decisionAlert(origin string, value string, duration string) *models.Alert {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	return &models.Alert{
+		Capacity:        new(int32(0)),
+		Scenario:        new("test/scenario"),
+		ScenarioVersion: new(""),
+		ScenarioHash:    new(""),
+		Message:         new("test"),
+		Leakspeed:       new(""),
+		EventsCount:     new(int32(1)),
+		Simulated:       new(false),
+		StartAt:         &now,
+		StopAt:          &now,
+		Source:          &models.Source{Scope: new("Ip"), Value: &value, IP: value},
+		Decisions: []*models.Decision{{
+			Duration: &duration,
+			Origin:   &origin,
+			Scenario: new("test/scenario"),
+			Scope:    new("Ip"),
+			Value:    &value,
+			Type:     new("ban"),
+		}},
+	}
+}
+
+func TestPollDecisions(t *testing.T) {
+	ctx := t.Context()
+
+	dbClient, err := database.NewClient(ctx, &csconfig.DatabaseCfg{
+		Type:   "sqlite",
+		DbName: "crowdsec",
+		DbPath: ":memory:",
+	}, nil)
+	require.NoError(t, err)
+
+	// existing decisions are not published
+	_, err = dbClient.CreateAlert(ctx, "", []*models.Alert{decisionAlert(types.CrowdSecOrigin, "1.1.1.1", "4h")})
+	require.NoError(t, err)
+
+	b, _ := newTestBus(t, dbClient,
+		&csconfig.EventSinkCfg{Type: csconfig.EventSinkWebhook, URL: "http://soc/hook"},
+	)
+
+	lastID, err := dbClient.LastDecisionID(ctx)
+	require.NoError(t, err)
+
+	p := &decisionPoller{lastID: lastID, since: time.Now().UTC()}
+
+	_, err = dbClient.CreateAlert(ctx, "", []*models.Alert{
+		decisionAlert(types.CscliOrigin, "2.2.2.2", "4h"),
+		decisionAlert(types.CAPIOrigin, "3.3.3.3", "4h"),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, b.poll(ctx, p, time.Now().UTC()))
+
+	evt := <-b.workers[0].queue
+	assert.Equal(t, csconfig.EventDecisionApplied, evt.Type)
+	assert.Equal(t, "2.2.2.2", evt.Decision.Value)
+	assert.Equal(t, types.CscliOrigin, evt.Decision.Origin)
+	assert.Empty(t, b.workers[0].queue)
+
+	// nothing new
+	require.NoError(t, b.poll(ctx, p, time.Now().UTC()))
+	assert.Empty(t, b.workers[0].queue)
+
+	// deleted decisions are expired
+	_, deleted, err := dbClient.ExpireDecisionsWithFilter(ctx, map[string][]string{"value": {"1.1.1.1"}})
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+
+	require.NoError(t, b.poll(ctx, p, time.Now().UTC().Add(time.Second)))
+
+	evt = <-b.workers[0].queue
+	assert.Equal(t, csconfig.EventDecisionExpired, evt.Type)
+	assert.Equal(t, "1.1.1.1", evt.Decision.Value)
+	assert.Empty(t, b.workers[0].queue)
+}
@@ -0,0 +1,46 @@
+package eventbus
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+)
+
+// kafkaSink writes each event to a topic. The message key is the ip or range the event is about,
+// so that the events of an ip stay in the same partition, in order.
+type kafkaSink struct {
+	writer *kafka.Writer
+}
+
+func newKafkaSink(cfg *csconfig.EventSinkCfg) *kafkaSink {
+	transport := &kafka.Transport{}
+	if cfg.TLS {
+		transport.TLS = &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify} //nolint:gosec // only if explicitly asked for
+	}
+
+	return &kafkaSink{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Topic:        cfg.Topic,
+			Balancer:     &kafka.Hash{},
+			BatchSize:    1, // don't wait for more messages
+			RequiredAcks: kafka.RequireOne,
+			Transport:    transport,
+		},
+	}
+}
+
+func (s *kafkaSink) Send(ctx context.Context, evt *Event, payload []byte) error {
+	return s.writer.WriteMessages(ctx, kafka.Message{
+		Key:     []byte(evt.key()),
+		Value:   payload,
+		Headers: []kafka.Header{{Key: "type", Value: []byte(evt.Type)}},
+	})
+}
+
+func (s *kafkaSink) Close() error {
+	return s.writer.Close()
+}
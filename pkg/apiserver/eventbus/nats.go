@@ -0,0 +1,95 @@
+package eventbus
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+)
+
+// natsSink publishes each event on a NATS subject. The connection is opened with the first event,
+// then the client reconnects by itself when the server goes away.
+type natsSink struct {
+	cfg  *csconfig.EventSinkCfg
+	conn *nats.Conn
+}
+
+func newNATSSink(cfg *csconfig.EventSinkCfg) *natsSink {
+	return &natsSink{cfg: cfg}
+}
+
+func (s *natsSink) connect(ctx context.Context) error {
+	opts := []nats.Option{
+		nats.Name("crowdsec-lapi"),
+		// keep retrying: the bus drops the events that can't be sent
+		nats.MaxReconnects(-1),
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		opts = append(opts, nats.Timeout(time.Until(deadline)))
+	}
+
+	if s.cfg.Token != "" {
+		opts = append(opts, nats.Token(s.cfg.Token))
+	}
+
+	if s.cfg.Username != "" {
+		opts = append(opts, nats.UserInfo(s.cfg.Username, s.cfg.Password))
+	}
+
+	if s.cfg.InsecureSkipVerify {
+		// not nats.Secure(), which would require TLS with a nats:// server
+		opts = append(opts, func(o *nats.Options) error {
+			o.TLSConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // only if explicitly asked for
+			return nil
+		})
+	}
+
+	conn, err := nats.Connect(s.cfg.URL, opts...)
+	if err != nil {
+		return err
+	}
+
+	s.conn = conn
+
+	return nil
+}
+
+func (s *natsSink) Send(ctx context.Context, evt *Event, payload []byte) error {
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return fmt.Errorf("unable to connect to %s: %w", s.cfg.URL, err)
+		}
+	}
+
+	// the errors of the server, like a permission violation, are not returned by Publish()
+	lastErr := s.conn.LastError()
+
+	if err := s.conn.Publish(s.cfg.Subject+"."+evt.Type, payload); err != nil {
+		return err
+	}
+
+	// the server answers the flush once it has processed the message
+	if err := s.conn.FlushWithContext(ctx); err != nil {
+		return err
+	}
+
+	if err := s.conn.LastError(); err != nil && !errors.Is(err, lastErr) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *natsSink) Close() error {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+
+	return nil
+}
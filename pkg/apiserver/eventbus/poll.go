@@ -0,0 +1,89 @@
+package eventbus
+
+import (
+	"context"
+	"time"
+
+	"github.com/crowdsecurity/go-cs-lib/trace"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+)
+
+// the community blocklist and the subscribed lists are refreshed in bulk, their decisions
+// would drown the others
+var excludedOrigins = []string{types.CAPIOrigin, types.ListOrigin}
+
+// decisionPoller remembers what was already published.
+type decisionPoller struct {
+	lastID int       // decisions with a greater id are new
+	since  time.Time // decisions expired after this time are new
+}
+
+func (b *Bus) poll(ctx context.Context, p *decisionPoller, now time.Time) error {
+	if b.wants(csconfig.EventDecisionApplied) {
+		applied, err := b.dbClient.QueryDecisionsAfterID(ctx, p.lastID, now, excludedOrigins)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range applied {
+			b.Publish(&Event{Type: csconfig.EventDecisionApplied, Time: d.CreatedAt.UTC(), Decision: newDecision(d)})
+		}
+
+		if len(applied) > 0 {
+			p.lastID = applied[len(applied)-1].ID
+		}
+	}
+
+	if b.wants(csconfig.EventDecisionExpired) {
+		expired, err := b.dbClient.QueryDecisionsExpiredBetween(ctx, p.since, now, excludedOrigins)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range expired {
+			b.Publish(&Event{Type: csconfig.EventDecisionExpired, Time: d.Until.UTC(), Decision: newDecision(d)})
+		}
+	}
+
+	p.since = now
+
+	return nil
+}
+
+// pollDecisions publishes the decisions applied or expired since the previous poll. The decisions
+// that exist at startup are not published.
+func (b *Bus) pollDecisions(ctx context.Context) error {
+	defer trace.ReportPanic()
+
+	if !b.wants(csconfig.EventDecisionApplied) && !b.wants(csconfig.EventDecisionExpired) {
+		<-ctx.Done()
+		return nil
+	}
+
+	lastID, err := b.dbClient.LastDecisionID(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		return err
+	}
+
+	p := &decisionPoller{lastID: lastID, since: time.Now().UTC()}
+
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := b.poll(ctx, p, time.Now().UTC()); err != nil && ctx.Err() == nil {
+				b.logger.Errorf("while looking for decision changes: %s", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/crowdsecurity/crowdsec/pkg/apiclient/useragent"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+)
+
+const (
+	eventHeader     = "X-Crowdsec-Event"
+	signatureHeader = "X-Crowdsec-Signature"
+)
+
+// webhookSink posts each event to an url.
+type webhookSink struct {
+	cfg    *csconfig.EventSinkCfg
+	client *http.Client
+}
+
+func newWebhookSink(cfg *csconfig.EventSinkCfg) *webhookSink {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // explicitly asked for
+	}
+
+	return &webhookSink{
+		cfg:    cfg,
+		client: &http.Client{Transport: transport},
+	}
+}
+
+// sign returns the signature of a payload: "sha256=" and the hex HMAC-SHA256 of the payload with the secret.
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *webhookSink) Send(ctx context.Context, evt *Event, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	for name, value := range s.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", useragent.Default())
+	req.Header.Set(eventHeader, evt.Type)
+
+	if s.cfg.Secret != "" {
+		req.Header.Set(signatureHeader, sign(s.cfg.Secret, payload))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,106 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/crowdsecurity/go-cs-lib/trace"
+
+	controllersv1 "github.com/crowdsecurity/crowdsec/pkg/apiserver/controllers/v1"
+	"github.com/crowdsecurity/crowdsec/pkg/protobufs"
+)
+
+const (
+	// a connection is closed if a keepalive ping is not answered in time
+	grpcKeepaliveTimeout = 20 * time.Second
+	// the bouncers can ping their connection, to keep the proxies in between from closing it
+	grpcMinClientPing = 10 * time.Second
+)
+
+// newGRPCServer returns the server of the gRPC decision stream. It uses the certificate of the
+// HTTP server if TLS is enabled.
+func (s *APIServer) newGRPCServer(stream *controllersv1.DecisionStream) (*grpc.Server, error) {
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    *s.cfg.GRPC.Keepalive,
+			Timeout: grpcKeepaliveTimeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             grpcMinClientPing,
+			PermitWithoutStream: true,
+		}),
+	}
+
+	if s.cfg.TLS != nil && s.cfg.TLS.CertFilePath != "" && s.cfg.TLS.KeyFilePath != "" {
+		tlsCfg, err := s.cfg.TLS.GetTLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("while creating TLS config: %w", err)
+		}
+
+		cert, err := tls.LoadX509KeyPair(s.cfg.TLS.CertFilePath, s.cfg.TLS.KeyFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("while loading TLS certificate: %w", err)
+		}
+
+		tlsCfg.Certificates = []tls.Certificate{cert}
+
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+
+	srv := grpc.NewServer(opts...)
+	protobufs.RegisterDecisionsServer(srv, stream)
+
+	return srv, nil
+}
+
+// listenAndServeGRPC serves the gRPC decision stream, until the http server tomb is dying.
+func (s *APIServer) listenAndServeGRPC(ctx context.Context) error {
+	stream := s.controller.HandlerV1.NewDecisionStream(*s.cfg.GRPC.PollInterval)
+
+	srv, err := s.newGRPCServer(stream)
+	if err != nil {
+		return err
+	}
+
+	listenConfig := &net.ListenConfig{}
+
+	listener, err := listenConfig.Listen(ctx, "tcp", s.cfg.GRPC.ListenURI)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", s.cfg.GRPC.ListenURI, err)
+	}
+
+	log.Infof("CrowdSec Local API gRPC decision stream listening on %s", s.cfg.GRPC.ListenURI)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		defer trace.ReportPanic()
+
+		_ = stream.Run(ctx)
+	}()
+
+	serverError := make(chan error, 1)
+
+	go func() {
+		serverError <- srv.Serve(listener)
+	}()
+
+	select {
+	case err := <-serverError:
+		return err
+	case <-s.httpServerTomb.Dying():
+		// the streams don't end by themselves, don't wait for them
+		srv.Stop()
+	}
+
+	return nil
+}
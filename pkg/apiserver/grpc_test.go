@@ -0,0 +1,205 @@
+package apiserver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/protobufs"
+)
+
+// setupGRPCTest starts the gRPC decision stream of a local API, and returns a client.
+func setupGRPCTest(t *testing.T, ctx context.Context) (LAPI, protobufs.DecisionsClient) {
+	t.Helper()
+
+	apiServer, config := NewAPIServer(t, ctx)
+	require.NoError(t, apiServer.InitController())
+
+	router, err := apiServer.Router()
+	require.NoError(t, err)
+
+	apiKey, dbClient := CreateTestBouncer(t, ctx, config.API.Server.DbConfig)
+
+	lapi := LAPI{
+		router:     router,
+		loginResp:  LoginToTestAPI(t, ctx, router, config),
+		bouncerKey: apiKey,
+		DBConfig:   config.API.Server.DbConfig,
+		DBClient:   dbClient,
+	}
+
+	apiServer.cfg.GRPC = &csconfig.GRPCServerCfg{ListenURI: "127.0.0.1:0", PollInterval: new(50 * time.Millisecond)}
+	require.NoError(t, apiServer.cfg.GRPC.Load())
+
+	stream := apiServer.controller.HandlerV1.NewDecisionStream(*apiServer.cfg.GRPC.PollInterval)
+
+	srv, err := apiServer.newGRPCServer(stream)
+	require.NoError(t, err)
+
+	listener, err := (&net.ListenConfig{}).Listen(ctx, "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	runCtx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		_ = stream.Run(runCtx)
+	}()
+
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		srv.Stop()
+	})
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	t.Cleanup(func() { conn.Close() })
+
+	return lapi, protobufs.NewDecisionsClient(conn)
+}
+
+func openStream(t *testing.T, ctx context.Context, client protobufs.DecisionsClient, apiKey string, req *protobufs.StreamRequest) grpc.ServerStreamingClient[protobufs.DecisionChanges] {
+	t.Helper()
+
+	if apiKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", apiKey)
+	}
+
+	stream, err := client.Stream(ctx, req)
+	require.NoError(t, err)
+
+	return stream
+}
+
+// recvChanges returns the next message of a stream, in a goroutine to not block the test forever.
+func recvChanges(t *testing.T, stream grpc.ServerStreamingClient[protobufs.DecisionChanges]) *protobufs.DecisionChanges {
+	t.Helper()
+
+	type result struct {
+		changes *protobufs.DecisionChanges
+		err     error
+	}
+
+	ch := make(chan result, 1)
+
+	go func() {
+		changes, err := stream.Recv()
+		ch <- result{changes, err}
+	}()
+
+	select {
+	case r := <-ch:
+		require.NoError(t, r.err)
+		return r.changes
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for decisions")
+	}
+
+	return nil
+}
+
+// recvUntil returns the first message of a stream that matches, the changes made just before a
+// stream is opened can be sent again.
+func recvUntil(t *testing.T, stream grpc.ServerStreamingClient[protobufs.DecisionChanges], match func(*protobufs.DecisionChanges) bool) *protobufs.DecisionChanges {
+	t.Helper()
+
+	for {
+		if changes := recvChanges(t, stream); match(changes) {
+			return changes
+		}
+	}
+}
+
+func decisionValues(decisions []*protobufs.Decision) []string {
+	ret := make([]string, 0, len(decisions))
+	for _, d := range decisions {
+		ret = append(ret, d.GetValue())
+	}
+
+	return ret
+}
+
+func TestGRPCStreamAuth(t *testing.T) {
+	ctx := t.Context()
+	_, client := setupGRPCTest(t, ctx)
+
+	for _, apiKey := range []string{"", "wrong"} {
+		stream := openStream(t, ctx, client, apiKey, &protobufs.StreamRequest{Startup: true})
+
+		_, err := stream.Recv()
+		require.Error(t, err)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+}
+
+func TestGRPCStream(t *testing.T) {
+	ctx := t.Context()
+	lapi, client := setupGRPCTest(t, ctx)
+
+	lapi.InsertAlertFromFile(t, ctx, "./tests/alert_minibulk.json")
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream := openStream(t, streamCtx, client, lapi.bouncerKey, &protobufs.StreamRequest{Startup: true})
+
+	changes := recvChanges(t, stream)
+	assert.ElementsMatch(t, []string{"91.121.79.178", "91.121.79.179"}, decisionValues(changes.GetNew()))
+	assert.Empty(t, changes.GetDeleted())
+
+	d := changes.GetNew()[0]
+	assert.Equal(t, "ban", d.GetType())
+	assert.Equal(t, "Ip", d.GetScope())
+	assert.Equal(t, "crowdsec", d.GetOrigin())
+	assert.NotEmpty(t, d.GetUuid())
+	assert.NotEmpty(t, d.GetDuration())
+
+	// the deletions are sent as they happen
+	w := lapi.RecordResponse(t, ctx, "DELETE", "/v1/decisions?ip=91.121.79.179", emptyBody, PASSWORD)
+	require.Equal(t, 200, w.Code)
+
+	changes = recvUntil(t, stream, func(c *protobufs.DecisionChanges) bool { return len(c.GetDeleted()) > 0 })
+	assert.Equal(t, []string{"91.121.79.179"}, decisionValues(changes.GetDeleted()))
+
+	// a stream that doesn't want the ip decisions
+	rangeStream := openStream(t, streamCtx, client, lapi.bouncerKey, &protobufs.StreamRequest{Startup: true, Scopes: []string{"range"}})
+
+	// and the new decisions
+	lapi.InsertAlertFromFile(t, ctx, "./tests/alert_sample.json")
+
+	changes = recvUntil(t, stream, func(c *protobufs.DecisionChanges) bool { return len(c.GetNew()) > 0 })
+	assert.Equal(t, []string{"127.0.0.1"}, decisionValues(changes.GetNew()))
+
+	// each stream is recorded in the pull history when it's opened
+	require.Eventually(t, func() bool {
+		return len(GetBouncers(t, lapi.DBConfig)[0].PullHistory) == 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	bouncers := GetBouncers(t, lapi.DBConfig)
+	require.Len(t, bouncers, 1)
+	require.NotNil(t, bouncers[0].LastPull)
+	assert.Equal(t, "grpc", bouncers[0].PullHistory[0].Endpoint)
+	assert.True(t, bouncers[0].PullHistory[0].Startup)
+	assert.Equal(t, 2, bouncers[0].PullHistory[0].New)
+	assert.Equal(t, "scopes=Range", bouncers[0].PullHistory[1].Filters)
+
+	// the other stream received nothing, its first message is the cancellation
+	cancel()
+
+	_, err := rangeStream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
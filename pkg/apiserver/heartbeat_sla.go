@@ -0,0 +1,119 @@
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/go-cs-lib/trace"
+
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+)
+
+const (
+	// HeartbeatSLAScenario is the scenario of the alerts raised when a machine stops sending heartbeats.
+	HeartbeatSLAScenario = "crowdsecurity/lapi-machine-heartbeat"
+	HeartbeatSLAScope    = "machine"
+)
+
+func newHeartbeatAlert(m *ent.Machine, maxDelay time.Duration, now time.Time) *models.Alert {
+	last := m.CreatedAt
+	message := fmt.Sprintf("machine %s has never sent a heartbeat (max delay: %s)", m.MachineId, maxDelay)
+
+	if m.LastHeartbeat != nil {
+		last = *m.LastHeartbeat
+		message = fmt.Sprintf("machine %s has not sent a heartbeat for %s (max delay: %s)", m.MachineId, now.Sub(last).Truncate(time.Second), maxDelay)
+	}
+
+	return &models.Alert{
+		Source: &models.Source{
+			Scope: new(HeartbeatSLAScope),
+			Value: new(m.MachineId),
+			IP:    m.IpAddress,
+		},
+		Scenario:        new(HeartbeatSLAScenario),
+		Kind:            types.LAPIAlertKind.String(),
+		Message:         new(message),
+		StartAt:         new(last.UTC().Format(time.RFC3339)),
+		StopAt:          new(now.UTC().Format(time.RFC3339)),
+		Capacity:        new(int32(0)),
+		Simulated:       new(false),
+		EventsCount:     new(int32(0)),
+		Leakspeed:       new(""),
+		ScenarioHash:    new(""),
+		ScenarioVersion: new(""),
+		Meta: models.Meta{
+			{Key: "machine_version", Value: m.Version},
+			{Key: "max_delay", Value: maxDelay.String()},
+		},
+	}
+}
+
+// checkHeartbeats raises an alert for each machine that stopped sending heartbeats. There is one alert
+// per outage: nothing is done if an alert was already stored after the last heartbeat of the machine.
+func (s *APIServer) checkHeartbeats(ctx context.Context) error {
+	sla := s.cfg.HeartbeatSLA
+
+	stale, err := s.dbClient.ListStaleMachines(ctx, sla)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	for _, m := range stale {
+		last := m.CreatedAt
+		if m.LastHeartbeat != nil {
+			last = *m.LastHeartbeat
+		}
+
+		exists, err := s.dbClient.AlertExistsSince(ctx, HeartbeatSLAScenario, m.MachineId, last)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			continue
+		}
+
+		alert := newHeartbeatAlert(m, sla.MaxDelayFor(m.MachineId), now)
+
+		log.Warning(*alert.Message)
+
+		if _, err := s.dbClient.CreateAlert(ctx, "", []*models.Alert{alert}); err != nil {
+			return fmt.Errorf("while saving heartbeat alert for %s: %w", m.MachineId, err)
+		}
+
+		s.eventBus.PublishAlerts([]*models.Alert{alert})
+
+		if sla.Notify && s.controller.HandlerV1 != nil {
+			s.controller.HandlerV1.NotifyAlert(alert)
+		}
+	}
+
+	return nil
+}
+
+func (s *APIServer) runHeartbeatSLA(ctx context.Context) error {
+	defer trace.ReportPanic()
+
+	ticker := time.NewTicker(*s.cfg.HeartbeatSLA.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.heartbeatTomb.Dying():
+			return nil
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.checkHeartbeats(ctx); err != nil {
+				log.Errorf("while checking machine heartbeats: %s", err)
+			}
+		}
+	}
+}
@@ -3,8 +3,15 @@ package apiserver
 import (
 	"net/http"
 	"testing"
+	"time"
 
+	"github.com/go-openapi/strfmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/machine"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
 )
 
 func TestHeartBeat(t *testing.T) {
@@ -17,3 +24,49 @@ func TestHeartBeat(t *testing.T) {
 	w = lapi.RecordResponse(t, ctx, http.MethodPost, "/v1/heartbeat", emptyBody, "password")
 	assert.Equal(t, 405, w.Code)
 }
+
+func TestCheckHeartbeats(t *testing.T) {
+	ctx := t.Context()
+	apiServer, _ := NewAPIServer(t, ctx)
+	require.NoError(t, apiServer.InitController())
+
+	apiServer.cfg.HeartbeatSLA = &csconfig.HeartbeatSLACfg{
+		Alert:    true,
+		Machines: map[string]time.Duration{"laptop": time.Hour},
+	}
+	require.NoError(t, apiServer.cfg.HeartbeatSLA.Load())
+
+	db := apiServer.dbClient
+	password := strfmt.Password("password")
+	tenMinutesAgo := time.Now().UTC().Add(-10 * time.Minute)
+
+	for _, name := range []string{"stale", "alive", "laptop"} {
+		_, err := db.CreateMachine(ctx, new(name), &password, "10.0.0.1", true, false, types.PasswordAuthType)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, db.Ent.Machine.Update().Where(machine.MachineIdIn("stale", "laptop")).SetLastHeartbeat(tenMinutesAgo).Exec(ctx))
+	require.NoError(t, db.UpdateMachineLastHeartBeat(ctx, "alive"))
+
+	stale, err := db.ListStaleMachines(ctx, apiServer.cfg.HeartbeatSLA)
+	require.NoError(t, err)
+	require.Len(t, stale, 1)
+	assert.Equal(t, "stale", stale[0].MachineId)
+
+	require.NoError(t, apiServer.checkHeartbeats(ctx))
+
+	alerts, err := db.QueryAlertWithFilter(ctx, map[string][]string{"scenario": {HeartbeatSLAScenario}})
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, HeartbeatSLAScope, alerts[0].SourceScope)
+	assert.Equal(t, "stale", alerts[0].SourceValue)
+	assert.Equal(t, "10.0.0.1", alerts[0].SourceIp)
+	assert.Contains(t, alerts[0].Message, "machine stale has not sent a heartbeat for 10m")
+
+	// one alert per outage
+	require.NoError(t, apiServer.checkHeartbeats(ctx))
+
+	alerts, err = db.QueryAlertWithFilter(ctx, map[string][]string{"scenario": {HeartbeatSLAScenario}})
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+}
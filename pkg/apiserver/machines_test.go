@@ -6,9 +6,13 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	middlewares "github.com/crowdsecurity/crowdsec/pkg/apiserver/middlewares/v1"
+	"github.com/crowdsecurity/crowdsec/pkg/database"
 )
 
 func TestCreateMachine(t *testing.T) {
@@ -241,3 +245,84 @@ func TestAutoRegistration(t *testing.T) {
 
 	assert.Equal(t, http.StatusCreated, w.Code)
 }
+
+func TestOneTimeRegistrationToken(t *testing.T) {
+	ctx := t.Context()
+	router, config := NewAPITest(t, ctx)
+
+	dbClient, err := database.NewClient(ctx, config.API.Server.DbConfig, nil)
+	require.NoError(t, err)
+
+	_, err = dbClient.CreateRegistrationToken(ctx, middlewares.HashSHA512("Aeb7phoo3eeVah5aiyohSheiqu8ieh8u"), "", time.Now().UTC().Add(time.Hour))
+	require.NoError(t, err)
+
+	_, err = dbClient.CreateRegistrationToken(ctx, middlewares.HashSHA512("Ohtee9Ki5aib0ahfaeghoo7ieG1eibie"), "", time.Now().UTC().Add(-time.Minute))
+	require.NoError(t, err)
+
+	register := func(machineID string, token string) int {
+		regReq := MachineTest
+		regReq.MachineID = &machineID
+		regReq.RegistrationToken = token
+		b, err := json.Marshal(regReq)
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/v1/watchers", strings.NewReader(string(b)))
+		require.NoError(t, err)
+		req.Header.Add("User-Agent", UserAgent)
+		// one-time tokens are not restricted to the allowed ranges
+		req.RemoteAddr = "42.42.42.42:4242"
+		router.ServeHTTP(w, req)
+
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, register("expired", "Ohtee9Ki5aib0ahfaeghoo7ieG1eibie"))
+	assert.Equal(t, http.StatusAccepted, register("machine1", "Aeb7phoo3eeVah5aiyohSheiqu8ieh8u"))
+	// the token has been used
+	assert.Equal(t, http.StatusUnauthorized, register("machine2", "Aeb7phoo3eeVah5aiyohSheiqu8ieh8u"))
+
+	machine, err := dbClient.QueryMachineByID(ctx, "machine1")
+	require.NoError(t, err)
+	assert.True(t, machine.IsValidated)
+
+	tokens, err := dbClient.ListRegistrationTokens(ctx)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, "machine1", tokens[0].UsedBy)
+	assert.NotNil(t, tokens[0].UsedAt)
+}
+
+func TestOneTimeRegistrationTokenAlreadyExist(t *testing.T) {
+	ctx := t.Context()
+	router, config := NewAPITest(t, ctx)
+
+	dbClient, err := database.NewClient(ctx, config.API.Server.DbConfig, nil)
+	require.NoError(t, err)
+
+	_, err = dbClient.CreateRegistrationToken(ctx, middlewares.HashSHA512("Aeb7phoo3eeVah5aiyohSheiqu8ieh8u"), "", time.Now().UTC().Add(time.Hour))
+	require.NoError(t, err)
+
+	body := CreateTestMachine(t, ctx, router, "")
+	assert.NotEmpty(t, body)
+
+	regReq := MachineTest
+	regReq.RegistrationToken = "Aeb7phoo3eeVah5aiyohSheiqu8ieh8u"
+	b, err := json.Marshal(regReq)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/v1/watchers", strings.NewReader(string(b)))
+	require.NoError(t, err)
+	req.Header.Add("User-Agent", UserAgent)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// the registration failed, the token can be used again
+	tokens, err := dbClient.ListRegistrationTokens(ctx)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Nil(t, tokens[0].UsedAt)
+	assert.Empty(t, tokens[0].UsedBy)
+}
@@ -28,6 +28,7 @@ type APIKey struct {
 	HeaderName string
 	DbClient   *database.Client
 	TlsAuth    *TLSAuth
+	Supervisor *database.Supervisor // while the database is down, the bouncers are authenticated from its snapshot
 }
 
 // baseBouncerName removes any trailing "@<ip>" segments from a bouncer's name.
@@ -216,6 +217,37 @@ func (a *APIKey) authPlain(c *gin.Context, logger *log.Entry) *ent.Bouncer {
 	return bouncer
 }
 
+// authDegraded authenticates a bouncer from the snapshot of the database, without creating or updating anything.
+func (a *APIKey) authDegraded(c *gin.Context, logger *log.Entry) *ent.Bouncer {
+	snapshot := a.Supervisor.Snapshot()
+
+	if c.Request.TLS != nil && len(c.Request.TLS.PeerCertificates) > 0 {
+		if a.TlsAuth == nil {
+			return nil
+		}
+
+		extractedCN, err := a.TlsAuth.ValidateCert(c)
+		if err != nil {
+			logger.Warn(err)
+			return nil
+		}
+
+		bouncer := snapshot.BouncerByName(fmt.Sprintf("%s@%s", extractedCN, c.ClientIP()))
+		if bouncer == nil || bouncer.AuthType != types.TlsAuthType {
+			return nil
+		}
+
+		return bouncer
+	}
+
+	val, ok := c.Request.Header[APIKeyHeader]
+	if !ok {
+		return nil
+	}
+
+	return snapshot.BouncerByKey(HashSHA512(val[0]), types.ApiKeyAuthType, c.ClientIP())
+}
+
 func (a *APIKey) Middleware(c *gin.Context) {
 	var bouncer *ent.Bouncer
 
@@ -225,6 +257,20 @@ func (a *APIKey) Middleware(c *gin.Context) {
 
 	logger := log.WithField("ip", clientIP)
 
+	if a.Supervisor.Degraded() {
+		bouncer = a.authDegraded(c, logger)
+		if bouncer == nil {
+			c.JSON(http.StatusForbidden, gin.H{"message": "access forbidden"})
+			c.Abort()
+
+			return
+		}
+
+		c.Set(BouncerContextKey, bouncer)
+
+		return
+	}
+
 	if c.Request.TLS != nil && len(c.Request.TLS.PeerCertificates) > 0 {
 		bouncer = a.authTLS(c, logger)
 	} else {
@@ -265,7 +311,7 @@ func (a *APIKey) Middleware(c *gin.Context) {
 	}
 
 	if bouncer.Version != useragent[1] || bouncer.Type != useragent[0] {
-		if err := a.DbClient.UpdateBouncerTypeAndVersion(ctx, useragent[0], useragent[1], bouncer.ID); err != nil {
+		if err := a.DbClient.UpdateBouncerTypeAndVersion(ctx, useragent[0], useragent[1], bouncer); err != nil {
 			logger.Errorf("failed to update bouncer version and type: %s", err)
 			c.JSON(http.StatusForbidden, gin.H{"message": "bad user agent"})
 			c.Abort()
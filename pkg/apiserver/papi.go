@@ -33,6 +33,7 @@ var operationMap = map[string]func(context.Context, *Message, *Papi, bool) error
 	"decision":   DecisionCmd,
 	"alert":      AlertCmd,
 	"management": ManagementCmd,
+	"allowlist":  AllowlistCmd,
 }
 
 type Header struct {
@@ -73,6 +74,8 @@ type Papi struct {
 	Logger        *log.Entry
 	apic          *apic
 	stopChan      chan struct{}
+	// where the profiles pushed by the console are written
+	ManagedProfilesDir string
 }
 
 type PapiPermCheckError struct {
@@ -126,33 +129,43 @@ func NewPAPI(apic *apic, dbClient *database.Client, consoleConfig *csconfig.Cons
 	return papi, nil
 }
 
-func (p *Papi) handleEvent(ctx context.Context, event longpollclient.Event, sync bool) error {
-	logger := p.Logger.WithField("request-id", event.RequestId)
-	logger.Debugf("message received: %+v", event.Data)
-
+func parseMessage(event longpollclient.Event) (*Message, error) {
 	message := &Message{}
 	if err := json.Unmarshal([]byte(event.Data), message); err != nil {
-		return fmt.Errorf("polling papi message format is not compatible: %+v: %w", event.Data, err)
+		return nil, fmt.Errorf("polling papi message format is not compatible: %+v: %w", event.Data, err)
 	}
 
 	if message.Header == nil {
-		return errors.New("no header in message, skipping")
+		return nil, errors.New("no header in message, skipping")
 	}
 
 	if message.Header.Source == nil {
-		return errors.New("no source user in header message, skipping")
+		return nil, errors.New("no source user in header message, skipping")
+	}
+
+	if _, ok := operationMap[message.Header.OperationType]; !ok {
+		return message, fmt.Errorf("operation '%s' unknown, continue", message.Header.OperationType)
 	}
 
-	operationFunc, ok := operationMap[message.Header.OperationType]
-	if !ok {
-		return fmt.Errorf("operation '%s' unknown, continue", message.Header.OperationType)
+	return message, nil
+}
+
+func (p *Papi) handleEvent(ctx context.Context, event longpollclient.Event, sync bool) error {
+	logger := p.Logger.WithField("request-id", event.RequestId)
+	logger.Debugf("message received: %+v", event.Data)
+
+	message, err := parseMessage(event)
+	if err != nil {
+		return err
 	}
 
+	operationFunc := operationMap[message.Header.OperationType]
+
 	metrics.PapiOrdersReceived.WithLabelValues(message.Header.OperationType, message.Header.OperationCmd).Inc()
 
 	logger.Debugf("Calling operation '%s'", message.Header.OperationType)
 
-	err := operationFunc(ctx, message, p, sync)
+	err = operationFunc(ctx, message, p, sync)
 	if err != nil {
 		return fmt.Errorf("'%s %s failed: %w", message.Header.OperationType, message.Header.OperationCmd, err)
 	}
@@ -161,8 +174,13 @@ func (p *Papi) handleEvent(ctx context.Context, event longpollclient.Event, sync
 }
 
 func (p *Papi) GetPermissions(ctx context.Context) (PapiPermCheckSuccess, error) {
-	httpClient := p.apiClient.GetClient()
-	papiCheckURL := fmt.Sprintf("%s%s%s", p.URL, PAPIVersion, PAPIPermissionsURL)
+	return GetPAPIPermissions(ctx, p.apiClient, p.URL)
+}
+
+// GetPAPIPermissions returns the plan and the categories of orders of the instance, with a client logged in to the Central API.
+func GetPAPIPermissions(ctx context.Context, client *apiclient.ApiClient, papiURL string) (PapiPermCheckSuccess, error) {
+	httpClient := client.GetClient()
+	papiCheckURL := fmt.Sprintf("%s%s%s", papiURL, PAPIVersion, PAPIPermissionsURL)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, papiCheckURL, http.NoBody)
 	if err != nil {
@@ -197,6 +215,72 @@ func (p *Papi) GetPermissions(ctx context.Context) (PapiPermCheckSuccess, error)
 	return respBody, nil
 }
 
+// SubscriptionType is the plan of the instance, from the token of the central API.
+func (p *Papi) SubscriptionType() string {
+	return p.apiClient.GetSubscriptionType()
+}
+
+// Order is the header of an order received from PAPI, to list them without applying them.
+type Order struct {
+	RequestID string    `json:"request_id,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type,omitempty"`
+	Cmd       string    `json:"cmd,omitempty"`
+	User      string    `json:"user,omitempty"`
+	UUID      string    `json:"uuid,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	// why the order would be ignored
+	Error string `json:"error,omitempty"`
+}
+
+func newOrder(event longpollclient.Event) Order {
+	order := Order{
+		RequestID: event.RequestId,
+		Timestamp: time.UnixMilli(event.Timestamp).UTC(),
+	}
+
+	message, err := parseMessage(event)
+	if err != nil {
+		order.Error = err.Error()
+	}
+
+	if message == nil {
+		return order
+	}
+
+	order.Type = message.Header.OperationType
+	order.Cmd = message.Header.OperationCmd
+	order.UUID = message.Header.UUID
+	order.Message = message.Header.Message
+
+	if message.Header.Source != nil {
+		order.User = message.Header.Source.User
+	}
+
+	if !message.Header.Timestamp.IsZero() {
+		order.Timestamp = message.Header.Timestamp.UTC()
+	}
+
+	return order
+}
+
+// ListOrders returns the orders sent since the given time, in the order they are applied, without
+// applying them.
+func (p *Papi) ListOrders(ctx context.Context, since time.Time) ([]Order, error) {
+	events, err := p.Client.PullOnce(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]Order, 0, len(events))
+
+	for _, event := range reverse(events) {
+		ret = append(ret, newOrder(event))
+	}
+
+	return ret, nil
+}
+
 func reverse(s []longpollclient.Event) []longpollclient.Event {
 	a := make([]longpollclient.Event, len(s))
 	copy(a, s)
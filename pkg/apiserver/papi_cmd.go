@@ -11,6 +11,7 @@ import (
 
 	"github.com/crowdsecurity/crowdsec/pkg/apiclient"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/managedconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/models"
 	"github.com/crowdsecurity/crowdsec/pkg/modelscapi"
 	"github.com/crowdsecurity/crowdsec/pkg/types"
@@ -35,6 +36,14 @@ type allowlistUnsubscribe struct {
 	Id   string `json:"id"`
 }
 
+// allowlistContent is an allowlist defined in the console, pushed with its content.
+type allowlistContent struct {
+	Id          string                  `json:"id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Items       []*models.AllowlistItem `json:"items"`
+}
+
 func DecisionCmd(ctx context.Context, message *Message, p *Papi, sync bool) error {
 	switch message.Header.OperationCmd {
 	case "delete":
@@ -319,6 +328,113 @@ func ManagementCmd(ctx context.Context, message *Message, p *Papi, sync bool) er
 		}
 
 		return nil
+	case "config_push":
+		data, err := json.Marshal(message.Data)
+		if err != nil {
+			return err
+		}
+
+		snippet := managedconfig.Snippet{}
+
+		if err := json.Unmarshal(data, &snippet); err != nil {
+			return fmt.Errorf("message for '%s' contains bad data format: %w", message.Header.OperationType, err)
+		}
+
+		p.Logger.Infof("Received config_push command from PAPI for %s %s", snippet.Kind, snippet.Name)
+
+		if err := p.pushConfig(ctx, snippet); err != nil {
+			return fmt.Errorf("unable to apply %s %s: %w", snippet.Kind, snippet.Name, err)
+		}
+	case "config_delete":
+		data, err := json.Marshal(message.Data)
+		if err != nil {
+			return err
+		}
+
+		deleteMsg := configDelete{}
+
+		if err := json.Unmarshal(data, &deleteMsg); err != nil {
+			return fmt.Errorf("message for '%s' contains bad data format: %w", message.Header.OperationType, err)
+		}
+
+		if deleteMsg.Name == "" {
+			return fmt.Errorf("message for '%s' contains bad data format: missing name", message.Header.OperationType)
+		}
+
+		p.Logger.Infof("Received config_delete command from PAPI for %s %s", deleteMsg.Kind, deleteMsg.Name)
+
+		if err := p.deleteConfig(ctx, deleteMsg.Kind, deleteMsg.Name); err != nil {
+			return fmt.Errorf("unable to remove %s %s: %w", deleteMsg.Kind, deleteMsg.Name, err)
+		}
+	default:
+		return fmt.Errorf("unknown command '%s' for operation type '%s'", message.Header.OperationCmd, message.Header.OperationType)
+	}
+
+	return nil
+}
+
+func AllowlistCmd(ctx context.Context, message *Message, p *Papi, sync bool) error {
+	data, err := json.Marshal(message.Data)
+	if err != nil {
+		return err
+	}
+
+	switch message.Header.OperationCmd {
+	case "add":
+		allowlistMsg := allowlistContent{}
+
+		if err := json.Unmarshal(data, &allowlistMsg); err != nil {
+			return fmt.Errorf("message for '%s' contains bad data format: %w", message.Header.OperationType, err)
+		}
+
+		if allowlistMsg.Name == "" {
+			return fmt.Errorf("message for '%s' contains bad data format: missing allowlist name", message.Header.OperationType)
+		}
+
+		if allowlistMsg.Id == "" {
+			return fmt.Errorf("message for '%s' contains bad data format: missing allowlist id", message.Header.OperationType)
+		}
+
+		for _, item := range allowlistMsg.Items {
+			if err := item.Validate(strfmt.Default); err != nil {
+				return fmt.Errorf("message for '%s' contains bad data format: %w", message.Header.OperationType, err)
+			}
+		}
+
+		p.Logger.Infof("Received allowlist %s from PAPI (%d items)", allowlistMsg.Name, len(allowlistMsg.Items))
+
+		if err := p.apic.saveAllowlist(ctx, allowlistMsg.Id, allowlistMsg.Name, allowlistMsg.Description, allowlistMsg.Items); err != nil {
+			return err
+		}
+
+		deleted, err := p.DBClient.ApplyAllowlistsToExistingDecisions(ctx)
+		if err != nil {
+			log.Errorf("could not apply allowlists to existing decisions: %s", err)
+		}
+
+		if deleted > 0 {
+			log.Infof("deleted %d decisions from allowlists", deleted)
+		}
+	case "delete":
+		deleteMsg := allowlistUnsubscribe{}
+
+		if err := json.Unmarshal(data, &deleteMsg); err != nil {
+			return fmt.Errorf("message for '%s' contains bad data format: %w", message.Header.OperationType, err)
+		}
+
+		if deleteMsg.Id == "" {
+			return fmt.Errorf("message for '%s' contains bad data format: missing allowlist id", message.Header.OperationType)
+		}
+
+		p.Logger.Infof("Received allowlist deletion from PAPI, deleting allowlist %s", deleteMsg.Name)
+
+		if err := p.DBClient.DeleteAllowListByID(ctx, deleteMsg.Name, deleteMsg.Id, true); err != nil {
+			if !ent.IsNotFound(err) {
+				return err
+			}
+
+			p.Logger.Warningf("Allowlist %s not found", deleteMsg.Name)
+		}
 	default:
 		return fmt.Errorf("unknown command '%s' for operation type '%s'", message.Header.OperationCmd, message.Header.OperationType)
 	}
@@ -0,0 +1,128 @@
+package apiserver
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csnet"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+)
+
+func TestAllowlistCmd(t *testing.T) {
+	ctx := t.Context()
+	api := getAPIC(t, ctx)
+
+	p := &Papi{
+		DBClient: api.dbClient,
+		apic:     api,
+		Logger:   log.WithField("test", "papi"),
+	}
+
+	rng, err := csnet.NewRange("1.2.3.4")
+	require.NoError(t, err)
+
+	api.dbClient.Ent.Decision.Create().
+		SetOrigin(types.CrowdSecOrigin).
+		SetType("ban").
+		SetValue("1.2.3.4").
+		SetScope("Ip").
+		SetScenario("crowdsecurity/ssh-bf").
+		SetUntil(time.Now().Add(time.Hour)).
+		SetIPSize(int64(rng.Size())).
+		SetStartIP(rng.Start.Addr).
+		SetStartSuffix(rng.Start.Sfx).
+		SetEndIP(rng.End.Addr).
+		SetEndSuffix(rng.End.Sfx).
+		ExecX(ctx)
+	assertTotalValidDecisionCount(t, api.dbClient, 1)
+
+	msg := &Message{
+		Header: &Header{OperationType: "allowlist", OperationCmd: "add"},
+		Data: map[string]any{
+			"id":          "42",
+			"name":        "office",
+			"description": "office network",
+			"items": []map[string]any{
+				{"value": "1.2.3.0/24", "description": "hq"},
+				{"value": "5.6.7.8"},
+			},
+		},
+	}
+
+	require.NoError(t, AllowlistCmd(ctx, msg, p, false))
+
+	list, err := api.dbClient.GetAllowListByID(ctx, "42", true)
+	require.NoError(t, err)
+	assert.Equal(t, "office", list.Name)
+	assert.Equal(t, "office network", list.Description)
+	assert.True(t, list.FromConsole)
+	assert.Len(t, list.Edges.AllowlistItems, 2)
+
+	// existing decisions are expired
+	assertTotalValidDecisionCount(t, api.dbClient, 0)
+
+	allowlisted, _, err := api.dbClient.IsAllowlisted(ctx, "5.6.7.8")
+	require.NoError(t, err)
+	assert.True(t, allowlisted)
+
+	// the content is replaced
+	msg.Data = map[string]any{
+		"id":    "42",
+		"name":  "office",
+		"items": []map[string]any{{"value": "9.9.9.9"}},
+	}
+
+	require.NoError(t, AllowlistCmd(ctx, msg, p, true))
+
+	list, err = api.dbClient.GetAllowListByID(ctx, "42", true)
+	require.NoError(t, err)
+	require.Len(t, list.Edges.AllowlistItems, 1)
+	assert.Equal(t, "9.9.9.9", list.Edges.AllowlistItems[0].Value)
+
+	msg.Data = map[string]any{"name": "office"}
+	require.EqualError(t, AllowlistCmd(ctx, msg, p, false), "message for 'allowlist' contains bad data format: missing allowlist id")
+
+	msg.Header.OperationCmd = "delete"
+	msg.Data = map[string]any{"id": "42", "name": "office"}
+	require.NoError(t, AllowlistCmd(ctx, msg, p, false))
+
+	lists, err := api.dbClient.ListAllowLists(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, lists)
+
+	msg.Header.OperationCmd = "foo"
+	require.EqualError(t, AllowlistCmd(ctx, msg, p, false), "unknown command 'foo' for operation type 'allowlist'")
+}
+
+func TestWhitelistedByRange(t *testing.T) {
+	api := &apic{}
+
+	ips := []netip.Addr{netip.MustParseAddr("1.2.3.4")}
+	ranges := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/16")}
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"1.2.3.4", "1.2.3.4"},
+		{"10.0.1.1", "10.0.0.0/16"},
+		{"5.5.5.5", ""},
+		{"1.2.3.0/24", "1.2.3.4"},
+		{"10.0.0.0/8", "10.0.0.0/16"},
+		{"10.0.5.0/24", "10.0.0.0/16"},
+		{"5.5.0.0/16", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			d := &models.Decision{Value: new(tc.value)}
+			assert.Equal(t, tc.expected, api.whitelistedBy(d, ips, ranges))
+		})
+	}
+}
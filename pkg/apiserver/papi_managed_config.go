@@ -0,0 +1,127 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/csprofiles"
+	"github.com/crowdsecurity/crowdsec/pkg/managedconfig"
+)
+
+// configDelete removes a configuration file sent with config_push.
+type configDelete struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+func validateManagedProfiles(content string) error {
+	profiles, err := csconfig.DecodeProfiles(strings.NewReader(content), false)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range profiles {
+		if p.Name == "" {
+			return errors.New("profile without a name")
+		}
+	}
+
+	_, err = csprofiles.NewProfile(profiles)
+
+	return err
+}
+
+// pushConfig stores a configuration file sent by the console. The profiles are written in the managed
+// directory of the local API, the acquisition is kept in the database until the machines fetch it.
+// They are applied on the next reload.
+func (p *Papi) pushConfig(ctx context.Context, snippet managedconfig.Snippet) error {
+	if err := snippet.Validate(); err != nil {
+		return err
+	}
+
+	switch snippet.Kind {
+	case managedconfig.KindProfiles:
+		if err := validateManagedProfiles(snippet.Content); err != nil {
+			return fmt.Errorf("invalid profiles: %w", err)
+		}
+
+		if p.ManagedProfilesDir == "" {
+			return errors.New("no directory for the managed profiles")
+		}
+
+		if err := managedconfig.WriteFile(p.ManagedProfilesDir, snippet); err != nil {
+			return err
+		}
+
+		p.Logger.Infof("Profiles %s written in %s, they will be applied on the next reload", snippet.Name, p.ManagedProfilesDir)
+	case managedconfig.KindAcquisition:
+		snippets, err := p.DBClient.GetManagedConfig(ctx)
+		if err != nil {
+			return err
+		}
+
+		snippets = slices.DeleteFunc(snippets, func(s managedconfig.Snippet) bool {
+			return s.Kind == snippet.Kind && s.Name == snippet.Name
+		})
+
+		snippets = append(snippets, snippet)
+
+		if err := p.DBClient.SetManagedConfig(ctx, snippets); err != nil {
+			return err
+		}
+
+		target := "all machines"
+		if len(snippet.Machines) > 0 {
+			target = strings.Join(snippet.Machines, ", ")
+		}
+
+		p.Logger.Infof("Acquisition %s stored for %s, it will be applied when they reload", snippet.Name, target)
+	}
+
+	return nil
+}
+
+func (p *Papi) deleteConfig(ctx context.Context, kind string, name string) error {
+	switch kind {
+	case managedconfig.KindProfiles:
+		if p.ManagedProfilesDir == "" {
+			return errors.New("no directory for the managed profiles")
+		}
+
+		if err := managedconfig.RemoveFile(p.ManagedProfilesDir, name); err != nil {
+			return err
+		}
+
+		p.Logger.Infof("Profiles %s removed, the change will be applied on the next reload", name)
+	case managedconfig.KindAcquisition:
+		snippets, err := p.DBClient.GetManagedConfig(ctx)
+		if err != nil {
+			return err
+		}
+
+		before := len(snippets)
+
+		snippets = slices.DeleteFunc(snippets, func(s managedconfig.Snippet) bool {
+			return s.Kind == kind && s.Name == name
+		})
+
+		if len(snippets) == before {
+			p.Logger.Warningf("Acquisition %s not found", name)
+			return nil
+		}
+
+		if err := p.DBClient.SetManagedConfig(ctx, snippets); err != nil {
+			return err
+		}
+
+		p.Logger.Infof("Acquisition %s removed, the change will be applied when the machines reload", name)
+	default:
+		return fmt.Errorf("unknown kind %q", kind)
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package apiserver
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/managedconfig"
+)
+
+func TestManagementConfigPush(t *testing.T) {
+	ctx := t.Context()
+	api := getAPIC(t, ctx)
+
+	p := &Papi{
+		DBClient:           api.dbClient,
+		apic:               api,
+		Logger:             log.WithField("test", "papi"),
+		ManagedProfilesDir: t.TempDir(),
+	}
+
+	push := func(data map[string]any) error {
+		msg := &Message{
+			Header: &Header{OperationType: "management", OperationCmd: "config_push"},
+			Data:   data,
+		}
+
+		return ManagementCmd(ctx, msg, p, false)
+	}
+
+	remove := func(kind string, name string) error {
+		msg := &Message{
+			Header: &Header{OperationType: "management", OperationCmd: "config_delete"},
+			Data:   map[string]any{"kind": kind, "name": name},
+		}
+
+		return ManagementCmd(ctx, msg, p, false)
+	}
+
+	// acquisition, stored for the machines
+
+	require.NoError(t, push(map[string]any{
+		"kind":     "acquisition",
+		"name":     "nginx",
+		"content":  "source: file\nfilenames: [/var/log/nginx/*.log]\nlabels:\n  type: nginx\n",
+		"machines": []string{"web1"},
+	}))
+	require.NoError(t, push(map[string]any{
+		"kind":    "acquisition",
+		"name":    "ssh",
+		"content": "source: journalctl\njournalctl_filter: [_SYSTEMD_UNIT=ssh.service]\nlabels:\n  type: syslog\n",
+	}))
+	// update
+	require.NoError(t, push(map[string]any{
+		"kind":     "acquisition",
+		"name":     "nginx",
+		"content":  "source: file\nfilenames: [/var/log/nginx/access.log]\nlabels:\n  type: nginx\n",
+		"machines": []string{"web1", "web2"},
+	}))
+
+	snippets, err := api.dbClient.GetManagedConfig(ctx)
+	require.NoError(t, err)
+	require.Len(t, snippets, 2)
+	assert.Equal(t, "ssh", snippets[0].Name)
+	assert.Equal(t, "nginx", snippets[1].Name)
+	assert.Equal(t, []string{"web1", "web2"}, snippets[1].Machines)
+	assert.Contains(t, snippets[1].Content, "access.log")
+
+	err = push(map[string]any{"kind": "acquisition", "name": "cmd", "content": "source: exec\ncommand: id\n"})
+	cstest.RequireErrorContains(t, err, "unable to apply acquisition cmd: the exec datasource can't be pushed by the console")
+
+	require.NoError(t, remove("acquisition", "ssh"))
+	require.NoError(t, remove("acquisition", "ssh"))
+
+	snippets, err = api.dbClient.GetManagedConfig(ctx)
+	require.NoError(t, err)
+	require.Len(t, snippets, 1)
+
+	// profiles, written for the next reload
+
+	require.NoError(t, push(map[string]any{
+		"kind":    "profiles",
+		"name":    "long_bans",
+		"content": "name: long_bans\nfilters:\n - Alert.Remediation == true\ndecisions:\n - type: ban\n   duration: 24h\non_success: break\n",
+	}))
+
+	path := filepath.Join(p.ManagedProfilesDir, "long_bans.yaml")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "name: long_bans\n")
+
+	err = push(map[string]any{"kind": "profiles", "name": "bad", "content": "name: bad\nfilters:\n - Alert.Foo(\n"})
+	cstest.RequireErrorContains(t, err, "unable to apply profiles bad: invalid profiles:")
+
+	err = push(map[string]any{"kind": "profiles", "name": "bad", "content": "filters:\n - true\n"})
+	cstest.RequireErrorContains(t, err, "unable to apply profiles bad: invalid profiles: profile without a name")
+
+	err = push(map[string]any{"kind": "profiles", "name": "bad", "content": "name: bad\nunknown: 1\n"})
+	cstest.RequireErrorContains(t, err, "field unknown not found in type csconfig.ProfileCfg")
+
+	// local changes are kept
+	require.NoError(t, os.WriteFile(path, []byte("name: long_bans\non_success: continue\n"), 0o600))
+
+	err = push(map[string]any{"kind": "profiles", "name": "long_bans", "content": "name: long_bans\n"})
+	require.ErrorIs(t, err, managedconfig.ErrLocalFile)
+
+	err = remove("profiles", "long_bans")
+	require.ErrorIs(t, err, managedconfig.ErrLocalFile)
+
+	require.NoError(t, os.Remove(path))
+
+	err = remove("parsers", "x")
+	cstest.RequireErrorContains(t, err, `unable to remove parsers x: unknown kind "parsers"`)
+
+	// ignored when syncing
+	msg := &Message{
+		Header: &Header{OperationType: "management", OperationCmd: "config_push"},
+		Data:   map[string]any{"kind": "profiles", "name": "p", "content": "name: p\n"},
+	}
+	require.NoError(t, ManagementCmd(ctx, msg, p, true))
+	assert.NoFileExists(t, filepath.Join(p.ManagedProfilesDir, "p.yaml"))
+}
+
+func TestGetManagedConfig(t *testing.T) {
+	ctx := t.Context()
+	lapi := SetupLAPITest(t, ctx)
+
+	w := lapi.RecordResponse(t, ctx, http.MethodGet, "/v1/watchers/self/managed_config", emptyBody, passwordAuthType)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+
+	err := lapi.DBClient.SetManagedConfig(ctx, []managedconfig.Snippet{
+		{Kind: managedconfig.KindAcquisition, Name: "all", Content: "source: file\n"},
+		{Kind: managedconfig.KindAcquisition, Name: "mine", Content: "source: journalctl\n", Machines: []string{"other", testMachineID}},
+		{Kind: managedconfig.KindAcquisition, Name: "other", Content: "source: file\n", Machines: []string{"other"}},
+	})
+	require.NoError(t, err)
+
+	w = lapi.RecordResponse(t, ctx, http.MethodGet, "/v1/watchers/self/managed_config", emptyBody, passwordAuthType)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[
+		{"kind": "acquisition", "name": "all", "content": "source: file\n"},
+		{"kind": "acquisition", "name": "mine", "content": "source: journalctl\n"}
+	]`, w.Body.String())
+
+	w = lapi.RecordResponse(t, ctx, http.MethodGet, "/v1/watchers/self/managed_config", emptyBody, apiKeyAuthType)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,63 @@
+package apiserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crowdsecurity/crowdsec/pkg/longpollclient"
+)
+
+func TestNewOrder(t *testing.T) {
+	received := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		data     string
+		expected Order
+	}{
+		{
+			name: "decision",
+			data: `{"header": {"operation_type": "decision", "operation_cmd": "delete", "timestamp": "2026-10-01T11:59:58Z",
+				"message": "unban", "uuid": "1234", "source": {"user": "alice@example.com"}}, "data": ["5678"]}`,
+			expected: Order{
+				RequestID: "req",
+				Timestamp: time.Date(2026, 10, 1, 11, 59, 58, 0, time.UTC),
+				Type:      "decision",
+				Cmd:       "delete",
+				User:      "alice@example.com",
+				UUID:      "1234",
+				Message:   "unban",
+			},
+		},
+		{
+			name: "unknown operation",
+			data: `{"header": {"operation_type": "firmware", "operation_cmd": "upgrade", "source": {"user": "bob"}}}`,
+			expected: Order{
+				RequestID: "req",
+				Timestamp: received,
+				Type:      "firmware",
+				Cmd:       "upgrade",
+				User:      "bob",
+				Error:     "operation 'firmware' unknown, continue",
+			},
+		},
+		{
+			name: "no source",
+			data: `{"header": {"operation_type": "decision", "operation_cmd": "delete"}}`,
+			expected: Order{
+				RequestID: "req",
+				Timestamp: received,
+				Error:     "no source user in header message, skipping",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			order := newOrder(longpollclient.Event{Timestamp: received.UnixMilli(), Data: tc.data, RequestId: "req"})
+			assert.Equal(t, tc.expected, order)
+		})
+	}
+}
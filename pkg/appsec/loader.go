@@ -1,6 +1,7 @@
 package appsec
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -12,34 +13,50 @@ import (
 
 var appsecRules = make(map[string]AppsecCollectionConfig) // FIXME: would probably be better to have a struct for this
 
+var errEmptyRuleName = errors.New("appsec rule name is empty")
+
+// AddAppsecRuleFile makes the rules of a file available to the appsec configs, under its name.
+// It replaces the rules with the same name. It returns the name.
+func AddAppsecRuleFile(path string, hash string, version string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read file %s : %w", path, err)
+	}
+
+	var rule AppsecCollectionConfig
+
+	err = yaml.UnmarshalStrict(content, &rule)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse file %s : %w", path, err)
+	}
+
+	if rule.Name == "" {
+		return "", fmt.Errorf("%w for %s", errEmptyRuleName, path)
+	}
+
+	rule.hash = hash
+	rule.version = version
+
+	appsecRules[rule.Name] = rule
+
+	return rule.Name, nil
+}
+
 func LoadAppsecRules(hub *cwhub.Hub) error {
 	appsecRules = make(map[string]AppsecCollectionConfig)
 
 	for _, hubAppsecRuleItem := range hub.GetInstalledByType(cwhub.APPSEC_RULES, false) {
-		content, err := os.ReadFile(hubAppsecRuleItem.State.LocalPath)
-		if err != nil {
-			log.Warnf("unable to read file %s : %s", hubAppsecRuleItem.State.LocalPath, err)
-			continue
+		name, err := AddAppsecRuleFile(hubAppsecRuleItem.State.LocalPath, hubAppsecRuleItem.State.LocalHash, hubAppsecRuleItem.Version)
+		if errors.Is(err, errEmptyRuleName) {
+			return err
 		}
 
-		var rule AppsecCollectionConfig
-
-		err = yaml.UnmarshalStrict(content, &rule)
 		if err != nil {
-			log.Warnf("unable to parse file %s : %s", hubAppsecRuleItem.State.LocalPath, err)
+			log.Warn(err)
 			continue
 		}
 
-		if rule.Name == "" {
-			return fmt.Errorf("appsec rule name is empty for %s", hubAppsecRuleItem.State.LocalPath)
-		}
-
-		rule.hash = hubAppsecRuleItem.State.LocalHash
-		rule.version = hubAppsecRuleItem.Version
-
-		log.Infof("Adding %s to appsec rules", rule.Name)
-
-		appsecRules[rule.Name] = rule
+		log.Infof("Adding %s to appsec rules", name)
 	}
 
 	if len(appsecRules) == 0 {
@@ -0,0 +1,32 @@
+package appsec
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+func unixPeerInfo(c *net.UnixConn) *UnixPeerInfo {
+	raw, err := c.SyscallConn()
+	if err != nil {
+		return nil
+	}
+
+	var (
+		cred    *unix.Ucred
+		credErr error
+	)
+
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil || credErr != nil {
+		return nil
+	}
+
+	return &UnixPeerInfo{
+		PID: int(cred.Pid),
+		UID: int(cred.Uid),
+		GID: int(cred.Gid),
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package appsec
+
+import "net"
+
+// the peer credentials are only read on linux (SO_PEERCRED)
+func unixPeerInfo(_ *net.UnixConn) *UnixPeerInfo {
+	return nil
+}
@@ -0,0 +1,86 @@
+package appsec
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RemediationComponent describes the remediation component (bouncer) that forwarded a request
+// to the appsec engine, so that hooks can behave differently for each ingress point.
+type RemediationComponent struct {
+	// Name and Version are read from the User-Agent of the component, e.g. crowdsec-nginx-bouncer/v1.0.8
+	Name      string `json:"name,omitempty"`
+	Version   string `json:"version,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	// RemoteAddr is the address the component connected from
+	RemoteAddr string `json:"remote_addr,omitempty"`
+	// Listener is the address the appsec engine accepted the connection on: unix:<socket path> or tcp:<ip:port>
+	Listener string `json:"listener,omitempty"`
+	// ForwardedBy is the X-Forwarded-For chain of the proxies between the component and the appsec engine
+	ForwardedBy []string `json:"forwarded_by,omitempty"`
+	// Peer holds the credentials of the component process, only for unix sockets on supported systems
+	Peer *UnixPeerInfo `json:"peer,omitempty"`
+}
+
+// UnixPeerInfo holds the credentials of the process connected to a unix socket.
+type UnixPeerInfo struct {
+	PID int `json:"pid"`
+	UID int `json:"uid"`
+	GID int `json:"gid"`
+}
+
+type connInfoKey struct{}
+
+type connInfo struct {
+	listener string
+	peer     *UnixPeerInfo
+}
+
+// ConnContext is meant to be used as http.Server.ConnContext. It records, once per connection,
+// the listener the connection was accepted on and the credentials of the peer for unix sockets.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	addr := c.LocalAddr()
+	info := &connInfo{listener: addr.Network() + ":" + addr.String()}
+
+	if uc, ok := c.(*net.UnixConn); ok {
+		info.peer = unixPeerInfo(uc)
+	}
+
+	return context.WithValue(ctx, connInfoKey{}, info)
+}
+
+// newRemediationComponent must be called before the forwarded headers are applied to the request,
+// since they replace the User-Agent of the component.
+func newRemediationComponent(r *http.Request) RemediationComponent {
+	ret := RemediationComponent{
+		UserAgent:  r.Header.Get("User-Agent"),
+		RemoteAddr: r.RemoteAddr,
+	}
+
+	// the first product of the user agent, the comments and other products are ignored
+	product, _, _ := strings.Cut(ret.UserAgent, " ")
+	ret.Name, ret.Version, _ = strings.Cut(product, "/")
+
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		for hop := range strings.SplitSeq(h, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				ret.ForwardedBy = append(ret.ForwardedBy, hop)
+			}
+		}
+	}
+
+	if info, ok := r.Context().Value(connInfoKey{}).(*connInfo); ok {
+		ret.Listener = info.listener
+		ret.Peer = info.peer
+	}
+
+	return ret
+}
+
+// UnixSocketPeerInfo returns the credentials of the remediation component if it connected
+// through a unix socket, nil otherwise.
+func (r *ParsedRequest) UnixSocketPeerInfo() *UnixPeerInfo {
+	return r.RemediationComponent.Peer
+}
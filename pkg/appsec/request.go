@@ -49,6 +49,8 @@ type ParsedRequest struct {
 	AppsecEngine         string                  `json:"appsec_engine,omitempty"`
 	RemoteAddrNormalized string                  `json:"normalized_remote_addr,omitempty"`
 	HTTPRequest          *http.Request           `json:"-"`
+	// RemediationComponent describes the component that forwarded the request, not the client.
+	RemediationComponent RemediationComponent `json:"remediation_component"`
 	// BodyTruncated is true when the body was larger than the configured limit and was truncated (partial mode).
 	BodyTruncated bool `json:"body_truncated,omitempty"`
 	// BodySizeExceeded is true when the body exceeded the configured limit and the action is drop.
@@ -423,6 +425,7 @@ func NewParsedRequestFromRequest(r *http.Request, logger *log.Entry, bodySetting
 	}
 
 	userAgent := r.Header.Get(UserAgentHeaderName)
+	remediationComponent := newRemediationComponent(r)
 
 	transactionID := r.Header.Get(TransactionIDHeaderName)
 	if transactionID == "" {
@@ -484,5 +487,6 @@ func NewParsedRequestFromRequest(r *http.Request, logger *log.Entry, bodySetting
 		ResponseChannel:      make(chan AppsecTempResponse),
 		RemoteAddrNormalized: normalizeRemoteAddr(r.RemoteAddr),
 		HTTPRequest:          originalHTTPRequest,
+		RemediationComponent: remediationComponent,
 	}, nil
 }
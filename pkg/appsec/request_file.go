@@ -0,0 +1,258 @@
+package appsec
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maximum size of a request file
+const maxRequestFileSize = 64 * 1024 * 1024
+
+// requestLine matches the first line of a raw HTTP/1.x request
+var requestLine = regexp.MustCompile(`^[A-Z]+ \S+ HTTP/\d(\.\d)?\r?$`)
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text"`
+	Params   []harNameValue `json:"params"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	PostData    *harPostData   `json:"postData"`
+}
+
+type harArchive struct {
+	Log struct {
+		Entries []struct {
+			Request harRequest `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+func (h *harRequest) toHTTP() (*http.Request, error) {
+	var body []byte
+
+	if h.PostData != nil {
+		body = []byte(h.PostData.Text)
+
+		// forms can be recorded as a list of parameters
+		if h.PostData.Text == "" && len(h.PostData.Params) > 0 {
+			form := url.Values{}
+			for _, param := range h.PostData.Params {
+				form.Add(param.Name, param.Value)
+			}
+
+			body = []byte(form.Encode())
+		}
+	}
+
+	req, err := http.NewRequest(h.Method, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, header := range h.Headers {
+		switch strings.ToLower(header.Name) {
+		case ":authority", "host":
+			req.Host = header.Value
+		case "content-length":
+			// it's the length of the body we have
+		default:
+			// the other HTTP/2 pseudo-headers are in the request line
+			if !strings.HasPrefix(header.Name, ":") {
+				req.Header.Add(header.Name, header.Value)
+			}
+		}
+	}
+
+	if h.PostData != nil && h.PostData.MimeType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", h.PostData.MimeType)
+	}
+
+	switch strings.ToLower(h.HTTPVersion) {
+	case "h2", "http/2", "http/2.0":
+		req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/2", 2, 0
+	case "h3", "http/3", "http/3.0":
+		req.Proto, req.ProtoMajor, req.ProtoMinor = "HTTP/3", 3, 0
+	default:
+		if major, minor, ok := http.ParseHTTPVersion(strings.ToUpper(h.HTTPVersion)); ok {
+			req.Proto, req.ProtoMajor, req.ProtoMinor = strings.ToUpper(h.HTTPVersion), major, minor
+		}
+	}
+
+	return req, nil
+}
+
+// readHARRequests returns the requests of a HAR archive.
+func readHARRequests(content []byte) ([]*http.Request, error) {
+	archive := harArchive{}
+
+	if err := json.Unmarshal(content, &archive); err != nil {
+		return nil, fmt.Errorf("invalid HAR archive: %w", err)
+	}
+
+	ret := make([]*http.Request, 0, len(archive.Log.Entries))
+
+	for idx, entry := range archive.Log.Entries {
+		req, err := entry.Request.toHTTP()
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", idx, err)
+		}
+
+		ret = append(ret, req)
+	}
+
+	return ret, nil
+}
+
+// skipBlankLines consumes the empty lines before a request.
+func skipBlankLines(r *bufio.Reader) error {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return err
+		}
+
+		if b[0] != '\r' && b[0] != '\n' {
+			return nil
+		}
+
+		if _, err := r.ReadByte(); err != nil {
+			return err
+		}
+	}
+}
+
+// readRawRequests returns the HTTP/1.x requests written one after the other. A request without
+// Content-Length or Transfer-Encoding header is followed by its body, if what follows is not another request.
+func readRawRequests(content []byte) ([]*http.Request, error) {
+	r := bufio.NewReader(bytes.NewReader(content))
+	ret := []*http.Request{}
+
+	for {
+		if err := skipBlankLines(r); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, err
+		}
+
+		req, err := http.ReadRequest(r)
+		if err != nil {
+			return nil, fmt.Errorf("request %d: %w", len(ret)+1, err)
+		}
+
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("request %d: unable to read body: %w", len(ret)+1, err)
+		}
+
+		if req.ContentLength <= 0 && len(req.TransferEncoding) == 0 {
+			rest, _ := io.ReadAll(r)
+			firstLine, _, _ := strings.Cut(strings.TrimLeft(string(rest), "\r\n"), "\n")
+
+			if requestLine.MatchString(firstLine) {
+				r = bufio.NewReader(bytes.NewReader(rest))
+			} else {
+				body = bytes.TrimRight(rest, "\r\n")
+				r = bufio.NewReader(bytes.NewReader(nil))
+			}
+		}
+
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+		req.Header.Del("Content-Length")
+		req.TransferEncoding = nil
+
+		ret = append(ret, req)
+	}
+
+	return ret, nil
+}
+
+// ReadRequestFile returns the requests recorded in a file: a HAR archive (.har file, or JSON content),
+// or one or more raw HTTP/1.x requests.
+func ReadRequestFile(path string) ([]*http.Request, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(io.LimitReader(f, maxRequestFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) > maxRequestFileSize {
+		return nil, fmt.Errorf("%s: larger than %d bytes", path, maxRequestFileSize)
+	}
+
+	var reqs []*http.Request
+
+	if strings.EqualFold(filepath.Ext(path), ".har") || bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		reqs, err = readHARRequests(content)
+	} else {
+		reqs, err = readRawRequests(content)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("%s: no request found", path)
+	}
+
+	return reqs, nil
+}
+
+// NewParsedRequestFromClientRequest returns the ParsedRequest of a request as sent by the client (i.e.
+// a recorded request), instead of as forwarded by a remediation component, with the given client ip.
+func NewParsedRequestFromClientRequest(r *http.Request, clientIP string, logger *log.Entry, bodySettings BodySettings) (ParsedRequest, error) {
+	forwarded := r.Clone(r.Context())
+
+	forwarded.Header.Set(IPHeaderName, clientIP)
+	forwarded.Header.Set(URIHeaderName, r.URL.RequestURI())
+	forwarded.Header.Set(VerbHeaderName, r.Method)
+
+	if host := cmp.Or(r.Host, r.URL.Host); host != "" {
+		forwarded.Header.Set(HostHeaderName, host)
+	}
+
+	if ua := r.UserAgent(); ua != "" {
+		forwarded.Header.Set(UserAgentHeaderName, ua)
+	}
+
+	if r.ProtoMajor > 0 && r.ProtoMajor < 10 && r.ProtoMinor >= 0 && r.ProtoMinor < 10 {
+		forwarded.Header.Set(HTTPVersionHeaderName, fmt.Sprintf("%d%d", r.ProtoMajor, r.ProtoMinor))
+	}
+
+	// the address of the remediation component
+	forwarded.RemoteAddr = "127.0.0.1:65535"
+
+	return NewParsedRequestFromRequest(forwarded, logger, bodySettings)
+}
@@ -0,0 +1,99 @@
+package appsec
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func writeRequestFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestReadRequestFileHAR(t *testing.T) {
+	path := writeRequestFile(t, "session.har", `{"log": {"entries": [
+		{"request": {"method": "GET", "url": "https://example.com/search?q=1", "httpVersion": "h2",
+			"headers": [{"name": ":authority", "value": "example.com"}, {"name": ":path", "value": "/search?q=1"}, {"name": "user-agent", "value": "firefox"}]}},
+		{"request": {"method": "POST", "url": "https://example.com/login", "httpVersion": "HTTP/1.1",
+			"headers": [{"name": "Host", "value": "example.com"}, {"name": "Content-Length", "value": "999"}],
+			"postData": {"mimeType": "application/x-www-form-urlencoded", "params": [{"name": "user", "value": "admin"}, {"name": "pass", "value": "' or 1=1"}]}}}
+	]}}`)
+
+	reqs, err := ReadRequestFile(path)
+	require.NoError(t, err)
+	require.Len(t, reqs, 2)
+
+	assert.Equal(t, "GET", reqs[0].Method)
+	assert.Equal(t, "example.com", reqs[0].Host)
+	assert.Equal(t, "/search?q=1", reqs[0].URL.RequestURI())
+	assert.Equal(t, "firefox", reqs[0].UserAgent())
+	assert.Empty(t, reqs[0].Header.Get(":path"))
+	assert.Equal(t, 2, reqs[0].ProtoMajor)
+
+	body, err := io.ReadAll(reqs[1].Body)
+	require.NoError(t, err)
+	assert.Equal(t, "pass=%27+or+1%3D1&user=admin", string(body))
+	assert.Equal(t, "application/x-www-form-urlencoded", reqs[1].Header.Get("Content-Type"))
+	assert.Empty(t, reqs[1].Header.Get("Content-Length"))
+	assert.Equal(t, 1, reqs[1].ProtoMinor)
+}
+
+func TestReadRequestFileRaw(t *testing.T) {
+	path := writeRequestFile(t, "requests.txt", "\n"+
+		"POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Length: 5\r\n\r\nhello\r\n\r\n"+
+		"GET /index.php?id=1 HTTP/1.1\nHost: example.com\n\n"+
+		"POST /api HTTP/1.1\nHost: example.com\nContent-Type: application/json\n\n{\"a\": 1}\n")
+
+	reqs, err := ReadRequestFile(path)
+	require.NoError(t, err)
+	require.Len(t, reqs, 3)
+
+	bodies := []string{}
+
+	for _, req := range reqs {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+
+		bodies = append(bodies, string(body))
+	}
+
+	assert.Equal(t, []string{"hello", "", `{"a": 1}`}, bodies)
+	assert.Equal(t, "/index.php?id=1", reqs[1].RequestURI)
+	assert.Equal(t, int64(8), reqs[2].ContentLength)
+
+	_, err = ReadRequestFile(writeRequestFile(t, "empty.txt", "\n\n"))
+	cstest.RequireErrorContains(t, err, "no request found")
+
+	_, err = ReadRequestFile(writeRequestFile(t, "bad.txt", "hello world\n\n"))
+	cstest.RequireErrorContains(t, err, "request 1: malformed HTTP request")
+}
+
+func TestNewParsedRequestFromClientRequest(t *testing.T) {
+	path := writeRequestFile(t, "request.txt", "POST /login?next=/admin HTTP/1.1\nHost: example.com\nUser-Agent: curl/8.0\n\nuser=admin\n")
+
+	reqs, err := ReadRequestFile(path)
+	require.NoError(t, err)
+	require.Len(t, reqs, 1)
+
+	parsed, err := NewParsedRequestFromClientRequest(reqs[0], "1.2.3.4", log.NewEntry(log.StandardLogger()), BodySettings{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.2.3.4", parsed.ClientIP)
+	assert.Equal(t, "POST", parsed.Method)
+	assert.Equal(t, "/login?next=/admin", parsed.URI)
+	assert.Equal(t, "example.com", parsed.Host)
+	assert.Equal(t, "HTTP/1.1", parsed.Proto)
+	assert.Equal(t, []byte("user=admin"), parsed.Body)
+}
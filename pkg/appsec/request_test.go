@@ -3,7 +3,11 @@ package appsec
 import (
 	"bytes"
 	"io"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	log "github.com/sirupsen/logrus"
@@ -273,3 +277,67 @@ func TestNewParsedRequestFromRequestBodyLimit(t *testing.T) {
 		})
 	}
 }
+
+func TestRemediationComponent(t *testing.T) {
+	logger := log.WithField("test", "remediation-component")
+
+	r := makeTestRequest(t, nil)
+	r.RemoteAddr = "10.0.0.2:4242"
+	r.Header.Set("User-Agent", "crowdsec-nginx-bouncer/v1.0.8 (lua)")
+	r.Header.Set(UserAgentHeaderName, "Mozilla/5.0")
+	r.Header.Add("X-Forwarded-For", "10.0.0.1, 10.0.0.3")
+	r.Header.Add("X-Forwarded-For", "10.0.0.4")
+
+	parsed, err := NewParsedRequestFromRequest(r, logger, BodySettings{})
+	require.NoError(t, err)
+
+	require.Equal(t, RemediationComponent{
+		Name:        "crowdsec-nginx-bouncer",
+		Version:     "v1.0.8",
+		UserAgent:   "crowdsec-nginx-bouncer/v1.0.8 (lua)",
+		RemoteAddr:  "10.0.0.2:4242",
+		ForwardedBy: []string{"10.0.0.1", "10.0.0.3", "10.0.0.4"},
+	}, parsed.RemediationComponent)
+	require.Nil(t, parsed.UnixSocketPeerInfo())
+	// the WAF sees the user agent of the client
+	require.Equal(t, "Mozilla/5.0", parsed.HTTPRequest.Header.Get("User-Agent"))
+}
+
+func TestConnContext(t *testing.T) {
+	dir, err := os.MkdirTemp("", "appsec")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "appsec.sock")
+
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	client, err := net.Dial("unix", socket)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	r := makeTestRequest(t, nil).WithContext(ConnContext(t.Context(), conn))
+
+	parsed, err := NewParsedRequestFromRequest(r, log.WithField("test", "conn-context"), BodySettings{})
+	require.NoError(t, err)
+
+	require.Equal(t, "unix:"+socket, parsed.RemediationComponent.Listener)
+
+	peer := parsed.UnixSocketPeerInfo()
+
+	if runtime.GOOS != "linux" {
+		require.Nil(t, peer)
+		return
+	}
+
+	require.NotNil(t, peer)
+	require.Equal(t, os.Getpid(), peer.PID)
+	require.Equal(t, os.Getuid(), peer.UID)
+	require.Equal(t, os.Getgid(), peer.GID)
+}
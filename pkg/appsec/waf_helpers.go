@@ -25,6 +25,8 @@ func GetPreEvalEnv(w *AppsecRuntimeConfig, state *AppsecRequestState, request *P
 		"IsInBand":                request.IsInBand,
 		"IsOutBand":               request.IsOutBand,
 		"req":                     request.HTTPRequest,
+		"bouncer":                 request.RemediationComponent,
+		"UnixSocketPeerInfo":      request.UnixSocketPeerInfo,
 		"RemoveInBandRuleByID":    func(id int) error { return w.RemoveInbandRuleByID(state, id) },
 		"RemoveInBandRuleByName":  func(name string) error { return w.RemoveInbandRuleByName(state, name) },
 		"RemoveInBandRuleByTag":   func(tag string) error { return w.RemoveInbandRuleByTag(state, tag) },
@@ -49,25 +51,29 @@ func GetPreEvalEnv(w *AppsecRuntimeConfig, state *AppsecRequestState, request *P
 
 func GetPostEvalEnv(w *AppsecRuntimeConfig, state *AppsecRequestState, request *ParsedRequest) map[string]interface{} {
 	return map[string]interface{}{
-		"IsInBand":    request.IsInBand,
-		"IsOutBand":   request.IsOutBand,
-		"DumpRequest": request.DumpRequest,
-		"req":         request.HTTPRequest,
+		"IsInBand":           request.IsInBand,
+		"IsOutBand":          request.IsOutBand,
+		"DumpRequest":        request.DumpRequest,
+		"req":                request.HTTPRequest,
+		"bouncer":            request.RemediationComponent,
+		"UnixSocketPeerInfo": request.UnixSocketPeerInfo,
 	}
 }
 
 func GetOnMatchEnv(w *AppsecRuntimeConfig, state *AppsecRequestState, request *ParsedRequest, evt pipeline.Event) map[string]interface{} {
 	return map[string]interface{}{
-		"evt":            evt,
-		"req":            request.HTTPRequest,
-		"IsInBand":       request.IsInBand,
-		"IsOutBand":      request.IsOutBand,
-		"SetRemediation": func(action string) error { return w.SetAction(state, action) },
-		"SetReturnCode":  func(code int) error { return w.SetHTTPCode(state, code) },
-		"CancelEvent":    func() error { return w.CancelEvent(state) },
-		"SendEvent":      func() error { return w.SendEvent(state) },
-		"CancelAlert":    func() error { return w.CancelAlert(state) },
-		"SendAlert":      func() error { return w.SendAlert(state) },
-		"DumpRequest":    request.DumpRequest,
+		"evt":                evt,
+		"req":                request.HTTPRequest,
+		"bouncer":            request.RemediationComponent,
+		"IsInBand":           request.IsInBand,
+		"IsOutBand":          request.IsOutBand,
+		"SetRemediation":     func(action string) error { return w.SetAction(state, action) },
+		"SetReturnCode":      func(code int) error { return w.SetHTTPCode(state, code) },
+		"CancelEvent":        func() error { return w.CancelEvent(state) },
+		"SendEvent":          func() error { return w.SendEvent(state) },
+		"CancelAlert":        func() error { return w.CancelAlert(state) },
+		"SendAlert":          func() error { return w.SendAlert(state) },
+		"DumpRequest":        request.DumpRequest,
+		"UnixSocketPeerInfo": request.UnixSocketPeerInfo,
 	}
 }
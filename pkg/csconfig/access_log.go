@@ -0,0 +1,49 @@
+package csconfig
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	AccessLogFormatText = "text"
+	AccessLogFormatJSON = "json"
+)
+
+// AccessLogCfg configures how the requests to the local API are logged.
+type AccessLogCfg struct {
+	// text (one line per request, like a web server) or json (one object per line, with structured fields)
+	Format string `yaml:"format,omitempty"`
+	// share of the successful requests that are logged, between 0 and 1
+	SampleRate *float64 `yaml:"sample_rate,omitempty"`
+	// share of the failed requests (status >= 400) that are logged, between 0 and 1
+	ErrorSampleRate *float64 `yaml:"error_sample_rate,omitempty"`
+}
+
+func (a *AccessLogCfg) Load() error {
+	switch a.Format {
+	case "":
+		a.Format = AccessLogFormatText
+	case AccessLogFormatText, AccessLogFormatJSON:
+	default:
+		return fmt.Errorf("invalid format %q, must be one of: text, json", a.Format)
+	}
+
+	if a.SampleRate == nil {
+		a.SampleRate = new(1.0)
+	}
+
+	if a.ErrorSampleRate == nil {
+		a.ErrorSampleRate = new(1.0)
+	}
+
+	if *a.SampleRate < 0 || *a.SampleRate > 1 {
+		return errors.New("sample_rate must be between 0 and 1")
+	}
+
+	if *a.ErrorSampleRate < 0 || *a.ErrorSampleRate > 1 {
+		return errors.New("error_sample_rate must be between 0 and 1")
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package csconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestAccessLogLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         AccessLogCfg
+		expected    AccessLogCfg
+		expectedErr string
+	}{
+		{
+			name:     "defaults",
+			cfg:      AccessLogCfg{},
+			expected: AccessLogCfg{Format: AccessLogFormatText, SampleRate: new(1.0), ErrorSampleRate: new(1.0)},
+		},
+		{
+			name:     "json, sampled",
+			cfg:      AccessLogCfg{Format: "json", SampleRate: new(0.1)},
+			expected: AccessLogCfg{Format: AccessLogFormatJSON, SampleRate: new(0.1), ErrorSampleRate: new(1.0)},
+		},
+		{
+			name:        "bad format",
+			cfg:         AccessLogCfg{Format: "xml"},
+			expectedErr: `invalid format "xml", must be one of: text, json`,
+		},
+		{
+			name:        "bad sample rate",
+			cfg:         AccessLogCfg{SampleRate: new(1.5)},
+			expectedErr: "sample_rate must be between 0 and 1",
+		},
+		{
+			name:        "bad error sample rate",
+			cfg:         AccessLogCfg{ErrorSampleRate: new(-0.1)},
+			expectedErr: "error_sample_rate must be between 0 and 1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Load()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, tc.cfg)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package csconfig
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	AlertExportCompressionSnappy = "snappy"
+	AlertExportCompressionZstd   = "zstd"
+	AlertExportCompressionGzip   = "gzip"
+	AlertExportCompressionNone   = "none"
+)
+
+const (
+	defaultAlertExportInterval  = time.Hour
+	defaultAlertExportBatchSize = 10000
+	// S3-compatible endpoint of Google Cloud Storage, with HMAC keys
+	defaultGCSEndpoint = "https://storage.googleapis.com"
+)
+
+// AlertExportCfg periodically writes the new alerts, and their events, as Parquet files to object
+// storage, for the long-term analytics to run in Athena or BigQuery while the database stays small.
+type AlertExportCfg struct {
+	// s3://bucket/prefix or gs://bucket/prefix
+	URL      string `yaml:"url"`
+	Region   string `yaml:"region,omitempty"`
+	Endpoint string `yaml:"endpoint,omitempty"` // S3-compatible storage
+	// default credentials of the SDK if empty; gs:// requires HMAC keys
+	AccessKeyID     string         `yaml:"access_key_id,omitempty"`
+	SecretAccessKey string         `yaml:"secret_access_key,omitempty"`
+	Interval        *time.Duration `yaml:"interval,omitempty"`
+	// alerts per file, at most
+	BatchSize     int    `yaml:"batch_size,omitempty"`
+	IncludeEvents bool   `yaml:"include_events,omitempty"`
+	Compression   string `yaml:"compression,omitempty"` // snappy, zstd, gzip or none
+
+	Bucket string `yaml:"-"`
+	Prefix string `yaml:"-"`
+}
+
+func (c *AlertExportCfg) Load() error {
+	if c.Interval == nil {
+		c.Interval = new(defaultAlertExportInterval)
+	}
+
+	if c.BatchSize == 0 {
+		c.BatchSize = defaultAlertExportBatchSize
+	}
+
+	if c.Compression == "" {
+		c.Compression = AlertExportCompressionSnappy
+	}
+
+	if *c.Interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+
+	if c.BatchSize < 0 {
+		return errors.New("batch_size must be positive")
+	}
+
+	switch c.Compression {
+	case AlertExportCompressionSnappy, AlertExportCompressionZstd, AlertExportCompressionGzip, AlertExportCompressionNone:
+	default:
+		return fmt.Errorf("unsupported compression '%s': must be snappy, zstd, gzip or none", c.Compression)
+	}
+
+	if c.URL == "" {
+		return errors.New("url is required")
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+
+	switch u.Scheme {
+	case "s3":
+	case "gs":
+		if c.Endpoint == "" {
+			c.Endpoint = defaultGCSEndpoint
+		}
+
+		if c.AccessKeyID == "" {
+			return errors.New("gs:// requires access_key_id and secret_access_key (HMAC keys)")
+		}
+	default:
+		return fmt.Errorf("invalid url scheme '%s': must be s3 or gs", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return errors.New("url must include the bucket")
+	}
+
+	if (c.AccessKeyID == "") != (c.SecretAccessKey == "") {
+		return errors.New("access_key_id and secret_access_key must be set together")
+	}
+
+	c.Bucket = u.Host
+	c.Prefix = strings.Trim(u.Path, "/")
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package csconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestAlertExportLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         AlertExportCfg
+		expected    AlertExportCfg
+		expectedErr string
+	}{
+		{
+			name: "defaults",
+			cfg:  AlertExportCfg{URL: "s3://analytics/crowdsec/lapi1/"},
+			expected: AlertExportCfg{
+				URL:         "s3://analytics/crowdsec/lapi1/",
+				Interval:    new(time.Hour),
+				BatchSize:   10000,
+				Compression: "snappy",
+				Bucket:      "analytics",
+				Prefix:      "crowdsec/lapi1",
+			},
+		},
+		{
+			name: "gcs",
+			cfg:  AlertExportCfg{URL: "gs://analytics", AccessKeyID: "GOOG1E", SecretAccessKey: "secret", Compression: "zstd", IncludeEvents: true},
+			expected: AlertExportCfg{
+				URL:             "gs://analytics",
+				Endpoint:        "https://storage.googleapis.com",
+				AccessKeyID:     "GOOG1E",
+				SecretAccessKey: "secret",
+				Interval:        new(time.Hour),
+				BatchSize:       10000,
+				IncludeEvents:   true,
+				Compression:     "zstd",
+				Bucket:          "analytics",
+			},
+		},
+		{
+			name:        "no url",
+			cfg:         AlertExportCfg{},
+			expectedErr: "url is required",
+		},
+		{
+			name:        "bad scheme",
+			cfg:         AlertExportCfg{URL: "https://analytics.example.com/crowdsec"},
+			expectedErr: "invalid url scheme 'https': must be s3 or gs",
+		},
+		{
+			name:        "no bucket",
+			cfg:         AlertExportCfg{URL: "s3:///crowdsec"},
+			expectedErr: "url must include the bucket",
+		},
+		{
+			name:        "gcs without hmac keys",
+			cfg:         AlertExportCfg{URL: "gs://analytics"},
+			expectedErr: "gs:// requires access_key_id and secret_access_key (HMAC keys)",
+		},
+		{
+			name:        "key without secret",
+			cfg:         AlertExportCfg{URL: "s3://analytics", AccessKeyID: "AKIA"},
+			expectedErr: "access_key_id and secret_access_key must be set together",
+		},
+		{
+			name:        "bad interval",
+			cfg:         AlertExportCfg{URL: "s3://analytics", Interval: new(time.Duration(0))},
+			expectedErr: "interval must be positive",
+		},
+		{
+			name:        "bad batch_size",
+			cfg:         AlertExportCfg{URL: "s3://analytics", BatchSize: -1},
+			expectedErr: "batch_size must be positive",
+		},
+		{
+			name:        "bad compression",
+			cfg:         AlertExportCfg{URL: "s3://analytics", Compression: "lz4"},
+			expectedErr: "unsupported compression 'lz4': must be snappy, zstd, gzip or none",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Load()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, tc.cfg)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package csconfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GetAliasesFilePath returns the path to the file containing the cscli aliases.
+// Like feature.yaml, the file is in the same directory as config.yaml, because
+// aliases are expanded before the configuration is loaded.
+func GetAliasesFilePath(configPath string) string {
+	dir := filepath.Dir(configPath)
+	return filepath.Join(dir, "cscli_aliases.yaml")
+}
+
+// LoadAliases reads the cscli aliases (name -> command line). A missing file is not an error.
+func LoadAliases(path string) (map[string]string, error) {
+	aliases := map[string]string{}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return aliases, nil
+		}
+
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(content, &aliases); err != nil {
+		return nil, fmt.Errorf("file %s: %w", path, err)
+	}
+
+	if aliases == nil {
+		aliases = map[string]string{}
+	}
+
+	return aliases, nil
+}
+
+// SaveAliases writes the cscli aliases, replacing the content of the file.
+func SaveAliases(path string, aliases map[string]string) error {
+	content, err := yaml.Marshal(aliases)
+	if err != nil {
+		return fmt.Errorf("unable to serialize aliases: %w", err)
+	}
+
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("unable to write %s: %w", path, err)
+	}
+
+	return nil
+}
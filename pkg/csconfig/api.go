@@ -229,6 +229,8 @@ type LocalApiServerCfg struct {
 	DbConfig                      *DatabaseCfg             `yaml:"-"`
 	OnlineClient                  *OnlineApiClientCfg      `yaml:"online_client"`
 	ProfilesPath                  string                   `yaml:"profiles_path,omitempty"`
+	ProfilesDryRun                bool                     `yaml:"profiles_dry_run,omitempty"` // default dry_run of the profiles
+	ManagedProfilesDir            string                   `yaml:"-"`                          // profiles pushed by the console
 	ConsoleConfigPath             string                   `yaml:"console_path,omitempty"`
 	ConsoleConfig                 *ConsoleConfig           `yaml:"-"`
 	Profiles                      []*ProfileCfg            `yaml:"-"`
@@ -242,6 +244,18 @@ type LocalApiServerCfg struct {
 	CapiWhitelists                *CapiWhitelist           `yaml:"-"`
 	AutoRegister                  *LocalAPIAutoRegisterCfg `yaml:"auto_registration,omitempty"`
 	DisableUsageMetricsExport     bool                     `yaml:"disable_usage_metrics_export"`
+	DecisionDurations             DecisionDurationsCfg     `yaml:"decision_durations,omitempty"`
+	DecisionConflicts             *DecisionConflictsCfg    `yaml:"decision_conflicts,omitempty"`
+	EventAnonymization            EventAnonymizationsCfg   `yaml:"event_anonymization,omitempty"`
+	DecisionBudgets               DecisionBudgetsCfg       `yaml:"decision_budgets,omitempty"`
+	AccessLog                     *AccessLogCfg            `yaml:"access_log,omitempty"`
+	HeartbeatSLA                  *HeartbeatSLACfg         `yaml:"heartbeat_sla,omitempty"`
+	EventBus                      *EventBusCfg             `yaml:"event_bus,omitempty"`
+	AutoBan                       *AutoBanCfg              `yaml:"auto_ban,omitempty"`
+	MaintenanceWindows            MaintenanceWindowsCfg    `yaml:"maintenance_windows,omitempty"`
+	GRPC                          *GRPCServerCfg           `yaml:"grpc,omitempty"`
+	AlertExport                   *AlertExportCfg          `yaml:"alert_export,omitempty"`
+	DBSupervision                 *DBSupervisionCfg        `yaml:"db_supervision,omitempty"`
 }
 
 // NewAccessLogger builds and returns a logger configured for HTTP access
@@ -259,6 +273,10 @@ func (c *LocalApiServerCfg) NewAccessLogger(cfg LogConfig, filename string) *log
 
 	accessLogger := logging.SubLogger(log.StandardLogger(), "lapi", c.LogLevel)
 
+	if c.AccessLog != nil && c.AccessLog.Format == AccessLogFormatJSON {
+		accessLogger.Logger.SetFormatter(&log.JSONFormatter{TimestampFormat: time.RFC3339Nano})
+	}
+
 	if media != "file" {
 		return accessLogger
 	}
@@ -406,6 +424,52 @@ func (c *Config) LoadAPIServer(inCli bool, skipOnlineCreds bool) error {
 		return err
 	}
 
+	if c.API.Server.AccessLog == nil {
+		c.API.Server.AccessLog = &AccessLogCfg{}
+	}
+
+	if err := c.API.Server.AccessLog.Load(); err != nil {
+		return fmt.Errorf("access_log: %w", err)
+	}
+
+	if c.API.Server.HeartbeatSLA == nil {
+		c.API.Server.HeartbeatSLA = &HeartbeatSLACfg{}
+	}
+
+	if err := c.API.Server.HeartbeatSLA.Load(); err != nil {
+		return fmt.Errorf("heartbeat_sla: %w", err)
+	}
+
+	if c.API.Server.EventBus != nil {
+		if err := c.API.Server.EventBus.Load(); err != nil {
+			return fmt.Errorf("event_bus: %w", err)
+		}
+	}
+
+	if c.API.Server.GRPC != nil {
+		if err := c.API.Server.GRPC.Load(); err != nil {
+			return fmt.Errorf("grpc: %w", err)
+		}
+	}
+
+	if c.API.Server.AutoBan != nil {
+		if err := c.API.Server.AutoBan.Load(); err != nil {
+			return fmt.Errorf("auto_ban: %w", err)
+		}
+	}
+
+	if c.API.Server.AlertExport != nil {
+		if err := c.API.Server.AlertExport.Load(); err != nil {
+			return fmt.Errorf("alert_export: %w", err)
+		}
+	}
+
+	if c.API.Server.DBSupervision != nil {
+		if err := c.API.Server.DBSupervision.Load(); err != nil {
+			return fmt.Errorf("db_supervision: %w", err)
+		}
+	}
+
 	if c.API.Server.AutoRegister != nil && c.API.Server.AutoRegister.Enable != nil && *c.API.Server.AutoRegister.Enable && !inCli {
 		log.Infof("auto LAPI registration enabled for ranges %+v", c.API.Server.AutoRegister.AllowedRanges)
 	}
@@ -418,6 +482,30 @@ func (c *Config) LoadAPIServer(inCli bool, skipOnlineCreds bool) error {
 		c.API.Server.UseForwardedForHeaders = true
 	}
 
+	if err := c.API.Server.DecisionDurations.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.API.Server.DecisionConflicts.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.API.Server.EventAnonymization.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.API.Server.DecisionBudgets.Validate(); err != nil {
+		return err
+	}
+
+	if err := c.API.Server.MaintenanceWindows.Validate(); err != nil {
+		return err
+	}
+
+	if c.ConfigPaths != nil {
+		c.API.Server.ManagedProfilesDir = filepath.Join(c.ConfigPaths.ManagedConfigDir, "profiles")
+	}
+
 	if err := c.API.Server.LoadProfiles(); err != nil {
 		return fmt.Errorf("while loading profiles for LAPI: %w", err)
 	}
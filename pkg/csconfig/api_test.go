@@ -3,8 +3,10 @@ package csconfig
 import (
 	"net/netip"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -229,6 +231,15 @@ func TestLoadAPIServer(t *testing.T) {
 					AllowedRanges:       nil,
 					AllowedRangesParsed: nil,
 				},
+				AccessLog: &AccessLogCfg{
+					Format:          AccessLogFormatText,
+					SampleRate:      new(1.0),
+					ErrorSampleRate: new(1.0),
+				},
+				HeartbeatSLA: &HeartbeatSLACfg{
+					MaxDelay:      new(2 * time.Minute),
+					CheckInterval: new(time.Minute),
+				},
 			},
 		},
 		{
@@ -270,6 +281,65 @@ func TestLoadAPIServer(t *testing.T) {
 	}
 }
 
+func TestLoadProfilesDryRun(t *testing.T) {
+	profiles := `name: default
+filters:
+ - Alert.Remediation == true
+decisions:
+ - type: ban
+---
+name: enforced
+dry_run: false
+filters:
+ - Alert.Remediation == true
+decisions:
+ - type: ban
+`
+
+	profilesPath := filepath.Join(t.TempDir(), "profiles.yaml")
+	require.NoError(t, os.WriteFile(profilesPath, []byte(profiles), 0o600))
+
+	lapi := &LocalApiServerCfg{ProfilesPath: profilesPath}
+	require.NoError(t, lapi.LoadProfiles())
+	require.Len(t, lapi.Profiles, 2)
+	assert.False(t, lapi.Profiles[0].IsDryRun())
+	assert.False(t, lapi.Profiles[1].IsDryRun())
+
+	lapi = &LocalApiServerCfg{ProfilesPath: profilesPath, ProfilesDryRun: true}
+	require.NoError(t, lapi.LoadProfiles())
+	require.Len(t, lapi.Profiles, 2)
+	assert.True(t, lapi.Profiles[0].IsDryRun())
+	assert.False(t, lapi.Profiles[1].IsDryRun())
+}
+
+func TestLoadManagedProfiles(t *testing.T) {
+	dir := t.TempDir()
+	managedDir := filepath.Join(dir, "managed", "profiles")
+	require.NoError(t, os.MkdirAll(managedDir, 0o700))
+
+	profilesPath := filepath.Join(dir, "profiles.yaml")
+	require.NoError(t, os.WriteFile(profilesPath, []byte("name: default\non_success: break\n---\nname: long_bans\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(managedDir, "a.yaml"), []byte("name: long_bans\n---\nname: console\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(managedDir, "b.yaml"), []byte("name: other\n"), 0o600))
+
+	lapi := &LocalApiServerCfg{ProfilesPath: profilesPath, ManagedProfilesDir: managedDir}
+	require.NoError(t, lapi.LoadProfiles())
+
+	names := []string{}
+	for _, p := range lapi.Profiles {
+		names = append(names, p.Name)
+	}
+
+	// the local profile of the same name is kept, the managed ones follow the local ones
+	assert.Equal(t, []string{"default", "long_bans", "console", "other"}, names)
+
+	require.NoError(t, os.WriteFile(filepath.Join(managedDir, "c.yaml"), []byte("name: bad\nunknown: 1\n"), 0o600))
+
+	lapi = &LocalApiServerCfg{ProfilesPath: profilesPath, ManagedProfilesDir: managedDir}
+	err := lapi.LoadProfiles()
+	cstest.RequireErrorContains(t, err, "c.yaml: yaml: unmarshal errors:\n  line 2: field unknown not found in type csconfig.ProfileCfg")
+}
+
 func TestParseCapiWhitelists(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -0,0 +1,58 @@
+package csconfig
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	defaultAutoBanCapacity  = 10
+	defaultAutoBanLeakSpeed = 10 * time.Second
+	defaultAutoBanDuration  = 4 * time.Hour
+	defaultAutoBanType      = "ban"
+)
+
+// AutoBanCfg makes the local API ban the clients that keep sending invalid credentials
+// or malformed payloads. Each client has a token bucket: every failed request takes a token,
+// a token comes back every leak_speed, and the client is banned when the bucket is empty.
+type AutoBanCfg struct {
+	// failed requests allowed in a burst
+	Capacity *int `yaml:"capacity,omitempty"`
+	// one failed request is forgiven every leak_speed
+	LeakSpeed *time.Duration `yaml:"leak_speed,omitempty"`
+	Duration  *time.Duration `yaml:"duration,omitempty"`
+	// type of the decision, ban by default
+	Type string `yaml:"type,omitempty"`
+}
+
+func (c *AutoBanCfg) Load() error {
+	if c.Capacity == nil {
+		c.Capacity = new(defaultAutoBanCapacity)
+	}
+
+	if c.LeakSpeed == nil {
+		c.LeakSpeed = new(defaultAutoBanLeakSpeed)
+	}
+
+	if c.Duration == nil {
+		c.Duration = new(defaultAutoBanDuration)
+	}
+
+	if c.Type == "" {
+		c.Type = defaultAutoBanType
+	}
+
+	if *c.Capacity <= 0 {
+		return errors.New("capacity must be positive")
+	}
+
+	if *c.LeakSpeed <= 0 {
+		return errors.New("leak_speed must be positive")
+	}
+
+	if *c.Duration <= 0 {
+		return errors.New("duration must be positive")
+	}
+
+	return nil
+}
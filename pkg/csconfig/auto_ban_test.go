@@ -0,0 +1,58 @@
+package csconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestAutoBanLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         AutoBanCfg
+		expected    AutoBanCfg
+		expectedErr string
+	}{
+		{
+			name:     "defaults",
+			cfg:      AutoBanCfg{},
+			expected: AutoBanCfg{Capacity: new(10), LeakSpeed: new(10 * time.Second), Duration: new(4 * time.Hour), Type: "ban"},
+		},
+		{
+			name:     "custom",
+			cfg:      AutoBanCfg{Capacity: new(3), LeakSpeed: new(time.Minute), Duration: new(24 * time.Hour), Type: "captcha"},
+			expected: AutoBanCfg{Capacity: new(3), LeakSpeed: new(time.Minute), Duration: new(24 * time.Hour), Type: "captcha"},
+		},
+		{
+			name:        "bad capacity",
+			cfg:         AutoBanCfg{Capacity: new(0)},
+			expectedErr: "capacity must be positive",
+		},
+		{
+			name:        "bad leak_speed",
+			cfg:         AutoBanCfg{LeakSpeed: new(-time.Second)},
+			expectedErr: "leak_speed must be positive",
+		},
+		{
+			name:        "bad duration",
+			cfg:         AutoBanCfg{Duration: new(time.Duration(0))},
+			expectedErr: "duration must be positive",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Load()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, tc.cfg)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package csconfig
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+)
+
+// BucketsStateCfg configures the persistence of the live buckets: they are written to a file
+// on shutdown and periodically, and restored at startup so a restart doesn't reset the
+// detections in progress. It's enabled by default.
+type BucketsStateCfg struct {
+	Enabled *bool  `yaml:"enabled,omitempty"`
+	Path    string `yaml:"path,omitempty"` // default: <data_dir>/buckets_state.json
+	// how often the state is written while running, 0 to only write it on shutdown
+	DumpInterval *time.Duration `yaml:"dump_interval,omitempty"`
+}
+
+func (b *BucketsStateCfg) Load(dataDir string) error {
+	if b.Enabled == nil {
+		b.Enabled = new(true)
+	}
+
+	if b.Path == "" {
+		if dataDir == "" {
+			return errors.New("path is required when data_dir is not set")
+		}
+
+		b.Path = filepath.Join(dataDir, "buckets_state.json")
+	}
+
+	if err := ensureAbsolutePath(&b.Path); err != nil {
+		return err
+	}
+
+	if b.DumpInterval == nil {
+		b.DumpInterval = new(time.Minute)
+	}
+
+	if *b.DumpInterval < 0 {
+		return errors.New("dump_interval can't be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package csconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestBucketsStateLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         BucketsStateCfg
+		dataDir     string
+		expected    BucketsStateCfg
+		expectedErr string
+	}{
+		{
+			name:    "defaults",
+			cfg:     BucketsStateCfg{},
+			dataDir: "/var/lib/crowdsec/data",
+			expected: BucketsStateCfg{
+				Enabled:      new(true),
+				Path:         "/var/lib/crowdsec/data/buckets_state.json",
+				DumpInterval: new(time.Minute),
+			},
+		},
+		{
+			name:    "disabled, on shutdown only",
+			cfg:     BucketsStateCfg{Enabled: new(false), Path: "/run/crowdsec/buckets.json", DumpInterval: new(time.Duration(0))},
+			dataDir: "/var/lib/crowdsec/data",
+			expected: BucketsStateCfg{
+				Enabled:      new(false),
+				Path:         "/run/crowdsec/buckets.json",
+				DumpInterval: new(time.Duration(0)),
+			},
+		},
+		{
+			name:        "no path",
+			cfg:         BucketsStateCfg{},
+			expectedErr: "path is required when data_dir is not set",
+		},
+		{
+			name:        "bad interval",
+			cfg:         BucketsStateCfg{DumpInterval: new(-time.Second)},
+			dataDir:     "/var/lib/crowdsec/data",
+			expectedErr: "dump_interval can't be negative",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Load(tc.dataDir)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, tc.cfg)
+		})
+	}
+}
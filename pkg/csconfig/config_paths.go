@@ -6,14 +6,17 @@ import (
 )
 
 type ConfigurationPaths struct {
-	ConfigDir          string `yaml:"config_dir"`
-	DataDir            string `yaml:"data_dir,omitempty"`
-	SimulationFilePath string `yaml:"simulation_path,omitempty"`
-	HubIndexFile       string `yaml:"index_path,omitempty"` // path of the .index.json
-	HubDir             string `yaml:"hub_dir,omitempty"`
-	PluginDir          string `yaml:"plugin_dir,omitempty"`
-	NotificationDir    string `yaml:"notification_dir,omitempty"`
-	PatternDir         string `yaml:"pattern_dir,omitempty"`
+	ConfigDir             string `yaml:"config_dir"`
+	DataDir               string `yaml:"data_dir,omitempty"`
+	SimulationFilePath    string `yaml:"simulation_path,omitempty"`
+	HubIndexFile          string `yaml:"index_path,omitempty"` // path of the .index.json
+	HubDir                string `yaml:"hub_dir,omitempty"`
+	PluginDir             string `yaml:"plugin_dir,omitempty"`
+	NotificationDir       string `yaml:"notification_dir,omitempty"`
+	PatternDir            string `yaml:"pattern_dir,omitempty"`
+	PatternOverrideDir    string `yaml:"pattern_override_dir,omitempty"`    // local patterns, replacing the ones with the same name in pattern_dir
+	ManagedConfigDir      string `yaml:"managed_config_dir,omitempty"`      // acquisition and profiles pushed by the console
+	ScenarioOverridesPath string `yaml:"scenario_overrides_path,omitempty"` // local thresholds of the scenarios
 }
 
 func (c *Config) loadConfigurationPaths() error {
@@ -45,6 +48,18 @@ func (c *Config) loadConfigurationPaths() error {
 		c.ConfigPaths.PatternDir = filepath.Join(c.ConfigPaths.ConfigDir, "patterns")
 	}
 
+	if c.ConfigPaths.PatternOverrideDir == "" {
+		c.ConfigPaths.PatternOverrideDir = filepath.Join(c.ConfigPaths.ConfigDir, "patterns.local")
+	}
+
+	if c.ConfigPaths.ManagedConfigDir == "" {
+		c.ConfigPaths.ManagedConfigDir = filepath.Join(c.ConfigPaths.ConfigDir, "managed")
+	}
+
+	if c.ConfigPaths.ScenarioOverridesPath == "" {
+		c.ConfigPaths.ScenarioOverridesPath = filepath.Join(c.ConfigPaths.ConfigDir, "scenario_overrides.yaml")
+	}
+
 	cleanup := []*string{
 		&c.ConfigPaths.HubDir,
 		&c.ConfigPaths.HubIndexFile,
@@ -54,6 +69,9 @@ func (c *Config) loadConfigurationPaths() error {
 		&c.ConfigPaths.PluginDir,
 		&c.ConfigPaths.NotificationDir,
 		&c.ConfigPaths.PatternDir,
+		&c.ConfigPaths.PatternOverrideDir,
+		&c.ConfigPaths.ManagedConfigDir,
+		&c.ConfigPaths.ScenarioOverridesPath,
 	}
 
 	for _, k := range cleanup {
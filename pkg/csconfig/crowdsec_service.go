@@ -13,19 +13,30 @@ import (
 
 // CrowdsecServiceCfg contains the location of parsers/scenarios/... and acquisition files
 type CrowdsecServiceCfg struct {
-	Enable                    *bool            `yaml:"enable"`
-	AcquisitionFilePath       string           `yaml:"acquisition_path,omitempty"`
-	AcquisitionDirPath        string           `yaml:"acquisition_dir,omitempty"`
-	ConsoleContextPath        string           `yaml:"console_context_path"`
-	ConsoleContextValueLength int              `yaml:"console_context_value_length"`
-	AcquisitionFiles          []string         `yaml:"-"`
-	ParserRoutinesCount       int              `yaml:"parser_routines"`
-	BucketsRoutinesCount      int              `yaml:"buckets_routines"`
-	OutputRoutinesCount       int              `yaml:"output_routines"`
-	SimulationConfig          SimulationConfig `yaml:"-"`
-	BucketStateFile           string           `yaml:"state_input_file,omitempty"` // if we need to unserialize buckets at start
-	BucketStateDumpDir        string           `yaml:"state_output_dir,omitempty"` // if we need to unserialize buckets on shutdown
-	BucketsGCEnabled          bool             `yaml:"-"`                          // we need to garbage collect buckets when in forensic mode
+	Enable                    *bool                `yaml:"enable"`
+	AcquisitionFilePath       string               `yaml:"acquisition_path,omitempty"`
+	AcquisitionDirPath        string               `yaml:"acquisition_dir,omitempty"`
+	ConsoleContextPath        string               `yaml:"console_context_path"`
+	ConsoleContextValueLength int                  `yaml:"console_context_value_length"`
+	AcquisitionFiles          []string             `yaml:"-"`
+	ManagedAcquisition        bool                 `yaml:"managed_acquisition"` // read the acquisition pushed by the console to the machine
+	ManagedAcquisitionDir     string               `yaml:"-"`
+	ParserRoutinesCount       int                  `yaml:"parser_routines"`
+	EnricherRoutinesCount     int                  `yaml:"enricher_routines"` // per event, for the slow enrichers
+	BucketsRoutinesCount      int                  `yaml:"buckets_routines"`
+	OutputRoutinesCount       int                  `yaml:"output_routines"`
+	SimulationConfig          SimulationConfig     `yaml:"-"`
+	ScenarioOverrides         ScenarioOverrides    `yaml:"-"`
+	BucketStateFile           string               `yaml:"state_input_file,omitempty"` // deprecated, replaced by buckets_state.path
+	BucketStateDumpDir        string               `yaml:"state_output_dir,omitempty"` // deprecated, replaced by buckets_state.path
+	BucketsGCEnabled          bool                 `yaml:"-"`                          // we need to garbage collect buckets when in forensic mode
+	HTTPHelper                *HTTPHelperCfg       `yaml:"http_helper,omitempty"`
+	GeoIPUpdate               *GeoIPUpdateCfg      `yaml:"geoip_update,omitempty"`
+	UnparsedLines             *UnparsedLinesCfg    `yaml:"unparsed_lines,omitempty"`
+	Trace                     *TraceCfg            `yaml:"trace,omitempty"`
+	ParseErrorBudget          *ParseErrorBudgetCfg `yaml:"parse_error_budget,omitempty"`
+	BucketsState              *BucketsStateCfg     `yaml:"buckets_state,omitempty"`
+	MLModels                  []*MLModelCfg        `yaml:"ml_models,omitempty"`
 
 	SimulationFilePath string              `yaml:"-"`
 	ContextToSend      map[string][]string `yaml:"-"`
@@ -75,7 +86,16 @@ func (c *CrowdsecServiceCfg) CollectAcquisitionFiles() ([]string, error) {
 		ret = append(ret, dirFiles...)
 	}
 
-	if c.AcquisitionDirPath == "" && c.AcquisitionFilePath == "" {
+	if c.ManagedAcquisitionDir != "" {
+		managedFiles, err := c.collectManagedAcquisitionFiles(ret)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, managedFiles...)
+	}
+
+	if c.AcquisitionDirPath == "" && c.AcquisitionFilePath == "" && c.ManagedAcquisitionDir == "" {
 		return nil, ErrNoAcquisitionDefined
 	}
 
@@ -84,6 +104,33 @@ func (c *CrowdsecServiceCfg) CollectAcquisitionFiles() ([]string, error) {
 	return ret, nil
 }
 
+// collectManagedAcquisitionFiles returns the acquisition files pushed by the console, except the
+// ones with the same name as a local file, which takes precedence.
+func (c *CrowdsecServiceCfg) collectManagedAcquisitionFiles(localFiles []string) ([]string, error) {
+	managedFiles, err := filepath.Glob(filepath.Join(c.ManagedAcquisitionDir, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("while globbing managed acquisition: %w", err)
+	}
+
+	local := make(map[string]bool, len(localFiles))
+	for _, f := range localFiles {
+		local[filepath.Base(f)] = true
+	}
+
+	ret := []string{}
+
+	for _, f := range managedFiles {
+		if local[filepath.Base(f)] {
+			log.Warningf("managed acquisition %s is overridden by a local file of the same name", f)
+			continue
+		}
+
+		ret = append(ret, f)
+	}
+
+	return ret, nil
+}
+
 func (c *Config) LoadCrowdsec() error {
 	var err error
 
@@ -106,6 +153,10 @@ func (c *Config) LoadCrowdsec() error {
 		return nil
 	}
 
+	if c.Crowdsec.ManagedAcquisition && c.ConfigPaths != nil {
+		c.Crowdsec.ManagedAcquisitionDir = filepath.Join(c.ConfigPaths.ManagedConfigDir, "acquisition")
+	}
+
 	cleanup := []*string{
 		&c.Crowdsec.AcquisitionDirPath,
 		&c.Crowdsec.AcquisitionFilePath,
@@ -134,10 +185,20 @@ func (c *Config) LoadCrowdsec() error {
 		return fmt.Errorf("load error (simulation): %w", err)
 	}
 
+	if c.ConfigPaths != nil {
+		if c.Crowdsec.ScenarioOverrides, err = LoadScenarioOverrides(c.ConfigPaths.ScenarioOverridesPath); err != nil {
+			return fmt.Errorf("load error (scenario overrides): %w", err)
+		}
+	}
+
 	if c.Crowdsec.ParserRoutinesCount <= 0 {
 		c.Crowdsec.ParserRoutinesCount = 1
 	}
 
+	if c.Crowdsec.EnricherRoutinesCount <= 0 {
+		c.Crowdsec.EnricherRoutinesCount = 1
+	}
+
 	if c.Crowdsec.BucketsRoutinesCount <= 0 {
 		c.Crowdsec.BucketsRoutinesCount = 1
 	}
@@ -146,6 +207,65 @@ func (c *Config) LoadCrowdsec() error {
 		c.Crowdsec.OutputRoutinesCount = 1
 	}
 
+	if c.Crowdsec.HTTPHelper != nil {
+		if err = c.Crowdsec.HTTPHelper.Load(); err != nil {
+			return fmt.Errorf("http_helper: %w", err)
+		}
+	}
+
+	if c.Crowdsec.GeoIPUpdate != nil {
+		if err = c.Crowdsec.GeoIPUpdate.Load(); err != nil {
+			return fmt.Errorf("geoip_update: %w", err)
+		}
+	}
+
+	if c.Crowdsec.UnparsedLines != nil {
+		if err = c.Crowdsec.UnparsedLines.Load(); err != nil {
+			return fmt.Errorf("unparsed_lines: %w", err)
+		}
+	}
+
+	if c.Crowdsec.Trace != nil {
+		if err = c.Crowdsec.Trace.Load(); err != nil {
+			return fmt.Errorf("trace: %w", err)
+		}
+	}
+
+	if c.Crowdsec.ParseErrorBudget != nil {
+		if err = c.Crowdsec.ParseErrorBudget.Load(); err != nil {
+			return fmt.Errorf("parse_error_budget: %w", err)
+		}
+	}
+
+	if c.Crowdsec.BucketsState == nil {
+		c.Crowdsec.BucketsState = &BucketsStateCfg{}
+	}
+
+	if c.Crowdsec.BucketStateFile != "" {
+		log.Warning("state_input_file is deprecated, use buckets_state.path instead")
+
+		if c.Crowdsec.BucketsState.Path == "" {
+			c.Crowdsec.BucketsState.Path = c.Crowdsec.BucketStateFile
+		}
+	}
+
+	if c.Crowdsec.BucketStateDumpDir != "" {
+		log.Warning("state_output_dir is deprecated and ignored, use buckets_state.path instead")
+	}
+
+	dataDir := ""
+	if c.ConfigPaths != nil {
+		dataDir = c.ConfigPaths.DataDir
+	}
+
+	if err = c.Crowdsec.BucketsState.Load(dataDir); err != nil {
+		return fmt.Errorf("buckets_state: %w", err)
+	}
+
+	if err = loadMLModels(c.Crowdsec.MLModels); err != nil {
+		return fmt.Errorf("ml_models: %w", err)
+	}
+
 	if err = c.LoadAPIClient(); err != nil {
 		return fmt.Errorf("loading api client: %w", err)
 	}
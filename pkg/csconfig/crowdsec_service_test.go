@@ -1,8 +1,10 @@
 package csconfig
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -27,6 +29,15 @@ func TestLoadCrowdsec(t *testing.T) {
 	notExistFullPath, err := filepath.Abs(notExist)
 	require.NoError(t, err)
 
+	bucketsStateFullPath, err := filepath.Abs("./data/buckets_state.json")
+	require.NoError(t, err)
+
+	bucketsState := &BucketsStateCfg{
+		Enabled:      new(true),
+		Path:         bucketsStateFullPath,
+		DumpInterval: new(time.Minute),
+	}
+
 	tests := []struct {
 		name        string
 		input       *Config
@@ -60,7 +71,9 @@ func TestLoadCrowdsec(t *testing.T) {
 				AcquisitionFilePath:       acquisFullPath,
 				BucketsRoutinesCount:      1,
 				ParserRoutinesCount:       1,
+				EnricherRoutinesCount:     1,
 				OutputRoutinesCount:       1,
+				BucketsState:              bucketsState,
 				ConsoleContextValueLength: 2500,
 				AcquisitionFiles:          []string{acquisFullPath},
 				SimulationFilePath:        "./testdata/simulation.yaml",
@@ -97,7 +110,9 @@ func TestLoadCrowdsec(t *testing.T) {
 				ConsoleContextPath:        contextFileFullPath,
 				BucketsRoutinesCount:      1,
 				ParserRoutinesCount:       1,
+				EnricherRoutinesCount:     1,
 				OutputRoutinesCount:       1,
+				BucketsState:              bucketsState,
 				ConsoleContextValueLength: 0,
 				AcquisitionFiles:          []string{acquisFullPath, acquisInDirFullPath},
 				// context is loaded in pkg/alertcontext
@@ -132,7 +147,9 @@ func TestLoadCrowdsec(t *testing.T) {
 				ConsoleContextPath:        contextFileFullPath,
 				BucketsRoutinesCount:      1,
 				ParserRoutinesCount:       1,
+				EnricherRoutinesCount:     1,
 				OutputRoutinesCount:       1,
+				BucketsState:              bucketsState,
 				ConsoleContextValueLength: 10,
 				AcquisitionFiles:          []string{},
 				SimulationFilePath:        "",
@@ -161,12 +178,14 @@ func TestLoadCrowdsec(t *testing.T) {
 				},
 			},
 			expected: &CrowdsecServiceCfg{
-				Enable:               new(true),
-				AcquisitionFilePath:  notExistFullPath,
-				AcquisitionFiles:     []string{},
-				ParserRoutinesCount:  1,
-				OutputRoutinesCount:  1,
-				BucketsRoutinesCount: 1,
+				Enable:                new(true),
+				AcquisitionFilePath:   notExistFullPath,
+				AcquisitionFiles:      []string{},
+				ParserRoutinesCount:   1,
+				EnricherRoutinesCount: 1,
+				OutputRoutinesCount:   1,
+				BucketsState:          bucketsState,
+				BucketsRoutinesCount:  1,
 			},
 		},
 		{
@@ -195,3 +214,35 @@ func TestLoadCrowdsec(t *testing.T) {
 		})
 	}
 }
+
+func TestCollectManagedAcquisitionFiles(t *testing.T) {
+	dir := t.TempDir()
+	acquisDir := filepath.Join(dir, "acquis.d")
+	managedDir := filepath.Join(dir, "managed", "acquisition")
+
+	for _, path := range []string{
+		filepath.Join(acquisDir, "nginx.yaml"),
+		filepath.Join(managedDir, "nginx.yaml"),
+		filepath.Join(managedDir, "ssh.yaml"),
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte("source: file\n"), 0o600))
+	}
+
+	cfg := &CrowdsecServiceCfg{
+		AcquisitionDirPath:    acquisDir,
+		ManagedAcquisitionDir: managedDir,
+	}
+
+	files, err := cfg.CollectAcquisitionFiles()
+	require.NoError(t, err)
+	// the local file takes precedence
+	require.Equal(t, []string{filepath.Join(acquisDir, "nginx.yaml"), filepath.Join(managedDir, "ssh.yaml")}, files)
+
+	// only the managed acquisition
+	cfg = &CrowdsecServiceCfg{ManagedAcquisitionDir: managedDir}
+
+	files, err = cfg.CollectAcquisitionFiles()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+}
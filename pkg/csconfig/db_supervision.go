@@ -0,0 +1,50 @@
+package csconfig
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	defaultDBCheckInterval    = 10 * time.Second
+	defaultDBMaxBackoff       = time.Minute
+	defaultDBSnapshotInterval = 30 * time.Second
+)
+
+// DBSupervisionCfg sets how the local API checks its database connection. While the database is
+// unavailable, the decision streams are served from a snapshot of the active decisions, in read-only mode.
+type DBSupervisionCfg struct {
+	CheckInterval *time.Duration `yaml:"check_interval,omitempty"`
+	// longest delay between two reconnection attempts, the delay doubles after each failure
+	MaxBackoff *time.Duration `yaml:"max_backoff,omitempty"`
+	// how often the snapshot of the decisions and the bouncers is refreshed, while the database is up
+	SnapshotInterval *time.Duration `yaml:"snapshot_interval,omitempty"`
+}
+
+func (c *DBSupervisionCfg) Load() error {
+	if c.CheckInterval == nil {
+		c.CheckInterval = new(defaultDBCheckInterval)
+	}
+
+	if c.MaxBackoff == nil {
+		c.MaxBackoff = new(defaultDBMaxBackoff)
+	}
+
+	if c.SnapshotInterval == nil {
+		c.SnapshotInterval = new(defaultDBSnapshotInterval)
+	}
+
+	if *c.CheckInterval <= 0 {
+		return errors.New("check_interval must be positive")
+	}
+
+	if *c.MaxBackoff < time.Second {
+		return errors.New("max_backoff must be at least 1s")
+	}
+
+	if *c.SnapshotInterval <= 0 {
+		return errors.New("snapshot_interval must be positive")
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package csconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestDBSupervisionLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         DBSupervisionCfg
+		expected    DBSupervisionCfg
+		expectedErr string
+	}{
+		{
+			name:     "defaults",
+			cfg:      DBSupervisionCfg{},
+			expected: DBSupervisionCfg{CheckInterval: new(10 * time.Second), MaxBackoff: new(time.Minute), SnapshotInterval: new(30 * time.Second)},
+		},
+		{
+			name:     "custom",
+			cfg:      DBSupervisionCfg{CheckInterval: new(time.Second), MaxBackoff: new(5 * time.Minute)},
+			expected: DBSupervisionCfg{CheckInterval: new(time.Second), MaxBackoff: new(5 * time.Minute), SnapshotInterval: new(30 * time.Second)},
+		},
+		{
+			name:        "bad check_interval",
+			cfg:         DBSupervisionCfg{CheckInterval: new(time.Duration(0))},
+			expectedErr: "check_interval must be positive",
+		},
+		{
+			name:        "bad max_backoff",
+			cfg:         DBSupervisionCfg{MaxBackoff: new(500 * time.Millisecond)},
+			expectedErr: "max_backoff must be at least 1s",
+		},
+		{
+			name:        "bad snapshot_interval",
+			cfg:         DBSupervisionCfg{SnapshotInterval: new(-time.Second)},
+			expectedErr: "snapshot_interval must be positive",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Load()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, tc.cfg)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package csconfig
+
+import (
+	"errors"
+	"fmt"
+	"path"
+)
+
+const (
+	DecisionBudgetDrop     = "drop"
+	DecisionBudgetSimulate = "simulate"
+)
+
+// DecisionBudgetCfg limits the number of active decisions created by a scenario, as a
+// safety valve against a buggy scenario. Beyond the limit, the new decisions of the scenario
+// are dropped, or stored as simulated so they can be reviewed without being enforced.
+// The alerts are always stored.
+type DecisionBudgetCfg struct {
+	Scenario  string `yaml:"scenario"`         // scenario name, or a pattern like crowdsecurity/* (each scenario has its own budget)
+	MaxActive int    `yaml:"max_active"`       // maximum number of active, non-simulated decisions
+	Action    string `yaml:"action,omitempty"` // drop (default) or simulate
+}
+
+type DecisionBudgetsCfg []DecisionBudgetCfg
+
+func (c DecisionBudgetsCfg) Validate() error {
+	seen := make(map[string]struct{}, len(c))
+
+	for i := range c {
+		b := &c[i]
+
+		if b.Scenario == "" {
+			return errors.New("decision_budgets: missing scenario")
+		}
+
+		if _, err := path.Match(b.Scenario, ""); err != nil {
+			return fmt.Errorf("decision_budgets: invalid scenario pattern %s: %w", b.Scenario, err)
+		}
+
+		if _, ok := seen[b.Scenario]; ok {
+			return fmt.Errorf("decision_budgets: duplicate scenario %s", b.Scenario)
+		}
+
+		seen[b.Scenario] = struct{}{}
+
+		if b.MaxActive <= 0 {
+			return fmt.Errorf("decision_budgets: max_active must be positive for scenario %s", b.Scenario)
+		}
+
+		switch b.Action {
+		case "":
+			b.Action = DecisionBudgetDrop
+		case DecisionBudgetDrop, DecisionBudgetSimulate:
+		default:
+			return fmt.Errorf("decision_budgets: invalid action %q for scenario %s, must be %s or %s", b.Action, b.Scenario, DecisionBudgetDrop, DecisionBudgetSimulate)
+		}
+	}
+
+	return nil
+}
+
+// Lookup returns the budget of a scenario: the first one with a matching name or pattern.
+func (c DecisionBudgetsCfg) Lookup(scenario string) (DecisionBudgetCfg, bool) {
+	for _, b := range c {
+		if ok, _ := path.Match(b.Scenario, scenario); ok {
+			return b, true
+		}
+	}
+
+	return DecisionBudgetCfg{}, false
+}
@@ -0,0 +1,86 @@
+package csconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestDecisionBudgetsValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			input: `
+- scenario: crowdsecurity/ssh-bf
+  max_active: 100
+  action: simulate
+- scenario: crowdsecurity/*
+  max_active: 1000`,
+		},
+		{
+			name:        "missing scenario",
+			input:       `[{max_active: 10}]`,
+			expectedErr: "decision_budgets: missing scenario",
+		},
+		{
+			name:        "invalid pattern",
+			input:       `[{scenario: "crowdsecurity/[", max_active: 10}]`,
+			expectedErr: "decision_budgets: invalid scenario pattern crowdsecurity/[: syntax error in pattern",
+		},
+		{
+			name:        "duplicate scenario",
+			input:       `[{scenario: a/b, max_active: 10}, {scenario: a/b, max_active: 20}]`,
+			expectedErr: "decision_budgets: duplicate scenario a/b",
+		},
+		{
+			name:        "no limit",
+			input:       `[{scenario: a/b}]`,
+			expectedErr: "decision_budgets: max_active must be positive for scenario a/b",
+		},
+		{
+			name:        "invalid action",
+			input:       `[{scenario: a/b, max_active: 10, action: queue}]`,
+			expectedErr: `decision_budgets: invalid action "queue" for scenario a/b, must be drop or simulate`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg DecisionBudgetsCfg
+
+			require.NoError(t, yaml.Unmarshal([]byte(tc.input), &cfg))
+
+			err := cfg.Validate()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestDecisionBudgetsLookup(t *testing.T) {
+	cfg := DecisionBudgetsCfg{
+		{Scenario: "crowdsecurity/ssh-bf", MaxActive: 100, Action: DecisionBudgetSimulate},
+		{Scenario: "crowdsecurity/*", MaxActive: 1000},
+	}
+
+	require.NoError(t, cfg.Validate())
+
+	b, ok := cfg.Lookup("crowdsecurity/ssh-bf")
+	require.True(t, ok)
+	assert.Equal(t, 100, b.MaxActive)
+
+	b, ok = cfg.Lookup("crowdsecurity/http-probing")
+	require.True(t, ok)
+	assert.Equal(t, 1000, b.MaxActive)
+	assert.Equal(t, DecisionBudgetDrop, b.Action)
+
+	_, ok = cfg.Lookup("custom/scenario")
+	assert.False(t, ok)
+}
@@ -0,0 +1,83 @@
+package csconfig
+
+import (
+	"fmt"
+	"slices"
+)
+
+const (
+	// the longest decision of each value and type is sent to the bouncers
+	DecisionConflictLongest = "longest"
+	// only the strictest remediation of each value is sent, then the longest
+	DecisionConflictStrictest = "strictest"
+	// the decision of the most trusted origin is sent, then the longest: by default, the manual decisions win
+	DecisionConflictTrust = "trust"
+)
+
+// DecisionConflictsCfg chooses which decision is sent to the bouncers when a value has
+// several active decisions, from the same or different origins. The policy is applied to
+// the stream (startup and delta, REST and gRPC) and to the /v1/decisions queries.
+type DecisionConflictsCfg struct {
+	Policy string `yaml:"policy"`
+	// remediations of the strictest policy, the strictest first. The other types come last.
+	Remediations []string `yaml:"remediations,omitempty"`
+	// trust levels of the trust policy, the most trusted origin first. The other origins come last.
+	Origins []string `yaml:"origins,omitempty"`
+}
+
+var (
+	defaultConflictRemediations = []string{"ban", "captcha"}
+	defaultConflictOrigins      = []string{"cscli", "cscli-import", "console", "lapi", "crowdsec", "lists", "CAPI"}
+)
+
+func validateConflictRanks(name string, values []string) error {
+	for i, v := range values {
+		if v == "" {
+			return fmt.Errorf("decision_conflicts: empty value in %s", name)
+		}
+
+		if slices.Contains(values[:i], v) {
+			return fmt.Errorf("decision_conflicts: duplicate value %s in %s", v, name)
+		}
+	}
+
+	return nil
+}
+
+func (c *DecisionConflictsCfg) Validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if c.Policy == "" {
+		c.Policy = DecisionConflictLongest
+	}
+
+	switch c.Policy {
+	case DecisionConflictLongest:
+	case DecisionConflictStrictest:
+		if len(c.Remediations) == 0 {
+			c.Remediations = defaultConflictRemediations
+		}
+	case DecisionConflictTrust:
+		if len(c.Origins) == 0 {
+			c.Origins = defaultConflictOrigins
+		}
+	default:
+		return fmt.Errorf("decision_conflicts: unknown policy '%s': must be longest, strictest or trust", c.Policy)
+	}
+
+	if c.Policy != DecisionConflictStrictest && len(c.Remediations) > 0 {
+		return fmt.Errorf("decision_conflicts: remediations can't be used with the %s policy", c.Policy)
+	}
+
+	if c.Policy != DecisionConflictTrust && len(c.Origins) > 0 {
+		return fmt.Errorf("decision_conflicts: origins can't be used with the %s policy", c.Policy)
+	}
+
+	if err := validateConflictRanks("remediations", c.Remediations); err != nil {
+		return err
+	}
+
+	return validateConflictRanks("origins", c.Origins)
+}
@@ -0,0 +1,78 @@
+package csconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestDecisionConflictsValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    DecisionConflictsCfg
+		expectedErr string
+	}{
+		{
+			name:     "default",
+			input:    `{}`,
+			expected: DecisionConflictsCfg{Policy: "longest"},
+		},
+		{
+			name:     "strictest",
+			input:    `{policy: strictest}`,
+			expected: DecisionConflictsCfg{Policy: "strictest", Remediations: []string{"ban", "captcha"}},
+		},
+		{
+			name:     "trust",
+			input:    `{policy: trust, origins: [cscli, lists]}`,
+			expected: DecisionConflictsCfg{Policy: "trust", Origins: []string{"cscli", "lists"}},
+		},
+		{
+			name:        "unknown policy",
+			input:       `{policy: newest}`,
+			expectedErr: "decision_conflicts: unknown policy 'newest': must be longest, strictest or trust",
+		},
+		{
+			name:        "origins without trust",
+			input:       `{policy: strictest, origins: [cscli]}`,
+			expectedErr: "decision_conflicts: origins can't be used with the strictest policy",
+		},
+		{
+			name:        "remediations without strictest",
+			input:       `{remediations: [ban]}`,
+			expectedErr: "decision_conflicts: remediations can't be used with the longest policy",
+		},
+		{
+			name:        "duplicate origin",
+			input:       `{policy: trust, origins: [cscli, CAPI, cscli]}`,
+			expectedErr: "decision_conflicts: duplicate value cscli in origins",
+		},
+		{
+			name:        "empty remediation",
+			input:       `{policy: strictest, remediations: [ban, ""]}`,
+			expectedErr: "decision_conflicts: empty value in remediations",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg DecisionConflictsCfg
+
+			require.NoError(t, yaml.Unmarshal([]byte(tc.input), &cfg))
+
+			err := cfg.Validate()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, cfg)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package csconfig
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/crowdsecurity/go-cs-lib/cstime"
+)
+
+// DecisionDurationCfg bounds the duration of the decisions coming from an origin
+// (crowdsec, cscli, CAPI, lists...). A zero value means no bound.
+type DecisionDurationCfg struct {
+	Origin      string                  `yaml:"origin"`
+	MinDuration cstime.DurationWithDays `yaml:"min_duration,omitempty"`
+	MaxDuration cstime.DurationWithDays `yaml:"max_duration,omitempty"`
+}
+
+type DecisionDurationsCfg []DecisionDurationCfg
+
+func (c DecisionDurationsCfg) Validate() error {
+	seen := make(map[string]struct{}, len(c))
+
+	for _, d := range c {
+		if d.Origin == "" {
+			return errors.New("decision_durations: missing origin")
+		}
+
+		if _, ok := seen[d.Origin]; ok {
+			return fmt.Errorf("decision_durations: duplicate origin %s", d.Origin)
+		}
+
+		seen[d.Origin] = struct{}{}
+
+		minDuration := time.Duration(d.MinDuration)
+		maxDuration := time.Duration(d.MaxDuration)
+
+		if minDuration < 0 || maxDuration < 0 {
+			return fmt.Errorf("decision_durations: negative duration for origin %s", d.Origin)
+		}
+
+		if minDuration == 0 && maxDuration == 0 {
+			return fmt.Errorf("decision_durations: origin %s needs min_duration or max_duration", d.Origin)
+		}
+
+		if maxDuration != 0 && minDuration > maxDuration {
+			return fmt.Errorf("decision_durations: min_duration is greater than max_duration for origin %s", d.Origin)
+		}
+	}
+
+	return nil
+}
+
+func (c DecisionDurationsCfg) lookup(origin string) (DecisionDurationCfg, bool) {
+	for _, d := range c {
+		if d.Origin == origin {
+			return d, true
+		}
+	}
+
+	return DecisionDurationCfg{}, false
+}
+
+// Apply returns the duration of a new decision from origin, once extended to
+// min_duration or capped to max_duration.
+func (c DecisionDurationsCfg) Apply(origin string, duration time.Duration) time.Duration {
+	d, ok := c.lookup(origin)
+	if !ok {
+		return duration
+	}
+
+	if minDuration := time.Duration(d.MinDuration); minDuration != 0 && duration < minDuration {
+		duration = minDuration
+	}
+
+	if maxDuration := time.Duration(d.MaxDuration); maxDuration != 0 && duration > maxDuration {
+		duration = maxDuration
+	}
+
+	return duration
+}
+
+// CapUntil returns the expiration of an existing decision, capped to max_duration
+// after its creation. This enforces the setting for the decisions that were
+// inserted before it was changed. The minimum is only applied at insert time.
+func (c DecisionDurationsCfg) CapUntil(origin string, createdAt time.Time, until time.Time) time.Time {
+	d, ok := c.lookup(origin)
+	if !ok || d.MaxDuration == 0 {
+		return until
+	}
+
+	if limit := createdAt.Add(time.Duration(d.MaxDuration)); limit.Before(until) {
+		return limit
+	}
+
+	return until
+}
@@ -0,0 +1,91 @@
+package csconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+	"github.com/crowdsecurity/go-cs-lib/cstime"
+)
+
+func TestDecisionDurationsValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			input: `
+- origin: CAPI
+  max_duration: 24h
+- origin: lists
+  min_duration: 7d
+- origin: cscli
+  min_duration: 1h
+  max_duration: 2d`,
+		},
+		{
+			name:        "missing origin",
+			input:       `[{max_duration: 1h}]`,
+			expectedErr: "decision_durations: missing origin",
+		},
+		{
+			name:        "duplicate origin",
+			input:       `[{origin: CAPI, max_duration: 1h}, {origin: CAPI, max_duration: 2h}]`,
+			expectedErr: "decision_durations: duplicate origin CAPI",
+		},
+		{
+			name:        "no bound",
+			input:       `[{origin: CAPI}]`,
+			expectedErr: "decision_durations: origin CAPI needs min_duration or max_duration",
+		},
+		{
+			name:        "negative",
+			input:       `[{origin: CAPI, max_duration: -1h}]`,
+			expectedErr: "decision_durations: negative duration for origin CAPI",
+		},
+		{
+			name:        "min greater than max",
+			input:       `[{origin: CAPI, min_duration: 2d, max_duration: 1d}]`,
+			expectedErr: "decision_durations: min_duration is greater than max_duration for origin CAPI",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg DecisionDurationsCfg
+
+			require.NoError(t, yaml.Unmarshal([]byte(tc.input), &cfg))
+
+			err := cfg.Validate()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestDecisionDurationsApply(t *testing.T) {
+	cfg := DecisionDurationsCfg{
+		{Origin: "CAPI", MaxDuration: cstime.DurationWithDays(24 * time.Hour)},
+		{Origin: "lists", MinDuration: cstime.DurationWithDays(7 * 24 * time.Hour)},
+	}
+
+	assert.Equal(t, 24*time.Hour, cfg.Apply("CAPI", 72*time.Hour))
+	assert.Equal(t, 4*time.Hour, cfg.Apply("CAPI", 4*time.Hour))
+	assert.Equal(t, 7*24*time.Hour, cfg.Apply("lists", time.Hour))
+	assert.Equal(t, 30*24*time.Hour, cfg.Apply("lists", 30*24*time.Hour))
+	assert.Equal(t, 72*time.Hour, cfg.Apply("crowdsec", 72*time.Hour))
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := created.Add(72 * time.Hour)
+
+	assert.Equal(t, created.Add(24*time.Hour), cfg.CapUntil("CAPI", created, until))
+	assert.Equal(t, created.Add(time.Hour), cfg.CapUntil("CAPI", created, created.Add(time.Hour)))
+	assert.Equal(t, until, cfg.CapUntil("crowdsec", created, until))
+	// the minimum does not apply to existing decisions
+	assert.Equal(t, created.Add(time.Hour), cfg.CapUntil("lists", created, created.Add(time.Hour)))
+}
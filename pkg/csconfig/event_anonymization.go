@@ -0,0 +1,116 @@
+package csconfig
+
+import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+const (
+	AnonymizeHash     = "hash"
+	AnonymizeTruncate = "truncate"
+)
+
+// the value stored when an IP can't be truncated
+const AnonymizedValue = "<anonymized>"
+
+// EventAnonymizationCfg replaces an IP in the meta of the events stored in the database
+// by its hash or its network, for data minimization. The alerts and their decisions keep
+// the full source IP.
+type EventAnonymizationCfg struct {
+	Key        string `yaml:"key"`                   // meta key, e.g. source_ip
+	Mode       string `yaml:"mode"`                  // hash or truncate
+	Salt       string `yaml:"salt,omitempty"`        // hash: secret key of the HMAC
+	IPv4Prefix int    `yaml:"ipv4_prefix,omitempty"` // truncate: number of bits kept (default 24)
+	IPv6Prefix int    `yaml:"ipv6_prefix,omitempty"` // truncate: number of bits kept (default 48)
+}
+
+type EventAnonymizationsCfg []EventAnonymizationCfg
+
+func (c EventAnonymizationsCfg) Validate() error {
+	seen := make(map[string]struct{}, len(c))
+
+	for _, a := range c {
+		if a.Key == "" {
+			return errors.New("event_anonymization: missing key")
+		}
+
+		if _, ok := seen[a.Key]; ok {
+			return fmt.Errorf("event_anonymization: duplicate key %s", a.Key)
+		}
+
+		seen[a.Key] = struct{}{}
+
+		switch a.Mode {
+		case AnonymizeHash:
+			// without a secret, the hash of an IPv4 is easy to reverse
+			if a.Salt == "" {
+				return fmt.Errorf("event_anonymization: key %s needs a salt to be hashed", a.Key)
+			}
+		case AnonymizeTruncate:
+			if a.IPv4Prefix < 0 || a.IPv4Prefix > 32 {
+				return fmt.Errorf("event_anonymization: invalid ipv4_prefix %d for key %s", a.IPv4Prefix, a.Key)
+			}
+
+			if a.IPv6Prefix < 0 || a.IPv6Prefix > 128 {
+				return fmt.Errorf("event_anonymization: invalid ipv6_prefix %d for key %s", a.IPv6Prefix, a.Key)
+			}
+		default:
+			return fmt.Errorf("event_anonymization: invalid mode %q for key %s, must be %s or %s", a.Mode, a.Key, AnonymizeHash, AnonymizeTruncate)
+		}
+	}
+
+	return nil
+}
+
+func (c EventAnonymizationsCfg) lookup(key string) (EventAnonymizationCfg, bool) {
+	for _, a := range c {
+		if a.Key == key {
+			return a, true
+		}
+	}
+
+	return EventAnonymizationCfg{}, false
+}
+
+// Apply returns the value to store for a meta key: the value itself if the key is not
+// to be anonymized, the HMAC of the value (hash), or the network of the IP (truncate).
+// A value that is not an IP can't be truncated and is replaced by AnonymizedValue.
+func (c EventAnonymizationsCfg) Apply(key string, value string) string {
+	a, ok := c.lookup(key)
+	if !ok {
+		return value
+	}
+
+	switch a.Mode {
+	case AnonymizeHash:
+		mac := hmac.New(sha256.New, []byte(a.Salt))
+		mac.Write([]byte(value))
+
+		return hex.EncodeToString(mac.Sum(nil))
+	case AnonymizeTruncate:
+		ip, err := netip.ParseAddr(value)
+		if err != nil {
+			return AnonymizedValue
+		}
+
+		bits := cmp.Or(a.IPv6Prefix, 48)
+		if ip.Unmap().Is4() {
+			ip = ip.Unmap()
+			bits = cmp.Or(a.IPv4Prefix, 24)
+		}
+
+		prefix, err := ip.Prefix(bits)
+		if err != nil {
+			return AnonymizedValue
+		}
+
+		return prefix.Addr().String()
+	}
+
+	return value
+}
@@ -0,0 +1,90 @@
+package csconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestEventAnonymizationValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			input: `
+- key: source_ip
+  mode: truncate
+  ipv4_prefix: 16
+- key: target_ip
+  mode: hash
+  salt: secret`,
+		},
+		{
+			name:        "missing key",
+			input:       `[{mode: truncate}]`,
+			expectedErr: "event_anonymization: missing key",
+		},
+		{
+			name:        "duplicate key",
+			input:       `[{key: source_ip, mode: truncate}, {key: source_ip, mode: truncate}]`,
+			expectedErr: "event_anonymization: duplicate key source_ip",
+		},
+		{
+			name:        "invalid mode",
+			input:       `[{key: source_ip, mode: encrypt}]`,
+			expectedErr: `event_anonymization: invalid mode "encrypt" for key source_ip, must be hash or truncate`,
+		},
+		{
+			name:        "hash without salt",
+			input:       `[{key: source_ip, mode: hash}]`,
+			expectedErr: "event_anonymization: key source_ip needs a salt to be hashed",
+		},
+		{
+			name:        "invalid prefix",
+			input:       `[{key: source_ip, mode: truncate, ipv6_prefix: 129}]`,
+			expectedErr: "event_anonymization: invalid ipv6_prefix 129 for key source_ip",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg EventAnonymizationsCfg
+
+			require.NoError(t, yaml.Unmarshal([]byte(tc.input), &cfg))
+
+			err := cfg.Validate()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestEventAnonymizationApply(t *testing.T) {
+	cfg := EventAnonymizationsCfg{
+		{Key: "source_ip", Mode: AnonymizeTruncate},
+		{Key: "target_ip", Mode: AnonymizeTruncate, IPv4Prefix: 16, IPv6Prefix: 32},
+		{Key: "user_ip", Mode: AnonymizeHash, Salt: "secret"},
+	}
+
+	assert.Equal(t, "1.2.3.0", cfg.Apply("source_ip", "1.2.3.4"))
+	assert.Equal(t, "1.2.3.0", cfg.Apply("source_ip", "::ffff:1.2.3.4"))
+	assert.Equal(t, "2001:db8:1::", cfg.Apply("source_ip", "2001:db8:1:2::1"))
+	assert.Equal(t, "1.2.0.0", cfg.Apply("target_ip", "1.2.3.4"))
+	assert.Equal(t, "2001:db8::", cfg.Apply("target_ip", "2001:db8:1:2::1"))
+	assert.Equal(t, AnonymizedValue, cfg.Apply("source_ip", "not an ip"))
+
+	hashed := cfg.Apply("user_ip", "1.2.3.4")
+	assert.Len(t, hashed, 64)
+	assert.NotContains(t, hashed, "1.2.3.4")
+	assert.Equal(t, hashed, cfg.Apply("user_ip", "1.2.3.4"))
+	assert.NotEqual(t, hashed, cfg.Apply("user_ip", "1.2.3.5"))
+
+	// other keys are left as is
+	assert.Equal(t, "1.2.3.4", cfg.Apply("log_type", "1.2.3.4"))
+}
@@ -0,0 +1,170 @@
+package csconfig
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// events published by the local API
+const (
+	EventAlertCreated    = "alert.created"
+	EventDecisionApplied = "decision.applied"
+	EventDecisionExpired = "decision.expired" // also when the decision is deleted
+)
+
+const (
+	EventSinkWebhook = "webhook"
+	EventSinkKafka   = "kafka"
+	EventSinkNATS    = "nats"
+)
+
+const (
+	defaultEventBusPollInterval = 10 * time.Second
+	defaultEventSinkQueueSize   = 1000
+	defaultEventSinkTimeout     = 10 * time.Second
+	defaultEventSinkSubject     = "crowdsec"
+)
+
+var eventBusEvents = []string{EventAlertCreated, EventDecisionApplied, EventDecisionExpired}
+
+// EventBusCfg lists the external systems the lifecycle events of the alerts and decisions are sent to.
+type EventBusCfg struct {
+	// how often the new and expired decisions are looked for
+	PollInterval *time.Duration  `yaml:"poll_interval,omitempty"`
+	Sinks        []*EventSinkCfg `yaml:"sinks"`
+}
+
+type EventSinkCfg struct {
+	Name string `yaml:"name,omitempty"`
+	Type string `yaml:"type"` // webhook, kafka or nats
+	// events sent to the sink, all of them if empty
+	Events []string `yaml:"events,omitempty"`
+	// events waiting to be sent; the new ones are dropped when it's full
+	QueueSize          int            `yaml:"queue_size,omitempty"`
+	Timeout            *time.Duration `yaml:"timeout,omitempty"`
+	InsecureSkipVerify bool           `yaml:"insecure_skip_verify,omitempty"`
+
+	// webhook: http(s) url. nats: nats:// or tls:// url of the server
+	URL string `yaml:"url,omitempty"`
+
+	// webhook
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// the body is signed with HMAC-SHA256 in the X-Crowdsec-Signature header
+	Secret string `yaml:"secret,omitempty"`
+
+	// kafka
+	Brokers []string `yaml:"brokers,omitempty"`
+	Topic   string   `yaml:"topic,omitempty"`
+	TLS     bool     `yaml:"tls,omitempty"`
+
+	// nats: the event type is appended to the subject, i.e. crowdsec.alert.created
+	Subject  string `yaml:"subject,omitempty"`
+	Token    string `yaml:"token,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+}
+
+func (c *EventBusCfg) Load() error {
+	if c.PollInterval == nil {
+		c.PollInterval = new(defaultEventBusPollInterval)
+	}
+
+	if *c.PollInterval <= 0 {
+		return errors.New("poll_interval must be positive")
+	}
+
+	names := make(map[string]bool, len(c.Sinks))
+
+	for idx, sink := range c.Sinks {
+		if sink == nil {
+			return fmt.Errorf("sink %d is empty", idx)
+		}
+
+		if sink.Name == "" {
+			sink.Name = fmt.Sprintf("%s-%d", sink.Type, idx)
+		}
+
+		if names[sink.Name] {
+			return fmt.Errorf("duplicate sink name %s", sink.Name)
+		}
+
+		names[sink.Name] = true
+
+		if err := sink.load(); err != nil {
+			return fmt.Errorf("sink %s: %w", sink.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *EventSinkCfg) load() error {
+	for _, event := range c.Events {
+		if !slices.Contains(eventBusEvents, event) {
+			return fmt.Errorf("unknown event '%s': must be one of %s", event, strings.Join(eventBusEvents, ", "))
+		}
+	}
+
+	if c.QueueSize == 0 {
+		c.QueueSize = defaultEventSinkQueueSize
+	}
+
+	if c.QueueSize < 0 {
+		return errors.New("queue_size must be positive")
+	}
+
+	if c.Timeout == nil {
+		c.Timeout = new(defaultEventSinkTimeout)
+	}
+
+	if *c.Timeout <= 0 {
+		return errors.New("timeout must be positive")
+	}
+
+	switch c.Type {
+	case EventSinkWebhook:
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid url '%s': must be an http or https url", c.URL)
+		}
+	case EventSinkKafka:
+		if len(c.Brokers) == 0 {
+			return errors.New("brokers are required")
+		}
+
+		if c.Topic == "" {
+			return errors.New("topic is required")
+		}
+	case EventSinkNATS:
+		u, err := url.Parse(c.URL)
+		if err != nil || (u.Scheme != "nats" && u.Scheme != "tls") || u.Host == "" {
+			return fmt.Errorf("invalid url '%s': must be a nats:// or tls:// url", c.URL)
+		}
+
+		if c.Subject == "" {
+			c.Subject = defaultEventSinkSubject
+		}
+
+		// they are sent as is in the commands of the protocol
+		if strings.ContainsAny(c.Subject, " \t\r\n*>") || strings.HasSuffix(c.Subject, ".") {
+			return fmt.Errorf("invalid subject '%s'", c.Subject)
+		}
+
+		if c.Token != "" && c.Username != "" {
+			return errors.New("token and username are mutually exclusive")
+		}
+	default:
+		return fmt.Errorf("invalid type '%s': must be %s, %s or %s", c.Type, EventSinkWebhook, EventSinkKafka, EventSinkNATS)
+	}
+
+	return nil
+}
+
+// Wants tells if the event must be sent to the sink.
+func (c *EventSinkCfg) Wants(event string) bool {
+	return len(c.Events) == 0 || slices.Contains(c.Events, event)
+}
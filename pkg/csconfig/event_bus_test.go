@@ -0,0 +1,117 @@
+package csconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestEventBusLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         EventBusCfg
+		expected    EventBusCfg
+		expectedErr string
+	}{
+		{
+			name: "defaults",
+			cfg: EventBusCfg{Sinks: []*EventSinkCfg{
+				{Type: "webhook", URL: "https://soc.example.com/hook"},
+				{Name: "bus", Type: "nats", URL: "nats://nats:4222"},
+			}},
+			expected: EventBusCfg{
+				PollInterval: new(10 * time.Second),
+				Sinks: []*EventSinkCfg{
+					{Name: "webhook-0", Type: "webhook", URL: "https://soc.example.com/hook", QueueSize: 1000, Timeout: new(10 * time.Second)},
+					{Name: "bus", Type: "nats", URL: "nats://nats:4222", Subject: "crowdsec", QueueSize: 1000, Timeout: new(10 * time.Second)},
+				},
+			},
+		},
+		{
+			name: "kafka",
+			cfg: EventBusCfg{PollInterval: new(time.Minute), Sinks: []*EventSinkCfg{
+				{Type: "kafka", Brokers: []string{"kafka:9092"}, Topic: "crowdsec", Events: []string{"decision.applied", "decision.expired"}, QueueSize: 10},
+			}},
+			expected: EventBusCfg{
+				PollInterval: new(time.Minute),
+				Sinks: []*EventSinkCfg{
+					{Name: "kafka-0", Type: "kafka", Brokers: []string{"kafka:9092"}, Topic: "crowdsec", Events: []string{"decision.applied", "decision.expired"}, QueueSize: 10, Timeout: new(10 * time.Second)},
+				},
+			},
+		},
+		{
+			name:        "bad poll_interval",
+			cfg:         EventBusCfg{PollInterval: new(time.Duration(0))},
+			expectedErr: "poll_interval must be positive",
+		},
+		{
+			name:        "bad type",
+			cfg:         EventBusCfg{Sinks: []*EventSinkCfg{{Type: "smtp"}}},
+			expectedErr: "sink smtp-0: invalid type 'smtp': must be webhook, kafka or nats",
+		},
+		{
+			name:        "bad event",
+			cfg:         EventBusCfg{Sinks: []*EventSinkCfg{{Type: "webhook", URL: "http://soc/hook", Events: []string{"alert.deleted"}}}},
+			expectedErr: "sink webhook-0: unknown event 'alert.deleted': must be one of alert.created, decision.applied, decision.expired",
+		},
+		{
+			name: "duplicate name",
+			cfg: EventBusCfg{Sinks: []*EventSinkCfg{
+				{Name: "soc", Type: "webhook", URL: "http://soc/hook"},
+				{Name: "soc", Type: "webhook", URL: "http://soc/other"},
+			}},
+			expectedErr: "duplicate sink name soc",
+		},
+		{
+			name:        "webhook without url",
+			cfg:         EventBusCfg{Sinks: []*EventSinkCfg{{Type: "webhook"}}},
+			expectedErr: "sink webhook-0: invalid url '': must be an http or https url",
+		},
+		{
+			name:        "kafka without topic",
+			cfg:         EventBusCfg{Sinks: []*EventSinkCfg{{Type: "kafka", Brokers: []string{"kafka:9092"}}}},
+			expectedErr: "sink kafka-0: topic is required",
+		},
+		{
+			name:        "nats with http url",
+			cfg:         EventBusCfg{Sinks: []*EventSinkCfg{{Type: "nats", URL: "http://nats:4222"}}},
+			expectedErr: "sink nats-0: invalid url 'http://nats:4222': must be a nats:// or tls:// url",
+		},
+		{
+			name:        "nats with wildcard subject",
+			cfg:         EventBusCfg{Sinks: []*EventSinkCfg{{Type: "nats", URL: "nats://nats", Subject: "crowdsec.>"}}},
+			expectedErr: "sink nats-0: invalid subject 'crowdsec.>'",
+		},
+		{
+			name:        "bad queue_size",
+			cfg:         EventBusCfg{Sinks: []*EventSinkCfg{{Type: "webhook", URL: "http://soc/hook", QueueSize: -1}}},
+			expectedErr: "sink webhook-0: queue_size must be positive",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Load()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, tc.cfg)
+		})
+	}
+}
+
+func TestEventSinkWants(t *testing.T) {
+	all := EventSinkCfg{}
+	assert.True(t, all.Wants(EventAlertCreated))
+	assert.True(t, all.Wants(EventDecisionExpired))
+
+	some := EventSinkCfg{Events: []string{EventDecisionApplied}}
+	assert.False(t, some.Wants(EventAlertCreated))
+	assert.True(t, some.Wants(EventDecisionApplied))
+}
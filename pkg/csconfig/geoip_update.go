@@ -0,0 +1,50 @@
+package csconfig
+
+import (
+	"errors"
+	"time"
+)
+
+const defaultGeoIPUpdateURL = "https://download.maxmind.com/geoip/databases/%s/download?suffix=tar.gz"
+
+// GeoIPUpdateCfg configures the download of the MaxMind GeoLite2 databases (City and ASN) in the
+// data directory. They are downloaded again when they are missing or older than max_age, and
+// reloaded without restart.
+type GeoIPUpdateCfg struct {
+	AccountID  string `yaml:"account_id"`
+	LicenseKey string `yaml:"license_key"`
+	// how often the age of the databases is checked
+	RefreshInterval *time.Duration `yaml:"refresh_interval,omitempty"`
+	// the databases are downloaded again when they are older than this (build date of the database)
+	MaxAge *time.Duration `yaml:"max_age,omitempty"`
+	// download URL, with %s for the edition: a mirror, or the MaxMind API by default
+	URL string `yaml:"url,omitempty"`
+}
+
+func (g *GeoIPUpdateCfg) Load() error {
+	if g.AccountID == "" || g.LicenseKey == "" {
+		return errors.New("account_id and license_key are required")
+	}
+
+	if g.RefreshInterval == nil {
+		g.RefreshInterval = new(24 * time.Hour)
+	}
+
+	if *g.RefreshInterval < time.Minute {
+		return errors.New("refresh_interval must be at least 1m")
+	}
+
+	if g.MaxAge == nil {
+		g.MaxAge = new(7 * 24 * time.Hour)
+	}
+
+	if *g.MaxAge <= 0 {
+		return errors.New("max_age must be positive")
+	}
+
+	if g.URL == "" {
+		g.URL = defaultGeoIPUpdateURL
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package csconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestGeoIPUpdateLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         GeoIPUpdateCfg
+		expected    GeoIPUpdateCfg
+		expectedErr string
+	}{
+		{
+			name: "defaults",
+			cfg:  GeoIPUpdateCfg{AccountID: "42", LicenseKey: "key"},
+			expected: GeoIPUpdateCfg{
+				AccountID:       "42",
+				LicenseKey:      "key",
+				RefreshInterval: new(24 * time.Hour),
+				MaxAge:          new(7 * 24 * time.Hour),
+				URL:             "https://download.maxmind.com/geoip/databases/%s/download?suffix=tar.gz",
+			},
+		},
+		{
+			name: "mirror",
+			cfg:  GeoIPUpdateCfg{AccountID: "42", LicenseKey: "key", URL: "https://mirror.local/%s.tar.gz", MaxAge: new(72 * time.Hour)},
+			expected: GeoIPUpdateCfg{
+				AccountID:       "42",
+				LicenseKey:      "key",
+				RefreshInterval: new(24 * time.Hour),
+				MaxAge:          new(72 * time.Hour),
+				URL:             "https://mirror.local/%s.tar.gz",
+			},
+		},
+		{
+			name:        "no license key",
+			cfg:         GeoIPUpdateCfg{AccountID: "42"},
+			expectedErr: "account_id and license_key are required",
+		},
+		{
+			name:        "bad refresh_interval",
+			cfg:         GeoIPUpdateCfg{AccountID: "42", LicenseKey: "key", RefreshInterval: new(time.Second)},
+			expectedErr: "refresh_interval must be at least 1m",
+		},
+		{
+			name:        "bad max_age",
+			cfg:         GeoIPUpdateCfg{AccountID: "42", LicenseKey: "key", MaxAge: new(time.Duration(0))},
+			expectedErr: "max_age must be positive",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Load()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, tc.cfg)
+		})
+	}
+}
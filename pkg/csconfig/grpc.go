@@ -0,0 +1,45 @@
+package csconfig
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	defaultGRPCPollInterval = time.Second
+	defaultGRPCKeepalive    = 30 * time.Second
+)
+
+// GRPCServerCfg enables the gRPC decision stream: the bouncers keep a stream open and receive the
+// new and deleted decisions as they happen, instead of polling /v1/decisions/stream.
+type GRPCServerCfg struct {
+	ListenURI string `yaml:"listen_uri"` // 127.0.0.1:8081
+	// how often the database is queried for the changes, once for all the streams
+	PollInterval *time.Duration `yaml:"poll_interval,omitempty"`
+	// interval of the pings on idle connections, to detect the dead bouncers and keep the proxies open
+	Keepalive *time.Duration `yaml:"keepalive,omitempty"`
+}
+
+func (c *GRPCServerCfg) Load() error {
+	if c.ListenURI == "" {
+		return errors.New("listen_uri is required")
+	}
+
+	if c.PollInterval == nil {
+		c.PollInterval = new(defaultGRPCPollInterval)
+	}
+
+	if c.Keepalive == nil {
+		c.Keepalive = new(defaultGRPCKeepalive)
+	}
+
+	if *c.PollInterval <= 0 {
+		return errors.New("poll_interval must be positive")
+	}
+
+	if *c.Keepalive <= 0 {
+		return errors.New("keepalive must be positive")
+	}
+
+	return nil
+}
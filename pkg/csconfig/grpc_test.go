@@ -0,0 +1,58 @@
+package csconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestGRPCServerLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         GRPCServerCfg
+		expected    GRPCServerCfg
+		expectedErr string
+	}{
+		{
+			name:     "defaults",
+			cfg:      GRPCServerCfg{ListenURI: "127.0.0.1:8081"},
+			expected: GRPCServerCfg{ListenURI: "127.0.0.1:8081", PollInterval: new(time.Second), Keepalive: new(30 * time.Second)},
+		},
+		{
+			name:     "custom",
+			cfg:      GRPCServerCfg{ListenURI: "127.0.0.1:8081", PollInterval: new(500 * time.Millisecond), Keepalive: new(time.Minute)},
+			expected: GRPCServerCfg{ListenURI: "127.0.0.1:8081", PollInterval: new(500 * time.Millisecond), Keepalive: new(time.Minute)},
+		},
+		{
+			name:        "missing listen_uri",
+			cfg:         GRPCServerCfg{},
+			expectedErr: "listen_uri is required",
+		},
+		{
+			name:        "bad poll_interval",
+			cfg:         GRPCServerCfg{ListenURI: "127.0.0.1:8081", PollInterval: new(time.Duration(0))},
+			expectedErr: "poll_interval must be positive",
+		},
+		{
+			name:        "bad keepalive",
+			cfg:         GRPCServerCfg{ListenURI: "127.0.0.1:8081", Keepalive: new(-time.Second)},
+			expectedErr: "keepalive must be positive",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Load()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, tc.cfg)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package csconfig
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// agents send a heartbeat every minute
+	defaultHeartbeatMaxDelay      = 2 * time.Minute
+	defaultHeartbeatCheckInterval = time.Minute
+)
+
+// HeartbeatSLACfg sets how long a machine can go without sending a heartbeat
+// before it's considered stale, and what the local API does about it.
+type HeartbeatSLACfg struct {
+	MaxDelay *time.Duration `yaml:"max_delay,omitempty"`
+	// per-machine max_delay, for the agents that are expected to be down from time to time
+	Machines map[string]time.Duration `yaml:"machines,omitempty"`
+	// store an alert when a machine becomes stale
+	Alert bool `yaml:"alert,omitempty"`
+	// also send the alert to the notification plugins of the matching profiles
+	Notify        bool           `yaml:"notify,omitempty"`
+	CheckInterval *time.Duration `yaml:"check_interval,omitempty"`
+}
+
+func (c *HeartbeatSLACfg) Load() error {
+	if c.MaxDelay == nil {
+		c.MaxDelay = new(defaultHeartbeatMaxDelay)
+	}
+
+	if c.CheckInterval == nil {
+		c.CheckInterval = new(defaultHeartbeatCheckInterval)
+	}
+
+	if *c.MaxDelay <= 0 {
+		return errors.New("max_delay must be positive")
+	}
+
+	if *c.CheckInterval <= 0 {
+		return errors.New("check_interval must be positive")
+	}
+
+	for machineID, delay := range c.Machines {
+		if delay <= 0 {
+			return fmt.Errorf("max_delay of machine %s must be positive", machineID)
+		}
+	}
+
+	if c.Notify && !c.Alert {
+		return errors.New("notify requires alert to be enabled")
+	}
+
+	return nil
+}
+
+// MaxDelayFor returns the longest time a machine can go without sending a heartbeat.
+func (c *HeartbeatSLACfg) MaxDelayFor(machineID string) time.Duration {
+	if c == nil || c.MaxDelay == nil {
+		return defaultHeartbeatMaxDelay
+	}
+
+	if delay, ok := c.Machines[machineID]; ok {
+		return delay
+	}
+
+	return *c.MaxDelay
+}
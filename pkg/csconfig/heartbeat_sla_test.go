@@ -0,0 +1,75 @@
+package csconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestHeartbeatSLALoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         HeartbeatSLACfg
+		expected    HeartbeatSLACfg
+		expectedErr string
+	}{
+		{
+			name:     "defaults",
+			cfg:      HeartbeatSLACfg{},
+			expected: HeartbeatSLACfg{MaxDelay: new(2 * time.Minute), CheckInterval: new(time.Minute)},
+		},
+		{
+			name:     "alert and notify",
+			cfg:      HeartbeatSLACfg{MaxDelay: new(5 * time.Minute), Alert: true, Notify: true},
+			expected: HeartbeatSLACfg{MaxDelay: new(5 * time.Minute), CheckInterval: new(time.Minute), Alert: true, Notify: true},
+		},
+		{
+			name:        "bad max_delay",
+			cfg:         HeartbeatSLACfg{MaxDelay: new(time.Duration(0))},
+			expectedErr: "max_delay must be positive",
+		},
+		{
+			name:        "bad check_interval",
+			cfg:         HeartbeatSLACfg{CheckInterval: new(-time.Second)},
+			expectedErr: "check_interval must be positive",
+		},
+		{
+			name:        "bad machine max_delay",
+			cfg:         HeartbeatSLACfg{Machines: map[string]time.Duration{"laptop": 0}},
+			expectedErr: "max_delay of machine laptop must be positive",
+		},
+		{
+			name:        "notify without alert",
+			cfg:         HeartbeatSLACfg{Notify: true},
+			expectedErr: "notify requires alert to be enabled",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Load()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, tc.cfg)
+		})
+	}
+}
+
+func TestHeartbeatSLAMaxDelayFor(t *testing.T) {
+	var cfg *HeartbeatSLACfg
+
+	assert.Equal(t, 2*time.Minute, cfg.MaxDelayFor("agent"))
+
+	cfg = &HeartbeatSLACfg{Machines: map[string]time.Duration{"laptop": 24 * time.Hour}}
+	assert.NoError(t, cfg.Load())
+
+	assert.Equal(t, 2*time.Minute, cfg.MaxDelayFor("agent"))
+	assert.Equal(t, 24*time.Hour, cfg.MaxDelayFor("laptop"))
+}
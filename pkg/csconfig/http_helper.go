@@ -0,0 +1,82 @@
+package csconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// HTTPHelperCfg configures the HttpGet expr helper, used by parsers and scenarios
+// to query internal enrichment services. The helper is disabled if there is no
+// configuration, and only queries the allowed hosts.
+type HTTPHelperCfg struct {
+	// host names, with an optional port ("enrich.local:8080"), or a wildcard for subdomains ("*.internal")
+	AllowedHosts      []string       `yaml:"allowed_hosts"`
+	Timeout           *time.Duration `yaml:"timeout,omitempty"`
+	CacheTimeout      *time.Duration `yaml:"cache_timeout,omitempty"`
+	CacheSize         *int           `yaml:"cache_size,omitempty"`
+	MaxConcurrent     *int           `yaml:"max_concurrent,omitempty"`
+	RequestsPerSecond *float64       `yaml:"requests_per_second,omitempty"`
+	MaxResponseSize   *int64         `yaml:"max_response_size,omitempty"`
+	LogLevel          log.Level      `yaml:"log_level,omitempty"`
+}
+
+func (h *HTTPHelperCfg) Load() error {
+	if len(h.AllowedHosts) == 0 {
+		return errors.New("allowed_hosts is required")
+	}
+
+	for i, host := range h.AllowedHosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host == "" || host == "*" || strings.Contains(host, "/") {
+			return fmt.Errorf("allowed_hosts: invalid host %q", h.AllowedHosts[i])
+		}
+
+		h.AllowedHosts[i] = host
+	}
+
+	if h.Timeout == nil {
+		h.Timeout = new(2 * time.Second)
+	}
+
+	if *h.Timeout <= 0 {
+		return errors.New("timeout must be positive")
+	}
+
+	if h.CacheTimeout == nil {
+		h.CacheTimeout = new(5 * time.Minute)
+	}
+
+	if h.CacheSize == nil {
+		h.CacheSize = new(1000)
+	}
+
+	if *h.CacheSize < 0 {
+		return errors.New("cache_size can't be negative")
+	}
+
+	if h.MaxConcurrent == nil {
+		h.MaxConcurrent = new(10)
+	}
+
+	if *h.MaxConcurrent <= 0 {
+		return errors.New("max_concurrent must be positive")
+	}
+
+	if h.RequestsPerSecond != nil && *h.RequestsPerSecond <= 0 {
+		return errors.New("requests_per_second must be positive")
+	}
+
+	if h.MaxResponseSize == nil {
+		h.MaxResponseSize = new(int64(1024 * 1024))
+	}
+
+	if *h.MaxResponseSize <= 0 {
+		return errors.New("max_response_size must be positive")
+	}
+
+	return nil
+}
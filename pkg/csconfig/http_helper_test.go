@@ -0,0 +1,63 @@
+package csconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestHTTPHelperLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         HTTPHelperCfg
+		expectedErr string
+	}{
+		{
+			name: "defaults",
+			cfg:  HTTPHelperCfg{AllowedHosts: []string{" Enrich.local "}},
+		},
+		{
+			name:        "no host",
+			cfg:         HTTPHelperCfg{},
+			expectedErr: "allowed_hosts is required",
+		},
+		{
+			name:        "any host",
+			cfg:         HTTPHelperCfg{AllowedHosts: []string{"*"}},
+			expectedErr: `allowed_hosts: invalid host "*"`,
+		},
+		{
+			name:        "url",
+			cfg:         HTTPHelperCfg{AllowedHosts: []string{"http://enrich.local/"}},
+			expectedErr: `allowed_hosts: invalid host "http://enrich.local/"`,
+		},
+		{
+			name:        "bad concurrency",
+			cfg:         HTTPHelperCfg{AllowedHosts: []string{"enrich.local"}, MaxConcurrent: new(0)},
+			expectedErr: "max_concurrent must be positive",
+		},
+		{
+			name:        "bad rate",
+			cfg:         HTTPHelperCfg{AllowedHosts: []string{"enrich.local"}, RequestsPerSecond: new(0.0)},
+			expectedErr: "requests_per_second must be positive",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Load()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+
+	cfg := HTTPHelperCfg{AllowedHosts: []string{" Enrich.local "}}
+	require.NoError(t, cfg.Load())
+	assert.Equal(t, []string{"enrich.local"}, cfg.AllowedHosts)
+	assert.Equal(t, 2*time.Second, *cfg.Timeout)
+	assert.Equal(t, 10, *cfg.MaxConcurrent)
+	assert.Nil(t, cfg.RequestsPerSecond)
+}
@@ -0,0 +1,211 @@
+package csconfig
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// MaintenanceWindowCfg is a period of planned disruption (chaos tests, migrations...). The alerts
+// raised during the window are stored and their decisions are applied, but their notifications
+// and the signals sent to the Central API are held, and sent when the window is over.
+//
+// A window is either a one-off period (start, end) or a recurring one (from, to, on some days).
+type MaintenanceWindowCfg struct {
+	Name      string     `yaml:"name"`
+	Start     *time.Time `yaml:"start,omitempty"`     // one-off window, RFC 3339
+	End       *time.Time `yaml:"end,omitempty"`       //
+	Days      []string   `yaml:"days,omitempty"`      // recurring window: days it starts on (monday, tuesday...), every day if empty
+	From      string     `yaml:"from,omitempty"`      // 15:04, the window can end on the next day
+	To        string     `yaml:"to,omitempty"`        //
+	Timezone  string     `yaml:"timezone,omitempty"`  // of from and to, local time if empty
+	Scenarios []string   `yaml:"scenarios,omitempty"` // names or patterns (crowdsecurity/*), all the alerts if empty
+	Machines  []string   `yaml:"machines,omitempty"`  // names or patterns of the machines raising the alerts, all if empty
+
+	days     map[time.Weekday]bool
+	from, to time.Duration // since midnight
+	location *time.Location
+}
+
+type MaintenanceWindowsCfg []MaintenanceWindowCfg
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// parseTimeOfDay parses a time like 15:04, and returns the duration since midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, must be HH:MM", s)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func validatePatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid pattern %s: %w", p, err)
+		}
+	}
+
+	return nil
+}
+
+func (w *MaintenanceWindowCfg) validate() error {
+	oneOff := w.Start != nil || w.End != nil
+	recurring := w.From != "" || w.To != "" || len(w.Days) > 0 || w.Timezone != ""
+
+	switch {
+	case oneOff && recurring:
+		return errors.New("start and end are mutually exclusive with days, from, to and timezone")
+	case oneOff:
+		if w.Start == nil || w.End == nil {
+			return errors.New("start and end are required")
+		}
+
+		if !w.End.After(*w.Start) {
+			return errors.New("end must be after start")
+		}
+	case recurring:
+		if w.From == "" || w.To == "" {
+			return errors.New("from and to are required")
+		}
+
+		var err error
+
+		if w.from, err = parseTimeOfDay(w.From); err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+
+		if w.to, err = parseTimeOfDay(w.To); err != nil {
+			return fmt.Errorf("to: %w", err)
+		}
+
+		if w.from == w.to {
+			return errors.New("from and to must be different")
+		}
+
+		w.location = time.Local
+
+		if w.Timezone != "" {
+			if w.location, err = time.LoadLocation(w.Timezone); err != nil {
+				return fmt.Errorf("invalid timezone: %w", err)
+			}
+		}
+
+		w.days = make(map[time.Weekday]bool, len(w.Days))
+
+		for _, day := range w.Days {
+			weekday, ok := weekdays[strings.ToLower(day)]
+			if !ok {
+				return fmt.Errorf("invalid day %q", day)
+			}
+
+			w.days[weekday] = true
+		}
+	default:
+		return errors.New("either start and end, or from and to are required")
+	}
+
+	if err := validatePatterns(w.Scenarios); err != nil {
+		return fmt.Errorf("scenarios: %w", err)
+	}
+
+	if err := validatePatterns(w.Machines); err != nil {
+		return fmt.Errorf("machines: %w", err)
+	}
+
+	return nil
+}
+
+func (c MaintenanceWindowsCfg) Validate() error {
+	seen := make(map[string]struct{}, len(c))
+
+	for i := range c {
+		w := &c[i]
+
+		if w.Name == "" {
+			return errors.New("maintenance_windows: missing name")
+		}
+
+		if _, ok := seen[w.Name]; ok {
+			return fmt.Errorf("maintenance_windows: duplicate name %s", w.Name)
+		}
+
+		seen[w.Name] = struct{}{}
+
+		if err := w.validate(); err != nil {
+			return fmt.Errorf("maintenance_windows: %s: %w", w.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// startsOn returns true if a recurring window starts on a day.
+func (w *MaintenanceWindowCfg) startsOn(day time.Weekday) bool {
+	return len(w.days) == 0 || w.days[day]
+}
+
+// Active returns true if the window is open at the given time.
+func (w *MaintenanceWindowCfg) Active(now time.Time) bool {
+	if w.Start != nil {
+		return !now.Before(*w.Start) && now.Before(*w.End)
+	}
+
+	now = now.In(w.location)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, w.location)
+	tod := now.Sub(midnight)
+
+	if w.from < w.to {
+		return w.startsOn(now.Weekday()) && tod >= w.from && tod < w.to
+	}
+
+	// the window ends on the next day
+	if tod >= w.from {
+		return w.startsOn(now.Weekday())
+	}
+
+	return tod < w.to && w.startsOn(now.AddDate(0, 0, -1).Weekday())
+}
+
+func matchAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, value); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matches returns true if the window applies to the alerts of a scenario and a machine.
+func (w *MaintenanceWindowCfg) Matches(scenario string, machineID string) bool {
+	return matchAny(w.Scenarios, scenario) && matchAny(w.Machines, machineID)
+}
+
+// Lookup returns the first window that is open at the given time and applies to the alerts of a
+// scenario and a machine.
+func (c MaintenanceWindowsCfg) Lookup(now time.Time, scenario string, machineID string) (*MaintenanceWindowCfg, bool) {
+	for i := range c {
+		if c[i].Active(now) && c[i].Matches(scenario, machineID) {
+			return &c[i], true
+		}
+	}
+
+	return nil, false
+}
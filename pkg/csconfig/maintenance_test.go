@@ -0,0 +1,209 @@
+package csconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestMaintenanceWindowsValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			input: `
+- name: migration
+  start: 2026-03-01T22:00:00Z
+  end: 2026-03-02T02:00:00Z
+- name: chaos
+  days: [Tuesday]
+  from: "23:00"
+  to: "01:00"
+  timezone: Europe/Paris
+  scenarios: [crowdsecurity/http-*]
+  machines: [web-*]`,
+		},
+		{
+			name:        "missing name",
+			input:       `[{from: "10:00", to: "11:00"}]`,
+			expectedErr: "maintenance_windows: missing name",
+		},
+		{
+			name:        "duplicate name",
+			input:       `[{name: a, from: "10:00", to: "11:00"}, {name: a, from: "12:00", to: "13:00"}]`,
+			expectedErr: "maintenance_windows: duplicate name a",
+		},
+		{
+			name:        "no period",
+			input:       `[{name: a}]`,
+			expectedErr: "maintenance_windows: a: either start and end, or from and to are required",
+		},
+		{
+			name:        "both kinds",
+			input:       `[{name: a, start: 2026-03-01T22:00:00Z, end: 2026-03-02T02:00:00Z, from: "10:00", to: "11:00"}]`,
+			expectedErr: "maintenance_windows: a: start and end are mutually exclusive with days, from, to and timezone",
+		},
+		{
+			name:        "missing end",
+			input:       `[{name: a, start: 2026-03-01T22:00:00Z}]`,
+			expectedErr: "maintenance_windows: a: start and end are required",
+		},
+		{
+			name:        "end before start",
+			input:       `[{name: a, start: 2026-03-02T02:00:00Z, end: 2026-03-01T22:00:00Z}]`,
+			expectedErr: "maintenance_windows: a: end must be after start",
+		},
+		{
+			name:        "missing to",
+			input:       `[{name: a, from: "10:00"}]`,
+			expectedErr: "maintenance_windows: a: from and to are required",
+		},
+		{
+			name:        "invalid time",
+			input:       `[{name: a, from: "25:00", to: "11:00"}]`,
+			expectedErr: `maintenance_windows: a: from: invalid time "25:00", must be HH:MM`,
+		},
+		{
+			name:        "empty window",
+			input:       `[{name: a, from: "10:00", to: "10:00"}]`,
+			expectedErr: "maintenance_windows: a: from and to must be different",
+		},
+		{
+			name:        "invalid timezone",
+			input:       `[{name: a, from: "10:00", to: "11:00", timezone: Mars/Olympus}]`,
+			expectedErr: "maintenance_windows: a: invalid timezone: unknown time zone Mars/Olympus",
+		},
+		{
+			name:        "invalid day",
+			input:       `[{name: a, days: [someday], from: "10:00", to: "11:00"}]`,
+			expectedErr: `maintenance_windows: a: invalid day "someday"`,
+		},
+		{
+			name:        "invalid scenario pattern",
+			input:       `[{name: a, from: "10:00", to: "11:00", scenarios: ["crowdsecurity/["]}]`,
+			expectedErr: "maintenance_windows: a: scenarios: invalid pattern crowdsecurity/[: syntax error in pattern",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var cfg MaintenanceWindowsCfg
+
+			require.NoError(t, yaml.Unmarshal([]byte(tc.input), &cfg))
+
+			err := cfg.Validate()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestMaintenanceWindowActive(t *testing.T) {
+	start := time.Date(2026, 3, 1, 22, 0, 0, 0, time.UTC)
+	end := start.Add(4 * time.Hour)
+
+	tests := []struct {
+		name   string
+		window MaintenanceWindowCfg
+		now    time.Time
+		active bool
+	}{
+		{
+			name:   "one-off, before",
+			window: MaintenanceWindowCfg{Start: &start, End: &end},
+			now:    start.Add(-time.Second),
+		},
+		{
+			name:   "one-off, during",
+			window: MaintenanceWindowCfg{Start: &start, End: &end},
+			now:    start,
+			active: true,
+		},
+		{
+			name:   "one-off, after",
+			window: MaintenanceWindowCfg{Start: &start, End: &end},
+			now:    end,
+		},
+		{
+			name:   "daily, during",
+			window: MaintenanceWindowCfg{From: "10:00", To: "11:00", Timezone: "UTC"},
+			now:    time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC),
+			active: true,
+		},
+		{
+			name:   "daily, after",
+			window: MaintenanceWindowCfg{From: "10:00", To: "11:00", Timezone: "UTC"},
+			now:    time.Date(2026, 3, 4, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "timezone",
+			window: MaintenanceWindowCfg{From: "10:00", To: "11:00", Timezone: "Europe/Paris"},
+			now:    time.Date(2026, 3, 4, 9, 30, 0, 0, time.UTC),
+			active: true,
+		},
+		{
+			// 2026-03-03 is a Tuesday
+			name:   "other day",
+			window: MaintenanceWindowCfg{Days: []string{"monday"}, From: "10:00", To: "11:00", Timezone: "UTC"},
+			now:    time.Date(2026, 3, 3, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:   "crossing midnight, before midnight",
+			window: MaintenanceWindowCfg{Days: []string{"tuesday"}, From: "23:00", To: "01:00", Timezone: "UTC"},
+			now:    time.Date(2026, 3, 3, 23, 30, 0, 0, time.UTC),
+			active: true,
+		},
+		{
+			name:   "crossing midnight, after midnight",
+			window: MaintenanceWindowCfg{Days: []string{"tuesday"}, From: "23:00", To: "01:00", Timezone: "UTC"},
+			now:    time.Date(2026, 3, 4, 0, 30, 0, 0, time.UTC),
+			active: true,
+		},
+		{
+			name:   "crossing midnight, started the day before",
+			window: MaintenanceWindowCfg{Days: []string{"tuesday"}, From: "23:00", To: "01:00", Timezone: "UTC"},
+			now:    time.Date(2026, 3, 3, 0, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.window.Name = "test"
+			require.NoError(t, tc.window.validate())
+			assert.Equal(t, tc.active, tc.window.Active(tc.now))
+		})
+	}
+}
+
+func TestMaintenanceWindowsLookup(t *testing.T) {
+	cfg := MaintenanceWindowsCfg{
+		{Name: "web", From: "10:00", To: "11:00", Timezone: "UTC", Scenarios: []string{"crowdsecurity/http-*"}, Machines: []string{"web-*"}},
+		{Name: "all", From: "12:00", To: "13:00", Timezone: "UTC"},
+	}
+
+	require.NoError(t, cfg.Validate())
+
+	morning := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)
+	noon := time.Date(2026, 3, 4, 12, 30, 0, 0, time.UTC)
+
+	w, ok := cfg.Lookup(morning, "crowdsecurity/http-probing", "web-1")
+	require.True(t, ok)
+	assert.Equal(t, "web", w.Name)
+
+	_, ok = cfg.Lookup(morning, "crowdsecurity/ssh-bf", "web-1")
+	assert.False(t, ok)
+
+	_, ok = cfg.Lookup(morning, "crowdsecurity/http-probing", "db-1")
+	assert.False(t, ok)
+
+	w, ok = cfg.Lookup(noon, "crowdsecurity/ssh-bf", "db-1")
+	require.True(t, ok)
+	assert.Equal(t, "all", w.Name)
+}
@@ -0,0 +1,61 @@
+package csconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MLModelCfg declares an ONNX model, to be used by the MachineLearningScore expr helper.
+type MLModelCfg struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+	// output of the model that holds the score, the first float output if empty
+	Output string `yaml:"output,omitempty"`
+	// element of the output, the last one if not set (the probability of the positive class of a binary classifier)
+	Index *int `yaml:"index,omitempty"`
+}
+
+func (m *MLModelCfg) Load() error {
+	m.Name = strings.TrimSpace(m.Name)
+
+	if m.Name == "" {
+		return errors.New("name is required")
+	}
+
+	if m.Path == "" {
+		return errors.New("path is required")
+	}
+
+	if err := ensureAbsolutePath(&m.Path); err != nil {
+		return err
+	}
+
+	if m.Index != nil && *m.Index < 0 {
+		return errors.New("index can't be negative")
+	}
+
+	return nil
+}
+
+func loadMLModels(models []*MLModelCfg) error {
+	seen := make(map[string]struct{}, len(models))
+
+	for i, m := range models {
+		if m == nil {
+			return fmt.Errorf("model %d: empty definition", i)
+		}
+
+		if err := m.Load(); err != nil {
+			return fmt.Errorf("model %d: %w", i, err)
+		}
+
+		if _, ok := seen[m.Name]; ok {
+			return fmt.Errorf("model %d: duplicate name %q", i, m.Name)
+		}
+
+		seen[m.Name] = struct{}{}
+	}
+
+	return nil
+}
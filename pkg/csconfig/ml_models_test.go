@@ -0,0 +1,64 @@
+package csconfig
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestLoadMLModels(t *testing.T) {
+	tests := []struct {
+		name        string
+		models      []*MLModelCfg
+		expectedErr string
+	}{
+		{
+			name: "none",
+		},
+		{
+			name: "valid",
+			models: []*MLModelCfg{
+				{Name: "bots", Path: "/etc/crowdsec/models/bots.onnx"},
+				{Name: "anomaly", Path: "/etc/crowdsec/models/anomaly.onnx", Output: "variable", Index: new(0)},
+			},
+		},
+		{
+			name:        "missing name",
+			models:      []*MLModelCfg{{Name: " ", Path: "/etc/crowdsec/models/bots.onnx"}},
+			expectedErr: "model 0: name is required",
+		},
+		{
+			name:        "missing path",
+			models:      []*MLModelCfg{{Name: "bots"}},
+			expectedErr: "model 0: path is required",
+		},
+		{
+			name:        "negative index",
+			models:      []*MLModelCfg{{Name: "bots", Path: "/etc/crowdsec/models/bots.onnx", Index: new(-1)}},
+			expectedErr: "model 0: index can't be negative",
+		},
+		{
+			name: "duplicate name",
+			models: []*MLModelCfg{
+				{Name: "bots", Path: "/etc/crowdsec/models/bots.onnx"},
+				{Name: "bots", Path: "/etc/crowdsec/models/bots2.onnx"},
+			},
+			expectedErr: `model 1: duplicate name "bots"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := loadMLModels(tc.models)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+
+	m := MLModelCfg{Name: "bots", Path: "models/bots.onnx"}
+	require.NoError(t, m.Load())
+	assert.True(t, filepath.IsAbs(m.Path))
+}
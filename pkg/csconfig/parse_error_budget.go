@@ -0,0 +1,66 @@
+package csconfig
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// ParseErrorBudgetCfg raises an alert when a datasource sends too many lines that can't be
+// parsed, which usually means the wrong log format or a missing parser. The source can also
+// be quarantined: its lines are still read, but not parsed, to save CPU.
+type ParseErrorBudgetCfg struct {
+	// share of the lines, between 0 and 1, that can fail to parse in a window
+	MaxRatio *float64 `yaml:"max_ratio,omitempty"`
+	// the budget of a source is not checked before it has sent this many lines in the window
+	MinLines *int           `yaml:"min_lines,omitempty"`
+	Window   *time.Duration `yaml:"window,omitempty"`
+	// only check these datasources (acquisition type label, like "file" or "journalctl"),
+	// all of them if empty
+	Sources            []string       `yaml:"sources,omitempty"`
+	Quarantine         bool           `yaml:"quarantine"`
+	QuarantineDuration *time.Duration `yaml:"quarantine_duration,omitempty"`
+}
+
+func (p *ParseErrorBudgetCfg) Load() error {
+	if p.MaxRatio == nil {
+		p.MaxRatio = new(0.9)
+	}
+
+	if *p.MaxRatio < 0 || *p.MaxRatio >= 1 {
+		return errors.New("max_ratio must be at least 0, and less than 1")
+	}
+
+	if p.MinLines == nil {
+		p.MinLines = new(1000)
+	}
+
+	if *p.MinLines <= 0 {
+		return errors.New("min_lines must be positive")
+	}
+
+	if p.Window == nil {
+		p.Window = new(5 * time.Minute)
+	}
+
+	if *p.Window <= 0 {
+		return errors.New("window must be positive")
+	}
+
+	for i, source := range p.Sources {
+		p.Sources[i] = strings.TrimSpace(source)
+		if p.Sources[i] == "" {
+			return errors.New("sources: empty datasource type")
+		}
+	}
+
+	if p.QuarantineDuration == nil {
+		p.QuarantineDuration = new(time.Hour)
+	}
+
+	if *p.QuarantineDuration <= 0 {
+		return errors.New("quarantine_duration must be positive")
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package csconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestParseErrorBudgetLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr string
+	}{
+		{
+			name:  "defaults",
+			input: `{}`,
+		},
+		{
+			name:        "ratio too high",
+			input:       `max_ratio: 1`,
+			expectedErr: "max_ratio must be at least 0, and less than 1",
+		},
+		{
+			name:        "negative ratio",
+			input:       `max_ratio: -0.1`,
+			expectedErr: "max_ratio must be at least 0, and less than 1",
+		},
+		{
+			name:        "bad min_lines",
+			input:       `min_lines: 0`,
+			expectedErr: "min_lines must be positive",
+		},
+		{
+			name:        "bad window",
+			input:       `window: 0s`,
+			expectedErr: "window must be positive",
+		},
+		{
+			name:        "empty source",
+			input:       `sources: ["file", " "]`,
+			expectedErr: "sources: empty datasource type",
+		},
+		{
+			name:        "bad quarantine duration",
+			input:       "quarantine: true\nquarantine_duration: -1m",
+			expectedErr: "quarantine_duration must be positive",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := ParseErrorBudgetCfg{}
+			require.NoError(t, yaml.Unmarshal([]byte(tc.input), &cfg))
+
+			err := cfg.Load()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+
+	cfg := ParseErrorBudgetCfg{}
+	require.NoError(t, yaml.Unmarshal([]byte("max_ratio: 0.5\nsources: [' file ']\nquarantine: true"), &cfg))
+	require.NoError(t, cfg.Load())
+	assert.InDelta(t, 0.5, *cfg.MaxRatio, 0.0001)
+	assert.Equal(t, 1000, *cfg.MinLines)
+	assert.Equal(t, 5*time.Minute, *cfg.Window)
+	assert.Equal(t, []string{"file"}, cfg.Sources)
+	assert.True(t, cfg.Quarantine)
+	assert.Equal(t, time.Hour, *cfg.QuarantineDuration)
+}
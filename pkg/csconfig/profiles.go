@@ -5,7 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 
+	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 
 	"github.com/crowdsecurity/go-cs-lib/csyaml"
@@ -30,6 +33,43 @@ type ProfileCfg struct {
 	OnFailure     string            `yaml:"on_failure,omitempty"` // continue or break
 	OnError       string            `yaml:"on_error,omitempty"`   // continue, break, error, report, apply, ignore
 	Notifications []string          `yaml:"notifications,omitempty"`
+	DryRun        *bool             `yaml:"dry_run,omitempty"` // decisions are recorded in simulation mode and not sent to the bouncers
+}
+
+// IsDryRun returns true if the decisions of the profile must not be enforced.
+func (p *ProfileCfg) IsDryRun() bool {
+	return p.DryRun != nil && *p.DryRun
+}
+
+// DecodeProfiles decodes the profiles of a YAML stream. The profiles without a dry_run setting
+// follow dryRun.
+func DecodeProfiles(r io.Reader, dryRun bool) ([]*ProfileCfg, error) {
+	ret := []*ProfileCfg{}
+
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	for {
+		t := ProfileCfg{}
+
+		err := dec.Decode(&t)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, err
+		}
+
+		// a profile can opt out of the global dry-run mode with dry_run: false
+		if t.DryRun == nil && dryRun {
+			t.DryRun = new(true)
+		}
+
+		ret = append(ret, &t)
+	}
+
+	return ret, nil
 }
 
 func (c *LocalApiServerCfg) LoadProfiles() error {
@@ -44,28 +84,59 @@ func (c *LocalApiServerCfg) LoadProfiles() error {
 		return err
 	}
 
-	reader := bytes.NewReader(fcontent)
+	profiles, err := DecodeProfiles(bytes.NewReader(fcontent), c.ProfilesDryRun)
+	if err != nil {
+		return fmt.Errorf("while decoding %s: %w", c.ProfilesPath, err)
+	}
 
-	dec := yaml.NewDecoder(reader)
-	dec.KnownFields(true)
+	c.Profiles = append(c.Profiles, profiles...)
 
-	for {
-		t := ProfileCfg{}
+	if c.ManagedProfilesDir != "" {
+		if err := c.loadManagedProfiles(); err != nil {
+			return err
+		}
+	}
+
+	if len(c.Profiles) == 0 {
+		return errors.New("zero profiles loaded for LAPI")
+	}
+
+	return nil
+}
+
+// loadManagedProfiles appends the profiles pushed by the console. A local profile with the same
+// name takes precedence.
+func (c *LocalApiServerCfg) loadManagedProfiles() error {
+	files, err := filepath.Glob(filepath.Join(c.ManagedProfilesDir, "*.yaml"))
+	if err != nil {
+		return fmt.Errorf("while globbing managed profiles: %w", err)
+	}
 
-		err = dec.Decode(&t)
+	names := map[string]bool{}
+	for _, p := range c.Profiles {
+		names[p.Name] = true
+	}
+
+	for _, file := range files {
+		fcontent, err := os.ReadFile(file)
 		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			}
+			return err
+		}
 
-			return fmt.Errorf("while decoding %s: %w", c.ProfilesPath, err)
+		profiles, err := DecodeProfiles(bytes.NewReader(fcontent), c.ProfilesDryRun)
+		if err != nil {
+			return fmt.Errorf("while decoding %s: %w", file, err)
 		}
 
-		c.Profiles = append(c.Profiles, &t)
-	}
+		for _, p := range profiles {
+			if names[p.Name] {
+				log.Warningf("managed profile %q (%s) is overridden by a local profile of the same name", p.Name, file)
+				continue
+			}
 
-	if len(c.Profiles) == 0 {
-		return errors.New("zero profiles loaded for LAPI")
+			names[p.Name] = true
+			c.Profiles = append(c.Profiles, p)
+		}
 	}
 
 	return nil
@@ -0,0 +1,90 @@
+package csconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScenarioOverride replaces the thresholds of a scenario, without modifying the hub file.
+type ScenarioOverride struct {
+	Capacity  *int    `yaml:"capacity,omitempty"`
+	LeakSpeed *string `yaml:"leakspeed,omitempty"`
+	Duration  *string `yaml:"duration,omitempty"`
+	Blackhole *string `yaml:"blackhole,omitempty"`
+	Cooldown  *string `yaml:"cooldown,omitempty"`
+}
+
+// ScenarioOverrides maps the name of a scenario to its local thresholds.
+type ScenarioOverrides map[string]*ScenarioOverride
+
+func (o *ScenarioOverride) Validate() error {
+	if o.Capacity != nil && *o.Capacity < -1 {
+		return fmt.Errorf("capacity must be positive or -1, got %d", *o.Capacity)
+	}
+
+	durations := []struct {
+		name  string
+		value *string
+	}{
+		{"leakspeed", o.LeakSpeed},
+		{"duration", o.Duration},
+		{"blackhole", o.Blackhole},
+		{"cooldown", o.Cooldown},
+	}
+
+	for _, d := range durations {
+		if d.value == nil {
+			continue
+		}
+
+		if _, err := time.ParseDuration(*d.value); err != nil {
+			return fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+	}
+
+	return nil
+}
+
+// LoadScenarioOverrides reads the local tuning file of the scenarios. A missing file means no override.
+func LoadScenarioOverrides(path string) (ScenarioOverrides, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	var overrides ScenarioOverrides
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&overrides); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("while parsing %s: %w", path, err)
+	}
+
+	for name, o := range overrides {
+		if o == nil {
+			return nil, fmt.Errorf("%s: %s: empty override", path, name)
+		}
+
+		if err := o.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", path, name, err)
+		}
+	}
+
+	return overrides, nil
+}
@@ -0,0 +1,82 @@
+package csconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestLoadScenarioOverrides(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected ScenarioOverrides
+		wantErr  string
+	}{
+		{
+			name:    "empty",
+			content: "",
+		},
+		{
+			name: "valid",
+			content: `crowdsecurity/ssh-bf:
+  capacity: 10
+  leakspeed: 30s
+crowdsecurity/http-probing:
+  blackhole: 5m
+  cooldown: 1m
+  duration: 10s
+`,
+			expected: ScenarioOverrides{
+				"crowdsecurity/ssh-bf":       {Capacity: new(10), LeakSpeed: new("30s")},
+				"crowdsecurity/http-probing": {Blackhole: new("5m"), Cooldown: new("1m"), Duration: new("10s")},
+			},
+		},
+		{
+			name:    "unknown field",
+			content: "crowdsecurity/ssh-bf:\n  filter: 'true'\n",
+			wantErr: "field filter not found in type csconfig.ScenarioOverride",
+		},
+		{
+			name:    "bad duration",
+			content: "crowdsecurity/ssh-bf:\n  leakspeed: 30\n",
+			wantErr: `crowdsecurity/ssh-bf: invalid leakspeed: time: missing unit in duration "30"`,
+		},
+		{
+			name:    "bad capacity",
+			content: "crowdsecurity/ssh-bf:\n  capacity: -2\n",
+			wantErr: "crowdsecurity/ssh-bf: capacity must be positive or -1, got -2",
+		},
+		{
+			name:    "empty override",
+			content: "crowdsecurity/ssh-bf:\n",
+			wantErr: "crowdsecurity/ssh-bf: empty override",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scenario_overrides.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), 0o600))
+
+			overrides, err := LoadScenarioOverrides(path)
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+
+			if tc.wantErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, overrides)
+		})
+	}
+
+	// a missing file means no override
+	overrides, err := LoadScenarioOverrides(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.NoError(t, err)
+	assert.Empty(t, overrides)
+}
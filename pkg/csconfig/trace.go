@@ -0,0 +1,56 @@
+package csconfig
+
+import (
+	"errors"
+	"strings"
+)
+
+// TraceCfg enables the tracing of the events processed by the log processor: the decisions
+// taken on a line, from acquisition to the buckets, are kept in memory under a trace ID
+// and can be retrieved with "cscli trace", from the prometheus endpoint.
+type TraceCfg struct {
+	// number of traces kept, the oldest ones are dropped first
+	BufferSize *int `yaml:"buffer_size,omitempty"`
+	// number of steps recorded for a trace, the next ones are counted but not kept
+	MaxSteps *int `yaml:"max_steps,omitempty"`
+	// only trace the lines of these datasources (acquisition type label, like "file" or
+	// "journalctl"), all of them if empty
+	Sources []string `yaml:"sources,omitempty"`
+	// ratio of the lines that are traced, between 0 and 1
+	SampleRate *float64 `yaml:"sample_rate,omitempty"`
+}
+
+func (t *TraceCfg) Load() error {
+	if t.BufferSize == nil {
+		t.BufferSize = new(1000)
+	}
+
+	if *t.BufferSize <= 0 {
+		return errors.New("buffer_size must be positive")
+	}
+
+	if t.MaxSteps == nil {
+		t.MaxSteps = new(200)
+	}
+
+	if *t.MaxSteps <= 0 {
+		return errors.New("max_steps must be positive")
+	}
+
+	for i, source := range t.Sources {
+		t.Sources[i] = strings.TrimSpace(source)
+		if t.Sources[i] == "" {
+			return errors.New("sources: empty datasource type")
+		}
+	}
+
+	if t.SampleRate == nil {
+		t.SampleRate = new(1.0)
+	}
+
+	if *t.SampleRate <= 0 || *t.SampleRate > 1 {
+		return errors.New("sample_rate must be greater than 0, and at most 1")
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package csconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestTraceLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         TraceCfg
+		expectedErr string
+	}{
+		{
+			name: "defaults",
+			cfg:  TraceCfg{},
+		},
+		{
+			name:        "bad buffer size",
+			cfg:         TraceCfg{BufferSize: new(0)},
+			expectedErr: "buffer_size must be positive",
+		},
+		{
+			name:        "bad max steps",
+			cfg:         TraceCfg{MaxSteps: new(-1)},
+			expectedErr: "max_steps must be positive",
+		},
+		{
+			name:        "empty source",
+			cfg:         TraceCfg{Sources: []string{"file", ""}},
+			expectedErr: "sources: empty datasource type",
+		},
+		{
+			name:        "no sampling",
+			cfg:         TraceCfg{SampleRate: new(0.0)},
+			expectedErr: "sample_rate must be greater than 0, and at most 1",
+		},
+		{
+			name:        "oversampling",
+			cfg:         TraceCfg{SampleRate: new(1.5)},
+			expectedErr: "sample_rate must be greater than 0, and at most 1",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Load()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+
+	cfg := TraceCfg{Sources: []string{" journalctl "}}
+	require.NoError(t, cfg.Load())
+	assert.Equal(t, []string{"journalctl"}, cfg.Sources)
+	assert.Equal(t, 1000, *cfg.BufferSize)
+	assert.Equal(t, 200, *cfg.MaxSteps)
+	assert.InDelta(t, 1.0, *cfg.SampleRate, 0)
+}
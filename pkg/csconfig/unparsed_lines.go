@@ -0,0 +1,53 @@
+package csconfig
+
+import (
+	"errors"
+	"strings"
+)
+
+// UnparsedLinesCfg configures a sink for the lines that were not successfully parsed, to
+// find gaps in the parsers coverage. Each line is written as JSON, with its labels and the
+// stage it failed at, to a file rotated like the log files.
+type UnparsedLinesCfg struct {
+	Path string `yaml:"path"`
+	// only keep the lines of these datasources (acquisition type label, like "file" or
+	// "journalctl"), all of them if empty
+	Sources  []string `yaml:"sources,omitempty"`
+	MaxSize  *int     `yaml:"max_size,omitempty"` // megabytes
+	MaxFiles *int     `yaml:"max_files,omitempty"`
+}
+
+func (u *UnparsedLinesCfg) Load() error {
+	if u.Path == "" {
+		return errors.New("path is required")
+	}
+
+	if err := ensureAbsolutePath(&u.Path); err != nil {
+		return err
+	}
+
+	for i, source := range u.Sources {
+		u.Sources[i] = strings.TrimSpace(source)
+		if u.Sources[i] == "" {
+			return errors.New("sources: empty datasource type")
+		}
+	}
+
+	if u.MaxSize == nil {
+		u.MaxSize = new(100)
+	}
+
+	if *u.MaxSize <= 0 {
+		return errors.New("max_size must be positive")
+	}
+
+	if u.MaxFiles == nil {
+		u.MaxFiles = new(defMaxFiles)
+	}
+
+	if *u.MaxFiles < 0 {
+		return errors.New("max_files can't be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package csconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestUnparsedLinesLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         UnparsedLinesCfg
+		expectedErr string
+	}{
+		{
+			name: "defaults",
+			cfg:  UnparsedLinesCfg{Path: "/var/log/crowdsec_unparsed.log"},
+		},
+		{
+			name:        "no path",
+			cfg:         UnparsedLinesCfg{},
+			expectedErr: "path is required",
+		},
+		{
+			name:        "empty source",
+			cfg:         UnparsedLinesCfg{Path: "/var/log/crowdsec_unparsed.log", Sources: []string{"file", " "}},
+			expectedErr: "sources: empty datasource type",
+		},
+		{
+			name:        "bad size",
+			cfg:         UnparsedLinesCfg{Path: "/var/log/crowdsec_unparsed.log", MaxSize: new(0)},
+			expectedErr: "max_size must be positive",
+		},
+		{
+			name:        "bad files",
+			cfg:         UnparsedLinesCfg{Path: "/var/log/crowdsec_unparsed.log", MaxFiles: new(-1)},
+			expectedErr: "max_files can't be negative",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Load()
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+
+	cfg := UnparsedLinesCfg{Path: "/var/log/crowdsec_unparsed.log", Sources: []string{" journalctl "}}
+	require.NoError(t, cfg.Load())
+	assert.Equal(t, []string{"journalctl"}, cfg.Sources)
+	assert.Equal(t, 100, *cfg.MaxSize)
+	assert.Equal(t, 3, *cfg.MaxFiles)
+}
@@ -1,6 +1,9 @@
 package csnet
 
 import (
+	"net/netip"
+	"strings"
+
 	"github.com/crowdsecurity/crowdsec/pkg/types"
 )
 
@@ -20,6 +23,8 @@ type IP struct {
 type Range struct {
 	Start IP
 	End   IP
+	// the network the range was created from, if it can be represented as such
+	prefix netip.Prefix
 }
 
 func (r Range) Size() int {
@@ -43,5 +48,57 @@ func NewRange(anyIP string) (Range, error) {
 			Addr: end_ip,
 			Sfx:  end_sfx,
 		},
+		prefix: parsePrefix(anyIP),
 	}, nil
 }
+
+// parsePrefix returns the network of an ip (/32 or /128) or range. IPv4-mapped addresses
+// are stored as IPv4 and don't have a usable prefix.
+func parsePrefix(anyIP string) netip.Prefix {
+	var (
+		prefix netip.Prefix
+		err    error
+	)
+
+	if strings.Contains(anyIP, "/") {
+		prefix, err = netip.ParsePrefix(anyIP)
+	} else {
+		var addr netip.Addr
+
+		addr, err = netip.ParseAddr(anyIP)
+		if err == nil {
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+	}
+
+	if err != nil || prefix.Addr().Is4In6() || prefix.Addr().Zone() != "" {
+		return netip.Prefix{}
+	}
+
+	return prefix.Masked()
+}
+
+// Supernets returns the networks that contain the range, from /0 to the range itself.
+// Decisions are set on ips or CIDR networks, so a decision contains the range if and
+// only if it is one of these networks: they can be looked up by exact match on the
+// start and end addresses. Returns nil if the range was not created from a network.
+func (r Range) Supernets() []Range {
+	if !r.prefix.IsValid() {
+		return nil
+	}
+
+	ret := make([]Range, 0, r.prefix.Bits()+1)
+
+	for bits := range r.prefix.Bits() + 1 {
+		network := netip.PrefixFrom(r.prefix.Addr(), bits).Masked()
+
+		sup, err := NewRange(network.String())
+		if err != nil {
+			return nil
+		}
+
+		ret = append(ret, sup)
+	}
+
+	return ret
+}
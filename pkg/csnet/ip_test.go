@@ -205,3 +205,47 @@ func TestAdd2Int(t *testing.T) {
 		}
 	}
 }
+
+func TestSupernets(t *testing.T) {
+	tests := []struct {
+		in_addr  string
+		exp_len  int
+		exp_last string
+	}{
+		{in_addr: "1.2.3.4", exp_len: 33, exp_last: "1.2.3.4"},
+		{in_addr: "1.2.3.4/24", exp_len: 25, exp_last: "1.2.3.0/24"},
+		{in_addr: "2001:db8::1", exp_len: 129, exp_last: "2001:db8::1"},
+		{in_addr: "2001:db8::/32", exp_len: 33, exp_last: "2001:db8::/32"},
+		{in_addr: "::ffff:1.2.3.4", exp_len: 0},
+	}
+
+	for idx, test := range tests {
+		rng, err := NewRange(test.in_addr)
+		if err != nil {
+			t.Fatalf("%d unexpected error : %s", idx, err)
+		}
+
+		supernets := rng.Supernets()
+		if len(supernets) != test.exp_len {
+			t.Fatalf("%d unexpected number of supernets %d != %d", idx, len(supernets), test.exp_len)
+		}
+
+		if test.exp_len == 0 {
+			continue
+		}
+
+		last, err := NewRange(test.exp_last)
+		if err != nil {
+			t.Fatalf("%d unexpected error : %s", idx, err)
+		}
+
+		if supernets[len(supernets)-1] != last {
+			t.Fatalf("%d unexpected last supernet %v != %v", idx, supernets[len(supernets)-1], last)
+		}
+
+		// the first one is the whole address space
+		if supernets[0].Start.Addr > rng.Start.Addr || supernets[0].End.Addr < rng.End.Addr {
+			t.Fatalf("%d first supernet %v does not contain %v", idx, supernets[0], rng)
+		}
+	}
+}
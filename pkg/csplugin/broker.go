@@ -16,6 +16,7 @@ import (
 	"github.com/Masterminds/sprig/v3"
 	"github.com/google/uuid"
 	plugin "github.com/hashicorp/go-plugin"
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/tomb.v2"
 	"gopkg.in/yaml.v2"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/logging"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
 	"github.com/crowdsecurity/crowdsec/pkg/models"
 	"github.com/crowdsecurity/crowdsec/pkg/protobufs"
 )
@@ -420,9 +422,20 @@ func (pb *PluginBroker) pushNotificationsToPlugin(ctx context.Context, pluginNam
 	// make sure we have a default or custom backoff
 	pb.ensureBackoff()
 
+	start := time.Now()
+
 	err = retryWithBackoff(ctx, pluginCfg, logger, func(ctx context.Context) error {
 		return pb.tryNotify(ctx, pluginName, message)
 	}, pb.newBackoff)
+
+	status := "success"
+	if err != nil {
+		status = "failure"
+	}
+
+	metrics.NotificationDeliveries.With(prometheus.Labels{"plugin": pluginName, "status": status}).Inc()
+	metrics.NotificationDeliveryDuration.With(prometheus.Labels{"plugin": pluginName}).Observe(time.Since(start).Seconds())
+
 	if err != nil {
 		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 			logger.Warn("delivery canceled during shutdown")
@@ -434,6 +447,16 @@ func (pb *PluginBroker) pushNotificationsToPlugin(ctx context.Context, pluginNam
 	return err
 }
 
+// Deliver sends alerts to a plugin right away, ignoring its group_wait and group_threshold settings.
+func (pb *PluginBroker) Deliver(ctx context.Context, pluginName string, alerts []*models.Alert) error {
+	// don't retry for nothing
+	if _, ok := pb.pluginConfigByName[pluginName]; !ok {
+		return fmt.Errorf("plugin %q: config not found", pluginName)
+	}
+
+	return pb.pushNotificationsToPlugin(ctx, pluginName, alerts)
+}
+
 func NewPluginConfigList(fin io.Reader) (PluginConfigList, error) {
 	parsedConfigs := make(PluginConfigList, 0)
 
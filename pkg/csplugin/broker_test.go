@@ -10,6 +10,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -19,6 +21,7 @@ import (
 	"github.com/crowdsecurity/go-cs-lib/cstest"
 
 	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
 	"github.com/crowdsecurity/crowdsec/pkg/models"
 )
 
@@ -401,3 +404,33 @@ func (s *PluginSuite) TestBrokerRunSimple() {
 	err = decoder.Decode(&alerts)
 	assert.Equal(t, err, io.EOF)
 }
+
+func (s *PluginSuite) TestBrokerDeliver() {
+	ctx := s.T().Context()
+	t := s.T()
+
+	pb, err := s.InitBroker(ctx, nil)
+	require.NoError(t, err)
+
+	defer os.Remove(s.outFile)
+
+	delivered := testutil.ToFloat64(metrics.NotificationDeliveries.With(prometheus.Labels{"plugin": "dummy_default", "status": "success"}))
+
+	// no Run(): the alert is delivered right away, without waiting for group_wait
+	err = pb.Deliver(ctx, "dummy_default", []*models.Alert{{}})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(s.outFile)
+	require.NoError(t, err)
+
+	var alerts []models.Alert
+
+	err = json.Unmarshal(content, &alerts)
+	require.NoError(t, err)
+	assert.Len(t, alerts, 1)
+
+	assert.InDelta(t, delivered+1, testutil.ToFloat64(metrics.NotificationDeliveries.With(prometheus.Labels{"plugin": "dummy_default", "status": "success"})), 0)
+
+	err = pb.Deliver(ctx, "not_configured", []*models.Alert{{}})
+	cstest.RequireErrorContains(t, err, `plugin "not_configured": config not found`)
+}
@@ -45,6 +45,10 @@ func NewProfile(profilesCfg []*csconfig.ProfileCfg) ([]*Runtime, error) {
 		runtime.RuntimeFilters = make([]*vm.Program, len(profile.Filters))
 		runtime.Cfg = profile
 
+		if profile.IsDryRun() {
+			runtime.Logger.Infof("Profile %s is in dry-run mode, its decisions won't be enforced", profile.Name)
+		}
+
 		if runtime.Cfg.OnSuccess != "" && runtime.Cfg.OnSuccess != "continue" && runtime.Cfg.OnSuccess != "break" {
 			return nil, fmt.Errorf("invalid 'on_success' for '%s': %s", profile.Name, runtime.Cfg.OnSuccess)
 		}
@@ -101,6 +105,10 @@ func (profile *Runtime) GenerateDecisionFromProfile(alert *models.Alert) ([]*mod
 		decision := models.Decision{}
 		/*the reference decision from profile is in simulated mode */
 		if refDecision.Simulated != nil && *refDecision.Simulated {
+			decision.Simulated = new(bool)
+			*decision.Simulated = true
+			/*the profile is in dry-run mode: record the decision, but don't enforce it */
+		} else if profile.Cfg.IsDryRun() {
 			decision.Simulated = new(bool)
 			*decision.Simulated = true
 			/*the event is already in simulation mode */
@@ -127,6 +127,7 @@ func TestEvaluateProfile(t *testing.T) {
 		args                  args
 		expectedDecisionCount int // count of expected decisions
 		expectedDuration      string
+		expectedSimulated     bool
 		expectedMatchStatus   bool
 	}{
 		{
@@ -194,6 +195,22 @@ func TestEvaluateProfile(t *testing.T) {
 			expectedDuration:      "16h",
 			expectedMatchStatus:   true,
 		},
+		{
+			name: "dry run",
+			args: args{
+				profileCfg: &csconfig.ProfileCfg{
+					Filters: []string{"1==1"},
+					Decisions: []models.Decision{
+						{Type: &typ, Scope: &scope, Duration: &duration},
+					},
+					DryRun: &boolTrue,
+				},
+				Alert: &models.Alert{Remediation: true, Scenario: &scenario, Source: &models.Source{Value: &value}},
+			},
+			expectedDecisionCount: 1,
+			expectedSimulated:     true,
+			expectedMatchStatus:   true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -219,6 +236,12 @@ func TestEvaluateProfile(t *testing.T) {
 			if tt.expectedDuration != "" {
 				require.Equal(t, tt.expectedDuration, *got[0].Duration, "The two durations should be the same")
 			}
+
+			if tt.expectedSimulated {
+				for _, decision := range got {
+					require.True(t, *decision.Simulated, "the decision should be simulated")
+				}
+			}
 		})
 	}
 }
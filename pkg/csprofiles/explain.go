@@ -0,0 +1,144 @@
+package csprofiles
+
+import (
+	"fmt"
+
+	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+)
+
+// FilterResult is the outcome of one filter of a profile, for an alert.
+type FilterResult struct {
+	Filter  string `json:"filter"`
+	Matched bool   `json:"matched"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ProfileResult tells how a profile handled an alert: whether it was reached, which filters
+// matched, and the decisions and notifications it would produce.
+type ProfileResult struct {
+	Name          string             `json:"name"`
+	Evaluated     bool               `json:"evaluated"`
+	Matched       bool               `json:"matched"`
+	Filters       []FilterResult     `json:"filters"`
+	Decisions     []*models.Decision `json:"decisions"`
+	Notifications []string           `json:"notifications"`
+	Reason        string             `json:"reason"`
+}
+
+// explainFilters runs each filter of the profile on its own, to tell which ones matched.
+func (profile *Runtime) explainFilters(alert *models.Alert) []FilterResult {
+	ret := make([]FilterResult, 0, len(profile.RuntimeFilters))
+
+	for idx, program := range profile.RuntimeFilters {
+		res := FilterResult{Filter: profile.Cfg.Filters[idx]}
+
+		output, err := exprhelpers.Run(program, map[string]any{"Alert": alert}, profile.Logger, false)
+		if err == nil {
+			out, ok := output.(bool)
+			if !ok {
+				err = fmt.Errorf("unexpected type %T (%v)", output, output)
+			}
+
+			res.Matched = out
+		}
+
+		if err != nil {
+			res.Error = err.Error()
+		}
+
+		ret = append(ret, res)
+	}
+
+	return ret
+}
+
+// Explain runs an alert through the profiles the way the local API does when the alert is
+// pushed, including on_success, on_error and dry_run, without side effects: nothing is
+// written and no notification is sent.
+func Explain(profiles []*Runtime, alert *models.Alert) []ProfileResult {
+	ret := make([]ProfileResult, 0, len(profiles))
+
+	// alerts with decisions (cscli decisions add, imports) are only sent to the notifications
+	withDecisions := len(alert.Decisions) > 0
+
+	stoppedBy := ""
+
+	for _, profile := range profiles {
+		res := ProfileResult{
+			Name:          profile.Cfg.Name,
+			Filters:       []FilterResult{},
+			Decisions:     []*models.Decision{},
+			Notifications: []string{},
+		}
+
+		if stoppedBy != "" {
+			res.Reason = stoppedBy
+			ret = append(ret, res)
+
+			continue
+		}
+
+		res.Evaluated = true
+		res.Filters = profile.explainFilters(alert)
+
+		decisions, matched, err := profile.EvaluateProfile(alert)
+		forceBreak := false
+
+		switch {
+		case err != nil && withDecisions:
+			matched = false
+			res.Reason = fmt.Sprintf("filter error, skipped: %s", err)
+		case err != nil:
+			switch profile.Cfg.OnError {
+			case "apply":
+				matched = true
+				res.Reason = fmt.Sprintf("filter error, applied anyway (on_error: apply): %s", err)
+			case "continue", "ignore":
+				res.Reason = fmt.Sprintf("filter error, ignored (on_error: %s): %s", profile.Cfg.OnError, err)
+			case "break":
+				forceBreak = true
+				res.Reason = fmt.Sprintf("filter error (on_error: break): %s", err)
+			default:
+				matched = false
+				res.Reason = fmt.Sprintf("filter error, the local API would reject the alert: %s", err)
+				stoppedBy = fmt.Sprintf("not evaluated, the alert was rejected by profile %s", profile.Cfg.Name)
+			}
+		}
+
+		if !matched {
+			if res.Reason == "" {
+				res.Reason = "no filter matched"
+			}
+
+			ret = append(ret, res)
+
+			continue
+		}
+
+		res.Matched = true
+		res.Notifications = append(res.Notifications, profile.Cfg.Notifications...)
+
+		if withDecisions {
+			// the decisions of the profile are not applied, only the notifications
+			res.Reason = "matched, the alert already has decisions: notifications only"
+		} else {
+			res.Decisions = append(res.Decisions, decisions...)
+		}
+
+		if res.Reason == "" {
+			res.Reason = "matched"
+		}
+
+		switch {
+		case profile.Cfg.OnSuccess == "break":
+			stoppedBy = fmt.Sprintf("not evaluated, profile %s matched with on_success: break", profile.Cfg.Name)
+		case forceBreak:
+			stoppedBy = fmt.Sprintf("not evaluated, profile %s failed with on_error: break", profile.Cfg.Name)
+		}
+
+		ret = append(ret, res)
+	}
+
+	return ret
+}
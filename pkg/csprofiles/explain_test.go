@@ -0,0 +1,126 @@
+package csprofiles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+)
+
+func TestExplain(t *testing.T) {
+	err := exprhelpers.Init(nil)
+	require.NoError(t, err)
+
+	profiles, err := NewProfile([]*csconfig.ProfileCfg{
+		{
+			Name:      "country",
+			Filters:   []string{`Alert.GetScope() == "Ip"`, `Alert.GetScope() == "Country"`},
+			Decisions: []models.Decision{{Type: &typ, Duration: &duration}},
+			OnSuccess: "break",
+		},
+		{
+			Name:          "notify",
+			Filters:       []string{`Alert.GetScenario() == "ssh-bf"`},
+			Notifications: []string{"slack_default"},
+		},
+		{
+			Name:      "fallback",
+			Filters:   []string{`true`},
+			Decisions: []models.Decision{{Type: &typ, Duration: &duration}},
+		},
+	})
+	require.NoError(t, err)
+
+	alert := &models.Alert{Scenario: &scenario, Source: &models.Source{Scope: &scope, Value: &value}}
+
+	results := Explain(profiles, alert)
+	require.Len(t, results, 3)
+
+	assert.True(t, results[0].Matched)
+	assert.Equal(t, "matched", results[0].Reason)
+	assert.Equal(t, []FilterResult{
+		{Filter: `Alert.GetScope() == "Ip"`, Matched: false},
+		{Filter: `Alert.GetScope() == "Country"`, Matched: true},
+	}, results[0].Filters)
+	require.Len(t, results[0].Decisions, 1)
+	assert.Equal(t, "CH", *results[0].Decisions[0].Value)
+
+	for _, res := range results[1:] {
+		assert.False(t, res.Evaluated)
+		assert.False(t, res.Matched)
+		assert.Equal(t, "not evaluated, profile country matched with on_success: break", res.Reason)
+	}
+
+	// no break: every profile is evaluated
+	otherScenario := "http-probing"
+	ipScope := "Range"
+	alert = &models.Alert{Scenario: &otherScenario, Source: &models.Source{Scope: &ipScope, Value: &value}}
+
+	results = Explain(profiles, alert)
+	require.Len(t, results, 3)
+
+	assert.False(t, results[0].Matched)
+	assert.Equal(t, "no filter matched", results[0].Reason)
+	assert.False(t, results[1].Matched)
+	assert.True(t, results[2].Matched)
+	assert.Len(t, results[2].Decisions, 1)
+
+	// alert with decisions: notifications only
+	alert = &models.Alert{Scenario: &scenario, Source: &models.Source{Scope: &ipScope, Value: &value}, Decisions: []*models.Decision{{}}}
+
+	results = Explain(profiles, alert)
+	require.Len(t, results, 3)
+
+	assert.True(t, results[1].Matched)
+	assert.Equal(t, []string{"slack_default"}, results[1].Notifications)
+	assert.True(t, results[2].Matched)
+	assert.Empty(t, results[2].Decisions)
+	assert.Equal(t, "matched, the alert already has decisions: notifications only", results[2].Reason)
+}
+
+func TestExplainOnError(t *testing.T) {
+	err := exprhelpers.Init(nil)
+	require.NoError(t, err)
+
+	newProfiles := func(onError string) []*Runtime {
+		profiles, err := NewProfile([]*csconfig.ProfileCfg{
+			{
+				Name:      "broken",
+				Filters:   []string{`Alert.Source.Value == "CH"`},
+				Decisions: []models.Decision{{Type: &typ, Duration: &duration}},
+				OnError:   onError,
+			},
+			{
+				Name:    "next",
+				Filters: []string{`true`},
+			},
+		})
+		require.NoError(t, err)
+
+		return profiles
+	}
+
+	// no source: the filter fails
+	alert := &models.Alert{Scenario: &scenario}
+
+	results := Explain(newProfiles("continue"), alert)
+	assert.False(t, results[0].Matched)
+	assert.NotEmpty(t, results[0].Filters[0].Error)
+	assert.Contains(t, results[0].Reason, "filter error, ignored (on_error: continue)")
+	assert.True(t, results[1].Matched)
+
+	results = Explain(newProfiles("apply"), alert)
+	assert.True(t, results[0].Matched)
+	assert.Empty(t, results[0].Decisions)
+	assert.True(t, results[1].Matched)
+
+	results = Explain(newProfiles(""), alert)
+	assert.False(t, results[0].Matched)
+	assert.Contains(t, results[0].Reason, "the local API would reject the alert")
+	assert.False(t, results[1].Evaluated)
+	assert.Equal(t, "not evaluated, the alert was rejected by profile broken", results[1].Reason)
+}
@@ -14,12 +14,11 @@ import (
 	"github.com/crowdsecurity/go-cs-lib/downloader"
 )
 
-// writeEmbeddedContentTo writes the embedded content to the specified path and checks the hash.
-// If the content is base64 encoded, it will be decoded before writing. Call this method only
-// if item.Content if not empty.
-func (i *Item) writeEmbeddedContentTo(destPath, wantHash string) error {
+// EmbeddedContent returns the content of the item from the index (if it was downloaded
+// with --with-content), decoded if it is base64 encoded.
+func (i *Item) EmbeddedContent() ([]byte, error) {
 	if i.Content == "" {
-		return fmt.Errorf("no embedded content for %s", i.Name)
+		return nil, fmt.Errorf("no embedded content for %s", i.Name)
 	}
 
 	content, err := base64.StdEncoding.DecodeString(i.Content)
@@ -27,6 +26,18 @@ func (i *Item) writeEmbeddedContentTo(destPath, wantHash string) error {
 		content = []byte(i.Content)
 	}
 
+	return content, nil
+}
+
+// writeEmbeddedContentTo writes the embedded content to the specified path and checks the hash.
+// If the content is base64 encoded, it will be decoded before writing. Call this method only
+// if item.Content if not empty.
+func (i *Item) writeEmbeddedContentTo(destPath, wantHash string) error {
+	content, err := i.EmbeddedContent()
+	if err != nil {
+		return err
+	}
+
 	dir := filepath.Dir(destPath)
 	reader := bytes.NewReader(content)
 	hash := crypto.SHA256.New()
@@ -8,24 +8,42 @@ package component
 // Built is a map of all the known components, and whether they are built-in or not.
 // This is populated as soon as possible by the respective init() functions
 var Built = map[string]bool{
-	"datasource_appsec":       false,
-	"datasource_cloudwatch":   false,
-	"datasource_docker":       false,
-	"datasource_file":         false,
-	"datasource_journalctl":   false,
-	"datasource_k8s-audit":    false,
-	"datasource_kafka":        false,
-	"datasource_kinesis":      false,
-	"datasource_loki":         false,
-	"datasource_s3":           false,
-	"datasource_syslog":       false,
-	"datasource_wineventlog":  false,
-	"datasource_victorialogs": false,
-	"datasource_http":         false,
-	"cscli_setup":             false,
-	"db_mysql":                false,
-	"db_postgres":             false,
-	"db_sqlite":               false,
+	"datasource_appsec":        false,
+	"datasource_auth0":         false,
+	"datasource_cloudwatch":    false,
+	"datasource_docker":        false,
+	"datasource_docker-events": false,
+	"datasource_ebpf":          false,
+	"datasource_etw":           false,
+	"datasource_exec":          false,
+	"datasource_file":          false,
+	"datasource_gelf":          false,
+	"datasource_journalctl":    false,
+	"datasource_k8s-audit":     false,
+	"datasource_kafka":         false,
+	"datasource_kinesis":       false,
+	"datasource_loki":          false,
+	"datasource_mailbox":       false,
+	"datasource_netflow":       false,
+	"datasource_office365":     false,
+	"datasource_okta":          false,
+	"datasource_proxmox":       false,
+	"datasource_s3":            false,
+	"datasource_salesforce":    false,
+	"datasource_suricata":      false,
+	"datasource_syslog":        false,
+	"datasource_tailscale":     false,
+	"datasource_wineventlog":   false,
+	"datasource_victorialogs":  false,
+	"datasource_vcenter":       false,
+	"datasource_webstatus":     false,
+	"datasource_zeek":          false,
+	"datasource_http":          false,
+	"cscli_setup":              false,
+	"mlscore":                  false,
+	"db_mysql":                 false,
+	"db_postgres":              false,
+	"db_sqlite":                false,
 }
 
 func Register(name string) {
@@ -66,89 +66,18 @@ func handleTimeFilters(param, value string, predicates *[]predicate.Alert) error
 	return nil
 }
 
-func handleAlertIPv4Predicates(rng csnet.Range, contains bool, predicates *[]predicate.Alert) {
-	if contains { // decision contains {start_ip,end_ip}
-		*predicates = append(*predicates, alert.And(
-			alert.HasDecisionsWith(decision.StartIPLTE(rng.Start.Addr)),
-			alert.HasDecisionsWith(decision.EndIPGTE(rng.End.Addr)),
-			alert.HasDecisionsWith(decision.IPSizeEQ(int64(rng.Size()))),
-		))
-	} else { // decision is contained within {start_ip,end_ip}
-		*predicates = append(*predicates, alert.And(
-			alert.HasDecisionsWith(decision.StartIPGTE(rng.Start.Addr)),
-			alert.HasDecisionsWith(decision.EndIPLTE(rng.End.Addr)),
-			alert.HasDecisionsWith(decision.IPSizeEQ(int64(rng.Size()))),
-		))
+func handleAlertIPPredicates(rng csnet.Range, contains bool, predicates *[]predicate.Alert) error {
+	pred, err := decisionIPPredicate(contains, rng)
+	if err != nil {
+		return err
 	}
-}
 
-func handleAlertIPv6Predicates(rng csnet.Range, contains bool, predicates *[]predicate.Alert) {
-	if contains { // decision contains {start_ip,end_ip}
-		*predicates = append(*predicates, alert.And(
-			// matching addr size
-			alert.HasDecisionsWith(decision.IPSizeEQ(int64(rng.Size()))),
-			alert.Or(
-				// decision.start_ip < query.start_ip
-				alert.HasDecisionsWith(decision.StartIPLT(rng.Start.Addr)),
-				alert.And(
-					// decision.start_ip == query.start_ip
-					alert.HasDecisionsWith(decision.StartIPEQ(rng.Start.Addr)),
-					// decision.start_suffix <= query.start_suffix
-					alert.HasDecisionsWith(decision.StartSuffixLTE(rng.Start.Sfx)),
-				),
-			),
-			alert.Or(
-				// decision.end_ip > query.end_ip
-				alert.HasDecisionsWith(decision.EndIPGT(rng.End.Addr)),
-				alert.And(
-					// decision.end_ip == query.end_ip
-					alert.HasDecisionsWith(decision.EndIPEQ(rng.End.Addr)),
-					// decision.end_suffix >= query.end_suffix
-					alert.HasDecisionsWith(decision.EndSuffixGTE(rng.End.Sfx)),
-				),
-			),
-		))
-	} else { // decision is contained within {start_ip,end_ip}
-		*predicates = append(*predicates, alert.And(
-			// matching addr size
-			alert.HasDecisionsWith(decision.IPSizeEQ(int64(rng.Size()))),
-			alert.Or(
-				// decision.start_ip > query.start_ip
-				alert.HasDecisionsWith(decision.StartIPGT(rng.Start.Addr)),
-				alert.And(
-					// decision.start_ip == query.start_ip
-					alert.HasDecisionsWith(decision.StartIPEQ(rng.Start.Addr)),
-					// decision.start_suffix >= query.start_suffix
-					alert.HasDecisionsWith(decision.StartSuffixGTE(rng.Start.Sfx)),
-				),
-			),
-			alert.Or(
-				// decision.end_ip < query.end_ip
-				alert.HasDecisionsWith(decision.EndIPLT(rng.End.Addr)),
-				alert.And(
-					// decision.end_ip == query.end_ip
-					alert.HasDecisionsWith(decision.EndIPEQ(rng.End.Addr)),
-					// decision.end_suffix <= query.end_suffix
-					alert.HasDecisionsWith(decision.EndSuffixLTE(rng.End.Sfx)),
-				),
-			),
-		))
+	if pred != nil {
+		// the conditions must hold for the same decision
+		*predicates = append(*predicates, alert.HasDecisionsWith(pred))
 	}
-}
 
-func handleAlertIPPredicates(rng csnet.Range, contains bool, predicates *[]predicate.Alert) error {
-	switch rng.Size() {
-	case 4:
-		handleAlertIPv4Predicates(rng, contains, predicates)
-		return nil
-	case 16:
-		handleAlertIPv6Predicates(rng, contains, predicates)
-		return nil
-	case 0:
-		return nil
-	default:
-		return fmt.Errorf("unknown ip size %d: %w", rng.Size(), InvalidFilter)
-	}
+	return nil
 }
 
 func handleIncludeCapiFilter(value string, predicates *[]predicate.Alert) error {
@@ -15,6 +15,7 @@ import (
 	"github.com/crowdsecurity/go-cs-lib/cstime"
 	"github.com/crowdsecurity/go-cs-lib/slicetools"
 
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/csnet"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/alert"
@@ -148,6 +149,8 @@ func (c *Client) CreateOrUpdateAlert(ctx context.Context, machineID string, aler
 			alertTime = time.Now()
 		}
 
+		decisionDuration = c.DecisionDurations.Apply(*decisionItem.Origin, decisionDuration)
+
 		decisionUntil := alertTime.UTC().Add(decisionDuration)
 
 		decisionBuilder := c.Ent.Decision.Create().
@@ -283,6 +286,8 @@ func (c *Client) UpdateCommunityBlocklist(ctx context.Context, alertItem *models
 			return 0, 0, 0, rollbackOnError(txClient, err, "parsing decision duration")
 		}
 
+		duration = c.DecisionDurations.Apply(*decisionItem.Origin, duration)
+
 		if decisionItem.Scope == nil {
 			log.Warning("nil scope in community decision")
 			continue
@@ -377,6 +382,8 @@ func (c *Client) createDecisionBatch(ctx context.Context, client *ent.Client, si
 			return nil, fmt.Errorf("decision duration '%+v': %w: %w", *decisionItem.Duration, err, ParseDurationFail)
 		}
 
+		duration = c.DecisionDurations.Apply(*decisionItem.Origin, duration)
+
 		// if the scope is IP or Range, convert the value to integers
 		if strings.ToLower(*decisionItem.Scope) == "ip" || strings.ToLower(*decisionItem.Scope) == "range" {
 			rng, err = csnet.NewRange(*decisionItem.Value)
@@ -386,6 +393,9 @@ func (c *Client) createDecisionBatch(ctx context.Context, client *ent.Client, si
 			}
 		}
 
+		// the decision itself can be in simulation mode (profile in dry-run mode), even if the alert is not
+		decisionSimulated := simulated || (decisionItem.Simulated != nil && *decisionItem.Simulated)
+
 		newDecision := client.Decision.Create().
 			SetUntil(stopAtTime.Add(duration)).
 			SetScenario(*decisionItem.Scenario).
@@ -398,7 +408,7 @@ func (c *Client) createDecisionBatch(ctx context.Context, client *ent.Client, si
 			SetValue(*decisionItem.Value).
 			SetScope(*decisionItem.Scope).
 			SetOrigin(*decisionItem.Origin).
-			SetSimulated(simulated).
+			SetSimulated(decisionSimulated).
 			SetUUID(decisionItem.UUID)
 
 		decisionCreate = append(decisionCreate, newDecision)
@@ -436,7 +446,30 @@ func parseAlertTimes(alert *models.Alert, logger log.FieldLogger) (time.Time, ti
 	return start, stop
 }
 
-func buildEventCreates(ctx context.Context, logger log.FieldLogger, client *ent.Client, machineID string, alertItem *models.Alert) ([]*ent.Event, error) {
+// anonymizeMeta returns a copy of the meta of an event with the values anonymized according to the configuration.
+// The meta of the alert is not modified, as it's also sent to CAPI and the notification plugins.
+func anonymizeMeta(meta models.Meta, anonymization csconfig.EventAnonymizationsCfg) models.Meta {
+	if len(anonymization) == 0 {
+		return meta
+	}
+
+	ret := make(models.Meta, 0, len(meta))
+
+	for _, item := range meta {
+		if item == nil {
+			continue
+		}
+
+		ret = append(ret, &models.MetaItems0{
+			Key:   item.Key,
+			Value: anonymization.Apply(item.Key, item.Value),
+		})
+	}
+
+	return ret
+}
+
+func buildEventCreates(ctx context.Context, logger log.FieldLogger, client *ent.Client, machineID string, alertItem *models.Alert, anonymization csconfig.EventAnonymizationsCfg) ([]*ent.Event, error) {
 	// let's track when we strip or drop data, notify outside of loop to avoid spam
 	stripped := false
 	dropped := false
@@ -455,9 +488,11 @@ func buildEventCreates(ctx context.Context, logger log.FieldLogger, client *ent.
 			ts = time.Now().UTC()
 		}
 
-		marshallMetas, err := json.Marshal(eventItem.Meta)
+		eventMeta := anonymizeMeta(eventItem.Meta, anonymization)
+
+		marshallMetas, err := json.Marshal(eventMeta)
 		if err != nil {
-			return nil, fmt.Errorf("event meta '%v': %w: %w", eventItem.Meta, err, MarshalFail)
+			return nil, fmt.Errorf("event meta '%v': %w: %w", eventMeta, err, MarshalFail)
 		}
 
 		// the serialized field is too big, let's try to progressively strip it
@@ -468,15 +503,15 @@ func buildEventCreates(ctx context.Context, logger log.FieldLogger, client *ent.
 			stripSize := 2048
 
 			for !valid && stripSize > 0 {
-				for _, serializedItem := range eventItem.Meta {
+				for _, serializedItem := range eventMeta {
 					if len(serializedItem.Value) > stripSize*2 {
 						serializedItem.Value = serializedItem.Value[:stripSize] + "<stripped>"
 					}
 				}
 
-				marshallMetas, err = json.Marshal(eventItem.Meta)
+				marshallMetas, err = json.Marshal(eventMeta)
 				if err != nil {
-					return nil, fmt.Errorf("event meta '%v': %w: %w", eventItem.Meta, err, MarshalFail)
+					return nil, fmt.Errorf("event meta '%v': %w: %w", eventMeta, err, MarshalFail)
 				}
 
 				if event.SerializedValidator(string(marshallMetas)) == nil {
@@ -542,8 +577,13 @@ func buildMetaCreates(ctx context.Context, logger log.FieldLogger, client *ent.C
 }
 
 func (c *Client) buildDecisions(ctx context.Context, logger log.FieldLogger, client *ent.Client, alertItem *models.Alert, stopAtTime time.Time) ([]*ent.Decision, int, error) {
+	items, err := c.applyDecisionBudgets(ctx, logger, client, *alertItem.Simulated, alertItem.Decisions)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	decisions := []*ent.Decision{}
-	if err := slicetools.Batch(ctx, alertItem.Decisions, c.decisionBulkSize, func(ctx context.Context, part []*models.Decision) error {
+	if err := slicetools.Batch(ctx, items, c.decisionBulkSize, func(ctx context.Context, part []*models.Decision) error {
 		ret, err := c.createDecisionBatch(ctx, client, *alertItem.Simulated, stopAtTime, part)
 		if err != nil {
 			return fmt.Errorf("creating alert decisions: %w", err)
@@ -554,7 +594,8 @@ func (c *Client) buildDecisions(ctx context.Context, logger log.FieldLogger, cli
 		return nil, 0, err
 	}
 
-	discarded := len(alertItem.Decisions) - len(decisions)
+	// the decisions dropped by a budget are not counted: the alert is kept even if it has no decision left
+	discarded := len(items) - len(decisions)
 	if discarded > 0 {
 		logger.Warningf("discarded %d decisions for %s", discarded, alertItem.UUID)
 	}
@@ -648,7 +689,7 @@ func (c *Client) createAlertBatch(ctx context.Context, machineID string, owner *
 			c.Log.Info(disp)
 		}
 
-		events, err := buildEventCreates(ctx, c.Log, txEnt, machineID, alertItem)
+		events, err := buildEventCreates(ctx, c.Log, txEnt, machineID, alertItem, c.EventAnonymization)
 		if err != nil {
 			return nil, rollbackOnError(tx, err, fmt.Sprintf("building events for alert %s", alertItem.UUID))
 		}
@@ -693,6 +734,7 @@ func (c *Client) createAlertBatch(ctx context.Context, machineID string, owner *
 			SetRemediation(alertItem.Remediation).
 			SetUUID(alertItem.UUID).
 			SetKind(alertItem.Kind).
+			SetLabels(alertItem.Labels).
 			AddEvents(events...).
 			AddMetas(metas...)
 
@@ -789,6 +831,67 @@ func (c *Client) AlertsCountPerScenario(ctx context.Context, filter map[string][
 	return counts, nil
 }
 
+// AlertExistsSince returns true if an alert of the scenario, with the given source value, was created after since.
+func (c *Client) AlertExistsSince(ctx context.Context, scenario string, sourceValue string, since time.Time) (bool, error) {
+	exists, err := c.Ent.Alert.Query().
+		Where(alert.ScenarioEQ(scenario), alert.SourceValueEQ(sourceValue), alert.CreatedAtGT(since)).
+		Exist(ctx)
+	if err != nil {
+		return false, fmt.Errorf("querying alerts of %s: %w: %w", scenario, err, QueryFail)
+	}
+
+	return exists, nil
+}
+
+// alertsByIPQuery selects the alerts on an IP created after since, with or without decisions:
+// simulated alerts, or alerts of allowlisted IPs, are included.
+func (c *Client) alertsByIPQuery(ip string, since time.Time) *ent.AlertQuery {
+	return c.Ent.Alert.Query().
+		Where(alert.Or(alert.SourceIpEQ(ip), alert.SourceValueEQ(ip)), alert.CreatedAtGT(since))
+}
+
+func (c *Client) CountAlertsByIP(ctx context.Context, ip string, since time.Time) (int, error) {
+	count, err := c.alertsByIPQuery(ip, since).Count(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("counting alerts of %s: %w: %w", ip, err, QueryFail)
+	}
+
+	return count, nil
+}
+
+// AlertScenariosByIP returns the distinct scenarios of the alerts on an IP created after since, sorted.
+func (c *Client) AlertScenariosByIP(ctx context.Context, ip string, since time.Time) ([]string, error) {
+	scenarios, err := c.alertsByIPQuery(ip, since).
+		Unique(true).
+		Order(ent.Asc(alert.FieldScenario)).
+		Select(alert.FieldScenario).
+		Strings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("querying alerts of %s: %w: %w", ip, err, QueryFail)
+	}
+
+	return scenarios, nil
+}
+
+// ListAlertsToExport returns, in order, at most limit alerts with an ID greater than afterID and
+// created before the given time, with their decisions, events and metas.
+func (c *Client) ListAlertsToExport(ctx context.Context, afterID int, before time.Time, limit int) ([]*ent.Alert, error) {
+	alerts, err := c.Ent.Alert.Query().
+		Where(alert.IDGT(afterID), alert.CreatedAtLT(before)).
+		Order(ent.Asc(alert.FieldID)).
+		Limit(limit).
+		WithDecisions().
+		WithEvents().
+		WithMetas().
+		WithOwner().
+		All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("querying alerts to export: %w: %w", err, QueryFail)
+	}
+
+	return alerts, nil
+}
+
 func (c *Client) TotalAlerts(ctx context.Context) (int, error) {
 	return c.Ent.Alert.Query().Count(ctx)
 }
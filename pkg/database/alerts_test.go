@@ -0,0 +1,354 @@
+package database
+
+import (
+	"fmt"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstime"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/decision"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+)
+
+func makeDecisionAlert(origin string, value string, duration string) *models.Alert {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	return &models.Alert{
+		Capacity:        new(int32(0)),
+		Scenario:        new("test/scenario"),
+		ScenarioVersion: new(""),
+		ScenarioHash:    new(""),
+		Message:         new("test"),
+		Leakspeed:       new(""),
+		EventsCount:     new(int32(1)),
+		Simulated:       new(false),
+		StartAt:         &now,
+		StopAt:          &now,
+		Source: &models.Source{
+			Scope: new("Ip"),
+			Value: &value,
+			IP:    value,
+		},
+		Decisions: []*models.Decision{
+			{
+				Duration: &duration,
+				Origin:   &origin,
+				Scenario: new("test/scenario"),
+				Scope:    new("Ip"),
+				Value:    &value,
+				Type:     new("ban"),
+			},
+		},
+	}
+}
+
+func TestDecisionDurations(t *testing.T) {
+	ctx := t.Context()
+	dbClient := getDBClient(t, ctx)
+
+	dbClient.DecisionDurations = csconfig.DecisionDurationsCfg{
+		{Origin: CapiMachineID, MaxDuration: cstime.DurationWithDays(24 * time.Hour)},
+		{Origin: "cscli", MinDuration: cstime.DurationWithDays(7 * 24 * time.Hour)},
+	}
+
+	start := time.Now().UTC()
+
+	_, err := dbClient.CreateAlert(ctx, "", []*models.Alert{
+		makeDecisionAlert("cscli", "1.2.3.4", "4h"),
+		makeDecisionAlert("crowdsec", "1.2.3.5", "4h"),
+	})
+	require.NoError(t, err)
+
+	_, _, _, err = dbClient.UpdateCommunityBlocklist(ctx, makeDecisionAlert(CapiMachineID, "1.2.3.6", "168h"))
+	require.NoError(t, err)
+
+	decisions, err := dbClient.Ent.Decision.Query().All(ctx)
+	require.NoError(t, err)
+	require.Len(t, decisions, 3)
+
+	expected := map[string]time.Duration{
+		"1.2.3.4": 7 * 24 * time.Hour,
+		"1.2.3.5": 4 * time.Hour,
+		"1.2.3.6": 24 * time.Hour,
+	}
+
+	for _, d := range decisions {
+		assert.WithinDuration(t, start.Add(expected[d.Value]), *d.Until, 5*time.Second, d.Value)
+	}
+}
+
+func TestCapDecisionDurations(t *testing.T) {
+	ctx := t.Context()
+	dbClient := getDBClient(t, ctx)
+	now := time.Now().UTC()
+
+	// inserted before the setting
+	longCAPI := createTestDecision(t, ctx, dbClient, "1.2.3.4", "ban", CapiMachineID, now.Add(-time.Hour), now.Add(6*24*time.Hour))
+	pastCap := createTestDecision(t, ctx, dbClient, "1.2.3.5", "ban", CapiMachineID, now.Add(-48*time.Hour), now.Add(time.Hour))
+	shortCAPI := createTestDecision(t, ctx, dbClient, "1.2.3.6", "ban", CapiMachineID, now, now.Add(time.Hour))
+	other := createTestDecision(t, ctx, dbClient, "1.2.3.7", "ban", "crowdsec", now.Add(-48*time.Hour), now.Add(48*time.Hour))
+
+	dbClient.DecisionDurations = csconfig.DecisionDurationsCfg{
+		{Origin: CapiMachineID, MaxDuration: cstime.DurationWithDays(24 * time.Hour)},
+		{Origin: "cscli", MinDuration: cstime.DurationWithDays(7 * 24 * time.Hour)},
+	}
+
+	capped, err := dbClient.CapDecisionDurations(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, capped)
+
+	expected := map[int]time.Time{
+		longCAPI:  now.Add(23 * time.Hour),
+		pastCap:   now,
+		shortCAPI: now.Add(time.Hour),
+		other:     now.Add(48 * time.Hour),
+	}
+
+	for id, until := range expected {
+		d, err := dbClient.Ent.Decision.Get(ctx, id)
+		require.NoError(t, err)
+		assert.WithinDuration(t, until, *d.Until, time.Second, d.Value)
+	}
+
+	// already applied
+	capped, err = dbClient.CapDecisionDurations(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, capped)
+}
+
+func TestEventAnonymization(t *testing.T) {
+	ctx := t.Context()
+	dbClient := getDBClient(t, ctx)
+
+	dbClient.EventAnonymization = csconfig.EventAnonymizationsCfg{
+		{Key: "source_ip", Mode: csconfig.AnonymizeTruncate},
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	alertItem := makeDecisionAlert("crowdsec", "1.2.3.4", "4h")
+	alertItem.Events = []*models.Event{
+		{
+			Timestamp: &now,
+			Meta: models.Meta{
+				{Key: "source_ip", Value: "1.2.3.4"},
+				{Key: "log_type", Value: "ssh_failed-auth"},
+			},
+		},
+	}
+
+	_, err := dbClient.CreateAlert(ctx, "", []*models.Alert{alertItem})
+	require.NoError(t, err)
+
+	// the alert sent to CAPI and the plugins is not modified
+	assert.Equal(t, "1.2.3.4", alertItem.Events[0].Meta[0].Value)
+
+	events, err := dbClient.Ent.Event.Query().All(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.JSONEq(t, `[{"key":"source_ip","value":"1.2.3.0"},{"key":"log_type","value":"ssh_failed-auth"}]`, events[0].Serialized)
+
+	// the decisions keep the full IP
+	decisions, err := dbClient.Ent.Decision.Query().All(ctx)
+	require.NoError(t, err)
+	require.Len(t, decisions, 1)
+	assert.Equal(t, "1.2.3.4", decisions[0].Value)
+}
+
+func TestDecisionBudgets(t *testing.T) {
+	ctx := t.Context()
+	dbClient := getDBClient(t, ctx)
+
+	dbClient.DecisionBudgets = csconfig.DecisionBudgetsCfg{
+		{Scenario: "test/*", MaxActive: 2, Action: csconfig.DecisionBudgetDrop},
+		{Scenario: "other/scenario", MaxActive: 1, Action: csconfig.DecisionBudgetSimulate},
+	}
+
+	alerts := []*models.Alert{
+		makeDecisionAlert("crowdsec", "1.2.3.1", "4h"),
+		makeDecisionAlert("crowdsec", "1.2.3.2", "4h"),
+		makeDecisionAlert("crowdsec", "1.2.3.3", "4h"),
+		makeDecisionAlert("crowdsec", "1.2.3.4", "4h"),
+	}
+
+	// a dry-run decision is not counted
+	alerts[0].Decisions[0].Simulated = new(true)
+
+	for _, value := range []string{"1.2.3.7", "1.2.3.8"} {
+		a := makeDecisionAlert("crowdsec", value, "4h")
+		a.Scenario = new("other/scenario")
+		a.Decisions[0].Scenario = new("other/scenario")
+		alerts = append(alerts, a)
+	}
+
+	_, err := dbClient.CreateAlert(ctx, "", alerts)
+	require.NoError(t, err)
+
+	// the 4th alert exceeded the budget of its scenario, it's kept without its decision
+	count, err := dbClient.Ent.Alert.Query().Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 6, count)
+
+	count, err = dbClient.Ent.Decision.Query().Where(decision.ValueEQ("1.2.3.4")).Count(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	enforced, err := dbClient.Ent.Decision.Query().Where(decision.SimulatedEQ(false)).Order(decision.ByValue()).All(ctx)
+	require.NoError(t, err)
+	require.Len(t, enforced, 3)
+	assert.Equal(t, "1.2.3.2", enforced[0].Value)
+	assert.Equal(t, "1.2.3.3", enforced[1].Value)
+	assert.Equal(t, "1.2.3.7", enforced[2].Value)
+
+	simulated, err := dbClient.Ent.Decision.Query().Where(decision.SimulatedEQ(true)).Order(decision.ByValue()).All(ctx)
+	require.NoError(t, err)
+	require.Len(t, simulated, 2)
+	assert.Equal(t, "1.2.3.1", simulated[0].Value)
+	assert.Equal(t, "1.2.3.8", simulated[1].Value)
+
+	// the alert is not modified
+	assert.Nil(t, alerts[5].Decisions[0].Simulated)
+
+	// the budget is shared by the alerts of the following requests
+	_, err = dbClient.CreateAlert(ctx, "", []*models.Alert{makeDecisionAlert("crowdsec", "1.2.3.9", "4h")})
+	require.NoError(t, err)
+
+	count, err = dbClient.Ent.Decision.Query().Where(decision.ValueEQ("1.2.3.9")).Count(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestSimulatedDecision(t *testing.T) {
+	ctx := t.Context()
+	dbClient := getDBClient(t, ctx)
+
+	since := time.Now().UTC().Add(-time.Minute)
+
+	enforced := makeDecisionAlert("crowdsec", "1.2.3.4", "4h")
+	dryRun := makeDecisionAlert("crowdsec", "1.2.3.5", "4h")
+	dryRun.Decisions[0].Simulated = new(true)
+
+	_, err := dbClient.CreateAlert(ctx, "", []*models.Alert{enforced, dryRun})
+	require.NoError(t, err)
+
+	simulated, err := dbClient.Ent.Decision.Query().Where(decision.SimulatedEQ(true)).All(ctx)
+	require.NoError(t, err)
+	require.Len(t, simulated, 1)
+	assert.Equal(t, "1.2.3.5", simulated[0].Value)
+
+	// the decisions in simulation mode are not streamed to the bouncers
+	decisions, err := dbClient.QueryNewDecisionsSinceWithFilters(ctx, time.Now().UTC(), &since, map[string][]string{})
+	require.NoError(t, err)
+	require.Len(t, decisions, 1)
+	assert.Equal(t, "1.2.3.4", decisions[0].Value)
+}
+
+func TestIPFilters(t *testing.T) {
+	ctx := t.Context()
+	dbClient := getDBClient(t, ctx)
+
+	_, err := dbClient.CreateAlert(ctx, "", []*models.Alert{
+		makeDecisionAlert("cscli", "1.2.3.4", "4h"),
+		makeDecisionAlert("cscli", "1.2.3.0/24", "4h"),
+		makeDecisionAlert("cscli", "1.2.0.0/16", "4h"),
+		makeDecisionAlert("cscli", "1.2.4.0/24", "4h"),
+		makeDecisionAlert("cscli", "2001:db8::1", "4h"),
+		makeDecisionAlert("cscli", "2001:db8::/32", "4h"),
+		makeDecisionAlert("cscli", "2001:db9::/32", "4h"),
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		filter   map[string][]string
+		expected []string
+	}{
+		{
+			filter:   map[string][]string{"ip": {"1.2.3.4"}},
+			expected: []string{"1.2.3.4", "1.2.3.0/24", "1.2.0.0/16"},
+		},
+		{
+			filter:   map[string][]string{"ip": {"1.2.3.5"}},
+			expected: []string{"1.2.3.0/24", "1.2.0.0/16"},
+		},
+		{
+			filter:   map[string][]string{"range": {"1.2.3.0/24"}},
+			expected: []string{"1.2.3.0/24", "1.2.0.0/16"},
+		},
+		{
+			filter:   map[string][]string{"range": {"1.2.3.0/24"}, "contains": {"false"}},
+			expected: []string{"1.2.3.4", "1.2.3.0/24"},
+		},
+		{
+			filter:   map[string][]string{"range": {"1.0.0.0/8"}, "contains": {"false"}},
+			expected: []string{"1.2.3.4", "1.2.3.0/24", "1.2.0.0/16", "1.2.4.0/24"},
+		},
+		{
+			filter:   map[string][]string{"ip": {"5.5.5.5"}},
+			expected: []string{},
+		},
+		{
+			filter:   map[string][]string{"ip": {"2001:db8::1"}},
+			expected: []string{"2001:db8::1", "2001:db8::/32"},
+		},
+		{
+			filter:   map[string][]string{"ip": {"2001:db8::2"}},
+			expected: []string{"2001:db8::/32"},
+		},
+		{
+			filter:   map[string][]string{"range": {"2001:db8::/16"}, "contains": {"false"}},
+			expected: []string{"2001:db8::1", "2001:db8::/32", "2001:db9::/32"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprint(tc.filter), func(t *testing.T) {
+			// the filter is consumed by the queries
+			alertFilter := maps.Clone(tc.filter)
+
+			decisions, err := dbClient.QueryDecisionWithFilter(ctx, maps.Clone(tc.filter))
+			require.NoError(t, err)
+
+			values := make([]string, 0, len(decisions))
+			for _, d := range decisions {
+				values = append(values, d.Value)
+			}
+
+			assert.ElementsMatch(t, tc.expected, values)
+
+			alerts, err := dbClient.QueryAlertWithFilter(ctx, alertFilter)
+			require.NoError(t, err)
+
+			values = make([]string, 0, len(alerts))
+			for _, a := range alerts {
+				values = append(values, a.SourceValue)
+			}
+
+			assert.ElementsMatch(t, tc.expected, values)
+		})
+	}
+}
+
+func TestAlertLabels(t *testing.T) {
+	ctx := t.Context()
+	dbClient := getDBClient(t, ctx)
+
+	alert := makeDecisionAlert("crowdsec", "1.2.3.4", "4h")
+	alert.Labels = []string{"classification:attack.T1110", "behavior:ssh:bruteforce", "confidence:3"}
+
+	_, err := dbClient.CreateAlert(ctx, "", []*models.Alert{alert, makeDecisionAlert("crowdsec", "1.2.3.5", "4h")})
+	require.NoError(t, err)
+
+	dec, err := dbClient.Ent.Decision.Query().Where(decision.ValueEQ("1.2.3.4")).WithOwner().Only(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, alert.Labels, dec.Edges.Owner.Labels)
+
+	dec, err = dbClient.Ent.Decision.Query().Where(decision.ValueEQ("1.2.3.5")).WithOwner().Only(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, dec.Edges.Owner.Labels)
+}
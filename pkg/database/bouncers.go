@@ -3,14 +3,22 @@ package database
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/bouncer"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/schema"
 	"github.com/crowdsecurity/crowdsec/pkg/models"
 )
 
+// number of entries kept in the pull and version histories of a bouncer
+const (
+	bouncerPullHistorySize    = 20
+	bouncerVersionHistorySize = 10
+)
+
 type BouncerNotFoundError struct {
 	BouncerName string
 }
@@ -129,12 +137,38 @@ func (c *Client) BulkDeleteBouncers(ctx context.Context, bouncers []*ent.Bouncer
 	return nbDeleted, nil
 }
 
-func (c *Client) UpdateBouncerLastPull(ctx context.Context, lastPull time.Time, id int) error {
-	_, err := c.Ent.Bouncer.UpdateOneID(id).
-		SetLastPull(lastPull).
-		Save(ctx)
-	if err != nil {
-		return fmt.Errorf("unable to update machine last pull in database: %w", err)
+// appendHistory returns a copy of history with item appended, keeping at most size items.
+func appendHistory[T any](history []T, item T, size int) []T {
+	ret := append(slices.Clone(history), item)
+	if len(ret) > size {
+		ret = ret[len(ret)-size:]
+	}
+
+	return ret
+}
+
+// UpdateBouncerPull records a pull of the decisions in the history of the bouncer.
+// The last pull time, used to compute the next delta, is only updated if lastPull is not nil.
+func (c *Client) UpdateBouncerPull(ctx context.Context, b *ent.Bouncer, lastPull *time.Time, pull schema.BouncerPull) error {
+	update := c.Ent.Bouncer.UpdateOneID(b.ID).
+		SetPullHistory(appendHistory(b.PullHistory, pull, bouncerPullHistorySize))
+
+	if lastPull != nil {
+		update = update.SetLastPull(*lastPull)
+	}
+
+	if _, err := update.Save(ctx); err != nil {
+		return fmt.Errorf("unable to update bouncer pull in database: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateBouncerLastPull sets the last pull time of a bouncer, without recording a pull in its history.
+// It's used for the bouncers that keep a stream open.
+func (c *Client) UpdateBouncerLastPull(ctx context.Context, id int, lastPull time.Time) error {
+	if _, err := c.Ent.Bouncer.UpdateOneID(id).SetLastPull(lastPull).Save(ctx); err != nil {
+		return fmt.Errorf("unable to update bouncer last pull in database: %w", err)
 	}
 
 	return nil
@@ -149,8 +183,18 @@ func (c *Client) UpdateBouncerIP(ctx context.Context, ipAddr string, id int) err
 	return nil
 }
 
-func (c *Client) UpdateBouncerTypeAndVersion(ctx context.Context, bType string, version string, id int) error {
-	_, err := c.Ent.Bouncer.UpdateOneID(id).SetVersion(version).SetType(bType).Save(ctx)
+func (c *Client) UpdateBouncerTypeAndVersion(ctx context.Context, bType string, version string, b *ent.Bouncer) error {
+	versionHistory := appendHistory(b.VersionHistory, schema.BouncerVersion{
+		Type:      bType,
+		Version:   version,
+		FirstSeen: time.Now().UTC(),
+	}, bouncerVersionHistorySize)
+
+	_, err := c.Ent.Bouncer.UpdateOneID(b.ID).
+		SetVersion(version).
+		SetType(bType).
+		SetVersionHistory(versionHistory).
+		Save(ctx)
 	if err != nil {
 		return fmt.Errorf("unable to update bouncer type and version in database: %w", err)
 	}
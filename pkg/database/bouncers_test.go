@@ -0,0 +1,89 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/schema"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+)
+
+func TestUpdateBouncerPull(t *testing.T) {
+	ctx := t.Context()
+	dbClient := getDBClient(t, ctx)
+
+	b, err := dbClient.CreateBouncer(ctx, "test", "127.0.0.1", "key", types.ApiKeyAuthType, false)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// a partial pull (e.g. a page of the startup snapshot) doesn't move the last pull
+	err = dbClient.UpdateBouncerPull(ctx, b, nil, schema.BouncerPull{Time: now, Endpoint: "stream", Startup: true, New: 3})
+	require.NoError(t, err)
+
+	b, err = dbClient.SelectBouncerByName(ctx, "test")
+	require.NoError(t, err)
+	assert.Nil(t, b.LastPull)
+	require.Len(t, b.PullHistory, 1)
+	assert.Equal(t, 3, b.PullHistory[0].New)
+	assert.True(t, b.PullHistory[0].Startup)
+
+	for i := range bouncerPullHistorySize + 5 {
+		err = dbClient.UpdateBouncerPull(ctx, b, &now, schema.BouncerPull{Time: now, Endpoint: "stream", Deleted: i})
+		require.NoError(t, err)
+
+		b, err = dbClient.SelectBouncerByName(ctx, "test")
+		require.NoError(t, err)
+	}
+
+	require.NotNil(t, b.LastPull)
+	assert.True(t, now.Equal(*b.LastPull))
+	require.Len(t, b.PullHistory, bouncerPullHistorySize)
+	// the oldest entries are dropped first
+	assert.Equal(t, 5, b.PullHistory[0].Deleted)
+	assert.Equal(t, bouncerPullHistorySize+4, b.PullHistory[bouncerPullHistorySize-1].Deleted)
+}
+
+func TestUpdateBouncerLastPull(t *testing.T) {
+	ctx := t.Context()
+	dbClient := getDBClient(t, ctx)
+
+	b, err := dbClient.CreateBouncer(ctx, "test", "127.0.0.1", "key", types.ApiKeyAuthType, false)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	err = dbClient.UpdateBouncerLastPull(ctx, b.ID, now)
+	require.NoError(t, err)
+
+	b, err = dbClient.SelectBouncerByName(ctx, "test")
+	require.NoError(t, err)
+	require.NotNil(t, b.LastPull)
+	assert.True(t, now.Equal(*b.LastPull))
+	assert.Empty(t, b.PullHistory)
+}
+
+func TestUpdateBouncerTypeAndVersion(t *testing.T) {
+	ctx := t.Context()
+	dbClient := getDBClient(t, ctx)
+
+	b, err := dbClient.CreateBouncer(ctx, "test", "127.0.0.1", "key", types.ApiKeyAuthType, false)
+	require.NoError(t, err)
+
+	for _, version := range []string{"v1.0.0", "v1.1.0"} {
+		err = dbClient.UpdateBouncerTypeAndVersion(ctx, "crowdsec-firewall-bouncer", version, b)
+		require.NoError(t, err)
+
+		b, err = dbClient.SelectBouncerByName(ctx, "test")
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, "v1.1.0", b.Version)
+	require.Len(t, b.VersionHistory, 2)
+	assert.Equal(t, "v1.0.0", b.VersionHistory[0].Version)
+	assert.Equal(t, "v1.1.0", b.VersionHistory[1].Version)
+	assert.Equal(t, "crowdsec-firewall-bouncer", b.VersionHistory[1].Type)
+}
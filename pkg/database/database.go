@@ -22,6 +22,15 @@ type Client struct {
 	Type             string
 	WalMode          *bool
 	decisionBulkSize int
+	db               *sql.DB
+	// bounds of the decision durations, by origin
+	DecisionDurations csconfig.DecisionDurationsCfg
+	// which decision is sent when a value has several of them
+	DecisionConflicts *csconfig.DecisionConflictsCfg
+	// how to anonymize the meta of the stored events
+	EventAnonymization csconfig.EventAnonymizationsCfg
+	// maximum number of active decisions, by scenario
+	DecisionBudgets csconfig.DecisionBudgetsCfg
 }
 
 func getEntDriver(dbtype string, dbdialect string, dsn string, config *csconfig.DatabaseCfg) (*entsql.Driver, error) {
@@ -106,9 +115,20 @@ func NewClient(ctx context.Context, config *csconfig.DatabaseCfg, logger *log.En
 		Type:             config.Type,
 		WalMode:          config.UseWal,
 		decisionBulkSize: config.DecisionBulkSize,
+		db:               drv.DB(),
 	}, nil
 }
 
+// Ping checks the connection to the database. The connection pool reconnects by itself,
+// so a successful ping after a failure means the database is usable again.
+func (c *Client) Ping(ctx context.Context) error {
+	if c.db == nil {
+		return nil
+	}
+
+	return c.db.PingContext(ctx)
+}
+
 func (c *Client) Close() error {
 	return c.Ent.Close()
 }
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/decision"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+)
+
+// applyDecisionBudgets returns the decisions of an alert, without the ones that exceed the budget
+// of their scenario, or with these ones simulated if the action of the budget is "simulate".
+// The decisions are not modified, the simulated ones are copies.
+func (c *Client) applyDecisionBudgets(ctx context.Context, logger log.FieldLogger, client *ent.Client, simulated bool, decisions []*models.Decision) ([]*models.Decision, error) {
+	if len(c.DecisionBudgets) == 0 || simulated {
+		return decisions, nil
+	}
+
+	now := time.Now().UTC()
+	// active decisions by scenario, including the ones of this alert
+	active := map[string]int{}
+	exceeded := map[string]int{}
+	ret := make([]*models.Decision, 0, len(decisions))
+
+	for _, d := range decisions {
+		if d.Scenario == nil || (d.Simulated != nil && *d.Simulated) {
+			ret = append(ret, d)
+			continue
+		}
+
+		scenario := *d.Scenario
+
+		budget, ok := c.DecisionBudgets.Lookup(scenario)
+		if !ok {
+			ret = append(ret, d)
+			continue
+		}
+
+		count, ok := active[scenario]
+		if !ok {
+			n, err := client.Decision.Query().
+				Where(decision.ScenarioEQ(scenario), decision.UntilGT(now), decision.SimulatedEQ(false)).
+				Count(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("counting the active decisions of %s: %w", scenario, err)
+			}
+
+			count = n
+		}
+
+		if count < budget.MaxActive {
+			active[scenario] = count + 1
+			ret = append(ret, d)
+
+			continue
+		}
+
+		active[scenario] = count
+		exceeded[scenario]++
+
+		if budget.Action == csconfig.DecisionBudgetSimulate {
+			sim := *d
+			sim.Simulated = new(true)
+			ret = append(ret, &sim)
+		}
+	}
+
+	for _, scenario := range slices.Sorted(maps.Keys(exceeded)) {
+		budget, _ := c.DecisionBudgets.Lookup(scenario)
+
+		verb := "dropped"
+		if budget.Action == csconfig.DecisionBudgetSimulate {
+			verb = "simulated"
+		}
+
+		logger.Warningf("scenario %s has reached its budget of %d active decisions: %d new decision(s) %s",
+			scenario, budget.MaxActive, exceeded[scenario], verb)
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,166 @@
+package database
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"entgo.io/ent/dialect/sql"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/decision"
+)
+
+func (c *Client) conflictPolicy() string {
+	if c == nil || c.DecisionConflicts == nil || c.DecisionConflicts.Policy == "" {
+		return csconfig.DecisionConflictLongest
+	}
+
+	return c.DecisionConflicts.Policy
+}
+
+// writeRank writes the position of the column value in values, or len(values) if it's not there.
+func writeRank(b *sql.Builder, column string, values []string) {
+	b.WriteString("CASE ").Ident(column)
+
+	for i, v := range values {
+		b.WriteString(" WHEN ").Arg(v).WriteString(" THEN " + strconv.Itoa(i))
+	}
+
+	b.WriteString(" ELSE " + strconv.Itoa(len(values)) + " END")
+}
+
+// conflictTarget matches the decisions of t and s that are in conflict: the same value
+// and scope, and the same type unless the strictest remediation wins.
+func (c *Client) conflictTarget(t, s func(string) string) *sql.Predicate {
+	preds := []*sql.Predicate{
+		sql.ColumnsEQ(t(decision.FieldValue), s(decision.FieldValue)),
+		sql.ColumnsEQ(t(decision.FieldScope), s(decision.FieldScope)),
+	}
+
+	if c.conflictPolicy() != csconfig.DecisionConflictStrictest {
+		preds = append(preds, sql.ColumnsEQ(t(decision.FieldType), s(decision.FieldType)))
+	}
+
+	return sql.And(preds...)
+}
+
+// conflictWins matches the decisions of t that win over the decisions of s.
+func (c *Client) conflictWins(t, s func(string) string) *sql.Predicate {
+	longer := sql.ColumnsGT(t(decision.FieldUntil), s(decision.FieldUntil))
+
+	var (
+		field string
+		ranks []string
+	)
+
+	switch c.conflictPolicy() {
+	case csconfig.DecisionConflictStrictest:
+		field, ranks = decision.FieldType, c.DecisionConflicts.Remediations
+	case csconfig.DecisionConflictTrust:
+		field, ranks = decision.FieldOrigin, c.DecisionConflicts.Origins
+	default:
+		return longer
+	}
+
+	return sql.P(func(b *sql.Builder) {
+		b.Wrap(func(b *sql.Builder) {
+			writeRank(b, t(field), ranks)
+			b.WriteString(" < ")
+			writeRank(b, s(field), ranks)
+			b.WriteString(" OR ")
+			b.Wrap(func(b *sql.Builder) {
+				writeRank(b, t(field), ranks)
+				b.WriteString(" = ")
+				writeRank(b, s(field), ranks)
+				b.WriteString(" AND ")
+				b.Join(longer)
+			})
+		})
+	})
+}
+
+// winningDecisions keeps the decisions that win over the other active decisions of their
+// target, according to the decision_conflicts policy: the longest one by default.
+func (c *Client) winningDecisions(now time.Time) func(*sql.Selector) {
+	if c.conflictPolicy() == csconfig.DecisionConflictLongest {
+		return longestDecisionForScopeTypeValue
+	}
+
+	return func(s *sql.Selector) {
+		t := sql.Table(decision.Table)
+		s.LeftJoin(t).OnP(sql.And(
+			c.conflictTarget(t.C, s.C),
+			sql.GT(t.C(decision.FieldUntil), now),
+			c.conflictWins(t.C, s.C),
+		))
+		s.Where(
+			sql.IsNull(
+				t.C(decision.FieldID),
+			),
+		)
+	}
+}
+
+// newOrPromotedDecisions keeps the decisions created after since, and the ones that won
+// over their target since then because a better decision expired. With the longest policy,
+// the decisions that outlived the winner are expired as well.
+func (c *Client) newOrPromotedDecisions(since time.Time, now time.Time) func(*sql.Selector) {
+	return func(s *sql.Selector) {
+		if c.conflictPolicy() == csconfig.DecisionConflictLongest {
+			s.Where(sql.GT(s.C(decision.FieldCreatedAt), since))
+			return
+		}
+
+		t := sql.Dialect(s.Dialect()).Table(decision.Table).As("expired_winner")
+		expiredWinner := sql.Dialect(s.Dialect()).Select(t.C(decision.FieldID)).From(t).Where(sql.And(
+			c.conflictTarget(t.C, s.C),
+			sql.GT(t.C(decision.FieldUntil), since),
+			sql.LTE(t.C(decision.FieldUntil), now),
+			c.conflictWins(t.C, s.C),
+		))
+
+		s.Where(sql.Or(
+			sql.GT(s.C(decision.FieldCreatedAt), since),
+			sql.Exists(expiredWinner),
+		))
+	}
+}
+
+// The same selection, for the decisions in memory (the snapshot of the degraded mode).
+
+func rank(values []string, v string) int {
+	if i := slices.Index(values, v); i >= 0 {
+		return i
+	}
+
+	return len(values)
+}
+
+// inConflict is conflictTarget, for decisions in memory.
+func (c *Client) inConflict(t, s *ent.Decision) bool {
+	if t.Value != s.Value || t.Scope != s.Scope {
+		return false
+	}
+
+	return c.conflictPolicy() == csconfig.DecisionConflictStrictest || t.Type == s.Type
+}
+
+// winsOver is conflictWins, for decisions in memory.
+func (c *Client) winsOver(t, s *ent.Decision) bool {
+	longer := t.Until.After(*s.Until)
+
+	var tRank, sRank int
+
+	switch c.conflictPolicy() {
+	case csconfig.DecisionConflictStrictest:
+		tRank, sRank = rank(c.DecisionConflicts.Remediations, t.Type), rank(c.DecisionConflicts.Remediations, s.Type)
+	case csconfig.DecisionConflictTrust:
+		tRank, sRank = rank(c.DecisionConflicts.Origins, t.Origin), rank(c.DecisionConflicts.Origins, s.Origin)
+	default:
+		return longer
+	}
+
+	return tRank < sRank || (tRank == sRank && longer)
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+)
+
+func createTestDecision(t *testing.T, ctx context.Context, c *Client, value string, typ string, origin string, createdAt time.Time, until time.Time) int {
+	t.Helper()
+
+	d, err := c.Ent.Decision.Create().
+		SetCreatedAt(createdAt).
+		SetUntil(until).
+		SetScenario("test").
+		SetType(typ).
+		SetScope("Ip").
+		SetValue(value).
+		SetOrigin(origin).
+		Save(ctx)
+	require.NoError(t, err)
+
+	return d.ID
+}
+
+func decisionIDsOf(decisions []*ent.Decision) []int {
+	ret := make([]int, 0, len(decisions))
+	for _, d := range decisions {
+		ret = append(ret, d.ID)
+	}
+
+	return ret
+}
+
+func TestDecisionConflicts(t *testing.T) {
+	ctx := t.Context()
+	dbClient := getDBClient(t, ctx)
+	now := time.Now().UTC()
+
+	capiBan := createTestDecision(t, ctx, dbClient, "1.2.3.4", "ban", "CAPI", now, now.Add(24*time.Hour))
+	manualCaptcha := createTestDecision(t, ctx, dbClient, "1.2.3.4", "captcha", "cscli", now, now.Add(48*time.Hour))
+	manualBan := createTestDecision(t, ctx, dbClient, "1.2.3.4", "ban", "cscli", now, now.Add(time.Hour))
+
+	tests := []struct {
+		name   string
+		config *csconfig.DecisionConflictsCfg
+		stream []int
+		query  []int
+	}{
+		{
+			name:   "default",
+			stream: []int{capiBan, manualCaptcha},
+			query:  []int{capiBan, manualCaptcha, manualBan},
+		},
+		{
+			name:   "strictest",
+			config: &csconfig.DecisionConflictsCfg{Policy: "strictest"},
+			stream: []int{capiBan},
+			query:  []int{capiBan},
+		},
+		{
+			name:   "manual decisions win",
+			config: &csconfig.DecisionConflictsCfg{Policy: "trust"},
+			stream: []int{manualCaptcha, manualBan},
+			query:  []int{manualCaptcha, manualBan},
+		},
+		{
+			name:   "CAPI wins",
+			config: &csconfig.DecisionConflictsCfg{Policy: "trust", Origins: []string{"CAPI"}},
+			stream: []int{capiBan, manualCaptcha},
+			query:  []int{capiBan, manualCaptcha},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.config.Validate())
+			dbClient.DecisionConflicts = tc.config
+
+			decisions, err := dbClient.QueryAllDecisionsWithFilters(ctx, now, map[string][]string{})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.stream, decisionIDsOf(decisions))
+
+			decisions, err = dbClient.QueryDecisionWithFilter(ctx, map[string][]string{})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.query, decisionIDsOf(decisions))
+
+			decisions, err = dbClient.QueryAllDecisionsWithFilters(ctx, now, map[string][]string{"dedup": {"false"}})
+			require.NoError(t, err)
+			assert.Len(t, decisions, 3)
+		})
+	}
+}
+
+func TestDecisionConflictsDelta(t *testing.T) {
+	ctx := t.Context()
+	dbClient := getDBClient(t, ctx)
+	now := time.Now().UTC()
+	lastPull := now.Add(-time.Minute)
+
+	// the manual ban hid the CAPI one, and expired since the last pull
+	createTestDecision(t, ctx, dbClient, "1.2.3.4", "ban", "cscli", now.Add(-2*time.Hour), now.Add(-30*time.Second))
+	capiBan := createTestDecision(t, ctx, dbClient, "1.2.3.4", "ban", "CAPI", now.Add(-2*time.Hour), now.Add(24*time.Hour))
+	// already sent
+	createTestDecision(t, ctx, dbClient, "5.6.7.8", "ban", "CAPI", now.Add(-2*time.Hour), now.Add(24*time.Hour))
+	newBan := createTestDecision(t, ctx, dbClient, "9.9.9.9", "ban", "crowdsec", now, now.Add(4*time.Hour))
+
+	decisions, err := dbClient.QueryNewDecisionsSinceWithFilters(ctx, now, &lastPull, map[string][]string{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{newBan}, decisionIDsOf(decisions))
+
+	dbClient.DecisionConflicts = &csconfig.DecisionConflictsCfg{Policy: "trust"}
+	require.NoError(t, dbClient.DecisionConflicts.Validate())
+
+	decisions, err = dbClient.QueryNewDecisionsSinceWithFilters(ctx, now, &lastPull, map[string][]string{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int{capiBan, newBan}, decisionIDsOf(decisions))
+}
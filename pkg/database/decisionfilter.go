@@ -43,16 +43,7 @@ func applyDecisionFilter(query *ent.DecisionQuery, filter map[string][]string) (
 		case "scopes", "scope": // Swagger mentions both of them, let's just support both to make sure we don't break anything
 			scopes := strings.Split(value[0], ",")
 			for i, scope := range scopes {
-				switch strings.ToLower(scope) {
-				case "ip":
-					scopes[i] = types.Ip
-				case "range":
-					scopes[i] = types.Range
-				case "country":
-					scopes[i] = types.Country
-				case "as":
-					scopes[i] = types.AS
-				}
+				scopes[i] = normalizeScope(scope)
 			}
 
 			query = query.Where(decision.ScopeIn(scopes...))
@@ -111,26 +102,58 @@ func applyDecisionFilter(query *ent.DecisionQuery, filter map[string][]string) (
 	return query, nil
 }
 
-func decisionIPv4Filter(decisions *ent.DecisionQuery, contains bool, rng csnet.Range) (*ent.DecisionQuery, error) {
+// decisionSupernetsPredicate matches the decisions set on one of the networks that contain the range.
+// It's an exact match on the bounds, which can use the index on (start_ip, end_ip) instead of
+// scanning all the decisions that start before the range.
+func decisionSupernetsPredicate(rng csnet.Range, supernets []csnet.Range) predicate.Decision {
+	bounds := make([]predicate.Decision, len(supernets))
+
+	for i, sup := range supernets {
+		if rng.Size() == 4 {
+			bounds[i] = decision.And(
+				decision.StartIPEQ(sup.Start.Addr),
+				decision.EndIPEQ(sup.End.Addr),
+			)
+
+			continue
+		}
+
+		bounds[i] = decision.And(
+			decision.StartIPEQ(sup.Start.Addr),
+			decision.StartSuffixEQ(sup.Start.Sfx),
+			decision.EndIPEQ(sup.End.Addr),
+			decision.EndSuffixEQ(sup.End.Sfx),
+		)
+	}
+
+	return decision.And(
+		decision.IPSizeEQ(int64(rng.Size())),
+		decision.Or(bounds...),
+	)
+}
+
+func decisionIPv4Predicate(contains bool, rng csnet.Range) predicate.Decision {
 	if contains {
 		// Decision contains {start_ip,end_ip}
-		return decisions.Where(decision.And(
+		return decision.And(
 			decision.StartIPLTE(rng.Start.Addr),
 			decision.EndIPGTE(rng.End.Addr),
-			decision.IPSizeEQ(int64(rng.Size())))), nil
+			decision.IPSizeEQ(int64(rng.Size())))
 	}
 
 	// Decision is contained within {start_ip,end_ip}
-	return decisions.Where(decision.And(
+	return decision.And(
 		decision.StartIPGTE(rng.Start.Addr),
+		// redundant, but bounds the scan of the index
+		decision.StartIPLTE(rng.End.Addr),
 		decision.EndIPLTE(rng.End.Addr),
-		decision.IPSizeEQ(int64(rng.Size())))), nil
+		decision.IPSizeEQ(int64(rng.Size())))
 }
 
-func decisionIPv6Filter(decisions *ent.DecisionQuery, contains bool, rng csnet.Range) (*ent.DecisionQuery, error) {
+func decisionIPv6Predicate(contains bool, rng csnet.Range) predicate.Decision {
 	// decision contains {start_ip,end_ip}
 	if contains {
-		return decisions.Where(decision.And(
+		return decision.And(
 			// matching addr size
 			decision.IPSizeEQ(int64(rng.Size())),
 			decision.Or(
@@ -152,13 +175,15 @@ func decisionIPv6Filter(decisions *ent.DecisionQuery, contains bool, rng csnet.R
 					decision.EndSuffixGTE(rng.End.Sfx),
 				),
 			),
-		)), nil
+		)
 	}
 
 	// decision is contained within {start_ip,end_ip}
-	return decisions.Where(decision.And(
+	return decision.And(
 		// matching addr size
 		decision.IPSizeEQ(int64(rng.Size())),
+		// redundant, but bounds the scan of the index
+		decision.StartIPLTE(rng.End.Addr),
 		decision.Or(
 			// decision.start_ip > query.start_ip
 			decision.StartIPGT(rng.Start.Addr),
@@ -178,20 +203,60 @@ func decisionIPv6Filter(decisions *ent.DecisionQuery, contains bool, rng csnet.R
 				decision.EndSuffixLTE(rng.End.Sfx),
 			),
 		),
-	)), nil
+	)
 }
 
-func decisionIPFilter(decisions *ent.DecisionQuery, contains bool, rng csnet.Range) (*ent.DecisionQuery, error) {
+// decisionIPPredicate returns the predicate matching the decisions that contain (or are contained in) the range,
+// nil if there is no range to filter on.
+func decisionIPPredicate(contains bool, rng csnet.Range) (predicate.Decision, error) {
 	switch rng.Size() {
-	case 4:
-		return decisionIPv4Filter(decisions, contains, rng)
-	case 16:
-		return decisionIPv6Filter(decisions, contains, rng)
 	case 0:
-		return decisions, nil
+		return nil, nil
+	case 4, 16:
 	default:
 		return nil, fmt.Errorf("unknown ip size %d: %w", rng.Size(), InvalidFilter)
 	}
+
+	if contains {
+		if supernets := rng.Supernets(); supernets != nil {
+			return decisionSupernetsPredicate(rng, supernets), nil
+		}
+	}
+
+	if rng.Size() == 4 {
+		return decisionIPv4Predicate(contains, rng), nil
+	}
+
+	return decisionIPv6Predicate(contains, rng), nil
+}
+
+func decisionIPFilter(decisions *ent.DecisionQuery, contains bool, rng csnet.Range) (*ent.DecisionQuery, error) {
+	pred, err := decisionIPPredicate(contains, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	if pred == nil {
+		return decisions, nil
+	}
+
+	return decisions.Where(pred), nil
+}
+
+// normalizeScope returns the stored name of the well-known scopes, which can be requested in lower case.
+func normalizeScope(scope string) string {
+	switch strings.ToLower(scope) {
+	case "ip":
+		return types.Ip
+	case "range":
+		return types.Range
+	case "country":
+		return types.Country
+	case "as":
+		return types.AS
+	}
+
+	return scope
 }
 
 func decisionPredicatesFromStr(s string, predicateFunc func(string) predicate.Decision) []predicate.Decision {
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 	"time"
 
@@ -10,8 +11,10 @@ import (
 
 	"github.com/crowdsecurity/go-cs-lib/slicetools"
 
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/csnet"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/alert"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/decision"
 )
 
@@ -34,7 +37,7 @@ func (c *Client) QueryAllDecisionsWithFilters(ctx context.Context, now time.Time
 		)
 	// Allow a bouncer to ask for non-deduplicated results
 	if v, ok := filter["dedup"]; !ok || v[0] != "false" {
-		query = query.Where(longestDecisionForScopeTypeValue)
+		query = query.Where(c.winningDecisions(now))
 	}
 
 	query, err := applyDecisionFilter(query, filter)
@@ -62,7 +65,7 @@ func (c *Client) QueryExpiredDecisionsWithFilters(ctx context.Context, now time.
 		)
 	// Allow a bouncer to ask for non-deduplicated results
 	if v, ok := filter["dedup"]; !ok || v[0] != "false" {
-		query = query.Where(longestDecisionForScopeTypeValue)
+		query = query.Where(c.winningDecisions(now))
 	}
 
 	query, err := applyDecisionFilter(query, filter)
@@ -110,8 +113,15 @@ func (c *Client) QueryDecisionWithFilter(ctx context.Context, filter map[string]
 		data []*ent.Decision
 	)
 
+	now := time.Now().UTC()
+
 	query := c.Ent.Decision.Query().
-		Where(decision.UntilGTE(time.Now().UTC()))
+		Where(decision.UntilGTE(now))
+
+	// all the decisions are returned with the longest policy, like before it was configurable
+	if c.conflictPolicy() != csconfig.DecisionConflictLongest {
+		query = query.Where(c.winningDecisions(now))
+	}
 
 	query, err = applyDecisionFilter(query, filter)
 	if err != nil {
@@ -128,6 +138,8 @@ func (c *Client) QueryDecisionWithFilter(ctx context.Context, filter map[string]
 		decision.FieldValue,
 		decision.FieldScope,
 		decision.FieldOrigin,
+		decision.FieldCreatedAt,
+		decision.FieldAlertDecisions,
 	).Scan(ctx, &data)
 	if err != nil {
 		c.Log.Warningf("QueryDecisionWithFilter : %s", err)
@@ -137,6 +149,38 @@ func (c *Client) QueryDecisionWithFilter(ctx context.Context, filter map[string]
 	return data, nil
 }
 
+// AlertLabelsOfDecisions returns the labels of the alerts that created the decisions, by alert ID.
+func (c *Client) AlertLabelsOfDecisions(ctx context.Context, decisions []*ent.Decision) (map[int][]string, error) {
+	ids := make([]int, 0, len(decisions))
+
+	for _, d := range decisions {
+		if d.AlertDecisions != 0 && !slices.Contains(ids, d.AlertDecisions) {
+			ids = append(ids, d.AlertDecisions)
+		}
+	}
+
+	ret := make(map[int][]string, len(ids))
+
+	if len(ids) == 0 {
+		return ret, nil
+	}
+
+	alerts, err := c.Ent.Alert.Query().
+		Where(alert.IDIn(ids...)).
+		Select(alert.FieldID, alert.FieldLabels).
+		All(ctx)
+	if err != nil {
+		c.Log.Warningf("AlertLabelsOfDecisions : %s", err)
+		return nil, fmt.Errorf("query alert labels failed: %w", QueryFail)
+	}
+
+	for _, a := range alerts {
+		ret[a.ID] = a.Labels
+	}
+
+	return ret, nil
+}
+
 // ent translation of https://stackoverflow.com/a/28090544
 func longestDecisionForScopeTypeValue(s *sql.Selector) {
 	t := sql.Table(decision.Table)
@@ -178,7 +222,7 @@ func (c *Client) QueryExpiredDecisionsSinceWithFilters(ctx context.Context, now
 
 	// Allow a bouncer to ask for non-deduplicated results
 	if v, ok := filter["dedup"]; !ok || v[0] != "false" {
-		query = query.Where(longestDecisionForScopeTypeValue)
+		query = query.Where(c.winningDecisions(now))
 	}
 
 	query, err := applyDecisionFilter(query, filter)
@@ -207,15 +251,24 @@ func (c *Client) QueryNewDecisionsSinceWithFilters(ctx context.Context, now time
 
 	errorMsg := "new decisions"
 
+	// Allow a bouncer to ask for non-deduplicated results
+	dedup := true
+	if v, ok := filter["dedup"]; ok && v[0] == "false" {
+		dedup = false
+	}
+
 	if since != nil {
-		query = query.Where(decision.CreatedAtGT(*since))
+		if dedup {
+			query = query.Where(c.newOrPromotedDecisions(*since, now))
+		} else {
+			query = query.Where(decision.CreatedAtGT(*since))
+		}
 
 		errorMsg = fmt.Sprintf("%s since %q", errorMsg, since)
 	}
 
-	// Allow a bouncer to ask for non-deduplicated results
-	if v, ok := filter["dedup"]; !ok || v[0] != "false" {
-		query = query.Where(longestDecisionForScopeTypeValue)
+	if dedup {
+		query = query.Where(c.winningDecisions(now))
 	}
 
 	query, err := applyDecisionFilter(query, filter)
@@ -400,6 +453,47 @@ func (c *Client) ExpireDecisionByID(ctx context.Context, decisionID int) (int, [
 	return count, toUpdate, err
 }
 
+// CapDecisionDurations shortens the active decisions that last longer than the max_duration of their
+// origin: the ones inserted before decision_durations was changed. The decisions already past their cap
+// expire now, so that the bouncers get their deletion with the next delta. It returns the number of
+// updated decisions.
+func (c *Client) CapDecisionDurations(ctx context.Context, now time.Time) (int, error) {
+	capped := 0
+
+	for _, d := range c.DecisionDurations {
+		if d.MaxDuration == 0 {
+			continue
+		}
+
+		decisions, err := c.Ent.Decision.Query().
+			Select(decision.FieldID, decision.FieldCreatedAt, decision.FieldUntil, decision.FieldOrigin).
+			Where(decision.OriginEQ(d.Origin), decision.UntilGT(now)).
+			All(ctx)
+		if err != nil {
+			return capped, fmt.Errorf("querying the decisions of %s: %w", d.Origin, err)
+		}
+
+		for _, dec := range decisions {
+			until := c.DecisionDurations.CapUntil(dec.Origin, dec.CreatedAt, *dec.Until)
+			if !until.Before(*dec.Until) {
+				continue
+			}
+
+			if until.Before(now) {
+				until = now
+			}
+
+			if err := c.Ent.Decision.UpdateOneID(dec.ID).SetUntil(until).Exec(ctx); err != nil {
+				return capped, fmt.Errorf("capping decision %d: %w", dec.ID, err)
+			}
+
+			capped++
+		}
+	}
+
+	return capped, nil
+}
+
 func (c *Client) CountDecisionsByValue(ctx context.Context, value string, since *time.Time, onlyActive bool) (int, error) {
 	rng, err := csnet.NewRange(value)
 	if err != nil {
@@ -430,6 +524,32 @@ func (c *Client) CountDecisionsByValue(ctx context.Context, value string, since
 	return count, nil
 }
 
+// HasDecisionsFromOtherScenarios tells if an ip, or a range that contains it, received a non-simulated
+// decision (active or expired) from any scenario or origin other than the given scenarios.
+func (c *Client) HasDecisionsFromOtherScenarios(ctx context.Context, value string, scenarios []string) (bool, error) {
+	rng, err := csnet.NewRange(value)
+	if err != nil {
+		return false, fmt.Errorf("unable to convert '%s' to int: %w", value, err)
+	}
+
+	decisions := c.Ent.Decision.Query().Where(
+		decision.SimulatedEQ(false),
+		decision.ScenarioNotIn(scenarios...),
+	)
+
+	decisions, err = decisionIPFilter(decisions, true, rng)
+	if err != nil {
+		return false, fmt.Errorf("fail to apply StartIpEndIpFilter: %w", err)
+	}
+
+	found, err := decisions.Exist(ctx)
+	if err != nil {
+		return false, fmt.Errorf("fail to look for decisions: %w", err)
+	}
+
+	return found, nil
+}
+
 func (c *Client) GetActiveDecisionsTimeLeftByValue(ctx context.Context, decisionValue string) (time.Duration, error) {
 	rng, err := csnet.NewRange(decisionValue)
 	if err != nil {
@@ -459,3 +579,55 @@ func (c *Client) GetActiveDecisionsTimeLeftByValue(ctx context.Context, decision
 
 	return decision.Until.Sub(time.Now().UTC()), nil
 }
+
+// LastDecisionID returns the id of the most recent decision, or 0 if there is none.
+func (c *Client) LastDecisionID(ctx context.Context) (int, error) {
+	id, err := c.Ent.Decision.Query().Order(ent.Desc(decision.FieldID)).FirstID(ctx)
+
+	switch {
+	case ent.IsNotFound(err):
+		return 0, nil
+	case err != nil:
+		return 0, fmt.Errorf("fail to get last decision: %w", err)
+	}
+
+	return id, nil
+}
+
+// QueryDecisionsAfterID returns the decisions with an id greater than afterID, active at now,
+// and whose origin is not in excludedOrigins. They are ordered by id.
+func (c *Client) QueryDecisionsAfterID(ctx context.Context, afterID int, now time.Time, excludedOrigins []string) ([]*ent.Decision, error) {
+	decisions, err := c.Ent.Decision.Query().
+		Where(
+			decision.IDGT(afterID),
+			decision.UntilGT(now),
+			decision.OriginNotIn(excludedOrigins...),
+		).
+		Order(ent.Asc(decision.FieldID)).
+		All(ctx)
+	if err != nil {
+		c.Log.Warningf("QueryDecisionsAfterID : %s", err)
+		return nil, fmt.Errorf("decisions after id %d: %w", afterID, QueryFail)
+	}
+
+	return decisions, nil
+}
+
+// QueryDecisionsExpiredBetween returns the decisions that expired, or were deleted, in the ]since, until]
+// interval, and whose origin is not in excludedOrigins. They are ordered by id.
+func (c *Client) QueryDecisionsExpiredBetween(ctx context.Context, since time.Time, until time.Time, excludedOrigins []string) ([]*ent.Decision, error) {
+	decisions, err := c.Ent.Decision.Query().
+		Where(
+			decision.UntilGT(since),
+			decision.UntilLTE(until),
+			decision.OriginNotIn(excludedOrigins...),
+		).
+		Order(ent.Asc(decision.FieldID)).
+		All(ctx)
+	if err != nil {
+		c.Log.Warningf("QueryDecisionsExpiredBetween : %s", err)
+		return nil, fmt.Errorf("decisions expired since %s: %w", since, QueryFail)
+	}
+
+	return decisions, nil
+}
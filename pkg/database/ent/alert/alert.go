@@ -64,6 +64,8 @@ const (
 	FieldRemediation = "remediation"
 	// FieldKind holds the string denoting the kind field in the database.
 	FieldKind = "kind"
+	// FieldLabels holds the string denoting the labels field in the database.
+	FieldLabels = "labels"
 	// EdgeOwner holds the string denoting the owner edge name in mutations.
 	EdgeOwner = "owner"
 	// EdgeDecisions holds the string denoting the decisions edge name in mutations.
@@ -132,6 +134,7 @@ var Columns = []string{
 	FieldUUID,
 	FieldRemediation,
 	FieldKind,
+	FieldLabels,
 }
 
 // ForeignKeys holds the SQL foreign-keys that are owned by the "alerts"
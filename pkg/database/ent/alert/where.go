@@ -1705,6 +1705,16 @@ func KindContainsFold(v string) predicate.Alert {
 	return predicate.Alert(sql.FieldContainsFold(FieldKind, v))
 }
 
+// LabelsIsNil applies the IsNil predicate on the "labels" field.
+func LabelsIsNil() predicate.Alert {
+	return predicate.Alert(sql.FieldIsNull(FieldLabels))
+}
+
+// LabelsNotNil applies the NotNil predicate on the "labels" field.
+func LabelsNotNil() predicate.Alert {
+	return predicate.Alert(sql.FieldNotNull(FieldLabels))
+}
+
 // HasOwner applies the HasEdge predicate on the "owner" edge.
 func HasOwner() predicate.Alert {
 	return predicate.Alert(func(s *sql.Selector) {
@@ -368,6 +368,12 @@ func (_c *AlertCreate) SetNillableKind(v *string) *AlertCreate {
 	return _c
 }
 
+// SetLabels sets the "labels" field.
+func (_c *AlertCreate) SetLabels(v []string) *AlertCreate {
+	_c.mutation.SetLabels(v)
+	return _c
+}
+
 // SetOwnerID sets the "owner" edge to the Machine entity by ID.
 func (_c *AlertCreate) SetOwnerID(id int) *AlertCreate {
 	_c.mutation.SetOwnerID(id)
@@ -642,6 +648,10 @@ func (_c *AlertCreate) createSpec() (*Alert, *sqlgraph.CreateSpec) {
 		_spec.SetField(alert.FieldKind, field.TypeString, value)
 		_node.Kind = value
 	}
+	if value, ok := _c.mutation.Labels(); ok {
+		_spec.SetField(alert.FieldLabels, field.TypeJSON, value)
+		_node.Labels = value
+	}
 	if nodes := _c.mutation.OwnerIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -854,6 +864,9 @@ func (u *AlertUpsertOne) UpdateNewValues() *AlertUpsertOne {
 		if _, exists := u.create.mutation.Kind(); exists {
 			s.SetIgnore(alert.FieldKind)
 		}
+		if _, exists := u.create.mutation.Labels(); exists {
+			s.SetIgnore(alert.FieldLabels)
+		}
 	}))
 	return u
 }
@@ -1147,6 +1160,9 @@ func (u *AlertUpsertBulk) UpdateNewValues() *AlertUpsertBulk {
 			if _, exists := b.mutation.Kind(); exists {
 				s.SetIgnore(alert.FieldKind)
 			}
+			if _, exists := b.mutation.Labels(); exists {
+				s.SetIgnore(alert.FieldLabels)
+			}
 		}
 	}))
 	return u
@@ -287,6 +287,9 @@ func (_u *AlertUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if _u.mutation.KindCleared() {
 		_spec.ClearField(alert.FieldKind, field.TypeString)
 	}
+	if _u.mutation.LabelsCleared() {
+		_spec.ClearField(alert.FieldLabels, field.TypeJSON)
+	}
 	if _u.mutation.OwnerCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -756,6 +759,9 @@ func (_u *AlertUpdateOne) sqlSave(ctx context.Context) (_node *Alert, err error)
 	if _u.mutation.KindCleared() {
 		_spec.ClearField(alert.FieldKind, field.TypeString)
 	}
+	if _u.mutation.LabelsCleared() {
+		_spec.ClearField(alert.FieldLabels, field.TypeJSON)
+	}
 	if _u.mutation.OwnerCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -3,6 +3,7 @@
 package ent
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"entgo.io/ent"
 	"entgo.io/ent/dialect/sql"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/bouncer"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/schema"
 )
 
 // Bouncer is the model entity for the Bouncer schema.
@@ -46,8 +48,12 @@ type Bouncer struct {
 	// Featureflags holds the value of the "featureflags" field.
 	Featureflags string `json:"featureflags,omitempty"`
 	// AutoCreated holds the value of the "auto_created" field.
-	AutoCreated  bool `json:"auto_created"`
-	selectValues sql.SelectValues
+	AutoCreated bool `json:"auto_created"`
+	// PullHistory holds the value of the "pull_history" field.
+	PullHistory []schema.BouncerPull `json:"pull_history,omitempty"`
+	// VersionHistory holds the value of the "version_history" field.
+	VersionHistory []schema.BouncerVersion `json:"version_history,omitempty"`
+	selectValues   sql.SelectValues
 }
 
 // scanValues returns the types for scanning values from sql.Rows.
@@ -55,6 +61,8 @@ func (*Bouncer) scanValues(columns []string) ([]any, error) {
 	values := make([]any, len(columns))
 	for i := range columns {
 		switch columns[i] {
+		case bouncer.FieldPullHistory, bouncer.FieldVersionHistory:
+			values[i] = new([]byte)
 		case bouncer.FieldRevoked, bouncer.FieldAutoCreated:
 			values[i] = new(sql.NullBool)
 		case bouncer.FieldID:
@@ -175,6 +183,22 @@ func (_m *Bouncer) assignValues(columns []string, values []any) error {
 			} else if value.Valid {
 				_m.AutoCreated = value.Bool
 			}
+		case bouncer.FieldPullHistory:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field pull_history", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.PullHistory); err != nil {
+					return fmt.Errorf("unmarshal field pull_history: %w", err)
+				}
+			}
+		case bouncer.FieldVersionHistory:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field version_history", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.VersionHistory); err != nil {
+					return fmt.Errorf("unmarshal field version_history: %w", err)
+				}
+			}
 		default:
 			_m.selectValues.Set(columns[i], values[i])
 		}
@@ -256,6 +280,12 @@ func (_m *Bouncer) String() string {
 	builder.WriteString(", ")
 	builder.WriteString("auto_created=")
 	builder.WriteString(fmt.Sprintf("%v", _m.AutoCreated))
+	builder.WriteString(", ")
+	builder.WriteString("pull_history=")
+	builder.WriteString(fmt.Sprintf("%v", _m.PullHistory))
+	builder.WriteString(", ")
+	builder.WriteString("version_history=")
+	builder.WriteString(fmt.Sprintf("%v", _m.VersionHistory))
 	builder.WriteByte(')')
 	return builder.String()
 }
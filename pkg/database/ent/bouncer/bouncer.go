@@ -43,6 +43,10 @@ const (
 	FieldFeatureflags = "featureflags"
 	// FieldAutoCreated holds the string denoting the auto_created field in the database.
 	FieldAutoCreated = "auto_created"
+	// FieldPullHistory holds the string denoting the pull_history field in the database.
+	FieldPullHistory = "pull_history"
+	// FieldVersionHistory holds the string denoting the version_history field in the database.
+	FieldVersionHistory = "version_history"
 	// Table holds the table name of the bouncer in the database.
 	Table = "bouncers"
 )
@@ -65,6 +69,8 @@ var Columns = []string{
 	FieldOsversion,
 	FieldFeatureflags,
 	FieldAutoCreated,
+	FieldPullHistory,
+	FieldVersionHistory,
 }
 
 // ValidColumn reports if the column name is valid (part of the table columns).
@@ -999,6 +999,26 @@ func AutoCreatedNEQ(v bool) predicate.Bouncer {
 	return predicate.Bouncer(sql.FieldNEQ(FieldAutoCreated, v))
 }
 
+// PullHistoryIsNil applies the IsNil predicate on the "pull_history" field.
+func PullHistoryIsNil() predicate.Bouncer {
+	return predicate.Bouncer(sql.FieldIsNull(FieldPullHistory))
+}
+
+// PullHistoryNotNil applies the NotNil predicate on the "pull_history" field.
+func PullHistoryNotNil() predicate.Bouncer {
+	return predicate.Bouncer(sql.FieldNotNull(FieldPullHistory))
+}
+
+// VersionHistoryIsNil applies the IsNil predicate on the "version_history" field.
+func VersionHistoryIsNil() predicate.Bouncer {
+	return predicate.Bouncer(sql.FieldIsNull(FieldVersionHistory))
+}
+
+// VersionHistoryNotNil applies the NotNil predicate on the "version_history" field.
+func VersionHistoryNotNil() predicate.Bouncer {
+	return predicate.Bouncer(sql.FieldNotNull(FieldVersionHistory))
+}
+
 // And groups predicates with the AND operator between them.
 func And(predicates ...predicate.Bouncer) predicate.Bouncer {
 	return predicate.Bouncer(sql.AndPredicates(predicates...))
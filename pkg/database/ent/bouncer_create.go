@@ -12,6 +12,7 @@ import (
 	"entgo.io/ent/dialect/sql/sqlgraph"
 	"entgo.io/ent/schema/field"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/bouncer"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/schema"
 )
 
 // BouncerCreate is the builder for creating a Bouncer entity.
@@ -208,6 +209,18 @@ func (_c *BouncerCreate) SetNillableAutoCreated(v *bool) *BouncerCreate {
 	return _c
 }
 
+// SetPullHistory sets the "pull_history" field.
+func (_c *BouncerCreate) SetPullHistory(v []schema.BouncerPull) *BouncerCreate {
+	_c.mutation.SetPullHistory(v)
+	return _c
+}
+
+// SetVersionHistory sets the "version_history" field.
+func (_c *BouncerCreate) SetVersionHistory(v []schema.BouncerVersion) *BouncerCreate {
+	_c.mutation.SetVersionHistory(v)
+	return _c
+}
+
 // Mutation returns the BouncerMutation object of the builder.
 func (_c *BouncerCreate) Mutation() *BouncerMutation {
 	return _c.mutation
@@ -375,6 +388,14 @@ func (_c *BouncerCreate) createSpec() (*Bouncer, *sqlgraph.CreateSpec) {
 		_spec.SetField(bouncer.FieldAutoCreated, field.TypeBool, value)
 		_node.AutoCreated = value
 	}
+	if value, ok := _c.mutation.PullHistory(); ok {
+		_spec.SetField(bouncer.FieldPullHistory, field.TypeJSON, value)
+		_node.PullHistory = value
+	}
+	if value, ok := _c.mutation.VersionHistory(); ok {
+		_spec.SetField(bouncer.FieldVersionHistory, field.TypeJSON, value)
+		_node.VersionHistory = value
+	}
 	return _node, _spec
 }
 
@@ -619,6 +640,42 @@ func (u *BouncerUpsert) ClearFeatureflags() *BouncerUpsert {
 	return u
 }
 
+// SetPullHistory sets the "pull_history" field.
+func (u *BouncerUpsert) SetPullHistory(v []schema.BouncerPull) *BouncerUpsert {
+	u.Set(bouncer.FieldPullHistory, v)
+	return u
+}
+
+// UpdatePullHistory sets the "pull_history" field to the value that was provided on create.
+func (u *BouncerUpsert) UpdatePullHistory() *BouncerUpsert {
+	u.SetExcluded(bouncer.FieldPullHistory)
+	return u
+}
+
+// ClearPullHistory clears the value of the "pull_history" field.
+func (u *BouncerUpsert) ClearPullHistory() *BouncerUpsert {
+	u.SetNull(bouncer.FieldPullHistory)
+	return u
+}
+
+// SetVersionHistory sets the "version_history" field.
+func (u *BouncerUpsert) SetVersionHistory(v []schema.BouncerVersion) *BouncerUpsert {
+	u.Set(bouncer.FieldVersionHistory, v)
+	return u
+}
+
+// UpdateVersionHistory sets the "version_history" field to the value that was provided on create.
+func (u *BouncerUpsert) UpdateVersionHistory() *BouncerUpsert {
+	u.SetExcluded(bouncer.FieldVersionHistory)
+	return u
+}
+
+// ClearVersionHistory clears the value of the "version_history" field.
+func (u *BouncerUpsert) ClearVersionHistory() *BouncerUpsert {
+	u.SetNull(bouncer.FieldVersionHistory)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -894,6 +951,48 @@ func (u *BouncerUpsertOne) ClearFeatureflags() *BouncerUpsertOne {
 	})
 }
 
+// SetPullHistory sets the "pull_history" field.
+func (u *BouncerUpsertOne) SetPullHistory(v []schema.BouncerPull) *BouncerUpsertOne {
+	return u.Update(func(s *BouncerUpsert) {
+		s.SetPullHistory(v)
+	})
+}
+
+// UpdatePullHistory sets the "pull_history" field to the value that was provided on create.
+func (u *BouncerUpsertOne) UpdatePullHistory() *BouncerUpsertOne {
+	return u.Update(func(s *BouncerUpsert) {
+		s.UpdatePullHistory()
+	})
+}
+
+// ClearPullHistory clears the value of the "pull_history" field.
+func (u *BouncerUpsertOne) ClearPullHistory() *BouncerUpsertOne {
+	return u.Update(func(s *BouncerUpsert) {
+		s.ClearPullHistory()
+	})
+}
+
+// SetVersionHistory sets the "version_history" field.
+func (u *BouncerUpsertOne) SetVersionHistory(v []schema.BouncerVersion) *BouncerUpsertOne {
+	return u.Update(func(s *BouncerUpsert) {
+		s.SetVersionHistory(v)
+	})
+}
+
+// UpdateVersionHistory sets the "version_history" field to the value that was provided on create.
+func (u *BouncerUpsertOne) UpdateVersionHistory() *BouncerUpsertOne {
+	return u.Update(func(s *BouncerUpsert) {
+		s.UpdateVersionHistory()
+	})
+}
+
+// ClearVersionHistory clears the value of the "version_history" field.
+func (u *BouncerUpsertOne) ClearVersionHistory() *BouncerUpsertOne {
+	return u.Update(func(s *BouncerUpsert) {
+		s.ClearVersionHistory()
+	})
+}
+
 // Exec executes the query.
 func (u *BouncerUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -1335,6 +1434,48 @@ func (u *BouncerUpsertBulk) ClearFeatureflags() *BouncerUpsertBulk {
 	})
 }
 
+// SetPullHistory sets the "pull_history" field.
+func (u *BouncerUpsertBulk) SetPullHistory(v []schema.BouncerPull) *BouncerUpsertBulk {
+	return u.Update(func(s *BouncerUpsert) {
+		s.SetPullHistory(v)
+	})
+}
+
+// UpdatePullHistory sets the "pull_history" field to the value that was provided on create.
+func (u *BouncerUpsertBulk) UpdatePullHistory() *BouncerUpsertBulk {
+	return u.Update(func(s *BouncerUpsert) {
+		s.UpdatePullHistory()
+	})
+}
+
+// ClearPullHistory clears the value of the "pull_history" field.
+func (u *BouncerUpsertBulk) ClearPullHistory() *BouncerUpsertBulk {
+	return u.Update(func(s *BouncerUpsert) {
+		s.ClearPullHistory()
+	})
+}
+
+// SetVersionHistory sets the "version_history" field.
+func (u *BouncerUpsertBulk) SetVersionHistory(v []schema.BouncerVersion) *BouncerUpsertBulk {
+	return u.Update(func(s *BouncerUpsert) {
+		s.SetVersionHistory(v)
+	})
+}
+
+// UpdateVersionHistory sets the "version_history" field to the value that was provided on create.
+func (u *BouncerUpsertBulk) UpdateVersionHistory() *BouncerUpsertBulk {
+	return u.Update(func(s *BouncerUpsert) {
+		s.UpdateVersionHistory()
+	})
+}
+
+// ClearVersionHistory clears the value of the "version_history" field.
+func (u *BouncerUpsertBulk) ClearVersionHistory() *BouncerUpsertBulk {
+	return u.Update(func(s *BouncerUpsert) {
+		s.ClearVersionHistory()
+	})
+}
+
 // Exec executes the query.
 func (u *BouncerUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
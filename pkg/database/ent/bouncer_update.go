@@ -10,9 +10,11 @@ import (
 
 	"entgo.io/ent/dialect/sql"
 	"entgo.io/ent/dialect/sql/sqlgraph"
+	"entgo.io/ent/dialect/sql/sqljson"
 	"entgo.io/ent/schema/field"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/bouncer"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/predicate"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/schema"
 )
 
 // BouncerUpdate is the builder for updating Bouncer entities.
@@ -236,6 +238,42 @@ func (_u *BouncerUpdate) ClearFeatureflags() *BouncerUpdate {
 	return _u
 }
 
+// SetPullHistory sets the "pull_history" field.
+func (_u *BouncerUpdate) SetPullHistory(v []schema.BouncerPull) *BouncerUpdate {
+	_u.mutation.SetPullHistory(v)
+	return _u
+}
+
+// AppendPullHistory appends value to the "pull_history" field.
+func (_u *BouncerUpdate) AppendPullHistory(v []schema.BouncerPull) *BouncerUpdate {
+	_u.mutation.AppendPullHistory(v)
+	return _u
+}
+
+// ClearPullHistory clears the value of the "pull_history" field.
+func (_u *BouncerUpdate) ClearPullHistory() *BouncerUpdate {
+	_u.mutation.ClearPullHistory()
+	return _u
+}
+
+// SetVersionHistory sets the "version_history" field.
+func (_u *BouncerUpdate) SetVersionHistory(v []schema.BouncerVersion) *BouncerUpdate {
+	_u.mutation.SetVersionHistory(v)
+	return _u
+}
+
+// AppendVersionHistory appends value to the "version_history" field.
+func (_u *BouncerUpdate) AppendVersionHistory(v []schema.BouncerVersion) *BouncerUpdate {
+	_u.mutation.AppendVersionHistory(v)
+	return _u
+}
+
+// ClearVersionHistory clears the value of the "version_history" field.
+func (_u *BouncerUpdate) ClearVersionHistory() *BouncerUpdate {
+	_u.mutation.ClearVersionHistory()
+	return _u
+}
+
 // Mutation returns the BouncerMutation object of the builder.
 func (_u *BouncerUpdate) Mutation() *BouncerMutation {
 	return _u.mutation
@@ -346,6 +384,28 @@ func (_u *BouncerUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if _u.mutation.FeatureflagsCleared() {
 		_spec.ClearField(bouncer.FieldFeatureflags, field.TypeString)
 	}
+	if value, ok := _u.mutation.PullHistory(); ok {
+		_spec.SetField(bouncer.FieldPullHistory, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedPullHistory(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, bouncer.FieldPullHistory, value)
+		})
+	}
+	if _u.mutation.PullHistoryCleared() {
+		_spec.ClearField(bouncer.FieldPullHistory, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.VersionHistory(); ok {
+		_spec.SetField(bouncer.FieldVersionHistory, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedVersionHistory(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, bouncer.FieldVersionHistory, value)
+		})
+	}
+	if _u.mutation.VersionHistoryCleared() {
+		_spec.ClearField(bouncer.FieldVersionHistory, field.TypeJSON)
+	}
 	if _node, err = sqlgraph.UpdateNodes(ctx, _u.driver, _spec); err != nil {
 		if _, ok := err.(*sqlgraph.NotFoundError); ok {
 			err = &NotFoundError{bouncer.Label}
@@ -574,6 +634,42 @@ func (_u *BouncerUpdateOne) ClearFeatureflags() *BouncerUpdateOne {
 	return _u
 }
 
+// SetPullHistory sets the "pull_history" field.
+func (_u *BouncerUpdateOne) SetPullHistory(v []schema.BouncerPull) *BouncerUpdateOne {
+	_u.mutation.SetPullHistory(v)
+	return _u
+}
+
+// AppendPullHistory appends value to the "pull_history" field.
+func (_u *BouncerUpdateOne) AppendPullHistory(v []schema.BouncerPull) *BouncerUpdateOne {
+	_u.mutation.AppendPullHistory(v)
+	return _u
+}
+
+// ClearPullHistory clears the value of the "pull_history" field.
+func (_u *BouncerUpdateOne) ClearPullHistory() *BouncerUpdateOne {
+	_u.mutation.ClearPullHistory()
+	return _u
+}
+
+// SetVersionHistory sets the "version_history" field.
+func (_u *BouncerUpdateOne) SetVersionHistory(v []schema.BouncerVersion) *BouncerUpdateOne {
+	_u.mutation.SetVersionHistory(v)
+	return _u
+}
+
+// AppendVersionHistory appends value to the "version_history" field.
+func (_u *BouncerUpdateOne) AppendVersionHistory(v []schema.BouncerVersion) *BouncerUpdateOne {
+	_u.mutation.AppendVersionHistory(v)
+	return _u
+}
+
+// ClearVersionHistory clears the value of the "version_history" field.
+func (_u *BouncerUpdateOne) ClearVersionHistory() *BouncerUpdateOne {
+	_u.mutation.ClearVersionHistory()
+	return _u
+}
+
 // Mutation returns the BouncerMutation object of the builder.
 func (_u *BouncerUpdateOne) Mutation() *BouncerMutation {
 	return _u.mutation
@@ -714,6 +810,28 @@ func (_u *BouncerUpdateOne) sqlSave(ctx context.Context) (_node *Bouncer, err er
 	if _u.mutation.FeatureflagsCleared() {
 		_spec.ClearField(bouncer.FieldFeatureflags, field.TypeString)
 	}
+	if value, ok := _u.mutation.PullHistory(); ok {
+		_spec.SetField(bouncer.FieldPullHistory, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedPullHistory(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, bouncer.FieldPullHistory, value)
+		})
+	}
+	if _u.mutation.PullHistoryCleared() {
+		_spec.ClearField(bouncer.FieldPullHistory, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.VersionHistory(); ok {
+		_spec.SetField(bouncer.FieldVersionHistory, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedVersionHistory(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, bouncer.FieldVersionHistory, value)
+		})
+	}
+	if _u.mutation.VersionHistoryCleared() {
+		_spec.ClearField(bouncer.FieldVersionHistory, field.TypeJSON)
+	}
 	_node = &Bouncer{config: _u.config}
 	_spec.Assign = _node.assignValues
 	_spec.ScanValues = _node.scanValues
@@ -26,6 +26,7 @@ import (
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/machine"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/meta"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/metric"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/registrationtoken"
 )
 
 // Client is the client that holds all ent builders.
@@ -55,6 +56,8 @@ type Client struct {
 	Meta *MetaClient
 	// Metric is the client for interacting with the Metric builders.
 	Metric *MetricClient
+	// RegistrationToken is the client for interacting with the RegistrationToken builders.
+	RegistrationToken *RegistrationTokenClient
 }
 
 // NewClient creates a new client configured with the given options.
@@ -77,6 +80,7 @@ func (c *Client) init() {
 	c.Machine = NewMachineClient(c.config)
 	c.Meta = NewMetaClient(c.config)
 	c.Metric = NewMetricClient(c.config)
+	c.RegistrationToken = NewRegistrationTokenClient(c.config)
 }
 
 type (
@@ -167,19 +171,20 @@ func (c *Client) Tx(ctx context.Context) (*Tx, error) {
 	cfg := c.config
 	cfg.driver = tx
 	return &Tx{
-		ctx:           ctx,
-		config:        cfg,
-		Alert:         NewAlertClient(cfg),
-		AllowList:     NewAllowListClient(cfg),
-		AllowListItem: NewAllowListItemClient(cfg),
-		Bouncer:       NewBouncerClient(cfg),
-		ConfigItem:    NewConfigItemClient(cfg),
-		Decision:      NewDecisionClient(cfg),
-		Event:         NewEventClient(cfg),
-		Lock:          NewLockClient(cfg),
-		Machine:       NewMachineClient(cfg),
-		Meta:          NewMetaClient(cfg),
-		Metric:        NewMetricClient(cfg),
+		ctx:               ctx,
+		config:            cfg,
+		Alert:             NewAlertClient(cfg),
+		AllowList:         NewAllowListClient(cfg),
+		AllowListItem:     NewAllowListItemClient(cfg),
+		Bouncer:           NewBouncerClient(cfg),
+		ConfigItem:        NewConfigItemClient(cfg),
+		Decision:          NewDecisionClient(cfg),
+		Event:             NewEventClient(cfg),
+		Lock:              NewLockClient(cfg),
+		Machine:           NewMachineClient(cfg),
+		Meta:              NewMetaClient(cfg),
+		Metric:            NewMetricClient(cfg),
+		RegistrationToken: NewRegistrationTokenClient(cfg),
 	}, nil
 }
 
@@ -197,19 +202,20 @@ func (c *Client) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error)
 	cfg := c.config
 	cfg.driver = &txDriver{tx: tx, drv: c.driver}
 	return &Tx{
-		ctx:           ctx,
-		config:        cfg,
-		Alert:         NewAlertClient(cfg),
-		AllowList:     NewAllowListClient(cfg),
-		AllowListItem: NewAllowListItemClient(cfg),
-		Bouncer:       NewBouncerClient(cfg),
-		ConfigItem:    NewConfigItemClient(cfg),
-		Decision:      NewDecisionClient(cfg),
-		Event:         NewEventClient(cfg),
-		Lock:          NewLockClient(cfg),
-		Machine:       NewMachineClient(cfg),
-		Meta:          NewMetaClient(cfg),
-		Metric:        NewMetricClient(cfg),
+		ctx:               ctx,
+		config:            cfg,
+		Alert:             NewAlertClient(cfg),
+		AllowList:         NewAllowListClient(cfg),
+		AllowListItem:     NewAllowListItemClient(cfg),
+		Bouncer:           NewBouncerClient(cfg),
+		ConfigItem:        NewConfigItemClient(cfg),
+		Decision:          NewDecisionClient(cfg),
+		Event:             NewEventClient(cfg),
+		Lock:              NewLockClient(cfg),
+		Machine:           NewMachineClient(cfg),
+		Meta:              NewMetaClient(cfg),
+		Metric:            NewMetricClient(cfg),
+		RegistrationToken: NewRegistrationTokenClient(cfg),
 	}, nil
 }
 
@@ -240,7 +246,7 @@ func (c *Client) Close() error {
 func (c *Client) Use(hooks ...Hook) {
 	for _, n := range []interface{ Use(...Hook) }{
 		c.Alert, c.AllowList, c.AllowListItem, c.Bouncer, c.ConfigItem, c.Decision,
-		c.Event, c.Lock, c.Machine, c.Meta, c.Metric,
+		c.Event, c.Lock, c.Machine, c.Meta, c.Metric, c.RegistrationToken,
 	} {
 		n.Use(hooks...)
 	}
@@ -251,7 +257,7 @@ func (c *Client) Use(hooks ...Hook) {
 func (c *Client) Intercept(interceptors ...Interceptor) {
 	for _, n := range []interface{ Intercept(...Interceptor) }{
 		c.Alert, c.AllowList, c.AllowListItem, c.Bouncer, c.ConfigItem, c.Decision,
-		c.Event, c.Lock, c.Machine, c.Meta, c.Metric,
+		c.Event, c.Lock, c.Machine, c.Meta, c.Metric, c.RegistrationToken,
 	} {
 		n.Intercept(interceptors...)
 	}
@@ -282,6 +288,8 @@ func (c *Client) Mutate(ctx context.Context, m Mutation) (Value, error) {
 		return c.Meta.mutate(ctx, m)
 	case *MetricMutation:
 		return c.Metric.mutate(ctx, m)
+	case *RegistrationTokenMutation:
+		return c.RegistrationToken.mutate(ctx, m)
 	default:
 		return nil, fmt.Errorf("ent: unknown mutation type %T", m)
 	}
@@ -1910,14 +1918,147 @@ func (c *MetricClient) mutate(ctx context.Context, m *MetricMutation) (Value, er
 	}
 }
 
+// RegistrationTokenClient is a client for the RegistrationToken schema.
+type RegistrationTokenClient struct {
+	config
+}
+
+// NewRegistrationTokenClient returns a client for the RegistrationToken from the given config.
+func NewRegistrationTokenClient(c config) *RegistrationTokenClient {
+	return &RegistrationTokenClient{config: c}
+}
+
+// Use adds a list of mutation hooks to the hooks stack.
+// A call to `Use(f, g, h)` equals to `registrationtoken.Hooks(f(g(h())))`.
+func (c *RegistrationTokenClient) Use(hooks ...Hook) {
+	c.hooks.RegistrationToken = append(c.hooks.RegistrationToken, hooks...)
+}
+
+// Intercept adds a list of query interceptors to the interceptors stack.
+// A call to `Intercept(f, g, h)` equals to `registrationtoken.Intercept(f(g(h())))`.
+func (c *RegistrationTokenClient) Intercept(interceptors ...Interceptor) {
+	c.inters.RegistrationToken = append(c.inters.RegistrationToken, interceptors...)
+}
+
+// Create returns a builder for creating a RegistrationToken entity.
+func (c *RegistrationTokenClient) Create() *RegistrationTokenCreate {
+	mutation := newRegistrationTokenMutation(c.config, OpCreate)
+	return &RegistrationTokenCreate{config: c.config, hooks: c.Hooks(), mutation: mutation}
+}
+
+// CreateBulk returns a builder for creating a bulk of RegistrationToken entities.
+func (c *RegistrationTokenClient) CreateBulk(builders ...*RegistrationTokenCreate) *RegistrationTokenCreateBulk {
+	return &RegistrationTokenCreateBulk{config: c.config, builders: builders}
+}
+
+// MapCreateBulk creates a bulk creation builder from the given slice. For each item in the slice, the function creates
+// a builder and applies setFunc on it.
+func (c *RegistrationTokenClient) MapCreateBulk(slice any, setFunc func(*RegistrationTokenCreate, int)) *RegistrationTokenCreateBulk {
+	rv := reflect.ValueOf(slice)
+	if rv.Kind() != reflect.Slice {
+		return &RegistrationTokenCreateBulk{err: fmt.Errorf("calling to RegistrationTokenClient.MapCreateBulk with wrong type %T, need slice", slice)}
+	}
+	builders := make([]*RegistrationTokenCreate, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		builders[i] = c.Create()
+		setFunc(builders[i], i)
+	}
+	return &RegistrationTokenCreateBulk{config: c.config, builders: builders}
+}
+
+// Update returns an update builder for RegistrationToken.
+func (c *RegistrationTokenClient) Update() *RegistrationTokenUpdate {
+	mutation := newRegistrationTokenMutation(c.config, OpUpdate)
+	return &RegistrationTokenUpdate{config: c.config, hooks: c.Hooks(), mutation: mutation}
+}
+
+// UpdateOne returns an update builder for the given entity.
+func (c *RegistrationTokenClient) UpdateOne(_m *RegistrationToken) *RegistrationTokenUpdateOne {
+	mutation := newRegistrationTokenMutation(c.config, OpUpdateOne, withRegistrationToken(_m))
+	return &RegistrationTokenUpdateOne{config: c.config, hooks: c.Hooks(), mutation: mutation}
+}
+
+// UpdateOneID returns an update builder for the given id.
+func (c *RegistrationTokenClient) UpdateOneID(id int) *RegistrationTokenUpdateOne {
+	mutation := newRegistrationTokenMutation(c.config, OpUpdateOne, withRegistrationTokenID(id))
+	return &RegistrationTokenUpdateOne{config: c.config, hooks: c.Hooks(), mutation: mutation}
+}
+
+// Delete returns a delete builder for RegistrationToken.
+func (c *RegistrationTokenClient) Delete() *RegistrationTokenDelete {
+	mutation := newRegistrationTokenMutation(c.config, OpDelete)
+	return &RegistrationTokenDelete{config: c.config, hooks: c.Hooks(), mutation: mutation}
+}
+
+// DeleteOne returns a builder for deleting the given entity.
+func (c *RegistrationTokenClient) DeleteOne(_m *RegistrationToken) *RegistrationTokenDeleteOne {
+	return c.DeleteOneID(_m.ID)
+}
+
+// DeleteOneID returns a builder for deleting the given entity by its id.
+func (c *RegistrationTokenClient) DeleteOneID(id int) *RegistrationTokenDeleteOne {
+	builder := c.Delete().Where(registrationtoken.ID(id))
+	builder.mutation.id = &id
+	builder.mutation.op = OpDeleteOne
+	return &RegistrationTokenDeleteOne{builder}
+}
+
+// Query returns a query builder for RegistrationToken.
+func (c *RegistrationTokenClient) Query() *RegistrationTokenQuery {
+	return &RegistrationTokenQuery{
+		config: c.config,
+		ctx:    &QueryContext{Type: TypeRegistrationToken},
+		inters: c.Interceptors(),
+	}
+}
+
+// Get returns a RegistrationToken entity by its id.
+func (c *RegistrationTokenClient) Get(ctx context.Context, id int) (*RegistrationToken, error) {
+	return c.Query().Where(registrationtoken.ID(id)).Only(ctx)
+}
+
+// GetX is like Get, but panics if an error occurs.
+func (c *RegistrationTokenClient) GetX(ctx context.Context, id int) *RegistrationToken {
+	obj, err := c.Get(ctx, id)
+	if err != nil {
+		panic(err)
+	}
+	return obj
+}
+
+// Hooks returns the client hooks.
+func (c *RegistrationTokenClient) Hooks() []Hook {
+	return c.hooks.RegistrationToken
+}
+
+// Interceptors returns the client interceptors.
+func (c *RegistrationTokenClient) Interceptors() []Interceptor {
+	return c.inters.RegistrationToken
+}
+
+func (c *RegistrationTokenClient) mutate(ctx context.Context, m *RegistrationTokenMutation) (Value, error) {
+	switch m.Op() {
+	case OpCreate:
+		return (&RegistrationTokenCreate{config: c.config, hooks: c.Hooks(), mutation: m}).Save(ctx)
+	case OpUpdate:
+		return (&RegistrationTokenUpdate{config: c.config, hooks: c.Hooks(), mutation: m}).Save(ctx)
+	case OpUpdateOne:
+		return (&RegistrationTokenUpdateOne{config: c.config, hooks: c.Hooks(), mutation: m}).Save(ctx)
+	case OpDelete, OpDeleteOne:
+		return (&RegistrationTokenDelete{config: c.config, hooks: c.Hooks(), mutation: m}).Exec(ctx)
+	default:
+		return nil, fmt.Errorf("ent: unknown RegistrationToken mutation op: %q", m.Op())
+	}
+}
+
 // hooks and interceptors per client, for fast access.
 type (
 	hooks struct {
 		Alert, AllowList, AllowListItem, Bouncer, ConfigItem, Decision, Event, Lock,
-		Machine, Meta, Metric []ent.Hook
+		Machine, Meta, Metric, RegistrationToken []ent.Hook
 	}
 	inters struct {
 		Alert, AllowList, AllowListItem, Bouncer, ConfigItem, Decision, Event, Lock,
-		Machine, Meta, Metric []ent.Interceptor
+		Machine, Meta, Metric, RegistrationToken []ent.Interceptor
 	}
 )
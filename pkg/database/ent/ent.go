@@ -23,6 +23,7 @@ import (
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/machine"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/meta"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/metric"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/registrationtoken"
 )
 
 // ent aliases to avoid import conflicts in user's code.
@@ -83,17 +84,18 @@ var (
 func checkColumn(t, c string) error {
 	initCheck.Do(func() {
 		columnCheck = sql.NewColumnCheck(map[string]func(string) bool{
-			alert.Table:         alert.ValidColumn,
-			allowlist.Table:     allowlist.ValidColumn,
-			allowlistitem.Table: allowlistitem.ValidColumn,
-			bouncer.Table:       bouncer.ValidColumn,
-			configitem.Table:    configitem.ValidColumn,
-			decision.Table:      decision.ValidColumn,
-			event.Table:         event.ValidColumn,
-			lock.Table:          lock.ValidColumn,
-			machine.Table:       machine.ValidColumn,
-			meta.Table:          meta.ValidColumn,
-			metric.Table:        metric.ValidColumn,
+			alert.Table:             alert.ValidColumn,
+			allowlist.Table:         allowlist.ValidColumn,
+			allowlistitem.Table:     allowlistitem.ValidColumn,
+			bouncer.Table:           bouncer.ValidColumn,
+			configitem.Table:        configitem.ValidColumn,
+			decision.Table:          decision.ValidColumn,
+			event.Table:             event.ValidColumn,
+			lock.Table:              lock.ValidColumn,
+			machine.Table:           machine.ValidColumn,
+			meta.Table:              meta.ValidColumn,
+			metric.Table:            metric.ValidColumn,
+			registrationtoken.Table: registrationtoken.ValidColumn,
 		})
 	})
 	return columnCheck(t, c)
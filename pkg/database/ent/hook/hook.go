@@ -141,6 +141,18 @@ func (f MetricFunc) Mutate(ctx context.Context, m ent.Mutation) (ent.Value, erro
 	return nil, fmt.Errorf("unexpected mutation type %T. expect *ent.MetricMutation", m)
 }
 
+// The RegistrationTokenFunc type is an adapter to allow the use of ordinary
+// function as RegistrationToken mutator.
+type RegistrationTokenFunc func(context.Context, *ent.RegistrationTokenMutation) (ent.Value, error)
+
+// Mutate calls f(ctx, m).
+func (f RegistrationTokenFunc) Mutate(ctx context.Context, m ent.Mutation) (ent.Value, error) {
+	if mv, ok := m.(*ent.RegistrationTokenMutation); ok {
+		return f(ctx, mv)
+	}
+	return nil, fmt.Errorf("unexpected mutation type %T. expect *ent.RegistrationTokenMutation", m)
+}
+
 // Condition is a hook condition function.
 type Condition func(context.Context, ent.Mutation) bool
 
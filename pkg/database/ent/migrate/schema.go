@@ -36,6 +36,7 @@ var (
 		{Name: "uuid", Type: field.TypeString, Nullable: true},
 		{Name: "remediation", Type: field.TypeBool, Nullable: true},
 		{Name: "kind", Type: field.TypeString, Nullable: true},
+		{Name: "labels", Type: field.TypeJSON, Nullable: true},
 		{Name: "machine_alerts", Type: field.TypeInt, Nullable: true},
 	}
 	// AlertsTable holds the schema information for the "alerts" table.
@@ -46,7 +47,7 @@ var (
 		ForeignKeys: []*schema.ForeignKey{
 			{
 				Symbol:     "alerts_machines_alerts",
-				Columns:    []*schema.Column{AlertsColumns[26]},
+				Columns:    []*schema.Column{AlertsColumns[27]},
 				RefColumns: []*schema.Column{MachinesColumns[0]},
 				OnDelete:   schema.SetNull,
 			},
@@ -157,6 +158,8 @@ var (
 		{Name: "osversion", Type: field.TypeString, Nullable: true},
 		{Name: "featureflags", Type: field.TypeString, Nullable: true},
 		{Name: "auto_created", Type: field.TypeBool, Default: false},
+		{Name: "pull_history", Type: field.TypeJSON, Nullable: true},
+		{Name: "version_history", Type: field.TypeJSON, Nullable: true},
 	}
 	// BouncersTable holds the schema information for the "bouncers" table.
 	BouncersTable = &schema.Table{
@@ -377,6 +380,29 @@ var (
 		Columns:    MetricsColumns,
 		PrimaryKey: []*schema.Column{MetricsColumns[0]},
 	}
+	// RegistrationTokensColumns holds the columns for the "registration_tokens" table.
+	RegistrationTokensColumns = []*schema.Column{
+		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "created_at", Type: field.TypeTime},
+		{Name: "expires_at", Type: field.TypeTime},
+		{Name: "token_hash", Type: field.TypeString, Unique: true},
+		{Name: "description", Type: field.TypeString, Nullable: true},
+		{Name: "used_at", Type: field.TypeTime, Nullable: true},
+		{Name: "used_by", Type: field.TypeString, Nullable: true},
+	}
+	// RegistrationTokensTable holds the schema information for the "registration_tokens" table.
+	RegistrationTokensTable = &schema.Table{
+		Name:       "registration_tokens",
+		Columns:    RegistrationTokensColumns,
+		PrimaryKey: []*schema.Column{RegistrationTokensColumns[0]},
+		Indexes: []*schema.Index{
+			{
+				Name:    "registrationtoken_expires_at",
+				Unique:  false,
+				Columns: []*schema.Column{RegistrationTokensColumns[2]},
+			},
+		},
+	}
 	// AllowListAllowlistItemsColumns holds the columns for the "allow_list_allowlist_items" table.
 	AllowListAllowlistItemsColumns = []*schema.Column{
 		{Name: "allow_list_id", Type: field.TypeInt},
@@ -415,6 +441,7 @@ var (
 		MachinesTable,
 		MetaTable,
 		MetricsTable,
+		RegistrationTokensTable,
 		AllowListAllowlistItemsTable,
 	}
 )
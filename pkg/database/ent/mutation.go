@@ -23,6 +23,7 @@ import (
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/meta"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/metric"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/predicate"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/registrationtoken"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/schema"
 )
 
@@ -35,17 +36,18 @@ const (
 	OpUpdateOne = ent.OpUpdateOne
 
 	// Node types.
-	TypeAlert         = "Alert"
-	TypeAllowList     = "AllowList"
-	TypeAllowListItem = "AllowListItem"
-	TypeBouncer       = "Bouncer"
-	TypeConfigItem    = "ConfigItem"
-	TypeDecision      = "Decision"
-	TypeEvent         = "Event"
-	TypeLock          = "Lock"
-	TypeMachine       = "Machine"
-	TypeMeta          = "Meta"
-	TypeMetric        = "Metric"
+	TypeAlert             = "Alert"
+	TypeAllowList         = "AllowList"
+	TypeAllowListItem     = "AllowListItem"
+	TypeBouncer           = "Bouncer"
+	TypeConfigItem        = "ConfigItem"
+	TypeDecision          = "Decision"
+	TypeEvent             = "Event"
+	TypeLock              = "Lock"
+	TypeMachine           = "Machine"
+	TypeMeta              = "Meta"
+	TypeMetric            = "Metric"
+	TypeRegistrationToken = "RegistrationToken"
 )
 
 // AlertMutation represents an operation that mutates the Alert nodes in the graph.
@@ -83,6 +85,8 @@ type AlertMutation struct {
 	uuid               *string
 	remediation        *bool
 	kind               *string
+	labels             *[]string
+	appendlabels       []string
 	clearedFields      map[string]struct{}
 	owner              *int
 	clearedowner       bool
@@ -1455,6 +1459,71 @@ func (m *AlertMutation) ResetKind() {
 	delete(m.clearedFields, alert.FieldKind)
 }
 
+// SetLabels sets the "labels" field.
+func (m *AlertMutation) SetLabels(s []string) {
+	m.labels = &s
+	m.appendlabels = nil
+}
+
+// Labels returns the value of the "labels" field in the mutation.
+func (m *AlertMutation) Labels() (r []string, exists bool) {
+	v := m.labels
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldLabels returns the old "labels" field's value of the Alert entity.
+// If the Alert object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *AlertMutation) OldLabels(ctx context.Context) (v []string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldLabels is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldLabels requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldLabels: %w", err)
+	}
+	return oldValue.Labels, nil
+}
+
+// AppendLabels adds s to the "labels" field.
+func (m *AlertMutation) AppendLabels(s []string) {
+	m.appendlabels = append(m.appendlabels, s...)
+}
+
+// AppendedLabels returns the list of values that were appended to the "labels" field in this mutation.
+func (m *AlertMutation) AppendedLabels() ([]string, bool) {
+	if len(m.appendlabels) == 0 {
+		return nil, false
+	}
+	return m.appendlabels, true
+}
+
+// ClearLabels clears the value of the "labels" field.
+func (m *AlertMutation) ClearLabels() {
+	m.labels = nil
+	m.appendlabels = nil
+	m.clearedFields[alert.FieldLabels] = struct{}{}
+}
+
+// LabelsCleared returns if the "labels" field was cleared in this mutation.
+func (m *AlertMutation) LabelsCleared() bool {
+	_, ok := m.clearedFields[alert.FieldLabels]
+	return ok
+}
+
+// ResetLabels resets all changes to the "labels" field.
+func (m *AlertMutation) ResetLabels() {
+	m.labels = nil
+	m.appendlabels = nil
+	delete(m.clearedFields, alert.FieldLabels)
+}
+
 // SetOwnerID sets the "owner" edge to the Machine entity by id.
 func (m *AlertMutation) SetOwnerID(id int) {
 	m.owner = &id
@@ -1690,7 +1759,7 @@ func (m *AlertMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *AlertMutation) Fields() []string {
-	fields := make([]string, 0, 25)
+	fields := make([]string, 0, 26)
 	if m.created_at != nil {
 		fields = append(fields, alert.FieldCreatedAt)
 	}
@@ -1766,6 +1835,9 @@ func (m *AlertMutation) Fields() []string {
 	if m.kind != nil {
 		fields = append(fields, alert.FieldKind)
 	}
+	if m.labels != nil {
+		fields = append(fields, alert.FieldLabels)
+	}
 	return fields
 }
 
@@ -1824,6 +1896,8 @@ func (m *AlertMutation) Field(name string) (ent.Value, bool) {
 		return m.Remediation()
 	case alert.FieldKind:
 		return m.Kind()
+	case alert.FieldLabels:
+		return m.Labels()
 	}
 	return nil, false
 }
@@ -1883,6 +1957,8 @@ func (m *AlertMutation) OldField(ctx context.Context, name string) (ent.Value, e
 		return m.OldRemediation(ctx)
 	case alert.FieldKind:
 		return m.OldKind(ctx)
+	case alert.FieldLabels:
+		return m.OldLabels(ctx)
 	}
 	return nil, fmt.Errorf("unknown Alert field %s", name)
 }
@@ -2067,6 +2143,13 @@ func (m *AlertMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetKind(v)
 		return nil
+	case alert.FieldLabels:
+		v, ok := value.([]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetLabels(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Alert field %s", name)
 }
@@ -2211,6 +2294,9 @@ func (m *AlertMutation) ClearedFields() []string {
 	if m.FieldCleared(alert.FieldKind) {
 		fields = append(fields, alert.FieldKind)
 	}
+	if m.FieldCleared(alert.FieldLabels) {
+		fields = append(fields, alert.FieldLabels)
+	}
 	return fields
 }
 
@@ -2288,6 +2374,9 @@ func (m *AlertMutation) ClearField(name string) error {
 	case alert.FieldKind:
 		m.ClearKind()
 		return nil
+	case alert.FieldLabels:
+		m.ClearLabels()
+		return nil
 	}
 	return fmt.Errorf("unknown Alert nullable field %s", name)
 }
@@ -2371,6 +2460,9 @@ func (m *AlertMutation) ResetField(name string) error {
 	case alert.FieldKind:
 		m.ResetKind()
 		return nil
+	case alert.FieldLabels:
+		m.ResetLabels()
+		return nil
 	}
 	return fmt.Errorf("unknown Alert field %s", name)
 }
@@ -4476,28 +4568,32 @@ func (m *AllowListItemMutation) ResetEdge(name string) error {
 // BouncerMutation represents an operation that mutates the Bouncer nodes in the graph.
 type BouncerMutation struct {
 	config
-	op            Op
-	typ           string
-	id            *int
-	created_at    *time.Time
-	updated_at    *time.Time
-	name          *string
-	api_key       *string
-	revoked       *bool
-	ip_address    *string
-	_type         *string
-	version       *string
-	last_pull     *time.Time
-	auth_type     *string
-	osname        *string
-	osfamily      *string
-	osversion     *string
-	featureflags  *string
-	auto_created  *bool
-	clearedFields map[string]struct{}
-	done          bool
-	oldValue      func(context.Context) (*Bouncer, error)
-	predicates    []predicate.Bouncer
+	op                    Op
+	typ                   string
+	id                    *int
+	created_at            *time.Time
+	updated_at            *time.Time
+	name                  *string
+	api_key               *string
+	revoked               *bool
+	ip_address            *string
+	_type                 *string
+	version               *string
+	last_pull             *time.Time
+	auth_type             *string
+	osname                *string
+	osfamily              *string
+	osversion             *string
+	featureflags          *string
+	auto_created          *bool
+	pull_history          *[]schema.BouncerPull
+	appendpull_history    []schema.BouncerPull
+	version_history       *[]schema.BouncerVersion
+	appendversion_history []schema.BouncerVersion
+	clearedFields         map[string]struct{}
+	done                  bool
+	oldValue              func(context.Context) (*Bouncer, error)
+	predicates            []predicate.Bouncer
 }
 
 var _ ent.Mutation = (*BouncerMutation)(nil)
@@ -5242,6 +5338,136 @@ func (m *BouncerMutation) ResetAutoCreated() {
 	m.auto_created = nil
 }
 
+// SetPullHistory sets the "pull_history" field.
+func (m *BouncerMutation) SetPullHistory(sp []schema.BouncerPull) {
+	m.pull_history = &sp
+	m.appendpull_history = nil
+}
+
+// PullHistory returns the value of the "pull_history" field in the mutation.
+func (m *BouncerMutation) PullHistory() (r []schema.BouncerPull, exists bool) {
+	v := m.pull_history
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldPullHistory returns the old "pull_history" field's value of the Bouncer entity.
+// If the Bouncer object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *BouncerMutation) OldPullHistory(ctx context.Context) (v []schema.BouncerPull, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldPullHistory is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldPullHistory requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldPullHistory: %w", err)
+	}
+	return oldValue.PullHistory, nil
+}
+
+// AppendPullHistory adds sp to the "pull_history" field.
+func (m *BouncerMutation) AppendPullHistory(sp []schema.BouncerPull) {
+	m.appendpull_history = append(m.appendpull_history, sp...)
+}
+
+// AppendedPullHistory returns the list of values that were appended to the "pull_history" field in this mutation.
+func (m *BouncerMutation) AppendedPullHistory() ([]schema.BouncerPull, bool) {
+	if len(m.appendpull_history) == 0 {
+		return nil, false
+	}
+	return m.appendpull_history, true
+}
+
+// ClearPullHistory clears the value of the "pull_history" field.
+func (m *BouncerMutation) ClearPullHistory() {
+	m.pull_history = nil
+	m.appendpull_history = nil
+	m.clearedFields[bouncer.FieldPullHistory] = struct{}{}
+}
+
+// PullHistoryCleared returns if the "pull_history" field was cleared in this mutation.
+func (m *BouncerMutation) PullHistoryCleared() bool {
+	_, ok := m.clearedFields[bouncer.FieldPullHistory]
+	return ok
+}
+
+// ResetPullHistory resets all changes to the "pull_history" field.
+func (m *BouncerMutation) ResetPullHistory() {
+	m.pull_history = nil
+	m.appendpull_history = nil
+	delete(m.clearedFields, bouncer.FieldPullHistory)
+}
+
+// SetVersionHistory sets the "version_history" field.
+func (m *BouncerMutation) SetVersionHistory(sv []schema.BouncerVersion) {
+	m.version_history = &sv
+	m.appendversion_history = nil
+}
+
+// VersionHistory returns the value of the "version_history" field in the mutation.
+func (m *BouncerMutation) VersionHistory() (r []schema.BouncerVersion, exists bool) {
+	v := m.version_history
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldVersionHistory returns the old "version_history" field's value of the Bouncer entity.
+// If the Bouncer object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *BouncerMutation) OldVersionHistory(ctx context.Context) (v []schema.BouncerVersion, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldVersionHistory is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldVersionHistory requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldVersionHistory: %w", err)
+	}
+	return oldValue.VersionHistory, nil
+}
+
+// AppendVersionHistory adds sv to the "version_history" field.
+func (m *BouncerMutation) AppendVersionHistory(sv []schema.BouncerVersion) {
+	m.appendversion_history = append(m.appendversion_history, sv...)
+}
+
+// AppendedVersionHistory returns the list of values that were appended to the "version_history" field in this mutation.
+func (m *BouncerMutation) AppendedVersionHistory() ([]schema.BouncerVersion, bool) {
+	if len(m.appendversion_history) == 0 {
+		return nil, false
+	}
+	return m.appendversion_history, true
+}
+
+// ClearVersionHistory clears the value of the "version_history" field.
+func (m *BouncerMutation) ClearVersionHistory() {
+	m.version_history = nil
+	m.appendversion_history = nil
+	m.clearedFields[bouncer.FieldVersionHistory] = struct{}{}
+}
+
+// VersionHistoryCleared returns if the "version_history" field was cleared in this mutation.
+func (m *BouncerMutation) VersionHistoryCleared() bool {
+	_, ok := m.clearedFields[bouncer.FieldVersionHistory]
+	return ok
+}
+
+// ResetVersionHistory resets all changes to the "version_history" field.
+func (m *BouncerMutation) ResetVersionHistory() {
+	m.version_history = nil
+	m.appendversion_history = nil
+	delete(m.clearedFields, bouncer.FieldVersionHistory)
+}
+
 // Where appends a list predicates to the BouncerMutation builder.
 func (m *BouncerMutation) Where(ps ...predicate.Bouncer) {
 	m.predicates = append(m.predicates, ps...)
@@ -5276,7 +5502,7 @@ func (m *BouncerMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *BouncerMutation) Fields() []string {
-	fields := make([]string, 0, 15)
+	fields := make([]string, 0, 17)
 	if m.created_at != nil {
 		fields = append(fields, bouncer.FieldCreatedAt)
 	}
@@ -5322,6 +5548,12 @@ func (m *BouncerMutation) Fields() []string {
 	if m.auto_created != nil {
 		fields = append(fields, bouncer.FieldAutoCreated)
 	}
+	if m.pull_history != nil {
+		fields = append(fields, bouncer.FieldPullHistory)
+	}
+	if m.version_history != nil {
+		fields = append(fields, bouncer.FieldVersionHistory)
+	}
 	return fields
 }
 
@@ -5360,6 +5592,10 @@ func (m *BouncerMutation) Field(name string) (ent.Value, bool) {
 		return m.Featureflags()
 	case bouncer.FieldAutoCreated:
 		return m.AutoCreated()
+	case bouncer.FieldPullHistory:
+		return m.PullHistory()
+	case bouncer.FieldVersionHistory:
+		return m.VersionHistory()
 	}
 	return nil, false
 }
@@ -5399,6 +5635,10 @@ func (m *BouncerMutation) OldField(ctx context.Context, name string) (ent.Value,
 		return m.OldFeatureflags(ctx)
 	case bouncer.FieldAutoCreated:
 		return m.OldAutoCreated(ctx)
+	case bouncer.FieldPullHistory:
+		return m.OldPullHistory(ctx)
+	case bouncer.FieldVersionHistory:
+		return m.OldVersionHistory(ctx)
 	}
 	return nil, fmt.Errorf("unknown Bouncer field %s", name)
 }
@@ -5513,6 +5753,20 @@ func (m *BouncerMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetAutoCreated(v)
 		return nil
+	case bouncer.FieldPullHistory:
+		v, ok := value.([]schema.BouncerPull)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetPullHistory(v)
+		return nil
+	case bouncer.FieldVersionHistory:
+		v, ok := value.([]schema.BouncerVersion)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetVersionHistory(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Bouncer field %s", name)
 }
@@ -5567,6 +5821,12 @@ func (m *BouncerMutation) ClearedFields() []string {
 	if m.FieldCleared(bouncer.FieldFeatureflags) {
 		fields = append(fields, bouncer.FieldFeatureflags)
 	}
+	if m.FieldCleared(bouncer.FieldPullHistory) {
+		fields = append(fields, bouncer.FieldPullHistory)
+	}
+	if m.FieldCleared(bouncer.FieldVersionHistory) {
+		fields = append(fields, bouncer.FieldVersionHistory)
+	}
 	return fields
 }
 
@@ -5605,6 +5865,12 @@ func (m *BouncerMutation) ClearField(name string) error {
 	case bouncer.FieldFeatureflags:
 		m.ClearFeatureflags()
 		return nil
+	case bouncer.FieldPullHistory:
+		m.ClearPullHistory()
+		return nil
+	case bouncer.FieldVersionHistory:
+		m.ClearVersionHistory()
+		return nil
 	}
 	return fmt.Errorf("unknown Bouncer nullable field %s", name)
 }
@@ -5658,6 +5924,12 @@ func (m *BouncerMutation) ResetField(name string) error {
 	case bouncer.FieldAutoCreated:
 		m.ResetAutoCreated()
 		return nil
+	case bouncer.FieldPullHistory:
+		m.ResetPullHistory()
+		return nil
+	case bouncer.FieldVersionHistory:
+		m.ResetVersionHistory()
+		return nil
 	}
 	return fmt.Errorf("unknown Bouncer field %s", name)
 }
@@ -11416,3 +11688,659 @@ func (m *MetricMutation) ClearEdge(name string) error {
 func (m *MetricMutation) ResetEdge(name string) error {
 	return fmt.Errorf("unknown Metric edge %s", name)
 }
+
+// RegistrationTokenMutation represents an operation that mutates the RegistrationToken nodes in the graph.
+type RegistrationTokenMutation struct {
+	config
+	op            Op
+	typ           string
+	id            *int
+	created_at    *time.Time
+	expires_at    *time.Time
+	token_hash    *string
+	description   *string
+	used_at       *time.Time
+	used_by       *string
+	clearedFields map[string]struct{}
+	done          bool
+	oldValue      func(context.Context) (*RegistrationToken, error)
+	predicates    []predicate.RegistrationToken
+}
+
+var _ ent.Mutation = (*RegistrationTokenMutation)(nil)
+
+// registrationtokenOption allows management of the mutation configuration using functional options.
+type registrationtokenOption func(*RegistrationTokenMutation)
+
+// newRegistrationTokenMutation creates new mutation for the RegistrationToken entity.
+func newRegistrationTokenMutation(c config, op Op, opts ...registrationtokenOption) *RegistrationTokenMutation {
+	m := &RegistrationTokenMutation{
+		config:        c,
+		op:            op,
+		typ:           TypeRegistrationToken,
+		clearedFields: make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// withRegistrationTokenID sets the ID field of the mutation.
+func withRegistrationTokenID(id int) registrationtokenOption {
+	return func(m *RegistrationTokenMutation) {
+		var (
+			err   error
+			once  sync.Once
+			value *RegistrationToken
+		)
+		m.oldValue = func(ctx context.Context) (*RegistrationToken, error) {
+			once.Do(func() {
+				if m.done {
+					err = errors.New("querying old values post mutation is not allowed")
+				} else {
+					value, err = m.Client().RegistrationToken.Get(ctx, id)
+				}
+			})
+			return value, err
+		}
+		m.id = &id
+	}
+}
+
+// withRegistrationToken sets the old RegistrationToken of the mutation.
+func withRegistrationToken(node *RegistrationToken) registrationtokenOption {
+	return func(m *RegistrationTokenMutation) {
+		m.oldValue = func(context.Context) (*RegistrationToken, error) {
+			return node, nil
+		}
+		m.id = &node.ID
+	}
+}
+
+// Client returns a new `ent.Client` from the mutation. If the mutation was
+// executed in a transaction (ent.Tx), a transactional client is returned.
+func (m RegistrationTokenMutation) Client() *Client {
+	client := &Client{config: m.config}
+	client.init()
+	return client
+}
+
+// Tx returns an `ent.Tx` for mutations that were executed in transactions;
+// it returns an error otherwise.
+func (m RegistrationTokenMutation) Tx() (*Tx, error) {
+	if _, ok := m.driver.(*txDriver); !ok {
+		return nil, errors.New("ent: mutation is not running in a transaction")
+	}
+	tx := &Tx{config: m.config}
+	tx.init()
+	return tx, nil
+}
+
+// ID returns the ID value in the mutation. Note that the ID is only available
+// if it was provided to the builder or after it was returned from the database.
+func (m *RegistrationTokenMutation) ID() (id int, exists bool) {
+	if m.id == nil {
+		return
+	}
+	return *m.id, true
+}
+
+// IDs queries the database and returns the entity ids that match the mutation's predicate.
+// That means, if the mutation is applied within a transaction with an isolation level such
+// as sql.LevelSerializable, the returned ids match the ids of the rows that will be updated
+// or updated by the mutation.
+func (m *RegistrationTokenMutation) IDs(ctx context.Context) ([]int, error) {
+	switch {
+	case m.op.Is(OpUpdateOne | OpDeleteOne):
+		id, exists := m.ID()
+		if exists {
+			return []int{id}, nil
+		}
+		fallthrough
+	case m.op.Is(OpUpdate | OpDelete):
+		return m.Client().RegistrationToken.Query().Where(m.predicates...).IDs(ctx)
+	default:
+		return nil, fmt.Errorf("IDs is not allowed on %s operations", m.op)
+	}
+}
+
+// SetCreatedAt sets the "created_at" field.
+func (m *RegistrationTokenMutation) SetCreatedAt(t time.Time) {
+	m.created_at = &t
+}
+
+// CreatedAt returns the value of the "created_at" field in the mutation.
+func (m *RegistrationTokenMutation) CreatedAt() (r time.Time, exists bool) {
+	v := m.created_at
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldCreatedAt returns the old "created_at" field's value of the RegistrationToken entity.
+// If the RegistrationToken object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *RegistrationTokenMutation) OldCreatedAt(ctx context.Context) (v time.Time, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldCreatedAt is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldCreatedAt requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldCreatedAt: %w", err)
+	}
+	return oldValue.CreatedAt, nil
+}
+
+// ResetCreatedAt resets all changes to the "created_at" field.
+func (m *RegistrationTokenMutation) ResetCreatedAt() {
+	m.created_at = nil
+}
+
+// SetExpiresAt sets the "expires_at" field.
+func (m *RegistrationTokenMutation) SetExpiresAt(t time.Time) {
+	m.expires_at = &t
+}
+
+// ExpiresAt returns the value of the "expires_at" field in the mutation.
+func (m *RegistrationTokenMutation) ExpiresAt() (r time.Time, exists bool) {
+	v := m.expires_at
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldExpiresAt returns the old "expires_at" field's value of the RegistrationToken entity.
+// If the RegistrationToken object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *RegistrationTokenMutation) OldExpiresAt(ctx context.Context) (v time.Time, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldExpiresAt is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldExpiresAt requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldExpiresAt: %w", err)
+	}
+	return oldValue.ExpiresAt, nil
+}
+
+// ResetExpiresAt resets all changes to the "expires_at" field.
+func (m *RegistrationTokenMutation) ResetExpiresAt() {
+	m.expires_at = nil
+}
+
+// SetTokenHash sets the "token_hash" field.
+func (m *RegistrationTokenMutation) SetTokenHash(s string) {
+	m.token_hash = &s
+}
+
+// TokenHash returns the value of the "token_hash" field in the mutation.
+func (m *RegistrationTokenMutation) TokenHash() (r string, exists bool) {
+	v := m.token_hash
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldTokenHash returns the old "token_hash" field's value of the RegistrationToken entity.
+// If the RegistrationToken object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *RegistrationTokenMutation) OldTokenHash(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldTokenHash is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldTokenHash requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldTokenHash: %w", err)
+	}
+	return oldValue.TokenHash, nil
+}
+
+// ResetTokenHash resets all changes to the "token_hash" field.
+func (m *RegistrationTokenMutation) ResetTokenHash() {
+	m.token_hash = nil
+}
+
+// SetDescription sets the "description" field.
+func (m *RegistrationTokenMutation) SetDescription(s string) {
+	m.description = &s
+}
+
+// Description returns the value of the "description" field in the mutation.
+func (m *RegistrationTokenMutation) Description() (r string, exists bool) {
+	v := m.description
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldDescription returns the old "description" field's value of the RegistrationToken entity.
+// If the RegistrationToken object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *RegistrationTokenMutation) OldDescription(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldDescription is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldDescription requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldDescription: %w", err)
+	}
+	return oldValue.Description, nil
+}
+
+// ClearDescription clears the value of the "description" field.
+func (m *RegistrationTokenMutation) ClearDescription() {
+	m.description = nil
+	m.clearedFields[registrationtoken.FieldDescription] = struct{}{}
+}
+
+// DescriptionCleared returns if the "description" field was cleared in this mutation.
+func (m *RegistrationTokenMutation) DescriptionCleared() bool {
+	_, ok := m.clearedFields[registrationtoken.FieldDescription]
+	return ok
+}
+
+// ResetDescription resets all changes to the "description" field.
+func (m *RegistrationTokenMutation) ResetDescription() {
+	m.description = nil
+	delete(m.clearedFields, registrationtoken.FieldDescription)
+}
+
+// SetUsedAt sets the "used_at" field.
+func (m *RegistrationTokenMutation) SetUsedAt(t time.Time) {
+	m.used_at = &t
+}
+
+// UsedAt returns the value of the "used_at" field in the mutation.
+func (m *RegistrationTokenMutation) UsedAt() (r time.Time, exists bool) {
+	v := m.used_at
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldUsedAt returns the old "used_at" field's value of the RegistrationToken entity.
+// If the RegistrationToken object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *RegistrationTokenMutation) OldUsedAt(ctx context.Context) (v *time.Time, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldUsedAt is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldUsedAt requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldUsedAt: %w", err)
+	}
+	return oldValue.UsedAt, nil
+}
+
+// ClearUsedAt clears the value of the "used_at" field.
+func (m *RegistrationTokenMutation) ClearUsedAt() {
+	m.used_at = nil
+	m.clearedFields[registrationtoken.FieldUsedAt] = struct{}{}
+}
+
+// UsedAtCleared returns if the "used_at" field was cleared in this mutation.
+func (m *RegistrationTokenMutation) UsedAtCleared() bool {
+	_, ok := m.clearedFields[registrationtoken.FieldUsedAt]
+	return ok
+}
+
+// ResetUsedAt resets all changes to the "used_at" field.
+func (m *RegistrationTokenMutation) ResetUsedAt() {
+	m.used_at = nil
+	delete(m.clearedFields, registrationtoken.FieldUsedAt)
+}
+
+// SetUsedBy sets the "used_by" field.
+func (m *RegistrationTokenMutation) SetUsedBy(s string) {
+	m.used_by = &s
+}
+
+// UsedBy returns the value of the "used_by" field in the mutation.
+func (m *RegistrationTokenMutation) UsedBy() (r string, exists bool) {
+	v := m.used_by
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldUsedBy returns the old "used_by" field's value of the RegistrationToken entity.
+// If the RegistrationToken object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *RegistrationTokenMutation) OldUsedBy(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldUsedBy is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldUsedBy requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldUsedBy: %w", err)
+	}
+	return oldValue.UsedBy, nil
+}
+
+// ClearUsedBy clears the value of the "used_by" field.
+func (m *RegistrationTokenMutation) ClearUsedBy() {
+	m.used_by = nil
+	m.clearedFields[registrationtoken.FieldUsedBy] = struct{}{}
+}
+
+// UsedByCleared returns if the "used_by" field was cleared in this mutation.
+func (m *RegistrationTokenMutation) UsedByCleared() bool {
+	_, ok := m.clearedFields[registrationtoken.FieldUsedBy]
+	return ok
+}
+
+// ResetUsedBy resets all changes to the "used_by" field.
+func (m *RegistrationTokenMutation) ResetUsedBy() {
+	m.used_by = nil
+	delete(m.clearedFields, registrationtoken.FieldUsedBy)
+}
+
+// Where appends a list predicates to the RegistrationTokenMutation builder.
+func (m *RegistrationTokenMutation) Where(ps ...predicate.RegistrationToken) {
+	m.predicates = append(m.predicates, ps...)
+}
+
+// WhereP appends storage-level predicates to the RegistrationTokenMutation builder. Using this method,
+// users can use type-assertion to append predicates that do not depend on any generated package.
+func (m *RegistrationTokenMutation) WhereP(ps ...func(*sql.Selector)) {
+	p := make([]predicate.RegistrationToken, len(ps))
+	for i := range ps {
+		p[i] = ps[i]
+	}
+	m.Where(p...)
+}
+
+// Op returns the operation name.
+func (m *RegistrationTokenMutation) Op() Op {
+	return m.op
+}
+
+// SetOp allows setting the mutation operation.
+func (m *RegistrationTokenMutation) SetOp(op Op) {
+	m.op = op
+}
+
+// Type returns the node type of this mutation (RegistrationToken).
+func (m *RegistrationTokenMutation) Type() string {
+	return m.typ
+}
+
+// Fields returns all fields that were changed during this mutation. Note that in
+// order to get all numeric fields that were incremented/decremented, call
+// AddedFields().
+func (m *RegistrationTokenMutation) Fields() []string {
+	fields := make([]string, 0, 6)
+	if m.created_at != nil {
+		fields = append(fields, registrationtoken.FieldCreatedAt)
+	}
+	if m.expires_at != nil {
+		fields = append(fields, registrationtoken.FieldExpiresAt)
+	}
+	if m.token_hash != nil {
+		fields = append(fields, registrationtoken.FieldTokenHash)
+	}
+	if m.description != nil {
+		fields = append(fields, registrationtoken.FieldDescription)
+	}
+	if m.used_at != nil {
+		fields = append(fields, registrationtoken.FieldUsedAt)
+	}
+	if m.used_by != nil {
+		fields = append(fields, registrationtoken.FieldUsedBy)
+	}
+	return fields
+}
+
+// Field returns the value of a field with the given name. The second boolean
+// return value indicates that this field was not set, or was not defined in the
+// schema.
+func (m *RegistrationTokenMutation) Field(name string) (ent.Value, bool) {
+	switch name {
+	case registrationtoken.FieldCreatedAt:
+		return m.CreatedAt()
+	case registrationtoken.FieldExpiresAt:
+		return m.ExpiresAt()
+	case registrationtoken.FieldTokenHash:
+		return m.TokenHash()
+	case registrationtoken.FieldDescription:
+		return m.Description()
+	case registrationtoken.FieldUsedAt:
+		return m.UsedAt()
+	case registrationtoken.FieldUsedBy:
+		return m.UsedBy()
+	}
+	return nil, false
+}
+
+// OldField returns the old value of the field from the database. An error is
+// returned if the mutation operation is not UpdateOne, or the query to the
+// database failed.
+func (m *RegistrationTokenMutation) OldField(ctx context.Context, name string) (ent.Value, error) {
+	switch name {
+	case registrationtoken.FieldCreatedAt:
+		return m.OldCreatedAt(ctx)
+	case registrationtoken.FieldExpiresAt:
+		return m.OldExpiresAt(ctx)
+	case registrationtoken.FieldTokenHash:
+		return m.OldTokenHash(ctx)
+	case registrationtoken.FieldDescription:
+		return m.OldDescription(ctx)
+	case registrationtoken.FieldUsedAt:
+		return m.OldUsedAt(ctx)
+	case registrationtoken.FieldUsedBy:
+		return m.OldUsedBy(ctx)
+	}
+	return nil, fmt.Errorf("unknown RegistrationToken field %s", name)
+}
+
+// SetField sets the value of a field with the given name. It returns an error if
+// the field is not defined in the schema, or if the type mismatched the field
+// type.
+func (m *RegistrationTokenMutation) SetField(name string, value ent.Value) error {
+	switch name {
+	case registrationtoken.FieldCreatedAt:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetCreatedAt(v)
+		return nil
+	case registrationtoken.FieldExpiresAt:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetExpiresAt(v)
+		return nil
+	case registrationtoken.FieldTokenHash:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetTokenHash(v)
+		return nil
+	case registrationtoken.FieldDescription:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetDescription(v)
+		return nil
+	case registrationtoken.FieldUsedAt:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetUsedAt(v)
+		return nil
+	case registrationtoken.FieldUsedBy:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetUsedBy(v)
+		return nil
+	}
+	return fmt.Errorf("unknown RegistrationToken field %s", name)
+}
+
+// AddedFields returns all numeric fields that were incremented/decremented during
+// this mutation.
+func (m *RegistrationTokenMutation) AddedFields() []string {
+	return nil
+}
+
+// AddedField returns the numeric value that was incremented/decremented on a field
+// with the given name. The second boolean return value indicates that this field
+// was not set, or was not defined in the schema.
+func (m *RegistrationTokenMutation) AddedField(name string) (ent.Value, bool) {
+	return nil, false
+}
+
+// AddField adds the value to the field with the given name. It returns an error if
+// the field is not defined in the schema, or if the type mismatched the field
+// type.
+func (m *RegistrationTokenMutation) AddField(name string, value ent.Value) error {
+	switch name {
+	}
+	return fmt.Errorf("unknown RegistrationToken numeric field %s", name)
+}
+
+// ClearedFields returns all nullable fields that were cleared during this
+// mutation.
+func (m *RegistrationTokenMutation) ClearedFields() []string {
+	var fields []string
+	if m.FieldCleared(registrationtoken.FieldDescription) {
+		fields = append(fields, registrationtoken.FieldDescription)
+	}
+	if m.FieldCleared(registrationtoken.FieldUsedAt) {
+		fields = append(fields, registrationtoken.FieldUsedAt)
+	}
+	if m.FieldCleared(registrationtoken.FieldUsedBy) {
+		fields = append(fields, registrationtoken.FieldUsedBy)
+	}
+	return fields
+}
+
+// FieldCleared returns a boolean indicating if a field with the given name was
+// cleared in this mutation.
+func (m *RegistrationTokenMutation) FieldCleared(name string) bool {
+	_, ok := m.clearedFields[name]
+	return ok
+}
+
+// ClearField clears the value of the field with the given name. It returns an
+// error if the field is not defined in the schema.
+func (m *RegistrationTokenMutation) ClearField(name string) error {
+	switch name {
+	case registrationtoken.FieldDescription:
+		m.ClearDescription()
+		return nil
+	case registrationtoken.FieldUsedAt:
+		m.ClearUsedAt()
+		return nil
+	case registrationtoken.FieldUsedBy:
+		m.ClearUsedBy()
+		return nil
+	}
+	return fmt.Errorf("unknown RegistrationToken nullable field %s", name)
+}
+
+// ResetField resets all changes in the mutation for the field with the given name.
+// It returns an error if the field is not defined in the schema.
+func (m *RegistrationTokenMutation) ResetField(name string) error {
+	switch name {
+	case registrationtoken.FieldCreatedAt:
+		m.ResetCreatedAt()
+		return nil
+	case registrationtoken.FieldExpiresAt:
+		m.ResetExpiresAt()
+		return nil
+	case registrationtoken.FieldTokenHash:
+		m.ResetTokenHash()
+		return nil
+	case registrationtoken.FieldDescription:
+		m.ResetDescription()
+		return nil
+	case registrationtoken.FieldUsedAt:
+		m.ResetUsedAt()
+		return nil
+	case registrationtoken.FieldUsedBy:
+		m.ResetUsedBy()
+		return nil
+	}
+	return fmt.Errorf("unknown RegistrationToken field %s", name)
+}
+
+// AddedEdges returns all edge names that were set/added in this mutation.
+func (m *RegistrationTokenMutation) AddedEdges() []string {
+	edges := make([]string, 0, 0)
+	return edges
+}
+
+// AddedIDs returns all IDs (to other nodes) that were added for the given edge
+// name in this mutation.
+func (m *RegistrationTokenMutation) AddedIDs(name string) []ent.Value {
+	return nil
+}
+
+// RemovedEdges returns all edge names that were removed in this mutation.
+func (m *RegistrationTokenMutation) RemovedEdges() []string {
+	edges := make([]string, 0, 0)
+	return edges
+}
+
+// RemovedIDs returns all IDs (to other nodes) that were removed for the edge with
+// the given name in this mutation.
+func (m *RegistrationTokenMutation) RemovedIDs(name string) []ent.Value {
+	return nil
+}
+
+// ClearedEdges returns all edge names that were cleared in this mutation.
+func (m *RegistrationTokenMutation) ClearedEdges() []string {
+	edges := make([]string, 0, 0)
+	return edges
+}
+
+// EdgeCleared returns a boolean which indicates if the edge with the given name
+// was cleared in this mutation.
+func (m *RegistrationTokenMutation) EdgeCleared(name string) bool {
+	return false
+}
+
+// ClearEdge clears the value of the edge with the given name. It returns an error
+// if that edge is not defined in the schema.
+func (m *RegistrationTokenMutation) ClearEdge(name string) error {
+	return fmt.Errorf("unknown RegistrationToken unique edge %s", name)
+}
+
+// ResetEdge resets all changes to the edge with the given name in this mutation.
+// It returns an error if the edge is not defined in the schema.
+func (m *RegistrationTokenMutation) ResetEdge(name string) error {
+	return fmt.Errorf("unknown RegistrationToken edge %s", name)
+}
@@ -38,3 +38,6 @@ type Meta func(*sql.Selector)
 
 // Metric is the predicate function for metric builders.
 type Metric func(*sql.Selector)
+
+// RegistrationToken is the predicate function for registrationtoken builders.
+type RegistrationToken func(*sql.Selector)
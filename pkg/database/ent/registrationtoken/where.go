@@ -0,0 +1,445 @@
+// Code generated by ent, DO NOT EDIT.
+
+package registrationtoken
+
+import (
+	"time"
+
+	"entgo.io/ent/dialect/sql"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/predicate"
+)
+
+// ID filters vertices based on their ID field.
+func ID(id int) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEQ(FieldID, id))
+}
+
+// IDEQ applies the EQ predicate on the ID field.
+func IDEQ(id int) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEQ(FieldID, id))
+}
+
+// IDNEQ applies the NEQ predicate on the ID field.
+func IDNEQ(id int) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNEQ(FieldID, id))
+}
+
+// IDIn applies the In predicate on the ID field.
+func IDIn(ids ...int) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldIn(FieldID, ids...))
+}
+
+// IDNotIn applies the NotIn predicate on the ID field.
+func IDNotIn(ids ...int) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNotIn(FieldID, ids...))
+}
+
+// IDGT applies the GT predicate on the ID field.
+func IDGT(id int) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldGT(FieldID, id))
+}
+
+// IDGTE applies the GTE predicate on the ID field.
+func IDGTE(id int) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldGTE(FieldID, id))
+}
+
+// IDLT applies the LT predicate on the ID field.
+func IDLT(id int) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldLT(FieldID, id))
+}
+
+// IDLTE applies the LTE predicate on the ID field.
+func IDLTE(id int) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldLTE(FieldID, id))
+}
+
+// CreatedAt applies equality check predicate on the "created_at" field. It's identical to CreatedAtEQ.
+func CreatedAt(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEQ(FieldCreatedAt, v))
+}
+
+// ExpiresAt applies equality check predicate on the "expires_at" field. It's identical to ExpiresAtEQ.
+func ExpiresAt(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEQ(FieldExpiresAt, v))
+}
+
+// TokenHash applies equality check predicate on the "token_hash" field. It's identical to TokenHashEQ.
+func TokenHash(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEQ(FieldTokenHash, v))
+}
+
+// Description applies equality check predicate on the "description" field. It's identical to DescriptionEQ.
+func Description(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEQ(FieldDescription, v))
+}
+
+// UsedAt applies equality check predicate on the "used_at" field. It's identical to UsedAtEQ.
+func UsedAt(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEQ(FieldUsedAt, v))
+}
+
+// UsedBy applies equality check predicate on the "used_by" field. It's identical to UsedByEQ.
+func UsedBy(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEQ(FieldUsedBy, v))
+}
+
+// CreatedAtEQ applies the EQ predicate on the "created_at" field.
+func CreatedAtEQ(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEQ(FieldCreatedAt, v))
+}
+
+// CreatedAtNEQ applies the NEQ predicate on the "created_at" field.
+func CreatedAtNEQ(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNEQ(FieldCreatedAt, v))
+}
+
+// CreatedAtIn applies the In predicate on the "created_at" field.
+func CreatedAtIn(vs ...time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldIn(FieldCreatedAt, vs...))
+}
+
+// CreatedAtNotIn applies the NotIn predicate on the "created_at" field.
+func CreatedAtNotIn(vs ...time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNotIn(FieldCreatedAt, vs...))
+}
+
+// CreatedAtGT applies the GT predicate on the "created_at" field.
+func CreatedAtGT(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldGT(FieldCreatedAt, v))
+}
+
+// CreatedAtGTE applies the GTE predicate on the "created_at" field.
+func CreatedAtGTE(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldGTE(FieldCreatedAt, v))
+}
+
+// CreatedAtLT applies the LT predicate on the "created_at" field.
+func CreatedAtLT(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldLT(FieldCreatedAt, v))
+}
+
+// CreatedAtLTE applies the LTE predicate on the "created_at" field.
+func CreatedAtLTE(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldLTE(FieldCreatedAt, v))
+}
+
+// ExpiresAtEQ applies the EQ predicate on the "expires_at" field.
+func ExpiresAtEQ(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEQ(FieldExpiresAt, v))
+}
+
+// ExpiresAtNEQ applies the NEQ predicate on the "expires_at" field.
+func ExpiresAtNEQ(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNEQ(FieldExpiresAt, v))
+}
+
+// ExpiresAtIn applies the In predicate on the "expires_at" field.
+func ExpiresAtIn(vs ...time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldIn(FieldExpiresAt, vs...))
+}
+
+// ExpiresAtNotIn applies the NotIn predicate on the "expires_at" field.
+func ExpiresAtNotIn(vs ...time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNotIn(FieldExpiresAt, vs...))
+}
+
+// ExpiresAtGT applies the GT predicate on the "expires_at" field.
+func ExpiresAtGT(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldGT(FieldExpiresAt, v))
+}
+
+// ExpiresAtGTE applies the GTE predicate on the "expires_at" field.
+func ExpiresAtGTE(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldGTE(FieldExpiresAt, v))
+}
+
+// ExpiresAtLT applies the LT predicate on the "expires_at" field.
+func ExpiresAtLT(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldLT(FieldExpiresAt, v))
+}
+
+// ExpiresAtLTE applies the LTE predicate on the "expires_at" field.
+func ExpiresAtLTE(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldLTE(FieldExpiresAt, v))
+}
+
+// TokenHashEQ applies the EQ predicate on the "token_hash" field.
+func TokenHashEQ(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEQ(FieldTokenHash, v))
+}
+
+// TokenHashNEQ applies the NEQ predicate on the "token_hash" field.
+func TokenHashNEQ(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNEQ(FieldTokenHash, v))
+}
+
+// TokenHashIn applies the In predicate on the "token_hash" field.
+func TokenHashIn(vs ...string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldIn(FieldTokenHash, vs...))
+}
+
+// TokenHashNotIn applies the NotIn predicate on the "token_hash" field.
+func TokenHashNotIn(vs ...string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNotIn(FieldTokenHash, vs...))
+}
+
+// TokenHashGT applies the GT predicate on the "token_hash" field.
+func TokenHashGT(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldGT(FieldTokenHash, v))
+}
+
+// TokenHashGTE applies the GTE predicate on the "token_hash" field.
+func TokenHashGTE(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldGTE(FieldTokenHash, v))
+}
+
+// TokenHashLT applies the LT predicate on the "token_hash" field.
+func TokenHashLT(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldLT(FieldTokenHash, v))
+}
+
+// TokenHashLTE applies the LTE predicate on the "token_hash" field.
+func TokenHashLTE(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldLTE(FieldTokenHash, v))
+}
+
+// TokenHashContains applies the Contains predicate on the "token_hash" field.
+func TokenHashContains(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldContains(FieldTokenHash, v))
+}
+
+// TokenHashHasPrefix applies the HasPrefix predicate on the "token_hash" field.
+func TokenHashHasPrefix(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldHasPrefix(FieldTokenHash, v))
+}
+
+// TokenHashHasSuffix applies the HasSuffix predicate on the "token_hash" field.
+func TokenHashHasSuffix(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldHasSuffix(FieldTokenHash, v))
+}
+
+// TokenHashEqualFold applies the EqualFold predicate on the "token_hash" field.
+func TokenHashEqualFold(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEqualFold(FieldTokenHash, v))
+}
+
+// TokenHashContainsFold applies the ContainsFold predicate on the "token_hash" field.
+func TokenHashContainsFold(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldContainsFold(FieldTokenHash, v))
+}
+
+// DescriptionEQ applies the EQ predicate on the "description" field.
+func DescriptionEQ(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEQ(FieldDescription, v))
+}
+
+// DescriptionNEQ applies the NEQ predicate on the "description" field.
+func DescriptionNEQ(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNEQ(FieldDescription, v))
+}
+
+// DescriptionIn applies the In predicate on the "description" field.
+func DescriptionIn(vs ...string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldIn(FieldDescription, vs...))
+}
+
+// DescriptionNotIn applies the NotIn predicate on the "description" field.
+func DescriptionNotIn(vs ...string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNotIn(FieldDescription, vs...))
+}
+
+// DescriptionGT applies the GT predicate on the "description" field.
+func DescriptionGT(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldGT(FieldDescription, v))
+}
+
+// DescriptionGTE applies the GTE predicate on the "description" field.
+func DescriptionGTE(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldGTE(FieldDescription, v))
+}
+
+// DescriptionLT applies the LT predicate on the "description" field.
+func DescriptionLT(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldLT(FieldDescription, v))
+}
+
+// DescriptionLTE applies the LTE predicate on the "description" field.
+func DescriptionLTE(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldLTE(FieldDescription, v))
+}
+
+// DescriptionContains applies the Contains predicate on the "description" field.
+func DescriptionContains(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldContains(FieldDescription, v))
+}
+
+// DescriptionHasPrefix applies the HasPrefix predicate on the "description" field.
+func DescriptionHasPrefix(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldHasPrefix(FieldDescription, v))
+}
+
+// DescriptionHasSuffix applies the HasSuffix predicate on the "description" field.
+func DescriptionHasSuffix(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldHasSuffix(FieldDescription, v))
+}
+
+// DescriptionIsNil applies the IsNil predicate on the "description" field.
+func DescriptionIsNil() predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldIsNull(FieldDescription))
+}
+
+// DescriptionNotNil applies the NotNil predicate on the "description" field.
+func DescriptionNotNil() predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNotNull(FieldDescription))
+}
+
+// DescriptionEqualFold applies the EqualFold predicate on the "description" field.
+func DescriptionEqualFold(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEqualFold(FieldDescription, v))
+}
+
+// DescriptionContainsFold applies the ContainsFold predicate on the "description" field.
+func DescriptionContainsFold(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldContainsFold(FieldDescription, v))
+}
+
+// UsedAtEQ applies the EQ predicate on the "used_at" field.
+func UsedAtEQ(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEQ(FieldUsedAt, v))
+}
+
+// UsedAtNEQ applies the NEQ predicate on the "used_at" field.
+func UsedAtNEQ(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNEQ(FieldUsedAt, v))
+}
+
+// UsedAtIn applies the In predicate on the "used_at" field.
+func UsedAtIn(vs ...time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldIn(FieldUsedAt, vs...))
+}
+
+// UsedAtNotIn applies the NotIn predicate on the "used_at" field.
+func UsedAtNotIn(vs ...time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNotIn(FieldUsedAt, vs...))
+}
+
+// UsedAtGT applies the GT predicate on the "used_at" field.
+func UsedAtGT(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldGT(FieldUsedAt, v))
+}
+
+// UsedAtGTE applies the GTE predicate on the "used_at" field.
+func UsedAtGTE(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldGTE(FieldUsedAt, v))
+}
+
+// UsedAtLT applies the LT predicate on the "used_at" field.
+func UsedAtLT(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldLT(FieldUsedAt, v))
+}
+
+// UsedAtLTE applies the LTE predicate on the "used_at" field.
+func UsedAtLTE(v time.Time) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldLTE(FieldUsedAt, v))
+}
+
+// UsedAtIsNil applies the IsNil predicate on the "used_at" field.
+func UsedAtIsNil() predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldIsNull(FieldUsedAt))
+}
+
+// UsedAtNotNil applies the NotNil predicate on the "used_at" field.
+func UsedAtNotNil() predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNotNull(FieldUsedAt))
+}
+
+// UsedByEQ applies the EQ predicate on the "used_by" field.
+func UsedByEQ(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEQ(FieldUsedBy, v))
+}
+
+// UsedByNEQ applies the NEQ predicate on the "used_by" field.
+func UsedByNEQ(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNEQ(FieldUsedBy, v))
+}
+
+// UsedByIn applies the In predicate on the "used_by" field.
+func UsedByIn(vs ...string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldIn(FieldUsedBy, vs...))
+}
+
+// UsedByNotIn applies the NotIn predicate on the "used_by" field.
+func UsedByNotIn(vs ...string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNotIn(FieldUsedBy, vs...))
+}
+
+// UsedByGT applies the GT predicate on the "used_by" field.
+func UsedByGT(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldGT(FieldUsedBy, v))
+}
+
+// UsedByGTE applies the GTE predicate on the "used_by" field.
+func UsedByGTE(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldGTE(FieldUsedBy, v))
+}
+
+// UsedByLT applies the LT predicate on the "used_by" field.
+func UsedByLT(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldLT(FieldUsedBy, v))
+}
+
+// UsedByLTE applies the LTE predicate on the "used_by" field.
+func UsedByLTE(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldLTE(FieldUsedBy, v))
+}
+
+// UsedByContains applies the Contains predicate on the "used_by" field.
+func UsedByContains(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldContains(FieldUsedBy, v))
+}
+
+// UsedByHasPrefix applies the HasPrefix predicate on the "used_by" field.
+func UsedByHasPrefix(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldHasPrefix(FieldUsedBy, v))
+}
+
+// UsedByHasSuffix applies the HasSuffix predicate on the "used_by" field.
+func UsedByHasSuffix(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldHasSuffix(FieldUsedBy, v))
+}
+
+// UsedByIsNil applies the IsNil predicate on the "used_by" field.
+func UsedByIsNil() predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldIsNull(FieldUsedBy))
+}
+
+// UsedByNotNil applies the NotNil predicate on the "used_by" field.
+func UsedByNotNil() predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldNotNull(FieldUsedBy))
+}
+
+// UsedByEqualFold applies the EqualFold predicate on the "used_by" field.
+func UsedByEqualFold(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldEqualFold(FieldUsedBy, v))
+}
+
+// UsedByContainsFold applies the ContainsFold predicate on the "used_by" field.
+func UsedByContainsFold(v string) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.FieldContainsFold(FieldUsedBy, v))
+}
+
+// And groups predicates with the AND operator between them.
+func And(predicates ...predicate.RegistrationToken) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.AndPredicates(predicates...))
+}
+
+// Or groups predicates with the OR operator between them.
+func Or(predicates ...predicate.RegistrationToken) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.OrPredicates(predicates...))
+}
+
+// Not applies the not operator on the given predicate.
+func Not(p predicate.RegistrationToken) predicate.RegistrationToken {
+	return predicate.RegistrationToken(sql.NotPredicates(p))
+}
@@ -0,0 +1,663 @@
+// Code generated by ent, DO NOT EDIT.
+
+package ent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/sqlgraph"
+	"entgo.io/ent/schema/field"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/registrationtoken"
+)
+
+// RegistrationTokenCreate is the builder for creating a RegistrationToken entity.
+type RegistrationTokenCreate struct {
+	config
+	mutation *RegistrationTokenMutation
+	hooks    []Hook
+	conflict []sql.ConflictOption
+}
+
+// SetCreatedAt sets the "created_at" field.
+func (_c *RegistrationTokenCreate) SetCreatedAt(v time.Time) *RegistrationTokenCreate {
+	_c.mutation.SetCreatedAt(v)
+	return _c
+}
+
+// SetNillableCreatedAt sets the "created_at" field if the given value is not nil.
+func (_c *RegistrationTokenCreate) SetNillableCreatedAt(v *time.Time) *RegistrationTokenCreate {
+	if v != nil {
+		_c.SetCreatedAt(*v)
+	}
+	return _c
+}
+
+// SetExpiresAt sets the "expires_at" field.
+func (_c *RegistrationTokenCreate) SetExpiresAt(v time.Time) *RegistrationTokenCreate {
+	_c.mutation.SetExpiresAt(v)
+	return _c
+}
+
+// SetTokenHash sets the "token_hash" field.
+func (_c *RegistrationTokenCreate) SetTokenHash(v string) *RegistrationTokenCreate {
+	_c.mutation.SetTokenHash(v)
+	return _c
+}
+
+// SetDescription sets the "description" field.
+func (_c *RegistrationTokenCreate) SetDescription(v string) *RegistrationTokenCreate {
+	_c.mutation.SetDescription(v)
+	return _c
+}
+
+// SetNillableDescription sets the "description" field if the given value is not nil.
+func (_c *RegistrationTokenCreate) SetNillableDescription(v *string) *RegistrationTokenCreate {
+	if v != nil {
+		_c.SetDescription(*v)
+	}
+	return _c
+}
+
+// SetUsedAt sets the "used_at" field.
+func (_c *RegistrationTokenCreate) SetUsedAt(v time.Time) *RegistrationTokenCreate {
+	_c.mutation.SetUsedAt(v)
+	return _c
+}
+
+// SetNillableUsedAt sets the "used_at" field if the given value is not nil.
+func (_c *RegistrationTokenCreate) SetNillableUsedAt(v *time.Time) *RegistrationTokenCreate {
+	if v != nil {
+		_c.SetUsedAt(*v)
+	}
+	return _c
+}
+
+// SetUsedBy sets the "used_by" field.
+func (_c *RegistrationTokenCreate) SetUsedBy(v string) *RegistrationTokenCreate {
+	_c.mutation.SetUsedBy(v)
+	return _c
+}
+
+// SetNillableUsedBy sets the "used_by" field if the given value is not nil.
+func (_c *RegistrationTokenCreate) SetNillableUsedBy(v *string) *RegistrationTokenCreate {
+	if v != nil {
+		_c.SetUsedBy(*v)
+	}
+	return _c
+}
+
+// Mutation returns the RegistrationTokenMutation object of the builder.
+func (_c *RegistrationTokenCreate) Mutation() *RegistrationTokenMutation {
+	return _c.mutation
+}
+
+// Save creates the RegistrationToken in the database.
+func (_c *RegistrationTokenCreate) Save(ctx context.Context) (*RegistrationToken, error) {
+	_c.defaults()
+	return withHooks(ctx, _c.sqlSave, _c.mutation, _c.hooks)
+}
+
+// SaveX calls Save and panics if Save returns an error.
+func (_c *RegistrationTokenCreate) SaveX(ctx context.Context) *RegistrationToken {
+	v, err := _c.Save(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Exec executes the query.
+func (_c *RegistrationTokenCreate) Exec(ctx context.Context) error {
+	_, err := _c.Save(ctx)
+	return err
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (_c *RegistrationTokenCreate) ExecX(ctx context.Context) {
+	if err := _c.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
+
+// defaults sets the default values of the builder before save.
+func (_c *RegistrationTokenCreate) defaults() {
+	if _, ok := _c.mutation.CreatedAt(); !ok {
+		v := registrationtoken.DefaultCreatedAt()
+		_c.mutation.SetCreatedAt(v)
+	}
+}
+
+// check runs all checks and user-defined validators on the builder.
+func (_c *RegistrationTokenCreate) check() error {
+	if _, ok := _c.mutation.CreatedAt(); !ok {
+		return &ValidationError{Name: "created_at", err: errors.New(`ent: missing required field "RegistrationToken.created_at"`)}
+	}
+	if _, ok := _c.mutation.ExpiresAt(); !ok {
+		return &ValidationError{Name: "expires_at", err: errors.New(`ent: missing required field "RegistrationToken.expires_at"`)}
+	}
+	if _, ok := _c.mutation.TokenHash(); !ok {
+		return &ValidationError{Name: "token_hash", err: errors.New(`ent: missing required field "RegistrationToken.token_hash"`)}
+	}
+	return nil
+}
+
+func (_c *RegistrationTokenCreate) sqlSave(ctx context.Context) (*RegistrationToken, error) {
+	if err := _c.check(); err != nil {
+		return nil, err
+	}
+	_node, _spec := _c.createSpec()
+	if err := sqlgraph.CreateNode(ctx, _c.driver, _spec); err != nil {
+		if sqlgraph.IsConstraintError(err) {
+			err = &ConstraintError{msg: err.Error(), wrap: err}
+		}
+		return nil, err
+	}
+	id := _spec.ID.Value.(int64)
+	_node.ID = int(id)
+	_c.mutation.id = &_node.ID
+	_c.mutation.done = true
+	return _node, nil
+}
+
+func (_c *RegistrationTokenCreate) createSpec() (*RegistrationToken, *sqlgraph.CreateSpec) {
+	var (
+		_node = &RegistrationToken{config: _c.config}
+		_spec = sqlgraph.NewCreateSpec(registrationtoken.Table, sqlgraph.NewFieldSpec(registrationtoken.FieldID, field.TypeInt))
+	)
+	_spec.OnConflict = _c.conflict
+	if value, ok := _c.mutation.CreatedAt(); ok {
+		_spec.SetField(registrationtoken.FieldCreatedAt, field.TypeTime, value)
+		_node.CreatedAt = value
+	}
+	if value, ok := _c.mutation.ExpiresAt(); ok {
+		_spec.SetField(registrationtoken.FieldExpiresAt, field.TypeTime, value)
+		_node.ExpiresAt = value
+	}
+	if value, ok := _c.mutation.TokenHash(); ok {
+		_spec.SetField(registrationtoken.FieldTokenHash, field.TypeString, value)
+		_node.TokenHash = value
+	}
+	if value, ok := _c.mutation.Description(); ok {
+		_spec.SetField(registrationtoken.FieldDescription, field.TypeString, value)
+		_node.Description = value
+	}
+	if value, ok := _c.mutation.UsedAt(); ok {
+		_spec.SetField(registrationtoken.FieldUsedAt, field.TypeTime, value)
+		_node.UsedAt = &value
+	}
+	if value, ok := _c.mutation.UsedBy(); ok {
+		_spec.SetField(registrationtoken.FieldUsedBy, field.TypeString, value)
+		_node.UsedBy = value
+	}
+	return _node, _spec
+}
+
+// OnConflict allows configuring the `ON CONFLICT` / `ON DUPLICATE KEY` clause
+// of the `INSERT` statement. For example:
+//
+//	client.RegistrationToken.Create().
+//		SetCreatedAt(v).
+//		OnConflict(
+//			// Update the row with the new values
+//			// the was proposed for insertion.
+//			sql.ResolveWithNewValues(),
+//		).
+//		// Override some of the fields with custom
+//		// update values.
+//		Update(func(u *ent.RegistrationTokenUpsert) {
+//			SetCreatedAt(v+v).
+//		}).
+//		Exec(ctx)
+func (_c *RegistrationTokenCreate) OnConflict(opts ...sql.ConflictOption) *RegistrationTokenUpsertOne {
+	_c.conflict = opts
+	return &RegistrationTokenUpsertOne{
+		create: _c,
+	}
+}
+
+// OnConflictColumns calls `OnConflict` and configures the columns
+// as conflict target. Using this option is equivalent to using:
+//
+//	client.RegistrationToken.Create().
+//		OnConflict(sql.ConflictColumns(columns...)).
+//		Exec(ctx)
+func (_c *RegistrationTokenCreate) OnConflictColumns(columns ...string) *RegistrationTokenUpsertOne {
+	_c.conflict = append(_c.conflict, sql.ConflictColumns(columns...))
+	return &RegistrationTokenUpsertOne{
+		create: _c,
+	}
+}
+
+type (
+	// RegistrationTokenUpsertOne is the builder for "upsert"-ing
+	//  one RegistrationToken node.
+	RegistrationTokenUpsertOne struct {
+		create *RegistrationTokenCreate
+	}
+
+	// RegistrationTokenUpsert is the "OnConflict" setter.
+	RegistrationTokenUpsert struct {
+		*sql.UpdateSet
+	}
+)
+
+// SetUsedAt sets the "used_at" field.
+func (u *RegistrationTokenUpsert) SetUsedAt(v time.Time) *RegistrationTokenUpsert {
+	u.Set(registrationtoken.FieldUsedAt, v)
+	return u
+}
+
+// UpdateUsedAt sets the "used_at" field to the value that was provided on create.
+func (u *RegistrationTokenUpsert) UpdateUsedAt() *RegistrationTokenUpsert {
+	u.SetExcluded(registrationtoken.FieldUsedAt)
+	return u
+}
+
+// ClearUsedAt clears the value of the "used_at" field.
+func (u *RegistrationTokenUpsert) ClearUsedAt() *RegistrationTokenUpsert {
+	u.SetNull(registrationtoken.FieldUsedAt)
+	return u
+}
+
+// SetUsedBy sets the "used_by" field.
+func (u *RegistrationTokenUpsert) SetUsedBy(v string) *RegistrationTokenUpsert {
+	u.Set(registrationtoken.FieldUsedBy, v)
+	return u
+}
+
+// UpdateUsedBy sets the "used_by" field to the value that was provided on create.
+func (u *RegistrationTokenUpsert) UpdateUsedBy() *RegistrationTokenUpsert {
+	u.SetExcluded(registrationtoken.FieldUsedBy)
+	return u
+}
+
+// ClearUsedBy clears the value of the "used_by" field.
+func (u *RegistrationTokenUpsert) ClearUsedBy() *RegistrationTokenUpsert {
+	u.SetNull(registrationtoken.FieldUsedBy)
+	return u
+}
+
+// UpdateNewValues updates the mutable fields using the new values that were set on create.
+// Using this option is equivalent to using:
+//
+//	client.RegistrationToken.Create().
+//		OnConflict(
+//			sql.ResolveWithNewValues(),
+//		).
+//		Exec(ctx)
+func (u *RegistrationTokenUpsertOne) UpdateNewValues() *RegistrationTokenUpsertOne {
+	u.create.conflict = append(u.create.conflict, sql.ResolveWithNewValues())
+	u.create.conflict = append(u.create.conflict, sql.ResolveWith(func(s *sql.UpdateSet) {
+		if _, exists := u.create.mutation.CreatedAt(); exists {
+			s.SetIgnore(registrationtoken.FieldCreatedAt)
+		}
+		if _, exists := u.create.mutation.ExpiresAt(); exists {
+			s.SetIgnore(registrationtoken.FieldExpiresAt)
+		}
+		if _, exists := u.create.mutation.TokenHash(); exists {
+			s.SetIgnore(registrationtoken.FieldTokenHash)
+		}
+		if _, exists := u.create.mutation.Description(); exists {
+			s.SetIgnore(registrationtoken.FieldDescription)
+		}
+	}))
+	return u
+}
+
+// Ignore sets each column to itself in case of conflict.
+// Using this option is equivalent to using:
+//
+//	client.RegistrationToken.Create().
+//	    OnConflict(sql.ResolveWithIgnore()).
+//	    Exec(ctx)
+func (u *RegistrationTokenUpsertOne) Ignore() *RegistrationTokenUpsertOne {
+	u.create.conflict = append(u.create.conflict, sql.ResolveWithIgnore())
+	return u
+}
+
+// DoNothing configures the conflict_action to `DO NOTHING`.
+// Supported only by SQLite and PostgreSQL.
+func (u *RegistrationTokenUpsertOne) DoNothing() *RegistrationTokenUpsertOne {
+	u.create.conflict = append(u.create.conflict, sql.DoNothing())
+	return u
+}
+
+// Update allows overriding fields `UPDATE` values. See the RegistrationTokenCreate.OnConflict
+// documentation for more info.
+func (u *RegistrationTokenUpsertOne) Update(set func(*RegistrationTokenUpsert)) *RegistrationTokenUpsertOne {
+	u.create.conflict = append(u.create.conflict, sql.ResolveWith(func(update *sql.UpdateSet) {
+		set(&RegistrationTokenUpsert{UpdateSet: update})
+	}))
+	return u
+}
+
+// SetUsedAt sets the "used_at" field.
+func (u *RegistrationTokenUpsertOne) SetUsedAt(v time.Time) *RegistrationTokenUpsertOne {
+	return u.Update(func(s *RegistrationTokenUpsert) {
+		s.SetUsedAt(v)
+	})
+}
+
+// UpdateUsedAt sets the "used_at" field to the value that was provided on create.
+func (u *RegistrationTokenUpsertOne) UpdateUsedAt() *RegistrationTokenUpsertOne {
+	return u.Update(func(s *RegistrationTokenUpsert) {
+		s.UpdateUsedAt()
+	})
+}
+
+// ClearUsedAt clears the value of the "used_at" field.
+func (u *RegistrationTokenUpsertOne) ClearUsedAt() *RegistrationTokenUpsertOne {
+	return u.Update(func(s *RegistrationTokenUpsert) {
+		s.ClearUsedAt()
+	})
+}
+
+// SetUsedBy sets the "used_by" field.
+func (u *RegistrationTokenUpsertOne) SetUsedBy(v string) *RegistrationTokenUpsertOne {
+	return u.Update(func(s *RegistrationTokenUpsert) {
+		s.SetUsedBy(v)
+	})
+}
+
+// UpdateUsedBy sets the "used_by" field to the value that was provided on create.
+func (u *RegistrationTokenUpsertOne) UpdateUsedBy() *RegistrationTokenUpsertOne {
+	return u.Update(func(s *RegistrationTokenUpsert) {
+		s.UpdateUsedBy()
+	})
+}
+
+// ClearUsedBy clears the value of the "used_by" field.
+func (u *RegistrationTokenUpsertOne) ClearUsedBy() *RegistrationTokenUpsertOne {
+	return u.Update(func(s *RegistrationTokenUpsert) {
+		s.ClearUsedBy()
+	})
+}
+
+// Exec executes the query.
+func (u *RegistrationTokenUpsertOne) Exec(ctx context.Context) error {
+	if len(u.create.conflict) == 0 {
+		return errors.New("ent: missing options for RegistrationTokenCreate.OnConflict")
+	}
+	return u.create.Exec(ctx)
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (u *RegistrationTokenUpsertOne) ExecX(ctx context.Context) {
+	if err := u.create.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
+
+// Exec executes the UPSERT query and returns the inserted/updated ID.
+func (u *RegistrationTokenUpsertOne) ID(ctx context.Context) (id int, err error) {
+	node, err := u.create.Save(ctx)
+	if err != nil {
+		return id, err
+	}
+	return node.ID, nil
+}
+
+// IDX is like ID, but panics if an error occurs.
+func (u *RegistrationTokenUpsertOne) IDX(ctx context.Context) int {
+	id, err := u.ID(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// RegistrationTokenCreateBulk is the builder for creating many RegistrationToken entities in bulk.
+type RegistrationTokenCreateBulk struct {
+	config
+	err      error
+	builders []*RegistrationTokenCreate
+	conflict []sql.ConflictOption
+}
+
+// Save creates the RegistrationToken entities in the database.
+func (_c *RegistrationTokenCreateBulk) Save(ctx context.Context) ([]*RegistrationToken, error) {
+	if _c.err != nil {
+		return nil, _c.err
+	}
+	specs := make([]*sqlgraph.CreateSpec, len(_c.builders))
+	nodes := make([]*RegistrationToken, len(_c.builders))
+	mutators := make([]Mutator, len(_c.builders))
+	for i := range _c.builders {
+		func(i int, root context.Context) {
+			builder := _c.builders[i]
+			builder.defaults()
+			var mut Mutator = MutateFunc(func(ctx context.Context, m Mutation) (Value, error) {
+				mutation, ok := m.(*RegistrationTokenMutation)
+				if !ok {
+					return nil, fmt.Errorf("unexpected mutation type %T", m)
+				}
+				if err := builder.check(); err != nil {
+					return nil, err
+				}
+				builder.mutation = mutation
+				var err error
+				nodes[i], specs[i] = builder.createSpec()
+				if i < len(mutators)-1 {
+					_, err = mutators[i+1].Mutate(root, _c.builders[i+1].mutation)
+				} else {
+					spec := &sqlgraph.BatchCreateSpec{Nodes: specs}
+					spec.OnConflict = _c.conflict
+					// Invoke the actual operation on the latest mutation in the chain.
+					if err = sqlgraph.BatchCreate(ctx, _c.driver, spec); err != nil {
+						if sqlgraph.IsConstraintError(err) {
+							err = &ConstraintError{msg: err.Error(), wrap: err}
+						}
+					}
+				}
+				if err != nil {
+					return nil, err
+				}
+				mutation.id = &nodes[i].ID
+				if specs[i].ID.Value != nil {
+					id := specs[i].ID.Value.(int64)
+					nodes[i].ID = int(id)
+				}
+				mutation.done = true
+				return nodes[i], nil
+			})
+			for i := len(builder.hooks) - 1; i >= 0; i-- {
+				mut = builder.hooks[i](mut)
+			}
+			mutators[i] = mut
+		}(i, ctx)
+	}
+	if len(mutators) > 0 {
+		if _, err := mutators[0].Mutate(ctx, _c.builders[0].mutation); err != nil {
+			return nil, err
+		}
+	}
+	return nodes, nil
+}
+
+// SaveX is like Save, but panics if an error occurs.
+func (_c *RegistrationTokenCreateBulk) SaveX(ctx context.Context) []*RegistrationToken {
+	v, err := _c.Save(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Exec executes the query.
+func (_c *RegistrationTokenCreateBulk) Exec(ctx context.Context) error {
+	_, err := _c.Save(ctx)
+	return err
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (_c *RegistrationTokenCreateBulk) ExecX(ctx context.Context) {
+	if err := _c.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
+
+// OnConflict allows configuring the `ON CONFLICT` / `ON DUPLICATE KEY` clause
+// of the `INSERT` statement. For example:
+//
+//	client.RegistrationToken.CreateBulk(builders...).
+//		OnConflict(
+//			// Update the row with the new values
+//			// the was proposed for insertion.
+//			sql.ResolveWithNewValues(),
+//		).
+//		// Override some of the fields with custom
+//		// update values.
+//		Update(func(u *ent.RegistrationTokenUpsert) {
+//			SetCreatedAt(v+v).
+//		}).
+//		Exec(ctx)
+func (_c *RegistrationTokenCreateBulk) OnConflict(opts ...sql.ConflictOption) *RegistrationTokenUpsertBulk {
+	_c.conflict = opts
+	return &RegistrationTokenUpsertBulk{
+		create: _c,
+	}
+}
+
+// OnConflictColumns calls `OnConflict` and configures the columns
+// as conflict target. Using this option is equivalent to using:
+//
+//	client.RegistrationToken.Create().
+//		OnConflict(sql.ConflictColumns(columns...)).
+//		Exec(ctx)
+func (_c *RegistrationTokenCreateBulk) OnConflictColumns(columns ...string) *RegistrationTokenUpsertBulk {
+	_c.conflict = append(_c.conflict, sql.ConflictColumns(columns...))
+	return &RegistrationTokenUpsertBulk{
+		create: _c,
+	}
+}
+
+// RegistrationTokenUpsertBulk is the builder for "upsert"-ing
+// a bulk of RegistrationToken nodes.
+type RegistrationTokenUpsertBulk struct {
+	create *RegistrationTokenCreateBulk
+}
+
+// UpdateNewValues updates the mutable fields using the new values that
+// were set on create. Using this option is equivalent to using:
+//
+//	client.RegistrationToken.Create().
+//		OnConflict(
+//			sql.ResolveWithNewValues(),
+//		).
+//		Exec(ctx)
+func (u *RegistrationTokenUpsertBulk) UpdateNewValues() *RegistrationTokenUpsertBulk {
+	u.create.conflict = append(u.create.conflict, sql.ResolveWithNewValues())
+	u.create.conflict = append(u.create.conflict, sql.ResolveWith(func(s *sql.UpdateSet) {
+		for _, b := range u.create.builders {
+			if _, exists := b.mutation.CreatedAt(); exists {
+				s.SetIgnore(registrationtoken.FieldCreatedAt)
+			}
+			if _, exists := b.mutation.ExpiresAt(); exists {
+				s.SetIgnore(registrationtoken.FieldExpiresAt)
+			}
+			if _, exists := b.mutation.TokenHash(); exists {
+				s.SetIgnore(registrationtoken.FieldTokenHash)
+			}
+			if _, exists := b.mutation.Description(); exists {
+				s.SetIgnore(registrationtoken.FieldDescription)
+			}
+		}
+	}))
+	return u
+}
+
+// Ignore sets each column to itself in case of conflict.
+// Using this option is equivalent to using:
+//
+//	client.RegistrationToken.Create().
+//		OnConflict(sql.ResolveWithIgnore()).
+//		Exec(ctx)
+func (u *RegistrationTokenUpsertBulk) Ignore() *RegistrationTokenUpsertBulk {
+	u.create.conflict = append(u.create.conflict, sql.ResolveWithIgnore())
+	return u
+}
+
+// DoNothing configures the conflict_action to `DO NOTHING`.
+// Supported only by SQLite and PostgreSQL.
+func (u *RegistrationTokenUpsertBulk) DoNothing() *RegistrationTokenUpsertBulk {
+	u.create.conflict = append(u.create.conflict, sql.DoNothing())
+	return u
+}
+
+// Update allows overriding fields `UPDATE` values. See the RegistrationTokenCreateBulk.OnConflict
+// documentation for more info.
+func (u *RegistrationTokenUpsertBulk) Update(set func(*RegistrationTokenUpsert)) *RegistrationTokenUpsertBulk {
+	u.create.conflict = append(u.create.conflict, sql.ResolveWith(func(update *sql.UpdateSet) {
+		set(&RegistrationTokenUpsert{UpdateSet: update})
+	}))
+	return u
+}
+
+// SetUsedAt sets the "used_at" field.
+func (u *RegistrationTokenUpsertBulk) SetUsedAt(v time.Time) *RegistrationTokenUpsertBulk {
+	return u.Update(func(s *RegistrationTokenUpsert) {
+		s.SetUsedAt(v)
+	})
+}
+
+// UpdateUsedAt sets the "used_at" field to the value that was provided on create.
+func (u *RegistrationTokenUpsertBulk) UpdateUsedAt() *RegistrationTokenUpsertBulk {
+	return u.Update(func(s *RegistrationTokenUpsert) {
+		s.UpdateUsedAt()
+	})
+}
+
+// ClearUsedAt clears the value of the "used_at" field.
+func (u *RegistrationTokenUpsertBulk) ClearUsedAt() *RegistrationTokenUpsertBulk {
+	return u.Update(func(s *RegistrationTokenUpsert) {
+		s.ClearUsedAt()
+	})
+}
+
+// SetUsedBy sets the "used_by" field.
+func (u *RegistrationTokenUpsertBulk) SetUsedBy(v string) *RegistrationTokenUpsertBulk {
+	return u.Update(func(s *RegistrationTokenUpsert) {
+		s.SetUsedBy(v)
+	})
+}
+
+// UpdateUsedBy sets the "used_by" field to the value that was provided on create.
+func (u *RegistrationTokenUpsertBulk) UpdateUsedBy() *RegistrationTokenUpsertBulk {
+	return u.Update(func(s *RegistrationTokenUpsert) {
+		s.UpdateUsedBy()
+	})
+}
+
+// ClearUsedBy clears the value of the "used_by" field.
+func (u *RegistrationTokenUpsertBulk) ClearUsedBy() *RegistrationTokenUpsertBulk {
+	return u.Update(func(s *RegistrationTokenUpsert) {
+		s.ClearUsedBy()
+	})
+}
+
+// Exec executes the query.
+func (u *RegistrationTokenUpsertBulk) Exec(ctx context.Context) error {
+	if u.create.err != nil {
+		return u.create.err
+	}
+	for i, b := range u.create.builders {
+		if len(b.conflict) != 0 {
+			return fmt.Errorf("ent: OnConflict was set for builder %d. Set it on the RegistrationTokenCreateBulk instead", i)
+		}
+	}
+	if len(u.create.conflict) == 0 {
+		return errors.New("ent: missing options for RegistrationTokenCreateBulk.OnConflict")
+	}
+	return u.create.Exec(ctx)
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (u *RegistrationTokenUpsertBulk) ExecX(ctx context.Context) {
+	if err := u.create.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,286 @@
+// Code generated by ent, DO NOT EDIT.
+
+package ent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/sqlgraph"
+	"entgo.io/ent/schema/field"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/predicate"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/registrationtoken"
+)
+
+// RegistrationTokenUpdate is the builder for updating RegistrationToken entities.
+type RegistrationTokenUpdate struct {
+	config
+	hooks    []Hook
+	mutation *RegistrationTokenMutation
+}
+
+// Where appends a list predicates to the RegistrationTokenUpdate builder.
+func (_u *RegistrationTokenUpdate) Where(ps ...predicate.RegistrationToken) *RegistrationTokenUpdate {
+	_u.mutation.Where(ps...)
+	return _u
+}
+
+// SetUsedAt sets the "used_at" field.
+func (_u *RegistrationTokenUpdate) SetUsedAt(v time.Time) *RegistrationTokenUpdate {
+	_u.mutation.SetUsedAt(v)
+	return _u
+}
+
+// SetNillableUsedAt sets the "used_at" field if the given value is not nil.
+func (_u *RegistrationTokenUpdate) SetNillableUsedAt(v *time.Time) *RegistrationTokenUpdate {
+	if v != nil {
+		_u.SetUsedAt(*v)
+	}
+	return _u
+}
+
+// ClearUsedAt clears the value of the "used_at" field.
+func (_u *RegistrationTokenUpdate) ClearUsedAt() *RegistrationTokenUpdate {
+	_u.mutation.ClearUsedAt()
+	return _u
+}
+
+// SetUsedBy sets the "used_by" field.
+func (_u *RegistrationTokenUpdate) SetUsedBy(v string) *RegistrationTokenUpdate {
+	_u.mutation.SetUsedBy(v)
+	return _u
+}
+
+// SetNillableUsedBy sets the "used_by" field if the given value is not nil.
+func (_u *RegistrationTokenUpdate) SetNillableUsedBy(v *string) *RegistrationTokenUpdate {
+	if v != nil {
+		_u.SetUsedBy(*v)
+	}
+	return _u
+}
+
+// ClearUsedBy clears the value of the "used_by" field.
+func (_u *RegistrationTokenUpdate) ClearUsedBy() *RegistrationTokenUpdate {
+	_u.mutation.ClearUsedBy()
+	return _u
+}
+
+// Mutation returns the RegistrationTokenMutation object of the builder.
+func (_u *RegistrationTokenUpdate) Mutation() *RegistrationTokenMutation {
+	return _u.mutation
+}
+
+// Save executes the query and returns the number of nodes affected by the update operation.
+func (_u *RegistrationTokenUpdate) Save(ctx context.Context) (int, error) {
+	return withHooks(ctx, _u.sqlSave, _u.mutation, _u.hooks)
+}
+
+// SaveX is like Save, but panics if an error occurs.
+func (_u *RegistrationTokenUpdate) SaveX(ctx context.Context) int {
+	affected, err := _u.Save(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return affected
+}
+
+// Exec executes the query.
+func (_u *RegistrationTokenUpdate) Exec(ctx context.Context) error {
+	_, err := _u.Save(ctx)
+	return err
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (_u *RegistrationTokenUpdate) ExecX(ctx context.Context) {
+	if err := _u.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
+
+func (_u *RegistrationTokenUpdate) sqlSave(ctx context.Context) (_node int, err error) {
+	_spec := sqlgraph.NewUpdateSpec(registrationtoken.Table, registrationtoken.Columns, sqlgraph.NewFieldSpec(registrationtoken.FieldID, field.TypeInt))
+	if ps := _u.mutation.predicates; len(ps) > 0 {
+		_spec.Predicate = func(selector *sql.Selector) {
+			for i := range ps {
+				ps[i](selector)
+			}
+		}
+	}
+	if _u.mutation.DescriptionCleared() {
+		_spec.ClearField(registrationtoken.FieldDescription, field.TypeString)
+	}
+	if value, ok := _u.mutation.UsedAt(); ok {
+		_spec.SetField(registrationtoken.FieldUsedAt, field.TypeTime, value)
+	}
+	if _u.mutation.UsedAtCleared() {
+		_spec.ClearField(registrationtoken.FieldUsedAt, field.TypeTime)
+	}
+	if value, ok := _u.mutation.UsedBy(); ok {
+		_spec.SetField(registrationtoken.FieldUsedBy, field.TypeString, value)
+	}
+	if _u.mutation.UsedByCleared() {
+		_spec.ClearField(registrationtoken.FieldUsedBy, field.TypeString)
+	}
+	if _node, err = sqlgraph.UpdateNodes(ctx, _u.driver, _spec); err != nil {
+		if _, ok := err.(*sqlgraph.NotFoundError); ok {
+			err = &NotFoundError{registrationtoken.Label}
+		} else if sqlgraph.IsConstraintError(err) {
+			err = &ConstraintError{msg: err.Error(), wrap: err}
+		}
+		return 0, err
+	}
+	_u.mutation.done = true
+	return _node, nil
+}
+
+// RegistrationTokenUpdateOne is the builder for updating a single RegistrationToken entity.
+type RegistrationTokenUpdateOne struct {
+	config
+	fields   []string
+	hooks    []Hook
+	mutation *RegistrationTokenMutation
+}
+
+// SetUsedAt sets the "used_at" field.
+func (_u *RegistrationTokenUpdateOne) SetUsedAt(v time.Time) *RegistrationTokenUpdateOne {
+	_u.mutation.SetUsedAt(v)
+	return _u
+}
+
+// SetNillableUsedAt sets the "used_at" field if the given value is not nil.
+func (_u *RegistrationTokenUpdateOne) SetNillableUsedAt(v *time.Time) *RegistrationTokenUpdateOne {
+	if v != nil {
+		_u.SetUsedAt(*v)
+	}
+	return _u
+}
+
+// ClearUsedAt clears the value of the "used_at" field.
+func (_u *RegistrationTokenUpdateOne) ClearUsedAt() *RegistrationTokenUpdateOne {
+	_u.mutation.ClearUsedAt()
+	return _u
+}
+
+// SetUsedBy sets the "used_by" field.
+func (_u *RegistrationTokenUpdateOne) SetUsedBy(v string) *RegistrationTokenUpdateOne {
+	_u.mutation.SetUsedBy(v)
+	return _u
+}
+
+// SetNillableUsedBy sets the "used_by" field if the given value is not nil.
+func (_u *RegistrationTokenUpdateOne) SetNillableUsedBy(v *string) *RegistrationTokenUpdateOne {
+	if v != nil {
+		_u.SetUsedBy(*v)
+	}
+	return _u
+}
+
+// ClearUsedBy clears the value of the "used_by" field.
+func (_u *RegistrationTokenUpdateOne) ClearUsedBy() *RegistrationTokenUpdateOne {
+	_u.mutation.ClearUsedBy()
+	return _u
+}
+
+// Mutation returns the RegistrationTokenMutation object of the builder.
+func (_u *RegistrationTokenUpdateOne) Mutation() *RegistrationTokenMutation {
+	return _u.mutation
+}
+
+// Where appends a list predicates to the RegistrationTokenUpdate builder.
+func (_u *RegistrationTokenUpdateOne) Where(ps ...predicate.RegistrationToken) *RegistrationTokenUpdateOne {
+	_u.mutation.Where(ps...)
+	return _u
+}
+
+// Select allows selecting one or more fields (columns) of the returned entity.
+// The default is selecting all fields defined in the entity schema.
+func (_u *RegistrationTokenUpdateOne) Select(field string, fields ...string) *RegistrationTokenUpdateOne {
+	_u.fields = append([]string{field}, fields...)
+	return _u
+}
+
+// Save executes the query and returns the updated RegistrationToken entity.
+func (_u *RegistrationTokenUpdateOne) Save(ctx context.Context) (*RegistrationToken, error) {
+	return withHooks(ctx, _u.sqlSave, _u.mutation, _u.hooks)
+}
+
+// SaveX is like Save, but panics if an error occurs.
+func (_u *RegistrationTokenUpdateOne) SaveX(ctx context.Context) *RegistrationToken {
+	node, err := _u.Save(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return node
+}
+
+// Exec executes the query on the entity.
+func (_u *RegistrationTokenUpdateOne) Exec(ctx context.Context) error {
+	_, err := _u.Save(ctx)
+	return err
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (_u *RegistrationTokenUpdateOne) ExecX(ctx context.Context) {
+	if err := _u.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
+
+func (_u *RegistrationTokenUpdateOne) sqlSave(ctx context.Context) (_node *RegistrationToken, err error) {
+	_spec := sqlgraph.NewUpdateSpec(registrationtoken.Table, registrationtoken.Columns, sqlgraph.NewFieldSpec(registrationtoken.FieldID, field.TypeInt))
+	id, ok := _u.mutation.ID()
+	if !ok {
+		return nil, &ValidationError{Name: "id", err: errors.New(`ent: missing "RegistrationToken.id" for update`)}
+	}
+	_spec.Node.ID.Value = id
+	if fields := _u.fields; len(fields) > 0 {
+		_spec.Node.Columns = make([]string, 0, len(fields))
+		_spec.Node.Columns = append(_spec.Node.Columns, registrationtoken.FieldID)
+		for _, f := range fields {
+			if !registrationtoken.ValidColumn(f) {
+				return nil, &ValidationError{Name: f, err: fmt.Errorf("ent: invalid field %q for query", f)}
+			}
+			if f != registrationtoken.FieldID {
+				_spec.Node.Columns = append(_spec.Node.Columns, f)
+			}
+		}
+	}
+	if ps := _u.mutation.predicates; len(ps) > 0 {
+		_spec.Predicate = func(selector *sql.Selector) {
+			for i := range ps {
+				ps[i](selector)
+			}
+		}
+	}
+	if _u.mutation.DescriptionCleared() {
+		_spec.ClearField(registrationtoken.FieldDescription, field.TypeString)
+	}
+	if value, ok := _u.mutation.UsedAt(); ok {
+		_spec.SetField(registrationtoken.FieldUsedAt, field.TypeTime, value)
+	}
+	if _u.mutation.UsedAtCleared() {
+		_spec.ClearField(registrationtoken.FieldUsedAt, field.TypeTime)
+	}
+	if value, ok := _u.mutation.UsedBy(); ok {
+		_spec.SetField(registrationtoken.FieldUsedBy, field.TypeString, value)
+	}
+	if _u.mutation.UsedByCleared() {
+		_spec.ClearField(registrationtoken.FieldUsedBy, field.TypeString)
+	}
+	_node = &RegistrationToken{config: _u.config}
+	_spec.Assign = _node.assignValues
+	_spec.ScanValues = _node.scanValues
+	if err = sqlgraph.UpdateNode(ctx, _u.driver, _spec); err != nil {
+		if _, ok := err.(*sqlgraph.NotFoundError); ok {
+			err = &NotFoundError{registrationtoken.Label}
+		} else if sqlgraph.IsConstraintError(err) {
+			err = &ConstraintError{msg: err.Error(), wrap: err}
+		}
+		return nil, err
+	}
+	_u.mutation.done = true
+	return _node, nil
+}
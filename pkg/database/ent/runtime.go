@@ -15,6 +15,7 @@ import (
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/lock"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/machine"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/meta"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/registrationtoken"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/schema"
 )
 
@@ -200,4 +201,10 @@ func init() {
 	metaDescValue := metaFields[3].Descriptor()
 	// meta.ValueValidator is a validator for the "value" field. It is called by the builders before save.
 	meta.ValueValidator = metaDescValue.Validators[0].(func(string) error)
+	registrationtokenFields := schema.RegistrationToken{}.Fields()
+	_ = registrationtokenFields
+	// registrationtokenDescCreatedAt is the schema descriptor for created_at field.
+	registrationtokenDescCreatedAt := registrationtokenFields[0].Descriptor()
+	// registrationtoken.DefaultCreatedAt holds the default value on creation for the created_at field.
+	registrationtoken.DefaultCreatedAt = registrationtokenDescCreatedAt.Default.(func() time.Time)
 }
@@ -52,6 +52,7 @@ func (Alert) Fields() []ent.Field {
 		field.String("uuid").Optional().Immutable(), // this uuid is mostly here to ensure that CAPI/PAPI has a unique id for each alert
 		field.Bool("remediation").Optional().Immutable(),
 		field.String("kind").Optional().Immutable(), // Origin of the alert (crowdsec,waf,bot-detection,...)
+		field.JSON("labels", []string{}).Optional().Immutable(), // labels of the scenario (classification, behavior...) as key:value
 	}
 }
 
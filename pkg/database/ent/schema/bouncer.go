@@ -1,6 +1,8 @@
 package schema
 
 import (
+	"time"
+
 	"entgo.io/ent"
 	"entgo.io/ent/schema/field"
 	"entgo.io/ent/schema/index"
@@ -8,6 +10,23 @@ import (
 	"github.com/crowdsecurity/crowdsec/pkg/types"
 )
 
+// BouncerPull is a request of a bouncer for the decisions, with the filters and the number of decisions sent.
+type BouncerPull struct {
+	Time     time.Time `json:"time"`
+	Endpoint string    `json:"endpoint"` // stream or decisions
+	Startup  bool      `json:"startup,omitempty"`
+	Filters  string    `json:"filters,omitempty"`
+	New      int       `json:"new"`
+	Deleted  int       `json:"deleted"`
+}
+
+// BouncerVersion is a user agent reported by a bouncer, and when it was first seen.
+type BouncerVersion struct {
+	Type      string    `json:"type"`
+	Version   string    `json:"version"`
+	FirstSeen time.Time `json:"first_seen"`
+}
+
 // Bouncer holds the schema definition for the Bouncer entity.
 type Bouncer struct {
 	ent.Schema
@@ -37,6 +56,9 @@ func (Bouncer) Fields() []ent.Field {
 		field.String("featureflags").Optional(),
 		// Old auto-created TLS bouncers will have a wrong value for this field
 		field.Bool("auto_created").StructTag(`json:"auto_created"`).Default(false).Immutable(),
+		// the last pulls and versions, most recent last
+		field.JSON("pull_history", []BouncerPull{}).Optional().StructTag(`json:"pull_history,omitempty"`),
+		field.JSON("version_history", []BouncerVersion{}).Optional().StructTag(`json:"version_history,omitempty"`),
 	}
 }
 
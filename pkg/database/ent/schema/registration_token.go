@@ -0,0 +1,52 @@
+package schema
+
+import (
+	"entgo.io/ent"
+	"entgo.io/ent/schema/field"
+	"entgo.io/ent/schema/index"
+)
+
+// RegistrationToken holds the schema definition for the RegistrationToken entity.
+// A registration token can be used once by a log processor to register and be validated.
+type RegistrationToken struct {
+	ent.Schema
+}
+
+// Fields of the RegistrationToken.
+func (RegistrationToken) Fields() []ent.Field {
+	return []ent.Field{
+		field.Time("created_at").
+			Default(UtcNow).
+			Immutable().
+			StructTag(`json:"created_at"`),
+		field.Time("expires_at").
+			Immutable().
+			StructTag(`json:"expires_at"`),
+		// sha512 of the token, which is only shown at creation
+		field.String("token_hash").
+			Unique().
+			Immutable().
+			Sensitive(),
+		field.String("description").
+			Optional().
+			Immutable().
+			StructTag(`json:"description,omitempty"`),
+		field.Time("used_at").
+			Nillable().
+			Optional().
+			StructTag(`json:"used_at,omitempty"`),
+		field.String("used_by").
+			Optional().
+			StructTag(`json:"used_by,omitempty"`), // machine id
+	}
+}
+
+func (RegistrationToken) Indexes() []ent.Index {
+	return []ent.Index{
+		index.Fields("expires_at"),
+	}
+}
+
+func (RegistrationToken) Edges() []ent.Edge {
+	return nil
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/event"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/machine"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/metric"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/registrationtoken"
 	"github.com/crowdsecurity/crowdsec/pkg/logging"
 	"github.com/crowdsecurity/crowdsec/pkg/types"
 )
@@ -133,6 +134,15 @@ func (c *Client) StartFlushScheduler(ctx context.Context, config *csconfig.Flush
 		return nil, fmt.Errorf("while starting FlushAllowlists scheduler: %w", err)
 	}
 
+	_, err = scheduler.NewJob(
+		gocron.DurationJob(flushInterval),
+		gocron.NewTask(c.flushRegistrationTokens, ctx),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("while starting flushRegistrationTokens scheduler: %w", err)
+	}
+
 	scheduler.Start()
 
 	return scheduler, nil
@@ -342,3 +352,17 @@ func (c *Client) flushAllowlists(ctx context.Context) {
 		c.Log.Debugf("flushed %d allowlists", deleted)
 	}
 }
+
+func (c *Client) flushRegistrationTokens(ctx context.Context) {
+	deleted, err := c.Ent.RegistrationToken.Delete().Where(
+		registrationtoken.ExpiresAtLTE(time.Now().UTC()),
+	).Exec(ctx)
+	if err != nil {
+		c.Log.Errorf("while flushing registration tokens: %s", err)
+		return
+	}
+
+	if deleted > 0 {
+		c.Log.Debugf("flushed %d registration tokens", deleted)
+	}
+}
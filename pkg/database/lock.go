@@ -14,6 +14,10 @@ import (
 const (
 	CAPIPullLockTimeout = 10
 	CapiPullLockName    = "pullCAPI"
+
+	// minutes: the export of a large backlog can take a while
+	AlertExportLockTimeout = 30
+	AlertExportLockName    = "alertExport"
 )
 
 func (c *Client) AcquireLock(ctx context.Context, name string) error {
@@ -84,3 +88,18 @@ func (c *Client) ReleasePullCAPILock(ctx context.Context) error {
 
 	return nil
 }
+
+// AcquireAlertExportLock makes sure a single local API exports the alerts, when several of them share
+// the database.
+func (c *Client) AcquireAlertExportLock(ctx context.Context) error {
+	err := c.ReleaseLockWithTimeout(ctx, AlertExportLockName, AlertExportLockTimeout)
+	if err != nil {
+		log.Errorf("unable to release %s lock: %s", AlertExportLockName, err)
+	}
+
+	return c.AcquireLock(ctx, AlertExportLockName)
+}
+
+func (c *Client) ReleaseAlertExportLock(ctx context.Context) error {
+	return c.ReleaseLock(ctx, AlertExportLockName)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/go-openapi/strfmt"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/machine"
 	"github.com/crowdsecurity/crowdsec/pkg/database/ent/schema"
@@ -240,3 +241,37 @@ func (c *Client) QueryMachinesInactiveSince(ctx context.Context, t time.Time) ([
 		),
 	).All(ctx)
 }
+
+// IsMachineStale returns true if a validated machine did not send a heartbeat for longer than maxDelay.
+// Machines that never sent a heartbeat are stale once they have been registered for longer than maxDelay.
+func IsMachineStale(m *ent.Machine, maxDelay time.Duration, now time.Time) bool {
+	if !m.IsValidated {
+		return false
+	}
+
+	last := m.CreatedAt
+	if m.LastHeartbeat != nil {
+		last = *m.LastHeartbeat
+	}
+
+	return now.Sub(last) > maxDelay
+}
+
+// ListStaleMachines returns the validated machines that did not send a heartbeat within their max delay.
+func (c *Client) ListStaleMachines(ctx context.Context, sla *csconfig.HeartbeatSLACfg) ([]*ent.Machine, error) {
+	machines, err := c.Ent.Machine.Query().Where(machine.IsValidatedEQ(true)).All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing machines: %w: %w", err, QueryFail)
+	}
+
+	now := time.Now().UTC()
+	stale := []*ent.Machine{}
+
+	for _, m := range machines {
+		if IsMachineStale(m, sla.MaxDelayFor(m.MachineId), now) {
+			stale = append(stale, m)
+		}
+	}
+
+	return stale, nil
+}
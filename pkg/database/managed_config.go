@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/crowdsecurity/crowdsec/pkg/managedconfig"
+)
+
+// the acquisition pushed by the console, until the machines fetch it
+const managedConfigKey = "papi:managed_config"
+
+func (c *Client) GetManagedConfig(ctx context.Context) ([]managedconfig.Snippet, error) {
+	value, err := c.GetConfigItem(ctx, managedConfigKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []managedconfig.Snippet{}
+
+	if value == "" {
+		return ret, nil
+	}
+
+	if err := json.Unmarshal([]byte(value), &ret); err != nil {
+		return nil, fmt.Errorf("decoding managed config: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (c *Client) SetManagedConfig(ctx context.Context, snippets []managedconfig.Snippet) error {
+	value, err := json.Marshal(snippets)
+	if err != nil {
+		return fmt.Errorf("encoding managed config: %w", err)
+	}
+
+	return c.SetConfigItem(ctx, managedConfigKey, string(value))
+}
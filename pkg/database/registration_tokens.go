@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/registrationtoken"
+)
+
+func (c *Client) CreateRegistrationToken(ctx context.Context, tokenHash string, description string, expiresAt time.Time) (*ent.RegistrationToken, error) {
+	token, err := c.Ent.RegistrationToken.
+		Create().
+		SetTokenHash(tokenHash).
+		SetDescription(description).
+		SetExpiresAt(expiresAt.UTC()).
+		Save(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating registration token: %w: %w", err, InsertFail)
+	}
+
+	return token, nil
+}
+
+func (c *Client) ListRegistrationTokens(ctx context.Context) ([]*ent.RegistrationToken, error) {
+	tokens, err := c.Ent.RegistrationToken.Query().Order(ent.Asc(registrationtoken.FieldID)).All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing registration tokens: %w: %w", err, QueryFail)
+	}
+
+	return tokens, nil
+}
+
+func (c *Client) DeleteRegistrationToken(ctx context.Context, id int) error {
+	nbDeleted, err := c.Ent.RegistrationToken.Delete().Where(registrationtoken.IDEQ(id)).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("deleting registration token: %w: %w", err, DeleteFail)
+	}
+
+	if nbDeleted == 0 {
+		return fmt.Errorf("registration token %d: %w", id, ItemNotFound)
+	}
+
+	return nil
+}
+
+// UseRegistrationToken marks a token as used by a machine. It returns false if the token
+// does not exist, has expired or has already been used.
+func (c *Client) UseRegistrationToken(ctx context.Context, tokenHash string, machineID string) (bool, error) {
+	// a single update, so that concurrent registrations can't use the same token
+	nbUpdated, err := c.Ent.RegistrationToken.Update().
+		Where(
+			registrationtoken.TokenHashEQ(tokenHash),
+			registrationtoken.UsedAtIsNil(),
+			registrationtoken.ExpiresAtGT(time.Now().UTC()),
+		).
+		SetUsedAt(time.Now().UTC()).
+		SetUsedBy(machineID).
+		Save(ctx)
+	if err != nil {
+		return false, fmt.Errorf("using registration token: %w: %w", err, UpdateFail)
+	}
+
+	return nbUpdated == 1, nil
+}
+
+// RegistrationTokenExists returns true if a token has been created with this hash, even if it can't be used anymore.
+func (c *Client) RegistrationTokenExists(ctx context.Context, tokenHash string) (bool, error) {
+	exists, err := c.Ent.RegistrationToken.Query().Where(registrationtoken.TokenHashEQ(tokenHash)).Exist(ctx)
+	if err != nil {
+		return false, fmt.Errorf("querying registration token: %w: %w", err, QueryFail)
+	}
+
+	return exists, nil
+}
+
+// ReleaseRegistrationToken makes a token available again, if the registration failed after using it.
+func (c *Client) ReleaseRegistrationToken(ctx context.Context, tokenHash string) error {
+	_, err := c.Ent.RegistrationToken.Update().
+		Where(registrationtoken.TokenHashEQ(tokenHash)).
+		ClearUsedAt().
+		ClearUsedBy().
+		Save(ctx)
+	if err != nil {
+		return fmt.Errorf("releasing registration token: %w: %w", err, UpdateFail)
+	}
+
+	return nil
+}
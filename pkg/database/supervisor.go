@@ -0,0 +1,402 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent/decision"
+)
+
+// the first reconnection attempt, the delay doubles after each failure up to max_backoff
+const minReconnectBackoff = time.Second
+
+// Snapshot is a copy of the active decisions and of the bouncers, taken while the database is up.
+// It has the queries of the decision stream, with the same deduplication as the database.
+type Snapshot struct {
+	Time      time.Time
+	Decisions []*ent.Decision
+	Bouncers  []*ent.Bouncer
+
+	client   *Client                    // for the decision_conflicts policy
+	byTarget map[string][]*ent.Decision // the decisions that can be in conflict, by targetKey()
+}
+
+func newSnapshot(client *Client, now time.Time, decisions []*ent.Decision, bouncers []*ent.Bouncer) Snapshot {
+	byTarget := make(map[string][]*ent.Decision)
+
+	for _, d := range decisions {
+		byTarget[targetKey(d)] = append(byTarget[targetKey(d)], d)
+	}
+
+	return Snapshot{Time: now, Decisions: decisions, Bouncers: bouncers, client: client, byTarget: byTarget}
+}
+
+// Supervisor checks the connection to the database. While the database is down, it tries to reconnect
+// with a backoff, and the local API runs in degraded mode: the decision streams are served from the
+// last snapshot, without any write.
+type Supervisor struct {
+	client *Client
+	cfg    *csconfig.DBSupervisionCfg
+	logger *log.Entry
+	ping   func(context.Context) error
+
+	degraded atomic.Bool
+
+	mu       sync.RWMutex
+	snapshot Snapshot
+
+	pullsMu sync.Mutex
+	// the pulls served in degraded mode, by bouncer: they are not saved in the database
+	lastPulls map[string]time.Time
+}
+
+func NewSupervisor(client *Client, cfg *csconfig.DBSupervisionCfg, logger *log.Entry) *Supervisor {
+	return &Supervisor{
+		client: client,
+		cfg:    cfg,
+		logger: logger,
+		ping:   client.Ping,
+	}
+}
+
+// Degraded returns true while the database is unavailable.
+func (s *Supervisor) Degraded() bool {
+	if s == nil {
+		return false
+	}
+
+	return s.degraded.Load()
+}
+
+func (s *Supervisor) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.snapshot
+}
+
+// LastPull returns the last pull of a bouncer, the one saved in the database or, if it's more
+// recent, the one served in degraded mode.
+func (s *Supervisor) LastPull(bouncer *ent.Bouncer) *time.Time {
+	s.pullsMu.Lock()
+	defer s.pullsMu.Unlock()
+
+	if t, ok := s.lastPulls[bouncer.Name]; ok && (bouncer.LastPull == nil || t.After(*bouncer.LastPull)) {
+		return &t
+	}
+
+	return bouncer.LastPull
+}
+
+// SetLastPull records a pull served in degraded mode, so that the next delta doesn't send the same decisions.
+func (s *Supervisor) SetLastPull(name string, t time.Time) {
+	s.pullsMu.Lock()
+	defer s.pullsMu.Unlock()
+
+	if s.lastPulls == nil {
+		s.lastPulls = make(map[string]time.Time)
+	}
+
+	s.lastPulls[name] = t
+}
+
+func (s *Supervisor) refresh(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	decisions, err := s.client.Ent.Decision.Query().
+		Select(decision.FieldID, decision.FieldCreatedAt, decision.FieldUntil, decision.FieldScenario, decision.FieldScope,
+			decision.FieldValue, decision.FieldType, decision.FieldOrigin, decision.FieldUUID).
+		Where(decision.UntilGT(now), decision.SimulatedEQ(false)).
+		Order(ent.Asc(decision.FieldID)).
+		All(ctx)
+	if err != nil {
+		return err
+	}
+
+	bouncers, err := s.client.ListBouncers(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshot = newSnapshot(s.client, now, decisions, bouncers)
+
+	return nil
+}
+
+func (s *Supervisor) check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, *s.cfg.CheckInterval)
+	defer cancel()
+
+	return s.ping(ctx)
+}
+
+// Run checks the database until the context is canceled.
+func (s *Supervisor) Run(ctx context.Context) error {
+	if err := s.refresh(ctx); err != nil {
+		s.logger.Warningf("unable to take the snapshot of the decisions: %s", err)
+	}
+
+	lastRefresh := time.Now()
+	backoff := minReconnectBackoff
+
+	for {
+		wait := *s.cfg.CheckInterval
+		if s.degraded.Load() {
+			wait = backoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+
+		err := s.check(ctx)
+
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err != nil && !s.degraded.Load():
+			s.degraded.Store(true)
+			s.logger.Errorf("database unavailable, serving the decision streams from the snapshot of %s: %s",
+				s.Snapshot().Time.Format(time.RFC3339), err)
+		case err != nil:
+			backoff = min(backoff*2, *s.cfg.MaxBackoff)
+			s.logger.Warningf("database still unavailable, next attempt in %s: %s", backoff, err)
+		case s.degraded.Load():
+			s.logger.Info("database available again, leaving the degraded mode")
+			s.degraded.Store(false)
+
+			// the last pulls in the database are older: the first delta covers the whole outage
+			s.pullsMu.Lock()
+			s.lastPulls = nil
+			s.pullsMu.Unlock()
+
+			backoff = minReconnectBackoff
+
+			fallthrough
+		case time.Since(lastRefresh) >= *s.cfg.SnapshotInterval:
+			if err := s.refresh(ctx); err != nil {
+				s.logger.Warningf("unable to take the snapshot of the decisions: %s", err)
+				continue
+			}
+
+			lastRefresh = time.Now()
+		}
+	}
+}
+
+// BouncerByKey returns the bouncer authenticating with an API key (hashed) from an IP address. A key shared
+// by several bouncers falls back to the first one: there is no bouncer creation in degraded mode.
+func (s *Snapshot) BouncerByKey(apiKeyHash string, authType string, clientIP string) *ent.Bouncer {
+	var first *ent.Bouncer
+
+	for _, b := range s.Bouncers {
+		if b.APIKey != apiKeyHash || b.AuthType != authType {
+			continue
+		}
+
+		if b.IPAddress == clientIP {
+			return b
+		}
+
+		if first == nil {
+			first = b
+		}
+	}
+
+	return first
+}
+
+func (s *Snapshot) BouncerByName(name string) *ent.Bouncer {
+	for _, b := range s.Bouncers {
+		if b.Name == name {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// matchFilter applies the filters of the decision stream that can be evaluated in memory:
+// scopes, origins, scenarios_containing and scenarios_not_containing.
+func matchFilter(d *ent.Decision, filter map[string][]string) bool {
+	containsAny := func(words string) bool {
+		return slices.ContainsFunc(strings.Split(words, ","), func(w string) bool {
+			return strings.Contains(strings.ToLower(d.Scenario), strings.ToLower(w))
+		})
+	}
+
+	for param, value := range filter {
+		switch param {
+		case "scopes", "scope":
+			scopes := strings.Split(value[0], ",")
+			if !slices.ContainsFunc(scopes, func(scope string) bool { return normalizeScope(scope) == d.Scope }) {
+				return false
+			}
+		case "origins":
+			if !slices.Contains(strings.Split(value[0], ","), d.Origin) {
+				return false
+			}
+		case "scenarios_containing":
+			if !containsAny(value[0]) {
+				return false
+			}
+		case "scenarios_not_containing":
+			if containsAny(value[0]) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// targetKey groups the decisions that can be in conflict.
+func targetKey(d *ent.Decision) string {
+	return d.Scope + "|" + d.Value
+}
+
+// isWinning is winningDecisions, on the snapshot: no active decision of the same target wins over d.
+func (s *Snapshot) isWinning(d *ent.Decision, now time.Time) bool {
+	longest := s.client.conflictPolicy() == csconfig.DecisionConflictLongest
+
+	for _, t := range s.byTarget[targetKey(d)] {
+		if t.ID == d.ID || (!longest && !t.Until.After(now)) {
+			continue
+		}
+
+		if s.client.inConflict(t, d) && s.client.winsOver(t, d) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isPromoted is true if a decision that won over d expired between since and now: d has to be sent again.
+func (s *Snapshot) isPromoted(d *ent.Decision, since time.Time, now time.Time) bool {
+	if s.client.conflictPolicy() == csconfig.DecisionConflictLongest {
+		return false
+	}
+
+	for _, t := range s.byTarget[targetKey(d)] {
+		if t.ID == d.ID || !t.Until.After(since) || t.Until.After(now) {
+			continue
+		}
+
+		if s.client.inConflict(t, d) && s.client.winsOver(t, d) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// query returns the decisions of the snapshot kept by keep, in the order of their IDs, with the
+// filters of the decision stream: the ones of matchFilter, id_gt and limit.
+func (s *Snapshot) query(filter map[string][]string, keep func(d *ent.Decision) bool) ([]*ent.Decision, error) {
+	idGT, limit := 0, 0
+
+	if v, ok := filter["id_gt"]; ok {
+		n, err := strconv.Atoi(v[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid id_gt '%s': %w", v[0], err)
+		}
+
+		idGT = n
+	}
+
+	if v, ok := filter["limit"]; ok {
+		n, err := strconv.Atoi(v[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid limit '%s': %w", v[0], err)
+		}
+
+		limit = n
+	}
+
+	ret := []*ent.Decision{}
+
+	for _, d := range s.Decisions {
+		if limit > 0 && len(ret) == limit {
+			break
+		}
+
+		if d.Until == nil || d.ID <= idGT || !matchFilter(d, filter) {
+			continue
+		}
+
+		if keep(d) {
+			ret = append(ret, d)
+		}
+	}
+
+	return ret, nil
+}
+
+func wantDedup(filter map[string][]string) bool {
+	v, ok := filter["dedup"]
+	return !ok || v[0] != "false"
+}
+
+// QueryAllDecisionsWithFilters is the database query of the startup pull, on the snapshot.
+func (s *Snapshot) QueryAllDecisionsWithFilters(_ context.Context, now time.Time, filter map[string][]string) ([]*ent.Decision, error) {
+	dedup := wantDedup(filter)
+
+	return s.query(filter, func(d *ent.Decision) bool {
+		return d.Until.After(now) && (!dedup || s.isWinning(d, now))
+	})
+}
+
+// QueryExpiredDecisionsWithFilters is the database query of the startup pull, on the snapshot.
+func (s *Snapshot) QueryExpiredDecisionsWithFilters(_ context.Context, now time.Time, filter map[string][]string) ([]*ent.Decision, error) {
+	dedup := wantDedup(filter)
+
+	return s.query(filter, func(d *ent.Decision) bool {
+		return d.Until.Before(now) && (!dedup || s.isWinning(d, now))
+	})
+}
+
+// QueryNewDecisionsSinceWithFilters is the database query of the delta pull, on the snapshot.
+func (s *Snapshot) QueryNewDecisionsSinceWithFilters(_ context.Context, now time.Time, since *time.Time, filter map[string][]string) ([]*ent.Decision, error) {
+	dedup := wantDedup(filter)
+
+	return s.query(filter, func(d *ent.Decision) bool {
+		if !d.Until.After(now) {
+			return false
+		}
+
+		if since != nil && !d.CreatedAt.After(*since) && (!dedup || !s.isPromoted(d, *since, now)) {
+			return false
+		}
+
+		return !dedup || s.isWinning(d, now)
+	})
+}
+
+// QueryExpiredDecisionsSinceWithFilters is the database query of the delta pull, on the snapshot.
+func (s *Snapshot) QueryExpiredDecisionsSinceWithFilters(_ context.Context, now time.Time, since *time.Time, filter map[string][]string) ([]*ent.Decision, error) {
+	dedup := wantDedup(filter)
+
+	return s.query(filter, func(d *ent.Decision) bool {
+		if !d.Until.Before(now) || (since != nil && !d.Until.After(*since)) {
+			return false
+		}
+
+		return !dedup || s.isWinning(d, now)
+	})
+}
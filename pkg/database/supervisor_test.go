@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/database/ent"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+)
+
+func TestSnapshotBouncerByKey(t *testing.T) {
+	snapshot := Snapshot{Bouncers: []*ent.Bouncer{
+		{Name: "b1", APIKey: "hash", AuthType: types.ApiKeyAuthType, IPAddress: "1.2.3.4"},
+		{Name: "b2", APIKey: "hash", AuthType: types.ApiKeyAuthType, IPAddress: "5.6.7.8"},
+		{Name: "b3", APIKey: "other", AuthType: types.ApiKeyAuthType, IPAddress: "5.6.7.8"},
+	}}
+
+	assert.Equal(t, "b2", snapshot.BouncerByKey("hash", types.ApiKeyAuthType, "5.6.7.8").Name)
+	// unknown IP: the first bouncer with the key
+	assert.Equal(t, "b1", snapshot.BouncerByKey("hash", types.ApiKeyAuthType, "9.9.9.9").Name)
+	assert.Nil(t, snapshot.BouncerByKey("hash", types.TlsAuthType, "1.2.3.4"))
+	assert.Nil(t, snapshot.BouncerByKey("nope", types.ApiKeyAuthType, "1.2.3.4"))
+
+	assert.Equal(t, "b3", snapshot.BouncerByName("b3").Name)
+	assert.Nil(t, snapshot.BouncerByName("b4"))
+}
+
+func snapshotIDs(t *testing.T, decisions []*ent.Decision, err error) []int {
+	t.Helper()
+
+	require.NoError(t, err)
+
+	ret := []int{}
+	for _, d := range decisions {
+		ret = append(ret, d.ID)
+	}
+
+	return ret
+}
+
+func TestSnapshotDecisions(t *testing.T) {
+	ctx := t.Context()
+	now := time.Now().UTC()
+
+	snapshot := newSnapshot(nil, now, []*ent.Decision{
+		{ID: 1, CreatedAt: now.Add(-time.Hour), Until: new(now.Add(time.Hour)), Scope: types.Ip, Value: "1.1.1.1", Type: "ban", Origin: types.CAPIOrigin, Scenario: "crowdsecurity/ssh-bf"},
+		{ID: 2, CreatedAt: now.Add(-time.Minute), Until: new(now.Add(time.Hour)), Scope: types.Range, Value: "2.2.2.0/24", Type: "ban", Origin: types.CrowdSecOrigin, Scenario: "crowdsecurity/http-probing"},
+		{ID: 3, CreatedAt: now.Add(-time.Hour), Until: new(now.Add(-30 * time.Minute)), Scope: types.Ip, Value: "3.3.3.3", Type: "ban", Origin: types.CrowdSecOrigin, Scenario: "crowdsecurity/ssh-bf"},
+		{ID: 4, CreatedAt: now.Add(-time.Hour), Until: new(now.Add(-time.Minute)), Scope: types.Ip, Value: "4.4.4.4", Type: "ban", Origin: types.CrowdSecOrigin, Scenario: "crowdsecurity/http-bf"},
+	}, nil)
+
+	since := now.Add(-10 * time.Minute)
+
+	all := func(filter map[string][]string) []int {
+		decisions, err := snapshot.QueryAllDecisionsWithFilters(ctx, now, filter)
+		return snapshotIDs(t, decisions, err)
+	}
+
+	assert.Equal(t, []int{1, 2}, all(nil))
+	assert.Equal(t, []int{1}, all(map[string][]string{"scopes": {"ip"}}))
+	assert.Equal(t, []int{2}, all(map[string][]string{"origins": {"crowdsec,cscli"}}))
+	assert.Equal(t, []int{2}, all(map[string][]string{"scenarios_containing": {"http"}}))
+	assert.Equal(t, []int{1}, all(map[string][]string{"scenarios_not_containing": {"http"}}))
+	assert.Equal(t, []int{2}, all(map[string][]string{"id_gt": {"1"}, "limit": {"1"}}))
+	assert.Equal(t, []int{1}, all(map[string][]string{"limit": {"1"}}))
+
+	decisions, err := snapshot.QueryNewDecisionsSinceWithFilters(ctx, now, &since, nil)
+	assert.Equal(t, []int{2}, snapshotIDs(t, decisions, err))
+
+	decisions, err = snapshot.QueryExpiredDecisionsWithFilters(ctx, now, nil)
+	assert.Equal(t, []int{3, 4}, snapshotIDs(t, decisions, err))
+
+	decisions, err = snapshot.QueryExpiredDecisionsSinceWithFilters(ctx, now, &since, nil)
+	assert.Equal(t, []int{4}, snapshotIDs(t, decisions, err))
+
+	decisions, err = snapshot.QueryExpiredDecisionsWithFilters(ctx, now, map[string][]string{"scenarios_containing": {"ssh"}})
+	assert.Equal(t, []int{3}, snapshotIDs(t, decisions, err))
+
+	_, err = snapshot.QueryAllDecisionsWithFilters(ctx, now, map[string][]string{"limit": {"ten"}})
+	require.Error(t, err)
+}
+
+// the deduplication of the snapshot is the one of the database, for each decision_conflicts policy
+func TestSnapshotDecisionConflicts(t *testing.T) {
+	ctx := t.Context()
+	dbClient := getDBClient(t, ctx)
+	now := time.Now().UTC()
+	lastPull := now.Add(-time.Minute)
+
+	createTestDecision(t, ctx, dbClient, "1.2.3.4", "ban", "CAPI", now.Add(-2*time.Hour), now.Add(24*time.Hour))
+	createTestDecision(t, ctx, dbClient, "1.2.3.4", "captcha", "cscli", now.Add(-2*time.Hour), now.Add(48*time.Hour))
+	createTestDecision(t, ctx, dbClient, "1.2.3.4", "ban", "cscli", now, now.Add(time.Hour))
+	// expired since the last pull
+	createTestDecision(t, ctx, dbClient, "5.6.7.8", "ban", "cscli", now.Add(-2*time.Hour), now.Add(-30*time.Second))
+	createTestDecision(t, ctx, dbClient, "5.6.7.8", "ban", "CAPI", now.Add(-2*time.Hour), now.Add(24*time.Hour))
+	createTestDecision(t, ctx, dbClient, "5.6.7.8", "captcha", "crowdsec", now.Add(-2*time.Hour), now.Add(-20*time.Second))
+
+	decisions, err := dbClient.Ent.Decision.Query().Order(ent.Asc("id")).All(ctx)
+	require.NoError(t, err)
+
+	snapshot := newSnapshot(dbClient, now, decisions, nil)
+
+	configs := map[string]*csconfig.DecisionConflictsCfg{
+		"default":   nil,
+		"strictest": {Policy: "strictest"},
+		"trust":     {Policy: "trust"},
+		"CAPI wins": {Policy: "trust", Origins: []string{"CAPI"}},
+	}
+
+	for name, cfg := range configs {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, cfg.Validate())
+			dbClient.DecisionConflicts = cfg
+
+			for _, filter := range []map[string][]string{{}, {"dedup": {"false"}}} {
+				expected, err := dbClient.QueryAllDecisionsWithFilters(ctx, now, filter)
+				require.NoError(t, err)
+
+				actual, err := snapshot.QueryAllDecisionsWithFilters(ctx, now, filter)
+				assert.Equal(t, decisionIDsOf(expected), snapshotIDs(t, actual, err), "startup %v", filter)
+
+				expected, err = dbClient.QueryExpiredDecisionsWithFilters(ctx, now, filter)
+				require.NoError(t, err)
+
+				actual, err = snapshot.QueryExpiredDecisionsWithFilters(ctx, now, filter)
+				assert.Equal(t, decisionIDsOf(expected), snapshotIDs(t, actual, err), "startup expired %v", filter)
+
+				expected, err = dbClient.QueryNewDecisionsSinceWithFilters(ctx, now, &lastPull, filter)
+				require.NoError(t, err)
+
+				actual, err = snapshot.QueryNewDecisionsSinceWithFilters(ctx, now, &lastPull, filter)
+				assert.Equal(t, decisionIDsOf(expected), snapshotIDs(t, actual, err), "delta %v", filter)
+
+				expected, err = dbClient.QueryExpiredDecisionsSinceWithFilters(ctx, now, &lastPull, filter)
+				require.NoError(t, err)
+
+				actual, err = snapshot.QueryExpiredDecisionsSinceWithFilters(ctx, now, &lastPull, filter)
+				assert.Equal(t, decisionIDsOf(expected), snapshotIDs(t, actual, err), "delta expired %v", filter)
+			}
+		})
+	}
+}
+
+func TestSupervisorLastPull(t *testing.T) {
+	now := time.Now().UTC()
+	s := &Supervisor{}
+
+	bouncer := &ent.Bouncer{Name: "test", LastPull: new(now.Add(-time.Hour))}
+	assert.Equal(t, bouncer.LastPull, s.LastPull(bouncer))
+
+	s.SetLastPull("test", now)
+	assert.Equal(t, now, *s.LastPull(bouncer))
+	assert.Nil(t, s.LastPull(&ent.Bouncer{Name: "other"}))
+
+	// more recent in the database
+	bouncer.LastPull = new(now.Add(time.Minute))
+	assert.Equal(t, bouncer.LastPull, s.LastPull(bouncer))
+}
+
+func TestSupervisorRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	dbClient := getDBClient(t, ctx)
+
+	_, err := dbClient.CreateBouncer(ctx, "test", "127.0.0.1", "key", types.ApiKeyAuthType, false)
+	require.NoError(t, err)
+
+	cfg := &csconfig.DBSupervisionCfg{}
+	require.NoError(t, cfg.Load())
+	cfg.CheckInterval = new(10 * time.Millisecond)
+
+	var down atomic.Bool
+
+	s := NewSupervisor(dbClient, cfg, log.NewEntry(log.StandardLogger()))
+	s.ping = func(ctx context.Context) error {
+		if down.Load() {
+			return errors.New("connection refused")
+		}
+
+		return dbClient.Ping(ctx)
+	}
+
+	done := make(chan error)
+
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		return len(s.Snapshot().Bouncers) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.False(t, s.Degraded())
+
+	down.Store(true)
+	require.Eventually(t, s.Degraded, 5*time.Second, 10*time.Millisecond)
+
+	// the snapshot is kept while degraded
+	snapshot := s.Snapshot()
+	assert.Equal(t, "test", snapshot.BouncerByName("test").Name)
+
+	down.Store(false)
+	require.Eventually(t, func() bool { return !s.Degraded() }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+
+	var nilSupervisor *Supervisor
+	assert.False(t, nilSupervisor.Degraded())
+}
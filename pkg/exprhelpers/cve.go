@@ -0,0 +1,209 @@
+package exprhelpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// dataFileCPE holds the CPE dictionaries (data files of type "cpe"), keyed by filename,
+// then by product name as it appears in banners.
+var dataFileCPE map[string]map[string][]cpeEntry
+
+// cpeRecord is a line of a "cpe" data file. The version bounds follow the NVD configuration
+// format, they are only used if the version of the CPE is a wildcard.
+//
+//	{"cve": "CVE-2021-41773", "cpe": "cpe:2.3:a:apache:http_server:2.4.49:*:*:*:*:*:*:*", "banner": "Apache"}
+//	{"cve": "CVE-2024-6387", "cpe": "cpe:2.3:a:openbsd:openssh:*:*:*:*:*:*:*:*", "version_start_including": "8.5p1", "version_end_excluding": "9.8p1"}
+type cpeRecord struct {
+	CVE                   string `json:"cve"`
+	CPE                   string `json:"cpe"`
+	Banner                string `json:"banner"` // product name in banners, when it differs from the CPE product
+	VersionStartIncluding string `json:"version_start_including"`
+	VersionStartExcluding string `json:"version_start_excluding"`
+	VersionEndIncluding   string `json:"version_end_including"`
+	VersionEndExcluding   string `json:"version_end_excluding"`
+}
+
+type cpeEntry struct {
+	cve     string
+	version string // empty if any version in the bounds
+	record  cpeRecord
+}
+
+// a product name followed by its version, like "OpenSSH_8.9p1", "Apache/2.4.49" or "nginx 1.18.0"
+var bannerProductRe = regexp.MustCompile(`(?i)([a-z][a-z0-9_.+-]*?)[/_ -]v?(\d+(?:\.\d+)*[a-z]*\d*)`)
+
+func normalizeProduct(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "-", "_")
+}
+
+// cpeFileInit parses a single JSON line and adds it to the CPE dictionary of the given filename.
+func cpeFileInit(filename string, line string) error {
+	var record cpeRecord
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		return fmt.Errorf("failed to parse JSON line in %s: %w", filename, err)
+	}
+
+	if record.CVE == "" {
+		return fmt.Errorf("missing mandatory 'cve' field in %s: %s", filename, line)
+	}
+
+	// cpe:2.3:part:vendor:product:version:update:...
+	fields := strings.Split(record.CPE, ":")
+	if len(fields) < 7 || fields[0] != "cpe" || fields[1] != "2.3" {
+		return fmt.Errorf("invalid 'cpe' field in %s (expected cpe:2.3:part:vendor:product:version:update:...): %s", filename, line)
+	}
+
+	entry := cpeEntry{
+		cve:    record.CVE,
+		record: record,
+	}
+
+	if version := fields[5]; version != "*" && version != "-" {
+		entry.version = version
+		if update := fields[6]; update != "*" && update != "-" {
+			entry.version += update
+		}
+	}
+
+	product := normalizeProduct(fields[4])
+	if record.Banner != "" {
+		product = normalizeProduct(record.Banner)
+	}
+
+	if dataFileCPE[filename] == nil {
+		dataFileCPE[filename] = make(map[string][]cpeEntry)
+	}
+
+	dataFileCPE[filename][product] = append(dataFileCPE[filename][product], entry)
+
+	return nil
+}
+
+// splitVersion cuts a version in numeric and alphabetic segments: "8.9p1" -> ["8", "9", "p", "1"]
+func splitVersion(version string) []string {
+	segments := []string{}
+	current := ""
+
+	for _, r := range strings.ToLower(version) {
+		switch {
+		case r == '.' || r == '-' || r == '_':
+			if current != "" {
+				segments = append(segments, current)
+			}
+
+			current = ""
+		case current != "" && (r >= '0' && r <= '9') != (current[0] >= '0' && current[0] <= '9'):
+			segments = append(segments, current)
+			current = string(r)
+		default:
+			current += string(r)
+		}
+	}
+
+	if current != "" {
+		segments = append(segments, current)
+	}
+
+	return segments
+}
+
+// compareVersions compares two versions segment by segment, numerically when both segments are numbers.
+func compareVersions(a string, b string) int {
+	sa := splitVersion(a)
+	sb := splitVersion(b)
+
+	for i := range max(len(sa), len(sb)) {
+		// a missing segment is lower than any other: 8.9 < 8.9p1, 1.0 < 1.0.1
+		if i >= len(sa) {
+			return -1
+		}
+
+		if i >= len(sb) {
+			return 1
+		}
+
+		na, errA := strconv.Atoi(sa[i])
+		nb, errB := strconv.Atoi(sb[i])
+
+		var c int
+
+		switch {
+		case errA == nil && errB == nil:
+			c = na - nb
+		case errA == nil:
+			// numbers are greater than letters: 1.0.1 > 1.0a
+			c = 1
+		case errB == nil:
+			c = -1
+		default:
+			c = strings.Compare(sa[i], sb[i])
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return 0
+}
+
+func (e cpeEntry) matches(version string) bool {
+	if e.version != "" {
+		return compareVersions(version, e.version) == 0
+	}
+
+	r := e.record
+
+	if r.VersionStartIncluding != "" && compareVersions(version, r.VersionStartIncluding) < 0 {
+		return false
+	}
+
+	if r.VersionStartExcluding != "" && compareVersions(version, r.VersionStartExcluding) <= 0 {
+		return false
+	}
+
+	if r.VersionEndIncluding != "" && compareVersions(version, r.VersionEndIncluding) > 0 {
+		return false
+	}
+
+	if r.VersionEndExcluding != "" && compareVersions(version, r.VersionEndExcluding) >= 0 {
+		return false
+	}
+
+	return true
+}
+
+// CveMatch extracts the product names and versions from a banner or user agent, and returns
+// the CVEs of the CPE dictionary that affect them, sorted.
+// func CveMatch(banner string, filename string) []string
+func CveMatch(params ...any) (any, error) {
+	banner := params[0].(string)
+	filename := params[1].(string)
+
+	dict, ok := dataFileCPE[filename]
+	if !ok {
+		log.Errorf("file '%s' (type:cpe) not found in expr library", filename)
+		return []string{}, nil
+	}
+
+	ret := []string{}
+
+	for _, match := range bannerProductRe.FindAllStringSubmatch(banner, -1) {
+		for _, entry := range dict[normalizeProduct(match[1])] {
+			if entry.matches(match[2]) && !slices.Contains(ret, entry.cve) {
+				ret = append(ret, entry.cve)
+			}
+		}
+	}
+
+	slices.Sort(ret)
+
+	return ret, nil
+}
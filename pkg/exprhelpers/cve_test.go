@@ -0,0 +1,119 @@
+package exprhelpers
+
+import (
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		expected int
+	}{
+		{"2.4.49", "2.4.49", 0},
+		{"2.4.49", "2.4.50", -1},
+		{"2.4.100", "2.4.50", 1},
+		{"8.9p1", "8.9", 1},
+		{"8.9p1", "9.3p2", -1},
+		{"9.3p2", "9.3p10", -1},
+		{"1.0.1", "1.0a", 1},
+		{"1.0.2k", "1.0.2l", -1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.a+"_"+tc.b, func(t *testing.T) {
+			c := compareVersions(tc.a, tc.b)
+			assert.Equal(t, tc.expected, max(-1, min(1, c)))
+		})
+	}
+}
+
+func TestFileInitCPEInvalid(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	err = FileInit("testdata", "test_data_cpe_invalid.json", "cpe")
+	cstest.RequireErrorContains(t, err, "invalid 'cpe' field in test_data_cpe_invalid.json")
+}
+
+func TestCveMatch(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	err = FileInit("testdata", "test_data_cpe.json", "cpe")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		banner   string
+		expected []string
+	}{
+		{
+			name:     "exact version",
+			banner:   "Apache/2.4.49 (Unix)",
+			expected: []string{"CVE-2021-41773", "CVE-2021-42013"},
+		},
+		{
+			name:     "patched version",
+			banner:   "Apache/2.4.51 (Unix)",
+			expected: []string{},
+		},
+		{
+			name:     "version with update",
+			banner:   "SSH-2.0-OpenSSH_7.2p2 Ubuntu-4ubuntu2.10",
+			expected: []string{"CVE-2016-20012", "CVE-2023-38408"},
+		},
+		{
+			name:     "version range",
+			banner:   "SSH-2.0-OpenSSH_9.6p1 Ubuntu-3ubuntu13",
+			expected: []string{"CVE-2024-6387"},
+		},
+		{
+			name:     "after the range",
+			banner:   "SSH-2.0-OpenSSH_9.8p1",
+			expected: []string{},
+		},
+		{
+			name:     "excluded start",
+			banner:   "nginx/1.18.0",
+			expected: []string{},
+		},
+		{
+			name:     "included end",
+			banner:   "nginx/1.20.0",
+			expected: []string{"CVE-2021-3618"},
+		},
+		{
+			name:     "unknown product",
+			banner:   "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0",
+			expected: []string{},
+		},
+		{
+			name:     "empty",
+			banner:   "",
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vm, err := expr.Compile(`CveMatch(banner, "test_data_cpe.json")`, GetExprOptions(map[string]any{"banner": ""})...)
+			require.NoError(t, err)
+
+			ret, err := expr.Run(vm, map[string]any{"banner": tc.banner})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ret)
+		})
+	}
+
+	// unknown file
+	ret, err := CveMatch("Apache/2.4.49", "nope.json")
+	require.NoError(t, err)
+	assert.Equal(t, []string{}, ret)
+}
@@ -0,0 +1,125 @@
+package exprhelpers
+
+import (
+	"net/mail"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// dataFileDomain holds the domain lists (data files of type "domain"), keyed by filename.
+// A domain in the list also matches its subdomains.
+var dataFileDomain map[string]map[string]struct{}
+
+// providers ignoring the dots in the user part, and the domain to use for them
+var dotInsensitiveDomains = map[string]string{
+	"gmail.com":      "gmail.com",
+	"googlemail.com": "gmail.com",
+}
+
+func domainFileInit(filename string, line string) {
+	if dataFileDomain[filename] == nil {
+		dataFileDomain[filename] = make(map[string]struct{})
+	}
+
+	domain := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(line)), ".")
+	dataFileDomain[filename][domain] = struct{}{}
+}
+
+// splitEmail returns the lower-cased user and domain parts of an email address,
+// with or without a display name ("John <john@example.com>").
+func splitEmail(email string) (string, string, bool) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil {
+		return "", "", false
+	}
+
+	user, domain, ok := strings.Cut(addr.Address, "@")
+	if !ok || user == "" || domain == "" {
+		return "", "", false
+	}
+
+	return strings.ToLower(user), strings.TrimSuffix(strings.ToLower(domain), "."), true
+}
+
+// normalizeEmail removes the plus-addressing tag of the user, and the dots for the
+// providers that ignore them, so the variants of an address compare equal.
+func normalizeEmail(user string, domain string) (string, string, string) {
+	user, tag, _ := strings.Cut(user, "+")
+
+	if canonical, ok := dotInsensitiveDomains[domain]; ok {
+		user = strings.ReplaceAll(user, ".", "")
+		domain = canonical
+	}
+
+	return user, domain, tag
+}
+
+// ParseEmailAddress returns the parts of an email address: user, domain, tag (the plus-addressing
+// suffix of the user) and normalized (the address without tag, and without the dots of the user
+// for gmail). It returns an empty map if the address is invalid.
+// func ParseEmailAddress(email string) map[string]string
+func ParseEmailAddress(params ...any) (any, error) {
+	email := params[0].(string)
+
+	user, domain, ok := splitEmail(email)
+	if !ok {
+		log.Debugf("unable to parse email address '%s'", email)
+		return map[string]string{}, nil
+	}
+
+	normUser, normDomain, tag := normalizeEmail(user, domain)
+
+	return map[string]string{
+		"user":       user,
+		"domain":     domain,
+		"tag":        tag,
+		"normalized": normUser + "@" + normDomain,
+	}, nil
+}
+
+// NormalizeEmail returns the normalized form of an email address (see ParseEmailAddress),
+// or an empty string if the address is invalid.
+// func NormalizeEmail(email string) string
+func NormalizeEmail(params ...any) (any, error) {
+	user, domain, ok := splitEmail(params[0].(string))
+	if !ok {
+		return "", nil
+	}
+
+	user, domain, _ = normalizeEmail(user, domain)
+
+	return user + "@" + domain, nil
+}
+
+// IsDisposableEmail returns true if the domain of the email address, or one of its parent
+// domains, is in the given data file of type "domain".
+// func IsDisposableEmail(email string, filename string) bool
+func IsDisposableEmail(params ...any) (any, error) {
+	email := params[0].(string)
+	filename := params[1].(string)
+
+	domains, ok := dataFileDomain[filename]
+	if !ok {
+		log.Errorf("file '%s' (type:domain) not found in expr library", filename)
+		return false, nil
+	}
+
+	_, domain, ok := splitEmail(email)
+	if !ok {
+		return false, nil
+	}
+
+	for {
+		if _, ok := domains[domain]; ok {
+			return true, nil
+		}
+
+		_, parent, found := strings.Cut(domain, ".")
+		if !found || !strings.Contains(parent, ".") {
+			return false, nil
+		}
+
+		domain = parent
+	}
+}
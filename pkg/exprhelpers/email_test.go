@@ -0,0 +1,110 @@
+package exprhelpers
+
+import (
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEmailAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		expected map[string]string
+	}{
+		{
+			name:  "simple",
+			email: "John.Doe@Example.com",
+			expected: map[string]string{
+				"user": "john.doe", "domain": "example.com", "tag": "", "normalized": "john.doe@example.com",
+			},
+		},
+		{
+			name:  "plus addressing",
+			email: "john+signup@example.com",
+			expected: map[string]string{
+				"user": "john+signup", "domain": "example.com", "tag": "signup", "normalized": "john@example.com",
+			},
+		},
+		{
+			name:  "gmail dots",
+			email: "j.o.h.n+1@googlemail.com",
+			expected: map[string]string{
+				"user": "j.o.h.n+1", "domain": "googlemail.com", "tag": "1", "normalized": "john@gmail.com",
+			},
+		},
+		{
+			name:  "display name",
+			email: "John Doe <john@example.com>",
+			expected: map[string]string{
+				"user": "john", "domain": "example.com", "tag": "", "normalized": "john@example.com",
+			},
+		},
+		{
+			name:     "invalid",
+			email:    "not an email",
+			expected: map[string]string{},
+		},
+		{
+			name:     "empty",
+			email:    "",
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vm, err := expr.Compile(`ParseEmailAddress(email)`, GetExprOptions(map[string]any{"email": ""})...)
+			require.NoError(t, err)
+
+			ret, err := expr.Run(vm, map[string]any{"email": tc.email})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ret)
+
+			normalized, err := NormalizeEmail(tc.email)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected["normalized"], normalized)
+		})
+	}
+}
+
+func TestIsDisposableEmail(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	err = FileInit("testdata", "test_data_domain.txt", "domain")
+	require.NoError(t, err)
+
+	tests := []struct {
+		email    string
+		expected bool
+	}{
+		{"foo@mailinator.com", true},
+		{"foo+bar@MAILINATOR.COM", true},
+		{"foo@guerrillamail.com", true},
+		{"foo@yopmail.fr", true},
+		{"foo@eu.mailinator.com", true},
+		{"foo@mailinator.com.example.org", false},
+		{"foo@example.com", false},
+		{"foo@com", false},
+		{"invalid", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.email, func(t *testing.T) {
+			vm, err := expr.Compile(`IsDisposableEmail(email, "test_data_domain.txt")`, GetExprOptions(map[string]any{"email": ""})...)
+			require.NoError(t, err)
+
+			ret, err := expr.Run(vm, map[string]any{"email": tc.email})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ret)
+		})
+	}
+
+	// unknown file
+	ret, err := IsDisposableEmail("foo@mailinator.com", "nope.txt")
+	require.NoError(t, err)
+	assert.False(t, ret.(bool))
+}
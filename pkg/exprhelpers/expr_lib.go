@@ -26,6 +26,20 @@ var exprFuncs = []exprCustomFunc{
 			new(func(string) (*cticlient.SmokeItem, error)),
 		},
 	},
+	{
+		name:     "HttpGet",
+		function: HttpGet,
+		signature: []any{
+			new(func(string) string),
+		},
+	},
+	{
+		name:     "MachineLearningScore",
+		function: MachineLearningScore,
+		signature: []any{
+			new(func(string, []any) float64),
+		},
+	},
 	{
 		name:      "Flatten",
 		function:  Flatten,
@@ -41,6 +55,33 @@ var exprFuncs = []exprCustomFunc{
 		function:  FlattenDistinct,
 		signature: []any{},
 	},
+	{
+		name:      "MapKeys",
+		function:  MapKeys,
+		signature: []any{},
+	},
+	{
+		name:      "MapValues",
+		function:  MapValues,
+		signature: []any{},
+	},
+	{
+		name:      "ConcatMaps",
+		function:  ConcatMaps,
+		signature: []any{},
+	},
+	{
+		name:     "MergeMeta",
+		function: MergeMeta,
+		signature: []any{
+			new(func(any, any) map[string]string),
+		},
+	},
+	{
+		name:      "Sort",
+		function:  Sort,
+		signature: []any{},
+	},
 	{
 		name:     "Distance",
 		function: Distance,
@@ -132,6 +173,97 @@ var exprFuncs = []exprCustomFunc{
 			new(func(string, string) string),
 		},
 	},
+	{
+		name:     "CveMatch",
+		function: CveMatch,
+		signature: []any{
+			new(func(string, string) []string),
+		},
+	},
+	{
+		name:     "ParseEmailAddress",
+		function: ParseEmailAddress,
+		signature: []any{
+			new(func(string) map[string]string),
+		},
+	},
+	{
+		name:     "NormalizeEmail",
+		function: NormalizeEmail,
+		signature: []any{
+			new(func(string) string),
+		},
+	},
+	{
+		name:     "IsDisposableEmail",
+		function: IsDisposableEmail,
+		signature: []any{
+			new(func(string, string) bool),
+		},
+	},
+	{
+		name:     "WeakPasswordCheck",
+		function: WeakPasswordCheck,
+		signature: []any{
+			new(func(string, string) int),
+		},
+	},
+	{
+		name:     "Sha256",
+		function: Sha256,
+		signature: []any{
+			new(func(string) string),
+		},
+	},
+	{
+		name:     "Sha1",
+		function: Sha1,
+		signature: []any{
+			new(func(string) string),
+		},
+	},
+	{
+		name:     "Md5",
+		function: Md5,
+		signature: []any{
+			new(func(string) string),
+		},
+	},
+	{
+		name:     "Xxhash",
+		function: Xxhash,
+		signature: []any{
+			new(func(string) string),
+		},
+	},
+	{
+		name:     "Hmac",
+		function: Hmac,
+		signature: []any{
+			new(func(string, string, string) string),
+		},
+	},
+	{
+		name:     "DecodeJWTClaims",
+		function: DecodeJWTClaims,
+		signature: []any{
+			new(func(string) map[string]any),
+		},
+	},
+	{
+		name:     "JWTIsExpired",
+		function: JWTIsExpired,
+		signature: []any{
+			new(func(string) bool),
+		},
+	},
+	{
+		name:     "VerifyJWT",
+		function: VerifyJWT,
+		signature: []any{
+			new(func(string, string) bool),
+		},
+	},
 	{
 		name:     "Upper",
 		function: Upper,
@@ -167,6 +299,13 @@ var exprFuncs = []exprCustomFunc{
 			new(func(string) map[string][]string),
 		},
 	},
+	{
+		name:     "ParseURL",
+		function: ParseURL,
+		signature: []any{
+			new(func(string) *ParsedURL),
+		},
+	},
 	{
 		name:     "ParseQuery",
 		function: ExprWrapParseQuery,
@@ -223,6 +362,22 @@ var exprFuncs = []exprCustomFunc{
 			new(func(string, string) string),
 		},
 	},
+	{
+		name:     "XMLExtract",
+		function: XMLExtract,
+		signature: []any{
+			new(func(string, string) string),
+			new(func(string, string, map[string]any) string),
+		},
+	},
+	{
+		name:     "XMLExtractSlice",
+		function: XMLExtractSlice,
+		signature: []any{
+			new(func(string, string) []string),
+			new(func(string, string, map[string]any) []string),
+		},
+	},
 	{
 		name:     "IpToRange",
 		function: IpToRange,
@@ -230,6 +385,21 @@ var exprFuncs = []exprCustomFunc{
 			new(func(string, string) string),
 		},
 	},
+	{
+		name:     "IpRangeSize",
+		function: IpRangeSize,
+		signature: []any{
+			new(func(string) int),
+		},
+	},
+	{
+		name:     "AggregateRanges",
+		function: AggregateRanges,
+		signature: []any{
+			new(func([]string) []string),
+			new(func([]any) []string),
+		},
+	},
 	{
 		name:     "IsIPV6",
 		function: IsIPV6,
@@ -286,6 +456,20 @@ var exprFuncs = []exprCustomFunc{
 			new(func(string, string) int),
 		},
 	},
+	{
+		name:     "GetAlertsCountForIP",
+		function: GetAlertsCountForIP,
+		signature: []any{
+			new(func(string, string) int),
+		},
+	},
+	{
+		name:     "GetAlertsScenariosForIP",
+		function: GetAlertsScenariosForIP,
+		signature: []any{
+			new(func(string, string) []string),
+		},
+	},
 	{
 		name:     "Sprintf",
 		function: Sprintf,
@@ -307,6 +491,13 @@ var exprFuncs = []exprCustomFunc{
 			new(func(string, string, string, *time.Duration) error),
 		},
 	},
+	{
+		name:     "Throttle",
+		function: Throttle,
+		signature: []any{
+			new(func(string, int, string) bool),
+		},
+	},
 	{
 		name:     "Fields",
 		function: Fields,
@@ -333,6 +524,7 @@ var exprFuncs = []exprCustomFunc{
 		function: Join,
 		signature: []any{
 			new(func([]string, string) string),
+			new(func([]any, string) string),
 		},
 	},
 	{
@@ -419,6 +611,20 @@ var exprFuncs = []exprCustomFunc{
 			new(func(string, string) string),
 		},
 	},
+	{
+		name:     "CountOccurrences",
+		function: CountOccurrences,
+		signature: []any{
+			new(func(string, string) int),
+		},
+	},
+	{
+		name:     "Tokenize",
+		function: Tokenize,
+		signature: []any{
+			new(func(string, string) []string),
+		},
+	},
 	{
 		name:     "Get",
 		function: Get,
@@ -433,6 +639,30 @@ var exprFuncs = []exprCustomFunc{
 			new(func(any) string),
 		},
 	},
+	{
+		name:     "ToInt",
+		function: ToInt,
+		signature: []any{
+			new(func(any) int),
+			new(func(any, int) int),
+		},
+	},
+	{
+		name:     "ToFloat",
+		function: ToFloat,
+		signature: []any{
+			new(func(any) float64),
+			new(func(any, float64) float64),
+		},
+	},
+	{
+		name:     "ToBool",
+		function: ToBool,
+		signature: []any{
+			new(func(any) bool),
+			new(func(any, bool) bool),
+		},
+	},
 	{
 		name:     "Match",
 		function: Match,
@@ -496,6 +726,35 @@ var exprFuncs = []exprCustomFunc{
 			new(func(float64, float64) bool),
 		},
 	},
+	{
+		name:     "Pow",
+		function: Pow,
+		signature: []any{
+			new(func(float64, float64) float64),
+		},
+	},
+	{
+		name:     "Log2",
+		function: Log2,
+		signature: []any{
+			new(func(float64) float64),
+		},
+	},
+	{
+		name:     "Clamp",
+		function: Clamp,
+		signature: []any{
+			new(func(float64, float64, float64) float64),
+		},
+	},
+	{
+		name:     "Round",
+		function: Round,
+		signature: []any{
+			new(func(float64) float64),
+			new(func(float64, int) float64),
+		},
+	},
 	{
 		name:     "LibInjectionIsSQLI",
 		function: LibInjectionIsSQLI,
@@ -531,6 +790,20 @@ var exprFuncs = []exprCustomFunc{
 			new(func(string) *net.IPNet),
 		},
 	},
+	{
+		name:     "GeoIPRangeForIP",
+		function: GeoIPRangeForIP,
+		signature: []any{
+			new(func(string) string),
+		},
+	},
+	{
+		name:     "GeoIPDBAge",
+		function: GeoIPDBAge,
+		signature: []any{
+			new(func() time.Duration),
+		},
+	},
 	{
 		name:     "JA4H",
 		function: JA4H,
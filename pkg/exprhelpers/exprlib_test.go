@@ -2,6 +2,7 @@ package exprhelpers
 
 import (
 	"errors"
+	"math"
 	"net/url"
 	"testing"
 	"time"
@@ -631,6 +632,116 @@ func TestIpToRange(t *testing.T) {
 	}
 }
 
+func TestIpRangeSize(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		cidr string
+		want int
+	}{
+		{name: "IPv4 range", cidr: "192.168.0.0/24", want: 256},
+		{name: "IPv4 address", cidr: "192.168.0.1", want: 1},
+		{name: "IPv4 host bits are ignored", cidr: "192.168.0.1/30", want: 4},
+		{name: "IPv6 range", cidr: "2001:db8::/120", want: 256},
+		{name: "IPv6 large range", cidr: "2001:db8::/32", want: math.MaxInt},
+		{name: "invalid range", cidr: "192.168.0.0/33", want: 0},
+		{name: "not an IP", cidr: "foo", want: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			env := map[string]any{"cidr": tc.cidr}
+
+			program, err := expr.Compile("IpRangeSize(cidr)", GetExprOptions(env)...)
+			require.NoError(t, err)
+
+			output, err := expr.Run(program, env)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, output)
+		})
+	}
+}
+
+func TestAggregateRanges(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		code    string
+		env     map[string]any
+		want    []string
+		wantLog string
+	}{
+		{
+			name: "empty list",
+			code: "AggregateRanges([])",
+			want: []string{},
+		},
+		{
+			name: "adjacent addresses",
+			code: `AggregateRanges(["192.168.0.3", "192.168.0.0", "192.168.0.1", "192.168.0.2"])`,
+			want: []string{"192.168.0.0/30"},
+		},
+		{
+			name: "not aligned addresses",
+			code: `AggregateRanges(["192.168.0.1", "192.168.0.2"])`,
+			want: []string{"192.168.0.1/32", "192.168.0.2/32"},
+		},
+		{
+			name: "addresses covered by a range",
+			code: `AggregateRanges(["10.0.0.0/8", "10.1.2.3", "10.2.0.0/16", "11.0.0.1"])`,
+			want: []string{"10.0.0.0/8", "11.0.0.1/32"},
+		},
+		{
+			name: "cascading merges",
+			code: `AggregateRanges(["10.0.0.0/25", "10.0.0.128/26", "10.0.0.192/26", "10.0.1.0/24"])`,
+			want: []string{"10.0.0.0/23"},
+		},
+		{
+			name: "duplicates",
+			code: `AggregateRanges(["1.2.3.4", "1.2.3.4/32", "1.2.3.4"])`,
+			want: []string{"1.2.3.4/32"},
+		},
+		{
+			name: "IPv4 and IPv6",
+			code: `AggregateRanges(["2001:db8::1", "2001:db8::", "0.0.0.0/1", "128.0.0.0/1"])`,
+			want: []string{"0.0.0.0/0", "2001:db8::/127"},
+		},
+		{
+			name: "list of strings",
+			code: "AggregateRanges(ips)",
+			env:  map[string]any{"ips": []string{"192.168.1.0/24", "192.168.0.0/24"}},
+			want: []string{"192.168.0.0/23"},
+		},
+		{
+			name:    "invalid entries are skipped",
+			code:    `AggregateRanges(["1.2.3.4", "foo"])`,
+			want:    []string{"1.2.3.4/32"},
+			wantLog: "can't parse range 'foo'",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := cstest.CaptureLogs(t)
+
+			program, err := expr.Compile(tc.code, GetExprOptions(tc.env)...)
+			require.NoError(t, err)
+
+			output, err := expr.Run(program, tc.env)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, output)
+
+			if tc.wantLog != "" {
+				assert.Contains(t, buf.String(), tc.wantLog)
+			}
+		})
+	}
+}
+
 func TestAtof(t *testing.T) {
 	err := Init(nil)
 	require.NoError(t, err)
@@ -1615,6 +1726,86 @@ func TestGetDecisionsSinceCount(t *testing.T) {
 	}
 }
 
+func TestGetAlertsForIP(t *testing.T) {
+	ctx := t.Context()
+
+	existingIP := "1.2.3.4"
+	unknownIP := "1.2.3.5"
+
+	dbClient = getDBClient(t)
+
+	// no decision: the alerts were simulated, or the IP is allowlisted
+	for _, scenario := range []string{"crowdsecurity/ssh-bf", "crowdsecurity/ssh-slow-bf", "crowdsecurity/ssh-bf"} {
+		dbClient.Ent.Alert.Create().
+			SetScenario(scenario).
+			SetSourceIp(existingIP).
+			SetSourceScope("Ip").
+			SetSourceValue(existingIP).
+			SetSimulated(true).
+			SaveX(ctx)
+	}
+
+	dbClient.Ent.Alert.Create().
+		SetCreatedAt(time.Now().AddDate(0, 0, -2)).
+		SetScenario("crowdsecurity/http-probing").
+		SetSourceIp(existingIP).
+		SetSourceScope("Ip").
+		SetSourceValue(existingIP).
+		SaveX(ctx)
+
+	err := Init(dbClient)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		code   string
+		result any
+	}{
+		{
+			name:   "existing IP count since 1 hour",
+			code:   "GetAlertsCountForIP(ip, '1h')",
+			result: 3,
+		},
+		{
+			name:   "existing IP count since 3 days",
+			code:   "GetAlertsCountForIP(ip, '3d')",
+			result: 4,
+		},
+		{
+			name:   "unknown IP count",
+			code:   "GetAlertsCountForIP(unknown, '3d')",
+			result: 0,
+		},
+		{
+			name:   "invalid since",
+			code:   "GetAlertsCountForIP(ip, 'xx')",
+			result: 0,
+		},
+		{
+			name:   "existing IP scenarios since 1 hour",
+			code:   "GetAlertsScenariosForIP(ip, '1h')",
+			result: []string{"crowdsecurity/ssh-bf", "crowdsecurity/ssh-slow-bf"},
+		},
+		{
+			name:   "unknown IP scenarios",
+			code:   "GetAlertsScenariosForIP(unknown, '3d')",
+			result: []string{},
+		},
+	}
+
+	env := map[string]any{"ip": existingIP, "unknown": unknownIP}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			program, err := expr.Compile(tc.code, GetExprOptions(env)...)
+			require.NoError(t, err)
+			output, err := expr.Run(program, env)
+			require.NoError(t, err)
+			assert.Equal(t, tc.result, output)
+		})
+	}
+}
+
 func TestGetActiveDecisionsCount(t *testing.T) {
 	ctx := t.Context()
 
@@ -2078,6 +2269,199 @@ func TestToString(t *testing.T) {
 	}
 }
 
+func TestConverters(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		value any
+		want  any
+		expr  string
+	}{
+		{name: "ToInt() string", value: "42", want: 42, expr: `ToInt(value)`},
+		{name: "ToInt() string with spaces", value: " -42 ", want: -42, expr: `ToInt(value)`},
+		{name: "ToInt() float string", value: "3.9", want: 3, expr: `ToInt(value)`},
+		{name: "ToInt() float", value: 3.9, want: 3, expr: `ToInt(value)`},
+		{name: "ToInt() int64", value: int64(1) << 40, want: 1 << 40, expr: `ToInt(value)`},
+		{name: "ToInt() uint8", value: uint8(7), want: 7, expr: `ToInt(value)`},
+		{name: "ToInt() bool", value: true, want: 1, expr: `ToInt(value)`},
+		{name: "ToInt() nil", value: nil, want: 0, expr: `ToInt(value)`},
+		{name: "ToInt() invalid string", value: "foo", want: 0, expr: `ToInt(value)`},
+		{name: "ToInt() NaN", value: "NaN", want: -1, expr: `ToInt(value, -1)`},
+		{name: "ToInt() default", value: "", want: -1, expr: `ToInt(value, -1)`},
+		{name: "ToInt() unsupported type", value: []string{"1"}, want: 5, expr: `ToInt(value, 5)`},
+		{name: "ToInt() out of range string", value: "1e30", want: -1, expr: `ToInt(value, -1)`},
+		{name: "ToInt() out of range float", value: -1e300, want: -1, expr: `ToInt(value, -1)`},
+		{name: "ToInt() max int64 as float", value: float64(math.MaxInt64), want: -1, expr: `ToInt(value, -1)`},
+		{name: "ToInt() min int64 as float", value: float64(math.MinInt64), want: math.MinInt64, expr: `ToInt(value)`},
+		{name: "ToInt() infinity", value: "Inf", want: -1, expr: `ToInt(value, -1)`},
+		{name: "ToFloat() string", value: "1.5", want: 1.5, expr: `ToFloat(value)`},
+		{name: "ToFloat() int", value: 2, want: 2.0, expr: `ToFloat(value)`},
+		{name: "ToFloat() float32", value: float32(0.5), want: 0.5, expr: `ToFloat(value)`},
+		{name: "ToFloat() nil", value: nil, want: 0.0, expr: `ToFloat(value)`},
+		{name: "ToFloat() default", value: "n/a", want: 0.1, expr: `ToFloat(value, 0.1)`},
+		{name: "ToFloat() arithmetic", value: "10", want: 5.0, expr: `ToFloat(value) / 2`},
+		{name: "ToBool() bool", value: true, want: true, expr: `ToBool(value)`},
+		{name: "ToBool() true string", value: "True", want: true, expr: `ToBool(value)`},
+		{name: "ToBool() yes string", value: " yes", want: true, expr: `ToBool(value)`},
+		{name: "ToBool() off string", value: "off", want: false, expr: `ToBool(value, true)`},
+		{name: "ToBool() int", value: 2, want: true, expr: `ToBool(value)`},
+		{name: "ToBool() zero", value: 0.0, want: false, expr: `ToBool(value, true)`},
+		{name: "ToBool() nil", value: nil, want: false, expr: `ToBool(value)`},
+		{name: "ToBool() default", value: "maybe", want: true, expr: `ToBool(value, true)`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vm, err := expr.Compile(tc.expr, GetExprOptions(map[string]any{"value": tc.value})...)
+			require.NoError(t, err)
+			output, err := expr.Run(vm, map[string]any{"value": tc.value})
+			require.NoError(t, err)
+			require.Equal(t, tc.want, output)
+		})
+	}
+}
+
+func TestMathHelpers(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		value       any
+		want        any
+		expr        string
+		expectedErr string
+	}{
+		{name: "Pow() floats", value: 2.0, want: 8.0, expr: `Pow(value, 3.0)`},
+		{name: "Pow() ints", value: 2, want: 0.25, expr: `Pow(value, -2)`},
+		{name: "Pow() fractional", value: 9.0, want: 3.0, expr: `Pow(value, 0.5)`},
+		{name: "Log2()", value: 1024, want: 10.0, expr: `Log2(value)`},
+		{name: "Log2() zero", value: 0.0, want: math.Inf(-1), expr: `Log2(value)`},
+		{name: "Clamp() inside", value: 0.4, want: 0.4, expr: `Clamp(value, 0, 1)`},
+		{name: "Clamp() low", value: -3, want: 0.0, expr: `Clamp(value, 0, 1)`},
+		{name: "Clamp() high", value: 12.5, want: 10.0, expr: `Clamp(value, 0, 10)`},
+		{name: "Clamp() bad bounds", value: 1.0, expr: `Clamp(value, 1, 0)`, expectedErr: "Clamp: low bound 1 is greater than high bound 0"},
+		{name: "Round()", value: 2.5, want: 3.0, expr: `Round(value)`},
+		{name: "Round() negative", value: -2.5, want: -3.0, expr: `Round(value)`},
+		{name: "Round() digits", value: 3.14159, want: 3.14, expr: `Round(value, 2)`},
+		{name: "Round() negative digits", value: 1234.0, want: 1200.0, expr: `Round(value, -2)`},
+		{name: "scoring", value: 37, want: 0.52, expr: `Round(Clamp(Log2(value) / 10, 0, 1), 2)`},
+		{name: "Log2() untyped", value: map[string]any{"count": 8}, want: 3.0, expr: `Log2(value.count)`},
+		{name: "Log2() untyped string", value: map[string]any{"count": "8"}, expr: `Log2(value.count)`, expectedErr: "Log2: expected a number, got string"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vm, err := expr.Compile(tc.expr, GetExprOptions(map[string]any{"value": tc.value})...)
+			require.NoError(t, err)
+			output, err := expr.Run(vm, map[string]any{"value": tc.value})
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			require.Equal(t, tc.want, output)
+		})
+	}
+}
+
+func TestStringAnalytics(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		value any
+		want  any
+		expr  string
+	}{
+		{name: "CountOccurrences()", value: "id=1' OR '1'='1", want: 4, expr: `CountOccurrences(value, "'")`},
+		{name: "CountOccurrences() substring", value: "/a/../../etc/passwd", want: 2, expr: `CountOccurrences(value, "../")`},
+		{name: "CountOccurrences() non-overlapping", value: "aaaa", want: 2, expr: `CountOccurrences(value, "aa")`},
+		{name: "CountOccurrences() none", value: "foo", want: 0, expr: `CountOccurrences(value, "(")`},
+		{name: "CountOccurrences() empty substr", value: "foo", want: 0, expr: `CountOccurrences(value, "")`},
+		{name: "CountOccurrences() filter", value: "concat(char(0x41),char(0x42))", want: true, expr: `CountOccurrences(value, "(") >= 3 && CountOccurrences(value, "(") == CountOccurrences(value, ")")`},
+		{name: "Tokenize()", value: "id=1; DROP TABLE users--", want: []string{"id", "1", "DROP", "TABLE", "users--"}, expr: `Tokenize(value, " =;")`},
+		{name: "Tokenize() consecutive separators", value: "a,,b;;c", want: []string{"a", "b", "c"}, expr: `Tokenize(value, ",;")`},
+		{name: "Tokenize() white space", value: " GET  /index.php\tHTTP/1.1 ", want: []string{"GET", "/index.php", "HTTP/1.1"}, expr: `Tokenize(value, "")`},
+		{name: "Tokenize() multibyte separator", value: "a→b→c", want: []string{"a", "b", "c"}, expr: `Tokenize(value, "→")`},
+		{name: "Tokenize() empty", value: "", want: []string{}, expr: `Tokenize(value, ",")`},
+		{name: "Tokenize() count", value: "select a from b union select c from d", want: 2, expr: `count(Tokenize(value, " "), # == "select")`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vm, err := expr.Compile(tc.expr, GetExprOptions(map[string]any{"value": tc.value})...)
+			require.NoError(t, err)
+			output, err := expr.Run(vm, map[string]any{"value": tc.value})
+			require.NoError(t, err)
+			require.Equal(t, tc.want, output)
+		})
+	}
+}
+
+func TestMapHelpers(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	unmarshaled := map[string]any{
+		"user": "bob",
+		"tags": []any{"a", "b", "a"},
+		"headers": map[string]any{
+			"host":   "example.com",
+			"accept": "*/*",
+		},
+		"count": 2.0,
+	}
+
+	tests := []struct {
+		name  string
+		value any
+		want  any
+		expr  string
+	}{
+		{name: "MapKeys()", value: unmarshaled, want: []string{"count", "headers", "tags", "user"}, expr: `MapKeys(value)`},
+		{name: "MapKeys() map[string]string", value: map[string]string{"b": "1", "a": "2"}, want: []string{"a", "b"}, expr: `MapKeys(value)`},
+		{name: "MapKeys() not a map", value: "foo", want: []string{}, expr: `MapKeys(value)`},
+		{name: "MapKeys() nil", value: nil, want: []string{}, expr: `MapKeys(value)`},
+		{name: "MapKeys() nested", value: unmarshaled, want: []string{"accept", "host"}, expr: `MapKeys(value.headers)`},
+		{name: "MapValues()", value: map[string]string{"b": "1", "a": "2"}, want: []any{"2", "1"}, expr: `MapValues(value)`},
+		{name: "MapValues() not a map", value: []string{"a"}, want: []any{}, expr: `MapValues(value)`},
+		{name: "Join(MapKeys())", value: unmarshaled, want: "accept,host", expr: `Join(MapKeys(value.headers), ",")`},
+		{name: "Join(MapValues())", value: unmarshaled, want: "*/*,example.com", expr: `Join(MapValues(value.headers), ",")`},
+		{name: "Join() non-string values", value: map[string]any{"a": 1, "b": nil, "c": true}, want: "1,,true", expr: `Join(MapValues(value), ",")`},
+		{name: "Flatten() map", value: unmarshaled, want: []any{2.0, "*/*", "example.com", "a", "b", "a", "bob"}, expr: `Flatten(value)`},
+		{name: "FlattenDistinct() map", value: unmarshaled, want: []any{2.0, "*/*", "example.com", "a", "b", "bob"}, expr: `FlattenDistinct(value)`},
+		{name: "Distinct() map", value: map[string]string{"a": "x", "b": "y", "c": "x"}, want: []any{"x", "y"}, expr: `Distinct(value)`},
+		{name: "Distinct() []string", value: []string{"x", "y", "x"}, want: []any{"x", "y"}, expr: `Distinct(value)`},
+		{name: "Distinct() uncomparable", value: []any{[]any{1}, []any{1}}, want: []any{[]any{1}, []any{1}}, expr: `Distinct(value)`},
+		{name: "Sort() strings", value: []string{"b", "c", "a"}, want: []any{"a", "b", "c"}, expr: `Sort(value)`},
+		{name: "Sort() numbers", value: []any{10, 2.5, 1}, want: []any{1, 2.5, 10}, expr: `Sort(value)`},
+		{name: "Sort() mixed", value: []any{"b", 3, "a", 1.0}, want: []any{1.0, 3, "a", "b"}, expr: `Sort(value)`},
+		{name: "Sort() map", value: map[string]string{"a": "z", "b": "y"}, want: []any{"y", "z"}, expr: `Sort(value)`},
+		{name: "Join(Sort(Distinct()))", value: unmarshaled, want: "a b", expr: `Join(Sort(Distinct(value.tags)), " ")`},
+		{name: "len(MapKeys())", value: unmarshaled, want: 4, expr: `len(MapKeys(value))`},
+		{name: "ConcatMaps()", value: unmarshaled, want: map[string]any{"host": "example.com", "accept": "*/*", "user": "bob"}, expr: `ConcatMaps(value.headers, {"user": value.user})`},
+		{name: "ConcatMaps() last wins", value: map[string]string{"a": "1", "b": "2"}, want: map[string]any{"a": "1", "b": "3"}, expr: `ConcatMaps(value, {"b": "3"})`},
+		{name: "ConcatMaps() not a map", value: "foo", want: map[string]any{"a": 1}, expr: `ConcatMaps(value, nil, {"a": 1})`},
+		{name: "ConcatMaps() no argument", value: nil, want: map[string]any{}, expr: `ConcatMaps()`},
+		{name: "MapKeys(ConcatMaps())", value: unmarshaled, want: []string{"accept", "host", "user"}, expr: `MapKeys(ConcatMaps(value.headers, {"user": value.user}))`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vm, err := expr.Compile(tc.expr, GetExprOptions(map[string]any{"value": tc.value})...)
+			require.NoError(t, err)
+			output, err := expr.Run(vm, map[string]any{"value": tc.value})
+			require.NoError(t, err)
+			require.Equal(t, tc.want, output)
+		})
+	}
+}
+
 func TestB64Decode(t *testing.T) {
 	err := Init(nil)
 	require.NoError(t, err)
@@ -2,9 +2,16 @@ package exprhelpers
 
 import (
 	"net"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+	log "github.com/sirupsen/logrus"
 )
 
 func GeoIPEnrich(params ...any) (any, error) {
+	geoIPMu.RLock()
+	defer geoIPMu.RUnlock()
+
 	if geoIPCityReader == nil {
 		return nil, nil
 	}
@@ -22,6 +29,9 @@ func GeoIPEnrich(params ...any) (any, error) {
 }
 
 func GeoIPASNEnrich(params ...any) (any, error) {
+	geoIPMu.RLock()
+	defer geoIPMu.RUnlock()
+
 	if geoIPASNReader == nil {
 		return nil, nil
 	}
@@ -38,6 +48,9 @@ func GeoIPASNEnrich(params ...any) (any, error) {
 }
 
 func GeoIPRangeEnrich(params ...any) (any, error) {
+	geoIPMu.RLock()
+	defer geoIPMu.RUnlock()
+
 	if geoIPRangeReader == nil {
 		return nil, nil
 	}
@@ -58,3 +71,65 @@ func GeoIPRangeEnrich(params ...any) (any, error) {
 
 	return rangeIP, nil
 }
+
+// GeoIPRangeForIP returns the network prefix registered for an IP in the ASN database,
+// i.e. "1.0.0.0/24", to produce range decisions. It returns an empty string if the database
+// is not loaded or if the IP is not in it.
+// func GeoIPRangeForIP(ip string) string
+func GeoIPRangeForIP(params ...any) (any, error) {
+	geoIPMu.RLock()
+	defer geoIPMu.RUnlock()
+
+	if geoIPRangeReader == nil {
+		return "", nil
+	}
+
+	ip := params[0].(string)
+
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		log.Errorf("can't parse IP address '%s'", ip)
+		return "", nil
+	}
+
+	var dummy any
+
+	network, ok, err := geoIPRangeReader.LookupNetwork(parsedIP, &dummy)
+	if err != nil {
+		log.Errorf("looking up the network of '%s': %s", ip, err)
+		return "", nil
+	}
+
+	if !ok {
+		return "", nil
+	}
+
+	return network.String(), nil
+}
+
+// GeoIPDBAge returns the age of the oldest GeoIP database loaded, from its build date, to detect
+// stale databases: GeoIPDBAge() > duration("168h"). It returns -1ns if no database is loaded.
+// func GeoIPDBAge() time.Duration
+func GeoIPDBAge(_ ...any) (any, error) {
+	geoIPMu.RLock()
+	defer geoIPMu.RUnlock()
+
+	var oldest uint
+
+	for _, reader := range []*geoip2.Reader{geoIPCityReader, geoIPASNReader} {
+		if reader == nil {
+			continue
+		}
+
+		epoch := reader.Metadata().BuildEpoch
+		if oldest == 0 || epoch < oldest {
+			oldest = epoch
+		}
+	}
+
+	if oldest == 0 {
+		return time.Duration(-1), nil
+	}
+
+	return time.Since(time.Unix(int64(oldest), 0)), nil
+}
@@ -0,0 +1,104 @@
+package exprhelpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeoIPRangeForIP(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	// not loaded
+	ret, err := GeoIPRangeForIP("1.0.0.1")
+	require.NoError(t, err)
+	assert.Empty(t, ret)
+
+	err = GeoIPInit("../parser/testdata/")
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		GeoIPClose()
+
+		geoIPCityReader = nil
+		geoIPASNReader = nil
+		geoIPRangeReader = nil
+	})
+
+	tests := []struct {
+		name string
+		ip   string
+		want string
+	}{
+		{
+			name: "registered IP",
+			ip:   "1.0.0.1",
+			want: "1.0.0.0/24",
+		},
+		{
+			name: "private IP",
+			ip:   "192.168.0.1",
+			want: "",
+		},
+		{
+			name: "invalid IP",
+			ip:   "not an IP",
+			want: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			env := map[string]any{"ip": tc.ip}
+
+			vm, err := expr.Compile("GeoIPRangeForIP(ip)", GetExprOptions(env)...)
+			require.NoError(t, err)
+
+			ret, err := expr.Run(vm, env)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, ret)
+		})
+	}
+}
+
+func TestGeoIPDBAge(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	vm, err := expr.Compile(`GeoIPDBAge()`, GetExprOptions(map[string]any{})...)
+	require.NoError(t, err)
+
+	// not loaded
+	ret, err := expr.Run(vm, map[string]any{})
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(-1), ret)
+
+	err = GeoIPInit("../parser/testdata/")
+	require.NoError(t, err)
+
+	t.Cleanup(GeoIPClose)
+
+	// the oldest database: the city one, built on 2020-11-23
+	ret, err = expr.Run(vm, map[string]any{})
+	require.NoError(t, err)
+	assert.InDelta(t, time.Since(time.Date(2020, 11, 23, 22, 15, 58, 0, time.UTC)).Seconds(), ret.(time.Duration).Seconds(), 60)
+
+	vm, err = expr.Compile(`GeoIPDBAge() > duration("168h")`, GetExprOptions(map[string]any{})...)
+	require.NoError(t, err)
+
+	ret, err = expr.Run(vm, map[string]any{})
+	require.NoError(t, err)
+	assert.True(t, ret.(bool))
+
+	// reload: the readers are replaced
+	err = GeoIPInit("../parser/testdata/")
+	require.NoError(t, err)
+
+	ret, err = GeoIPRangeForIP("1.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0.0/24", ret)
+}
@@ -0,0 +1,71 @@
+package exprhelpers
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// The hashing helpers return the digest as a lower-case hex string, to anonymize values
+// (cookies, tokens, payloads...) before they are shared in the context of an alert.
+
+// Sha256 returns the SHA-256 digest of a string, as 64 hex characters.
+// func Sha256(s string) string
+func Sha256(params ...any) (any, error) {
+	sum := sha256.Sum256([]byte(params[0].(string)))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Sha1 returns the SHA-1 digest of a string, as 40 hex characters.
+// func Sha1(s string) string
+func Sha1(params ...any) (any, error) {
+	sum := sha1.Sum([]byte(params[0].(string)))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Md5 returns the MD5 digest of a string, as 32 hex characters.
+// func Md5(s string) string
+func Md5(params ...any) (any, error) {
+	sum := md5.Sum([]byte(params[0].(string)))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Xxhash returns the 64-bit xxHash (XXH64, seed 0) of a string, as 16 hex characters
+// padded with zeros. It's not a cryptographic hash: use it for bucketing or deduplication.
+// func Xxhash(s string) string
+func Xxhash(params ...any) (any, error) {
+	return fmt.Sprintf("%016x", xxhash.Sum64String(params[0].(string))), nil
+}
+
+var hmacAlgorithms = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// Hmac returns the HMAC of data with the given key, as a hex string. The algorithm is
+// one of sha1, sha256 or sha512.
+// func Hmac(algo string, key string, data string) string
+func Hmac(params ...any) (any, error) {
+	algo := params[0].(string)
+	key := params[1].(string)
+	data := params[2].(string)
+
+	newHash, ok := hmacAlgorithms[strings.ToLower(algo)]
+	if !ok {
+		return "", fmt.Errorf("unsupported hmac algorithm '%s': must be sha1, sha256 or sha512", algo)
+	}
+
+	mac := hmac.New(newHash, []byte(key))
+	mac.Write([]byte(data))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,76 @@
+package exprhelpers
+
+import (
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestHashHelpers(t *testing.T) {
+	tests := []struct {
+		code     string
+		value    string
+		expected string
+	}{
+		{code: "Sha256(value)", value: "abc", expected: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{code: "Sha1(value)", value: "abc", expected: "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{code: "Md5(value)", value: "abc", expected: "900150983cd24fb0d6963f7d28e17f72"},
+		{code: "Md5(value)", value: "", expected: "d41d8cd98f00b204e9800998ecf8427e"},
+		{code: "Xxhash(value)", value: "abc", expected: "44bc2cf5ad770999"},
+		{code: "Xxhash(value)", value: "", expected: "ef46db3751d8e999"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.code+"/"+tc.value, func(t *testing.T) {
+			vm, err := expr.Compile(tc.code, GetExprOptions(map[string]any{"value": ""})...)
+			require.NoError(t, err)
+
+			ret, err := expr.Run(vm, map[string]any{"value": tc.value})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ret)
+		})
+	}
+}
+
+func TestHmac(t *testing.T) {
+	tests := []struct {
+		name        string
+		algo        string
+		expected    string
+		expectedErr string
+	}{
+		{name: "sha1", algo: "sha1", expected: "de7c9b85b8b78aa6bc8a7a36f70a90701c9db4d9"},
+		{name: "sha256", algo: "sha256", expected: "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{name: "upper case", algo: "SHA256", expected: "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"},
+		{
+			name:     "sha512",
+			algo:     "sha512",
+			expected: "b42af09057bac1e2d41708e48a902e09b5ff7f12ab428a4fe86653c73dd248fb82f948a549f7b791a5b41915ee4d1ec3935357e4e2317250d0372afa2ebeeb3a",
+		},
+		{name: "unsupported", algo: "md5", expectedErr: "unsupported hmac algorithm 'md5': must be sha1, sha256 or sha512"},
+	}
+
+	env := map[string]any{"algo": "", "key": "key", "data": "The quick brown fox jumps over the lazy dog"}
+
+	vm, err := expr.Compile(`Hmac(algo, key, data)`, GetExprOptions(env)...)
+	require.NoError(t, err)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			env["algo"] = tc.algo
+
+			ret, err := expr.Run(vm, env)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			assert.Equal(t, tc.expected, ret)
+		})
+	}
+}
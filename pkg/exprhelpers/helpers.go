@@ -2,6 +2,7 @@ package exprhelpers
 
 import (
 	"bufio"
+	"cmp"
 	"context"
 	"encoding/base64"
 	"errors"
@@ -19,6 +20,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bluele/gcache"
@@ -65,6 +67,8 @@ var keyValuePattern = regexp.MustCompile(`(?P<key>[^=\s]+)=(?:"(?P<quoted_value>
 var keyStart = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_.-]*)=`) // More restrictive key pattern for loose parsing
 
 var (
+	// geoIPMu protects the readers, replaced when the databases are updated
+	geoIPMu          sync.RWMutex
 	geoIPCityReader  *geoip2.Reader
 	geoIPASNReader   *geoip2.Reader
 	geoIPRangeReader *maxminddb.Reader
@@ -79,41 +83,76 @@ func GetExprOptions(ctx map[string]any) []expr.Option {
 	return opts
 }
 
+// GeoIPInit opens the GeoLite2 databases of the data directory. It's also called to reload them after
+// an update: the readers in use are replaced, and closed, only by the ones that could be opened.
 func GeoIPInit(datadir string) error {
-	var err error
+	var firstErr error
 
-	geoIPCityReader, err = geoip2.Open(filepath.Join(datadir, "GeoLite2-City.mmdb"))
+	cityReader, err := geoip2.Open(filepath.Join(datadir, "GeoLite2-City.mmdb"))
 	if err != nil {
 		log.Errorf("unable to open GeoLite2-City.mmdb : %s", err)
-		return err
+		firstErr = cmp.Or(firstErr, err)
 	}
 
-	geoIPASNReader, err = geoip2.Open(filepath.Join(datadir, "GeoLite2-ASN.mmdb"))
+	asnReader, err := geoip2.Open(filepath.Join(datadir, "GeoLite2-ASN.mmdb"))
 	if err != nil {
 		log.Errorf("unable to open GeoLite2-ASN.mmdb : %s", err)
-		return err
+		firstErr = cmp.Or(firstErr, err)
 	}
 
-	geoIPRangeReader, err = maxminddb.Open(filepath.Join(datadir, "GeoLite2-ASN.mmdb"))
+	rangeReader, err := maxminddb.Open(filepath.Join(datadir, "GeoLite2-ASN.mmdb"))
 	if err != nil {
 		log.Errorf("unable to open GeoLite2-ASN.mmdb : %s", err)
-		return err
+		firstErr = cmp.Or(firstErr, err)
 	}
 
-	return nil
+	geoIPMu.Lock()
+	defer geoIPMu.Unlock()
+
+	if cityReader != nil {
+		if geoIPCityReader != nil {
+			geoIPCityReader.Close()
+		}
+
+		geoIPCityReader = cityReader
+	}
+
+	if asnReader != nil {
+		if geoIPASNReader != nil {
+			geoIPASNReader.Close()
+		}
+
+		geoIPASNReader = asnReader
+	}
+
+	if rangeReader != nil {
+		if geoIPRangeReader != nil {
+			geoIPRangeReader.Close()
+		}
+
+		geoIPRangeReader = rangeReader
+	}
+
+	return firstErr
 }
 
 func GeoIPClose() {
+	geoIPMu.Lock()
+	defer geoIPMu.Unlock()
+
 	if geoIPCityReader != nil {
 		geoIPCityReader.Close()
+		geoIPCityReader = nil
 	}
 
 	if geoIPASNReader != nil {
 		geoIPASNReader.Close()
+		geoIPASNReader = nil
 	}
 
 	if geoIPRangeReader != nil {
 		geoIPRangeReader.Close()
+		geoIPRangeReader = nil
 	}
 }
 
@@ -122,9 +161,14 @@ func Init(databaseClient *database.Client) error {
 	dataFileRegex = make(map[string][]*regexp.Regexp)
 	dataFileRe2 = make(map[string][]*re2.Regexp)
 	dataFileMap = make(map[string]*fileMapEntry)
+	dataFileCPE = make(map[string]map[string][]cpeEntry)
+	dataFileDomain = make(map[string]map[string]struct{})
+	dataFilePwned = make(map[string]map[string]map[string]int)
+	dataFileJWKS = make(map[string][]jwk)
 	dbClient = databaseClient
 
 	XMLCacheInit()
+	ThrottleCacheInit()
 
 	return nil
 }
@@ -137,6 +181,10 @@ func ResetDataFiles() {
 	dataFileRe2 = make(map[string][]*re2.Regexp)
 	dataFileRegexCache = make(map[string]gcache.Cache)
 	dataFileMap = make(map[string]*fileMapEntry)
+	dataFileCPE = make(map[string]map[string][]cpeEntry)
+	dataFileDomain = make(map[string]map[string]struct{})
+	dataFilePwned = make(map[string]map[string]map[string]int)
+	dataFileJWKS = make(map[string][]jwk)
 }
 
 func RegexpCacheInit(filename string, cacheCfg enrichment.DataProvider) error {
@@ -216,6 +264,11 @@ func FileInit(directory string, filename string, fileType string) error {
 	}
 	defer file.Close()
 
+	// a key set is a single JSON document
+	if fileType == "jwks" {
+		return jwksFileInit(filename, file)
+	}
+
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		if strings.HasPrefix(scanner.Text(), "#") { // allow comments
@@ -240,6 +293,16 @@ func FileInit(directory string, filename string, fileType string) error {
 			if err := fileMapInit(filename, scanner.Text()); err != nil {
 				return err
 			}
+		case "cpe":
+			if err := cpeFileInit(filename, scanner.Text()); err != nil {
+				return err
+			}
+		case "domain":
+			domainFileInit(filename, scanner.Text())
+		case "pwned":
+			if err := pwnedFileInit(filename, scanner.Text()); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -259,20 +322,31 @@ func FileInit(directory string, filename string, fileType string) error {
 
 // Expr helpers
 
+// func Distinct(list []any | map[any]any) []any
+// Distinct returns the unique elements of a list, or the unique values of a map (in key order).
 func Distinct(params ...any) (any, error) {
-	if rt := reflect.TypeOf(params[0]).Kind(); rt != reflect.Slice && rt != reflect.Array {
-		return nil, nil
-	}
+	v := reflect.ValueOf(params[0])
 
-	array := params[0].([]any)
-	if array == nil {
-		return []any{}, nil
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+	case reflect.Map:
+		v = reflect.ValueOf(mapValues(v))
+	default:
+		return nil, nil
 	}
 
 	exists := make(map[any]bool)
 	ret := make([]any, 0)
 
-	for _, val := range array {
+	for i := range v.Len() {
+		val := v.Index(i).Interface()
+
+		// maps and slices can't be compared, keep them all
+		if val != nil && !reflect.TypeOf(val).Comparable() {
+			ret = append(ret, val)
+			continue
+		}
+
 		if _, ok := exists[val]; !ok {
 			exists[val] = true
 			ret = append(ret, val)
@@ -286,6 +360,7 @@ func FlattenDistinct(params ...any) (any, error) {
 	return Distinct(flatten(nil, reflect.ValueOf(params)))
 }
 
+// Flatten returns the elements of nested lists as a single list. Maps are replaced by their values, in key order.
 func Flatten(params ...any) (any, error) {
 	return flatten(nil, reflect.ValueOf(params)), nil
 }
@@ -295,17 +370,144 @@ func flatten(args []any, v reflect.Value) []any {
 		v = v.Elem()
 	}
 
-	if v.Kind() == reflect.Array || v.Kind() == reflect.Slice {
+	switch v.Kind() {
+	case reflect.Array, reflect.Slice:
 		for i := range v.Len() {
 			args = flatten(args, v.Index(i))
 		}
-	} else {
+	case reflect.Map:
+		for _, k := range sortedMapKeys(v) {
+			args = flatten(args, v.MapIndex(k))
+		}
+	case reflect.Invalid:
+		args = append(args, nil)
+	default:
 		args = append(args, v.Interface())
 	}
 
 	return args
 }
 
+// sortedMapKeys returns the keys of a map, sorted by their string representation
+// to have a deterministic output.
+func sortedMapKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+
+	slices.SortFunc(keys, func(a, b reflect.Value) int {
+		return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+	})
+
+	return keys
+}
+
+func mapValues(v reflect.Value) []any {
+	ret := make([]any, 0, v.Len())
+
+	for _, k := range sortedMapKeys(v) {
+		ret = append(ret, v.MapIndex(k).Interface())
+	}
+
+	return ret
+}
+
+// func MapKeys(m map[string]any) []string
+// MapKeys returns the sorted keys of a map, or an empty list if the argument is not a map.
+func MapKeys(params ...any) (any, error) {
+	v := reflect.ValueOf(params[0])
+	if v.Kind() != reflect.Map {
+		return []string{}, nil
+	}
+
+	ret := make([]string, 0, v.Len())
+
+	for _, k := range sortedMapKeys(v) {
+		ret = append(ret, fmt.Sprint(k.Interface()))
+	}
+
+	return ret, nil
+}
+
+// func MapValues(m map[string]any) []any
+// MapValues returns the values of a map in key order, or an empty list if the argument is not a map.
+func MapValues(params ...any) (any, error) {
+	v := reflect.ValueOf(params[0])
+	if v.Kind() != reflect.Map {
+		return []any{}, nil
+	}
+
+	return mapValues(v), nil
+}
+
+// func ConcatMaps(maps ...map[string]any) map[string]any
+// ConcatMaps returns a new map with the entries of all the maps. When a key is in several maps,
+// the value of the last one is kept. The arguments that are not maps are ignored.
+func ConcatMaps(params ...any) (any, error) {
+	ret := map[string]any{}
+
+	for _, param := range params {
+		v := reflect.ValueOf(param)
+		if v.Kind() != reflect.Map {
+			continue
+		}
+
+		iter := v.MapRange()
+		for iter.Next() {
+			ret[fmt.Sprint(iter.Key().Interface())] = iter.Value().Interface()
+		}
+	}
+
+	return ret, nil
+}
+
+// func Sort(list []any) []any
+// Sort returns a sorted copy of a list. Numbers are sorted numerically and before the other values,
+// which are sorted by their string representation. A map is replaced by its values.
+func Sort(params ...any) (any, error) {
+	v := reflect.ValueOf(params[0])
+
+	var ret []any
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		ret = make([]any, 0, v.Len())
+		for i := range v.Len() {
+			ret = append(ret, v.Index(i).Interface())
+		}
+	case reflect.Map:
+		ret = mapValues(v)
+	default:
+		return []any{}, nil
+	}
+
+	slices.SortStableFunc(ret, func(a, b any) int {
+		fa, aIsNum := toNumber(a)
+		fb, bIsNum := toNumber(b)
+
+		switch {
+		case aIsNum && bIsNum:
+			return cmp.Compare(fa, fb)
+		case aIsNum:
+			return -1
+		case bIsNum:
+			return 1
+		default:
+			return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+		}
+	})
+
+	return ret, nil
+}
+
+// toNumber is like toFloat, but doesn't parse strings or booleans.
+func toNumber(value any) (float64, bool) {
+	switch value.(type) {
+	case string, bool:
+		return 0, false
+	}
+
+	return toFloat(value)
+}
+
 func existsInFileMaps(filename string, ftype string) (bool, error) {
 	var err error
 
@@ -322,6 +524,14 @@ func existsInFileMaps(filename string, ftype string) (bool, error) {
 		_, ok = dataFile[filename]
 	case "map":
 		_, ok = dataFileMap[filename]
+	case "cpe":
+		_, ok = dataFileCPE[filename]
+	case "domain":
+		_, ok = dataFileDomain[filename]
+	case "pwned":
+		_, ok = dataFilePwned[filename]
+	case "jwks":
+		_, ok = dataFileJWKS[filename]
 	default:
 		err = fmt.Errorf("unknown data type '%s' for : '%s'", ftype, filename)
 	}
@@ -617,6 +827,121 @@ func IpToRange(params ...any) (any, error) {
 	return prefix.String(), nil
 }
 
+// parsePrefix parses a CIDR or a single IP address, as a range of one address.
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	addr = addr.Unmap()
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// func IpRangeSize(cidr string) int {
+func IpRangeSize(params ...any) (any, error) {
+	cidr := params[0].(string)
+
+	prefix, err := parsePrefix(cidr)
+	if err != nil {
+		log.Errorf("can't parse range '%s': %v", cidr, err)
+		return 0, nil
+	}
+
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits >= strconv.IntSize-1 {
+		// an IPv6 range can be larger than what an int holds
+		return math.MaxInt, nil
+	}
+
+	return 1 << hostBits, nil
+}
+
+// func AggregateRanges(ranges []string) []string {
+func AggregateRanges(params ...any) (any, error) {
+	v := reflect.ValueOf(params[0])
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return []string{}, nil
+	}
+
+	prefixes := make([]netip.Prefix, 0, v.Len())
+
+	for i := range v.Len() {
+		s, ok := v.Index(i).Interface().(string)
+		if !ok {
+			log.Errorf("AggregateRanges: %v is not a string", v.Index(i).Interface())
+			continue
+		}
+
+		prefix, err := parsePrefix(s)
+		if err != nil {
+			log.Errorf("can't parse range '%s': %v", s, err)
+			continue
+		}
+
+		prefixes = append(prefixes, prefix)
+	}
+
+	ret := []string{}
+
+	for _, prefix := range aggregatePrefixes(prefixes) {
+		ret = append(ret, prefix.String())
+	}
+
+	return ret, nil
+}
+
+// aggregatePrefixes returns the smallest list of prefixes covering exactly the same addresses.
+func aggregatePrefixes(prefixes []netip.Prefix) []netip.Prefix {
+	// by address, then the larger range first
+	slices.SortFunc(prefixes, func(a, b netip.Prefix) int {
+		return cmp.Or(a.Addr().Compare(b.Addr()), cmp.Compare(a.Bits(), b.Bits()))
+	})
+
+	ret := make([]netip.Prefix, 0, len(prefixes))
+
+	for _, prefix := range prefixes {
+		// skip what is already covered
+		if len(ret) > 0 {
+			last := ret[len(ret)-1]
+			if last.Bits() <= prefix.Bits() && last.Contains(prefix.Addr()) {
+				continue
+			}
+		}
+
+		ret = append(ret, prefix)
+
+		// merge the two halves of a range, as long as it is possible
+		for len(ret) >= 2 {
+			a, b := ret[len(ret)-2], ret[len(ret)-1]
+			if a.Bits() != b.Bits() || a.Bits() == 0 || a.Addr().Is4() != b.Addr().Is4() {
+				break
+			}
+
+			parentA, _ := a.Addr().Prefix(a.Bits() - 1)
+			parentB, _ := b.Addr().Prefix(b.Bits() - 1)
+
+			if parentA != parentB {
+				break
+			}
+
+			ret = append(ret[:len(ret)-2], parentA)
+		}
+	}
+
+	return ret
+}
+
 // func TimeNow() string {
 func TimeNow(params ...any) (any, error) {
 	return time.Now().UTC().Format(time.RFC3339), nil
@@ -832,6 +1157,66 @@ func GetActiveDecisionsTimeLeft(params ...any) (any, error) {
 	return timeLeft, nil
 }
 
+// func GetAlertsCountForIP(ip string, since string) int {
+func GetAlertsCountForIP(params ...any) (any, error) {
+	ip := params[0].(string)
+	since := params[1].(string)
+
+	if dbClient == nil {
+		log.Error("No database config to call GetAlertsCountForIP()")
+		return 0, nil
+	}
+
+	sinceDuration, err := cstime.ParseDurationWithDays(since)
+	if err != nil {
+		log.Errorf("Failed to parse since parameter '%s' : %s", since, err)
+		return 0, nil
+	}
+
+	ctx := context.TODO()
+	sinceTime := time.Now().UTC().Add(-sinceDuration)
+
+	count, err := dbClient.CountAlertsByIP(ctx, ip, sinceTime)
+	if err != nil {
+		log.Errorf("Failed to get alerts count for ip '%s': %s", ip, err)
+		return 0, nil
+	}
+
+	return count, nil
+}
+
+// func GetAlertsScenariosForIP(ip string, since string) []string {
+func GetAlertsScenariosForIP(params ...any) (any, error) {
+	ip := params[0].(string)
+	since := params[1].(string)
+
+	if dbClient == nil {
+		log.Error("No database config to call GetAlertsScenariosForIP()")
+		return []string{}, nil
+	}
+
+	sinceDuration, err := cstime.ParseDurationWithDays(since)
+	if err != nil {
+		log.Errorf("Failed to parse since parameter '%s' : %s", since, err)
+		return []string{}, nil
+	}
+
+	ctx := context.TODO()
+	sinceTime := time.Now().UTC().Add(-sinceDuration)
+
+	scenarios, err := dbClient.AlertScenariosByIP(ctx, ip, sinceTime)
+	if err != nil {
+		log.Errorf("Failed to get alerts scenarios for ip '%s': %s", ip, err)
+		return []string{}, nil
+	}
+
+	if scenarios == nil {
+		return []string{}, nil
+	}
+
+	return scenarios, nil
+}
+
 // func LookupHost(value string) []string {
 func LookupHost(params ...any) (any, error) {
 	value := params[0].(string)
@@ -882,6 +1267,124 @@ func ToString(params ...any) (any, error) {
 	return s, nil
 }
 
+// toFloat converts strings, numbers and booleans to float64. ok is false for nil, unsupported types and unparsable strings.
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, false
+		}
+
+		return f, true
+	case bool:
+		if v {
+			return 1, true
+		}
+
+		return 0, true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// func ToInt(value any, [defaultValue int]) int {
+func ToInt(params ...any) (any, error) {
+	defaultValue := 0
+	if len(params) > 1 {
+		defaultValue = params[1].(int)
+	}
+
+	switch v := params[0].(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case string:
+		// avoid the loss of precision of the float conversion for large integers
+		if i, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			return i, nil
+		}
+	}
+
+	f, ok := toFloat(params[0])
+	// the conversion of an out of range float is implementation-defined.
+	// math.MaxInt rounds up to 2^63 as a float, which doesn't fit in an int
+	if !ok || math.IsNaN(f) || f < math.MinInt || f >= math.MaxInt {
+		return defaultValue, nil
+	}
+
+	return int(f), nil
+}
+
+// func ToFloat(value any, [defaultValue float64]) float64 {
+func ToFloat(params ...any) (any, error) {
+	defaultValue := 0.0
+	if len(params) > 1 {
+		defaultValue = params[1].(float64)
+	}
+
+	f, ok := toFloat(params[0])
+	if !ok {
+		return defaultValue, nil
+	}
+
+	return f, nil
+}
+
+// func ToBool(value any, [defaultValue bool]) bool {
+func ToBool(params ...any) (any, error) {
+	defaultValue := false
+	if len(params) > 1 {
+		defaultValue = params[1].(bool)
+	}
+
+	switch v := params[0].(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "1", "t", "true", "y", "yes", "on":
+			return true, nil
+		case "0", "f", "false", "n", "no", "off":
+			return false, nil
+		default:
+			return defaultValue, nil
+		}
+	}
+
+	f, ok := toFloat(params[0])
+	if !ok {
+		return defaultValue, nil
+	}
+
+	return f != 0, nil
+}
+
 // func GetFromStash(cacheName string, key string) (string, error) {
 func GetFromStash(params ...any) (any, error) {
 	cacheName := params[0].(string)
@@ -953,6 +1456,92 @@ func FloatApproxEqual(params ...any) (any, error) {
 	return false, nil
 }
 
+// numberParam converts a parameter of a math helper to float64. The signature of the helpers
+// accepts untyped values (ie. from Parsed or Meta), and integers that are not literals.
+func numberParam(helper string, value any) (float64, error) {
+	switch value.(type) {
+	case string, bool:
+		return 0, fmt.Errorf("%s: expected a number, got %T", helper, value)
+	}
+
+	f, ok := toFloat(value)
+	if !ok {
+		return 0, fmt.Errorf("%s: expected a number, got %T", helper, value)
+	}
+
+	return f, nil
+}
+
+// func Pow(x float64, y float64) float64 {
+func Pow(params ...any) (any, error) {
+	x, err := numberParam("Pow", params[0])
+	if err != nil {
+		return 0.0, err
+	}
+
+	y, err := numberParam("Pow", params[1])
+	if err != nil {
+		return 0.0, err
+	}
+
+	return math.Pow(x, y), nil
+}
+
+// func Log2(x float64) float64 {
+// Like math.Log2, returns -Inf for 0 and NaN for negative values.
+func Log2(params ...any) (any, error) {
+	x, err := numberParam("Log2", params[0])
+	if err != nil {
+		return 0.0, err
+	}
+
+	return math.Log2(x), nil
+}
+
+// func Clamp(value float64, low float64, high float64) float64 {
+func Clamp(params ...any) (any, error) {
+	var bounds [3]float64
+
+	for i := range bounds {
+		f, err := numberParam("Clamp", params[i])
+		if err != nil {
+			return 0.0, err
+		}
+
+		bounds[i] = f
+	}
+
+	value, low, high := bounds[0], bounds[1], bounds[2]
+
+	if low > high {
+		return 0.0, fmt.Errorf("Clamp: low bound %v is greater than high bound %v", low, high)
+	}
+
+	return min(max(value, low), high), nil
+}
+
+// func Round(value float64, [digits int]) float64 {
+// Rounds half away from zero, to the given number of decimal digits (0 by default).
+func Round(params ...any) (any, error) {
+	value, err := numberParam("Round", params[0])
+	if err != nil {
+		return 0.0, err
+	}
+
+	digits := 0
+	if len(params) > 1 {
+		digits = params[1].(int)
+	}
+
+	if digits == 0 {
+		return math.Round(value), nil
+	}
+
+	scale := math.Pow(10, float64(digits))
+
+	return math.Round(value*scale) / scale, nil
+}
+
 func B64Decode(params ...any) (any, error) {
 	encoded := params[0].(string)
 
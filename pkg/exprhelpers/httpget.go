@@ -0,0 +1,260 @@
+package exprhelpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluele/gcache"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/crowdsecurity/crowdsec/pkg/apiclient/useragent"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/logging"
+)
+
+// the errors of a host are usually the same for every event
+const httpGetErrorLogInterval = time.Minute
+
+// refusedError is returned when the helper refuses to send a request: the url is not allowed, or
+// the limits are reached. It's expected under load and not worth a warning.
+type refusedError struct {
+	err error
+}
+
+func (e *refusedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *refusedError) Unwrap() error {
+	return e.err
+}
+
+// httpGetter holds the state of the HttpGet helper. It's nil when the helper is not configured.
+type httpGetter struct {
+	client          *http.Client
+	allowedHosts    []string
+	timeout         time.Duration
+	cache           gcache.Cache
+	cacheTimeout    time.Duration
+	slots           chan struct{}
+	limiter         *rate.Limiter
+	maxResponseSize int64
+	logger          *log.Entry
+	errLogs         sync.Map // host -> *rate.Sometimes
+}
+
+var (
+	httpGetterLock     sync.RWMutex
+	httpGet            *httpGetter
+	httpGetMissingOnce sync.Once
+)
+
+func InitHttpGet(cfg *csconfig.HTTPHelperCfg) error {
+	if cfg == nil {
+		ShutdownHttpGet()
+		return nil
+	}
+
+	clog := logging.SubLogger(log.StandardLogger(), "http_helper", cfg.LogLevel)
+
+	g := &httpGetter{
+		client: &http.Client{
+			Timeout: *cfg.Timeout,
+			// a redirect could lead to a host that is not allowed
+			CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		allowedHosts:    cfg.AllowedHosts,
+		timeout:         *cfg.Timeout,
+		cacheTimeout:    *cfg.CacheTimeout,
+		slots:           make(chan struct{}, *cfg.MaxConcurrent),
+		maxResponseSize: *cfg.MaxResponseSize,
+		logger:          clog.WithField("type", "http_helper"),
+	}
+
+	if *cfg.CacheSize > 0 {
+		g.cache = gcache.New(*cfg.CacheSize).LRU().Build()
+	}
+
+	if cfg.RequestsPerSecond != nil {
+		g.limiter = rate.NewLimiter(rate.Limit(*cfg.RequestsPerSecond), *cfg.MaxConcurrent)
+	}
+
+	httpGetterLock.Lock()
+	httpGet = g
+	httpGetterLock.Unlock()
+
+	return nil
+}
+
+func ShutdownHttpGet() {
+	httpGetterLock.Lock()
+	httpGet = nil
+	httpGetterLock.Unlock()
+}
+
+// hostAllowed returns true if the host of the url matches one of the allowed hosts.
+func (g *httpGetter) hostAllowed(u *url.URL) bool {
+	hostname := strings.ToLower(u.Hostname())
+	hostport := strings.ToLower(u.Host)
+
+	for _, allowed := range g.allowedHosts {
+		host := hostname
+
+		if _, _, err := net.SplitHostPort(allowed); err == nil {
+			// the port must match too
+			host = hostport
+		} else {
+			allowed = strings.Trim(allowed, "[]")
+		}
+
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+
+			continue
+		}
+
+		if host == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *httpGetter) get(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", &refusedError{fmt.Errorf("unsupported scheme %q", u.Scheme)}
+	}
+
+	if !g.hostAllowed(u) {
+		return "", &refusedError{fmt.Errorf("host %s is not allowed", u.Host)}
+	}
+
+	if g.cache != nil {
+		if val, err := g.cache.Get(rawURL); err == nil {
+			g.logger.Tracef("cache hit for %s", rawURL)
+			return val.(string), nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	if g.limiter != nil {
+		if err := g.limiter.Wait(ctx); err != nil {
+			return "", &refusedError{fmt.Errorf("rate limited: %w", err)}
+		}
+	}
+
+	select {
+	case g.slots <- struct{}{}:
+		defer func() { <-g.slots }()
+	case <-ctx.Done():
+		return "", &refusedError{fmt.Errorf("too many concurrent requests: %w", ctx.Err())}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, http.NoBody)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("User-Agent", useragent.Default())
+
+	g.logger.Debugf("GET %s", rawURL)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, g.maxResponseSize+1))
+	if err != nil {
+		return "", err
+	}
+
+	if int64(len(body)) > g.maxResponseSize {
+		return "", fmt.Errorf("response is larger than %d bytes", g.maxResponseSize)
+	}
+
+	ret := string(body)
+
+	if g.cache != nil {
+		if err := g.cache.SetWithExpire(rawURL, ret, g.cacheTimeout); err != nil {
+			g.logger.Warningf("while caching response for %s: %s", rawURL, err)
+		}
+	}
+
+	return ret, nil
+}
+
+// HttpGet queries an url and returns the body of the response. Errors are logged and
+// result in an empty string, to not break the parsing of the event.
+//
+// func HttpGet(url string) string {
+func HttpGet(params ...any) (any, error) {
+	rawURL := params[0].(string)
+
+	httpGetterLock.RLock()
+	g := httpGet
+	httpGetterLock.RUnlock()
+
+	if g == nil {
+		httpGetMissingOnce.Do(func() {
+			log.Warning("HttpGet: the helper is not configured (crowdsec_service.http_helper), returning empty responses")
+		})
+
+		return "", nil
+	}
+
+	ret, err := g.get(rawURL)
+	if err != nil {
+		g.logError(rawURL, err)
+		return "", nil
+	}
+
+	return ret, nil
+}
+
+// logError logs the error of a request. The refusals of the helper are logged at debug level, the
+// errors of the hosts at most once per interval and host, as they happen while parsing each event.
+func (g *httpGetter) logError(rawURL string, err error) {
+	var refused *refusedError
+	if errors.As(err, &refused) {
+		g.logger.Debugf("HttpGet %s: %s", rawURL, err)
+		return
+	}
+
+	host := ""
+	if u, err := url.Parse(rawURL); err == nil {
+		host = strings.ToLower(u.Host)
+	}
+
+	errLog, _ := g.errLogs.LoadOrStore(host, &rate.Sometimes{Interval: httpGetErrorLogInterval})
+
+	errLog.(*rate.Sometimes).Do(func() {
+		g.logger.Warningf("HttpGet %s: %s", rawURL, err)
+	})
+}
@@ -0,0 +1,186 @@
+package exprhelpers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/expr-lang/expr"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+)
+
+func TestHttpGet(t *testing.T) {
+	var hits atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte(`{"owner": "team-a"}`))
+		case "/big":
+			w.Write(make([]byte, 100))
+		case "/slow":
+			time.Sleep(500 * time.Millisecond)
+			w.Write([]byte("late"))
+		case "/redirect":
+			http.Redirect(w, r, "https://example.com/", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tsURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	cfg := &csconfig.HTTPHelperCfg{
+		AllowedHosts:    []string{tsURL.Host},
+		Timeout:         new(200 * time.Millisecond),
+		MaxResponseSize: new(int64(50)),
+	}
+	require.NoError(t, cfg.Load())
+
+	require.NoError(t, InitHttpGet(cfg))
+	defer ShutdownHttpGet()
+
+	env := map[string]any{"url": ""}
+
+	program, err := expr.Compile(`HttpGet(url)`, GetExprOptions(env)...)
+	require.NoError(t, err)
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{url: ts.URL + "/ok", expected: `{"owner": "team-a"}`},
+		{url: ts.URL + "/notfound", expected: ""},
+		{url: ts.URL + "/big", expected: ""},
+		{url: ts.URL + "/slow", expected: ""},
+		{url: ts.URL + "/redirect", expected: ""},
+		{url: "http://example.com/ok", expected: ""},
+		{url: "file:///etc/passwd", expected: ""},
+		{url: "://", expected: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.url, func(t *testing.T) {
+			env["url"] = tc.url
+
+			out, err := expr.Run(program, env)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+
+	// the response is cached
+	before := hits.Load()
+
+	env["url"] = ts.URL + "/ok"
+
+	out, err := expr.Run(program, env)
+	require.NoError(t, err)
+	assert.Equal(t, `{"owner": "team-a"}`, out)
+	assert.Equal(t, before, hits.Load())
+}
+
+func TestHttpGetNotConfigured(t *testing.T) {
+	ShutdownHttpGet()
+
+	out, err := HttpGet("http://127.0.0.1/")
+	require.NoError(t, err)
+	assert.Empty(t, out)
+}
+
+func TestHttpGetAllowedHosts(t *testing.T) {
+	g := &httpGetter{allowedHosts: []string{"enrich.local", "*.internal", "api.local:8080", "[::1]"}}
+
+	tests := []struct {
+		url      string
+		expected bool
+	}{
+		{"http://enrich.local/foo", true},
+		{"http://ENRICH.local:9000/foo", true},
+		{"http://evil.enrich.local/foo", false},
+		{"https://a.b.internal/", true},
+		{"https://internal/", false},
+		{"http://api.local:8080/", true},
+		{"http://api.local/", false},
+		{"http://api.local:8081/", false},
+		{"http://[::1]:80/", true},
+		{"http://enrich.local.evil.com/", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.url, func(t *testing.T) {
+			u, err := url.Parse(tc.url)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, g.hostAllowed(u))
+		})
+	}
+}
+
+func TestHttpGetLogs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	tsURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	logger, hook := logtest.NewNullLogger()
+	logger.SetLevel(log.DebugLevel)
+
+	g := &httpGetter{
+		client:          ts.Client(),
+		allowedHosts:    []string{tsURL.Host},
+		timeout:         time.Second,
+		slots:           make(chan struct{}, 1),
+		maxResponseSize: 100,
+		logger:          log.NewEntry(logger),
+	}
+
+	httpGetterLock.Lock()
+	httpGet = g
+	httpGetterLock.Unlock()
+
+	defer ShutdownHttpGet()
+
+	levels := func() []log.Level {
+		ret := []log.Level{}
+		for _, entry := range hook.AllEntries() {
+			if entry.Message != "GET "+ts.URL+"/" {
+				ret = append(ret, entry.Level)
+			}
+		}
+
+		hook.Reset()
+
+		return ret
+	}
+
+	// the refusals of the helper are not warnings
+	for range 3 {
+		_, err = HttpGet("http://example.com/")
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []log.Level{log.DebugLevel, log.DebugLevel, log.DebugLevel}, levels())
+
+	// the errors of a host are logged once per interval
+	for range 3 {
+		_, err = HttpGet(ts.URL + "/")
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []log.Level{log.WarnLevel}, levels())
+}
@@ -0,0 +1,320 @@
+package exprhelpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// dataFileJWKS holds the public keys of the key sets (data files of type "jwks", in the
+// JSON Web Key Set format: {"keys": [...]}), keyed by filename.
+var dataFileJWKS map[string][]jwk
+
+// the key sets downloaded with the http helper, parsed again only when they change
+var (
+	jwksURLLock  sync.Mutex
+	jwksURLCache = map[string]jwksURLEntry{}
+)
+
+type jwksURLEntry struct {
+	body string
+	keys []jwk
+}
+
+type jwk struct {
+	kid     string
+	key     any // *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey or []byte
+	methods []string
+}
+
+type rawJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+func b64Int(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+func parseJWK(raw rawJWK) (jwk, error) {
+	ret := jwk{kid: raw.Kid}
+
+	switch raw.Kty {
+	case "RSA":
+		n, err := b64Int(raw.N)
+		if err != nil {
+			return ret, fmt.Errorf("invalid 'n': %w", err)
+		}
+
+		e, err := b64Int(raw.E)
+		if err != nil || !e.IsInt64() {
+			return ret, errors.New("invalid 'e'")
+		}
+
+		ret.key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+		ret.methods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+	case "EC":
+		curves := map[string]struct {
+			curve  elliptic.Curve
+			method string
+		}{
+			"P-256": {elliptic.P256(), "ES256"},
+			"P-384": {elliptic.P384(), "ES384"},
+			"P-521": {elliptic.P521(), "ES512"},
+		}
+
+		c, ok := curves[raw.Crv]
+		if !ok {
+			return ret, fmt.Errorf("unsupported curve '%s'", raw.Crv)
+		}
+
+		x, err := b64Int(raw.X)
+		if err != nil {
+			return ret, fmt.Errorf("invalid 'x': %w", err)
+		}
+
+		y, err := b64Int(raw.Y)
+		if err != nil {
+			return ret, fmt.Errorf("invalid 'y': %w", err)
+		}
+
+		ret.key = &ecdsa.PublicKey{Curve: c.curve, X: x, Y: y}
+		ret.methods = []string{c.method}
+	case "OKP":
+		if raw.Crv != "Ed25519" {
+			return ret, fmt.Errorf("unsupported curve '%s'", raw.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(raw.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return ret, errors.New("invalid 'x'")
+		}
+
+		ret.key = ed25519.PublicKey(x)
+		ret.methods = []string{"EdDSA"}
+	case "oct":
+		k, err := base64.RawURLEncoding.DecodeString(raw.K)
+		if err != nil || len(k) == 0 {
+			return ret, errors.New("invalid 'k'")
+		}
+
+		ret.key = k
+		ret.methods = []string{"HS256", "HS384", "HS512"}
+	default:
+		return ret, fmt.Errorf("unsupported key type '%s'", raw.Kty)
+	}
+
+	// the key is restricted to one algorithm
+	if raw.Alg != "" {
+		ret.methods = []string{raw.Alg}
+	}
+
+	return ret, nil
+}
+
+// parseJWKS returns the signature keys of a key set. The keys that can't be used are skipped.
+func parseJWKS(data []byte) ([]jwk, error) {
+	var set struct {
+		Keys []rawJWK `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	ret := []jwk{}
+
+	for _, raw := range set.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+
+		key, err := parseJWK(raw)
+		if err != nil {
+			log.Debugf("skipping key '%s' of the key set: %s", raw.Kid, err)
+			continue
+		}
+
+		ret = append(ret, key)
+	}
+
+	return ret, nil
+}
+
+func jwksFileInit(filename string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse key set %s: %w", filename, err)
+	}
+
+	if len(keys) == 0 {
+		return fmt.Errorf("no usable key in %s", filename)
+	}
+
+	dataFileJWKS[filename] = keys
+
+	return nil
+}
+
+// urlKeySet downloads a key set with the http helper, which restricts the hosts and caches
+// the responses.
+func urlKeySet(rawURL string) ([]jwk, error) {
+	httpGetterLock.RLock()
+	g := httpGet
+	httpGetterLock.RUnlock()
+
+	if g == nil {
+		return nil, errors.New("the http helper is not configured (crowdsec_service.http_helper)")
+	}
+
+	body, err := g.get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	jwksURLLock.Lock()
+	defer jwksURLLock.Unlock()
+
+	if entry, ok := jwksURLCache[rawURL]; ok && entry.body == body {
+		return entry.keys, nil
+	}
+
+	keys, err := parseJWKS([]byte(body))
+	if err != nil {
+		return nil, err
+	}
+
+	jwksURLCache[rawURL] = jwksURLEntry{body: body, keys: keys}
+
+	return keys, nil
+}
+
+// cleanToken removes the scheme of an Authorization header value.
+func cleanToken(token string) string {
+	token = strings.TrimSpace(token)
+
+	if scheme, rest, ok := strings.Cut(token, " "); ok && strings.EqualFold(scheme, "bearer") {
+		token = strings.TrimSpace(rest)
+	}
+
+	return token
+}
+
+// DecodeJWTClaims returns the claims of a JWT, or of a "Bearer <jwt>" header value, without
+// verifying its signature: they must not be trusted, but can reveal expired tokens or
+// unexpected issuers. It returns an empty map if the token is invalid.
+// func DecodeJWTClaims(token string) map[string]any
+func DecodeJWTClaims(params ...any) (any, error) {
+	token := cleanToken(params[0].(string))
+
+	claims := jwt.MapClaims{}
+
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		log.Debugf("unable to decode JWT: %s", err)
+		return map[string]any{}, nil
+	}
+
+	return map[string]any(claims), nil
+}
+
+// JWTIsExpired returns true if the exp claim of a JWT, or of a "Bearer <jwt>" header value, is in
+// the past. The signature is not verified. It returns false if the token is invalid or has no exp claim.
+// func JWTIsExpired(token string) bool
+func JWTIsExpired(params ...any) (any, error) {
+	token := cleanToken(params[0].(string))
+
+	claims := jwt.MapClaims{}
+
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		log.Debugf("unable to decode JWT: %s", err)
+		return false, nil
+	}
+
+	return !claims.VerifyExpiresAt(time.Now().Unix(), false), nil
+}
+
+// VerifyJWT returns true if the signature of a JWT is valid for one of the keys of the key set:
+// the name of a data file of type "jwks", or an url downloaded with the http helper. The
+// claims (exp, nbf...) are not checked, use DecodeJWTClaims for that.
+// func VerifyJWT(token string, keyset string) bool
+func VerifyJWT(params ...any) (any, error) {
+	token := cleanToken(params[0].(string))
+	keyset := params[1].(string)
+
+	var keys []jwk
+
+	if strings.HasPrefix(keyset, "http://") || strings.HasPrefix(keyset, "https://") {
+		var err error
+
+		keys, err = urlKeySet(keyset)
+		if err != nil {
+			log.Errorf("VerifyJWT: unable to get key set %s: %s", keyset, err)
+			return false, nil
+		}
+	} else {
+		var ok bool
+
+		keys, ok = dataFileJWKS[keyset]
+		if !ok {
+			log.Errorf("file '%s' (type:jwks) not found in expr library", keyset)
+			return false, nil
+		}
+	}
+
+	unverified, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		log.Debugf("unable to decode JWT: %s", err)
+		return false, nil
+	}
+
+	kid, _ := unverified.Header["kid"].(string)
+
+	for _, k := range keys {
+		if kid != "" && k.kid != "" && kid != k.kid {
+			continue
+		}
+
+		parser := jwt.NewParser(jwt.WithValidMethods(k.methods), jwt.WithoutClaimsValidation())
+
+		if _, err := parser.Parse(token, func(*jwt.Token) (any, error) { return k.key, nil }); err == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
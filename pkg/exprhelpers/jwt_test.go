@@ -0,0 +1,245 @@
+package exprhelpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+)
+
+func signJWT(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	ret, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return ret
+}
+
+func TestDecodeJWTClaims(t *testing.T) {
+	token := signJWT(t, jwt.SigningMethodHS256, "", []byte("secret"), jwt.MapClaims{"iss": "https://idp.example.com", "sub": "alice", "exp": 1700000000})
+
+	tests := []struct {
+		name     string
+		token    string
+		expected map[string]any
+	}{
+		{
+			name:     "token",
+			token:    token,
+			expected: map[string]any{"iss": "https://idp.example.com", "sub": "alice", "exp": float64(1700000000)},
+		},
+		{
+			name:     "authorization header",
+			token:    "Bearer " + token,
+			expected: map[string]any{"iss": "https://idp.example.com", "sub": "alice", "exp": float64(1700000000)},
+		},
+		{
+			name:     "not a token",
+			token:    "hello",
+			expected: map[string]any{},
+		},
+		{
+			name:     "bad payload",
+			token:    "eyJhbGciOiJIUzI1NiJ9.bm9wZQ.c2ln",
+			expected: map[string]any{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vm, err := expr.Compile(`DecodeJWTClaims(token)`, GetExprOptions(map[string]any{"token": ""})...)
+			require.NoError(t, err)
+
+			ret, err := expr.Run(vm, map[string]any{"token": tc.token})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ret)
+		})
+	}
+}
+
+func TestJWTIsExpired(t *testing.T) {
+	key := []byte("secret")
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		token    string
+		expected bool
+	}{
+		{
+			name:     "expired",
+			token:    signJWT(t, jwt.SigningMethodHS256, "", key, jwt.MapClaims{"sub": "alice", "exp": now.Add(-time.Minute).Unix()}),
+			expected: true,
+		},
+		{
+			name:     "valid",
+			token:    signJWT(t, jwt.SigningMethodHS256, "", key, jwt.MapClaims{"sub": "alice", "exp": now.Add(time.Hour).Unix()}),
+			expected: false,
+		},
+		{
+			name:     "authorization header",
+			token:    "Bearer " + signJWT(t, jwt.SigningMethodHS256, "", key, jwt.MapClaims{"exp": now.Add(-time.Hour).Unix()}),
+			expected: true,
+		},
+		{
+			name:     "no exp",
+			token:    signJWT(t, jwt.SigningMethodHS256, "", key, jwt.MapClaims{"sub": "alice"}),
+			expected: false,
+		},
+		{
+			name:     "not a token",
+			token:    "hello",
+			expected: false,
+		},
+	}
+
+	vm, err := expr.Compile(`JWTIsExpired(token)`, GetExprOptions(map[string]any{"token": ""})...)
+	require.NoError(t, err)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ret, err := expr.Run(vm, map[string]any{"token": tc.token})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ret)
+		})
+	}
+}
+
+func TestVerifyJWT(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	keyset := fmt.Sprintf(`{"keys": [
+		{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": %q, "y": %q},
+		{"kty": "OKP", "crv": "Ed25519", "x": %q, "use": "sig"},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+		{"kty": "EC", "kid": "weird", "crv": "P-192", "x": "AA", "y": "AA"}
+	]}`, b64(ecKey.X.Bytes()), b64(ecKey.Y.Bytes()), b64(edPub))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keys.json"), []byte(keyset), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "empty.json"), []byte(`{"keys": []}`), 0o600))
+
+	require.NoError(t, FileInit(dir, "keys.json", "jwks"))
+	assert.Len(t, dataFileJWKS["keys.json"], 2)
+
+	err = FileInit(dir, "empty.json", "jwks")
+	cstest.RequireErrorContains(t, err, "no usable key in empty.json")
+
+	claims := jwt.MapClaims{"iss": "https://idp.example.com", "exp": time.Now().Add(-time.Hour).Unix()}
+
+	tests := []struct {
+		name     string
+		token    string
+		expected bool
+	}{
+		{
+			name:     "ecdsa, expired",
+			token:    signJWT(t, jwt.SigningMethodES256, "ec1", ecKey, claims),
+			expected: true,
+		},
+		{
+			name:     "eddsa, no kid",
+			token:    "Bearer " + signJWT(t, jwt.SigningMethodEdDSA, "", edKey, claims),
+			expected: true,
+		},
+		{
+			name:     "unknown key",
+			token:    signJWT(t, jwt.SigningMethodES256, "", otherKey, claims),
+			expected: false,
+		},
+		{
+			name:     "wrong kid",
+			token:    signJWT(t, jwt.SigningMethodES256, "other", ecKey, claims),
+			expected: false,
+		},
+		{
+			name:     "alg none",
+			token:    signJWT(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, claims),
+			expected: false,
+		},
+		{
+			name:     "not a token",
+			token:    "hello",
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vm, err := expr.Compile(`VerifyJWT(token, "keys.json")`, GetExprOptions(map[string]any{"token": ""})...)
+			require.NoError(t, err)
+
+			ret, err := expr.Run(vm, map[string]any{"token": tc.token})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ret)
+		})
+	}
+
+	// unknown file
+	ret, err := VerifyJWT(tests[0].token, "nope.json")
+	require.NoError(t, err)
+	assert.False(t, ret.(bool))
+
+	// key set from an url
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(keyset))
+	}))
+	defer ts.Close()
+
+	// the http helper is not configured
+	ret, err = VerifyJWT(tests[0].token, ts.URL)
+	require.NoError(t, err)
+	assert.False(t, ret.(bool))
+
+	tsURL, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	cfg := &csconfig.HTTPHelperCfg{AllowedHosts: []string{tsURL.Host}}
+	require.NoError(t, cfg.Load())
+
+	require.NoError(t, InitHttpGet(cfg))
+	defer ShutdownHttpGet()
+
+	ret, err = VerifyJWT(tests[0].token, ts.URL)
+	require.NoError(t, err)
+	assert.True(t, ret.(bool))
+
+	ret, err = VerifyJWT(tests[2].token, ts.URL)
+	require.NoError(t, err)
+	assert.False(t, ret.(bool))
+}
@@ -0,0 +1,148 @@
+package exprhelpers
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// modelsMeta adds the entries of a models.Meta to a map. The first value of a key is kept.
+func modelsMeta(meta models.Meta, ret map[string]string) {
+	for _, m := range meta {
+		if m == nil {
+			continue
+		}
+
+		if _, ok := ret[m.Key]; !ok {
+			ret[m.Key] = m.Value
+		}
+	}
+}
+
+func setModelsMeta(meta *models.Meta, key string, value string) {
+	for _, m := range *meta {
+		if m != nil && m.Key == key {
+			m.Value = value
+			return
+		}
+	}
+
+	*meta = append(*meta, &models.MetaItems0{Key: key, Value: value})
+}
+
+// eventMeta returns the meta of an event, an alert or a map. The meta of an overflow, or an alert,
+// is the meta of the alert followed by the meta of its events, like GetMeta() does.
+func eventMeta(v any) (map[string]string, error) {
+	ret := map[string]string{}
+
+	switch e := v.(type) {
+	case nil:
+	case pipeline.Event:
+		return eventMeta(&e)
+	case *pipeline.Event:
+		if e == nil {
+			break
+		}
+
+		maps.Copy(ret, e.Meta)
+
+		if e.Type == pipeline.OVFLW && e.Overflow.Alert != nil {
+			alertMeta, _ := eventMeta(e.Overflow.Alert)
+			for k, v := range alertMeta {
+				if _, ok := ret[k]; !ok {
+					ret[k] = v
+				}
+			}
+		}
+	case *models.Alert:
+		if e == nil {
+			break
+		}
+
+		modelsMeta(e.Meta, ret)
+
+		for _, evt := range e.Events {
+			if evt != nil {
+				modelsMeta(evt.Meta, ret)
+			}
+		}
+	case *models.Event:
+		if e != nil {
+			modelsMeta(e.Meta, ret)
+		}
+	default:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Map {
+			return nil, fmt.Errorf("can't get the meta of a %T", v)
+		}
+
+		iter := rv.MapRange()
+		for iter.Next() {
+			ret[fmt.Sprint(iter.Key().Interface())] = fmt.Sprint(iter.Value().Interface())
+		}
+	}
+
+	return ret, nil
+}
+
+// func MergeMeta(dst any, src any) map[string]string
+// MergeMeta copies to dst the meta of src that dst doesn't have yet, and returns the meta of dst.
+// dst is an event (*pipeline.Event, *models.Alert or *models.Event); src can also be a map.
+// The meta of an overflow is set on its alert, which is what is sent to the local API.
+func MergeMeta(params ...any) (any, error) {
+	dst := params[0]
+
+	ret, err := eventMeta(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := eventMeta(params[1])
+	if err != nil {
+		return nil, err
+	}
+
+	var set func(key string, value string)
+
+	switch e := dst.(type) {
+	case *pipeline.Event:
+		if e == nil {
+			return nil, fmt.Errorf("can't set the meta of a nil %T", dst)
+		}
+
+		set = func(key string, value string) { e.SetMeta(key, value) }
+
+		if e.Type == pipeline.OVFLW && e.Overflow.Alert != nil {
+			set = func(key string, value string) { setModelsMeta(&e.Overflow.Alert.Meta, key, value) }
+		}
+	case *models.Alert:
+		if e == nil {
+			return nil, fmt.Errorf("can't set the meta of a nil %T", dst)
+		}
+
+		set = func(key string, value string) { setModelsMeta(&e.Meta, key, value) }
+	case *models.Event:
+		if e == nil {
+			return nil, fmt.Errorf("can't set the meta of a nil %T", dst)
+		}
+
+		set = func(key string, value string) { setModelsMeta(&e.Meta, key, value) }
+	default:
+		return nil, fmt.Errorf("can't set the meta of a %T", dst)
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(src)) {
+		if _, ok := ret[k]; ok {
+			continue
+		}
+
+		set(k, src[k])
+		ret[k] = src[k]
+	}
+
+	return ret, nil
+}
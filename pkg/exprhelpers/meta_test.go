@@ -0,0 +1,89 @@
+package exprhelpers
+
+import (
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func runMetaExpr(t *testing.T, code string, env map[string]any) (any, error) {
+	t.Helper()
+
+	vm, err := expr.Compile(code, GetExprOptions(env)...)
+	require.NoError(t, err)
+
+	return expr.Run(vm, env)
+}
+
+func TestMergeMetaEvents(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	evt := &pipeline.Event{Type: pipeline.LOG, Meta: map[string]string{"source_ip": "1.2.3.4"}}
+	other := pipeline.Event{Type: pipeline.LOG, Meta: map[string]string{"source_ip": "5.6.7.8", "user": "bob"}}
+
+	ret, err := runMetaExpr(t, `MergeMeta(evt, other)["user"]`, map[string]any{"evt": evt, "other": other})
+	require.NoError(t, err)
+	assert.Equal(t, "bob", ret)
+
+	// the values of dst are kept
+	assert.Equal(t, map[string]string{"source_ip": "1.2.3.4", "user": "bob"}, evt.Meta)
+
+	// a map, and an event without meta
+	evt = &pipeline.Event{Type: pipeline.LOG}
+
+	_, err = runMetaExpr(t, `MergeMeta(evt, {"service": "ssh", "port": 22})`, map[string]any{"evt": evt})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"service": "ssh", "port": "22"}, evt.Meta)
+}
+
+func TestMergeMetaOverflow(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	alert := &models.Alert{
+		Meta: models.Meta{{Key: "target_fqdn", Value: "example.com"}},
+		Events: []*models.Event{
+			{Meta: models.Meta{{Key: "source_ip", Value: "1.2.3.4"}, {Key: "service", Value: "http"}}},
+		},
+	}
+	ovflw := &pipeline.Event{Type: pipeline.OVFLW, Overflow: pipeline.RuntimeAlert{Alert: alert}}
+
+	src := &models.Event{Meta: models.Meta{{Key: "service", Value: "ssh"}, {Key: "user", Value: "root"}}}
+
+	ret, err := runMetaExpr(t, `MergeMeta(evt, src)`, map[string]any{"evt": ovflw, "src": src})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"target_fqdn": "example.com", "source_ip": "1.2.3.4", "service": "http", "user": "root"}, ret)
+
+	// only the missing keys are added, to the alert
+	assert.Equal(t, models.Meta{{Key: "target_fqdn", Value: "example.com"}, {Key: "user", Value: "root"}}, alert.Meta)
+
+	// from an alert, in a profile
+	other := &models.Alert{Meta: models.Meta{{Key: "target_fqdn", Value: "other.com"}, {Key: "country", Value: "FR"}}}
+
+	_, err = runMetaExpr(t, `MergeMeta(Alert, other)`, map[string]any{"Alert": alert, "other": other})
+	require.NoError(t, err)
+	assert.Equal(t, "FR", alert.GetMeta("country"))
+	assert.Equal(t, "example.com", alert.GetMeta("target_fqdn"))
+}
+
+func TestMergeMetaErrors(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	_, err = runMetaExpr(t, `MergeMeta(value, {})`, map[string]any{"value": map[string]string{}})
+	require.ErrorContains(t, err, "can't set the meta of a map[string]string")
+
+	_, err = runMetaExpr(t, `MergeMeta(evt, value)`, map[string]any{"evt": &pipeline.Event{}, "value": "foo"})
+	require.ErrorContains(t, err, "can't get the meta of a string")
+
+	var evt *pipeline.Event
+
+	_, err = runMetaExpr(t, `MergeMeta(evt, {})`, map[string]any{"evt": evt})
+	require.ErrorContains(t, err, "can't set the meta of a nil *pipeline.Event")
+}
@@ -0,0 +1,156 @@
+//go:build !no_mlscore
+
+package exprhelpers
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/cwversion/component"
+	"github.com/crowdsecurity/crowdsec/pkg/onnx"
+)
+
+// the errors of a model are usually the same for every event
+const mlErrorLogInterval = time.Minute
+
+// mlModel is a loaded model, with the element of its output that is the score.
+type mlModel struct {
+	model  *onnx.Model
+	output string
+	index  int
+	errLog *rate.Sometimes
+}
+
+var (
+	mlModelsLock sync.RWMutex
+	mlModels     map[string]*mlModel
+)
+
+//nolint:gochecknoinits
+func init() {
+	component.Register("mlscore")
+}
+
+// InitMachineLearningModels loads the models used by MachineLearningScore. The models are
+// replaced only if they can all be loaded.
+func InitMachineLearningModels(cfgs []*csconfig.MLModelCfg) error {
+	models := make(map[string]*mlModel, len(cfgs))
+
+	for _, cfg := range cfgs {
+		model, err := onnx.Load(cfg.Path)
+		if err != nil {
+			return fmt.Errorf("model %s: %w", cfg.Name, err)
+		}
+
+		m := &mlModel{
+			model:  model,
+			output: cfg.Output,
+			index:  -1,
+			errLog: &rate.Sometimes{Interval: mlErrorLogInterval},
+		}
+
+		if cfg.Index != nil {
+			m.index = *cfg.Index
+		}
+
+		if m.output != "" && !slices.Contains(model.Outputs(), m.output) {
+			return fmt.Errorf("model %s: no output %q (outputs: %v)", cfg.Name, m.output, model.Outputs())
+		}
+
+		log.Infof("loaded ml model %s from %s", cfg.Name, cfg.Path)
+
+		models[cfg.Name] = m
+	}
+
+	mlModelsLock.Lock()
+	mlModels = models
+	mlModelsLock.Unlock()
+
+	return nil
+}
+
+// toFeatures converts the features given by an expression: numbers and booleans.
+func toFeatures(v any) ([]float64, error) {
+	var values []any
+
+	switch v := v.(type) {
+	case []float64:
+		return v, nil
+	case []int:
+		ret := make([]float64, len(v))
+		for i, x := range v {
+			ret[i] = float64(x)
+		}
+
+		return ret, nil
+	case []any:
+		values = v
+	default:
+		return nil, fmt.Errorf("features must be an array, got %T", v)
+	}
+
+	ret := make([]float64, len(values))
+
+	for i, x := range values {
+		switch x := x.(type) {
+		case float64:
+			ret[i] = x
+		case float32:
+			ret[i] = float64(x)
+		case int:
+			ret[i] = float64(x)
+		case int64:
+			ret[i] = float64(x)
+		case int32:
+			ret[i] = float64(x)
+		case bool:
+			if x {
+				ret[i] = 1
+			}
+		default:
+			return nil, fmt.Errorf("feature %d: expected a number, got %T", i, x)
+		}
+	}
+
+	return ret, nil
+}
+
+// MachineLearningScore runs a model declared in crowdsec_service.ml_models on a vector of
+// features, and returns its score. Errors are logged and result in a score of 0, to not
+// break the processing of the event.
+//
+// func MachineLearningScore(model string, features []any) float64 {
+func MachineLearningScore(params ...any) (any, error) {
+	name := params[0].(string)
+
+	mlModelsLock.RLock()
+	m := mlModels[name]
+	mlModelsLock.RUnlock()
+
+	if m == nil {
+		warnMLModelMissing(name, "is not declared (crowdsec_service.ml_models)")
+		return 0.0, nil
+	}
+
+	features, err := toFeatures(params[1])
+	if err == nil {
+		var score float64
+
+		score, err = m.model.Score(features, m.output, m.index)
+		if err == nil {
+			return score, nil
+		}
+	}
+
+	m.errLog.Do(func() {
+		log.Warningf("MachineLearningScore %s: %s (the errors of this model are logged at most once per %s)", name, err, mlErrorLogInterval)
+	})
+
+	return 0.0, nil
+}
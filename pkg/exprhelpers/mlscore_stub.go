@@ -0,0 +1,26 @@
+//go:build no_mlscore
+
+package exprhelpers
+
+import (
+	"errors"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+)
+
+func InitMachineLearningModels(cfgs []*csconfig.MLModelCfg) error {
+	if len(cfgs) > 0 {
+		return errors.New("crowdsec_service.ml_models is set, but this binary was built without the mlscore component")
+	}
+
+	return nil
+}
+
+// MachineLearningScore is always 0 without the mlscore component.
+//
+// func MachineLearningScore(model string, features []any) float64 {
+func MachineLearningScore(params ...any) (any, error) {
+	warnMLModelMissing(params[0].(string), "can't be used, this binary was built without the mlscore component")
+
+	return 0.0, nil
+}
@@ -0,0 +1,135 @@
+//go:build !no_mlscore
+
+package exprhelpers
+
+import (
+	"math"
+	"testing"
+
+	"github.com/expr-lang/expr"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestMachineLearningScore(t *testing.T) {
+	// logistic regression on 3 features: sigmoid(x0 - 2*x1 + 0.5*x2 + 0.25)
+	require.NoError(t, InitMachineLearningModels([]*csconfig.MLModelCfg{
+		{Name: "bots", Path: "testdata/logistic_regression.onnx"},
+		{Name: "bots_output", Path: "testdata/logistic_regression.onnx", Output: "Y", Index: new(0)},
+	}))
+	defer InitMachineLearningModels(nil)
+
+	sigmoid := func(v float64) float64 { return 1 / (1 + math.Exp(-v)) }
+
+	tests := []struct {
+		name     string
+		code     string
+		env      map[string]any
+		expected float64
+	}{
+		{
+			name:     "literal features",
+			code:     `MachineLearningScore("bots", [1, 2, 4])`,
+			expected: sigmoid(1 - 4 + 2 + 0.25),
+		},
+		{
+			name:     "features from the environment",
+			code:     `MachineLearningScore("bots_output", [count, ratio, is_bot])`,
+			env:      map[string]any{"count": 3, "ratio": 0.5, "is_bot": true},
+			expected: sigmoid(3 - 1 + 0.5 + 0.25),
+		},
+		{
+			name:     "in a condition",
+			code:     `MachineLearningScore("bots", [10, 0, 0]) > 0.9 ? 1.0 : 0.0`,
+			expected: 1,
+		},
+		{
+			name:     "wrong number of features",
+			code:     `MachineLearningScore("bots", [1, 2])`,
+			expected: 0,
+		},
+		{
+			name:     "not a number",
+			code:     `MachineLearningScore("bots", [1, 2, "3"])`,
+			expected: 0,
+		},
+		{
+			name:     "unknown model",
+			code:     `MachineLearningScore("unknown", [1, 2, 3])`,
+			expected: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			env := tc.env
+			if env == nil {
+				env = map[string]any{}
+			}
+
+			program, err := expr.Compile(tc.code, GetExprOptions(env)...)
+			require.NoError(t, err)
+
+			output, err := expr.Run(program, env)
+			require.NoError(t, err)
+			assert.InDelta(t, tc.expected, output, 1e-6)
+		})
+	}
+}
+
+func TestInitMachineLearningModels(t *testing.T) {
+	err := InitMachineLearningModels([]*csconfig.MLModelCfg{
+		{Name: "missing", Path: "testdata/does-not-exist.onnx"},
+	})
+	cstest.RequireErrorContains(t, err, "model missing: open testdata/does-not-exist.onnx: "+cstest.FileNotFoundMessage)
+
+	err = InitMachineLearningModels([]*csconfig.MLModelCfg{
+		{Name: "bots", Path: "testdata/logistic_regression.onnx", Output: "probabilities"},
+	})
+	cstest.RequireErrorContains(t, err, `model bots: no output "probabilities" (outputs: [Y])`)
+
+	err = InitMachineLearningModels([]*csconfig.MLModelCfg{
+		{Name: "data", Path: "testdata/test_data.txt"},
+	})
+	cstest.RequireErrorContains(t, err, "model data: testdata/test_data.txt: invalid model")
+}
+
+func TestMachineLearningScoreLogs(t *testing.T) {
+	require.NoError(t, InitMachineLearningModels([]*csconfig.MLModelCfg{
+		{Name: "bots", Path: "testdata/logistic_regression.onnx"},
+	}))
+	defer InitMachineLearningModels(nil)
+
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+
+	// each missing model is reported once
+	for range 3 {
+		for _, name := range []string{"missing_a", "missing_b"} {
+			_, err := MachineLearningScore(name, []any{1, 2, 3})
+			require.NoError(t, err)
+		}
+	}
+
+	// the errors of a model are rate limited
+	for range 3 {
+		_, err := MachineLearningScore("bots", []any{1, 2})
+		require.NoError(t, err)
+		_, err = MachineLearningScore("bots", []any{"1", 2, 3})
+		require.NoError(t, err)
+	}
+
+	messages := []string{}
+	for _, entry := range hook.AllEntries() {
+		messages = append(messages, entry.Message)
+	}
+
+	require.Len(t, messages, 3)
+	assert.Contains(t, messages[0], "model missing_a is not declared")
+	assert.Contains(t, messages[1], "model missing_b is not declared")
+	assert.Contains(t, messages[2], "MachineLearningScore bots: ")
+}
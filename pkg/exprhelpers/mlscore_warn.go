@@ -0,0 +1,18 @@
+package exprhelpers
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// the names of the models that were used but are not available, each one is reported once
+var mlModelsMissing sync.Map
+
+func warnMLModelMissing(name string, reason string) {
+	if _, loaded := mlModelsMissing.LoadOrStore(name, struct{}{}); loaded {
+		return
+	}
+
+	log.Warningf("MachineLearningScore: model %s %s, returning 0", name, reason)
+}
@@ -0,0 +1,90 @@
+package exprhelpers
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The prefix length of the k-anonymity range API of Have I Been Pwned.
+const pwnedPrefixLen = 5
+
+// dataFilePwned holds the breached password sets (data files of type "pwned"), keyed by filename,
+// then by the prefix and the suffix of the SHA-1 hash, with the number of breaches as value.
+// A line of the file is a SHA-1 hash with an optional count, as in the HIBP downloads:
+//
+//	7C4A8D09CA3762AF61E59520943DC26494F8941B:24230577
+var dataFilePwned map[string]map[string]map[string]int
+
+// normalizeSHA1 returns the upper-cased hash, or false if it's not a SHA-1 hex digest.
+func normalizeSHA1(hash string) (string, bool) {
+	hash = strings.ToUpper(strings.TrimSpace(hash))
+
+	if len(hash) != 40 {
+		return "", false
+	}
+
+	if _, err := hex.DecodeString(hash); err != nil {
+		return "", false
+	}
+
+	return hash, true
+}
+
+func pwnedFileInit(filename string, line string) error {
+	hash, countStr, hasCount := strings.Cut(line, ":")
+
+	hash, ok := normalizeSHA1(hash)
+	if !ok {
+		return fmt.Errorf("invalid SHA-1 hash in %s: %q", filename, line)
+	}
+
+	count := 1
+
+	if hasCount {
+		var err error
+
+		count, err = strconv.Atoi(strings.TrimSpace(countStr))
+		if err != nil || count < 1 {
+			return fmt.Errorf("invalid count in %s: %q", filename, line)
+		}
+	}
+
+	if dataFilePwned[filename] == nil {
+		dataFilePwned[filename] = make(map[string]map[string]int)
+	}
+
+	prefix, suffix := hash[:pwnedPrefixLen], hash[pwnedPrefixLen:]
+
+	if dataFilePwned[filename][prefix] == nil {
+		dataFilePwned[filename][prefix] = make(map[string]int)
+	}
+
+	dataFilePwned[filename][prefix][suffix] += count
+
+	return nil
+}
+
+// WeakPasswordCheck returns the number of breaches of a password, from the SHA-1 hash of the password
+// and a data file of type "pwned". It returns 0 if the password is not in the file, or the hash is invalid.
+// func WeakPasswordCheck(pw_hash string, filename string) int
+func WeakPasswordCheck(params ...any) (any, error) {
+	filename := params[1].(string)
+
+	ranges, ok := dataFilePwned[filename]
+	if !ok {
+		log.Errorf("file '%s' (type:pwned) not found in expr library", filename)
+		return 0, nil
+	}
+
+	hash, ok := normalizeSHA1(params[0].(string))
+	if !ok {
+		log.Debugf("WeakPasswordCheck: invalid SHA-1 hash '%s'", params[0].(string))
+		return 0, nil
+	}
+
+	return ranges[hash[:pwnedPrefixLen]][hash[pwnedPrefixLen:]], nil
+}
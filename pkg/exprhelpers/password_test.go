@@ -0,0 +1,53 @@
+package exprhelpers
+
+import (
+	"testing"
+
+	"github.com/expr-lang/expr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestWeakPasswordCheck(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	err = FileInit("testdata", "test_data_pwned.txt", "pwned")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		hash     string
+		expected int
+	}{
+		{"with count", "7C4A8D09CA3762AF61E59520943DC26494F8941B", 24230577},
+		{"lower case", "7c4a8d09ca3762af61e59520943dc26494f8941b", 24230577},
+		{"lower case in file", "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8", 9545824},
+		{"without count", "b1b3773a05c0ed0176787a4f1574ff0075f7521e", 1},
+		{"same prefix", "7C4A8D09CA3762AF61E59520943DC26494F8941C", 0},
+		{"not found", "DA39A3EE5E6B4B0D3255BFEF95601890AFD80709", 0},
+		{"not a hash", "123456", 0},
+		{"empty", "", 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vm, err := expr.Compile(`WeakPasswordCheck(hash, "test_data_pwned.txt")`, GetExprOptions(map[string]any{"hash": ""})...)
+			require.NoError(t, err)
+
+			ret, err := expr.Run(vm, map[string]any{"hash": tc.hash})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ret)
+		})
+	}
+
+	// unknown file
+	ret, err := WeakPasswordCheck("7C4A8D09CA3762AF61E59520943DC26494F8941B", "nope.txt")
+	require.NoError(t, err)
+	assert.Equal(t, 0, ret)
+
+	err = FileInit("testdata", "test_data_pwned_invalid.txt", "pwned")
+	cstest.RequireErrorContains(t, err, `invalid count in test_data_pwned_invalid.txt: "7C4A8D09CA3762AF61E59520943DC26494F8941B:lots"`)
+}
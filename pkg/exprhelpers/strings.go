@@ -1,6 +1,7 @@
 package exprhelpers
 
 import (
+	"fmt"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -21,7 +22,28 @@ func IndexAny(params ...any) (any, error) {
 }
 
 func Join(params ...any) (any, error) {
-	return strings.Join(params[0].([]string), params[1].(string)), nil
+	switch list := params[0].(type) {
+	case []string:
+		return strings.Join(list, params[1].(string)), nil
+	case []any:
+		// values from maps (MapValues, Flatten...) are not necessarily strings
+		elems := make([]string, 0, len(list))
+
+		for _, elem := range list {
+			switch e := elem.(type) {
+			case nil:
+				elems = append(elems, "")
+			case string:
+				elems = append(elems, e)
+			default:
+				elems = append(elems, fmt.Sprint(e))
+			}
+		}
+
+		return strings.Join(elems, params[1].(string)), nil
+	default:
+		return "", nil
+	}
 }
 
 func Split(params ...any) (any, error) {
@@ -72,6 +94,35 @@ func TrimSuffix(params ...any) (any, error) {
 	return strings.TrimSuffix(params[0].(string), params[1].(string)), nil
 }
 
+// CountOccurrences returns the number of non-overlapping occurrences of substr in s,
+// i.e. CountOccurrences(evt.Parsed.request, "'") for the quotes of a payload. It returns 0 if substr is empty.
+func CountOccurrences(params ...any) (any, error) {
+	s := params[0].(string)
+	substr := params[1].(string)
+
+	if substr == "" {
+		return 0, nil
+	}
+
+	return strings.Count(s, substr), nil
+}
+
+// Tokenize splits s around each of the characters of seps, without the empty tokens,
+// i.e. Tokenize("id=1; DROP TABLE users--", " =;") is ["id", "1", "DROP", "TABLE", "users--"].
+// If seps is empty, s is split around white space.
+func Tokenize(params ...any) (any, error) {
+	s := params[0].(string)
+	seps := params[1].(string)
+
+	if seps == "" {
+		return strings.Fields(s), nil
+	}
+
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return strings.ContainsRune(seps, r)
+	}), nil
+}
+
 func LogInfo(params ...any) (any, error) {
 	log.Infof(params[0].(string), params[1:]...)
 	return true, nil
@@ -0,0 +1,107 @@
+package exprhelpers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bluele/gcache"
+	log "github.com/sirupsen/logrus"
+)
+
+// throttleCacheSize is the number of keys tracked by Throttle. Beyond it, the least recently used
+// keys are forgotten: their next action is allowed.
+const throttleCacheSize = 100000
+
+var (
+	throttleMu    sync.Mutex
+	throttleCache = newThrottleCache()
+	// throttleWindows caches the windows given to Throttle, parsed once: the duration, or 0 if
+	// it's invalid
+	throttleWindows sync.Map
+)
+
+// tokenBucket is the state of a Throttle key: the tokens left, refilled continuously up to the
+// limit over the window.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newThrottleCache() gcache.Cache {
+	return gcache.New(throttleCacheSize).LRU().Build()
+}
+
+// ThrottleCacheInit forgets the state of all the keys.
+func ThrottleCacheInit() {
+	throttleMu.Lock()
+	defer throttleMu.Unlock()
+
+	throttleCache = newThrottleCache()
+}
+
+// throttle takes a token of the bucket of a key, and returns false if there is none left.
+func throttle(key string, limit int, window time.Duration, now time.Time) bool {
+	if limit <= 0 {
+		return false
+	}
+
+	throttleMu.Lock()
+	defer throttleMu.Unlock()
+
+	bucket := &tokenBucket{tokens: float64(limit), last: now}
+
+	if cached, err := throttleCache.Get(key); err == nil {
+		bucket = cached.(*tokenBucket)
+		refill := now.Sub(bucket.last).Seconds() * float64(limit) / window.Seconds()
+		bucket.tokens = min(float64(limit), bucket.tokens+max(0, refill))
+		bucket.last = now
+	}
+
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+
+	// an idle key has all its tokens back after a window, it can be forgotten
+	if err := throttleCache.SetWithExpire(key, bucket, window); err != nil {
+		log.Warningf("Throttle: could not store the state of %s: %s", key, err)
+	}
+
+	return allowed
+}
+
+// throttleWindow returns the duration of a window, or 0 if it's invalid. An invalid window is
+// reported once, not on each event.
+func throttleWindow(s string) time.Duration {
+	if window, ok := throttleWindows.Load(s); ok {
+		return window.(time.Duration)
+	}
+
+	window, err := time.ParseDuration(s)
+	if err != nil || window <= 0 {
+		window = 0
+	}
+
+	if _, loaded := throttleWindows.LoadOrStore(s, window); !loaded && window == 0 {
+		log.Errorf("Throttle: invalid window %q, the actions are allowed", s)
+	}
+
+	return window
+}
+
+// func Throttle(key string, limit int, window string) bool
+//
+// Throttle returns true if the action of a key can be done: at most limit times per window, with
+// bursts of up to limit actions. The state is kept in memory, it's shared by all the expressions
+// and lost on restart.
+func Throttle(params ...any) (any, error) {
+	key := params[0].(string)
+	limit := params[1].(int)
+
+	window := throttleWindow(params[2].(string))
+	if window == 0 {
+		return true, nil
+	}
+
+	return throttle(key, limit, window, time.Now()), nil
+}
@@ -0,0 +1,102 @@
+package exprhelpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/expr-lang/expr"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottleRefill(t *testing.T) {
+	ThrottleCacheInit()
+
+	now := time.Now()
+
+	// a burst of up to limit actions
+	for range 3 {
+		assert.True(t, throttle("a", 3, time.Minute, now))
+	}
+
+	assert.False(t, throttle("a", 3, time.Minute, now))
+
+	// the keys are independent
+	assert.True(t, throttle("b", 3, time.Minute, now))
+
+	// one token every 20 seconds
+	assert.False(t, throttle("a", 3, time.Minute, now.Add(19*time.Second)))
+	assert.True(t, throttle("a", 3, time.Minute, now.Add(20*time.Second)))
+	assert.False(t, throttle("a", 3, time.Minute, now.Add(21*time.Second)))
+
+	// no more than limit tokens after a long pause
+	later := now.Add(time.Hour)
+	for range 3 {
+		assert.True(t, throttle("a", 3, time.Minute, later))
+	}
+
+	assert.False(t, throttle("a", 3, time.Minute, later))
+
+	assert.False(t, throttle("c", 0, time.Minute, now))
+}
+
+func TestThrottle(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		code   string
+		env    map[string]any
+		result []bool
+	}{
+		{
+			name:   "limit",
+			code:   `Throttle("notify-" + ip, 2, "1h")`,
+			env:    map[string]any{"ip": "1.2.3.4"},
+			result: []bool{true, true, false, false},
+		},
+		{
+			name:   "other key",
+			code:   `Throttle("notify-" + ip, 2, "1h")`,
+			env:    map[string]any{"ip": "5.6.7.8"},
+			result: []bool{true, true, false},
+		},
+		{
+			name:   "invalid window",
+			code:   `Throttle("x", 1, "soon")`,
+			result: []bool{true, true},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			program, err := expr.Compile(tc.code, GetExprOptions(tc.env)...)
+			require.NoError(t, err)
+
+			for i, expected := range tc.result {
+				output, err := expr.Run(program, tc.env)
+				require.NoError(t, err)
+				assert.Equal(t, expected, output, "call %d", i)
+			}
+		})
+	}
+}
+
+func TestThrottleInvalidWindow(t *testing.T) {
+	hook := logtest.NewGlobal()
+	defer hook.Reset()
+
+	for range 3 {
+		for _, window := range []string{"1m", "later", "-1h"} {
+			_, err := Throttle("invalid-window", 1, window)
+			require.NoError(t, err)
+		}
+	}
+
+	// each invalid window is reported once
+	require.Len(t, hook.AllEntries(), 2)
+	assert.Equal(t, `Throttle: invalid window "later", the actions are allowed`, hook.AllEntries()[0].Message)
+	assert.Equal(t, `Throttle: invalid window "-1h", the actions are allowed`, hook.AllEntries()[1].Message)
+}
@@ -0,0 +1,94 @@
+package exprhelpers
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/idna"
+)
+
+// ports used when the URL doesn't have one
+var defaultPorts = map[string]int{
+	"http":  80,
+	"https": 443,
+	"ws":    80,
+	"wss":   443,
+	"ftp":   21,
+}
+
+// ParsedURL holds the parts of a URL. The fields that are not in the URL are empty: a request URI
+// like "/login?user=admin" has no scheme, host or port.
+type ParsedURL struct {
+	Scheme      string              // lower-cased
+	User        string              // user name of the userinfo, without the password
+	Host        string              // lower-cased, internationalized names in their ASCII (punycode) form, IPv6 without brackets
+	HostUnicode string              // Host with the internationalized names decoded
+	Port        int                 // explicit port, or the default port of the scheme
+	Path        string              // decoded
+	Query       map[string][]string // decoded query parameters
+	Fragment    string              // decoded
+}
+
+// hostForms returns the ASCII and unicode forms of a host name. IP addresses and names that
+// can't be converted are only lower-cased.
+func hostForms(host string) (string, string) {
+	host = strings.ToLower(host)
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return host, host
+	}
+
+	unicode, err := idna.Display.ToUnicode(ascii)
+	if err != nil {
+		return ascii, ascii
+	}
+
+	return ascii, unicode
+}
+
+// ParseURL returns the parts of an absolute URL or a request URI. It returns an empty ParsedURL
+// if the URL is invalid.
+// func ParseURL(uri string) *ParsedURL
+func ParseURL(params ...any) (any, error) {
+	uri := params[0].(string)
+	ret := &ParsedURL{Query: map[string][]string{}}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		log.Debugf("unable to parse URL '%s': %s", uri, err)
+		return ret, nil
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		log.Debugf("unable to parse query of URL '%s': %s", uri, err)
+		return ret, nil
+	}
+
+	ret.Scheme = strings.ToLower(u.Scheme)
+	ret.Path = u.Path
+	ret.Query = query
+	ret.Fragment = u.Fragment
+
+	if u.User != nil {
+		ret.User = u.User.Username()
+	}
+
+	if hostname := u.Hostname(); hostname != "" {
+		ret.Host, ret.HostUnicode = hostForms(hostname)
+	}
+
+	if port := u.Port(); port != "" {
+		ret.Port, err = strconv.Atoi(port)
+		if err != nil {
+			log.Debugf("invalid port in URL '%s': %s", uri, err)
+		}
+	} else if ret.Host != "" {
+		ret.Port = defaultPorts[ret.Scheme]
+	}
+
+	return ret, nil
+}
@@ -2,6 +2,8 @@ package exprhelpers
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -126,3 +128,147 @@ func XMLGetNodeValue(params ...any) (any, error) {
 
 	return elem.Text(), nil
 }
+
+// splitXMLPath splits path on '/', ignoring the ones in quoted filter values.
+func splitXMLPath(path string) []string {
+	var (
+		segments []string
+		quote    rune
+		start    int
+	)
+
+	for i, c := range path {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '/':
+			segments = append(segments, path[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(segments, path[start:])
+}
+
+// namespacedPath rewrites the 'prefix:tag' selectors of path into filters on the namespace URI
+// declared for prefix in namespaces, so that the query doesn't depend on the prefixes used in the document.
+func namespacedPath(path string, namespaces map[string]any) (string, error) {
+	if len(namespaces) == 0 {
+		return path, nil
+	}
+
+	segments := splitXMLPath(path)
+
+	for i, segment := range segments {
+		selector, filters, _ := strings.Cut(segment, "[")
+		if filters != "" {
+			filters = "[" + filters
+		}
+
+		prefix, tag, found := strings.Cut(selector, ":")
+		if !found {
+			continue
+		}
+
+		v, ok := namespaces[prefix]
+		if !ok {
+			continue
+		}
+
+		uri, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("namespace URI for prefix '%s' must be a string, got %T", prefix, v)
+		}
+
+		segments[i] = fmt.Sprintf("*[local-name()='%s'][namespace-uri()='%s']%s", tag, uri, filters)
+	}
+
+	return strings.Join(segments, "/"), nil
+}
+
+// xmlExtract returns the elements (or attribute values, if the last segment of path is '@name') matching path.
+func xmlExtract(xmlString string, path string, namespaces map[string]any) ([]string, error) {
+	var attributeName string
+
+	if segments := splitXMLPath(path); len(segments) > 1 && strings.HasPrefix(segments[len(segments)-1], "@") {
+		attributeName = segments[len(segments)-1][1:]
+		path = strings.Join(segments[:len(segments)-1], "/")
+	}
+
+	path, err := namespacedPath(path, namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	compiledPath, err := compileOrGetPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile path %s: %w", path, err)
+	}
+
+	doc, err := getXMLDocumentFromCache(xmlString)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse XML: %w", err)
+	}
+
+	ret := []string{}
+
+	for _, elem := range doc.FindElementsPath(compiledPath) {
+		if attributeName == "" {
+			ret = append(ret, elem.Text())
+			continue
+		}
+
+		if attr := elem.SelectAttr(attributeName); attr != nil {
+			ret = append(ret, attr.Value)
+		}
+	}
+
+	return ret, nil
+}
+
+func xmlExtractParams(params []any) (string, string, map[string]any) {
+	xmlString := params[0].(string)
+	path := params[1].(string)
+
+	var namespaces map[string]any
+	if len(params) > 2 {
+		namespaces = params[2].(map[string]any)
+	}
+
+	return xmlString, path, namespaces
+}
+
+// func XMLExtract(xmlString string, path string, namespaces map[string]any) string {
+func XMLExtract(params ...any) (any, error) {
+	xmlString, path, namespaces := xmlExtractParams(params)
+
+	values, err := xmlExtract(xmlString, path, namespaces)
+	if err != nil {
+		log.Errorf("XMLExtract : %s", err)
+		return "", nil
+	}
+
+	if len(values) == 0 {
+		log.Debugf("Could not find element %s", path)
+		return "", nil
+	}
+
+	return values[0], nil
+}
+
+// func XMLExtractSlice(xmlString string, path string, namespaces map[string]any) []string {
+func XMLExtractSlice(params ...any) (any, error) {
+	xmlString, path, namespaces := xmlExtractParams(params)
+
+	values, err := xmlExtract(xmlString, path, namespaces)
+	if err != nil {
+		log.Errorf("XMLExtractSlice : %s", err)
+		return []string(nil), nil
+	}
+
+	return values, nil
+}
@@ -4,7 +4,9 @@ import (
 	"log"
 	"testing"
 
+	"github.com/expr-lang/expr"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestXMLGetAttributeValue(t *testing.T) {
@@ -116,3 +118,100 @@ func TestXMLGetNodeValue(t *testing.T) {
 		log.Printf("test '%s' : OK", test.name)
 	}
 }
+
+func TestXMLExtract(t *testing.T) {
+	err := Init(nil)
+	require.NoError(t, err)
+
+	soap := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:m="urn:example:login">
+<soap:Body><m:Login m:method="password"><m:User>admin</m:User><m:User>root</m:User></m:Login></soap:Body>
+</soap:Envelope>`
+
+	winEvent := `<Event xmlns="http://schemas.microsoft.com/win/2004/08/events/event"><System><EventID>4625</EventID></System>` +
+		`<EventData><Data Name="TargetUserName">bob</Data><Data Name="IpAddress">1.2.3.4</Data></EventData></Event>`
+
+	tests := []struct {
+		name     string
+		expr     string
+		env      map[string]any
+		expected any
+	}{
+		{
+			name:     "plain path",
+			expr:     `XMLExtract(xml, "/root/child")`,
+			env:      map[string]any{"xml": `<root><child>foobar</child></root>`},
+			expected: "foobar",
+		},
+		{
+			name:     "attribute",
+			expr:     `XMLExtract(xml, "/root/child/@attr")`,
+			env:      map[string]any{"xml": `<root><child attr="value"/></root>`},
+			expected: "value",
+		},
+		{
+			name:     "document prefixes",
+			expr:     `XMLExtract(xml, "//soap:Body/m:Login/m:User")`,
+			env:      map[string]any{"xml": soap},
+			expected: "admin",
+		},
+		{
+			name:     "namespace map with different prefixes",
+			expr:     `XMLExtract(xml, "/s:Envelope/s:Body/l:Login/l:User", {"s": "http://schemas.xmlsoap.org/soap/envelope/", "l": "urn:example:login"})`,
+			env:      map[string]any{"xml": soap},
+			expected: "admin",
+		},
+		{
+			name:     "namespaced attribute",
+			expr:     `XMLExtract(xml, "//l:Login/@m:method", {"l": "urn:example:login"})`,
+			env:      map[string]any{"xml": soap},
+			expected: "password",
+		},
+		{
+			name:     "default namespace with filter",
+			expr:     `XMLExtract(xml, "//e:Data[@Name='IpAddress']", {"e": "http://schemas.microsoft.com/win/2004/08/events/event"})`,
+			env:      map[string]any{"xml": winEvent},
+			expected: "1.2.3.4",
+		},
+		{
+			name:     "wrong namespace",
+			expr:     `XMLExtract(xml, "//e:Data", {"e": "urn:nope"})`,
+			env:      map[string]any{"xml": winEvent},
+			expected: "",
+		},
+		{
+			name:     "invalid XML",
+			expr:     `XMLExtract(xml, "/root/child")`,
+			env:      map[string]any{"xml": `<root><`},
+			expected: "",
+		},
+		{
+			name:     "slice",
+			expr:     `XMLExtractSlice(xml, "//l:User", {"l": "urn:example:login"})`,
+			env:      map[string]any{"xml": soap},
+			expected: []string{"admin", "root"},
+		},
+		{
+			name:     "slice of attributes",
+			expr:     `XMLExtractSlice(xml, "//Data/@Name")`,
+			env:      map[string]any{"xml": winEvent},
+			expected: []string{"TargetUserName", "IpAddress"},
+		},
+		{
+			name:     "empty slice",
+			expr:     `XMLExtractSlice(xml, "/foo/bar")`,
+			env:      map[string]any{"xml": winEvent},
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vm, err := expr.Compile(tc.expr, GetExprOptions(tc.env)...)
+			require.NoError(t, err)
+
+			out, err := expr.Run(vm, tc.env)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
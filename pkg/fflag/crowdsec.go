@@ -12,6 +12,7 @@ var (
 	Re2GrokSupport = &Feature{Name: "re2_grok_support", Description: "Enable RE2 support for GROK patterns"}
 	// This one is only available on OS where RE2 support is enabled by default (linux only at the moment)
 	Re2DisableGrokSupport  = &Feature{Name: "re2_disable_grok_support", Description: "Disable RE2 support for GROK patterns (linux only)"}
+	Re2GrokAutoSwitch      = &Feature{Name: "re2_grok_auto_switch", Description: "Use RE2 for the GROK patterns prone to catastrophic backtracking"}
 	Re2RegexpInfileSupport = &Feature{Name: "re2_regexp_in_file_support", Description: "Enable RE2 support for RegexpInFile expr helper"}
 	PProfBlockProfile      = &Feature{Name: "pprof_block_profile", Description: "Enable pprof block/mutex profiling. Do not use unless instructed by CrowdSec support"}
 )
@@ -50,6 +51,10 @@ func RegisterAllFeatures() error {
 		return err
 	}
 
+	if err := Crowdsec.RegisterFeature(Re2GrokAutoSwitch); err != nil {
+		return err
+	}
+
 	return nil
 }
 
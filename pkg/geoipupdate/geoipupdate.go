@@ -0,0 +1,220 @@
+// Package geoipupdate keeps the GeoLite2 databases of the data directory up to date: they are downloaded
+// when missing or stale, and reloaded by the expr helpers without restart.
+package geoipupdate
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/apiclient/useragent"
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+)
+
+// the databases used by the expr helpers and the geoip enrichers
+var editions = []string{"GeoLite2-City", "GeoLite2-ASN"}
+
+// a database is much smaller, this only protects against a wrong URL
+const maxDownloadSize = 512 * 1024 * 1024
+
+type Updater struct {
+	cfg     *csconfig.GeoIPUpdateCfg
+	dataDir string
+	client  *http.Client
+	logger  *log.Entry
+	// reload the readers of the expr helpers
+	reload func(dataDir string) error
+}
+
+func New(cfg *csconfig.GeoIPUpdateCfg, dataDir string, logger *log.Entry) *Updater {
+	return &Updater{
+		cfg:     cfg,
+		dataDir: dataDir,
+		client:  &http.Client{Timeout: 5 * time.Minute},
+		logger:  logger,
+		reload:  exprhelpers.GeoIPInit,
+	}
+}
+
+// dbAge returns the age of a database, from the build date in its metadata.
+func dbAge(path string, now time.Time) (time.Duration, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	return now.Sub(time.Unix(int64(reader.Metadata.BuildEpoch), 0)), nil
+}
+
+// extractDatabase returns the reader of the .mmdb file of the edition in a tar.gz archive,
+// or the body itself if it's not compressed (mirror serving the database file).
+func extractDatabase(body io.Reader, edition string) (io.Reader, error) {
+	buffered := bufio.NewReader(body)
+
+	magic, err := buffered.Peek(2)
+	if err != nil {
+		return nil, fmt.Errorf("reading the response: %w", err)
+	}
+
+	if !bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		return buffered, nil
+	}
+
+	gz, err := gzip.NewReader(buffered)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := tar.NewReader(gz)
+
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s.mmdb not found in the archive", edition)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("reading the archive: %w", err)
+		}
+
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == edition+".mmdb" {
+			return archive, nil
+		}
+	}
+}
+
+// download replaces the database of an edition. The new file is checked before it's renamed over
+// the old one, which stays valid for the readers still using it.
+func (u *Updater) download(ctx context.Context, edition string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(u.cfg.URL, edition), http.NoBody)
+	if err != nil {
+		return err
+	}
+
+	req.SetBasicAuth(u.cfg.AccountID, u.cfg.LicenseKey)
+	req.Header.Set("User-Agent", useragent.Default())
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad HTTP code %d", resp.StatusCode)
+	}
+
+	db, err := extractDatabase(io.LimitReader(resp.Body, maxDownloadSize), edition)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(u.dataDir, edition+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, db); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", tmp.Name(), err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	reader, err := maxminddb.Open(tmp.Name())
+	if err != nil {
+		return fmt.Errorf("invalid database: %w", err)
+	}
+
+	dbType := reader.Metadata.DatabaseType
+	reader.Close()
+
+	if dbType != edition {
+		return fmt.Errorf("unexpected database type %q", dbType)
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(u.dataDir, edition+".mmdb"))
+}
+
+// Update downloads the databases that are missing or older than max_age, and reloads them.
+// It returns true if a database was replaced.
+func (u *Updater) Update(ctx context.Context) (bool, error) {
+	updated := false
+
+	var errs []error
+
+	for _, edition := range editions {
+		path := filepath.Join(u.dataDir, edition+".mmdb")
+
+		age, err := dbAge(path, time.Now())
+
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			u.logger.Infof("%s is missing, downloading it", edition)
+		case err != nil:
+			u.logger.Warningf("%s can't be read, downloading it again: %s", edition, err)
+		case age < *u.cfg.MaxAge:
+			u.logger.Debugf("%s is up to date (%s old)", edition, age.Round(time.Hour))
+			continue
+		default:
+			u.logger.Infof("%s is %s old, downloading a new version", edition, age.Round(time.Hour))
+		}
+
+		if err := u.download(ctx, edition); err != nil {
+			errs = append(errs, fmt.Errorf("downloading %s: %w", edition, err))
+			continue
+		}
+
+		updated = true
+	}
+
+	if updated {
+		if err := u.reload(u.dataDir); err != nil {
+			errs = append(errs, fmt.Errorf("reloading the databases: %w", err))
+		} else {
+			u.logger.Info("GeoIP databases updated")
+		}
+	}
+
+	return updated, errors.Join(errs...)
+}
+
+// Run checks the databases every refresh_interval, until the context is canceled.
+func (u *Updater) Run(ctx context.Context) {
+	ticker := time.NewTicker(*u.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := u.Update(ctx); err != nil {
+			u.logger.Errorf("unable to update the GeoIP databases: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package geoipupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+)
+
+const testdataDir = "../parser/testdata"
+
+// archive builds a tar.gz like the ones of the MaxMind API.
+func archive(t *testing.T, edition string) []byte {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join(testdataDir, edition+".mmdb"))
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: edition + "_20261017/LICENSE.txt", Mode: 0o644, Size: 3, Typeflag: tar.TypeReg}))
+	_, err = tw.Write([]byte("foo"))
+	require.NoError(t, err)
+
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: edition + "_20261017/" + edition + ".mmdb", Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+	_, err = tw.Write(content)
+	require.NoError(t, err)
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+func newTestUpdater(t *testing.T, url string) (*Updater, *atomic.Int32) {
+	t.Helper()
+
+	cfg := &csconfig.GeoIPUpdateCfg{AccountID: "42", LicenseKey: "secret", URL: url}
+	require.NoError(t, cfg.Load())
+
+	u := New(cfg, t.TempDir(), log.NewEntry(log.StandardLogger()))
+
+	reloads := &atomic.Int32{}
+	u.reload = func(string) error {
+		reloads.Add(1)
+		return nil
+	}
+
+	return u, reloads
+}
+
+func TestUpdate(t *testing.T) {
+	downloads := &atomic.Int32{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "42" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		edition := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")[0]
+		downloads.Add(1)
+		w.Write(archive(t, edition))
+	}))
+	defer server.Close()
+
+	u, reloads := newTestUpdater(t, server.URL+"/%s/download?suffix=tar.gz")
+
+	// missing: both are downloaded
+	updated, err := u.Update(t.Context())
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, int32(2), downloads.Load())
+	assert.Equal(t, int32(1), reloads.Load())
+
+	for _, edition := range editions {
+		age, err := dbAge(filepath.Join(u.dataDir, edition+".mmdb"), time.Now())
+		require.NoError(t, err)
+		assert.Greater(t, age, 24*time.Hour)
+	}
+
+	// up to date: nothing to do
+	*u.cfg.MaxAge = 100 * 365 * 24 * time.Hour
+
+	updated, err = u.Update(t.Context())
+	require.NoError(t, err)
+	assert.False(t, updated)
+	assert.Equal(t, int32(2), downloads.Load())
+
+	// stale: downloaded again
+	*u.cfg.MaxAge = time.Hour
+
+	updated, err = u.Update(t.Context())
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, int32(4), downloads.Load())
+	assert.Equal(t, int32(2), reloads.Load())
+
+	// bad credentials: the databases are kept
+	u.cfg.LicenseKey = "wrong"
+
+	updated, err = u.Update(t.Context())
+	cstest.RequireErrorContains(t, err, "downloading GeoLite2-City: bad HTTP code 401")
+	assert.False(t, updated)
+
+	_, err = os.Stat(filepath.Join(u.dataDir, "GeoLite2-City.mmdb"))
+	require.NoError(t, err)
+}
+
+func TestUpdateMirror(t *testing.T) {
+	// a mirror serving the database files, and the city database for any edition
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		content, err := os.ReadFile(filepath.Join(testdataDir, "GeoLite2-City.mmdb"))
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	u, reloads := newTestUpdater(t, server.URL+"/%s.mmdb")
+
+	updated, err := u.Update(t.Context())
+	cstest.RequireErrorContains(t, err, `downloading GeoLite2-ASN: unexpected database type "GeoLite2-City"`)
+	assert.True(t, updated)
+	assert.Equal(t, int32(1), reloads.Load())
+
+	_, err = os.Stat(filepath.Join(u.dataDir, "GeoLite2-ASN.mmdb"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// no leftover
+	entries, err := os.ReadDir(u.dataDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "GeoLite2-City.mmdb", entries[0].Name())
+}
@@ -19,6 +19,10 @@ have separate preparation and execution methods.
     addition of commands, handles dependencies between them, and orchestrates their
     execution. ActionPlan also provides a mechanism for interactive confirmation and dry-run.
 
+  - Transaction:
+    Transaction runs an ActionPlan on a staged copy of the hub, and swaps it with the live hub
+    only if the plan succeeded. The replaced state is kept for Rollback().
+
 To perform operations on hub items, create an ActionPlan and add the desired
 Commands to it. Once all commands are added, execute the ActionPlan to perform
 the operations in the correct order, handling dependencies and user confirmations.
@@ -97,9 +97,9 @@ type DataSet struct {
 	Data []enrichment.DataProvider `yaml:"data,omitempty"`
 }
 
-// downloadDataSet downloads all the data files for an item.
-func downloadDataSet(ctx context.Context, dataFolder string, force bool, reader io.Reader) (bool, error) {
-	needReload := false
+// dataSources returns the data files referenced by an item file, which can contain several YAML documents.
+func dataSources(reader io.Reader) ([]enrichment.DataProvider, error) {
+	var ret []enrichment.DataProvider
 
 	dec := yaml.NewDecoder(reader)
 
@@ -111,7 +111,7 @@ func downloadDataSet(ctx context.Context, dataFolder string, force bool, reader
 				break
 			}
 
-			return needReload, fmt.Errorf("while reading file: %w", err)
+			return nil, fmt.Errorf("while reading file: %w", err)
 		}
 
 		for _, dataS := range data.Data {
@@ -119,42 +119,58 @@ func downloadDataSet(ctx context.Context, dataFolder string, force bool, reader
 				continue
 			}
 
-			// twopenny validation
-			if u, err := url.Parse(dataS.SourceURL); err != nil {
-				return false, err
-			} else if u.Scheme == "" {
-				return false, fmt.Errorf("a valid URL was expected (note: local items can download data too): %s", dataS.SourceURL)
-			}
+			ret = append(ret, dataS)
+		}
+	}
 
-			// XXX: check context cancellation
-			destPath, err := cwhub.SafePath(dataFolder, dataS.DestPath)
-			if err != nil {
-				return needReload, err
-			}
+	return ret, nil
+}
 
-			d := downloader.
-				New().
-				WithHTTPClient(cwhub.HubClient).
-				WithMakeDirs(true).
-				ToFile(destPath).
-				CompareContent().
-				BeforeRequest(func(req *http.Request) {
-					fmt.Fprintf(os.Stdout, "downloading %s\n", req.URL)
-				}).
-				WithLogger(log.WithField("url", dataS.SourceURL))
-
-			if !force {
-				d = d.WithLastModified().
-					WithShelfLife(7 * 24 * time.Hour)
-			}
+// downloadDataSet downloads all the data files for an item.
+func downloadDataSet(ctx context.Context, dataFolder string, force bool, reader io.Reader) (bool, error) {
+	needReload := false
 
-			downloaded, err := d.Download(ctx, dataS.SourceURL)
-			if err != nil {
-				return needReload, fmt.Errorf("while getting data: %w", err)
-			}
+	sources, err := dataSources(reader)
+	if err != nil {
+		return needReload, err
+	}
+
+	for _, dataS := range sources {
+		// twopenny validation
+		if u, err := url.Parse(dataS.SourceURL); err != nil {
+			return false, err
+		} else if u.Scheme == "" {
+			return false, fmt.Errorf("a valid URL was expected (note: local items can download data too): %s", dataS.SourceURL)
+		}
+
+		// XXX: check context cancellation
+		destPath, err := cwhub.SafePath(dataFolder, dataS.DestPath)
+		if err != nil {
+			return needReload, err
+		}
 
-			needReload = needReload || downloaded
+		d := downloader.
+			New().
+			WithHTTPClient(cwhub.HubClient).
+			WithMakeDirs(true).
+			ToFile(destPath).
+			CompareContent().
+			BeforeRequest(func(req *http.Request) {
+				fmt.Fprintf(os.Stdout, "downloading %s\n", req.URL)
+			}).
+			WithLogger(log.WithField("url", dataS.SourceURL))
+
+		if !force {
+			d = d.WithLastModified().
+				WithShelfLife(7 * 24 * time.Hour)
 		}
+
+		downloaded, err := d.Download(ctx, dataS.SourceURL)
+		if err != nil {
+			return needReload, fmt.Errorf("while getting data: %w", err)
+		}
+
+		needReload = needReload || downloaded
 	}
 
 	return needReload, nil
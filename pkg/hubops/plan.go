@@ -1,10 +1,12 @@
 package hubops
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"slices"
 	"strings"
@@ -14,6 +16,7 @@ import (
 	"github.com/crowdsecurity/go-cs-lib/slicetools"
 
 	"github.com/crowdsecurity/crowdsec/pkg/cwhub"
+	"github.com/crowdsecurity/crowdsec/pkg/enrichment"
 )
 
 var ErrUserCanceled = errors.New("operation canceled")
@@ -183,6 +186,66 @@ func (p *ActionPlan) compactDescription() string {
 	return sb.String()
 }
 
+// DataFiles returns the data files referenced by the installed items and by the items to download,
+// sorted by destination. The new content of an item is only known in advance if the index embeds
+// it, otherwise the data files of the version on disk are listed.
+func (p *ActionPlan) DataFiles() []enrichment.DataProvider {
+	items := make(map[*cwhub.Item]bool)
+
+	for _, itemType := range cwhub.ItemTypes {
+		for _, item := range p.hub.GetInstalledByType(itemType, false) {
+			items[item] = false
+		}
+	}
+
+	for _, cmd := range p.commands {
+		if dl, ok := cmd.(*DownloadCommand); ok {
+			items[dl.Item] = true
+		}
+	}
+
+	seen := make(map[string]enrichment.DataProvider)
+
+	for item, downloading := range items {
+		var (
+			content []byte
+			err     error
+		)
+
+		switch {
+		case downloading && item.Content != "":
+			content, err = item.EmbeddedContent()
+		case item.State.IsDownloaded():
+			content, err = os.ReadFile(item.State.DownloadPath)
+		case item.State.IsLocal():
+			content, err = os.ReadFile(item.State.LocalPath)
+		default:
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		sources, err := dataSources(bytes.NewReader(content))
+		if err != nil {
+			continue
+		}
+
+		for _, s := range sources {
+			seen[s.DestPath] = s
+		}
+	}
+
+	ret := slices.Collect(maps.Values(seen))
+
+	slices.SortFunc(ret, func(a, b enrichment.DataProvider) int {
+		return strings.Compare(a.DestPath, b.DestPath)
+	})
+
+	return ret
+}
+
 func (p *ActionPlan) Confirm(verbose bool) (bool, error) {
 	fmt.Fprintln(os.Stdout, "The following actions will be performed:\n"+p.Description(verbose))
 
@@ -0,0 +1,468 @@
+package hubops
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/cwhub"
+)
+
+var ErrNoPreviousState = errors.New("no previous state of the hub to roll back to")
+
+const (
+	stagingName  = ".hub-staging"
+	outgoingName = ".hub-outgoing"
+	previousName = ".hub-previous"
+)
+
+// hubState is the location of a copy of the state of the hub: the downloaded items, the
+// install directory of each item type (links and local items) and the data files.
+// The index file is not part of the state.
+//
+// The directories are hidden siblings of the live ones, so they are ignored when the hub is
+// loaded and can be swapped with a rename.
+type hubState struct {
+	root       string // contains hubDir and installDir
+	hubDir     string
+	installDir string
+	dataDir    string
+}
+
+func stateAt(local *csconfig.LocalHubCfg, name string) hubState {
+	root := filepath.Join(filepath.Dir(filepath.Clean(local.HubDir)), name)
+
+	return hubState{
+		root:       root,
+		hubDir:     filepath.Join(root, "hub"),
+		installDir: filepath.Join(root, "install"),
+		dataDir:    filepath.Join(local.InstallDataDir, name),
+	}
+}
+
+func (s hubState) exists() bool {
+	_, err := os.Stat(s.root)
+	return err == nil
+}
+
+func (s hubState) remove() error {
+	return errors.Join(os.RemoveAll(s.root), os.RemoveAll(s.dataDir))
+}
+
+// Transaction applies an action plan to a copy of the hub (the staging directory), and replaces
+// the live hub with it only if all the commands succeeded. The state it replaces is kept, to be
+// restored with Rollback() if the new items don't work as expected.
+type Transaction struct {
+	live    *csconfig.LocalHubCfg
+	staging hubState
+}
+
+// NewTransaction copies the state of the live hub to the staging directory. The items are copied
+// because they are overwritten in place, the data files are hard linked when possible because
+// they are always replaced with a new file.
+func NewTransaction(hub *cwhub.Hub, local *csconfig.LocalHubCfg) (*Transaction, error) {
+	t := &Transaction{
+		live:    local,
+		staging: stateAt(local, stagingName),
+	}
+
+	// leftover of an interrupted upgrade
+	if err := t.staging.remove(); err != nil {
+		return nil, err
+	}
+
+	if err := t.stage(hub); err != nil {
+		return nil, errors.Join(err, t.staging.remove())
+	}
+
+	return t, nil
+}
+
+func (t *Transaction) stage(hub *cwhub.Hub) error {
+	indexFile, _ := filepath.Abs(t.live.HubIndexFile)
+
+	if err := os.MkdirAll(t.staging.hubDir, 0o755); err != nil {
+		return err
+	}
+
+	err := copyTree(t.live.HubDir, t.staging.hubDir, func(src string, _ fs.DirEntry) bool {
+		abs, _ := filepath.Abs(src)
+		return abs == indexFile
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("while copying the hub directory: %w", err)
+	}
+
+	for _, itemType := range cwhub.ItemTypes {
+		src := filepath.Join(t.live.InstallDir, itemType)
+		dst := filepath.Join(t.staging.installDir, itemType)
+
+		if _, err := os.Stat(src); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err := copyTree(src, dst, nil); err != nil {
+			return fmt.Errorf("while copying the %s directory: %w", itemType, err)
+		}
+
+		if err := retargetLinks(dst, t.live.HubDir, t.staging.hubDir); err != nil {
+			return err
+		}
+	}
+
+	for _, dest := range downloadedDataFiles(hub) {
+		src, err := cwhub.SafePath(t.live.InstallDataDir, dest)
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Stat(src); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		dst, err := cwhub.SafePath(t.staging.dataDir, dest)
+		if err != nil {
+			return err
+		}
+
+		if err := linkOrCopy(src, dst); err != nil {
+			return fmt.Errorf("while copying data file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Hub returns the staged hub, to build and run an action plan on.
+func (t *Transaction) Hub(logger *logrus.Logger) (*cwhub.Hub, error) {
+	hub, err := cwhub.NewHub(&csconfig.LocalHubCfg{
+		HubIndexFile:   t.live.HubIndexFile,
+		HubDir:         t.staging.hubDir,
+		InstallDir:     t.staging.installDir,
+		InstallDataDir: t.staging.dataDir,
+	}, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := hub.Load(); err != nil {
+		return nil, err
+	}
+
+	return hub, nil
+}
+
+// Commit replaces the live hub with the staged one, and keeps the replaced state for Rollback().
+func (t *Transaction) Commit() error {
+	return swapState(t.live, t.staging)
+}
+
+// Abort discards the staged hub. It's a no-op after Commit().
+func (t *Transaction) Abort() error {
+	return t.staging.remove()
+}
+
+// Rollback restores the state of the hub before the last upgrade. The current state
+// replaces it, so a second rollback applies the upgrade again.
+func Rollback(local *csconfig.LocalHubCfg) error {
+	previous := stateAt(local, previousName)
+
+	if !previous.exists() {
+		return ErrNoPreviousState
+	}
+
+	return swapState(local, previous)
+}
+
+// downloadedDataFiles returns the destination of the data files of all the downloaded items.
+func downloadedDataFiles(hub *cwhub.Hub) []string {
+	var ret []string
+
+	for _, itemType := range cwhub.ItemTypes {
+		for _, item := range hub.GetItemsByType(itemType, false) {
+			if !item.State.IsDownloaded() {
+				continue
+			}
+
+			f, err := os.Open(item.State.DownloadPath)
+			if err != nil {
+				continue
+			}
+
+			sources, err := dataSources(f)
+			f.Close()
+
+			if err != nil {
+				continue
+			}
+
+			for _, s := range sources {
+				ret = append(ret, s.DestPath)
+			}
+		}
+	}
+
+	return ret
+}
+
+// swapState moves the live state to the outgoing directory and the incoming state in place.
+// The moves are undone if one of them fails. On success, the outgoing state becomes the
+// previous state.
+func swapState(local *csconfig.LocalHubCfg, incoming hubState) error {
+	outgoing := stateAt(local, outgoingName)
+
+	if err := outgoing.remove(); err != nil {
+		return err
+	}
+
+	m := &mover{}
+
+	if err := m.swap(local, incoming, outgoing); err != nil {
+		if undoErr := m.undo(); undoErr != nil {
+			err = fmt.Errorf("%w (the hub may be in an inconsistent state: %w)", err, undoErr)
+		}
+
+		return errors.Join(err, outgoing.remove())
+	}
+
+	previous := stateAt(local, previousName)
+
+	// incoming and previous are the same in case of rollback
+	if err := errors.Join(incoming.remove(), previous.remove()); err != nil {
+		return err
+	}
+
+	if err := os.Rename(outgoing.root, previous.root); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(outgoing.dataDir); err == nil {
+		return os.Rename(outgoing.dataDir, previous.dataDir)
+	}
+
+	return nil
+}
+
+// mover renames files and directories, and remembers them to be able to undo.
+type mover struct {
+	done [][2]string
+}
+
+func (m *mover) move(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		return err
+	}
+
+	m.done = append(m.done, [2]string{src, dst})
+
+	return nil
+}
+
+func (m *mover) undo() error {
+	var errs []error
+
+	for i := len(m.done) - 1; i >= 0; i-- {
+		if err := os.Rename(m.done[i][1], m.done[i][0]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	m.done = nil
+
+	return errors.Join(errs...)
+}
+
+func (m *mover) swap(local *csconfig.LocalHubCfg, incoming hubState, outgoing hubState) error {
+	if _, err := os.Lstat(local.HubDir); err == nil {
+		if err := m.move(local.HubDir, outgoing.hubDir); err != nil {
+			return err
+		}
+	}
+
+	if err := m.move(incoming.hubDir, local.HubDir); err != nil {
+		return err
+	}
+
+	// the index stays in place
+	if rel, err := filepath.Rel(local.HubDir, local.HubIndexFile); err == nil && !strings.HasPrefix(rel, "..") {
+		if _, err := os.Stat(filepath.Join(outgoing.hubDir, rel)); err == nil {
+			if err := m.move(filepath.Join(outgoing.hubDir, rel), local.HubIndexFile); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, itemType := range cwhub.ItemTypes {
+		live := filepath.Join(local.InstallDir, itemType)
+		in := filepath.Join(incoming.installDir, itemType)
+
+		if _, err := os.Lstat(live); err == nil {
+			if err := m.move(live, filepath.Join(outgoing.installDir, itemType)); err != nil {
+				return err
+			}
+		}
+
+		if _, err := os.Lstat(in); err == nil {
+			if err := retargetLinks(in, incoming.hubDir, local.HubDir); err != nil {
+				return err
+			}
+
+			if err := m.move(in, live); err != nil {
+				return err
+			}
+		}
+	}
+
+	return filepath.WalkDir(incoming.dataDir, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == incoming.dataDir {
+			return nil
+		}
+
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(incoming.dataDir, path)
+		if err != nil {
+			return err
+		}
+
+		live := filepath.Join(local.InstallDataDir, rel)
+
+		if _, err := os.Lstat(live); err == nil {
+			if err := m.move(live, filepath.Join(outgoing.dataDir, rel)); err != nil {
+				return err
+			}
+		}
+
+		return m.move(path, live)
+	})
+}
+
+// retargetLinks changes the symlinks in dir that point inside oldDir to point inside newDir.
+func retargetLinks(dir string, oldDir string, newDir string) error {
+	oldDir, err := filepath.Abs(oldDir)
+	if err != nil {
+		return err
+	}
+
+	newDir, err = filepath.Abs(newDir)
+	if err != nil {
+		return err
+	}
+
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.Type()&fs.ModeSymlink == 0 {
+			return err
+		}
+
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+
+		// the install links are absolute
+		if !filepath.IsAbs(target) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(oldDir, target)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return nil //nolint:nilerr  // not a link to the hub, leave it alone
+		}
+
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+
+		return os.Symlink(filepath.Join(newDir, rel), path)
+	})
+}
+
+// copyTree copies a directory, preserving the symlinks, modes and modification times.
+func copyTree(src string, dst string, skip func(string, fs.DirEntry) bool) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if skip != nil && skip(path, d) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target)
+		}
+	})
+}
+
+// linkOrCopy creates a hard link to src, or a copy if it's on another filesystem.
+func linkOrCopy(src string, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+
+	return copyFile(src, dst)
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	st, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, st.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	// the data files are not downloaded again if they are recent enough
+	return os.Chtimes(dst, st.ModTime(), st.ModTime())
+}
@@ -0,0 +1,152 @@
+package hubops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/cwhub"
+)
+
+const testIndex = `{
+  "scenarios": {
+    "crowdsecurity/foo": {
+      "path": "scenarios/crowdsecurity/foo.yaml",
+      "version": "0.2",
+      "versions": {"0.1": {"digest": "x"}, "0.2": {"digest": "y"}}
+    }
+  }
+}`
+
+// testHub creates a hub with one installed scenario that has a data file.
+func testHub(t *testing.T) *csconfig.LocalHubCfg {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+
+	local := &csconfig.LocalHubCfg{
+		HubDir:         filepath.Join(tmpDir, "crowdsec", "hub"),
+		HubIndexFile:   filepath.Join(tmpDir, "crowdsec", "hub", ".index.json"),
+		InstallDir:     filepath.Join(tmpDir, "crowdsec"),
+		InstallDataDir: filepath.Join(tmpDir, "data"),
+	}
+
+	item := filepath.Join(local.HubDir, "scenarios", "crowdsecurity", "foo.yaml")
+	link := filepath.Join(local.InstallDir, "scenarios", "foo.yaml")
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(item), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Dir(link), 0o755))
+	require.NoError(t, os.MkdirAll(local.InstallDataDir, 0o755))
+	require.NoError(t, os.WriteFile(local.HubIndexFile, []byte(testIndex), 0o644))
+	require.NoError(t, os.WriteFile(item, []byte("name: v1\ndata:\n  - source_url: https://example.com/foo.txt\n    dest_file: foo.txt\n"), 0o644))
+	require.NoError(t, os.Symlink(item, link))
+	require.NoError(t, os.WriteFile(filepath.Join(local.InstallDataDir, "foo.txt"), []byte("data v1"), 0o644))
+	// not part of the hub
+	require.NoError(t, os.WriteFile(filepath.Join(local.InstallDataDir, "crowdsec.db"), []byte("db"), 0o644))
+
+	return local
+}
+
+func loadHub(t *testing.T, local *csconfig.LocalHubCfg) *cwhub.Hub {
+	t.Helper()
+
+	hub, err := cwhub.NewHub(local, nil)
+	require.NoError(t, err)
+	require.NoError(t, hub.Load())
+
+	return hub
+}
+
+func assertFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(b))
+}
+
+func TestTransaction(t *testing.T) {
+	local := testHub(t)
+	hub := loadHub(t, local)
+
+	item := filepath.Join(local.HubDir, "scenarios", "crowdsecurity", "foo.yaml")
+	dataFile := filepath.Join(local.InstallDataDir, "foo.txt")
+
+	tx, err := NewTransaction(hub, local)
+	require.NoError(t, err)
+
+	staged, err := tx.Hub(nil)
+	require.NoError(t, err)
+
+	stagedItem := staged.GetItem(cwhub.SCENARIOS, "crowdsecurity/foo")
+	require.NotNil(t, stagedItem)
+	assert.True(t, stagedItem.State.IsInstalled())
+	assert.False(t, stagedItem.State.IsLocal())
+	assertFile(t, filepath.Join(staged.GetDataDir(), "foo.txt"), "data v1")
+
+	// what an upgrade would do to the staged hub
+	require.NoError(t, os.WriteFile(stagedItem.State.DownloadPath, []byte("v2"), 0o644))
+	require.NoError(t, os.Remove(filepath.Join(tx.staging.dataDir, "foo.txt")))
+	require.NoError(t, os.WriteFile(filepath.Join(tx.staging.dataDir, "foo.txt"), []byte("data v2"), 0o644))
+
+	// nothing changed in the live hub yet
+	assertFile(t, item, "name: v1\ndata:\n  - source_url: https://example.com/foo.txt\n    dest_file: foo.txt\n")
+	assertFile(t, dataFile, "data v1")
+
+	require.NoError(t, tx.Commit())
+	require.NoError(t, tx.Abort())
+
+	assertFile(t, item, "v2")
+	assertFile(t, dataFile, "data v2")
+	assertFile(t, filepath.Join(local.InstallDataDir, "crowdsec.db"), "db")
+	assertFile(t, local.HubIndexFile, testIndex)
+	assert.NoDirExists(t, stateAt(local, stagingName).root)
+	assert.NoDirExists(t, stateAt(local, stagingName).dataDir)
+
+	// the link points to the live hub, not the staging directory
+	target, err := os.Readlink(filepath.Join(local.InstallDir, "scenarios", "foo.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, item, target)
+
+	live := loadHub(t, local)
+	assert.True(t, live.GetItem(cwhub.SCENARIOS, "crowdsecurity/foo").State.IsInstalled())
+
+	require.NoError(t, Rollback(local))
+
+	assertFile(t, item, "name: v1\ndata:\n  - source_url: https://example.com/foo.txt\n    dest_file: foo.txt\n")
+	assertFile(t, dataFile, "data v1")
+	assertFile(t, local.HubIndexFile, testIndex)
+
+	live = loadHub(t, local)
+	assert.True(t, live.GetItem(cwhub.SCENARIOS, "crowdsecurity/foo").State.IsInstalled())
+
+	// rolling back again applies the upgrade
+	require.NoError(t, Rollback(local))
+	assertFile(t, item, "v2")
+	assertFile(t, dataFile, "data v2")
+}
+
+func TestTransactionAbort(t *testing.T) {
+	local := testHub(t)
+	hub := loadHub(t, local)
+
+	require.ErrorIs(t, Rollback(local), ErrNoPreviousState)
+
+	tx, err := NewTransaction(hub, local)
+	require.NoError(t, err)
+
+	staged, err := tx.Hub(nil)
+	require.NoError(t, err)
+
+	// a failed upgrade: the staged hub is discarded
+	require.NoError(t, os.Remove(staged.GetItem(cwhub.SCENARIOS, "crowdsecurity/foo").State.LocalPath))
+	require.NoError(t, tx.Abort())
+
+	assert.NoDirExists(t, stateAt(local, stagingName).root)
+	assert.True(t, loadHub(t, local).GetItem(cwhub.SCENARIOS, "crowdsecurity/foo").State.IsInstalled())
+	require.ErrorIs(t, Rollback(local), ErrNoPreviousState)
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
@@ -226,3 +228,45 @@ func (h *HubTest) LoadAllTests() error {
 
 	return nil
 }
+
+// ParseShard parses a shard specification in the form "i/n", where i is between 1 and n.
+func ParseShard(spec string) (int, int, error) {
+	idx, tot, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid shard %q, expected i/n", spec)
+	}
+
+	index, err := strconv.Atoi(idx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid shard %q, expected i/n", spec)
+	}
+
+	total, err := strconv.Atoi(tot)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid shard %q, expected i/n", spec)
+	}
+
+	if total < 1 || index < 1 || index > total {
+		return 0, 0, fmt.Errorf("invalid shard %q, i must be between 1 and n", spec)
+	}
+
+	return index, total, nil
+}
+
+// Shard keeps only the tests that belong to the given shard (1-based), to split the tests across
+// several runners. The tests are distributed by name, so every runner gets the same partition.
+func (h *HubTest) Shard(index int, total int) {
+	sorted := slices.SortedFunc(slices.Values(h.Tests), func(a, b *HubTestItem) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	tests := make([]*HubTestItem, 0, len(sorted)/total+1)
+
+	for i, test := range sorted {
+		if i%total == index-1 {
+			tests = append(tests, test)
+		}
+	}
+
+	h.Tests = tests
+}
@@ -0,0 +1,65 @@
+package hubtest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestParseShard(t *testing.T) {
+	tests := []struct {
+		spec          string
+		expectedIndex int
+		expectedTotal int
+		expectedErr   string
+	}{
+		{spec: "1/1", expectedIndex: 1, expectedTotal: 1},
+		{spec: "2/4", expectedIndex: 2, expectedTotal: 4},
+		{spec: "4/4", expectedIndex: 4, expectedTotal: 4},
+		{spec: "0/4", expectedErr: `invalid shard "0/4", i must be between 1 and n`},
+		{spec: "5/4", expectedErr: `invalid shard "5/4", i must be between 1 and n`},
+		{spec: "1/0", expectedErr: `invalid shard "1/0", i must be between 1 and n`},
+		{spec: "1", expectedErr: `invalid shard "1", expected i/n`},
+		{spec: "a/2", expectedErr: `invalid shard "a/2", expected i/n`},
+		{spec: "1/b", expectedErr: `invalid shard "1/b", expected i/n`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.spec, func(t *testing.T) {
+			index, total, err := ParseShard(tc.spec)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+			assert.Equal(t, tc.expectedIndex, index)
+			assert.Equal(t, tc.expectedTotal, total)
+		})
+	}
+}
+
+func TestShard(t *testing.T) {
+	names := []string{"e", "a", "d", "b", "c"}
+
+	shards := [][]string{}
+	seen := []string{}
+
+	for i := 1; i <= 2; i++ {
+		h := &HubTest{}
+		for _, name := range names {
+			h.Tests = append(h.Tests, &HubTestItem{Name: name})
+		}
+
+		h.Shard(i, 2)
+
+		shard := []string{}
+		for _, test := range h.Tests {
+			shard = append(shard, test.Name)
+		}
+
+		shards = append(shards, shard)
+		seen = append(seen, shard...)
+	}
+
+	require.Equal(t, [][]string{{"a", "c", "e"}, {"b", "d"}}, shards)
+	assert.ElementsMatch(t, names, seen)
+}
@@ -100,7 +100,7 @@ func (e *BayesianEvent) bayesianUpdate(p *BayesianProcessor, msg pipeline.Event,
 	}
 
 	l.logger.Debugf("running condition expression: %s", e.rawCondition.ConditionalFilterName)
-	ret, err := exprhelpers.Run(e.conditionalFilterRuntime, map[string]any{"evt": &msg, "queue": l.Queue, "leaky": l}, l.logger, l.Factory.Spec.Debug)
+	ret, err := exprhelpers.Run(e.conditionalFilterRuntime, bucketEnv(l, map[string]any{"evt": &msg, "queue": l.Queue}), l.logger, l.Factory.Spec.Debug)
 	if err != nil {
 		return fmt.Errorf("unable to run conditional filter: %w", err)
 	}
@@ -143,7 +143,7 @@ func compileCondition(filterName string) (*vm.Program, error) {
 	}
 
 	// don't hold lock during compile
-	compiled, err := compile(filterName, map[string]any{"queue": &pipeline.Queue{}, "leaky": &Leaky{}})
+	compiled, err := compile(filterName, bucketEnv(&Leaky{}, map[string]any{"queue": &pipeline.Queue{}}))
 	if err != nil {
 		return nil, fmt.Errorf("bayesian condition compile error: %w", err)
 	}
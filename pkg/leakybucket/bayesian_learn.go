@@ -0,0 +1,281 @@
+package leakybucket
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// BayesianSample is a group of events from the same source (for example the events of a stored
+// alert), labeled as malicious or benign.
+type BayesianSample struct {
+	Events    []pipeline.Event
+	Malicious bool
+}
+
+// BayesianConditionEstimate holds how often a condition was observed for each label, and the
+// likelihoods computed from it.
+type BayesianConditionEstimate struct {
+	Condition       string  `json:"condition"`
+	TrueMalicious   int     `json:"true_malicious"` // malicious samples for which the condition was true
+	TrueBenign      int     `json:"true_benign"`    // benign samples for which the condition was true
+	ProbGivenEvil   float32 `json:"prob_given_evil"`
+	ProbGivenBenign float32 `json:"prob_given_benign"`
+}
+
+// BayesianEstimate holds the prior and likelihoods of a bayesian bucket, learned from samples.
+type BayesianEstimate struct {
+	Scenario   string                      `json:"scenario"`
+	Malicious  int                         `json:"malicious"`
+	Benign     int                         `json:"benign"`
+	Skipped    int                         `json:"skipped"` // samples without any event matching the filter of the bucket
+	Prior      float32                     `json:"prior"`
+	Conditions []BayesianConditionEstimate `json:"conditions"`
+}
+
+// smoothed returns the estimated probability of an outcome seen count times out of total, with
+// Laplace smoothing so that it's never 0 or 1: those would make the posterior stuck forever.
+func smoothed(count int, total int) float32 {
+	return float32(count+1) / float32(total+2)
+}
+
+// sampleConditions pours the events of a sample into a bucket instance, the way the bucket would,
+// and returns for each condition whether it was true after any of the pours.
+// It returns nil if none of the events match the filter.
+func (f *BucketFactory) sampleConditions(sample BayesianSample, conditions []*BayesianEvent) ([]bool, error) {
+	var l *Leaky
+
+	ret := make([]bool, len(conditions))
+
+	for _, evt := range sample.Events {
+		if f.RunTimeFilter != nil {
+			output, err := exprhelpers.Run(f.RunTimeFilter, map[string]any{"evt": &evt}, f.logger, f.Spec.Debug)
+			if err != nil {
+				return nil, fmt.Errorf("unable to run filter: %w", err)
+			}
+
+			if match, ok := output.(bool); !ok || !match {
+				continue
+			}
+		}
+
+		if l == nil {
+			l = NewLeakyFromFactory(f, &evt)
+			l.Mode = pipeline.TIMEMACHINE
+			l.First_ts = evt.Time
+		}
+
+		l.Queue.Add(evt)
+		l.Last_ts = evt.Time
+		l.Total_count++
+
+		for idx, cond := range conditions {
+			if ret[idx] {
+				continue
+			}
+
+			output, err := exprhelpers.Run(cond.conditionalFilterRuntime, bucketEnv(l, map[string]any{"evt": &evt, "queue": l.Queue}), f.logger, f.Spec.Debug)
+			if err != nil {
+				return nil, fmt.Errorf("unable to run condition %s: %w", cond.rawCondition.ConditionalFilterName, err)
+			}
+
+			value, ok := output.(bool)
+			if !ok {
+				return nil, fmt.Errorf("bayesian condition unexpected non-bool return: %T", output)
+			}
+
+			ret[idx] = value
+		}
+	}
+
+	if l == nil {
+		return nil, nil
+	}
+
+	return ret, nil
+}
+
+// LearnBayesian estimates the prior of a bayesian bucket and the likelihoods of its conditions from
+// labeled samples. A condition is considered observed for a sample if it was true at any point while
+// its events were poured. The threshold is left to the user: it's a trade-off between false positives
+// and detection, not something the samples can tell.
+func LearnBayesian(f *BucketFactory, samples []BayesianSample) (*BayesianEstimate, error) {
+	if f.Spec.Type != "bayesian" {
+		return nil, fmt.Errorf("scenario %s is not a bayesian bucket", f.Spec.Name)
+	}
+
+	conditions := make([]*BayesianEvent, len(f.Spec.BayesianConditions))
+
+	for idx, bcond := range f.Spec.BayesianConditions {
+		prog, err := compileCondition(bcond.ConditionalFilterName)
+		if err != nil {
+			return nil, err
+		}
+
+		conditions[idx] = &BayesianEvent{
+			rawCondition:             bcond,
+			conditionalFilterRuntime: prog,
+		}
+	}
+
+	est := &BayesianEstimate{
+		Scenario:   f.Spec.Name,
+		Conditions: make([]BayesianConditionEstimate, len(conditions)),
+	}
+
+	for idx, cond := range conditions {
+		est.Conditions[idx].Condition = cond.rawCondition.ConditionalFilterName
+	}
+
+	for _, sample := range samples {
+		observed, err := f.sampleConditions(sample, conditions)
+		if err != nil {
+			return nil, err
+		}
+
+		if observed == nil {
+			est.Skipped++
+			continue
+		}
+
+		if sample.Malicious {
+			est.Malicious++
+		} else {
+			est.Benign++
+		}
+
+		for idx, value := range observed {
+			switch {
+			case !value:
+			case sample.Malicious:
+				est.Conditions[idx].TrueMalicious++
+			default:
+				est.Conditions[idx].TrueBenign++
+			}
+		}
+	}
+
+	if est.Malicious == 0 || est.Benign == 0 {
+		return nil, fmt.Errorf("scenario %s: both malicious and benign samples are required, got %d malicious and %d benign", f.Spec.Name, est.Malicious, est.Benign)
+	}
+
+	est.Prior = smoothed(est.Malicious, est.Malicious+est.Benign)
+
+	for idx := range est.Conditions {
+		c := &est.Conditions[idx]
+		c.ProbGivenEvil = smoothed(c.TrueMalicious, est.Malicious)
+		c.ProbGivenBenign = smoothed(c.TrueBenign, est.Benign)
+	}
+
+	return est, nil
+}
+
+func formatProbability(p float32) string {
+	return strconv.FormatFloat(float64(p), 'g', 4, 32)
+}
+
+// setMappingValue replaces the value of a key in a yaml mapping, or appends it.
+func setMappingValue(mapping *yaml.Node, key string, value string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: value}
+			return
+		}
+	}
+
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: value})
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// TuneBayesianScenario rewrites a scenario file with the learned prior and likelihoods of its bayesian
+// buckets, by name. Comments and the other fields are kept.
+func TuneBayesianScenario(content []byte, estimates []*BayesianEstimate) ([]byte, error) {
+	byName := make(map[string]*BayesianEstimate, len(estimates))
+	for _, est := range estimates {
+		byName[est.Scenario] = est
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+
+	var (
+		out   bytes.Buffer
+		tuned int
+	)
+
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+
+	for {
+		doc := yaml.Node{}
+
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse scenario: %w", err)
+		}
+
+		if len(doc.Content) == 1 && doc.Content[0].Kind == yaml.MappingNode {
+			spec := doc.Content[0]
+
+			name := mappingValue(spec, "name")
+			if name != nil && byName[name.Value] != nil {
+				est := byName[name.Value]
+
+				setMappingValue(spec, "bayesian_prior", formatProbability(est.Prior))
+
+				if conds := mappingValue(spec, "bayesian_conditions"); conds != nil && conds.Kind == yaml.SequenceNode {
+					for idx, cond := range conds.Content {
+						if idx >= len(est.Conditions) || cond.Kind != yaml.MappingNode {
+							break
+						}
+
+						// the estimate was built from the same file, in the same order
+						if expr := mappingValue(cond, "condition"); expr == nil || expr.Value != est.Conditions[idx].Condition {
+							return nil, fmt.Errorf("scenario %s: condition %d does not match the learned one", est.Scenario, idx)
+						}
+
+						setMappingValue(cond, "prob_given_evil", formatProbability(est.Conditions[idx].ProbGivenEvil))
+						setMappingValue(cond, "prob_given_benign", formatProbability(est.Conditions[idx].ProbGivenBenign))
+					}
+				}
+
+				tuned++
+			}
+		}
+
+		if err := enc.Encode(&doc); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	if tuned != len(byName) {
+		return nil, fmt.Errorf("found %d of the %d bayesian scenarios in the file", tuned, len(byName))
+	}
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,148 @@
+package leakybucket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+const bayesianScenario = `# learned from the alerts of crowdsecurity/http-collect
+type: bayesian
+name: test/learn-bayesian
+description: "bayesian bucket"
+filter: "evt.Meta.log_type == 'http_access-log'"
+groupby: evt.Meta.source_ip
+bayesian_prior: 0.5
+bayesian_threshold: 0.8
+bayesian_conditions:
+  - condition: any(queue.Queue, {.Meta.http_path == "/admin"})
+    prob_given_evil: 0.5
+    prob_given_benign: 0.5
+  - condition: evt.Meta.http_status == "404"
+    prob_given_evil: 0.5
+    prob_given_benign: 0.5
+    guillotine: true
+leakspeed: 30s
+capacity: -1
+labels:
+  type: scan
+---
+type: leaky
+name: test/other
+description: "leaky bucket"
+filter: "true"
+capacity: 5
+leakspeed: 10s
+`
+
+func TestLearnBayesian(t *testing.T) {
+	f := &BucketFactory{
+		Spec: BucketSpec{
+			Name:              "test/learn-bayesian",
+			Description:       "bayesian bucket",
+			Type:              "bayesian",
+			Filter:            "evt.Meta.log_type == 'http_access-log'",
+			GroupBy:           "evt.Meta.source_ip",
+			LeakSpeed:         "30s",
+			Capacity:          -1,
+			BayesianPrior:     0.5,
+			BayesianThreshold: 0.8,
+			BayesianConditions: []RawBayesianCondition{
+				{ConditionalFilterName: `any(queue.Queue, {.Meta.http_path == "/admin"})`, ProbGivenEvil: 0.5, ProbGivenBenign: 0.5},
+				{ConditionalFilterName: `evt.Meta.http_status == "404"`, ProbGivenEvil: 0.5, ProbGivenBenign: 0.5, Guillotine: true},
+			},
+		},
+	}
+
+	require.NoError(t, f.LoadBucket())
+	require.NoError(t, f.Validate())
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	event := func(path string, status string) pipeline.Event {
+		start = start.Add(time.Second)
+
+		return pipeline.Event{
+			Type: pipeline.LOG,
+			Time: start,
+			Meta: map[string]string{"log_type": "http_access-log", "http_path": path, "http_status": status},
+		}
+	}
+
+	samples := []BayesianSample{
+		// the 404 comes before /admin, the queue condition is true on the last pour only
+		{Malicious: true, Events: []pipeline.Event{event("/wp-login.php", "404"), event("/admin", "200")}},
+		{Malicious: true, Events: []pipeline.Event{event("/admin", "403")}},
+		{Malicious: true, Events: []pipeline.Event{event("/.env", "404")}},
+		{Malicious: false, Events: []pipeline.Event{event("/", "200"), event("/favicon.ico", "404")}},
+		{Malicious: false, Events: []pipeline.Event{event("/", "200")}},
+		// no event matches the filter
+		{Malicious: false, Events: []pipeline.Event{{Type: pipeline.LOG, Meta: map[string]string{"log_type": "ssh_failed-auth"}}}},
+	}
+
+	est, err := LearnBayesian(f, samples)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, est.Malicious)
+	assert.Equal(t, 2, est.Benign)
+	assert.Equal(t, 1, est.Skipped)
+	assert.InDelta(t, 4.0/7, est.Prior, 1e-6)
+
+	require.Len(t, est.Conditions, 2)
+
+	assert.Equal(t, 2, est.Conditions[0].TrueMalicious)
+	assert.Equal(t, 0, est.Conditions[0].TrueBenign)
+	assert.InDelta(t, 3.0/5, est.Conditions[0].ProbGivenEvil, 1e-6)
+	assert.InDelta(t, 1.0/4, est.Conditions[0].ProbGivenBenign, 1e-6)
+
+	assert.Equal(t, 2, est.Conditions[1].TrueMalicious)
+	assert.Equal(t, 1, est.Conditions[1].TrueBenign)
+	assert.InDelta(t, 3.0/5, est.Conditions[1].ProbGivenEvil, 1e-6)
+	assert.InDelta(t, 2.0/4, est.Conditions[1].ProbGivenBenign, 1e-6)
+
+	_, err = LearnBayesian(f, samples[:3])
+	cstest.RequireErrorContains(t, err, "scenario test/learn-bayesian: both malicious and benign samples are required, got 3 malicious and 0 benign")
+
+	tuned, err := TuneBayesianScenario([]byte(bayesianScenario), []*BayesianEstimate{est})
+	require.NoError(t, err)
+
+	expected := `# learned from the alerts of crowdsecurity/http-collect
+type: bayesian
+name: test/learn-bayesian
+description: "bayesian bucket"
+filter: "evt.Meta.log_type == 'http_access-log'"
+groupby: evt.Meta.source_ip
+bayesian_prior: 0.5714
+bayesian_threshold: 0.8
+bayesian_conditions:
+  - condition: any(queue.Queue, {.Meta.http_path == "/admin"})
+    prob_given_evil: 0.6
+    prob_given_benign: 0.25
+  - condition: evt.Meta.http_status == "404"
+    prob_given_evil: 0.6
+    prob_given_benign: 0.5
+    guillotine: true
+leakspeed: 30s
+capacity: -1
+labels:
+  type: scan
+---
+type: leaky
+name: test/other
+description: "leaky bucket"
+filter: "true"
+capacity: 5
+leakspeed: 10s
+`
+	assert.Equal(t, expected, string(tuned))
+
+	est.Scenario = "test/missing"
+	_, err = TuneBayesianScenario([]byte(bayesianScenario), []*BayesianEstimate{est})
+	cstest.RequireErrorContains(t, err, "found 0 of the 1 bayesian scenarios in the file")
+}
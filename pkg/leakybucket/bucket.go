@@ -34,7 +34,9 @@ type Leaky struct {
 	// shared for all buckets (the idea is to kill this afterward)
 	AllOut chan pipeline.Event `json:"-"`
 	// the unique identifier of the bucket (a hash)
-	Mapkey              string
+	Mapkey string
+	// the result of the groupby expression, used to build Mapkey
+	GroupBy             string
 	ready               chan struct{} // closed when LeakRoutine is ready
 	readyOnce           sync.Once     // use to prevent double close
 	done                chan struct{} // closed when LeakRoutine has stopped processing
@@ -46,6 +48,8 @@ type Leaky struct {
 	Ovflw_ts            time.Time
 	Total_count         int
 	Factory             *BucketFactory
+	Capacity            int           // capacity of this instance, from capacity_expr if set
+	LeakSpeed           time.Duration // leakspeed of this instance, from leakspeed_expr if set
 	Duration            time.Duration
 	Pour                func(*Leaky, pourGate, pipeline.Event) `json:"-"`
 	timedOverflow       bool
@@ -53,51 +57,38 @@ type Leaky struct {
 	logger              *log.Entry
 	mutex               *sync.Mutex // used only for TIMEMACHINE mode to allow garbage collection without races
 	cancel              context.CancelFunc
+	restoredTTL         time.Duration // time left before the expiration of a bucket restored from the state file
 }
 
 // NewLeakyFromFactory creates a new leaky bucket from a BucketFactory
 // Events created by the bucket (overflow, bucket empty) are sent to a chan defined by BucketFactory
 // The leaky bucket implementation is based on rate limiter (see https://godoc.org/golang.org/x/time/rate)
 // There's a trick to have an event said when the bucket gets empty to allow its destruction
-func NewLeakyFromFactory(f *BucketFactory) *Leaky {
+// The event that creates the bucket, if any, is used to evaluate capacity_expr and leakspeed_expr
+func NewLeakyFromFactory(f *BucketFactory, evt *pipeline.Event) *Leaky {
 	f.logger.Tracef("Instantiating live bucket %s", f.Spec.Name)
 
-	var limiter rate.RateLimiter
-	// golang rate limiter. It's mainly intended for http rate limiter
-	Qsize := f.Spec.Capacity
-	if f.Spec.CacheSize > 0 {
-		// cache is smaller than actual capacity
-		if f.Spec.CacheSize <= f.Spec.Capacity {
-			Qsize = f.Spec.CacheSize
-			// bucket might be counter (infinite size), allow cache limitation
-		} else if f.Spec.Capacity == -1 {
-			Qsize = f.Spec.CacheSize
-		}
-	}
-	if f.Spec.Capacity == -1 {
-		// In this case we allow all events to pass.
-		// maybe in the future we could avoid using a limiter
-		limiter = &rate.AlwaysFull{}
-	} else {
-		limiter = rate.NewLimiter(rate.Every(f.leakspeed), f.Spec.Capacity)
-	}
+	capacity, leakspeed := f.instanceLimits(evt)
+
 	metrics.BucketsInstantiation.With(prometheus.Labels{"name": f.Spec.Name}).Inc()
 
 	// create the leaky bucket per se
 	l := &Leaky{
-		Limiter: limiter,
-		Uuid:    seed.Generate(),
-		Queue:   pipeline.NewQueue(Qsize),
-		Out:     make(chan *pipeline.Queue, 1),
-		Suicide: make(chan bool, 1),
-		AllOut:  f.ret,
-		Factory: f,
-		Pour:    Pour,
-		Mode:    pipeline.LIVE,
-		mutex:   &sync.Mutex{},
+		Limiter:   newLimiter(capacity, leakspeed),
+		Uuid:      seed.Generate(),
+		Queue:     pipeline.NewQueue(f.queueSize(capacity)),
+		Out:       make(chan *pipeline.Queue, 1),
+		Suicide:   make(chan bool, 1),
+		AllOut:    f.ret,
+		Factory:   f,
+		Capacity:  capacity,
+		LeakSpeed: leakspeed,
+		Pour:      Pour,
+		Mode:      pipeline.LIVE,
+		mutex:     &sync.Mutex{},
 	}
-	if f.Spec.Capacity > 0 && f.leakspeed != time.Duration(0) {
-		l.Duration = time.Duration(f.Spec.Capacity+1) * f.leakspeed
+	if capacity > 0 && leakspeed != time.Duration(0) {
+		l.Duration = time.Duration(capacity+1) * leakspeed
 	}
 	if f.duration != time.Duration(0) {
 		l.Duration = f.duration
@@ -106,15 +97,42 @@ func NewLeakyFromFactory(f *BucketFactory) *Leaky {
 
 	if f.Spec.Type == "conditional" {
 		l.conditionalOverflow = true
-		l.Duration = f.leakspeed
+		l.Duration = leakspeed
 	}
 
 	if f.Spec.Type == "bayesian" {
-		l.Duration = f.leakspeed
+		l.Duration = leakspeed
 	}
 	return l
 }
 
+// queueSize is the number of events kept by a bucket of the given capacity
+func (f *BucketFactory) queueSize(capacity int) int {
+	if f.Spec.CacheSize > 0 {
+		// cache is smaller than actual capacity
+		if f.Spec.CacheSize <= capacity {
+			return f.Spec.CacheSize
+		}
+		// bucket might be counter (infinite size), allow cache limitation
+		if capacity == -1 {
+			return f.Spec.CacheSize
+		}
+	}
+
+	return capacity
+}
+
+// golang rate limiter. It's mainly intended for http rate limiter
+func newLimiter(capacity int, leakspeed time.Duration) rate.RateLimiter {
+	if capacity == -1 {
+		// In this case we allow all events to pass.
+		// maybe in the future we could avoid using a limiter
+		return &rate.AlwaysFull{}
+	}
+
+	return rate.NewLimiter(rate.Every(leakspeed), capacity)
+}
+
 // for now mimic a leak routine
 // LeakRoutine is the life of a bucket. It dies when the bucket underflows or overflows
 func (l *Leaky) LeakRoutine(ctx context.Context, gate pourGate) {
@@ -155,6 +173,14 @@ func (l *Leaky) LeakRoutine(ctx context.Context, gate pourGate) {
 	}
 
 	l.logger.Debugf("Leaky routine starting, lifetime : %s", l.Duration)
+
+	// a restored bucket expires when it would have without the restart, even if it's not poured again
+	if l.restoredTTL > 0 {
+		durationTicker = time.NewTicker(l.restoredTTL)
+		durationTickerChan = durationTicker.C
+		firstEvent = false
+	}
+
 	for {
 		select {
 		// receiving an event
@@ -229,7 +255,7 @@ func (l *Leaky) LeakRoutine(ctx context.Context, gate pourGate) {
 			alert = pipeline.RuntimeAlert{Mapkey: l.Mapkey}
 
 			if l.timedOverflow {
-				metrics.BucketsOverflow.With(prometheus.Labels{"name": l.Factory.Spec.Name}).Inc()
+				metrics.BucketsOverflow.With(l.Factory.overflowMetricLabels()).Inc()
 
 				alert, err = NewAlert(l, ofw)
 				if err != nil {
@@ -309,7 +335,7 @@ func (l *Leaky) overflow(ofw *pipeline.Queue) {
 	mt, _ := l.Ovflw_ts.MarshalText()
 	l.logger.Tracef("overflow time : %s", mt)
 
-	metrics.BucketsOverflow.With(prometheus.Labels{"name": l.Factory.Spec.Name}).Inc()
+	metrics.BucketsOverflow.With(l.Factory.overflowMetricLabels()).Inc()
 
 	l.AllOut <- pipeline.Event{Overflow: alert, Type: pipeline.OVFLW, MarshaledTime: string(mt)}
 }
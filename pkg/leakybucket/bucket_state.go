@@ -0,0 +1,55 @@
+package leakybucket
+
+import (
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// bucketEnv adds the bucket to the environment of the expressions evaluated in its context
+// (condition, overflow_filter, bayesian conditions, reinject meta), along with read-only accessors
+// of its state:
+//
+//   - BucketFill() float64: the share of the capacity in use, from 0 to 1. Always 0 when the capacity is -1.
+//   - BucketAge() duration: the time since the first event, e.g. BucketAge() < duration("10s").
+//   - QueueLen() int: the number of events held by the bucket, at most cache_size if it's set.
+//
+// They are not available in the filter, which runs before the event is assigned to a bucket.
+func bucketEnv(l *Leaky, env map[string]any) map[string]any {
+	env["leaky"] = l
+	env["BucketFill"] = l.fillRatio
+	env["BucketAge"] = l.age
+	env["QueueLen"] = l.queueLen
+
+	return env
+}
+
+// stateRef is the time the state of the bucket is computed at: the time of the logs in time
+// machine mode.
+func (l *Leaky) stateRef() time.Time {
+	if l.Mode == pipeline.TIMEMACHINE {
+		return l.Last_ts
+	}
+
+	return time.Now().UTC()
+}
+
+func (l *Leaky) fillRatio() float64 {
+	return l.info(l.stateRef()).FillRatio
+}
+
+func (l *Leaky) age() time.Duration {
+	if l.First_ts.IsZero() {
+		return 0
+	}
+
+	return l.stateRef().Sub(l.First_ts)
+}
+
+func (l *Leaky) queueLen() int {
+	if l.Queue == nil {
+		return 0
+	}
+
+	return len(l.Queue.GetQueue())
+}
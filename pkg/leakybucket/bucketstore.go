@@ -1,62 +1,108 @@
 package leakybucket
 
 import (
+	"hash/maphash"
 	"maps"
 	"sync"
 )
 
+// bucketStoreShards is the number of parts of the bucket map, each one with its own lock, to
+// reduce the contention between the pour routines.
+const bucketStoreShards = 64
+
+type bucketShard struct {
+	mu sync.Mutex // lock to mutate m
+	m  map[string]*Leaky
+}
+
 // BucketStore is the struct used to hold buckets during the lifecycle of the app
 // (i.e. between reloads).
 type BucketStore struct {
-	mu sync.Mutex        // lock to mutate m
-	m map[string]*Leaky
+	seed   maphash.Seed
+	shards []bucketShard
 	muFlow sync.RWMutex // read lock for pours, write lock for dump/snapshot/GC
 }
 
 func NewBucketStore() *BucketStore {
-	return &BucketStore{
-		m:           make(map[string]*Leaky),
+	return newBucketStore(bucketStoreShards)
+}
+
+func newBucketStore(shards int) *BucketStore {
+	b := &BucketStore{
+		seed:   maphash.MakeSeed(),
+		shards: make([]bucketShard, shards),
 	}
+
+	for i := range b.shards {
+		b.shards[i].m = make(map[string]*Leaky)
+	}
+
+	return b
+}
+
+func (b *BucketStore) shard(key string) *bucketShard {
+	return &b.shards[maphash.String(b.seed, key)%uint64(len(b.shards))]
 }
 
 func (b *BucketStore) Load(key string) (*Leaky, bool) {
-	b.mu.Lock()
-	v, ok := b.m[key]
-	b.mu.Unlock()
+	s := b.shard(key)
+	s.mu.Lock()
+	v, ok := s.m[key]
+	s.mu.Unlock()
+
 	if !ok {
 		return nil, false
 	}
+
 	return v, true
 }
 
 func (b *BucketStore) LoadOrStore(key string, val *Leaky) (*Leaky, bool) {
-	b.mu.Lock()
-	if existing, ok := b.m[key]; ok {
-		b.mu.Unlock()
+	s := b.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.m[key]; ok {
 		return existing, true
 	}
-	b.m[key] = val
-	b.mu.Unlock()
+
+	s.m[key] = val
+
 	return val, false
 }
 
 func (b *BucketStore) Delete(key string) {
-	b.mu.Lock()
-	delete(b.m, key)
-	b.mu.Unlock()
+	s := b.shard(key)
+	s.mu.Lock()
+	delete(s.m, key)
+	s.mu.Unlock()
 }
 
+// Snapshot returns a copy of the bucket map. The shards are copied one after the other: a bucket
+// created or deleted meanwhile can be missing, or present, unless the pours are frozen.
 func (b *BucketStore) Snapshot() map[string]*Leaky {
-	b.mu.Lock()
-	snap := maps.Clone(b.m)
-	b.mu.Unlock()
+	snap := make(map[string]*Leaky, b.Len())
+
+	for i := range b.shards {
+		s := &b.shards[i]
+		s.mu.Lock()
+		maps.Copy(snap, s.m)
+		s.mu.Unlock()
+	}
+
 	return snap
 }
 
 func (b *BucketStore) Len() int {
-	b.mu.Lock()
-	n := len(b.m)
-	b.mu.Unlock()
+	n := 0
+
+	for i := range b.shards {
+		s := &b.shards[i]
+		s.mu.Lock()
+		n += len(s.m)
+		s.mu.Unlock()
+	}
+
 	return n
 }
 
@@ -2,13 +2,17 @@ package leakybucket
 
 import (
 	"bytes"
+	"crypto/sha1"
 	"encoding/json"
+	"fmt"
 	"html/template"
 	"io"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
 	"reflect"
 	"slices"
+	"strconv"
 	"testing"
 	"time"
 
@@ -215,7 +219,7 @@ POLL_AGAIN:
 			results = append(results, ret)
 			if ret.Overflow.Reprocess {
 				log.Errorf("Overflow being reprocessed.")
-				ok, err := PourItemToHolders(ctx, ret, holders, bucketStore, nil)
+				ok, err := PourItemToHolders(ctx, ReinjectEvent(ret), holders, bucketStore, nil)
 				require.NoError(t, err)
 				if !ok {
 					log.Warning("Event wasn't poured")
@@ -272,3 +276,64 @@ POLL_AGAIN:
 		}
 	}
 }
+
+func TestBucketStoreShards(t *testing.T) {
+	store := NewBucketStore()
+
+	for i := range 1000 {
+		key := strconv.Itoa(i)
+		_, loaded := store.LoadOrStore(key, &Leaky{Mapkey: key})
+		require.False(t, loaded)
+	}
+
+	require.Equal(t, 1000, store.Len())
+
+	existing, loaded := store.LoadOrStore("42", &Leaky{Mapkey: "other"})
+	require.True(t, loaded)
+	require.Equal(t, "42", existing.Mapkey)
+
+	store.Delete("42")
+
+	_, ok := store.Load("42")
+	require.False(t, ok)
+
+	snap := store.Snapshot()
+	require.Len(t, snap, 999)
+	require.Equal(t, "7", snap["7"].Mapkey)
+}
+
+// BenchmarkBucketStore compares a single lock with the sharded map, with pour-like accesses:
+//
+//	go test -run '^$' -bench BucketStore -cpu 1,4,16 ./pkg/leakybucket/
+func BenchmarkBucketStore(b *testing.B) {
+	const nbKeys = 10000
+
+	keys := make([]string, nbKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%x", sha1.Sum([]byte(strconv.Itoa(i))))
+	}
+
+	for _, shards := range []int{1, bucketStoreShards} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			store := newBucketStore(shards)
+
+			b.RunParallel(func(pb *testing.PB) {
+				i := rand.IntN(nbKeys)
+
+				for pb.Next() {
+					key := keys[i%nbKeys]
+					i++
+
+					if _, ok := store.Load(key); !ok {
+						store.LoadOrStore(key, &Leaky{})
+					}
+
+					// buckets overflow or expire now and then
+					if i%100 == 0 {
+						store.Delete(key)
+					}
+				}
+			})
+		})
+	}
+}
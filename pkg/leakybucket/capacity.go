@@ -0,0 +1,107 @@
+package leakybucket
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// eventTime returns the time of the event in time machine mode, the current time otherwise.
+func eventTime(evt *pipeline.Event) time.Time {
+	if evt.ExpectMode == pipeline.TIMEMACHINE {
+		var ts time.Time
+		if err := ts.UnmarshalText([]byte(evt.MarshaledTime)); err == nil {
+			return ts
+		}
+
+		if !evt.Time.IsZero() {
+			return evt.Time
+		}
+	}
+
+	return time.Now().UTC()
+}
+
+func exprCapacity(output any) (int, error) {
+	switch v := output.(type) {
+	case int:
+		if v > 0 {
+			return v, nil
+		}
+	case float64:
+		if v > 0 && v == math.Trunc(v) {
+			return int(v), nil
+		}
+	default:
+		return 0, fmt.Errorf("unexpected type %T, expected an integer", output)
+	}
+
+	return 0, fmt.Errorf("invalid capacity '%v': must be a positive integer", output)
+}
+
+func exprLeakSpeed(output any) (time.Duration, error) {
+	var (
+		d   time.Duration
+		err error
+	)
+
+	switch v := output.(type) {
+	case time.Duration:
+		d = v
+	case string:
+		if d, err = time.ParseDuration(v); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("unexpected type %T, expected a duration or a string", output)
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid leakspeed '%s': must be > 0", d)
+	}
+
+	return d, nil
+}
+
+// instanceLimits returns the capacity and leakspeed of a new bucket instance. They are the ones of
+// the scenario, unless capacity_expr or leakspeed_expr are set: they are evaluated against the event
+// that creates the bucket and the time of that event. If the evaluation fails, the static values are used.
+func (f *BucketFactory) instanceLimits(evt *pipeline.Event) (int, time.Duration) {
+	capacity := f.Spec.Capacity
+	leakspeed := f.leakspeed
+
+	if evt == nil || (f.runTimeCapacity == nil && f.runTimeLeakSpeed == nil) {
+		return capacity, leakspeed
+	}
+
+	env := map[string]any{"evt": evt, "now": eventTime(evt)}
+
+	if f.runTimeCapacity != nil {
+		output, err := exprhelpers.Run(f.runTimeCapacity, env, f.logger, f.Spec.Debug)
+		if err == nil {
+			capacity, err = exprCapacity(output)
+		}
+
+		if err != nil {
+			f.logger.Warningf("capacity_expr: %s, using capacity %d", err, f.Spec.Capacity)
+			capacity = f.Spec.Capacity
+		}
+	}
+
+	if f.runTimeLeakSpeed != nil {
+		output, err := exprhelpers.Run(f.runTimeLeakSpeed, env, f.logger, f.Spec.Debug)
+		if err == nil {
+			leakspeed, err = exprLeakSpeed(output)
+		}
+
+		if err != nil {
+			f.logger.Warningf("leakspeed_expr: %s, using leakspeed %s", err, f.leakspeed)
+			leakspeed = f.leakspeed
+		}
+	}
+
+	return capacity, leakspeed
+}
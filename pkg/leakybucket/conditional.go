@@ -32,7 +32,7 @@ func (p *ConditionalProcessor) OnBucketInit(f *BucketFactory) error {
 	} else {
 		conditionalExprCacheLock.Unlock()
 		// release the lock during compile
-		compiledExpr, err = compile(f.Spec.ConditionalOverflow, map[string]any{"queue": &pipeline.Queue{}, "leaky": &Leaky{}})
+		compiledExpr, err = compile(f.Spec.ConditionalOverflow, bucketEnv(&Leaky{}, map[string]any{"queue": &pipeline.Queue{}}))
 		if err != nil {
 			return fmt.Errorf("conditional compile error : %w", err)
 		}
@@ -53,7 +53,7 @@ func (p *ConditionalProcessor) AfterBucketPour(f *BucketFactory, msg pipeline.Ev
 		l.logger.Debugf("Running condition expression : %s", p.ConditionalFilter)
 
 		ret, err := exprhelpers.Run(p.ConditionalFilterRuntime,
-			map[string]any{"evt": &msg, "queue": l.Queue, "leaky": l},
+			bucketEnv(l, map[string]any{"evt": &msg, "queue": l.Queue}),
 			l.logger, f.Spec.Debug)
 		if err != nil {
 			l.logger.Errorf("unable to run conditional filter : %s", err)
@@ -0,0 +1,81 @@
+package leakybucket
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// CooldownProcessor ignores the events of a partition for a while after it overflowed. Unlike the
+// blackhole, no bucket is created in the meantime: an ongoing attack doesn't fill new buckets only
+// to have their overflows discarded.
+type CooldownProcessor struct {
+	duration time.Duration
+	mu       sync.Mutex
+	keys     map[string]time.Time // end of the cooldown, by partition key
+	DumbProcessor
+}
+
+func NewCooldownProcessor(f *BucketFactory) (*CooldownProcessor, error) {
+	duration, err := time.ParseDuration(f.Spec.Cooldown)
+	if err != nil || duration <= 0 {
+		return nil, fmt.Errorf("cooldown duration not valid '%s'", f.Spec.Cooldown)
+	}
+
+	return &CooldownProcessor{
+		duration: duration,
+		keys:     map[string]time.Time{},
+	}, nil
+}
+
+func (p *CooldownProcessor) OnBucketOverflow(
+	_ *BucketFactory,
+	leaky *Leaky,
+	alert pipeline.RuntimeAlert,
+	queue *pipeline.Queue,
+) (pipeline.RuntimeAlert, *pipeline.Queue) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// overflows are rare enough to clean up the expired cooldowns each time
+	for key, end := range p.keys {
+		if !end.After(leaky.Ovflw_ts) {
+			delete(p.keys, key)
+		}
+	}
+
+	p.keys[leaky.Mapkey] = leaky.Ovflw_ts.Add(p.duration)
+	leaky.logger.Debugf("Cooldown until %s", p.keys[leaky.Mapkey])
+
+	return alert, queue
+}
+
+// active returns true if the partition is in cooldown at the time of the event.
+func (p *CooldownProcessor) active(key string, evt *pipeline.Event) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	end, ok := p.keys[key]
+	if !ok {
+		return false
+	}
+
+	now := time.Now().UTC()
+
+	if evt.ExpectMode == pipeline.TIMEMACHINE {
+		if err := now.UnmarshalText([]byte(evt.MarshaledTime)); err != nil {
+			// the event will be rejected by the bucket anyway
+			return false
+		}
+	}
+
+	if now.Before(end) {
+		return true
+	}
+
+	delete(p.keys, key)
+
+	return false
+}
@@ -0,0 +1,142 @@
+package leakybucket
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// BucketInfo is the state of a live bucket, as seen from the outside.
+type BucketInfo struct {
+	Scenario string `json:"scenario"`
+	Type     string `json:"type"`
+	Mapkey   string `json:"mapkey"`
+	GroupBy  string `json:"groupby"`
+	// Capacity is -1 for counters
+	Capacity int `json:"capacity"`
+	// Fill is the number of events currently in the bucket, after leaking.
+	// FillRatio is Fill/Capacity, or 0 when the bucket has no capacity.
+	Fill       float64   `json:"fill"`
+	FillRatio  float64   `json:"fill_ratio"`
+	Events     int       `json:"events"`
+	FirstEvent time.Time `json:"first_event"`
+	LastEvent  time.Time `json:"last_event"`
+	// Age is the time since the first event, TTL the time left before
+	// the bucket underflows (or overflows for counters) if nothing is poured.
+	Age         float64 `json:"age_seconds"`
+	TTL         float64 `json:"ttl_seconds"`
+	TimeMachine bool    `json:"timemachine"`
+}
+
+// InspectFilter selects the buckets returned by BucketStore.Inspect. Zero values match everything.
+type InspectFilter struct {
+	// Scenario is a scenario name or a glob pattern (crowdsecurity/ssh-*)
+	Scenario string
+	GroupBy  string
+	MinFill  float64
+	Limit    int
+}
+
+func (f InspectFilter) match(info BucketInfo) bool {
+	if f.Scenario != "" {
+		if ok, err := path.Match(f.Scenario, info.Scenario); err != nil || !ok {
+			return false
+		}
+	}
+
+	if f.GroupBy != "" && f.GroupBy != info.GroupBy {
+		return false
+	}
+
+	if f.MinFill > 0 && info.FillRatio < f.MinFill {
+		return false
+	}
+
+	return true
+}
+
+func (l *Leaky) info(now time.Time) BucketInfo {
+	info := BucketInfo{
+		Scenario:    l.Factory.Spec.Name,
+		Type:        l.Factory.Spec.Type,
+		Mapkey:      l.Mapkey,
+		GroupBy:     l.GroupBy,
+		Capacity:    l.Capacity,
+		Events:      l.Total_count,
+		FirstEvent:  l.First_ts,
+		LastEvent:   l.Last_ts,
+		TimeMachine: l.Mode == pipeline.TIMEMACHINE,
+	}
+
+	// in time machine mode, the limiter runs on the time of the logs
+	ref := now
+	if info.TimeMachine {
+		ref = l.Last_ts
+	}
+
+	// counters and conditional buckets don't leak: the queue is all we have
+	if limiter, ok := l.Limiter.(*rate.Limiter); ok && info.Capacity > 0 {
+		info.Fill = max(float64(info.Capacity)-limiter.TokensAt(ref), 0)
+	} else if l.Queue != nil {
+		info.Fill = float64(len(l.Queue.GetQueue()))
+	}
+
+	if info.Capacity > 0 {
+		info.FillRatio = info.Fill / float64(info.Capacity)
+	}
+
+	if !l.First_ts.IsZero() {
+		info.Age = ref.Sub(l.First_ts).Seconds()
+	}
+
+	// timed buckets expire relative to the first event, the others to the last one
+	deadline := l.Last_ts
+	if l.timedOverflow {
+		deadline = l.First_ts
+	}
+
+	if !deadline.IsZero() {
+		info.TTL = max(deadline.Add(l.Duration).Sub(ref).Seconds(), 0)
+	}
+
+	return info
+}
+
+// Inspect returns the state of the live buckets matching the filter, the fullest first.
+// Pours are suspended while the buckets are read.
+func (b *BucketStore) Inspect(now time.Time, filter InspectFilter) []BucketInfo {
+	resume := b.FreezePours()
+	defer resume()
+
+	ret := []BucketInfo{}
+
+	for _, l := range b.Snapshot() {
+		info := l.info(now)
+		if filter.match(info) {
+			ret = append(ret, info)
+		}
+	}
+
+	slices.SortFunc(ret, func(a, b BucketInfo) int {
+		if c := cmp.Compare(b.FillRatio, a.FillRatio); c != 0 {
+			return c
+		}
+
+		if c := cmp.Compare(a.Scenario, b.Scenario); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(a.Mapkey, b.Mapkey)
+	})
+
+	if filter.Limit > 0 && len(ret) > filter.Limit {
+		ret = ret[:filter.Limit]
+	}
+
+	return ret
+}
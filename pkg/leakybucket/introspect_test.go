@@ -0,0 +1,104 @@
+package leakybucket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func TestInspect(t *testing.T) {
+	ctx := t.Context()
+	bucketStore := NewBucketStore()
+
+	holders := []BucketFactory{
+		{
+			Spec: BucketSpec{
+				Name:        "test/leaky_slow",
+				Description: "test_leaky_slow",
+				Type:        "leaky",
+				Capacity:    4,
+				LeakSpeed:   "10m",
+				Filter:      "true",
+				GroupBy:     "evt.Parsed.source_ip",
+			},
+		},
+		{
+			Spec: BucketSpec{
+				Name:        "other/counter_slow",
+				Description: "test_counter_slow",
+				Type:        "counter",
+				Capacity:    -1,
+				Duration:    "10m",
+				Filter:      "true",
+			},
+		},
+	}
+
+	for idx := range holders {
+		require.NoError(t, holders[idx].LoadBucket())
+		require.NoError(t, holders[idx].Validate())
+	}
+
+	for _, ip := range []string{"1.2.3.4", "1.2.3.4", "1.2.3.4", "5.6.7.8"} {
+		in := pipeline.Event{Parsed: map[string]string{"source_ip": ip}}
+		ok, err := PourItemToHolders(ctx, in, holders, bucketStore, nil)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	// pours are asynchronous: wait for the 4 events to reach both scenarios
+	require.Eventually(t, func() bool {
+		total := 0
+		for _, b := range bucketStore.Inspect(time.Now(), InspectFilter{}) {
+			total += b.Events
+		}
+
+		return total == 8
+	}, 5*time.Second, 50*time.Millisecond)
+
+	all := bucketStore.Inspect(time.Now(), InspectFilter{})
+	require.Len(t, all, 3)
+
+	// the fullest first
+	assert.Equal(t, "test/leaky_slow", all[0].Scenario)
+	assert.Equal(t, "1.2.3.4", all[0].GroupBy)
+	assert.Equal(t, 4, all[0].Capacity)
+	assert.Equal(t, 3, all[0].Events)
+	assert.InDelta(t, 3, all[0].Fill, 0.01)
+	assert.InDelta(t, 0.75, all[0].FillRatio, 0.01)
+	// (capacity+1) * leakspeed
+	assert.InDelta(t, 50*60, all[0].TTL, 5)
+
+	assert.Equal(t, "5.6.7.8", all[1].GroupBy)
+	assert.InDelta(t, 1, all[1].Fill, 0.01)
+
+	counter := all[2]
+	assert.Equal(t, "other/counter_slow", counter.Scenario)
+	assert.Equal(t, -1, counter.Capacity)
+	assert.Equal(t, 4, counter.Events)
+	assert.InDelta(t, 4, counter.Fill, 0.01)
+	assert.Zero(t, counter.FillRatio)
+	assert.InDelta(t, 10*60, counter.TTL, 5)
+
+	got := bucketStore.Inspect(time.Now(), InspectFilter{MinFill: 0.7})
+	require.Len(t, got, 1)
+	assert.Equal(t, "1.2.3.4", got[0].GroupBy)
+
+	got = bucketStore.Inspect(time.Now(), InspectFilter{Scenario: "test/*"})
+	require.Len(t, got, 2)
+
+	got = bucketStore.Inspect(time.Now(), InspectFilter{GroupBy: "5.6.7.8"})
+	require.Len(t, got, 1)
+	assert.Equal(t, "test/leaky_slow", got[0].Scenario)
+
+	got = bucketStore.Inspect(time.Now(), InspectFilter{Limit: 1})
+	require.Len(t, got, 1)
+	assert.Equal(t, "test/leaky_slow", got[0].Scenario)
+
+	got = bucketStore.Inspect(time.Now(), InspectFilter{Scenario: "nope"})
+	assert.Empty(t, got)
+}
@@ -0,0 +1,59 @@
+package leakybucket
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// propagatedLabels are the scenario labels that are copied to the alerts (and their decisions)
+// and used as dimensions of the overflow metrics, to filter them by classification.
+var propagatedLabels = []string{"classification", "behavior", "confidence"}
+
+// labelValues returns the values of a scenario label as strings. A label can be a scalar or a list.
+func labelValues(v any) []string {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		ret := make([]string, 0, len(val))
+		for _, item := range val {
+			ret = append(ret, fmt.Sprint(item))
+		}
+
+		return ret
+	case []string:
+		return val
+	default:
+		return []string{fmt.Sprint(val)}
+	}
+}
+
+// alertLabels returns the propagated labels of the scenario, as "key:value".
+func (f *BucketFactory) alertLabels() []string {
+	var ret []string
+
+	for _, key := range propagatedLabels {
+		for _, value := range labelValues(f.Spec.Labels[key]) {
+			ret = append(ret, key+":"+value)
+		}
+	}
+
+	return ret
+}
+
+// overflowMetricLabels returns the prometheus labels of the overflow counter. The values of
+// a list label are sorted and joined by commas.
+func (f *BucketFactory) overflowMetricLabels() prometheus.Labels {
+	ret := prometheus.Labels{"name": f.Spec.Name}
+
+	for _, key := range propagatedLabels {
+		values := slices.Clone(labelValues(f.Spec.Labels[key]))
+		slices.Sort(values)
+		ret[key] = strings.Join(values, ",")
+	}
+
+	return ret
+}
@@ -0,0 +1,46 @@
+package leakybucket
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScenarioLabels(t *testing.T) {
+	tests := []struct {
+		name            string
+		labels          map[string]any
+		expectedAlert   []string
+		expectedMetrics prometheus.Labels
+	}{
+		{
+			name:            "no labels",
+			expectedMetrics: prometheus.Labels{"name": "test", "classification": "", "behavior": "", "confidence": ""},
+		},
+		{
+			name: "all labels",
+			labels: map[string]any{
+				"classification": []any{"attack.T1595", "attack.T1110"},
+				"behavior":       "ssh:bruteforce",
+				"confidence":     3,
+				"remediation":    true,
+			},
+			expectedAlert: []string{"classification:attack.T1595", "classification:attack.T1110", "behavior:ssh:bruteforce", "confidence:3"},
+			expectedMetrics: prometheus.Labels{
+				"name":           "test",
+				"classification": "attack.T1110,attack.T1595",
+				"behavior":       "ssh:bruteforce",
+				"confidence":     "3",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &BucketFactory{Spec: BucketSpec{Name: "test", Labels: tc.labels}}
+			assert.Equal(t, tc.expectedAlert, f.alertLabels())
+			assert.Equal(t, tc.expectedMetrics, f.overflowMetricLabels())
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/expr-lang/expr/vm"
@@ -34,14 +35,18 @@ type BucketSpec struct {
 	Name                string                     `yaml:"name"`                // Name of the bucket, used later in log and user-messages. Should be unique
 	Capacity            int                        `yaml:"capacity"`            // Capacity is applicable to leaky buckets and determines the "burst" capacity
 	LeakSpeed           string                     `yaml:"leakspeed"`           // Leakspeed is a float representing how many events per second leak out of the bucket
+	CapacityExpr        string                     `yaml:"capacity_expr,omitempty"`   // CapacityExpr, if present, is an expr evaluated at bucket creation that overrides Capacity for this instance
+	LeakSpeedExpr       string                     `yaml:"leakspeed_expr,omitempty"`  // LeakSpeedExpr, if present, is an expr evaluated at bucket creation that overrides LeakSpeed for this instance
 	Filter              string                     `yaml:"filter"`              // Filter is an expr that determines if an event is elligible for said bucket. Filter is evaluated against the Event struct
 	GroupBy             string                     `yaml:"groupby,omitempty"`   // groupy is an expr that allows to determine the partitions of the bucket. A common example is the source_ip
 	Distinct            string                     `yaml:"distinct"`            // Distinct, when present, adds a `Pour()` processor that will only pour uniq items (based on distinct expr result)
 	Debug               bool                       `yaml:"debug"`               // Debug, when set to true, will enable debugging for _this_ scenario specifically
 	Labels              map[string]any             `yaml:"labels"`              // Labels is K:V list aiming at providing context the overflow
 	Blackhole           string                     `yaml:"blackhole,omitempty"` // Blackhole is a duration that, if present, will prevent same bucket partition to overflow more often than $duration
+	Cooldown            string                     `yaml:"cooldown,omitempty"`  // Cooldown is a duration that, if present, will ignore the events of a bucket partition for $duration after it overflowed
 	ScopeType           ScopeType                  `yaml:"scope,omitempty"`     // to enforce a different remediation than blocking an IP. Will default this to IP
 	Reprocess           bool                       `yaml:"reprocess"`       // Reprocess, if true, will for the bucket to be re-injected into processing chain
+	Reinject            *ReinjectSpec              `yaml:"reinject,omitempty"` // Reinject, if present, transforms the overflow into a synthetic event re-injected into processing chain
 	Data                []*enrichment.DataProvider `yaml:"data,omitempty"`
 	ConditionalOverflow string                     `yaml:"condition"`       // condition if present, is an expression that must return true for the bucket to overflow
 	CacheSize           int                        `yaml:"cache_size"`      // CacheSize, if > 0, limits the size of in-memory cache of the bucket
@@ -63,11 +68,14 @@ type BucketFactory struct {
 	Filename            string
 	RunTimeFilter       *vm.Program         `json:"-"`
 	RunTimeGroupBy      *vm.Program         `json:"-"`
+	runTimeCapacity     *vm.Program         // compiled `CapacityExpr`
+	runTimeLeakSpeed    *vm.Program         // compiled `LeakSpeedExpr`
 	DataDir             string
 	leakspeed           time.Duration       // internal representation of `Leakspeed`
 	duration            time.Duration       // internal representation of `Duration`
 	ret                 chan pipeline.Event // the bucket-specific output chan for overflows
 	processors          []Processor         // processors is the list of hooks for pour/overflow/create (cf. uniq, blackhole etc.)
+	cooldown            *CooldownProcessor  // cooldown, if set, is checked before the events are poured
 	scenarioHash        string
 	Simulated           bool                // Set to true if the scenario instantiating the bucket was in the exclusion list
 	orderEvent          bool
@@ -94,6 +102,14 @@ func (f *BucketFactory) Validate() error {
 		return fmt.Errorf("%s bucket: %w", f.Spec.Type, err)
 	}
 
+	if f.Spec.CapacityExpr != "" && f.Spec.Type != "leaky" {
+		return fmt.Errorf("%s bucket: capacity_expr is only supported by leaky buckets", f.Spec.Type)
+	}
+
+	if f.Spec.LeakSpeedExpr != "" && f.Spec.LeakSpeed == "" {
+		return fmt.Errorf("%s bucket: leakspeed_expr requires a leakspeed to fall back to", f.Spec.Type)
+	}
+
 	return f.Spec.ScopeType.CompileFilter()
 }
 
@@ -107,6 +123,7 @@ func loadBucketFactoriesFromFile(
 	response chan pipeline.Event,
 	orderEvent bool,
 	simcheck SimulationChecker,
+	overrides csconfig.ScenarioOverrides,
 ) ([]BucketFactory, error) {
 	itemPath := item.State.LocalPath
 
@@ -161,6 +178,12 @@ func loadBucketFactoriesFromFile(
 		}
 
 		f.Filename = filepath.Clean(itemPath)
+
+		if o, ok := overrides[f.Spec.Name]; ok {
+			log.Infof("scenario %s: using the local thresholds", f.Spec.Name)
+			f.Spec.applyOverride(o)
+		}
+
 		f.BucketName = seed.Generate()
 		f.ret = response
 		f.Simulated = simcheck.IsSimulated(f.Spec.Name)
@@ -193,7 +216,7 @@ func LoadBuckets(
 	for _, item := range scenarios {
 		log.Debugf("Loading '%s'", item.State.LocalPath)
 
-		factories, err := loadBucketFactoriesFromFile(item, hub, response, orderEvent, &cscfg.SimulationConfig)
+		factories, err := loadBucketFactoriesFromFile(item, hub, response, orderEvent, &cscfg.SimulationConfig, cscfg.ScenarioOverrides)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -201,6 +224,12 @@ func LoadBuckets(
 		allFactories = append(allFactories, factories...)
 	}
 
+	for name := range cscfg.ScenarioOverrides {
+		if !slices.ContainsFunc(allFactories, func(f BucketFactory) bool { return f.Spec.Name == name }) {
+			log.Warningf("local thresholds for %s: no such scenario is enabled", name)
+		}
+	}
+
 	if err := alertcontext.NewAlertContext(cscfg.ContextToSend, cscfg.ConsoleContextValueLength); err != nil {
 		return nil, nil, fmt.Errorf("unable to load alert context: %w", err)
 	}
@@ -210,6 +239,29 @@ func LoadBuckets(
 	return allFactories, response, nil
 }
 
+// applyOverride replaces the thresholds of the scenario with the ones of the local tuning file.
+func (s *BucketSpec) applyOverride(o *csconfig.ScenarioOverride) {
+	if o.Capacity != nil {
+		s.Capacity = *o.Capacity
+	}
+
+	if o.LeakSpeed != nil {
+		s.LeakSpeed = *o.LeakSpeed
+	}
+
+	if o.Duration != nil {
+		s.Duration = *o.Duration
+	}
+
+	if o.Blackhole != nil {
+		s.Blackhole = *o.Blackhole
+	}
+
+	if o.Cooldown != nil {
+		s.Cooldown = *o.Cooldown
+	}
+}
+
 func bucketLogger(f *BucketFactory) *log.Entry {
 	fields := log.Fields{"cfg": f.BucketName, "name": f.Spec.Name}
 
@@ -261,6 +313,24 @@ func (f *BucketFactory) compileExpr() error {
 		f.RunTimeGroupBy = runtimeGroupBy
 	}
 
+	limitsEnv := map[string]any{"now": time.Time{}}
+
+	if f.Spec.CapacityExpr != "" {
+		runtimeCapacity, err := compile(f.Spec.CapacityExpr, limitsEnv)
+		if err != nil {
+			return fmt.Errorf("invalid capacity_expr '%s' in %s: %w", f.Spec.CapacityExpr, f.Filename, err)
+		}
+		f.runTimeCapacity = runtimeCapacity
+	}
+
+	if f.Spec.LeakSpeedExpr != "" {
+		runtimeLeakSpeed, err := compile(f.Spec.LeakSpeedExpr, limitsEnv)
+		if err != nil {
+			return fmt.Errorf("invalid leakspeed_expr '%s' in %s: %w", f.Spec.LeakSpeedExpr, f.Filename, err)
+		}
+		f.runTimeLeakSpeed = runtimeLeakSpeed
+	}
+
 	return nil
 }
 
@@ -316,10 +386,23 @@ func (f *BucketFactory) buildOptionalProcessors() ([]Processor, error) {
 		procs = append(procs, blackhole)
 	}
 
+	if f.Spec.Cooldown != "" {
+		f.logger.Tracef("Adding cooldown.")
+
+		cooldown, err := NewCooldownProcessor(f)
+		if err != nil {
+			f.logger.Errorf("Error creating cooldown : %s", err)
+			return nil, fmt.Errorf("error creating cooldown : %w", err)
+		}
+
+		f.cooldown = cooldown
+		procs = append(procs, cooldown)
+	}
+
 	if f.Spec.ConditionalOverflow != "" {
 		f.logger.Tracef("Adding conditional overflow")
 		procs = append(procs, &ConditionalProcessor{})
-		if err := check("condition", f.Spec.ConditionalOverflow, map[string]any{"queue": &pipeline.Queue{}, "leaky": &Leaky{}}); err != nil {
+		if err := check("condition", f.Spec.ConditionalOverflow, bucketEnv(&Leaky{}, map[string]any{"queue": &pipeline.Queue{}})); err != nil {
 			return nil, err
 		}
 	}
@@ -329,6 +412,18 @@ func (f *BucketFactory) buildOptionalProcessors() ([]Processor, error) {
 		procs = append(procs, &BayesianProcessor{})
 	}
 
+	// keep it last, the overflow must only be re-injected if no other processor discarded it
+	if f.Spec.Reinject != nil {
+		f.logger.Tracef("Adding reinject processor")
+
+		reinject, err := NewReinjectProcessor(f)
+		if err != nil {
+			return nil, fmt.Errorf("error creating reinject: %w", err)
+		}
+
+		procs = append(procs, reinject)
+	}
+
 	return procs, nil
 }
 
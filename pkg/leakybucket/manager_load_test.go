@@ -2,7 +2,17 @@ package leakybucket
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/cwhub"
+	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
 )
 
 type cfgTest struct {
@@ -79,6 +89,14 @@ func TestLeakyBucketsConfig(t *testing.T) {
 		{BucketFactory{Spec: BucketSpec{Name: "test", Description: "test1", Type: "leaky", Capacity: 1, LeakSpeed: "1s", Filter: "true", ConditionalOverflow: "xu"}}, false, true},
 		// leaky with valid conditional overflow filter
 		{BucketFactory{Spec: BucketSpec{Name: "test", Description: "test1", Type: "leaky", Capacity: 1, LeakSpeed: "1s", Filter: "true", ConditionalOverflow: "true"}}, true, true},
+		// leaky with invalid capacity expression
+		{BucketFactory{Spec: BucketSpec{Name: "test", Description: "test1", Type: "leaky", Capacity: 1, LeakSpeed: "1s", Filter: "true", CapacityExpr: "xu"}}, false, true},
+		// leaky with valid capacity expression
+		{BucketFactory{Spec: BucketSpec{Name: "test", Description: "test1", Type: "leaky", Capacity: 1, LeakSpeed: "1s", Filter: "true", CapacityExpr: "now.Hour() < 8 ? 2 : 5"}}, true, true},
+		// leaky with invalid leakspeed expression
+		{BucketFactory{Spec: BucketSpec{Name: "test", Description: "test1", Type: "leaky", Capacity: 1, LeakSpeed: "1s", Filter: "true", LeakSpeedExpr: "xu"}}, false, true},
+		// leaky with valid leakspeed expression
+		{BucketFactory{Spec: BucketSpec{Name: "test", Description: "test1", Type: "leaky", Capacity: 1, LeakSpeed: "1s", Filter: "true", LeakSpeedExpr: "evt.Meta.country == 'FR' ? '10s' : '1m'"}}, true, true},
 	}
 
 	if err := runTest(CfgTests); err != nil {
@@ -103,6 +121,8 @@ func TestTriggerBucketsConfig(t *testing.T) {
 	CfgTests := []cfgTest{
 		// basic valid counter
 		{BucketFactory{Spec: BucketSpec{Name: "test", Description: "test1", Type: "trigger", Filter: "true"}}, true, true},
+		// capacity expression on a trigger
+		{BucketFactory{Spec: BucketSpec{Name: "test", Description: "test1", Type: "trigger", Filter: "true", CapacityExpr: "2"}}, false, false},
 	}
 
 	if err := runTest(CfgTests); err != nil {
@@ -145,3 +165,56 @@ func TestBayesianBucketsConfig(t *testing.T) {
 		t.Fatalf("%s", err)
 	}
 }
+
+func TestScenarioOverrides(t *testing.T) {
+	hub, err := cwhub.NewHub(&csconfig.LocalHubCfg{
+		HubDir:         filepath.Join("testdata", "hub"),
+		HubIndexFile:   filepath.Join("testdata", "hub", "index.json"),
+		InstallDataDir: "testdata",
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, hub.Load())
+	require.NoError(t, exprhelpers.Init(nil))
+
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`type: leaky
+name: test/bf
+description: test
+filter: "true"
+capacity: 5
+leakspeed: 10s
+blackhole: 1m
+---
+type: leaky
+name: test/slow-bf
+description: test
+filter: "true"
+capacity: 10
+leakspeed: 1m
+`), 0o600))
+
+	cscfg := &csconfig.CrowdsecServiceCfg{
+		ScenarioOverrides: csconfig.ScenarioOverrides{
+			"test/bf":      {Capacity: new(20), LeakSpeed: new("30s")},
+			"test/missing": {Capacity: new(1)},
+		},
+	}
+
+	holders, _, err := LoadBuckets(cscfg, hub, []*cwhub.Item{{State: cwhub.ItemState{LocalPath: path}}}, false)
+	require.NoError(t, err)
+	require.Len(t, holders, 2)
+
+	assert.Equal(t, 20, holders[0].Spec.Capacity)
+	assert.Equal(t, "30s", holders[0].Spec.LeakSpeed)
+	assert.Equal(t, "1m", holders[0].Spec.Blackhole)
+	assert.Equal(t, 30*time.Second, holders[0].leakspeed)
+
+	assert.Equal(t, 10, holders[1].Spec.Capacity)
+	assert.Equal(t, "1m", holders[1].Spec.LeakSpeed)
+
+	// the thresholds are validated with the scenario
+	cscfg.ScenarioOverrides = csconfig.ScenarioOverrides{"test/bf": {Capacity: new(0)}}
+
+	_, _, err = LoadBuckets(cscfg, hub, []*cwhub.Item{{State: cwhub.ItemState{LocalPath: path}}}, false)
+	require.ErrorContains(t, err, "bucket test/bf")
+}
@@ -43,7 +43,7 @@ func GarbageCollectBuckets(deadline time.Time, bucketStore *BucketStore) {
 		const eps = 1e-9
 
 		tokat := val.Limiter.GetTokensCountAt(deadline)
-		tokcapa := float64(val.Factory.Spec.Capacity)
+		tokcapa := float64(val.Capacity)
 
 		// bucket actually underflowed based on log time, but no in real time
 		if tokat+eps >= tokcapa {
@@ -94,7 +94,7 @@ func PourItemToBucket(
 			bucket.logger.Tracef("Bucket %s found dead, cleanup the body", buckey)
 			bucketStore.Delete(buckey)
 			sigclosed += 1
-			bucket, err = LoadOrStoreBucketFromHolder(ctx, buckey, bucketStore, holder, parsed.ExpectMode)
+			bucket, err = LoadOrStoreBucketFromHolder(ctx, buckey, bucket.GroupBy, bucketStore, holder, parsed)
 			if err != nil {
 				return err
 			}
@@ -123,7 +123,7 @@ func PourItemToBucket(
 					bucketStore.Delete(buckey)
 					// not sure about this, should we create a new one ?
 					sigclosed += 1
-					bucket, err = LoadOrStoreBucketFromHolder(ctx, buckey, bucketStore, holder, parsed.ExpectMode)
+					bucket, err = LoadOrStoreBucketFromHolder(ctx, buckey, bucket.GroupBy, bucketStore, holder, parsed)
 					if err != nil {
 						return err
 					}
@@ -153,9 +153,10 @@ func PourItemToBucket(
 func LoadOrStoreBucketFromHolder(
 	ctx context.Context,
 	partitionKey string,
+	groupBy string,
 	buckets *BucketStore,
 	holder *BucketFactory,
-	expectMode int,
+	evt *pipeline.Event,
 ) (*Leaky, error) {
 	leaky, ok := buckets.Load(partitionKey)
 	if ok {
@@ -165,42 +166,56 @@ func LoadOrStoreBucketFromHolder(
 	/* the bucket doesn't exist, create it !*/
 	var fresh_bucket *Leaky
 
-	switch expectMode {
+	switch evt.ExpectMode {
 	case pipeline.TIMEMACHINE:
-		fresh_bucket = NewTimeMachine(holder)
+		fresh_bucket = NewTimeMachine(holder, evt)
 		holder.logger.Debugf("Creating TimeMachine bucket")
 	case pipeline.LIVE:
-		fresh_bucket = NewLeakyFromFactory(holder)
+		fresh_bucket = NewLeakyFromFactory(holder, evt)
 		holder.logger.Debugf("Creating Live bucket")
 	default:
-		return nil, fmt.Errorf("input event has no expected mode : %+v", expectMode)
+		return nil, fmt.Errorf("input event has no expected mode : %+v", evt.ExpectMode)
 	}
-	fresh_bucket.In = make(chan *pipeline.Event)
-	fresh_bucket.Mapkey = partitionKey
-	fresh_bucket.ready = make(chan struct{})
-	fresh_bucket.done = make(chan struct{})
-	actual, stored := buckets.LoadOrStore(partitionKey, fresh_bucket)
+	leaky, stored := storeBucket(ctx, partitionKey, groupBy, buckets, fresh_bucket)
 	if !stored {
-		go func() {
-			defer trace.ReportPanic()
-			ctx, cancel := context.WithCancel(ctx)
-			fresh_bucket.cancel = cancel
-			fresh_bucket.LeakRoutine(ctx, buckets)
-			// Always call cancel to avoid leaks
-			// In case of replay, cancel() may be called by the GC func (eg, for an underflow), but cancel is safe to call multiple times
-			cancel()
-		}()
-		leaky = fresh_bucket
-		// once the created goroutine is ready to process event, we can return it
-		<-fresh_bucket.ready
-	} else {
 		holder.logger.Debugf("Unexpectedly found exisint bucket for %s", partitionKey)
-		leaky = actual
 	}
 	holder.logger.Debugf("Created new bucket %s", partitionKey)
 	return leaky, nil
 }
 
+// storeBucket adds a new bucket to the store and starts its leak routine. If another bucket was
+// stored meanwhile with the same key, it is returned instead and stored is false.
+func storeBucket(
+	ctx context.Context,
+	partitionKey string,
+	groupBy string,
+	buckets *BucketStore,
+	fresh_bucket *Leaky,
+) (leaky *Leaky, stored bool) {
+	fresh_bucket.In = make(chan *pipeline.Event)
+	fresh_bucket.Mapkey = partitionKey
+	fresh_bucket.GroupBy = groupBy
+	fresh_bucket.ready = make(chan struct{})
+	fresh_bucket.done = make(chan struct{})
+	actual, loaded := buckets.LoadOrStore(partitionKey, fresh_bucket)
+	if loaded {
+		return actual, false
+	}
+	go func() {
+		defer trace.ReportPanic()
+		ctx, cancel := context.WithCancel(ctx)
+		fresh_bucket.cancel = cancel
+		fresh_bucket.LeakRoutine(ctx, buckets)
+		// Always call cancel to avoid leaks
+		// In case of replay, cancel() may be called by the GC func (eg, for an underflow), but cancel is safe to call multiple times
+		cancel()
+	}()
+	// once the created goroutine is ready to process event, we can return it
+	<-fresh_bucket.ready
+	return fresh_bucket, true
+}
+
 var orderEvent map[string]*sync.WaitGroup
 
 func PourItemToHolders(
@@ -219,6 +234,11 @@ func PourItemToHolders(
 	// find the relevant holders (scenarios)
 	for idx := range holders {
 		// for idx, holder := range holders {
+		// loop protection: a re-injected overflow never goes back to a scenario it came from
+		if alreadyReinjected(&parsed, holders[idx].Spec.Name) {
+			holders[idx].logger.Tracef("Event leaving node : ko (already went through scenario)")
+			continue
+		}
 		// evaluate bucket's condition
 		if holders[idx].RunTimeFilter != nil {
 			holders[idx].logger.Tracef("event against holder %d/%d", idx, len(holders))
@@ -239,6 +259,9 @@ func PourItemToHolders(
 				holders[idx].logger.Debugf("Event leaving node : ko (filter mismatch)")
 				continue
 			}
+
+			// the mismatches are not recorded, there are as many as scenarios
+			pipeline.RecordTraceStep(&parsed, "bucket filter", holders[idx].Spec.Name, "true", holders[idx].Spec.Filter)
 		}
 
 		// groupby determines the partition key for the specific bucket
@@ -257,8 +280,16 @@ func PourItemToHolders(
 		}
 		buckey := holders[idx].BucketKey(groupby)
 
+		// the partition overflowed recently, don't start a new bucket
+		if holders[idx].cooldown != nil && holders[idx].cooldown.active(buckey, &parsed) {
+			holders[idx].logger.Debugf("Event leaving node : ko (cooldown)")
+			pipeline.RecordTraceStep(&parsed, "bucket", holders[idx].Spec.Name, "cooldown", groupby)
+
+			continue
+		}
+
 		// we need to either find the existing bucket, or create a new one (if it's the first event to hit it for this partition key)
-		bucket, err := LoadOrStoreBucketFromHolder(ctx, buckey, buckets, &holders[idx], parsed.ExpectMode)
+		bucket, err := LoadOrStoreBucketFromHolder(ctx, buckey, groupby, buckets, &holders[idx], &parsed)
 		if err != nil {
 			return false, fmt.Errorf("failed to load or store bucket: %w", err)
 		}
@@ -286,6 +317,9 @@ func PourItemToHolders(
 		if err != nil {
 			return false, fmt.Errorf("failed to pour bucket: %w", err)
 		}
+
+		pipeline.RecordTraceStep(&parsed, "bucket", holders[idx].Spec.Name, "poured", groupby)
+
 		poured = true
 	}
 	return poured, nil
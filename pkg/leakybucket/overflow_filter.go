@@ -4,11 +4,17 @@ import (
 	"fmt"
 
 	"github.com/expr-lang/expr/vm"
+	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
 	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
 )
 
+// OverflowProcessor runs the overflow_filter expression when the bucket overflows.
+// The expression has access to all the events in the bucket (queue), not only the last
+// one, so an overflow can be discarded based on aggregate properties of the events,
+// e.g. !all(queue.Queue, {.Meta.http_status == '200'})
 type OverflowProcessor struct {
 	Filter        string
 	FilterRuntime *vm.Program
@@ -22,29 +28,35 @@ func NewOverflowProcessor(f *BucketFactory) (*OverflowProcessor, error) {
 
 	u.Filter = f.Spec.OverflowFilter
 
-	u.FilterRuntime, err = compile(u.Filter, map[string]any{"queue": &pipeline.Queue{}, "signal": &pipeline.RuntimeAlert{}, "leaky": &Leaky{}})
+	u.FilterRuntime, err = compile(u.Filter, bucketEnv(&Leaky{}, map[string]any{"queue": &pipeline.Queue{}, "signal": &pipeline.RuntimeAlert{}}))
 	if err != nil {
-		f.logger.Errorf("Unable to compile filter : %v", err)
-		return nil, fmt.Errorf("unable to compile filter : %v", err)
+		f.logger.Errorf("Unable to compile overflow filter : %v", err)
+		return nil, fmt.Errorf("unable to compile overflow filter : %w", err)
 	}
+
+	if f.Spec.CacheSize > 0 {
+		f.logger.Warningf("cache_size is set: the overflow filter only sees the last %d events of the bucket", f.Spec.CacheSize)
+	}
+
 	return &u, nil
 }
 
 func (u *OverflowProcessor) OnBucketOverflow(f *BucketFactory, l *Leaky, s pipeline.RuntimeAlert, q *pipeline.Queue) (pipeline.RuntimeAlert, *pipeline.Queue) {
-	el, err := exprhelpers.Run(u.FilterRuntime, map[string]any{
-		"queue": q, "signal": s, "leaky": l}, l.logger, f.Spec.Debug)
+	el, err := exprhelpers.Run(u.FilterRuntime, bucketEnv(l, map[string]any{
+		"queue": q, "signal": s}), l.logger, f.Spec.Debug)
 	if err != nil {
 		l.logger.Errorf("Failed running overflow filter: %s", err)
 		return s, q
 	}
 	element, ok := el.(bool)
 	if !ok {
-		l.logger.Errorf("Overflow filter didn't return bool: %s", err)
+		l.logger.Errorf("Overflow filter didn't return bool: %T", el)
 		return s, q
 	}
 	// filter returned false, event is blackholded
 	if !element {
 		l.logger.Infof("Event is discarded by overflow filter (%s)", u.Filter)
+		metrics.BucketsOverflowDiscarded.With(prometheus.Labels{"name": f.Spec.Name}).Inc()
 		return pipeline.RuntimeAlert{
 			Mapkey: l.Mapkey,
 		}, nil
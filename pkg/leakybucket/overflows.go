@@ -301,9 +301,9 @@ func NewAlert(leaky *Leaky, queue *pipeline.Queue) (pipeline.RuntimeAlert, error
 		log.Warningf("failed to serialize ovflw ts %s : %s", leaky.First_ts.String(), err)
 	}
 
-	capacity := int32(leaky.Factory.Spec.Capacity)
+	capacity := int32(leaky.Capacity)
 	EventsCount := int32(leaky.Total_count)
-	leakSpeed := leaky.Factory.leakspeed.String()
+	leakSpeed := leaky.LeakSpeed.String()
 	startAt := string(start_at)
 	stopAt := string(stop_at)
 	apiAlert := models.Alert{
@@ -318,6 +318,7 @@ func NewAlert(leaky *Leaky, queue *pipeline.Queue) (pipeline.RuntimeAlert, error
 		StopAt:          &stopAt,
 		Simulated:       &leaky.Factory.Simulated,
 		Kind:            types.CrowdsecAlertKind.String(),
+		Labels:          leaky.Factory.alertLabels(),
 	}
 
 	if leaky.Factory == nil {
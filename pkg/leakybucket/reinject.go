@@ -0,0 +1,135 @@
+package leakybucket
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/expr-lang/expr/vm"
+
+	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// DefaultReinjectMaxDepth is the maximum length of a scenario chain when the scenario doesn't set max_depth.
+const DefaultReinjectMaxDepth = 3
+
+// ReinjectSpec describes how an overflow is turned into a synthetic event and re-injected in the pipeline.
+type ReinjectSpec struct {
+	Meta     map[string]string `yaml:"meta,omitempty"`      // Meta is a map of expressions evaluated on overflow, results are set in the synthetic event Meta
+	MaxDepth int               `yaml:"max_depth,omitempty"` // MaxDepth is the maximum number of scenarios the synthetic event can have gone through
+}
+
+type ReinjectProcessor struct {
+	meta     map[string]*vm.Program
+	maxDepth int
+	DumbProcessor
+}
+
+func NewReinjectProcessor(f *BucketFactory) (*ReinjectProcessor, error) {
+	spec := f.Spec.Reinject
+
+	if spec.MaxDepth < 0 {
+		return nil, fmt.Errorf("invalid max_depth '%d': must be >= 0", spec.MaxDepth)
+	}
+
+	p := ReinjectProcessor{
+		meta:     make(map[string]*vm.Program, len(spec.Meta)),
+		maxDepth: spec.MaxDepth,
+	}
+
+	if p.maxDepth == 0 {
+		p.maxDepth = DefaultReinjectMaxDepth
+	}
+
+	for key, ex := range spec.Meta {
+		if key == "" {
+			return nil, errors.New("empty meta key")
+		}
+
+		prog, err := compile(ex, bucketEnv(&Leaky{}, map[string]any{"queue": &pipeline.Queue{}, "signal": &pipeline.RuntimeAlert{}}))
+		if err != nil {
+			return nil, fmt.Errorf("invalid meta expression '%s' for key '%s': %w", ex, key, err)
+		}
+
+		p.meta[key] = prog
+	}
+
+	return &p, nil
+}
+
+// reinjectChain returns the names of the scenarios the overflows in the queue went through.
+func reinjectChain(q *pipeline.Queue) []string {
+	var chain []string
+
+	for _, evt := range q.GetQueue() {
+		if evt.Type != pipeline.OVFLW {
+			continue
+		}
+
+		for _, name := range evt.Overflow.ReinjectChain {
+			if !slices.Contains(chain, name) {
+				chain = append(chain, name)
+			}
+		}
+	}
+
+	return chain
+}
+
+func (p *ReinjectProcessor) OnBucketOverflow(f *BucketFactory, l *Leaky, s pipeline.RuntimeAlert, q *pipeline.Queue) (pipeline.RuntimeAlert, *pipeline.Queue) {
+	if q == nil || s.Alert == nil {
+		return s, q
+	}
+
+	chain := reinjectChain(q)
+
+	if slices.Contains(chain, f.Spec.Name) {
+		l.logger.Warningf("overflow already went through %s, not re-injecting (chain: %v)", f.Spec.Name, chain)
+		return s, q
+	}
+
+	if len(chain) >= p.maxDepth {
+		l.logger.Warningf("max re-injection depth (%d) reached, not re-injecting (chain: %v)", p.maxDepth, chain)
+		return s, q
+	}
+
+	meta := make(map[string]string, len(p.meta))
+
+	for key, prog := range p.meta {
+		ret, err := exprhelpers.Run(prog, bucketEnv(l, map[string]any{"queue": q, "signal": s}), l.logger, f.Spec.Debug)
+		if err != nil {
+			l.logger.Errorf("failed to run meta expression for '%s': %s", key, err)
+			continue
+		}
+
+		switch v := ret.(type) {
+		case nil:
+			continue
+		case string:
+			meta[key] = v
+		default:
+			meta[key] = fmt.Sprintf("%v", v)
+		}
+	}
+
+	s.Reprocess = true
+	s.ReinjectChain = append(chain, f.Spec.Name)
+	s.ReinjectMeta = meta
+
+	return s, q
+}
+
+// ReinjectEvent prepares an overflow event before it is sent back to the processing pipeline.
+func ReinjectEvent(evt pipeline.Event) pipeline.Event {
+	for k, v := range evt.Overflow.ReinjectMeta {
+		evt.SetMeta(k, v)
+	}
+
+	return evt
+}
+
+// alreadyReinjected returns true if the event is an overflow that already went through the scenario.
+func alreadyReinjected(evt *pipeline.Event, name string) bool {
+	return evt.Type == pipeline.OVFLW && slices.Contains(evt.Overflow.ReinjectChain, name)
+}
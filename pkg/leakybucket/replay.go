@@ -0,0 +1,79 @@
+package leakybucket
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// Replay pours already parsed events, in chronological order and in time machine mode, to the
+// given holders and returns the alerts they would have raised.
+// Buckets emit their overflows asynchronously: Replay returns once no overflow has been
+// received for the settle duration after the last event has been poured.
+func Replay(ctx context.Context, holders []BucketFactory, response chan pipeline.Event, events []pipeline.Event, settle time.Duration) ([]pipeline.RuntimeAlert, error) {
+	var (
+		mu     sync.Mutex
+		alerts []pipeline.RuntimeAlert
+		// signals the collector received something, to restart the settle timer
+		received = make(chan struct{}, 1)
+	)
+
+	bucketStore := NewBucketStore()
+
+	// the buckets are killed when we return, the collector keeps draining
+	// the response channel so that they are not blocked on their way out
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		for evt := range response {
+			// underflows and bucket removals are reported with an empty alert
+			if evt.Overflow.Alert != nil {
+				mu.Lock()
+				alerts = append(alerts, evt.Overflow)
+				mu.Unlock()
+			}
+
+			select {
+			case received <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	sorted := slices.Clone(events)
+	slices.SortStableFunc(sorted, func(a, b pipeline.Event) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	for _, evt := range sorted {
+		evt.ExpectMode = pipeline.TIMEMACHINE
+		if evt.MarshaledTime == "" {
+			evt.MarshaledTime = evt.Time.Format(time.RFC3339Nano)
+		}
+
+		if _, err := PourItemToHolders(ctx, evt, holders, bucketStore, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	timer := time.NewTimer(settle)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-received:
+			timer.Reset(settle)
+		case <-timer.C:
+			mu.Lock()
+			defer mu.Unlock()
+
+			return slices.Clone(alerts), nil
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package leakybucket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func TestReplay(t *testing.T) {
+	response := make(chan pipeline.Event, 1)
+
+	holders := []BucketFactory{
+		{
+			Spec: BucketSpec{
+				Name:        "test_replay",
+				Description: "test_replay",
+				Type:        "leaky",
+				Capacity:    2,
+				LeakSpeed:   "10s",
+				Filter:      "evt.Meta.log_type == 'ssh_failed-auth'",
+				GroupBy:     "evt.Meta.source_ip",
+			},
+		},
+	}
+
+	for idx := range holders {
+		require.NoError(t, holders[idx].LoadBucket())
+		require.NoError(t, holders[idx].Validate())
+		holders[idx].ret = response
+	}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	event := func(ip string, offset time.Duration) pipeline.Event {
+		return pipeline.Event{
+			Type: pipeline.LOG,
+			Time: start.Add(offset),
+			Meta: map[string]string{"log_type": "ssh_failed-auth", "source_ip": ip},
+		}
+	}
+
+	events := []pipeline.Event{
+		// out of order on purpose, Replay sorts them
+		event("1.2.3.4", 2*time.Second),
+		event("1.2.3.4", 0),
+		event("1.2.3.4", time.Second),
+		// too slow to overflow
+		event("5.6.7.8", 0),
+		event("5.6.7.8", time.Minute),
+		event("5.6.7.8", 2*time.Minute),
+	}
+
+	alerts, err := Replay(t.Context(), holders, response, events, 500*time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, "test_replay", *alerts[0].Alert.Scenario)
+	assert.Equal(t, int32(3), *alerts[0].Alert.EventsCount)
+	assert.Equal(t, "1.2.3.4", *alerts[0].Alert.Source.Value)
+}
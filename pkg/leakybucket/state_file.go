@@ -0,0 +1,255 @@
+package leakybucket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// bucketsStateVersion is increased when the format of the state file changes, the files of
+// another version are ignored.
+const bucketsStateVersion = 1
+
+// BucketState is the serialized form of a live bucket, to restore it after a restart.
+//
+// The state of the processors (uniq values, bayesian posteriors...) is not kept.
+type BucketState struct {
+	Scenario     string           `json:"scenario"`
+	ScenarioHash string           `json:"scenario_hash,omitempty"`
+	Mapkey       string           `json:"mapkey"`
+	GroupBy      string           `json:"groupby,omitempty"`
+	Uuid         string           `json:"uuid"`
+	FirstTs      time.Time        `json:"first_ts"`
+	LastTs       time.Time        `json:"last_ts"`
+	TotalCount   int              `json:"total_count"`
+	Capacity     int              `json:"capacity"`
+	LeakSpeed    time.Duration    `json:"leakspeed"`
+	Duration     time.Duration    `json:"duration"`
+	Limiter      rate.Lstate      `json:"limiter"`
+	Queue        []pipeline.Event `json:"queue"`
+}
+
+type bucketsStateFile struct {
+	Version  int               `json:"version"`
+	DumpedAt time.Time         `json:"dumped_at"`
+	Buckets  []json.RawMessage `json:"buckets"`
+}
+
+// persistable tells if the state of a bucket can be saved: the buckets of a replay, and the
+// ones that are overflowing or dead, are not.
+func (l *Leaky) persistable() bool {
+	if l.Mode != pipeline.LIVE || !l.Ovflw_ts.IsZero() || l.First_ts.IsZero() {
+		return false
+	}
+
+	// the bayesian buckets only make sense with the posteriors of their processor
+	if l.Factory.Spec.Type == "bayesian" {
+		return false
+	}
+
+	select {
+	case <-l.done:
+		return false
+	default:
+	}
+
+	return true
+}
+
+func (l *Leaky) state() BucketState {
+	return BucketState{
+		Scenario:     l.Factory.Spec.Name,
+		ScenarioHash: l.Factory.scenarioHash,
+		Mapkey:       l.Mapkey,
+		GroupBy:      l.GroupBy,
+		Uuid:         l.Uuid,
+		FirstTs:      l.First_ts,
+		LastTs:       l.Last_ts,
+		TotalCount:   l.Total_count,
+		Capacity:     l.Capacity,
+		LeakSpeed:    l.LeakSpeed,
+		Duration:     l.Duration,
+		Limiter:      l.Limiter.Dump(),
+		Queue:        append([]pipeline.Event(nil), l.Queue.GetQueue()...),
+	}
+}
+
+// deadline is the time the bucket underflows if it's not poured again, or the time a counter
+// bucket overflows.
+func (s *BucketState) deadline(f *BucketFactory) time.Time {
+	if f.duration != 0 {
+		return s.FirstTs.Add(s.Duration)
+	}
+
+	return s.LastTs.Add(s.Duration)
+}
+
+// DumpBucketsState writes the state of the live buckets to path, replacing the previous file
+// atomically. It returns the number of buckets written.
+func DumpBucketsState(path string, buckets *BucketStore) (int, error) {
+	states := []BucketState{}
+
+	resume := buckets.FreezePours()
+
+	for _, l := range buckets.Snapshot() {
+		if !l.persistable() {
+			continue
+		}
+
+		states = append(states, l.state())
+	}
+
+	resume()
+
+	file := bucketsStateFile{
+		Version:  bucketsStateVersion,
+		DumpedAt: time.Now().UTC(),
+		Buckets:  make([]json.RawMessage, 0, len(states)),
+	}
+
+	for _, st := range states {
+		// rate.Every(0) is an infinite limit, which can't be represented in JSON
+		if math.IsInf(float64(st.Limiter.Limit), 0) {
+			continue
+		}
+
+		data, err := json.Marshal(st)
+		if err != nil {
+			log.Warningf("unable to serialize bucket %s of %s: %s", st.Mapkey, st.Scenario, err)
+			continue
+		}
+
+		file.Buckets = append(file.Buckets, data)
+	}
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to serialize buckets state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return 0, fmt.Errorf("while creating directories for %s: %w", path, err)
+	}
+
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return 0, fmt.Errorf("while writing buckets state: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, fmt.Errorf("while writing buckets state: %w", err)
+	}
+
+	return len(file.Buckets), nil
+}
+
+func dropRestoredBucket(st *BucketState, reason string) {
+	metrics.BucketsRestoreDropped.With(prometheus.Labels{"name": st.Scenario, "reason": reason}).Inc()
+}
+
+// RestoreBucketsState starts again the buckets saved in path by DumpBucketsState. Their
+// lifetime is shortened by the time elapsed since the dump, and the expired ones are dropped,
+// like the ones of the scenarios that were removed or modified. A missing file is not an error.
+func RestoreBucketsState(ctx context.Context, path string, holders []BucketFactory, buckets *BucketStore, now time.Time) (restored int, dropped int, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, 0, nil
+	}
+
+	if err != nil {
+		return 0, 0, fmt.Errorf("while reading buckets state: %w", err)
+	}
+
+	var file bucketsStateFile
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return 0, 0, fmt.Errorf("while parsing buckets state %s: %w", path, err)
+	}
+
+	if file.Version != bucketsStateVersion {
+		return 0, 0, fmt.Errorf("unsupported buckets state version %d in %s", file.Version, path)
+	}
+
+	factories := make(map[string]*BucketFactory, len(holders))
+	for idx := range holders {
+		factories[holders[idx].Spec.Name] = &holders[idx]
+	}
+
+	for _, raw := range file.Buckets {
+		var st BucketState
+
+		if err := json.Unmarshal(raw, &st); err != nil {
+			log.Warningf("unable to parse bucket state: %s", err)
+			dropped++
+
+			continue
+		}
+
+		f, ok := factories[st.Scenario]
+
+		switch {
+		case !ok:
+			dropRestoredBucket(&st, "unknown_scenario")
+			dropped++
+
+			continue
+		case f.scenarioHash != st.ScenarioHash:
+			dropRestoredBucket(&st, "scenario_changed")
+			dropped++
+
+			continue
+		}
+
+		ttl := st.deadline(f).Sub(now)
+		if ttl <= 0 {
+			dropRestoredBucket(&st, "expired")
+			dropped++
+
+			continue
+		}
+
+		l := NewLeakyFromFactory(f, nil)
+		l.Uuid = st.Uuid
+		l.First_ts = st.FirstTs
+		l.Last_ts = st.LastTs
+		l.Total_count = st.TotalCount
+		l.Capacity = st.Capacity
+		l.LeakSpeed = st.LeakSpeed
+		l.Duration = st.Duration
+		l.Limiter = newLimiter(st.Capacity, st.LeakSpeed)
+		l.Limiter.Load(st.Limiter)
+		l.Queue = pipeline.NewQueue(f.queueSize(st.Capacity))
+
+		for _, evt := range st.Queue {
+			l.Queue.Add(evt)
+		}
+
+		l.restoredTTL = ttl
+
+		if _, stored := storeBucket(ctx, st.Mapkey, st.GroupBy, buckets, l); !stored {
+			f.logger.Debugf("bucket %s already exists, not restored", st.Mapkey)
+			continue
+		}
+
+		metrics.BucketsRestored.With(prometheus.Labels{"name": st.Scenario}).Inc()
+		f.logger.Debugf("restored bucket %s, expiring in %s", st.Mapkey, ttl)
+
+		restored++
+	}
+
+	return restored, dropped, nil
+}
@@ -0,0 +1,164 @@
+package leakybucket
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func stateTestHolders(t *testing.T) []BucketFactory {
+	t.Helper()
+
+	holders := []BucketFactory{
+		{
+			Spec: BucketSpec{
+				Name:        "test_counter",
+				Description: "test_counter",
+				Type:        "counter",
+				Capacity:    -1,
+				Duration:    "10m",
+				Filter:      "true",
+			},
+		},
+		{
+			Spec: BucketSpec{
+				Name:        "test_leaky",
+				Description: "test_leaky",
+				Type:        "leaky",
+				Capacity:    5,
+				LeakSpeed:   "1m",
+				GroupBy:     "evt.Parsed.source_ip",
+				Filter:      "true",
+			},
+		},
+	}
+
+	for idx := range holders {
+		require.NoError(t, holders[idx].LoadBucket())
+		require.NoError(t, holders[idx].Validate())
+	}
+
+	return holders
+}
+
+func TestBucketsState(t *testing.T) {
+	ctx := t.Context()
+	path := filepath.Join(t.TempDir(), "state", "buckets_state.json")
+
+	holders := stateTestHolders(t)
+	bucketStore := NewBucketStore()
+
+	for _, ip := range []string{"1.2.3.4", "1.2.3.4", "1.2.3.5"} {
+		evt := pipeline.Event{Parsed: map[string]string{"source_ip": ip}}
+		ok, err := PourItemToHolders(ctx, evt, holders, bucketStore, nil)
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+
+	// the events are poured by the leak routines
+	time.Sleep(500 * time.Millisecond)
+
+	count, err := DumpBucketsState(path, bucketStore)
+	require.NoError(t, err)
+	// one counter, one leaky per ip
+	assert.Equal(t, 3, count)
+
+	dumped := bucketStore.Snapshot()
+
+	// restart
+	holders = stateTestHolders(t)
+	bucketStore = NewBucketStore()
+
+	restored, dropped, err := RestoreBucketsState(ctx, path, holders, bucketStore, time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, 3, restored)
+	assert.Equal(t, 0, dropped)
+
+	for key, before := range dumped {
+		after, ok := bucketStore.Load(key)
+		require.True(t, ok, "bucket %s not restored", key)
+		assert.Equal(t, before.Uuid, after.Uuid)
+		assert.Equal(t, before.GroupBy, after.GroupBy)
+		assert.Equal(t, before.Total_count, after.Total_count)
+		assert.True(t, before.First_ts.Equal(after.First_ts))
+		assert.Len(t, after.Queue.GetQueue(), before.Total_count)
+		assert.InDelta(t, before.Limiter.GetTokensCount(), after.Limiter.GetTokensCount(), 0.1)
+	}
+
+	leaky, ok := bucketStore.Load(holders[1].BucketKey("1.2.3.4"))
+	require.True(t, ok)
+	assert.Equal(t, "1.2.3.4", leaky.Queue.GetQueue()[1].Parsed["source_ip"])
+
+	// the restored buckets keep filling up
+	evt := pipeline.Event{Parsed: map[string]string{"source_ip": "1.2.3.4"}}
+	_, err = PourItemToHolders(ctx, evt, holders, bucketStore, nil)
+	require.NoError(t, err)
+
+	time.Sleep(500 * time.Millisecond)
+
+	resume := bucketStore.FreezePours()
+	assert.Equal(t, 3, leaky.Total_count)
+	resume()
+
+	// the leaky buckets expire after 6 minutes without events, the counter after 10
+	restored, dropped, err = RestoreBucketsState(ctx, path, holders, NewBucketStore(), time.Now().UTC().Add(8*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	assert.Equal(t, 2, dropped)
+
+	// the scenario was removed
+	restored, dropped, err = RestoreBucketsState(ctx, path, holders[1:], NewBucketStore(), time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored)
+	assert.Equal(t, 1, dropped)
+
+	// the scenario was modified
+	holders[1].scenarioHash = "new"
+
+	restored, dropped, err = RestoreBucketsState(ctx, path, holders, NewBucketStore(), time.Now().UTC())
+	require.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	assert.Equal(t, 2, dropped)
+}
+
+func TestBucketsStateFile(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	holders := stateTestHolders(t)
+
+	// first start
+	restored, dropped, err := RestoreBucketsState(ctx, filepath.Join(dir, "missing.json"), holders, NewBucketStore(), time.Now().UTC())
+	require.NoError(t, err)
+	assert.Zero(t, restored)
+	assert.Zero(t, dropped)
+
+	path := filepath.Join(dir, "buckets_state.json")
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+
+	_, _, err = RestoreBucketsState(ctx, path, holders, NewBucketStore(), time.Now().UTC())
+	cstest.RequireErrorContains(t, err, "while parsing buckets state")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 42, "buckets": []}`), 0o600))
+
+	_, _, err = RestoreBucketsState(ctx, path, holders, NewBucketStore(), time.Now().UTC())
+	cstest.RequireErrorContains(t, err, "unsupported buckets state version 42")
+
+	// nothing to save
+	count, err := DumpBucketsState(path, NewBucketStore())
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	restored, dropped, err = RestoreBucketsState(ctx, path, holders, NewBucketStore(), time.Now().UTC())
+	require.NoError(t, err)
+	assert.Zero(t, restored)
+	assert.Zero(t, dropped)
+}
@@ -47,8 +47,8 @@ func TimeMachinePour(l *Leaky, _ pourGate, msg pipeline.Event) {
 	}
 }
 
-func NewTimeMachine(f *BucketFactory) *Leaky {
-	l := NewLeakyFromFactory(f)
+func NewTimeMachine(f *BucketFactory, evt *pipeline.Event) *Leaky {
+	l := NewLeakyFromFactory(f, evt)
 	f.logger.Tracef("Instantiating timeMachine bucket")
 	l.Pour = TimeMachinePour
 	l.Mode = pipeline.TIMEMACHINE
@@ -0,0 +1,151 @@
+package managedconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		snippet Snippet
+		wantErr string
+	}{
+		{
+			name:    "acquisition",
+			snippet: Snippet{Kind: KindAcquisition, Name: "nginx", Content: "source: file\nfilenames: [/var/log/nginx/*.log]\nlabels:\n  type: nginx\n", Machines: []string{"m1"}},
+		},
+		{
+			name:    "profiles",
+			snippet: Snippet{Kind: KindProfiles, Name: "ban_long", Content: "name: ban_long\non_success: break\n---\nname: other\n"},
+		},
+		{
+			name:    "unknown kind",
+			snippet: Snippet{Kind: "parsers", Name: "x", Content: "a: b"},
+			wantErr: `unknown kind "parsers", must be acquisition or profiles`,
+		},
+		{
+			name:    "bad name",
+			snippet: Snippet{Kind: KindAcquisition, Name: "../acquis", Content: "source: file"},
+			wantErr: `invalid name "../acquis"`,
+		},
+		{
+			name:    "hidden file",
+			snippet: Snippet{Kind: KindAcquisition, Name: ".acquis", Content: "source: file"},
+			wantErr: `invalid name ".acquis"`,
+		},
+		{
+			name:    "profiles for machines",
+			snippet: Snippet{Kind: KindProfiles, Name: "p", Content: "name: p", Machines: []string{"m1"}},
+			wantErr: "profiles are used by the local API and can't target machines",
+		},
+		{
+			name:    "empty",
+			snippet: Snippet{Kind: KindAcquisition, Name: "x", Content: "---\n"},
+			wantErr: "empty content",
+		},
+		{
+			name:    "not yaml",
+			snippet: Snippet{Kind: KindAcquisition, Name: "x", Content: "source: [file"},
+			wantErr: "invalid content: yaml: line 1: did not find expected ',' or ']'",
+		},
+		{
+			name:    "exec",
+			snippet: Snippet{Kind: KindAcquisition, Name: "x", Content: "source: file\n---\nsource: exec\ncommand: id\n"},
+			wantErr: "the exec datasource can't be pushed by the console",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.snippet.Validate()
+			cstest.RequireErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestForMachine(t *testing.T) {
+	s := Snippet{}
+	assert.True(t, s.ForMachine("m1"))
+
+	s.Machines = []string{"m1", "m2"}
+	assert.True(t, s.ForMachine("m2"))
+	assert.False(t, s.ForMachine("m3"))
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "acquisition")
+	path := filepath.Join(dir, "nginx.yaml")
+
+	require.NoError(t, WriteFile(dir, Snippet{Name: "nginx", Content: "source: file\n"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, pristine(data))
+	assert.Contains(t, string(data), "\nsource: file\n")
+
+	// update
+	require.NoError(t, WriteFile(dir, Snippet{Name: "nginx", Content: "source: journalctl\n"}))
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "\nsource: journalctl\n")
+
+	// local change
+	require.NoError(t, os.WriteFile(path, append(data, "# local\n"...), 0o600))
+
+	err = WriteFile(dir, Snippet{Name: "nginx", Content: "source: file\n"})
+	require.ErrorIs(t, err, ErrLocalFile)
+
+	err = RemoveFile(dir, "nginx")
+	require.ErrorIs(t, err, ErrLocalFile)
+
+	// local file
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "local.yaml"), []byte("source: file\n"), 0o600))
+
+	err = WriteFile(dir, Snippet{Name: "local", Content: "source: file\n"})
+	require.ErrorIs(t, err, ErrLocalFile)
+
+	require.NoError(t, RemoveFile(dir, "missing"))
+}
+
+func TestSync(t *testing.T) {
+	dir := t.TempDir()
+	logger := log.NewEntry(log.StandardLogger())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "local.yaml"), []byte("source: file\n"), 0o600))
+
+	err := Sync(dir, []Snippet{
+		{Name: "a", Content: "source: file\n"},
+		{Name: "b", Content: "source: file\n"},
+		{Name: "local", Content: "source: journalctl\n"},
+	}, logger)
+	require.NoError(t, err)
+
+	err = Sync(dir, []Snippet{{Name: "b", Content: "source: journalctl\n"}}, logger)
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	assert.Equal(t, []string{"b.yaml", "local.yaml"}, names)
+
+	data, err := os.ReadFile(filepath.Join(dir, "local.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "source: file\n", string(data))
+
+	// nothing was pushed yet
+	require.NoError(t, Sync(filepath.Join(dir, "missing"), nil, logger))
+}
@@ -0,0 +1,108 @@
+// Package managedconfig handles the configuration files pushed by the console: the acquisition of the
+// log processors, and the profiles of the local API. They are stored in a directory of their own
+// and applied when the service is reloaded.
+package managedconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	KindAcquisition = "acquisition"
+	KindProfiles    = "profiles"
+)
+
+// Snippet is a configuration file pushed by the console.
+type Snippet struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	// the log processors receiving an acquisition snippet, all of them if empty
+	Machines []string `json:"machines,omitempty"`
+}
+
+var validName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,127}$`)
+
+// the datasources running commands can't be configured remotely
+var forbiddenSources = []string{"exec"}
+
+// FileName is the name of the file of the snippet in the managed directory.
+func (s *Snippet) FileName() string {
+	return s.Name + ".yaml"
+}
+
+// ForMachine returns true if the snippet must be applied by the machine.
+func (s *Snippet) ForMachine(machineID string) bool {
+	return len(s.Machines) == 0 || slices.Contains(s.Machines, machineID)
+}
+
+func (s *Snippet) Validate() error {
+	switch s.Kind {
+	case KindAcquisition, KindProfiles:
+	default:
+		return fmt.Errorf("unknown kind %q, must be %s or %s", s.Kind, KindAcquisition, KindProfiles)
+	}
+
+	if !validName.MatchString(s.Name) {
+		return fmt.Errorf("invalid name %q", s.Name)
+	}
+
+	if s.Kind == KindProfiles && len(s.Machines) > 0 {
+		return errors.New("profiles are used by the local API and can't target machines")
+	}
+
+	docs, err := documents(s.Content)
+	if err != nil {
+		return err
+	}
+
+	if len(docs) == 0 {
+		return errors.New("empty content")
+	}
+
+	if s.Kind != KindAcquisition {
+		return nil
+	}
+
+	for _, doc := range docs {
+		source, _ := doc["source"].(string)
+		if slices.Contains(forbiddenSources, source) {
+			return fmt.Errorf("the %s datasource can't be pushed by the console", source)
+		}
+	}
+
+	return nil
+}
+
+// documents decodes the YAML documents of the content, skipping the empty ones.
+func documents(content string) ([]map[string]any, error) {
+	var ret []map[string]any
+
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(content)))
+
+	for {
+		var doc map[string]any
+
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid content: %w", err)
+		}
+
+		if len(doc) > 0 {
+			ret = append(ret, doc)
+		}
+	}
+
+	return ret, nil
+}
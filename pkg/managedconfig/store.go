@@ -0,0 +1,165 @@
+package managedconfig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The files written by the console start with the checksum of their content. A file without this
+// header, or with a different content, was created or modified locally: it's not updated anymore.
+const (
+	headerPrefix = "# managed by the console (sha256:"
+	headerSuffix = "), a local change stops the updates of this file\n"
+)
+
+var ErrLocalFile = errors.New("the file was created or modified locally")
+
+func render(content string) []byte {
+	sum := sha256.Sum256([]byte(content))
+
+	return []byte(headerPrefix + hex.EncodeToString(sum[:]) + headerSuffix + content)
+}
+
+// pristine returns true if the file was written by the console and not modified since.
+func pristine(data []byte) bool {
+	rest, ok := bytes.CutPrefix(data, []byte(headerPrefix))
+	if !ok {
+		return false
+	}
+
+	sum, content, ok := bytes.Cut(rest, []byte(headerSuffix))
+	if !ok {
+		return false
+	}
+
+	expected := sha256.Sum256(content)
+
+	return string(sum) == hex.EncodeToString(expected[:])
+}
+
+// checkFile returns the content of the file, or ErrLocalFile if it was not written by the console.
+func checkFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case !pristine(data):
+		return nil, fmt.Errorf("%s: %w", path, ErrLocalFile)
+	}
+
+	return data, nil
+}
+
+// WriteFile writes the snippet in dir, unless a file of the same name was created or modified locally.
+func WriteFile(dir string, s Snippet) error {
+	path := filepath.Join(dir, s.FileName())
+
+	current, err := checkFile(path)
+	if err != nil {
+		return err
+	}
+
+	data := render(s.Content)
+
+	if bytes.Equal(current, data) {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	// the content is written in a temporary file first, for the reload to never read a partial file
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// RemoveFile removes the file of a snippet, unless it was modified locally.
+func RemoveFile(dir string, name string) error {
+	path := filepath.Join(dir, (&Snippet{Name: name}).FileName())
+
+	if _, err := checkFile(path); err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// Sync writes the snippets in dir, and removes the files of the snippets that are not pushed anymore.
+// The files created or modified locally are kept as they are.
+func Sync(dir string, snippets []Snippet, logger *log.Entry) error {
+	wanted := make(map[string]bool, len(snippets))
+
+	for _, s := range snippets {
+		wanted[s.FileName()] = true
+
+		err := WriteFile(dir, s)
+
+		switch {
+		case errors.Is(err, ErrLocalFile):
+			logger.Warnf("not updating %s: %s", s.Name, err)
+		case err != nil:
+			return fmt.Errorf("while writing %s: %w", s.Name, err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".yaml")
+		if !ok || entry.IsDir() || wanted[entry.Name()] {
+			continue
+		}
+
+		err := RemoveFile(dir, name)
+
+		switch {
+		case errors.Is(err, ErrLocalFile):
+			logger.Warnf("not removing %s: %s", name, err)
+		case err != nil:
+			return fmt.Errorf("while removing %s: %w", name, err)
+		default:
+			logger.Infof("removed %s", name)
+		}
+	}
+
+	return nil
+}
@@ -1,7 +1,29 @@
 package metrics
 
+import "github.com/prometheus/client_golang/prometheus"
+
 var AcquisitionMetricsNames = []string{}
 
 func RegisterAcquisitionMetric(metricName string) {
 	AcquisitionMetricsNames = append(AcquisitionMetricsNames, metricName)
 }
+
+const TransformDroppedLinesMetricName = "cs_transform_dropped_lines_total"
+
+var TransformDroppedLines = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: TransformDroppedLinesMetricName,
+		Help: "Total lines dropped by the transform expression of a datasource.",
+	},
+	[]string{"source", "type"},
+)
+
+const StructuredDroppedLinesMetricName = "cs_structured_dropped_lines_total"
+
+var StructuredDroppedLines = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: StructuredDroppedLinesMetricName,
+		Help: "Total structured events of a datasource dropped because they could not be decoded or validated.",
+	},
+	[]string{"source", "type"},
+)
@@ -0,0 +1,21 @@
+//go:build !no_datasource_auth0
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const Auth0DataSourceEventsReadMetricName = "cs_auth0source_hits_total"
+
+var Auth0DataSourceEventsRead = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: Auth0DataSourceEventsReadMetricName,
+		Help: "Total log events that were read from the Auth0 Management API.",
+	},
+	[]string{"source", "datasource_type", "acquis_type"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(Auth0DataSourceEventsReadMetricName)
+}
@@ -0,0 +1,21 @@
+//go:build !no_datasource_dockerevents
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const DockerEventsDataSourceEventsReadMetricName = "cs_dockereventssource_hits_total"
+
+var DockerEventsDataSourceEventsRead = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: DockerEventsDataSourceEventsReadMetricName,
+		Help: "Total events that were read from the container runtime.",
+	},
+	[]string{"source", "datasource_type", "acquis_type"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(DockerEventsDataSourceEventsReadMetricName)
+}
@@ -0,0 +1,21 @@
+//go:build !no_datasource_ebpf
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const EBPFDataSourceEventsReadMetricName = "cs_ebpfsource_hits_total"
+
+var EBPFDataSourceEventsRead = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: EBPFDataSourceEventsReadMetricName,
+		Help: "Total event that were read.",
+	},
+	[]string{"source", "datasource_type", "acquis_type"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(EBPFDataSourceEventsReadMetricName)
+}
@@ -0,0 +1,21 @@
+//go:build !no_datasource_etw
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const ETWDataSourceLinesReadMetricName = "cs_etwsource_hits_total"
+
+var ETWDataSourceLinesRead = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: ETWDataSourceLinesReadMetricName,
+		Help: "Total event that were read.",
+	},
+	[]string{"source", "datasource_type", "acquis_type"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(ETWDataSourceLinesReadMetricName)
+}
@@ -0,0 +1,30 @@
+//go:build !no_datasource_exec
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const ExecDataSourceLinesReadMetricName = "cs_execsource_hits_total"
+
+var ExecDataSourceLinesRead = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: ExecDataSourceLinesReadMetricName,
+		Help: "Total lines that were read from the output of a command.",
+	},
+	[]string{"source", "datasource_type", "acquis_type"})
+
+const ExecDataSourceExitsMetricName = "cs_execsource_exits_total"
+
+var ExecDataSourceExits = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: ExecDataSourceExitsMetricName,
+		Help: "Total exits of the commands run by the exec datasource, by exit code.",
+	},
+	[]string{"source", "exit_code"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(ExecDataSourceLinesReadMetricName)
+}
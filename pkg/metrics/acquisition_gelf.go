@@ -0,0 +1,30 @@
+//go:build !no_datasource_gelf
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const GelfDataSourceMessagesReceivedMetricName = "cs_gelfsource_hits_total"
+
+var GelfDataSourceMessagesReceived = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: GelfDataSourceMessagesReceivedMetricName,
+		Help: "Total GELF messages received.",
+	},
+	[]string{"source", "datasource_type", "acquis_type"})
+
+const GelfDataSourceMessagesDroppedMetricName = "cs_gelfsource_dropped_total"
+
+var GelfDataSourceMessagesDropped = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: GelfDataSourceMessagesDroppedMetricName,
+		Help: "Total GELF messages dropped, by reason (invalid, too_large, incomplete).",
+	},
+	[]string{"source", "reason"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(GelfDataSourceMessagesReceivedMetricName)
+}
@@ -0,0 +1,21 @@
+//go:build !no_datasource_mailbox
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const MailboxDataSourceEventsReadMetricName = "cs_mailboxsource_hits_total"
+
+var MailboxDataSourceEventsRead = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: MailboxDataSourceEventsReadMetricName,
+		Help: "Total messages that were read from a mailbox (IMAP or POP3).",
+	},
+	[]string{"source", "datasource_type", "acquis_type"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(MailboxDataSourceEventsReadMetricName)
+}
@@ -0,0 +1,30 @@
+//go:build !no_datasource_netflow
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const NetflowDataSourceFlowsReceivedMetricName = "cs_netflowsource_hits_total"
+
+var NetflowDataSourceFlowsReceived = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: NetflowDataSourceFlowsReceivedMetricName,
+		Help: "Total flow records received.",
+	},
+	[]string{"source", "datasource_type", "acquis_type"})
+
+const NetflowDataSourcePacketsDroppedMetricName = "cs_netflowsource_dropped_total"
+
+var NetflowDataSourcePacketsDropped = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: NetflowDataSourcePacketsDroppedMetricName,
+		Help: "Total flow packets or flowsets dropped, by reason (invalid, no_template, unsupported_version).",
+	},
+	[]string{"source", "reason"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(NetflowDataSourceFlowsReceivedMetricName)
+}
@@ -0,0 +1,21 @@
+//go:build !no_datasource_office365
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const Office365DataSourceEventsReadMetricName = "cs_office365source_hits_total"
+
+var Office365DataSourceEventsRead = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: Office365DataSourceEventsReadMetricName,
+		Help: "Total audit records that were read from the Office 365 Management Activity API.",
+	},
+	[]string{"source", "content_type", "datasource_type", "acquis_type"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(Office365DataSourceEventsReadMetricName)
+}
@@ -0,0 +1,21 @@
+//go:build !no_datasource_okta
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const OktaDataSourceEventsReadMetricName = "cs_oktasource_hits_total"
+
+var OktaDataSourceEventsRead = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: OktaDataSourceEventsReadMetricName,
+		Help: "Total events that were read from the Okta System Log API.",
+	},
+	[]string{"source", "datasource_type", "acquis_type"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(OktaDataSourceEventsReadMetricName)
+}
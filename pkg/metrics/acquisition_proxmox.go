@@ -0,0 +1,21 @@
+//go:build !no_datasource_proxmox
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const ProxmoxDataSourceEventsReadMetricName = "cs_proxmoxsource_hits_total"
+
+var ProxmoxDataSourceEventsRead = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: ProxmoxDataSourceEventsReadMetricName,
+		Help: "Total events that were read from Proxmox VE.",
+	},
+	[]string{"source", "datasource_type", "acquis_type"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(ProxmoxDataSourceEventsReadMetricName)
+}
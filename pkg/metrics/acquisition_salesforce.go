@@ -0,0 +1,21 @@
+//go:build !no_datasource_salesforce
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const SalesforceDataSourceEventsReadMetricName = "cs_salesforcesource_hits_total"
+
+var SalesforceDataSourceEventsRead = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: SalesforceDataSourceEventsReadMetricName,
+		Help: "Total events that were read from the Salesforce event log files.",
+	},
+	[]string{"source", "event_type", "datasource_type", "acquis_type"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(SalesforceDataSourceEventsReadMetricName)
+}
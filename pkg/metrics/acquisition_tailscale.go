@@ -0,0 +1,21 @@
+//go:build !no_datasource_tailscale
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const TailscaleDataSourceEventsReadMetricName = "cs_tailscalesource_hits_total"
+
+var TailscaleDataSourceEventsRead = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: TailscaleDataSourceEventsReadMetricName,
+		Help: "Total events that were read from the Tailscale local API.",
+	},
+	[]string{"source", "datasource_type", "acquis_type"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(TailscaleDataSourceEventsReadMetricName)
+}
@@ -0,0 +1,21 @@
+//go:build !no_datasource_vcenter
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const VCenterDataSourceEventsReadMetricName = "cs_vcentersource_hits_total"
+
+var VCenterDataSourceEventsRead = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: VCenterDataSourceEventsReadMetricName,
+		Help: "Total events that were read from vCenter / ESXi.",
+	},
+	[]string{"source", "datasource_type", "acquis_type"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(VCenterDataSourceEventsReadMetricName)
+}
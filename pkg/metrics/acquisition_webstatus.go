@@ -0,0 +1,21 @@
+//go:build !no_datasource_webstatus
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const WebStatusDataSourceEventsReadMetricName = "cs_webstatussource_hits_total"
+
+var WebStatusDataSourceEventsRead = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: WebStatusDataSourceEventsReadMetricName,
+		Help: "Total events that were read from the status pages of the web servers.",
+	},
+	[]string{"source", "datasource_type", "acquis_type"})
+
+//nolint:gochecknoinits
+func init() {
+	RegisterAcquisitionMetric(WebStatusDataSourceEventsReadMetricName)
+}
@@ -35,6 +35,36 @@ var GlobalParserHitsKo = prometheus.NewCounterVec(
 	[]string{"source", "type", "acquis_type"},
 )
 
+const GlobalParserUnparsedLinesMetricName = "cs_parser_unparsed_lines_total"
+
+var GlobalParserUnparsedLines = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: GlobalParserUnparsedLinesMetricName,
+		Help: "Total unparsed lines written to the unparsed lines sink.",
+	},
+	[]string{"source", "acquis_type", "stage"},
+)
+
+const GlobalParserQuarantinedSourcesMetricName = "cs_parser_quarantined_sources"
+
+var GlobalParserQuarantinedSources = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: GlobalParserQuarantinedSourcesMetricName,
+		Help: "Datasources whose lines are not parsed, because they went over the parsing error budget.",
+	},
+	[]string{"source", "type", "acquis_type"},
+)
+
+const GlobalParserQuarantinedLinesMetricName = "cs_parser_quarantined_lines_total"
+
+var GlobalParserQuarantinedLines = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: GlobalParserQuarantinedLinesMetricName,
+		Help: "Total lines of quarantined datasources that were not parsed.",
+	},
+	[]string{"source", "type", "acquis_type"},
+)
+
 const GlobalBucketPourKoMetricName = "cs_bucket_pour_ko_total"
 
 var GlobalBucketPourKo = prometheus.NewCounter(
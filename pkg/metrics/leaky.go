@@ -19,7 +19,8 @@ var BucketsOverflow = prometheus.NewCounterVec(
 		Name: BucketsOverflowMetricName,
 		Help: "Total buckets overflowed.",
 	},
-	[]string{"name"},
+	// classification, behavior and confidence are copied from the labels of the scenario
+	[]string{"name", "classification", "behavior", "confidence"},
 )
 
 const BucketsCanceledMetricName = "cs_bucket_canceled_total"
@@ -32,6 +33,16 @@ var BucketsCanceled = prometheus.NewCounterVec(
 	[]string{"name"},
 )
 
+const BucketsOverflowDiscardedMetricName = "cs_bucket_overflow_discarded_total"
+
+var BucketsOverflowDiscarded = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: BucketsOverflowDiscardedMetricName,
+		Help: "Total overflows discarded by overflow_filter.",
+	},
+	[]string{"name"},
+)
+
 const BucketsUnderflowMetricName = "cs_bucket_underflowed_total"
 
 var BucketsUnderflow = prometheus.NewCounterVec(
@@ -61,3 +72,24 @@ var BucketsCurrentCount = prometheus.NewGaugeVec(
 	},
 	[]string{"name"},
 )
+
+const BucketsRestoredMetricName = "cs_bucket_restored_total"
+
+var BucketsRestored = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: BucketsRestoredMetricName,
+		Help: "Total buckets restored from the state file at startup.",
+	},
+	[]string{"name"},
+)
+
+const BucketsRestoreDroppedMetricName = "cs_bucket_restore_dropped_total"
+
+var BucketsRestoreDropped = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: BucketsRestoreDroppedMetricName,
+		Help: "Total buckets of the state file not restored at startup.",
+	},
+	// reason: expired, unknown_scenario or scenario_changed
+	[]string{"name", "reason"},
+)
@@ -34,21 +34,28 @@ func RegisterMetrics(metricsLevel MetricsLevelConfig) error {
 		// Do not register any metrics
 	case MetricsLevelAggregated:
 		prometheus.MustRegister(GlobalParserHits, GlobalParserHitsOk, GlobalParserHitsKo,
+			GlobalParserUnparsedLines, GlobalParserQuarantinedSources, GlobalParserQuarantinedLines,
+			TransformDroppedLines, StructuredDroppedLines,
 			GlobalCsInfo, GlobalParsingHistogram, GlobalPourHistogram,
-			BucketsUnderflow, BucketsCanceled, BucketsInstantiation, BucketsOverflow,
+			BucketsUnderflow, BucketsCanceled, BucketsInstantiation, BucketsOverflow, BucketsOverflowDiscarded,
 			LapiRouteHits,
-			BucketsCurrentCount,
-			CacheMetrics, RegexpCacheMetrics, NodesWlHitsOk, NodesWlHits,
-			PapiOrdersReceived, PapiInvalidOrdersReceived, PapiLastPullTimestamp, PapiPollErrors)
+			BucketsCurrentCount, BucketsRestored, BucketsRestoreDropped,
+			CacheMetrics, RegexpCacheMetrics, NodesWlHitsOk, NodesWlHits, NodesCacheHits, NodesCacheMisses,
+			PapiOrdersReceived, PapiInvalidOrdersReceived, PapiLastPullTimestamp, PapiPollErrors,
+			NotificationDeliveries, NotificationDeliveryDuration)
 	case MetricsLevelFull:
 		prometheus.MustRegister(GlobalParserHits, GlobalParserHitsOk, GlobalParserHitsKo,
+			GlobalParserUnparsedLines, GlobalParserQuarantinedSources, GlobalParserQuarantinedLines,
+			TransformDroppedLines, StructuredDroppedLines,
 			NodesHits, NodesHitsOk, NodesHitsKo,
 			GlobalCsInfo, GlobalParsingHistogram, GlobalPourHistogram,
 			LapiRouteHits, LapiMachineHits, LapiBouncerHits, LapiNilDecisions, LapiNonNilDecisions, LapiResponseTime,
-			BucketsPour, BucketsUnderflow, BucketsCanceled, BucketsInstantiation, BucketsOverflow, BucketsCurrentCount,
-			GlobalActiveDecisions, GlobalAlerts, NodesWlHitsOk, NodesWlHits,
+			BucketsPour, BucketsUnderflow, BucketsCanceled, BucketsInstantiation, BucketsOverflow, BucketsOverflowDiscarded, BucketsCurrentCount,
+			BucketsRestored, BucketsRestoreDropped,
+			GlobalActiveDecisions, GlobalAlerts, NodesWlHitsOk, NodesWlHits, NodesCacheHits, NodesCacheMisses,
 			CacheMetrics, RegexpCacheMetrics,
-			PapiOrdersReceived, PapiInvalidOrdersReceived, PapiLastPullTimestamp, PapiPollErrors)
+			PapiOrdersReceived, PapiInvalidOrdersReceived, PapiLastPullTimestamp, PapiPollErrors,
+			NotificationDeliveries, NotificationDeliveryDuration)
 	default:
 		return fmt.Errorf("%w: %s", ErrInvalidMetricsLevel, metricsLevel)
 	}
@@ -0,0 +1,24 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const NotificationDeliveriesMetricName = "cs_notification_deliveries_total"
+
+var NotificationDeliveries = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: NotificationDeliveriesMetricName,
+		Help: "Number of notifications delivered to plugins, by status (success or failure).",
+	},
+	[]string{"plugin", "status"},
+)
+
+const NotificationDeliveryDurationMetricName = "cs_notification_delivery_duration_seconds"
+
+var NotificationDeliveryDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    NotificationDeliveryDurationMetricName,
+		Help:    "Time to deliver a notification to a plugin, retries included.",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	},
+	[]string{"plugin"},
+)
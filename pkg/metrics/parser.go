@@ -51,3 +51,23 @@ var NodesWlHits = prometheus.NewCounterVec(
 	},
 	[]string{"source", "type", "name", "reason", "stage", "acquis_type"},
 )
+
+const NodesCacheHitsMetricName = "cs_node_cache_hits_total"
+
+var NodesCacheHits = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: NodesCacheHitsMetricName,
+		Help: "Total events for which the cached result of the node statics was used.",
+	},
+	[]string{"name", "stage"},
+)
+
+const NodesCacheMissesMetricName = "cs_node_cache_misses_total"
+
+var NodesCacheMisses = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: NodesCacheMissesMetricName,
+		Help: "Total events for which the node statics were run and their result cached.",
+	},
+	[]string{"name", "stage"},
+)
@@ -23,6 +23,9 @@ type DecisionsStreamResponse struct {
 
 	// new
 	New GetDecisionsResponse `json:"new,omitempty"`
+
+	// Opaque cursor to provide to get the next page of a paginated startup pull. Absent on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // Validate validates this decisions stream response
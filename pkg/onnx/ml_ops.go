@@ -0,0 +1,641 @@
+package onnx
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// operators of the ai.onnx.ml domain, as exported by skl2onnx and onnxmltools
+var mlOps = map[string]opFunc{
+	"LinearClassifier":       opLinearClassifier,
+	"LinearRegressor":        opLinearRegressor,
+	"Normalizer":             opNormalizer,
+	"Scaler":                 opScaler,
+	"TreeEnsembleClassifier": opTreeEnsemble,
+	"TreeEnsembleRegressor":  opTreeEnsemble,
+}
+
+// mlPreparers parse the attributes of an operator once, when the model is loaded.
+var mlPreparers = map[string]func(n *node) error{
+	"TreeEnsembleClassifier": prepareTreeEnsemble,
+	"TreeEnsembleRegressor":  prepareTreeEnsemble,
+}
+
+// floatsOrTensor returns a float attribute that may also be given as a tensor (<name>_as_tensor).
+func floatsOrTensor(n *node, name string) []float64 {
+	if t := n.attrTensor(name + "_as_tensor"); t != nil {
+		return t.Data
+	}
+
+	return n.attrFloats(name)
+}
+
+// postTransform applies the post_transform of a model to each row of scores.
+func postTransform(transform string, scores []float64, cols int) error {
+	switch transform {
+	case "", "NONE":
+	case "LOGISTIC":
+		for i, v := range scores {
+			scores[i] = sigmoid(v)
+		}
+	case "SOFTMAX":
+		softmaxRows(scores, cols)
+	case "SOFTMAX_ZERO":
+		for start := 0; start+cols <= len(scores); start += cols {
+			row := scores[start : start+cols]
+			maxv := slices.Max(row)
+			sum := 0.0
+
+			for i, v := range row {
+				if v != 0 {
+					row[i] = math.Exp(v - maxv)
+					sum += row[i]
+				}
+			}
+
+			for i := range row {
+				if sum > 0 {
+					row[i] /= sum
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported post_transform %s", transform)
+	}
+
+	return nil
+}
+
+// classLabels returns the labels of a classifier: a string tensor or a numeric one, to fill
+// with the predicted classes.
+func classLabels(n *node, intsAttr string) ([]string, []int64, int) {
+	if s := n.attrStrings("classlabels_strings"); len(s) > 0 {
+		return s, nil, len(s)
+	}
+
+	ints := n.attrInts(intsAttr)
+
+	return nil, ints, len(ints)
+}
+
+// labelTensor returns the predicted labels, from the index of the winning class of each row.
+func labelTensor(strs []string, ints []int64, winners []int) *Tensor {
+	if strs != nil {
+		ret := &Tensor{Shape: []int{len(winners)}, Strings: make([]string, len(winners))}
+		for i, w := range winners {
+			ret.Strings[i] = strs[w]
+		}
+
+		return ret
+	}
+
+	ret := newTensor([]int{len(winners)})
+	for i, w := range winners {
+		ret.Data[i] = float64(ints[w])
+	}
+
+	return ret
+}
+
+func argmax(row []float64) int {
+	best := 0
+
+	for i, v := range row {
+		if v > row[best] {
+			best = i
+		}
+	}
+
+	return best
+}
+
+// linear computes x . coefficients^T + intercepts, for each row of x.
+func linear(x *Tensor, coefficients []float64, intercepts []float64, targets int) (*Tensor, int, error) {
+	batch, cols := rows(x)
+
+	if targets <= 0 || len(coefficients) != targets*cols {
+		return nil, 0, fmt.Errorf("%d coefficients for %d features and %d targets", len(coefficients), cols, targets)
+	}
+
+	if len(intercepts) != 0 && len(intercepts) != targets {
+		return nil, 0, fmt.Errorf("%d intercepts for %d targets", len(intercepts), targets)
+	}
+
+	ret := matmul2D(x.Data, transpose2D(coefficients, targets, cols), batch, cols, targets)
+
+	if len(intercepts) > 0 {
+		for i := range ret {
+			ret[i] += intercepts[i%targets]
+		}
+	}
+
+	return &Tensor{Shape: []int{batch, targets}, Data: ret}, batch, nil
+}
+
+func opLinearClassifier(n *node, in []*Tensor) ([]*Tensor, error) {
+	x, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	strs, ints, classes := classLabels(n, "classlabels_ints")
+	coefficients := n.attrFloats("coefficients")
+	intercepts := n.attrFloats("intercepts")
+
+	targets := len(intercepts)
+	if targets == 0 {
+		targets = classes
+	}
+
+	scores, batch, err := linear(x, coefficients, intercepts, targets)
+	if err != nil {
+		return nil, err
+	}
+
+	transform := n.attrString("post_transform", "NONE")
+	winners := make([]int, batch)
+
+	// binary classifier with a single score: the score of the positive class
+	if targets == 1 && classes == 2 {
+		binary := newTensor([]int{batch, 2})
+
+		for i, s := range scores.Data {
+			if s > 0 {
+				winners[i] = 1
+			}
+
+			switch transform {
+			case "LOGISTIC":
+				binary.Data[2*i], binary.Data[2*i+1] = sigmoid(-s), sigmoid(s)
+			case "NONE", "":
+				binary.Data[2*i], binary.Data[2*i+1] = -s, s
+			default:
+				return nil, fmt.Errorf("unsupported post_transform %s for a binary classifier", transform)
+			}
+		}
+
+		return []*Tensor{labelTensor(strs, ints, winners), binary}, nil
+	}
+
+	if targets != classes {
+		return nil, fmt.Errorf("%d classes for %d targets", classes, targets)
+	}
+
+	if err := postTransform(transform, scores.Data, targets); err != nil {
+		return nil, err
+	}
+
+	for i := range batch {
+		winners[i] = argmax(scores.Data[i*targets : (i+1)*targets])
+	}
+
+	return []*Tensor{labelTensor(strs, ints, winners), scores}, nil
+}
+
+func opLinearRegressor(n *node, in []*Tensor) ([]*Tensor, error) {
+	x, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := int(n.attrInt("targets", 1))
+
+	ret, _, err := linear(x, n.attrFloats("coefficients"), n.attrFloats("intercepts"), targets)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := postTransform(n.attrString("post_transform", "NONE"), ret.Data, targets); err != nil {
+		return nil, err
+	}
+
+	return []*Tensor{ret}, nil
+}
+
+func opNormalizer(n *node, in []*Tensor) ([]*Tensor, error) {
+	x, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, cols := rows(x)
+	norm := n.attrString("norm", "MAX")
+	ret := &Tensor{Shape: []int{batch, cols}, Data: slices.Clone(x.Data)}
+
+	for i := range batch {
+		row := ret.Data[i*cols : (i+1)*cols]
+		div := 0.0
+
+		for _, v := range row {
+			switch norm {
+			case "MAX":
+				div = max(div, math.Abs(v))
+			case "L1":
+				div += math.Abs(v)
+			case "L2":
+				div += v * v
+			default:
+				return nil, fmt.Errorf("unsupported norm %s", norm)
+			}
+		}
+
+		if norm == "L2" {
+			div = math.Sqrt(div)
+		}
+
+		if div == 0 {
+			continue
+		}
+
+		for j := range row {
+			row[j] /= div
+		}
+	}
+
+	return []*Tensor{ret}, nil
+}
+
+func opScaler(n *node, in []*Tensor) ([]*Tensor, error) {
+	x, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	_, cols := rows(x)
+	offset := n.attrFloats("offset")
+	scale := n.attrFloats("scale")
+
+	for _, p := range [][]float64{offset, scale} {
+		if len(p) > 1 && len(p) != cols {
+			return nil, fmt.Errorf("%d parameters for %d features", len(p), cols)
+		}
+	}
+
+	param := func(p []float64, i int, def float64) float64 {
+		switch len(p) {
+		case 0:
+			return def
+		case 1:
+			return p[0]
+		default:
+			return p[i%cols]
+		}
+	}
+
+	ret := newTensor(slices.Clone(x.Shape))
+	for i, v := range x.Data {
+		ret.Data[i] = (v - param(offset, i, 0)) * param(scale, i, 1)
+	}
+
+	return []*Tensor{ret}, nil
+}
+
+// branch modes of the tree nodes
+const (
+	branchLeaf = iota
+	branchLEQ
+	branchLT
+	branchGTE
+	branchGT
+	branchEQ
+	branchNEQ
+)
+
+var branchModes = map[string]int{
+	"LEAF":       branchLeaf,
+	"BRANCH_LEQ": branchLEQ,
+	"BRANCH_LT":  branchLT,
+	"BRANCH_GTE": branchGTE,
+	"BRANCH_GT":  branchGT,
+	"BRANCH_EQ":  branchEQ,
+	"BRANCH_NEQ": branchNEQ,
+}
+
+type leafWeight struct {
+	target int
+	weight float64
+}
+
+type treeNode struct {
+	mode        int
+	feature     int
+	value       float64
+	trueNode    int
+	falseNode   int
+	missingTrue bool
+	weights     []leafWeight
+}
+
+// treeEnsemble is the parsed form of TreeEnsembleClassifier and TreeEnsembleRegressor.
+type treeEnsemble struct {
+	nodes      []treeNode
+	roots      []int
+	targets    int
+	aggregate  string
+	baseValues []float64
+	transform  string
+	// classifiers only
+	classifier   bool
+	labelStrings []string
+	labelInts    []int64
+	binary       bool // a single score, for the positive class
+	allPositive  bool // all the weights are positive
+	maxFeature   int
+}
+
+func prepareTreeEnsemble(n *node) error {
+	ens := &treeEnsemble{
+		classifier: n.opType == "TreeEnsembleClassifier",
+		aggregate:  n.attrString("aggregate_function", "SUM"),
+		baseValues: floatsOrTensor(n, "base_values"),
+		transform:  n.attrString("post_transform", "NONE"),
+		maxFeature: -1,
+	}
+
+	treeIDs := n.attrInts("nodes_treeids")
+	nodeIDs := n.attrInts("nodes_nodeids")
+	features := n.attrInts("nodes_featureids")
+	values := floatsOrTensor(n, "nodes_values")
+	modes := n.attrStrings("nodes_modes")
+	trueIDs := n.attrInts("nodes_truenodeids")
+	falseIDs := n.attrInts("nodes_falsenodeids")
+	missing := n.attrInts("nodes_missing_value_tracks_true")
+
+	count := len(nodeIDs)
+	for _, l := range []int{len(treeIDs), len(features), len(values), len(modes), len(trueIDs), len(falseIDs)} {
+		if l != count {
+			return fmt.Errorf("nodes attributes of different lengths (%d and %d)", count, l)
+		}
+	}
+
+	if len(missing) != 0 && len(missing) != count {
+		return fmt.Errorf("nodes attributes of different lengths (%d and %d)", count, len(missing))
+	}
+
+	type key struct{ tree, node int64 }
+
+	index := make(map[key]int, count)
+	ens.nodes = make([]treeNode, count)
+
+	for i := range count {
+		mode, ok := branchModes[modes[i]]
+		if !ok {
+			return fmt.Errorf("unsupported node mode %s", modes[i])
+		}
+
+		k := key{treeIDs[i], nodeIDs[i]}
+		if _, dup := index[k]; dup {
+			return fmt.Errorf("duplicate node %d in tree %d", k.node, k.tree)
+		}
+
+		// the first node of a tree is its root
+		if !slices.Contains(treeIDs[:i], treeIDs[i]) {
+			ens.roots = append(ens.roots, i)
+		}
+
+		index[k] = i
+		ens.nodes[i] = treeNode{
+			mode:        mode,
+			feature:     int(features[i]),
+			value:       values[i],
+			missingTrue: len(missing) > 0 && missing[i] != 0,
+		}
+
+		if mode != branchLeaf {
+			ens.maxFeature = max(ens.maxFeature, int(features[i]))
+		}
+	}
+
+	for i := range count {
+		if ens.nodes[i].mode == branchLeaf {
+			continue
+		}
+
+		t, ok := index[key{treeIDs[i], trueIDs[i]}]
+		f, ok2 := index[key{treeIDs[i], falseIDs[i]}]
+
+		if !ok || !ok2 {
+			return fmt.Errorf("node %d of tree %d: missing child", nodeIDs[i], treeIDs[i])
+		}
+
+		ens.nodes[i].trueNode, ens.nodes[i].falseNode = t, f
+	}
+
+	prefix := "target"
+	if ens.classifier {
+		prefix = "class"
+	}
+
+	wTrees := n.attrInts(prefix + "_treeids")
+	wNodes := n.attrInts(prefix + "_nodeids")
+	wIDs := n.attrInts(prefix + "_ids")
+	weights := floatsOrTensor(n, prefix+"_weights")
+
+	if len(wNodes) != len(wTrees) || len(wIDs) != len(wTrees) || len(weights) != len(wTrees) {
+		return fmt.Errorf("%s attributes of different lengths", prefix)
+	}
+
+	if ens.classifier {
+		var classes int
+
+		ens.labelStrings, ens.labelInts, classes = classLabels(n, "classlabels_int64s")
+		if classes == 0 {
+			return fmt.Errorf("no class labels")
+		}
+
+		ens.targets = classes
+		ens.binary = classes == 2 && len(wIDs) > 0 && !slices.ContainsFunc(wIDs, func(id int64) bool { return id != wIDs[0] })
+		ens.allPositive = !slices.ContainsFunc(weights, func(w float64) bool { return w < 0 })
+	} else {
+		ens.targets = int(n.attrInt("n_targets", 1))
+	}
+
+	for i := range wTrees {
+		idx, ok := index[key{wTrees[i], wNodes[i]}]
+		if !ok {
+			return fmt.Errorf("weight of an unknown node %d in tree %d", wNodes[i], wTrees[i])
+		}
+
+		target := int(wIDs[i])
+		if ens.binary {
+			target = 0
+		}
+
+		if target < 0 || target >= ens.targets {
+			return fmt.Errorf("weight of an unknown target %d", target)
+		}
+
+		ens.nodes[idx].weights = append(ens.nodes[idx].weights, leafWeight{target: target, weight: weights[i]})
+	}
+
+	switch ens.aggregate {
+	case "SUM", "AVERAGE", "MIN", "MAX":
+	default:
+		return fmt.Errorf("unsupported aggregate_function %s", ens.aggregate)
+	}
+
+	if err := postTransform(ens.transform, nil, 1); err != nil {
+		return err
+	}
+
+	n.state = ens
+
+	return nil
+}
+
+// leaf returns the leaf reached by a row of features in a tree.
+func (ens *treeEnsemble) leaf(root int, row []float64) *treeNode {
+	tn := &ens.nodes[root]
+
+	for tn.mode != branchLeaf {
+		x := row[tn.feature]
+
+		var ok bool
+
+		switch {
+		case math.IsNaN(x) && tn.missingTrue:
+			ok = true
+		case tn.mode == branchLEQ:
+			ok = x <= tn.value
+		case tn.mode == branchLT:
+			ok = x < tn.value
+		case tn.mode == branchGTE:
+			ok = x >= tn.value
+		case tn.mode == branchGT:
+			ok = x > tn.value
+		case tn.mode == branchEQ:
+			ok = x == tn.value
+		case tn.mode == branchNEQ:
+			ok = x != tn.value
+		}
+
+		if ok {
+			tn = &ens.nodes[tn.trueNode]
+		} else {
+			tn = &ens.nodes[tn.falseNode]
+		}
+	}
+
+	return tn
+}
+
+// predict returns the aggregated scores of a row of features.
+func (ens *treeEnsemble) predict(row []float64, scores []float64) {
+	seen := make([]bool, len(scores))
+
+	for _, root := range ens.roots {
+		for _, w := range ens.leaf(root, row).weights {
+			switch {
+			case !seen[w.target]:
+				scores[w.target] = w.weight
+			case ens.aggregate == "MIN":
+				scores[w.target] = min(scores[w.target], w.weight)
+			case ens.aggregate == "MAX":
+				scores[w.target] = max(scores[w.target], w.weight)
+			default:
+				scores[w.target] += w.weight
+			}
+
+			seen[w.target] = true
+		}
+	}
+
+	if ens.aggregate == "AVERAGE" && len(ens.roots) > 0 {
+		for i := range scores {
+			scores[i] /= float64(len(ens.roots))
+		}
+	}
+}
+
+func opTreeEnsemble(n *node, in []*Tensor) ([]*Tensor, error) {
+	ens, ok := n.state.(*treeEnsemble)
+	if !ok {
+		return nil, fmt.Errorf("tree ensemble was not prepared")
+	}
+
+	x, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, cols := rows(x)
+	if ens.maxFeature >= cols {
+		return nil, fmt.Errorf("%d features, the model uses %d", cols, ens.maxFeature+1)
+	}
+
+	scores := newTensor([]int{batch, ens.targets})
+
+	if !ens.classifier {
+		for i := range batch {
+			row := scores.Data[i*ens.targets : (i+1)*ens.targets]
+			ens.predict(x.Data[i*cols:(i+1)*cols], row)
+
+			for j := range row {
+				if j < len(ens.baseValues) {
+					row[j] += ens.baseValues[j]
+				}
+			}
+		}
+
+		if err := postTransform(ens.transform, scores.Data, ens.targets); err != nil {
+			return nil, err
+		}
+
+		return []*Tensor{scores}, nil
+	}
+
+	winners := make([]int, batch)
+
+	for i := range batch {
+		row := scores.Data[i*ens.targets : (i+1)*ens.targets]
+		ens.predict(x.Data[i*cols:(i+1)*cols], row)
+
+		if !ens.binary {
+			for j := range row {
+				if j < len(ens.baseValues) {
+					row[j] += ens.baseValues[j]
+				}
+			}
+
+			winners[i] = argmax(row)
+
+			continue
+		}
+
+		// binary case: the single score is the one of the positive class
+		s := row[0]
+		if len(ens.baseValues) > 0 {
+			s += ens.baseValues[len(ens.baseValues)-1]
+		}
+
+		switch {
+		case ens.allPositive:
+			row[0], row[1] = 1-s, s
+
+			if s > 0.5 {
+				winners[i] = 1
+			}
+		case ens.transform == "LOGISTIC":
+			row[0], row[1] = sigmoid(-s), sigmoid(s)
+
+			if s > 0 {
+				winners[i] = 1
+			}
+		default:
+			row[0], row[1] = -s, s
+
+			if s > 0 {
+				winners[i] = 1
+			}
+		}
+	}
+
+	if !ens.binary {
+		if err := postTransform(ens.transform, scores.Data, ens.targets); err != nil {
+			return nil, err
+		}
+	}
+
+	return []*Tensor{labelTensor(ens.labelStrings, ens.labelInts, winners), scores}, nil
+}
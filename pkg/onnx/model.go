@@ -0,0 +1,256 @@
+// Package onnx runs machine learning models in the ONNX format, without a native runtime.
+//
+// Only the operators found in the models exported from the classic scikit-learn estimators
+// (linear models, decision trees, random forests, gradient boosting) and in small neural
+// networks are supported. A model with another operator is rejected when it's loaded.
+package onnx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+const (
+	domainDefault = ""
+	domainONNX    = "ai.onnx"
+	domainML      = "ai.onnx.ml"
+)
+
+// Model is a loaded ONNX model, it can be run from several goroutines.
+type Model struct {
+	graph      *graph
+	input      string
+	inputShape []int64
+	outputs    []string
+	scoreName  string // first float output
+}
+
+// Load reads an ONNX model from a file.
+func Load(path string) (*Model, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// Parse reads an ONNX model, and checks that all its operators are supported.
+func Parse(b []byte) (*Model, error) {
+	proto, err := decodeModel(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid model: %w", err)
+	}
+
+	g := proto.graph
+
+	for i, n := range g.nodes {
+		if err := prepareNode(n, proto.opsets); err != nil {
+			name := n.name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i)
+			}
+
+			return nil, fmt.Errorf("node %s (%s): %w", name, n.opType, err)
+		}
+	}
+
+	m := &Model{graph: g}
+
+	for _, vi := range g.inputs {
+		// old exporters list the initializers as inputs
+		if _, ok := g.initializers[vi.name]; ok {
+			continue
+		}
+
+		if m.input != "" {
+			return nil, errors.New("models with several inputs are not supported")
+		}
+
+		if !vi.isTensor || vi.elemType == typeString {
+			return nil, fmt.Errorf("input %s: only numeric tensors are supported", vi.name)
+		}
+
+		m.input = vi.name
+		m.inputShape = vi.shape
+	}
+
+	if m.input == "" {
+		return nil, errors.New("model has no input")
+	}
+
+	for _, vi := range g.outputs {
+		m.outputs = append(m.outputs, vi.name)
+
+		if m.scoreName == "" && (vi.elemType == typeFloat || vi.elemType == typeDouble) {
+			m.scoreName = vi.name
+		}
+	}
+
+	if len(m.outputs) == 0 {
+		return nil, errors.New("model has no output")
+	}
+
+	return m, nil
+}
+
+func prepareNode(n *node, opsets map[string]int64) error {
+	switch n.domain {
+	case domainDefault, domainONNX:
+		if _, ok := ops[n.opType]; !ok {
+			return errors.New("unsupported operator")
+		}
+
+		n.opset = opsets[domainDefault]
+		if v, ok := opsets[domainONNX]; ok {
+			n.opset = v
+		}
+	case domainML:
+		if n.opType == "ZipMap" {
+			return errors.New("unsupported operator, the model must be exported without zipmap (ie. options={'zipmap': False} with skl2onnx)")
+		}
+
+		if _, ok := mlOps[n.opType]; !ok {
+			return errors.New("unsupported operator")
+		}
+
+		n.opset = opsets[domainML]
+
+		if prepare, ok := mlPreparers[n.opType]; ok {
+			return prepare(n)
+		}
+	default:
+		return fmt.Errorf("unsupported domain %q", n.domain)
+	}
+
+	return nil
+}
+
+// Outputs returns the names of the outputs of the model.
+func (m *Model) Outputs() []string {
+	return slices.Clone(m.outputs)
+}
+
+// NumFeatures returns the number of features expected by the model, 0 if it's not known.
+func (m *Model) NumFeatures() int {
+	if len(m.inputShape) == 0 {
+		return 0
+	}
+
+	return int(max(m.inputShape[len(m.inputShape)-1], 0))
+}
+
+// Run runs the model on an input tensor, and returns its outputs by name.
+func (m *Model) Run(x *Tensor) (map[string]*Tensor, error) {
+	values := make(map[string]*Tensor, len(m.graph.initializers)+len(m.graph.nodes)+1)
+
+	for name, t := range m.graph.initializers {
+		values[name] = t
+	}
+
+	values[m.input] = x
+
+	for _, n := range m.graph.nodes {
+		in := make([]*Tensor, len(n.inputs))
+
+		for i, name := range n.inputs {
+			if name == "" {
+				continue
+			}
+
+			t, ok := values[name]
+			if !ok {
+				return nil, fmt.Errorf("node %s (%s): unknown input %s", n.name, n.opType, name)
+			}
+
+			in[i] = t
+		}
+
+		op := ops[n.opType]
+		if n.domain == domainML {
+			op = mlOps[n.opType]
+		}
+
+		out, err := op(n, in)
+		if err != nil {
+			return nil, fmt.Errorf("node %s (%s): %w", n.name, n.opType, err)
+		}
+
+		for i, name := range n.outputs {
+			if i < len(out) && name != "" {
+				values[name] = out[i]
+			}
+		}
+	}
+
+	ret := make(map[string]*Tensor, len(m.outputs))
+
+	for _, name := range m.outputs {
+		t, ok := values[name]
+		if !ok {
+			return nil, fmt.Errorf("output %s was not computed", name)
+		}
+
+		ret[name] = t
+	}
+
+	return ret, nil
+}
+
+// Score runs the model on a vector of features, and returns an element of an output.
+// With an empty output name, the first float output is used (the probabilities of a
+// classifier, the value of a regressor). A negative index selects the last element, which
+// is the probability of the positive class of a binary classifier.
+func (m *Model) Score(features []float64, output string, index int) (float64, error) {
+	if n := m.NumFeatures(); n > 0 && len(features) != n {
+		return 0, fmt.Errorf("model expects %d features, got %d", n, len(features))
+	}
+
+	x := &Tensor{Shape: []int{1, len(features)}, Data: features}
+	if len(m.inputShape) == 1 {
+		x.Shape = []int{len(features)}
+	}
+
+	outputs, err := m.Run(x)
+	if err != nil {
+		return 0, err
+	}
+
+	if output == "" {
+		if m.scoreName == "" {
+			return 0, errors.New("model has no float output, the output to use must be set")
+		}
+
+		output = m.scoreName
+	}
+
+	t := outputs[output]
+	if t == nil {
+		return 0, fmt.Errorf("model has no output %s", output)
+	}
+
+	if t.isString() {
+		return 0, fmt.Errorf("output %s is not numeric", output)
+	}
+
+	if len(t.Data) == 0 {
+		return 0, errors.New("empty output")
+	}
+
+	if index < 0 {
+		index = len(t.Data) - 1
+	}
+
+	if index >= len(t.Data) {
+		return 0, fmt.Errorf("index %d out of range, output has %d elements", index, len(t.Data))
+	}
+
+	return t.Data[index], nil
+}
@@ -0,0 +1,537 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+// encoders of the ONNX messages, to build the test models
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func appendVarint(b []byte, num protowire.Number, v int64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+// floatTensor encodes a float tensor, in raw_data.
+func floatTensor(name string, dims []int64, values ...float32) []byte {
+	var b []byte
+
+	for _, d := range dims {
+		b = appendVarint(b, 1, d)
+	}
+
+	b = appendVarint(b, 2, typeFloat)
+	b = appendString(b, 8, name)
+
+	raw := make([]byte, 0, 4*len(values))
+	for _, v := range values {
+		raw = binary.LittleEndian.AppendUint32(raw, math.Float32bits(v))
+	}
+
+	return appendMessage(b, 9, raw)
+}
+
+// int64Tensor encodes an int64 tensor, in packed int64_data.
+func int64Tensor(name string, dims []int64, values ...int64) []byte {
+	var b []byte
+
+	for _, d := range dims {
+		b = appendVarint(b, 1, d)
+	}
+
+	b = appendVarint(b, 2, typeInt64)
+	b = appendString(b, 8, name)
+
+	var packed []byte
+	for _, v := range values {
+		packed = protowire.AppendVarint(packed, uint64(v))
+	}
+
+	return appendMessage(b, 7, packed)
+}
+
+func intAttr(name string, v int64) []byte {
+	return appendVarint(appendString(nil, 1, name), 3, v)
+}
+
+func floatAttr(name string, v float32) []byte {
+	b := appendString(nil, 1, name)
+	b = protowire.AppendTag(b, 2, protowire.Fixed32Type)
+
+	return protowire.AppendFixed32(b, math.Float32bits(v))
+}
+
+func stringAttr(name string, v string) []byte {
+	return appendString(appendString(nil, 1, name), 4, v)
+}
+
+func intsAttr(name string, values ...int64) []byte {
+	b := appendString(nil, 1, name)
+	for _, v := range values {
+		b = appendVarint(b, 8, v)
+	}
+
+	return b
+}
+
+func floatsAttr(name string, values ...float32) []byte {
+	b := appendString(nil, 1, name)
+
+	var packed []byte
+	for _, v := range values {
+		packed = binary.LittleEndian.AppendUint32(packed, math.Float32bits(v))
+	}
+
+	return appendMessage(b, 7, packed)
+}
+
+func stringsAttr(name string, values ...string) []byte {
+	b := appendString(nil, 1, name)
+	for _, v := range values {
+		b = appendString(b, 9, v)
+	}
+
+	return b
+}
+
+func testNode(opType string, domain string, inputs []string, outputs []string, attrs ...[]byte) []byte {
+	var b []byte
+
+	for _, in := range inputs {
+		b = appendString(b, 1, in)
+	}
+
+	for _, out := range outputs {
+		b = appendString(b, 2, out)
+	}
+
+	b = appendString(b, 3, opType+"_"+outputs[0])
+	b = appendString(b, 4, opType)
+
+	for _, a := range attrs {
+		b = appendMessage(b, 5, a)
+	}
+
+	if domain != "" {
+		b = appendString(b, 7, domain)
+	}
+
+	return b
+}
+
+func encodeValueInfo(name string, elemType int64, dims ...int64) []byte {
+	var shape []byte
+
+	for _, d := range dims {
+		var dim []byte
+		if d >= 0 {
+			dim = appendVarint(nil, 1, d)
+		} else {
+			dim = appendString(nil, 2, "N")
+		}
+
+		shape = appendMessage(shape, 1, dim)
+	}
+
+	tensorType := appendVarint(nil, 1, elemType)
+	tensorType = appendMessage(tensorType, 2, shape)
+
+	b := appendString(nil, 1, name)
+
+	return appendMessage(b, 2, appendMessage(nil, 1, tensorType))
+}
+
+type testGraph struct {
+	nodes        [][]byte
+	initializers [][]byte
+	inputs       [][]byte
+	outputs      [][]byte
+}
+
+func testModel(g testGraph) []byte {
+	var graph []byte
+
+	for _, n := range g.nodes {
+		graph = appendMessage(graph, 1, n)
+	}
+
+	graph = appendString(graph, 2, "test")
+
+	for _, t := range g.initializers {
+		graph = appendMessage(graph, 5, t)
+	}
+
+	for _, vi := range g.inputs {
+		graph = appendMessage(graph, 11, vi)
+	}
+
+	for _, vi := range g.outputs {
+		graph = appendMessage(graph, 12, vi)
+	}
+
+	b := appendVarint(nil, 1, 8)
+	b = appendMessage(b, 8, appendVarint(nil, 2, 15))
+	b = appendMessage(b, 8, appendVarint(appendString(nil, 1, domainML), 2, 3))
+
+	return appendMessage(b, 7, graph)
+}
+
+// logisticRegression is a model of 3 features, as exported from pytorch.
+func logisticRegression() []byte {
+	return testModel(testGraph{
+		nodes: [][]byte{
+			testNode("MatMul", "", []string{"X", "W"}, []string{"xw"}),
+			testNode("Add", "", []string{"xw", "B"}, []string{"logit"}),
+			testNode("Sigmoid", "", []string{"logit"}, []string{"Y"}),
+		},
+		initializers: [][]byte{
+			floatTensor("W", []int64{3, 1}, 1, -2, 0.5),
+			floatTensor("B", []int64{1}, 0.25),
+		},
+		inputs:  [][]byte{encodeValueInfo("X", typeFloat, -1, 3)},
+		outputs: [][]byte{encodeValueInfo("Y", typeFloat, -1, 1)},
+	})
+}
+
+func TestScoreLogisticRegression(t *testing.T) {
+	m, err := Parse(logisticRegression())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Y"}, m.Outputs())
+	assert.Equal(t, 3, m.NumFeatures())
+
+	score, err := m.Score([]float64{1, 2, 4}, "", -1)
+	require.NoError(t, err)
+	assert.InDelta(t, sigmoid(1-4+2+0.25), score, 1e-9)
+
+	_, err = m.Score([]float64{1, 2}, "", -1)
+	cstest.RequireErrorContains(t, err, "model expects 3 features, got 2")
+
+	_, err = m.Score([]float64{1, 2, 4}, "Z", -1)
+	cstest.RequireErrorContains(t, err, "model has no output Z")
+
+	_, err = m.Score([]float64{1, 2, 4}, "", 1)
+	cstest.RequireErrorContains(t, err, "index 1 out of range, output has 1 elements")
+}
+
+func TestScoreConcurrent(t *testing.T) {
+	m, err := Parse(logisticRegression())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := range 8 {
+		wg.Go(func() {
+			for range 100 {
+				score, err := m.Score([]float64{float64(i), 0, 0}, "", -1)
+				assert.NoError(t, err)
+				assert.InDelta(t, sigmoid(float64(i)+0.25), score, 1e-9)
+			}
+		})
+	}
+
+	wg.Wait()
+}
+
+func TestScoreMLP(t *testing.T) {
+	// 2 features, a hidden layer of 2 units, 2 classes
+	m, err := Parse(testModel(testGraph{
+		nodes: [][]byte{
+			testNode("Gemm", "", []string{"X", "W1", "B1"}, []string{"h"}, intAttr("transB", 1)),
+			testNode("Relu", "", []string{"h"}, []string{"hr"}),
+			testNode("Gemm", "", []string{"hr", "W2", "B2"}, []string{"logits"}),
+			testNode("Softmax", "", []string{"logits"}, []string{"probabilities"}, intAttr("axis", -1)),
+		},
+		initializers: [][]byte{
+			floatTensor("W1", []int64{2, 2}, 1, 0, 0, -1),
+			floatTensor("B1", []int64{2}, 0, 1),
+			floatTensor("W2", []int64{2, 2}, 1, -1, -1, 1),
+			floatTensor("B2", []int64{2}, 0, 0),
+		},
+		inputs:  [][]byte{encodeValueInfo("X", typeFloat, -1, 2)},
+		outputs: [][]byte{encodeValueInfo("probabilities", typeFloat, -1, 2)},
+	}))
+	require.NoError(t, err)
+
+	// h = relu([3, -2+1]) = [3, 0], logits = [3, -3]
+	out, err := m.Run(&Tensor{Shape: []int{1, 2}, Data: []float64{3, 2}})
+	require.NoError(t, err)
+
+	p := out["probabilities"]
+	assert.Equal(t, []int{1, 2}, p.Shape)
+	assert.InDelta(t, 1/(1+math.Exp(-6)), p.Data[0], 1e-9)
+	assert.InDelta(t, 1/(1+math.Exp(6)), p.Data[1], 1e-9)
+
+	score, err := m.Score([]float64{3, 2}, "probabilities", 0)
+	require.NoError(t, err)
+	assert.InDelta(t, p.Data[0], score, 1e-9)
+}
+
+func TestScoreLinearClassifier(t *testing.T) {
+	// as exported by skl2onnx for a binary LogisticRegression, with zipmap disabled
+	m, err := Parse(testModel(testGraph{
+		nodes: [][]byte{
+			testNode("Scaler", domainML, []string{"X"}, []string{"scaled"},
+				floatsAttr("offset", 1, 0), floatsAttr("scale", 0.5)),
+			testNode("LinearClassifier", domainML, []string{"scaled"}, []string{"label", "probabilities"},
+				floatsAttr("coefficients", -1, -2, 1, 2),
+				floatsAttr("intercepts", -0.5, 0.5),
+				intsAttr("classlabels_ints", 0, 1),
+				stringAttr("post_transform", "LOGISTIC")),
+			testNode("Normalizer", domainML, []string{"probabilities"}, []string{"normalized"}, stringAttr("norm", "L1")),
+		},
+		inputs: [][]byte{encodeValueInfo("X", typeFloat, -1, 2)},
+		outputs: [][]byte{
+			encodeValueInfo("label", typeInt64, -1),
+			encodeValueInfo("normalized", typeFloat, -1, 2),
+		},
+	}))
+	require.NoError(t, err)
+
+	// scaled = [1.5, 0.5], z = 1.5 + 1 + 0.5 = 3
+	out, err := m.Run(&Tensor{Shape: []int{1, 2}, Data: []float64{4, 1}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []float64{1}, out["label"].Data)
+	assert.InDelta(t, sigmoid(3), out["normalized"].Data[1], 1e-9)
+
+	// the label output is skipped, it's not a float
+	score, err := m.Score([]float64{4, 1}, "", -1)
+	require.NoError(t, err)
+	assert.InDelta(t, sigmoid(3), score, 1e-9)
+}
+
+// decisionTree returns a tree on 2 features:
+//
+//	x0 <= 0.5 ? (x1 <= 10 ? leaf 2 : leaf 3) : leaf 4
+func decisionTree() [][]byte {
+	return [][]byte{
+		intsAttr("nodes_treeids", 0, 0, 0, 0, 0),
+		intsAttr("nodes_nodeids", 0, 1, 2, 3, 4),
+		intsAttr("nodes_featureids", 0, 1, 0, 0, 0),
+		floatsAttr("nodes_values", 0.5, 10, 0, 0, 0),
+		stringsAttr("nodes_modes", "BRANCH_LEQ", "BRANCH_LEQ", "LEAF", "LEAF", "LEAF"),
+		intsAttr("nodes_truenodeids", 1, 2, 0, 0, 0),
+		intsAttr("nodes_falsenodeids", 4, 3, 0, 0, 0),
+	}
+}
+
+func TestScoreTreeEnsembleClassifier(t *testing.T) {
+	attrs := append(decisionTree(),
+		intsAttr("class_treeids", 0, 0, 0, 0, 0, 0),
+		intsAttr("class_nodeids", 2, 2, 3, 3, 4, 4),
+		intsAttr("class_ids", 0, 1, 0, 1, 0, 1),
+		floatsAttr("class_weights", 0.9, 0.1, 0.25, 0.75, 0, 1),
+		stringsAttr("classlabels_strings", "benign", "malicious"),
+	)
+
+	m, err := Parse(testModel(testGraph{
+		nodes: [][]byte{
+			testNode("TreeEnsembleClassifier", domainML, []string{"X"}, []string{"label", "probabilities"}, attrs...),
+		},
+		inputs: [][]byte{encodeValueInfo("X", typeFloat, -1, 2)},
+		outputs: [][]byte{
+			encodeValueInfo("label", typeString, -1),
+			encodeValueInfo("probabilities", typeFloat, -1, 2),
+		},
+	}))
+	require.NoError(t, err)
+
+	tests := []struct {
+		features []float64
+		label    string
+		score    float64
+	}{
+		{[]float64{0, 5}, "benign", 0.1},
+		{[]float64{0, 20}, "malicious", 0.75},
+		{[]float64{1, 5}, "malicious", 1},
+		{[]float64{math.NaN(), 5}, "malicious", 1},
+	}
+
+	for _, tc := range tests {
+		out, err := m.Run(&Tensor{Shape: []int{1, 2}, Data: tc.features})
+		require.NoError(t, err)
+		assert.Equal(t, []string{tc.label}, out["label"].Strings)
+
+		score, err := m.Score(tc.features, "", -1)
+		require.NoError(t, err)
+		assert.InDelta(t, tc.score, score, 1e-6)
+	}
+}
+
+func TestScoreTreeEnsembleRegressor(t *testing.T) {
+	// the tree of decisionTree, twice, with different weights
+	attrs := [][]byte{
+		intsAttr("nodes_treeids", 0, 0, 0, 0, 0, 1, 1, 1, 1, 1),
+		intsAttr("nodes_nodeids", 0, 1, 2, 3, 4, 0, 1, 2, 3, 4),
+		intsAttr("nodes_featureids", 0, 1, 0, 0, 0, 0, 1, 0, 0, 0),
+		floatsAttr("nodes_values", 0.5, 10, 0, 0, 0, 0.5, 10, 0, 0, 0),
+		stringsAttr("nodes_modes", "BRANCH_LEQ", "BRANCH_LEQ", "LEAF", "LEAF", "LEAF", "BRANCH_LEQ", "BRANCH_LEQ", "LEAF", "LEAF", "LEAF"),
+		intsAttr("nodes_truenodeids", 1, 2, 0, 0, 0, 1, 2, 0, 0, 0),
+		intsAttr("nodes_falsenodeids", 4, 3, 0, 0, 0, 4, 3, 0, 0, 0),
+		intsAttr("target_treeids", 0, 0, 0, 1, 1, 1),
+		intsAttr("target_nodeids", 2, 3, 4, 2, 3, 4),
+		intsAttr("target_ids", 0, 0, 0, 0, 0, 0),
+		floatsAttr("target_weights", 1, 2, 3, 3, 4, 5),
+		intAttr("n_targets", 1),
+		stringAttr("aggregate_function", "AVERAGE"),
+		floatsAttr("base_values", 100),
+	}
+
+	m, err := Parse(testModel(testGraph{
+		nodes:   [][]byte{testNode("TreeEnsembleRegressor", domainML, []string{"X"}, []string{"variable"}, attrs...)},
+		inputs:  [][]byte{encodeValueInfo("X", typeFloat, -1, 2)},
+		outputs: [][]byte{encodeValueInfo("variable", typeFloat, -1, 1)},
+	}))
+	require.NoError(t, err)
+
+	score, err := m.Score([]float64{0, 20}, "", -1)
+	require.NoError(t, err)
+	assert.InDelta(t, 103, score, 1e-9)
+
+	score, err = m.Score([]float64{1, 0}, "", 0)
+	require.NoError(t, err)
+	assert.InDelta(t, 104, score, 1e-9)
+}
+
+func TestParseErrors(t *testing.T) {
+	input := [][]byte{encodeValueInfo("X", typeFloat, -1, 2)}
+	output := [][]byte{encodeValueInfo("Y", typeFloat, -1, 2)}
+
+	tests := []struct {
+		name        string
+		model       []byte
+		expectedErr string
+	}{
+		{
+			name:        "garbage",
+			model:       []byte{0xff, 0xff},
+			expectedErr: "invalid model",
+		},
+		{
+			name:        "no graph",
+			model:       appendVarint(nil, 1, 8),
+			expectedErr: "invalid model: no graph",
+		},
+		{
+			name: "unsupported operator",
+			model: testModel(testGraph{
+				nodes:   [][]byte{testNode("Conv", "", []string{"X"}, []string{"Y"})},
+				inputs:  input,
+				outputs: output,
+			}),
+			expectedErr: "node Conv_Y (Conv): unsupported operator",
+		},
+		{
+			name: "zipmap",
+			model: testModel(testGraph{
+				nodes:   [][]byte{testNode("ZipMap", domainML, []string{"X"}, []string{"Y"})},
+				inputs:  input,
+				outputs: output,
+			}),
+			expectedErr: "the model must be exported without zipmap",
+		},
+		{
+			name: "unknown domain",
+			model: testModel(testGraph{
+				nodes:   [][]byte{testNode("Custom", "com.example", []string{"X"}, []string{"Y"})},
+				inputs:  input,
+				outputs: output,
+			}),
+			expectedErr: `unsupported domain "com.example"`,
+		},
+		{
+			name: "several inputs",
+			model: testModel(testGraph{
+				nodes:   [][]byte{testNode("Add", "", []string{"X", "X2"}, []string{"Y"})},
+				inputs:  append(input, encodeValueInfo("X2", typeFloat, -1, 2)),
+				outputs: output,
+			}),
+			expectedErr: "models with several inputs are not supported",
+		},
+		{
+			name: "string input",
+			model: testModel(testGraph{
+				nodes:   [][]byte{testNode("Identity", "", []string{"X"}, []string{"Y"})},
+				inputs:  [][]byte{encodeValueInfo("X", typeString, -1, 2)},
+				outputs: output,
+			}),
+			expectedErr: "input X: only numeric tensors are supported",
+		},
+		{
+			name: "invalid tree",
+			model: testModel(testGraph{
+				nodes: [][]byte{testNode("TreeEnsembleRegressor", domainML, []string{"X"}, []string{"Y"},
+					intsAttr("nodes_treeids", 0, 0),
+					intsAttr("nodes_nodeids", 0, 1),
+					intsAttr("nodes_featureids", 0, 0),
+					floatsAttr("nodes_values", 0.5, 0),
+					stringsAttr("nodes_modes", "BRANCH_LEQ", "LEAF"),
+					intsAttr("nodes_truenodeids", 1, 0),
+					intsAttr("nodes_falsenodeids", 2, 0),
+				)},
+				inputs:  input,
+				outputs: output,
+			}),
+			expectedErr: "node 0 of tree 0: missing child",
+		},
+		{
+			name: "initializer with missing values",
+			model: testModel(testGraph{
+				nodes:        [][]byte{testNode("Add", "", []string{"X", "B"}, []string{"Y"})},
+				initializers: [][]byte{floatTensor("B", []int64{2}, 1)},
+				inputs:       input,
+				outputs:      output,
+			}),
+			expectedErr: "initializer B: 1 values for 2 elements",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.model)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	_, err := Load("testdata/does-not-exist.onnx")
+	cstest.RequireErrorContains(t, err, "no such file or directory")
+}
+
+func TestRunReshape(t *testing.T) {
+	m, err := Parse(testModel(testGraph{
+		nodes: [][]byte{
+			testNode("Reshape", "", []string{"X", "shape"}, []string{"flat"}),
+			testNode("LeakyRelu", "", []string{"flat"}, []string{"Y"}, floatAttr("alpha", 0.5)),
+		},
+		initializers: [][]byte{int64Tensor("shape", []int64{2}, 0, -1)},
+		inputs:       [][]byte{encodeValueInfo("X", typeFloat, -1, 2, 2)},
+		outputs:      [][]byte{encodeValueInfo("Y", typeFloat, -1, 4)},
+	}))
+	require.NoError(t, err)
+
+	out, err := m.Run(&Tensor{Shape: []int{1, 2, 2}, Data: []float64{1, -2, 3, -4}})
+	require.NoError(t, err)
+
+	assert.Equal(t, &Tensor{Shape: []int{1, 4}, Data: []float64{1, -1, 3, -2}}, out["Y"])
+}
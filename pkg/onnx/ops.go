@@ -0,0 +1,752 @@
+package onnx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// opFunc runs an operator on its inputs, a missing optional input is nil.
+type opFunc func(n *node, in []*Tensor) ([]*Tensor, error)
+
+// operators of the default domain
+var ops = map[string]opFunc{
+	"Abs":         unaryOp(math.Abs),
+	"Add":         binaryOp(func(a, b float64) float64 { return a + b }),
+	"ArgMax":      opArgMax,
+	"Cast":        opCast,
+	"Ceil":        unaryOp(math.Ceil),
+	"Clip":        opClip,
+	"Concat":      opConcat,
+	"Constant":    opConstant,
+	"Div":         binaryOp(func(a, b float64) float64 { return a / b }),
+	"Dropout":     opIdentity,
+	"Exp":         unaryOp(math.Exp),
+	"Flatten":     opFlatten,
+	"Floor":       unaryOp(math.Floor),
+	"Gather":      opGather,
+	"Gemm":        opGemm,
+	"HardSigmoid": opHardSigmoid,
+	"Identity":    opIdentity,
+	"LeakyRelu":   opLeakyRelu,
+	"Log":         unaryOp(math.Log),
+	"MatMul":      opMatMul,
+	"Max":         variadicOp(math.Max),
+	"Min":         variadicOp(math.Min),
+	"Mul":         binaryOp(func(a, b float64) float64 { return a * b }),
+	"Neg":         unaryOp(func(v float64) float64 { return -v }),
+	"Pow":         binaryOp(math.Pow),
+	"Relu":        unaryOp(func(v float64) float64 { return max(v, 0) }),
+	"Reshape":     opReshape,
+	"Shape":       opShape,
+	"Sigmoid":     unaryOp(sigmoid),
+	"Softmax":     opSoftmax,
+	"Softplus":    unaryOp(func(v float64) float64 { return math.Log1p(math.Exp(v)) }),
+	"Sqrt":        unaryOp(math.Sqrt),
+	"Squeeze":     opSqueeze,
+	"Sub":         binaryOp(func(a, b float64) float64 { return a - b }),
+	"Sum":         variadicOp(func(a, b float64) float64 { return a + b }),
+	"Tanh":        unaryOp(math.Tanh),
+	"Unsqueeze":   opUnsqueeze,
+}
+
+func sigmoid(v float64) float64 {
+	return 1 / (1 + math.Exp(-v))
+}
+
+// input returns a required input.
+func input(in []*Tensor, idx int) (*Tensor, error) {
+	if idx >= len(in) || in[idx] == nil {
+		return nil, fmt.Errorf("missing input %d", idx)
+	}
+
+	if in[idx].isString() {
+		return nil, fmt.Errorf("input %d: unsupported string tensor", idx)
+	}
+
+	return in[idx], nil
+}
+
+// optionalInput returns an input, nil if it's missing.
+func optionalInput(in []*Tensor, idx int) *Tensor {
+	if idx >= len(in) {
+		return nil
+	}
+
+	return in[idx]
+}
+
+func unaryOp(f func(float64) float64) opFunc {
+	return func(_ *node, in []*Tensor) ([]*Tensor, error) {
+		x, err := input(in, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		ret, err := unary(x, f)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*Tensor{ret}, nil
+	}
+}
+
+func binaryOp(f func(float64, float64) float64) opFunc {
+	return func(_ *node, in []*Tensor) ([]*Tensor, error) {
+		a, err := input(in, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := input(in, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		ret, err := elementwise(a, b, f)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*Tensor{ret}, nil
+	}
+}
+
+func variadicOp(f func(float64, float64) float64) opFunc {
+	return func(_ *node, in []*Tensor) ([]*Tensor, error) {
+		ret, err := input(in, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 1; i < len(in); i++ {
+			x, err := input(in, i)
+			if err != nil {
+				return nil, err
+			}
+
+			if ret, err = elementwise(ret, x, f); err != nil {
+				return nil, err
+			}
+		}
+
+		return []*Tensor{ret}, nil
+	}
+}
+
+func opIdentity(_ *node, in []*Tensor) ([]*Tensor, error) {
+	if len(in) == 0 || in[0] == nil {
+		return nil, errors.New("missing input 0")
+	}
+
+	return []*Tensor{in[0]}, nil
+}
+
+func opLeakyRelu(n *node, in []*Tensor) ([]*Tensor, error) {
+	alpha := n.attrFloat("alpha", 0.01)
+
+	return unaryOp(func(v float64) float64 {
+		if v < 0 {
+			return alpha * v
+		}
+
+		return v
+	})(n, in)
+}
+
+func opHardSigmoid(n *node, in []*Tensor) ([]*Tensor, error) {
+	alpha := n.attrFloat("alpha", 0.2)
+	beta := n.attrFloat("beta", 0.5)
+
+	return unaryOp(func(v float64) float64 {
+		return max(0, min(1, alpha*v+beta))
+	})(n, in)
+}
+
+func opCast(n *node, in []*Tensor) ([]*Tensor, error) {
+	x, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var f func(float64) float64
+
+	switch n.attrInt("to", typeFloat) {
+	case typeFloat, typeDouble:
+		return []*Tensor{x}, nil
+	case typeBool:
+		f = func(v float64) float64 {
+			if v != 0 {
+				return 1
+			}
+
+			return 0
+		}
+	case typeInt8, typeUint8, typeInt16, typeUint16, typeInt32, typeUint32, typeInt64, typeUint64:
+		f = math.Trunc
+	default:
+		return nil, fmt.Errorf("unsupported cast to type %d", n.attrInt("to", 0))
+	}
+
+	return unaryOp(f)(n, in)
+}
+
+func opClip(n *node, in []*Tensor) ([]*Tensor, error) {
+	lo := math.Inf(-1)
+	hi := math.Inf(1)
+
+	if n.opset < 11 {
+		lo = n.attrFloat("min", lo)
+		hi = n.attrFloat("max", hi)
+	} else {
+		if t := optionalInput(in, 1); t != nil && len(t.Data) > 0 {
+			lo = t.Data[0]
+		}
+
+		if t := optionalInput(in, 2); t != nil && len(t.Data) > 0 {
+			hi = t.Data[0]
+		}
+	}
+
+	return unaryOp(func(v float64) float64 { return max(lo, min(hi, v)) })(n, in)
+}
+
+func opConstant(n *node, _ []*Tensor) ([]*Tensor, error) {
+	if t := n.attrTensor("value"); t != nil {
+		return []*Tensor{t}, nil
+	}
+
+	if a, ok := n.attrs["value_float"]; ok {
+		return []*Tensor{{Shape: []int{}, Data: []float64{a.f}}}, nil
+	}
+
+	if a, ok := n.attrs["value_int"]; ok {
+		return []*Tensor{{Shape: []int{}, Data: []float64{float64(a.i)}}}, nil
+	}
+
+	if a, ok := n.attrs["value_floats"]; ok {
+		return []*Tensor{{Shape: []int{len(a.floats)}, Data: slices.Clone(a.floats)}}, nil
+	}
+
+	if a, ok := n.attrs["value_ints"]; ok {
+		data := make([]float64, len(a.ints))
+		for i, v := range a.ints {
+			data[i] = float64(v)
+		}
+
+		return []*Tensor{{Shape: []int{len(data)}, Data: data}}, nil
+	}
+
+	return nil, errors.New("unsupported constant")
+}
+
+func opShape(_ *node, in []*Tensor) ([]*Tensor, error) {
+	if len(in) == 0 || in[0] == nil {
+		return nil, errors.New("missing input 0")
+	}
+
+	ret := newTensor([]int{len(in[0].Shape)})
+	for i, d := range in[0].Shape {
+		ret.Data[i] = float64(d)
+	}
+
+	return []*Tensor{ret}, nil
+}
+
+func opFlatten(n *node, in []*Tensor) ([]*Tensor, error) {
+	x, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	axis := n.attrInt("axis", 1)
+	if axis < 0 {
+		axis += int64(len(x.Shape))
+	}
+
+	if axis < 0 || axis > int64(len(x.Shape)) {
+		return nil, fmt.Errorf("axis %d out of range", axis)
+	}
+
+	outer := shapeSize(x.Shape[:axis])
+
+	return []*Tensor{{Shape: []int{outer, x.size() / max(outer, 1)}, Data: x.Data}}, nil
+}
+
+func opReshape(n *node, in []*Tensor) ([]*Tensor, error) {
+	x, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := input(in, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	allowZero := n.attrInt("allowzero", 0) != 0
+	shape := make([]int, len(s.Data))
+	unknown := -1
+	known := 1
+
+	for i, v := range s.Data {
+		d := int(v)
+
+		switch {
+		case d == 0 && !allowZero:
+			if i >= len(x.Shape) {
+				return nil, fmt.Errorf("invalid shape %v for %v", s.Data, x.Shape)
+			}
+
+			d = x.Shape[i]
+		case d == -1:
+			if unknown >= 0 {
+				return nil, fmt.Errorf("invalid shape %v", s.Data)
+			}
+
+			unknown = i
+
+			continue
+		case d < 0:
+			return nil, fmt.Errorf("invalid shape %v", s.Data)
+		}
+
+		shape[i] = d
+		known *= d
+	}
+
+	if unknown >= 0 {
+		if known == 0 || x.size()%known != 0 {
+			return nil, fmt.Errorf("can't reshape %v to %v", x.Shape, s.Data)
+		}
+
+		shape[unknown] = x.size() / known
+	}
+
+	if shapeSize(shape) != x.size() {
+		return nil, fmt.Errorf("can't reshape %v to %v", x.Shape, s.Data)
+	}
+
+	return []*Tensor{{Shape: shape, Data: x.Data}}, nil
+}
+
+// axesOf returns the axes of Squeeze and Unsqueeze, an attribute before opset 13 and an input after.
+func axesOf(n *node, in []*Tensor) []int64 {
+	if n.opset < 13 {
+		return n.attrInts("axes")
+	}
+
+	t := optionalInput(in, 1)
+	if t == nil {
+		return nil
+	}
+
+	axes := make([]int64, len(t.Data))
+	for i, v := range t.Data {
+		axes[i] = int64(v)
+	}
+
+	return axes
+}
+
+func opSqueeze(n *node, in []*Tensor) ([]*Tensor, error) {
+	x, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	axes := axesOf(n, in)
+	squeezed := make([]bool, len(x.Shape))
+
+	for _, a := range axes {
+		axis, err := normalizeAxis(a, len(x.Shape))
+		if err != nil {
+			return nil, err
+		}
+
+		if x.Shape[axis] != 1 {
+			return nil, fmt.Errorf("can't squeeze dimension %d of size %d", axis, x.Shape[axis])
+		}
+
+		squeezed[axis] = true
+	}
+
+	shape := []int{}
+
+	for i, d := range x.Shape {
+		if squeezed[i] || (len(axes) == 0 && d == 1) {
+			continue
+		}
+
+		shape = append(shape, d)
+	}
+
+	return []*Tensor{{Shape: shape, Data: x.Data}}, nil
+}
+
+func opUnsqueeze(n *node, in []*Tensor) ([]*Tensor, error) {
+	x, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	axes := axesOf(n, in)
+	rank := len(x.Shape) + len(axes)
+	inserted := make([]bool, rank)
+
+	for _, a := range axes {
+		axis, err := normalizeAxis(a, rank)
+		if err != nil {
+			return nil, err
+		}
+
+		inserted[axis] = true
+	}
+
+	shape := make([]int, 0, rank)
+	j := 0
+
+	for i := range rank {
+		if inserted[i] {
+			shape = append(shape, 1)
+			continue
+		}
+
+		shape = append(shape, x.Shape[j])
+		j++
+	}
+
+	return []*Tensor{{Shape: shape, Data: x.Data}}, nil
+}
+
+func opConcat(n *node, in []*Tensor) ([]*Tensor, error) {
+	first, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	axis, err := normalizeAxis(n.attrInt("axis", 0), len(first.Shape))
+	if err != nil {
+		return nil, err
+	}
+
+	shape := slices.Clone(first.Shape)
+	shape[axis] = 0
+
+	for i := range in {
+		x, err := input(in, i)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(x.Shape) != len(shape) {
+			return nil, fmt.Errorf("can't concatenate %v and %v", first.Shape, x.Shape)
+		}
+
+		for d := range shape {
+			if d != axis && x.Shape[d] != shape[d] {
+				return nil, fmt.Errorf("can't concatenate %v and %v", first.Shape, x.Shape)
+			}
+		}
+
+		shape[axis] += x.Shape[axis]
+	}
+
+	ret := &Tensor{Shape: shape, Data: make([]float64, 0, shapeSize(shape))}
+	outer := shapeSize(shape[:axis])
+
+	for o := range outer {
+		for _, x := range in {
+			chunk := shapeSize(x.Shape[axis:])
+			ret.Data = append(ret.Data, x.Data[o*chunk:(o+1)*chunk]...)
+		}
+	}
+
+	return []*Tensor{ret}, nil
+}
+
+func opGather(n *node, in []*Tensor) ([]*Tensor, error) {
+	x, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	indices, err := input(in, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	axis, err := normalizeAxis(n.attrInt("axis", 0), len(x.Shape))
+	if err != nil {
+		return nil, err
+	}
+
+	shape := slices.Concat(x.Shape[:axis], indices.Shape, x.Shape[axis+1:])
+	ret := &Tensor{Shape: shape, Data: make([]float64, 0, shapeSize(shape))}
+	outer := shapeSize(x.Shape[:axis])
+	inner := shapeSize(x.Shape[axis+1:])
+
+	for o := range outer {
+		for _, v := range indices.Data {
+			idx := int(v)
+			if idx < 0 {
+				idx += x.Shape[axis]
+			}
+
+			if idx < 0 || idx >= x.Shape[axis] {
+				return nil, fmt.Errorf("index %d out of range", int(v))
+			}
+
+			start := (o*x.Shape[axis] + idx) * inner
+			ret.Data = append(ret.Data, x.Data[start:start+inner]...)
+		}
+	}
+
+	return []*Tensor{ret}, nil
+}
+
+// matmul2D multiplies a [m, k] matrix by a [k, n] one.
+func matmul2D(a []float64, b []float64, m int, k int, n int) []float64 {
+	ret := make([]float64, m*n)
+
+	for i := range m {
+		for p := range k {
+			av := a[i*k+p]
+			if av == 0 {
+				continue
+			}
+
+			row := ret[i*n : (i+1)*n]
+			for j, bv := range b[p*n : (p+1)*n] {
+				row[j] += av * bv
+			}
+		}
+	}
+
+	return ret
+}
+
+func opMatMul(_ *node, in []*Tensor) ([]*Tensor, error) {
+	a, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := input(in, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b.Shape) == 0 || len(b.Shape) > 2 || len(a.Shape) == 0 {
+		return nil, fmt.Errorf("unsupported matmul of %v by %v", a.Shape, b.Shape)
+	}
+
+	k, n := b.Shape[0], 1
+	if len(b.Shape) == 2 {
+		n = b.Shape[1]
+	}
+
+	if a.Shape[len(a.Shape)-1] != k {
+		return nil, fmt.Errorf("can't multiply %v by %v", a.Shape, b.Shape)
+	}
+
+	m := a.size() / max(k, 1)
+	shape := slices.Clone(a.Shape[:len(a.Shape)-1])
+
+	if len(b.Shape) == 2 {
+		shape = append(shape, n)
+	}
+
+	return []*Tensor{{Shape: shape, Data: matmul2D(a.Data, b.Data, m, k, n)}}, nil
+}
+
+// transpose2D returns the transpose of a [rows, cols] matrix.
+func transpose2D(data []float64, rows int, cols int) []float64 {
+	ret := make([]float64, len(data))
+
+	for i := range rows {
+		for j := range cols {
+			ret[j*rows+i] = data[i*cols+j]
+		}
+	}
+
+	return ret
+}
+
+func opGemm(n *node, in []*Tensor) ([]*Tensor, error) {
+	a, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := input(in, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(a.Shape) != 2 || len(b.Shape) != 2 {
+		return nil, fmt.Errorf("gemm expects matrices, got %v and %v", a.Shape, b.Shape)
+	}
+
+	m, k := a.Shape[0], a.Shape[1]
+	aData := a.Data
+
+	if n.attrInt("transA", 0) != 0 {
+		aData = transpose2D(a.Data, m, k)
+		m, k = k, m
+	}
+
+	kb, nb := b.Shape[0], b.Shape[1]
+	bData := b.Data
+
+	if n.attrInt("transB", 0) != 0 {
+		bData = transpose2D(b.Data, kb, nb)
+		kb, nb = nb, kb
+	}
+
+	if k != kb {
+		return nil, fmt.Errorf("can't multiply %v by %v", a.Shape, b.Shape)
+	}
+
+	alpha := n.attrFloat("alpha", 1)
+	beta := n.attrFloat("beta", 1)
+
+	ret := &Tensor{Shape: []int{m, nb}, Data: matmul2D(aData, bData, m, k, nb)}
+
+	for i := range ret.Data {
+		ret.Data[i] *= alpha
+	}
+
+	if c := optionalInput(in, 2); c != nil && beta != 0 {
+		scaled, err := unary(c, func(v float64) float64 { return beta * v })
+		if err != nil {
+			return nil, err
+		}
+
+		if ret, err = elementwise(ret, scaled, func(x, y float64) float64 { return x + y }); err != nil {
+			return nil, err
+		}
+	}
+
+	return []*Tensor{ret}, nil
+}
+
+// softmaxRows applies softmax in place on each row of a [rows, cols] matrix.
+func softmaxRows(data []float64, cols int) {
+	for start := 0; start+cols <= len(data); start += cols {
+		row := data[start : start+cols]
+		maxv := slices.Max(row)
+		sum := 0.0
+
+		for i, v := range row {
+			row[i] = math.Exp(v - maxv)
+			sum += row[i]
+		}
+
+		for i := range row {
+			row[i] /= sum
+		}
+	}
+}
+
+func opSoftmax(n *node, in []*Tensor) ([]*Tensor, error) {
+	x, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(x.Shape) == 0 {
+		return []*Tensor{{Shape: []int{}, Data: []float64{1}}}, nil
+	}
+
+	defaultAxis := int64(-1)
+	if n.opset < 13 {
+		defaultAxis = 1
+	}
+
+	axis, err := normalizeAxis(n.attrInt("axis", defaultAxis), len(x.Shape))
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &Tensor{Shape: slices.Clone(x.Shape), Data: slices.Clone(x.Data)}
+
+	// before opset 13, the input is seen as a matrix of [outer, inner] at the axis
+	if n.opset < 13 || axis == len(x.Shape)-1 {
+		cols := shapeSize(x.Shape[axis:])
+		if n.opset >= 13 {
+			cols = x.Shape[axis]
+		}
+
+		if cols > 0 {
+			softmaxRows(ret.Data, cols)
+		}
+
+		return []*Tensor{ret}, nil
+	}
+
+	// softmax on an inner axis
+	dim := x.Shape[axis]
+	inner := shapeSize(x.Shape[axis+1:])
+	outer := shapeSize(x.Shape[:axis])
+	values := make([]float64, dim)
+
+	for o := range outer {
+		for i := range inner {
+			for d := range dim {
+				values[d] = x.Data[(o*dim+d)*inner+i]
+			}
+
+			softmaxRows(values, dim)
+
+			for d := range dim {
+				ret.Data[(o*dim+d)*inner+i] = values[d]
+			}
+		}
+	}
+
+	return []*Tensor{ret}, nil
+}
+
+func opArgMax(n *node, in []*Tensor) ([]*Tensor, error) {
+	x, err := input(in, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	axis, err := normalizeAxis(n.attrInt("axis", 0), len(x.Shape))
+	if err != nil {
+		return nil, err
+	}
+
+	dim := x.Shape[axis]
+	inner := shapeSize(x.Shape[axis+1:])
+	outer := shapeSize(x.Shape[:axis])
+
+	shape := slices.Clone(x.Shape)
+	if n.attrInt("keepdims", 1) != 0 {
+		shape[axis] = 1
+	} else {
+		shape = slices.Delete(shape, axis, axis+1)
+	}
+
+	ret := newTensor(shape)
+
+	for o := range outer {
+		for i := range inner {
+			best := 0
+
+			for d := 1; d < dim; d++ {
+				if x.Data[(o*dim+d)*inner+i] > x.Data[(o*dim+best)*inner+i] {
+					best = d
+				}
+			}
+
+			ret.Data[o*inner+i] = float64(best)
+		}
+	}
+
+	return []*Tensor{ret}, nil
+}
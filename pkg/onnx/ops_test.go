@@ -0,0 +1,140 @@
+package onnx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func TestOps(t *testing.T) {
+	matrix := &Tensor{Shape: []int{2, 3}, Data: []float64{1, 2, 3, 4, 5, 6}}
+
+	tests := []struct {
+		name        string
+		op          string
+		attrs       map[string]*attribute
+		opset       int64
+		in          []*Tensor
+		expected    *Tensor
+		expectedErr string
+	}{
+		{
+			name:     "broadcast row",
+			op:       "Add",
+			in:       []*Tensor{matrix, {Shape: []int{3}, Data: []float64{10, 20, 30}}},
+			expected: &Tensor{Shape: []int{2, 3}, Data: []float64{11, 22, 33, 14, 25, 36}},
+		},
+		{
+			name:     "broadcast column",
+			op:       "Mul",
+			in:       []*Tensor{matrix, {Shape: []int{2, 1}, Data: []float64{0, 2}}},
+			expected: &Tensor{Shape: []int{2, 3}, Data: []float64{0, 0, 0, 8, 10, 12}},
+		},
+		{
+			name:        "broadcast mismatch",
+			op:          "Sub",
+			in:          []*Tensor{matrix, {Shape: []int{2}, Data: []float64{1, 2}}},
+			expectedErr: "shapes [2 3] and [2] can't be broadcast",
+		},
+		{
+			name:     "concat",
+			op:       "Concat",
+			attrs:    map[string]*attribute{"axis": {i: 1}},
+			in:       []*Tensor{matrix, {Shape: []int{2, 1}, Data: []float64{7, 8}}},
+			expected: &Tensor{Shape: []int{2, 4}, Data: []float64{1, 2, 3, 7, 4, 5, 6, 8}},
+		},
+		{
+			name:     "gather",
+			op:       "Gather",
+			attrs:    map[string]*attribute{"axis": {i: 1}},
+			in:       []*Tensor{matrix, {Shape: []int{2}, Data: []float64{2, -3}}},
+			expected: &Tensor{Shape: []int{2, 2}, Data: []float64{3, 1, 6, 4}},
+		},
+		{
+			name:     "argmax",
+			op:       "ArgMax",
+			attrs:    map[string]*attribute{"axis": {i: 1}, "keepdims": {i: 0}},
+			in:       []*Tensor{{Shape: []int{2, 3}, Data: []float64{1, 9, 3, 7, 5, 6}}},
+			expected: &Tensor{Shape: []int{2}, Data: []float64{1, 0}},
+		},
+		{
+			name:     "squeeze opset 13",
+			op:       "Squeeze",
+			opset:    13,
+			in:       []*Tensor{{Shape: []int{1, 3, 1}, Data: []float64{1, 2, 3}}, {Shape: []int{1}, Data: []float64{0}}},
+			expected: &Tensor{Shape: []int{3, 1}, Data: []float64{1, 2, 3}},
+		},
+		{
+			name:     "unsqueeze opset 11",
+			op:       "Unsqueeze",
+			opset:    11,
+			attrs:    map[string]*attribute{"axes": {ints: []int64{0}}},
+			in:       []*Tensor{{Shape: []int{3}, Data: []float64{1, 2, 3}}},
+			expected: &Tensor{Shape: []int{1, 3}, Data: []float64{1, 2, 3}},
+		},
+		{
+			name:     "flatten",
+			op:       "Flatten",
+			in:       []*Tensor{{Shape: []int{1, 2, 2}, Data: []float64{1, 2, 3, 4}}},
+			expected: &Tensor{Shape: []int{1, 4}, Data: []float64{1, 2, 3, 4}},
+		},
+		{
+			name:        "reshape mismatch",
+			op:          "Reshape",
+			in:          []*Tensor{matrix, {Shape: []int{1}, Data: []float64{4}}},
+			expectedErr: "can't reshape [2 3] to [4]",
+		},
+		{
+			name:     "clip opset 6",
+			op:       "Clip",
+			opset:    6,
+			attrs:    map[string]*attribute{"min": {f: 2}, "max": {f: 4}},
+			in:       []*Tensor{matrix},
+			expected: &Tensor{Shape: []int{2, 3}, Data: []float64{2, 2, 3, 4, 4, 4}},
+		},
+		{
+			name:     "cast to int",
+			op:       "Cast",
+			attrs:    map[string]*attribute{"to": {i: typeInt64}},
+			in:       []*Tensor{{Shape: []int{2}, Data: []float64{1.7, -1.7}}},
+			expected: &Tensor{Shape: []int{2}, Data: []float64{1, -1}},
+		},
+		{
+			name:        "matmul mismatch",
+			op:          "MatMul",
+			in:          []*Tensor{matrix, matrix},
+			expectedErr: "can't multiply [2 3] by [2 3]",
+		},
+		{
+			name:     "softmax inner axis",
+			op:       "Softmax",
+			opset:    13,
+			attrs:    map[string]*attribute{"axis": {i: 0}},
+			in:       []*Tensor{{Shape: []int{2, 2}, Data: []float64{0, 1, 0, 1}}},
+			expected: &Tensor{Shape: []int{2, 2}, Data: []float64{0.5, 0.5, 0.5, 0.5}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			n := &node{opType: tc.op, attrs: tc.attrs, opset: tc.opset}
+			if n.attrs == nil {
+				n.attrs = map[string]*attribute{}
+			}
+
+			out, err := ops[tc.op](n, tc.in)
+			cstest.RequireErrorContains(t, err, tc.expectedErr)
+
+			if tc.expectedErr != "" {
+				return
+			}
+
+			require.Len(t, out, 1)
+			assert.Equal(t, tc.expected.Shape, out[0].Shape)
+			assert.InDeltaSlice(t, tc.expected.Data, out[0].Data, 1e-9)
+		})
+	}
+}
@@ -0,0 +1,692 @@
+package onnx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The ONNX messages are decoded from the wire format, for the fields needed to run a model.
+// Field numbers are the ones of onnx.proto.
+
+// tensor element types
+const (
+	typeFloat  = 1
+	typeUint8  = 2
+	typeInt8   = 3
+	typeUint16 = 4
+	typeInt16  = 5
+	typeInt32  = 6
+	typeInt64  = 7
+	typeString = 8
+	typeBool   = 9
+	typeDouble = 11
+	typeUint32 = 12
+	typeUint64 = 13
+)
+
+type attribute struct {
+	f       float64
+	i       int64
+	s       string
+	t       *Tensor
+	floats  []float64
+	ints    []int64
+	strings []string
+}
+
+type node struct {
+	name    string
+	opType  string
+	domain  string
+	inputs  []string
+	outputs []string
+	attrs   map[string]*attribute
+	opset   int64 // version of the domain of the operator, set when loading the model
+	state   any   // parsed attributes, for the operators that have a preparer
+}
+
+func (n *node) attrInt(name string, def int64) int64 {
+	if a, ok := n.attrs[name]; ok {
+		return a.i
+	}
+
+	return def
+}
+
+func (n *node) attrFloat(name string, def float64) float64 {
+	if a, ok := n.attrs[name]; ok {
+		return a.f
+	}
+
+	return def
+}
+
+func (n *node) attrString(name string, def string) string {
+	if a, ok := n.attrs[name]; ok {
+		return a.s
+	}
+
+	return def
+}
+
+func (n *node) attrTensor(name string) *Tensor {
+	if a, ok := n.attrs[name]; ok {
+		return a.t
+	}
+
+	return nil
+}
+
+func (n *node) attrInts(name string) []int64 {
+	if a, ok := n.attrs[name]; ok {
+		return a.ints
+	}
+
+	return nil
+}
+
+func (n *node) attrFloats(name string) []float64 {
+	if a, ok := n.attrs[name]; ok {
+		return a.floats
+	}
+
+	return nil
+}
+
+func (n *node) attrStrings(name string) []string {
+	if a, ok := n.attrs[name]; ok {
+		return a.strings
+	}
+
+	return nil
+}
+
+type valueInfo struct {
+	name     string
+	elemType int
+	isTensor bool
+	shape    []int64 // -1 for an unknown dimension
+}
+
+type graph struct {
+	nodes        []*node
+	initializers map[string]*Tensor
+	inputs       []*valueInfo
+	outputs      []*valueInfo
+}
+
+type modelProto struct {
+	irVersion int64
+	opsets    map[string]int64
+	graph     *graph
+}
+
+// fieldReader iterates on the fields of a message.
+type fieldReader struct {
+	b   []byte
+	num protowire.Number
+	typ protowire.Type
+	val []byte // raw value, for the bytes fields
+	v   uint64 // value of the varint and fixed fields
+}
+
+func (r *fieldReader) next() (bool, error) {
+	if len(r.b) == 0 {
+		return false, nil
+	}
+
+	num, typ, n := protowire.ConsumeTag(r.b)
+	if n < 0 {
+		return false, protowire.ParseError(n)
+	}
+
+	r.b = r.b[n:]
+	r.num, r.typ, r.val, r.v = num, typ, nil, 0
+
+	switch typ {
+	case protowire.VarintType:
+		r.v, n = protowire.ConsumeVarint(r.b)
+	case protowire.Fixed32Type:
+		var v uint32
+		v, n = protowire.ConsumeFixed32(r.b)
+		r.v = uint64(v)
+	case protowire.Fixed64Type:
+		r.v, n = protowire.ConsumeFixed64(r.b)
+	case protowire.BytesType:
+		r.val, n = protowire.ConsumeBytes(r.b)
+	default:
+		n = protowire.ConsumeFieldValue(num, typ, r.b)
+	}
+
+	if n < 0 {
+		return false, protowire.ParseError(n)
+	}
+
+	r.b = r.b[n:]
+
+	return true, nil
+}
+
+// varints appends the values of a repeated varint field, packed or not.
+func (r *fieldReader) varints(dst []int64) ([]int64, error) {
+	if r.typ == protowire.VarintType {
+		return append(dst, int64(r.v)), nil
+	}
+
+	if r.typ != protowire.BytesType {
+		return nil, fmt.Errorf("field %d: unexpected wire type %d", r.num, r.typ)
+	}
+
+	b := r.val
+
+	for len(b) > 0 {
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+
+		dst = append(dst, int64(v))
+		b = b[n:]
+	}
+
+	return dst, nil
+}
+
+// floats appends the values of a repeated float field, packed or not.
+func (r *fieldReader) floats(dst []float64) ([]float64, error) {
+	if r.typ == protowire.Fixed32Type {
+		return append(dst, float64(math.Float32frombits(uint32(r.v)))), nil
+	}
+
+	if r.typ != protowire.BytesType || len(r.val)%4 != 0 {
+		return nil, fmt.Errorf("field %d: invalid float values", r.num)
+	}
+
+	for i := 0; i < len(r.val); i += 4 {
+		dst = append(dst, float64(math.Float32frombits(binary.LittleEndian.Uint32(r.val[i:]))))
+	}
+
+	return dst, nil
+}
+
+// doubles appends the values of a repeated double field, packed or not.
+func (r *fieldReader) doubles(dst []float64) ([]float64, error) {
+	if r.typ == protowire.Fixed64Type {
+		return append(dst, math.Float64frombits(r.v)), nil
+	}
+
+	if r.typ != protowire.BytesType || len(r.val)%8 != 0 {
+		return nil, fmt.Errorf("field %d: invalid double values", r.num)
+	}
+
+	for i := 0; i < len(r.val); i += 8 {
+		dst = append(dst, math.Float64frombits(binary.LittleEndian.Uint64(r.val[i:])))
+	}
+
+	return dst, nil
+}
+
+func decodeModel(b []byte) (*modelProto, error) {
+	m := &modelProto{opsets: map[string]int64{}}
+	r := fieldReader{b: b}
+
+	for {
+		ok, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		switch r.num {
+		case 1:
+			m.irVersion = int64(r.v)
+		case 7:
+			if m.graph, err = decodeGraph(r.val); err != nil {
+				return nil, fmt.Errorf("graph: %w", err)
+			}
+		case 8:
+			domain, version, err := decodeOpset(r.val)
+			if err != nil {
+				return nil, fmt.Errorf("opset_import: %w", err)
+			}
+
+			m.opsets[domain] = version
+		}
+	}
+
+	if m.graph == nil {
+		return nil, errors.New("no graph")
+	}
+
+	return m, nil
+}
+
+func decodeOpset(b []byte) (string, int64, error) {
+	var (
+		domain  string
+		version int64
+	)
+
+	r := fieldReader{b: b}
+
+	for {
+		ok, err := r.next()
+		if err != nil {
+			return "", 0, err
+		}
+
+		if !ok {
+			break
+		}
+
+		switch r.num {
+		case 1:
+			domain = string(r.val)
+		case 2:
+			version = int64(r.v)
+		}
+	}
+
+	return domain, version, nil
+}
+
+func decodeGraph(b []byte) (*graph, error) {
+	g := &graph{initializers: map[string]*Tensor{}}
+	r := fieldReader{b: b}
+
+	for {
+		ok, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		switch r.num {
+		case 1:
+			n, err := decodeNode(r.val)
+			if err != nil {
+				return nil, fmt.Errorf("node %d: %w", len(g.nodes), err)
+			}
+
+			g.nodes = append(g.nodes, n)
+		case 5:
+			name, t, err := decodeTensor(r.val)
+			if err != nil {
+				return nil, fmt.Errorf("initializer %s: %w", name, err)
+			}
+
+			g.initializers[name] = t
+		case 15:
+			return nil, errors.New("sparse initializers are not supported")
+		case 11, 12:
+			vi, err := decodeValueInfo(r.val)
+			if err != nil {
+				return nil, err
+			}
+
+			if r.num == 11 {
+				g.inputs = append(g.inputs, vi)
+			} else {
+				g.outputs = append(g.outputs, vi)
+			}
+		}
+	}
+
+	return g, nil
+}
+
+func decodeNode(b []byte) (*node, error) {
+	n := &node{attrs: map[string]*attribute{}}
+	r := fieldReader{b: b}
+
+	for {
+		ok, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		switch r.num {
+		case 1:
+			n.inputs = append(n.inputs, string(r.val))
+		case 2:
+			n.outputs = append(n.outputs, string(r.val))
+		case 3:
+			n.name = string(r.val)
+		case 4:
+			n.opType = string(r.val)
+		case 7:
+			n.domain = string(r.val)
+		case 5:
+			name, attr, err := decodeAttribute(r.val)
+			if err != nil {
+				return nil, fmt.Errorf("attribute %s: %w", name, err)
+			}
+
+			n.attrs[name] = attr
+		}
+	}
+
+	return n, nil
+}
+
+func decodeAttribute(b []byte) (string, *attribute, error) {
+	var name string
+
+	a := &attribute{}
+	r := fieldReader{b: b}
+
+	for {
+		ok, err := r.next()
+		if err != nil {
+			return name, nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		switch r.num {
+		case 1:
+			name = string(r.val)
+		case 2:
+			a.f = float64(math.Float32frombits(uint32(r.v)))
+		case 3:
+			a.i = int64(r.v)
+		case 4:
+			a.s = string(r.val)
+		case 5:
+			if _, a.t, err = decodeTensor(r.val); err != nil {
+				return name, nil, err
+			}
+		case 7:
+			if a.floats, err = r.floats(a.floats); err != nil {
+				return name, nil, err
+			}
+		case 8:
+			if a.ints, err = r.varints(a.ints); err != nil {
+				return name, nil, err
+			}
+		case 9:
+			a.strings = append(a.strings, string(r.val))
+		}
+	}
+
+	return name, a, nil
+}
+
+func decodeValueInfo(b []byte) (*valueInfo, error) {
+	vi := &valueInfo{}
+	r := fieldReader{b: b}
+
+	for {
+		ok, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		switch r.num {
+		case 1:
+			vi.name = string(r.val)
+		case 2:
+			if err := decodeType(r.val, vi); err != nil {
+				return nil, fmt.Errorf("%s: %w", vi.name, err)
+			}
+		}
+	}
+
+	return vi, nil
+}
+
+// decodeType reads a TypeProto, only tensors have an element type and a shape.
+func decodeType(b []byte, vi *valueInfo) error {
+	r := fieldReader{b: b}
+
+	for {
+		ok, err := r.next()
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return nil
+		}
+
+		if r.num != 1 {
+			continue
+		}
+
+		vi.isTensor = true
+
+		tr := fieldReader{b: r.val}
+
+		for {
+			ok, err := tr.next()
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				break
+			}
+
+			switch tr.num {
+			case 1:
+				vi.elemType = int(tr.v)
+			case 2:
+				if vi.shape, err = decodeShape(tr.val); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func decodeShape(b []byte) ([]int64, error) {
+	shape := []int64{}
+	r := fieldReader{b: b}
+
+	for {
+		ok, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return shape, nil
+		}
+
+		if r.num != 1 {
+			continue
+		}
+
+		dim := int64(-1)
+		dr := fieldReader{b: r.val}
+
+		for {
+			ok, err := dr.next()
+			if err != nil {
+				return nil, err
+			}
+
+			if !ok {
+				break
+			}
+
+			if dr.num == 1 {
+				dim = int64(dr.v)
+			}
+		}
+
+		shape = append(shape, dim)
+	}
+}
+
+func decodeTensor(b []byte) (string, *Tensor, error) {
+	var (
+		name     string
+		dims     []int64
+		dataType int
+		raw      []byte
+		values   []float64
+		strs     []string
+	)
+
+	r := fieldReader{b: b}
+
+	for {
+		ok, err := r.next()
+		if err != nil {
+			return name, nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		switch r.num {
+		case 1:
+			if dims, err = r.varints(dims); err != nil {
+				return name, nil, err
+			}
+		case 2:
+			dataType = int(r.v)
+		case 4:
+			if values, err = r.floats(values); err != nil {
+				return name, nil, err
+			}
+		case 5, 7, 11:
+			ints, err := r.varints(nil)
+			if err != nil {
+				return name, nil, err
+			}
+
+			for _, v := range ints {
+				switch r.num {
+				case 5:
+					values = append(values, float64(int32(v)))
+				case 11:
+					values = append(values, float64(uint64(v)))
+				default:
+					values = append(values, float64(v))
+				}
+			}
+		case 6:
+			strs = append(strs, string(r.val))
+		case 8:
+			name = string(r.val)
+		case 9:
+			raw = r.val
+		case 10:
+			if values, err = r.doubles(values); err != nil {
+				return name, nil, err
+			}
+		case 13, 14:
+			return name, nil, errors.New("external data is not supported")
+		}
+	}
+
+	shape := make([]int, len(dims))
+	size := 1
+
+	for i, d := range dims {
+		if d < 0 {
+			return name, nil, fmt.Errorf("invalid dimension %d", d)
+		}
+
+		shape[i] = int(d)
+		size *= int(d)
+	}
+
+	if dataType == typeString {
+		if len(strs) != size {
+			return name, nil, fmt.Errorf("%d strings for %d elements", len(strs), size)
+		}
+
+		return name, &Tensor{Shape: shape, Strings: strs}, nil
+	}
+
+	if raw != nil {
+		var err error
+
+		if values, err = decodeRaw(raw, dataType); err != nil {
+			return name, nil, err
+		}
+	}
+
+	if len(values) != size {
+		return name, nil, fmt.Errorf("%d values for %d elements", len(values), size)
+	}
+
+	return name, &Tensor{Shape: shape, Data: values}, nil
+}
+
+// decodeRaw reads the little-endian raw_data of a tensor.
+func decodeRaw(raw []byte, dataType int) ([]float64, error) {
+	sizes := map[int]int{
+		typeFloat: 4, typeDouble: 8,
+		typeInt8: 1, typeUint8: 1, typeBool: 1,
+		typeInt16: 2, typeUint16: 2,
+		typeInt32: 4, typeUint32: 4,
+		typeInt64: 8, typeUint64: 8,
+	}
+
+	size, ok := sizes[dataType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported data type %d", dataType)
+	}
+
+	if len(raw)%size != 0 {
+		return nil, fmt.Errorf("raw data of %d bytes for elements of %d bytes", len(raw), size)
+	}
+
+	values := make([]float64, 0, len(raw)/size)
+
+	for i := 0; i < len(raw); i += size {
+		var v float64
+
+		switch dataType {
+		case typeFloat:
+			v = float64(math.Float32frombits(binary.LittleEndian.Uint32(raw[i:])))
+		case typeDouble:
+			v = math.Float64frombits(binary.LittleEndian.Uint64(raw[i:]))
+		case typeInt8:
+			v = float64(int8(raw[i]))
+		case typeUint8, typeBool:
+			v = float64(raw[i])
+		case typeInt16:
+			v = float64(int16(binary.LittleEndian.Uint16(raw[i:])))
+		case typeUint16:
+			v = float64(binary.LittleEndian.Uint16(raw[i:]))
+		case typeInt32:
+			v = float64(int32(binary.LittleEndian.Uint32(raw[i:])))
+		case typeUint32:
+			v = float64(binary.LittleEndian.Uint32(raw[i:]))
+		case typeInt64:
+			v = float64(int64(binary.LittleEndian.Uint64(raw[i:])))
+		case typeUint64:
+			v = float64(binary.LittleEndian.Uint64(raw[i:]))
+		}
+
+		values = append(values, v)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,176 @@
+package onnx
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Tensor is a dense tensor, in row-major order. The values of all numeric types are held as
+// float64, which is exact for the integers used as shapes, indices and labels. Tensors of
+// strings (class labels) only have Strings.
+type Tensor struct {
+	Shape   []int
+	Data    []float64
+	Strings []string
+}
+
+func newTensor(shape []int) *Tensor {
+	return &Tensor{Shape: shape, Data: make([]float64, shapeSize(shape))}
+}
+
+func shapeSize(shape []int) int {
+	size := 1
+	for _, d := range shape {
+		size *= d
+	}
+
+	return size
+}
+
+func (t *Tensor) size() int {
+	return shapeSize(t.Shape)
+}
+
+func (t *Tensor) isString() bool {
+	return t.Strings != nil
+}
+
+// strides returns the number of elements between two consecutive indices of each dimension.
+func strides(shape []int) []int {
+	ret := make([]int, len(shape))
+	stride := 1
+
+	for i := len(shape) - 1; i >= 0; i-- {
+		ret[i] = stride
+		stride *= shape[i]
+	}
+
+	return ret
+}
+
+// normalizeAxis returns a positive axis for a tensor of rank dimensions.
+func normalizeAxis(axis int64, rank int) (int, error) {
+	if axis < 0 {
+		axis += int64(rank)
+	}
+
+	if axis < 0 || axis >= int64(rank) {
+		return 0, fmt.Errorf("axis %d out of range for rank %d", axis, rank)
+	}
+
+	return int(axis), nil
+}
+
+// broadcastShape returns the shape of the result of an element-wise operation, with the
+// numpy broadcasting rules.
+func broadcastShape(a []int, b []int) ([]int, error) {
+	rank := max(len(a), len(b))
+	ret := make([]int, rank)
+
+	for i := range rank {
+		da, db := 1, 1
+
+		if j := i - (rank - len(a)); j >= 0 {
+			da = a[j]
+		}
+
+		if j := i - (rank - len(b)); j >= 0 {
+			db = b[j]
+		}
+
+		switch {
+		case da == db, db == 1:
+			ret[i] = da
+		case da == 1:
+			ret[i] = db
+		default:
+			return nil, fmt.Errorf("shapes %v and %v can't be broadcast", a, b)
+		}
+	}
+
+	return ret, nil
+}
+
+// broadcastIndex returns the index in a tensor of a shape broadcast to outShape, for an
+// index of the output.
+func broadcastIndex(idx int, outShape []int, outStrides []int, shape []int, shapeStrides []int) int {
+	ret := 0
+	offset := len(outShape) - len(shape)
+
+	for i := range outShape {
+		pos := (idx / outStrides[i]) % outShape[i]
+
+		j := i - offset
+		if j < 0 || shape[j] == 1 {
+			continue
+		}
+
+		ret += pos * shapeStrides[j]
+	}
+
+	return ret
+}
+
+// elementwise applies a binary operation, with broadcasting.
+func elementwise(a *Tensor, b *Tensor, op func(float64, float64) float64) (*Tensor, error) {
+	if a.isString() || b.isString() {
+		return nil, fmt.Errorf("unsupported string tensor")
+	}
+
+	if slices.Equal(a.Shape, b.Shape) {
+		ret := newTensor(slices.Clone(a.Shape))
+		for i := range ret.Data {
+			ret.Data[i] = op(a.Data[i], b.Data[i])
+		}
+
+		return ret, nil
+	}
+
+	shape, err := broadcastShape(a.Shape, b.Shape)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := newTensor(shape)
+	outStrides := strides(shape)
+	aStrides := strides(a.Shape)
+	bStrides := strides(b.Shape)
+
+	for i := range ret.Data {
+		ret.Data[i] = op(
+			a.Data[broadcastIndex(i, shape, outStrides, a.Shape, aStrides)],
+			b.Data[broadcastIndex(i, shape, outStrides, b.Shape, bStrides)],
+		)
+	}
+
+	return ret, nil
+}
+
+// unary applies a function to each element.
+func unary(t *Tensor, op func(float64) float64) (*Tensor, error) {
+	if t.isString() {
+		return nil, fmt.Errorf("unsupported string tensor")
+	}
+
+	ret := newTensor(slices.Clone(t.Shape))
+	for i, v := range t.Data {
+		ret.Data[i] = op(v)
+	}
+
+	return ret, nil
+}
+
+// rows returns a view of a tensor as a matrix of [batch, features], batch being the
+// product of all the dimensions but the last.
+func rows(t *Tensor) (int, int) {
+	if len(t.Shape) == 0 {
+		return 1, 1
+	}
+
+	cols := t.Shape[len(t.Shape)-1]
+	if cols == 0 {
+		return 0, 0
+	}
+
+	return t.size() / cols, cols
+}
@@ -14,34 +14,42 @@ type (
 
 type EnricherCtx struct {
 	Registered map[string]*Enricher
+	// Routines is the maximum number of statics of an event computed at the same time
+	Routines int
 }
 
 type Enricher struct {
 	Name       string
 	EnrichFunc EnrichFunc
+	// Concurrent is set when the enricher doesn't modify the event
+	Concurrent bool
 }
 
 /* mimic plugin loading */
 func Loadplugin() (EnricherCtx, error) {
-	enricherCtx := EnricherCtx{}
+	enricherCtx := EnricherCtx{Routines: 1}
 	enricherCtx.Registered = make(map[string]*Enricher)
 
 	EnrichersList := []*Enricher{
 		{
 			Name:       "GeoIpCity",
 			EnrichFunc: GeoIpCity,
+			Concurrent: true,
 		},
 		{
 			Name:       "GeoIpASN",
 			EnrichFunc: GeoIpASN,
+			Concurrent: true,
 		},
 		{
 			Name:       "IpToRange",
 			EnrichFunc: IpToRange,
+			Concurrent: true,
 		},
 		{
 			Name:       "reverse_dns",
 			EnrichFunc: reverse_dns,
+			Concurrent: true,
 		},
 		{
 			Name:       "ParseDate",
@@ -0,0 +1,255 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+)
+
+const (
+	// ErrorBudgetScenario is the scenario of the alerts raised when a datasource goes over the parsing error budget.
+	ErrorBudgetScenario = "crowdsecurity/parser-error-budget"
+	ErrorBudgetScope    = "datasource"
+)
+
+// ErrorBudget counts, for each datasource, the lines that could not be parsed in a window.
+// A nil budget is never exhausted.
+type ErrorBudget struct {
+	cfg     *csconfig.ParseErrorBudgetCfg
+	mu      sync.Mutex
+	sources map[string]*sourceBudget
+	now     func() time.Time
+}
+
+type sourceBudget struct {
+	src         string
+	module      string
+	acquisType  string
+	windowStart time.Time
+	total       int
+	failed      int
+	// an alert was raised, and the source has not had a window under budget since
+	exhausted        bool
+	quarantinedUntil time.Time
+}
+
+// ErrorBudgetReport describes a datasource that just went over budget.
+type ErrorBudgetReport struct {
+	Source      string
+	Module      string
+	AcquisType  string
+	Total       int
+	Failed      int
+	Window      time.Duration
+	MaxRatio    float64
+	Quarantined time.Duration // 0 if the source is not quarantined
+	Start       time.Time     // of the window
+	Time        time.Time
+}
+
+func NewErrorBudget(cfg *csconfig.ParseErrorBudgetCfg) *ErrorBudget {
+	return &ErrorBudget{
+		cfg:     cfg,
+		sources: map[string]*sourceBudget{},
+		now:     time.Now,
+	}
+}
+
+func (b *ErrorBudget) checks(evt pipeline.Event) bool {
+	if b == nil || evt.Type != pipeline.LOG || evt.Line.Src == "" {
+		return false
+	}
+
+	return len(b.cfg.Sources) == 0 || slices.Contains(b.cfg.Sources, evt.Line.Labels["type"])
+}
+
+func (s *sourceBudget) reset(now time.Time) {
+	s.windowStart = now
+	s.total = 0
+	s.failed = 0
+}
+
+func (s *sourceBudget) overBudget(cfg *csconfig.ParseErrorBudgetCfg) bool {
+	return s.total >= *cfg.MinLines && float64(s.failed) > *cfg.MaxRatio*float64(s.total)
+}
+
+func (s *sourceBudget) labels() prometheus.Labels {
+	return prometheus.Labels{"source": s.src, "type": s.module, "acquis_type": s.acquisType}
+}
+
+// Quarantined returns true if the lines of the event's datasource must not be parsed.
+// The source is released when the quarantine is over, with a new window.
+func (b *ErrorBudget) Quarantined(evt pipeline.Event) bool {
+	if !b.checks(evt) || !b.cfg.Quarantine {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.sources[evt.Line.Module+":"+evt.Line.Src]
+	if s == nil || s.quarantinedUntil.IsZero() {
+		return false
+	}
+
+	now := b.now()
+
+	if now.Before(s.quarantinedUntil) {
+		metrics.GlobalParserQuarantinedLines.With(s.labels()).Inc()
+		return true
+	}
+
+	log.Infof("parsing error budget: resuming the parsing of %s:%s", s.module, s.src)
+
+	s.quarantinedUntil = time.Time{}
+	s.reset(now)
+	metrics.GlobalParserQuarantinedSources.Delete(s.labels())
+
+	return false
+}
+
+// Record counts a line of a datasource, and returns a report if the datasource just went over budget.
+// There is one report until the source has a full window under budget again, but a source
+// that is still failing after its quarantine is quarantined again.
+func (b *ErrorBudget) Record(evt pipeline.Event, failed bool) *ErrorBudgetReport {
+	if !b.checks(evt) {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	key := evt.Line.Module + ":" + evt.Line.Src
+
+	s := b.sources[key]
+	if s == nil {
+		b.prune(now)
+
+		s = &sourceBudget{
+			src:         evt.Line.Src,
+			module:      evt.Line.Module,
+			acquisType:  evt.Line.Labels["type"],
+			windowStart: now,
+		}
+		b.sources[key] = s
+	}
+
+	if now.Sub(s.windowStart) >= *b.cfg.Window {
+		if s.total >= *b.cfg.MinLines && !s.overBudget(b.cfg) {
+			s.exhausted = false
+		}
+
+		s.reset(now)
+	}
+
+	s.total++
+
+	if failed {
+		s.failed++
+	}
+
+	if !s.overBudget(b.cfg) || !s.quarantinedUntil.IsZero() {
+		return nil
+	}
+
+	report := &ErrorBudgetReport{
+		Source:     s.src,
+		Module:     s.module,
+		AcquisType: s.acquisType,
+		Total:      s.total,
+		Failed:     s.failed,
+		Window:     *b.cfg.Window,
+		MaxRatio:   *b.cfg.MaxRatio,
+		Start:      s.windowStart,
+		Time:       now,
+	}
+
+	if b.cfg.Quarantine {
+		report.Quarantined = *b.cfg.QuarantineDuration
+		s.quarantinedUntil = now.Add(report.Quarantined)
+		metrics.GlobalParserQuarantinedSources.With(s.labels()).Set(1)
+		log.Warningf("parsing error budget: %s, the lines are not parsed for %s", report.summary(), report.Quarantined)
+	}
+
+	if s.exhausted {
+		return nil
+	}
+
+	s.exhausted = true
+
+	return report
+}
+
+// prune forgets the sources that have not sent a line for two windows, like the short-lived
+// connections of a syslog server.
+func (b *ErrorBudget) prune(now time.Time) {
+	for key, s := range b.sources {
+		if s.quarantinedUntil.IsZero() && now.Sub(s.windowStart) >= 2**b.cfg.Window {
+			delete(b.sources, key)
+		}
+	}
+}
+
+func (r *ErrorBudgetReport) summary() string {
+	return fmt.Sprintf("%d/%d lines of %s:%s failed to parse in %s (max: %s%%)",
+		r.Failed, r.Total, r.Module, r.Source, r.Window,
+		strconv.FormatFloat(r.MaxRatio*100, 'f', -1, 64))
+}
+
+// Event returns the overflow event of the alert raised for the report. The alert has no decision.
+func (r *ErrorBudgetReport) Event() pipeline.Event {
+	message := "parsing error budget exhausted: " + r.summary()
+	if r.Quarantined > 0 {
+		message += fmt.Sprintf(", parsing quarantined for %s", r.Quarantined)
+	}
+
+	ts := r.Time.UTC().Format(time.RFC3339)
+	meta := models.Meta{
+		{Key: "datasource_type", Value: r.AcquisType},
+		{Key: "module", Value: r.Module},
+		{Key: "failed_lines", Value: strconv.Itoa(r.Failed)},
+		{Key: "total_lines", Value: strconv.Itoa(r.Total)},
+		{Key: "window", Value: r.Window.String()},
+		{Key: "quarantined", Value: strconv.FormatBool(r.Quarantined > 0)},
+	}
+
+	alert := models.Alert{
+		Source: &models.Source{
+			Scope: new(ErrorBudgetScope),
+			Value: new(r.Module + ":" + r.Source),
+		},
+		Scenario:        new(ErrorBudgetScenario),
+		Kind:            types.AgentAlertKind.String(),
+		Message:         new(message),
+		StartAt:         new(r.Start.UTC().Format(time.RFC3339)),
+		StopAt:          new(ts),
+		Capacity:        new(int32(0)),
+		Simulated:       new(false),
+		EventsCount:     new(int32(1)),
+		Leakspeed:       new(""),
+		ScenarioHash:    new(""),
+		ScenarioVersion: new(""),
+		Events:          []*models.Event{{Timestamp: new(ts), Meta: meta}},
+		Meta:            meta,
+	}
+
+	evt := pipeline.MakeEvent(false, pipeline.OVFLW, true)
+	evt.Overflow = pipeline.RuntimeAlert{
+		Alert:     &alert,
+		APIAlerts: []models.Alert{alert},
+	}
+
+	return evt
+}
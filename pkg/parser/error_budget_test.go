@@ -0,0 +1,164 @@
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/models"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+	"github.com/crowdsecurity/crowdsec/pkg/types"
+)
+
+func budgetEvent(src string, acquisType string) pipeline.Event {
+	return pipeline.Event{
+		Type: pipeline.LOG,
+		Line: pipeline.Line{
+			Src:    src,
+			Module: "file",
+			Labels: map[string]string{"type": acquisType},
+		},
+	}
+}
+
+func newTestBudget(t *testing.T, cfg *csconfig.ParseErrorBudgetCfg) (*ErrorBudget, *time.Time) {
+	t.Helper()
+
+	require.NoError(t, cfg.Load())
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := NewErrorBudget(cfg)
+	b.now = func() time.Time { return now }
+
+	return b, &now
+}
+
+// record sends n lines, and returns the reports
+func record(b *ErrorBudget, evt pipeline.Event, n int, failed bool) []*ErrorBudgetReport {
+	var ret []*ErrorBudgetReport
+
+	for range n {
+		if b.Quarantined(evt) {
+			continue
+		}
+
+		if r := b.Record(evt, failed); r != nil {
+			ret = append(ret, r)
+		}
+	}
+
+	return ret
+}
+
+func TestErrorBudgetNil(t *testing.T) {
+	var b *ErrorBudget
+
+	evt := budgetEvent("/var/log/auth.log", "syslog")
+	assert.False(t, b.Quarantined(evt))
+	assert.Nil(t, b.Record(evt, true))
+}
+
+func TestErrorBudgetAlert(t *testing.T) {
+	b, now := newTestBudget(t, &csconfig.ParseErrorBudgetCfg{
+		MaxRatio: new(0.5),
+		MinLines: new(10),
+		Sources:  []string{"nginx"},
+	})
+
+	evt := budgetEvent("/var/log/nginx/access.log", "nginx")
+
+	// not checked before min_lines
+	assert.Empty(t, record(b, evt, 9, true))
+
+	reports := record(b, evt, 1, true)
+	require.Len(t, reports, 1)
+	assert.Equal(t, 10, reports[0].Failed)
+	assert.Equal(t, 10, reports[0].Total)
+	assert.Zero(t, reports[0].Quarantined)
+
+	// one report while the source stays over budget, even in the next windows
+	assert.Empty(t, record(b, evt, 100, true))
+	assert.False(t, b.Quarantined(evt))
+
+	*now = now.Add(5 * time.Minute)
+	assert.Empty(t, record(b, evt, 100, true))
+
+	// a window under budget, then the source fails again
+	*now = now.Add(5 * time.Minute)
+	assert.Empty(t, record(b, evt, 20, false))
+
+	*now = now.Add(5 * time.Minute)
+	assert.Empty(t, record(b, evt, 5, false))
+	assert.Len(t, record(b, evt, 10, true), 1)
+
+	// other datasources are not checked
+	other := budgetEvent("/var/log/auth.log", "syslog")
+	assert.Empty(t, record(b, other, 100, true))
+}
+
+func TestErrorBudgetQuarantine(t *testing.T) {
+	b, now := newTestBudget(t, &csconfig.ParseErrorBudgetCfg{
+		MaxRatio:           new(0.5),
+		MinLines:           new(10),
+		Quarantine:         true,
+		QuarantineDuration: new(time.Hour),
+	})
+
+	evt := budgetEvent("/var/log/nginx/access.log", "nginx")
+
+	reports := record(b, evt, 10, true)
+	require.Len(t, reports, 1)
+	assert.Equal(t, time.Hour, reports[0].Quarantined)
+	assert.True(t, b.Quarantined(evt))
+
+	// the other sources are still parsed
+	assert.False(t, b.Quarantined(budgetEvent("/var/log/nginx/error.log", "nginx")))
+
+	*now = now.Add(59 * time.Minute)
+	assert.True(t, b.Quarantined(evt))
+
+	// still failing after the quarantine: quarantined again, without a new report
+	*now = now.Add(time.Minute)
+	assert.Empty(t, record(b, evt, 10, true))
+	assert.True(t, b.Quarantined(evt))
+
+	// fixed
+	*now = now.Add(time.Hour)
+	assert.Empty(t, record(b, evt, 100, false))
+	assert.False(t, b.Quarantined(evt))
+}
+
+func TestErrorBudgetEvent(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	r := ErrorBudgetReport{
+		Source:      "/var/log/nginx/access.log",
+		Module:      "file",
+		AcquisType:  "nginx",
+		Total:       1000,
+		Failed:      950,
+		Window:      5 * time.Minute,
+		MaxRatio:    0.9,
+		Quarantined: time.Hour,
+		Start:       start,
+		Time:        start.Add(2 * time.Minute),
+	}
+
+	evt := r.Event()
+	assert.Equal(t, pipeline.OVFLW, evt.Type)
+
+	alert := evt.Overflow.Alert
+	require.NotNil(t, alert)
+	assert.Equal(t, ErrorBudgetScenario, *alert.Scenario)
+	assert.Equal(t, types.AgentAlertKind.String(), alert.Kind)
+	assert.Equal(t, "file:/var/log/nginx/access.log", *alert.Source.Value)
+	assert.Equal(t, "2024-01-01T12:00:00Z", *alert.StartAt)
+	assert.Equal(t, "2024-01-01T12:02:00Z", *alert.StopAt)
+	assert.Equal(t, "parsing error budget exhausted: 950/1000 lines of file:/var/log/nginx/access.log failed to parse in 5m0s (max: 90%), parsing quarantined for 1h0m0s", *alert.Message)
+	assert.Empty(t, alert.Decisions)
+	assert.Contains(t, alert.Meta, &models.MetaItems0{Key: "quarantined", Value: "true"})
+	require.NoError(t, alert.Validate(nil))
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+	"github.com/crowdsecurity/crowdsec/pkg/fflag"
 	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
 )
 
@@ -62,6 +64,12 @@ func (g *GrokPattern) Compile(pctx *UnixParserCtx, logger *log.Entry) (*RuntimeG
 		logger.Tracef("%s regexp: %s", g.RegexpValue, rg.RunTimeRegexp.String())
 	}
 
+	if rg.RunTimeRegexp != nil {
+		if err := g.checkRisky(rg, pctx, logger); err != nil {
+			return nil, err
+		}
+	}
+
 	// if grok source is an expression
 	if g.ExpValue != "" {
 		rg.RunTimeValue, err = expr.Compile(g.ExpValue,
@@ -85,6 +93,43 @@ func (g *GrokPattern) Compile(pctx *UnixParserCtx, logger *log.Entry) (*RuntimeG
 	return rg, nil
 }
 
+// checkRisky warns about the constructs of the compiled pattern that are prone to catastrophic
+// backtracking. With the re2_grok_auto_switch feature flag, the pattern is compiled again with
+// the RE2 engine, if it was not already in use.
+func (g *GrokPattern) checkRisky(rg *RuntimeGrokPattern, pctx *UnixParserCtx, logger *log.Entry) error {
+	risky := riskyConstructs(rg.RunTimeRegexp.String())
+	if len(risky) == 0 {
+		return nil
+	}
+
+	name := cmp.Or(g.RegexpName, g.RegexpValue)
+
+	logger.Warningf("grok %q is prone to catastrophic backtracking: %s", name, strings.Join(risky, ", "))
+
+	if pctx.Grok.UseRe2 || !fflag.Re2GrokAutoSwitch.IsEnabled() {
+		return nil
+	}
+
+	re2Host := pctx.Grok
+	re2Host.UseRe2 = true
+
+	var err error
+
+	if g.RegexpName != "" {
+		rg.RunTimeRegexp, err = re2Host.Get(g.RegexpName)
+	} else {
+		rg.RunTimeRegexp, err = re2Host.Compile(g.RegexpValue)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to compile grok %q with RE2: %w", name, err)
+	}
+
+	logger.Infof("grok %q: using the RE2 engine", name)
+
+	return nil
+}
+
 func (g *GrokPattern) Validate() error {
 	if g.TargetField == "" && g.ExpValue == "" {
 		return errors.New("grok requires 'expression' or 'apply_on'")
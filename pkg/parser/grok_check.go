@@ -0,0 +1,300 @@
+package parser
+
+import (
+	"fmt"
+	"regexp/syntax"
+	"slices"
+	"unicode"
+)
+
+// The go regexp and RE2 engines don't backtrack, but the constructs that make a backtracking
+// engine explode also make them slow, and a pattern that relies on them is fragile anyway.
+// The checks are heuristics: they can flag a pattern that is not really ambiguous.
+
+// maxReportedLen is the maximum length of the sub-expression quoted in a report.
+const maxReportedLen = 40
+
+// riskyConstructs returns a description of the constructs of a regular expression
+// that are prone to catastrophic backtracking:
+//
+//   - a repetition whose body can match the same input in several ways from one iteration to the
+//     next, because a repeated element overlaps with what follows it: (a+)+, (\w+\s?)*, (.*a)*
+//   - a repetition of an alternation whose branches can start with the same character: (a|ab)*
+func riskyConstructs(expr string) []string {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+
+	var ret []string
+
+	walkRegexp(re, func(r *syntax.Regexp) {
+		if !isUnbounded(r) {
+			return
+		}
+
+		body := r.Sub[0]
+
+		switch {
+		case hasOverlappingRepeat(body):
+			ret = append(ret, "nested quantifier in "+quoteRegexp(r))
+		case hasOverlappingAlternation(body):
+			ret = append(ret, "overlapping alternation in "+quoteRegexp(r))
+		}
+	})
+
+	return slices.Compact(ret)
+}
+
+func walkRegexp(re *syntax.Regexp, fn func(*syntax.Regexp)) {
+	fn(re)
+
+	for _, sub := range re.Sub {
+		walkRegexp(sub, fn)
+	}
+}
+
+func quoteRegexp(re *syntax.Regexp) string {
+	s := re.String()
+	if len(s) > maxReportedLen {
+		s = s[:maxReportedLen] + "..."
+	}
+
+	return fmt.Sprintf("%q", s)
+}
+
+func isUnbounded(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus:
+		return true
+	case syntax.OpRepeat:
+		return re.Max == -1
+	default:
+		return false
+	}
+}
+
+// elements returns the sequence of elements of a regexp, without the groups.
+func elements(re *syntax.Regexp) []*syntax.Regexp {
+	switch re.Op {
+	case syntax.OpCapture:
+		return elements(re.Sub[0])
+	case syntax.OpConcat:
+		var ret []*syntax.Regexp
+		for _, sub := range re.Sub {
+			ret = append(ret, elements(sub)...)
+		}
+
+		return ret
+	default:
+		return []*syntax.Regexp{re}
+	}
+}
+
+// hasOverlappingRepeat returns true if the body of a repetition contains a repeated element
+// that can match the characters that follow it, in the same iteration or in the next one.
+func hasOverlappingRepeat(body *syntax.Regexp) bool {
+	elems := elements(body)
+	bodyFirst := firstChars(body)
+
+	for i, elem := range elems {
+		if !isUnbounded(elem) {
+			continue
+		}
+
+		chars := allChars(elem)
+
+		var follow []rune
+
+		nullableRest := true
+
+		for _, next := range elems[i+1:] {
+			// (\b\w+\b\s*)*: the word boundary prevents the next word from overlapping
+			if next.Op == syntax.OpWordBoundary && subsetOf(chars, wordChars) {
+				nullableRest = false
+				break
+			}
+
+			follow = append(follow, firstChars(next)...)
+
+			if !nullable(next) {
+				nullableRest = false
+				break
+			}
+		}
+
+		if nullableRest {
+			follow = append(follow, bodyFirst...)
+		}
+
+		if overlaps(chars, follow) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasOverlappingAlternation returns true if the body of a repetition is an alternation with
+// two branches that can start with the same character.
+func hasOverlappingAlternation(body *syntax.Regexp) bool {
+	elems := elements(body)
+	if len(elems) != 1 || elems[0].Op != syntax.OpAlternate {
+		return false
+	}
+
+	branches := elems[0].Sub
+
+	for i := range branches {
+		for j := i + 1; j < len(branches); j++ {
+			if overlaps(firstChars(branches[i]), firstChars(branches[j])) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func nullable(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune) == 0
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL, syntax.OpNoMatch:
+		return false
+	case syntax.OpCapture, syntax.OpPlus:
+		return nullable(re.Sub[0])
+	case syntax.OpRepeat:
+		return re.Min == 0 || nullable(re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !nullable(sub) {
+				return false
+			}
+		}
+
+		return true
+	case syntax.OpAlternate:
+		return slices.ContainsFunc(re.Sub, nullable)
+	default:
+		// star, quest, empty match, anchors, word boundaries
+		return true
+	}
+}
+
+// runeRanges returns the characters matched by a single character regexp, as pairs of bounds.
+func runeRanges(re *syntax.Regexp, r rune) []rune {
+	if re.Flags&syntax.FoldCase == 0 {
+		return []rune{r, r}
+	}
+
+	ret := []rune{r, r}
+
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		ret = append(ret, f, f)
+	}
+
+	return ret
+}
+
+// firstChars returns the characters a regexp can start with, as pairs of bounds.
+func firstChars(re *syntax.Regexp) []rune {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if len(re.Rune) == 0 {
+			return nil
+		}
+
+		return runeRanges(re, re.Rune[0])
+	case syntax.OpCharClass:
+		return re.Rune
+	case syntax.OpAnyChar:
+		return []rune{0, unicode.MaxRune}
+	case syntax.OpAnyCharNotNL:
+		return []rune{0, '\n' - 1, '\n' + 1, unicode.MaxRune}
+	case syntax.OpCapture, syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		return firstChars(re.Sub[0])
+	case syntax.OpConcat:
+		var ret []rune
+
+		for _, sub := range re.Sub {
+			ret = append(ret, firstChars(sub)...)
+
+			if !nullable(sub) {
+				break
+			}
+		}
+
+		return ret
+	case syntax.OpAlternate:
+		var ret []rune
+
+		for _, sub := range re.Sub {
+			ret = append(ret, firstChars(sub)...)
+		}
+
+		return ret
+	default:
+		return nil
+	}
+}
+
+// allChars returns all the characters a regexp can match, as pairs of bounds.
+func allChars(re *syntax.Regexp) []rune {
+	if re.Op == syntax.OpLiteral {
+		var ret []rune
+
+		for _, r := range re.Rune {
+			ret = append(ret, runeRanges(re, r)...)
+		}
+
+		return ret
+	}
+
+	if len(re.Sub) == 0 {
+		return firstChars(re)
+	}
+
+	var ret []rune
+
+	for _, sub := range re.Sub {
+		ret = append(ret, allChars(sub)...)
+	}
+
+	return ret
+}
+
+// the characters of \w
+var wordChars = []rune{'0', '9', 'A', 'Z', '_', '_', 'a', 'z'}
+
+// subsetOf returns true if all the ranges of a are included in one of the ranges of b.
+func subsetOf(a []rune, b []rune) bool {
+	for i := 0; i+1 < len(a); i += 2 {
+		included := false
+
+		for j := 0; j+1 < len(b); j += 2 {
+			if b[j] <= a[i] && a[i+1] <= b[j+1] {
+				included = true
+				break
+			}
+		}
+
+		if !included {
+			return false
+		}
+	}
+
+	return true
+}
+
+func overlaps(a []rune, b []rune) bool {
+	for i := 0; i+1 < len(a); i += 2 {
+		for j := 0; j+1 < len(b); j += 2 {
+			if a[i] <= b[j+1] && b[j] <= a[i+1] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/grokky"
+
+	"github.com/crowdsecurity/crowdsec/pkg/fflag"
+)
+
+func TestRiskyConstructs(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{expr: `^\d+ [a-z]+$`},
+		{expr: `(a+)+$`, want: []string{`nested quantifier in "(a+)+"`}},
+		{expr: `(?:\w+\s?)*!`, want: []string{`nested quantifier in "(?:[0-9A-Z_a-z]+[\\t\\n\\f\\r ]?)*"`}},
+		{expr: `(.*a)*`, want: []string{`nested quantifier in "(?-s:(.*a)*)"`}},
+		{expr: `(\d+|[a-z0-9]+)*x`, want: []string{`overlapping alternation in "([0-9]+|[0-9a-z]+)*"`}},
+		{expr: `(foo|bar)+`},
+		// the separators don't overlap with the words
+		{expr: `(?:\s+\w+)*`},
+		{expr: `(?:[a-z]+\.)+com`},
+		// the word boundary prevents a word from being split
+		{expr: `(?:\b\w+\b\s*)*`},
+		// bounded repetitions are fine
+		{expr: `(?:[a-z]{1,10}\.?){1,5}`},
+		{expr: `(?i)(?:A+a)*`, want: []string{`nested quantifier in "(?i:(?:A+A)*)"`}},
+		// invalid expressions are reported when compiled
+		{expr: `(a+`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			assert.Equal(t, tc.want, riskyConstructs(tc.expr))
+		})
+	}
+}
+
+func TestGrokCheckRisky(t *testing.T) {
+	pctx := &UnixParserCtx{Grok: newGrokHost()}
+	pctx.Grok.UseRe2 = false
+
+	require.NoError(t, pctx.Grok.Add("WORDS", `(?:\w+\s?)+`))
+
+	logger, hook := logtest.NewNullLogger()
+	entry := log.NewEntry(logger)
+
+	g := GrokPattern{RegexpValue: `^%{WORDS:words}!`, TargetField: "Line.Raw"}
+
+	rg, err := g.Compile(pctx, entry)
+	require.NoError(t, err)
+	assert.IsType(t, &grokky.PatternLegacy{}, rg.RunTimeRegexp)
+	require.NotNil(t, hook.LastEntry())
+	assert.Equal(t, log.WarnLevel, hook.LastEntry().Level)
+	assert.Contains(t, hook.LastEntry().Message, `grok "^%{WORDS:words}!" is prone to catastrophic backtracking: nested quantifier in`)
+
+	require.NoError(t, fflag.Re2GrokAutoSwitch.Set(true))
+	t.Cleanup(func() { _ = fflag.Re2GrokAutoSwitch.Set(false) })
+
+	rg, err = g.Compile(pctx, entry)
+	require.NoError(t, err)
+	assert.IsType(t, &grokky.PatternRe2{}, rg.RunTimeRegexp)
+	assert.Equal(t, `grok "^%{WORDS:words}!": using the RE2 engine`, hook.LastEntry().Message)
+	assert.Equal(t, map[string]string{"words": "hello world"}, rg.RunTimeRegexp.Parse("hello world!"))
+
+	// a safe pattern is left alone
+	hook.Reset()
+
+	g = GrokPattern{RegexpValue: `^%{WORD:word}!`, TargetField: "Line.Raw"}
+
+	rg, err = g.Compile(pctx, entry)
+	require.NoError(t, err)
+	assert.IsType(t, &grokky.PatternLegacy{}, rg.RunTimeRegexp)
+	assert.Empty(t, hook.AllEntries())
+}
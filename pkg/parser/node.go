@@ -37,6 +37,7 @@ type Node struct {
 	RuntimeGrok RuntimeGrokPattern `yaml:"-"`
 	RuntimeStatics []RuntimeStatic `yaml:"-"`
 	RuntimeStashes []RuntimeStash `yaml:"-"`
+	RuntimeCache *RuntimeNodeCache `yaml:"-"`
 }
 
 func (n *Node) UnmarshalYAML(unmarshal func(any) error) error {
@@ -98,10 +99,16 @@ func (n *Node) validate(ectx EnricherCtx) error {
 		}
 	}
 
+	if n.Cache != nil {
+		if err := n.Cache.Validate(n.Statics); err != nil {
+			return fmt.Errorf("cache: %w", err)
+		}
+	}
+
 	return nil
 }
 
-func (n *Node) processFilter(cachedExprEnv map[string]any) (bool, error) {
+func (n *Node) processFilter(p *pipeline.Event, cachedExprEnv map[string]any) (bool, error) {
 	clog := n.Logger
 	if n.RunTimeFilter == nil {
 		clog.Trace("Node has no filter, enter")
@@ -113,6 +120,7 @@ func (n *Node) processFilter(cachedExprEnv map[string]any) (bool, error) {
 	if err != nil {
 		clog.Warningf("failed to run filter: %v", err)
 		clog.Debug("Event leaving node: ko")
+		pipeline.RecordTraceStep(p, "filter", n.Name, "error", err.Error())
 
 		return false, nil
 	}
@@ -121,15 +129,20 @@ func (n *Node) processFilter(cachedExprEnv map[string]any) (bool, error) {
 	case bool:
 		if !out {
 			clog.Debug("Event leaving node: ko (failed filter)")
+			pipeline.RecordTraceStep(p, "filter", n.Name, "false", n.Filter)
+
 			return false, nil
 		}
 	default:
 		clog.Warningf("Expr %q returned non-bool, abort: %T", n.Filter, output)
 		clog.Debug("Event leaving node: ko")
+		pipeline.RecordTraceStep(p, "filter", n.Name, "error", "non-bool result")
 
 		return false, nil
 	}
 
+	pipeline.RecordTraceStep(p, "filter", n.Name, "true", n.Filter)
+
 	return true, nil
 }
 
@@ -146,6 +159,12 @@ func (n *Node) processWhitelist(cachedExprEnv map[string]any, p *pipeline.Event)
 		return false, nil //nolint:nilerr
 	}
 
+	if isWhitelisted {
+		pipeline.RecordTraceStep(p, "whitelist", n.Name, "whitelisted", n.Whitelist.Reason)
+	} else if n.ContainsWLs() {
+		pipeline.RecordTraceStep(p, "whitelist", n.Name, "no match", n.Whitelist.Reason)
+	}
+
 	if isWhitelisted && !p.Whitelisted {
 		p.Whitelisted = true
 		p.WhitelistReason = n.Whitelist.Reason
@@ -187,6 +206,8 @@ func (n *Node) processGrok(p *pipeline.Event, cachedExprEnv map[string]any) (boo
 			gstr = val
 		} else {
 			clog.Debugf("(%s) target field %q doesn't exist in %v", n.rn, n.Grok.TargetField, p.Parsed)
+			pipeline.RecordTraceStep(p, "grok", n.Name, "missing field", n.Grok.TargetField)
+
 			return false, false, nil
 		}
 	} else if n.RuntimeGrok.RunTimeValue != nil {
@@ -220,9 +241,13 @@ func (n *Node) processGrok(p *pipeline.Event, cachedExprEnv map[string]any) (boo
 	if len(grok) == 0 {
 		// grok failed, node failed
 		clog.Debugf("+ Grok %q didn't return data on %q", groklabel, gstr)
+		pipeline.RecordTraceStep(p, "grok", n.Name, "no match", groklabel)
+
 		return false, false, nil
 	}
 
+	pipeline.RecordTraceStep(p, "grok", n.Name, "match", groklabel)
+
 	// tag explicitly that the *current* node had a successful grok pattern. it's important to know success state
 	nodeHasOKGrok = true
 
@@ -279,6 +304,11 @@ func (n *Node) processLeaves(
 				n.Logger.Debugf("child is success, OnSuccess=next_stage, skip")
 				break
 			}
+
+			if child.Break {
+				n.Logger.Debugf("child (%s) is success, break, skip the remaining sub-nodes", child.rn)
+				break
+			}
 		} else if !nodeHasOKGrok {
 			/*
 				If the parent node has a successful grok pattern, its state will stay successful even if one or more childs fail.
@@ -298,7 +328,7 @@ func (n *Node) process(p *pipeline.Event, ctx UnixParserCtx, expressionEnv map[s
 
 	clog.Trace("Event entering node")
 
-	nodeState, err := n.processFilter(cachedExprEnv)
+	nodeState, err := n.processFilter(p, cachedExprEnv)
 	if err != nil {
 		return false, err
 	}
@@ -347,6 +377,7 @@ func (n *Node) process(p *pipeline.Event, ctx UnixParserCtx, expressionEnv map[s
 		}
 
 		clog.Debug("Event leaving node: ko")
+		pipeline.RecordTraceStep(p, "node", n.Name, "ko", "")
 
 		return nodeState, nil
 	}
@@ -362,15 +393,19 @@ func (n *Node) process(p *pipeline.Event, ctx UnixParserCtx, expressionEnv map[s
 	if len(n.Statics) > 0 && (isWhitelisted || !n.ContainsWLs()) {
 		clog.Debugf("+ Processing %d statics", len(n.Statics))
 		// if all else is good in whitelist, process node's statics
-		err := n.ProcessStatics(p)
+		err := n.processCachedStatics(p, cachedExprEnv)
 		if err != nil {
 			clog.Errorf("Failed to process statics: %v", err)
+			pipeline.RecordTraceStep(p, "node", n.Name, "error", err.Error())
+
 			return false, err
 		}
 	} else {
 		clog.Trace("! No node statics")
 	}
 
+	pipeline.RecordTraceStep(p, "node", n.Name, "ok", n.OnSuccess)
+
 	if nodeState {
 		clog.Debug("Event leaving node : ok")
 		log.Trace("node is successful, check strategy")
@@ -460,6 +495,7 @@ func (n *Node) compile(pctx *UnixParserCtx, ectx EnricherCtx) error {
 		}
 
 		n.RuntimeGrok = *rg
+		groupStatics(n.RuntimeGrok.RuntimeStatics, ectx)
 		valid = true
 	}
 
@@ -508,6 +544,15 @@ func (n *Node) compile(pctx *UnixParserCtx, ectx EnricherCtx) error {
 		valid = true
 	}
 
+	groupStatics(n.RuntimeStatics, ectx)
+
+	if n.Cache != nil {
+		n.RuntimeCache, err = n.Cache.Compile()
+		if err != nil {
+			return fmt.Errorf("cache: %w", err)
+		}
+	}
+
 	/* compile whitelists if present */
 	whitelistValid, err := n.CompileWLs()
 	if err != nil {
@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/bluele/gcache"
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/crowdsecurity/crowdsec/pkg/exprhelpers"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// NodeCache is the YAML shape of the cache directive of a node: the changes made by the statics
+// of the node are kept for the value of the key expression, and applied as is to the next events
+// with the same key instead of running the statics (and their enrichment methods) again.
+type NodeCache struct {
+	Key  string `yaml:"key,omitempty"`
+	TTL  string `yaml:"ttl,omitempty"`
+	Size int    `yaml:"size,omitempty"`
+}
+
+type RuntimeNodeCache struct {
+	Config        *NodeCache
+	KeyExpression *vm.Program
+	TTLVal        time.Duration
+	cache         gcache.Cache
+}
+
+// cachedStatics holds the fields set by the statics of a node for a given key.
+type cachedStatics struct {
+	parsed   map[string]string
+	meta     map[string]string
+	enriched map[string]string
+}
+
+const nodeCacheDefaultSize = 1000
+
+func (c *NodeCache) Validate(statics []Static) error {
+	if c.Key == "" {
+		return errors.New("key expression must be set")
+	}
+
+	if c.TTL == "" {
+		return errors.New("ttl must be set")
+	}
+
+	if c.Size < 0 {
+		return errors.New("size must be positive")
+	}
+
+	if len(statics) == 0 {
+		return errors.New("the node has no statics to cache")
+	}
+
+	for idx, static := range statics {
+		// only the changes to Parsed, Meta and Enriched can be replayed
+		if static.TargetByName != "" {
+			return fmt.Errorf("static %d: target can't be used in a cached node", idx)
+		}
+
+		if static.Method == "ParseDate" {
+			return fmt.Errorf("static %d: method ParseDate can't be used in a cached node", idx)
+		}
+	}
+
+	return nil
+}
+
+func (c *NodeCache) Compile() (*RuntimeNodeCache, error) {
+	var err error
+
+	rc := &RuntimeNodeCache{Config: c}
+
+	rc.KeyExpression, err = expr.Compile(c.Key,
+		exprhelpers.GetExprOptions(map[string]any{"evt": &pipeline.Event{}})...)
+	if err != nil {
+		return nil, fmt.Errorf("while compiling cache key expression: %w", err)
+	}
+
+	rc.TTLVal, err = time.ParseDuration(c.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing cache ttl: %w", err)
+	}
+
+	size := c.Size
+	if size == 0 {
+		size = nodeCacheDefaultSize
+	}
+
+	rc.cache = gcache.New(size).LRU().Build()
+
+	return rc, nil
+}
+
+// changedKeys returns the entries of after that are not in before, or have a different value.
+func changedKeys(before map[string]string, after map[string]string) map[string]string {
+	changed := map[string]string{}
+
+	for k, v := range after {
+		if old, ok := before[k]; !ok || old != v {
+			changed[k] = v
+		}
+	}
+
+	return changed
+}
+
+func applyCached(dst map[string]string, src map[string]string) map[string]string {
+	if dst == nil {
+		dst = make(map[string]string, len(src))
+	}
+
+	maps.Copy(dst, src)
+
+	return dst
+}
+
+// processCachedStatics applies the statics of the node, or their cached result if the node
+// has a cache directive and the key was already seen.
+func (n *Node) processCachedStatics(p *pipeline.Event, cachedExprEnv map[string]any) error {
+	rc := n.RuntimeCache
+	if rc == nil {
+		return n.ProcessStatics(p)
+	}
+
+	output, err := exprhelpers.Run(rc.KeyExpression, cachedExprEnv, n.Logger, n.Debug)
+	if err != nil {
+		n.Logger.Warningf("failed to run cache key expression: %v", err)
+		return n.ProcessStatics(p)
+	}
+
+	key, ok := output.(string)
+	if !ok || key == "" {
+		n.Logger.Debugf("cache key expression returned %T (%v), not caching", output, output)
+		return n.ProcessStatics(p)
+	}
+
+	labels := prometheus.Labels{"name": n.Name, "stage": p.Stage}
+
+	if cached, err := rc.cache.Get(key); err == nil {
+		c := cached.(cachedStatics)
+		n.Logger.Debugf("+ Cache hit for %q", key)
+		metrics.NodesCacheHits.With(labels).Inc()
+
+		p.Parsed = applyCached(p.Parsed, c.parsed)
+		p.Meta = applyCached(p.Meta, c.meta)
+		p.Enriched = applyCached(p.Enriched, c.enriched)
+
+		return nil
+	}
+
+	metrics.NodesCacheMisses.With(labels).Inc()
+
+	parsed := maps.Clone(p.Parsed)
+	meta := maps.Clone(p.Meta)
+	enriched := maps.Clone(p.Enriched)
+
+	if err := n.ProcessStatics(p); err != nil {
+		return err
+	}
+
+	c := cachedStatics{
+		parsed:   changedKeys(parsed, p.Parsed),
+		meta:     changedKeys(meta, p.Meta),
+		enriched: changedKeys(enriched, p.Enriched),
+	}
+
+	if err := rc.cache.SetWithExpire(key, c, rc.TTLVal); err != nil {
+		n.Logger.Warningf("while caching statics for %q: %v", key, err)
+	}
+
+	return nil
+}
@@ -24,7 +24,10 @@ type NodeConfig struct {
 	Stage string `yaml:"stage,omitempty"`
 	// OnSuccess allows to tag a node to be able to move log to next stage on success
 	OnSuccess string `yaml:"onsuccess,omitempty"`
-	Filter    string `yaml:"filter,omitempty"`
+	// Break stops the processing of the following nodes of the stage (or of the sibling nodes) on success,
+	// without moving the event to the next stage
+	Break  bool   `yaml:"break,omitempty"`
+	Filter string `yaml:"filter,omitempty"`
 
 	SubNodes []NodeConfig `yaml:"nodes,omitempty"`
 
@@ -40,4 +43,6 @@ type NodeConfig struct {
 	Stashes   []Stash                    `yaml:"stash,omitempty"`
 	Whitelist Whitelist                  `yaml:"whitelist,omitempty"`
 	Data      []*enrichment.DataProvider `yaml:"data,omitempty"`
+	// Cache keeps the result of the statics for the value of a key expression
+	Cache *NodeCache `yaml:"cache,omitempty"`
 }
@@ -3,11 +3,15 @@ package parser
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	yaml "gopkg.in/yaml.v2"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
 )
 
 func TestParserConfigs(t *testing.T) {
-	pctx, err := NewUnixParserCtx("../../config/patterns/", "./testdata/")
+	pctx, err := NewUnixParserCtx("../../config/patterns/", "", "./testdata/")
 	if err != nil {
 		t.Fatalf("unable to load patterns : %s", err)
 	}
@@ -46,6 +50,14 @@ func TestParserConfigs(t *testing.T) {
 			{Key: string("SUBGROKBIS"), Value: string("[a-z]%{MYGROKBIS}")},
 			{Key: string("MYGROKBIS"), Value: string("[a-z]")},
 		}, Grok: GrokPattern{RegexpValue: "^x%{MYGROKBIS:extr}$", TargetField: "t"}}, false, true},
+		// node with cached statics
+		{NodeConfig{Debug: true, Stage: "s00", Cache: &NodeCache{Key: "evt.Meta.source_ip", TTL: "1m"}, Statics: []Static{{Meta: "foo", Value: "bar"}}}, true, true},
+		// cache with a bad ttl
+		{NodeConfig{Debug: true, Stage: "s00", Cache: &NodeCache{Key: "evt.Meta.source_ip", TTL: "1 minute"}, Statics: []Static{{Meta: "foo", Value: "bar"}}}, false, true},
+		// cache without statics
+		{NodeConfig{Debug: true, Stage: "s00", Cache: &NodeCache{Key: "evt.Meta.source_ip", TTL: "1m"}, Grok: GrokPattern{RegexpValue: "^x%{DATA:extr}$", TargetField: "t"}}, false, false},
+		// cache with a target static
+		{NodeConfig{Debug: true, Stage: "s00", Cache: &NodeCache{Key: "evt.Meta.source_ip", TTL: "1m"}, Statics: []Static{{TargetByName: "evt.StrTime", Value: "bar"}}}, false, false},
 	}
 
 	for idx, tc := range CfgTests {
@@ -69,3 +81,136 @@ func TestParserConfigs(t *testing.T) {
 		}
 	}
 }
+
+func TestNodeCache(t *testing.T) {
+	pctx, err := NewUnixParserCtx("../../config/patterns/", "", "./testdata/")
+	require.NoError(t, err)
+
+	node := &Node{NodeConfig: NodeConfig{
+		Stage: "s00",
+		Cache: &NodeCache{Key: "evt.Parsed.ip", TTL: "1m"},
+		Statics: []Static{
+			{Meta: "source_ip", ExpValue: "evt.Parsed.ip"},
+			{Meta: "value", ExpValue: "evt.Parsed.value"},
+		},
+	}}
+	require.NoError(t, node.compile(pctx, EnricherCtx{}))
+	require.NoError(t, node.validate(EnricherCtx{}))
+
+	run := func(ip string, value string) *pipeline.Event {
+		evt := &pipeline.Event{Stage: "s00", Parsed: map[string]string{"ip": ip, "value": value}, Meta: map[string]string{}, Enriched: map[string]string{}}
+		require.NoError(t, node.processCachedStatics(evt, map[string]any{"evt": evt}))
+
+		return evt
+	}
+
+	evt := run("1.2.3.4", "first")
+	assert.Equal(t, map[string]string{"source_ip": "1.2.3.4", "value": "first"}, evt.Meta)
+
+	// same key: the statics are not run again
+	evt = run("1.2.3.4", "second")
+	assert.Equal(t, map[string]string{"source_ip": "1.2.3.4", "value": "first"}, evt.Meta)
+	assert.Equal(t, "second", evt.Parsed["value"])
+
+	evt = run("5.6.7.8", "third")
+	assert.Equal(t, map[string]string{"source_ip": "5.6.7.8", "value": "third"}, evt.Meta)
+
+	// no key: no cache
+	evt = run("", "fourth")
+	assert.Equal(t, "fourth", evt.Meta["value"])
+	evt = run("", "fifth")
+	assert.Equal(t, "fifth", evt.Meta["value"])
+}
+
+func TestParseTrace(t *testing.T) {
+	pctx, err := NewUnixParserCtx("../../config/patterns/", "", "./testdata/")
+	require.NoError(t, err)
+
+	pctx.Stages = []string{"s00-raw", "s01-parse"}
+
+	tracer := pipeline.NewTracer(10, 100, nil, 1)
+	pipeline.SetTracer(tracer)
+	t.Cleanup(func() { pipeline.SetTracer(nil) })
+
+	nodes := []Node{
+		{NodeConfig: NodeConfig{Name: "raw", Stage: "s00-raw", OnSuccess: "next_stage", Filter: "evt.Line.Labels.type == 'file'", Grok: GrokPattern{RegexpValue: "^%{WORD:program} %{GREEDYDATA:message}$", TargetField: "Line.Raw"}}},
+		{NodeConfig: NodeConfig{Name: "other", Stage: "s01-parse", Filter: "evt.Parsed.program == 'nginx'", Statics: []Static{{Meta: "service", Value: "nginx"}}}},
+		{NodeConfig: NodeConfig{Name: "ssh", Stage: "s01-parse", Filter: "evt.Parsed.program == 'sshd'", Grok: GrokPattern{RegexpValue: "^Invalid user %{WORD:user}$", TargetField: "message"}}},
+	}
+
+	for idx := range nodes {
+		require.NoError(t, nodes[idx].compile(pctx, EnricherCtx{}))
+	}
+
+	evt := pipeline.MakeEvent(false, pipeline.LOG, true)
+	evt.Line = pipeline.Line{Raw: "sshd Failed password", Module: "file", Labels: map[string]string{"type": "file"}}
+	evt.Stage = "s00-raw"
+
+	pipeline.StartTrace(&evt)
+	require.NotEmpty(t, evt.TraceID)
+
+	parsed, err := Parse(*pctx, evt, nodes, nil)
+	require.NoError(t, err)
+	assert.False(t, parsed.Process)
+
+	trace, ok := tracer.Get(evt.TraceID)
+	require.True(t, ok)
+
+	steps := []string{}
+	for _, step := range trace.Steps {
+		steps = append(steps, step.Stage+" "+step.Kind+" "+step.Name+" "+step.Result)
+	}
+
+	assert.Equal(t, []string{
+		" acquisition file read",
+		"s00-raw filter raw true",
+		"s00-raw grok raw match",
+		"s00-raw node raw ok",
+		"s01-parse stage s00-raw ok",
+		"s01-parse filter other false",
+		"s01-parse filter ssh true",
+		"s01-parse grok ssh no match",
+		"s01-parse node ssh ko",
+		"s01-parse stage s01-parse ko",
+	}, steps)
+}
+
+func TestNodeBreak(t *testing.T) {
+	pctx, err := NewUnixParserCtx("../../config/patterns/", "", "./testdata/")
+	require.NoError(t, err)
+
+	pctx.Stages = []string{"s02-enrich"}
+
+	nodes := []Node{
+		{NodeConfig: NodeConfig{Name: "skipped", Stage: "s02-enrich", Break: true, Filter: "evt.Parsed.program == 'nginx'", Statics: []Static{{Meta: "skipped", Value: "yes"}}}},
+		{NodeConfig: NodeConfig{Name: "first", Stage: "s02-enrich", Break: true, Filter: "evt.Parsed.program == 'sshd'", Statics: []Static{{Meta: "first", Value: "yes"}}}},
+		{NodeConfig: NodeConfig{Name: "second", Stage: "s02-enrich", Statics: []Static{{Meta: "second", Value: "yes"}}}},
+		{NodeConfig: NodeConfig{Name: "parent", Stage: "s02-enrich", SubNodes: []NodeConfig{
+			{Stage: "s02-enrich", Break: true, Statics: []Static{{Meta: "child1", Value: "yes"}}},
+			{Stage: "s02-enrich", Statics: []Static{{Meta: "child2", Value: "yes"}}},
+		}}},
+	}
+
+	for idx := range nodes {
+		nodes[idx].initRuntimeChildrenFromConfig()
+		require.NoError(t, nodes[idx].compile(pctx, EnricherCtx{}))
+	}
+
+	run := func(program string) map[string]string {
+		evt := pipeline.MakeEvent(false, pipeline.LOG, true)
+		evt.Stage = "s02-enrich"
+		evt.Parsed = map[string]string{"program": program}
+
+		parsed, err := Parse(*pctx, evt, nodes, nil)
+		require.NoError(t, err)
+		assert.True(t, parsed.Process)
+
+		return parsed.Meta
+	}
+
+	// the first successful node with break stops the stage
+	assert.Equal(t, map[string]string{"first": "yes"}, run("sshd"))
+
+	// no break: the following nodes are processed, a child with break stops its siblings
+	assert.Equal(t, map[string]string{"second": "yes", "child1": "yes"}, run("other"))
+}
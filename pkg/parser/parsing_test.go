@@ -179,7 +179,7 @@ func prepTests(t require.TestingT) (*UnixParserCtx, EnricherCtx) {
 
 	/* this should be refactored to 2 lines :p */
 	// Init the parser
-	pctx, err = NewUnixParserCtx(filepath.Join(cfgdir, "patterns"), "./testdata/")
+	pctx, err = NewUnixParserCtx(filepath.Join(cfgdir, "patterns"), "", "./testdata/")
 	require.NoError(t, err, "parser init failed")
 
 	return pctx, ectx
@@ -404,7 +404,7 @@ func TestGeneratePatternsDoc(t *testing.T) {
 		return
 	}
 
-	pctx, err := NewUnixParserCtx("../../config/patterns/", "./testdata/")
+	pctx, err := NewUnixParserCtx("../../config/patterns/", "", "./testdata/")
 	require.NoError(t, err, "unable to load patterns")
 
 	log.Infof("-> %s", spew.Sdump(pctx))
@@ -0,0 +1,186 @@
+package parser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/crowdsecurity/grokky"
+
+	"github.com/crowdsecurity/crowdsec/pkg/fflag"
+)
+
+// BuiltinPatternOrigin is the origin of the patterns that come with the grok library.
+const BuiltinPatternOrigin = "builtin"
+
+// PatternInfo tells which file provides a grok pattern.
+type PatternInfo struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Origin     string `json:"origin"`              // path of the file, or "builtin"
+	Overrides  string `json:"overrides,omitempty"` // origin of the definition replaced from the override directory
+}
+
+// same syntax as grokky.Host.AddFromFile
+var patternLine = regexp.MustCompile(`^(\w+)\s+(.+)$`)
+
+func newGrokHost() grokky.Host {
+	host := grokky.NewBase()
+	// RE2 is enabled by default on linux, but can be disabled with re2_disable_grok_support
+	if runtime.GOOS == "linux" {
+		host.UseRe2 = !fflag.Re2DisableGrokSupport.IsEnabled()
+	} else {
+		host.UseRe2 = fflag.Re2GrokSupport.IsEnabled()
+	}
+
+	return host
+}
+
+// patternFiles returns the pattern files in dir, in loading order.
+// Files with a dot in their name are ignored (README.md, backups...).
+func patternFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := []string{}
+
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".") || entry.IsDir() {
+			continue
+		}
+
+		ret = append(ret, filepath.Join(dir, entry.Name()))
+	}
+
+	return ret, nil
+}
+
+// loadPatternFile adds the patterns of a file to the grok host. If override is true,
+// the patterns replace the existing ones with the same name, otherwise redefining a pattern is an error.
+func loadPatternFile(host grokky.Host, path string, override bool, library map[string]*PatternInfo) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		sub := patternLine.FindStringSubmatch(scanner.Text())
+		if len(sub) == 0 {
+			continue
+		}
+
+		name, expr := sub[1], sub[2]
+
+		previous, exists := library[name]
+
+		if exists && override {
+			delete(host.Patterns, name)
+		}
+
+		if err := host.Add(name, expr); err != nil {
+			if exists && !override {
+				return fmt.Errorf("pattern %s: %w (defined in %s)", name, err, previous.Origin)
+			}
+
+			return fmt.Errorf("pattern %s: %w", name, err)
+		}
+
+		info := &PatternInfo{
+			Name:       name,
+			Expression: expr,
+			Origin:     path,
+		}
+
+		if exists {
+			info.Overrides = previous.Origin
+			if previous.Overrides != "" {
+				info.Overrides = previous.Overrides
+			}
+
+			log.Debugf("pattern %s from %s overrides %s", name, path, info.Overrides)
+		}
+
+		library[name] = info
+	}
+
+	return scanner.Err()
+}
+
+// loadPatternLibrary loads the patterns from patternDir, then those from overrideDir, which take precedence.
+// The override directory is optional.
+func loadPatternLibrary(host grokky.Host, patternDir string, overrideDir string) (map[string]*PatternInfo, error) {
+	library := make(map[string]*PatternInfo, len(host.Patterns))
+
+	for name, expr := range host.Patterns {
+		library[name] = &PatternInfo{Name: name, Expression: expr, Origin: BuiltinPatternOrigin}
+	}
+
+	files, err := patternFiles(patternDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range files {
+		if err := loadPatternFile(host, path, false, library); err != nil {
+			log.Errorf("failed to load pattern %s: %v", filepath.Base(path), err)
+			return nil, err
+		}
+	}
+
+	log.Debugf("Loaded %d pattern files", len(files))
+
+	if overrideDir == "" {
+		return library, nil
+	}
+
+	files, err = patternFiles(overrideDir)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return library, nil
+	case err != nil:
+		return nil, err
+	}
+
+	for _, path := range files {
+		if err := loadPatternFile(host, path, true, library); err != nil {
+			log.Errorf("failed to load pattern override %s: %v", path, err)
+			return nil, err
+		}
+	}
+
+	log.Debugf("Loaded %d pattern override files", len(files))
+
+	return library, nil
+}
+
+// ListPatterns returns the grok patterns available to the parsers, sorted by name, with the file that provides them.
+func ListPatterns(patternDir string, overrideDir string) ([]PatternInfo, error) {
+	library, err := loadPatternLibrary(newGrokHost(), patternDir, overrideDir)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]PatternInfo, 0, len(library))
+
+	for _, name := range slices.Sorted(maps.Keys(library)) {
+		ret = append(ret, *library[name])
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/go-cs-lib/cstest"
+)
+
+func writePatterns(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	return path
+}
+
+func TestPatternOverride(t *testing.T) {
+	tmp := t.TempDir()
+	patternDir := filepath.Join(tmp, "patterns")
+	overrideDir := filepath.Join(tmp, "patterns.local")
+
+	base := writePatterns(t, patternDir, "app", "APPUSER [a-z]+\nAPPLOG user=%{APPUSER:user}\n")
+	writePatterns(t, patternDir, "README.md", "NOTAPATTERN x\n")
+
+	// the override directory is optional
+	pctx, err := NewUnixParserCtx(patternDir, overrideDir, "")
+	require.NoError(t, err)
+
+	p, err := pctx.Grok.Get("APPLOG")
+	require.NoError(t, err)
+	assert.Empty(t, p.Parse("user=Admin"))
+
+	local := writePatterns(t, overrideDir, "app", "APPUSER [a-zA-Z]+\n")
+	localInt := writePatterns(t, overrideDir, "int", "INT [0-9]+\n")
+
+	pctx, err = NewUnixParserCtx(patternDir, overrideDir, "")
+	require.NoError(t, err)
+
+	// patterns referencing the overridden one use the new definition
+	p, err = pctx.Grok.Get("APPLOG")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"user": "Admin"}, p.Parse("user=Admin"))
+
+	patterns, err := ListPatterns(patternDir, overrideDir)
+	require.NoError(t, err)
+
+	byName := map[string]PatternInfo{}
+	for _, p := range patterns {
+		byName[p.Name] = p
+	}
+
+	assert.NotContains(t, byName, "NOTAPATTERN")
+	assert.Equal(t, PatternInfo{Name: "APPUSER", Expression: "[a-zA-Z]+", Origin: local, Overrides: base}, byName["APPUSER"])
+	assert.Equal(t, PatternInfo{Name: "APPLOG", Expression: "user=%{APPUSER:user}", Origin: base}, byName["APPLOG"])
+	assert.Equal(t, PatternInfo{Name: "INT", Expression: "[0-9]+", Origin: localInt, Overrides: BuiltinPatternOrigin}, byName["INT"])
+	assert.Equal(t, BuiltinPatternOrigin, byName["WORD"].Origin)
+}
+
+func TestPatternRedefinition(t *testing.T) {
+	tmp := t.TempDir()
+	patternDir := filepath.Join(tmp, "patterns")
+
+	first := writePatterns(t, patternDir, "a", "APPUSER [a-z]+\n")
+	writePatterns(t, patternDir, "b", "APPUSER [A-Z]+\n")
+
+	_, err := NewUnixParserCtx(patternDir, "", "")
+	cstest.RequireErrorContains(t, err, "pattern APPUSER: the pattern already exist (defined in "+first+")")
+
+	_, err = ListPatterns(filepath.Join(tmp, "missing"), "")
+	cstest.RequireErrorContains(t, err, cstest.PathNotFoundMessage)
+}
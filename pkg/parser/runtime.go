@@ -115,7 +115,8 @@ func (s *Static) targetExpr() string {
 	}
 }
 
-func (rs *RuntimeStatic) Apply(event *pipeline.Event, enrichFunctions EnricherCtx, logger *log.Entry, debug bool) error {
+// value returns the value of the static, and false if there is nothing to apply.
+func (rs *RuntimeStatic) value(event *pipeline.Event, logger *log.Entry, debug bool) (string, bool, error) {
 	// we have a few cases :
 	// (meta||key) + (static||reference||expr)
 	exprEnv := map[string]any{"evt": event}
@@ -127,7 +128,7 @@ func (rs *RuntimeStatic) Apply(event *pipeline.Event, enrichFunctions EnricherCt
 		output, err := exprhelpers.Run(rs.RunTimeValue, exprEnv, logger, debug)
 		if err != nil {
 			logger.Warningf("failed to run RunTimeValue : %v", err)
-			return nil
+			return "", false, nil
 		}
 
 		switch out := output.(type) {
@@ -145,7 +146,7 @@ func (rs *RuntimeStatic) Apply(event *pipeline.Event, enrichFunctions EnricherCt
 			logger.Debugf("Expression %q returned nil, skipping", rs.Config.ExpValue)
 		default:
 			logger.Errorf("unexpected return type for %q: %T", rs.Config.ExpValue, output)
-			return errors.New("unexpected return type for RunTimeValue")
+			return "", false, errors.New("unexpected return type for RunTimeValue")
 		}
 	}
 
@@ -153,40 +154,45 @@ func (rs *RuntimeStatic) Apply(event *pipeline.Event, enrichFunctions EnricherCt
 		// allow ParseDate to have empty input
 		if rs.Config.Method != "ParseDate" {
 			logger.Debugf("Empty value for %s, skip.", rs.Config.targetExpr())
-			return nil
+			return "", false, nil
 		}
 	}
 
-	switch {
-	case rs.Config.Method != "":
-		processed := false
-		/*still way too hackish, but : inject all the results in enriched, and */
-		if enricherPlugin, ok := enrichFunctions.Registered[rs.Config.Method]; ok {
-			logger.Tracef("Found method '%s'", rs.Config.Method)
+	return value, true, nil
+}
 
-			ret, err := enricherPlugin.EnrichFunc(value, event, logger.WithField("method", rs.Config.Method))
-			if err != nil {
-				logger.Errorf("method '%s' returned an error : %v", rs.Config.Method, err)
-			}
+// enrich runs the method of the static, and returns the entries to merge in .Enriched.
+func (rs *RuntimeStatic) enrich(value string, event *pipeline.Event, enrichFunctions EnricherCtx, logger *log.Entry) map[string]string {
+	enricherPlugin, ok := enrichFunctions.Registered[rs.Config.Method]
+	if !ok {
+		logger.Debugf("method '%s' doesn't exist or plugin not initialized", rs.Config.Method)
+		return nil
+	}
 
-			processed = true
+	logger.Tracef("Found method '%s'", rs.Config.Method)
 
-			logger.Debugf("+ Method %s('%s') returned %d entries to merge in .Enriched\n", rs.Config.Method, value, len(ret))
-			// Hackish check, but those methods do not return any data by design
-			if len(ret) == 0 && rs.Config.Method != "UnmarshalJSON" {
-				logger.Debugf("+ Method '%s' empty response on '%s'", rs.Config.Method, value)
-			}
+	ret, err := enricherPlugin.EnrichFunc(value, event, logger.WithField("method", rs.Config.Method))
+	if err != nil {
+		logger.Errorf("method '%s' returned an error : %v", rs.Config.Method, err)
+	}
 
-			for k, v := range ret {
-				logger.Debugf("\t.Enriched[%s] = '%s'\n", k, v)
-				event.Enriched[k] = v
-			}
-		} else {
-			logger.Debugf("method '%s' doesn't exist or plugin not initialized", rs.Config.Method)
-		}
+	logger.Debugf("+ Method %s('%s') returned %d entries to merge in .Enriched\n", rs.Config.Method, value, len(ret))
+	// Hackish check, but those methods do not return any data by design
+	if len(ret) == 0 && rs.Config.Method != "UnmarshalJSON" {
+		logger.Debugf("+ Method '%s' empty response on '%s'", rs.Config.Method, value)
+	}
+
+	return ret
+}
 
-		if !processed {
-			logger.Debugf("method '%s' doesn't exist", rs.Config.Method)
+// assign writes the value of the static, or the entries returned by its method, to the event.
+func (rs *RuntimeStatic) assign(event *pipeline.Event, value string, enriched map[string]string, logger *log.Entry) {
+	switch {
+	case rs.Config.Method != "":
+		/*still way too hackish, but : inject all the results in enriched, and */
+		for k, v := range enriched {
+			logger.Debugf("\t.Enriched[%s] = '%s'\n", k, v)
+			event.Enriched[k] = v
 		}
 	case rs.Config.Parsed != "":
 		logger.Debugf(".Parsed[%s] = '%s'", rs.Config.Parsed, value)
@@ -206,30 +212,33 @@ func (rs *RuntimeStatic) Apply(event *pipeline.Event, enrichFunctions EnricherCt
 	default:
 		logger.Fatal("unable to process static : unknown target")
 	}
-
-	return nil
 }
 
-func (n *Node) ProcessStatics(event *pipeline.Event) error {
-	for _, rs := range n.RuntimeStatics {
-		if err := rs.Apply(event, n.EnrichFunctions, n.Logger, n.Debug); err != nil {
-			return fmt.Errorf("applying %s: %w", rs.Config.targetExpr(), err)
-		}
+func (rs *RuntimeStatic) Apply(event *pipeline.Event, enrichFunctions EnricherCtx, logger *log.Entry, debug bool) error {
+	value, ok, err := rs.value(event, logger, debug)
+	if err != nil || !ok {
+		return err
 	}
 
-	return nil
-}
+	var enriched map[string]string
 
-func (rg *RuntimeGrokPattern) ProcessStatics(event *pipeline.Event, ectx EnricherCtx, logger *log.Entry, debug bool) error {
-	for _, rs := range rg.RuntimeStatics {
-		if err := rs.Apply(event, ectx, logger, debug); err != nil {
-			return fmt.Errorf("applying %s: %w", rs.Config.targetExpr(), err)
-		}
+	if rs.Config.Method != "" {
+		enriched = rs.enrich(value, event, enrichFunctions, logger)
 	}
 
+	rs.assign(event, value, enriched, logger)
+
 	return nil
 }
 
+func (n *Node) ProcessStatics(event *pipeline.Event) error {
+	return applyStatics(n.RuntimeStatics, event, n.EnrichFunctions, n.Logger, n.Debug)
+}
+
+func (rg *RuntimeGrokPattern) ProcessStatics(event *pipeline.Event, ectx EnricherCtx, logger *log.Entry, debug bool) error {
+	return applyStatics(rg.RuntimeStatics, event, ectx, logger, debug)
+}
+
 func Parse(ctx UnixParserCtx, event pipeline.Event, nodes []Node, collector *StageParseCollector) (pipeline.Event, error) {
 	/* the stage is undefined, probably line is freshly acquired, set to first stage !*/
 	if event.Stage == "" && len(ctx.Stages) > 0 {
@@ -277,6 +286,7 @@ func Parse(ctx UnixParserCtx, event pipeline.Event, nodes []Node, collector *Sta
 		/* if the stage is wrong, it means that the log didn't manage "pass" a stage with a onsuccess: next_stage tag */
 		if event.Stage != stage {
 			log.Debugf("Event not parsed, expected stage '%s' got '%s', abort", stage, event.Stage)
+			pipeline.RecordTraceStep(&event, "stage", stage, "not reached", "no node moved the event to this stage")
 			event.Process = false
 
 			return event, nil
@@ -322,6 +332,11 @@ func Parse(ctx UnixParserCtx, event pipeline.Event, nodes []Node, collector *Sta
 				break
 			}
 
+			if ret && nodes[idx].Break {
+				clog.Debugf("node successful, break, skip the remaining nodes of stage %s", stage)
+				break
+			}
+
 			// the parsed object moved onto the next phase
 			if event.Stage != stage {
 				clog.Tracef("node moved stage, break and redo")
@@ -331,10 +346,13 @@ func Parse(ctx UnixParserCtx, event pipeline.Event, nodes []Node, collector *Sta
 
 		if !isStageOK {
 			log.Debugf("Log didn't finish stage %s", event.Stage)
+			pipeline.RecordTraceStep(&event, "stage", stage, "ko", "no node succeeded")
 			event.Process = false
 
 			return event, nil
 		}
+
+		pipeline.RecordTraceStep(&event, "stage", stage, "ok", "")
 	}
 
 	event.Process = true
@@ -26,6 +26,8 @@ type Static struct {
 type RuntimeStatic struct {
 	Config       *Static
 	RunTimeValue *vm.Program
+
+	joinsPrevious bool // computed along with the previous static, see groupStatics
 }
 
 func (s *Static) Validate(ectx EnricherCtx) error {
@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// independent tells if the static only reads the event and can be slow: the enrichers flagged as
+// concurrent, and the expressions calling HttpGet.
+func (rs *RuntimeStatic) independent(ectx EnricherCtx) bool {
+	switch {
+	case rs.Config.Method != "":
+		enricher, ok := ectx.Registered[rs.Config.Method]
+		return ok && enricher.Concurrent
+	case rs.Config.Meta != "" || rs.Config.Parsed != "":
+		return strings.Contains(rs.Config.ExpValue, "HttpGet(")
+	default:
+		return false
+	}
+}
+
+// written is what a static writes to, to find the statics reading it.
+func (rs *RuntimeStatic) written() string {
+	switch {
+	case rs.Config.Method != "":
+		return "Enriched"
+	case rs.Config.Meta != "":
+		return rs.Config.Meta
+	default:
+		return rs.Config.Parsed
+	}
+}
+
+// groupStatics flags the statics that can run along with the previous ones: consecutive independent
+// statics, the expression of which doesn't mention what the others write. The check is
+// conservative, a static reading any Enriched field never joins a group of enrichers.
+func groupStatics(statics []RuntimeStatic, ectx EnricherCtx) {
+	written := []string{}
+
+	for idx := range statics {
+		rs := &statics[idx]
+
+		if !rs.independent(ectx) {
+			written = written[:0]
+			continue
+		}
+
+		rs.joinsPrevious = len(written) > 0
+
+		for _, w := range written {
+			if w != "" && strings.Contains(rs.Config.ExpValue, w) {
+				rs.joinsPrevious = false
+				break
+			}
+		}
+
+		if !rs.joinsPrevious {
+			written = written[:0]
+		}
+
+		written = append(written, rs.written())
+	}
+}
+
+type staticResult struct {
+	value    string
+	ok       bool
+	enriched map[string]string
+	err      error
+}
+
+// applyConcurrently computes the statics of a group with at most ectx.Routines goroutines, then
+// merges the results in order: the event is the same as if they ran one after the other.
+func applyConcurrently(statics []RuntimeStatic, event *pipeline.Event, ectx EnricherCtx, logger *log.Entry, debug bool) error {
+	results := make([]staticResult, len(statics))
+
+	g := errgroup.Group{}
+	g.SetLimit(ectx.Routines)
+
+	for idx := range statics {
+		g.Go(func() error {
+			rs := &statics[idx]
+			res := &results[idx]
+
+			res.value, res.ok, res.err = rs.value(event, logger, debug)
+			if res.err == nil && res.ok && rs.Config.Method != "" {
+				res.enriched = rs.enrich(res.value, event, ectx, logger)
+			}
+
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	for idx := range statics {
+		res := results[idx]
+		if res.err != nil {
+			return fmt.Errorf("applying %s: %w", statics[idx].Config.targetExpr(), res.err)
+		}
+
+		if res.ok {
+			statics[idx].assign(event, res.value, res.enriched, logger)
+		}
+	}
+
+	return nil
+}
+
+func applyStatics(statics []RuntimeStatic, event *pipeline.Event, ectx EnricherCtx, logger *log.Entry, debug bool) error {
+	for idx := 0; idx < len(statics); {
+		end := idx + 1
+		for end < len(statics) && statics[end].joinsPrevious {
+			end++
+		}
+
+		if end-idx > 1 && ectx.Routines > 1 {
+			if err := applyConcurrently(statics[idx:end], event, ectx, logger, debug); err != nil {
+				return err
+			}
+
+			idx = end
+
+			continue
+		}
+
+		for ; idx < end; idx++ {
+			if err := statics[idx].Apply(event, ectx, logger, debug); err != nil {
+				return fmt.Errorf("applying %s: %w", statics[idx].Config.targetExpr(), err)
+			}
+		}
+	}
+
+	return nil
+}
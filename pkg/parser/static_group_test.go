@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func compileStatics(t *testing.T, statics []Static, ectx EnricherCtx) []RuntimeStatic {
+	t.Helper()
+
+	ret := []RuntimeStatic{}
+
+	for idx := range statics {
+		compiled, err := statics[idx].Compile()
+		require.NoError(t, err)
+
+		ret = append(ret, *compiled)
+	}
+
+	groupStatics(ret, ectx)
+
+	return ret
+}
+
+func testEnricherCtx(routines int, funcs map[string]EnrichFunc) EnricherCtx {
+	ectx := EnricherCtx{Registered: map[string]*Enricher{}, Routines: routines}
+
+	for name, f := range funcs {
+		ectx.Registered[name] = &Enricher{Name: name, EnrichFunc: f, Concurrent: name != "Sequential"}
+	}
+
+	return ectx
+}
+
+func constEnricher(ret map[string]string) EnrichFunc {
+	return func(_ string, _ *pipeline.Event, _ *log.Entry) (map[string]string, error) {
+		return ret, nil
+	}
+}
+
+func TestGroupStatics(t *testing.T) {
+	ectx := testEnricherCtx(4, map[string]EnrichFunc{
+		"A":          constEnricher(nil),
+		"B":          constEnricher(nil),
+		"Sequential": constEnricher(nil),
+	})
+
+	statics := compileStatics(t, []Static{
+		{Method: "A", ExpValue: "evt.Meta.source_ip"},
+		{Method: "B", ExpValue: "evt.Meta.source_ip"},
+		{Meta: "reputation", ExpValue: `HttpGet("http://enrich.internal/" + evt.Meta.source_ip)`},
+		// reads what the enrichers wrote
+		{Meta: "whois", ExpValue: `HttpGet("http://enrich.internal/" + evt.Enriched.ASNumber)`},
+		// reads what the previous static wrote
+		{Meta: "score", ExpValue: `HttpGet("http://enrich.internal/" + evt.Meta.whois)`},
+		{Method: "A", ExpValue: "evt.Meta.source_ip"},
+		{Meta: "log_type", Value: "http"},
+		{Method: "A", ExpValue: "evt.Meta.source_ip"},
+		{Method: "Sequential", ExpValue: "evt.Meta.source_ip"},
+		{Method: "B", ExpValue: "evt.Meta.source_ip"},
+	}, ectx)
+
+	joins := []bool{}
+	for idx := range statics {
+		joins = append(joins, statics[idx].joinsPrevious)
+	}
+
+	assert.Equal(t, []bool{false, true, true, false, false, true, false, false, false, false}, joins)
+}
+
+func TestApplyStaticsConcurrently(t *testing.T) {
+	var (
+		started sync.WaitGroup
+		both    = make(chan struct{})
+	)
+
+	started.Add(2)
+
+	go func() {
+		started.Wait()
+		close(both)
+	}()
+
+	// each enricher only returns once the other one has started
+	waitBoth := func(ret map[string]string) EnrichFunc {
+		return func(_ string, _ *pipeline.Event, _ *log.Entry) (map[string]string, error) {
+			started.Done()
+
+			select {
+			case <-both:
+			case <-time.After(5 * time.Second):
+				return nil, nil
+			}
+
+			return ret, nil
+		}
+	}
+
+	ectx := testEnricherCtx(2, map[string]EnrichFunc{
+		"A": waitBoth(map[string]string{"a": "1", "common": "from A"}),
+		"B": waitBoth(map[string]string{"b": "2", "common": "from B"}),
+	})
+
+	statics := compileStatics(t, []Static{
+		{Method: "A", ExpValue: "evt.Meta.source_ip"},
+		{Method: "B", ExpValue: "evt.Meta.source_ip"},
+		{Meta: "common", ExpValue: "evt.Enriched.common"},
+	}, ectx)
+
+	event := pipeline.Event{
+		Meta:     map[string]string{"source_ip": "1.2.3.4"},
+		Parsed:   map[string]string{},
+		Enriched: map[string]string{},
+	}
+
+	require.NoError(t, applyStatics(statics, &event, ectx, log.NewEntry(log.StandardLogger()), false))
+
+	// merged in the order of the statics, as if they ran one after the other
+	assert.Equal(t, map[string]string{"a": "1", "b": "2", "common": "from B"}, event.Enriched)
+	assert.Equal(t, "from B", event.Meta["common"])
+}
+
+func TestApplyStaticsSequential(t *testing.T) {
+	calls := []string{}
+
+	record := func(name string) EnrichFunc {
+		return func(_ string, _ *pipeline.Event, _ *log.Entry) (map[string]string, error) {
+			calls = append(calls, name)
+			return map[string]string{"last": name}, nil
+		}
+	}
+
+	// with a single routine, the statics of a group are applied one after the other
+	ectx := testEnricherCtx(1, map[string]EnrichFunc{
+		"A": record("A"),
+		"B": record("B"),
+	})
+
+	statics := compileStatics(t, []Static{
+		{Method: "A", ExpValue: "evt.Meta.source_ip"},
+		{Method: "B", ExpValue: "evt.Meta.source_ip"},
+	}, ectx)
+
+	event := pipeline.Event{
+		Meta:     map[string]string{"source_ip": "1.2.3.4"},
+		Enriched: map[string]string{},
+	}
+
+	require.NoError(t, applyStatics(statics, &event, ectx, log.NewEntry(log.StandardLogger()), false))
+
+	assert.Equal(t, []string{"A", "B"}, calls)
+	assert.Equal(t, "B", event.Enriched["last"])
+}
@@ -2,11 +2,7 @@ package parser
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
-	"runtime"
 	"sort"
-	"strings"
 
 	log "github.com/sirupsen/logrus"
 
@@ -14,7 +10,6 @@ import (
 
 	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
 	"github.com/crowdsecurity/crowdsec/pkg/cwhub"
-	"github.com/crowdsecurity/crowdsec/pkg/fflag"
 )
 
 type UnixParserCtx struct {
@@ -32,38 +27,24 @@ type Parsers struct {
 	Nodes           []Node
 	Povfwnodes      []Node
 	EnricherCtx     EnricherCtx
+	// where the lines that failed to parse are written, if configured
+	Unparsed *UnparsedSink
+	// tracks the datasources sending too many lines that fail to parse, if configured
+	ErrorBudget *ErrorBudget
 }
 
-func NewUnixParserCtx(patternDir string, dataDir string) (*UnixParserCtx, error) {
+// NewUnixParserCtx loads the grok patterns from patternDir, then from overrideDir (if not empty)
+// whose definitions take precedence.
+func NewUnixParserCtx(patternDir string, overrideDir string, dataDir string) (*UnixParserCtx, error) {
 	r := UnixParserCtx{}
-	r.Grok = grokky.NewBase()
-	// RE2 is enabled by default on linux, but can be disabled with re2_disable_grok_support
-	if runtime.GOOS == "linux" {
-		r.Grok.UseRe2 = !fflag.Re2DisableGrokSupport.IsEnabled()
-	} else {
-		r.Grok.UseRe2 = fflag.Re2GrokSupport.IsEnabled()
-	}
+	r.Grok = newGrokHost()
 
-	files, err := os.ReadDir(patternDir)
-	if err != nil {
+	if _, err := loadPatternLibrary(r.Grok, patternDir, overrideDir); err != nil {
 		return nil, err
 	}
 
 	r.DataFolder = dataDir
 
-	for _, file := range files {
-		if strings.Contains(file.Name(), ".") || file.IsDir() {
-			continue
-		}
-
-		if err := r.Grok.AddFromFile(filepath.Join(patternDir, file.Name())); err != nil {
-			log.Errorf("failed to load pattern %s: %v", file.Name(), err)
-			return nil, err
-		}
-	}
-
-	log.Debugf("Loaded %d pattern files", len(files))
-
 	return &r, nil
 }
 
@@ -109,17 +90,18 @@ func LoadParsers(cConfig *csconfig.Config, hub *cwhub.Hub) (*Parsers, error) {
 	var err error
 
 	patternDir := cConfig.ConfigPaths.PatternDir
-	log.Infof("Loading grok library %s", patternDir)
+	overrideDir := cConfig.ConfigPaths.PatternOverrideDir
+	log.Infof("Loading grok library %s (local overrides: %s)", patternDir, overrideDir)
 
 	parsers := NewParsers(hub)
 
 	/* load base regexps for two grok parsers */
-	parsers.Ctx, err = NewUnixParserCtx(patternDir, cConfig.ConfigPaths.DataDir)
+	parsers.Ctx, err = NewUnixParserCtx(patternDir, overrideDir, cConfig.ConfigPaths.DataDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load parser patterns: %w", err)
 	}
 
-	parsers.PovfwCtx, err = NewUnixParserCtx(patternDir, cConfig.ConfigPaths.DataDir)
+	parsers.PovfwCtx, err = NewUnixParserCtx(patternDir, overrideDir, cConfig.ConfigPaths.DataDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load postovflw parser patterns: %w", err)
 	}
@@ -134,6 +116,10 @@ func LoadParsers(cConfig *csconfig.Config, hub *cwhub.Hub) (*Parsers, error) {
 		return nil, fmt.Errorf("failed to load enrich plugin: %w", err)
 	}
 
+	if cConfig.Crowdsec != nil {
+		parsers.EnricherCtx.Routines = cConfig.Crowdsec.EnricherRoutinesCount
+	}
+
 	/*
 	 Load the actual parsers
 	*/
@@ -158,6 +144,16 @@ func LoadParsers(cConfig *csconfig.Config, hub *cwhub.Hub) (*Parsers, error) {
 		parsers.Povfwnodes = []Node{}
 	}
 
+	if cConfig.Crowdsec != nil && cConfig.Crowdsec.UnparsedLines != nil {
+		log.Infof("Writing unparsed lines to %s", cConfig.Crowdsec.UnparsedLines.Path)
+
+		parsers.Unparsed = NewUnparsedSink(cConfig.Crowdsec.UnparsedLines)
+	}
+
+	if cConfig.Crowdsec != nil && cConfig.Crowdsec.ParseErrorBudget != nil {
+		parsers.ErrorBudget = NewErrorBudget(cConfig.Crowdsec.ParseErrorBudget)
+	}
+
 	if cConfig.Prometheus != nil && cConfig.Prometheus.Enabled {
 		parsers.Ctx.Profiling = true
 		parsers.PovfwCtx.Profiling = true
@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/metrics"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+// UnparsedSink receives the lines that were not successfully parsed.
+// A nil sink discards them.
+type UnparsedSink struct {
+	mu      sync.Mutex
+	out     io.WriteCloser
+	sources []string
+}
+
+type unparsedLine struct {
+	Time   time.Time         `json:"time"`
+	Source string            `json:"source"`
+	Module string            `json:"module"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Stage  string            `json:"stage"`
+	Line   string            `json:"line"`
+}
+
+func NewUnparsedSink(cfg *csconfig.UnparsedLinesCfg) *UnparsedSink {
+	return newUnparsedSink(&lumberjack.Logger{
+		Filename:   cfg.Path,
+		MaxSize:    *cfg.MaxSize,
+		MaxBackups: *cfg.MaxFiles,
+	}, cfg.Sources)
+}
+
+func newUnparsedSink(out io.WriteCloser, sources []string) *UnparsedSink {
+	return &UnparsedSink{
+		out:     out,
+		sources: sources,
+	}
+}
+
+// Write appends an unparsed event to the sink, unless its datasource is excluded.
+func (s *UnparsedSink) Write(evt pipeline.Event) error {
+	if s == nil || evt.Type != pipeline.LOG {
+		return nil
+	}
+
+	acquisType := evt.Line.Labels["type"]
+
+	if len(s.sources) > 0 && !slices.Contains(s.sources, acquisType) {
+		return nil
+	}
+
+	record := unparsedLine{
+		Time:   evt.Line.Time,
+		Source: evt.Line.Src,
+		Module: evt.Line.Module,
+		Labels: evt.Line.Labels,
+		Stage:  evt.Stage,
+		Line:   evt.Line.Raw,
+	}
+
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC()
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("while serializing unparsed line: %w", err)
+	}
+
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	_, err = s.out.Write(b)
+	s.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("while writing unparsed line: %w", err)
+	}
+
+	metrics.GlobalParserUnparsedLines.With(prometheus.Labels{"source": evt.Line.Src, "acquis_type": acquisType, "stage": evt.Stage}).Inc()
+
+	return nil
+}
+
+func (s *UnparsedSink) Close() error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.out.Close()
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/crowdsecurity/crowdsec/pkg/csconfig"
+	"github.com/crowdsecurity/crowdsec/pkg/pipeline"
+)
+
+func TestUnparsedSink(t *testing.T) {
+	var sink *UnparsedSink
+
+	// a nil sink discards everything
+	require.NoError(t, sink.Write(pipeline.Event{Type: pipeline.LOG}))
+	require.NoError(t, sink.Close())
+
+	cfg := &csconfig.UnparsedLinesCfg{
+		Path:    filepath.Join(t.TempDir(), "unparsed.log"),
+		Sources: []string{"file"},
+	}
+	require.NoError(t, cfg.Load())
+
+	sink = NewUnparsedSink(cfg)
+
+	acquired := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	event := func(acquisType string, raw string) pipeline.Event {
+		return pipeline.Event{
+			Type:  pipeline.LOG,
+			Stage: "s01-parse",
+			Line: pipeline.Line{
+				Raw:    raw,
+				Src:    "/var/log/app.log",
+				Time:   acquired,
+				Labels: map[string]string{"type": acquisType},
+				Module: "file",
+			},
+		}
+	}
+
+	require.NoError(t, sink.Write(event("file", "unknown format")))
+	// not a datasource we want
+	require.NoError(t, sink.Write(event("journalctl", "ignored")))
+	// overflows are not lines
+	require.NoError(t, sink.Write(pipeline.Event{Type: pipeline.OVFLW}))
+	require.NoError(t, sink.Close())
+
+	fd, err := os.Open(cfg.Path)
+	require.NoError(t, err)
+	t.Cleanup(func() { fd.Close() })
+
+	lines := []unparsedLine{}
+
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		line := unparsedLine{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+
+	require.NoError(t, scanner.Err())
+
+	assert.Equal(t, []unparsedLine{
+		{
+			Time:   acquired,
+			Source: "/var/log/app.log",
+			Module: "file",
+			Labels: map[string]string{"type": "file"},
+			Stage:  "s01-parse",
+			Line:   "unknown format",
+		},
+	}, lines)
+}
@@ -43,6 +43,8 @@ type Event struct {
 	Appsec        AppsecEvent  `json:"Appsec,omitempty"        yaml:"Appsec,omitempty"`
 	/* Meta is the only part that will make it to the API - it should be normalized */
 	Meta map[string]string `json:"Meta,omitempty" yaml:"Meta,omitempty"`
+	/* set when the processing of the line is traced */
+	TraceID string `json:"-" yaml:"-"`
 }
 
 func MakeEvent(timeMachine bool, evtType int, process bool) Event {
@@ -141,12 +143,14 @@ func (e *Event) ParseIPSources() []netip.Addr {
 }
 
 type RuntimeAlert struct {
-	Mapkey      string                   `json:"MapKey,omitempty"      yaml:"MapKey,omitempty"`
-	BucketId    string                   `json:"BucketId,omitempty"    yaml:"BucketId,omitempty"`
-	Whitelisted bool                     `json:"Whitelisted,omitempty" yaml:"Whitelisted,omitempty"`
-	Reprocess   bool                     `json:"Reprocess,omitempty"   yaml:"Reprocess,omitempty"`
-	Sources     map[string]models.Source `json:"Sources,omitempty"     yaml:"Sources,omitempty"`
-	Alert       *models.Alert            `json:"Alert,omitempty"       yaml:"Alert,omitempty"` // this one is a pointer to APIAlerts[0] for convenience.
+	Mapkey        string                   `json:"MapKey,omitempty"        yaml:"MapKey,omitempty"`
+	BucketId      string                   `json:"BucketId,omitempty"      yaml:"BucketId,omitempty"`
+	Whitelisted   bool                     `json:"Whitelisted,omitempty"   yaml:"Whitelisted,omitempty"`
+	Reprocess     bool                     `json:"Reprocess,omitempty"     yaml:"Reprocess,omitempty"`
+	ReinjectChain []string                 `json:"ReinjectChain,omitempty" yaml:"ReinjectChain,omitempty"` // scenarios a re-injected overflow went through, for loop protection
+	ReinjectMeta  map[string]string        `json:"ReinjectMeta,omitempty"  yaml:"ReinjectMeta,omitempty"`  // set in the Meta of the re-injected event
+	Sources       map[string]models.Source `json:"Sources,omitempty"       yaml:"Sources,omitempty"`
+	Alert         *models.Alert            `json:"Alert,omitempty"         yaml:"Alert,omitempty"` // this one is a pointer to APIAlerts[0] for convenience.
 	// APIAlerts will be populated at the end when there is more than one source
 	APIAlerts []models.Alert `json:"APIAlerts,omitempty" yaml:"APIAlerts,omitempty"`
 }
@@ -0,0 +1,264 @@
+package pipeline
+
+import (
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// outcome of a traced event
+const (
+	TraceProcessing  = "processing"
+	TraceDropped     = "dropped"
+	TraceUnparsed    = "unparsed"
+	TraceWhitelisted = "whitelisted"
+	TraceParsed      = "parsed"
+	TracePoured      = "poured"
+	TraceNotPoured   = "not poured"
+)
+
+// tracer of the running log processor, nil if tracing is disabled
+var tracer atomic.Pointer[Tracer]
+
+// TraceStep is a decision taken on an event: a filter was evaluated, a grok pattern
+// matched, a node succeeded, the event was poured in a bucket...
+type TraceStep struct {
+	Time   time.Time `json:"time"`
+	Stage  string    `json:"stage,omitempty"`
+	Kind   string    `json:"kind"`
+	Name   string    `json:"name,omitempty"`
+	Result string    `json:"result"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Trace holds what happened to a line, from acquisition to the buckets.
+type Trace struct {
+	ID        string            `json:"id"`
+	Time      time.Time         `json:"time"`
+	Source    string            `json:"source"`
+	Type      string            `json:"type"` // datasource type
+	Module    string            `json:"module"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Line      string            `json:"line"`
+	Outcome   string            `json:"outcome"`
+	StepCount int               `json:"step_count"` // including the steps that were not kept
+	Steps     []TraceStep       `json:"steps,omitempty"`
+}
+
+// TraceFilter selects the traces to list.
+type TraceFilter struct {
+	Type     string // datasource type
+	Outcome  string
+	Contains string // substring of the line
+	Limit    int
+}
+
+// Tracer keeps the traces of the last events in a ring buffer.
+type Tracer struct {
+	mu         sync.Mutex
+	traces     map[string]*Trace
+	ring       []string // trace IDs, by age
+	next       int
+	maxSteps   int
+	sources    []string
+	sampleRate float64
+}
+
+// NewTracer returns a tracer keeping the last bufferSize traces, with up to maxSteps steps each.
+// The lines of the datasource types in sources (all if empty) are traced, with a probability of
+// sampleRate.
+func NewTracer(bufferSize int, maxSteps int, sources []string, sampleRate float64) *Tracer {
+	return &Tracer{
+		traces:     make(map[string]*Trace, bufferSize),
+		ring:       make([]string, bufferSize),
+		maxSteps:   maxSteps,
+		sources:    sources,
+		sampleRate: sampleRate,
+	}
+}
+
+// SetTracer enables the tracing of the events, or disables it with a nil tracer.
+func SetTracer(t *Tracer) {
+	tracer.Store(t)
+}
+
+// GetTracer returns the tracer of the log processor, nil if tracing is disabled.
+func GetTracer() *Tracer {
+	return tracer.Load()
+}
+
+// StartTrace assigns a trace ID to a line read by a datasource, if tracing is enabled and the
+// line is selected by the datasource filter and the sampling.
+func StartTrace(evt *Event) {
+	t := tracer.Load()
+	if t == nil || evt.Type != LOG || evt.TraceID != "" {
+		return
+	}
+
+	t.start(evt)
+}
+
+// RecordTraceStep adds a step to the trace of an event, if it has one.
+func RecordTraceStep(evt *Event, kind string, name string, result string, detail string) {
+	if evt.TraceID == "" {
+		return
+	}
+
+	t := tracer.Load()
+	if t == nil {
+		return
+	}
+
+	t.record(evt.TraceID, TraceStep{
+		Time:   time.Now().UTC(),
+		Stage:  evt.Stage,
+		Kind:   kind,
+		Name:   name,
+		Result: result,
+		Detail: detail,
+	})
+}
+
+// SetTraceOutcome records what happened to a traced event in the end.
+func SetTraceOutcome(evt *Event, outcome string) {
+	if evt.TraceID == "" {
+		return
+	}
+
+	t := tracer.Load()
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if trace, ok := t.traces[evt.TraceID]; ok {
+		trace.Outcome = outcome
+	}
+}
+
+func (t *Tracer) start(evt *Event) {
+	acquisType := evt.Line.Labels["type"]
+
+	if len(t.sources) > 0 && !slices.Contains(t.sources, acquisType) {
+		return
+	}
+
+	if t.sampleRate < 1 && rand.Float64() >= t.sampleRate {
+		return
+	}
+
+	now := time.Now().UTC()
+
+	trace := &Trace{
+		ID:        fmt.Sprintf("%016x", rand.Uint64()),
+		Time:      now,
+		Source:    evt.Line.Src,
+		Type:      acquisType,
+		Module:    evt.Line.Module,
+		Labels:    maps.Clone(evt.Line.Labels),
+		Line:      evt.Line.Raw,
+		Outcome:   TraceProcessing,
+		StepCount: 1,
+		Steps: []TraceStep{{
+			Time:   now,
+			Kind:   "acquisition",
+			Name:   acquisType,
+			Result: "read",
+			Detail: evt.Line.Src,
+		}},
+	}
+
+	t.mu.Lock()
+
+	if old := t.ring[t.next]; old != "" {
+		delete(t.traces, old)
+	}
+
+	t.ring[t.next] = trace.ID
+	t.next = (t.next + 1) % len(t.ring)
+	t.traces[trace.ID] = trace
+
+	t.mu.Unlock()
+
+	evt.TraceID = trace.ID
+}
+
+func (t *Tracer) record(id string, step TraceStep) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// the trace may have been dropped from the buffer
+	trace, ok := t.traces[id]
+	if !ok {
+		return
+	}
+
+	trace.StepCount++
+
+	if len(trace.Steps) < t.maxSteps {
+		trace.Steps = append(trace.Steps, step)
+	}
+}
+
+// Get returns a copy of a trace.
+func (t *Tracer) Get(id string) (Trace, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	trace, ok := t.traces[id]
+	if !ok {
+		return Trace{}, false
+	}
+
+	ret := *trace
+	ret.Steps = slices.Clone(trace.Steps)
+
+	return ret, true
+}
+
+// List returns the traces selected by the filter, without their steps, the most recent first.
+func (t *Tracer) List(filter TraceFilter) []Trace {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ret := []Trace{}
+
+	for i := range len(t.ring) {
+		id := t.ring[(t.next-1-i+len(t.ring))%len(t.ring)]
+		if id == "" {
+			break
+		}
+
+		trace := t.traces[id]
+
+		if filter.Type != "" && trace.Type != filter.Type {
+			continue
+		}
+
+		if filter.Outcome != "" && trace.Outcome != filter.Outcome {
+			continue
+		}
+
+		if filter.Contains != "" && !strings.Contains(trace.Line, filter.Contains) {
+			continue
+		}
+
+		summary := *trace
+		summary.Steps = nil
+
+		ret = append(ret, summary)
+
+		if filter.Limit > 0 && len(ret) >= filter.Limit {
+			break
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTracer(t *testing.T, bufferSize int, maxSteps int, sources []string) *Tracer {
+	t.Helper()
+
+	tracer := NewTracer(bufferSize, maxSteps, sources, 1)
+	SetTracer(tracer)
+	t.Cleanup(func() { SetTracer(nil) })
+
+	return tracer
+}
+
+func logEvent(acquisType string, raw string) *Event {
+	evt := MakeEvent(false, LOG, true)
+	evt.Line = Line{Raw: raw, Src: "/var/log/test.log", Module: acquisType, Labels: map[string]string{"type": acquisType}}
+
+	return &evt
+}
+
+func TestTrace(t *testing.T) {
+	tracer := newTestTracer(t, 2, 3, []string{"file"})
+
+	// not traced: filtered out, or tracing is not started
+	other := logEvent("journalctl", "other")
+	StartTrace(other)
+	assert.Empty(t, other.TraceID)
+	RecordTraceStep(other, "node", "test", "ok", "")
+
+	evts := []*Event{logEvent("file", "one"), logEvent("file", "two"), logEvent("file", "three")}
+
+	for _, evt := range evts {
+		StartTrace(evt)
+		require.NotEmpty(t, evt.TraceID)
+	}
+
+	// the oldest trace was dropped
+	_, ok := tracer.Get(evts[0].TraceID)
+	assert.False(t, ok)
+	RecordTraceStep(evts[0], "node", "test", "ok", "")
+
+	evts[2].Stage = "s00-raw"
+
+	for _, result := range []string{"false", "true", "match"} {
+		RecordTraceStep(evts[2], "filter", "test", result, "")
+	}
+
+	SetTraceOutcome(evts[2], TraceParsed)
+
+	trace, ok := tracer.Get(evts[2].TraceID)
+	require.True(t, ok)
+	assert.Equal(t, "three", trace.Line)
+	assert.Equal(t, "file", trace.Type)
+	assert.Equal(t, TraceParsed, trace.Outcome)
+	assert.Equal(t, 4, trace.StepCount)
+	require.Len(t, trace.Steps, 3)
+	assert.Equal(t, "acquisition", trace.Steps[0].Kind)
+	assert.Equal(t, "s00-raw", trace.Steps[1].Stage)
+	assert.Equal(t, "true", trace.Steps[2].Result)
+
+	traces := tracer.List(TraceFilter{})
+	require.Len(t, traces, 2)
+	assert.Equal(t, "three", traces[0].Line)
+	assert.Equal(t, "two", traces[1].Line)
+	assert.Nil(t, traces[0].Steps)
+
+	assert.Len(t, tracer.List(TraceFilter{Outcome: TraceParsed}), 1)
+	assert.Len(t, tracer.List(TraceFilter{Contains: "tw"}), 1)
+	assert.Len(t, tracer.List(TraceFilter{Limit: 1}), 1)
+	assert.Empty(t, tracer.List(TraceFilter{Type: "journalctl"}))
+}
+
+func TestTraceDisabled(t *testing.T) {
+	SetTracer(nil)
+
+	evt := logEvent("file", "one")
+	StartTrace(evt)
+	assert.Empty(t, evt.TraceID)
+
+	// an event traced before a reload is ignored
+	evt.TraceID = "0123456789abcdef"
+	RecordTraceStep(evt, "node", "test", "ok", "")
+	SetTraceOutcome(evt, TraceParsed)
+}
@@ -0,0 +1,136 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.21.12
+// source: decisions.proto
+
+package protobufs
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Decisions_Stream_FullMethodName = "/crowdsec.decisions.v1.Decisions/Stream"
+)
+
+// DecisionsClient is the client API for Decisions service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Decisions is the gRPC variant of /v1/decisions/stream. The bouncers authenticate with their API
+// key in the x-api-key metadata.
+type DecisionsClient interface {
+	// Stream sends the active decisions if asked for, then the changes as they happen, until the
+	// bouncer disconnects. The changes made while the stream is opened can be sent twice. A bouncer
+	// that reconnects must ask for the startup decisions again.
+	Stream(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DecisionChanges], error)
+}
+
+type decisionsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDecisionsClient(cc grpc.ClientConnInterface) DecisionsClient {
+	return &decisionsClient{cc}
+}
+
+func (c *decisionsClient) Stream(ctx context.Context, in *StreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DecisionChanges], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Decisions_ServiceDesc.Streams[0], Decisions_Stream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamRequest, DecisionChanges]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Decisions_StreamClient = grpc.ServerStreamingClient[DecisionChanges]
+
+// DecisionsServer is the server API for Decisions service.
+// All implementations must embed UnimplementedDecisionsServer
+// for forward compatibility.
+//
+// Decisions is the gRPC variant of /v1/decisions/stream. The bouncers authenticate with their API
+// key in the x-api-key metadata.
+type DecisionsServer interface {
+	// Stream sends the active decisions if asked for, then the changes as they happen, until the
+	// bouncer disconnects. The changes made while the stream is opened can be sent twice. A bouncer
+	// that reconnects must ask for the startup decisions again.
+	Stream(*StreamRequest, grpc.ServerStreamingServer[DecisionChanges]) error
+	mustEmbedUnimplementedDecisionsServer()
+}
+
+// UnimplementedDecisionsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDecisionsServer struct{}
+
+func (UnimplementedDecisionsServer) Stream(*StreamRequest, grpc.ServerStreamingServer[DecisionChanges]) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedDecisionsServer) mustEmbedUnimplementedDecisionsServer() {}
+func (UnimplementedDecisionsServer) testEmbeddedByValue()                   {}
+
+// UnsafeDecisionsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DecisionsServer will
+// result in compilation errors.
+type UnsafeDecisionsServer interface {
+	mustEmbedUnimplementedDecisionsServer()
+}
+
+func RegisterDecisionsServer(s grpc.ServiceRegistrar, srv DecisionsServer) {
+	// If the following call pancis, it indicates UnimplementedDecisionsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Decisions_ServiceDesc, srv)
+}
+
+func _Decisions_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DecisionsServer).Stream(m, &grpc.GenericServerStream[StreamRequest, DecisionChanges]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Decisions_StreamServer = grpc.ServerStreamingServer[DecisionChanges]
+
+// Decisions_ServiceDesc is the grpc.ServiceDesc for Decisions service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Decisions_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "crowdsec.decisions.v1.Decisions",
+	HandlerType: (*DecisionsServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _Decisions_Stream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "decisions.proto",
+}
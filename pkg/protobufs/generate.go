@@ -12,3 +12,4 @@ package protobufs
 // go install google.golang.org/grpc/cmd/protoc-gen-go-grpc@v1.5.1
 
 //go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative notifier.proto
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative decisions.proto
@@ -9,6 +9,8 @@ const (
 	CAPIAlertKind         AlertKind = "capi"          // Alert created from a CAPI pull
 	PAPIAlertKind         AlertKind = "papi"          // Alert created from a PAPI order
 	CscliAlertKind        AlertKind = "cscli"         // Alert created from a cscli command
+	LAPIAlertKind         AlertKind = "lapi"          // Alert created by the local API itself (i.e. stale machines)
+	AgentAlertKind        AlertKind = "agent"         // Alert created by the log processor itself (i.e. parsing error budget)
 )
 
 func (k AlertKind) String() string {
@@ -23,5 +25,7 @@ func GetAlertKinds() []string {
 		CAPIAlertKind.String(),
 		PAPIAlertKind.String(),
 		CscliAlertKind.String(),
+		LAPIAlertKind.String(),
+		AgentAlertKind.String(),
 	}
 }
@@ -15,6 +15,7 @@ const (
 	CAPIOrigin                        = "CAPI"
 	CommunityBlocklistPullSourceScope = "crowdsecurity/community-blocklist"
 	RemediationSyncOrigin             = "remediation_sync"
+	LAPIOrigin                        = "lapi" // decisions taken by the local API itself
 )
 
 const DecisionTypeBan = "ban"
@@ -28,5 +29,6 @@ func GetOrigins() []string {
 		ListOrigin,
 		CAPIOrigin,
 		RemediationSyncOrigin,
+		LAPIOrigin,
 	}
 }
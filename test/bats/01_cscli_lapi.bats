@@ -216,3 +216,48 @@ teardown() {
     rune -1 cscli machines inspect outofrange -o json
     assert_stderr --partial "unable to read machine data 'outofrange': user 'outofrange': user doesn't exist"
 }
+
+@test "cscli lapi register --token (one-time token)" {
+    rune -0 ./instance-crowdsec start
+
+    rune -0 cscli machines token create --ttl 10m --description "for tests" -o raw
+    token="$output"
+
+    rune -0 cscli lapi register --machine onetime --token "$token"
+    assert_stderr --partial "Successfully registered to Local API"
+    rune -0 cscli machines inspect onetime -o json
+    rune -0 jq -r '.isValidated' <(output)
+    assert_output "true"
+
+    # a token can be used only once
+    rune -1 cscli lapi register --machine onetime2 --token "$token"
+    assert_stderr --partial "401 Unauthorized: API error: registration token expired or already used"
+
+    rune -0 cscli machines token list -o json
+    rune -0 jq -c '.[] | [.description, .used_by]' <(output)
+    assert_output '["for tests","onetime"]'
+
+    rune -0 cscli machines token list -o raw
+    assert_line --regexp '^1,for tests,.*,used,onetime$'
+}
+
+@test "cscli machines token create/delete" {
+    rune -1 cscli machines token create --ttl 0s
+    assert_stderr --partial "the token duration must be positive"
+
+    rune -0 cscli machines token create -o json
+    rune -0 jq -r '.token | length' <(output)
+    assert_output 43
+
+    rune -0 cscli machines token list -o raw
+    assert_line --regexp '^1,,.*,valid,$'
+
+    rune -0 cscli machines token delete 1
+    assert_stderr --partial "registration token 1 deleted successfully"
+
+    rune -1 cscli machines token delete 1
+    assert_stderr --partial "unable to delete token: registration token 1: object not found"
+
+    rune -1 cscli machines token delete foo
+    assert_stderr --partial "invalid token id 'foo'"
+}
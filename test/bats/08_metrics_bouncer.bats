@@ -383,6 +383,71 @@ teardown() {
 	EOT
 }
 
+@test "rc usage metrics (per scenario)" {
+    # bouncers can detail the remediation counters by scenario, they are shown below their origin
+
+    API_KEY=$(cscli bouncers add testbouncer -o raw)
+    export API_KEY
+
+    payload=$(yq -o j <<-EOT
+	remediation_components:
+	  - version: "v1.0"
+	    utc_startup_timestamp: 1707369316
+	log_processors: []
+	EOT
+    )
+
+    payload=$(yq -o j '
+        .remediation_components[0].metrics = [
+        {
+          "meta": {"utc_now_timestamp": 1707460000, "window_size_seconds":600},
+          "items":[
+            {"name": "active_decisions", "unit": "ip",      "value": 20,  "labels": {"ip_type": "ipv4", "origin": "crowdsec", "scenario": "crowdsecurity/ssh-bf"}},
+            {"name": "active_decisions", "unit": "ip",      "value": 5,   "labels": {"ip_type": "ipv6", "origin": "crowdsec", "scenario": "crowdsecurity/ssh-bf"}},
+            {"name": "active_decisions", "unit": "ip",      "value": 10,  "labels": {"ip_type": "ipv4", "origin": "crowdsec", "scenario": "crowdsecurity/http-probing"}},
+            {"name": "dropped",          "unit": "request", "value": 300, "labels": {"ip_type": "ipv4", "origin": "crowdsec", "scenario": "crowdsecurity/ssh-bf"}},
+            {"name": "dropped",          "unit": "request", "value": 120, "labels": {"ip_type": "ipv4", "origin": "crowdsec", "scenario": "crowdsecurity/http-probing"}},
+            {"name": "dropped",          "unit": "request", "value": 40,  "labels": {"ip_type": "ipv4", "origin": "cscli"}},
+            {"name": "dropped",          "unit": "request", "value": 60,  "labels": {"ip_type": "ipv4", "origin": "CAPI", "scenario": "crowdsecurity/ssh-bf"}}
+          ]
+        }, {
+          "meta": {"utc_now_timestamp": 1707450000, "window_size_seconds":600},
+          "items":[
+            {"name": "active_decisions", "unit": "ip",      "value": 99,  "labels": {"ip_type": "ipv4", "origin": "crowdsec", "scenario": "crowdsecurity/ssh-bf"}},
+            {"name": "dropped",          "unit": "request", "value": 100, "labels": {"ip_type": "ipv4", "origin": "crowdsec", "scenario": "crowdsecurity/ssh-bf"}}
+          ]
+        }
+        ] |
+        .remediation_components[0].type = "crowdsec-firewall-bouncer"
+    ' <<<"$payload")
+
+    rune -0 curl-with-key '/v1/usage-metrics' -X POST --data "$payload"
+
+    # the json output is not detailed by scenario
+    rune -0 cscli metrics show bouncers -o json
+    assert_json '{bouncers: {testbouncer: {CAPI: {dropped: {request: 60}}, crowdsec: {active_decisions: {ip: 35}, dropped: {request: 520}}, cscli: {dropped: {request: 40}}}}}'
+
+    rune -0 cscli metrics show bouncers
+    assert_output - <<-EOT
+	+------------------------------------------------------------+
+	| Bouncer Metrics (testbouncer) since 2024-02-09 03:40:00 +0 |
+	| 000 UTC                                                    |
+	+------------------------------+------------------+----------+
+	| Origin                       | active_decisions |  dropped |
+	|                              |        IPs       | requests |
+	+------------------------------+------------------+----------+
+	| CAPI (community blocklist)   |                - |       60 |
+	|   crowdsecurity/ssh-bf       |                - |       60 |
+	| crowdsec (security engine)   |               35 |      520 |
+	|   crowdsecurity/http-probing |               10 |      120 |
+	|   crowdsecurity/ssh-bf       |               25 |      400 |
+	| cscli (manual decisions)     |                - |       40 |
+	+------------------------------+------------------+----------+
+	|                        Total |               35 |      620 |
+	+------------------------------+------------------+----------+
+	EOT
+}
+
 @test "rc usage metrics (ipv4/ipv6)" {
     # gauge metrics are not aggregated over time, but they are over ip type
 
@@ -0,0 +1,80 @@
+#!/usr/bin/env bats
+
+set -u
+
+# not enough to overflow ssh-bf
+fake_log() {
+    for _ in $(seq 1 3); do
+        echo "$(LC_ALL=C date '+%b %d %H:%M:%S ')"'sd-126005 sshd[12422]: Invalid user netflix from 1.1.1.172 port 35424'
+    done
+}
+
+setup_file() {
+    load "../lib/setup_file.sh"
+}
+
+teardown_file() {
+    load "../lib/teardown_file.sh"
+}
+
+setup() {
+    load "../lib/setup.sh"
+    ./instance-data load
+}
+
+teardown() {
+    ./instance-crowdsec stop
+}
+
+#----------
+
+@test "cscli buckets list (crowdsec not running)" {
+    rune -1 cscli buckets list
+    assert_stderr --partial 'fetching buckets: Get \"http://127.0.0.1:6060/debug/buckets\": dial tcp 127.0.0.1:6060: connect: connection refused'
+}
+
+@test "cscli buckets list (.prometheus.enabled=false)" {
+    config_set '.prometheus.enabled=false'
+    rune -1 cscli buckets list
+    assert_stderr --partial "prometheus is not enabled, can't inspect buckets"
+}
+
+@test "cscli buckets list (invalid filters)" {
+    rune -1 cscli buckets list --min-fill 2
+    assert_stderr --partial "--min-fill must be between 0 and 1"
+
+    rune -0 ./instance-crowdsec start
+    rune -1 cscli buckets list --scenario '['
+    assert_stderr --partial 'invalid scenario pattern'
+}
+
+@test "cscli buckets list" {
+    rune -0 ./instance-crowdsec start
+    rune -0 cscli buckets list
+    assert_output "No live bucket."
+
+    rune -0 cscli buckets list -o json
+    assert_json '[]'
+
+    rune -0 cscli buckets list -o raw
+    assert_output "scenario,type,groupby,mapkey,capacity,fill,fill_ratio,events,first_event,last_event,age_seconds,ttl_seconds"
+}
+
+@test "cscli buckets list (live buckets)" {
+    tmpfile=$(TMPDIR="$BATS_TEST_TMPDIR" mktemp)
+    touch "$tmpfile"
+    ACQUIS_YAML=$(config_get '.crowdsec_service.acquisition_path')
+    echo -e "---\nfilename: ${tmpfile}\nlabels:\n  type: syslog\n" >>"$ACQUIS_YAML"
+
+    rune -0 ./instance-crowdsec start
+    sleep 1
+    fake_log >>"${tmpfile}"
+    sleep 2
+
+    rune -0 cscli buckets list -o json --scenario 'crowdsecurity/ssh-*' --groupby 1.1.1.172
+    rune -0 jq -r '.[].scenario' <(output)
+    assert_line 'crowdsecurity/ssh-bf'
+
+    rune -0 cscli buckets list --min-fill 1
+    assert_output "No live bucket."
+}
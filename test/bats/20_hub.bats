@@ -186,6 +186,35 @@ teardown() {
 	EOT
 }
 
+@test "cscli hub upgrade --plan, cscli hub rollback" {
+    rune -1 cscli hub rollback
+    assert_stderr --partial "no previous state of the hub to roll back to"
+
+    rune -0 cscli parsers install crowdsecurity/syslog-logs
+    rune -0 jq -r '.local_path' <(cscli parsers inspect crowdsecurity/syslog-logs --no-metrics -o json)
+    echo "# tainted" >>"$output"
+
+    rune -0 cscli hub upgrade --plan --force
+    assert_output --partial "Upgrade plan:"
+    assert_output --partial "crowdsecurity/syslog-logs"
+    assert_output --partial "No action taken."
+    rune -0 cscli parsers inspect crowdsecurity/syslog-logs --no-metrics -o json
+    rune -0 jq -r '.tainted' <(output)
+    assert_output "true"
+
+    rune -0 cscli hub upgrade --force
+    assert_output --partial "run 'cscli hub rollback' to restore it"
+    rune -0 cscli parsers inspect crowdsecurity/syslog-logs --no-metrics -o json
+    rune -0 jq -r '.tainted' <(output)
+    assert_output "false"
+
+    rune -0 cscli hub rollback
+    assert_output --partial "The hub was restored to its state before the last upgrade."
+    rune -0 cscli parsers inspect crowdsecurity/syslog-logs --no-metrics -o json
+    rune -0 jq -r '.tainted' <(output)
+    assert_output "true"
+}
+
 @test "cscli hub types" {
     rune -0 cscli hub types -o raw
     assert_line "parsers"
@@ -218,6 +218,25 @@ teardown() {
     assert_stderr --partial '200 alert(s) deleted'
 }
 
+@test "cscli alerts export" {
+    rune -1 cscli alerts export
+    assert_stderr 'Error: cscli alerts export: required flag(s) "format" not set'
+
+    rune -1 cscli alerts export --format xml
+    assert_stderr "Error: cscli alerts export: invalid format 'xml', expected one of cef, ecs"
+
+    rune -0 cscli decisions add -i 10.20.30.40 -d 1h -R "test export"
+
+    rune -0 cscli alerts export --format ecs
+    rune -0 jq -c '[.event.kind, .event.action, .source.ip, .crowdsec.decisions[0].type]' <(output)
+    assert_output '["alert","ban","10.20.30.40","ban"]'
+
+    rune -0 cscli alerts export --format cef -o "${BATS_TEST_TMPDIR}/alerts.cef"
+    assert_stderr --partial "1 alert(s) exported to ${BATS_TEST_TMPDIR}/alerts.cef"
+    rune -0 cat "${BATS_TEST_TMPDIR}/alerts.cef"
+    assert_output --regexp '^CEF:0\|CrowdSec\|CrowdSec\|.*\|test export\|.*\|7\|.* src=10.20.30.40 .* act=ban '
+}
+
 @test "bad duration" {
     skip 'TODO'
     rune -0 cscli decisions add -i 10.20.30.40 -t ban
@@ -207,3 +207,35 @@ teardown() {
     assert_stderr --partial 'Processing chunk of 1 decisions'
     assert_output --partial 'Imported 3 decisions'
 }
+
+@test "cscli decisions export" {
+    rune -1 cscli decisions export
+    assert_stderr 'Error: cscli decisions export: required flag(s) "format" not set'
+
+    rune -1 cscli decisions export --format xml
+    assert_stderr "Error: cscli decisions export: invalid format 'xml', expected one of ipset, nftables, nginx, waf-json"
+
+    rune -1 cscli decisions export --format nginx --name 'crowd-sec'
+    assert_stderr "Error: cscli decisions export: invalid name 'crowd-sec': only letters, digits and underscores are allowed"
+
+    rune -0 cscli decisions add -i 10.20.30.40 -d 1h
+    rune -0 cscli decisions add -r 10.30.0.0/16 -d 2h
+    rune -0 cscli decisions add --scope username --value admin -d 1h
+    rune -0 cscli decisions add -i 10.20.30.41 -t captcha
+
+    rune -0 cscli decisions export --format nginx
+    assert_line --index 0 'geo $crowdsec_banned {'
+    assert_line --index 2 --regexp '^\s+10.20.30.40 1;$'
+    assert_line --index 3 --regexp '^\s+10.30.0.0/16 1;$'
+    assert_line --index 4 '}'
+    assert_stderr --partial "1 decision(s) skipped: only the ip and range scopes can be exported"
+
+    rune -0 cscli decisions export --format ipset --type captcha -o "${BATS_TEST_TMPDIR}/crowdsec.ipset"
+    assert_stderr --partial "1 ip(s) and range(s) exported to ${BATS_TEST_TMPDIR}/crowdsec.ipset"
+    rune -0 grep -c '^add crowdsec-blacklists 10.20.30.41 timeout ' "${BATS_TEST_TMPDIR}/crowdsec.ipset"
+    assert_output 1
+
+    rune -0 cscli decisions export --format waf-json
+    rune -0 jq -c '.' <(output)
+    assert_output '{"ipv4":["10.20.30.40/32","10.30.0.0/16"],"ipv6":[]}'
+}
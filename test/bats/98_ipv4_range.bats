@@ -124,3 +124,27 @@ setup() {
     rune -0 curl-with-key '/v1/decisions?range=4.4.3.2/28'
     assert_output 'null'
 }
+
+# check overlapping decisions
+
+@test "CLI - decisions for ip 4.4.4.3, with an ip and a larger range" {
+    rune -0 cscli decisions add -i '4.4.4.3'
+    rune -0 cscli decisions add -r '4.4.0.0/16'
+    rune -0 cscli decisions list -i '4.4.4.3' -o json
+    rune -0 jq -c '[.[].decisions[].value] | sort' <(output)
+    assert_json '["4.4.0.0/16","4.4.4.0/24","4.4.4.3"]'
+
+    rune -0 cscli decisions list -i '4.4.4.5' -o json
+    rune -0 jq -c '[.[].decisions[].value] | sort' <(output)
+    assert_json '["4.4.0.0/16","4.4.4.0/24"]'
+
+    rune -0 cscli decisions list -r '4.4.4.0/24' -o json --contained
+    rune -0 jq -c '[.[].decisions[].value] | sort' <(output)
+    assert_json '["4.4.4.0/24","4.4.4.3"]'
+}
+
+@test "API - decisions for ip 4.4.4.3, with an ip and a larger range" {
+    rune -0 curl-with-key '/v1/decisions?ip=4.4.4.3'
+    rune -0 jq -c '[.[].value] | sort' <(output)
+    assert_json '["4.4.0.0/16","4.4.4.0/24","4.4.4.3"]'
+}
@@ -0,0 +1,99 @@
+#!/usr/bin/env bats
+
+set -u
+
+setup_file() {
+    load "../lib/setup_file.sh"
+}
+
+teardown_file() {
+    load "../lib/teardown_file.sh"
+}
+
+setup() {
+    load "../lib/setup.sh"
+    ./instance-data load
+}
+
+#----------
+
+@test "cscli alias <unknown command>" {
+    rune -1 cscli alias foobar
+    assert_output --partial "Usage:"
+    assert_stderr --partial 'unknown command "foobar" for "cscli alias"'
+}
+
+@test "cscli alias list (empty)" {
+    rune -0 cscli alias list
+    assert_output "No alias defined."
+
+    rune -0 cscli alias list -o json
+    assert_json '{}'
+}
+
+@test "cscli alias add" {
+    rune -0 cscli alias add banlist 'decisions list -o json --origin cscli'
+    assert_stderr --partial "alias 'banlist' added"
+
+    CONFIG_DIR=$(dirname "$CONFIG_YAML")
+    rune -0 yq -o json "$CONFIG_DIR/cscli_aliases.yaml"
+    assert_json '{banlist: "decisions list -o json --origin cscli"}'
+
+    rune -1 cscli alias add banlist 'decisions list'
+    assert_stderr --partial 'alias "banlist" already exists, use --force to replace it'
+
+    rune -0 cscli alias add banlist 'decisions list -o json' --force
+
+    rune -1 cscli alias add decisions 'alerts list'
+    assert_stderr --partial '"decisions" is a cscli command, it can'"'"'t be used as an alias'
+
+    rune -1 cscli alias add foo 'frobnicate --all'
+    assert_stderr --partial 'invalid command "frobnicate --all": unknown command "frobnicate"'
+
+    rune -1 cscli alias add 'ban list' 'decisions list'
+    assert_stderr --partial 'invalid alias name "ban list"'
+}
+
+@test "aliases are expanded" {
+    rune -0 cscli alias add banlist 'decisions list -o json --origin cscli'
+
+    rune -0 cscli decisions add -i 1.2.3.4
+    rune -0 cscli banlist
+    rune -0 jq -r '.[].decisions[0].value' <(output)
+    assert_output '1.2.3.4'
+
+    # extra arguments are appended
+    rune -0 cscli banlist --ip 1.2.3.5
+    assert_json '[]'
+
+    rune -1 cscli banlist --foo
+    assert_stderr 'Error: cscli decisions list: unknown flag: --foo'
+}
+
+@test "aliases are completed" {
+    rune -0 cscli alias add banlist 'decisions list -o json --origin cscli'
+
+    rune -0 cscli __complete ban
+    assert_line "$(printf 'banlist\tdecisions list -o json --origin cscli')"
+
+    rune -0 cscli __complete banlist --sc
+    assert_line --partial '--scenario'
+}
+
+@test "cscli alias delete" {
+    rune -0 cscli alias add banlist 'decisions list -o json --origin cscli'
+    rune -0 cscli alias list -o raw
+    assert_output - <<-EOT
+	name,command
+	banlist,decisions list -o json --origin cscli
+	EOT
+
+    rune -1 cscli alias delete banlist nope
+    assert_stderr --partial 'alias "nope" not found'
+
+    rune -0 cscli alias delete banlist nope --ignore-missing
+    assert_stderr --partial "alias 'banlist' deleted"
+
+    rune -0 cscli alias list -o json
+    assert_json '{}'
+}
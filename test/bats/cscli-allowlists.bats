@@ -65,6 +65,20 @@ teardown() {
     rune -1 wait-for --err 'error while performing request' "$CSCLI" allowlists list
 }
 
+@test "cscli allowlists list --origin" {
+    rune -1 cscli allowlists list --origin capi
+    assert_stderr 'Error: cscli allowlists list: invalid origin "capi", must be one of: console, local'
+
+    rune -0 cscli allowlist create foo -d 'a foo'
+
+    rune -0 cscli allowlists list --origin local -o json
+    rune -0 jq -r '.[].name' <(output)
+    assert_output 'foo'
+
+    rune -0 cscli allowlists list --origin console -o json
+    assert_json '[]'
+}
+
 @test "cscli allowlists create" {
     rune -1 cscli allowlist create
     assert_stderr 'Error: cscli allowlists create: accepts 1 arg(s), received 0'
@@ -0,0 +1,66 @@
+#!/usr/bin/env bats
+
+set -u
+
+setup_file() {
+    load "../lib/setup_file.sh"
+}
+
+teardown_file() {
+    load "../lib/teardown_file.sh"
+}
+
+setup() {
+    load "../lib/setup.sh"
+    ./instance-data load
+    CONFIG_DIR=$(config_get '.config_paths.config_dir')
+    export CONFIG_DIR
+}
+
+teardown() {
+    ./instance-crowdsec stop
+}
+
+#----------
+
+@test "cscli patterns <unknown command>" {
+    rune -1 cscli patterns foobar
+    assert_output --partial "Usage:"
+    assert_stderr --partial 'unknown command "foobar" for "cscli patterns"'
+}
+
+@test "cscli patterns list" {
+    rune -0 cscli patterns list -o json
+    rune -0 jq -c '.[] | select(.name=="SSHD_INVALID_USER") | [.origin, .overrides]' <(output)
+    assert_output "[\"$CONFIG_DIR/patterns/ssh\",null]"
+
+    rune -0 jq -c '.[] | select(.name=="WORD") | .origin' <(output)
+    assert_output '"builtin"'
+}
+
+@test "patterns can be overridden from pattern_override_dir" {
+    rune -0 mkdir -p "$CONFIG_DIR/patterns.local"
+    echo 'USERNAME [a-zA-Z0-9._@-]+' > "$CONFIG_DIR/patterns.local/fixes"
+
+    rune -0 cscli patterns list --origin
+    assert_line --regexp "^ USERNAME +$CONFIG_DIR/patterns.local/fixes +builtin +$"
+
+    rune -0 cscli patterns list -o raw
+    assert_line "USERNAME,$CONFIG_DIR/patterns.local/fixes,builtin,[a-zA-Z0-9._@-]+"
+
+    # the directory can be moved
+    rune -0 mv "$CONFIG_DIR/patterns.local" "$CONFIG_DIR/mypatterns"
+    config_set ".config_paths.pattern_override_dir=\"$CONFIG_DIR/mypatterns\""
+    rune -0 cscli patterns list -o raw
+    assert_line "USERNAME,$CONFIG_DIR/mypatterns/fixes,builtin,[a-zA-Z0-9._@-]+"
+
+    # crowdsec uses the same patterns
+    rune -0 ./instance-crowdsec start
+}
+
+@test "a pattern can't be redefined in pattern_dir" {
+    echo 'USERNAME [a-z]+' > "$CONFIG_DIR/patterns/zzz"
+    rune -1 cscli patterns list
+    assert_stderr --partial "unable to load patterns: pattern USERNAME: the pattern already exist (defined in builtin)"
+    rune -0 rm "$CONFIG_DIR/patterns/zzz"
+}